@@ -0,0 +1,577 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/tool"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+	"helpdesk/internal/policy"
+)
+
+// defaultBackupMaxAgeHours is the backup age above which get_backup_status
+// reports the backup stale when neither the tool call nor the database's
+// backup entry sets one: a daily backup plus two hours of slack.
+const defaultBackupMaxAgeHours = 26
+
+// archiveLagWarnSegments is the number of completed WAL segments waiting to be
+// archived above which get_backup_status reports archiving as falling behind.
+const archiveLagWarnSegments = 32
+
+// archiverQuery reads WAL archiving state. On a standby pg_current_wal_lsn is
+// unavailable, so the current segment is left empty and no lag is computed.
+const archiverQuery = `SELECT current_setting('archive_mode') AS archive_mode,
+  archived_count,
+  failed_count,
+  COALESCE(last_archived_wal, '') AS last_archived_wal,
+  COALESCE(to_char(last_archived_time AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), '') AS last_archived_time,
+  COALESCE(last_failed_wal, '') AS last_failed_wal,
+  COALESCE(to_char(last_failed_time AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), '') AS last_failed_time,
+  pg_is_in_recovery() AS in_recovery,
+  CASE WHEN pg_is_in_recovery() THEN '' ELSE pg_walfile_name(pg_current_wal_lsn()) END AS current_wal,
+  pg_size_bytes(current_setting('wal_segment_size')) AS wal_segment_size
+FROM pg_stat_archiver;`
+
+// archiverStatus is one row of archiverQuery.
+type archiverStatus struct {
+	Mode            string
+	ArchivedCount   int64
+	FailedCount     int64
+	LastArchivedWAL string
+	LastArchived    time.Time
+	LastFailedWAL   string
+	LastFailed      time.Time
+	InRecovery      bool
+	CurrentWAL      string
+	SegmentSize     int64
+}
+
+func parseArchiverStatus(output string) (archiverStatus, error) {
+	records := parsePsqlRecords(output)
+	if len(records) == 0 || len(records[0]) == 0 {
+		return archiverStatus{}, fmt.Errorf("pg_stat_archiver returned no rows")
+	}
+	r := records[0][0]
+	s := archiverStatus{
+		Mode:            r["archive_mode"],
+		LastArchivedWAL: r["last_archived_wal"],
+		LastFailedWAL:   r["last_failed_wal"],
+		InRecovery:      r["in_recovery"] == "t",
+		CurrentWAL:      r["current_wal"],
+	}
+	s.ArchivedCount, _ = strconv.ParseInt(r["archived_count"], 10, 64)
+	s.FailedCount, _ = strconv.ParseInt(r["failed_count"], 10, 64)
+	s.SegmentSize, _ = strconv.ParseInt(r["wal_segment_size"], 10, 64)
+	s.LastArchived, _ = time.Parse(time.RFC3339, r["last_archived_time"])
+	s.LastFailed, _ = time.Parse(time.RFC3339, r["last_failed_time"])
+	return s, nil
+}
+
+// walSegmentNumber returns the position of a WAL segment file in the WAL
+// stream, or false for other archived files (.history, .partial, .backup).
+func walSegmentNumber(name string, segmentSize int64) (uint64, bool) {
+	if len(name) != 24 || segmentSize <= 0 {
+		return 0, false
+	}
+	log, err1 := strconv.ParseUint(name[8:16], 16, 32)
+	seg, err2 := strconv.ParseUint(name[16:24], 16, 32)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return log*(0x100000000/uint64(segmentSize)) + seg, true
+}
+
+// pendingSegments returns the number of completed WAL segments not yet
+// archived, or -1 when it cannot be told (standby, nothing archived yet).
+func (s archiverStatus) pendingSegments() int64 {
+	cur, ok1 := walSegmentNumber(s.CurrentWAL, s.SegmentSize)
+	last, ok2 := walSegmentNumber(s.LastArchivedWAL, s.SegmentSize)
+	if !ok1 || !ok2 || cur <= last {
+		if ok1 && ok2 {
+			return 0
+		}
+		return -1
+	}
+	// The current segment is still being written; it is not due yet.
+	return int64(cur - last - 1)
+}
+
+// backupEntry is one backup in a repository catalog.
+type backupEntry struct {
+	Label    string
+	Type     string
+	Finished time.Time
+	Failed   bool
+}
+
+// backupCatalog is what a backup tool reports about a repository.
+type backupCatalog struct {
+	Status  string // repository status as reported by the tool; "" when it reports none
+	Backups []backupEntry
+}
+
+// lastSuccessful returns the most recently finished backup that did not fail.
+func (c backupCatalog) lastSuccessful() (backupEntry, bool) {
+	var best backupEntry
+	found := false
+	for _, b := range c.Backups {
+		if b.Failed || b.Finished.IsZero() {
+			continue
+		}
+		if !found || b.Finished.After(best.Finished) {
+			best, found = b, true
+		}
+	}
+	return best, found
+}
+
+// pgBackRestInfo is the part of "pgbackrest info --output=json" the tools use:
+// one element per stanza.
+type pgBackRestInfo []struct {
+	Name   string `json:"name"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+	Backup []struct {
+		Label     string `json:"label"`
+		Type      string `json:"type"`
+		Error     bool   `json:"error"`
+		Timestamp struct {
+			Start int64 `json:"start"`
+			Stop  int64 `json:"stop"`
+		} `json:"timestamp"`
+	} `json:"backup"`
+}
+
+func parsePgBackRestInfo(output, stanza string) (backupCatalog, error) {
+	var info pgBackRestInfo
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return backupCatalog{}, fmt.Errorf("pgbackrest info: invalid JSON output: %w", err)
+	}
+	for _, st := range info {
+		if st.Name != stanza {
+			continue
+		}
+		c := backupCatalog{Status: st.Status.Message}
+		for _, b := range st.Backup {
+			e := backupEntry{Label: b.Label, Type: b.Type, Failed: b.Error}
+			if b.Timestamp.Stop > 0 {
+				e.Finished = time.Unix(b.Timestamp.Stop, 0).UTC()
+			}
+			c.Backups = append(c.Backups, e)
+		}
+		return c, nil
+	}
+	return backupCatalog{}, fmt.Errorf("pgbackrest info: stanza %q not found in the repository", stanza)
+}
+
+// walgBackup is one element of "wal-g backup-list --json --detail".
+type walgBackup struct {
+	BackupName string `json:"backup_name"`
+	Time       string `json:"time"`
+	FinishTime string `json:"finish_time"`
+	IsFull     *bool  `json:"is_full,omitempty"`
+}
+
+func parseWALGBackupList(output string) (backupCatalog, error) {
+	// wal-g prints "No backups found" instead of an empty JSON list.
+	if strings.Contains(output, "No backups found") {
+		return backupCatalog{}, nil
+	}
+	var list []walgBackup
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return backupCatalog{}, fmt.Errorf("wal-g backup-list: invalid JSON output: %w", err)
+	}
+	var c backupCatalog
+	for _, b := range list {
+		e := backupEntry{Label: b.BackupName}
+		if b.IsFull != nil {
+			e.Type = "delta"
+			if *b.IsFull {
+				e.Type = "full"
+			}
+		}
+		finished := b.FinishTime
+		if finished == "" {
+			finished = b.Time
+		}
+		e.Finished, _ = time.Parse(time.RFC3339, finished)
+		// wal-g lists only completed backups.
+		c.Backups = append(c.Backups, e)
+	}
+	return c, nil
+}
+
+// backupCommand returns the argv of a backup tool's catalog listing
+// (verify=false) or repository check (verify=true).
+func backupCommand(repo *infra.BackupRepo, verify bool) []string {
+	switch repo.Tool {
+	case infra.BackupToolPgBackRest:
+		args := []string{"--stanza=" + repo.Stanza}
+		if repo.ConfigFile != "" {
+			args = append(args, "--config="+repo.ConfigFile)
+		}
+		if verify {
+			return append(args, "verify")
+		}
+		return append(args, "--output=json", "info")
+	default: // wal-g
+		var args []string
+		if verify {
+			args = []string{"wal-verify", "integrity"}
+		} else {
+			args = []string{"backup-list", "--json", "--detail"}
+		}
+		if repo.ConfigFile != "" {
+			args = append(args, "--config", repo.ConfigFile)
+		}
+		return args
+	}
+}
+
+// backupTarget is a database resolved for the backup tools.
+type backupTarget struct {
+	db   databaseInfo
+	repo *infra.BackupRepo // nil when the database has no backup entry
+}
+
+// resolveBackup resolves a database and its backup repository, if any.
+func resolveBackup(connStrOrName string) (backupTarget, error) {
+	dbInfo, err := resolveDatabaseInfo(connStrOrName)
+	if err != nil {
+		return backupTarget{}, err
+	}
+	t := backupTarget{db: dbInfo}
+	infraConfigMu.RLock()
+	ic := infraConfig
+	infraConfigMu.RUnlock()
+	if ic != nil && dbInfo.IsFromInfraConfig {
+		if server, ok := ic.DBServers[dbInfo.Name]; ok {
+			t.repo = server.Backup
+		}
+	}
+	return t, nil
+}
+
+// checkPolicy runs the pre-execution policy check of a backup tool command.
+func (t backupTarget) checkPolicy(ctx context.Context, toolName string) error {
+	if policyEnforcer == nil {
+		return nil
+	}
+	policyCtx := agentutil.WithToolName(ctx, toolName)
+	if err := policyEnforcer.CheckDatabase(policyCtx, t.db.Name, policy.ActionRead, t.db.Tags, "", t.db.Sensitivity); err != nil {
+		return fmt.Errorf("policy denied: %w", err)
+	}
+	return nil
+}
+
+// run executes the backup tool with args through the sandbox and records the
+// tool_execution audit event.
+func (t backupTarget) run(ctx context.Context, toolName string, args []string) (string, error) {
+	start := time.Now()
+	output, err := cmdRunner.Run(agentutil.WithToolName(ctx, toolName), t.repo.Tool, args, nil)
+	if toolAuditor != nil {
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
+			Name:       toolName,
+			Parameters: map[string]any{"connection_string": t.db.Name},
+			RawCommand: t.repo.Tool + " " + strings.Join(args, " "),
+			Argv:       append([]string{t.repo.Tool}, args...),
+		}, audit.ToolResult{
+			Output: truncateForAudit(output, 500),
+			Error:  errMsg,
+		}, time.Since(start))
+	}
+	if err != nil {
+		return output, fmt.Errorf("%s %s failed: %w\n%s", t.repo.Tool, strings.Join(args, " "), err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// maxAge returns the stale threshold: the tool argument, the database's
+// backup entry, or the default.
+func (t backupTarget) maxAge(override int) time.Duration {
+	hours := defaultBackupMaxAgeHours
+	if t.repo != nil && t.repo.MaxAgeHours > 0 {
+		hours = t.repo.MaxAgeHours
+	}
+	if override > 0 {
+		hours = override
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// GetBackupStatusArgs defines arguments for the get_backup_status tool.
+type GetBackupStatusArgs struct {
+	ConnectionString string `json:"connection_string" jsonschema:"required,PostgreSQL connection string or Server ID from infrastructure config."`
+	MaxAgeHours      int    `json:"max_age_hours,omitempty" jsonschema:"Age in hours above which the last successful backup is reported stale. Defaults to the database's backup max_age_hours, or 26."`
+}
+
+// backupStatus is everything get_backup_status found.
+type backupStatus struct {
+	Database   string
+	Archiver   archiverStatus
+	ArchiveErr error
+	Repo       *infra.BackupRepo
+	Catalog    backupCatalog
+	CatalogErr error
+	MaxAge     time.Duration
+	Now        time.Time
+}
+
+// stale returns the backup_stale payload when the last successful backup is
+// missing or older than the threshold, or nil. A catalog that could not be
+// read is reported as an error, not as stale.
+func (s backupStatus) stale() *audit.BackupStale {
+	if s.Repo == nil || s.CatalogErr != nil {
+		return nil
+	}
+	b := &audit.BackupStale{
+		Database:         s.Database,
+		Tool:             s.Repo.Tool,
+		ThresholdSeconds: int64(s.MaxAge.Seconds()),
+	}
+	last, ok := s.Catalog.lastSuccessful()
+	if !ok {
+		b.Reason = "no successful backup in the repository"
+		return b
+	}
+	age := s.Now.Sub(last.Finished)
+	if age <= s.MaxAge {
+		return nil
+	}
+	b.LastBackup = last.Finished
+	b.AgeSeconds = int64(age.Seconds())
+	b.Reason = fmt.Sprintf("last successful backup %s finished %s ago, threshold %s",
+		last.Label, formatDuration(int(age.Seconds())), formatDuration(int(s.MaxAge.Seconds())))
+	return b
+}
+
+// findings lists what an operator must act on before relying on the backups.
+func (s backupStatus) findings() []string {
+	var findings []string
+	if st := s.stale(); st != nil {
+		if st.LastBackup.IsZero() {
+			findings = append(findings, "NO BACKUP: "+st.Reason)
+		} else {
+			findings = append(findings, "BACKUP STALE: "+st.Reason)
+		}
+	}
+	if s.CatalogErr != nil {
+		findings = append(findings, "backup catalog unreadable: "+firstLine(s.CatalogErr.Error()))
+	} else if s.Catalog.Status != "" && s.Catalog.Status != "ok" {
+		findings = append(findings, "repository reports status: "+s.Catalog.Status)
+	}
+	if s.ArchiveErr != nil {
+		return findings
+	}
+	a := s.Archiver
+	switch {
+	case a.Mode == "off":
+		findings = append(findings, "WAL archiving is OFF: the database cannot be restored to a point in time after the last backup")
+	case !a.LastFailed.IsZero() && a.LastFailed.After(a.LastArchived):
+		findings = append(findings, fmt.Sprintf("WAL archiving is FAILING: %s failed at %s and nothing has been archived since (%d failures in total)",
+			a.LastFailedWAL, a.LastFailed.Format("2006-01-02 15:04:05 UTC"), a.FailedCount))
+	}
+	if n := a.pendingSegments(); n >= archiveLagWarnSegments {
+		findings = append(findings, fmt.Sprintf("WAL archiving is falling behind: %d completed segments (%s) are waiting to be archived",
+			n, formatLag(n*a.SegmentSize)))
+	}
+	return findings
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func formatBackupStatus(s backupStatus) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Backup status of %s\n\n", s.Database)
+
+	sb.WriteString("WAL archiving (pg_stat_archiver):\n")
+	if s.ArchiveErr != nil {
+		fmt.Fprintf(&sb, "  unavailable: %v\n", s.ArchiveErr)
+	} else {
+		a := s.Archiver
+		fmt.Fprintf(&sb, "  archive_mode:     %s\n", a.Mode)
+		if a.LastArchived.IsZero() {
+			sb.WriteString("  last archived:    never\n")
+		} else {
+			fmt.Fprintf(&sb, "  last archived:    %s at %s (%s ago)\n", a.LastArchivedWAL,
+				a.LastArchived.Format("2006-01-02 15:04:05 UTC"), formatDuration(int(s.Now.Sub(a.LastArchived).Seconds())))
+		}
+		fmt.Fprintf(&sb, "  archived/failed:  %d / %d\n", a.ArchivedCount, a.FailedCount)
+		switch n := a.pendingSegments(); {
+		case a.InRecovery:
+			sb.WriteString("  pending segments: n/a (standby)\n")
+		case n >= 0:
+			fmt.Fprintf(&sb, "  pending segments: %d\n", n)
+		}
+	}
+
+	sb.WriteString("\n")
+	if s.Repo == nil {
+		sb.WriteString("Backups: no backup repository configured for this database. " +
+			"Add a backup entry (tool, stanza) to it in the infrastructure config to check backup age.\n")
+	} else {
+		header := "Backups (" + s.Repo.Tool
+		if s.Repo.Stanza != "" {
+			header += ", stanza " + s.Repo.Stanza
+		}
+		sb.WriteString(header + "):\n")
+		if s.CatalogErr != nil {
+			fmt.Fprintf(&sb, "  unavailable: %v\n", s.CatalogErr)
+		} else {
+			if s.Catalog.Status != "" {
+				fmt.Fprintf(&sb, "  repository status: %s\n", s.Catalog.Status)
+			}
+			failed := 0
+			for _, b := range s.Catalog.Backups {
+				if b.Failed {
+					failed++
+				}
+			}
+			fmt.Fprintf(&sb, "  backups in catalog: %d", len(s.Catalog.Backups))
+			if failed > 0 {
+				fmt.Fprintf(&sb, " (%d failed)", failed)
+			}
+			sb.WriteString("\n")
+			if last, ok := s.Catalog.lastSuccessful(); ok {
+				label := last.Label
+				if last.Type != "" {
+					label += " (" + last.Type + ")"
+				}
+				fmt.Fprintf(&sb, "  last successful: %s finished %s, %s ago\n", label,
+					last.Finished.Format("2006-01-02 15:04:05 UTC"), formatDuration(int(s.Now.Sub(last.Finished).Seconds())))
+			} else {
+				sb.WriteString("  last successful: none\n")
+			}
+			fmt.Fprintf(&sb, "  stale threshold: %s\n", formatDuration(int(s.MaxAge.Seconds())))
+			if len(s.Catalog.Backups) > 1 {
+				sb.WriteString("  recent backups (newest first):\n")
+				for _, b := range recentBackups(s.Catalog, recentBackupsShown) {
+					status := "ok"
+					if b.Failed {
+						status = "FAILED"
+					}
+					fmt.Fprintf(&sb, "    %-26s %-6s %s  %s\n", b.Label, b.Type, b.Finished.Format("2006-01-02 15:04:05 UTC"), status)
+				}
+			}
+		}
+	}
+
+	if findings := s.findings(); len(findings) > 0 {
+		sb.WriteString("\nFindings:\n")
+		for _, f := range findings {
+			sb.WriteString("  - " + f + "\n")
+		}
+	} else if s.Repo != nil {
+		sb.WriteString("\nNo findings: the last backup is within the threshold and WAL archiving is keeping up.\n")
+	}
+	return sb.String()
+}
+
+// recentBackupsShown is how many backups get_backup_status lists.
+const recentBackupsShown = 5
+
+// recentBackups returns the catalog's newest backups, newest first.
+func recentBackups(c backupCatalog, n int) []backupEntry {
+	list := append([]backupEntry(nil), c.Backups...)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Finished.After(list[j].Finished) })
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+func getBackupStatusImpl(ctx context.Context, args GetBackupStatusArgs) (PsqlResult, error) {
+	const toolName = "get_backup_status"
+	t, err := resolveBackup(args.ConnectionString)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+
+	s := backupStatus{Database: t.db.Name, Repo: t.repo, MaxAge: t.maxAge(args.MaxAgeHours), Now: time.Now().UTC()}
+	if s.Database == "" {
+		s.Database = args.ConnectionString
+	}
+	out, err := runPsqlWithToolName(ctx, args.ConnectionString, archiverQuery, toolName)
+	if err == nil {
+		s.Archiver, err = parseArchiverStatus(out)
+	}
+	s.ArchiveErr = err
+
+	if t.repo != nil {
+		if err := t.checkPolicy(ctx, toolName); err != nil {
+			return errorResult(toolName, args.ConnectionString, err), nil
+		}
+		out, err := t.run(ctx, toolName, backupCommand(t.repo, false))
+		if err == nil {
+			if t.repo.Tool == infra.BackupToolPgBackRest {
+				s.Catalog, err = parsePgBackRestInfo(out, t.repo.Stanza)
+			} else {
+				s.Catalog, err = parseWALGBackupList(out)
+			}
+		}
+		s.CatalogErr = err
+	} else if s.ArchiveErr != nil {
+		return errorResult(toolName, args.ConnectionString, s.ArchiveErr), nil
+	}
+
+	if st := s.stale(); st != nil && toolAuditor != nil {
+		toolAuditor.RecordBackupStale(ctx, toolName, *st)
+	}
+	return PsqlResult{Output: formatBackupStatus(s)}, nil
+}
+
+func getBackupStatusTool(ctx tool.Context, args GetBackupStatusArgs) (PsqlResult, error) {
+	return getBackupStatusImpl(ctx, args)
+}
+
+// VerifyBackupRepoArgs defines arguments for the verify_backup_repo tool.
+type VerifyBackupRepoArgs struct {
+	ConnectionString string `json:"connection_string" jsonschema:"required,Server ID from infrastructure config of a database with a backup entry."`
+}
+
+func verifyBackupRepoImpl(ctx context.Context, args VerifyBackupRepoArgs) (PsqlResult, error) {
+	const toolName = "verify_backup_repo"
+	t, err := resolveBackup(args.ConnectionString)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	if t.repo == nil {
+		return errorResult(toolName, args.ConnectionString, fmt.Errorf("database %q has no backup repository configured; "+
+			"add a backup entry with its tool and stanza to the database in the infrastructure config", t.db.Name)), nil
+	}
+	if err := t.checkPolicy(ctx, toolName); err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	cmd := backupCommand(t.repo, true)
+	out, err := t.run(ctx, toolName, cmd)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	out = strings.TrimSpace(out)
+	if out == "" {
+		out = "(no output)"
+	}
+	return PsqlResult{Output: fmt.Sprintf("Backup repository of %s verified with %s %s:\n\n%s\n",
+		t.db.Name, t.repo.Tool, strings.Join(cmd, " "), out)}, nil
+}
+
+func verifyBackupRepoTool(ctx tool.Context, args VerifyBackupRepoArgs) (PsqlResult, error) {
+	return verifyBackupRepoImpl(ctx, args)
+}
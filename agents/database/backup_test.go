@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+)
+
+// archiverRow is pg_stat_archiver output for a primary with archiving
+// keeping up: the current segment directly follows the last archived one.
+const archiverRow = `-[ RECORD 1 ]------+-------------------------
+archive_mode       | on
+archived_count     | 5120
+failed_count       | 0
+last_archived_wal  | 000000010000000A00000041
+last_archived_time | 2026-05-02T02:59:00Z
+last_failed_wal    |
+last_failed_time   |
+in_recovery        | f
+current_wal        | 000000010000000A00000042
+wal_segment_size   | 16777216
+`
+
+// pgBackRestInfoJSON returns "pgbackrest info --output=json" for stanza main
+// with a full backup and a later failed incremental.
+func pgBackRestInfoJSON(fullStop time.Time) string {
+	return fmt.Sprintf(`[{"name":"main","status":{"code":0,"message":"ok"},"backup":[
+{"label":"20260501-010000F","type":"full","error":false,"timestamp":{"start":%d,"stop":%d}},
+{"label":"20260501-010000F_20260502-010000I","type":"incr","error":true,"timestamp":{"start":%d,"stop":%d}}]}]`,
+		fullStop.Add(-time.Hour).Unix(), fullStop.Unix(), fullStop.Add(23*time.Hour).Unix(), fullStop.Add(24*time.Hour).Unix())
+}
+
+// withBackupRepo registers "prod-db" with a pgBackRest repository and records
+// audit events in a temporary store.
+func withBackupRepo(t *testing.T) *audit.Store {
+	t.Helper()
+	restoreInfra := withInfraConfig(&infra.Config{
+		DBServers: map[string]infra.DBServer{
+			"prod-db": {
+				Name:             "prod-db",
+				ConnectionString: "host=prod.example.com dbname=mydb user=postgres",
+				Backup:           &infra.BackupRepo{Tool: infra.BackupToolPgBackRest, Stanza: "main"},
+			},
+			"plain-db": {Name: "plain-db", ConnectionString: "host=plain.example.com dbname=mydb user=postgres"},
+		},
+	})
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "backup.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	origAuditor := toolAuditor
+	toolAuditor = audit.NewToolAuditor(store, "postgres_database_agent", "sess_backup", "trace_backup")
+	t.Cleanup(func() {
+		toolAuditor = origAuditor
+		store.Close()
+		restoreInfra()
+	})
+	return store
+}
+
+func TestGetBackupStatus_Stale(t *testing.T) {
+	store := withBackupRepo(t)
+	defer withMockRunnerSequence(
+		psqlResponse{out: archiverRow, err: nil},
+		psqlResponse{out: pgBackRestInfoJSON(time.Now().Add(-50 * time.Hour)), err: nil},
+	)()
+
+	result, err := getBackupStatusTool(newTestContext(), GetBackupStatusArgs{ConnectionString: "prod-db"})
+	if err != nil {
+		t.Fatalf("getBackupStatusTool() error = %v", err)
+	}
+	for _, want := range []string{
+		"Backups (pgbackrest, stanza main):",
+		"backups in catalog: 2 (1 failed)",
+		"last successful: 20260501-010000F (full)",
+		"pending segments: 0",
+		"BACKUP STALE: last successful backup 20260501-010000F finished 50h",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeBackupStale})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 1 || events[0].BackupStale == nil {
+		t.Fatalf("events = %+v, want one backup_stale event", events)
+	}
+	if b := events[0].BackupStale; b.Database != "prod-db" || b.ThresholdSeconds != 26*3600 || b.AgeSeconds < 50*3600 {
+		t.Errorf("BackupStale = %+v, want prod-db 50h old against 26h", b)
+	}
+}
+
+func TestGetBackupStatus_Fresh(t *testing.T) {
+	store := withBackupRepo(t)
+	defer withMockRunnerSequence(
+		psqlResponse{out: archiverRow, err: nil},
+		psqlResponse{out: pgBackRestInfoJSON(time.Now().Add(-3 * time.Hour)), err: nil},
+	)()
+
+	result, _ := getBackupStatusImpl(newTestContext(), GetBackupStatusArgs{ConnectionString: "prod-db"})
+	if !strings.Contains(result.Output, "No findings") {
+		t.Errorf("output = %q, want no findings", result.Output)
+	}
+	events, _ := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeBackupStale})
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want no backup_stale event for a fresh backup", events)
+	}
+}
+
+func TestGetBackupStatus_NoRepoArchivingFailing(t *testing.T) {
+	defer withMockRunner(strings.NewReplacer(
+		"last_failed_wal    |\n", "last_failed_wal    | 000000010000000A00000042\n",
+		"last_failed_time   |\n", "last_failed_time   | 2026-05-02T03:05:00Z\n",
+		"failed_count       | 0", "failed_count       | 17",
+		"current_wal        | 000000010000000A00000042", "current_wal        | 000000010000000A00000081",
+	).Replace(archiverRow), nil)()
+
+	result, _ := getBackupStatusImpl(newTestContext(), GetBackupStatusArgs{ConnectionString: "host=localhost"})
+	for _, want := range []string{
+		"no backup repository configured",
+		"WAL archiving is FAILING: 000000010000000A00000042 failed at 2026-05-02 03:05:00 UTC",
+		"63 completed segments (1008.0 MB) are waiting to be archived",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+}
+
+func TestParseWALGBackupList(t *testing.T) {
+	c, err := parseWALGBackupList(`[
+{"backup_name":"base_000000010000000A00000010","time":"2026-05-01T01:00:00Z","finish_time":"2026-05-01T01:20:00Z","is_full":true},
+{"backup_name":"base_000000010000000A00000030_D_000000010000000A00000010","time":"2026-05-02T01:00:00Z","finish_time":"2026-05-02T01:05:00Z","is_full":false}]`)
+	if err != nil {
+		t.Fatalf("parseWALGBackupList() error = %v", err)
+	}
+	last, ok := c.lastSuccessful()
+	if !ok || last.Type != "delta" || !last.Finished.Equal(time.Date(2026, 5, 2, 1, 5, 0, 0, time.UTC)) {
+		t.Errorf("lastSuccessful() = %+v, %v; want the delta finished 2026-05-02 01:05", last, ok)
+	}
+
+	if c, err := parseWALGBackupList("INFO: 2026/05/02 No backups found\n"); err != nil || len(c.Backups) != 0 {
+		t.Errorf("empty repository = %+v, %v; want no backups and no error", c, err)
+	}
+}
+
+func TestVerifyBackupRepo_NotConfigured(t *testing.T) {
+	withBackupRepo(t)
+	result, _ := verifyBackupRepoImpl(newTestContext(), VerifyBackupRepoArgs{ConnectionString: "plain-db"})
+	if !strings.Contains(result.Output, "no backup repository configured") {
+		t.Errorf("output = %q, want the missing backup entry reported", result.Output)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/prompts"
+)
+
+// runFixture replays a recorded session (testdata/<name>.json) through the
+// full agent path — LLM, tool selection, psql — and returns the agent's final
+// text. Record a new fixture by running the agent with
+// HELPDESK_FIXTURE_RECORD=agents/database/testdata/<name>.json.
+func runFixture(t *testing.T, name, query string) string {
+	t.Helper()
+	replay, err := agentutil.LoadFixture("testdata/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := cmdRunner
+	cmdRunner = replay.Runner()
+	t.Cleanup(func() { cmdRunner = old })
+
+	ctx := context.Background()
+	llm, err := agentutil.NewLLM(ctx, agentutil.Config{
+		ModelVendor:  "anthropic",
+		ModelName:    "claude-sonnet-4-5",
+		APIKey:       "fixture",
+		LLMTransport: replay.Transport(),
+	})
+	if err != nil {
+		t.Fatalf("NewLLM: %v", err)
+	}
+	tools, err := createTools()
+	if err != nil {
+		t.Fatalf("createTools: %v", err)
+	}
+	argValidator, err := agentutil.NewArgValidator(tools, nil)
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	dbAgent, err := llmagent.New(llmagent.Config{
+		Name:                "postgres_database_agent",
+		Instruction:         prompts.Database,
+		Model:               llm,
+		Tools:               tools,
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{argValidator.BeforeToolCallback()},
+	})
+	if err != nil {
+		t.Fatalf("llmagent.New: %v", err)
+	}
+
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "fixture", UserID: "u", SessionID: "s"}); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	r, err := runner.New(runner.Config{AppName: "fixture", Agent: dbAgent, SessionService: sessions})
+	if err != nil {
+		t.Fatalf("runner.New: %v", err)
+	}
+
+	var text strings.Builder
+	for ev, err := range r.Run(ctx, "u", "s", genai.NewContentFromText(query, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			text.WriteString(p.Text)
+		}
+	}
+	if unused := replay.Unused(); len(unused) > 0 {
+		t.Errorf("fixture interactions never replayed: %v", unused)
+	}
+	return text.String()
+}
+
+func TestFixture_CheckConnection(t *testing.T) {
+	got := runFixture(t, "check_connection", "Is the database at host=db.fixture port=5432 dbname=app user=app reachable?")
+	if !strings.Contains(got, "PostgreSQL 16.4") {
+		t.Errorf("final answer = %q, want it to report the server version", got)
+	}
+}
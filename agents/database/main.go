@@ -9,9 +9,9 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
@@ -33,27 +33,29 @@ import (
 // Used by tools to resolve database names to connection strings.
 var infraConfig *infra.Config
 
+// infraConfigMu guards infraConfig, which is replaced when the signed config
+// fetched from auditd changes.
+var infraConfigMu sync.RWMutex
+
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := agentutil.MustLoadConfig("localhost:1100")
 	ctx := context.Background()
 
-	// Enforce governance compliance in fix mode before any other initialization.
-	agentutil.EnforceFixMode(ctx, agentutil.CheckFixModeViolations(cfg), "postgres_database_agent", cfg.AuditURL)
-
-	// Load infrastructure config if available (enables database name resolution)
-	if infraPath := os.Getenv("HELPDESK_INFRA_CONFIG"); infraPath != "" {
-		var err error
-		infraConfig, err = infra.Load(infraPath)
-		if err != nil {
-			slog.Warn("failed to load infrastructure config", "path", infraPath, "err", err)
-		} else {
-			dbKeys := make([]string, 0, len(infraConfig.DBServers))
-		for k := range infraConfig.DBServers {
-			dbKeys = append(dbKeys, k)
-		}
-		sort.Strings(dbKeys)
-		slog.Info("infrastructure config loaded", "databases", len(infraConfig.DBServers), "db_keys", strings.Join(dbKeys, ", "))
-		}
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "postgres_database_agent",
+		Binaries:  []agentutil.RequiredBinary{{Name: "psql", VersionArgs: []string{"--version"}}},
+	})
+
+	// Load infrastructure config: signed from auditd, or a local file.
+	var infraSrc *agentutil.InfraConfigSource
+	if ic, src, err := agentutil.LoadInfraConfig(ctx, cfg); err != nil {
+		slog.Error("failed to load infrastructure config", "err", err)
+		os.Exit(1)
+	} else {
+		infraConfig, infraSrc = ic, src
 	}
 
 	// Initialize audit store if enabled
@@ -99,6 +101,9 @@ func main() {
 		AgentName:                  "postgres_database_agent",
 		ToolAuditor:                toolAuditor,
 		RequirePurposeForSensitive: os.Getenv("HELPDESK_REQUIRE_PURPOSE_FOR_SENSITIVE") == "true",
+		FreezeCheckURL:             cfg.AuditURL,
+		FreezeFailOpen:             os.Getenv("HELPDESK_FREEZE_FAIL_OPEN") == "true",
+		PolicyCacheTTL:             cfg.PolicyCacheTTL,
 	})
 
 	// Apply HELPDESK_VERIFY_* env-var overrides for Level-2 post-mutation retry config.
@@ -124,6 +129,12 @@ func main() {
 		"approval", approvalClient != nil,
 	)
 
+	// HELPDESK_FIXTURE_RECORD captures psql and LLM traffic for replay tests.
+	if rec := agentutil.FixtureRecorderFromEnv(); rec != nil {
+		cmdRunner = rec.Runner(cmdRunner)
+		cfg.LLMTransport = rec.Transport(nil)
+	}
+
 	llmModel, err := agentutil.NewLLM(ctx, cfg)
 	if err != nil {
 		slog.Error("failed to create LLM model", "err", err)
@@ -136,6 +147,14 @@ func main() {
 		slog.Error("failed to create tools", "err", err)
 		os.Exit(1)
 	}
+
+	// Validate tool arguments against each tool's input schema, for LLM and
+	// direct calls alike; rejections are audited as tool_args_rejected.
+	argValidator, err := agentutil.NewArgValidator(tools, toolAuditor)
+	if err != nil {
+		slog.Error("failed to build argument validator", "err", err)
+		os.Exit(1)
+	}
 	toolNames := make([]string, len(tools))
 	for i, t := range tools {
 		toolNames[i] = t.Name()
@@ -147,12 +166,25 @@ func main() {
 		instruction += "\n\n## Known Infrastructure\n\n" + infraConfig.Summary()
 	}
 
+	// Pick up changes to the signed config. The summary in the instruction
+	// stays as loaded; name resolution and target checks use the new config.
+	if infraSrc != nil {
+		go infraSrc.Watch(ctx, agentutil.InfraRefreshInterval, func(ic *infra.Config) {
+			infraConfigMu.Lock()
+			infraConfig = ic
+			infraConfigMu.Unlock()
+		})
+	}
+
 	dbAgent, err := llmagent.New(llmagent.Config{
 		Name:        "postgres_database_agent",
 		Description: "PostgreSQL database troubleshooting agent that can check connections, query statistics, configuration, replication status, and diagnose performance issues.",
 		Instruction: instruction,
 		Model:       llmModel,
 		Tools:       tools,
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{
+			argValidator.BeforeToolCallback(),
+		},
 		AfterModelCallbacks: []llmagent.AfterModelCallback{
 			agentutil.NewReasoningCallback(toolAuditor),
 		},
@@ -182,6 +214,14 @@ func main() {
 			"postgres_database_agent-terminate_connection":        {"postgresql", "connections", "remediation"},
 			"postgres_database_agent-terminate_idle_connections":  {"postgresql", "connections", "remediation"},
 			"postgres_database_agent-reset_cache_stats":           {"postgresql", "performance", "remediation"},
+			"postgres_database_agent-get_patroni_status":          {"postgresql", "replication", "ha"},
+			"postgres_database_agent-trigger_switchover":          {"postgresql", "ha", "remediation"},
+			"postgres_database_agent-pause_autofailover":          {"postgresql", "ha", "remediation"},
+			"postgres_database_agent-resume_autofailover":         {"postgresql", "ha", "remediation"},
+			"postgres_database_agent-get_statement_snapshot":      {"postgresql", "performance", "statistics"},
+			"postgres_database_agent-reset_statement_stats":       {"postgresql", "performance", "remediation"},
+			"postgres_database_agent-get_backup_status":           {"postgresql", "backup", "inspection"},
+			"postgres_database_agent-verify_backup_repo":          {"postgresql", "backup", "inspection"},
 		},
 		SkillExamples: map[string][]string{
 			"postgres_database_agent-check_connection":       {"Check if the production database is reachable"},
@@ -259,7 +299,9 @@ func main() {
 		"POST /admin/register-db": http.HandlerFunc(handleRegisterDB),
 	}
 
-	if err := agentserve.ServeWithTracingAndDirectTools(ctx, dbAgent, cfg, traceStore, auditStore, NewDatabaseDirectRegistry(), cardOpts); err != nil {
+	directTools := NewDatabaseDirectRegistry()
+	directTools.SetArgValidator(argValidator)
+	if err := agentserve.ServeWithTracingAndDirectTools(ctx, dbAgent, cfg, traceStore, auditStore, directTools, cardOpts); err != nil {
 		slog.Error("server stopped", "err", err)
 		os.Exit(1)
 	}
@@ -375,7 +417,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	getSessionInfoToolDef, err := functiontool.New(functiontool.Config{
+	getSessionInfoToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "get_session_info",
 		Description: "Inspect a specific backend session by PID: current state, open transaction age, whether writes have occurred (uncommitted work), locked tables, row locks held, and a rollback time estimate. Read-only — safe to call before any destructive action. Use before terminate_connection or cancel_query to understand impact.",
 	}, getSessionInfoTool)
@@ -383,7 +425,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	cancelQueryToolDef, err := functiontool.New(functiontool.Config{
+	cancelQueryToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "cancel_query",
 		Description: "Cancel a running query by sending SIGINT to the backend process (pg_cancel_backend). The connection stays open. Use get_active_connections to find pids.",
 	}, cancelQueryTool)
@@ -391,7 +433,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	terminateConnectionToolDef, err := functiontool.New(functiontool.Config{
+	terminateConnectionToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "terminate_connection",
 		Description: "Forcefully terminate a database connection by pid (pg_terminate_backend). Use when cancel_query is insufficient or the backend is stuck. The client will receive a connection error.",
 	}, terminateConnectionTool)
@@ -399,7 +441,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	terminateIdleConnectionsToolDef, err := functiontool.New(functiontool.Config{
+	terminateIdleConnectionsToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "terminate_idle_connections",
 		Description: "Terminate all idle connections older than idle_minutes minutes. Use dry_run=true first to preview which connections would be affected. Minimum idle_minutes is 5.",
 	}, terminateIdleConnectionsTool)
@@ -559,6 +601,70 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
+	getPatroniStatusToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_patroni_status",
+		Description: "Show a Patroni HA cluster through its REST API: every member's role (leader, replica, sync_standby), state, timeline and replication lag, whether automatic failover is paused, any scheduled switchover, and the latest leader changes with their reason. Use first when a replica was promoted unexpectedly, the primary changed, or failover did not happen. Only for databases registered with a patroni entry.",
+	}, getPatroniStatusTool)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerSwitchoverToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "trigger_switchover",
+		Description: "Move the leader role of a Patroni cluster to another member (planned switchover), now or at scheduled_at. Clients connected to the current leader are disconnected. Requires operator approval (Destructive action). Call get_patroni_status first and prefer a candidate with the lowest lag.",
+	}, triggerSwitchoverTool)
+	if err != nil {
+		return nil, err
+	}
+
+	pauseAutofailoverToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "pause_autofailover",
+		Description: "Pause automatic failover of a Patroni cluster (maintenance mode): Patroni stops promoting replicas if the leader fails. Use to stop a flapping cluster from failing over again while it is investigated. Requires operator approval (Write action). Undo with resume_autofailover.",
+	}, pauseAutofailoverTool)
+	if err != nil {
+		return nil, err
+	}
+
+	resumeAutofailoverToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "resume_autofailover",
+		Description: "Resume automatic failover of a Patroni cluster after pause_autofailover. Requires operator approval (Write action).",
+	}, resumeAutofailoverTool)
+	if err != nil {
+		return nil, err
+	}
+
+	getStatementSnapshotToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_statement_snapshot",
+		Description: "Snapshot the top statements in pg_stat_statements by total execution time, with calls, time per call and share of total time, keyed by queryid. Give it a label (e.g. 'before-incident') to save it; pass compare_to with an earlier label to see what each statement did since then. Requires the pg_stat_statements extension.",
+	}, getStatementSnapshotTool)
+	if err != nil {
+		return nil, err
+	}
+
+	resetStatementStatsToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "reset_statement_stats",
+		Description: "Reset pg_stat_statements so statement counters start from zero. Discards the statement history, so take a labelled get_statement_snapshot first. Requires operator approval (Write action).",
+	}, resetStatementStatsTool)
+	if err != nil {
+		return nil, err
+	}
+
+	getBackupStatusToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_backup_status",
+		Description: "Check backup health: the last successful pgBackRest or WAL-G backup and its age against the database's stale threshold, and WAL archiving from pg_stat_archiver (failures, segments waiting to be archived). Call it before proposing any destructive remediation — the approver will ask whether there is a recent backup. Backup age needs a backup entry for the database in the infrastructure config.",
+	}, getBackupStatusTool)
+	if err != nil {
+		return nil, err
+	}
+
+	verifyBackupRepoToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "verify_backup_repo",
+		Description: "Verify the backup repository of a database: pgbackrest verify (checksums of backups and archived WAL) or wal-g wal-verify integrity (gaps in the archived WAL). Read-only, but reads the whole repository and can take many minutes.",
+	}, verifyBackupRepoTool)
+	if err != nil {
+		return nil, err
+	}
+
 	return []tool.Tool{
 		checkConnectionToolDef,
 		getServerInfoToolDef,
@@ -593,6 +699,14 @@ func createTools() ([]tool.Tool, error) {
 		dropReplicationSlotToolDef,
 		resetPgSettingToolDef,
 		resetCacheStatsToolDef,
+		getPatroniStatusToolDef,
+		triggerSwitchoverToolDef,
+		pauseAutofailoverToolDef,
+		resumeAutofailoverToolDef,
+		getStatementSnapshotToolDef,
+		resetStatementStatsToolDef,
+		getBackupStatusToolDef,
+		verifyBackupRepoToolDef,
 	}, nil
 }
 
@@ -0,0 +1,635 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/tool"
+
+	"helpdesk/agentutil"
+	"helpdesk/agentutil/retryutil"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+// patroniClient calls the Patroni REST API. Switchover waits on Patroni, which
+// answers once the new leader is up, so the timeout is generous.
+var patroniClient = &http.Client{Timeout: 60 * time.Second}
+
+// verifyPatroniConfig controls the re-check loop that confirms a switchover
+// or a pause change through GET /cluster. Overridable in tests.
+var verifyPatroniConfig = retryutil.Config{
+	MaxAttempts:   4,
+	InitialDelay:  3 * time.Second,
+	MaxDelay:      15 * time.Second,
+	BackoffFactor: 2.0,
+}
+
+// patroniTarget is a database's resolved Patroni REST API.
+type patroniTarget struct {
+	db       databaseInfo
+	url      string
+	username string
+	password string
+}
+
+// resolvePatroni resolves a database alias to its Patroni REST API. Only
+// databases registered with a patroni entry have one.
+func resolvePatroni(ctx context.Context, connStrOrName string) (patroniTarget, error) {
+	dbInfo, err := resolveDatabaseInfo(connStrOrName)
+	if err != nil {
+		return patroniTarget{}, err
+	}
+	infraConfigMu.RLock()
+	ic := infraConfig
+	infraConfigMu.RUnlock()
+	if ic == nil || !dbInfo.IsFromInfraConfig {
+		return patroniTarget{}, fmt.Errorf("Patroni tools need the database registered in the infrastructure config with a patroni entry")
+	}
+	server, ok := ic.DBServers[dbInfo.Name]
+	if !ok || server.Patroni == nil {
+		return patroniTarget{}, fmt.Errorf("database %q has no Patroni REST API configured; "+
+			"add a patroni entry with its url to the database in the infrastructure config", dbInfo.Name)
+	}
+	pw, err := ic.ResolvePatroniPassword(ctx, server)
+	if err != nil {
+		return patroniTarget{}, fmt.Errorf("database %q: patroni: %w", dbInfo.Name, err)
+	}
+	return patroniTarget{
+		db:       dbInfo,
+		url:      strings.TrimRight(server.Patroni.URL, "/"),
+		username: server.Patroni.Username,
+		password: pw,
+	}, nil
+}
+
+// call sends one request to the Patroni REST API and returns the response
+// status and body. body, when non-nil, is sent as JSON.
+func (t patroniTarget) call(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.url+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	resp, err := patroniClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("Patroni REST API at %s unreachable: %w", t.url, err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, out, nil
+}
+
+// record writes the tool_execution audit event of a Patroni API call.
+func (t patroniTarget) record(ctx context.Context, toolName string, params map[string]any, method, path string, body any, output string, err error, duration time.Duration) {
+	if toolAuditor == nil {
+		return
+	}
+	p := map[string]any{"connection_string": t.db.Name}
+	for k, v := range params {
+		p[k] = v
+	}
+	raw := method + " " + t.url + path
+	if body != nil {
+		b, _ := json.Marshal(body)
+		raw += " " + string(b)
+	}
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	toolAuditor.RecordToolCall(ctx, audit.ToolCall{
+		Name:       toolName,
+		Parameters: p,
+		RawCommand: raw,
+	}, audit.ToolResult{
+		Output: truncateForAudit(output, 500),
+		Error:  errMsg,
+	}, duration)
+}
+
+// checkPolicy runs the pre-execution policy check of a Patroni tool.
+func (t patroniTarget) checkPolicy(ctx context.Context, toolName string, action policy.ActionClass, note string) error {
+	if policyEnforcer == nil {
+		return nil
+	}
+	policyCtx := agentutil.WithToolName(ctx, toolName)
+	if err := policyEnforcer.CheckDatabase(policyCtx, t.db.Name, action, t.db.Tags, note, t.db.Sensitivity); err != nil {
+		return fmt.Errorf("policy denied: %w", err)
+	}
+	return nil
+}
+
+// patroniMember is one member of GET /cluster.
+type patroniMember struct {
+	Name     string          `json:"name"`
+	Role     string          `json:"role"`  // leader, replica, sync_standby, standby_leader
+	State    string          `json:"state"` // running, streaming, stopped, starting, ...
+	Host     string          `json:"host"`
+	Port     int             `json:"port"`
+	Timeline int             `json:"timeline"`
+	Lag      json.RawMessage `json:"lag,omitempty"` // bytes behind the leader, or "unknown"
+	Tags     map[string]any  `json:"tags,omitempty"`
+}
+
+// lagBytes returns the member's replication lag, or -1 if unknown.
+func (m patroniMember) lagBytes() int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(string(m.Lag)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// healthy reports whether the member runs and can take over as leader.
+func (m patroniMember) healthy() bool {
+	if nofailover, _ := m.Tags["nofailover"].(bool); nofailover {
+		return false
+	}
+	return m.State == "running" || m.State == "streaming"
+}
+
+// patroniCluster is the response of GET /cluster.
+type patroniCluster struct {
+	Members             []patroniMember `json:"members"`
+	Pause               bool            `json:"pause,omitempty"`
+	ScheduledSwitchover *struct {
+		At   string `json:"at"`
+		From string `json:"from"`
+		To   string `json:"to,omitempty"`
+	} `json:"scheduled_switchover,omitempty"`
+}
+
+// leader returns the cluster's leader, or nil when it has none.
+func (c patroniCluster) leader() *patroniMember {
+	for i, m := range c.Members {
+		if m.Role == "leader" || m.Role == "master" || m.Role == "standby_leader" {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+// member returns the member called name, or nil.
+func (c patroniCluster) member(name string) *patroniMember {
+	for i, m := range c.Members {
+		if m.Name == name {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+// fetchPatroniCluster reads GET /cluster.
+func fetchPatroniCluster(ctx context.Context, t patroniTarget) (patroniCluster, error) {
+	status, body, err := t.call(ctx, http.MethodGet, "/cluster", nil)
+	if err != nil {
+		return patroniCluster{}, err
+	}
+	if status != http.StatusOK {
+		return patroniCluster{}, fmt.Errorf("GET /cluster returned HTTP %d: %s", status, strings.TrimSpace(string(body)))
+	}
+	var c patroniCluster
+	if err := json.Unmarshal(body, &c); err != nil {
+		return patroniCluster{}, fmt.Errorf("GET /cluster: invalid response: %w", err)
+	}
+	return c, nil
+}
+
+// patroniHistoryEntry is one leader change from GET /history.
+type patroniHistoryEntry struct {
+	Timeline  int
+	LSN       int64
+	Reason    string
+	Timestamp string
+	NewLeader string
+}
+
+// fetchPatroniHistory reads GET /history: one entry per timeline switch,
+// [timeline, lsn, reason, timestamp, new_leader], oldest first.
+func fetchPatroniHistory(ctx context.Context, t patroniTarget) ([]patroniHistoryEntry, error) {
+	status, body, err := t.call(ctx, http.MethodGet, "/history", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("GET /history returned HTTP %d", status)
+	}
+	var raw [][]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("GET /history: invalid response: %w", err)
+	}
+	entries := make([]patroniHistoryEntry, 0, len(raw))
+	for _, r := range raw {
+		var e patroniHistoryEntry
+		for i, v := range r {
+			switch i {
+			case 0:
+				f, _ := v.(float64)
+				e.Timeline = int(f)
+			case 1:
+				f, _ := v.(float64)
+				e.LSN = int64(f)
+			case 2:
+				e.Reason, _ = v.(string)
+			case 3:
+				e.Timestamp, _ = v.(string)
+			case 4:
+				e.NewLeader, _ = v.(string)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// patroniHistoryShown is how many of the latest leader changes
+// get_patroni_status lists.
+const patroniHistoryShown = 5
+
+// formatPatroniStatus renders the cluster, its latest leader changes and the
+// findings an operator acts on.
+func formatPatroniStatus(db string, c patroniCluster, history []patroniHistoryEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Patroni cluster of %s: %d member(s)\n\n", db, len(c.Members))
+	fmt.Fprintf(&sb, "%-16s %-14s %-10s %-22s %-4s %s\n", "MEMBER", "ROLE", "STATE", "HOST", "TL", "LAG")
+	for _, m := range c.Members {
+		lag := ""
+		if len(m.Lag) > 0 {
+			lag = formatLag(m.lagBytes())
+		}
+		fmt.Fprintf(&sb, "%-16s %-14s %-10s %-22s %-4d %s\n", m.Name, m.Role, m.State, fmt.Sprintf("%s:%d", m.Host, m.Port), m.Timeline, lag)
+	}
+
+	if len(history) > 0 {
+		sb.WriteString("\nLeader changes (most recent last):\n")
+		start := len(history) - patroniHistoryShown
+		if start < 0 {
+			start = 0
+		}
+		for _, e := range history[start:] {
+			line := fmt.Sprintf("  timeline %d → %d", e.Timeline, e.Timeline+1)
+			if e.Timestamp != "" {
+				line += " at " + e.Timestamp
+			}
+			if e.NewLeader != "" {
+				line += ", new leader " + e.NewLeader
+			}
+			if e.Reason != "" {
+				line += ": " + e.Reason
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	findings := patroniFindings(c)
+	if len(findings) > 0 {
+		sb.WriteString("\nFindings:\n")
+		for _, f := range findings {
+			sb.WriteString("  - " + f + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// patroniFindings lists what is wrong or unusual about a cluster.
+func patroniFindings(c patroniCluster) []string {
+	var findings []string
+	if c.Pause {
+		findings = append(findings, "automatic failover is PAUSED: Patroni will not promote a replica if the leader fails (resume_autofailover re-enables it)")
+	}
+	if s := c.ScheduledSwitchover; s != nil {
+		f := fmt.Sprintf("switchover scheduled at %s from %s", s.At, s.From)
+		if s.To != "" {
+			f += " to " + s.To
+		}
+		findings = append(findings, f)
+	}
+	leader := c.leader()
+	if leader == nil {
+		return append(findings, "the cluster has NO LEADER: there is no writable primary")
+	}
+	healthyReplicas := 0
+	for _, m := range c.Members {
+		if m.Name == leader.Name {
+			continue
+		}
+		switch {
+		case m.State != "running" && m.State != "streaming":
+			findings = append(findings, fmt.Sprintf("replica %s is %s", m.Name, m.State))
+		case m.Timeline != 0 && m.Timeline < leader.Timeline:
+			findings = append(findings, fmt.Sprintf("replica %s is on timeline %d, the leader on %d: it has not followed the last leader change", m.Name, m.Timeline, leader.Timeline))
+		default:
+			if m.healthy() {
+				healthyReplicas++
+			}
+		}
+	}
+	if healthyReplicas == 0 {
+		findings = append(findings, "no healthy replica can take over if the leader fails")
+	}
+	return findings
+}
+
+// formatLag renders replication lag in bytes.
+func formatLag(n int64) string {
+	switch {
+	case n < 0:
+		return "unknown"
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// GetPatroniStatusArgs defines arguments for the get_patroni_status tool.
+type GetPatroniStatusArgs struct {
+	ConnectionString string `json:"connection_string" jsonschema:"required,Server ID from infrastructure config of a database managed by Patroni."`
+}
+
+func getPatroniStatusImpl(ctx context.Context, args GetPatroniStatusArgs) (PsqlResult, error) {
+	const toolName = "get_patroni_status"
+	t, err := resolvePatroni(ctx, args.ConnectionString)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	if err := t.checkPolicy(ctx, toolName, policy.ActionRead, ""); err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+
+	start := time.Now()
+	cluster, err := fetchPatroniCluster(ctx, t)
+	var output string
+	if err == nil {
+		// The history is context; the status stands without it.
+		history, histErr := fetchPatroniHistory(ctx, t)
+		if histErr != nil {
+			slog.Debug("patroni history unavailable", "database", t.db.Name, "err", histErr)
+		}
+		output = formatPatroniStatus(t.db.Name, cluster, history)
+	}
+	t.record(ctx, toolName, nil, http.MethodGet, "/cluster", nil, output, err, time.Since(start))
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	return PsqlResult{Output: output}, nil
+}
+
+func getPatroniStatusTool(ctx tool.Context, args GetPatroniStatusArgs) (PsqlResult, error) {
+	return getPatroniStatusImpl(ctx, args)
+}
+
+// SwitchoverPlan is what trigger_switchover will do, shown to the approver.
+type SwitchoverPlan struct {
+	Database     string `json:"database"`
+	Leader       string `json:"leader"`
+	Candidate    string `json:"candidate,omitempty"` // empty: Patroni picks the healthiest replica
+	CandidateLag string `json:"candidate_lag,omitempty"`
+	ScheduledAt  string `json:"scheduled_at,omitempty"`
+	Paused       bool   `json:"paused,omitempty"`
+}
+
+func (p SwitchoverPlan) summary() string {
+	to := "the healthiest replica"
+	if p.Candidate != "" {
+		to = p.Candidate
+		if p.CandidateLag != "" {
+			to += " (lag " + p.CandidateLag + ")"
+		}
+	}
+	s := fmt.Sprintf("switch over %s from leader %s to %s; clients connected to %s are disconnected", p.Database, p.Leader, to, p.Leader)
+	if p.ScheduledAt != "" {
+		s += ", at " + p.ScheduledAt
+	}
+	return s
+}
+
+// TriggerSwitchoverArgs defines arguments for the trigger_switchover tool.
+type TriggerSwitchoverArgs struct {
+	ConnectionString string `json:"connection_string" jsonschema:"required,Server ID from infrastructure config of a database managed by Patroni."`
+	Candidate        string `json:"candidate,omitempty" jsonschema:"Member to promote. If empty, Patroni picks the healthiest replica. Required while automatic failover is paused."`
+	ScheduledAt      string `json:"scheduled_at,omitempty" jsonschema:"Run the switchover later, at this RFC 3339 time (e.g. 2026-05-01T02:00:00+00:00). If empty, switches over now."`
+}
+
+func triggerSwitchoverImpl(ctx context.Context, args TriggerSwitchoverArgs) (PsqlResult, error) {
+	const toolName = "trigger_switchover"
+	t, err := resolvePatroni(ctx, args.ConnectionString)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	if args.ScheduledAt != "" {
+		if _, err := time.Parse(time.RFC3339, args.ScheduledAt); err != nil {
+			return errorResult(toolName, args.ConnectionString, fmt.Errorf("invalid scheduled_at %q: must be an RFC 3339 time", args.ScheduledAt)), nil
+		}
+	}
+
+	// Validate against the live cluster before asking for approval, so an
+	// approver is never asked to sign off on a switchover that cannot happen.
+	cluster, err := fetchPatroniCluster(ctx, t)
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	leader := cluster.leader()
+	if leader == nil {
+		return errorResult(toolName, args.ConnectionString, fmt.Errorf(
+			"the cluster has no leader; a switchover needs one (Patroni fails over on its own unless paused)")), nil
+	}
+	plan := SwitchoverPlan{Database: t.db.Name, Leader: leader.Name, Candidate: args.Candidate, ScheduledAt: args.ScheduledAt, Paused: cluster.Pause}
+	if args.Candidate != "" {
+		m := cluster.member(args.Candidate)
+		switch {
+		case m == nil:
+			return errorResult(toolName, args.ConnectionString, fmt.Errorf("candidate %q is not a member of the cluster", args.Candidate)), nil
+		case m.Name == leader.Name:
+			return errorResult(toolName, args.ConnectionString, fmt.Errorf("candidate %q is already the leader", args.Candidate)), nil
+		case !m.healthy():
+			return errorResult(toolName, args.ConnectionString, fmt.Errorf(
+				"candidate %q cannot take over: it is %s or tagged nofailover", args.Candidate, m.State)), nil
+		}
+		if len(m.Lag) > 0 {
+			plan.CandidateLag = formatLag(m.lagBytes())
+		}
+	} else if cluster.Pause {
+		return errorResult(toolName, args.ConnectionString, fmt.Errorf(
+			"automatic failover is paused; name a candidate for the switchover")), nil
+	}
+
+	ctx = agentutil.WithExecutionPlan(ctx, audit.NewExecutionPlan(toolName, plan.summary(), plan))
+	if err := t.checkPolicy(ctx, toolName, policy.ActionDestructive, plan.summary()); err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+
+	body := map[string]any{"leader": leader.Name}
+	if args.Candidate != "" {
+		body["candidate"] = args.Candidate
+	}
+	if args.ScheduledAt != "" {
+		body["scheduled_at"] = args.ScheduledAt
+	}
+	start := time.Now()
+	status, resp, err := t.call(ctx, http.MethodPost, "/switchover", body)
+	output := strings.TrimSpace(string(resp))
+	if err == nil && status != http.StatusOK && status != http.StatusAccepted {
+		err = fmt.Errorf("Patroni refused the switchover (HTTP %d): %s", status, output)
+	}
+	t.record(ctx, toolName, map[string]any{"leader": leader.Name, "candidate": args.Candidate, "scheduled_at": args.ScheduledAt},
+		http.MethodPost, "/switchover", body, output, err, time.Since(start))
+	if err != nil {
+		return errorResult(toolName, args.ConnectionString, err), nil
+	}
+	if status == http.StatusAccepted || args.ScheduledAt != "" {
+		return PsqlResult{Output: fmt.Sprintf("Switchover scheduled: %s\nPatroni: %s", plan.summary(), output)}, nil
+	}
+
+	// Level 2: confirm through the cluster view that the leader moved.
+	var newLeader string
+	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyPatroniConfig,
+		func() (bool, error) {
+			c, err := fetchPatroniCluster(ctx, t)
+			if err != nil {
+				return false, nil
+			}
+			l := c.leader()
+			if l == nil || l.State != "running" {
+				return false, nil
+			}
+			newLeader = l.Name
+			return l.Name != leader.Name && (args.Candidate == "" || l.Name == args.Candidate), nil
+		},
+		func(attempt int, r bool) {
+			if toolAuditor != nil {
+				toolAuditor.RecordToolRetry(ctx, toolName, attempt, r)
+			}
+		},
+	)
+	retryCount := attempts - 1
+	if retryCount < 0 {
+		retryCount = 0
+	}
+	if !resolved {
+		if toolAuditor != nil {
+			toolAuditor.RecordToolVerification(ctx, toolName, "warning")
+		}
+		want := plan.Candidate
+		if want == "" {
+			want = "a new member"
+		}
+		return PsqlResult{
+			Output: fmt.Sprintf("VERIFICATION WARNING: Patroni accepted the switchover, but %s is still not leading the cluster (current leader: %s).\n"+
+				"Call get_patroni_status to check the members before retrying.\nPatroni: %s",
+				want, newLeader, output),
+			VerifyStatus: "warning",
+			RetryCount:   retryCount,
+		}, nil
+	}
+	return PsqlResult{
+		Output:       fmt.Sprintf("Switchover complete: %s is the new leader of %s (was %s).\nPatroni: %s", newLeader, t.db.Name, leader.Name, output),
+		VerifyStatus: "ok",
+		RetryCount:   retryCount,
+	}, nil
+}
+
+func triggerSwitchoverTool(ctx tool.Context, args TriggerSwitchoverArgs) (PsqlResult, error) {
+	return triggerSwitchoverImpl(ctx, args)
+}
+
+// AutofailoverArgs defines arguments for the pause_autofailover and
+// resume_autofailover tools.
+type AutofailoverArgs struct {
+	ConnectionString string `json:"connection_string" jsonschema:"required,Server ID from infrastructure config of a database managed by Patroni."`
+}
+
+func pauseAutofailoverImpl(ctx context.Context, args AutofailoverArgs) (PsqlResult, error) {
+	return setAutofailoverPausedImpl(ctx, "pause_autofailover", args.ConnectionString, true)
+}
+
+func pauseAutofailoverTool(ctx tool.Context, args AutofailoverArgs) (PsqlResult, error) {
+	return pauseAutofailoverImpl(ctx, args)
+}
+
+func resumeAutofailoverImpl(ctx context.Context, args AutofailoverArgs) (PsqlResult, error) {
+	return setAutofailoverPausedImpl(ctx, "resume_autofailover", args.ConnectionString, false)
+}
+
+func resumeAutofailoverTool(ctx tool.Context, args AutofailoverArgs) (PsqlResult, error) {
+	return resumeAutofailoverImpl(ctx, args)
+}
+
+// setAutofailoverPausedImpl switches Patroni maintenance mode through
+// PATCH /config and confirms the change through GET /cluster.
+func setAutofailoverPausedImpl(ctx context.Context, toolName, connStr string, pause bool) (PsqlResult, error) {
+	t, err := resolvePatroni(ctx, connStr)
+	if err != nil {
+		return errorResult(toolName, connStr, err), nil
+	}
+
+	summary := fmt.Sprintf("resume automatic failover on %s: Patroni promotes a replica again if the leader fails", t.db.Name)
+	if pause {
+		summary = fmt.Sprintf("pause automatic failover on %s: Patroni will not promote a replica if the leader fails until resume_autofailover", t.db.Name)
+	}
+	ctx = agentutil.WithExecutionPlan(ctx, audit.NewExecutionPlan(toolName, summary, map[string]any{"database": t.db.Name, "pause": pause}))
+	if err := t.checkPolicy(ctx, toolName, policy.ActionWrite, summary); err != nil {
+		return errorResult(toolName, connStr, err), nil
+	}
+
+	body := map[string]any{"pause": pause}
+	start := time.Now()
+	status, resp, err := t.call(ctx, http.MethodPatch, "/config", body)
+	output := strings.TrimSpace(string(resp))
+	if err == nil && status != http.StatusOK {
+		err = fmt.Errorf("Patroni refused the configuration change (HTTP %d): %s", status, output)
+	}
+	t.record(ctx, toolName, map[string]any{"pause": pause}, http.MethodPatch, "/config", body, output, err, time.Since(start))
+	if err != nil {
+		return errorResult(toolName, connStr, err), nil
+	}
+
+	state := "resumed"
+	if pause {
+		state = "paused"
+	}
+	resolved, _, _ := retryutil.WaitUntilResolved(ctx, verifyPatroniConfig,
+		func() (bool, error) {
+			c, err := fetchPatroniCluster(ctx, t)
+			return err == nil && c.Pause == pause, nil
+		}, nil)
+	if !resolved {
+		if toolAuditor != nil {
+			toolAuditor.RecordToolVerification(ctx, toolName, "warning")
+		}
+		return PsqlResult{
+			Output:       fmt.Sprintf("VERIFICATION WARNING: Patroni accepted the change, but the cluster does not report automatic failover as %s yet. Call get_patroni_status to check.", state),
+			VerifyStatus: "warning",
+		}, nil
+	}
+	return PsqlResult{Output: fmt.Sprintf("Automatic failover %s on %s.", state, t.db.Name), VerifyStatus: "ok"}, nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"helpdesk/agentutil"
+	"helpdesk/agentutil/retryutil"
+	"helpdesk/internal/infra"
+)
+
+// fakePatroni serves the parts of the Patroni REST API the tools use. A
+// switchover moves the leader role; PATCH /config sets pause.
+type fakePatroni struct {
+	mu       sync.Mutex
+	cluster  patroniCluster
+	requests []string // "METHOD /path body"
+	auth     string   // "user:password" of the last authenticated request
+}
+
+func newFakePatroni() *fakePatroni {
+	return &fakePatroni{cluster: patroniCluster{Members: []patroniMember{
+		{Name: "pg-1", Role: "leader", State: "running", Host: "10.0.0.1", Port: 5432, Timeline: 5},
+		{Name: "pg-2", Role: "replica", State: "streaming", Host: "10.0.0.2", Port: 5432, Timeline: 5, Lag: json.RawMessage("0")},
+		{Name: "pg-3", Role: "replica", State: "streaming", Host: "10.0.0.3", Port: 5432, Timeline: 4, Lag: json.RawMessage("16777216")},
+	}}}
+}
+
+func (f *fakePatroni) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	b, _ := json.Marshal(body)
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+" "+string(b))
+	if u, p, ok := r.BasicAuth(); ok {
+		f.auth = u + ":" + p
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /cluster":
+		_ = json.NewEncoder(w).Encode(f.cluster)
+	case "GET /history":
+		_, _ = w.Write([]byte(`[[3, 50331648, "no recovery target specified", "2026-05-01T09:00:00+00:00", "pg-2"],
+			[4, 83886080, "no recovery target specified", "2026-05-02T03:14:00+00:00", "pg-1"]]`))
+	case "POST /switchover":
+		for i := range f.cluster.Members {
+			m := &f.cluster.Members[i]
+			switch m.Name {
+			case body["leader"]:
+				m.Role = "replica"
+			case body["candidate"]:
+				m.Role = "leader"
+				m.State = "running"
+			}
+		}
+		_, _ = w.Write([]byte(`Successfully switched over to "pg-2"`))
+	case "PATCH /config":
+		f.cluster.Pause, _ = body["pause"].(bool)
+		_, _ = w.Write([]byte(`{"pause": true}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// mutations returns the POST and PATCH requests the fake received.
+func (f *fakePatroni) mutations() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, r := range f.requests {
+		if !strings.HasPrefix(r, "GET ") {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// withFakePatroni registers "prod-db" with a Patroni API served by a fake and
+// makes verification immediate.
+func withFakePatroni(t *testing.T) *fakePatroni {
+	t.Helper()
+	f := newFakePatroni()
+	srv := httptest.NewServer(f)
+	t.Setenv("TEST_PATRONI_PW", "rest-pw")
+	restoreInfra := withInfraConfig(&infra.Config{
+		DBServers: map[string]infra.DBServer{
+			"prod-db": {
+				Name:             "prod-db",
+				ConnectionString: "host=prod.example.com dbname=mydb user=postgres",
+				Tags:             []string{"production"},
+				Patroni:          &infra.PatroniAPI{URL: srv.URL + "/", Username: "patroni", Credential: "patroni-api"},
+			},
+			"plain-db": {Name: "plain-db", ConnectionString: "host=plain.example.com dbname=mydb user=postgres"},
+		},
+		Credentials: map[string]infra.Credential{"patroni-api": {Env: "TEST_PATRONI_PW"}},
+	})
+	oldVerify := verifyPatroniConfig
+	verifyPatroniConfig = retryutil.Config{MaxAttempts: 2}
+	t.Cleanup(func() {
+		verifyPatroniConfig = oldVerify
+		restoreInfra()
+		srv.Close()
+	})
+	return f
+}
+
+func TestGetPatroniStatus(t *testing.T) {
+	f := withFakePatroni(t)
+	f.cluster.Pause = true
+
+	result, err := getPatroniStatusTool(newTestContext(), GetPatroniStatusArgs{ConnectionString: "prod-db"})
+	if err != nil {
+		t.Fatalf("getPatroniStatusTool() error = %v", err)
+	}
+	for _, want := range []string{
+		"pg-1", "leader", "pg-3", "16.0 MB",
+		"timeline 4 → 5 at 2026-05-02T03:14:00+00:00, new leader pg-1",
+		"automatic failover is PAUSED",
+		"replica pg-3 is on timeline 4, the leader on 5",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+	if f.auth != "patroni:rest-pw" {
+		t.Errorf("basic auth = %q, want patroni:rest-pw", f.auth)
+	}
+}
+
+func TestGetPatroniStatus_NotConfigured(t *testing.T) {
+	withFakePatroni(t)
+
+	result, _ := getPatroniStatusTool(newTestContext(), GetPatroniStatusArgs{ConnectionString: "plain-db"})
+	if !strings.Contains(result.Output, "no Patroni REST API configured") {
+		t.Errorf("output = %q, want a missing patroni entry error", result.Output)
+	}
+}
+
+func TestPatroniFindings_NoLeader(t *testing.T) {
+	c := patroniCluster{Members: []patroniMember{{Name: "pg-1", Role: "replica", State: "stopped"}}}
+	findings := patroniFindings(c)
+	if len(findings) != 1 || !strings.Contains(findings[0], "NO LEADER") {
+		t.Errorf("findings = %v, want only the missing leader", findings)
+	}
+}
+
+func TestTriggerSwitchover_Success(t *testing.T) {
+	f := withFakePatroni(t)
+
+	result, err := triggerSwitchoverTool(newTestContext(), TriggerSwitchoverArgs{ConnectionString: "prod-db", Candidate: "pg-2"})
+	if err != nil {
+		t.Fatalf("triggerSwitchoverTool() error = %v", err)
+	}
+	if result.VerifyStatus != "ok" || !strings.Contains(result.Output, "pg-2 is the new leader of prod-db (was pg-1)") {
+		t.Errorf("result = %+v, want a verified switchover to pg-2", result)
+	}
+	got := f.mutations()
+	if len(got) != 1 || got[0] != `POST /switchover {"candidate":"pg-2","leader":"pg-1"}` {
+		t.Errorf("mutations = %v, want one switchover from pg-1 to pg-2", got)
+	}
+}
+
+func TestTriggerSwitchover_CandidateRefused(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate string
+		want      string
+	}{
+		{"unknown member", "pg-9", "not a member"},
+		{"leader", "pg-1", "already the leader"},
+		{"nofailover", "pg-2", "tagged nofailover"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := withFakePatroni(t)
+			f.cluster.Members[1].Tags = map[string]any{"nofailover": true}
+
+			result, _ := triggerSwitchoverTool(newTestContext(), TriggerSwitchoverArgs{ConnectionString: "prod-db", Candidate: tt.candidate})
+			if !strings.Contains(result.Output, tt.want) {
+				t.Errorf("output = %q, want %q", result.Output, tt.want)
+			}
+			if got := f.mutations(); len(got) != 0 {
+				t.Errorf("mutations = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestTriggerSwitchover_PausedNeedsCandidate(t *testing.T) {
+	f := withFakePatroni(t)
+	f.cluster.Pause = true
+
+	result, _ := triggerSwitchoverTool(newTestContext(), TriggerSwitchoverArgs{ConnectionString: "prod-db"})
+	if !strings.Contains(result.Output, "name a candidate") {
+		t.Errorf("output = %q, want a candidate to be required", result.Output)
+	}
+	if got := f.mutations(); len(got) != 0 {
+		t.Errorf("mutations = %v, want none", got)
+	}
+}
+
+func TestTriggerSwitchover_RequiresApproval(t *testing.T) {
+	f := withFakePatroni(t)
+	const yaml = `
+version: "1"
+policies:
+  - name: switchover-approval
+    resources:
+      - type: database
+        match:
+          tool: trigger_switchover
+    rules:
+      - action: destructive
+        effect: require_approval
+`
+	engine, err := agentutil.InitPolicyEngine(agentutil.Config{
+		PolicyEnabled: true,
+		PolicyFile:    writeTempDBPolicyFile(t, yaml),
+		DefaultPolicy: "allow",
+	})
+	if err != nil {
+		t.Fatalf("InitPolicyEngine: %v", err)
+	}
+	defer withPolicyEnforcer(agentutil.NewPolicyEnforcerWithConfig(agentutil.PolicyEnforcerConfig{Engine: engine}))()
+
+	result, _ := triggerSwitchoverTool(newTestContext(), TriggerSwitchoverArgs{ConnectionString: "prod-db", Candidate: "pg-2"})
+	if !strings.Contains(result.Output, "policy denied") || !strings.Contains(strings.ToLower(result.Output), "approval") {
+		t.Errorf("output = %q, want an approval requirement", result.Output)
+	}
+	if got := f.mutations(); len(got) != 0 {
+		t.Errorf("mutations = %v, want none before approval", got)
+	}
+
+	// The status read stays allowed.
+	status, _ := getPatroniStatusTool(newTestContext(), GetPatroniStatusArgs{ConnectionString: "prod-db"})
+	if strings.Contains(status.Output, "policy denied") {
+		t.Errorf("get_patroni_status output = %q, want it allowed", status.Output)
+	}
+}
+
+func TestSwitchoverPlan_Summary(t *testing.T) {
+	p := SwitchoverPlan{Database: "prod-db", Leader: "pg-1", Candidate: "pg-2", CandidateLag: "0 B"}
+	want := "switch over prod-db from leader pg-1 to pg-2 (lag 0 B); clients connected to pg-1 are disconnected"
+	if got := p.summary(); got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
+
+func TestPauseResumeAutofailover(t *testing.T) {
+	f := withFakePatroni(t)
+
+	result, _ := pauseAutofailoverTool(newTestContext(), AutofailoverArgs{ConnectionString: "prod-db"})
+	if result.VerifyStatus != "ok" || !f.cluster.Pause {
+		t.Fatalf("pause result = %+v, cluster paused = %v", result, f.cluster.Pause)
+	}
+	result, _ = resumeAutofailoverTool(newTestContext(), AutofailoverArgs{ConnectionString: "prod-db"})
+	if result.VerifyStatus != "ok" || f.cluster.Pause {
+		t.Fatalf("resume result = %+v, cluster paused = %v", result, f.cluster.Pause)
+	}
+	got := f.mutations()
+	want := []string{`PATCH /config {"pause":true}`, `PATCH /config {"pause":false}`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("mutations = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"helpdesk/agentutil"
+)
+
+// PsqlData is the machine-readable form of a PsqlResult, returned to
+// direct-tool clients alongside the text the LLM sees.
+type PsqlData struct {
+	// ResultSets holds one entry per query result in the output; each record
+	// maps column name to value as psql printed it.
+	ResultSets   [][]map[string]string `json:"result_sets"`
+	Error        string                `json:"error,omitempty"`
+	VerifyStatus string                `json:"verify_status,omitempty"`
+	RetryCount   int                   `json:"retry_count,omitempty"`
+}
+
+// Structured parses the result's expanded (-x) psql output into records.
+// Text the tool added around the psql output (headings, summaries) is skipped.
+func (r PsqlResult) Structured() PsqlData {
+	d := PsqlData{
+		ResultSets:   parsePsqlRecords(r.Output),
+		VerifyStatus: r.VerifyStatus,
+		RetryCount:   r.RetryCount,
+	}
+	if strings.HasPrefix(r.Output, "---\nERROR — ") {
+		d.Error = strings.TrimSpace(strings.Trim(r.Output, "-\n"))
+	}
+	return d
+}
+
+// psqlTool adapts a psql tool for direct invocation with structured output.
+func psqlTool[T any](impl func(context.Context, T) (PsqlResult, error)) agentutil.StructuredToolFunc {
+	return func(ctx context.Context, args map[string]any) (agentutil.ToolOutput, error) {
+		a, err := argsToStruct[T](args)
+		if err != nil {
+			return agentutil.ToolOutput{}, err
+		}
+		result, _ := impl(ctx, a)
+		return agentutil.ToolOutput{Text: result.Output, Data: result.Structured()}, nil
+	}
+}
+
+// parsePsqlRecords reads psql expanded output:
+//
+//	-[ RECORD 1 ]----+------------
+//	datname          | postgres
+//	description      | first line+
+//	                 | second line
+//
+// A "RECORD 1" header starts a new result set. Multi-line values (marked by
+// a trailing "+") are joined with newlines. Always returns a non-nil slice.
+func parsePsqlRecords(output string) [][]map[string]string {
+	sets := [][]map[string]string{}
+	var cur map[string]string
+	var lastKey string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "-[ RECORD ") {
+			cur = map[string]string{}
+			lastKey = ""
+			if strings.HasPrefix(line, "-[ RECORD 1 ]") || len(sets) == 0 {
+				sets = append(sets, nil)
+			}
+			sets[len(sets)-1] = append(sets[len(sets)-1], cur)
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		idx := strings.Index(line, " |")
+		if idx < 0 {
+			// Anything else ends the record (blank line, "(N rows)", tool text).
+			cur = nil
+			continue
+		}
+		key := strings.TrimSpace(line[:idx])
+		val := strings.TrimPrefix(line[idx+2:], " ")
+		if key == "" && lastKey != "" {
+			cur[lastKey] = strings.TrimSuffix(cur[lastKey], "+") + "\n" + val
+			continue
+		}
+		cur[key] = val
+		lastKey = key
+	}
+	return sets
+}
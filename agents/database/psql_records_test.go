@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePsqlRecords(t *testing.T) {
+	output := `Connection successful!
+-[ RECORD 1 ]----+--------------------
+datname          | postgres
+description      | first line+
+                 | second line
+empty            |
+-[ RECORD 2 ]----+--------------------
+datname          | app
+description      | a | b
+empty            |
+
+-[ RECORD 1 ]-+---
+setting       | on
+`
+	sets := parsePsqlRecords(output)
+	if len(sets) != 2 {
+		t.Fatalf("result sets = %d, want 2: %+v", len(sets), sets)
+	}
+	if len(sets[0]) != 2 || len(sets[1]) != 1 {
+		t.Fatalf("records per set = %d/%d, want 2/1", len(sets[0]), len(sets[1]))
+	}
+	if got := sets[0][0]["description"]; got != "first line\nsecond line" {
+		t.Errorf("multi-line value = %q", got)
+	}
+	if got := sets[0][1]["description"]; got != "a | b" {
+		t.Errorf("value containing a pipe = %q", got)
+	}
+	if v, ok := sets[0][0]["empty"]; !ok || v != "" {
+		t.Errorf("empty value = %q, %v; want present and empty", v, ok)
+	}
+	if sets[1][0]["setting"] != "on" {
+		t.Errorf("second result set = %+v", sets[1])
+	}
+}
+
+func TestPsqlResult_Structured(t *testing.T) {
+	if d := (PsqlResult{Output: "(0 rows)"}).Structured(); d.ResultSets == nil || len(d.ResultSets) != 0 {
+		t.Errorf("no records: result_sets = %#v, want empty non-nil", d.ResultSets)
+	}
+
+	d := errorResult("check_connection", "host=db", errors.New("connection refused")).Structured()
+	if d.Error == "" || d.Error[:5] != "ERROR" {
+		t.Errorf("Error = %q, want the error block", d.Error)
+	}
+
+	d = PsqlResult{Output: "done", VerifyStatus: "ok", RetryCount: 2}.Structured()
+	if d.VerifyStatus != "ok" || d.RetryCount != 2 {
+		t.Errorf("verify fields = %q/%d", d.VerifyStatus, d.RetryCount)
+	}
+}
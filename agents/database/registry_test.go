@@ -32,6 +32,14 @@ var expectedDBTools = []string{
 	"get_wait_events",
 	"get_blocking_queries",
 	"explain_query",
+	"get_patroni_status",
+	"trigger_switchover",
+	"pause_autofailover",
+	"resume_autofailover",
+	"get_statement_snapshot",
+	"reset_statement_stats",
+	"get_backup_status",
+	"verify_backup_repo",
 }
 
 func TestDatabaseDirectRegistry_AllToolsRegistered(t *testing.T) {
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/tool"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+// maxStatementDeltas caps the statements listed in a snapshot comparison.
+const maxStatementDeltas = 10
+
+// statementsInstalled reports whether pg_stat_statements is installed in the
+// target database. Checking first avoids a noisy ERROR log from querying a
+// missing extension, a known-benign case. toolName attributes the check in
+// the audit trail.
+func statementsInstalled(ctx context.Context, connStr, toolName string) (bool, error) {
+	out, err := runPsqlWithToolName(ctx, connStr,
+		`SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements';`, toolName)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(out, "1"), nil
+}
+
+const statementsNotInstalled = "pg_stat_statements extension is not installed. Enable it with: CREATE EXTENSION pg_stat_statements; and add it to shared_preload_libraries in postgresql.conf."
+
+// serverNameFor is the name snapshots are saved under: the registered alias
+// when there is one, the connection string otherwise.
+func serverNameFor(connStr string) string {
+	dbInfo, _ := resolveDatabaseInfo(connStr)
+	if dbInfo.Name != "" {
+		return dbInfo.Name
+	}
+	return connStr
+}
+
+// ---------------------------------------------------------------------------
+// get_statement_snapshot
+// ---------------------------------------------------------------------------
+
+// GetStatementSnapshotArgs defines arguments for the get_statement_snapshot tool.
+type GetStatementSnapshotArgs struct {
+	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
+	Limit            int    `json:"limit,omitempty" jsonschema:"Number of statements to include, by total execution time (default 20, max 100)."`
+	Label            string `json:"label,omitempty" jsonschema:"Name for this snapshot, e.g. 'before-incident'. A later snapshot can compare against it by label."`
+	CompareTo        string `json:"compare_to,omitempty" jsonschema:"Label of an earlier snapshot of the same database. Adds what each statement did since then: calls, execution time and time per call."`
+}
+
+// statementStat is one pg_stat_statements row of a snapshot.
+type statementStat struct {
+	QueryID string
+	Query   string
+	Calls   int64
+	TotalMs float64
+}
+
+func (s statementStat) meanMs() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalMs / float64(s.Calls)
+}
+
+// parseStatementSnapshot reads the statements of a get_statement_snapshot
+// output, keyed by queryid.
+func parseStatementSnapshot(output string) map[string]statementStat {
+	stats := map[string]statementStat{}
+	sets := parsePsqlRecords(output)
+	if len(sets) == 0 {
+		return stats
+	}
+	for _, rec := range sets[0] {
+		id := strings.TrimSpace(rec["queryid"])
+		if id == "" {
+			continue
+		}
+		calls, _ := strconv.ParseInt(strings.TrimSpace(rec["calls"]), 10, 64)
+		total, _ := strconv.ParseFloat(strings.TrimSpace(rec["total_ms"]), 64)
+		stats[id] = statementStat{QueryID: id, Query: rec["query"], Calls: calls, TotalMs: total}
+	}
+	return stats
+}
+
+// statementDelta is what one statement did between two snapshots.
+type statementDelta struct {
+	statementStat          // the change: calls and time since the baseline
+	BaselineMeanMs float64 // time per call up to the baseline; 0 when New
+	New            bool    // not in the baseline's top statements
+}
+
+// diffStatements returns the statements that ran between before and after,
+// by execution time spent, and whether the statistics were reset in between.
+// After a reset the current counters are the best available change.
+func diffStatements(before, after map[string]statementStat) ([]statementDelta, bool) {
+	var deltas []statementDelta
+	reset := false
+	for id, cur := range after {
+		base, ok := before[id]
+		d := statementDelta{statementStat: cur, New: !ok}
+		if ok {
+			if cur.Calls < base.Calls || cur.TotalMs < base.TotalMs {
+				reset = true
+			} else {
+				d.Calls = cur.Calls - base.Calls
+				d.TotalMs = cur.TotalMs - base.TotalMs
+			}
+			d.BaselineMeanMs = base.meanMs()
+		}
+		if d.Calls == 0 {
+			continue
+		}
+		deltas = append(deltas, d)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].TotalMs != deltas[j].TotalMs {
+			return deltas[i].TotalMs > deltas[j].TotalMs
+		}
+		return deltas[i].QueryID < deltas[j].QueryID
+	})
+	return deltas, reset
+}
+
+// formatStatementDeltas renders a comparison for the LLM.
+func formatStatementDeltas(label string, baseline *audit.PersistedToolResult, deltas []statementDelta, reset bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "=== Changes since snapshot %q (recorded %s UTC) ===\n",
+		label, baseline.RecordedAt.UTC().Format("2006-01-02 15:04:05"))
+	if reset {
+		sb.WriteString("NOTE: statistics were reset after the baseline; counters that went down are shown as their current values.\n")
+	}
+	if len(deltas) == 0 {
+		sb.WriteString("No statement ran since the baseline.\n")
+		return sb.String()
+	}
+	var calls int64
+	var total float64
+	for _, d := range deltas {
+		calls += d.Calls
+		total += d.TotalMs
+	}
+	fmt.Fprintf(&sb, "Since then: %d calls, %.2f ms execution time across %d statements.\n\n", calls, total, len(deltas))
+	for i, d := range deltas {
+		if i == maxStatementDeltas {
+			fmt.Fprintf(&sb, "... %d more statements\n", len(deltas)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "%2d. queryid %s: +%d calls, +%.2f ms, %.3f ms/call", i+1, d.QueryID, d.Calls, d.TotalMs, d.meanMs())
+		switch {
+		case d.New:
+			sb.WriteString(" (not in the baseline)")
+		case d.BaselineMeanMs > 0:
+			fmt.Fprintf(&sb, " (was %.3f ms/call)", d.BaselineMeanMs)
+		}
+		fmt.Fprintf(&sb, "\n    %s\n", d.Query)
+	}
+	sb.WriteString("\nStatements outside the baseline's top list show as not in the baseline.\n")
+	return sb.String()
+}
+
+// findStatementSnapshot returns the most recent saved snapshot of serverName
+// taken with label, or nil when there is none.
+func findStatementSnapshot(ctx context.Context, serverName, label string) (*audit.PersistedToolResult, error) {
+	results, err := fetchSavedToolResults(ctx, "get_statement_snapshot", serverName, 50, "30d")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		var args GetStatementSnapshotArgs
+		if json.Unmarshal([]byte(r.ToolArgs), &args) == nil && args.Label == label {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func getStatementSnapshotImpl(ctx context.Context, args GetStatementSnapshotArgs) (PsqlResult, error) {
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	ok, err := statementsInstalled(ctx, args.ConnectionString, "get_statement_snapshot")
+	if err != nil {
+		return errorResult("get_statement_snapshot", args.ConnectionString, err), nil
+	}
+	if !ok {
+		return PsqlResult{Output: statementsNotInstalled}, nil
+	}
+
+	// One row per normalized statement: pg_stat_statements keeps a row per
+	// user and database, which are summed so queryid is a stable key.
+	query := fmt.Sprintf(`SELECT
+		queryid,
+		LEFT(regexp_replace(MIN(query), '\s+', ' ', 'g'), 200) AS query,
+		SUM(calls) AS calls,
+		ROUND(SUM(total_exec_time)::numeric, 2) AS total_ms,
+		ROUND((SUM(total_exec_time) / NULLIF(SUM(calls), 0))::numeric, 3) AS mean_ms,
+		SUM(rows) AS rows,
+		ROUND((100 * SUM(total_exec_time) / NULLIF(SUM(SUM(total_exec_time)) OVER (), 0))::numeric, 1) AS pct_total_time
+	FROM pg_stat_statements
+	GROUP BY queryid
+	ORDER BY SUM(total_exec_time) DESC
+	LIMIT %d;`, limit)
+
+	output, err := runPsqlWithToolName(ctx, args.ConnectionString, query, "get_statement_snapshot")
+	if err != nil {
+		return errorResult("get_statement_snapshot", args.ConnectionString, err), nil
+	}
+	if strings.TrimSpace(output) == "" || strings.Contains(output, "(0 rows)") {
+		return PsqlResult{Output: "No statements recorded yet. pg_stat_statements resets on server restart and on reset_statement_stats."}, nil
+	}
+
+	var sb strings.Builder
+	if args.Label != "" {
+		fmt.Fprintf(&sb, "=== Statement snapshot %q: top %d statements by total execution time ===\n", args.Label, limit)
+	} else {
+		fmt.Fprintf(&sb, "=== Statement snapshot: top %d statements by total execution time ===\n", limit)
+	}
+	sb.WriteString(output)
+
+	if args.CompareTo != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(compareStatementSnapshot(ctx, serverNameFor(args.ConnectionString), args.CompareTo, output))
+	}
+	return PsqlResult{Output: sb.String()}, nil
+}
+
+// compareStatementSnapshot diffs output against the saved snapshot labelled
+// label. A missing baseline is reported in the text, not as a tool error, so
+// the snapshot itself is still returned and saved.
+func compareStatementSnapshot(ctx context.Context, serverName, label, output string) string {
+	if auditBaseURL == "" {
+		return "Comparison unavailable: HELPDESK_AUDIT_URL is not configured, so no earlier snapshots are saved."
+	}
+	baseline, err := findStatementSnapshot(ctx, serverName, label)
+	if err != nil {
+		return fmt.Sprintf("Comparison unavailable: %v", err)
+	}
+	if baseline == nil {
+		return fmt.Sprintf("Comparison unavailable: no snapshot labelled %q was saved for %s in the last 30 days.", label, serverName)
+	}
+	deltas, reset := diffStatements(parseStatementSnapshot(baseline.Output), parseStatementSnapshot(output))
+	return formatStatementDeltas(label, baseline, deltas, reset)
+}
+
+func getStatementSnapshotTool(ctx tool.Context, args GetStatementSnapshotArgs) (PsqlResult, error) {
+	result, err := getStatementSnapshotImpl(ctx, args)
+	if err == nil && strings.HasPrefix(result.Output, "=== Statement snapshot") {
+		// Persist the snapshot so a later one can compare against it. This
+		// covers the A2A/LLM path; the direct-tool path is persisted by the gateway.
+		argsJSON, _ := json.Marshal(args)
+		go persistToolResult(context.WithoutCancel(ctx), "get_statement_snapshot", serverNameFor(args.ConnectionString), string(argsJSON), result.Output)
+	}
+	return result, err
+}
+
+// ---------------------------------------------------------------------------
+// reset_statement_stats
+// ---------------------------------------------------------------------------
+
+// ResetStatementStatsArgs defines arguments for the reset_statement_stats tool.
+type ResetStatementStatsArgs struct {
+	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
+	Reason           string `json:"reason,omitempty" jsonschema:"Why the statistics are being reset, e.g. 'start a clean window after the index fix'. Shown to approvers and recorded in the audit trail."`
+}
+
+func resetStatementStatsImpl(ctx context.Context, args ResetStatementStatsArgs) (PsqlResult, error) {
+	ok, err := statementsInstalled(ctx, args.ConnectionString, "reset_statement_stats")
+	if err != nil {
+		return errorResult("reset_statement_stats", args.ConnectionString, err), nil
+	}
+	if !ok {
+		return PsqlResult{Output: statementsNotInstalled}, nil
+	}
+
+	serverName := serverNameFor(args.ConnectionString)
+	summary := fmt.Sprintf("reset pg_stat_statements on %s; the statement history collected so far is discarded", serverName)
+	note := summary
+	if args.Reason != "" {
+		note += "\nReason: " + args.Reason
+	}
+	ctx = agentutil.WithExecutionPlan(ctx, audit.NewExecutionPlan("reset_statement_stats", summary,
+		map[string]any{"database": serverName, "reason": args.Reason}))
+	if _, err := runPsqlAs(ctx, args.ConnectionString, `SELECT pg_stat_statements_reset();`,
+		"reset_statement_stats", policy.ActionWrite, note); err != nil {
+		return errorResult("reset_statement_stats", args.ConnectionString, err), nil
+	}
+	return PsqlResult{Output: fmt.Sprintf(
+		"pg_stat_statements statistics reset on %s. Counters start again from zero; "+
+			"take a labelled get_statement_snapshot to start a new baseline.", serverName)}, nil
+}
+
+func resetStatementStatsTool(ctx tool.Context, args ResetStatementStatsArgs) (PsqlResult, error) {
+	return resetStatementStatsImpl(ctx, args)
+}
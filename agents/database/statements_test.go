@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+const snapshotBefore = `=== Statement snapshot "before": top 20 statements by total execution time ===
+-[ RECORD 1 ]--+------------------------------------
+queryid        | 111
+query          | SELECT * FROM orders WHERE id = $1
+calls          | 1000
+total_ms       | 2000.00
+mean_ms        | 2.000
+rows           | 1000
+pct_total_time | 80.0
+-[ RECORD 2 ]--+------------------------------------
+queryid        | 222
+query          | UPDATE accounts SET balance = $1
+calls          | 100
+total_ms       | 500.00
+mean_ms        | 5.000
+rows           | 100
+pct_total_time | 20.0
+`
+
+const snapshotAfterRows = `-[ RECORD 1 ]--+------------------------------------
+queryid        | 111
+query          | SELECT * FROM orders WHERE id = $1
+calls          | 1500
+total_ms       | 27000.00
+mean_ms        | 18.000
+rows           | 1500
+pct_total_time | 95.0
+-[ RECORD 2 ]--+------------------------------------
+queryid        | 222
+query          | UPDATE accounts SET balance = $1
+calls          | 100
+total_ms       | 500.00
+mean_ms        | 5.000
+rows           | 100
+pct_total_time | 1.8
+-[ RECORD 3 ]--+------------------------------------
+queryid        | 333
+query          | SELECT count(*) FROM orders
+calls          | 3
+total_ms       | 900.00
+mean_ms        | 300.000
+rows           | 3
+pct_total_time | 3.2
+`
+
+func TestGetStatementSnapshot_CompareTo(t *testing.T) {
+	args, _ := json.Marshal(GetStatementSnapshotArgs{ConnectionString: "host=localhost", Label: "before"})
+	body, _ := json.Marshal(map[string]any{
+		"results": []*audit.PersistedToolResult{{
+			ToolName:   "get_statement_snapshot",
+			ToolArgs:   string(args),
+			Output:     snapshotBefore,
+			RecordedAt: time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC),
+		}},
+		"count": 1,
+	})
+	defer withMockAuditServer(http.StatusOK, string(body))()
+	defer withMockRunnerSequence(
+		psqlResponse{out: " 1\n(1 row)", err: nil},
+		psqlResponse{out: snapshotAfterRows, err: nil},
+	)()
+
+	result, err := getStatementSnapshotTool(newTestContext(), GetStatementSnapshotArgs{
+		ConnectionString: "host=localhost", Label: "after", CompareTo: "before",
+	})
+	if err != nil {
+		t.Fatalf("getStatementSnapshotTool() error = %v", err)
+	}
+	for _, want := range []string{
+		`=== Statement snapshot "after"`,
+		`=== Changes since snapshot "before" (recorded 2026-05-02 03:00:00 UTC) ===`,
+		"Since then: 503 calls, 25900.00 ms execution time across 2 statements.",
+		" 1. queryid 111: +500 calls, +25000.00 ms, 50.000 ms/call (was 2.000 ms/call)",
+		" 2. queryid 333: +3 calls, +900.00 ms, 300.000 ms/call (not in the baseline)",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+	if strings.Contains(result.Output, "queryid 222") {
+		t.Errorf("output lists a statement that did not run since the baseline:\n%s", result.Output)
+	}
+}
+
+func TestGetStatementSnapshot_BaselineMissing(t *testing.T) {
+	defer withMockAuditServer(http.StatusOK, `{"results": [], "count": 0}`)()
+	defer withMockRunnerSequence(
+		psqlResponse{out: " 1\n(1 row)", err: nil},
+		psqlResponse{out: snapshotAfterRows, err: nil},
+	)()
+
+	result, _ := getStatementSnapshotImpl(newTestContext(), GetStatementSnapshotArgs{
+		ConnectionString: "host=localhost", CompareTo: "before",
+	})
+	if !strings.Contains(result.Output, "queryid        | 333") {
+		t.Errorf("output = %q, want the snapshot itself", result.Output)
+	}
+	if !strings.Contains(result.Output, `no snapshot labelled "before"`) {
+		t.Errorf("output = %q, want the missing baseline reported", result.Output)
+	}
+}
+
+func TestGetStatementSnapshot_ExtensionMissing(t *testing.T) {
+	defer withMockRunnerSequence(
+		psqlResponse{out: "(0 rows)", err: nil},
+	)()
+
+	result, _ := getStatementSnapshotImpl(newTestContext(), GetStatementSnapshotArgs{ConnectionString: "host=localhost"})
+	if result.Output != statementsNotInstalled {
+		t.Errorf("output = %q, want extension-not-installed message", result.Output)
+	}
+}
+
+func TestDiffStatements_Reset(t *testing.T) {
+	before := map[string]statementStat{"111": {QueryID: "111", Calls: 1000, TotalMs: 2000}}
+	after := map[string]statementStat{"111": {QueryID: "111", Calls: 10, TotalMs: 40}}
+
+	deltas, reset := diffStatements(before, after)
+	if !reset {
+		t.Error("reset = false, want the lower counters detected as a reset")
+	}
+	if len(deltas) != 1 || deltas[0].Calls != 10 || deltas[0].TotalMs != 40 {
+		t.Errorf("deltas = %+v, want the current counters", deltas)
+	}
+}
+
+func TestResetStatementStats(t *testing.T) {
+	defer withMockRunnerSequence(
+		psqlResponse{out: " 1\n(1 row)", err: nil},
+		psqlResponse{out: "-[ RECORD 1 ]-----------+-\npg_stat_statements_reset | ", err: nil},
+	)()
+
+	result, _ := resetStatementStatsImpl(newTestContext(), ResetStatementStatsArgs{ConnectionString: "host=localhost", Reason: "clean window"})
+	if !strings.Contains(result.Output, "statistics reset on host=localhost") {
+		t.Errorf("output = %q, want the reset confirmed", result.Output)
+	}
+}
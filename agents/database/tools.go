@@ -7,8 +7,6 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
-	"os/exec"
 	"regexp"
 	"sort"
 	"strconv"
@@ -37,7 +35,7 @@ func patchEphemeralDB(serverID string, server infra.DBServer) {
 	ephemeralDBsMu.Lock()
 	defer ephemeralDBsMu.Unlock()
 	ephemeralDBs[serverID] = server
-	slog.Info("ephemeral DB registered", "server_id", serverID, "connection_string", server.ConnectionString)
+	slog.Info("ephemeral DB registered", "server_id", serverID, "connection_string", maskPassword(server.ConnectionString))
 }
 
 // toolAuditor is set during initialization if auditing is enabled.
@@ -70,14 +68,33 @@ var verifyTerminateConfig = retryutil.Config{
 }
 
 // databaseInfo holds resolved database information for policy checks.
+// ConnectionStr carries the resolved password and is only ever handed to psql;
+// AuditConnStr is the password-free form recorded in audit events.
 type databaseInfo struct {
 	Name              string
 	ConnectionStr     string
+	AuditConnStr      string
 	Tags              []string
 	Sensitivity       []string
 	IsFromInfraConfig bool
 }
 
+// auditConnStr returns the connection string as recorded in audit events.
+func (d databaseInfo) auditConnStr() string {
+	return maskPassword(d.AuditConnStr)
+}
+
+// auditArgv returns the psql argv recorded in the tool_execution event, with
+// the resolved connection string swapped for its password-free form so a
+// secret never reaches the audit pipeline, even before redaction.
+func (d databaseInfo) auditArgv(args []string) []string {
+	a := append([]string(nil), args...)
+	if len(a) > 0 && d.ConnectionStr != "" && a[0] == d.ConnectionStr {
+		a[0] = d.auditConnStr()
+	}
+	return agentutil.AuditArgv("psql", a)
+}
+
 // connStrCoreFields parses a libpq key=value connection string and returns only
 // the non-sensitive, non-mode fields used for identity matching (host, port, dbname, user).
 func connStrCoreFields(s string) map[string]string {
@@ -120,6 +137,10 @@ func connStrCoreFieldsMatch(infraConn string, inputCore map[string]string) bool
 // When infraConfig is set and the database is not registered, returns an error
 // (hard reject) so callers can fail before any tool execution.
 func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
+	infraConfigMu.RLock()
+	ic := infraConfig
+	infraConfigMu.RUnlock()
+
 	connStrOrName = strings.TrimSpace(connStrOrName)
 
 	// If it contains "=" it's already a connection string
@@ -136,24 +157,19 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 		// Reverse lookup: find which infraConfig entry has this connection string.
 		// Compare core fields only (host/port/dbname/user) so that extra fields in
 		// the input (password=, sslmode=) or in the infra entry do not break the match.
-		if infraConfig != nil {
+		if ic != nil {
+			// Registered databases are addressed by alias only: a raw connection
+			// string would carry credentials through prompts and audit events.
 			inputCore := connStrCoreFields(connStrOrName)
-			for id, db := range infraConfig.DBServers {
-				slog.Debug("reverse lookup comparing", "id", id, "db_conn", db.ConnectionString, "input_conn", connStrOrName, "tags", db.Tags)
+			for id, db := range ic.DBServers {
 				if connStrCoreFieldsMatch(db.ConnectionString, inputCore) {
-					slog.Debug("reverse resolved connection string to database", "id", id, "tags", db.Tags)
-					return databaseInfo{
-						Name:              id,
-						ConnectionStr:     db.ResolvedConnectionString(),
-						Tags:              db.Tags,
-						Sensitivity:       db.Sensitivity,
-						IsFromInfraConfig: true,
-					}, nil
+					return databaseInfo{}, aliasRequiredError(id)
 				}
 			}
 			// Exact match failed — try host field as an infra ID. Handles the case
 			// where the LLM builds a DSN using the alias as the hostname
-			// (e.g. host=pg-cluster-minkube) instead of the stored FQDN.
+			// (e.g. host=pg-cluster-minkube) instead of the stored FQDN; the
+			// error points it at the alias.
 			inputHost := ""
 			for _, part := range strings.Split(connStrOrName, " ") {
 				if strings.HasPrefix(part, "host=") {
@@ -162,18 +178,13 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 				}
 			}
 			if inputHost != "" {
-				if db, ok := infraConfig.DBServers[inputHost]; ok {
-					slog.Debug("resolved connection string via host-as-infra-id", "id", inputHost)
-					return databaseInfo{
-						Name:              inputHost,
-						ConnectionStr:     db.ResolvedConnectionString(),
-						Tags:              db.Tags,
-						Sensitivity:       db.Sensitivity,
-						IsFromInfraConfig: true,
-					}, nil
+				if _, ok := ic.DBServers[inputHost]; ok {
+					return databaseInfo{}, aliasRequiredError(inputHost)
 				}
 			}
-			// infraConfig is set but connection string not registered — check ephemeral registry.
+			// infraConfig is set but connection string not registered — check ephemeral
+			// registry. Ephemeral entries are registered at runtime with their full
+			// connection string (faulttest --auto-db), so they still match on it.
 			ephemeralDBsMu.RLock()
 			for id, db := range ephemeralDBs {
 				if db.ConnectionString == connStrOrName {
@@ -182,6 +193,7 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 					return databaseInfo{
 						Name:              id,
 						ConnectionStr:     db.ResolvedConnectionString(),
+						AuditConnStr:      db.ConnectionString,
 						Tags:              db.Tags,
 						IsFromInfraConfig: true,
 					}, nil
@@ -189,8 +201,8 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 			}
 			ephemeralDBsMu.RUnlock()
 			// Hard reject: not in infra config or ephemeral registry.
-			known := make([]string, 0, len(infraConfig.DBServers))
-			for id := range infraConfig.DBServers {
+			known := make([]string, 0, len(ic.DBServers))
+			for id := range ic.DBServers {
 				known = append(known, id)
 			}
 			sort.Strings(known)
@@ -201,24 +213,30 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 		}
 
 		slog.Warn("connection string not found in infraConfig; policy will evaluate with no tags",
-			"connection_string", connStrOrName,
+			"connection_string", maskPassword(connStrOrName),
 			"known_databases", 0,
 		)
 		return databaseInfo{
 			Name:          dbName,
 			ConnectionStr: connStrOrName,
+			AuditConnStr:  connStrOrName,
 			Tags:          nil, // No tags - connection string not in infraConfig
 		}, nil
 	}
 
 	// If we have infrastructure config, try to look up the database name
-	if infraConfig != nil {
-		if db, ok := infraConfig.DBServers[connStrOrName]; ok {
+	if ic != nil {
+		if db, ok := ic.DBServers[connStrOrName]; ok {
+			resolved, err := ic.ResolveConnectionString(context.Background(), db)
+			if err != nil {
+				return databaseInfo{}, fmt.Errorf("database %q: %w", connStrOrName, err)
+			}
 			slog.Info("resolved database name to connection string", "name", connStrOrName)
 			return databaseInfo{
 				Name:              connStrOrName,
-				ConnectionStr:     db.ResolvedConnectionString(),
-				Tags:              db.Tags,
+				ConnectionStr:     resolved,
+				AuditConnStr:      db.ConnectionString,
+				Tags:              ic.DatabaseTags(db),
 				Sensitivity:       db.Sensitivity,
 				IsFromInfraConfig: true,
 			}, nil
@@ -231,14 +249,15 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 			return databaseInfo{
 				Name:              connStrOrName,
 				ConnectionStr:     db.ResolvedConnectionString(),
+				AuditConnStr:      db.ConnectionString,
 				Tags:              db.Tags,
 				IsFromInfraConfig: true,
 			}, nil
 		}
 		ephemeralDBsMu.RUnlock()
 		// Hard reject: not in infra config or ephemeral registry.
-		known := make([]string, 0, len(infraConfig.DBServers))
-		for id := range infraConfig.DBServers {
+		known := make([]string, 0, len(ic.DBServers))
+		for id := range ic.DBServers {
 			known = append(known, id)
 		}
 		sort.Strings(known)
@@ -252,28 +271,61 @@ func resolveDatabaseInfo(connStrOrName string) (databaseInfo, error) {
 	return databaseInfo{
 		Name:          connStrOrName,
 		ConnectionStr: connStrOrName,
+		AuditConnStr:  connStrOrName,
 	}, nil
 }
 
-// CommandRunner abstracts command execution for testing.
-type CommandRunner interface {
-	Run(ctx context.Context, name string, args []string, env []string) (string, error)
-}
-
-// execRunner is the production implementation that calls os/exec.
-type execRunner struct{}
-
-func (execRunner) Run(ctx context.Context, name string, args []string, env []string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	if len(env) > 0 {
-		cmd.Env = append(os.Environ(), env...)
-	}
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+// aliasRequiredError rejects a raw connection string that matches a registered
+// database, naming the alias the caller should use instead.
+func aliasRequiredError(alias string) error {
+	return fmt.Errorf("raw connection strings are not accepted when infrastructure config is loaded; "+
+		"pass the database alias %q as connection_string instead", alias)
+}
+
+// psqlSandbox is this agent's command allowlist: psql, with exactly the flags
+// the tools below pass, and the read-only commands of the backup tools. The
+// connection string is the sole positional argument and the query always
+// travels as the value of -c, so an LLM-supplied value can never be
+// interpreted as a psql option. Backup tool arguments come from the
+// infrastructure config only.
+var psqlSandbox = map[string]agentutil.CommandSpec{
+	"psql": {
+		Flags: map[string]bool{
+			"-w": false, // never prompt for a password
+			"-x": false, // expanded output
+			"-t": false, // tuples only
+			"-A": false, // unaligned
+			"-c": true,  // the query
+		},
+		Timeout:        2 * time.Minute,
+		MaxOutputBytes: 4 << 20,
+	},
+	"pgbackrest": {
+		Flags: map[string]bool{
+			"--stanza": true,
+			"--config": true,
+			"--output": true,
+		},
+		Subcommands:    []string{"info", "verify"},
+		Timeout:        2 * time.Minute,
+		ToolTimeouts:   map[string]time.Duration{"verify_backup_repo": 30 * time.Minute},
+		MaxOutputBytes: 4 << 20,
+	},
+	"wal-g": {
+		Flags: map[string]bool{
+			"--json":   false,
+			"--detail": false,
+			"--config": true,
+		},
+		Subcommands:    []string{"backup-list", "wal-verify"},
+		Timeout:        2 * time.Minute,
+		ToolTimeouts:   map[string]time.Duration{"verify_backup_repo": 30 * time.Minute},
+		MaxOutputBytes: 4 << 20,
+	},
 }
 
 // cmdRunner is the active command runner. Override in tests.
-var cmdRunner CommandRunner = execRunner{}
+var cmdRunner agentutil.CommandRunner = agentutil.NewSandboxRunner(psqlSandbox)
 
 // diagnosePsqlError examines psql output for common failure patterns and returns
 // a clear, actionable error message alongside the raw output.
@@ -319,6 +371,11 @@ func diagnosePsqlError(output string) string {
 	}
 }
 
+// psqlApplicationName is the application_name of the agent's connections
+// (unless the connection string sets its own), so that database logs tell the
+// agent's statements apart from other use of the same credentials.
+const psqlApplicationName = "helpdesk_database_agent"
+
 // runPsql executes a psql command and returns the output.
 // The provided ctx controls cancellation — if it expires, psql is killed.
 // If connStr looks like a database name (no "=" sign), it will be resolved
@@ -396,10 +453,16 @@ func runPsqlAs(ctx context.Context, connStr string, query string, toolName strin
 		// ("canceling statement due to lock timeout") that the agent can reason about,
 		// rather than the query hanging until the overall context deadline fires.
 		"PGOPTIONS=-c lock_timeout=10000",
+		"PGAPPNAME=" + psqlApplicationName,
 	}
-	output, err := cmdRunner.Run(ctx, "psql", args, env)
+	output, err := cmdRunner.Run(agentutil.WithToolName(ctx, toolName), "psql", args, env)
 	duration := time.Since(start)
 
+	var rowsAffected int
+	if err == nil {
+		rowsAffected = parseRowsAffected(output)
+	}
+
 	// Audit the tool execution
 	if toolAuditor != nil && toolName != "" {
 		var errMsg string
@@ -408,18 +471,19 @@ func runPsqlAs(ctx context.Context, connStr string, query string, toolName strin
 		}
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       toolName,
-			Parameters: map[string]any{"connection_string": maskPassword(connStr)},
+			Parameters: map[string]any{"connection_string": dbInfo.auditConnStr()},
 			RawCommand: query,
+			Argv:       dbInfo.auditArgv(args),
 		}, audit.ToolResult{
-			Output: truncateForAudit(output, 500),
-			Error:  errMsg,
+			Output:       truncateForAudit(output, 500),
+			Error:        errMsg,
+			RowsAffected: rowsAffected,
 		}, duration)
 	}
 
 	// Post-execution policy check: enforce blast-radius conditions using the
 	// actual row count from the command tag (e.g. "DELETE 1500").
 	if policyEnforcer != nil && err == nil {
-		rowsAffected := parseRowsAffected(output)
 		if postErr := policyEnforcer.CheckDatabaseResult(ctx, dbInfo.Name, action, dbInfo.Tags, agentutil.ToolOutcome{
 			RowsAffected: rowsAffected,
 			Err:          err,
@@ -552,7 +616,7 @@ func estimateRowsAffected(ctx context.Context, connStr, query string) (int, bool
 	if connStr != "" {
 		args = append([]string{connStr}, args...)
 	}
-	out, err := cmdRunner.Run(ctx, "psql", args, []string{"PGCONNECT_TIMEOUT=10", "PGOPTIONS=-c lock_timeout=10000"})
+	out, err := cmdRunner.Run(ctx, "psql", args, []string{"PGCONNECT_TIMEOUT=10", "PGOPTIONS=-c lock_timeout=10000", "PGAPPNAME=" + psqlApplicationName})
 	if err != nil {
 		return 0, false
 	}
@@ -571,34 +635,34 @@ func estimateRowsAffected(ctx context.Context, connStr, query string) (int, bool
 // destructive operation. It is returned by get_session_info and used as the
 // pre-execution plan step inside terminate_connection and cancel_query.
 type ConnectionPlan struct {
-	PID               int
-	User              string
-	Database          string
-	ClientAddr        string
-	State             string // "idle", "active", "idle in transaction", ...
-	StateDurationSecs int
+	PID               int    `json:"pid"`
+	User              string `json:"user"`
+	Database          string `json:"database"`
+	ClientAddr        string `json:"client_addr"`
+	State             string `json:"state"` // "idle", "active", "idle in transaction", ...
+	StateDurationSecs int    `json:"state_duration_secs"`
 
 	// Transaction state
-	HasOpenTransaction bool
-	OpenTxAgeSecs      int
+	HasOpenTransaction bool `json:"has_open_transaction"`
+	OpenTxAgeSecs      int  `json:"open_tx_age_secs,omitempty"`
 
 	// Uncommitted-work signals — what would be rolled back on termination.
 	// backend_xid IS NOT NULL is the definitive indicator that at least one
 	// write has occurred; read-only transactions have a NULL xid and roll back
 	// instantly. WAL bytes per backend are not exposed by PostgreSQL, so
 	// transaction age is the primary proxy for rollback cost.
-	HasWrites    bool     // false → read-only tx, rollback is instant
-	TotalLocks   int      // all granted locks held by this backend
-	RowLocks     int      // tuple-level (row-level) locks only
-	LockedTables []string // table names with any lock held
+	HasWrites    bool     `json:"has_writes"`              // false → read-only tx, rollback is instant
+	TotalLocks   int      `json:"total_locks"`             // all granted locks held by this backend
+	RowLocks     int      `json:"row_locks"`               // tuple-level (row-level) locks only
+	LockedTables []string `json:"locked_tables,omitempty"` // table names with any lock held
 
 	// Rollback time estimate (rule of thumb: 0.5× to 2× TX write duration).
 	// Both fields are 0 when HasWrites is false.
-	RollbackMinSecs int
-	RollbackMaxSecs int
+	RollbackMinSecs int `json:"rollback_min_secs,omitempty"`
+	RollbackMaxSecs int `json:"rollback_max_secs,omitempty"`
 
 	// Context
-	CurrentQuery string
+	CurrentQuery string `json:"current_query,omitempty"`
 }
 
 // parseExpandedRow parses a single record from psql -x (expanded) output into
@@ -786,6 +850,28 @@ func formatConnectionPlan(plan ConnectionPlan) string {
 	return b.String()
 }
 
+// executionPlan summarises what the operation will do to the inspected session
+// for the approver: whose session it is and what would be rolled back.
+func (plan ConnectionPlan) executionPlan(operation string) *audit.ExecutionPlan {
+	verb := "terminate"
+	if operation == "cancel_query" {
+		verb = "cancel the query of"
+	}
+	summary := fmt.Sprintf("%s PID %d (user %s on %s, %s)", verb, plan.PID, plan.User, plan.Database, plan.State)
+	switch {
+	case !plan.HasOpenTransaction:
+		summary += "; no open transaction"
+	case !plan.HasWrites:
+		summary += "; read-only transaction, rollback is instant"
+	default:
+		summary += fmt.Sprintf("; rolls back %s of writes (~%s to ~%s)",
+			formatDuration(plan.OpenTxAgeSecs),
+			formatDuration(plan.RollbackMinSecs),
+			formatDuration(plan.RollbackMaxSecs))
+	}
+	return audit.NewExecutionPlan(operation, summary, plan)
+}
+
 // PsqlResult is the standard output type for all psql tools.
 type PsqlResult struct {
 	Output       string `json:"output"`
@@ -1210,7 +1296,7 @@ func getTableStatsTool(ctx tool.Context, args GetTableStatsArgs) (PsqlResult, er
 // GetSessionInfoArgs defines arguments for the get_session_info tool.
 type GetSessionInfoArgs struct {
 	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
-	PID              int    `json:"pid" jsonschema:"required,The process ID of the session to inspect."`
+	PID              int    `json:"pid" jsonschema:"required,The process ID of the session to inspect." validate:"min=1"`
 }
 
 // getSessionInfoImpl inspects a specific backend PID and returns its current
@@ -1231,7 +1317,7 @@ func getSessionInfoTool(ctx tool.Context, args GetSessionInfoArgs) (PsqlResult,
 // CancelQueryArgs defines arguments for the cancel_query tool.
 type CancelQueryArgs struct {
 	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
-	PID              int    `json:"pid" jsonschema:"required,The process ID (pid) of the backend to cancel. Use get_active_connections to find pids."`
+	PID              int    `json:"pid" jsonschema:"required,The process ID (pid) of the backend to cancel. Use get_active_connections to find pids." validate:"min=1"`
 }
 
 func cancelQueryImpl(ctx context.Context, args CancelQueryArgs) (PsqlResult, error) {
@@ -1256,6 +1342,7 @@ func cancelQueryImpl(ctx context.Context, args CancelQueryArgs) (PsqlResult, err
 	// Step 2: cancel the query (policy pre-check happens inside runPsqlAs).
 	query := fmt.Sprintf(`SELECT pg_cancel_backend(%d) AS cancelled, pid, usename, datname, state, LEFT(query, 100) AS query_preview
 FROM pg_stat_activity WHERE pid = %d;`, args.PID, args.PID)
+	ctx = agentutil.WithExecutionPlan(ctx, plan.executionPlan("cancel_query"))
 	output, err := runPsqlAs(ctx, args.ConnectionString, query, "cancel_query", policy.ActionWrite, formatConnectionPlan(plan))
 	if err != nil {
 		return errorResult("cancel_query", args.ConnectionString, err), nil
@@ -1339,7 +1426,7 @@ func cancelQueryTool(ctx tool.Context, args CancelQueryArgs) (PsqlResult, error)
 // TerminateConnectionArgs defines arguments for the terminate_connection tool.
 type TerminateConnectionArgs struct {
 	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
-	PID              int    `json:"pid" jsonschema:"required,The process ID (pid) of the backend to terminate. Use get_active_connections to find pids."`
+	PID              int    `json:"pid" jsonschema:"required,The process ID (pid) of the backend to terminate. Use get_active_connections to find pids." validate:"min=1"`
 }
 
 func terminateConnectionImpl(ctx context.Context, args TerminateConnectionArgs) (PsqlResult, error) {
@@ -1364,6 +1451,7 @@ func terminateConnectionImpl(ctx context.Context, args TerminateConnectionArgs)
 	// Step 2: terminate the connection (policy pre-check happens inside runPsqlAs).
 	query := fmt.Sprintf(`SELECT pg_terminate_backend(%d) AS terminated, pid, usename, datname, state, LEFT(query, 100) AS query_preview
 FROM pg_stat_activity WHERE pid = %d;`, args.PID, args.PID)
+	ctx = agentutil.WithExecutionPlan(ctx, plan.executionPlan("terminate_connection"))
 	output, err := runPsqlAs(ctx, args.ConnectionString, query, "terminate_connection", policy.ActionDestructive, formatConnectionPlan(plan))
 	if err != nil {
 		return errorResult("terminate_connection", args.ConnectionString, err), nil
@@ -1448,16 +1536,12 @@ func terminateConnectionTool(ctx tool.Context, args TerminateConnectionArgs) (Ps
 // TerminateIdleConnectionsArgs defines arguments for the terminate_idle_connections tool.
 type TerminateIdleConnectionsArgs struct {
 	ConnectionString string `json:"connection_string,omitempty" jsonschema:"PostgreSQL connection string. If empty, uses environment defaults."`
-	IdleMinutes      int    `json:"idle_minutes" jsonschema:"required,Terminate connections idle longer than this many minutes. Use 0 to terminate all idle connections regardless of age (no minimum). The default recommended minimum is 5 to protect legitimate short-lived connections; use 0 only when an explicit connection overload requires terminating all idle sessions."`
+	IdleMinutes      int    `json:"idle_minutes" jsonschema:"required,Terminate connections idle longer than this many minutes. Use 0 to terminate all idle connections regardless of age (no minimum). The default recommended minimum is 5 to protect legitimate short-lived connections; use 0 only when an explicit connection overload requires terminating all idle sessions." validate:"min=0"`
 	Database         string `json:"database,omitempty" jsonschema:"Limit termination to connections in this specific database. If empty, targets all databases."`
 	DryRun           bool   `json:"dry_run,omitempty" jsonschema:"If true, only list connections that would be terminated without actually terminating them. Defaults to false."`
 }
 
 func terminateIdleConnectionsImpl(ctx context.Context, args TerminateIdleConnectionsArgs) (PsqlResult, error) {
-	if args.IdleMinutes < 0 {
-		return PsqlResult{Output: "ERROR: idle_minutes must be 0 or greater (0 = no age filter)."}, nil
-	}
-
 	dbFilter := ""
 	if args.Database != "" {
 		dbFilter = fmt.Sprintf("AND datname = '%s'", strings.ReplaceAll(args.Database, "'", "''"))
@@ -1839,16 +1923,12 @@ func getSlowQueriesImpl(ctx context.Context, args GetSlowQueriesArgs) (PsqlResul
 		limit = 10
 	}
 
-	// Pre-check: verify pg_stat_statements is installed before querying it.
-	// This avoids a noisy ERROR log from a known-benign missing-extension case.
-	checkOut, err := runPsqlWithToolName(ctx, args.ConnectionString,
-		`SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements';`,
-		"get_slow_queries")
+	ok, err := statementsInstalled(ctx, args.ConnectionString, "get_slow_queries")
 	if err != nil {
 		return errorResult("get_slow_queries", args.ConnectionString, err), nil
 	}
-	if !strings.Contains(checkOut, "1") {
-		return PsqlResult{Output: "pg_stat_statements extension is not installed. Enable it with: CREATE EXTENSION pg_stat_statements; and add it to shared_preload_libraries in postgresql.conf."}, nil
+	if !ok {
+		return PsqlResult{Output: statementsNotInstalled}, nil
 	}
 
 	query := fmt.Sprintf(`SELECT
@@ -2321,7 +2401,7 @@ func runPsqlTuples(ctx context.Context, connStr string, query string, toolName s
 	if dbInfo.ConnectionStr != "" {
 		psqlArgs = append([]string{dbInfo.ConnectionStr}, psqlArgs...)
 	}
-	output, err := cmdRunner.Run(ctx, "psql", psqlArgs, []string{"PGCONNECT_TIMEOUT=10", "PGOPTIONS=-c lock_timeout=10000"})
+	output, err := cmdRunner.Run(agentutil.WithToolName(ctx, toolName), "psql", psqlArgs, []string{"PGCONNECT_TIMEOUT=10", "PGOPTIONS=-c lock_timeout=10000", "PGAPPNAME=" + psqlApplicationName})
 	duration := time.Since(start)
 
 	if toolAuditor != nil {
@@ -2331,8 +2411,9 @@ func runPsqlTuples(ctx context.Context, connStr string, query string, toolName s
 		}
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       toolName,
-			Parameters: map[string]any{"connection_string": maskPassword(connStr)},
+			Parameters: map[string]any{"connection_string": dbInfo.auditConnStr()},
 			RawCommand: query,
+			Argv:       dbInfo.auditArgv(psqlArgs),
 		}, audit.ToolResult{
 			Output: truncateForAudit(output, 500),
 			Error:  errMsg,
@@ -2543,39 +2624,12 @@ func getSavedSnapshotsImpl(ctx context.Context, args GetSavedSnapshotsArgs) (Psq
 		since = "90d"
 	}
 
-	u := strings.TrimSuffix(auditBaseURL, "/") + "/v1/tool-results"
-	u += fmt.Sprintf("?tool=%s&limit=%d&since=%s", args.ToolName, limit, since)
-	if args.ServerName != "" {
-		u += "&server=" + args.ServerName
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return PsqlResult{Output: fmt.Sprintf("get_saved_snapshots ERROR: failed to build request: %v", err)}, nil
-	}
-	if auditAPIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+auditAPIKey)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+	results, err := fetchSavedToolResults(ctx, args.ToolName, args.ServerName, limit, since)
 	if err != nil {
-		return PsqlResult{Output: fmt.Sprintf("get_saved_snapshots ERROR: auditd unreachable: %v", err)}, nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return PsqlResult{Output: fmt.Sprintf("get_saved_snapshots ERROR: auditd returned status %d", resp.StatusCode)}, nil
+		return PsqlResult{Output: fmt.Sprintf("get_saved_snapshots ERROR: %v", err)}, nil
 	}
 
-	var body struct {
-		Results []*audit.PersistedToolResult `json:"results"`
-		Count   int                          `json:"count"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return PsqlResult{Output: fmt.Sprintf("get_saved_snapshots ERROR: failed to parse response: %v", err)}, nil
-	}
-
-	if body.Count == 0 {
+	if len(results) == 0 {
 		msg := fmt.Sprintf("get_saved_snapshots: no saved results found for tool %q", args.ToolName)
 		if args.ServerName != "" {
 			msg += fmt.Sprintf(" on server %q", args.ServerName)
@@ -2585,13 +2639,13 @@ func getSavedSnapshotsImpl(ctx context.Context, args GetSavedSnapshotsArgs) (Psq
 	}
 
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "=== %d saved snapshot(s) for tool %q", body.Count, args.ToolName)
+	fmt.Fprintf(&sb, "=== %d saved snapshot(s) for tool %q", len(results), args.ToolName)
 	if args.ServerName != "" {
 		fmt.Fprintf(&sb, " on %q", args.ServerName)
 	}
 	fmt.Fprintf(&sb, " (most recent first) ===\n\n")
 
-	for i, r := range body.Results {
+	for i, r := range results {
 		fmt.Fprintf(&sb, "── Snapshot %d ─ recorded: %s UTC ─ server: %s ─ by: %s ──\n",
 			i+1, r.RecordedAt.UTC().Format("2006-01-02 15:04:05"), r.ServerName, r.RecordedBy)
 		sb.WriteString(r.Output)
@@ -2601,7 +2655,7 @@ func getSavedSnapshotsImpl(ctx context.Context, args GetSavedSnapshotsArgs) (Psq
 		sb.WriteByte('\n')
 
 		if sb.Len() > maxSnapshotOutputBytes {
-			fmt.Fprintf(&sb, "[output truncated — %d of %d snapshots shown]\n", i+1, body.Count)
+			fmt.Fprintf(&sb, "[output truncated — %d of %d snapshots shown]\n", i+1, len(results))
 			break
 		}
 	}
@@ -2613,271 +2667,85 @@ func getSavedSnapshotsTool(ctx tool.Context, args GetSavedSnapshotsArgs) (PsqlRe
 	return getSavedSnapshotsImpl(ctx, args)
 }
 
+// fetchSavedToolResults lists the persisted outputs of toolName from auditd,
+// most recent first. serverName may be empty to match every server.
+func fetchSavedToolResults(ctx context.Context, toolName, serverName string, limit int, since string) ([]*audit.PersistedToolResult, error) {
+	u := strings.TrimSuffix(auditBaseURL, "/") + "/v1/tool-results"
+	u += fmt.Sprintf("?tool=%s&limit=%d&since=%s", toolName, limit, since)
+	if serverName != "" {
+		u += "&server=" + serverName
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	if auditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+auditAPIKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auditd unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auditd returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Results []*audit.PersistedToolResult `json:"results"`
+		Count   int                          `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return body.Results, nil
+}
+
 func NewDatabaseDirectRegistry() *agentutil.DirectToolRegistry {
 	r := agentutil.NewDirectToolRegistry()
-	r.Register("check_connection", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[CheckConnectionArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := checkConnectionImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_server_info", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetServerInfoArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getServerInfoImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_database_info", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetDatabaseInfoArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getDatabaseInfoImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_active_connections", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetActiveConnectionsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getActiveConnectionsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_connection_stats", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetConnectionStatsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getConnectionStatsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_database_stats", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetDatabaseStatsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getDatabaseStatsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_config_parameter", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetConfigParameterArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getConfigParameterImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_replication_status", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetReplicationStatusArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getReplicationStatusImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_lock_info", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetLockInfoArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getLockInfoImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_table_stats", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetTableStatsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getTableStatsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_session_info", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetSessionInfoArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getSessionInfoImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("cancel_query", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[CancelQueryArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := cancelQueryImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("terminate_connection", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[TerminateConnectionArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := terminateConnectionImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("terminate_idle_connections", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[TerminateIdleConnectionsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := terminateIdleConnectionsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_status_summary", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetStatusSummaryArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getStatusSummaryImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_pg_settings", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetPgSettingsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getPgSettingsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_extensions", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetExtensionsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getExtensionsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_baseline", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetBaselineArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getBaselineImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_slow_queries", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetSlowQueriesArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getSlowQueriesImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_vacuum_status", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetVacuumStatusArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getVacuumStatusImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_disk_usage", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetDiskUsageArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getDiskUsageImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_bgwriter_stats", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetBgwriterStatsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getBgwriterStatsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_wait_events", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetWaitEventsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getWaitEventsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_blocking_queries", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetBlockingQueriesArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getBlockingQueriesImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("explain_query", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[ExplainQueryArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := explainQueryImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("read_pg_log", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetPgLogArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getPgLogImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("read_uploaded_file", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[ReadUploadedFileArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := readUploadedFileImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("get_saved_snapshots", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[GetSavedSnapshotsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := getSavedSnapshotsImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("resume_wal_replay", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[ResumeWalReplayArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := resumeWalReplayImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("run_vacuum", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[RunVacuumArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := runVacuumImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("drop_replication_slot", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[DropReplicationSlotArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := dropReplicationSlotImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("reset_pg_setting", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[ResetPgSettingArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := resetPgSettingImpl(ctx, a)
-		return result.Output, nil
-	})
-	r.Register("reset_cache_stats", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := argsToStruct[ResetCacheStatsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, _ := resetCacheStatsImpl(ctx, a)
-		return result.Output, nil
-	})
+	r.RegisterStructured("check_connection", psqlTool(checkConnectionImpl))
+	r.RegisterStructured("get_server_info", psqlTool(getServerInfoImpl))
+	r.RegisterStructured("get_database_info", psqlTool(getDatabaseInfoImpl))
+	r.RegisterStructured("get_active_connections", psqlTool(getActiveConnectionsImpl))
+	r.RegisterStructured("get_connection_stats", psqlTool(getConnectionStatsImpl))
+	r.RegisterStructured("get_database_stats", psqlTool(getDatabaseStatsImpl))
+	r.RegisterStructured("get_config_parameter", psqlTool(getConfigParameterImpl))
+	r.RegisterStructured("get_replication_status", psqlTool(getReplicationStatusImpl))
+	r.RegisterStructured("get_lock_info", psqlTool(getLockInfoImpl))
+	r.RegisterStructured("get_table_stats", psqlTool(getTableStatsImpl))
+	r.RegisterStructured("get_session_info", psqlTool(getSessionInfoImpl))
+	r.RegisterStructured("cancel_query", psqlTool(cancelQueryImpl))
+	r.RegisterStructured("terminate_connection", psqlTool(terminateConnectionImpl))
+	r.RegisterStructured("terminate_idle_connections", psqlTool(terminateIdleConnectionsImpl))
+	r.RegisterStructured("get_status_summary", psqlTool(getStatusSummaryImpl))
+	r.RegisterStructured("get_pg_settings", psqlTool(getPgSettingsImpl))
+	r.RegisterStructured("get_extensions", psqlTool(getExtensionsImpl))
+	r.RegisterStructured("get_baseline", psqlTool(getBaselineImpl))
+	r.RegisterStructured("get_slow_queries", psqlTool(getSlowQueriesImpl))
+	r.RegisterStructured("get_vacuum_status", psqlTool(getVacuumStatusImpl))
+	r.RegisterStructured("get_disk_usage", psqlTool(getDiskUsageImpl))
+	r.RegisterStructured("get_bgwriter_stats", psqlTool(getBgwriterStatsImpl))
+	r.RegisterStructured("get_wait_events", psqlTool(getWaitEventsImpl))
+	r.RegisterStructured("get_blocking_queries", psqlTool(getBlockingQueriesImpl))
+	r.RegisterStructured("explain_query", psqlTool(explainQueryImpl))
+	r.RegisterStructured("read_pg_log", psqlTool(getPgLogImpl))
+	r.RegisterStructured("read_uploaded_file", psqlTool(readUploadedFileImpl))
+	r.RegisterStructured("get_saved_snapshots", psqlTool(getSavedSnapshotsImpl))
+	r.RegisterStructured("resume_wal_replay", psqlTool(resumeWalReplayImpl))
+	r.RegisterStructured("run_vacuum", psqlTool(runVacuumImpl))
+	r.RegisterStructured("drop_replication_slot", psqlTool(dropReplicationSlotImpl))
+	r.RegisterStructured("reset_pg_setting", psqlTool(resetPgSettingImpl))
+	r.RegisterStructured("reset_cache_stats", psqlTool(resetCacheStatsImpl))
+	r.RegisterStructured("get_patroni_status", psqlTool(getPatroniStatusImpl))
+	r.RegisterStructured("trigger_switchover", psqlTool(triggerSwitchoverImpl))
+	r.RegisterStructured("pause_autofailover", psqlTool(pauseAutofailoverImpl))
+	r.RegisterStructured("resume_autofailover", psqlTool(resumeAutofailoverImpl))
+	r.RegisterStructured("get_statement_snapshot", psqlTool(getStatementSnapshotImpl))
+	r.RegisterStructured("reset_statement_stats", psqlTool(resetStatementStatsImpl))
+	r.RegisterStructured("get_backup_status", psqlTool(getBackupStatusImpl))
+	r.RegisterStructured("verify_backup_repo", psqlTool(verifyBackupRepoImpl))
 	return r
 }
@@ -1186,6 +1186,33 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestConnectionPlan_ExecutionPlan(t *testing.T) {
+	plan := ConnectionPlan{
+		PID: 4242, User: "app", Database: "orders", State: "idle in transaction",
+		HasOpenTransaction: true, OpenTxAgeSecs: 120, HasWrites: true,
+		RowLocks: 3, LockedTables: []string{"orders"},
+		RollbackMinSecs: 60, RollbackMaxSecs: 240,
+	}
+	ep := plan.executionPlan("terminate_connection")
+	if ep.Operation != "terminate_connection" {
+		t.Errorf("Operation = %q", ep.Operation)
+	}
+	for _, want := range []string{"terminate PID 4242", "user app on orders", "rolls back 2m 0s of writes"} {
+		if !strings.Contains(ep.Summary, want) {
+			t.Errorf("Summary = %q, want to contain %q", ep.Summary, want)
+		}
+	}
+	if ep.Details["row_locks"] != float64(3) || ep.Details["database"] != "orders" {
+		t.Errorf("Details = %v, want the ConnectionPlan fields", ep.Details)
+	}
+
+	idle := ConnectionPlan{PID: 7, User: "app", Database: "orders", State: "idle"}
+	if got := idle.executionPlan("cancel_query").Summary; !strings.Contains(got, "cancel the query of PID 7") ||
+		!strings.Contains(got, "no open transaction") {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
 func TestFormatConnectionPlan(t *testing.T) {
 	t.Run("no open transaction", func(t *testing.T) {
 		plan := ConnectionPlan{
@@ -1784,19 +1811,28 @@ func TestTerminateConnectionTool_Level2_EscalationRequired(t *testing.T) {
 // =============================================================================
 
 func TestTerminateIdleConnectionsTool_NegativeIdle(t *testing.T) {
-	ctx := newTestContext()
-	result, err := terminateIdleConnectionsTool(ctx, TerminateIdleConnectionsArgs{
-		ConnectionString: "host=localhost",
-		IdleMinutes:      -1, // Negative is invalid
-	})
+	// The range check lives in the tool's input schema, enforced before the
+	// tool runs, rather than inside the tool.
+	tools, err := createTools()
 	if err != nil {
-		t.Fatalf("terminateIdleConnectionsTool() unexpected Go error: %v", err)
+		t.Fatalf("createTools: %v", err)
 	}
-	if !strings.Contains(result.Output, "ERROR") {
-		t.Errorf("terminateIdleConnectionsTool() output = %q, want ERROR for idle_minutes < 0", result.Output)
+	v, err := agentutil.NewArgValidator(tools, nil)
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	err = v.Validate(context.Background(), "terminate_idle_connections", map[string]any{
+		"connection_string": "host=localhost",
+		"idle_minutes":      -1, // Negative is invalid
+	})
+	if err == nil || !strings.Contains(err.Error(), "idle_minutes") {
+		t.Errorf("Validate(idle_minutes=-1) = %v, want an error naming idle_minutes", err)
 	}
-	if !strings.Contains(result.Output, "idle_minutes") {
-		t.Errorf("terminateIdleConnectionsTool() output = %q, want idle_minutes in error", result.Output)
+	if err := v.Validate(context.Background(), "terminate_idle_connections", map[string]any{
+		"connection_string": "host=localhost",
+		"idle_minutes":      0,
+	}); err != nil {
+		t.Errorf("Validate(idle_minutes=0) = %v, want nil (0 = no age filter)", err)
 	}
 }
 
@@ -2256,19 +2292,87 @@ func TestResolveDatabaseInfo_InfraEnforced_UnknownName(t *testing.T) {
 	}
 }
 
-func TestResolveDatabaseInfo_InfraEnforced_RegisteredConnString(t *testing.T) {
-	// infraConfig is set and the connection string is registered → succeed with tags.
+func TestResolveDatabaseInfo_InfraEnforced_RegisteredConnStringRejected(t *testing.T) {
+	// infraConfig is set and the connection string is registered → reject and
+	// point the caller at the alias; only aliases are accepted.
 	defer withInfraConfig(makeTestInfraConfig())()
 
-	info, err := resolveDatabaseInfo("host=prod.example.com dbname=mydb user=postgres")
+	for _, in := range []string{
+		"host=prod.example.com dbname=mydb user=postgres",
+		"host=prod.example.com dbname=mydb user=postgres password=leaked",
+		"host=prod-db dbname=mydb",
+	} {
+		_, err := resolveDatabaseInfo(in)
+		if err == nil {
+			t.Fatalf("resolveDatabaseInfo(%q) error = nil, want alias-required error", in)
+		}
+		if !strings.Contains(err.Error(), `alias "prod-db"`) {
+			t.Errorf("resolveDatabaseInfo(%q) error = %q, want alias 'prod-db' suggested", in, err.Error())
+		}
+		if strings.Contains(err.Error(), "leaked") {
+			t.Errorf("error echoes the password: %q", err.Error())
+		}
+	}
+}
+
+func TestResolveDatabaseInfo_CredentialAlias(t *testing.T) {
+	t.Setenv("TEST_DB_CRED_PW", "vaulted")
+	defer withInfraConfig(&infra.Config{
+		DBServers: map[string]infra.DBServer{
+			"secured-db": {
+				ConnectionString: "host=db.example.com dbname=app user=postgres",
+				Credential:       "app-pw",
+			},
+		},
+		Credentials: map[string]infra.Credential{"app-pw": {Env: "TEST_DB_CRED_PW"}},
+	})()
+
+	info, err := resolveDatabaseInfo("secured-db")
 	if err != nil {
-		t.Fatalf("resolveDatabaseInfo() error = %v, want nil for registered conn string", err)
+		t.Fatalf("resolveDatabaseInfo() error = %v", err)
+	}
+	if info.ConnectionStr != "host=db.example.com dbname=app user=postgres password=vaulted" {
+		t.Errorf("ConnectionStr = %q, want password resolved from credential", info.ConnectionStr)
 	}
-	if info.Name != "prod-db" {
-		t.Errorf("resolveDatabaseInfo() Name = %q, want 'prod-db'", info.Name)
+	if info.AuditConnStr != "host=db.example.com dbname=app user=postgres" {
+		t.Errorf("AuditConnStr = %q, want config template without password", info.AuditConnStr)
+	}
+
+	t.Setenv("TEST_DB_CRED_PW", "")
+	if _, err := resolveDatabaseInfo("secured-db"); err == nil || !strings.Contains(err.Error(), "app-pw") {
+		t.Errorf("resolveDatabaseInfo() error = %v, want unresolvable credential error", err)
+	}
+}
+
+func TestRunPsql_AuditNeverContainsResolvedPassword(t *testing.T) {
+	t.Setenv("TEST_DB_CRED_PW", "hunter2")
+	defer withInfraConfig(&infra.Config{
+		DBServers: map[string]infra.DBServer{
+			"secured-db": {ConnectionString: "host=db.example.com dbname=app", Credential: "pw"},
+		},
+		Credentials: map[string]infra.Credential{"pw": {Env: "TEST_DB_CRED_PW"}},
+	})()
+	capture := &capturingRunner{}
+	old := cmdRunner
+	cmdRunner = capture
+	defer func() { cmdRunner = old }()
+
+	info, _ := resolveDatabaseInfo("secured-db")
+	argv := info.auditArgv([]string{info.ConnectionStr, "-w", "-c", "SELECT 1"})
+	for _, a := range append(argv, info.auditConnStr()) {
+		if strings.Contains(a, "hunter2") {
+			t.Fatalf("audit argv contains resolved password: %v", argv)
+		}
+	}
+	if argv[1] != "host=db.example.com dbname=app" {
+		t.Errorf("audit argv[1] = %q, want password-free template", argv[1])
+	}
+
+	if _, err := runPsql(context.Background(), "secured-db", "SELECT 1"); err != nil {
+		t.Fatalf("runPsql: %v", err)
 	}
-	if len(info.Tags) == 0 || info.Tags[0] != "production" {
-		t.Errorf("resolveDatabaseInfo() Tags = %v, want ['production']", info.Tags)
+	if len(capture.lastArgs) == 0 || !strings.Contains(capture.lastArgs[0], "password=hunter2") {
+		t.Errorf("psql argv = %v, want resolved password passed to psql", capture.lastArgs)
 	}
 }
 
@@ -16,15 +16,24 @@ import (
 	"helpdesk/agentutil"
 	agentserve "helpdesk/agentutil/serve"
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
 	"helpdesk/prompts"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := agentutil.MustLoadConfig("localhost:1104")
 	ctx := context.Background()
 
-	// Enforce governance compliance in fix mode before any other initialization.
-	agentutil.EnforceFixMode(ctx, agentutil.CheckFixModeViolations(cfg), "incident_agent", cfg.AuditURL)
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "incident_agent",
+		Binaries: []agentutil.RequiredBinary{
+			{Name: "psql", VersionArgs: []string{"--version"}},
+			{Name: "kubectl", VersionArgs: []string{"version", "--client"}, MinVersion: "1.20"},
+		},
+	})
 
 	auditStore, err := agentserve.InitAuditStore(cfg)
 	if err != nil {
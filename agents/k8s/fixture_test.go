@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"helpdesk/agentutil"
+	"helpdesk/prompts"
+)
+
+// runFixture replays a recorded session (testdata/<name>.json) through the
+// full agent path — LLM, tool selection, Kubernetes API and kubectl — and
+// returns the agent's final text. Record a new fixture by running the agent
+// with HELPDESK_FIXTURE_RECORD=agents/k8s/testdata/<name>.json.
+func runFixture(t *testing.T, name, kubeContext, query string) string {
+	t.Helper()
+	replay, err := agentutil.LoadFixture("testdata/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := kubectlSandbox
+	kubectlSandbox = replay.Runner()
+	t.Cleanup(func() { kubectlSandbox = old })
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{
+		Host:      "https://k8s.fixture",
+		Transport: replay.Transport(),
+		Timeout:   10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("clientset: %v", err)
+	}
+	t.Cleanup(sharedClient.injectForTest(kubeContext, cs))
+
+	ctx := context.Background()
+	llm, err := agentutil.NewLLM(ctx, agentutil.Config{
+		ModelVendor:  "anthropic",
+		ModelName:    "claude-sonnet-4-5",
+		APIKey:       "fixture",
+		LLMTransport: replay.Transport(),
+	})
+	if err != nil {
+		t.Fatalf("NewLLM: %v", err)
+	}
+	tools, err := createTools()
+	if err != nil {
+		t.Fatalf("createTools: %v", err)
+	}
+	argValidator, err := agentutil.NewArgValidator(tools, nil)
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	k8sAgent, err := llmagent.New(llmagent.Config{
+		Name:                "k8s_agent",
+		Instruction:         prompts.K8s,
+		Model:               llm,
+		Tools:               tools,
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{argValidator.BeforeToolCallback()},
+	})
+	if err != nil {
+		t.Fatalf("llmagent.New: %v", err)
+	}
+
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "fixture", UserID: "u", SessionID: "s"}); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	r, err := runner.New(runner.Config{AppName: "fixture", Agent: k8sAgent, SessionService: sessions})
+	if err != nil {
+		t.Fatalf("runner.New: %v", err)
+	}
+
+	var text strings.Builder
+	for ev, err := range r.Run(ctx, "u", "s", genai.NewContentFromText(query, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			text.WriteString(p.Text)
+		}
+	}
+	if unused := replay.Unused(); len(unused) > 0 {
+		t.Errorf("fixture interactions never replayed: %v", unused)
+	}
+	return text.String()
+}
+
+func TestFixture_PodResources(t *testing.T) {
+	got := runFixture(t, "pod_resources", "fixture", "Which pods in namespace shop are closest to their memory limit? Use context fixture.")
+	if !strings.Contains(got, "web-1") || !strings.Contains(got, "498Mi") {
+		t.Errorf("final answer = %q, want it to name web-1 and its usage", got)
+	}
+}
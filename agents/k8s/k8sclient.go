@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -13,20 +14,28 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// k8sClient provides cached Kubernetes clientsets keyed by kubeconfig context.
+// k8sClient provides cached Kubernetes clientsets keyed by cluster (kubeconfig
+// file and context).
 // Clients are stored as kubernetes.Interface so fake clients can be injected
 // in tests without needing a real cluster.
 type k8sClient struct {
 	mu      sync.Mutex
 	clients map[string]kubernetes.Interface
 	configs map[string]*rest.Config
+
+	// wrapTransport, when set, wraps the transport of every clientset created
+	// from kubeconfig (e.g. to record API traffic as fixtures).
+	wrapTransport func(http.RoundTripper) http.RoundTripper
 }
 
 var sharedClient = &k8sClient{
@@ -35,7 +44,7 @@ var sharedClient = &k8sClient{
 }
 
 // injectForTest temporarily overrides the cached clientset for the given
-// context key. Returns a cleanup function that restores the previous state.
+// context key (the cache key of a cluster with no kubeconfig file). Returns a cleanup function that restores the previous state.
 // This is only intended for use in tests.
 func (kc *k8sClient) injectForTest(kubeContext string, cs kubernetes.Interface) func() {
 	kc.mu.Lock()
@@ -53,21 +62,23 @@ func (kc *k8sClient) injectForTest(kubeContext string, cs kubernetes.Interface)
 	}
 }
 
-// clientset returns a cached kubernetes.Interface for the given context.
-// If kubeContext is "", it tries in-cluster config first (for running in K8s),
-// then falls back to the default kubeconfig context.
-func (kc *k8sClient) clientset(kubeContext string) (kubernetes.Interface, error) {
+// clientset returns a cached kubernetes.Interface for the given cluster.
+// If the cluster names neither a context nor a kubeconfig file, it tries
+// in-cluster config first (for running in K8s), then falls back to the
+// default kubeconfig context.
+func (kc *k8sClient) clientset(cluster clusterInfo) (kubernetes.Interface, error) {
 	kc.mu.Lock()
 	defer kc.mu.Unlock()
 
-	if cs, ok := kc.clients[kubeContext]; ok {
+	key := cluster.key()
+	if cs, ok := kc.clients[key]; ok {
 		return cs, nil
 	}
 
 	var config *rest.Config
 	var err error
 
-	if kubeContext == "" {
+	if key == "" {
 		// Try in-cluster config first (when running inside K8s)
 		config, err = rest.InClusterConfig()
 		if err == nil {
@@ -84,9 +95,10 @@ func (kc *k8sClient) clientset(kubeContext string) (kubernetes.Interface, error)
 			}
 		}
 	} else {
-		// Use specified context from kubeconfig
+		// Use the cluster's kubeconfig file and context
 		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+		loadingRules.ExplicitPath = cluster.Kubeconfig
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: cluster.Context}
 		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 			loadingRules, overrides,
 		).ClientConfig()
@@ -96,24 +108,27 @@ func (kc *k8sClient) clientset(kubeContext string) (kubernetes.Interface, error)
 	}
 
 	config.Timeout = 10 * time.Second
+	if kc.wrapTransport != nil {
+		config.Wrap(kc.wrapTransport)
+	}
 
 	cs, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, diagnoseClientError(err)
 	}
 
-	kc.clients[kubeContext] = cs
-	kc.configs[kubeContext] = config
-	slog.Info("k8s clientset created", "context", kubeContext)
+	kc.clients[key] = cs
+	kc.configs[key] = config
+	slog.Info("k8s clientset created", "cluster", cluster.Name, "context", cluster.Context, "kubeconfig", cluster.Kubeconfig)
 	return cs, nil
 }
 
-// restConfig returns the cached *rest.Config for the given context.
-// Must be called after clientset() has been called for the same context.
-func (kc *k8sClient) restConfig(kubeContext string) *rest.Config {
+// restConfig returns the cached *rest.Config for the given cluster.
+// Must be called after clientset() has been called for the same cluster.
+func (kc *k8sClient) restConfig(cluster clusterInfo) *rest.Config {
 	kc.mu.Lock()
 	defer kc.mu.Unlock()
-	return kc.configs[kubeContext]
+	return kc.configs[cluster.key()]
 }
 
 // diagnoseClientError translates client-go errors into actionable messages.
@@ -207,8 +222,8 @@ func diagnoseClientError(err error) error {
 // --- Fetch functions ---
 
 // fetchPods lists pods using client-go and returns structured results.
-func fetchPods(ctx context.Context, kubeContext, namespace, labels string) (GetPodsResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchPods(ctx context.Context, cluster clusterInfo, namespace, labels string) (GetPodsResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetPodsResult{}, err
 	}
@@ -253,8 +268,8 @@ func fetchPods(ctx context.Context, kubeContext, namespace, labels string) (GetP
 }
 
 // fetchServices lists services using client-go and returns structured results.
-func fetchServices(ctx context.Context, kubeContext, namespace, serviceName, serviceType string) (GetServiceResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchServices(ctx context.Context, cluster clusterInfo, namespace, serviceName, serviceType string) (GetServiceResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetServiceResult{}, err
 	}
@@ -305,8 +320,8 @@ func fetchServices(ctx context.Context, kubeContext, namespace, serviceName, ser
 }
 
 // fetchEndpoints lists endpoints using client-go and returns structured results.
-func fetchEndpoints(ctx context.Context, kubeContext, namespace, endpointName string) (GetEndpointsResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchEndpoints(ctx context.Context, cluster clusterInfo, namespace, endpointName string) (GetEndpointsResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetEndpointsResult{}, err
 	}
@@ -378,8 +393,8 @@ func fetchEndpoints(ctx context.Context, kubeContext, namespace, endpointName st
 }
 
 // fetchEvents lists events using client-go and returns structured results.
-func fetchEvents(ctx context.Context, kubeContext, namespace, resourceName, eventType string) (GetEventsResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchEvents(ctx context.Context, cluster clusterInfo, namespace, resourceName, eventType string) (GetEventsResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetEventsResult{}, err
 	}
@@ -445,8 +460,8 @@ func formatEventTime(t time.Time) string {
 }
 
 // fetchNodes lists nodes using client-go and returns structured results.
-func fetchNodes(ctx context.Context, kubeContext string, showLabels bool) (GetNodesResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchNodes(ctx context.Context, cluster clusterInfo, showLabels bool) (GetNodesResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetNodesResult{}, err
 	}
@@ -488,8 +503,8 @@ func fetchNodes(ctx context.Context, kubeContext string, showLabels bool) (GetNo
 }
 
 // fetchNodeStatus lists nodes and returns detailed condition + resource info.
-func fetchNodeStatus(ctx context.Context, kubeContext string, nodeName string) (GetNodeStatusResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchNodeStatus(ctx context.Context, cluster clusterInfo, nodeName string) (GetNodeStatusResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetNodeStatusResult{}, err
 	}
@@ -552,10 +567,128 @@ func fetchNodeStatus(ctx context.Context, kubeContext string, nodeName string) (
 	return GetNodeStatusResult{Nodes: nodes, Count: len(nodes)}, nil
 }
 
+// mirrorPodAnnotation marks the API server's mirror of a static pod; the
+// kubelet owns those and they cannot be evicted.
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// fetchDrainPlan works out what "kubectl drain --ignore-daemonsets
+// --delete-emptydir-data" would do to nodeName: the pods it evicts, the
+// DaemonSet and static pods it leaves in place, and the unmanaged pods and
+// PodDisruptionBudgets that would stop it from completing.
+func fetchDrainPlan(ctx context.Context, cluster clusterInfo, nodeName string) (DrainPlan, error) {
+	cs, err := sharedClient.clientset(cluster)
+	if err != nil {
+		return DrainPlan{}, err
+	}
+
+	node, err := cs.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return DrainPlan{}, diagnoseClientError(err)
+	}
+	podList, err := cs.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName})
+	if err != nil {
+		return DrainPlan{}, diagnoseClientError(err)
+	}
+	pods := make([]corev1.Pod, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == nodeName {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	plan := DrainPlan{Node: nodeName, Unschedulable: node.Spec.Unschedulable, Evict: []DrainPod{}}
+	// running holds the evicted pods a PodDisruptionBudget can hold up.
+	var running []*corev1.Pod
+	var runningIdx []int
+	for i := range pods {
+		pod := &pods[i]
+		dp := DrainPod{Namespace: pod.Namespace, Name: pod.Name}
+		owner := metav1.GetControllerOf(pod)
+		if owner != nil {
+			dp.Owner = owner.Kind + "/" + owner.Name
+		}
+		switch {
+		case pod.Annotations[mirrorPodAnnotation] != "":
+			dp.Note = "static pod, left in place"
+			plan.Skip = append(plan.Skip, dp)
+			continue
+		case owner != nil && owner.Kind == "DaemonSet":
+			dp.Note = "DaemonSet pod, left in place"
+			plan.Skip = append(plan.Skip, dp)
+			continue
+		case pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed:
+			dp.Note = "finished"
+		default:
+			if owner == nil {
+				plan.Blocked = append(plan.Blocked, fmt.Sprintf(
+					"pod %s/%s is not managed by a controller and would not be recreated", pod.Namespace, pod.Name))
+			}
+			running = append(running, pod)
+			runningIdx = append(runningIdx, len(plan.Evict))
+		}
+		if usesEmptyDir(pod) {
+			dp.Note = strings.TrimPrefix(dp.Note+"; emptyDir data is deleted", "; ")
+		}
+		plan.Evict = append(plan.Evict, dp)
+	}
+
+	pdbs := make(map[string][]policyv1.PodDisruptionBudget)
+	var namespaces []string
+	for _, pod := range running {
+		if _, ok := pdbs[pod.Namespace]; ok {
+			continue
+		}
+		list, err := cs.PolicyV1().PodDisruptionBudgets(pod.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return DrainPlan{}, diagnoseClientError(err)
+		}
+		pdbs[pod.Namespace] = list.Items
+		namespaces = append(namespaces, pod.Namespace)
+	}
+	for _, ns := range namespaces {
+		for _, pdb := range pdbs[ns] {
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			covered := 0
+			for i, pod := range running {
+				if pod.Namespace == ns && selector.Matches(labels.Set(pod.Labels)) {
+					plan.Evict[runningIdx[i]].PDB = pdb.Name
+					covered++
+				}
+			}
+			if covered > 0 && pdb.Status.DisruptionsAllowed == 0 {
+				plan.Blocked = append(plan.Blocked, fmt.Sprintf(
+					"PodDisruptionBudget %s/%s allows no disruptions now; evicting its %d pod(s) on the node would wait until it does",
+					ns, pdb.Name, covered))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// usesEmptyDir reports whether pod mounts an emptyDir volume, whose data a
+// drain deletes.
+func usesEmptyDir(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // fetchPodResources reads pod specs for requests/limits and optionally
 // supplements with live usage from kubectl top pods.
-func fetchPodResources(ctx context.Context, kubeContext, namespace, podName string) (GetPodResourcesResult, error) {
-	cs, err := sharedClient.clientset(kubeContext)
+func fetchPodResources(ctx context.Context, cluster clusterInfo, namespace, podName string) (GetPodResourcesResult, error) {
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return GetPodResourcesResult{}, err
 	}
@@ -605,7 +738,7 @@ func fetchPodResources(ctx context.Context, kubeContext, namespace, podName stri
 		topArgs = append(topArgs, podName)
 	}
 	metricsNote := ""
-	topOut, topErr := runKubectl(ctx, kubeContext, topArgs...)
+	topOut, topErr := runKubectl(ctx, cluster, topArgs...)
 	if topErr != nil {
 		metricsNote = "Live CPU/memory usage unavailable (metrics-server may not be installed). Showing requests/limits only."
 	} else {
@@ -633,3 +766,408 @@ func fetchPodResources(ctx context.Context, kubeContext, namespace, podName stri
 		MetricsNote: metricsNote,
 	}, nil
 }
+
+// Default StorageClass annotations; the beta one is still set by some
+// provisioners.
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// provisionerGracePeriod is how long a claim may wait on an external
+// provisioner before the wait is reported as a missing provisioner.
+const provisionerGracePeriod = 2 * time.Minute
+
+// isDefaultStorageClass reports whether sc is marked as the cluster default.
+func isDefaultStorageClass(sc storagev1.StorageClass) bool {
+	return sc.Annotations[defaultStorageClassAnnotation] == "true" ||
+		sc.Annotations[betaDefaultStorageClassAnnotation] == "true"
+}
+
+// storageClassIndex holds a cluster's StorageClasses for PVC diagnosis. When
+// known is false they could not be listed (e.g. no RBAC access) and the
+// signatures that depend on them are not reported.
+type storageClassIndex struct {
+	known        bool
+	classes      map[string]storagev1.StorageClass
+	defaultClass string
+}
+
+// fetchStorageClassIndex lists the StorageClasses, tolerating failure.
+func fetchStorageClassIndex(ctx context.Context, cs kubernetes.Interface) storageClassIndex {
+	list, err := cs.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		slog.Debug("storage classes unavailable for PVC diagnosis", "err", err)
+		return storageClassIndex{}
+	}
+	idx := storageClassIndex{known: true, classes: make(map[string]storagev1.StorageClass, len(list.Items))}
+	for _, sc := range list.Items {
+		idx.classes[sc.Name] = sc
+		if isDefaultStorageClass(sc) {
+			idx.defaultClass = sc.Name
+		}
+	}
+	return idx
+}
+
+// fetchPVCStatus lists PersistentVolumeClaims with the pods that mount them
+// and detects the common reasons a claim does not bind or its volume does not
+// reach the pod.
+func fetchPVCStatus(ctx context.Context, cluster clusterInfo, namespace, pvcName string) (GetPVCStatusResult, error) {
+	cs, err := sharedClient.clientset(cluster)
+	if err != nil {
+		return GetPVCStatusResult{}, err
+	}
+
+	var claims []corev1.PersistentVolumeClaim
+	if pvcName != "" {
+		pvc, err := cs.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+		if err != nil {
+			return GetPVCStatusResult{}, diagnoseClientError(err)
+		}
+		claims = []corev1.PersistentVolumeClaim{*pvc}
+	} else {
+		list, err := cs.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return GetPVCStatusResult{}, diagnoseClientError(err)
+		}
+		claims = list.Items
+	}
+	if len(claims) == 0 {
+		return GetPVCStatusResult{PVCs: []PVCInfo{}, Message: fmt.Sprintf("No PersistentVolumeClaims found in namespace %q.", namespace)}, nil
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Name < claims[j].Name })
+
+	podList, err := cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return GetPVCStatusResult{}, diagnoseClientError(err)
+	}
+	usedBy := make(map[string][]string)
+	for _, pod := range podList.Items {
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil {
+				usedBy[v.PersistentVolumeClaim.ClaimName] = append(usedBy[v.PersistentVolumeClaim.ClaimName], pod.Name)
+			}
+		}
+	}
+	eventList, err := cs.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return GetPVCStatusResult{}, diagnoseClientError(err)
+	}
+	classes := fetchStorageClassIndex(ctx, cs)
+
+	result := GetPVCStatusResult{PVCs: make([]PVCInfo, 0, len(claims))}
+	for _, pvc := range claims {
+		info := PVCInfo{
+			Name:        pvc.Name,
+			Namespace:   pvc.Namespace,
+			Status:      string(pvc.Status.Phase),
+			Volume:      pvc.Spec.VolumeName,
+			AccessModes: accessModes(pvc.Spec.AccessModes),
+			Age:         formatAge(pvc.CreationTimestamp.Time),
+			UsedBy:      usedBy[pvc.Name],
+		}
+		if pvc.Spec.StorageClassName != nil {
+			info.StorageClass = *pvc.Spec.StorageClassName
+		}
+		if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			info.Capacity = q.String()
+		}
+		if q, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			info.Requested = q.String()
+		}
+		result.PVCs = append(result.PVCs, info)
+		result.Issues = append(result.Issues, pvcIssues(pvc, classes, info.UsedBy, eventList.Items, time.Now())...)
+		for _, pod := range info.UsedBy {
+			result.Issues = append(result.Issues, podVolumeIssues(pod, eventList.Items)...)
+		}
+	}
+	result.Count = len(result.PVCs)
+	return result, nil
+}
+
+// pvcIssues returns the storage failure signatures of one claim.
+func pvcIssues(pvc corev1.PersistentVolumeClaim, classes storageClassIndex, usedBy []string, events []corev1.Event, now time.Time) []StorageIssue {
+	object := "PersistentVolumeClaim/" + pvc.Name
+	issue := func(signature, format string, a ...any) StorageIssue {
+		return StorageIssue{Object: object, Signature: signature, Detail: fmt.Sprintf(format, a...)}
+	}
+
+	switch pvc.Status.Phase {
+	case corev1.ClaimLost:
+		return []StorageIssue{issue("pvc_lost",
+			"the claim's PersistentVolume %q no longer exists; the data is gone unless the volume can be restored", pvc.Spec.VolumeName)}
+	case corev1.ClaimPending:
+	default:
+		return nil
+	}
+
+	// A claim pre-bound to a named volume waits for that volume, not a provisioner.
+	if pvc.Spec.VolumeName != "" {
+		return []StorageIssue{issue("pvc_pending",
+			"the claim is pre-bound to PersistentVolume %q, which is missing or does not match the claim", pvc.Spec.VolumeName)}
+	}
+
+	className := classes.defaultClass
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if classes.known {
+		switch {
+		case pvc.Spec.StorageClassName == nil && className == "":
+			return []StorageIssue{issue("no_default_storage_class",
+				"the claim names no StorageClass and the cluster has no default one, so only a pre-created PersistentVolume without a class can bind it")}
+		case className != "":
+			sc, ok := classes.classes[className]
+			if !ok {
+				return []StorageIssue{issue("storage_class_not_found",
+					"the claim requests StorageClass %q, which does not exist; create it or fix the claim's storageClassName", className)}
+			}
+			if sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+				if len(usedBy) == 0 {
+					return []StorageIssue{issue("wait_for_first_consumer",
+						"StorageClass %q binds on first use and no pod mounts the claim yet; this is expected", className)}
+				}
+				if latestEvent(events, "PersistentVolumeClaim", pvc.Name, "ExternalProvisioning", "ProvisioningFailed") == nil {
+					return []StorageIssue{issue("wait_for_first_consumer",
+						"StorageClass %q binds once pod %s is scheduled; if the pod is Pending, diagnose its scheduling instead", className, strings.Join(usedBy, ", "))}
+				}
+			}
+		}
+	}
+
+	if e := latestEvent(events, "PersistentVolumeClaim", pvc.Name, "ProvisioningFailed"); e != nil {
+		return []StorageIssue{issue("provisioning_failed", "%s", e.Message)}
+	}
+	if e := latestEvent(events, "PersistentVolumeClaim", pvc.Name, "ExternalProvisioning"); e != nil &&
+		now.Sub(pvc.CreationTimestamp.Time) > provisionerGracePeriod {
+		return []StorageIssue{issue("provisioner_missing",
+			"no provisioner has acted on the claim for %s: %s; check that the CSI driver or provisioner for StorageClass %q is installed and running",
+			formatAge(pvc.CreationTimestamp.Time), e.Message, className)}
+	}
+	return nil
+}
+
+// podVolumeIssues returns the volume attach and mount failures reported for
+// a pod.
+func podVolumeIssues(pod string, events []corev1.Event) []StorageIssue {
+	var issues []StorageIssue
+	if e := latestEvent(events, "Pod", pod, "FailedAttachVolume"); e != nil {
+		signature := "volume_attach_failed"
+		if strings.Contains(e.Message, "Multi-Attach error") {
+			signature = "volume_multi_attach"
+		}
+		issues = append(issues, StorageIssue{Object: "Pod/" + pod, Signature: signature, Detail: e.Message})
+	}
+	if e := latestEvent(events, "Pod", pod, "FailedMount"); e != nil {
+		issues = append(issues, StorageIssue{Object: "Pod/" + pod, Signature: "volume_mount_failed", Detail: e.Message})
+	}
+	if e := latestEvent(events, "Pod", pod, "FailedScheduling"); e != nil && strings.Contains(e.Message, "volume node affinity conflict") {
+		issues = append(issues, StorageIssue{Object: "Pod/" + pod, Signature: "volume_node_affinity_conflict", Detail: e.Message})
+	}
+	return issues
+}
+
+// latestEvent returns the most recent event about kind/name with one of the
+// given reasons, or nil.
+func latestEvent(events []corev1.Event, kind, name string, reasons ...string) *corev1.Event {
+	var latest *corev1.Event
+	for i := range events {
+		e := &events[i]
+		if e.InvolvedObject.Kind != kind || e.InvolvedObject.Name != name {
+			continue
+		}
+		for _, r := range reasons {
+			if e.Reason == r && (latest == nil || eventTimestamp(*e).After(eventTimestamp(*latest))) {
+				latest = e
+			}
+		}
+	}
+	return latest
+}
+
+// fetchStorageClasses lists StorageClasses, or gets one by name.
+func fetchStorageClasses(ctx context.Context, cluster clusterInfo, name string) (GetStorageClassResult, error) {
+	cs, err := sharedClient.clientset(cluster)
+	if err != nil {
+		return GetStorageClassResult{}, err
+	}
+
+	var classes []storagev1.StorageClass
+	if name != "" {
+		sc, err := cs.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return GetStorageClassResult{}, diagnoseClientError(err)
+		}
+		classes = []storagev1.StorageClass{*sc}
+	} else {
+		list, err := cs.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return GetStorageClassResult{}, diagnoseClientError(err)
+		}
+		classes = list.Items
+	}
+	if len(classes) == 0 {
+		return GetStorageClassResult{StorageClasses: []StorageClassInfo{}, Message: "No StorageClasses found; dynamic provisioning is unavailable."}, nil
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Name < classes[j].Name })
+
+	infos := make([]StorageClassInfo, 0, len(classes))
+	for _, sc := range classes {
+		info := StorageClassInfo{
+			Name:              sc.Name,
+			Provisioner:       sc.Provisioner,
+			ReclaimPolicy:     string(corev1.PersistentVolumeReclaimDelete),
+			VolumeBindingMode: string(storagev1.VolumeBindingImmediate),
+			Default:           isDefaultStorageClass(sc),
+			Age:               formatAge(sc.CreationTimestamp.Time),
+		}
+		if sc.ReclaimPolicy != nil {
+			info.ReclaimPolicy = string(*sc.ReclaimPolicy)
+		}
+		if sc.VolumeBindingMode != nil {
+			info.VolumeBindingMode = string(*sc.VolumeBindingMode)
+		}
+		if sc.AllowVolumeExpansion != nil {
+			info.AllowVolumeExpansion = *sc.AllowVolumeExpansion
+		}
+		infos = append(infos, info)
+	}
+	return GetStorageClassResult{StorageClasses: infos, Count: len(infos)}, nil
+}
+
+// fetchNamespaces lists the cluster's namespaces. Each is marked with whether
+// the cluster's namespace allowlist in the infrastructure config names it.
+func fetchNamespaces(ctx context.Context, cluster clusterInfo) (GetNamespacesResult, error) {
+	cs, err := sharedClient.clientset(cluster)
+	if err != nil {
+		return GetNamespacesResult{}, err
+	}
+
+	list, err := cs.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return GetNamespacesResult{}, diagnoseClientError(err)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+
+	infos := make([]NamespaceInfo, 0, len(list.Items))
+	for _, ns := range list.Items {
+		_, listed := cluster.Namespaces[ns.Name]
+		infos = append(infos, NamespaceInfo{
+			Name:        ns.Name,
+			Status:      string(ns.Status.Phase),
+			Age:         formatAge(ns.CreationTimestamp.Time),
+			Allowlisted: listed,
+		})
+	}
+	return GetNamespacesResult{Namespaces: infos, Count: len(infos)}, nil
+}
+
+// fetchPVs lists PersistentVolumes, or gets one by name, with their CSI
+// attachments and storage failure signatures.
+func fetchPVs(ctx context.Context, cluster clusterInfo, name string) (DescribePVResult, error) {
+	cs, err := sharedClient.clientset(cluster)
+	if err != nil {
+		return DescribePVResult{}, err
+	}
+
+	var volumes []corev1.PersistentVolume
+	if name != "" {
+		pv, err := cs.CoreV1().PersistentVolumes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return DescribePVResult{}, diagnoseClientError(err)
+		}
+		volumes = []corev1.PersistentVolume{*pv}
+	} else {
+		list, err := cs.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return DescribePVResult{}, diagnoseClientError(err)
+		}
+		volumes = list.Items
+	}
+	if len(volumes) == 0 {
+		return DescribePVResult{Volumes: []PVInfo{}, Message: "No PersistentVolumes found."}, nil
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+
+	// Attachments only exist for CSI volumes; without access to them the
+	// volumes are still described.
+	attachments := make(map[string][]VolumeAttachmentInfo)
+	if list, err := cs.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{}); err != nil {
+		slog.Debug("volume attachments unavailable", "err", err)
+	} else {
+		for _, va := range list.Items {
+			if va.Spec.Source.PersistentVolumeName == nil {
+				continue
+			}
+			info := VolumeAttachmentInfo{
+				Name:     va.Name,
+				Node:     va.Spec.NodeName,
+				Attacher: va.Spec.Attacher,
+				Attached: va.Status.Attached,
+			}
+			if va.Status.AttachError != nil {
+				info.Error = "attach: " + va.Status.AttachError.Message
+			} else if va.Status.DetachError != nil {
+				info.Error = "detach: " + va.Status.DetachError.Message
+			}
+			pvName := *va.Spec.Source.PersistentVolumeName
+			attachments[pvName] = append(attachments[pvName], info)
+		}
+	}
+
+	result := DescribePVResult{Volumes: make([]PVInfo, 0, len(volumes))}
+	for _, pv := range volumes {
+		info := PVInfo{
+			Name:          pv.Name,
+			Status:        string(pv.Status.Phase),
+			AccessModes:   accessModes(pv.Spec.AccessModes),
+			ReclaimPolicy: string(pv.Spec.PersistentVolumeReclaimPolicy),
+			StorageClass:  pv.Spec.StorageClassName,
+			Source:        volumeSource(pv),
+			NodeAffinity:  volumeNodeAffinity(pv),
+			Message:       pv.Status.Message,
+			Age:           formatAge(pv.CreationTimestamp.Time),
+			Attachments:   attachments[pv.Name],
+		}
+		if pv.Status.Reason != "" {
+			info.Message = strings.TrimSuffix(pv.Status.Reason+": "+pv.Status.Message, ": ")
+		}
+		if q, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok {
+			info.Capacity = q.String()
+		}
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			info.Claim = ref.Namespace + "/" + ref.Name
+		}
+		result.Volumes = append(result.Volumes, info)
+		result.Issues = append(result.Issues, pvIssues(info)...)
+	}
+	result.Count = len(result.Volumes)
+	return result, nil
+}
+
+// pvIssues returns the storage failure signatures of one volume.
+func pvIssues(pv PVInfo) []StorageIssue {
+	object := "PersistentVolume/" + pv.Name
+	var issues []StorageIssue
+	switch corev1.PersistentVolumePhase(pv.Status) {
+	case corev1.VolumeFailed:
+		issues = append(issues, StorageIssue{Object: object, Signature: "pv_failed",
+			Detail: fmt.Sprintf("reclamation with policy %s failed: %s", pv.ReclaimPolicy, pv.Message)})
+	case corev1.VolumeReleased:
+		issues = append(issues, StorageIssue{Object: object, Signature: "pv_released",
+			Detail: fmt.Sprintf("claim %s was deleted; with reclaim policy %s the volume keeps its data but binds no new claim until its claimRef is cleared", pv.Claim, pv.ReclaimPolicy)})
+	}
+	for _, a := range pv.Attachments {
+		if a.Error == "" {
+			continue
+		}
+		signature := "volume_attach_failed"
+		if strings.HasPrefix(a.Error, "detach: ") {
+			signature = "volume_detach_failed"
+		}
+		issues = append(issues, StorageIssue{Object: object, Signature: signature,
+			Detail: fmt.Sprintf("%s on node %s: %s", a.Attacher, a.Node, a.Error)})
+	}
+	return issues
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -439,3 +440,80 @@ func TestDiagnoseClientError_NetOpErrorConnectionRefused(t *testing.T) {
 
 // Suppress unused import warning for errors package.
 var _ = errors.New
+
+// --- pvcIssues tests ---
+
+func TestPVCIssues(t *testing.T) {
+	now := time.Now()
+	className := func(s string) *string { return &s }
+	wffc := storagev1.VolumeBindingWaitForFirstConsumer
+	classes := storageClassIndex{
+		known: true,
+		classes: map[string]storagev1.StorageClass{
+			"standard": {ObjectMeta: metav1.ObjectMeta{Name: "standard"}, Provisioner: "ebs.csi.aws.com"},
+			"local":    {ObjectMeta: metav1.ObjectMeta{Name: "local"}, VolumeBindingMode: &wffc},
+		},
+	}
+	pending := func(class *string, age time.Duration) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: class},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		}
+	}
+	claimEvent := func(reason, message string) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "data"},
+			Reason:         reason,
+			Message:        message,
+		}
+	}
+	waiting := claimEvent("ExternalProvisioning", `waiting for a volume to be created, either by external provisioner "ebs.csi.aws.com" or manually created by system administrator`)
+
+	tests := []struct {
+		name    string
+		pvc     corev1.PersistentVolumeClaim
+		classes storageClassIndex
+		usedBy  []string
+		events  []corev1.Event
+		want    string // signature, "" for none
+	}{
+		{"bound", corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}}, classes, nil, nil, ""},
+		{"lost", corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimLost}}, classes, nil, nil, "pvc_lost"},
+		{"missing class", pending(className("fast"), time.Minute), classes, nil, nil, "storage_class_not_found"},
+		{"missing class, classes unknown", pending(className("fast"), time.Minute), storageClassIndex{}, nil, nil, ""},
+		{"no default class", pending(nil, time.Minute), classes, nil, nil, "no_default_storage_class"},
+		{"first consumer, unused", pending(className("local"), time.Hour), classes, nil, nil, "wait_for_first_consumer"},
+		{"first consumer, pod scheduled", pending(className("local"), time.Hour), classes, []string{"pg-0"}, []corev1.Event{waiting}, "provisioner_missing"},
+		{"provisioning failed", pending(className("standard"), time.Minute), classes, nil,
+			[]corev1.Event{waiting, claimEvent("ProvisioningFailed", "rpc error: quota exceeded")}, "provisioning_failed"},
+		{"provisioner slow but within grace", pending(className("standard"), time.Minute), classes, nil, []corev1.Event{waiting}, ""},
+		{"provisioner missing", pending(className("standard"), 10*time.Minute), classes, nil, []corev1.Event{waiting}, "provisioner_missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := pvcIssues(tt.pvc, tt.classes, tt.usedBy, tt.events, now)
+			got := ""
+			if len(issues) > 0 {
+				got = issues[0].Signature
+			}
+			if got != tt.want || len(issues) > 1 {
+				t.Errorf("pvcIssues = %+v, want signature %q", issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodVolumeIssues(t *testing.T) {
+	events := []corev1.Event{
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pg-0"}, Reason: "FailedMount",
+			Message: "MountVolume.SetUp failed for volume \"data\": mount failed: exit status 32"},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pg-0"}, Reason: "FailedScheduling",
+			Message: "0/3 nodes are available: 3 node(s) had volume node affinity conflict."},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other"}, Reason: "FailedAttachVolume", Message: "timeout"},
+	}
+	issues := podVolumeIssues("pg-0", events)
+	if len(issues) != 2 || issues[0].Signature != "volume_mount_failed" || issues[1].Signature != "volume_node_affinity_conflict" {
+		t.Errorf("podVolumeIssues = %+v, want volume_mount_failed and volume_node_affinity_conflict", issues)
+	}
+}
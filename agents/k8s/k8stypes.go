@@ -191,6 +191,131 @@ type GetPodResourcesResult struct {
 	MetricsNote     string            `json:"metrics_note,omitempty"` // set when live usage unavailable
 }
 
+// DrainPod is a pod on a node being drained.
+type DrainPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Owner     string `json:"owner,omitempty"`  // controller, e.g. "ReplicaSet/web-5d8f9"
+	PDB       string `json:"pdb,omitempty"`    // PodDisruptionBudget covering the pod
+	Note      string `json:"note,omitempty"`   // e.g. why the pod is left in place
+}
+
+// DrainPlan is what draining a node would do: the pods evicted, the pods left
+// in place, and anything that would stop the drain from completing.
+type DrainPlan struct {
+	Node          string     `json:"node"`
+	Unschedulable bool       `json:"unschedulable"` // node is already cordoned
+	Evict         []DrainPod `json:"evict"`
+	Skip          []DrainPod `json:"skip,omitempty"`    // DaemonSet and static pods
+	Blocked       []string   `json:"blocked,omitempty"` // reasons the drain cannot complete now
+}
+
+// evictionList returns the pods the plan evicts as namespace/name.
+func (p DrainPlan) evictionList() []string {
+	pods := make([]string, 0, len(p.Evict))
+	for _, pod := range p.Evict {
+		pods = append(pods, pod.Namespace+"/"+pod.Name)
+	}
+	return pods
+}
+
+// --- Storage types ---
+
+// StorageIssue is a known storage failure signature found on a
+// PersistentVolumeClaim, a PersistentVolume or a pod that mounts one.
+type StorageIssue struct {
+	Object    string `json:"object"`    // e.g. "PersistentVolumeClaim/data-postgres-0"
+	Signature string `json:"signature"` // e.g. "storage_class_not_found", "volume_attach_failed"
+	Detail    string `json:"detail"`
+}
+
+// PVCInfo contains structured information about a PersistentVolumeClaim.
+type PVCInfo struct {
+	Name         string   `json:"name"`
+	Namespace    string   `json:"namespace"`
+	Status       string   `json:"status"` // Pending, Bound, Lost
+	Volume       string   `json:"volume,omitempty"`
+	Capacity     string   `json:"capacity,omitempty"`
+	Requested    string   `json:"requested,omitempty"`
+	AccessModes  []string `json:"access_modes"`
+	StorageClass string   `json:"storage_class,omitempty"`
+	Age          string   `json:"age"`
+	UsedBy       []string `json:"used_by,omitempty"` // pods mounting the claim
+}
+
+// GetPVCStatusResult is the structured result for the get_pvc_status tool.
+type GetPVCStatusResult struct {
+	PVCs    []PVCInfo      `json:"pvcs"`
+	Count   int            `json:"count"`
+	Issues  []StorageIssue `json:"issues,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// StorageClassInfo contains structured information about a StorageClass.
+type StorageClassInfo struct {
+	Name                 string `json:"name"`
+	Provisioner          string `json:"provisioner"`
+	ReclaimPolicy        string `json:"reclaim_policy"`
+	VolumeBindingMode    string `json:"volume_binding_mode"`
+	AllowVolumeExpansion bool   `json:"allow_volume_expansion"`
+	Default              bool   `json:"default"`
+	Age                  string `json:"age"`
+}
+
+// GetStorageClassResult is the structured result for the get_storageclass tool.
+type GetStorageClassResult struct {
+	StorageClasses []StorageClassInfo `json:"storage_classes"`
+	Count          int                `json:"count"`
+	Message        string             `json:"message,omitempty"`
+}
+
+// NamespaceInfo contains structured information about a namespace.
+type NamespaceInfo struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"` // Active or Terminating
+	Age         string `json:"age"`
+	Allowlisted bool   `json:"allowlisted,omitempty"` // named in the cluster's namespace allowlist
+}
+
+// GetNamespacesResult is the structured result for the get_namespaces tool.
+type GetNamespacesResult struct {
+	Namespaces []NamespaceInfo `json:"namespaces"`
+	Count      int             `json:"count"`
+}
+
+// VolumeAttachmentInfo describes the attachment of a CSI volume to a node.
+type VolumeAttachmentInfo struct {
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	Attacher string `json:"attacher"`
+	Attached bool   `json:"attached"`
+	Error    string `json:"error,omitempty"` // attach or detach error reported by the driver
+}
+
+// PVInfo contains structured information about a PersistentVolume.
+type PVInfo struct {
+	Name          string                 `json:"name"`
+	Status        string                 `json:"status"` // Available, Bound, Released, Failed
+	Capacity      string                 `json:"capacity"`
+	AccessModes   []string               `json:"access_modes"`
+	ReclaimPolicy string                 `json:"reclaim_policy"`
+	StorageClass  string                 `json:"storage_class,omitempty"`
+	Claim         string                 `json:"claim,omitempty"`  // namespace/name of the bound claim
+	Source        string                 `json:"source"`           // e.g. "csi: ebs.csi.aws.com (vol-0abc)"
+	NodeAffinity  []string               `json:"node_affinity,omitempty"`
+	Message       string                 `json:"message,omitempty"` // status reason and message, if any
+	Age           string                 `json:"age"`
+	Attachments   []VolumeAttachmentInfo `json:"attachments,omitempty"`
+}
+
+// DescribePVResult is the structured result for the describe_pv tool.
+type DescribePVResult struct {
+	Volumes []PVInfo       `json:"volumes"`
+	Count   int            `json:"count"`
+	Issues  []StorageIssue `json:"issues,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
 // --- Conversion helpers ---
 
 // formatAge converts a creation timestamp to a human-readable age string.
@@ -303,3 +428,44 @@ func externalAddresses(svc corev1.Service) []string {
 	}
 	return addrs
 }
+
+// accessModes converts PersistentVolume access modes to strings.
+func accessModes(modes []corev1.PersistentVolumeAccessMode) []string {
+	result := make([]string, 0, len(modes))
+	for _, m := range modes {
+		result = append(result, string(m))
+	}
+	return result
+}
+
+// volumeSource describes where a PersistentVolume's storage lives.
+func volumeSource(pv corev1.PersistentVolume) string {
+	src := pv.Spec.PersistentVolumeSource
+	switch {
+	case src.CSI != nil:
+		return fmt.Sprintf("csi: %s (%s)", src.CSI.Driver, src.CSI.VolumeHandle)
+	case src.Local != nil:
+		return "local: " + src.Local.Path
+	case src.HostPath != nil:
+		return "hostPath: " + src.HostPath.Path
+	case src.NFS != nil:
+		return fmt.Sprintf("nfs: %s:%s", src.NFS.Server, src.NFS.Path)
+	default:
+		return "other"
+	}
+}
+
+// volumeNodeAffinity renders a PersistentVolume's required node affinity as
+// "key in (v1, v2)" terms.
+func volumeNodeAffinity(pv corev1.PersistentVolume) []string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return nil
+	}
+	var terms []string
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			terms = append(terms, fmt.Sprintf("%s %s (%s)", expr.Key, strings.ToLower(string(expr.Operator)), strings.Join(expr.Values, ", ")))
+		}
+	}
+	return terms
+}
@@ -7,9 +7,8 @@ import (
 	"context"
 	"log/slog"
 	"os"
-	"sort"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
@@ -30,27 +29,29 @@ import (
 // Used to resolve database names to K8s namespace/context.
 var infraConfig *infra.Config
 
+// infraConfigMu guards infraConfig, which is replaced when the signed config
+// fetched from auditd changes.
+var infraConfigMu sync.RWMutex
+
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := agentutil.MustLoadConfig("localhost:1102")
 	ctx := context.Background()
 
-	// Enforce governance compliance in fix mode before any other initialization.
-	agentutil.EnforceFixMode(ctx, agentutil.CheckFixModeViolations(cfg), "k8s_agent", cfg.AuditURL)
-
-	// Load infrastructure config if available (enables database name resolution)
-	if infraPath := os.Getenv("HELPDESK_INFRA_CONFIG"); infraPath != "" {
-		var err error
-		infraConfig, err = infra.Load(infraPath)
-		if err != nil {
-			slog.Warn("failed to load infrastructure config", "path", infraPath, "err", err)
-		} else {
-			dbKeys := make([]string, 0, len(infraConfig.DBServers))
-		for k := range infraConfig.DBServers {
-			dbKeys = append(dbKeys, k)
-		}
-		sort.Strings(dbKeys)
-		slog.Info("infrastructure config loaded", "databases", len(infraConfig.DBServers), "db_keys", strings.Join(dbKeys, ", "))
-		}
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "k8s_agent",
+		Binaries:  []agentutil.RequiredBinary{{Name: "kubectl", VersionArgs: []string{"version", "--client"}, MinVersion: "1.20"}},
+	})
+
+	// Load infrastructure config: signed from auditd, or a local file.
+	var infraSrc *agentutil.InfraConfigSource
+	if ic, src, err := agentutil.LoadInfraConfig(ctx, cfg); err != nil {
+		slog.Error("failed to load infrastructure config", "err", err)
+		os.Exit(1)
+	} else {
+		infraConfig, infraSrc = ic, src
 	}
 
 	// Initialize audit store if enabled
@@ -91,6 +92,9 @@ func main() {
 		AgentName:                  "k8s_agent",
 		ToolAuditor:                toolAuditor,
 		RequirePurposeForSensitive: os.Getenv("HELPDESK_REQUIRE_PURPOSE_FOR_SENSITIVE") == "true",
+		FreezeCheckURL:             cfg.AuditURL,
+		FreezeFailOpen:             os.Getenv("HELPDESK_FREEZE_FAIL_OPEN") == "true",
+		PolicyCacheTTL:             cfg.PolicyCacheTTL,
 	})
 
 	// Apply HELPDESK_VERIFY_* env-var overrides for Level-2 post-mutation retry config.
@@ -113,6 +117,14 @@ func main() {
 		"approval", approvalClient != nil,
 	)
 
+	// HELPDESK_FIXTURE_RECORD captures kubectl, Kubernetes API and LLM
+	// traffic for replay tests.
+	if rec := agentutil.FixtureRecorderFromEnv(); rec != nil {
+		kubectlSandbox = rec.Runner(kubectlSandbox)
+		sharedClient.wrapTransport = rec.Transport
+		cfg.LLMTransport = rec.Transport(nil)
+	}
+
 	llmModel, err := agentutil.NewLLM(ctx, cfg)
 	if err != nil {
 		slog.Error("failed to create LLM model", "err", err)
@@ -125,17 +137,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Validate tool arguments against each tool's input schema, for LLM and
+	// direct calls alike; rejections are audited as tool_args_rejected.
+	argValidator, err := agentutil.NewArgValidator(tools, toolAuditor)
+	if err != nil {
+		slog.Error("failed to build argument validator", "err", err)
+		os.Exit(1)
+	}
+
 	instruction := prompts.K8s
 	if infraConfig != nil {
 		instruction += "\n\n## Known Infrastructure\n\n" + infraConfig.Summary()
 	}
 
+	// Pick up changes to the signed config. The summary in the instruction
+	// stays as loaded; name resolution and target checks use the new config.
+	if infraSrc != nil {
+		go infraSrc.Watch(ctx, agentutil.InfraRefreshInterval, func(ic *infra.Config) {
+			infraConfigMu.Lock()
+			infraConfig = ic
+			infraConfigMu.Unlock()
+		})
+	}
+
 	k8sAgent, err := llmagent.New(llmagent.Config{
 		Name:        "k8s_agent",
 		Description: "Kubernetes troubleshooting agent that can inspect pods, services, endpoints, events, and logs to diagnose infrastructure issues.",
 		Instruction: instruction,
 		Model:       llmModel,
 		Tools:       tools,
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{
+			argValidator.BeforeToolCallback(),
+		},
 		AfterModelCallbacks: []llmagent.AfterModelCallback{
 			agentutil.NewReasoningCallback(toolAuditor),
 		},
@@ -162,6 +195,13 @@ func main() {
 			"k8s_agent-delete_pod":            {"kubernetes", "pods", "remediation"},
 			"k8s_agent-restart_deployment":    {"kubernetes", "deployments", "remediation"},
 			"k8s_agent-scale_deployment":      {"kubernetes", "deployments", "remediation"},
+			"k8s_agent-get_pvc_status":        {"kubernetes", "storage", "debugging"},
+			"k8s_agent-get_storageclass":      {"kubernetes", "storage", "cluster"},
+			"k8s_agent-get_namespaces":        {"kubernetes", "namespaces", "cluster"},
+			"k8s_agent-describe_pv":           {"kubernetes", "storage", "debugging"},
+			"k8s_agent-cordon_node":           {"kubernetes", "nodes", "remediation"},
+			"k8s_agent-drain_node":            {"kubernetes", "nodes", "remediation"},
+			"k8s_agent-uncordon_node":         {"kubernetes", "nodes", "remediation"},
 		},
 		SkillExamples: map[string][]string{
 			"k8s_agent-get_pods":      {"List all pods in the database namespace"},
@@ -173,7 +213,9 @@ func main() {
 		ToolSchemas:     agentutil.ComputeInputSchemas(tools),
 	}
 
-	if err := agentserve.ServeWithTracingAndDirectTools(ctx, k8sAgent, cfg, traceStore, auditStore, NewK8sDirectRegistry(), cardOpts); err != nil {
+	directTools := NewK8sDirectRegistry()
+	directTools.SetArgValidator(argValidator)
+	if err := agentserve.ServeWithTracingAndDirectTools(ctx, k8sAgent, cfg, traceStore, auditStore, directTools, cardOpts); err != nil {
 		slog.Error("server stopped", "err", err)
 		os.Exit(1)
 	}
@@ -220,7 +262,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	getPodLogsToolDef, err := functiontool.New(functiontool.Config{
+	getPodLogsToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "get_pod_logs",
 		Description: "Retrieve logs from a Kubernetes pod. Can get logs from specific containers and previous crashed instances.",
 	}, getPodLogsTool)
@@ -228,7 +270,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	readPodFileToolDef, err := functiontool.New(functiontool.Config{
+	readPodFileToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "read_pod_file",
 		Description: "Read a file directly from inside a running pod via kubectl exec. Use when PostgreSQL logs to a file (logging_collector=on) rather than stdout, making get_pod_logs return empty. Supports optional line-count limit and keyword filter.",
 	}, readPodFileTool)
@@ -252,7 +294,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	deletePodToolDef, err := functiontool.New(functiontool.Config{
+	deletePodToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "delete_pod",
 		Description: "Delete a Kubernetes pod by name. The deployment controller will reschedule it. Use to restart a stuck or crash-looping pod without affecting other replicas.",
 	}, deletePodTool)
@@ -268,7 +310,7 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
-	scaleDeploymentToolDef, err := functiontool.New(functiontool.Config{
+	scaleDeploymentToolDef, err := agentutil.NewTool(functiontool.Config{
 		Name:        "scale_deployment",
 		Description: "Scale a deployment to the specified number of replicas. Can scale up to add capacity or scale down (including to 0) to stop workloads. Use with caution — scaling to 0 causes downtime.",
 	}, scaleDeploymentTool)
@@ -292,6 +334,62 @@ func createTools() ([]tool.Tool, error) {
 		return nil, err
 	}
 
+	getPVCStatusToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_pvc_status",
+		Description: "Show PersistentVolumeClaims (status, bound volume, capacity, StorageClass, pods mounting them) and detect common storage failures: pending claims with a missing StorageClass or provisioner, failed provisioning, lost volumes, and volume attach or mount errors on the pods. Use when a pod is Pending or stuck in ContainerCreating.",
+	}, getPVCStatusTool)
+	if err != nil {
+		return nil, err
+	}
+
+	getStorageClassToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_storageclass",
+		Description: "List StorageClasses with their provisioner, reclaim policy, volume binding mode, expansion support and which one is the default.",
+	}, getStorageClassTool)
+	if err != nil {
+		return nil, err
+	}
+
+	getNamespacesToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "get_namespaces",
+		Description: "List the cluster's namespaces with their status and age, and whether the infrastructure config allowlists each. Use to check that a namespace exists.",
+	}, getNamespacesTool)
+	if err != nil {
+		return nil, err
+	}
+
+	describePVToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "describe_pv",
+		Description: "Describe PersistentVolumes: status, bound claim, backing storage (CSI driver and volume handle), node affinity, and CSI attachments to nodes with any attach or detach error. Detects failed and released volumes.",
+	}, describePVTool)
+	if err != nil {
+		return nil, err
+	}
+
+	cordonNodeToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "cordon_node",
+		Description: "Mark a node unschedulable (kubectl cordon) so no new pods land on it. Running pods are not touched. Use before maintenance or to isolate a faulty node; undo with uncordon_node.",
+	}, cordonNodeTool)
+	if err != nil {
+		return nil, err
+	}
+
+	drainNodeToolDef, err := agentutil.NewTool(functiontool.Config{
+		Name:        "drain_node",
+		Description: "Cordon a node and evict its pods so they are rescheduled elsewhere (kubectl drain). DaemonSet and static pods stay. Always call with dry_run=true first: it lists every pod that would move and any PodDisruptionBudget or unmanaged pod that would block the drain. The real drain requires approval.",
+	}, drainNodeTool)
+	if err != nil {
+		return nil, err
+	}
+
+	uncordonNodeToolDef, err := functiontool.New(functiontool.Config{
+		Name:        "uncordon_node",
+		Description: "Mark a node schedulable again (kubectl uncordon) after maintenance or a drain.",
+	}, uncordonNodeTool)
+	if err != nil {
+		return nil, err
+	}
+
 	return []tool.Tool{
 		getPodsToolDef,
 		getServiceToolDef,
@@ -307,6 +405,13 @@ func createTools() ([]tool.Tool, error) {
 		scaleDeploymentToolDef,
 		getPodResourcesToolDef,
 		getNodeStatusToolDef,
+		getPVCStatusToolDef,
+		getStorageClassToolDef,
+		getNamespacesToolDef,
+		describePVToolDef,
+		cordonNodeToolDef,
+		drainNodeToolDef,
+		uncordonNodeToolDef,
 	}, nil
 }
 
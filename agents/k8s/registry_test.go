@@ -26,6 +26,13 @@ var expectedK8sTools = []string{
 	"scale_deployment",
 	"get_pod_resources",
 	"get_node_status",
+	"get_pvc_status",
+	"get_storageclass",
+	"get_namespaces",
+	"describe_pv",
+	"cordon_node",
+	"drain_node",
+	"uncordon_node",
 }
 
 func TestK8sDirectRegistry_AllToolsRegistered(t *testing.T) {
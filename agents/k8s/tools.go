@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,6 +20,7 @@ import (
 	"helpdesk/agentutil"
 	"helpdesk/agentutil/retryutil"
 	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
 	"helpdesk/internal/policy"
 )
 
@@ -34,72 +34,208 @@ var policyEnforcer *agentutil.PolicyEnforcer
 // verification. Overridable in tests to use zero delays.
 var verifyRetryConfig = retryutil.Default
 
+// clusterInfo identifies the cluster a tool call runs against.
+type clusterInfo struct {
+	Name       string   // registered cluster name; "" when not resolved to one
+	Context    string   // kubeconfig context; "" = current context (or in-cluster config)
+	Kubeconfig string   // kubeconfig file; "" = KUBECONFIG or ~/.kube/config
+	Tags       []string // cluster policy tags
+	// Namespaces is the cluster's namespace allowlist; empty = any namespace.
+	Namespaces map[string]infra.K8sNamespace
+}
+
+// key identifies the cluster in the clientset cache.
+func (c clusterInfo) key() string {
+	if c.Kubeconfig == "" {
+		return c.Context
+	}
+	return c.Kubeconfig + "#" + c.Context
+}
+
+// auditParams adds the cluster and context a call ran against to params.
+func (c clusterInfo) auditParams(params map[string]any) map[string]any {
+	params["context"] = c.Context
+	if c.Name != "" {
+		params["cluster"] = c.Name
+	}
+	return params
+}
+
+func registeredCluster(name string, cluster infra.K8sCluster) clusterInfo {
+	return clusterInfo{
+		Name:       name,
+		Context:    cluster.Context,
+		Kubeconfig: cluster.Kubeconfig,
+		Tags:       cluster.Tags,
+		Namespaces: cluster.Namespaces,
+	}
+}
+
+// namespaceTags returns the cluster's tags plus those of namespace, if it is
+// allowlisted with tags of its own.
+func (c clusterInfo) namespaceTags(namespace string) []string {
+	return infra.MergeTags(c.Namespaces[namespace].Tags, c.Tags)
+}
+
+// checkNamespaceAllowed rejects a namespace outside the cluster's allowlist.
+func (c clusterInfo) checkNamespaceAllowed(namespace string) error {
+	if _, ok := c.Namespaces[namespace]; ok || len(c.Namespaces) == 0 {
+		return nil
+	}
+	allowed := make([]string, 0, len(c.Namespaces))
+	for ns := range c.Namespaces {
+		allowed = append(allowed, ns)
+	}
+	sort.Strings(allowed)
+	return fmt.Errorf("namespace %q is not allowlisted in kubernetes cluster %q; "+
+		"contact your IT administrator to add it. Allowed namespaces: %s",
+		namespace, c.Name, strings.Join(allowed, ", "))
+}
+
+// resolveClusterInfo resolves a tool's cluster and context arguments. A
+// cluster name is looked up in the infrastructure config's k8s_clusters;
+// when infraConfig is set and the cluster is not registered, returns an error
+// (hard reject) so callers can fail before any tool execution. Without a
+// cluster name, a database name resolves to its cluster, and any other value
+// is used as a kubeconfig context, matched to the registered cluster that
+// uses it (if any) for its kubeconfig and tags.
+func resolveClusterInfo(clusterName, contextOrDBName string) (clusterInfo, error) {
+	infraConfigMu.RLock()
+	ic := infraConfig
+	infraConfigMu.RUnlock()
+
+	clusterName = strings.TrimSpace(clusterName)
+	contextOrDBName = strings.TrimSpace(contextOrDBName)
+
+	if ic == nil {
+		// Dev mode: no infra config — a cluster name is taken as a context.
+		if clusterName != "" {
+			return clusterInfo{Context: clusterName}, nil
+		}
+		return clusterInfo{Context: contextOrDBName}, nil
+	}
+
+	if clusterName != "" {
+		if cluster, ok := ic.K8sClusters[clusterName]; ok {
+			return registeredCluster(clusterName, cluster), nil
+		}
+		return clusterInfo{}, fmt.Errorf(
+			"kubernetes cluster %q not registered in infrastructure config; "+
+				"contact your IT administrator to add it. Known clusters: %s",
+			clusterName, strings.Join(sortedClusterNames(ic), ", "))
+	}
+
+	if db, ok := ic.DBServers[contextOrDBName]; ok && db.K8sCluster != "" {
+		if cluster, ok := ic.K8sClusters[db.K8sCluster]; ok {
+			slog.Info("resolved database name to cluster", "name", contextOrDBName, "cluster", db.K8sCluster, "context", cluster.Context)
+			return registeredCluster(db.K8sCluster, cluster), nil
+		}
+	}
+	if contextOrDBName != "" {
+		for _, name := range sortedClusterNames(ic) {
+			if cluster := ic.K8sClusters[name]; cluster.Context == contextOrDBName {
+				return registeredCluster(name, cluster), nil
+			}
+		}
+	}
+	return clusterInfo{Context: contextOrDBName}, nil
+}
+
+func sortedClusterNames(ic *infra.Config) []string {
+	names := make([]string, 0, len(ic.K8sClusters))
+	for name := range ic.K8sClusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // namespaceInfo holds resolved namespace information for policy checks.
 type namespaceInfo struct {
 	Namespace string
 	Tags      []string
+	Cluster   string // cluster of the database the namespace belongs to, if any
 }
 
-// resolveNamespaceInfo resolves a namespace or database name to full info,
-// including policy tags. Tag resolution priority:
-//  1. DB name match → use DBServer.Tags
-//  2. DB K8s namespace match → use DBServer.Tags
-//  3. K8s cluster match by context → use K8sCluster.Tags (non-DB namespaces)
+// resolveNamespaceInfo resolves a namespace or database name in cluster to
+// full info, including policy tags. Tag resolution priority:
+//  1. DB name match → use DBServer.Tags plus the namespace's and cluster's tags
+//  2. DB K8s namespace match → use DBServer.Tags plus the namespace's and cluster's tags
+//  3. Registered cluster → use the namespace's and cluster's tags (non-DB
+//     namespaces), provided the cluster's namespace allowlist admits it
 //
-// When infraConfig is set and the namespace matches none of the above, returns
-// an error (hard reject) so callers can fail before any tool execution.
-func resolveNamespaceInfo(namespaceOrDBName, contextOrDBName string) (namespaceInfo, error) {
+// Databases hosted on another registered cluster than the resolved one do not
+// match. When infraConfig is set and the namespace matches none of the above,
+// or is not allowlisted, returns an error (hard reject) so callers can fail
+// before any tool execution.
+func resolveNamespaceInfo(namespaceOrDBName string, cluster clusterInfo) (namespaceInfo, error) {
+	infraConfigMu.RLock()
+	ic := infraConfig
+	infraConfigMu.RUnlock()
+
 	namespaceOrDBName = strings.TrimSpace(namespaceOrDBName)
 	if namespaceOrDBName == "" {
 		return namespaceInfo{Namespace: namespaceOrDBName}, nil
 	}
 
-	if infraConfig != nil {
+	if ic != nil {
+		inCluster := func(db infra.DBServer) bool {
+			return cluster.Name == "" || db.K8sCluster == "" || db.K8sCluster == cluster.Name
+		}
 		// Check if input is a registered database name with a K8s namespace.
-		if db, ok := infraConfig.DBServers[namespaceOrDBName]; ok {
-			if db.K8sNamespace != "" {
-				slog.Info("resolved database name to namespace", "name", namespaceOrDBName, "namespace", db.K8sNamespace)
-				return namespaceInfo{
-					Namespace: db.K8sNamespace,
-					Tags:      db.Tags,
-				}, nil
+		if db, ok := ic.DBServers[namespaceOrDBName]; ok && db.K8sNamespace != "" {
+			if !inCluster(db) {
+				return namespaceInfo{}, fmt.Errorf("database %q runs in kubernetes cluster %q, not %q",
+					namespaceOrDBName, db.K8sCluster, cluster.Name)
 			}
+			slog.Info("resolved database name to namespace", "name", namespaceOrDBName, "namespace", db.K8sNamespace)
+			return namespaceInfo{
+				Namespace: db.K8sNamespace,
+				Tags:      infra.MergeTags(db.Tags, cluster.namespaceTags(db.K8sNamespace)),
+				Cluster:   db.K8sCluster,
+			}, nil
 		}
 		// Check if input is the actual K8s namespace of a registered database.
-		for _, db := range infraConfig.DBServers {
-			if db.K8sNamespace == namespaceOrDBName {
+		for _, db := range ic.DBServers {
+			if db.K8sNamespace == namespaceOrDBName && inCluster(db) {
 				return namespaceInfo{
 					Namespace: namespaceOrDBName,
-					Tags:      db.Tags,
+					Tags:      infra.MergeTags(db.Tags, cluster.namespaceTags(namespaceOrDBName)),
+					Cluster:   db.K8sCluster,
 				}, nil
 			}
 		}
-		// Not a DB namespace — look up cluster-level tags by resolved context.
-		// This allows non-DB namespaces to inherit their cluster's policy tags.
-		// When no context is specified and there is exactly one cluster registered,
-		// use that cluster as the default (avoids requiring callers to always pass context).
-		resolvedCtx := resolveContext(contextOrDBName)
-		if resolvedCtx == "" && len(infraConfig.K8sClusters) == 1 {
-			for _, cluster := range infraConfig.K8sClusters {
-				slog.Info("resolved namespace tags from sole cluster", "namespace", namespaceOrDBName, "context", cluster.Context, "tags", cluster.Tags)
-				return namespaceInfo{
-					Namespace: namespaceOrDBName,
-					Tags:      cluster.Tags,
-				}, nil
+		// Not a DB namespace — use the registered cluster's policy tags.
+		if cluster.Name != "" {
+			if err := cluster.checkNamespaceAllowed(namespaceOrDBName); err != nil {
+				return namespaceInfo{}, err
 			}
+			tags := cluster.namespaceTags(namespaceOrDBName)
+			slog.Info("resolved namespace tags from cluster", "namespace", namespaceOrDBName, "cluster", cluster.Name, "tags", tags)
+			return namespaceInfo{
+				Namespace: namespaceOrDBName,
+				Tags:      tags,
+			}, nil
 		}
-		for _, cluster := range infraConfig.K8sClusters {
-			if cluster.Context == resolvedCtx {
-				slog.Info("resolved namespace tags from cluster", "namespace", namespaceOrDBName, "context", resolvedCtx, "tags", cluster.Tags)
+		// When no context is specified and there is exactly one cluster registered,
+		// use that cluster as the default (avoids requiring callers to always pass context).
+		if cluster.Context == "" && len(ic.K8sClusters) == 1 {
+			for name, k8s := range ic.K8sClusters {
+				sole := registeredCluster(name, k8s)
+				if err := sole.checkNamespaceAllowed(namespaceOrDBName); err != nil {
+					return namespaceInfo{}, err
+				}
+				tags := sole.namespaceTags(namespaceOrDBName)
+				slog.Info("resolved namespace tags from sole cluster", "namespace", namespaceOrDBName, "context", sole.Context, "tags", tags)
 				return namespaceInfo{
 					Namespace: namespaceOrDBName,
-					Tags:      cluster.Tags,
+					Tags:      tags,
 				}, nil
 			}
 		}
 		// infraConfig is set but namespace not registered — hard reject.
-		known := make([]string, 0, len(infraConfig.DBServers))
-		for id := range infraConfig.DBServers {
+		known := make([]string, 0, len(ic.DBServers))
+		for id := range ic.DBServers {
 			known = append(known, id)
 		}
 		sort.Strings(known)
@@ -113,25 +249,25 @@ func resolveNamespaceInfo(namespaceOrDBName, contextOrDBName string) (namespaceI
 	return namespaceInfo{Namespace: namespaceOrDBName}, nil
 }
 
-// resolveContext checks if the input looks like a database name from the
-// infrastructure config and returns the associated K8s context. If not found
-// or not a database name, returns the input unchanged.
-func resolveContext(contextOrDBName string) string {
-	contextOrDBName = strings.TrimSpace(contextOrDBName)
-
-	// Try to look up as a database name in the infrastructure config
-	if infraConfig != nil {
-		if db, ok := infraConfig.DBServers[contextOrDBName]; ok {
-			if db.K8sCluster != "" {
-				if cluster, ok := infraConfig.K8sClusters[db.K8sCluster]; ok {
-					slog.Info("resolved database name to context", "name", contextOrDBName, "context", cluster.Context)
-					return cluster.Context
-				}
-			}
+// resolveTarget resolves the cluster, context and namespace arguments of a
+// namespaced tool. When neither a cluster nor a context is given, a database
+// name or namespace also selects the database's cluster.
+func resolveTarget(clusterName, contextOrDBName, namespaceOrDBName string) (clusterInfo, namespaceInfo, error) {
+	cluster, err := resolveClusterInfo(clusterName, contextOrDBName)
+	if err != nil {
+		return clusterInfo{}, namespaceInfo{}, err
+	}
+	nsInfo, err := resolveNamespaceInfo(namespaceOrDBName, cluster)
+	if err != nil {
+		return clusterInfo{}, namespaceInfo{}, err
+	}
+	if cluster.Name == "" && cluster.Context == "" && nsInfo.Cluster != "" {
+		if dbCluster, err := resolveClusterInfo(nsInfo.Cluster, ""); err == nil {
+			cluster = dbCluster
+			nsInfo.Tags = infra.MergeTags(nsInfo.Tags, cluster.namespaceTags(nsInfo.Namespace))
 		}
 	}
-
-	return contextOrDBName
+	return cluster, nsInfo, nil
 }
 
 // diagnoseKubectlError examines kubectl output for common failure patterns and returns
@@ -211,6 +347,7 @@ func checkK8sPolicy(ctx context.Context, namespace string, action policy.ActionC
 //	service "baz" created
 //	deployment.apps "bar" restarted
 //	deployment.apps "bar" scaled
+//	pod/foo evicted
 func parsePodsAffected(output string) int {
 	count := 0
 	for _, line := range strings.Split(output, "\n") {
@@ -219,7 +356,8 @@ func parsePodsAffected(output string) int {
 			strings.HasSuffix(line, " configured") ||
 			strings.HasSuffix(line, " created") ||
 			strings.HasSuffix(line, " restarted") ||
-			strings.HasSuffix(line, " scaled") {
+			strings.HasSuffix(line, " scaled") ||
+			strings.HasSuffix(line, " evicted") {
 			count++
 		}
 	}
@@ -227,22 +365,22 @@ func parsePodsAffected(output string) int {
 }
 
 // checkK8sPolicyResult runs a post-execution policy check for a Kubernetes
-// operation, enforcing blast-radius conditions with the actual resource count.
-// Call this after write or destructive kubectl commands.
-func checkK8sPolicyResult(ctx context.Context, namespace string, action policy.ActionClass, tags []string, output string, execErr error) error {
+// operation, enforcing blast-radius conditions with the number of pods the
+// operation affected. Call this after write or destructive kubectl commands.
+func checkK8sPolicyResult(ctx context.Context, namespace string, action policy.ActionClass, tags []string, podsAffected int, execErr error) error {
 	if policyEnforcer == nil {
 		return nil
 	}
 	return policyEnforcer.CheckKubernetesResult(ctx, namespace, action, tags, agentutil.ToolOutcome{
-		PodsAffected: parsePodsAffected(output),
+		PodsAffected: podsAffected,
 		Err:          execErr,
 	})
 }
 
 // checkK8sBlastRadiusPreExec runs a pre-execution blast-radius check with a
-// caller-supplied resource count. Use when the target count is available before
-// execution (e.g. args.Replicas for scale_deployment). No-op when policyEnforcer
-// is nil.
+// caller-supplied resource count. Use when the count is available before
+// execution (e.g. the replicas a scale_deployment adds or removes). No-op when
+// policyEnforcer is nil.
 func checkK8sBlastRadiusPreExec(ctx context.Context, namespace string, action policy.ActionClass, tags []string, podsAffected int) error {
 	if policyEnforcer == nil {
 		return nil
@@ -252,21 +390,92 @@ func checkK8sBlastRadiusPreExec(ctx context.Context, namespace string, action po
 	})
 }
 
+// deploymentReplicas reads the desired replica count of a deployment. ok is
+// false when the deployment cannot be read.
+func deploymentReplicas(ctx context.Context, cluster clusterInfo, namespace, deployment string) (replicas int, ok bool) {
+	out, err := runKubectl(ctx, cluster, "get", "deployment", deployment,
+		"-n", namespace, "-o", "jsonpath={.spec.replicas}")
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// scalePodsAffected returns the number of pods a scale from current to target
+// replicas starts or stops. current is -1 when unknown; the target count is
+// used then.
+func scalePodsAffected(current, target int) int {
+	if current < 0 {
+		return target
+	}
+	if target > current {
+		return target - current
+	}
+	return current - target
+}
+
 // runKubectl is the kubectl execution function. Tests replace this variable to
 // inject mock output without spawning a real kubectl process.
 var runKubectl = runKubectlExec
 
-// runKubectlExec is the production kubectl runner: it prepends the timeout and
-// optional context flags, invokes kubectl, and returns structured errors.
-func runKubectlExec(ctx context.Context, kubeContext string, args ...string) (string, error) {
+// kubectlSandbox allowlists the kubectl subcommands and flags this agent uses.
+// Pod and deployment names come from LLM tool arguments; the sandbox ensures a
+// name such as "--all" is rejected instead of being parsed as a flag.
+var kubectlSandbox agentutil.CommandRunner = agentutil.NewSandboxRunner(map[string]agentutil.CommandSpec{
+	"kubectl": {
+		Subcommands: []string{"get", "top", "scale", "delete", "rollout", "cordon", "uncordon", "drain"},
+		Flags: map[string]bool{
+			"--request-timeout":      true,
+			"--context":              true,
+			"--kubeconfig":           true,
+			"-n":                     true,
+			"-o":                     true,
+			"--replicas":             true,
+			"--grace-period":         true,
+			"--timeout":              true,
+			"--no-headers":           false,
+			"--ignore-daemonsets":    false,
+			"--delete-emptydir-data": false,
+		},
+		Timeout: time.Minute,
+		// delete waits for graceful pod termination; drain for every eviction
+		// (bounded by its own --timeout).
+		ToolTimeouts: map[string]time.Duration{
+			"delete_pod": 5 * time.Minute,
+			"drain_node": drainTimeout + time.Minute,
+		},
+	},
+})
+
+// kubectlArgs prepends the request timeout and the cluster's kubeconfig and
+// context flags.
+func kubectlArgs(cluster clusterInfo, args []string) []string {
 	prefix := []string{"--request-timeout=10s"}
-	if kubeContext != "" {
-		prefix = append(prefix, "--context", kubeContext)
+	return append(append(prefix, clusterFlags(cluster)...), args...)
+}
+
+// clusterFlags returns the kubectl flags that select cluster.
+func clusterFlags(cluster clusterInfo) []string {
+	var flags []string
+	if cluster.Kubeconfig != "" {
+		flags = append(flags, "--kubeconfig", cluster.Kubeconfig)
+	}
+	if cluster.Context != "" {
+		flags = append(flags, "--context", cluster.Context)
 	}
-	fullArgs := append(prefix, args...)
-	output, err := exec.CommandContext(ctx, "kubectl", fullArgs...).CombinedOutput()
+	return flags
+}
+
+// runKubectlExec is the production kubectl runner: it executes kubectl through
+// the command sandbox and returns structured errors.
+func runKubectlExec(ctx context.Context, cluster clusterInfo, args ...string) (string, error) {
+	output, err := kubectlSandbox.Run(ctx, "kubectl", kubectlArgs(cluster, args), nil)
 	if err != nil {
-		out := strings.TrimSpace(string(output))
+		out := strings.TrimSpace(output)
 		if out == "" {
 			out = "(no output from kubectl)"
 		}
@@ -278,37 +487,45 @@ func runKubectlExec(ctx context.Context, kubeContext string, args ...string) (st
 		}
 		return "", fmt.Errorf("kubectl failed: %v\nOutput: %s", err, out)
 	}
-	return string(output), nil
+	return output, nil
 }
 
 // runKubectlWithToolName wraps runKubectl with timing, audit logging, and slog.
 // toolName is used for audit logging; if empty, no audit is recorded.
-func runKubectlWithToolName(ctx context.Context, kubeContext, toolName string, args ...string) (string, error) {
-	return runKubectlAndRecord(ctx, kubeContext, toolName, nil, args...)
+func runKubectlWithToolName(ctx context.Context, cluster clusterInfo, toolName string, args ...string) (string, error) {
+	return runKubectlAndRecord(ctx, cluster, toolName, nil, args...)
 }
 
 // runKubectlAndRecord is the underlying implementation of runKubectlWithToolName.
 // preState is optional JSON captured before the mutation for rollback support;
 // pass nil for read-only operations or tools that don't support rollback.
-func runKubectlAndRecord(ctx context.Context, kubeContext, toolName string, preState json.RawMessage, args ...string) (string, error) {
+func runKubectlAndRecord(ctx context.Context, cluster clusterInfo, toolName string, preState json.RawMessage, args ...string) (string, error) {
+	return runKubectlAndRecordParams(ctx, cluster, toolName, nil, preState, args...)
+}
+
+// runKubectlAndRecordParams is runKubectlAndRecord with extra audit
+// parameters, e.g. the pods a drain evicts.
+func runKubectlAndRecordParams(ctx context.Context, cluster clusterInfo, toolName string, params map[string]any, preState json.RawMessage, args ...string) (string, error) {
 	start := time.Now()
-	output, err := runKubectl(ctx, kubeContext, args...)
+	output, err := runKubectl(agentutil.WithToolName(ctx, toolName), cluster, args...)
 	duration := time.Since(start)
 
-	rawCommand := "kubectl " + strings.Join(args, " ")
-	if kubeContext != "" {
-		rawCommand = "kubectl --context " + kubeContext + " " + strings.Join(args, " ")
-	}
+	rawCommand := strings.Join(append(append([]string{"kubectl"}, clusterFlags(cluster)...), args...), " ")
 
 	if toolAuditor != nil && toolName != "" {
 		var errMsg string
 		if err != nil {
 			errMsg = err.Error()
 		}
+		auditParams := map[string]any{"args": args}
+		for k, v := range params {
+			auditParams[k] = v
+		}
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       toolName,
-			Parameters: map[string]any{"context": kubeContext, "args": args},
+			Parameters: cluster.auditParams(auditParams),
 			RawCommand: rawCommand,
+			Argv:       agentutil.AuditArgv("kubectl", kubectlArgs(cluster, args)),
 			PreState:   preState,
 		}, audit.ToolResult{
 			Output: truncateForAudit(output, 500),
@@ -371,19 +588,19 @@ type KubectlResult struct {
 
 // GetPodsArgs defines arguments for the get_pods tool.
 type GetPodsArgs struct {
-	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace string `json:"namespace" jsonschema:"The Kubernetes namespace to list pods from. Use 'all' for all namespaces."`
 	Labels    string `json:"labels,omitempty" jsonschema:"Optional label selector to filter pods (e.g., 'app=postgres')."`
 }
 
 func getPodsImpl(ctx context.Context, args GetPodsArgs) (GetPodsResult, error) {
 	// Resolve database name to namespace/context if applicable
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return GetPodsResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
@@ -396,14 +613,13 @@ func getPodsImpl(ctx context.Context, args GetPodsArgs) (GetPodsResult, error) {
 	}
 
 	start := time.Now()
-	result, err := fetchPods(ctx, kubeContext, namespace, args.Labels)
+	result, err := fetchPods(ctx, cluster, namespace, args.Labels)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_pods", map[string]any{
-		"context":   kubeContext,
+	recordClientGoAudit(ctx, "get_pods", cluster.auditParams(map[string]any{
 		"namespace": namespace,
 		"labels":    args.Labels,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -414,19 +630,19 @@ func getPodsTool(ctx tool.Context, args GetPodsArgs) (GetPodsResult, error) {
 
 // GetServiceArgs defines arguments for the get_service tool.
 type GetServiceArgs struct {
-	Context     string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster     string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context     string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace   string `json:"namespace" jsonschema:"The Kubernetes namespace to list services from."`
 	ServiceName string `json:"service_name,omitempty" jsonschema:"Optional specific service name to get. If empty, lists all services."`
 	ServiceType string `json:"service_type,omitempty" jsonschema:"Optional filter by service type: ClusterIP, NodePort, LoadBalancer."`
 }
 
 func getServiceImpl(ctx context.Context, args GetServiceArgs) (GetServiceResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return GetServiceResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
@@ -434,15 +650,14 @@ func getServiceImpl(ctx context.Context, args GetServiceArgs) (GetServiceResult,
 	}
 
 	start := time.Now()
-	result, err := fetchServices(ctx, kubeContext, namespace, args.ServiceName, args.ServiceType)
+	result, err := fetchServices(ctx, cluster, namespace, args.ServiceName, args.ServiceType)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_service", map[string]any{
-		"context":      kubeContext,
+	recordClientGoAudit(ctx, "get_service", cluster.auditParams(map[string]any{
 		"namespace":    namespace,
 		"service_name": args.ServiceName,
 		"service_type": args.ServiceType,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -453,24 +668,24 @@ func getServiceTool(ctx tool.Context, args GetServiceArgs) (GetServiceResult, er
 
 // DescribeServiceArgs defines arguments for the describe_service tool.
 type DescribeServiceArgs struct {
-	Context     string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster     string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context     string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace   string `json:"namespace" jsonschema:"The Kubernetes namespace of the service."`
 	ServiceName string `json:"service_name" jsonschema:"The name of the service to describe."`
 }
 
 func describeServiceImpl(ctx context.Context, args DescribeServiceArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
-	cs, err := sharedClient.clientset(kubeContext)
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return KubectlResult{}, err
 	}
@@ -545,7 +760,7 @@ func describeServiceImpl(ctx context.Context, args DescribeServiceArgs) (Kubectl
 	if toolAuditor != nil {
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       "describe_service",
-			Parameters: map[string]any{"namespace": namespace, "service_name": args.ServiceName},
+			Parameters: cluster.auditParams(map[string]any{"namespace": namespace, "service_name": args.ServiceName}),
 		}, audit.ToolResult{
 			Output: truncateForAudit(sb.String(), 500),
 		}, duration)
@@ -560,18 +775,18 @@ func describeServiceTool(ctx tool.Context, args DescribeServiceArgs) (KubectlRes
 
 // GetEndpointsArgs defines arguments for the get_endpoints tool.
 type GetEndpointsArgs struct {
-	Context      string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster      string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context      string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace    string `json:"namespace" jsonschema:"The Kubernetes namespace to check endpoints in."`
 	EndpointName string `json:"endpoint_name,omitempty" jsonschema:"Optional specific endpoint name (usually matches service name)."`
 }
 
 func getEndpointsImpl(ctx context.Context, args GetEndpointsArgs) (GetEndpointsResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return GetEndpointsResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
@@ -579,14 +794,13 @@ func getEndpointsImpl(ctx context.Context, args GetEndpointsArgs) (GetEndpointsR
 	}
 
 	start := time.Now()
-	result, err := fetchEndpoints(ctx, kubeContext, namespace, args.EndpointName)
+	result, err := fetchEndpoints(ctx, cluster, namespace, args.EndpointName)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_endpoints", map[string]any{
-		"context":       kubeContext,
+	recordClientGoAudit(ctx, "get_endpoints", cluster.auditParams(map[string]any{
 		"namespace":     namespace,
 		"endpoint_name": args.EndpointName,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -597,19 +811,19 @@ func getEndpointsTool(ctx tool.Context, args GetEndpointsArgs) (GetEndpointsResu
 
 // GetEventsArgs defines arguments for the get_events tool.
 type GetEventsArgs struct {
-	Context      string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster      string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context      string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace    string `json:"namespace" jsonschema:"The Kubernetes namespace to get events from."`
 	ResourceName string `json:"resource_name,omitempty" jsonschema:"Optional filter events related to a specific resource name."`
 	EventType    string `json:"event_type,omitempty" jsonschema:"Optional filter by event type: Normal or Warning."`
 }
 
 func getEventsImpl(ctx context.Context, args GetEventsArgs) (GetEventsResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return GetEventsResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
@@ -617,15 +831,14 @@ func getEventsImpl(ctx context.Context, args GetEventsArgs) (GetEventsResult, er
 	}
 
 	start := time.Now()
-	result, err := fetchEvents(ctx, kubeContext, namespace, args.ResourceName, args.EventType)
+	result, err := fetchEvents(ctx, cluster, namespace, args.ResourceName, args.EventType)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_events", map[string]any{
-		"context":       kubeContext,
+	recordClientGoAudit(ctx, "get_events", cluster.auditParams(map[string]any{
 		"namespace":     namespace,
 		"resource_name": args.ResourceName,
 		"event_type":    args.EventType,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -636,28 +849,28 @@ func getEventsTool(ctx tool.Context, args GetEventsArgs) (GetEventsResult, error
 
 // GetPodLogsArgs defines arguments for the get_pod_logs tool.
 type GetPodLogsArgs struct {
-	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace string `json:"namespace,omitempty" jsonschema:"The Kubernetes namespace of the pod (e.g., 'default', 'kube-system')."`
 	PodName   string `json:"pod_name" jsonschema:"required,The exact pod name to get logs from (e.g., 'nginx-7d6877d777-abc12')."`
 	Container string `json:"container,omitempty" jsonschema:"Container name, only needed if pod has multiple containers."`
-	TailLines int    `json:"tail_lines,omitempty" jsonschema:"Number of recent log lines to retrieve (default 50)."`
+	TailLines int    `json:"tail_lines,omitempty" jsonschema:"Number of recent log lines to retrieve (default 50)." validate:"min=0"`
 	Previous  bool   `json:"previous,omitempty" jsonschema:"If true, get logs from the previous container instance (useful for crash loops)."`
 }
 
 func getPodLogsImpl(ctx context.Context, args GetPodLogsArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
-	cs, err := sharedClient.clientset(kubeContext)
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return KubectlResult{}, err
 	}
@@ -692,7 +905,7 @@ func getPodLogsImpl(ctx context.Context, args GetPodLogsArgs) (KubectlResult, er
 	if toolAuditor != nil {
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       "get_pod_logs",
-			Parameters: map[string]any{"namespace": namespace, "pod_name": args.PodName, "previous": args.Previous},
+			Parameters: cluster.auditParams(map[string]any{"namespace": namespace, "pod_name": args.PodName, "previous": args.Previous}),
 		}, audit.ToolResult{
 			Output: truncateForAudit(output, 500),
 		}, duration)
@@ -710,22 +923,22 @@ func getPodLogsTool(ctx tool.Context, args GetPodLogsArgs) (KubectlResult, error
 
 // ReadPodFileArgs defines arguments for the read_pod_file tool.
 type ReadPodFileArgs struct {
-	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace string `json:"namespace,omitempty" jsonschema:"The Kubernetes namespace of the pod."`
 	PodName   string `json:"pod_name" jsonschema:"required,The exact pod name to read a file from."`
 	Container string `json:"container,omitempty" jsonschema:"Container name, only needed if pod has multiple containers."`
 	FilePath  string `json:"file_path" jsonschema:"required,Absolute path of the file to read inside the container (e.g. '/var/lib/postgresql/data/log/postgresql.log')."`
 	Filter    string `json:"filter,omitempty" jsonschema:"Optional string to filter lines (case-insensitive grep). Use to focus on FATAL, PANIC, or other keywords."`
-	TailLines int    `json:"tail_lines,omitempty" jsonschema:"Return only the last N lines of the file (default: all lines)."`
+	TailLines int    `json:"tail_lines,omitempty" jsonschema:"Return only the last N lines of the file (default: all lines)." validate:"min=0"`
 }
 
 func readPodFileImpl(ctx context.Context, args ReadPodFileArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
@@ -741,7 +954,7 @@ func readPodFileImpl(ctx context.Context, args ReadPodFileArgs) (KubectlResult,
 		shellCmd = fmt.Sprintf("(%s) | grep -i %q || true", shellCmd, args.Filter)
 	}
 
-	cs, err := sharedClient.clientset(kubeContext)
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return KubectlResult{}, err
 	}
@@ -759,7 +972,7 @@ func readPodFileImpl(ctx context.Context, args ReadPodFileArgs) (KubectlResult,
 		}, scheme.ParameterCodec)
 
 	start := time.Now()
-	exec, err := remotecommand.NewSPDYExecutor(sharedClient.restConfig(kubeContext), "POST", execReq.URL())
+	exec, err := remotecommand.NewSPDYExecutor(sharedClient.restConfig(cluster), "POST", execReq.URL())
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("error reading file %s from pod %s: %v", args.FilePath, args.PodName, diagnoseClientError(err))
 	}
@@ -779,7 +992,7 @@ func readPodFileImpl(ctx context.Context, args ReadPodFileArgs) (KubectlResult,
 	if toolAuditor != nil {
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       "read_pod_file",
-			Parameters: map[string]any{"namespace": namespace, "pod_name": args.PodName, "file_path": args.FilePath},
+			Parameters: cluster.auditParams(map[string]any{"namespace": namespace, "pod_name": args.PodName, "file_path": args.FilePath}),
 		}, audit.ToolResult{
 			Output: truncateForAudit(output, 500),
 		}, duration)
@@ -798,25 +1011,25 @@ func readPodFileTool(ctx tool.Context, args ReadPodFileArgs) (KubectlResult, err
 
 // DescribePodArgs defines arguments for the describe_pod tool.
 type DescribePodArgs struct {
-	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace string `json:"namespace,omitempty" jsonschema:"The Kubernetes namespace of the pod (e.g., 'default', 'kube-system')."`
 	PodName   string `json:"pod_name" jsonschema:"required,The exact pod name to describe."`
 }
 
 func describePodImpl(ctx context.Context, args DescribePodArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	// Check policy before executing
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
-	cs, err := sharedClient.clientset(kubeContext)
+	cs, err := sharedClient.clientset(cluster)
 	if err != nil {
 		return KubectlResult{}, err
 	}
@@ -852,7 +1065,7 @@ func describePodImpl(ctx context.Context, args DescribePodArgs) (KubectlResult,
 	if toolAuditor != nil {
 		toolAuditor.RecordToolCall(ctx, audit.ToolCall{
 			Name:       "describe_pod",
-			Parameters: map[string]any{"namespace": namespace, "pod_name": args.PodName},
+			Parameters: cluster.auditParams(map[string]any{"namespace": namespace, "pod_name": args.PodName}),
 		}, audit.ToolResult{
 			Output: truncateForAudit(sb.String(), 500),
 		}, duration)
@@ -867,26 +1080,29 @@ func describePodTool(ctx tool.Context, args DescribePodArgs) (KubectlResult, err
 
 // GetNodesArgs defines arguments for the get_nodes tool.
 type GetNodesArgs struct {
-	Context    string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster    string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context    string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	ShowLabels bool   `json:"show_labels,omitempty" jsonschema:"If true, show node labels in output."`
 }
 
 func getNodesImpl(ctx context.Context, args GetNodesArgs) (GetNodesResult, error) {
-	kubeContext := resolveContext(args.Context)
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
+	if err != nil {
+		return GetNodesResult{}, fmt.Errorf("access denied: %w", err)
+	}
 
 	// Nodes are cluster-scoped; use "cluster" as the sentinel resource_name.
-	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, nil); err != nil {
+	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, cluster.Tags); err != nil {
 		return GetNodesResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
 	start := time.Now()
-	result, err := fetchNodes(ctx, kubeContext, args.ShowLabels)
+	result, err := fetchNodes(ctx, cluster, args.ShowLabels)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_nodes", map[string]any{
-		"context":     kubeContext,
+	recordClientGoAudit(ctx, "get_nodes", cluster.auditParams(map[string]any{
 		"show_labels": args.ShowLabels,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -897,19 +1113,19 @@ func getNodesTool(ctx tool.Context, args GetNodesArgs) (GetNodesResult, error) {
 
 // DeletePodArgs defines arguments for the delete_pod tool.
 type DeletePodArgs struct {
-	Context          string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster          string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context          string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace        string `json:"namespace" jsonschema:"required,The Kubernetes namespace of the pod."`
 	PodName          string `json:"pod_name" jsonschema:"required,The exact pod name to delete. Use get_pods to find the name."`
-	GracePeriodSeconds int  `json:"grace_period_seconds,omitempty" jsonschema:"Seconds for graceful termination (default: pod's terminationGracePeriodSeconds). Use 0 for immediate deletion."`
+	GracePeriodSeconds int  `json:"grace_period_seconds,omitempty" jsonschema:"Seconds for graceful termination (default: pod's terminationGracePeriodSeconds). Use 0 for immediate deletion." validate:"min=0"`
 }
 
 func deletePodImpl(ctx context.Context, args DeletePodArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionDestructive, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
@@ -920,12 +1136,12 @@ func deletePodImpl(ctx context.Context, args DeletePodArgs) (KubectlResult, erro
 		cmdArgs = append(cmdArgs, "--grace-period", strconv.Itoa(args.GracePeriodSeconds))
 	}
 
-	output, err := runKubectlWithToolName(ctx, kubeContext, "delete_pod", cmdArgs...)
+	output, err := runKubectlWithToolName(ctx, cluster, "delete_pod", cmdArgs...)
 	if err != nil {
 		return KubectlResult{Output: fmt.Sprintf("ERROR: %v", err)}, nil
 	}
 
-	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, output, err); postErr != nil {
+	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, parsePodsAffected(output), err); postErr != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied after execution: %w", postErr)
 	}
 
@@ -934,7 +1150,7 @@ func deletePodImpl(ctx context.Context, args DeletePodArgs) (KubectlResult, erro
 	// shutdown or finalizers that delay removal from the API server.
 	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyRetryConfig,
 		func() (bool, error) {
-			_, err := runKubectl(ctx, kubeContext, "get", "pod", args.PodName, "-n", namespace)
+			_, err := runKubectl(ctx, cluster, "get", "pod", args.PodName, "-n", namespace)
 			return err != nil, nil // "not found" error means pod is gone → resolved
 		},
 		func(attempt int, r bool) {
@@ -972,30 +1188,44 @@ func deletePodTool(ctx tool.Context, args DeletePodArgs) (KubectlResult, error)
 
 // RestartDeploymentArgs defines arguments for the restart_deployment tool.
 type RestartDeploymentArgs struct {
-	Context        string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster        string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context        string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace      string `json:"namespace" jsonschema:"required,The Kubernetes namespace of the deployment."`
 	DeploymentName string `json:"deployment" jsonschema:"required,The name of the deployment to restart. Use get_pods or kubectl get deployments to find the name."`
 }
 
 func restartDeploymentImpl(ctx context.Context, args RestartDeploymentArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionDestructive, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
+	// Pre-execution blast radius: a rollout restart replaces every replica of
+	// the deployment. Skipped when the replica count cannot be read; the
+	// post-execution check is the backstop.
+	replicas := 0
+	if policyEnforcer != nil {
+		if n, ok := deploymentReplicas(ctx, cluster, namespace, args.DeploymentName); ok {
+			replicas = n
+			if err := checkK8sBlastRadiusPreExec(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, replicas); err != nil {
+				return KubectlResult{}, fmt.Errorf("blast radius check denied (%d replicas): %w", replicas, err)
+			}
+		}
+	}
+
 	cmdArgs := []string{"rollout", "restart", "deployment", args.DeploymentName, "-n", namespace}
-	output, err := runKubectlWithToolName(ctx, kubeContext, "restart_deployment", cmdArgs...)
+	output, err := runKubectlWithToolName(ctx, cluster, "restart_deployment", cmdArgs...)
 	if err != nil {
 		return KubectlResult{Output: fmt.Sprintf("ERROR: %v", err)}, nil
 	}
 
-	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, output, err); postErr != nil {
+	podsAffected := max(parsePodsAffected(output), replicas)
+	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, podsAffected, err); postErr != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied after execution: %w", postErr)
 	}
 
@@ -1003,7 +1233,7 @@ func restartDeploymentImpl(ctx context.Context, args RestartDeploymentArgs) (Kub
 	// Re-check with backoff to handle K8s API propagation lag.
 	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyRetryConfig,
 		func() (bool, error) {
-			out, err := runKubectl(ctx, kubeContext, "get", "deployment", args.DeploymentName,
+			out, err := runKubectl(ctx, cluster, "get", "deployment", args.DeploymentName,
 				"-n", namespace, "-o", "jsonpath={.spec.template.metadata.annotations}")
 			return err == nil && strings.Contains(out, "restartedAt"), nil
 		},
@@ -1041,35 +1271,41 @@ func restartDeploymentTool(ctx tool.Context, args RestartDeploymentArgs) (Kubect
 
 // ScaleDeploymentArgs defines arguments for the scale_deployment tool.
 type ScaleDeploymentArgs struct {
-	Context        string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster        string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context        string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace      string `json:"namespace" jsonschema:"required,The Kubernetes namespace of the deployment."`
 	DeploymentName string `json:"deployment" jsonschema:"required,The name of the deployment to scale."`
-	Replicas       int    `json:"replicas" jsonschema:"required,Target replica count. Use 0 to scale down completely."`
+	Replicas       int    `json:"replicas" jsonschema:"required,Target replica count. Use 0 to scale down completely." validate:"min=0"`
 }
 
 func scaleDeploymentImpl(ctx context.Context, args ScaleDeploymentArgs) (KubectlResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
+	// The current replica count is only read for the approver if the policy
+	// check asks for approval.
+	ctx = agentutil.WithExecutionPlanFunc(ctx, func() *audit.ExecutionPlan {
+		current := -1
+		if n, ok := deploymentReplicas(ctx, cluster, namespace, args.DeploymentName); ok {
+			current = n
+		}
+		return scaleExecutionPlan(namespace, args.DeploymentName, current, args.Replicas)
+	})
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionDestructive, nsInfo.Tags); err != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
-	// Pre-execution blast-radius: args.Replicas is the target state, known now.
-	if err := checkK8sBlastRadiusPreExec(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, args.Replicas); err != nil {
-		return KubectlResult{}, fmt.Errorf("blast radius check denied: %w", err)
-	}
-
-	// Best-effort pre-mutation state capture for rollback support.
-	// A failure to read the current replica count does NOT abort the scale operation.
+	// Best-effort pre-mutation state capture for rollback support and the
+	// blast radius. A failure to read the current replica count does NOT abort
+	// the scale operation.
+	current := -1
 	var preStateJSON json.RawMessage
-	if out, readErr := runKubectl(ctx, kubeContext, "get", "deployment", args.DeploymentName,
-		"-n", namespace, "-o", "jsonpath={.spec.replicas}"); readErr == nil {
-		if n, convErr := strconv.Atoi(strings.TrimSpace(out)); convErr == nil && n > 0 {
+	if n, ok := deploymentReplicas(ctx, cluster, namespace, args.DeploymentName); ok {
+		current = n
+		if n > 0 {
 			if b, marshalErr := json.Marshal(audit.ScalePreState{
 				Namespace:        namespace,
 				DeploymentName:   args.DeploymentName,
@@ -1080,17 +1316,24 @@ func scaleDeploymentImpl(ctx context.Context, args ScaleDeploymentArgs) (Kubectl
 		}
 	}
 
+	// Pre-execution blast-radius: the pods started or stopped by the scale,
+	// or the target replica count when the current one is unknown.
+	podsAffected := scalePodsAffected(current, args.Replicas)
+	if err := checkK8sBlastRadiusPreExec(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, podsAffected); err != nil {
+		return KubectlResult{}, fmt.Errorf("blast radius check denied (%d pods): %w", podsAffected, err)
+	}
+
 	cmdArgs := []string{
 		"scale", "deployment", args.DeploymentName,
 		"--replicas", strconv.Itoa(args.Replicas),
 		"-n", namespace,
 	}
-	output, err := runKubectlAndRecord(ctx, kubeContext, "scale_deployment", preStateJSON, cmdArgs...)
+	output, err := runKubectlAndRecord(ctx, cluster, "scale_deployment", preStateJSON, cmdArgs...)
 	if err != nil {
 		return KubectlResult{Output: fmt.Sprintf("ERROR: %v", err)}, nil
 	}
 
-	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, output, err); postErr != nil {
+	if postErr := checkK8sPolicyResult(ctx, namespace, policy.ActionDestructive, nsInfo.Tags, podsAffected, err); postErr != nil {
 		return KubectlResult{}, fmt.Errorf("policy denied after execution: %w", postErr)
 	}
 
@@ -1101,7 +1344,7 @@ func scaleDeploymentImpl(ctx context.Context, args ScaleDeploymentArgs) (Kubectl
 	expected := strconv.Itoa(args.Replicas)
 	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyRetryConfig,
 		func() (bool, error) {
-			out, err := runKubectl(ctx, kubeContext, "get", "deployment", args.DeploymentName,
+			out, err := runKubectl(ctx, cluster, "get", "deployment", args.DeploymentName,
 				"-n", namespace, "-o", "jsonpath={.spec.replicas}")
 			if err != nil {
 				return false, err
@@ -1110,7 +1353,7 @@ func scaleDeploymentImpl(ctx context.Context, args ScaleDeploymentArgs) (Kubectl
 				return true, nil
 			}
 			// Re-apply scale before next poll (idempotent; existing approval covers it).
-			runKubectl(ctx, kubeContext, "scale", "deployment", args.DeploymentName, //nolint:errcheck
+			runKubectl(ctx, cluster, "scale", "deployment", args.DeploymentName, //nolint:errcheck
 				"--replicas", expected, "-n", namespace)
 			return false, nil
 		},
@@ -1141,38 +1384,57 @@ func scaleDeploymentImpl(ctx context.Context, args ScaleDeploymentArgs) (Kubectl
 	return KubectlResult{Output: output, VerifyStatus: "ok", RetryCount: retryCount}, nil
 }
 
+// scaleExecutionPlan describes a scale for the approver. current is -1 when
+// the current replica count could not be read.
+func scaleExecutionPlan(namespace, deployment string, current, target int) *audit.ExecutionPlan {
+	details := map[string]any{
+		"namespace":       namespace,
+		"deployment":      deployment,
+		"target_replicas": target,
+	}
+	from := "unknown"
+	if current >= 0 {
+		details["current_replicas"] = current
+		from = strconv.Itoa(current)
+	}
+	summary := fmt.Sprintf("scale deployment %s in %s from %s to %d replicas", deployment, namespace, from, target)
+	if target == 0 {
+		summary += " (stops all pods)"
+	}
+	return audit.NewExecutionPlan("scale_deployment", summary, details)
+}
+
 func scaleDeploymentTool(ctx tool.Context, args ScaleDeploymentArgs) (KubectlResult, error) {
 	return scaleDeploymentImpl(ctx, args)
 }
 
 // GetPodResourcesArgs defines arguments for the get_pod_resources tool.
 type GetPodResourcesArgs struct {
-	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	Namespace string `json:"namespace" jsonschema:"required,The Kubernetes namespace to query."`
 	PodName   string `json:"pod_name,omitempty" jsonschema:"Specific pod name. If empty, returns resources for all pods in the namespace."`
 }
 
 func getPodResourcesImpl(ctx context.Context, args GetPodResourcesArgs) (GetPodResourcesResult, error) {
-	nsInfo, err := resolveNamespaceInfo(args.Namespace, args.Context)
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
 		return GetPodResourcesResult{}, fmt.Errorf("access denied: %w", err)
 	}
 	namespace := nsInfo.Namespace
-	kubeContext := resolveContext(args.Context)
 
 	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
 		return GetPodResourcesResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
 	start := time.Now()
-	result, err := fetchPodResources(ctx, kubeContext, namespace, args.PodName)
+	result, err := fetchPodResources(ctx, cluster, namespace, args.PodName)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_pod_resources", map[string]any{
-		"context":   kubeContext,
+	recordClientGoAudit(ctx, "get_pod_resources", cluster.auditParams(map[string]any{
 		"namespace": namespace,
 		"pod_name":  args.PodName,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -1183,27 +1445,30 @@ func getPodResourcesTool(ctx tool.Context, args GetPodResourcesArgs) (GetPodReso
 
 // GetNodeStatusArgs defines arguments for the get_node_status tool.
 type GetNodeStatusArgs struct {
-	Context  string `json:"context,omitempty" jsonschema:"Kubernetes context to use. If empty, uses current context."`
+	Cluster  string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context  string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
 	NodeName string `json:"node_name,omitempty" jsonschema:"Specific node name. If empty, returns status for all nodes."`
 }
 
 func getNodeStatusImpl(ctx context.Context, args GetNodeStatusArgs) (GetNodeStatusResult, error) {
-	kubeContext := resolveContext(args.Context)
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
+	if err != nil {
+		return GetNodeStatusResult{}, fmt.Errorf("access denied: %w", err)
+	}
 
 	// Nodes are cluster-scoped (no namespace); use the sentinel "cluster" so the
 	// policy check request carries a non-empty resource_name.
-	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, nil); err != nil {
+	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, cluster.Tags); err != nil {
 		return GetNodeStatusResult{}, fmt.Errorf("policy denied: %w", err)
 	}
 
 	start := time.Now()
-	result, err := fetchNodeStatus(ctx, kubeContext, args.NodeName)
+	result, err := fetchNodeStatus(ctx, cluster, args.NodeName)
 	duration := time.Since(start)
 
-	recordClientGoAudit(ctx, "get_node_status", map[string]any{
-		"context":   kubeContext,
+	recordClientGoAudit(ctx, "get_node_status", cluster.auditParams(map[string]any{
 		"node_name": args.NodeName,
-	}, result.Count, err, duration)
+	}), result.Count, err, duration)
 
 	return result, err
 }
@@ -1212,201 +1477,486 @@ func getNodeStatusTool(ctx tool.Context, args GetNodeStatusArgs) (GetNodeStatusR
 	return getNodeStatusImpl(ctx, args)
 }
 
-// k8sArgsToStruct converts a map[string]any to a typed struct via JSON round-trip.
-func k8sArgsToStruct[T any](args map[string]any) (T, error) {
-	var zero T
-	b, err := json.Marshal(args)
+// GetPVCStatusArgs defines arguments for the get_pvc_status tool.
+type GetPVCStatusArgs struct {
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context   string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	Namespace string `json:"namespace" jsonschema:"required,The Kubernetes namespace to query."`
+	PVCName   string `json:"pvc_name,omitempty" jsonschema:"Specific PersistentVolumeClaim name. If empty, returns all claims in the namespace."`
+}
+
+func getPVCStatusImpl(ctx context.Context, args GetPVCStatusArgs) (GetPVCStatusResult, error) {
+	cluster, nsInfo, err := resolveTarget(args.Cluster, args.Context, args.Namespace)
 	if err != nil {
-		return zero, fmt.Errorf("marshal args: %w", err)
+		return GetPVCStatusResult{}, fmt.Errorf("access denied: %w", err)
 	}
-	var out T
-	if err := json.Unmarshal(b, &out); err != nil {
-		return zero, fmt.Errorf("unmarshal args: %w", err)
+	namespace := nsInfo.Namespace
+
+	if err := checkK8sPolicy(ctx, namespace, policy.ActionRead, nsInfo.Tags); err != nil {
+		return GetPVCStatusResult{}, fmt.Errorf("policy denied: %w", err)
 	}
-	return out, nil
+
+	start := time.Now()
+	result, err := fetchPVCStatus(ctx, cluster, namespace, args.PVCName)
+	duration := time.Since(start)
+
+	recordClientGoAudit(ctx, "get_pvc_status", cluster.auditParams(map[string]any{
+		"namespace": namespace,
+		"pvc_name":  args.PVCName,
+	}), result.Count, err, duration)
+
+	return result, err
 }
 
-// k8sJSONOutput JSON-marshals a structured result into a string for the direct tool registry.
-func k8sJSONOutput(v any) (string, error) {
-	b, err := json.Marshal(v)
+func getPVCStatusTool(ctx tool.Context, args GetPVCStatusArgs) (GetPVCStatusResult, error) {
+	return getPVCStatusImpl(ctx, args)
+}
+
+// GetStorageClassArgs defines arguments for the get_storageclass tool.
+type GetStorageClassArgs struct {
+	Cluster          string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context          string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	StorageClassName string `json:"storage_class_name,omitempty" jsonschema:"Specific StorageClass name. If empty, returns all StorageClasses."`
+}
+
+func getStorageClassImpl(ctx context.Context, args GetStorageClassArgs) (GetStorageClassResult, error) {
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
 	if err != nil {
-		return "", fmt.Errorf("marshal result: %w", err)
+		return GetStorageClassResult{}, fmt.Errorf("access denied: %w", err)
 	}
-	return string(b), nil
+
+	// StorageClasses are cluster-scoped; see getNodeStatusImpl.
+	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, cluster.Tags); err != nil {
+		return GetStorageClassResult{}, fmt.Errorf("policy denied: %w", err)
+	}
+
+	start := time.Now()
+	result, err := fetchStorageClasses(ctx, cluster, args.StorageClassName)
+	duration := time.Since(start)
+
+	recordClientGoAudit(ctx, "get_storageclass", cluster.auditParams(map[string]any{
+		"storage_class_name": args.StorageClassName,
+	}), result.Count, err, duration)
+
+	return result, err
 }
 
-// NewK8sDirectRegistry returns a DirectToolRegistry with all k8s tools registered
-// as directly-callable functions that bypass the LLM dispatch layer.
-func NewK8sDirectRegistry() *agentutil.DirectToolRegistry {
-	r := agentutil.NewDirectToolRegistry()
+func getStorageClassTool(ctx tool.Context, args GetStorageClassArgs) (GetStorageClassResult, error) {
+	return getStorageClassImpl(ctx, args)
+}
 
-	r.Register("get_pods", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetPodsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := getPodsImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return k8sJSONOutput(result)
-	})
+// GetNamespacesArgs defines arguments for the get_namespaces tool.
+type GetNamespacesArgs struct {
+	Cluster string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+}
 
-	r.Register("get_service", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetServiceArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := getServiceImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return k8sJSONOutput(result)
-	})
+func getNamespacesImpl(ctx context.Context, args GetNamespacesArgs) (GetNamespacesResult, error) {
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
+	if err != nil {
+		return GetNamespacesResult{}, fmt.Errorf("access denied: %w", err)
+	}
 
-	r.Register("describe_service", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[DescribeServiceArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := describeServiceImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return result.Output, nil
-	})
+	// Namespaces are cluster-scoped; see getNodeStatusImpl.
+	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, cluster.Tags); err != nil {
+		return GetNamespacesResult{}, fmt.Errorf("policy denied: %w", err)
+	}
 
-	r.Register("get_endpoints", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetEndpointsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := getEndpointsImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return k8sJSONOutput(result)
-	})
+	start := time.Now()
+	result, err := fetchNamespaces(ctx, cluster)
+	duration := time.Since(start)
 
-	r.Register("get_events", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetEventsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := getEventsImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return k8sJSONOutput(result)
-	})
+	recordClientGoAudit(ctx, "get_namespaces", cluster.auditParams(map[string]any{}), result.Count, err, duration)
 
-	r.Register("get_pod_logs", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetPodLogsArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := getPodLogsImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return result.Output, nil
-	})
+	return result, err
+}
 
-	r.Register("read_pod_file", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[ReadPodFileArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := readPodFileImpl(ctx, a)
-		if err != nil {
-			return "", err
-		}
-		return result.Output, nil
-	})
+func getNamespacesTool(ctx tool.Context, args GetNamespacesArgs) (GetNamespacesResult, error) {
+	return getNamespacesImpl(ctx, args)
+}
 
-	r.Register("describe_pod", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[DescribePodArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := describePodImpl(ctx, a)
-		if err != nil {
-			return "", err
+// DescribePVArgs defines arguments for the describe_pv tool.
+type DescribePVArgs struct {
+	Cluster string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	PVName  string `json:"pv_name,omitempty" jsonschema:"Specific PersistentVolume name, e.g. the volume get_pvc_status reports for a claim. If empty, returns all PersistentVolumes."`
+}
+
+func describePVImpl(ctx context.Context, args DescribePVArgs) (DescribePVResult, error) {
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
+	if err != nil {
+		return DescribePVResult{}, fmt.Errorf("access denied: %w", err)
+	}
+
+	// PersistentVolumes are cluster-scoped; see getNodeStatusImpl.
+	if err := checkK8sPolicy(ctx, "cluster", policy.ActionRead, cluster.Tags); err != nil {
+		return DescribePVResult{}, fmt.Errorf("policy denied: %w", err)
+	}
+
+	start := time.Now()
+	result, err := fetchPVs(ctx, cluster, args.PVName)
+	duration := time.Since(start)
+
+	recordClientGoAudit(ctx, "describe_pv", cluster.auditParams(map[string]any{
+		"pv_name": args.PVName,
+	}), result.Count, err, duration)
+
+	return result, err
+}
+
+func describePVTool(ctx tool.Context, args DescribePVArgs) (DescribePVResult, error) {
+	return describePVImpl(ctx, args)
+}
+
+// drainTimeout bounds how long drain_node waits for its evictions.
+const drainTimeout = 5 * time.Minute
+
+// nodeResource is the policy resource name of a node mutation. Unlike the
+// "cluster" sentinel of the node read tools it names the node, so an approval
+// covers that node only.
+func nodeResource(node string) string {
+	return "node/" + node
+}
+
+// CordonNodeArgs defines arguments for the cordon_node tool.
+type CordonNodeArgs struct {
+	Cluster  string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context  string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	NodeName string `json:"node" jsonschema:"required,The node to mark unschedulable. Use get_nodes to find the name."`
+}
+
+func cordonNodeImpl(ctx context.Context, args CordonNodeArgs) (KubectlResult, error) {
+	return setNodeSchedulingImpl(ctx, "cordon_node", args.Cluster, args.Context, args.NodeName, true)
+}
+
+func cordonNodeTool(ctx tool.Context, args CordonNodeArgs) (KubectlResult, error) {
+	return cordonNodeImpl(ctx, args)
+}
+
+// UncordonNodeArgs defines arguments for the uncordon_node tool.
+type UncordonNodeArgs struct {
+	Cluster  string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context  string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	NodeName string `json:"node" jsonschema:"required,The node to mark schedulable again."`
+}
+
+func uncordonNodeImpl(ctx context.Context, args UncordonNodeArgs) (KubectlResult, error) {
+	return setNodeSchedulingImpl(ctx, "uncordon_node", args.Cluster, args.Context, args.NodeName, false)
+}
+
+func uncordonNodeTool(ctx tool.Context, args UncordonNodeArgs) (KubectlResult, error) {
+	return uncordonNodeImpl(ctx, args)
+}
+
+// setNodeSchedulingImpl cordons (unschedulable) or uncordons a node and
+// confirms spec.unschedulable changed.
+func setNodeSchedulingImpl(ctx context.Context, toolName, clusterName, contextName, node string, unschedulable bool) (KubectlResult, error) {
+	cluster, err := resolveClusterInfo(clusterName, contextName)
+	if err != nil {
+		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
+	}
+
+	verb, summary, want := "uncordon", fmt.Sprintf("uncordon node %s: new pods can be scheduled on it again", node), ""
+	if unschedulable {
+		verb, summary, want = "cordon", fmt.Sprintf("cordon node %s: no new pods are scheduled on it; running pods stay", node), "true"
+	}
+	ctx = agentutil.WithToolName(ctx, toolName)
+	ctx = agentutil.WithExecutionPlan(ctx, audit.NewExecutionPlan(toolName, summary, map[string]any{"node": node}))
+	if err := checkK8sPolicy(ctx, nodeResource(node), policy.ActionDestructive, cluster.Tags); err != nil {
+		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
+	}
+
+	output, err := runKubectlWithToolName(ctx, cluster, toolName, verb, node)
+	if err != nil {
+		return KubectlResult{Output: fmt.Sprintf("ERROR: %v", err)}, nil
+	}
+
+	// Level 2: confirm spec.unschedulable reached the requested state.
+	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyRetryConfig,
+		func() (bool, error) {
+			out, err := runKubectl(ctx, cluster, "get", "node", node, "-o", "jsonpath={.spec.unschedulable}")
+			return err == nil && strings.TrimSpace(out) == want, nil
+		},
+		func(attempt int, r bool) {
+			if toolAuditor != nil {
+				toolAuditor.RecordToolRetry(ctx, toolName, attempt, r)
+			}
+		},
+	)
+	retryCount := max(attempts-1, 0)
+	if !resolved {
+		if toolAuditor != nil {
+			toolAuditor.RecordToolVerification(ctx, toolName, "warning")
 		}
-		return result.Output, nil
-	})
+		return KubectlResult{
+			Output: fmt.Sprintf(
+				"VERIFICATION WARNING: node %q does not show the expected scheduling state after %d check(s).\n"+
+					"Check:\n  kubectl get node %s\n\n--- %s result ---\n%s",
+				node, attempts, node, verb, output),
+			VerifyStatus: "warning",
+			RetryCount:   retryCount,
+		}, nil
+	}
+	return KubectlResult{Output: output, VerifyStatus: "ok", RetryCount: retryCount}, nil
+}
 
-	r.Register("get_nodes", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetNodesArgs](args)
-		if err != nil {
-			return "", err
+// DrainNodeArgs defines arguments for the drain_node tool.
+type DrainNodeArgs struct {
+	Cluster            string `json:"cluster,omitempty" jsonschema:"Registered cluster name from the infrastructure config (see Known Infrastructure). Takes precedence over context."`
+	Context            string `json:"context,omitempty" jsonschema:"Kubernetes context to use. Also accepts a database name. Ignored when cluster is set; if both are empty, uses current context."`
+	NodeName           string `json:"node" jsonschema:"required,The node to drain. Use get_nodes to find the name."`
+	DryRun             bool   `json:"dry_run,omitempty" jsonschema:"Only report the pods the drain would evict or leave in place and anything that would hold it up (unmanaged pods, PodDisruptionBudgets). Changes nothing and needs no approval. Run this first."`
+	GracePeriodSeconds int    `json:"grace_period_seconds,omitempty" jsonschema:"Seconds each evicted pod gets to terminate (default: the pod's terminationGracePeriodSeconds)." validate:"min=0"`
+}
+
+func drainNodeImpl(ctx context.Context, args DrainNodeArgs) (KubectlResult, error) {
+	cluster, err := resolveClusterInfo(args.Cluster, args.Context)
+	if err != nil {
+		return KubectlResult{}, fmt.Errorf("access denied: %w", err)
+	}
+	ctx = agentutil.WithToolName(ctx, "drain_node")
+	resource := nodeResource(args.NodeName)
+
+	if args.DryRun {
+		// A dry run changes nothing, so it is checked as a read.
+		if err := checkK8sPolicy(ctx, resource, policy.ActionRead, cluster.Tags); err != nil {
+			return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
 		}
-		result, err := getNodesImpl(ctx, a)
+		start := time.Now()
+		plan, err := fetchDrainPlan(ctx, cluster, args.NodeName)
+		recordClientGoAudit(ctx, "drain_node", cluster.auditParams(map[string]any{
+			"node":      args.NodeName,
+			"dry_run":   true,
+			"evictions": plan.evictionList(),
+		}), len(plan.Evict), err, time.Since(start))
 		if err != nil {
-			return "", err
+			return KubectlResult{}, err
 		}
-		return k8sJSONOutput(result)
-	})
+		return KubectlResult{Output: "DRY RUN: nothing was changed.\n\n" + formatDrainPlan(plan)}, nil
+	}
 
-	r.Register("delete_pod", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[DeletePodArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := deletePodImpl(ctx, a)
-		if err != nil {
-			return "", err
+	plan, err := fetchDrainPlan(ctx, cluster, args.NodeName)
+	if err != nil {
+		return KubectlResult{}, fmt.Errorf("drain plan for node %q: %w", args.NodeName, err)
+	}
+	if len(plan.Blocked) > 0 {
+		return KubectlResult{}, fmt.Errorf("drain of node %q would not complete:\n  - %s\nResolve these first; run drain_node with dry_run for the full plan",
+			args.NodeName, strings.Join(plan.Blocked, "\n  - "))
+	}
+
+	// The approver sees every pod that will move.
+	ctx = agentutil.WithExecutionPlan(ctx, drainExecutionPlan(plan))
+	if err := checkK8sPolicy(ctx, resource, policy.ActionDestructive, cluster.Tags); err != nil {
+		return KubectlResult{}, fmt.Errorf("policy denied: %w", err)
+	}
+	if err := checkK8sBlastRadiusPreExec(ctx, resource, policy.ActionDestructive, cluster.Tags, len(plan.Evict)); err != nil {
+		return KubectlResult{}, fmt.Errorf("blast radius check denied (%d pods): %w", len(plan.Evict), err)
+	}
+
+	cmdArgs := []string{"drain", args.NodeName, "--ignore-daemonsets", "--delete-emptydir-data",
+		"--timeout", drainTimeout.String()}
+	if args.GracePeriodSeconds > 0 {
+		cmdArgs = append(cmdArgs, "--grace-period", strconv.Itoa(args.GracePeriodSeconds))
+	}
+	output, err := runKubectlAndRecordParams(ctx, cluster, "drain_node",
+		map[string]any{"evictions": plan.evictionList()}, nil, cmdArgs...)
+	if err != nil {
+		return KubectlResult{Output: fmt.Sprintf("ERROR: %v", err)}, nil
+	}
+
+	if postErr := checkK8sPolicyResult(ctx, resource, policy.ActionDestructive, cluster.Tags, parsePodsAffected(output), err); postErr != nil {
+		return KubectlResult{}, fmt.Errorf("policy denied after execution: %w", postErr)
+	}
+
+	// Level 2: confirm the node is cordoned and no evictable pod is left on it.
+	var remaining DrainPlan
+	resolved, attempts, _ := retryutil.WaitUntilResolved(ctx, verifyRetryConfig,
+		func() (bool, error) {
+			after, err := fetchDrainPlan(ctx, cluster, args.NodeName)
+			if err != nil {
+				return false, nil
+			}
+			remaining = after
+			return after.Unschedulable && len(after.Evict) == 0, nil
+		},
+		func(attempt int, r bool) {
+			if toolAuditor != nil {
+				toolAuditor.RecordToolRetry(ctx, "drain_node", attempt, r)
+			}
+		},
+	)
+	retryCount := max(attempts-1, 0)
+	if !resolved {
+		if toolAuditor != nil {
+			toolAuditor.RecordToolVerification(ctx, "drain_node", "warning")
 		}
-		return result.Output, nil
-	})
+		return KubectlResult{
+			Output: fmt.Sprintf(
+				"VERIFICATION WARNING: node %q is not fully drained after %d check(s).\n"+
+					"Still on the node:\n  %s\n\n--- Drain result ---\n%s",
+				args.NodeName, attempts, strings.Join(remaining.evictionList(), "\n  "), output),
+			VerifyStatus: "warning",
+			RetryCount:   retryCount,
+		}, nil
+	}
+	return KubectlResult{Output: output, VerifyStatus: "ok", RetryCount: retryCount}, nil
+}
 
-	r.Register("restart_deployment", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[RestartDeploymentArgs](args)
-		if err != nil {
-			return "", err
+func drainNodeTool(ctx tool.Context, args DrainNodeArgs) (KubectlResult, error) {
+	return drainNodeImpl(ctx, args)
+}
+
+// drainExecutionPlan describes a drain for the approver, listing every pod it
+// evicts and leaves in place.
+func drainExecutionPlan(plan DrainPlan) *audit.ExecutionPlan {
+	skipped := make([]string, 0, len(plan.Skip))
+	for _, pod := range plan.Skip {
+		skipped = append(skipped, pod.Namespace+"/"+pod.Name)
+	}
+	details := map[string]any{
+		"node":      plan.Node,
+		"evictions": plan.evictionList(),
+		"skipped":   skipped,
+		"cordoned":  plan.Unschedulable,
+	}
+	summary := fmt.Sprintf("drain node %s: cordon it and evict %d pod(s)", plan.Node, len(plan.Evict))
+	if len(plan.Skip) > 0 {
+		summary += fmt.Sprintf("; %d DaemonSet or static pod(s) stay", len(plan.Skip))
+	}
+	return audit.NewExecutionPlan("drain_node", summary, details)
+}
+
+// formatDrainPlan renders a drain plan for the LLM.
+func formatDrainPlan(plan DrainPlan) string {
+	var b strings.Builder
+	state := "schedulable"
+	if plan.Unschedulable {
+		state = "already cordoned"
+	}
+	fmt.Fprintf(&b, "Node %s (%s)\n", plan.Node, state)
+	fmt.Fprintf(&b, "Evicts %d pod(s):\n", len(plan.Evict))
+	for _, pod := range plan.Evict {
+		fmt.Fprintf(&b, "  - %s/%s%s\n", pod.Namespace, pod.Name, drainPodDetail(pod))
+	}
+	if len(plan.Skip) > 0 {
+		fmt.Fprintf(&b, "Leaves %d pod(s) in place:\n", len(plan.Skip))
+		for _, pod := range plan.Skip {
+			fmt.Fprintf(&b, "  - %s/%s%s\n", pod.Namespace, pod.Name, drainPodDetail(pod))
 		}
-		result, err := restartDeploymentImpl(ctx, a)
-		if err != nil {
-			return "", err
+	}
+	if len(plan.Blocked) > 0 {
+		b.WriteString("Would not complete:\n")
+		for _, reason := range plan.Blocked {
+			fmt.Fprintf(&b, "  - %s\n", reason)
 		}
-		return result.Output, nil
-	})
+	}
+	return b.String()
+}
 
-	r.Register("scale_deployment", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[ScaleDeploymentArgs](args)
-		if err != nil {
-			return "", err
-		}
-		result, err := scaleDeploymentImpl(ctx, a)
+func drainPodDetail(pod DrainPod) string {
+	var parts []string
+	if pod.Owner != "" {
+		parts = append(parts, pod.Owner)
+	}
+	if pod.PDB != "" {
+		parts = append(parts, "PDB "+pod.PDB)
+	}
+	if pod.Note != "" {
+		parts = append(parts, pod.Note)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// k8sArgsToStruct converts a map[string]any to a typed struct via JSON round-trip.
+func k8sArgsToStruct[T any](args map[string]any) (T, error) {
+	var zero T
+	b, err := json.Marshal(args)
+	if err != nil {
+		return zero, fmt.Errorf("marshal args: %w", err)
+	}
+	var out T
+	if err := json.Unmarshal(b, &out); err != nil {
+		return zero, fmt.Errorf("unmarshal args: %w", err)
+	}
+	return out, nil
+}
+
+// k8sJSONOutput JSON-marshals a structured result into a string for the direct tool registry.
+func k8sJSONOutput(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+	return string(b), nil
+}
+
+// k8sJSONTool adapts a tool with a typed result for direct invocation: the
+// result is returned both as JSON text and as structured data.
+func k8sJSONTool[T, R any](impl func(context.Context, T) (R, error)) agentutil.StructuredToolFunc {
+	return func(ctx context.Context, args map[string]any) (agentutil.ToolOutput, error) {
+		a, err := k8sArgsToStruct[T](args)
 		if err != nil {
-			return "", err
+			return agentutil.ToolOutput{}, err
 		}
-		return result.Output, nil
-	})
-
-	r.Register("get_pod_resources", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetPodResourcesArgs](args)
+		result, err := impl(ctx, a)
 		if err != nil {
-			return "", err
+			return agentutil.ToolOutput{}, err
 		}
-		result, err := getPodResourcesImpl(ctx, a)
+		text, err := k8sJSONOutput(result)
 		if err != nil {
-			return "", err
+			return agentutil.ToolOutput{}, err
 		}
-		return k8sJSONOutput(result)
-	})
+		return agentutil.ToolOutput{Text: text, Data: result}, nil
+	}
+}
 
-	r.Register("get_node_status", func(ctx context.Context, args map[string]any) (string, error) {
-		a, err := k8sArgsToStruct[GetNodeStatusArgs](args)
+// kubectlTool adapts a text tool for direct invocation, surfacing the
+// verification status of mutating tools as structured data.
+func kubectlTool[T any](impl func(context.Context, T) (KubectlResult, error)) agentutil.StructuredToolFunc {
+	return func(ctx context.Context, args map[string]any) (agentutil.ToolOutput, error) {
+		a, err := k8sArgsToStruct[T](args)
 		if err != nil {
-			return "", err
+			return agentutil.ToolOutput{}, err
 		}
-		result, err := getNodeStatusImpl(ctx, a)
+		result, err := impl(ctx, a)
 		if err != nil {
-			return "", err
+			return agentutil.ToolOutput{}, err
 		}
-		return k8sJSONOutput(result)
-	})
+		out := agentutil.ToolOutput{Text: result.Output}
+		if result.VerifyStatus != "" || result.RetryCount > 0 {
+			out.Data = map[string]any{"verify_status": result.VerifyStatus, "retry_count": result.RetryCount}
+		}
+		return out, nil
+	}
+}
 
+// NewK8sDirectRegistry returns a DirectToolRegistry with all k8s tools registered
+// as directly-callable functions that bypass the LLM dispatch layer.
+func NewK8sDirectRegistry() *agentutil.DirectToolRegistry {
+	r := agentutil.NewDirectToolRegistry()
+	r.RegisterStructured("get_pods", k8sJSONTool(getPodsImpl))
+	r.RegisterStructured("get_service", k8sJSONTool(getServiceImpl))
+	r.RegisterStructured("describe_service", kubectlTool(describeServiceImpl))
+	r.RegisterStructured("get_endpoints", k8sJSONTool(getEndpointsImpl))
+	r.RegisterStructured("get_events", k8sJSONTool(getEventsImpl))
+	r.RegisterStructured("get_pod_logs", kubectlTool(getPodLogsImpl))
+	r.RegisterStructured("read_pod_file", kubectlTool(readPodFileImpl))
+	r.RegisterStructured("describe_pod", kubectlTool(describePodImpl))
+	r.RegisterStructured("get_nodes", k8sJSONTool(getNodesImpl))
+	r.RegisterStructured("delete_pod", kubectlTool(deletePodImpl))
+	r.RegisterStructured("restart_deployment", kubectlTool(restartDeploymentImpl))
+	r.RegisterStructured("scale_deployment", kubectlTool(scaleDeploymentImpl))
+	r.RegisterStructured("get_pod_resources", k8sJSONTool(getPodResourcesImpl))
+	r.RegisterStructured("get_node_status", k8sJSONTool(getNodeStatusImpl))
+	r.RegisterStructured("get_pvc_status", k8sJSONTool(getPVCStatusImpl))
+	r.RegisterStructured("get_storageclass", k8sJSONTool(getStorageClassImpl))
+	r.RegisterStructured("get_namespaces", k8sJSONTool(getNamespacesImpl))
+	r.RegisterStructured("describe_pv", k8sJSONTool(describePVImpl))
+	r.RegisterStructured("cordon_node", kubectlTool(cordonNodeImpl))
+	r.RegisterStructured("drain_node", kubectlTool(drainNodeImpl))
+	r.RegisterStructured("uncordon_node", kubectlTool(uncordonNodeImpl))
 	return r
 }
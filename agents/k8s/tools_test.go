@@ -16,6 +16,8 @@ import (
 	"google.golang.org/adk/tool/toolconfirmation"
 	"google.golang.org/genai"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -63,7 +65,7 @@ func withZeroVerifyConfig() func() {
 // Returns a cleanup function that restores the original.
 func withMockKubectl(output string, err error) func() {
 	orig := runKubectl
-	runKubectl = func(_ context.Context, _ string, _ ...string) (string, error) {
+	runKubectl = func(_ context.Context, _ clusterInfo, _ ...string) (string, error) {
 		return output, err
 	}
 	return func() { runKubectl = orig }
@@ -84,7 +86,7 @@ type kubectlResponse struct {
 func withMockKubectlSequence(calls ...kubectlResponse) func() {
 	orig := runKubectl
 	i := 0
-	runKubectl = func(_ context.Context, _ string, _ ...string) (string, error) {
+	runKubectl = func(_ context.Context, _ clusterInfo, _ ...string) (string, error) {
 		if i >= len(calls) {
 			last := calls[len(calls)-1]
 			return last.out, last.err
@@ -731,6 +733,24 @@ func TestScaleDeploymentTool_VerificationFailed_WrongReplicas(t *testing.T) {
 	}
 }
 
+func TestScaleExecutionPlan(t *testing.T) {
+	plan := scaleExecutionPlan("prod", "api", 5, 0)
+	if plan.Summary != "scale deployment api in prod from 5 to 0 replicas (stops all pods)" {
+		t.Errorf("Summary = %q", plan.Summary)
+	}
+	if plan.Details["current_replicas"] != float64(5) || plan.Details["target_replicas"] != float64(0) {
+		t.Errorf("Details = %v, want current 5 and target 0", plan.Details)
+	}
+
+	unknown := scaleExecutionPlan("prod", "api", -1, 3)
+	if !strings.Contains(unknown.Summary, "from unknown to 3") {
+		t.Errorf("Summary = %q", unknown.Summary)
+	}
+	if _, ok := unknown.Details["current_replicas"]; ok {
+		t.Errorf("Details = %v, want no current_replicas when it could not be read", unknown.Details)
+	}
+}
+
 func TestScaleDeploymentTool_PolicyDenied(t *testing.T) {
 	defer withK8sPolicyEnforcer(newDenyK8sDestructiveEnforcer(t))()
 	defer withMockKubectl("", nil)() // should not be reached
@@ -968,11 +988,14 @@ func TestDeletePodTool_BlastRadiusDenied(t *testing.T) {
 func TestScaleDeploymentTool_BlastRadiusDenied_PreExec(t *testing.T) {
 	// Policy: allow destructive with max 5 pods. scale to 20 → denied pre-exec.
 	defer withK8sPolicyEnforcer(newK8sBlastRadiusEnforcer(t, 5))()
-	// kubectl must never be called — the pre-execution blast-radius check fires first.
+	// kubectl scale must never run — the pre-execution blast-radius check fires
+	// first. The current replica count is unreadable, so the target is used.
 	kubectlCalled := false
 	orig := runKubectl
-	runKubectl = func(ctx context.Context, kubeContext string, args ...string) (string, error) {
-		kubectlCalled = true
+	runKubectl = func(ctx context.Context, cluster clusterInfo, args ...string) (string, error) {
+		if args[0] == "scale" {
+			kubectlCalled = true
+		}
 		return "", nil
 	}
 	defer func() { runKubectl = orig }()
@@ -990,7 +1013,82 @@ func TestScaleDeploymentTool_BlastRadiusDenied_PreExec(t *testing.T) {
 		t.Errorf("scaleDeploymentTool() error = %v, want 'blast radius check denied'", err)
 	}
 	if kubectlCalled {
-		t.Error("kubectl was called despite pre-execution blast-radius denial — check should block before execution")
+		t.Error("kubectl scale was called despite pre-execution blast-radius denial — check should block before execution")
+	}
+}
+
+func TestScaleDeploymentTool_BlastRadiusCountsReplicaDelta(t *testing.T) {
+	// Policy: max 5 pods. Scaling 10 → 0 stops 10 pods → denied pre-exec, even
+	// though the target replica count is 0.
+	defer withK8sPolicyEnforcer(newK8sBlastRadiusEnforcer(t, 5))()
+	defer withMockKubectlSequence(
+		kubectlResponse{out: "10", err: nil},                   // current replicas
+		kubectlResponse{out: "", err: fmt.Errorf("no scale")}, // must not be reached
+	)()
+
+	_, err := scaleDeploymentTool(newK8sTestContext(), ScaleDeploymentArgs{
+		Namespace:      "default",
+		DeploymentName: "web",
+		Replicas:       0,
+	})
+	if err == nil || !strings.Contains(err.Error(), "blast radius check denied (10 pods)") {
+		t.Errorf("scaleDeploymentTool() error = %v, want pre-exec denial for 10 pods", err)
+	}
+
+	// Scaling 18 → 20 starts only 2 pods → allowed.
+	defer withMockKubectlSequence(
+		kubectlResponse{out: "18", err: nil},
+		kubectlResponse{out: `deployment.apps "web" scaled` + "\n", err: nil},
+		kubectlResponse{out: "20", err: nil},
+	)()
+	if _, err := scaleDeploymentTool(newK8sTestContext(), ScaleDeploymentArgs{
+		Namespace:      "default",
+		DeploymentName: "web",
+		Replicas:       20,
+	}); err != nil {
+		t.Errorf("scaleDeploymentTool() error = %v, want 18 → 20 within the limit of 5", err)
+	}
+}
+
+func TestRestartDeploymentTool_BlastRadiusDenied_PreExec(t *testing.T) {
+	// Policy: max 3 pods. The deployment has 8 replicas, all of which a rollout
+	// restart replaces → denied before the restart runs.
+	defer withK8sPolicyEnforcer(newK8sBlastRadiusEnforcer(t, 3))()
+	var calls [][]string
+	orig := runKubectl
+	runKubectl = func(_ context.Context, _ clusterInfo, args ...string) (string, error) {
+		calls = append(calls, args)
+		return "8", nil
+	}
+	defer func() { runKubectl = orig }()
+
+	_, err := restartDeploymentTool(newK8sTestContext(), RestartDeploymentArgs{
+		Namespace:      "default",
+		DeploymentName: "api",
+	})
+	if err == nil || !strings.Contains(err.Error(), "blast radius check denied (8 replicas)") {
+		t.Errorf("restartDeploymentTool() error = %v, want pre-exec denial for 8 replicas", err)
+	}
+	for _, args := range calls {
+		if args[0] == "rollout" {
+			t.Error("rollout restart ran despite pre-execution blast-radius denial")
+		}
+	}
+}
+
+func TestScalePodsAffected(t *testing.T) {
+	tests := []struct {
+		current, target, want int
+	}{
+		{current: 3, target: 5, want: 2},
+		{current: 5, target: 0, want: 5},
+		{current: 4, target: 4, want: 0},
+		{current: -1, target: 7, want: 7},
+	}
+	for _, tt := range tests {
+		if got := scalePodsAffected(tt.current, tt.target); got != tt.want {
+			t.Errorf("scalePodsAffected(%d, %d) = %d, want %d", tt.current, tt.target, got, tt.want)
+		}
 	}
 }
 
@@ -1057,15 +1155,15 @@ func TestResolveNamespaceInfo_InfraEnforced_UnknownNamespaceUnknownContext(t *te
 	cfg.K8sClusters["other-cluster"] = infra.K8sCluster{Name: "other", Context: "gke_staging", Tags: []string{"staging"}}
 	defer withK8sInfraConfig(cfg)()
 
-	_, err := resolveNamespaceInfo("unknown-namespace", "unknown-context")
+	_, _, err := resolveTarget("", "unknown-context", "unknown-namespace")
 	if err == nil {
-		t.Fatal("resolveNamespaceInfo() error = nil, want error for namespace in unrecognized context with infra config set")
+		t.Fatal("resolveTarget() error = nil, want error for namespace in unrecognized context with infra config set")
 	}
 	if !strings.Contains(err.Error(), "not registered in infrastructure config") {
-		t.Errorf("resolveNamespaceInfo() error = %q, want 'not registered in infrastructure config'", err.Error())
+		t.Errorf("resolveTarget() error = %q, want 'not registered in infrastructure config'", err.Error())
 	}
 	if !strings.Contains(err.Error(), "prod-db") {
-		t.Errorf("resolveNamespaceInfo() error = %q, want known database 'prod-db' listed", err.Error())
+		t.Errorf("resolveTarget() error = %q, want known database 'prod-db' listed", err.Error())
 	}
 }
 
@@ -1073,15 +1171,15 @@ func TestResolveNamespaceInfo_InfraEnforced_RegisteredByDBName(t *testing.T) {
 	// infraConfig is set and input is a registered database name → succeed with namespace + tags.
 	defer withK8sInfraConfig(makeK8sTestInfraConfig())()
 
-	info, err := resolveNamespaceInfo("prod-db", "")
+	_, info, err := resolveTarget("", "", "prod-db")
 	if err != nil {
-		t.Fatalf("resolveNamespaceInfo() error = %v, want nil for registered DB name", err)
+		t.Fatalf("resolveTarget() error = %v, want nil for registered DB name", err)
 	}
 	if info.Namespace != "prod-namespace" {
-		t.Errorf("resolveNamespaceInfo() Namespace = %q, want 'prod-namespace'", info.Namespace)
+		t.Errorf("resolveTarget() Namespace = %q, want 'prod-namespace'", info.Namespace)
 	}
 	if len(info.Tags) == 0 || info.Tags[0] != "production" {
-		t.Errorf("resolveNamespaceInfo() Tags = %v, want ['production']", info.Tags)
+		t.Errorf("resolveTarget() Tags = %v, want ['production']", info.Tags)
 	}
 }
 
@@ -1089,12 +1187,12 @@ func TestResolveNamespaceInfo_InfraEnforced_RegisteredByNamespace(t *testing.T)
 	// infraConfig is set and input is the actual K8s namespace of a registered DB → allowed.
 	defer withK8sInfraConfig(makeK8sTestInfraConfig())()
 
-	info, err := resolveNamespaceInfo("prod-namespace", "")
+	_, info, err := resolveTarget("", "", "prod-namespace")
 	if err != nil {
-		t.Fatalf("resolveNamespaceInfo() error = %v, want nil for registered K8s namespace", err)
+		t.Fatalf("resolveTarget() error = %v, want nil for registered K8s namespace", err)
 	}
 	if info.Namespace != "prod-namespace" {
-		t.Errorf("resolveNamespaceInfo() Namespace = %q, want 'prod-namespace'", info.Namespace)
+		t.Errorf("resolveTarget() Namespace = %q, want 'prod-namespace'", info.Namespace)
 	}
 }
 
@@ -1104,15 +1202,15 @@ func TestResolveNamespaceInfo_InfraEnforced_NonDBNamespaceInKnownCluster(t *test
 	// on a cluster tagged "development" should inherit those tags).
 	defer withK8sInfraConfig(makeK8sTestInfraConfig())()
 
-	info, err := resolveNamespaceInfo("default", "gke_prod")
+	_, info, err := resolveTarget("", "gke_prod", "default")
 	if err != nil {
-		t.Fatalf("resolveNamespaceInfo() error = %v, want nil for non-DB namespace in registered cluster", err)
+		t.Fatalf("resolveTarget() error = %v, want nil for non-DB namespace in registered cluster", err)
 	}
 	if info.Namespace != "default" {
-		t.Errorf("resolveNamespaceInfo() Namespace = %q, want 'default'", info.Namespace)
+		t.Errorf("resolveTarget() Namespace = %q, want 'default'", info.Namespace)
 	}
 	if len(info.Tags) == 0 || info.Tags[0] != "production" {
-		t.Errorf("resolveNamespaceInfo() Tags = %v, want cluster tags ['production']", info.Tags)
+		t.Errorf("resolveTarget() Tags = %v, want cluster tags ['production']", info.Tags)
 	}
 }
 
@@ -1120,15 +1218,15 @@ func TestResolveNamespaceInfo_InfraEnforced_NonDBNamespaceNoContextSoleCluster(t
 	// infraConfig has exactly one cluster; no context passed → use sole cluster's tags.
 	defer withK8sInfraConfig(makeK8sTestInfraConfig())()
 
-	info, err := resolveNamespaceInfo("default", "")
+	_, info, err := resolveTarget("", "", "default")
 	if err != nil {
-		t.Fatalf("resolveNamespaceInfo() error = %v, want nil for non-DB namespace with sole cluster default", err)
+		t.Fatalf("resolveTarget() error = %v, want nil for non-DB namespace with sole cluster default", err)
 	}
 	if info.Namespace != "default" {
-		t.Errorf("resolveNamespaceInfo() Namespace = %q, want 'default'", info.Namespace)
+		t.Errorf("resolveTarget() Namespace = %q, want 'default'", info.Namespace)
 	}
 	if len(info.Tags) == 0 || info.Tags[0] != "production" {
-		t.Errorf("resolveNamespaceInfo() Tags = %v, want sole cluster tags ['production']", info.Tags)
+		t.Errorf("resolveTarget() Tags = %v, want sole cluster tags ['production']", info.Tags)
 	}
 }
 
@@ -1136,12 +1234,12 @@ func TestResolveNamespaceInfo_InfraPermissive_UnknownNamespace(t *testing.T) {
 	// infraConfig is nil (dev mode) → any namespace is allowed.
 	defer withK8sInfraConfig(nil)()
 
-	info, err := resolveNamespaceInfo("any-namespace", "")
+	_, info, err := resolveTarget("", "", "any-namespace")
 	if err != nil {
-		t.Fatalf("resolveNamespaceInfo() error = %v, want nil in dev mode (no infra config)", err)
+		t.Fatalf("resolveTarget() error = %v, want nil in dev mode (no infra config)", err)
 	}
 	if info.Namespace != "any-namespace" {
-		t.Errorf("resolveNamespaceInfo() Namespace = %q, want 'any-namespace'", info.Namespace)
+		t.Errorf("resolveTarget() Namespace = %q, want 'any-namespace'", info.Namespace)
 	}
 }
 
@@ -1402,3 +1500,778 @@ func TestGetNodeStatus_MemoryPressure_IncludesMessage(t *testing.T) {
 		t.Error("MemoryPressure condition should have Message set when Status=True")
 	}
 }
+
+func TestRunKubectlExec_SandboxRejectsInjectedFlag(t *testing.T) {
+	// Validation happens before exec, so no kubectl binary is needed.
+	_, err := runKubectlExec(context.Background(), clusterInfo{}, "delete", "pod", "--all", "-n", "default")
+	if err == nil || !strings.Contains(err.Error(), "not allowlisted") {
+		t.Fatalf("err = %v, want sandbox rejection", err)
+	}
+}
+
+// --- multi-cluster resolution ---
+
+// makeMultiClusterInfraConfig registers two clusters, each in its own
+// kubeconfig file, with prod-db hosted on the production one.
+func makeMultiClusterInfraConfig() *infra.Config {
+	cfg := makeK8sTestInfraConfig()
+	prod := cfg.K8sClusters["prod-cluster"]
+	prod.Kubeconfig = "/etc/helpdesk/kube/prod.yaml"
+	cfg.K8sClusters["prod-cluster"] = prod
+	cfg.K8sClusters["staging-cluster"] = infra.K8sCluster{
+		Name:       "staging",
+		Context:    "gke_staging",
+		Kubeconfig: "/etc/helpdesk/kube/staging.yaml",
+		Tags:       []string{"staging"},
+	}
+	return cfg
+}
+
+func TestResolveClusterInfo_RegisteredCluster(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+
+	cluster, err := resolveClusterInfo("staging-cluster", "gke_prod")
+	if err != nil {
+		t.Fatalf("resolveClusterInfo() error = %v", err)
+	}
+	if cluster.Name != "staging-cluster" || cluster.Context != "gke_staging" || cluster.Kubeconfig != "/etc/helpdesk/kube/staging.yaml" {
+		t.Errorf("resolveClusterInfo() = %+v, want staging-cluster (cluster takes precedence over context)", cluster)
+	}
+	if len(cluster.Tags) != 1 || cluster.Tags[0] != "staging" {
+		t.Errorf("resolveClusterInfo() Tags = %v, want [staging]", cluster.Tags)
+	}
+
+	// A context or database name resolves to the registered cluster too.
+	for _, contextOrDB := range []string{"gke_prod", "prod-db"} {
+		cluster, err := resolveClusterInfo("", contextOrDB)
+		if err != nil || cluster.Name != "prod-cluster" || cluster.Kubeconfig != "/etc/helpdesk/kube/prod.yaml" {
+			t.Errorf("resolveClusterInfo(%q) = %+v, %v, want prod-cluster", contextOrDB, cluster, err)
+		}
+	}
+}
+
+func TestResolveClusterInfo_UnknownClusterRejected(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+
+	_, err := resolveClusterInfo("dev-cluster", "")
+	if err == nil {
+		t.Fatal("resolveClusterInfo() error = nil, want rejection of an unregistered cluster")
+	}
+	if !strings.Contains(err.Error(), "not registered in infrastructure config") ||
+		!strings.Contains(err.Error(), "prod-cluster, staging-cluster") {
+		t.Errorf("resolveClusterInfo() error = %q, want rejection listing known clusters", err)
+	}
+}
+
+func TestResolveClusterInfo_DevModeUsesNameAsContext(t *testing.T) {
+	defer withK8sInfraConfig(nil)()
+
+	cluster, err := resolveClusterInfo("kind-dev", "")
+	if err != nil || cluster.Context != "kind-dev" || cluster.Name != "" {
+		t.Errorf("resolveClusterInfo() = %+v, %v, want context kind-dev", cluster, err)
+	}
+}
+
+func TestResolveTarget_ClusterTags(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+
+	// A non-DB namespace inherits the tags of the named cluster.
+	cluster, info, err := resolveTarget("staging-cluster", "", "default")
+	if err != nil {
+		t.Fatalf("resolveTarget() error = %v", err)
+	}
+	if cluster.Name != "staging-cluster" || strings.Join(info.Tags, ",") != "staging" {
+		t.Errorf("resolveTarget() = %+v, %+v, want staging-cluster with tags [staging]", cluster, info)
+	}
+
+	// A database name alone selects the database's cluster and merges its tags.
+	cfg := makeMultiClusterInfraConfig()
+	prod := cfg.K8sClusters["prod-cluster"]
+	prod.Tags = []string{"production", "pci"}
+	cfg.K8sClusters["prod-cluster"] = prod
+	defer withK8sInfraConfig(cfg)()
+	cluster, info, err = resolveTarget("", "", "prod-db")
+	if err != nil {
+		t.Fatalf("resolveTarget() error = %v", err)
+	}
+	if cluster.Name != "prod-cluster" || info.Namespace != "prod-namespace" {
+		t.Errorf("resolveTarget() = %+v, %+v, want prod-namespace on prod-cluster", cluster, info)
+	}
+	if strings.Join(info.Tags, ",") != "production,pci" {
+		t.Errorf("resolveTarget() Tags = %v, want database and cluster tags [production pci]", info.Tags)
+	}
+}
+
+func TestResolveTarget_DatabaseOnOtherClusterRejected(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+
+	_, _, err := resolveTarget("staging-cluster", "", "prod-db")
+	if err == nil || !strings.Contains(err.Error(), `runs in kubernetes cluster "prod-cluster"`) {
+		t.Errorf("resolveTarget() error = %v, want database-on-other-cluster rejection", err)
+	}
+}
+
+func TestGetPodsTool_UnknownClusterRejected(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+
+	_, err := getPodsTool(newK8sTestContext(), GetPodsArgs{Cluster: "dev-cluster", Namespace: "default"})
+	if err == nil || !strings.Contains(err.Error(), "access denied") || !strings.Contains(err.Error(), "dev-cluster") {
+		t.Errorf("getPodsTool() error = %v, want access denied for the unregistered cluster", err)
+	}
+}
+
+func TestDeletePodTool_RunsAgainstNamedCluster(t *testing.T) {
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+	defer withZeroVerifyConfig()()
+
+	var got []clusterInfo
+	orig := runKubectl
+	runKubectl = func(_ context.Context, cluster clusterInfo, _ ...string) (string, error) {
+		got = append(got, cluster)
+		if len(got) == 1 {
+			return `pod "web-abc123" deleted`, nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+	defer func() { runKubectl = orig }()
+
+	if _, err := deletePodTool(newK8sTestContext(), DeletePodArgs{
+		Cluster:   "staging-cluster",
+		Namespace: "default",
+		PodName:   "web-abc123",
+	}); err != nil {
+		t.Fatalf("deletePodTool() error = %v", err)
+	}
+	for _, c := range got {
+		if c.Context != "gke_staging" || c.Kubeconfig != "/etc/helpdesk/kube/staging.yaml" {
+			t.Errorf("kubectl ran against %+v, want the staging cluster", c)
+		}
+	}
+	args := strings.Join(kubectlArgs(got[0], []string{"get", "pods"}), " ")
+	if args != "--request-timeout=10s --kubeconfig /etc/helpdesk/kube/staging.yaml --context gke_staging get pods" {
+		t.Errorf("kubectlArgs() = %q", args)
+	}
+}
+
+func TestDeletePodTool_ClusterTagsFeedPolicy(t *testing.T) {
+	const yaml = `
+version: "1"
+policies:
+  - name: protect-production
+    resources:
+      - type: kubernetes
+        match:
+          tags: [production]
+    rules:
+      - action: destructive
+        effect: deny
+        message: "no destructive operations on production clusters"
+`
+	engine, err := agentutil.InitPolicyEngine(agentutil.Config{
+		PolicyEnabled: true,
+		PolicyFile:    writeTempK8sPolicyFile(t, yaml),
+		DefaultPolicy: "allow",
+	})
+	if err != nil {
+		t.Fatalf("InitPolicyEngine: %v", err)
+	}
+	defer withK8sPolicyEnforcer(agentutil.NewPolicyEnforcerWithConfig(agentutil.PolicyEnforcerConfig{Engine: engine}))()
+	defer withK8sInfraConfig(makeMultiClusterInfraConfig())()
+	defer withMockKubectl("", fmt.Errorf("kubectl must not run"))()
+
+	// The namespace is not a database namespace; only the cluster carries the tag.
+	_, err = deletePodTool(newK8sTestContext(), DeletePodArgs{
+		Cluster:   "prod-cluster",
+		Namespace: "web",
+		PodName:   "web-abc123",
+	})
+	if err == nil || !strings.Contains(err.Error(), "policy denied") {
+		t.Errorf("deletePodTool() error = %v, want policy denial from the production cluster tag", err)
+	}
+}
+
+// --- namespace allowlisting ---
+
+// makeAllowlistInfraConfig registers prod-cluster with a namespace allowlist:
+// "web" carries tags of its own, "batch" only the cluster's.
+func makeAllowlistInfraConfig() *infra.Config {
+	cfg := makeK8sTestInfraConfig()
+	prod := cfg.K8sClusters["prod-cluster"]
+	prod.Namespaces = map[string]infra.K8sNamespace{
+		"web":   {Tags: []string{"pci"}},
+		"batch": {},
+	}
+	cfg.K8sClusters["prod-cluster"] = prod
+	return cfg
+}
+
+func TestResolveTarget_NamespaceAllowlist(t *testing.T) {
+	defer withK8sInfraConfig(makeAllowlistInfraConfig())()
+
+	tests := []struct {
+		name      string
+		namespace string
+		wantTags  string
+		wantErr   string
+	}{
+		{name: "allowlisted with tags", namespace: "web", wantTags: "pci,production"},
+		{name: "allowlisted without tags", namespace: "batch", wantTags: "production"},
+		{name: "database namespace", namespace: "prod-namespace", wantTags: "production"},
+		{name: "database name", namespace: "prod-db", wantTags: "production"},
+		{name: "not allowlisted", namespace: "kube-system", wantErr: "Allowed namespaces: batch, web"},
+		{name: "all namespaces", namespace: "all", wantErr: `namespace "all" is not allowlisted`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The allowlist applies with the cluster named and via the
+			// sole-cluster fallback alike.
+			for _, cluster := range []string{"prod-cluster", ""} {
+				_, info, err := resolveTarget(cluster, "", tt.namespace)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("resolveTarget(%q, %q) error = %v, want %q", cluster, tt.namespace, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("resolveTarget(%q, %q) error = %v", cluster, tt.namespace, err)
+				}
+				if got := strings.Join(info.Tags, ","); got != tt.wantTags {
+					t.Errorf("resolveTarget(%q, %q) Tags = %q, want %q", cluster, tt.namespace, got, tt.wantTags)
+				}
+			}
+		})
+	}
+}
+
+func TestDeletePodTool_NamespaceNotAllowlisted(t *testing.T) {
+	defer withK8sInfraConfig(makeAllowlistInfraConfig())()
+	defer withMockKubectl("", fmt.Errorf("kubectl must not run"))()
+
+	_, err := deletePodTool(newK8sTestContext(), DeletePodArgs{
+		Cluster:   "prod-cluster",
+		Namespace: "kube-system",
+		PodName:   "coredns-abc",
+	})
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("deletePodTool() error = %v, want access denied outside the namespace allowlist", err)
+	}
+}
+
+// --- node maintenance: cordon_node, drain_node, uncordon_node ---
+
+// newDrainFixture returns a fake cluster with node worker-1 running:
+//   - payments/api-1 and api-2, ReplicaSet pods covered by PDB api-pdb
+//     (disruptionsAllowed is the PDB's current budget);
+//   - payments/cache-0, a StatefulSet pod with an emptyDir volume;
+//   - kube-system/fluentd-abc, a DaemonSet pod;
+//   - kube-system/kube-proxy-worker-1, a static pod;
+//   - jobs/report-xyz, a finished Job pod;
+//
+// and payments/api-3 on worker-2.
+func newDrainFixture(disruptionsAllowed int32) *fake.Clientset {
+	controller := true
+	owned := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+	}
+	pod := func(ns, name, node string, owners []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, OwnerReferences: owners, Labels: map[string]string{}},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	api1 := pod("payments", "api-1", "worker-1", owned("ReplicaSet", "api-5d8f9"))
+	api2 := pod("payments", "api-2", "worker-1", owned("ReplicaSet", "api-5d8f9"))
+	api3 := pod("payments", "api-3", "worker-2", owned("ReplicaSet", "api-5d8f9"))
+	for _, p := range []*corev1.Pod{api1, api2, api3} {
+		p.Labels["app"] = "api"
+	}
+	cache := pod("payments", "cache-0", "worker-1", owned("StatefulSet", "cache"))
+	cache.Spec.Volumes = []corev1.Volume{{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	fluentd := pod("kube-system", "fluentd-abc", "worker-1", owned("DaemonSet", "fluentd"))
+	proxy := pod("kube-system", "kube-proxy-worker-1", "worker-1", nil)
+	proxy.Annotations = map[string]string{mirrorPodAnnotation: "abc123"}
+	report := pod("jobs", "report-xyz", "worker-1", owned("Job", "report"))
+	report.Status.Phase = corev1.PodSucceeded
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api-pdb"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed},
+	}
+	return fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+		api1, api2, api3, cache, fluentd, proxy, report, pdb,
+	)
+}
+
+func TestFetchDrainPlan(t *testing.T) {
+	defer injectFakeClientset("", newDrainFixture(1))()
+
+	plan, err := fetchDrainPlan(context.Background(), clusterInfo{}, "worker-1")
+	if err != nil {
+		t.Fatalf("fetchDrainPlan() error = %v", err)
+	}
+	want := []string{"jobs/report-xyz", "payments/api-1", "payments/api-2", "payments/cache-0"}
+	if got := plan.evictionList(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("evictions = %v, want %v", got, want)
+	}
+	if plan.Evict[1].PDB != "api-pdb" || plan.Evict[1].Owner != "ReplicaSet/api-5d8f9" {
+		t.Errorf("api-1 = %+v, want owner ReplicaSet/api-5d8f9 and PDB api-pdb", plan.Evict[1])
+	}
+	if plan.Evict[3].Note != "emptyDir data is deleted" {
+		t.Errorf("cache-0 note = %q, want emptyDir warning", plan.Evict[3].Note)
+	}
+	if len(plan.Skip) != 2 || plan.Skip[0].Name != "fluentd-abc" || plan.Skip[1].Name != "kube-proxy-worker-1" {
+		t.Errorf("skip = %+v, want the DaemonSet and static pods", plan.Skip)
+	}
+	if len(plan.Blocked) != 0 {
+		t.Errorf("blocked = %v, want none with a PDB budget of 1", plan.Blocked)
+	}
+}
+
+func TestFetchDrainPlan_Blocked(t *testing.T) {
+	cs := newDrainFixture(0)
+	bare := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "debug-shell"},
+		Spec:       corev1.PodSpec{NodeName: "worker-1"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if _, err := cs.CoreV1().Pods("default").Create(context.Background(), bare, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	defer injectFakeClientset("", cs)()
+
+	plan, err := fetchDrainPlan(context.Background(), clusterInfo{}, "worker-1")
+	if err != nil {
+		t.Fatalf("fetchDrainPlan() error = %v", err)
+	}
+	blocked := strings.Join(plan.Blocked, "\n")
+	if !strings.Contains(blocked, "default/debug-shell is not managed by a controller") {
+		t.Errorf("blocked = %q, want the unmanaged pod", blocked)
+	}
+	if !strings.Contains(blocked, "PodDisruptionBudget payments/api-pdb allows no disruptions now; evicting its 2 pod(s)") {
+		t.Errorf("blocked = %q, want the exhausted PDB", blocked)
+	}
+}
+
+func TestDrainNodeTool_DryRunChangesNothing(t *testing.T) {
+	defer injectFakeClientset("", newDrainFixture(1))()
+	defer withK8sPolicyEnforcer(newDenyK8sDestructiveEnforcer(t))()
+	defer withMockKubectl("", fmt.Errorf("kubectl must not run on a dry run"))()
+
+	result, err := drainNodeTool(newK8sTestContext(), DrainNodeArgs{NodeName: "worker-1", DryRun: true})
+	if err != nil {
+		t.Fatalf("drainNodeTool() error = %v, want a dry run to pass a policy that denies destructive operations", err)
+	}
+	for _, want := range []string{
+		"DRY RUN: nothing was changed.",
+		"Evicts 4 pod(s):",
+		"payments/api-1 (ReplicaSet/api-5d8f9, PDB api-pdb)",
+		"kube-system/fluentd-abc (DaemonSet/fluentd, DaemonSet pod, left in place)",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("drainNodeTool() output missing %q:\n%s", want, result.Output)
+		}
+	}
+}
+
+func TestDrainNodeTool_BlockedDrainRefused(t *testing.T) {
+	defer injectFakeClientset("", newDrainFixture(0))()
+	defer withMockKubectl("", fmt.Errorf("kubectl must not run"))()
+
+	_, err := drainNodeTool(newK8sTestContext(), DrainNodeArgs{NodeName: "worker-1"})
+	if err == nil || !strings.Contains(err.Error(), `drain of node "worker-1" would not complete`) {
+		t.Errorf("drainNodeTool() error = %v, want the drain refused while the PDB allows no disruptions", err)
+	}
+}
+
+func TestDrainNodeTool_PolicyDenied(t *testing.T) {
+	defer injectFakeClientset("", newDrainFixture(1))()
+	defer withK8sPolicyEnforcer(newDenyK8sDestructiveEnforcer(t))()
+	defer withMockKubectl("", fmt.Errorf("kubectl must not run"))()
+
+	_, err := drainNodeTool(newK8sTestContext(), DrainNodeArgs{NodeName: "worker-1"})
+	if err == nil || !strings.Contains(err.Error(), "policy denied") {
+		t.Errorf("drainNodeTool() error = %v, want policy denied", err)
+	}
+}
+
+func TestDrainNodeTool_RecordsEvictions(t *testing.T) {
+	cs := newDrainFixture(1)
+	defer injectFakeClientset("", cs)()
+	defer withZeroVerifyConfig()()
+
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "drain.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	origAuditor := toolAuditor
+	toolAuditor = audit.NewToolAuditor(store, "k8s_agent", "sess_drain", "trace_drain")
+	defer func() { toolAuditor = origAuditor }()
+
+	// The mock drains the fake cluster: cordon worker-1 and delete its
+	// evictable pods.
+	var drainArgs []string
+	orig := runKubectl
+	runKubectl = func(ctx context.Context, _ clusterInfo, args ...string) (string, error) {
+		drainArgs = args
+		node, _ := cs.CoreV1().Nodes().Get(ctx, "worker-1", metav1.GetOptions{})
+		node.Spec.Unschedulable = true
+		cs.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}) //nolint:errcheck
+		var out strings.Builder
+		out.WriteString("node/worker-1 cordoned\n")
+		for _, p := range []string{"jobs/report-xyz", "payments/api-1", "payments/api-2", "payments/cache-0"} {
+			ns, name, _ := strings.Cut(p, "/")
+			cs.CoreV1().Pods(ns).Delete(ctx, name, metav1.DeleteOptions{}) //nolint:errcheck
+			fmt.Fprintf(&out, "pod/%s evicted\n", name)
+		}
+		out.WriteString("node/worker-1 drained\n")
+		return out.String(), nil
+	}
+	defer func() { runKubectl = orig }()
+
+	result, err := drainNodeTool(newK8sTestContext(), DrainNodeArgs{NodeName: "worker-1", GracePeriodSeconds: 30})
+	if err != nil {
+		t.Fatalf("drainNodeTool() error = %v", err)
+	}
+	if result.VerifyStatus != "ok" {
+		t.Errorf("VerifyStatus = %q, want ok; output:\n%s", result.VerifyStatus, result.Output)
+	}
+	if got := strings.Join(drainArgs, " "); got != "drain worker-1 --ignore-daemonsets --delete-emptydir-data --timeout 5m0s --grace-period 30" {
+		t.Errorf("kubectl args = %q", got)
+	}
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{ToolName: "drain_node", EventType: audit.EventTypeToolExecution})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Query() = %d events, %v; want 1 drain_node execution", len(events), err)
+	}
+	evictions, _ := json.Marshal(events[0].Tool.Parameters["evictions"])
+	if string(evictions) != `["jobs/report-xyz","payments/api-1","payments/api-2","payments/cache-0"]` {
+		t.Errorf("audited evictions = %s", evictions)
+	}
+}
+
+func TestDrainExecutionPlan(t *testing.T) {
+	plan := drainExecutionPlan(DrainPlan{
+		Node:  "worker-1",
+		Evict: []DrainPod{{Namespace: "payments", Name: "api-1"}, {Namespace: "payments", Name: "api-2"}},
+		Skip:  []DrainPod{{Namespace: "kube-system", Name: "fluentd-abc"}},
+	})
+	if plan.Summary != "drain node worker-1: cordon it and evict 2 pod(s); 1 DaemonSet or static pod(s) stay" {
+		t.Errorf("Summary = %q", plan.Summary)
+	}
+	evictions, _ := json.Marshal(plan.Details["evictions"])
+	if string(evictions) != `["payments/api-1","payments/api-2"]` {
+		t.Errorf("Details[evictions] = %s", evictions)
+	}
+}
+
+func TestCordonNodeTool_Success(t *testing.T) {
+	var calls [][]string
+	orig := runKubectl
+	runKubectl = func(_ context.Context, _ clusterInfo, args ...string) (string, error) {
+		calls = append(calls, args)
+		if args[0] == "cordon" {
+			return "node/worker-1 cordoned\n", nil
+		}
+		return "true", nil
+	}
+	defer func() { runKubectl = orig }()
+
+	result, err := cordonNodeTool(newK8sTestContext(), CordonNodeArgs{NodeName: "worker-1"})
+	if err != nil {
+		t.Fatalf("cordonNodeTool() error = %v", err)
+	}
+	if result.VerifyStatus != "ok" || !strings.Contains(result.Output, "cordoned") {
+		t.Errorf("cordonNodeTool() = %+v, want verified cordon", result)
+	}
+	if strings.Join(calls[0], " ") != "cordon worker-1" {
+		t.Errorf("first kubectl call = %v, want cordon worker-1", calls[0])
+	}
+}
+
+func TestUncordonNodeTool_VerificationWarning(t *testing.T) {
+	defer withZeroVerifyConfig()()
+	defer withMockKubectlSequence(
+		kubectlResponse{out: "node/worker-1 uncordoned\n"},
+		kubectlResponse{out: "true"}, // still unschedulable
+	)()
+
+	result, err := uncordonNodeTool(newK8sTestContext(), UncordonNodeArgs{NodeName: "worker-1"})
+	if err != nil {
+		t.Fatalf("uncordonNodeTool() error = %v", err)
+	}
+	if result.VerifyStatus != "warning" || !strings.Contains(result.Output, "VERIFICATION WARNING") {
+		t.Errorf("uncordonNodeTool() = %+v, want verification warning", result)
+	}
+}
+
+func TestNodeTools_RequireApprovalByToolPolicy(t *testing.T) {
+	// The node-maintenance policy shipped in policies.example.yaml: node tools
+	// need approval even where other destructive operations are allowed.
+	const yaml = `
+version: "1"
+policies:
+  - name: k8s-node-maintenance
+    priority: 220
+    resources:
+      - type: kubernetes
+        match:
+          tool_pattern: "*_node"
+    rules:
+      - action: destructive
+        effect: allow
+        conditions:
+          require_approval: true
+          blocked_purposes: [diagnostic]
+  - name: allow-k8s
+    resources:
+      - type: kubernetes
+    rules:
+      - action: [read, write, destructive]
+        effect: allow
+`
+	engine, err := agentutil.InitPolicyEngine(agentutil.Config{
+		PolicyEnabled: true,
+		PolicyFile:    writeTempK8sPolicyFile(t, yaml),
+		DefaultPolicy: "deny",
+	})
+	if err != nil {
+		t.Fatalf("InitPolicyEngine: %v", err)
+	}
+	defer withK8sPolicyEnforcer(agentutil.NewPolicyEnforcerWithConfig(agentutil.PolicyEnforcerConfig{Engine: engine}))()
+	defer injectFakeClientset("", newDrainFixture(1))()
+	defer withMockKubectl("true", nil)()
+
+	ctx := newK8sTestContext()
+	if _, err := cordonNodeTool(ctx, CordonNodeArgs{NodeName: "worker-1"}); err == nil || !strings.Contains(err.Error(), "approval") {
+		t.Errorf("cordonNodeTool() error = %v, want approval required", err)
+	}
+	if _, err := drainNodeTool(ctx, DrainNodeArgs{NodeName: "worker-1"}); err == nil || !strings.Contains(err.Error(), "approval") {
+		t.Errorf("drainNodeTool() error = %v, want approval required", err)
+	}
+	if _, err := drainNodeTool(ctx, DrainNodeArgs{NodeName: "worker-1", DryRun: true}); err != nil {
+		t.Errorf("drainNodeTool(dry_run) error = %v, want the dry run allowed without approval", err)
+	}
+}
+
+// --- storage tool tests ---
+
+// newStorageFixture returns a fake clientset with a database namespace whose
+// claims show the common storage failures: data-pg-0 requests a missing
+// StorageClass, data-pg-1 is bound but its pod cannot attach the volume, and
+// scratch waits for its first consumer.
+func newStorageFixture() *fake.Clientset {
+	className := func(s string) *string { return &s }
+	wffc := storagev1.VolumeBindingWaitForFirstConsumer
+	retain := corev1.PersistentVolumeReclaimRetain
+	pvName := "pvc-1234"
+	claim := func(name string, class *string, phase corev1.PersistentVolumeClaimPhase, volume string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db", CreationTimestamp: metav1.Now()},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: class,
+				VolumeName:       volume,
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	podWithClaim := func(name, claimName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}},
+			}}},
+		}
+	}
+	return fake.NewClientset(
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "standard", Annotations: map[string]string{defaultStorageClassAnnotation: "true"}},
+			Provisioner: "ebs.csi.aws.com",
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: "local"},
+			Provisioner:       "kubernetes.io/no-provisioner",
+			VolumeBindingMode: &wffc,
+			ReclaimPolicy:     &retain,
+		},
+		claim("data-pg-0", className("fast-ssd"), corev1.ClaimPending, ""),
+		claim("data-pg-1", className("standard"), corev1.ClaimBound, pvName),
+		claim("scratch", className("local"), corev1.ClaimPending, ""),
+		podWithClaim("pg-0", "data-pg-0"),
+		podWithClaim("pg-1", "data-pg-1"),
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "pg-1.attach", Namespace: "db"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pg-1", Namespace: "db"},
+			Type:           "Warning",
+			Reason:         "FailedAttachVolume",
+			Message:        `Multi-Attach error for volume "pvc-1234" Volume is already exclusively attached to one node and can't be attached to another`,
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvName},
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				StorageClassName:              "standard",
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "db", Name: "data-pg-1"},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-0abc"},
+				},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		&storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-1"},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "ebs.csi.aws.com",
+				NodeName: "worker-2",
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+			},
+			Status: storagev1.VolumeAttachmentStatus{
+				AttachError: &storagev1.VolumeError{Message: "rpc error: volume vol-0abc is attached to worker-1"},
+			},
+		},
+	)
+}
+
+func storageSignatures(issues []StorageIssue) map[string]string {
+	got := make(map[string]string, len(issues))
+	for _, i := range issues {
+		got[i.Object] = i.Signature
+	}
+	return got
+}
+
+func TestGetPVCStatus_DetectsSignatures(t *testing.T) {
+	defer injectFakeClientset("", newStorageFixture())()
+
+	result, err := getPVCStatusTool(newK8sTestContext(), GetPVCStatusArgs{Namespace: "db"})
+	if err != nil {
+		t.Fatalf("getPVCStatusTool() error = %v", err)
+	}
+	if result.Count != 3 {
+		t.Fatalf("Count = %d, want 3", result.Count)
+	}
+	pvc := result.PVCs[1]
+	if pvc.Name != "data-pg-1" || pvc.Status != "Bound" || pvc.Volume != "pvc-1234" || pvc.Requested != "10Gi" {
+		t.Errorf("PVCs[1] = %+v, want bound data-pg-1 on pvc-1234 requesting 10Gi", pvc)
+	}
+	if len(pvc.UsedBy) != 1 || pvc.UsedBy[0] != "pg-1" {
+		t.Errorf("UsedBy = %v, want [pg-1]", pvc.UsedBy)
+	}
+
+	got := storageSignatures(result.Issues)
+	want := map[string]string{
+		"PersistentVolumeClaim/data-pg-0": "storage_class_not_found",
+		"Pod/pg-1":                        "volume_multi_attach",
+		"PersistentVolumeClaim/scratch":   "wait_for_first_consumer",
+	}
+	if len(got) != len(want) {
+		t.Errorf("issues = %+v, want %v", result.Issues, want)
+	}
+	for object, sig := range want {
+		if got[object] != sig {
+			t.Errorf("issue on %s = %q, want %q", object, got[object], sig)
+		}
+	}
+}
+
+func TestGetPVCStatus_SingleClaim(t *testing.T) {
+	defer injectFakeClientset("", newStorageFixture())()
+
+	result, err := getPVCStatusTool(newK8sTestContext(), GetPVCStatusArgs{Namespace: "db", PVCName: "data-pg-0"})
+	if err != nil {
+		t.Fatalf("getPVCStatusTool() error = %v", err)
+	}
+	if result.Count != 1 || len(result.Issues) != 1 {
+		t.Fatalf("result = %+v, want one claim with one issue", result)
+	}
+	if !strings.Contains(result.Issues[0].Detail, `"fast-ssd"`) {
+		t.Errorf("Detail = %q, want it to name the missing StorageClass", result.Issues[0].Detail)
+	}
+}
+
+func TestGetStorageClass(t *testing.T) {
+	defer injectFakeClientset("", newStorageFixture())()
+
+	result, err := getStorageClassTool(newK8sTestContext(), GetStorageClassArgs{})
+	if err != nil {
+		t.Fatalf("getStorageClassTool() error = %v", err)
+	}
+	if result.Count != 2 {
+		t.Fatalf("Count = %d, want 2", result.Count)
+	}
+	local, standard := result.StorageClasses[0], result.StorageClasses[1]
+	if local.VolumeBindingMode != "WaitForFirstConsumer" || local.ReclaimPolicy != "Retain" || local.Default {
+		t.Errorf("local = %+v", local)
+	}
+	if standard.VolumeBindingMode != "Immediate" || standard.ReclaimPolicy != "Delete" || !standard.Default {
+		t.Errorf("standard = %+v, want the defaults and default=true", standard)
+	}
+}
+
+func TestGetNamespaces(t *testing.T) {
+	terminating := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "old-app", CreationTimestamp: metav1.Now()},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	active := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "database", CreationTimestamp: metav1.Now()},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	defer injectFakeClientset("", fake.NewSimpleClientset(terminating, active))()
+
+	result, err := getNamespacesTool(newK8sTestContext(), GetNamespacesArgs{})
+	if err != nil {
+		t.Fatalf("getNamespacesTool() error = %v", err)
+	}
+	if result.Count != 2 {
+		t.Fatalf("Count = %d, want 2", result.Count)
+	}
+	if got := result.Namespaces[0]; got.Name != "database" || got.Status != "Active" {
+		t.Errorf("Namespaces[0] = %+v, want database, Active (sorted by name)", got)
+	}
+	if got := result.Namespaces[1]; got.Name != "old-app" || got.Status != "Terminating" {
+		t.Errorf("Namespaces[1] = %+v, want old-app, Terminating", got)
+	}
+}
+
+func TestDescribePV_AttachError(t *testing.T) {
+	defer injectFakeClientset("", newStorageFixture())()
+
+	result, err := describePVTool(newK8sTestContext(), DescribePVArgs{PVName: "pvc-1234"})
+	if err != nil {
+		t.Fatalf("describePVTool() error = %v", err)
+	}
+	if result.Count != 1 {
+		t.Fatalf("Count = %d, want 1", result.Count)
+	}
+	pv := result.Volumes[0]
+	if pv.Claim != "db/data-pg-1" || pv.Source != "csi: ebs.csi.aws.com (vol-0abc)" {
+		t.Errorf("volume = %+v", pv)
+	}
+	if len(pv.Attachments) != 1 || pv.Attachments[0].Node != "worker-2" {
+		t.Fatalf("Attachments = %+v, want one on worker-2", pv.Attachments)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Signature != "volume_attach_failed" {
+		t.Errorf("Issues = %+v, want volume_attach_failed", result.Issues)
+	}
+}
+
+func TestDescribePV_NotFound(t *testing.T) {
+	defer injectFakeClientset("", newStorageFixture())()
+
+	if _, err := describePVTool(newK8sTestContext(), DescribePVArgs{PVName: "missing"}); err == nil {
+		t.Error("describePVTool() error = nil, want not found")
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/secrets"
+)
+
+// Embedding providers selectable with HELPDESK_KB_EMBEDDINGS.
+const (
+	embedderGemini = "gemini"
+	embedderOpenAI = "openai" // any OpenAI-compatible /v1/embeddings API
+	embedderLocal  = "local"
+)
+
+const (
+	defaultGeminiEmbeddingModel = "gemini-embedding-001"
+	defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+	defaultOpenAIEmbeddingURL   = "https://api.openai.com/v1/embeddings"
+
+	// embedBatchSize is the most texts sent in one embedding request.
+	embedBatchSize = 100
+	// localDims is the size of the local embedder's hashed vectors.
+	localDims = 1024
+)
+
+// embedder turns texts into vectors; texts close in meaning get vectors with
+// a high cosine similarity.
+type embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// newEmbedder returns the embedder selected by HELPDESK_KB_EMBEDDINGS. Without
+// it, Gemini deployments embed with Gemini and others use the local embedder,
+// since Anthropic has no embeddings API.
+func newEmbedder(ctx context.Context, cfg agentutil.Config) (embedder, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("HELPDESK_KB_EMBEDDINGS")))
+	vendor := strings.ToLower(cfg.ModelVendor)
+	if name == "" {
+		name = embedderLocal
+		if vendor == "gemini" || vendor == "google" {
+			name = embedderGemini
+		}
+	}
+	key, err := secrets.Getenv(ctx, "HELPDESK_KB_EMBEDDING_API_KEY")
+	if err != nil {
+		return nil, fmt.Errorf("resolve HELPDESK_KB_EMBEDDING_API_KEY: %w", err)
+	}
+	model := os.Getenv("HELPDESK_KB_EMBEDDING_MODEL")
+
+	switch name {
+	case embedderGemini:
+		if key == "" && (vendor == "gemini" || vendor == "google") {
+			key = cfg.APIKey
+		}
+		if key == "" {
+			return nil, fmt.Errorf("HELPDESK_KB_EMBEDDINGS=gemini requires HELPDESK_KB_EMBEDDING_API_KEY")
+		}
+		if model == "" {
+			model = defaultGeminiEmbeddingModel
+		}
+		cc := &genai.ClientConfig{APIKey: key, Backend: genai.BackendGeminiAPI}
+		if cfg.LLMTransport != nil {
+			cc.HTTPClient = &http.Client{Transport: cfg.LLMTransport}
+		}
+		client, err := genai.NewClient(ctx, cc)
+		if err != nil {
+			return nil, fmt.Errorf("create Gemini client: %w", err)
+		}
+		return &geminiEmbedder{models: client.Models, model: model}, nil
+	case embedderOpenAI:
+		endpoint := os.Getenv("HELPDESK_KB_EMBEDDING_URL")
+		if endpoint == "" {
+			endpoint = defaultOpenAIEmbeddingURL
+		}
+		if model == "" {
+			model = defaultOpenAIEmbeddingModel
+		}
+		return &openAIEmbedder{endpoint: endpoint, key: key, model: model, client: &http.Client{Timeout: time.Minute}}, nil
+	case embedderLocal:
+		return localEmbedder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown HELPDESK_KB_EMBEDDINGS %q (want %s, %s or %s)", name, embedderGemini, embedderOpenAI, embedderLocal)
+	}
+}
+
+// embedAll embeds texts in batches of embedBatchSize.
+func embedAll(ctx context.Context, e embedder, texts []string) ([][]float32, error) {
+	var vecs [][]float32
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		batch, err := e.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("%s embeddings: got %d vectors for %d texts", e.Name(), len(batch), end-start)
+		}
+		vecs = append(vecs, batch...)
+	}
+	return vecs, nil
+}
+
+// geminiEmbedder embeds with the Gemini embeddings API.
+type geminiEmbedder struct {
+	models *genai.Models
+	model  string
+}
+
+func (g *geminiEmbedder) Name() string { return embedderGemini }
+
+func (g *geminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, t := range texts {
+		contents[i] = genai.NewContentFromText(t, genai.RoleUser)
+	}
+	resp, err := g.models.EmbedContent(ctx, g.model, contents, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gemini embeddings: %w", err)
+	}
+	vecs := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		vecs[i] = e.Values
+	}
+	return vecs, nil
+}
+
+// openAIEmbedder embeds with an OpenAI-compatible embeddings endpoint
+// (OpenAI, Azure OpenAI, Voyage, Ollama, vLLM, ...).
+type openAIEmbedder struct {
+	endpoint, key, model string
+	client               *http.Client
+}
+
+func (o *openAIEmbedder) Name() string { return embedderOpenAI }
+
+func (o *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": o.model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.key != "" {
+		req.Header.Set("Authorization", "Bearer "+o.key)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openai embeddings: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("openai embeddings: invalid response: %w", err)
+	}
+	vecs := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vecs) {
+			return nil, fmt.Errorf("openai embeddings: index %d out of range", d.Index)
+		}
+		vecs[d.Index] = d.Embedding
+	}
+	return vecs, nil
+}
+
+// localEmbedder hashes words and word pairs into a fixed-size vector. It needs
+// no API and matches on shared vocabulary rather than meaning, which suits
+// runbooks searched by error messages and component names.
+type localEmbedder struct{}
+
+func (localEmbedder) Name() string { return embedderLocal }
+
+func (localEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vecs := make([][]float32, len(texts))
+	for i, t := range texts {
+		vecs[i] = hashEmbed(t)
+	}
+	return vecs, nil
+}
+
+func hashEmbed(text string) []float32 {
+	vec := make([]float32, localDims)
+	add := func(term string, weight float32) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(term))
+		sum := h.Sum32()
+		sign := float32(1)
+		if sum&(1<<31) != 0 {
+			sign = -1
+		}
+		vec[sum%localDims] += sign * weight
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	prev := ""
+	for _, w := range words {
+		if len(w) < 2 || stopWords[w] {
+			prev = ""
+			continue
+		}
+		add(w, 1)
+		if prev != "" {
+			add(prev+" "+w, 0.5)
+		}
+		prev = w
+	}
+	normalize(vec)
+	return vec
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "or": true, "of": true, "to": true, "in": true, "on": true,
+	"is": true, "it": true, "for": true, "with": true, "we": true, "did": true, "do": true,
+	"how": true, "what": true, "this": true, "that": true, "was": true, "be": true, "at": true,
+	"an": true, "as": true, "by": true, "if": true, "last": true, "time": true,
+}
+
+// normalize scales vec to unit length, so a dot product is a cosine similarity.
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= n
+	}
+}
+
+// cosine returns the cosine similarity of a and b.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// maxChunkChars caps the size of a chunk; longer sections are split at
+// paragraph breaks so each embedding covers one topic.
+const maxChunkChars = 2000
+
+// maxHitsPerDoc limits how many sections of one document a search returns,
+// so one long runbook doesn't crowd out the others.
+const maxHitsPerDoc = 2
+
+// document is one markdown file of the knowledge base.
+type document struct {
+	Path     string // relative to the knowledge base directory, slash-separated
+	Title    string
+	Modified time.Time
+	Size     int64
+	Body     string
+	Chunks   []chunk
+}
+
+// chunk is a section of a document, the unit that is embedded and searched.
+type chunk struct {
+	Heading string // section heading; empty for text before the first heading
+	Anchor  string // GitHub-style anchor of Heading
+	Text    string
+	vec     []float32
+}
+
+// hit is a search result.
+type hit struct {
+	Doc   *document
+	Chunk chunk
+	Score float64
+}
+
+// index holds the embedded documents of a knowledge base directory. refresh
+// re-reads the directory and re-embeds only the files that changed.
+type index struct {
+	dir   string
+	embed embedder
+
+	refreshMu sync.Mutex // serializes refreshes
+	mu        sync.RWMutex
+	docs      map[string]*document // by Path
+}
+
+func newIndex(dir string, e embedder) *index {
+	return &index{dir: dir, embed: e, docs: make(map[string]*document)}
+}
+
+// isMarkdown reports whether name is a markdown file.
+func isMarkdown(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// refresh brings the index up to date with the directory. It returns the
+// number of documents (re-)embedded. On error the previous index is kept.
+func (x *index) refresh(ctx context.Context) (int, error) {
+	x.refreshMu.Lock()
+	defer x.refreshMu.Unlock()
+
+	x.mu.RLock()
+	old := x.docs
+	x.mu.RUnlock()
+
+	docs := make(map[string]*document, len(old))
+	var changed []*document
+	err := filepath.WalkDir(x.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != x.dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isMarkdown(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(x.dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if prev, ok := old[rel]; ok && prev.Modified.Equal(info.ModTime()) && prev.Size == info.Size() {
+			docs[rel] = prev
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		doc := parseMarkdown(rel, string(data))
+		doc.Modified = info.ModTime()
+		doc.Size = info.Size()
+		docs[rel] = doc
+		changed = append(changed, doc)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("read knowledge base %s: %w", x.dir, err)
+	}
+
+	var texts []string
+	for _, doc := range changed {
+		for _, c := range doc.Chunks {
+			texts = append(texts, embeddingText(doc, c))
+		}
+	}
+	if len(texts) > 0 {
+		vecs, err := embedAll(ctx, x.embed, texts)
+		if err != nil {
+			return 0, err
+		}
+		i := 0
+		for _, doc := range changed {
+			for j := range doc.Chunks {
+				doc.Chunks[j].vec = vecs[i]
+				i++
+			}
+		}
+	}
+
+	x.mu.Lock()
+	x.docs = docs
+	x.mu.Unlock()
+	return len(changed), nil
+}
+
+// embeddingText is the text embedded for a chunk: the document title and
+// section heading give short sections their context.
+func embeddingText(doc *document, c chunk) string {
+	var sb strings.Builder
+	sb.WriteString(doc.Title)
+	if c.Heading != "" {
+		sb.WriteString(" — ")
+		sb.WriteString(c.Heading)
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(c.Text)
+	return sb.String()
+}
+
+// search returns the sections most similar to query, best first. The index is
+// refreshed first; if that fails the current index is searched.
+func (x *index) search(ctx context.Context, query string, limit int) ([]hit, error) {
+	if n, err := x.refresh(ctx); err != nil {
+		slog.Warn("knowledge base refresh failed; searching the current index", "err", err)
+	} else if n > 0 {
+		slog.Info("knowledge base refreshed", "documents", n)
+	}
+	vecs, err := x.embed.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("%s embeddings: got %d vectors for 1 text", x.embed.Name(), len(vecs))
+	}
+	q := vecs[0]
+
+	x.mu.RLock()
+	var hits []hit
+	for _, doc := range x.docs {
+		for _, c := range doc.Chunks {
+			if s := cosine(q, c.vec); s > 0 {
+				hits = append(hits, hit{Doc: doc, Chunk: c, Score: s})
+			}
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc.Path < hits[j].Doc.Path
+	})
+	var out []hit
+	perDoc := make(map[string]int)
+	for _, h := range hits {
+		if len(out) == limit {
+			break
+		}
+		if perDoc[h.Doc.Path] == maxHitsPerDoc {
+			continue
+		}
+		perDoc[h.Doc.Path]++
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// get returns the indexed document at path.
+func (x *index) get(path string) (*document, bool) {
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	doc, ok := x.docs[path]
+	return doc, ok
+}
+
+// size returns the number of indexed documents and sections.
+func (x *index) size() (docs, chunks int) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, doc := range x.docs {
+		chunks += len(doc.Chunks)
+	}
+	return len(x.docs), chunks
+}
+
+// parseMarkdown splits a markdown document into sections at its level 1-3
+// headings. The title is the front matter title, else the first level-1
+// heading, else the file name.
+func parseMarkdown(path, content string) *document {
+	doc := &document{Path: path}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content, doc.Title = stripFrontMatter(content)
+	doc.Body = content
+
+	var (
+		heading string
+		lines   []string
+		inFence bool
+	)
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(lines, "\n"))
+		lines = nil
+		if text == "" {
+			return
+		}
+		for _, part := range splitText(text, maxChunkChars) {
+			doc.Chunks = append(doc.Chunks, chunk{Heading: heading, Anchor: anchor(heading), Text: part})
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if level, text := headingOf(line); level > 0 && level <= 3 {
+				if level == 1 && doc.Title == "" {
+					doc.Title = text
+				}
+				flush()
+				heading = text
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	if doc.Title == "" {
+		base := filepath.Base(path)
+		doc.Title = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return doc
+}
+
+// stripFrontMatter removes a leading YAML front matter block and returns its
+// title, if any.
+func stripFrontMatter(content string) (body, title string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return content, ""
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return content, ""
+	}
+	for _, line := range strings.Split(content[4:4+end], "\n") {
+		if v, ok := strings.CutPrefix(line, "title:"); ok {
+			title = strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	rest := content[4+end+len("\n---"):]
+	return strings.TrimPrefix(rest, "\n"), title
+}
+
+// headingOf returns the level and text of an ATX heading line, or 0.
+func headingOf(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+}
+
+// anchor returns the GitHub-style anchor of a heading.
+func anchor(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteByte('-')
+		}
+	}
+	return sb.String()
+}
+
+// splitText splits text at blank lines into parts of at most limit
+// characters; a paragraph longer than limit is kept whole.
+func splitText(text string, limit int) []string {
+	if len(text) <= limit {
+		return []string{text}
+	}
+	var parts []string
+	var cur strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		if cur.Len() > 0 && cur.Len()+2+len(para) > limit {
+			parts = append(parts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		}
+		if cur.Len() > 0 {
+			cur.WriteString("\n\n")
+		}
+		cur.WriteString(para)
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, strings.TrimSpace(cur.String()))
+	}
+	return parts
+}
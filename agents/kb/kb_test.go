@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolconfirmation"
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+// mockToolContext implements tool.Context and agent.CallbackContext for one
+// invocation.
+type mockToolContext struct {
+	context.Context
+}
+
+func (mockToolContext) UserContent() *genai.Content {
+	return genai.NewContentFromText("How did we fix replica lag last time?", genai.RoleUser)
+}
+func (mockToolContext) InvocationID() string                 { return "test-invocation" }
+func (mockToolContext) AgentName() string                    { return "kb_agent" }
+func (mockToolContext) ReadonlyState() session.ReadonlyState { return nil }
+func (mockToolContext) UserID() string                       { return "test-user" }
+func (mockToolContext) AppName() string                      { return "test-app" }
+func (mockToolContext) SessionID() string                    { return "test-session" }
+func (mockToolContext) Branch() string                       { return "" }
+func (mockToolContext) Artifacts() agent.Artifacts           { return nil }
+func (mockToolContext) State() session.State                 { return nil }
+func (mockToolContext) FunctionCallID() string               { return "test-call-id" }
+func (mockToolContext) Actions() *session.EventActions       { return nil }
+func (mockToolContext) SearchMemory(context.Context, string) (*memory.SearchResponse, error) {
+	return nil, nil
+}
+func (mockToolContext) ToolConfirmation() *toolconfirmation.ToolConfirmation { return nil }
+func (mockToolContext) RequestConfirmation(string, any) error                { return nil }
+
+var (
+	_ tool.Context          = mockToolContext{}
+	_ agent.CallbackContext = mockToolContext{}
+)
+
+// countingEmbedder is the local embedder, counting the texts it embeds.
+type countingEmbedder struct{ texts int }
+
+func (*countingEmbedder) Name() string { return "counting" }
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts += len(texts)
+	return localEmbedder{}.Embed(ctx, texts)
+}
+
+const replicaLagPostmortem = `---
+title: Replica lag after failover
+---
+Summary of the March incident.
+
+## Root cause
+
+The new primary kept wal_keep_size at its replica default, so replicas fell behind and lag grew.
+
+## Fix
+
+Raised wal_keep_size to 2GB and re-synced the lagging replica with pg_basebackup.
+
+` + "```" + `
+# not a heading: inside a code fence
+pg_basebackup -h db-primary -D /var/lib/postgresql/data -R
+` + "```\n"
+
+const pgbouncerRunbook = `# PgBouncer restart
+
+## When to use
+
+Clients fail with "no more connections allowed" from PgBouncer.
+
+## Steps
+
+Drain the pool with PAUSE, then restart the pgbouncer service.
+`
+
+func writeKB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseMarkdown(t *testing.T) {
+	doc := parseMarkdown("postmortems/2026-03-replica-lag.md", replicaLagPostmortem)
+	if doc.Title != "Replica lag after failover" {
+		t.Errorf("Title = %q, want the front matter title", doc.Title)
+	}
+	var headings []string
+	for _, c := range doc.Chunks {
+		headings = append(headings, c.Heading)
+	}
+	if got := strings.Join(headings, "|"); got != "|Root cause|Fix" {
+		t.Errorf("section headings = %q, want %q", got, "|Root cause|Fix")
+	}
+	if fix := doc.Chunks[2]; fix.Anchor != "fix" || !strings.Contains(fix.Text, "# not a heading") {
+		t.Errorf("Fix section = %+v, want anchor %q and the fenced code kept", fix, "fix")
+	}
+
+	if doc := parseMarkdown("runbooks/no-title.md", "Just text."); doc.Title != "no-title" {
+		t.Errorf("Title = %q, want the file name", doc.Title)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	text := strings.Repeat("a", 30) + "\n\n" + strings.Repeat("b", 30) + "\n\n" + strings.Repeat("c", 30)
+	parts := splitText(text, 70)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "ccc") {
+		t.Errorf("splitText() = %q, want two parts split at a paragraph break", parts)
+	}
+}
+
+func TestIndex_SearchAndIncrementalRefresh(t *testing.T) {
+	dir := writeKB(t, map[string]string{
+		"postmortems/2026-03-replica-lag.md": replicaLagPostmortem,
+		"runbooks/pgbouncer-restart.md":      pgbouncerRunbook,
+		"runbooks/notes.txt":                 "not markdown",
+		".git/HEAD.md":                       "hidden",
+	})
+	emb := &countingEmbedder{}
+	idx := newIndex(dir, emb)
+	if n, err := idx.refresh(context.Background()); err != nil || n != 2 {
+		t.Fatalf("refresh() = %d, %v, want 2 documents", n, err)
+	}
+
+	hits, err := idx.search(context.Background(), "replicas lag behind after failover", 3)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) == 0 || hits[0].Doc.Path != "postmortems/2026-03-replica-lag.md" {
+		t.Fatalf("search() = %+v, want the replica lag postmortem first", hits)
+	}
+
+	// Unchanged files are not embedded again; a changed file is, and a
+	// removed one leaves the index.
+	before := emb.texts
+	if n, err := idx.refresh(context.Background()); err != nil || n != 0 {
+		t.Errorf("refresh() of an unchanged directory = %d, %v, want 0", n, err)
+	}
+	path := filepath.Join(dir, "runbooks/pgbouncer-restart.md")
+	if err := os.WriteFile(path, []byte(pgbouncerRunbook+"\n## Verify\n\nSHOW POOLS.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "postmortems/2026-03-replica-lag.md")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := idx.refresh(context.Background()); err != nil || n != 1 {
+		t.Errorf("refresh() after an edit = %d, %v, want 1", n, err)
+	}
+	if emb.texts-before != 3 {
+		t.Errorf("embedded %d texts after the edit, want the edited runbook's 3 sections", emb.texts-before)
+	}
+	if docs, _ := idx.size(); docs != 1 {
+		t.Errorf("index has %d documents, want 1 after the removal", docs)
+	}
+}
+
+func TestKB_SearchCitesDocumentsInAnswer(t *testing.T) {
+	dir := writeKB(t, map[string]string{
+		"postmortems/2026-03-replica-lag.md": replicaLagPostmortem,
+		"runbooks/pgbouncer-restart.md":      pgbouncerRunbook,
+	})
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "kb.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+
+	idx := newIndex(dir, localEmbedder{})
+	k := newKB(idx, "https://git.example.com/ops/kb/blob/main/")
+	k.auditor = audit.NewToolAuditor(store, "kb_agent", "sess_kb", "trace_kb")
+	ctx := mockToolContext{context.Background()}
+
+	out, err := k.searchKnowledgeBase(ctx, SearchKnowledgeBaseArgs{Query: "wal_keep_size replica lag", Limit: 1})
+	if err != nil {
+		t.Fatalf("searchKnowledgeBase: %v", err)
+	}
+	if !strings.Contains(out.Output, "path: postmortems/2026-03-replica-lag.md") {
+		t.Errorf("output = %q, want the postmortem path", out.Output)
+	}
+	doc, err := k.getDocument(ctx, GetDocumentArgs{Path: "./postmortems/2026-03-replica-lag.md"})
+	if err != nil || !strings.Contains(doc.Output, "pg_basebackup") {
+		t.Errorf("getDocument() = %q, %v, want the full postmortem", doc.Output, err)
+	}
+	if out, _ := k.getDocument(ctx, GetDocumentArgs{Path: "../../etc/passwd.md"}); !strings.HasPrefix(out.Output, "ERROR") {
+		t.Errorf("getDocument() outside the index = %q, want an error", out.Output)
+	}
+
+	answer := &model.LLMResponse{Content: genai.NewContentFromText("Raise wal_keep_size and re-sync the replica.", genai.RoleModel)}
+	resp, _ := k.afterModel(ctx, answer, nil)
+	if resp == nil {
+		t.Fatal("afterModel() = nil, want the cited answer")
+	}
+	cs, _ := resp.CustomMetadata[agentutil.CitationsMetadataKey].([]audit.Citation)
+	if len(cs) != 2 {
+		t.Fatalf("citations = %+v, want the searched section and the document", cs)
+	}
+	if !strings.HasPrefix(cs[0].URL, "https://git.example.com/ops/kb/blob/main/postmortems/2026-03-replica-lag.md#") {
+		t.Errorf("citation URL = %q, want a link to the section", cs[0].URL)
+	}
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeResearchResult})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 1 || len(events[0].ResearchResult.SearchQueries) != 1 || len(events[0].ResearchResult.Citations) != 2 {
+		t.Errorf("research_result events = %+v, want one with the search and both citations", events)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &req)
+		if req.Model != "m" || len(req.Input) != 2 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// Out of order, as the API allows.
+		_, _ = io.WriteString(w, `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`)
+	}))
+	defer srv.Close()
+
+	e := &openAIEmbedder{endpoint: srv.URL, key: "k", model: "m", client: srv.Client()}
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vecs) != 2 || vecs[0][0] != 1 || vecs[1][1] != 1 {
+		t.Errorf("Embed() = %v, want the vectors in input order", vecs)
+	}
+}
+
+func TestNewEmbedder(t *testing.T) {
+	tests := []struct {
+		name, env, vendor string
+		want              string
+		wantErr           bool
+	}{
+		{name: "anthropic defaults to local", vendor: "anthropic", want: embedderLocal},
+		{name: "openai", env: "openai", vendor: "anthropic", want: embedderOpenAI},
+		{name: "gemini needs a key", env: "gemini", vendor: "anthropic", wantErr: true},
+		{name: "unknown", env: "word2vec", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HELPDESK_KB_EMBEDDINGS", tt.env)
+			t.Setenv("HELPDESK_KB_EMBEDDING_API_KEY", "")
+			e, err := newEmbedder(context.Background(), agentutil.Config{ModelVendor: tt.vendor})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newEmbedder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && e.Name() != tt.want {
+				t.Errorf("embedder = %q, want %q", e.Name(), tt.want)
+			}
+		})
+	}
+}
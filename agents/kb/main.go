@@ -0,0 +1,119 @@
+// Package main implements the knowledge base agent.
+// It indexes a directory of internal runbooks and postmortems (markdown)
+// with embeddings and answers "how did we fix this last time?" with
+// citations to the documents it used.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/google/uuid"
+	"google.golang.org/adk/agent/llmagent"
+
+	"helpdesk/agentutil"
+	agentserve "helpdesk/agentutil/serve"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/prompts"
+)
+
+func main() {
+	buildinfo.HandleVersionFlag()
+
+	cfg := agentutil.MustLoadConfig("localhost:1107")
+	ctx := context.Background()
+
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "kb_agent",
+	})
+
+	dir := os.Getenv("HELPDESK_KB_DIR")
+	if dir == "" {
+		slog.Error("HELPDESK_KB_DIR is required: the directory of runbooks and postmortems to index")
+		os.Exit(1)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		slog.Error("HELPDESK_KB_DIR is not a directory", "dir", dir, "err", err)
+		os.Exit(1)
+	}
+
+	auditStore, err := agentserve.InitAuditStore(cfg)
+	if err != nil {
+		slog.Error("failed to initialize audit store", "err", err)
+		os.Exit(1)
+	}
+	traceStore := &audit.CurrentTraceStore{}
+
+	emb, err := newEmbedder(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to configure embeddings", "err", err)
+		os.Exit(1)
+	}
+	idx := newIndex(dir, emb)
+	if _, err := idx.refresh(ctx); err != nil {
+		slog.Error("failed to index knowledge base", "dir", dir, "err", err)
+		os.Exit(1)
+	}
+	docs, sections := idx.size()
+	slog.Info("knowledge base indexed", "dir", dir, "documents", docs, "sections", sections, "embeddings", emb.Name())
+
+	k := newKB(idx, os.Getenv("HELPDESK_KB_BASE_URL"))
+	if auditStore != nil {
+		defer func() { _ = auditStore.Close() }()
+		sessionID := "kb_" + uuid.New().String()[:8]
+		k.auditor = audit.NewToolAuditorWithTraceStore(auditStore, "kb_agent", sessionID, traceStore)
+	}
+
+	slog.Info("governance", "audit", cfg.AuditEnabled, "policy", false)
+
+	llmModel, err := agentutil.NewLLM(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to create LLM model", "err", err)
+		os.Exit(1)
+	}
+
+	tools, err := k.createTools()
+	if err != nil {
+		slog.Error("failed to create tools", "err", err)
+		os.Exit(1)
+	}
+
+	kbAgent, err := llmagent.New(llmagent.Config{
+		Name:                "kb_agent",
+		Description:         "Knowledge base agent that searches internal runbooks and postmortems to answer how a known issue was handled before, citing the documents.",
+		Instruction:         prompts.KB,
+		Model:               llmModel,
+		Tools:               tools,
+		AfterModelCallbacks: []llmagent.AfterModelCallback{k.afterModel},
+	})
+	if err != nil {
+		slog.Error("failed to create kb agent", "err", err)
+		os.Exit(1)
+	}
+
+	cardOpts := agentutil.CardOptions{
+		Version:  "1.0.0",
+		Provider: &a2a.AgentProvider{Org: "Helpdesk"},
+		SkillTags: map[string][]string{
+			"kb_agent":                       {"knowledge", "runbooks", "postmortems"},
+			"kb_agent-search_knowledge_base": {"knowledge", "runbooks", "postmortems", "search"},
+			"kb_agent-get_document":          {"knowledge", "runbooks", "postmortems"},
+		},
+		SkillExamples: map[string][]string{
+			"kb_agent-search_knowledge_base": {
+				"How did we fix replica lag after the last failover?",
+				"Is there a runbook for 'remaining connection slots are reserved'?",
+			},
+			"kb_agent-get_document": {"Show me the PgBouncer restart runbook"},
+		},
+	}
+
+	if err := agentserve.ServeWithTracing(ctx, kbAgent, cfg, traceStore, auditStore, cardOpts); err != nil {
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
+	}
+}
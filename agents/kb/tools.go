@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+const (
+	defaultSearchResults = 5
+	maxSearchResults     = 10
+	// excerptChars is how much of a section a search result shows.
+	excerptChars = 600
+)
+
+// SearchKnowledgeBaseArgs defines arguments for the search_knowledge_base tool.
+type SearchKnowledgeBaseArgs struct {
+	Query string `json:"query" jsonschema:"required,What to look for: the symptom, error message or component, e.g. 'replica lag after failover' or 'FATAL: remaining connection slots are reserved'."`
+	Limit int    `json:"limit,omitempty" jsonschema:"Number of sections to return (default 5, max 10)."`
+}
+
+// GetDocumentArgs defines arguments for the get_document tool.
+type GetDocumentArgs struct {
+	Path string `json:"path" jsonschema:"required,Path of the document as shown in search_knowledge_base results, e.g. 'postmortems/2026-03-replica-lag.md'."`
+}
+
+// KBResult is the result of the knowledge base tools.
+type KBResult struct {
+	Output string `json:"output"`
+}
+
+// kb implements the knowledge base tools and the callback that attaches the
+// documents they returned to the answer as citations.
+type kb struct {
+	idx     *index
+	baseURL string // prefix that turns document paths into links; may be empty
+	auditor *audit.ToolAuditor
+	now     func() time.Time
+
+	mu      sync.Mutex
+	lookups map[string]*lookups // by invocation ID
+}
+
+// lookups are the searches and documents of one invocation.
+type lookups struct {
+	queries   []string
+	citations []audit.Citation
+}
+
+func newKB(idx *index, baseURL string) *kb {
+	return &kb{idx: idx, baseURL: strings.TrimRight(baseURL, "/"), now: time.Now, lookups: make(map[string]*lookups)}
+}
+
+// citation returns the citation of a document section.
+func (k *kb) citation(doc *document, c chunk, retrieved time.Time) audit.Citation {
+	link := doc.Path
+	if k.baseURL != "" {
+		link = k.baseURL + "/" + doc.Path
+	}
+	title := doc.Title
+	if c.Anchor != "" {
+		link += "#" + c.Anchor
+		if c.Heading != doc.Title {
+			title += " › " + c.Heading
+		}
+	}
+	return audit.Citation{URL: link, Title: title, RetrievedAt: retrieved}
+}
+
+// note remembers a lookup of the invocation for its answer's citations.
+func (k *kb) note(invocationID, query string, cs ...audit.Citation) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l := k.lookups[invocationID]
+	if l == nil {
+		l = &lookups{}
+		k.lookups[invocationID] = l
+	}
+	if query != "" {
+		l.queries = append(l.queries, query)
+	}
+	for _, c := range cs {
+		dup := false
+		for _, seen := range l.citations {
+			if seen.URL == c.URL {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			l.citations = append(l.citations, c)
+		}
+	}
+}
+
+// take returns and forgets the lookups of an invocation.
+func (k *kb) take(invocationID string) *lookups {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l := k.lookups[invocationID]
+	delete(k.lookups, invocationID)
+	return l
+}
+
+func (k *kb) searchKnowledgeBase(ctx tool.Context, args SearchKnowledgeBaseArgs) (KBResult, error) {
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return KBResult{Output: "ERROR — search_knowledge_base needs a query."}, nil
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	limit = min(limit, maxSearchResults)
+
+	hits, err := k.idx.search(ctx, query, limit)
+	if err != nil {
+		return KBResult{Output: fmt.Sprintf("ERROR — knowledge base search failed: %v", err)}, nil
+	}
+	if len(hits) == 0 {
+		k.note(ctx.InvocationID(), query)
+		return KBResult{Output: fmt.Sprintf("No runbooks or postmortems match %q.", query)}, nil
+	}
+
+	retrieved := k.now().UTC()
+	var cs []audit.Citation
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d section(s) for %q, best match first:\n", len(hits), query)
+	for i, h := range hits {
+		title := h.Doc.Title
+		if h.Chunk.Heading != "" && h.Chunk.Heading != h.Doc.Title {
+			title += " › " + h.Chunk.Heading
+		}
+		fmt.Fprintf(&sb, "\n[%d] %s\n    path: %s  updated: %s  score: %.2f\n", i+1, title, h.Doc.Path, h.Doc.Modified.Format("2006-01-02"), h.Score)
+		fmt.Fprintf(&sb, "    %s\n", strings.ReplaceAll(excerpt(h.Chunk.Text, excerptChars), "\n", "\n    "))
+		cs = append(cs, k.citation(h.Doc, h.Chunk, retrieved))
+	}
+	sb.WriteString("\nUse get_document with a path for the full text.\n")
+	k.note(ctx.InvocationID(), query, cs...)
+	return KBResult{Output: sb.String()}, nil
+}
+
+func (k *kb) getDocument(ctx tool.Context, args GetDocumentArgs) (KBResult, error) {
+	doc, ok := k.idx.get(args.Path)
+	if !ok {
+		return KBResult{Output: fmt.Sprintf("ERROR — no document %q in the knowledge base. Use a path from search_knowledge_base results.", args.Path)}, nil
+	}
+	k.note(ctx.InvocationID(), "", k.citation(doc, chunk{}, k.now().UTC()))
+	return KBResult{Output: fmt.Sprintf("# %s\npath: %s  updated: %s\n\n%s", doc.Title, doc.Path, doc.Modified.Format("2006-01-02"), doc.Body)}, nil
+}
+
+// excerpt returns the first n characters of text, cut at a word boundary.
+func excerpt(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	cut := strings.LastIndexByte(text[:n], ' ')
+	if cut <= 0 {
+		cut = n
+	}
+	return text[:cut] + " …"
+}
+
+// afterModel attaches the documents the turn's searches returned to the final
+// answer as citations and records the answer.
+func (k *kb) afterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	if respErr != nil || resp == nil || resp.Partial || resp.Content == nil {
+		return nil, nil
+	}
+	for _, p := range resp.Content.Parts {
+		if p.FunctionCall != nil {
+			return nil, nil
+		}
+	}
+	l := k.take(ctx.InvocationID())
+	if l == nil {
+		return nil, nil
+	}
+	if len(l.citations) > 0 {
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = make(map[string]any)
+		}
+		resp.CustomMetadata[agentutil.CitationsMetadataKey] = l.citations
+	}
+	if k.auditor != nil {
+		var query string
+		if u := ctx.UserContent(); u != nil {
+			var texts []string
+			for _, p := range u.Parts {
+				if p.Text != "" {
+					texts = append(texts, p.Text)
+				}
+			}
+			query = strings.Join(texts, "\n")
+		}
+		k.auditor.RecordResearchResult(ctx, audit.ResearchResult{
+			Query:         query,
+			SearchQueries: l.queries,
+			Citations:     l.citations,
+		})
+	}
+	return resp, nil
+}
+
+func (k *kb) createTools() ([]tool.Tool, error) {
+	searchTool, err := functiontool.New(functiontool.Config{
+		Name:        "search_knowledge_base",
+		Description: "Search the internal runbooks and postmortems for sections relevant to a symptom, error or component. Returns the best-matching sections with their document path, last update date and an excerpt.",
+	}, k.searchKnowledgeBase)
+	if err != nil {
+		return nil, err
+	}
+	getTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_document",
+		Description: "Return the full text of a runbook or postmortem by the path shown in search_knowledge_base results.",
+	}, k.getDocument)
+	if err != nil {
+		return nil, err
+	}
+	return []tool.Tool{searchTool, getTool}, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// Response cache defaults, overridable with HELPDESK_RESEARCH_CACHE_TTL and
+// HELPDESK_RESEARCH_CACHE_SIZE.
+const (
+	defaultCacheTTL  = time.Hour
+	defaultCacheSize = 256
+)
+
+// normalizeQuery returns the cache key of a query: case, surrounding and
+// repeated whitespace and trailing punctuation are ignored, so "Look up
+// CVE-2026-1234" and "look up  cve-2026-1234?" share an answer.
+func normalizeQuery(q string) string {
+	q = strings.Join(strings.Fields(strings.ToLower(q)), " ")
+	return strings.TrimRight(q, "?.! ")
+}
+
+type cacheEntry struct {
+	resp     *model.LLMResponse
+	storedAt time.Time
+}
+
+// responseCache holds recent answers keyed by normalized query. Entries
+// expire after ttl; when full, the oldest entry is evicted.
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func newResponseCache(ttl time.Duration, size int) *responseCache {
+	return &responseCache{ttl: ttl, size: size, entries: make(map[string]cacheEntry), now: time.Now}
+}
+
+// get returns a copy of the cached answer to query, if one is fresh.
+func (c *responseCache) get(query string) (*model.LLMResponse, bool) {
+	key := normalizeQuery(query)
+	if key == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(e.storedAt) > c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return cloneResponse(e.resp), true
+}
+
+// put caches the answer to query.
+func (c *responseCache) put(query string, resp *model.LLMResponse) {
+	key := normalizeQuery(query)
+	if key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		oldest := ""
+		for k, e := range c.entries {
+			if oldest == "" || e.storedAt.Before(c.entries[oldest].storedAt) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cacheEntry{resp: cloneResponse(resp), storedAt: c.now()}
+}
+
+// cloneResponse copies the parts of a response the agent runtime may modify.
+func cloneResponse(resp *model.LLMResponse) *model.LLMResponse {
+	out := *resp
+	out.CustomMetadata = maps.Clone(resp.CustomMetadata)
+	if resp.Content != nil {
+		content := *resp.Content
+		content.Parts = append(content.Parts[:0:0], resp.Content.Parts...)
+		out.Content = &content
+	}
+	return &out
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+// citationsFromGrounding returns the web sources of a grounded response, one
+// per URL, in the order the model cited them.
+func citationsFromGrounding(md *genai.GroundingMetadata, retrievedAt time.Time) []audit.Citation {
+	if md == nil {
+		return nil
+	}
+	var cs []audit.Citation
+	seen := make(map[string]bool)
+	for _, chunk := range md.GroundingChunks {
+		if chunk == nil || chunk.Web == nil || chunk.Web.URI == "" || seen[chunk.Web.URI] {
+			continue
+		}
+		seen[chunk.Web.URI] = true
+		cs = append(cs, audit.Citation{
+			URL:         chunk.Web.URI,
+			Title:       chunk.Web.Title,
+			Domain:      chunk.Web.Domain,
+			RetrievedAt: retrievedAt,
+		})
+	}
+	return cs
+}
+
+// mergeCitations appends the citations of more not already in cs, by URL.
+func mergeCitations(cs, more []audit.Citation) []audit.Citation {
+	seen := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		seen[c.URL] = true
+	}
+	for _, c := range more {
+		if c.URL == "" || seen[c.URL] {
+			continue
+		}
+		seen[c.URL] = true
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// responseCitations returns the citations attached to a response.
+func responseCitations(resp *model.LLMResponse) []audit.Citation {
+	cs, _ := resp.CustomMetadata[agentutil.CitationsMetadataKey].([]audit.Citation)
+	return cs
+}
+
+// contentText joins the text parts of content.
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// isFinalAnswer reports whether resp is a complete text answer, as opposed to
+// a streamed fragment, a tool call or an error.
+func isFinalAnswer(resp *model.LLMResponse) bool {
+	if resp == nil || resp.Partial || resp.ErrorCode != "" || resp.Content == nil {
+		return false
+	}
+	for _, p := range resp.Content.Parts {
+		if p.FunctionCall != nil {
+			return false
+		}
+	}
+	return contentText(resp.Content) != ""
+}
+
+// researcher holds the research agent's model callbacks: answers are cited,
+// cached by query and recorded in the audit trail.
+type researcher struct {
+	cache    *responseCache // nil when caching is disabled
+	auditor  *audit.ToolAuditor
+	search   searchBackend // web_search backend; nil with GoogleSearch grounding
+	searches searchLog
+	now      func() time.Time
+}
+
+// beforeModel answers a query asked before from the cache, skipping the model
+// call and the web search it would run. Only the first model call of a turn
+// is answered this way; later calls carry the turn's own context.
+func (r *researcher) beforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	if r.cache == nil || req == nil || len(req.Contents) == 0 {
+		return nil, nil
+	}
+	last := req.Contents[len(req.Contents)-1]
+	if last == nil || last.Role != genai.RoleUser || contentText(last) == "" {
+		return nil, nil
+	}
+	query := contentText(ctx.UserContent())
+	resp, ok := r.cache.get(query)
+	if !ok {
+		return nil, nil
+	}
+	r.record(ctx, audit.ResearchResult{
+		Query:     query,
+		Citations: responseCitations(resp),
+		Cached:    true,
+	})
+	return resp, nil
+}
+
+// afterModel attaches the sources of an answer as citations — its grounding
+// metadata with GoogleSearch, the turn's web_search results otherwise — caches
+// it and records it.
+func (r *researcher) afterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	if respErr != nil || !isFinalAnswer(resp) {
+		return nil, nil
+	}
+	result := audit.ResearchResult{
+		Query:     contentText(ctx.UserContent()),
+		Citations: citationsFromGrounding(resp.GroundingMetadata, r.now().UTC()),
+	}
+	if resp.GroundingMetadata != nil {
+		result.SearchQueries = resp.GroundingMetadata.WebSearchQueries
+	}
+	if queries, cs := r.searches.take(ctx.InvocationID()); len(queries) > 0 {
+		result.SearchQueries = append(result.SearchQueries, queries...)
+		result.Citations = mergeCitations(result.Citations, cs)
+	}
+	if len(result.Citations) > 0 {
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = make(map[string]any)
+		}
+		resp.CustomMetadata[agentutil.CitationsMetadataKey] = result.Citations
+	}
+	if r.cache != nil {
+		r.cache.put(result.Query, resp)
+	}
+	r.record(ctx, result)
+	return resp, nil
+}
+
+func (r *researcher) record(ctx context.Context, result audit.ResearchResult) {
+	if r.auditor != nil {
+		r.auditor.RecordResearchResult(ctx, result)
+	}
+}
@@ -1,42 +1,80 @@
 // Package main implements the research agent.
-// It provides web search capabilities using Google Search for finding
-// up-to-date information. This agent is used with Gemini models to work
-// around the limitation that GoogleSearch cannot be combined with
-// function declarations in the same request.
+// It provides web search capabilities for finding up-to-date information:
+// Google Search grounding with Gemini models, or a web_search function tool
+// backed by Bing, Brave, SearxNG or Tavily (HELPDESK_SEARCH_BACKEND) for any
+// model vendor. It is a separate agent because GoogleSearch cannot be
+// combined with function declarations in the same request.
 package main
 
 import (
 	"context"
 	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/google/uuid"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/adk/tool/geminitool"
 
 	"helpdesk/agentutil"
 	agentserve "helpdesk/agentutil/serve"
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
 	"helpdesk/prompts"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := agentutil.MustLoadConfig("localhost:1106")
 	ctx := context.Background()
 
-	// Enforce governance compliance in fix mode before any other initialization.
-	agentutil.EnforceFixMode(ctx, agentutil.CheckFixModeViolations(cfg), "research_agent", cfg.AuditURL)
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "research_agent",
+	})
 
 	auditStore, err := agentserve.InitAuditStore(cfg)
 	if err != nil {
 		slog.Error("failed to initialize audit store", "err", err)
 		os.Exit(1)
 	}
+	traceStore := &audit.CurrentTraceStore{}
+	res := &researcher{now: time.Now}
 	if auditStore != nil {
 		defer func() { _ = auditStore.Close() }()
+		sessionID := "research_" + uuid.New().String()[:8]
+		res.auditor = audit.NewToolAuditorWithTraceStore(auditStore, "research_agent", sessionID, traceStore)
+	}
+
+	// Repeated queries are answered from the cache so they don't use up the
+	// search quota. HELPDESK_RESEARCH_CACHE_TTL=0 disables it.
+	cacheTTL := defaultCacheTTL
+	if s := os.Getenv("HELPDESK_RESEARCH_CACHE_TTL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			slog.Error("invalid HELPDESK_RESEARCH_CACHE_TTL", "value", s, "err", err)
+			os.Exit(1)
+		}
+		cacheTTL = d
+	}
+	cacheSize := defaultCacheSize
+	if s := os.Getenv("HELPDESK_RESEARCH_CACHE_SIZE"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			slog.Error("invalid HELPDESK_RESEARCH_CACHE_SIZE", "value", s)
+			os.Exit(1)
+		}
+		cacheSize = n
+	}
+	if cacheTTL > 0 {
+		res.cache = newResponseCache(cacheTTL, cacheSize)
+		slog.Info("research response cache enabled", "ttl", cacheTTL, "size", cacheSize)
 	}
-	traceStore := &audit.CurrentTraceStore{}
 
 	llmModel, err := agentutil.NewLLM(ctx, cfg)
 	if err != nil {
@@ -46,10 +84,31 @@ func main() {
 
 	slog.Info("governance", "audit", cfg.AuditEnabled, "policy", false)
 
-	// Only add GoogleSearch for Gemini models
+	// A configured search backend replaces GoogleSearch, which only works
+	// with Gemini models and can't be combined with function tools.
+	res.search, err = newSearchBackend(ctx)
+	if err != nil {
+		slog.Error("failed to configure search backend", "err", err)
+		os.Exit(1)
+	}
 	var tools []tool.Tool
-	if cfg.ModelVendor == "google" || cfg.ModelVendor == "gemini" {
+	isGemini := cfg.ModelVendor == "google" || cfg.ModelVendor == "gemini"
+	switch {
+	case res.search != nil:
+		webSearchTool, err := functiontool.New(functiontool.Config{
+			Name:        "web_search",
+			Description: "Search the web. Returns numbered results with title, URL and snippet. Cite the URLs you rely on.",
+		}, res.webSearch)
+		if err != nil {
+			slog.Error("failed to create web_search tool", "err", err)
+			os.Exit(1)
+		}
+		tools = append(tools, webSearchTool)
+		slog.Info("web search backend enabled", "backend", res.search.Name())
+	case isGemini:
 		tools = append(tools, geminitool.GoogleSearch{})
+	default:
+		slog.Warn("no web search available: set HELPDESK_SEARCH_BACKEND to bing, brave, searxng or tavily for non-Gemini models", "vendor", cfg.ModelVendor)
 	}
 
 	researchAgent, err := llmagent.New(llmagent.Config{
@@ -59,6 +118,8 @@ func main() {
 		Model:       llmModel,
 		Tools:       tools,
 		// No SubAgents - this allows GoogleSearch to work on Gemini
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{res.beforeModel},
+		AfterModelCallbacks:  []llmagent.AfterModelCallback{res.afterModel},
 	})
 	if err != nil {
 		slog.Error("failed to create research agent", "err", err)
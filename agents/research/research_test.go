@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+// mockCallbackContext implements agent.CallbackContext for a user query.
+type mockCallbackContext struct {
+	context.Context
+	user *genai.Content
+}
+
+func (m mockCallbackContext) UserContent() *genai.Content        { return m.user }
+func (mockCallbackContext) InvocationID() string                 { return "test-invocation" }
+func (mockCallbackContext) AgentName() string                    { return "research_agent" }
+func (mockCallbackContext) ReadonlyState() session.ReadonlyState { return nil }
+func (mockCallbackContext) UserID() string                       { return "test-user" }
+func (mockCallbackContext) AppName() string                      { return "test-app" }
+func (mockCallbackContext) SessionID() string                    { return "test-session" }
+func (mockCallbackContext) Branch() string                       { return "" }
+func (mockCallbackContext) Artifacts() agent.Artifacts           { return nil }
+func (mockCallbackContext) State() session.State                 { return nil }
+
+var _ agent.CallbackContext = mockCallbackContext{}
+
+func queryContext(q string) mockCallbackContext {
+	return mockCallbackContext{Context: context.Background(), user: genai.NewContentFromText(q, genai.RoleUser)}
+}
+
+// groundedAnswer is a Gemini answer grounded on two search results, one cited twice.
+func groundedAnswer() *model.LLMResponse {
+	return &model.LLMResponse{
+		Content: genai.NewContentFromText("CVE-2026-1234 is fixed in Redis 7.2.5.", genai.RoleModel),
+		GroundingMetadata: &genai.GroundingMetadata{
+			WebSearchQueries: []string{"CVE-2026-1234 redis"},
+			GroundingChunks: []*genai.GroundingChunk{
+				{Web: &genai.GroundingChunkWeb{URI: "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", Title: "nvd.nist.gov"}},
+				{Web: &genai.GroundingChunkWeb{URI: "https://redis.io/blog/security-7-2-5", Title: "redis.io"}},
+				{Web: &genai.GroundingChunkWeb{URI: "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", Title: "nvd.nist.gov"}},
+			},
+		},
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	if a, b := normalizeQuery("Look up CVE-2026-1234"), normalizeQuery("  look up   cve-2026-1234? "); a != b {
+		t.Errorf("normalizeQuery: %q != %q, want the same key", a, b)
+	}
+}
+
+func TestResponseCache_ExpiryAndEviction(t *testing.T) {
+	now := time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC)
+	c := newResponseCache(time.Hour, 2)
+	c.now = func() time.Time { return now }
+
+	c.put("a", groundedAnswer())
+	now = now.Add(time.Minute)
+	c.put("b", groundedAnswer())
+	now = now.Add(time.Minute)
+	c.put("c", groundedAnswer()) // evicts "a", the oldest
+	if _, ok := c.get("a"); ok {
+		t.Error(`get("a") hit, want it evicted`)
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Error(`get("b") missed, want a hit`)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.get("c"); ok {
+		t.Error(`get("c") hit after the TTL, want it expired`)
+	}
+}
+
+func TestResearcher_CitesCachesAndAudits(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "research.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	retrieved := time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC)
+	r := &researcher{
+		cache:   newResponseCache(time.Hour, 10),
+		auditor: audit.NewToolAuditor(store, "research_agent", "sess_research", "trace_research"),
+		now:     func() time.Time { return retrieved },
+	}
+
+	// First ask: the model answers and the answer is cited and cached.
+	ctx := queryContext("Look up CVE-2026-1234")
+	req := &model.LLMRequest{Contents: []*genai.Content{ctx.user}}
+	if resp, _ := r.beforeModel(ctx, req); resp != nil {
+		t.Fatal("beforeModel answered a new query from the cache")
+	}
+	resp, err := r.afterModel(ctx, groundedAnswer(), nil)
+	if err != nil || resp == nil {
+		t.Fatalf("afterModel() = %v, %v", resp, err)
+	}
+	cs, _ := resp.CustomMetadata[agentutil.CitationsMetadataKey].([]audit.Citation)
+	if len(cs) != 2 || cs[0].URL != "https://nvd.nist.gov/vuln/detail/CVE-2026-1234" || !cs[0].RetrievedAt.Equal(retrieved) {
+		t.Errorf("citations = %+v, want the two distinct sources retrieved at %s", cs, retrieved)
+	}
+
+	// Second ask, worded differently: served from the cache with the
+	// original citations.
+	ctx = queryContext("look up cve-2026-1234?")
+	req = &model.LLMRequest{Contents: []*genai.Content{ctx.user}}
+	cached, _ := r.beforeModel(ctx, req)
+	if cached == nil || contentText(cached.Content) != "CVE-2026-1234 is fixed in Redis 7.2.5." {
+		t.Fatalf("beforeModel() = %+v, want the cached answer", cached)
+	}
+	if len(responseCitations(cached)) != 2 {
+		t.Errorf("cached citations = %+v, want the original two", responseCitations(cached))
+	}
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeResearchResult})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d research_result events, want 2", len(events))
+	}
+	var searched, fromCache *audit.ResearchResult
+	for _, e := range events {
+		if e.ResearchResult.Cached {
+			fromCache = e.ResearchResult
+		} else {
+			searched = e.ResearchResult
+		}
+	}
+	if searched == nil || len(searched.Citations) != 2 || len(searched.SearchQueries) != 1 {
+		t.Errorf("searched result = %+v, want 2 citations and the search query", searched)
+	}
+	if fromCache == nil || len(fromCache.Citations) != 2 {
+		t.Errorf("cached result = %+v, want the original citations", fromCache)
+	}
+}
+
+func TestResearcher_SkipsToolCallsAndLaterCalls(t *testing.T) {
+	r := &researcher{cache: newResponseCache(time.Hour, 10), now: time.Now}
+	ctx := queryContext("Find Kubernetes autoscaling best practices")
+
+	toolCall := &model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{Name: "lookup"}},
+	}}}
+	if resp, _ := r.afterModel(ctx, toolCall, nil); resp != nil {
+		t.Error("afterModel handled a tool call as an answer")
+	}
+	r.cache.put("Find Kubernetes autoscaling best practices", groundedAnswer())
+
+	// A later model call in the same turn ends with a tool response, not the
+	// user's query, and must reach the model.
+	req := &model.LLMRequest{Contents: []*genai.Content{
+		ctx.user,
+		{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{Name: "lookup"}}}},
+	}}
+	if resp, _ := r.beforeModel(ctx, req); resp != nil {
+		t.Error("beforeModel answered a follow-up model call from the cache")
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/tool"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/secrets"
+)
+
+// Search backends selectable with HELPDESK_SEARCH_BACKEND. They give the
+// research agent a web_search function tool, so it can search with any model
+// vendor; GoogleSearch grounding works with Gemini models only.
+const (
+	searchBackendBing    = "bing"
+	searchBackendBrave   = "brave"
+	searchBackendSearxNG = "searxng"
+	searchBackendTavily  = "tavily"
+)
+
+// Default endpoints; HELPDESK_SEARCH_URL overrides them and is required for
+// SearxNG, which is self-hosted.
+const (
+	bingEndpoint   = "https://api.bing.microsoft.com/v7.0/search"
+	braveEndpoint  = "https://api.search.brave.com/res/v1/web/search"
+	tavilyEndpoint = "https://api.tavily.com/search"
+)
+
+// defaultSearchResults and maxSearchResults bound web_search's max_results.
+const (
+	defaultSearchResults = 5
+	maxSearchResults     = 10
+)
+
+// searchResult is one web search hit.
+type searchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// searchBackend runs web searches against one search API.
+type searchBackend interface {
+	Name() string
+	Search(ctx context.Context, query string, limit int) ([]searchResult, error)
+}
+
+// searchClient calls the search APIs.
+var searchClient = &http.Client{Timeout: 20 * time.Second}
+
+// newSearchBackend returns the backend selected by HELPDESK_SEARCH_BACKEND, or
+// nil when none is set. HELPDESK_SEARCH_API_KEY may be a secrets reference.
+func newSearchBackend(ctx context.Context) (searchBackend, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("HELPDESK_SEARCH_BACKEND")))
+	if name == "" {
+		return nil, nil
+	}
+	key, err := secrets.Getenv(ctx, "HELPDESK_SEARCH_API_KEY")
+	if err != nil {
+		return nil, fmt.Errorf("resolve HELPDESK_SEARCH_API_KEY: %w", err)
+	}
+	endpoint := os.Getenv("HELPDESK_SEARCH_URL")
+	orDefault := func(def string) string {
+		if endpoint != "" {
+			return endpoint
+		}
+		return def
+	}
+
+	switch name {
+	case searchBackendBing, searchBackendBrave, searchBackendTavily:
+		if key == "" {
+			return nil, fmt.Errorf("HELPDESK_SEARCH_BACKEND=%s requires HELPDESK_SEARCH_API_KEY", name)
+		}
+	}
+	switch name {
+	case searchBackendBing:
+		return &bingBackend{endpoint: orDefault(bingEndpoint), key: key}, nil
+	case searchBackendBrave:
+		return &braveBackend{endpoint: orDefault(braveEndpoint), key: key}, nil
+	case searchBackendTavily:
+		return &tavilyBackend{endpoint: orDefault(tavilyEndpoint), key: key}, nil
+	case searchBackendSearxNG:
+		if endpoint == "" {
+			return nil, fmt.Errorf("HELPDESK_SEARCH_BACKEND=searxng requires HELPDESK_SEARCH_URL (the instance's /search URL)")
+		}
+		return &searxngBackend{endpoint: endpoint, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown HELPDESK_SEARCH_BACKEND %q (want %s, %s, %s or %s)",
+			name, searchBackendBing, searchBackendBrave, searchBackendSearxNG, searchBackendTavily)
+	}
+}
+
+// getJSON sends req and decodes a JSON response into out.
+func getJSON(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := searchClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// withQuery returns endpoint with params added to its query string.
+func withQuery(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// bingBackend is the Bing Web Search API.
+type bingBackend struct{ endpoint, key string }
+
+func (b *bingBackend) Name() string { return searchBackendBing }
+
+func (b *bingBackend) Search(ctx context.Context, query string, limit int) ([]searchResult, error) {
+	u, err := withQuery(b.endpoint, url.Values{"q": {query}, "count": {strconv.Itoa(limit)}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", b.key)
+	var out struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	if err := getJSON(req, &out); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, v := range out.WebPages.Value {
+		results = append(results, searchResult{Title: v.Name, URL: v.URL, Snippet: v.Snippet})
+	}
+	return results, nil
+}
+
+// braveBackend is the Brave Search API.
+type braveBackend struct{ endpoint, key string }
+
+func (b *braveBackend) Name() string { return searchBackendBrave }
+
+func (b *braveBackend) Search(ctx context.Context, query string, limit int) ([]searchResult, error) {
+	u, err := withQuery(b.endpoint, url.Values{"q": {query}, "count": {strconv.Itoa(limit)}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Subscription-Token", b.key)
+	var out struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := getJSON(req, &out); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, v := range out.Web.Results {
+		results = append(results, searchResult{Title: v.Title, URL: v.URL, Snippet: v.Description})
+	}
+	return results, nil
+}
+
+// searxngBackend is a SearxNG instance with the JSON output format enabled.
+// The key, if set, is sent as a bearer token for instances behind a proxy.
+type searxngBackend struct{ endpoint, key string }
+
+func (b *searxngBackend) Name() string { return searchBackendSearxNG }
+
+func (b *searxngBackend) Search(ctx context.Context, query string, limit int) ([]searchResult, error) {
+	u, err := withQuery(b.endpoint, url.Values{"q": {query}, "format": {"json"}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if b.key != "" {
+		req.Header.Set("Authorization", "Bearer "+b.key)
+	}
+	var out struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getJSON(req, &out); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, v := range out.Results {
+		if len(results) == limit {
+			break
+		}
+		results = append(results, searchResult{Title: v.Title, URL: v.URL, Snippet: v.Content})
+	}
+	return results, nil
+}
+
+// tavilyBackend is the Tavily search API.
+type tavilyBackend struct{ endpoint, key string }
+
+func (b *tavilyBackend) Name() string { return searchBackendTavily }
+
+func (b *tavilyBackend) Search(ctx context.Context, query string, limit int) ([]searchResult, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "max_results": limit})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.key)
+	var out struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getJSON(req, &out); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, v := range out.Results {
+		results = append(results, searchResult{Title: v.Title, URL: v.URL, Snippet: v.Content})
+	}
+	return results, nil
+}
+
+// WebSearchArgs defines arguments for the web_search tool.
+type WebSearchArgs struct {
+	Query      string `json:"query" jsonschema:"required,The search query. Use precise terms: product names, versions, error messages, CVE IDs."`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"Number of results to return (default 5, max 10)."`
+}
+
+// WebSearchResult is the result of the web_search tool.
+type WebSearchResult struct {
+	Output string `json:"output"`
+}
+
+// searchLog collects the searches of each invocation, so the final answer
+// can cite what the searches found.
+type searchLog struct {
+	mu       sync.Mutex
+	searches map[string]*invocationSearches // by invocation ID
+}
+
+type invocationSearches struct {
+	queries   []string
+	citations []audit.Citation
+}
+
+func (l *searchLog) add(invocationID, query string, cs []audit.Citation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.searches == nil {
+		l.searches = make(map[string]*invocationSearches)
+	}
+	s := l.searches[invocationID]
+	if s == nil {
+		s = &invocationSearches{}
+		l.searches[invocationID] = s
+	}
+	s.queries = append(s.queries, query)
+	s.citations = append(s.citations, cs...)
+}
+
+// take returns and forgets the searches of an invocation.
+func (l *searchLog) take(invocationID string) (queries []string, cs []audit.Citation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.searches[invocationID]
+	delete(l.searches, invocationID)
+	if s == nil {
+		return nil, nil
+	}
+	return s.queries, s.citations
+}
+
+// webSearch runs the web_search tool: one query against the configured
+// backend, its hits numbered for the model to cite.
+func (r *researcher) webSearch(ctx tool.Context, args WebSearchArgs) (WebSearchResult, error) {
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return WebSearchResult{Output: "ERROR — web_search needs a query."}, nil
+	}
+	limit := args.MaxResults
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	if limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	results, err := r.search.Search(ctx, query, limit)
+	if err != nil {
+		return WebSearchResult{Output: fmt.Sprintf("ERROR — %s search failed: %v", r.search.Name(), err)}, nil
+	}
+	if len(results) == 0 {
+		return WebSearchResult{Output: fmt.Sprintf("No results for %q.", query)}, nil
+	}
+
+	retrieved := r.now().UTC()
+	var cs []audit.Citation
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d result(s) for %q:\n", len(results), query)
+	for i, res := range results {
+		fmt.Fprintf(&sb, "\n[%d] %s\n    %s\n", i+1, res.Title, res.URL)
+		if res.Snippet != "" {
+			fmt.Fprintf(&sb, "    %s\n", res.Snippet)
+		}
+		c := audit.Citation{URL: res.URL, Title: res.Title, RetrievedAt: retrieved}
+		if u, err := url.Parse(res.URL); err == nil {
+			c.Domain = u.Hostname()
+		}
+		cs = append(cs, c)
+	}
+	r.searches.add(ctx.InvocationID(), query, cs)
+	return WebSearchResult{Output: sb.String()}, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolconfirmation"
+	"google.golang.org/genai"
+)
+
+// mockToolContext implements tool.Context for a web_search call in the turn
+// of a user query.
+type mockToolContext struct {
+	mockCallbackContext
+}
+
+func (mockToolContext) FunctionCallID() string         { return "test-call-id" }
+func (mockToolContext) Actions() *session.EventActions { return nil }
+func (mockToolContext) SearchMemory(context.Context, string) (*memory.SearchResponse, error) {
+	return nil, nil
+}
+func (mockToolContext) ToolConfirmation() *toolconfirmation.ToolConfirmation { return nil }
+func (mockToolContext) RequestConfirmation(string, any) error                { return nil }
+
+var _ tool.Context = mockToolContext{}
+
+func TestSearchBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func(endpoint string) searchBackend
+		auth    string // header carrying the key
+		want    string // value of the auth header
+		body    string
+	}{
+		{
+			name:    "bing",
+			backend: func(u string) searchBackend { return &bingBackend{endpoint: u, key: "k1"} },
+			auth:    "Ocp-Apim-Subscription-Key", want: "k1",
+			body: `{"webPages":{"value":[{"name":"Redis 7.2.5","url":"https://redis.io/a","snippet":"fixed"}]}}`,
+		},
+		{
+			name:    "brave",
+			backend: func(u string) searchBackend { return &braveBackend{endpoint: u, key: "k2"} },
+			auth:    "X-Subscription-Token", want: "k2",
+			body: `{"web":{"results":[{"title":"Redis 7.2.5","url":"https://redis.io/a","description":"fixed"}]}}`,
+		},
+		{
+			name:    "searxng",
+			backend: func(u string) searchBackend { return &searxngBackend{endpoint: u + "/search"} },
+			body:    `{"results":[{"title":"Redis 7.2.5","url":"https://redis.io/a","content":"fixed"},{"title":"extra","url":"https://x"}]}`,
+		},
+		{
+			name:    "tavily",
+			backend: func(u string) searchBackend { return &tavilyBackend{endpoint: u, key: "k4"} },
+			auth:    "Authorization", want: "Bearer k4",
+			body: `{"results":[{"title":"Redis 7.2.5","url":"https://redis.io/a","content":"fixed"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.auth != "" && r.Header.Get(tt.auth) != tt.want {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				query = r.URL.Query().Get("q")
+				if r.Method == http.MethodPost {
+					var body struct {
+						Query string `json:"query"`
+					}
+					b, _ := io.ReadAll(r.Body)
+					_ = json.Unmarshal(b, &body)
+					query = body.Query
+				}
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			results, err := tt.backend(srv.URL).Search(context.Background(), "redis cve", 1)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if query != "redis cve" {
+				t.Errorf("backend got query %q, want %q", query, "redis cve")
+			}
+			if len(results) != 1 || results[0].URL != "https://redis.io/a" || results[0].Snippet != "fixed" {
+				t.Errorf("results = %+v, want the one Redis hit", results)
+			}
+		})
+	}
+}
+
+func TestSearchBackend_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := (&braveBackend{endpoint: srv.URL, key: "k"}).Search(context.Background(), "q", 5)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Search() error = %v, want the HTTP 429", err)
+	}
+}
+
+func TestNewSearchBackend(t *testing.T) {
+	tests := []struct {
+		name, backend, key, url string
+		want                    string // backend name; "" for none
+		wantErr                 bool
+	}{
+		{name: "unset"},
+		{name: "tavily", backend: "Tavily", key: "k", want: searchBackendTavily},
+		{name: "missing key", backend: "bing", wantErr: true},
+		{name: "searxng without url", backend: "searxng", wantErr: true},
+		{name: "searxng", backend: "searxng", url: "http://searx:8080/search", want: searchBackendSearxNG},
+		{name: "unknown", backend: "altavista", key: "k", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HELPDESK_SEARCH_BACKEND", tt.backend)
+			t.Setenv("HELPDESK_SEARCH_API_KEY", tt.key)
+			t.Setenv("HELPDESK_SEARCH_URL", tt.url)
+			b, err := newSearchBackend(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSearchBackend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if b != nil {
+				got = b.Name()
+			}
+			if got != tt.want {
+				t.Errorf("backend = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubBackend returns fixed results.
+type stubBackend struct{ results []searchResult }
+
+func (stubBackend) Name() string { return "stub" }
+func (s stubBackend) Search(context.Context, string, int) ([]searchResult, error) {
+	return s.results, nil
+}
+
+func TestWebSearch_CitesResultsInAnswer(t *testing.T) {
+	retrieved := time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC)
+	r := &researcher{
+		search: stubBackend{results: []searchResult{
+			{Title: "CVE-2026-1234", URL: "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", Snippet: "Redis before 7.2.5"},
+			{Title: "Redis 7.2.5", URL: "https://redis.io/blog/security-7-2-5"},
+		}},
+		now: func() time.Time { return retrieved },
+	}
+	ctx := mockToolContext{queryContext("Look up CVE-2026-1234")}
+
+	out, err := r.webSearch(ctx, WebSearchArgs{Query: "CVE-2026-1234 redis"})
+	if err != nil {
+		t.Fatalf("webSearch: %v", err)
+	}
+	if !strings.Contains(out.Output, "[2] Redis 7.2.5") || !strings.Contains(out.Output, "https://redis.io/blog/security-7-2-5") {
+		t.Errorf("output = %q, want numbered results with URLs", out.Output)
+	}
+
+	// The same source found twice is cited once.
+	if _, err := r.webSearch(ctx, WebSearchArgs{Query: "redis 7.2.5 security"}); err != nil {
+		t.Fatalf("webSearch: %v", err)
+	}
+	answer := genai.NewContentFromText("CVE-2026-1234 is fixed in Redis 7.2.5.", genai.RoleModel)
+	resp, _ := r.afterModel(ctx.mockCallbackContext, &model.LLMResponse{Content: answer}, nil)
+	if resp == nil {
+		t.Fatal("afterModel() = nil, want the cited answer")
+	}
+	cs := responseCitations(resp)
+	if len(cs) != 2 || cs[0].Domain != "nvd.nist.gov" || !cs[1].RetrievedAt.Equal(retrieved) {
+		t.Errorf("citations = %+v, want the two distinct search results", cs)
+	}
+	if qs, _ := r.searches.take(ctx.InvocationID()); qs != nil {
+		t.Errorf("searches left after the answer: %v", qs)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"context"
 	"log/slog"
 	"os"
-	"sort"
-	"strings"
 
 	"github.com/a2aproject/a2a-go/a2a"
 	"github.com/google/uuid"
@@ -29,26 +27,23 @@ import (
 var infraConfig *infra.Config
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := agentutil.MustLoadConfig("localhost:1103")
 	ctx := context.Background()
 
-	// Enforce governance compliance in fix mode before any other initialization.
-	agentutil.EnforceFixMode(ctx, agentutil.CheckFixModeViolations(cfg), "sysadmin_agent", cfg.AuditURL)
-
-	// Load infrastructure config (required for resolving server IDs to HostConfig).
-	if infraPath := os.Getenv("HELPDESK_INFRA_CONFIG"); infraPath != "" {
-		var err error
-		infraConfig, err = infra.Load(infraPath)
-		if err != nil {
-			slog.Warn("failed to load infrastructure config", "path", infraPath, "err", err)
-		} else {
-			dbKeys := make([]string, 0, len(infraConfig.DBServers))
-		for k := range infraConfig.DBServers {
-			dbKeys = append(dbKeys, k)
-		}
-		sort.Strings(dbKeys)
-		slog.Info("infrastructure config loaded", "databases", len(infraConfig.DBServers), "db_keys", strings.Join(dbKeys, ", "))
-		}
+	// Enforce governance compliance and self-test dependencies before any other initialization.
+	agentutil.StartupPreflight(ctx, cfg, agentutil.SelfTestOptions{
+		Component: "sysadmin_agent",
+	})
+
+	// Load infrastructure config: signed from auditd, or a local file.
+	var infraSrc *agentutil.InfraConfigSource
+	if ic, src, err := agentutil.LoadInfraConfig(ctx, cfg); err != nil {
+		slog.Error("failed to load infrastructure config", "err", err)
+		os.Exit(1)
+	} else {
+		infraConfig, infraSrc = ic, src
 	}
 
 	// Initialize audit store if enabled.
@@ -88,6 +83,9 @@ func main() {
 		AgentName:                  "sysadmin_agent",
 		ToolAuditor:                toolAuditor,
 		RequirePurposeForSensitive: os.Getenv("HELPDESK_REQUIRE_PURPOSE_FOR_SENSITIVE") == "true",
+		FreezeCheckURL:             cfg.AuditURL,
+		FreezeFailOpen:             os.Getenv("HELPDESK_FREEZE_FAIL_OPEN") == "true",
+		PolicyCacheTTL:             cfg.PolicyCacheTTL,
 	})
 
 	slog.Info("governance",
@@ -108,17 +106,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Validate tool arguments against each tool's input schema, for LLM and
+	// direct calls alike; rejections are audited as tool_args_rejected.
+	argValidator, err := agentutil.NewArgValidator(tools, toolAuditor)
+	if err != nil {
+		slog.Error("failed to build argument validator", "err", err)
+		os.Exit(1)
+	}
+
 	instruction := prompts.Sysadmin
 	if infraConfig != nil {
 		instruction += "\n\n## Known Infrastructure\n\n" + infraConfig.Summary()
 	}
 
+	// Pick up changes to the signed config. The summary in the instruction
+	// stays as loaded; name resolution and target checks use the new config.
+	if infraSrc != nil {
+		go infraSrc.Watch(ctx, agentutil.InfraRefreshInterval, func(ic *infra.Config) {
+			infraConfigMu.Lock()
+			infraConfig = ic
+			infraConfigMu.Unlock()
+		})
+	}
+
 	sysadminAgent, err := llmagent.New(llmagent.Config{
 		Name:        "sysadmin_agent",
 		Description: "Host-level operations agent that can inspect container and systemd service status, retrieve logs, check disk and memory, and restart database processes when authorized.",
 		Instruction: instruction,
 		Model:       llmModel,
 		Tools:       tools,
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{
+			argValidator.BeforeToolCallback(),
+		},
 		AfterModelCallbacks: []llmagent.AfterModelCallback{
 			agentutil.NewReasoningCallback(toolAuditor),
 		},
@@ -156,7 +175,9 @@ func main() {
 		ToolSchemas:     agentutil.ComputeInputSchemas(tools),
 	}
 
-	if err := agentserve.ServeWithTracingAndDirectTools(ctx, sysadminAgent, cfg, traceStore, auditStore, NewSysadminDirectRegistry(), cardOpts); err != nil {
+	directTools := NewSysadminDirectRegistry()
+	directTools.SetArgValidator(argValidator)
+	if err := agentserve.ServeWithTracingAndDirectTools(ctx, sysadminAgent, cfg, traceStore, auditStore, directTools, cardOpts); err != nil {
 		slog.Error("server stopped", "err", err)
 		os.Exit(1)
 	}
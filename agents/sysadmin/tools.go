@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
 	"sort"
 	"strings"
 	"sync"
@@ -26,25 +25,42 @@ var toolAuditor *audit.ToolAuditor
 // policyEnforcer is set during initialization for policy enforcement.
 var policyEnforcer *agentutil.PolicyEnforcer
 
-// CommandRunner abstracts command execution for testing.
-type CommandRunner interface {
-	Run(ctx context.Context, name string, args []string, env []string) (string, error)
+// containerRuntimeSpec allowlists the docker/podman subcommands used below.
+// Everything after "exec <container>" is a fixed, code-defined inner command.
+var containerRuntimeSpec = agentutil.CommandSpec{
+	Subcommands:      []string{"exec", "inspect", "logs", "cp", "restart"},
+	Flags:            map[string]bool{"--format": true, "--tail": true},
+	PassthroughAfter: map[string]int{"exec": 2},
+	Timeout:          2 * time.Minute,
 }
 
-// execRunner is the production CommandRunner.
-type execRunner struct{}
-
-func (execRunner) Run(ctx context.Context, name string, args []string, env []string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	if len(env) > 0 {
-		cmd.Env = append(os.Environ(), env...)
-	}
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+// hostSandbox is this agent's command allowlist. Targets are resolved from the
+// infrastructure config, but the sandbox still rejects any argument that would
+// be parsed as an unexpected flag.
+var hostSandbox = map[string]agentutil.CommandSpec{
+	"docker": containerRuntimeSpec,
+	"podman": containerRuntimeSpec,
+	"kubectl": {
+		Subcommands:     []string{"get", "exec"},
+		Flags:           map[string]bool{"--context": true, "-l": true, "-n": true, "-o": true},
+		AllowDoubleDash: true,
+		Timeout:         time.Minute,
+	},
+	"systemctl": {
+		Subcommands: []string{"show", "restart"},
+		Flags:       map[string]bool{"--property": true},
+		Timeout:     2 * time.Minute,
+	},
+	"journalctl": {
+		Flags:   map[string]bool{"-u": true, "-n": true, "--no-pager": false},
+		Timeout: 30 * time.Second,
+	},
+	"df":   {Flags: map[string]bool{"-h": false}, Timeout: 10 * time.Second},
+	"free": {Flags: map[string]bool{"-h": false}, Timeout: 10 * time.Second},
 }
 
 // cmdRunner is the active command runner. Override in tests.
-var cmdRunner CommandRunner = execRunner{}
+var cmdRunner agentutil.CommandRunner = agentutil.NewSandboxRunner(hostSandbox)
 
 // infraConfigMu guards concurrent reads and writes to infraConfig, which may be
 // updated at runtime via the register_infra_db direct tool.
@@ -184,7 +200,7 @@ func resolveHost(serverID string) (resolvedHost, error) {
 			K8sContext:     k8s.Context,
 			K8sNamespace:   ns,
 			K8sPodSelector: db.K8sPodSelector,
-			Tags:           db.Tags,
+			Tags:           ic.DatabaseTags(db),
 			Sensitivity:    db.Sensitivity,
 		}, nil
 	}
@@ -204,7 +220,7 @@ func resolveHost(serverID string) (resolvedHost, error) {
 		Runtime:       vm.Runtime,
 		ContainerName: db.ContainerName,
 		SystemdUnit:   db.SystemdUnit,
-		Tags:          db.Tags,
+		Tags:          ic.DatabaseTags(db),
 		Sensitivity:   db.Sensitivity,
 	}
 
@@ -839,6 +855,7 @@ func restartContainerImpl(ctx context.Context, args RestartContainerArgs) (Resta
 			Name:       "restart_container",
 			Parameters: map[string]any{"target": args.Target, "reason": args.Reason},
 			RawCommand: fmt.Sprintf("%s restart %s", runtime, host.ContainerName),
+			Argv:       agentutil.AuditArgv(runtime, []string{"restart", host.ContainerName}),
 		}, audit.ToolResult{
 			Output: output,
 			Error:  errMsg,
@@ -905,6 +922,7 @@ func restartServiceImpl(ctx context.Context, args RestartServiceArgs) (RestartRe
 			Name:       "restart_service",
 			Parameters: map[string]any{"target": args.Target, "reason": args.Reason},
 			RawCommand: fmt.Sprintf("systemctl restart %s", host.SystemdUnit),
+			Argv:       agentutil.AuditArgv("systemctl", []string{"restart", host.SystemdUnit}),
 		}, audit.ToolResult{
 			Output: output,
 			Error:  errMsg,
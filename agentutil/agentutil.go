@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/anthropics/anthropic-sdk-go/option"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
@@ -40,10 +41,11 @@ type Config struct {
 	ExternalURL string // Optional: externally reachable URL for the agent card
 
 	// Audit configuration
-	AuditEnabled bool
-	AuditURL     string // URL of central audit service (preferred)
-	AuditDir     string // Local directory for audit.db (fallback if AuditURL not set)
-	AuditAPIKey  string // Bearer token for auditd service account (when auditd enforces auth)
+	AuditEnabled  bool
+	AuditURL      string // URL of central audit service (preferred)
+	AuditDir      string // Local directory for audit.db (fallback if AuditURL not set)
+	AuditAPIKey   string // Bearer token for auditd service account (when auditd enforces auth)
+	AuditGRPCAddr string // auditd gRPC address (host:port); when set, events go over gRPC instead of AuditURL
 
 	// Policy configuration
 	PolicyEnabled bool   // Master switch — must be true to enforce policy
@@ -63,11 +65,17 @@ type Config struct {
 	// Remote policy check (set automatically from AuditURL when PolicyEnabled)
 	PolicyCheckURL     string        // auditd base URL for /v1/governance/check; enables remote mode
 	PolicyCheckTimeout time.Duration // HTTP timeout for remote checks (default 5s)
+	PolicyCacheTTL     time.Duration // how long remote read allow decisions are reused (default 5s; 0 disables)
+
+	// LLMTransport, when set, carries every model API call. Used to record and
+	// replay LLM traffic as fixtures (see FixtureRecorder).
+	LLMTransport http.RoundTripper
 }
 
 // MustLoadConfig reads env vars. defaultAddr is used when HELPDESK_AGENT_ADDR is unset.
 // Exits the process if required vars (MODEL_VENDOR, MODEL_NAME, API_KEY) are missing.
-// It also initialises structured logging via logging.InitLogging.
+// It also initialises structured logging via logging.InitLogging and installs
+// the HELPDESK_AUDIT_TOKEN access token for calls to auditd.
 func MustLoadConfig(defaultAddr string) Config {
 	logging.InitLogging(os.Args[1:])
 
@@ -93,6 +101,14 @@ func MustLoadConfig(defaultAddr string) Config {
 		}
 	}
 
+	// Parse remote policy decision cache TTL (default 5s, 0 disables)
+	policyCacheTTL := 5 * time.Second
+	if v := os.Getenv("HELPDESK_POLICY_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			policyCacheTTL = d
+		}
+	}
+
 	cfg := Config{
 		ModelVendor:     os.Getenv("HELPDESK_MODEL_VENDOR"),
 		ModelName:       os.Getenv("HELPDESK_MODEL_NAME"),
@@ -103,6 +119,7 @@ func MustLoadConfig(defaultAddr string) Config {
 		AuditURL:        os.Getenv("HELPDESK_AUDIT_URL"),
 		AuditDir:        os.Getenv("HELPDESK_AUDIT_DIR"),
 		AuditAPIKey:     os.Getenv("HELPDESK_AUDIT_API_KEY"),
+		AuditGRPCAddr:   os.Getenv("HELPDESK_AUDIT_GRPC_ADDR"),
 		PolicyEnabled:   policyEnabledBool,
 		PolicyFile:      policyFile,
 		PolicyDryRun:    policyDryRun == "true" || policyDryRun == "1",
@@ -110,6 +127,7 @@ func MustLoadConfig(defaultAddr string) Config {
 		ApprovalEnabled: approvalEnabled == "true" || approvalEnabled == "1",
 		ApprovalTimeout: approvalTimeout,
 		UsersFile:       os.Getenv("HELPDESK_USERS_FILE"),
+		PolicyCacheTTL:  policyCacheTTL,
 	}
 
 	// Enable remote policy check mode: when HELPDESK_AUDIT_URL is set and policy is
@@ -131,6 +149,11 @@ func MustLoadConfig(defaultAddr string) Config {
 		cfg.DefaultPolicy = "deny"
 	}
 
+	if err := audit.InstallAuditToken(context.Background(), cfg.AuditURL); err != nil {
+		slog.Error("failed to configure the auditd access token", "err", err)
+		os.Exit(1)
+	}
+
 	return cfg
 }
 
@@ -175,9 +198,13 @@ func NewTextCompleter(ctx context.Context, cfg Config) (TextCompleter, error) {
 func NewLLM(ctx context.Context, cfg Config) (adkmodel.LLM, error) {
 	switch strings.ToLower(cfg.ModelVendor) {
 	case "google", "gemini":
-		llm, err := gemini.NewModel(ctx, cfg.ModelName, &genai.ClientConfig{
+		cc := &genai.ClientConfig{
 			APIKey: cfg.APIKey,
-		})
+		}
+		if cfg.LLMTransport != nil {
+			cc.HTTPClient = &http.Client{Transport: cfg.LLMTransport}
+		}
+		llm, err := gemini.NewModel(ctx, cfg.ModelName, cc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Gemini model: %v", err)
 		}
@@ -185,7 +212,11 @@ func NewLLM(ctx context.Context, cfg Config) (adkmodel.LLM, error) {
 		return llm, nil
 
 	case "anthropic":
-		llm, err := model.NewAnthropicModel(ctx, cfg.ModelName, cfg.APIKey)
+		var opts []option.RequestOption
+		if cfg.LLMTransport != nil {
+			opts = append(opts, option.WithHTTPClient(&http.Client{Transport: cfg.LLMTransport}))
+		}
+		llm, err := model.NewAnthropicModel(ctx, cfg.ModelName, cfg.APIKey, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Anthropic model: %v", err)
 		}
@@ -277,6 +308,8 @@ type PolicyEnforcer struct {
 	agentName                  string
 	toolAuditor                *audit.ToolAuditor // records policy decisions to the audit trail
 	requirePurposeForSensitive bool               // enforce explicit purpose for pii/critical resources
+	freeze                     *freezeWatcher     // nil when no auditd is configured
+	policyCache                *policyCache       // nil when remote decision caching is disabled
 }
 
 // PolicyEnforcerConfig configures the policy enforcer.
@@ -291,6 +324,9 @@ type PolicyEnforcerConfig struct {
 	AgentName                  string
 	ToolAuditor                *audit.ToolAuditor // optional; enables policy decision audit events
 	RequirePurposeForSensitive bool               // deny access to pii/critical resources without explicit purpose
+	FreezeCheckURL             string             // auditd base URL polled for the emergency freeze (usually cfg.AuditURL)
+	FreezeFailOpen             bool               // allow writes before auditd has answered a freeze check (HELPDESK_FREEZE_FAIL_OPEN); default fails closed
+	PolicyCacheTTL             time.Duration      // reuse remote read allow decisions this long (usually cfg.PolicyCacheTTL; 0 disables)
 }
 
 // NewPolicyEnforcer creates a policy enforcer. If engine is nil, enforcement is disabled.
@@ -312,7 +348,7 @@ func NewPolicyEnforcerWithConfig(cfg PolicyEnforcerConfig) *PolicyEnforcer {
 	if checkTimeout == 0 {
 		checkTimeout = 5 * time.Second
 	}
-	return &PolicyEnforcer{
+	e := &PolicyEnforcer{
 		engine:                     cfg.Engine,
 		policyCheckURL:             cfg.PolicyCheckURL,
 		policyCheckAPIKey:          cfg.PolicyCheckAPIKey,
@@ -324,6 +360,13 @@ func NewPolicyEnforcerWithConfig(cfg PolicyEnforcerConfig) *PolicyEnforcer {
 		agentName:                  cfg.AgentName,
 		requirePurposeForSensitive: cfg.RequirePurposeForSensitive,
 	}
+	if cfg.PolicyCheckURL != "" {
+		e.policyCache = newPolicyCache(cfg.PolicyCacheTTL)
+	}
+	if cfg.FreezeCheckURL != "" {
+		e.freeze = newFreezeWatcher(cfg.FreezeCheckURL, cfg.PolicyCheckAPIKey, cfg.FreezeFailOpen)
+	}
+	return e
 }
 
 // CheckTool evaluates whether a tool execution is allowed.
@@ -339,6 +382,35 @@ func (e *PolicyEnforcer) CheckTool(ctx context.Context, resourceType, resourceNa
 		e.toolAuditor.RecordToolInvoked(ctx, resourceType, resourceName, string(action), tags)
 	}
 
+	// Emergency freeze: an operator has put the whole fleet into read-only mode
+	// via the gateway. Like readonly-governed mode this is enforced in code so
+	// it holds even when the policy engine is disabled or misconfigured.
+	if e.freeze != nil && (action == policy.ActionWrite || action == policy.ActionDestructive) {
+		if st := e.freeze.current(ctx); st.Frozen {
+			msg := fmt.Sprintf(
+				"tool %q is a %s operation and is blocked by an emergency freeze (set by %s: %s)",
+				resourceType, string(action), st.ChangedBy, st.Reason)
+			if e.toolAuditor != nil {
+				principal := audit.PrincipalFromContext(ctx)
+				e.toolAuditor.RecordPolicyDecision(ctx, audit.PolicyDecision{
+					ResourceType: resourceType,
+					ResourceName: resourceName,
+					Action:       string(action),
+					Tags:         tags,
+					Effect:       "deny",
+					PolicyName:   "emergency_freeze",
+					Message:      msg,
+					Note:         note,
+					UserID:       principal.UserID,
+					Roles:        principal.Roles,
+					Service:      principal.Service,
+					AuthMethod:   principal.AuthMethod,
+				})
+			}
+			return fmt.Errorf("%s", msg)
+		}
+	}
+
 	// Block write and destructive tools unconditionally in readonly-governed mode.
 	// This is enforced in code, not by policy, so a misconfigured policy file
 	// cannot accidentally permit a mutation.
@@ -411,7 +483,7 @@ func (e *PolicyEnforcer) CheckTool(ctx context.Context, resourceType, resourceNa
 		principal := audit.PrincipalFromContext(ctx)
 		purpose, purposeNote := audit.PurposeFromContext(ctx)
 		toolName := toolNameFromContext(ctx)
-		resp, err := e.callRemotePolicyCheck(ctx, policyCheckReq{
+		req := policyCheckReq{
 			ResourceType: resourceType,
 			ResourceName: resourceName,
 			Action:       string(action),
@@ -424,10 +496,42 @@ func (e *PolicyEnforcer) CheckTool(ctx context.Context, resourceType, resourceNa
 			PurposeNote:  purposeNote,
 			Sensitivity:  sensitivity,
 			ToolName:     toolName,
-		})
+		}
+		cacheKey := ""
+		if e.policyCache != nil {
+			cacheKey = policyCacheKey(req)
+		}
+		if resp, ok := e.policyCache.get(cacheKey); ok {
+			// auditd did not see this check, so record the reused decision
+			// here: every tool call still has a policy_decision in its trace.
+			if e.toolAuditor != nil {
+				e.toolAuditor.RecordCachedPolicyDecision(ctx, audit.PolicyDecision{
+					ResourceType: resourceType,
+					ResourceName: resourceName,
+					Action:       string(action),
+					Tags:         tags,
+					Effect:       resp.Effect,
+					PolicyName:   resp.PolicyName,
+					Message:      resp.Message,
+					Note:         note,
+					Explanation:  resp.Explanation,
+					UserID:       principal.UserID,
+					Roles:        principal.Roles,
+					Service:      principal.Service,
+					AuthMethod:   principal.AuthMethod,
+					Purpose:      purpose,
+					PurposeNote:  purposeNote,
+					Sensitivity:  sensitivity,
+					CachedFrom:   resp.EventID,
+				})
+			}
+			return nil
+		}
+		resp, err := e.callRemotePolicyCheck(ctx, req)
 		if err != nil {
 			return err
 		}
+		e.policyCache.put(cacheKey, resp)
 		return e.handleRemoteResponse(ctx, resp, traceID, resourceType, resourceName, action, tags, note)
 	}
 
@@ -439,6 +543,7 @@ func (e *PolicyEnforcer) CheckTool(ctx context.Context, resourceType, resourceNa
 			UserID:  principal.UserID,
 			Roles:   principal.Roles,
 			Service: principal.Service,
+			Tenant:  principal.Tenant,
 		},
 		Resource: policy.RequestResource{
 			Type:        resourceType,
@@ -500,6 +605,11 @@ func (e *PolicyEnforcer) CheckTool(ctx context.Context, resourceType, resourceNa
 		e.toolAuditor.RecordPolicyDecision(ctx, pd)
 	}
 
+	// Under a remediation plan the plan's approval governs mutations.
+	if planID := remediationPlanFromContext(ctx); planID != "" && mutates(action) && !decision.IsDenied() {
+		return e.checkPlanStep(ctx, planID, traceID, resourceType, resourceName, action)
+	}
+
 	// If approval is required, check whether the incoming request carries a
 	// gateway-forwarded approval_mode that pre-authorises this action.
 	if decision.NeedsApproval() {
@@ -551,6 +661,7 @@ func (e *PolicyEnforcer) requestApproval(ctx context.Context, traceID, resourceT
 			slog.Info("using existing approval (cross-turn lookup)",
 				"approval_id", existing.ApprovalID,
 				"resource", toolKey)
+			e.toolAuditor.RecordApprovalGrant(existing.ApprovalID, existing.RequestedBy, existing.ResolvedBy, existing.ResolvedAt)
 			return nil
 		case "pending":
 			slog.Info("pending approval found (cross-turn lookup)",
@@ -568,6 +679,7 @@ func (e *PolicyEnforcer) requestApproval(ctx context.Context, traceID, resourceT
 				"approval_id", existing.ApprovalID,
 				"trace_id", traceID,
 				"resource", toolKey)
+			e.toolAuditor.RecordApprovalGrant(existing.ApprovalID, existing.RequestedBy, existing.ResolvedBy, existing.ResolvedAt)
 			return nil
 		}
 	}
@@ -586,6 +698,9 @@ func (e *PolicyEnforcer) requestApproval(ctx context.Context, traceID, resourceT
 	if note != "" {
 		reqCtx["session_info"] = note
 	}
+	if plan := executionPlanFromContext(ctx); plan != nil {
+		reqCtx[audit.RequestContextExecutionPlan] = plan
+	}
 	createResp, err := e.approvalClient.CreateApproval(ctx, audit.ApprovalCreateRequest{
 		TraceID:      traceID,
 		ActionClass:  string(action),
@@ -722,6 +837,7 @@ func (e *PolicyEnforcer) CheckResult(ctx context.Context, resourceType, resource
 			UserID:  principal2.UserID,
 			Roles:   principal2.Roles,
 			Service: principal2.Service,
+			Tenant:  principal2.Tenant,
 		},
 		Resource: policy.RequestResource{
 			Type: resourceType,
@@ -861,6 +977,7 @@ func (e *PolicyEnforcer) CheckDatabaseSessionAge(ctx context.Context, dbName str
 			UserID:  principal3.UserID,
 			Roles:   principal3.Roles,
 			Service: principal3.Service,
+			Tenant:  principal3.Tenant,
 		},
 		Resource: policy.RequestResource{
 			Type: "database",
@@ -932,9 +1049,34 @@ func toolNameFromContext(ctx context.Context) string {
 	return ""
 }
 
+// executionPlanContextKey is an unexported type to prevent context key collisions.
+type executionPlanContextKey struct{}
+
+// WithExecutionPlan returns a new context carrying the execution plan of the
+// tool call about to be policy-checked. When the check requires approval, the
+// plan is attached to the approval request so the approver sees what will
+// happen, not just which tool will run.
+func WithExecutionPlan(ctx context.Context, plan *audit.ExecutionPlan) context.Context {
+	return WithExecutionPlanFunc(ctx, func() *audit.ExecutionPlan { return plan })
+}
+
+// WithExecutionPlanFunc is WithExecutionPlan for plans that cost a round-trip
+// to build: build is only called when an approval request is created.
+func WithExecutionPlanFunc(ctx context.Context, build func() *audit.ExecutionPlan) context.Context {
+	return context.WithValue(ctx, executionPlanContextKey{}, build)
+}
+
+// executionPlanFromContext builds the plan set by WithExecutionPlan or
+// WithExecutionPlanFunc, or returns nil if none was set.
+func executionPlanFromContext(ctx context.Context) *audit.ExecutionPlan {
+	if build, ok := ctx.Value(executionPlanContextKey{}).(func() *audit.ExecutionPlan); ok {
+		return build()
+	}
+	return nil
+}
 
 // policyCheckReq is the body sent to POST /v1/governance/check.
-// Field names match PolicyCheckRequest in cmd/auditd/governance_handlers.go.
+// Field names match PolicyCheckRequest in internal/auditd/governance_handlers.go.
 type policyCheckReq struct {
 	ResourceType  string   `json:"resource_type"`
 	ResourceName  string   `json:"resource_name"`
@@ -956,7 +1098,7 @@ type policyCheckReq struct {
 }
 
 // policyCheckResp is the response from POST /v1/governance/check.
-// Field names match PolicyCheckResponse in cmd/auditd/governance_handlers.go.
+// Field names match PolicyCheckResponse in internal/auditd/governance_handlers.go.
 type policyCheckResp struct {
 	Effect      string `json:"effect"`
 	PolicyName  string `json:"policy_name"`
@@ -1047,12 +1189,18 @@ func (e *PolicyEnforcer) callRemotePolicyCheck(ctx context.Context, req policyCh
 			"url", checkURL, "status", httpResp.StatusCode, "err", err)
 		return policyCheckResp{}, fmt.Errorf("policy check failed: policy service unreachable")
 	}
+	e.policyCache.observeVersion(httpResp.Header.Get(policyVersionHeader))
 	return resp, nil
 }
 
 // handleRemoteResponse converts a policyCheckResp into a Go error.
-// For require_approval it invokes the approval workflow when a client is configured.
+// For require_approval it invokes the approval workflow when a client is configured;
+// mutations under a remediation plan are validated against the plan instead.
 func (e *PolicyEnforcer) handleRemoteResponse(ctx context.Context, resp policyCheckResp, traceID, resourceType, resourceName string, action policy.ActionClass, tags []string, note string) error {
+	// Under a remediation plan the plan's approval governs mutations.
+	if planID := remediationPlanFromContext(ctx); planID != "" && mutates(action) && resp.Effect != "deny" {
+		return e.checkPlanStep(ctx, planID, traceID, resourceType, resourceName, action)
+	}
 	switch resp.Effect {
 	case "allow":
 		return nil
@@ -1263,6 +1411,11 @@ func ComputeInputSchemas(tools []tool.Tool) map[string]map[string]any {
 	return result
 }
 
+// CitationsMetadataKey is the LLMResponse.CustomMetadata key under which an
+// agent attaches the sources of a response, as []audit.Citation. The A2A
+// server copies them into a citations DataPart of the final artifact.
+const CitationsMetadataKey = "helpdesk_citations"
+
 // NewReasoningCallback returns an ADK AfterModelCallback that captures agent-level
 // LLM reasoning to the audit trail. It emits an agent_reasoning event whenever
 // the model produces both text (deliberation) and function calls (tool decision)
@@ -720,6 +720,37 @@ func TestRequestApproval_NoSessionInfoWhenNoteEmpty(t *testing.T) {
 	}
 }
 
+func TestRequestApproval_ExecutionPlanInContext(t *testing.T) {
+	appSrv, captured := mockApprovalServer(t)
+	e := newRequireApprovalEnforcer(t, appSrv.URL)
+
+	plan := audit.NewExecutionPlan("terminate_connection", "terminate PID 1234 (app on prod)",
+		map[string]any{"pid": 1234, "row_locks": 3})
+	ctx := WithExecutionPlan(context.Background(), plan)
+	err := e.CheckTool(ctx, "database", "prod-db", policy.ActionDestructive, nil, "", nil)
+	var pending *ApprovalPendingError
+	if !errors.As(err, &pending) {
+		t.Fatalf("CheckTool: expected *ApprovalPendingError, got %T: %v", err, err)
+	}
+
+	select {
+	case req := <-captured:
+		stored := audit.StoredApproval{RequestContext: req.Context}
+		got := stored.ExecutionPlan()
+		if got == nil {
+			t.Fatalf("request_context has no execution_plan; got: %v", req.Context)
+		}
+		if got.Operation != "terminate_connection" || got.Summary != plan.Summary {
+			t.Errorf("execution_plan = %+v, want %+v", got, plan)
+		}
+		if got.Details["row_locks"] != float64(3) {
+			t.Errorf("execution_plan details = %v, want row_locks 3", got.Details)
+		}
+	default:
+		t.Fatal("no approval request was captured")
+	}
+}
+
 func TestCheckTool_RequireApproval_RemoteCheck_NoteForwarded(t *testing.T) {
 	// Remote governance check (handleRemoteResponse) returns require_approval;
 	// the local approval client must receive the note in request_context.session_info.
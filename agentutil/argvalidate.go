@@ -0,0 +1,200 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"helpdesk/internal/audit"
+)
+
+// Tool argument structs declare the constraints JSON Schema inference cannot
+// see with a `validate` struct tag, instead of checking them inside the tool:
+//
+//	IdleMinutes int    `json:"idle_minutes" validate:"min=0"`
+//	Mode        string `json:"mode,omitempty" validate:"enum=fast|full"`
+//
+// Rules are comma-separated: min=N, max=N (numbers) and enum=a|b|c (strings).
+// NewTool folds them into the tool's input schema; ArgValidator enforces that
+// schema on every call, from the LLM and from POST /tool/{name} alike.
+
+// ArgError reports tool arguments that failed validation. The tool was not run.
+type ArgError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %s", e.Tool, e.Reason)
+}
+
+// NewTool is functiontool.New with the input schema inferred from TArgs,
+// including its `validate` tag rules. The rules are therefore part of the
+// schema the LLM sees and the gateway discovers via GET /schemas.
+func NewTool[TArgs, TResults any](cfg functiontool.Config, handler functiontool.Func[TArgs, TResults]) (tool.Tool, error) {
+	if cfg.InputSchema == nil {
+		schema, err := InputSchemaFor[TArgs]()
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", cfg.Name, err)
+		}
+		cfg.InputSchema = schema
+	}
+	return functiontool.New(cfg, handler)
+}
+
+// InputSchemaFor infers the JSON Schema of the args struct T and applies the
+// `validate` tags of its fields.
+func InputSchemaFor[T any]() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.For[T](nil)
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return schema, nil
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		tag := f.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		prop := schema.Properties[name]
+		if prop == nil {
+			return nil, fmt.Errorf("field %s: validate tag on a field missing from the schema", f.Name)
+		}
+		if err := applyValidateTag(prop, tag); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return schema, nil
+}
+
+func applyValidateTag(prop *jsonschema.Schema, tag string) error {
+	for _, rule := range strings.Split(tag, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "min", "max":
+			n, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return fmt.Errorf("validate %s=%q: not a number", key, val)
+			}
+			if key == "min" {
+				prop.Minimum = &n
+			} else {
+				prop.Maximum = &n
+			}
+		case "enum":
+			for _, v := range strings.Split(val, "|") {
+				prop.Enum = append(prop.Enum, v)
+			}
+		default:
+			return fmt.Errorf("unknown validate rule %q", key)
+		}
+	}
+	return nil
+}
+
+// ArgValidator checks tool arguments against each tool's input schema:
+// required parameters, unknown parameters (the schema of an args struct
+// allows no others), types, ranges and enums. Rejections are recorded as
+// tool_args_rejected audit events.
+//
+// A nil *ArgValidator accepts everything.
+type ArgValidator struct {
+	schemas map[string]*jsonschema.Resolved
+	auditor *audit.ToolAuditor
+}
+
+// NewArgValidator resolves the input schema of every tool that publishes a
+// JSON Schema (tools built with functiontool.New or NewTool). Tools without
+// one are not validated. auditor may be nil.
+func NewArgValidator(tools []tool.Tool, auditor *audit.ToolAuditor) (*ArgValidator, error) {
+	v := &ArgValidator{schemas: make(map[string]*jsonschema.Resolved, len(tools)), auditor: auditor}
+	for _, t := range tools {
+		dp, ok := t.(declarationProvider)
+		if !ok {
+			continue
+		}
+		decl := dp.Declaration()
+		if decl == nil {
+			continue
+		}
+		schema, ok := decl.ParametersJsonSchema.(*jsonschema.Schema)
+		if !ok || schema == nil {
+			continue
+		}
+		resolved, err := schema.Resolve(nil)
+		if err != nil {
+			return nil, fmt.Errorf("resolve input schema of %s: %w", t.Name(), err)
+		}
+		v.schemas[t.Name()] = resolved
+	}
+	return v, nil
+}
+
+// Validate checks args for the named tool and returns an *ArgError on
+// failure. Keys starting with "_" are caller annotations (e.g. a rollback
+// note) and are not checked. Unknown tools pass; reporting them is the
+// caller's job.
+func (v *ArgValidator) Validate(ctx context.Context, toolName string, args map[string]any) error {
+	if v == nil {
+		return nil
+	}
+	resolved, ok := v.schemas[toolName]
+	if !ok {
+		return nil
+	}
+	checked := make(map[string]any, len(args))
+	for k, val := range args {
+		if !strings.HasPrefix(k, "_") {
+			checked[k] = val
+		}
+	}
+	// Validate the JSON form, as the tool will decode it.
+	data, err := json.Marshal(checked)
+	if err != nil {
+		return v.reject(ctx, toolName, args, err.Error())
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return v.reject(ctx, toolName, args, err.Error())
+	}
+	if err := resolved.Validate(m); err != nil {
+		return v.reject(ctx, toolName, args, err.Error())
+	}
+	return nil
+}
+
+func (v *ArgValidator) reject(ctx context.Context, toolName string, args map[string]any, reason string) error {
+	argErr := &ArgError{Tool: toolName, Reason: reason}
+	if v.auditor != nil {
+		v.auditor.RecordToolArgsRejected(ctx, toolName, args, argErr.Error())
+	}
+	return argErr
+}
+
+// BeforeToolCallback returns an ADK BeforeToolCallback that validates each
+// LLM tool call. Invalid calls are not run; the model gets {"error": ...} as
+// the tool result so it can correct the arguments and retry.
+func (v *ArgValidator) BeforeToolCallback() func(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+	return func(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+		if err := v.Validate(ctx, t.Name(), args); err != nil {
+			return map[string]any{"error": err.Error()}, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,127 @@
+package agentutil
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"helpdesk/internal/audit"
+)
+
+type validatedArgs struct {
+	Target  string `json:"target" jsonschema:"required,Server ID."`
+	Minutes int    `json:"minutes" validate:"min=5,max=60"`
+	Mode    string `json:"mode,omitempty" validate:"enum=fast|full"`
+}
+
+func newValidatedTool(t *testing.T) tool.Tool {
+	t.Helper()
+	tl, err := NewTool(functiontool.Config{Name: "sweep", Description: "test tool"},
+		func(tool.Context, validatedArgs) (string, error) { return "ok", nil })
+	if err != nil {
+		t.Fatalf("NewTool: %v", err)
+	}
+	return tl
+}
+
+func TestInputSchemaFor_ValidateTags(t *testing.T) {
+	schema, err := InputSchemaFor[validatedArgs]()
+	if err != nil {
+		t.Fatalf("InputSchemaFor: %v", err)
+	}
+	minutes := schema.Properties["minutes"]
+	if minutes.Minimum == nil || *minutes.Minimum != 5 || minutes.Maximum == nil || *minutes.Maximum != 60 {
+		t.Errorf("minutes range = %v..%v, want 5..60", minutes.Minimum, minutes.Maximum)
+	}
+	if got := schema.Properties["mode"].Enum; len(got) != 2 || got[0] != "fast" || got[1] != "full" {
+		t.Errorf("mode enum = %v", got)
+	}
+
+	type badRule struct {
+		N int `json:"n" validate:"between=1"`
+	}
+	if _, err := InputSchemaFor[badRule](); err == nil {
+		t.Error("expected error for unknown validate rule")
+	}
+}
+
+func TestArgValidator_Validate(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	v, err := NewArgValidator([]tool.Tool{newValidatedTool(t)}, audit.NewToolAuditor(store, "test_agent", "sess", "tr_1"))
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	ctx := context.Background()
+
+	ok := []map[string]any{
+		{"target": "db-1", "minutes": 5},
+		{"target": "db-1", "minutes": float64(60), "mode": "full"},
+		{"target": "db-1", "minutes": 10, "_rollback_note": "annotations are ignored"},
+	}
+	for _, args := range ok {
+		if err := v.Validate(ctx, "sweep", args); err != nil {
+			t.Errorf("Validate(%v) = %v, want nil", args, err)
+		}
+	}
+
+	bad := map[string]map[string]any{
+		"missing required": {"minutes": 10},
+		"below minimum":    {"target": "db-1", "minutes": 4},
+		"above maximum":    {"target": "db-1", "minutes": 61},
+		"not in enum":      {"target": "db-1", "minutes": 10, "mode": "slow"},
+		"wrong type":       {"target": "db-1", "minutes": "10"},
+		"unknown param":    {"target": "db-1", "minutes": 10, "force": true},
+	}
+	for name, args := range bad {
+		err := v.Validate(ctx, "sweep", args)
+		var argErr *ArgError
+		if !errors.As(err, &argErr) || argErr.Tool != "sweep" {
+			t.Errorf("%s: Validate = %v, want *ArgError for sweep", name, err)
+		}
+	}
+
+	events, err := store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeToolArgsRejected})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != len(bad) {
+		t.Fatalf("tool_args_rejected events = %d, want %d", len(events), len(bad))
+	}
+	if e := events[0]; e.Outcome == nil || e.Outcome.Status != "rejected" || e.Tool == nil || e.Tool.Name != "sweep" {
+		t.Errorf("event = %+v, want a rejected sweep call", e)
+	}
+
+	// Tools the validator does not know, and a nil validator, accept anything.
+	if err := v.Validate(ctx, "other_tool", map[string]any{"x": 1}); err != nil {
+		t.Errorf("unknown tool: %v", err)
+	}
+	var nilV *ArgValidator
+	if err := nilV.Validate(ctx, "sweep", nil); err != nil {
+		t.Errorf("nil validator: %v", err)
+	}
+}
+
+func TestArgValidator_BeforeToolCallback(t *testing.T) {
+	tl := newValidatedTool(t)
+	v, err := NewArgValidator([]tool.Tool{tl}, nil)
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	cb := v.BeforeToolCallback()
+
+	if res, err := cb(nil, tl, map[string]any{"target": "db-1", "minutes": 10}); res != nil || err != nil {
+		t.Errorf("valid call: result=%v err=%v, want the tool to run", res, err)
+	}
+	res, err := cb(nil, tl, map[string]any{"target": "db-1", "minutes": 1})
+	if err != nil || res["error"] == nil {
+		t.Errorf("invalid call: result=%v err=%v, want an error result", res, err)
+	}
+}
@@ -10,27 +10,71 @@ import (
 // bypassing the ADK/LLM layer. Used for deterministic fleet job execution.
 type DirectToolFunc func(ctx context.Context, args map[string]any) (string, error)
 
+// StructuredToolFunc is a DirectToolFunc that also returns machine-readable
+// fields, so bots can consume a result without parsing the LLM-oriented text.
+type StructuredToolFunc func(ctx context.Context, args map[string]any) (ToolOutput, error)
+
+// ToolOutput is the result of a StructuredToolFunc.
+type ToolOutput struct {
+	Text string // the text the LLM would see
+	Data any    // machine-readable fields; nil when the tool has none
+}
+
 // DirectToolRegistry maps tool names to directly-callable implementations.
 type DirectToolRegistry struct {
-	tools map[string]DirectToolFunc
+	tools     map[string]StructuredToolFunc
+	validator *ArgValidator
 }
 
 // NewDirectToolRegistry returns an empty registry.
 func NewDirectToolRegistry() *DirectToolRegistry {
-	return &DirectToolRegistry{tools: make(map[string]DirectToolFunc)}
+	return &DirectToolRegistry{tools: make(map[string]StructuredToolFunc)}
 }
 
-// Register adds a tool to the registry.
+// Register adds a text-only tool to the registry.
 func (r *DirectToolRegistry) Register(name string, fn DirectToolFunc) {
+	r.tools[name] = func(ctx context.Context, args map[string]any) (ToolOutput, error) {
+		text, err := fn(ctx, args)
+		return ToolOutput{Text: text}, err
+	}
+}
+
+// RegisterStructured adds a tool whose result carries structured data.
+func (r *DirectToolRegistry) RegisterStructured(name string, fn StructuredToolFunc) {
 	r.tools[name] = fn
 }
 
-// Get returns the handler for the given tool name.
+// Get returns the text-only handler for the given tool name.
 func (r *DirectToolRegistry) Get(name string) (DirectToolFunc, bool) {
+	fn, ok := r.tools[name]
+	if !ok {
+		return nil, false
+	}
+	return func(ctx context.Context, args map[string]any) (string, error) {
+		out, err := fn(ctx, args)
+		return out.Text, err
+	}, true
+}
+
+// GetStructured returns the handler for the given tool name. Tools added with
+// Register return a ToolOutput with nil Data.
+func (r *DirectToolRegistry) GetStructured(name string) (StructuredToolFunc, bool) {
 	fn, ok := r.tools[name]
 	return fn, ok
 }
 
+// SetArgValidator makes ValidateArgs check calls against v, which should be
+// built from the same tools the agent gives its LLM.
+func (r *DirectToolRegistry) SetArgValidator(v *ArgValidator) {
+	r.validator = v
+}
+
+// ValidateArgs checks args for the named tool with the registry's
+// ArgValidator. Returns nil when no validator is set.
+func (r *DirectToolRegistry) ValidateArgs(ctx context.Context, name string, args map[string]any) error {
+	return r.validator.Validate(ctx, name, args)
+}
+
 // Len returns the number of registered tools.
 func (r *DirectToolRegistry) Len() int {
 	return len(r.tools)
@@ -41,6 +85,7 @@ func (r *DirectToolRegistry) Len() int {
 // policy enforcement and audit logging are attributed to the originating request.
 type DirectToolRequest struct {
 	TraceID         string                     `json:"trace_id,omitempty"`
+	TraceParent     string                     `json:"traceparent,omitempty"` // W3C traceparent of the gateway request
 	Principal       identity.ResolvedPrincipal `json:"principal,omitempty"`
 	Purpose         string                     `json:"purpose,omitempty"`
 	PurposeNote     string                     `json:"purpose_note,omitempty"`
@@ -49,8 +94,10 @@ type DirectToolRequest struct {
 }
 
 // DirectToolResponse is the JSON body returned by POST /tool/{name}.
+// Data carries the tool's structured result when it has one.
 type DirectToolResponse struct {
 	Output string `json:"output,omitempty"`
+	Data   any    `json:"data,omitempty"`
 	Error  string `json:"error,omitempty"`
 }
 
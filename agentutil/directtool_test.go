@@ -43,3 +43,30 @@ func TestDirectToolRegistry_Len(t *testing.T) {
 		t.Errorf("Len() = %d, want 2 after two registrations", r.Len())
 	}
 }
+
+func TestDirectToolRegistry_Structured(t *testing.T) {
+	r := NewDirectToolRegistry()
+	r.RegisterStructured("typed", func(ctx context.Context, args map[string]any) (ToolOutput, error) {
+		return ToolOutput{Text: "1 pod", Data: map[string]int{"count": 1}}, nil
+	})
+	r.Register("plain", func(ctx context.Context, args map[string]any) (string, error) {
+		return "ok", nil
+	})
+
+	// Text-only callers see the text of a structured tool.
+	get, _ := r.Get("typed")
+	if out, _ := get(context.Background(), nil); out != "1 pod" {
+		t.Errorf("Get(typed)() = %q, want 1 pod", out)
+	}
+	typed, _ := r.GetStructured("typed")
+	if out, _ := typed(context.Background(), nil); out.Data == nil {
+		t.Error("GetStructured(typed) returned nil Data")
+	}
+	plain, ok := r.GetStructured("plain")
+	if !ok {
+		t.Fatal("GetStructured returned false for a plain tool")
+	}
+	if out, _ := plain(context.Background(), nil); out.Text != "ok" || out.Data != nil {
+		t.Errorf("GetStructured(plain)() = %+v, want text only", out)
+	}
+}
@@ -0,0 +1,349 @@
+package agentutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FixtureRecordEnv names the file an agent records its command and LLM
+// interactions to when set (see FixtureRecorderFromEnv).
+const FixtureRecordEnv = "HELPDESK_FIXTURE_RECORD"
+
+// Fixture is the on-disk form of a recorded session: every command an agent
+// ran and every HTTP round trip it made (LLM calls, Kubernetes API calls),
+// in the order they happened. Passwords in connection strings are masked.
+type Fixture struct {
+	Commands []CommandInteraction `json:"commands,omitempty"`
+	HTTP     []HTTPInteraction    `json:"http,omitempty"`
+}
+
+// CommandInteraction is one recorded CommandRunner invocation.
+type CommandInteraction struct {
+	Name   string   `json:"name"`
+	Args   []string `json:"args"`
+	Output string   `json:"output"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// HTTPInteraction is one recorded HTTP round trip. URL holds the path and
+// query only, so a fixture recorded against one host replays against any.
+// Request headers are never recorded: they carry API keys and bearer tokens.
+type HTTPInteraction struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// RequestBody is used to pick between recordings of the same URL. Leave
+	// it empty in hand-written fixtures to match any body.
+	RequestBody  string `json:"request_body,omitempty"`
+	Status       int    `json:"status"`
+	ContentType  string `json:"content_type,omitempty"`
+	ResponseBody string `json:"response_body"`
+}
+
+// maskFixture hides passwords embedded in connection strings.
+func maskFixture(s string) string {
+	s = urlPasswordRe.ReplaceAllString(s, "${1}***@")
+	return kvPasswordRe.ReplaceAllString(s, "${1}***")
+}
+
+// fixtureURL returns the path and query of u with API-key query parameters
+// masked.
+func fixtureURL(u *url.URL) string {
+	q := u.Query()
+	for _, k := range []string{"key", "api_key", "apikey"} {
+		if q.Has(k) {
+			q.Set(k, "***")
+		}
+	}
+	out := u.EscapedPath()
+	if len(q) > 0 {
+		out += "?" + q.Encode()
+	}
+	return out
+}
+
+// ── recording ────────────────────────────────────────────────────────────
+
+// FixtureRecorder captures the interactions of real commands and HTTP calls.
+// When created with a path, the fixture file is rewritten after every
+// interaction, so a long-running agent can be stopped at any point.
+type FixtureRecorder struct {
+	mu      sync.Mutex
+	path    string
+	fixture Fixture
+}
+
+// NewFixtureRecorder creates a recorder. path may be empty; call Save to
+// write the fixture explicitly.
+func NewFixtureRecorder(path string) *FixtureRecorder {
+	return &FixtureRecorder{path: path}
+}
+
+// FixtureRecorderFromEnv returns a recorder writing to the file named by
+// HELPDESK_FIXTURE_RECORD, or nil when it is unset.
+func FixtureRecorderFromEnv() *FixtureRecorder {
+	path := os.Getenv(FixtureRecordEnv)
+	if path == "" {
+		return nil
+	}
+	slog.Warn("recording command and LLM interactions to a fixture file — do not enable in production", "path", path)
+	return NewFixtureRecorder(path)
+}
+
+// Fixture returns a copy of what has been recorded so far.
+func (r *FixtureRecorder) Fixture() Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Fixture{
+		Commands: slices.Clone(r.fixture.Commands),
+		HTTP:     slices.Clone(r.fixture.HTTP),
+	}
+}
+
+// Save writes the recorded fixture to path.
+func (r *FixtureRecorder) Save(path string) error {
+	f := r.Fixture()
+	return writeFixture(path, &f)
+}
+
+func (r *FixtureRecorder) append(fn func(*Fixture)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.fixture)
+	if r.path == "" {
+		return
+	}
+	if err := writeFixture(r.path, &r.fixture); err != nil {
+		slog.Warn("fixture recorder: failed to write fixture", "path", r.path, "err", err)
+	}
+}
+
+func writeFixture(path string, f *Fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixture: %w", err)
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Runner wraps inner so every command it runs is recorded.
+func (r *FixtureRecorder) Runner(inner CommandRunner) CommandRunner {
+	return &recordingRunner{rec: r, inner: inner}
+}
+
+type recordingRunner struct {
+	rec   *FixtureRecorder
+	inner CommandRunner
+}
+
+func (rr *recordingRunner) Run(ctx context.Context, name string, args []string, env []string) (string, error) {
+	out, err := rr.inner.Run(ctx, name, args, env)
+	ci := CommandInteraction{Name: name, Args: AuditArgv(name, args)[1:], Output: maskFixture(out)}
+	if err != nil {
+		ci.Error = maskFixture(err.Error())
+	}
+	rr.rec.append(func(f *Fixture) { f.Commands = append(f.Commands, ci) })
+	return out, err
+}
+
+// Transport wraps base (http.DefaultTransport when nil) so every round trip
+// is recorded. Its signature matches rest.Config.Wrap.
+func (r *FixtureRecorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordingTransport{rec: r, base: base}
+}
+
+type recordingTransport struct {
+	rec  *FixtureRecorder
+	base http.RoundTripper
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fixture recorder: read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	hi := HTTPInteraction{
+		Method:       req.Method,
+		URL:          fixtureURL(req.URL),
+		RequestBody:  maskFixture(string(reqBody)),
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: maskFixture(string(respBody)),
+	}
+	rt.rec.append(func(f *Fixture) { f.HTTP = append(f.HTTP, hi) })
+	return resp, nil
+}
+
+// readRequestBody returns the request body and leaves req with an unread copy.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fixture: read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// ── replay ───────────────────────────────────────────────────────────────
+
+// FixtureReplayer serves recorded interactions instead of running commands
+// or making HTTP calls. Each recording is served at most once: a command is
+// matched on name and (masked) argv; an HTTP request on method and URL,
+// preferring a recording with the same body, otherwise the earliest unused
+// one.
+type FixtureReplayer struct {
+	mu       sync.Mutex
+	fixture  Fixture
+	usedCmd  []bool
+	usedHTTP []bool
+}
+
+// NewFixtureReplayer creates a replayer serving f.
+func NewFixtureReplayer(f Fixture) *FixtureReplayer {
+	return &FixtureReplayer{
+		fixture:  f,
+		usedCmd:  make([]bool, len(f.Commands)),
+		usedHTTP: make([]bool, len(f.HTTP)),
+	}
+}
+
+// LoadFixture reads a fixture file and returns a replayer for it.
+func LoadFixture(path string) (*FixtureReplayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return NewFixtureReplayer(f), nil
+}
+
+// Unused describes the recorded interactions that were never replayed. A
+// full-path test asserts it is empty to catch an agent that skipped a step.
+func (p *FixtureReplayer) Unused() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for i, used := range p.usedCmd {
+		if !used {
+			c := p.fixture.Commands[i]
+			out = append(out, "command "+c.Name+" "+strings.Join(c.Args, " "))
+		}
+	}
+	for i, used := range p.usedHTTP {
+		if !used {
+			h := p.fixture.HTTP[i]
+			out = append(out, "http "+h.Method+" "+h.URL)
+		}
+	}
+	return out
+}
+
+// Runner returns a CommandRunner that replays recorded commands.
+func (p *FixtureReplayer) Runner() CommandRunner {
+	return replayRunner{p: p}
+}
+
+type replayRunner struct{ p *FixtureReplayer }
+
+func (rr replayRunner) Run(_ context.Context, name string, args []string, _ []string) (string, error) {
+	masked := AuditArgv(name, args)[1:]
+	p := rr.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, c := range p.fixture.Commands {
+		if p.usedCmd[i] || c.Name != name || !slices.Equal(c.Args, masked) {
+			continue
+		}
+		p.usedCmd[i] = true
+		if c.Error != "" {
+			return c.Output, errors.New(c.Error)
+		}
+		return c.Output, nil
+	}
+	return "", fmt.Errorf("fixture: no recorded command for %s %s", name, strings.Join(masked, " "))
+}
+
+// Transport returns an http.RoundTripper that replays recorded responses.
+func (p *FixtureReplayer) Transport() http.RoundTripper {
+	return replayTransport{p: p}
+}
+
+type replayTransport struct{ p *FixtureReplayer }
+
+func (rt replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	reqURL, reqBody := fixtureURL(req.URL), maskFixture(string(body))
+
+	p := rt.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	match := p.findHTTP(req.Method, reqURL, func(h HTTPInteraction) bool { return h.RequestBody == reqBody })
+	if match < 0 {
+		match = p.findHTTP(req.Method, reqURL, func(HTTPInteraction) bool { return true })
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("fixture: no recorded response for %s %s", req.Method, reqURL)
+	}
+	p.usedHTTP[match] = true
+	h := p.fixture.HTTP[match]
+	header := http.Header{}
+	if h.ContentType != "" {
+		header.Set("Content-Type", h.ContentType)
+	}
+	return &http.Response{
+		StatusCode:    h.Status,
+		Status:        fmt.Sprintf("%d %s", h.Status, http.StatusText(h.Status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(h.ResponseBody)),
+		ContentLength: int64(len(h.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// findHTTP returns the index of the first unused recording for method and
+// URL accepted by ok, or -1. Callers hold p.mu.
+func (p *FixtureReplayer) findHTTP(method, reqURL string, ok func(HTTPInteraction) bool) int {
+	for i, h := range p.fixture.HTTP {
+		if !p.usedHTTP[i] && h.Method == method && h.URL == reqURL && ok(h) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,149 @@
+package agentutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type stubRunner struct {
+	out string
+	err error
+}
+
+func (s stubRunner) Run(context.Context, string, []string, []string) (string, error) {
+	return s.out, s.err
+}
+
+func TestFixtureRecorder_RecordAndReplayCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	rec := NewFixtureRecorder(path)
+	ctx := context.Background()
+
+	conn := []string{"-c", "SELECT 1", "postgres://app:s3cret@db:5432/app"}
+	r := rec.Runner(stubRunner{out: "1"})
+	if out, err := r.Run(ctx, "psql", conn, nil); out != "1" || err != nil {
+		t.Fatalf("recording runner = %q, %v", out, err)
+	}
+	failing := rec.Runner(stubRunner{out: "partial", err: errors.New("exit status 2")})
+	if _, err := failing.Run(ctx, "kubectl", []string{"get", "pods"}, nil); err == nil {
+		t.Fatal("recording runner must pass errors through")
+	}
+
+	f := rec.Fixture()
+	if len(f.Commands) != 2 {
+		t.Fatalf("recorded %d commands, want 2", len(f.Commands))
+	}
+	if strings.Contains(strings.Join(f.Commands[0].Args, " "), "s3cret") {
+		t.Errorf("password not masked: %v", f.Commands[0].Args)
+	}
+
+	// The recorder autosaves; replay from the file.
+	p, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	replay := p.Runner()
+	if out, err := replay.Run(ctx, "psql", conn, nil); out != "1" || err != nil {
+		t.Errorf("replayed psql = %q, %v", out, err)
+	}
+	if out, err := replay.Run(ctx, "kubectl", []string{"get", "pods"}, nil); out != "partial" || err == nil || err.Error() != "exit status 2" {
+		t.Errorf("replayed kubectl = %q, %v", out, err)
+	}
+	if _, err := replay.Run(ctx, "kubectl", []string{"get", "pods"}, nil); err == nil {
+		t.Error("a recording must be served at most once")
+	}
+	if u := p.Unused(); len(u) != 0 {
+		t.Errorf("Unused = %v, want none", u)
+	}
+}
+
+func TestFixtureRecorder_RecordAndReplayHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo":"` + string(body) + `"}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	rec := NewFixtureRecorder("")
+	client := &http.Client{Transport: rec.Transport(nil)}
+	for _, body := range []string{"first", "second"} {
+		resp, err := client.Post(srv.URL+"/v1/messages?key=abc", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST: %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want := `{"echo":"` + body + `"}`; string(got) != want {
+			t.Errorf("recorded response = %s, want %s", got, want)
+		}
+	}
+
+	f := rec.Fixture()
+	if len(f.HTTP) != 2 || f.HTTP[0].URL != "/v1/messages?key=%2A%2A%2A" {
+		t.Fatalf("recorded = %+v", f.HTTP)
+	}
+
+	// Replay against a different host, out of order: the body picks the match.
+	p := NewFixtureReplayer(f)
+	client = &http.Client{Transport: p.Transport()}
+	for _, body := range []string{"second", "first"} {
+		resp, err := client.Post("https://elsewhere.test/v1/messages?key=xyz", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("replay POST: %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want := `{"echo":"` + body + `"}`; string(got) != want || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("replayed response = %s, want %s", got, want)
+		}
+	}
+	if _, err := client.Get("https://elsewhere.test/v1/other"); err == nil {
+		t.Error("an unrecorded request must fail")
+	}
+}
+
+func TestFixtureReplayer_AnyBodyAndUnused(t *testing.T) {
+	p := NewFixtureReplayer(Fixture{
+		Commands: []CommandInteraction{{Name: "psql", Args: []string{"-c", "SELECT 2"}, Output: "2"}},
+		HTTP: []HTTPInteraction{
+			{Method: "POST", URL: "/v1/messages", Status: 200, ResponseBody: "a"},
+			{Method: "POST", URL: "/v1/messages", Status: 200, ResponseBody: "b"},
+		},
+	})
+	client := &http.Client{Transport: p.Transport()}
+	var got []string
+	for range 2 {
+		resp, err := client.Post("http://x/v1/messages", "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("POST: %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		got = append(got, string(b))
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("hand-written fixtures without a body replay in order: got %v", got)
+	}
+	if u := p.Unused(); !slices.Equal(u, []string{"command psql -c SELECT 2"}) {
+		t.Errorf("Unused = %v", u)
+	}
+}
+
+func TestFixtureRecorderFromEnv(t *testing.T) {
+	t.Setenv(FixtureRecordEnv, "")
+	if FixtureRecorderFromEnv() != nil {
+		t.Error("want nil recorder when the env var is unset")
+	}
+	t.Setenv(FixtureRecordEnv, filepath.Join(t.TempDir(), "f.json"))
+	if FixtureRecorderFromEnv() == nil {
+		t.Error("want a recorder when the env var is set")
+	}
+}
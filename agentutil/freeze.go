@@ -0,0 +1,108 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// freezeCacheTTL bounds how long an agent may keep acting on a stale view of
+// the emergency freeze after it is flipped in auditd.
+const freezeCacheTTL = 5 * time.Second
+
+// unknownFreezeState is reported until auditd has answered once. An agent
+// that starts while auditd is down or partitioned cannot tell whether the
+// fleet is frozen, and an emergency freeze must fail closed.
+var unknownFreezeState = audit.FreezeState{
+	Frozen:    true,
+	ChangedBy: "agent startup",
+	Reason:    "freeze state unknown until auditd answers",
+}
+
+// freezeWatcher caches the fleet-wide emergency freeze state published by
+// auditd at GET /v1/freeze. On fetch errors the last known state is kept, so
+// an auditd outage during a freeze does not silently re-enable mutations, and
+// the next attempt waits out the TTL as after a successful fetch, so callers
+// do not queue behind a slow or failing fetch on every check. Until a fetch
+// has succeeded the fleet counts as frozen, unless failOpen is set.
+type freezeWatcher struct {
+	url      string
+	apiKey   string
+	client   *http.Client
+	failOpen bool // treat the fleet as unfrozen before auditd has answered
+
+	mu        sync.Mutex
+	state     audit.FreezeState
+	known     bool      // a fetch has succeeded, so state is auditd's
+	checkedAt time.Time // last fetch attempt, successful or not
+}
+
+func newFreezeWatcher(auditURL, apiKey string, failOpen bool) *freezeWatcher {
+	return &freezeWatcher{
+		url:      strings.TrimRight(auditURL, "/") + "/v1/freeze",
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: 2 * time.Second},
+		failOpen: failOpen,
+	}
+}
+
+// current returns the freeze state, refreshing it when the cache has expired.
+func (f *freezeWatcher) current(ctx context.Context) audit.FreezeState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.checkedAt.IsZero() && time.Since(f.checkedAt) < freezeCacheTTL {
+		return f.lastKnown()
+	}
+	st, err := f.fetch(ctx)
+	f.checkedAt = time.Now()
+	if err != nil {
+		last := f.lastKnown()
+		slog.Warn("emergency freeze: state refresh failed; using last known state",
+			"url", f.url, "frozen", last.Frozen, "ever_fetched", f.known, "err", err)
+		return last
+	}
+	if st.Frozen != f.state.Frozen || !f.known {
+		slog.Warn("emergency freeze state changed", "frozen", st.Frozen, "by", st.ChangedBy, "reason", st.Reason)
+	}
+	f.state, f.known = st, true
+	return st
+}
+
+// lastKnown returns the state of the last successful fetch, or
+// unknownFreezeState before there has been one. f.mu must be held.
+func (f *freezeWatcher) lastKnown() audit.FreezeState {
+	if !f.known && !f.failOpen {
+		return unknownFreezeState
+	}
+	return f.state
+}
+
+func (f *freezeWatcher) fetch(ctx context.Context) (audit.FreezeState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return audit.FreezeState{}, err
+	}
+	if f.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return audit.FreezeState{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return audit.FreezeState{}, fmt.Errorf("auditd returned %d", resp.StatusCode)
+	}
+	var st audit.FreezeState
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return audit.FreezeState{}, err
+	}
+	return st, nil
+}
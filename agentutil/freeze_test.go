@@ -0,0 +1,149 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+func TestCheckTool_EmergencyFreeze(t *testing.T) {
+	var frozen atomic.Bool
+	frozen.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/freeze" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(audit.FreezeState{Frozen: frozen.Load(), Reason: "incident 42", ChangedBy: "alice"}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{FreezeCheckURL: srv.URL})
+	ctx := context.Background()
+
+	for _, action := range []policy.ActionClass{policy.ActionWrite, policy.ActionDestructive} {
+		err := e.CheckTool(ctx, "database", "prod-db", action, nil, "", nil)
+		if err == nil || !strings.Contains(err.Error(), "emergency freeze") {
+			t.Errorf("%s while frozen: err = %v, want emergency freeze denial", action, err)
+		}
+	}
+	if err := e.CheckTool(ctx, "database", "prod-db", policy.ActionRead, nil, "", nil); err != nil {
+		t.Errorf("read while frozen: %v", err)
+	}
+
+	// Once auditd is unreachable the last known (frozen) state still applies.
+	srv.Close()
+	e.freeze.checkedAt = e.freeze.checkedAt.Add(-2 * freezeCacheTTL)
+	if err := e.CheckTool(ctx, "database", "prod-db", policy.ActionWrite, nil, "", nil); err == nil {
+		t.Error("write allowed after auditd became unreachable during a freeze")
+	}
+}
+
+func TestFreezeWatcher_Unfreeze(t *testing.T) {
+	var frozen atomic.Bool
+	frozen.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(audit.FreezeState{Frozen: frozen.Load()}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	f := newFreezeWatcher(srv.URL, "", false)
+	if !f.current(context.Background()).Frozen {
+		t.Fatal("expected frozen")
+	}
+	frozen.Store(false)
+	if !f.current(context.Background()).Frozen {
+		t.Error("cache should hold state within the TTL")
+	}
+	f.checkedAt = f.checkedAt.Add(-2 * freezeCacheTTL)
+	if f.current(context.Background()).Frozen {
+		t.Error("expected unfrozen after cache expiry")
+	}
+}
+
+func TestFreezeWatcher_FailedFetchBacksOff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	f := newFreezeWatcher(srv.URL, "", false)
+	f.state, f.known = audit.FreezeState{Frozen: true}, true
+	for i := 0; i < 5; i++ {
+		if !f.current(context.Background()).Frozen {
+			t.Fatal("last known (frozen) state should be kept on fetch failure")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1 within the TTL after a failure", n)
+	}
+	f.checkedAt = f.checkedAt.Add(-2 * freezeCacheTTL)
+	f.current(context.Background())
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fetches = %d, want a retry after the TTL", n)
+	}
+}
+
+func TestCheckTool_FreezeUnknownAtStartup(t *testing.T) {
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(audit.FreezeState{}) //nolint:errcheck
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	// auditd is down when the agent starts: writes are refused until it answers.
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{FreezeCheckURL: srv.URL})
+	for _, action := range []policy.ActionClass{policy.ActionWrite, policy.ActionDestructive} {
+		err := e.CheckTool(ctx, "kubernetes", "prod", action, nil, "", nil)
+		if err == nil || !strings.Contains(err.Error(), "freeze state unknown") {
+			t.Errorf("%s before auditd answered: err = %v, want a fail-closed denial", action, err)
+		}
+	}
+	if err := e.CheckTool(ctx, "kubernetes", "prod", policy.ActionRead, nil, "", nil); err != nil {
+		t.Errorf("read before auditd answered: %v", err)
+	}
+
+	// A retry after the TTL still fails: still closed.
+	e.freeze.checkedAt = e.freeze.checkedAt.Add(-2 * freezeCacheTTL)
+	if err := e.CheckTool(ctx, "kubernetes", "prod", policy.ActionWrite, nil, "", nil); err == nil {
+		t.Error("write allowed on a failed refresh before auditd ever answered")
+	}
+
+	// Once auditd answers "not frozen", writes go through.
+	up.Store(true)
+	e.freeze.checkedAt = e.freeze.checkedAt.Add(-2 * freezeCacheTTL)
+	if err := e.CheckTool(ctx, "kubernetes", "prod", policy.ActionWrite, nil, "", nil); err != nil {
+		t.Errorf("write after auditd reported no freeze: %v", err)
+	}
+
+	// Losing auditd afterwards keeps the last known (unfrozen) state.
+	up.Store(false)
+	e.freeze.checkedAt = e.freeze.checkedAt.Add(-2 * freezeCacheTTL)
+	if err := e.CheckTool(ctx, "kubernetes", "prod", policy.ActionWrite, nil, "", nil); err != nil {
+		t.Errorf("write after auditd became unreachable while unfrozen: %v", err)
+	}
+}
+
+func TestCheckTool_FreezeFailOpen(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // unreachable from the start
+
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{FreezeCheckURL: srv.URL, FreezeFailOpen: true})
+	if err := e.CheckTool(context.Background(), "kubernetes", "prod", policy.ActionWrite, nil, "", nil); err != nil {
+		t.Errorf("write with HELPDESK_FREEZE_FAIL_OPEN and auditd unreachable: %v", err)
+	}
+}
@@ -243,16 +243,22 @@ func recordGovernanceViolationEvent(ctx context.Context, auditURL, componentName
 		},
 	}
 
+	if err := postAuditEvent(ctx, auditURL, event); err != nil {
+		slog.Warn("failed to send governance violation to auditd", "module", v.Module, "err", err)
+	}
+}
+
+// postAuditEvent POSTs event to auditd's /v1/events.
+func postAuditEvent(ctx context.Context, auditURL string, event *audit.Event) error {
+	audit.StampProducer(event)
 	data, err := json.Marshal(event)
 	if err != nil {
-		slog.Warn("failed to marshal governance violation event", "module", v.Module, "err", err)
-		return
+		return fmt.Errorf("marshal event: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(auditURL, "/")+"/v1/events", bytes.NewReader(data))
 	if err != nil {
-		slog.Warn("failed to build governance violation request", "module", v.Module, "err", err)
-		return
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	if apiKey := os.Getenv("HELPDESK_AUDIT_API_KEY"); apiKey != "" {
@@ -261,19 +267,15 @@ func recordGovernanceViolationEvent(ctx context.Context, auditURL, componentName
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		slog.Warn("failed to send governance violation to auditd", "module", v.Module, "err", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		slog.Warn("auditd rejected governance violation event",
-			"module", v.Module,
-			"status", resp.StatusCode,
-			"body", strings.TrimSpace(string(body)),
-		)
+		return fmt.Errorf("auditd rejected the event: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
+	return nil
 }
 
 // createGovernanceIncident POSTs to the gateway to open an incident for the violation.
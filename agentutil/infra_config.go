@@ -0,0 +1,163 @@
+package agentutil
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"helpdesk/internal/infra"
+)
+
+// InfraRefreshInterval is how often agents ask auditd whether the signed
+// infrastructure config has changed. Unchanged configs cost a 304.
+const InfraRefreshInterval = 30 * time.Second
+
+// InfraConfigSource fetches the infrastructure config from auditd's
+// GET /v1/infra and verifies its Ed25519 signature. It remembers the ETag of
+// the last verified config so refreshes are conditional.
+type InfraConfigSource struct {
+	url    string
+	apiKey string
+	pub    ed25519.PublicKey
+	client *http.Client
+
+	etag string
+}
+
+// NewInfraConfigSource returns a source for the signed config served by the
+// auditd at auditURL, trusted under the given Ed25519 public key (PEM or
+// base64; see infra.ParsePublicKey).
+func NewInfraConfigSource(auditURL, apiKey, publicKey string) (*InfraConfigSource, error) {
+	if auditURL == "" {
+		return nil, errors.New("HELPDESK_AUDIT_URL is required to fetch the signed infrastructure config")
+	}
+	pub, err := infra.ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &InfraConfigSource{
+		url:    strings.TrimRight(auditURL, "/") + "/v1/infra",
+		apiKey: apiKey,
+		pub:    pub,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Fetch returns the current config, or nil when it has not changed since the
+// last successful Fetch. A config whose signature does not verify is an error
+// and leaves the remembered ETag untouched.
+func (s *InfraConfigSource) Fetch(ctx context.Context) (*infra.Config, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("auditd returned %d", resp.StatusCode)
+	}
+	var sc infra.SignedConfig
+	if err := json.NewDecoder(resp.Body).Decode(&sc); err != nil {
+		return nil, fmt.Errorf("decode signed infrastructure config: %v", err)
+	}
+	cfg, err := sc.Verify(s.pub)
+	if err != nil {
+		return nil, err
+	}
+	s.etag = resp.Header.Get("ETag")
+	return cfg, nil
+}
+
+// Watch polls for config changes every interval until ctx is done, calling
+// apply with each new verified config. Fetch errors keep the current config.
+func (s *InfraConfigSource) Watch(ctx context.Context, interval time.Duration, apply func(*infra.Config)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cfg, err := s.Fetch(ctx)
+		if err != nil {
+			slog.Warn("infrastructure config refresh failed; keeping current config", "url", s.url, "err", err)
+			continue
+		}
+		if cfg != nil {
+			logInfraConfig("infrastructure config updated from auditd", cfg, "etag", s.etag)
+			apply(cfg)
+		}
+	}
+}
+
+// LoadInfraConfig loads the agent's infrastructure config.
+//
+// When HELPDESK_INFRA_PUBLIC_KEY is set the config comes from auditd and must
+// carry a valid signature under that key; HELPDESK_INFRA_CONFIG is ignored,
+// so editing a file on the agent host cannot widen the set of targets the
+// agent will act on. Failing to fetch or verify is an error rather than a
+// fallback to running without a config. The returned source is used to watch
+// for updates.
+//
+// Otherwise the config is read from the HELPDESK_INFRA_CONFIG file, if set;
+// a file that fails to load is logged and the agent runs without one.
+func LoadInfraConfig(ctx context.Context, cfg Config) (*infra.Config, *InfraConfigSource, error) {
+	if pubKey := os.Getenv("HELPDESK_INFRA_PUBLIC_KEY"); pubKey != "" {
+		if os.Getenv("HELPDESK_INFRA_CONFIG") != "" {
+			slog.Warn("HELPDESK_INFRA_CONFIG is ignored: the infrastructure config is fetched from auditd (HELPDESK_INFRA_PUBLIC_KEY is set)")
+		}
+		src, err := NewInfraConfigSource(cfg.AuditURL, cfg.AuditAPIKey, pubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		ic, err := src.Fetch(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch signed infrastructure config from %s: %w", src.url, err)
+		}
+		logInfraConfig("infrastructure config loaded from auditd", ic, "etag", src.etag)
+		return ic, src, nil
+	}
+
+	infraPath := os.Getenv("HELPDESK_INFRA_CONFIG")
+	if infraPath == "" {
+		return nil, nil, nil
+	}
+	ic, err := infra.Load(infraPath)
+	if err != nil {
+		slog.Warn("failed to load infrastructure config", "path", infraPath, "err", err)
+		return nil, nil, nil
+	}
+	logInfraConfig("infrastructure config loaded", ic, "path", infraPath)
+	return ic, nil, nil
+}
+
+func logInfraConfig(msg string, ic *infra.Config, keyvals ...any) {
+	dbKeys := make([]string, 0, len(ic.DBServers))
+	for k := range ic.DBServers {
+		dbKeys = append(dbKeys, k)
+	}
+	sort.Strings(dbKeys)
+	slog.Info(msg, append([]any{"databases", len(ic.DBServers), "db_keys", strings.Join(dbKeys, ", ")}, keyvals...)...)
+}
@@ -0,0 +1,84 @@
+package agentutil
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helpdesk/internal/infra"
+)
+
+// signedInfraServer serves payload signed with key at /v1/infra, honouring
+// If-None-Match.
+func signedInfraServer(t *testing.T, payload string, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	body, _ := json.Marshal(infra.Sign([]byte(payload), key))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/infra" || r.Header.Get("Authorization") != "Bearer svc-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(body) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInfraConfigSource_Fetch(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	srv := signedInfraServer(t, `{"db_servers":{"prod-db":{"name":"Prod","connection_string":"host=prod"}}}`, priv)
+
+	src, err := NewInfraConfigSource(srv.URL, "svc-key", base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("NewInfraConfigSource: %v", err)
+	}
+	cfg, err := src.Fetch(t.Context())
+	if err != nil || cfg == nil || len(cfg.DBServers) != 1 {
+		t.Fatalf("Fetch = %v, %v", cfg, err)
+	}
+	if cfg, err := src.Fetch(t.Context()); cfg != nil || err != nil {
+		t.Errorf("unchanged Fetch = %v, %v; want nil, nil", cfg, err)
+	}
+
+	// A config signed by another key is rejected.
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	src, _ = NewInfraConfigSource(srv.URL, "svc-key", base64.StdEncoding.EncodeToString(otherPub))
+	if _, err := src.Fetch(t.Context()); err == nil {
+		t.Error("Fetch accepted a config signed by another key")
+	}
+}
+
+func TestLoadInfraConfig_SignedIgnoresLocalFile(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	srv := signedInfraServer(t, `{"db_servers":{"prod-db":{"name":"Prod","connection_string":"host=prod"}}}`, priv)
+
+	local := filepath.Join(t.TempDir(), "infra.json")
+	if err := os.WriteFile(local, []byte(`{"db_servers":{"evil-db":{"name":"Evil","connection_string":"host=evil"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HELPDESK_INFRA_CONFIG", local)
+	t.Setenv("HELPDESK_INFRA_PUBLIC_KEY", base64.StdEncoding.EncodeToString(pub))
+
+	cfg, src, err := LoadInfraConfig(t.Context(), Config{AuditURL: srv.URL, AuditAPIKey: "svc-key"})
+	if err != nil || src == nil {
+		t.Fatalf("LoadInfraConfig = %v, %v", src, err)
+	}
+	if _, ok := cfg.DBServers["evil-db"]; ok || len(cfg.DBServers) != 1 {
+		t.Errorf("DBServers = %v, want only prod-db", cfg.DBServers)
+	}
+
+	// Failing to fetch is an error, not a fallback to no config.
+	if _, _, err := LoadInfraConfig(t.Context(), Config{AuditURL: srv.URL, AuditAPIKey: "wrong"}); err == nil {
+		t.Error("LoadInfraConfig succeeded without a verified config")
+	}
+}
@@ -0,0 +1,98 @@
+package agentutil
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// policyVersionHeader is set by auditd on policy check responses. It changes
+// whenever the policy configuration or the emergency freeze state does.
+const policyVersionHeader = "X-Policy-Version"
+
+// policyCache holds recent allow decisions from the remote policy check, so
+// that a run of read-only tool calls against the same resource costs one
+// round-trip to auditd instead of one per call.
+//
+// Only pre-execution allow decisions for read actions are cached: denials,
+// approval requirements and anything that can mutate always go to auditd.
+// Entries expire after ttl, and the whole cache is dropped as soon as a
+// response carries a different policy version.
+type policyCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	version string
+	entries map[string]cachedPolicyDecision
+}
+
+type cachedPolicyDecision struct {
+	resp    policyCheckResp
+	expires time.Time
+}
+
+// newPolicyCache returns a cache, or nil (caching disabled) when ttl <= 0.
+func newPolicyCache(ttl time.Duration) *policyCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &policyCache{ttl: ttl, entries: make(map[string]cachedPolicyDecision)}
+}
+
+// policyCacheKey returns the cache key for req, or "" when req must not be
+// cached. The key covers every input to the decision — resource, action,
+// principal, purpose and condition inputs — and leaves out the trace ID,
+// note and agent name, which are recorded but not evaluated.
+func policyCacheKey(req policyCheckReq) string {
+	if req.Action != "read" || req.PostExecution {
+		return ""
+	}
+	req.TraceID, req.Note, req.AgentName = "", "", ""
+	b, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// get returns an unexpired decision for key. Safe on a nil receiver.
+func (c *policyCache) get(key string) (policyCheckResp, bool) {
+	if c == nil || key == "" {
+		return policyCheckResp{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.entries[key]
+	if !ok {
+		return policyCheckResp{}, false
+	}
+	if time.Now().After(d.expires) {
+		delete(c.entries, key)
+		return policyCheckResp{}, false
+	}
+	return d.resp, true
+}
+
+// put caches an allow decision for key. Safe on a nil receiver.
+func (c *policyCache) put(key string, resp policyCheckResp) {
+	if c == nil || key == "" || resp.Effect != "allow" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedPolicyDecision{resp: resp, expires: time.Now().Add(c.ttl)}
+}
+
+// observeVersion drops every cached decision when auditd reports a policy
+// version other than the one they were made under. Safe on a nil receiver.
+func (c *policyCache) observeVersion(version string) {
+	if c == nil || version == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		c.version = version
+		clear(c.entries)
+	}
+}
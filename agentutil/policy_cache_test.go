@@ -0,0 +1,169 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/policy"
+)
+
+// countingPolicyServer answers policy checks with effect and the version in
+// *version, counting the checks it receives.
+func countingPolicyServer(t *testing.T, effect string, version *atomic.Value, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set(policyVersionHeader, version.Load().(string))
+		json.NewEncoder(w).Encode(policyCheckResp{Effect: effect, PolicyName: "mock-policy", EventID: "pol_cached01"}) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newCachingEnforcer(url string, ttl time.Duration, ta *audit.ToolAuditor) *PolicyEnforcer {
+	return NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{
+		PolicyCheckURL: url,
+		PolicyCacheTTL: ttl,
+		ToolAuditor:    ta,
+	})
+}
+
+func TestPolicyCache_ReusesReadAllow(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	var calls atomic.Int32
+	srv := countingPolicyServer(t, "allow", &version, &calls)
+
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ta := audit.NewToolAuditor(store, "test-agent", "sess-cache", "trace-cache")
+
+	e := newCachingEnforcer(srv.URL, time.Minute, ta)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := e.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil); err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("auditd received %d checks, want 1", n)
+	}
+
+	// Cache hits are still recorded, pointing at the decision they reused.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, err := store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypePolicyDecision})
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(events) == 2 {
+			for _, ev := range events {
+				if ev.PolicyDecision.CachedFrom != "pol_cached01" || ev.PolicyDecision.Effect != "allow" {
+					t.Errorf("cached decision = %+v", ev.PolicyDecision)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded %d cached decisions, want 2", len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPolicyCache_DifferentInputsMiss(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	var calls atomic.Int32
+	srv := countingPolicyServer(t, "allow", &version, &calls)
+
+	e := newCachingEnforcer(srv.URL, time.Minute, nil)
+	ctx := context.Background()
+	e.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	e.CheckTool(ctx, "database", "other-db", policy.ActionRead, nil, "", nil)
+	e.CheckTool(ctx, "database", "dev-db", policy.ActionRead, []string{"env:prod"}, "", nil)
+	bob := audit.WithTraceContext(ctx, &audit.TraceContext{Principal: identity.ResolvedPrincipal{UserID: "bob"}})
+	e.CheckTool(bob, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	if n := calls.Load(); n != 4 {
+		t.Errorf("auditd received %d checks, want 4 (one per distinct request)", n)
+	}
+}
+
+func TestPolicyCache_OnlyReadAllows(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+
+	var denyCalls atomic.Int32
+	deny := newCachingEnforcer(countingPolicyServer(t, "deny", &version, &denyCalls).URL, time.Minute, nil)
+	var writeCalls atomic.Int32
+	write := newCachingEnforcer(countingPolicyServer(t, "allow", &version, &writeCalls).URL, time.Minute, nil)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := deny.CheckTool(ctx, "database", "prod-db", policy.ActionRead, nil, "", nil); err == nil {
+			t.Error("deny: expected error")
+		}
+		write.CheckTool(ctx, "database", "dev-db", policy.ActionWrite, nil, "", nil)
+	}
+	if n := denyCalls.Load(); n != 2 {
+		t.Errorf("deny: auditd received %d checks, want 2", n)
+	}
+	if n := writeCalls.Load(); n != 2 {
+		t.Errorf("write: auditd received %d checks, want 2", n)
+	}
+}
+
+func TestPolicyCache_VersionChangeInvalidates(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	var calls atomic.Int32
+	srv := countingPolicyServer(t, "allow", &version, &calls)
+
+	e := newCachingEnforcer(srv.URL, time.Minute, nil)
+	ctx := context.Background()
+	e.CheckTool(ctx, "database", "a-db", policy.ActionRead, nil, "", nil)
+	e.CheckTool(ctx, "database", "b-db", policy.ActionRead, nil, "", nil)
+
+	// Any response under a new version (here a write check) drops the cache.
+	version.Store("v2")
+	e.CheckTool(ctx, "database", "a-db", policy.ActionWrite, nil, "", nil)
+	e.CheckTool(ctx, "database", "b-db", policy.ActionRead, nil, "", nil)
+	if n := calls.Load(); n != 4 {
+		t.Errorf("auditd received %d checks, want 4", n)
+	}
+}
+
+func TestPolicyCache_Expiry(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	var calls atomic.Int32
+	srv := countingPolicyServer(t, "allow", &version, &calls)
+
+	e := newCachingEnforcer(srv.URL, 20*time.Millisecond, nil)
+	ctx := context.Background()
+	e.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	time.Sleep(40 * time.Millisecond)
+	e.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("auditd received %d checks, want 2 after expiry", n)
+	}
+
+	var offCalls atomic.Int32
+	off := newCachingEnforcer(countingPolicyServer(t, "allow", &version, &offCalls).URL, 0, nil)
+	off.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	off.CheckTool(ctx, "database", "dev-db", policy.ActionRead, nil, "", nil)
+	if n := offCalls.Load(); n != 2 {
+		t.Errorf("TTL 0: auditd received %d checks, want 2", n)
+	}
+}
@@ -0,0 +1,262 @@
+package agentutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/policy"
+)
+
+// PlannedStep is one step of a multi-step action checked by PreflightPlan.
+type PlannedStep struct {
+	ResourceType string
+	ResourceName string
+	Action       policy.ActionClass
+	Tags         []string
+	Sensitivity  []string
+	ToolName     string // specific tool for policy matching
+	Note         string
+	XactAgeSecs  int // open transaction age, for max_xact_age_secs
+
+	// Blast-radius estimates, shown to approvers by ProposeRemediationPlan.
+	EstimatedRows int
+	EstimatedPods int
+}
+
+// PreflightStep is the decision for one planned step.
+type PreflightStep struct {
+	Effect      string `json:"effect"` // allow, deny, require_approval
+	PolicyName  string `json:"policy_name"`
+	Message     string `json:"message,omitempty"`
+	Explanation string `json:"explanation"`
+	EventID     string `json:"event_id"`
+}
+
+// PreflightResult is the consolidated decision for a plan.
+type PreflightResult struct {
+	Effect        string          `json:"effect"` // most restrictive step effect
+	Steps         []PreflightStep `json:"steps"`
+	DeniedSteps   []int           `json:"denied_steps,omitempty"`
+	ApprovalSteps []int           `json:"approval_steps,omitempty"`
+}
+
+// Denied reports whether any step would be denied.
+func (r *PreflightResult) Denied() bool { return len(r.DeniedSteps) > 0 }
+
+// PreflightPlan checks every step of a planned multi-step action before the
+// first one runs, so that an agent about to inspect → cancel → terminate
+// learns up front that the last step is denied or needs approval, instead of
+// after the first two have run. Remote mode makes one call to auditd's
+// POST /v1/governance/check/batch.
+//
+// A preflight does not replace the per-step CheckTool/CheckResult calls:
+// conditions such as blast radius are only known at execution time, and
+// approvals are requested when the step runs.
+func (e *PolicyEnforcer) PreflightPlan(ctx context.Context, steps []PlannedStep) (*PreflightResult, error) {
+	res, err := e.preflightPolicy(ctx, steps)
+	if err != nil {
+		return nil, err
+	}
+	// The emergency freeze and readonly-governed mode are enforced in code by
+	// CheckTool, whatever the policy says; report them here too.
+	for i, s := range steps {
+		if s.Action != policy.ActionWrite && s.Action != policy.ActionDestructive {
+			continue
+		}
+		name, msg := "", ""
+		if e.freeze != nil {
+			if st := e.freeze.current(ctx); st.Frozen {
+				name, msg = "emergency_freeze", fmt.Sprintf("blocked by an emergency freeze (set by %s: %s)", st.ChangedBy, st.Reason)
+			}
+		}
+		if strings.ToLower(os.Getenv("HELPDESK_OPERATING_MODE")) == "readonly-governed" {
+			name, msg = "readonly_governed_mode", "not permitted in readonly-governed mode"
+		}
+		if name == "" || res.Steps[i].Effect == string(policy.EffectDeny) {
+			continue
+		}
+		res.Steps[i] = PreflightStep{Effect: string(policy.EffectDeny), PolicyName: name, Message: msg, Explanation: msg}
+		res.ApprovalSteps = slices.DeleteFunc(res.ApprovalSteps, func(j int) bool { return j == i })
+		res.DeniedSteps = append(res.DeniedSteps, i)
+		slices.Sort(res.DeniedSteps)
+		res.Effect = string(policy.EffectDeny)
+	}
+	return res, nil
+}
+
+// preflightPolicy evaluates the steps against the policy engine, remote or local.
+func (e *PolicyEnforcer) preflightPolicy(ctx context.Context, steps []PlannedStep) (*PreflightResult, error) {
+	if e.engine == nil && e.policyCheckURL == "" {
+		res := &PreflightResult{Effect: string(policy.EffectAllow)}
+		for range steps {
+			res.Steps = append(res.Steps, PreflightStep{Effect: string(policy.EffectAllow)})
+		}
+		return res, nil
+	}
+
+	traceID := ""
+	if e.traceStore != nil {
+		traceID = e.traceStore.Get()
+	}
+	principal := audit.PrincipalFromContext(ctx)
+	purpose, purposeNote := audit.PurposeFromContext(ctx)
+
+	if e.policyCheckURL != "" {
+		batch := policyCheckBatchReq{
+			TraceID:     traceID,
+			AgentName:   e.agentName,
+			Principal:   principal,
+			Purpose:     purpose,
+			PurposeNote: purposeNote,
+		}
+		for _, s := range steps {
+			batch.Steps = append(batch.Steps, policyCheckReq{
+				ResourceType: s.ResourceType,
+				ResourceName: s.ResourceName,
+				Action:       string(s.Action),
+				Tags:         s.Tags,
+				Note:         s.Note,
+				XactAgeSecs:  s.XactAgeSecs,
+				Sensitivity:  s.Sensitivity,
+				ToolName:     s.ToolName,
+			})
+		}
+		return e.callRemotePolicyCheckBatch(ctx, batch)
+	}
+
+	// Local engine path.
+	res := &PreflightResult{Effect: string(policy.EffectAllow)}
+	for i, s := range steps {
+		trace := e.engine.Explain(policy.Request{
+			Principal: policy.RequestPrincipal{
+				UserID:  principal.UserID,
+				Roles:   principal.Roles,
+				Service: principal.Service,
+				Tenant:  principal.Tenant,
+			},
+			Resource: policy.RequestResource{
+				Type:        s.ResourceType,
+				Name:        s.ResourceName,
+				Tags:        s.Tags,
+				Sensitivity: s.Sensitivity,
+				ToolName:    s.ToolName,
+			},
+			Action: s.Action,
+			Context: policy.RequestContext{
+				TraceID:     traceID,
+				XactAgeSecs: s.XactAgeSecs,
+				Purpose:     purpose,
+				PurposeNote: purposeNote,
+			},
+		})
+		decision := trace.Decision
+		if e.toolAuditor != nil {
+			traceJSON, _ := json.Marshal(trace)
+			e.toolAuditor.RecordPolicyDecision(ctx, audit.PolicyDecision{
+				ResourceType: s.ResourceType,
+				ResourceName: s.ResourceName,
+				Action:       string(s.Action),
+				Tags:         s.Tags,
+				Effect:       string(decision.Effect),
+				PolicyName:   decision.PolicyName,
+				RuleIndex:    decision.RuleIndex,
+				Message:      decision.Message,
+				Note:         s.Note,
+				Preflight:    true,
+				Trace:        traceJSON,
+				Explanation:  trace.Explanation,
+				UserID:       principal.UserID,
+				Roles:        principal.Roles,
+				Service:      principal.Service,
+				AuthMethod:   principal.AuthMethod,
+				Purpose:      purpose,
+				PurposeNote:  purposeNote,
+				Sensitivity:  s.Sensitivity,
+			})
+		}
+		res.Steps = append(res.Steps, PreflightStep{
+			Effect:      string(decision.Effect),
+			PolicyName:  decision.PolicyName,
+			Message:     decision.Message,
+			Explanation: trace.Explanation,
+		})
+		switch {
+		case decision.IsDenied():
+			res.DeniedSteps = append(res.DeniedSteps, i)
+			res.Effect = string(policy.EffectDeny)
+		case decision.NeedsApproval():
+			res.ApprovalSteps = append(res.ApprovalSteps, i)
+			if res.Effect != string(policy.EffectDeny) {
+				res.Effect = string(policy.EffectRequireApproval)
+			}
+		}
+	}
+	return res, nil
+}
+
+// policyCheckBatchReq is the body sent to POST /v1/governance/check/batch.
+// Field names match PolicyCheckBatchRequest in internal/auditd/governance_handlers.go.
+type policyCheckBatchReq struct {
+	TraceID     string                     `json:"trace_id,omitempty"`
+	AgentName   string                     `json:"agent_name,omitempty"`
+	Principal   identity.ResolvedPrincipal `json:"principal,omitempty"`
+	Purpose     string                     `json:"purpose,omitempty"`
+	PurposeNote string                     `json:"purpose_note,omitempty"`
+	Steps       []policyCheckReq           `json:"steps"`
+}
+
+// callRemotePolicyCheckBatch sends a batch check to the auditd service. Like
+// callRemotePolicyCheck it fails closed: any network or server error is an error.
+func (e *PolicyEnforcer) callRemotePolicyCheckBatch(ctx context.Context, req policyCheckBatchReq) (*PreflightResult, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("policy preflight failed: marshal: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, e.policyCheckTimeout)
+	defer cancel()
+
+	checkURL := strings.TrimRight(e.policyCheckURL, "/") + "/v1/governance/check/batch"
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost, checkURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("policy preflight failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if e.policyCheckAPIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+e.policyCheckAPIKey)
+	}
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		slog.Warn("remote policy preflight: service unreachable", "url", checkURL, "err", err)
+		return nil, fmt.Errorf("policy preflight failed: policy service unreachable")
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		slog.Warn("remote policy preflight: unexpected status", "url", checkURL, "status", httpResp.StatusCode)
+		if httpResp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("policy preflight failed: agent not authenticated to audit service (set HELPDESK_AUDIT_API_KEY)")
+		}
+		return nil, fmt.Errorf("policy preflight failed: policy service returned %d", httpResp.StatusCode)
+	}
+
+	var res PreflightResult
+	if err := json.NewDecoder(httpResp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("policy preflight failed: invalid response: %w", err)
+	}
+	if len(res.Steps) != len(req.Steps) {
+		return nil, fmt.Errorf("policy preflight failed: %d decisions for %d steps", len(res.Steps), len(req.Steps))
+	}
+	e.policyCache.observeVersion(httpResp.Header.Get(policyVersionHeader))
+	return &res, nil
+}
@@ -0,0 +1,124 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+var remediationPlan = []PlannedStep{
+	{ResourceType: "database", ResourceName: "prod-db", Action: policy.ActionRead, ToolName: "get_session_info"},
+	{ResourceType: "database", ResourceName: "prod-db", Action: policy.ActionWrite, ToolName: "cancel_query"},
+	{ResourceType: "database", ResourceName: "prod-db", Action: policy.ActionDestructive, ToolName: "terminate_connection"},
+}
+
+func TestPreflightPlan_Remote(t *testing.T) {
+	var got policyCheckBatchReq
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/governance/check/batch" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)       //nolint:errcheck
+		json.NewEncoder(w).Encode(PreflightResult{ //nolint:errcheck
+			Effect: "deny",
+			Steps: []PreflightStep{
+				{Effect: "allow"}, {Effect: "require_approval"}, {Effect: "deny", PolicyName: "no-terminate"},
+			},
+			DeniedSteps:   []int{2},
+			ApprovalSteps: []int{1},
+		})
+	}))
+	defer srv.Close()
+
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{PolicyCheckURL: srv.URL, AgentName: "db-agent"})
+	res, err := e.PreflightPlan(context.Background(), remediationPlan)
+	if err != nil {
+		t.Fatalf("PreflightPlan: %v", err)
+	}
+	if !res.Denied() || res.Steps[2].PolicyName != "no-terminate" {
+		t.Errorf("result = %+v, want step 2 denied by no-terminate", res)
+	}
+	if got.AgentName != "db-agent" || len(got.Steps) != 3 || got.Steps[2].ToolName != "terminate_connection" {
+		t.Errorf("batch request = %+v", got)
+	}
+}
+
+func TestPreflightPlan_RemoteFailsClosed(t *testing.T) {
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{PolicyCheckURL: "http://127.0.0.1:19999"})
+	if _, err := e.PreflightPlan(context.Background(), remediationPlan); err == nil {
+		t.Error("expected an error when auditd is unreachable")
+	}
+}
+
+func TestPreflightPlan_LocalEngine(t *testing.T) {
+	cfg, err := policy.Load([]byte(requireApprovalPolicyYAML))
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{
+		Engine: policy.NewEngine(policy.EngineConfig{PolicyConfig: cfg}),
+	})
+	res, err := e.PreflightPlan(context.Background(), remediationPlan)
+	if err != nil {
+		t.Fatalf("PreflightPlan: %v", err)
+	}
+	if res.Effect != "require_approval" || res.Denied() {
+		t.Errorf("Effect = %q, denied %v; want require_approval, none denied", res.Effect, res.DeniedSteps)
+	}
+	if len(res.ApprovalSteps) != 2 || res.ApprovalSteps[0] != 1 || res.ApprovalSteps[1] != 2 {
+		t.Errorf("ApprovalSteps = %v, want [1 2]", res.ApprovalSteps)
+	}
+}
+
+func TestPreflightPlan_ReadonlyGovernedDeniesMutations(t *testing.T) {
+	t.Setenv("HELPDESK_OPERATING_MODE", "readonly-governed")
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{})
+	res, err := e.PreflightPlan(context.Background(), remediationPlan)
+	if err != nil {
+		t.Fatalf("PreflightPlan: %v", err)
+	}
+	if res.Steps[0].Effect != "allow" {
+		t.Errorf("read step = %q, want allow", res.Steps[0].Effect)
+	}
+	if len(res.DeniedSteps) != 2 || res.Steps[1].PolicyName != "readonly_governed_mode" {
+		t.Errorf("result = %+v, want write and destructive steps denied by readonly_governed_mode", res)
+	}
+}
+
+func TestPreflightPlan_LocalRecordsPreflightDecisions(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	cfg, err := policy.Load([]byte(requireApprovalPolicyYAML))
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{
+		Engine:      policy.NewEngine(policy.EngineConfig{PolicyConfig: cfg}),
+		ToolAuditor: audit.NewToolAuditor(store, "test-agent", "sess-plan", "trace-plan"),
+	})
+	if _, err := e.PreflightPlan(context.Background(), remediationPlan); err != nil {
+		t.Fatalf("PreflightPlan: %v", err)
+	}
+	events, err := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypePolicyDecision})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("recorded %d decisions, want 3", len(events))
+	}
+	for _, ev := range events {
+		if !ev.PolicyDecision.Preflight {
+			t.Errorf("decision %s not marked preflight", ev.EventID)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package agentutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+// remediationPlanContextKey is an unexported type to prevent context key collisions.
+type remediationPlanContextKey struct{}
+
+// WithRemediationPlan returns a new context whose tool calls execute the given
+// remediation plan. Callers that arrive through the A2A trace middleware can
+// instead pass the plan ID as "remediation_plan" request metadata.
+func WithRemediationPlan(ctx context.Context, planID string) context.Context {
+	return context.WithValue(ctx, remediationPlanContextKey{}, planID)
+}
+
+// remediationPlanFromContext returns the plan set by WithRemediationPlan or
+// forwarded in the trace context, or "" if none.
+func remediationPlanFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(remediationPlanContextKey{}).(string); ok && v != "" {
+		return v
+	}
+	if tc := audit.TraceContextFromContext(ctx); tc != nil {
+		return tc.RemediationPlan
+	}
+	return ""
+}
+
+// ProposeRemediationPlan records a multi-step fix in auditd as a plan for
+// approvers to review as one change record, instead of approving each tool
+// call as it comes. auditd evaluates every step against policy: the plan is
+// rejected if any step is denied, and steps that policy allows outright need
+// no approval. Once approved (as a whole or step by step), run the steps with
+// a context from WithRemediationPlan.
+func (e *PolicyEnforcer) ProposeRemediationPlan(ctx context.Context, title string, steps []PlannedStep) (*audit.RemediationPlan, error) {
+	if e.approvalClient == nil {
+		return nil, fmt.Errorf("remediation plans require approvals to be enabled (set HELPDESK_APPROVAL_ENABLED)")
+	}
+	traceID := ""
+	if e.traceStore != nil {
+		traceID = e.traceStore.Get()
+	}
+	principal := audit.PrincipalFromContext(ctx)
+	requestedBy := principal.UserID
+	if requestedBy == "" {
+		requestedBy = e.agentName
+	}
+	purpose, purposeNote := audit.PurposeFromContext(ctx)
+
+	req := audit.PlanCreateRequest{
+		Title:       title,
+		TraceID:     traceID,
+		AgentName:   e.agentName,
+		RequestedBy: requestedBy,
+		Principal:   principal,
+		Purpose:     purpose,
+		PurposeNote: purposeNote,
+	}
+	for _, s := range steps {
+		req.Steps = append(req.Steps, audit.RemediationPlanStep{
+			ToolName:      s.ToolName,
+			ResourceType:  s.ResourceType,
+			ResourceName:  s.ResourceName,
+			ActionClass:   string(s.Action),
+			Description:   s.Note,
+			EstimatedRows: s.EstimatedRows,
+			EstimatedPods: s.EstimatedPods,
+		})
+	}
+	plan, err := e.approvalClient.CreatePlan(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("propose remediation plan: %w", err)
+	}
+	slog.Info("remediation plan proposed", "plan_id", plan.PlanID, "steps", len(plan.Steps), "status", plan.Status)
+	return plan, nil
+}
+
+// checkPlanStep validates a write or destructive tool call that policy did not
+// deny against the remediation plan it runs under, and claims the matching
+// step in auditd. The plan's approval stands in for a per-call approval
+// request. Fails closed: a call that is not the plan's next approved step, or
+// that cannot be validated, is denied.
+func (e *PolicyEnforcer) checkPlanStep(ctx context.Context, planID, traceID, resourceType, resourceName string, action policy.ActionClass) error {
+	if e.approvalClient == nil {
+		return fmt.Errorf("tool %q cannot run under remediation plan %s: approvals are not enabled on this agent (set HELPDESK_APPROVAL_ENABLED)",
+			resourceType, planID)
+	}
+	step, err := e.approvalClient.ExecutePlanStep(ctx, planID, audit.PlanStepCall{
+		ToolName:     toolNameFromContext(ctx),
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		ActionClass:  string(action),
+		TraceID:      traceID,
+	})
+	var rejected *audit.PlanStepRejectedError
+	if errors.As(err, &rejected) {
+		return &policy.DeniedError{
+			Decision: policy.Decision{
+				Effect:     policy.EffectDeny,
+				PolicyName: "remediation_plan",
+				Message:    rejected.Error(),
+			},
+		}
+	}
+	if err != nil {
+		slog.Warn("remediation plan check failed; failing closed", "plan_id", planID, "err", err)
+		return fmt.Errorf("remediation plan check failed: %w", err)
+	}
+	slog.Info("executing remediation plan step", "plan_id", planID, "step", step.Index, "resource", resourceName)
+	return nil
+}
+
+// mutates reports whether action can change state.
+func mutates(action policy.ActionClass) bool {
+	return action == policy.ActionWrite || action == policy.ActionDestructive
+}
@@ -0,0 +1,157 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+// planServer mocks the auditd endpoints used under a remediation plan: policy
+// checks answer effect (reads are always allowed), and execute accepts the first call and rejects the rest.
+func planServer(t *testing.T, effect string, executes *atomic.Int32, created *audit.PlanCreateRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/governance/check":
+			var check struct {
+				Action string `json:"action"`
+			}
+			json.NewDecoder(r.Body).Decode(&check) //nolint:errcheck
+			eff := effect
+			if check.Action == "read" {
+				eff = "allow"
+			}
+			json.NewEncoder(w).Encode(policyCheckResp{Effect: eff, PolicyName: "mock-policy"}) //nolint:errcheck
+		case r.URL.Path == "/v1/remediation-plans":
+			json.NewDecoder(r.Body).Decode(created) //nolint:errcheck
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(audit.RemediationPlan{PlanID: "rpl_test0001", Status: audit.PlanPending, Steps: created.Steps}) //nolint:errcheck
+		case r.URL.Path == "/v1/remediation-plans/rpl_test0001/execute":
+			var call audit.PlanStepCall
+			json.NewDecoder(r.Body).Decode(&call) //nolint:errcheck
+			if executes.Add(1) > 1 || call.ActionClass != "write" {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{"error": "remediation plan conflict: step 1 was denied"}) //nolint:errcheck
+				return
+			}
+			json.NewEncoder(w).Encode(audit.RemediationPlanStep{Index: 1, ActionClass: call.ActionClass}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newPlanEnforcer(url string) *PolicyEnforcer {
+	return NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{
+		PolicyCheckURL: url,
+		ApprovalClient: audit.NewApprovalClient(url),
+		AgentName:      "db-agent",
+	})
+}
+
+func TestProposeRemediationPlan(t *testing.T) {
+	var created audit.PlanCreateRequest
+	var executes atomic.Int32
+	srv := planServer(t, "allow", &executes, &created)
+
+	ctx := audit.WithTraceContext(context.Background(), &audit.TraceContext{Purpose: "remediation"})
+	plan, err := newPlanEnforcer(srv.URL).ProposeRemediationPlan(ctx, "clear blocking session", []PlannedStep{
+		{ResourceType: "database", ResourceName: "prod-db", Action: policy.ActionRead, ToolName: "get_session_info"},
+		{ResourceType: "database", ResourceName: "prod-db", Action: policy.ActionWrite, ToolName: "cancel_query", Note: "pid 4242", EstimatedRows: 1},
+	})
+	if err != nil {
+		t.Fatalf("ProposeRemediationPlan: %v", err)
+	}
+	if plan.PlanID != "rpl_test0001" {
+		t.Errorf("PlanID = %q", plan.PlanID)
+	}
+	if created.RequestedBy != "db-agent" || created.Purpose != "remediation" || len(created.Steps) != 2 {
+		t.Errorf("create request = %+v", created)
+	}
+	if s := created.Steps[1]; s.ActionClass != "write" || s.Description != "pid 4242" || s.EstimatedRows != 1 {
+		t.Errorf("step 1 = %+v", s)
+	}
+}
+
+func TestProposeRemediationPlan_RequiresApprovals(t *testing.T) {
+	e := NewPolicyEnforcerWithConfig(PolicyEnforcerConfig{PolicyCheckURL: "http://127.0.0.1:19999"})
+	if _, err := e.ProposeRemediationPlan(context.Background(), "x", remediationPlan); err == nil {
+		t.Error("expected an error without an approval client")
+	}
+}
+
+func TestCheckTool_RemediationPlanReplacesApprovalRequest(t *testing.T) {
+	var created audit.PlanCreateRequest
+	var executes atomic.Int32
+	srv := planServer(t, "require_approval", &executes, &created)
+	e := newPlanEnforcer(srv.URL)
+	ctx := WithRemediationPlan(context.Background(), "rpl_test0001")
+
+	// The approved step runs without a per-call approval request.
+	if err := e.CheckTool(ctx, "database", "prod-db", policy.ActionWrite, nil, "", nil); err != nil {
+		t.Fatalf("approved plan step: %v", err)
+	}
+
+	// Anything else is denied by the plan.
+	err := e.CheckTool(ctx, "database", "prod-db", policy.ActionDestructive, nil, "", nil)
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) || denied.Decision.PolicyName != "remediation_plan" {
+		t.Fatalf("err = %v, want a remediation_plan denial", err)
+	}
+	if !strings.Contains(err.Error(), "step 1 was denied") {
+		t.Errorf("err = %v, want the auditd reason", err)
+	}
+
+	// Reads are not tracked by the plan.
+	if err := e.CheckTool(ctx, "database", "prod-db", policy.ActionRead, nil, "", nil); err != nil {
+		t.Errorf("read under plan: %v", err)
+	}
+	if n := executes.Load(); n != 2 {
+		t.Errorf("auditd saw %d step executions, want 2", n)
+	}
+}
+
+func TestCheckTool_RemediationPlanFromTraceContext(t *testing.T) {
+	var created audit.PlanCreateRequest
+	var executes atomic.Int32
+	srv := planServer(t, "allow", &executes, &created)
+	ctx := audit.WithTraceContext(context.Background(), &audit.TraceContext{RemediationPlan: "rpl_test0001"})
+	if err := newPlanEnforcer(srv.URL).CheckTool(ctx, "database", "prod-db", policy.ActionWrite, nil, "", nil); err != nil {
+		t.Fatalf("CheckTool: %v", err)
+	}
+	if executes.Load() != 1 {
+		t.Error("allowed write under a forwarded plan was not validated against it")
+	}
+}
+
+func TestCheckTool_RemediationPlanDenyWins(t *testing.T) {
+	var created audit.PlanCreateRequest
+	var executes atomic.Int32
+	srv := planServer(t, "deny", &executes, &created)
+	ctx := WithRemediationPlan(context.Background(), "rpl_test0001")
+	if err := newPlanEnforcer(srv.URL).CheckTool(ctx, "database", "prod-db", policy.ActionWrite, nil, "", nil); err == nil {
+		t.Fatal("policy deny must hold under an approved plan")
+	}
+	if executes.Load() != 0 {
+		t.Error("a policy-denied call claimed a plan step")
+	}
+}
+
+func TestCheckTool_RemediationPlanWithoutApprovalClient(t *testing.T) {
+	srv := mockPolicyCheckServer(t, "allow", http.StatusOK)
+	defer srv.Close()
+	ctx := WithRemediationPlan(context.Background(), "rpl_test0001")
+	if err := newRemoteEnforcer(srv.URL).CheckTool(ctx, "database", "prod-db", policy.ActionWrite, nil, "", nil); err == nil {
+		t.Error("expected the plan check to fail closed without an approval client")
+	}
+}
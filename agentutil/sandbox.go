@@ -0,0 +1,238 @@
+package agentutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandRunner executes an external command and returns its combined output.
+// Agents hold one in a package variable so tests can substitute a mock.
+type CommandRunner interface {
+	Run(ctx context.Context, name string, args []string, env []string) (string, error)
+}
+
+// Sandbox defaults applied when a CommandSpec leaves the field zero.
+const (
+	DefaultCommandTimeout   = 2 * time.Minute
+	DefaultCommandMaxOutput = 1 << 20 // 1 MiB
+)
+
+// CommandSpec is the sandbox allowlist entry for one binary. Tool arguments
+// partly originate from LLM output, so every argument that looks like a flag
+// must be declared here; an injected "--all" or "-f /etc/passwd" is rejected
+// before anything is executed.
+type CommandSpec struct {
+	// Flags maps each permitted flag to whether it takes a separate value
+	// argument ("-c" → true, "-x" → false). Flags may also be written as
+	// "--flag=value" or "-n5"; only the name part is checked.
+	Flags map[string]bool
+
+	// Subcommands, when non-empty, restricts the first positional argument
+	// (e.g. kubectl get/scale/delete).
+	Subcommands []string
+
+	// PassthroughAfter lets a subcommand hand the rest of argv to an inner
+	// command unchecked once the given number of positional arguments has
+	// been seen, e.g. {"exec": 2} for "docker exec <container> cmd...".
+	PassthroughAfter map[string]int
+
+	// AllowDoubleDash permits a "--" separator; everything after it is passed
+	// to the inner command unchecked (kubectl exec <pod> -- cmd...).
+	AllowDoubleDash bool
+
+	// Timeout caps each invocation; ToolTimeouts overrides it for specific
+	// tools, keyed by the tool name carried in the context (see WithToolName).
+	Timeout      time.Duration
+	ToolTimeouts map[string]time.Duration
+
+	// MaxOutputBytes caps captured output. Anything beyond is discarded and a
+	// truncation marker appended.
+	MaxOutputBytes int
+}
+
+// SandboxRunner is the production CommandRunner. It executes allowlisted
+// binaries directly via os/exec (never through a shell) after validating argv
+// against the binary's CommandSpec.
+type SandboxRunner struct {
+	specs map[string]CommandSpec
+}
+
+// NewSandboxRunner creates a runner that only executes the binaries in specs.
+func NewSandboxRunner(specs map[string]CommandSpec) *SandboxRunner {
+	return &SandboxRunner{specs: specs}
+}
+
+// Run validates and executes name with args. env entries are appended to the
+// agent's own environment.
+func (s *SandboxRunner) Run(ctx context.Context, name string, args []string, env []string) (string, error) {
+	spec, ok := s.specs[name]
+	if !ok || strings.ContainsRune(name, os.PathSeparator) {
+		return "", fmt.Errorf("sandbox: command %q is not allowlisted", name)
+	}
+	if err := spec.validate(args); err != nil {
+		return "", fmt.Errorf("sandbox: %s: %w", name, err)
+	}
+
+	timeout := spec.Timeout
+	if t, ok := spec.ToolTimeouts[toolNameFromContext(ctx)]; ok {
+		timeout = t
+	}
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	maxOut := spec.MaxOutputBytes
+	if maxOut <= 0 {
+		maxOut = DefaultCommandMaxOutput
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out := &cappedBuffer{max: maxOut}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err := cmd.Run()
+	if err != nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		err = fmt.Errorf("sandbox timeout after %s: %w", timeout, err)
+	}
+	return out.String(), err
+}
+
+// validate checks args against the spec's flag and subcommand allowlists.
+func (spec CommandSpec) validate(args []string) error {
+	for i, a := range args {
+		if strings.ContainsRune(a, 0) {
+			return fmt.Errorf("argument %d contains a NUL byte", i)
+		}
+	}
+	positional := 0
+	subcommand := ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if n, ok := spec.PassthroughAfter[subcommand]; ok && subcommand != "" && positional >= n {
+			return nil
+		}
+		if a == "--" {
+			if !spec.AllowDoubleDash {
+				return fmt.Errorf("\"--\" is not permitted")
+			}
+			return nil
+		}
+		if strings.HasPrefix(a, "-") && a != "-" {
+			name, inline := flagName(a)
+			takesValue, ok := spec.Flags[name]
+			if !ok {
+				return fmt.Errorf("flag %q is not allowlisted", name)
+			}
+			if inline && !takesValue && !strings.HasPrefix(a, "--") {
+				// Combined short flags ("-tA"): each must be a valueless flag.
+				for _, c := range a[2:] {
+					if tv, ok := spec.Flags["-"+string(c)]; !ok || tv {
+						return fmt.Errorf("flag %q is not allowlisted in %q", "-"+string(c), a)
+					}
+				}
+			}
+			if takesValue && !inline {
+				if i+1 >= len(args) {
+					return fmt.Errorf("flag %q requires a value", name)
+				}
+				i++ // the value may legitimately start with "-" (e.g. SQL comments)
+			}
+			continue
+		}
+		if positional == 0 {
+			if len(spec.Subcommands) > 0 && !containsString(spec.Subcommands, a) {
+				return fmt.Errorf("subcommand %q is not allowlisted", a)
+			}
+			subcommand = a
+		}
+		positional++
+	}
+	return nil
+}
+
+// flagName returns the flag name and whether its value is attached
+// ("--tail=5" → "--tail", true; "-n5" → "-n", true; "-x" → "-x", false).
+func flagName(a string) (string, bool) {
+	if strings.HasPrefix(a, "--") {
+		if i := strings.IndexByte(a, '='); i > 0 {
+			return a[:i], true
+		}
+		return a, false
+	}
+	if len(a) > 2 {
+		return a[:2], true
+	}
+	return a, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer collects combined output up to max bytes.
+type cappedBuffer struct {
+	mu      sync.Mutex
+	buf     strings.Builder
+	max     int
+	dropped int
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room := c.max - c.buf.Len()
+	switch {
+	case room <= 0:
+		c.dropped += len(p)
+	case len(p) > room:
+		c.buf.Write(p[:room])
+		c.dropped += len(p) - room
+	default:
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (c *cappedBuffer) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dropped == 0 {
+		return c.buf.String()
+	}
+	return c.buf.String() + fmt.Sprintf("\n[sandbox: output truncated, %d bytes omitted]", c.dropped)
+}
+
+var (
+	urlPasswordRe = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+	kvPasswordRe  = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+)
+
+// AuditArgv returns the argv of a command as recorded in the tool_execution
+// audit event: binary name first, with passwords in connection strings masked.
+func AuditArgv(name string, args []string) []string {
+	argv := make([]string, 0, len(args)+1)
+	argv = append(argv, name)
+	for _, a := range args {
+		a = urlPasswordRe.ReplaceAllString(a, "${1}***@")
+		a = kvPasswordRe.ReplaceAllString(a, "${1}***")
+		argv = append(argv, a)
+	}
+	return argv
+}
@@ -0,0 +1,118 @@
+package agentutil
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandSpec_Validate(t *testing.T) {
+	kubectl := CommandSpec{
+		Subcommands:     []string{"get", "delete", "exec"},
+		Flags:           map[string]bool{"-n": true, "--context": true, "--no-headers": false, "-o": true},
+		AllowDoubleDash: true,
+	}
+	docker := CommandSpec{
+		Subcommands:      []string{"exec", "logs"},
+		Flags:            map[string]bool{"--tail": true},
+		PassthroughAfter: map[string]int{"exec": 2},
+	}
+	psql := CommandSpec{Flags: map[string]bool{"-w": false, "-t": false, "-A": false, "-c": true}}
+
+	tests := []struct {
+		name    string
+		spec    CommandSpec
+		args    []string
+		wantErr string
+	}{
+		{"kubectl ok", kubectl, []string{"--context", "prod", "delete", "pod", "web-1", "-n", "app"}, ""},
+		{"inline value", kubectl, []string{"get", "pods", "-nkube-system", "--context=prod", "--no-headers"}, ""},
+		{"injected flag as name", kubectl, []string{"delete", "pod", "--all", "-n", "app"}, "--all"},
+		{"subcommand not allowed", kubectl, []string{"apply", "-f", "x.yaml"}, "subcommand"},
+		{"double dash passthrough", kubectl, []string{"exec", "pod", "-n", "db", "--", "df", "-h"}, ""},
+		{"double dash not allowed", docker, []string{"logs", "--", "-x"}, "--"},
+		{"docker exec passthrough", docker, []string{"exec", "pg", "tail", "-n", "50", "/var/log/x"}, ""},
+		{"docker flag before container", docker, []string{"exec", "--privileged", "pg", "sh"}, "--privileged"},
+		{"missing value", kubectl, []string{"get", "pods", "-n"}, "requires a value"},
+		{"value starting with dash", psql, []string{"host=db", "-w", "-c", "-- comment\nSELECT 1"}, ""},
+		{"combined short flags", psql, []string{"-tA", "-c", "SELECT 1"}, ""},
+		{"combined with unknown", psql, []string{"-tf", "x"}, "-f"},
+		{"file flag rejected", psql, []string{"-f", "/etc/passwd"}, "-f"},
+		{"NUL byte", psql, []string{"-c", "SELECT 1\x00"}, "NUL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.validate(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSandboxRunner_Run(t *testing.T) {
+	for _, bin := range []string{"echo", "sleep"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not available", bin)
+		}
+	}
+	r := NewSandboxRunner(map[string]CommandSpec{
+		"echo":  {Flags: map[string]bool{"-n": false}, MaxOutputBytes: 8},
+		"sleep": {Timeout: 50 * time.Millisecond, ToolTimeouts: map[string]time.Duration{"slow_tool": 5 * time.Second}},
+	})
+	ctx := context.Background()
+
+	if _, err := r.Run(ctx, "sh", []string{"-c", "id"}, nil); err == nil || !strings.Contains(err.Error(), "not allowlisted") {
+		t.Errorf("sh: err = %v, want not allowlisted", err)
+	}
+	if _, err := r.Run(ctx, "/bin/echo", nil, nil); err == nil {
+		t.Error("path-qualified binary should be rejected")
+	}
+
+	out, err := r.Run(ctx, "echo", []string{"-n", "hello world, this is long"}, nil)
+	if err != nil {
+		t.Fatalf("echo: %v", err)
+	}
+	if !strings.HasPrefix(out, "hello wo") || !strings.Contains(out, "output truncated") {
+		t.Errorf("output = %q, want capped at 8 bytes with marker", out)
+	}
+
+	start := time.Now()
+	_, err = r.Run(ctx, "sleep", []string{"5"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "sandbox timeout") {
+		t.Errorf("sleep: err = %v, want sandbox timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("timeout not enforced")
+	}
+
+	// The per-tool override outlives the binary default.
+	toolCtx, cancel := context.WithTimeout(WithToolName(ctx, "slow_tool"), time.Second)
+	defer cancel()
+	if _, err := r.Run(toolCtx, "sleep", []string{"0.2"}, nil); err != nil {
+		t.Errorf("sleep under slow_tool override: %v", err)
+	}
+}
+
+func TestAuditArgv_MasksPasswords(t *testing.T) {
+	got := AuditArgv("psql", []string{
+		"postgres://app:s3cret@db:5432/prod",
+		"host=db user=app password=hunter2 dbname=prod",
+		"-c", "SELECT 1",
+	})
+	joined := strings.Join(got, " ")
+	if strings.Contains(joined, "s3cret") || strings.Contains(joined, "hunter2") {
+		t.Errorf("password leaked: %q", joined)
+	}
+	if got[0] != "psql" || got[3] != "-c" || got[4] != "SELECT 1" {
+		t.Errorf("argv = %q", got)
+	}
+}
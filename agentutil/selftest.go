@@ -0,0 +1,377 @@
+package agentutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/policy"
+)
+
+// RequiredBinary is a command an agent's tools run, checked by the startup
+// self-test.
+type RequiredBinary struct {
+	Name        string   // command looked up on PATH, e.g. "psql"
+	VersionArgs []string // arguments that print its version, e.g. {"--version"}
+	MinVersion  string   // oldest compatible version, e.g. "1.20"; empty = any
+}
+
+// SelfTestOptions describes the agent StartupPreflight checks.
+type SelfTestOptions struct {
+	Component string // agent name in logs and reports, e.g. "postgres_database_agent"
+	Binaries  []RequiredBinary
+}
+
+// Self-test check statuses.
+const (
+	selfTestPass = "pass"
+	selfTestWarn = "warn"
+	selfTestFail = "fail"
+	selfTestSkip = "skip"
+)
+
+// selfTestTimeout bounds each network check of the self-test.
+const selfTestTimeout = 5 * time.Second
+
+// LLM API base URLs the self-test checks the model against. Variables so
+// tests can point them at a fake server.
+var (
+	anthropicAPIBase = "https://api.anthropic.com"
+	geminiAPIBase    = "https://generativelanguage.googleapis.com"
+)
+
+// StartupPreflight runs an agent's startup checks before it serves: the
+// governed-mode checks of EnforceFixMode and a self-test of everything the
+// agent depends on — the LLM, the command binaries its tools run, the policy
+// engine, auditd and the approval flow. Each run is logged and recorded as a
+// startup_report event.
+//
+// Fatal governed-mode violations exit as in EnforceFixMode. With
+// HELPDESK_SELFTEST_REQUIRED=true a failing self-test blocks startup: it is
+// repeated every HELPDESK_SELFTEST_RETRY_INTERVAL (default 30s) and the agent
+// serves only once it passes. Otherwise failures are reported and the agent
+// starts anyway.
+func StartupPreflight(ctx context.Context, cfg Config, opts SelfTestOptions) {
+	required := os.Getenv("HELPDESK_SELFTEST_REQUIRED") == "true" || os.Getenv("HELPDESK_SELFTEST_REQUIRED") == "1"
+	interval := 30 * time.Second
+	if v := os.Getenv("HELPDESK_SELFTEST_RETRY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			interval = d
+		}
+	}
+	violations := CheckFixModeViolations(cfg)
+
+	for attempt := 1; ; attempt++ {
+		report := runSelfTest(ctx, cfg, opts)
+		report.Attempt = attempt
+		report.Required = required
+		addViolationChecks(&report, violations)
+		logStartupReport(report)
+		if cfg.AuditURL != "" {
+			recordStartupReport(ctx, cfg.AuditURL, report)
+		}
+		if attempt == 1 {
+			EnforceFixMode(ctx, violations, opts.Component, cfg.AuditURL)
+		}
+		if report.Passed || !required {
+			return
+		}
+
+		slog.Error("startup self-test failed — not serving until it passes (HELPDESK_SELFTEST_REQUIRED)",
+			"component", opts.Component, "attempt", attempt, "retry_in", interval)
+		select {
+		case <-ctx.Done():
+			os.Exit(1)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runSelfTest runs every self-test check once.
+func runSelfTest(ctx context.Context, cfg Config, opts SelfTestOptions) audit.StartupReport {
+	report := audit.StartupReport{
+		Component:     opts.Component,
+		Version:       buildinfo.Version,
+		OperatingMode: currentOperatingMode(),
+	}
+	run := func(name string, check func() (status, detail string)) {
+		start := time.Now()
+		status, detail := check()
+		report.Checks = append(report.Checks, audit.SelfTestCheck{
+			Name:       name,
+			Status:     status,
+			Detail:     detail,
+			DurationMs: time.Since(start).Milliseconds(),
+		})
+	}
+
+	run("llm", func() (string, string) { return checkLLM(ctx, cfg) })
+	for _, b := range opts.Binaries {
+		run("binary:"+b.Name, func() (string, string) { return checkBinary(ctx, b) })
+	}
+	run("policy", func() (string, string) { return checkPolicy(cfg) })
+	run("audit", func() (string, string) { return checkAudit(ctx, cfg) })
+	run("approval", func() (string, string) { return checkApprovalFlow(ctx, cfg, opts.Component) })
+
+	report.Passed = true
+	for _, c := range report.Checks {
+		if c.Status == selfTestFail {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// addViolationChecks adds the governed-mode violations to the report: fatal
+// ones fail it, warnings do not.
+func addViolationChecks(report *audit.StartupReport, violations []FixModeViolation) {
+	for _, v := range violations {
+		status := selfTestWarn
+		if v.Severity == "fatal" {
+			status = selfTestFail
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, audit.SelfTestCheck{
+			Name:   "governance:" + v.Module,
+			Status: status,
+			Detail: v.Description + " " + v.Remediation,
+		})
+	}
+}
+
+// checkLLM asks the model vendor's API for the configured model, which
+// needs a valid API key and a model name the vendor knows but generates
+// nothing.
+func checkLLM(ctx context.Context, cfg Config) (string, string) {
+	if cfg.LLMTransport != nil {
+		return selfTestSkip, "model traffic goes through a recording or replay transport"
+	}
+	var req *http.Request
+	var err error
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+	switch strings.ToLower(cfg.ModelVendor) {
+	case "anthropic":
+		base := anthropicAPIBase
+		if v := os.Getenv("ANTHROPIC_BASE_URL"); v != "" {
+			base = v
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/v1/models/"+url.PathEscape(cfg.ModelName), nil)
+		if err == nil {
+			req.Header.Set("x-api-key", cfg.APIKey)
+			req.Header.Set("anthropic-version", "2023-06-01")
+		}
+	case "google", "gemini":
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, geminiAPIBase+"/v1beta/models/"+url.PathEscape(cfg.ModelName), nil)
+		if err == nil {
+			req.Header.Set("x-goog-api-key", cfg.APIKey)
+		}
+	default:
+		return selfTestFail, fmt.Sprintf("unknown model vendor %q (supported: google, gemini, anthropic)", cfg.ModelVendor)
+	}
+	if err != nil {
+		return selfTestFail, err.Error()
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return selfTestFail, fmt.Sprintf("%s API unreachable: %v", cfg.ModelVendor, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return selfTestPass, fmt.Sprintf("%s model %s is available", cfg.ModelVendor, cfg.ModelName)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return selfTestFail, fmt.Sprintf("%s rejected the API key (HTTP %d); check HELPDESK_API_KEY", cfg.ModelVendor, resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound:
+		return selfTestFail, fmt.Sprintf("%s does not know model %q; check HELPDESK_MODEL_NAME", cfg.ModelVendor, cfg.ModelName)
+	default:
+		return selfTestFail, fmt.Sprintf("%s API returned HTTP %d", cfg.ModelVendor, resp.StatusCode)
+	}
+}
+
+// versionPattern finds a dotted version number in a command's version
+// output, e.g. "16.2" in "psql (PostgreSQL) 16.2" or "1.30.1" in
+// "Client Version: v1.30.1".
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// checkBinary checks that b is on PATH and at least b.MinVersion.
+func checkBinary(ctx context.Context, b RequiredBinary) (string, string) {
+	path, err := exec.LookPath(b.Name)
+	if err != nil {
+		return selfTestFail, fmt.Sprintf("%s not found on PATH", b.Name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, b.VersionArgs...).Output()
+	if err != nil {
+		return selfTestFail, fmt.Sprintf("%s %s: %v", path, strings.Join(b.VersionArgs, " "), err)
+	}
+	version := versionPattern.FindString(string(out))
+	if version == "" {
+		return selfTestFail, fmt.Sprintf("%s: no version in %q", path, strings.TrimSpace(string(out)))
+	}
+	if b.MinVersion != "" && compareVersions(version, b.MinVersion) < 0 {
+		return selfTestFail, fmt.Sprintf("%s is version %s; %s or later is required", path, version, b.MinVersion)
+	}
+	return selfTestPass, fmt.Sprintf("%s version %s", path, version)
+}
+
+// compareVersions compares dotted version numbers component by component,
+// a missing component counting as 0.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkPolicy checks the policy engine the agent will use: auditd's in
+// remote check mode, otherwise the local policy file.
+func checkPolicy(cfg Config) (string, string) {
+	switch {
+	case !cfg.PolicyEnabled:
+		return selfTestSkip, "policy enforcement disabled"
+	case cfg.PolicyCheckURL != "":
+		enabled, authFailed := probeRemotePolicyEngine(cfg.PolicyCheckURL, cfg.AuditAPIKey)
+		switch {
+		case authFailed:
+			return selfTestFail, "auditd rejected the policy probe; set HELPDESK_AUDIT_API_KEY"
+		case !enabled:
+			return selfTestFail, "auditd has no policy engine; set HELPDESK_POLICY_FILE and HELPDESK_POLICY_ENABLED on auditd"
+		}
+		return selfTestPass, "policy is evaluated by auditd"
+	case cfg.PolicyFile == "":
+		return selfTestFail, "HELPDESK_POLICY_FILE is not set"
+	}
+	pc, err := policy.LoadFile(cfg.PolicyFile)
+	if err != nil {
+		return selfTestFail, err.Error()
+	}
+	return selfTestPass, fmt.Sprintf("%s: %d policies", cfg.PolicyFile, len(pc.Policies))
+}
+
+// checkAudit checks that auditd answers its health endpoint.
+func checkAudit(ctx context.Context, cfg Config) (string, string) {
+	switch {
+	case !cfg.AuditEnabled:
+		return selfTestSkip, "audit disabled"
+	case cfg.AuditURL == "":
+		return selfTestSkip, "events go to a local audit store"
+	}
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.AuditURL, "/")+"/health", nil)
+	if err != nil {
+		return selfTestFail, err.Error()
+	}
+	if cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.AuditAPIKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return selfTestFail, fmt.Sprintf("auditd unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return selfTestFail, fmt.Sprintf("auditd /health returned HTTP %d", resp.StatusCode)
+	}
+	return selfTestPass, "auditd is healthy"
+}
+
+// checkApprovalFlow submits a dry-run approval request, which auditd
+// authenticates and validates like a real one without creating it or
+// notifying approvers.
+func checkApprovalFlow(ctx context.Context, cfg Config, component string) (string, string) {
+	switch {
+	case !cfg.ApprovalEnabled:
+		return selfTestSkip, "approval workflow disabled"
+	case cfg.AuditURL == "":
+		return selfTestFail, "approvals need HELPDESK_AUDIT_URL"
+	}
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+	client := audit.NewApprovalClient(strings.TrimRight(cfg.AuditURL, "/"))
+	if cfg.AuditAPIKey != "" {
+		client = client.WithAPIKey(cfg.AuditAPIKey)
+	}
+	resp, err := client.DryRunApproval(ctx, audit.ApprovalCreateRequest{
+		ActionClass: string(policy.ActionWrite),
+		ToolName:    "startup_selftest",
+		AgentName:   component,
+		RequestedBy: component,
+	})
+	if err != nil {
+		return selfTestFail, fmt.Sprintf("dry-run approval request failed: %v", err)
+	}
+	if !resp.Notify {
+		return selfTestWarn, "auditd accepts approval requests but has no notifier configured; approvers must poll for them"
+	}
+	return selfTestPass, "auditd accepts approval requests and notifies approvers"
+}
+
+// logStartupReport logs a self-test run: a summary line, and one line per
+// check that did not pass.
+func logStartupReport(report audit.StartupReport) {
+	log := slog.Info
+	if !report.Passed {
+		log = slog.Error
+	}
+	log("startup self-test",
+		"component", report.Component,
+		"version", report.Version,
+		"passed", report.Passed,
+		"attempt", report.Attempt)
+	for _, c := range report.Checks {
+		switch c.Status {
+		case selfTestFail:
+			slog.Error("startup self-test check failed", "component", report.Component, "check", c.Name, "detail", c.Detail)
+		case selfTestWarn:
+			slog.Warn("startup self-test check warning", "component", report.Component, "check", c.Name, "detail", c.Detail)
+		}
+	}
+}
+
+// recordStartupReport sends the report to auditd as a startup_report event.
+func recordStartupReport(ctx context.Context, auditURL string, report audit.StartupReport) {
+	status := "success"
+	if !report.Passed {
+		status = "error"
+	}
+	event := &audit.Event{
+		EventID:       "sup_" + uuid.New().String()[:8],
+		Timestamp:     time.Now().UTC(),
+		EventType:     audit.EventTypeStartupReport,
+		Session:       audit.Session{ID: report.Component, AgentName: report.Component},
+		Outcome:       &audit.Outcome{Status: status},
+		StartupReport: &report,
+	}
+	if err := postAuditEvent(ctx, auditURL, event); err != nil {
+		slog.Warn("failed to send startup report to auditd", "component", report.Component, "err", err)
+	}
+}
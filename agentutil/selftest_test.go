@@ -0,0 +1,197 @@
+package agentutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+// fakeBinary writes an executable named name to a temp dir on PATH that
+// prints output.
+func fakeBinary(t *testing.T, name, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script binaries")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestCheckBinary(t *testing.T) {
+	fakeBinary(t, "kubectl", "Client Version: v1.19.4")
+	ctx := context.Background()
+
+	status, detail := checkBinary(ctx, RequiredBinary{Name: "kubectl", VersionArgs: []string{"version", "--client"}, MinVersion: "1.20"})
+	if status != selfTestFail || !strings.Contains(detail, "version 1.19.4; 1.20 or later is required") {
+		t.Errorf("old kubectl: %s %q", status, detail)
+	}
+	status, detail = checkBinary(ctx, RequiredBinary{Name: "kubectl", VersionArgs: []string{"version", "--client"}, MinVersion: "1.19"})
+	if status != selfTestPass || !strings.HasSuffix(detail, "version 1.19.4") {
+		t.Errorf("kubectl: %s %q", status, detail)
+	}
+	status, detail = checkBinary(ctx, RequiredBinary{Name: "psql", VersionArgs: []string{"--version"}})
+	if status != selfTestFail || detail != "psql not found on PATH" {
+		t.Errorf("missing psql: %s %q", status, detail)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.20", "1.20.0", 0},
+		{"1.9", "1.20", -1},
+		{"16.2", "9.6", 1},
+		{"1.30.1", "1.30", 1},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// fakeSelfTestServer stands in for both the model vendor API and auditd.
+type fakeSelfTestServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func newFakeSelfTestServer(t *testing.T) *fakeSelfTestServer {
+	t.Helper()
+	f := &fakeSelfTestServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models/{model}", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("x-api-key") != "good-key":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.PathValue("model") != "claude-test":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"id":"claude-test"}`))
+		}
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("GET /v1/governance/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"policy":{"enabled":true}}`))
+	})
+	mux.HandleFunc("POST /v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		var req audit.ApprovalCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.DryRun {
+			http.Error(w, "want a dry run", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"dry_run":true,"status":"pending","notify":true}`))
+	})
+	mux.HandleFunc("POST /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		var e audit.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.events = append(f.events, e)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	orig := anthropicAPIBase
+	anthropicAPIBase = f.URL
+	t.Cleanup(func() { anthropicAPIBase = orig })
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	return f
+}
+
+func TestRunSelfTest(t *testing.T) {
+	srv := newFakeSelfTestServer(t)
+	fakeBinary(t, "psql", "psql (PostgreSQL) 16.2")
+
+	cfg := Config{
+		ModelVendor:     "anthropic",
+		ModelName:       "claude-test",
+		APIKey:          "good-key",
+		AuditEnabled:    true,
+		AuditURL:        srv.URL,
+		PolicyEnabled:   true,
+		PolicyCheckURL:  srv.URL,
+		ApprovalEnabled: true,
+	}
+	opts := SelfTestOptions{
+		Component: "postgres_database_agent",
+		Binaries:  []RequiredBinary{{Name: "psql", VersionArgs: []string{"--version"}}},
+	}
+
+	report := runSelfTest(context.Background(), cfg, opts)
+	if !report.Passed {
+		t.Errorf("report failed: %+v", report.Checks)
+	}
+	var names []string
+	for _, c := range report.Checks {
+		names = append(names, c.Name+"="+c.Status)
+	}
+	if got, want := strings.Join(names, " "), "llm=pass binary:psql=pass policy=pass audit=pass approval=pass"; got != want {
+		t.Errorf("checks = %s, want %s", got, want)
+	}
+
+	cfg.APIKey = "bad-key"
+	cfg.ApprovalEnabled = false
+	report = runSelfTest(context.Background(), cfg, opts)
+	if report.Passed {
+		t.Error("report passed with a rejected API key")
+	}
+	if c := report.Checks[0]; c.Status != selfTestFail || !strings.Contains(c.Detail, "rejected the API key") {
+		t.Errorf("llm check = %+v", c)
+	}
+	if c := report.Checks[4]; c.Status != selfTestSkip {
+		t.Errorf("approval check = %+v, want skip", c)
+	}
+}
+
+func TestStartupPreflight_RecordsReport(t *testing.T) {
+	srv := newFakeSelfTestServer(t)
+	t.Setenv("HELPDESK_SELFTEST_REQUIRED", "")
+	cfg := Config{
+		ModelVendor: "anthropic",
+		ModelName:   "missing-model",
+		APIKey:      "good-key",
+		AuditURL:    srv.URL,
+	}
+
+	// Not required: a failing self-test is reported and startup continues.
+	StartupPreflight(context.Background(), cfg, SelfTestOptions{Component: "kb_agent"})
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(srv.events))
+	}
+	e := srv.events[0]
+	if e.EventType != audit.EventTypeStartupReport || !strings.HasPrefix(e.EventID, "sup_") || e.StartupReport == nil {
+		t.Fatalf("event = %+v", e)
+	}
+	r := e.StartupReport
+	if r.Passed || r.Required || r.Attempt != 1 || r.Component != "kb_agent" {
+		t.Errorf("report = %+v", r)
+	}
+	if c := r.Checks[0]; c.Name != "llm" || !strings.Contains(c.Detail, `does not know model "missing-model"`) {
+		t.Errorf("llm check = %+v", c)
+	}
+}
@@ -14,8 +14,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
@@ -29,7 +31,11 @@ import (
 	"helpdesk/agentutil"
 	"helpdesk/internal/audit"
 	"helpdesk/internal/authz"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/discovery"
 	"helpdesk/internal/identity"
+	"helpdesk/internal/secrets"
+	"helpdesk/internal/shutdown"
 )
 
 // InitApprovalClient initializes an approval client if the approval workflow is enabled.
@@ -58,13 +64,23 @@ func InitApprovalClient(cfg agentutil.Config) *audit.ApprovalClient {
 
 // InitAuditStore initializes an audit store for an agent if auditing is enabled.
 // Returns nil if auditing is disabled. The caller should defer store.Close() if non-nil.
-// If HELPDESK_AUDIT_URL is set, uses the central audit service (preferred).
+// If HELPDESK_AUDIT_GRPC_ADDR or HELPDESK_AUDIT_URL is set, uses the central
+// audit service (preferred), over gRPC when both are set.
 // Otherwise falls back to local SQLite if HELPDESK_AUDIT_DIR is set.
 func InitAuditStore(cfg agentutil.Config) (audit.Auditor, error) {
 	if !cfg.AuditEnabled {
 		return nil, nil
 	}
 
+	if cfg.AuditGRPCAddr != "" {
+		store, err := audit.NewGRPCStore(cfg.AuditGRPCAddr, cfg.AuditAPIKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gRPC audit store: %w", err)
+		}
+		slog.Info("agent audit logging enabled (remote, gRPC)", "addr", cfg.AuditGRPCAddr)
+		return store, nil
+	}
+
 	// Prefer central audit service
 	if cfg.AuditURL != "" {
 		slog.Info("agent audit logging enabled (remote)", "url", cfg.AuditURL)
@@ -108,6 +124,54 @@ func registerSchemasHandler(mux *http.ServeMux, schemas map[string]map[string]an
 	})
 }
 
+// registerManifestHandler serves the operator-signed capability manifest at
+// path on discovery.ManifestPath. The file is served as is: the agent cannot
+// sign it, only publish it.
+func registerManifestHandler(mux *http.ServeMux, path string) {
+	if path == "" {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("agentutil/serve: failed to read capability manifest; orchestrators that verify manifests will refuse this agent", "path", path, "err", err)
+		return
+	}
+	mux.HandleFunc("GET "+discovery.ManifestPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b) //nolint:errcheck
+	})
+}
+
+// requireA2ASignatures wraps the A2A handler so that, when
+// HELPDESK_A2A_SIGNING_KEY is set, only requests signed with it by the
+// gateway or orchestrator reach the agent. The key may be a secrets reference.
+func requireA2ASignatures(next http.Handler, auditor audit.Auditor, agentName string) (http.Handler, error) {
+	key, err := secrets.Getenv(context.Background(), audit.A2ASigningKeyEnv)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", audit.A2ASigningKeyEnv, err)
+	}
+	if key == "" {
+		slog.Warn("A2A requests are not authenticated — set " + audit.A2ASigningKeyEnv + " to accept only signed requests")
+		return next, nil
+	}
+	slog.Info("A2A request signatures required", "agent", agentName)
+	return audit.A2ASignatureMiddleware(audit.NewA2AVerifier([]byte(key), 0), auditor, agentName, next), nil
+}
+
+// requireA2AToolSignatures does the same for the direct-tool routes, whose
+// callers sign with headers instead of message metadata, so that
+// POST /tool/{name} is not a way around the signing of /invoke.
+func requireA2AToolSignatures(next http.Handler, auditor audit.Auditor, agentName string) (http.Handler, error) {
+	key, err := secrets.Getenv(context.Background(), audit.A2ASigningKeyEnv)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", audit.A2ASigningKeyEnv, err)
+	}
+	if key == "" {
+		return next, nil
+	}
+	return audit.A2AToolSignatureMiddleware(audit.NewA2AVerifier([]byte(key), 0), auditor, agentName, next), nil
+}
+
 // =============================================================================
 // Tool-call tracking — injects structured tool call data into the A2A response
 // so faulttest (and other evaluation clients) can use exact tool-name matching
@@ -122,11 +186,17 @@ const HelpdeskToolCallSummaryMetaKey = "helpdesk_type"
 // HelpdeskToolCallSummaryMetaValue is the value of HelpdeskToolCallSummaryMetaKey.
 const HelpdeskToolCallSummaryMetaValue = "tool_call_summary"
 
+// HelpdeskCitationsMetaValue marks the DataPart listing the sources of the
+// response, {"citations": [audit.Citation...]}. Agents attach them to model
+// responses under agentutil.CitationsMetadataKey.
+const HelpdeskCitationsMetaValue = "citations"
+
 type toolCallStoreKey struct{}
 
 type toolCallStore struct {
-	mu    sync.Mutex
-	names []string
+	mu        sync.Mutex
+	names     []string
+	citations []audit.Citation
 }
 
 func (s *toolCallStore) add(name string) {
@@ -146,6 +216,35 @@ func (s *toolCallStore) snapshot() []string {
 	return out
 }
 
+// addCitations records the sources of a response, once per URL.
+func (s *toolCallStore) addCitations(cs []audit.Citation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range cs {
+		seen := false
+		for _, have := range s.citations {
+			if have.URL == c.URL {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			s.citations = append(s.citations, c)
+		}
+	}
+}
+
+func (s *toolCallStore) citationsSnapshot() []audit.Citation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.citations) == 0 {
+		return nil
+	}
+	out := make([]audit.Citation, len(s.citations))
+	copy(out, s.citations)
+	return out
+}
+
 func toolCallStoreFromContext(ctx context.Context) *toolCallStore {
 	s, _ := ctx.Value(toolCallStoreKey{}).(*toolCallStore)
 	return s
@@ -153,30 +252,43 @@ func toolCallStoreFromContext(ctx context.Context) *toolCallStore {
 
 func newToolCallCallbacks() (adka2a.BeforeExecuteCallback, adka2a.AfterEventCallback) {
 	before := func(ctx context.Context, _ *a2asrv.RequestContext) (context.Context, error) {
+		// The executor picking the request up ends its queueing phase.
+		audit.PhaseTimerFromContext(ctx).Start()
 		return context.WithValue(ctx, toolCallStoreKey{}, &toolCallStore{}), nil
 	}
 
 	after := func(ctx adka2a.ExecutorContext, adkEvent *session.Event, processed *a2a.TaskArtifactUpdateEvent) error {
+		store := toolCallStoreFromContext(ctx)
+		if store == nil {
+			return nil
+		}
 		if adkEvent.Content != nil {
-			if store := toolCallStoreFromContext(ctx); store != nil {
-				for _, part := range adkEvent.Content.Parts {
-					if part.FunctionCall != nil && part.FunctionCall.Name != "" {
-						store.add(part.FunctionCall.Name)
-					}
+			for _, part := range adkEvent.Content.Parts {
+				if part.FunctionCall != nil && part.FunctionCall.Name != "" {
+					store.add(part.FunctionCall.Name)
 				}
 			}
 		}
+		if cs, ok := adkEvent.CustomMetadata[agentutil.CitationsMetadataKey].([]audit.Citation); ok {
+			store.addCitations(cs)
+		}
 
 		if adkEvent.IsFinalResponse() && processed != nil && processed.Artifact != nil {
-			if store := toolCallStoreFromContext(ctx); store != nil {
-				if names := store.snapshot(); len(names) > 0 {
-					processed.Artifact.Parts = append(processed.Artifact.Parts, a2a.DataPart{
-						Data: map[string]any{"tool_calls": names},
-						Metadata: map[string]any{
-							HelpdeskToolCallSummaryMetaKey: HelpdeskToolCallSummaryMetaValue,
-						},
-					})
-				}
+			if names := store.snapshot(); len(names) > 0 {
+				processed.Artifact.Parts = append(processed.Artifact.Parts, a2a.DataPart{
+					Data: map[string]any{"tool_calls": names},
+					Metadata: map[string]any{
+						HelpdeskToolCallSummaryMetaKey: HelpdeskToolCallSummaryMetaValue,
+					},
+				})
+			}
+			if cs := store.citationsSnapshot(); len(cs) > 0 {
+				processed.Artifact.Parts = append(processed.Artifact.Parts, a2a.DataPart{
+					Data: map[string]any{"citations": cs},
+					Metadata: map[string]any{
+						HelpdeskToolCallSummaryMetaKey: HelpdeskCitationsMetaValue,
+					},
+				})
 			}
 		}
 		return nil
@@ -207,7 +319,7 @@ func registerDirectToolRoutes(mux *http.ServeMux, registry *agentutil.DirectTool
 			return
 		}
 		toolName := r.PathValue("name")
-		fn, ok := registry.Get(toolName)
+		fn, ok := registry.GetStructured(toolName)
 		if !ok {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusNotFound)
@@ -239,16 +351,28 @@ func registerDirectToolRoutes(mux *http.ServeMux, registry *agentutil.DirectTool
 			PurposeNote:     req.PurposeNote,
 			PurposeExplicit: req.PurposeExplicit,
 		}
+		if tp, ok := audit.ParseTraceParent(req.TraceParent); ok {
+			tc.TraceParent = tp.String()
+		}
 		ctx := audit.WithTraceContext(r.Context(), tc)
 
 		if traceStore != nil && req.TraceID != "" {
 			traceStore.Set(req.TraceID)
+			traceStore.SetTraceParent(tc.TraceParent)
 		}
 
 		target, _ := req.Args["target"].(string)
 
+		if err := registry.ValidateArgs(ctx, toolName, req.Args); err != nil {
+			slog.Info("direct tool: arguments rejected", "tool", toolName, "target", target, "err", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(agentutil.DirectToolResponse{Error: err.Error()}) //nolint:errcheck
+			return
+		}
+
 		start := time.Now()
-		output, err := fn(ctx, req.Args)
+		out, err := fn(ctx, req.Args)
 		ms := time.Since(start).Milliseconds()
 		w.Header().Set("Content-Type", "application/json")
 		if err != nil {
@@ -258,7 +382,7 @@ func registerDirectToolRoutes(mux *http.ServeMux, registry *agentutil.DirectTool
 			return
 		}
 		slog.Debug("direct tool: ok", "tool", toolName, "target", target, "ms", ms)
-		json.NewEncoder(w).Encode(agentutil.DirectToolResponse{Output: output}) //nolint:errcheck
+		json.NewEncoder(w).Encode(agentutil.DirectToolResponse{Output: out.Text, Data: out.Data}) //nolint:errcheck
 	})
 }
 
@@ -301,6 +425,8 @@ func Serve(ctx context.Context, a agent.Agent, cfg agentutil.Config, opts ...age
 		toolSchemas = opts[0].ToolSchemas
 	}
 	registerSchemasHandler(mux, toolSchemas)
+	mux.HandleFunc("GET /version", buildinfo.Handler())
+	registerManifestHandler(mux, os.Getenv("HELPDESK_CAPABILITY_MANIFEST"))
 
 	toolCallBefore, toolCallAfter := newToolCallCallbacks()
 	executor := adka2a.NewExecutor(adka2a.ExecutorConfig{
@@ -321,7 +447,16 @@ func Serve(ctx context.Context, a agent.Agent, cfg agentutil.Config, opts ...age
 		"card", baseURL.String()+"/.well-known/agent-card.json",
 	)
 
-	return http.Serve(listener, mux)
+	return serveUntilSignal(ctx, listener, mux)
+}
+
+// serveUntilSignal serves mux on listener until SIGINT or SIGTERM (or ctx
+// is done), then stops accepting requests and drains in-flight ones and the
+// audit events they are still recording, within HELPDESK_SHUTDOWN_TIMEOUT.
+func serveUntilSignal(ctx context.Context, listener net.Listener, mux http.Handler) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return shutdown.Serve(ctx, &http.Server{Handler: mux}, listener, shutdown.Timeout())
 }
 
 // ServeWithTracing starts an A2A server with trace_id extraction from incoming messages.
@@ -363,6 +498,8 @@ func ServeWithTracing(ctx context.Context, a agent.Agent, cfg agentutil.Config,
 		toolSchemas = opts[0].ToolSchemas
 	}
 	registerSchemasHandler(mux, toolSchemas)
+	mux.HandleFunc("GET /version", buildinfo.Handler())
+	registerManifestHandler(mux, os.Getenv("HELPDESK_CAPABILITY_MANIFEST"))
 
 	toolCallBefore, toolCallAfter := newToolCallCallbacks()
 	executor := adka2a.NewExecutor(adka2a.ExecutorConfig{
@@ -377,7 +514,11 @@ func ServeWithTracing(ctx context.Context, a agent.Agent, cfg agentutil.Config,
 	requestHandler := a2asrv.NewHandler(executor)
 
 	tracedHandler := audit.TraceMiddlewareWithAudit(traceStore, auditor, a.Name(), a2asrv.NewJSONRPCHandler(requestHandler))
-	mux.Handle(agentPath, tracedHandler)
+	invokeHandler, err := requireA2ASignatures(tracedHandler, auditor, a.Name())
+	if err != nil {
+		return err
+	}
+	mux.Handle(agentPath, invokeHandler)
 
 	slog.Info("starting A2A server with tracing",
 		"agent", a.Name(),
@@ -385,7 +526,7 @@ func ServeWithTracing(ctx context.Context, a agent.Agent, cfg agentutil.Config,
 		"card", baseURL.String()+"/.well-known/agent-card.json",
 	)
 
-	return http.Serve(listener, mux)
+	return serveUntilSignal(ctx, listener, mux)
 }
 
 // ServeWithTracingAndDirectTools is like ServeWithTracing but also registers
@@ -428,6 +569,8 @@ func ServeWithTracingAndDirectTools(ctx context.Context, a agent.Agent, cfg agen
 		toolSchemas = opts[0].ToolSchemas
 	}
 	registerSchemasHandler(mux, toolSchemas)
+	mux.HandleFunc("GET /version", buildinfo.Handler())
+	registerManifestHandler(mux, os.Getenv("HELPDESK_CAPABILITY_MANIFEST"))
 
 	toolCallBefore, toolCallAfter := newToolCallCallbacks()
 	executor := adka2a.NewExecutor(adka2a.ExecutorConfig{
@@ -442,7 +585,11 @@ func ServeWithTracingAndDirectTools(ctx context.Context, a agent.Agent, cfg agen
 	requestHandler := a2asrv.NewHandler(executor)
 
 	tracedHandler := audit.TraceMiddlewareWithAudit(traceStore, auditor, a.Name(), a2asrv.NewJSONRPCHandler(requestHandler))
-	mux.Handle(agentPath, tracedHandler)
+	invokeHandler, err := requireA2ASignatures(tracedHandler, auditor, a.Name())
+	if err != nil {
+		return err
+	}
+	mux.Handle(agentPath, invokeHandler)
 
 	if registry != nil {
 		var idProvider identity.Provider = &identity.NoAuthProvider{}
@@ -455,11 +602,17 @@ func ServeWithTracingAndDirectTools(ctx context.Context, a agent.Agent, cfg agen
 			}
 			idProvider = p
 			slog.Info("agent inbound auth enabled", "users_file", cfg.UsersFile)
-		} else {
-			slog.Warn("POST /tool/{name} is unauthenticated — set HELPDESK_USERS_FILE to require service-account credentials")
+		} else if os.Getenv(audit.A2ASigningKeyEnv) == "" {
+			slog.Warn("POST /tool/{name} is unauthenticated — set HELPDESK_USERS_FILE or " + audit.A2ASigningKeyEnv + " to require credentials")
 		}
 		authzr := authz.NewAuthorizer(authz.DefaultAgentPermissions, enforcing)
-		registerDirectToolRoutes(mux, registry, traceStore, idProvider, authzr)
+		toolMux := http.NewServeMux()
+		registerDirectToolRoutes(toolMux, registry, traceStore, idProvider, authzr)
+		toolHandler, err := requireA2AToolSignatures(toolMux, auditor, a.Name())
+		if err != nil {
+			return err
+		}
+		mux.Handle("POST /tool/", toolHandler)
 		slog.Info("direct tool dispatch enabled", "agent", a.Name(), "tools", registry.Len())
 	}
 
@@ -475,5 +628,5 @@ func ServeWithTracingAndDirectTools(ctx context.Context, a agent.Agent, cfg agen
 		"card", baseURL.String()+"/.well-known/agent-card.json",
 	)
 
-	return http.Serve(listener, mux)
+	return serveUntilSignal(ctx, listener, mux)
 }
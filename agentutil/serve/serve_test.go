@@ -11,11 +11,14 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
 	"github.com/a2aproject/a2a-go/a2asrv"
 	"google.golang.org/adk/server/adka2a"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
 
 	"helpdesk/agentutil"
@@ -198,6 +201,35 @@ func TestNewToolCallCallbacks_AfterNoSummaryWhenNoToolCalls(t *testing.T) {
 	}
 }
 
+func TestNewToolCallCallbacks_AfterInjectsCitations(t *testing.T) {
+	before, after := newToolCallCallbacks()
+	ctx, _ := before(context.Background(), nil)
+	execCtx := mockExecutorContext{ctx}
+
+	retrieved := time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC)
+	evt := makeFinalResponseEvent("CVE-2026-1234 affects Redis 7.2")
+	evt.CustomMetadata = map[string]any{agentutil.CitationsMetadataKey: []audit.Citation{
+		{URL: "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", Title: "nvd.nist.gov", RetrievedAt: retrieved},
+		{URL: "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", Title: "nvd.nist.gov", RetrievedAt: retrieved},
+	}}
+	artifact := &a2a.Artifact{ID: "art-cite"}
+	if err := after(execCtx, evt, &a2a.TaskArtifactUpdateEvent{Artifact: artifact}); err != nil {
+		t.Fatalf("after callback error: %v", err)
+	}
+
+	if len(artifact.Parts) != 1 {
+		t.Fatalf("artifact parts = %v, want only the citations DataPart", artifact.Parts)
+	}
+	dp, ok := artifact.Parts[0].(a2a.DataPart)
+	if !ok || dp.Metadata[HelpdeskToolCallSummaryMetaKey] != HelpdeskCitationsMetaValue {
+		t.Fatalf("part = %+v, want a citations DataPart", artifact.Parts[0])
+	}
+	cs, ok := dp.Data["citations"].([]audit.Citation)
+	if !ok || len(cs) != 1 || !cs[0].RetrievedAt.Equal(retrieved) {
+		t.Errorf("citations = %v, want the one source with its retrieval time", dp.Data["citations"])
+	}
+}
+
 func TestNewToolCallCallbacks_AfterNoSummaryWhenProcessedNil(t *testing.T) {
 	before, after := newToolCallCallbacks()
 	ctx, _ := before(context.Background(), nil)
@@ -325,6 +357,69 @@ func TestDirectToolRoutes_Success(t *testing.T) {
 	}
 }
 
+func TestDirectToolRoutes_StructuredData(t *testing.T) {
+	r := agentutil.NewDirectToolRegistry()
+	r.RegisterStructured("typed_tool", func(ctx context.Context, args map[string]any) (agentutil.ToolOutput, error) {
+		return agentutil.ToolOutput{Text: "2 pods", Data: map[string]int{"count": 2}}, nil
+	})
+	mux := makeDirectToolMux(r, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/tool/typed_tool", strings.NewReader(`{"args":{}}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp struct {
+		Output string         `json:"output"`
+		Data   map[string]int `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Output != "2 pods" || resp.Data["count"] != 2 {
+		t.Errorf("response = %+v, want output and data.count=2", resp)
+	}
+}
+
+func TestDirectToolRoutes_InvalidArgs_Returns400(t *testing.T) {
+	type scaleArgs struct {
+		Replicas int `json:"replicas" validate:"min=0"`
+	}
+	tl, err := agentutil.NewTool(functiontool.Config{Name: "scale", Description: "test"},
+		func(tool.Context, scaleArgs) (string, error) { return "scaled", nil })
+	if err != nil {
+		t.Fatalf("NewTool: %v", err)
+	}
+	v, err := agentutil.NewArgValidator([]tool.Tool{tl}, nil)
+	if err != nil {
+		t.Fatalf("NewArgValidator: %v", err)
+	}
+	called := false
+	r := agentutil.NewDirectToolRegistry()
+	r.Register("scale", func(ctx context.Context, args map[string]any) (string, error) {
+		called = true
+		return "scaled", nil
+	})
+	r.SetArgValidator(v)
+	mux := makeDirectToolMux(r, nil)
+
+	for body, want := range map[string]int{
+		`{"args":{"replicas":-1}}`:             http.StatusBadRequest,
+		`{"args":{"replicas":2,"force":true}}`: http.StatusBadRequest,
+		`{"args":{"replicas":2}}`:              http.StatusOK,
+	} {
+		called = false
+		req := httptest.NewRequest(http.MethodPost, "/tool/scale", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d; body: %s", body, rec.Code, want, rec.Body.String())
+		}
+		if called != (want == http.StatusOK) {
+			t.Errorf("%s: tool called = %v", body, called)
+		}
+	}
+}
+
 func TestDirectToolRoutes_ContentTypeIsJSON(t *testing.T) {
 	r := agentutil.NewDirectToolRegistry()
 	r.Register("ok_tool", func(ctx context.Context, args map[string]any) (string, error) {
@@ -593,6 +688,33 @@ func TestDirectToolRoutes_Auth_HumanRejected(t *testing.T) {
 	}
 }
 
+func TestDirectToolRoutes_A2ASignatureRequired(t *testing.T) {
+	t.Setenv(audit.A2ASigningKeyEnv, "test-signing-key")
+	r := agentutil.NewDirectToolRegistry()
+	r.Register("ok_tool", func(_ context.Context, _ map[string]any) (string, error) {
+		return "ok", nil
+	})
+	h, err := requireA2AToolSignatures(makeDirectToolMux(r, nil), nil, "test_agent")
+	if err != nil {
+		t.Fatalf("requireA2AToolSignatures: %v", err)
+	}
+
+	const body = `{"args":{}}`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tool/ok_tool", strings.NewReader(body)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned call: status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/tool/ok_tool", strings.NewReader(body))
+	audit.SignA2AToolRequest(req, []byte(body), []byte("test-signing-key"))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("signed call: status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func mustGenerateAPIKey(t *testing.T) (rawKey, hash string) {
 	t.Helper()
 	rawKey = "test-gateway-key"
@@ -18,10 +18,14 @@ import (
 	"time"
 
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/cliout"
 	"helpdesk/internal/logging"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	// Initialize logging first (strips --log-level from args)
 	args := logging.InitLogging(os.Args[1:])
 
@@ -37,7 +41,9 @@ func main() {
 	fs.StringVar(&auditURL, "url", auditURL, "URL of the audit service (or set HELPDESK_AUDIT_URL)")
 	fs.StringVar(&apiKey, "api-key", apiKey, "API key for authenticated requests (or set HELPDESK_APPROVAL_KEY)")
 	fs.StringVar(&approvalUser, "user", approvalUser, "User ID for X-User header auth (or set HELPDESK_APPROVAL_USER)")
-	outputJSON := fs.Bool("json", false, "Output in JSON format")
+	var output cliout.Format
+	cliout.Register(fs, &output)
+	outputJSON := fs.Bool("json", false, "Same as -o json")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: approvals [options] <command> [arguments]
@@ -49,7 +55,7 @@ Commands:
   approve <approval_id> --reason "..."     Approve a request
   deny <approval_id> --reason "..."        Deny a request
   cancel <approval_id>                     Cancel a pending request
-  watch                                    Watch for new approval requests (interactive)
+  watch                                    Watch for new approval requests (interactive, table only)
 
 Options:
 `)
@@ -59,23 +65,41 @@ Environment Variables:
   HELPDESK_AUDIT_URL      URL of the audit service (e.g., http://localhost:1199)
   HELPDESK_APPROVAL_KEY   API key for service-account authentication (Bearer token)
   HELPDESK_APPROVAL_USER  User ID for human-operator authentication (X-User header)
+  HELPDESK_AUDIT_TOKEN    auditd access token, sent as X-Audit-Token
 
 Examples:
   approvals pending                         # List pending approvals
   approvals approve apr_abc123 --reason "Verified by ops team"
   approvals deny apr_abc123 --reason "Request not justified"
   approvals watch                           # Interactive approval mode
+  approvals pending -o json | jq length     # Count pending approvals in a script
+
+Output:
+  -o json and -o yaml print the approval objects returned by auditd, with the
+  same field names (approval_id, status, action_class, ...). approve and deny
+  print the resolved approval; cancel prints {"approval_id", "status"}.
+
+Exit codes:
+  0  success
+  1  error (bad arguments, auditd unreachable, HTTP error from auditd)
 `)
 	}
 
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
+	if *outputJSON {
+		output = cliout.JSON
+	}
 
 	if auditURL == "" {
 		fmt.Fprintln(os.Stderr, "Error: audit service URL required (use --url or set HELPDESK_AUDIT_URL)")
 		os.Exit(1)
 	}
+	if err := audit.InstallAuditToken(context.Background(), auditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	creds := authCreds{apiKey: apiKey, user: approvalUser}
 
@@ -99,18 +123,22 @@ Examples:
 	var err error
 	switch command {
 	case "list":
-		err = cmdList(ctx, client, cmdArgs, *outputJSON, auditURL)
+		err = cmdList(ctx, client, cmdArgs, output, auditURL)
 	case "pending":
-		err = cmdList(ctx, client, []string{"--status=pending"}, *outputJSON, auditURL)
+		err = cmdList(ctx, client, []string{"--status=pending"}, output, auditURL)
 	case "show":
-		err = cmdShow(ctx, client, cmdArgs, *outputJSON)
+		err = cmdShow(ctx, client, cmdArgs, output)
 	case "approve":
-		err = cmdApprove(ctx, cmdArgs, auditURL, creds)
+		err = cmdApprove(ctx, cmdArgs, auditURL, creds, output)
 	case "deny":
-		err = cmdDeny(ctx, cmdArgs, auditURL, creds)
+		err = cmdDeny(ctx, cmdArgs, auditURL, creds, output)
 	case "cancel":
-		err = cmdCancel(ctx, client, cmdArgs)
+		err = cmdCancel(ctx, client, cmdArgs, output)
 	case "watch":
+		if output.Structured() {
+			err = fmt.Errorf("watch is interactive and supports only -o table")
+			break
+		}
 		err = cmdWatch(ctx, client, auditURL, creds)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
@@ -124,7 +152,7 @@ Examples:
 	}
 }
 
-func cmdList(ctx context.Context, client *audit.ApprovalClient, args []string, outputJSON bool, auditURL string) error {
+func cmdList(ctx context.Context, client *audit.ApprovalClient, args []string, output cliout.Format, auditURL string) error {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
 	status := fs.String("status", "", "Filter by status (pending, approved, denied, expired)")
 	agent := fs.String("agent", "", "Filter by agent name")
@@ -144,10 +172,11 @@ func cmdList(ctx context.Context, client *audit.ApprovalClient, args []string, o
 		return fmt.Errorf("list approvals: %w", err)
 	}
 
-	if outputJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(approvals)
+	if output.Structured() {
+		if approvals == nil {
+			approvals = []audit.StoredApproval{}
+		}
+		return cliout.Write(os.Stdout, output, approvals)
 	}
 
 	if len(approvals) == 0 {
@@ -183,7 +212,7 @@ func cmdList(ctx context.Context, client *audit.ApprovalClient, args []string, o
 	return nil
 }
 
-func cmdShow(ctx context.Context, client *audit.ApprovalClient, args []string, outputJSON bool) error {
+func cmdShow(ctx context.Context, client *audit.ApprovalClient, args []string, output cliout.Format) error {
 	if len(args) == 0 {
 		return fmt.Errorf("approval ID required")
 	}
@@ -194,10 +223,8 @@ func cmdShow(ctx context.Context, client *audit.ApprovalClient, args []string, o
 		return fmt.Errorf("get approval: %w", err)
 	}
 
-	if outputJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(approval)
+	if output.Structured() {
+		return cliout.Write(os.Stdout, output, approval)
 	}
 
 	fmt.Printf("Approval ID:    %s\n", approval.ApprovalID)
@@ -238,9 +265,22 @@ func cmdShow(ctx context.Context, client *audit.ApprovalClient, args []string, o
 	if approval.ResolutionReason != "" {
 		fmt.Printf("Reason:         %s\n", approval.ResolutionReason)
 	}
+	if approval.Status == "approved" {
+		fmt.Printf("Execution:      %s\n", approval.Execution.Summary())
+		if approval.Execution != nil {
+			fmt.Printf("Executed At:    %s (event %s)\n", approval.Execution.ExecutedAt.Format(time.RFC3339), approval.Execution.EventID)
+		}
+	}
+	if plan := approval.ExecutionPlan(); plan != nil {
+		fmt.Println("Execution Plan:")
+		fmt.Print(plan.Format("  "))
+	}
 	if len(approval.RequestContext) > 0 {
 		fmt.Println("Request Context:")
 		for k, v := range approval.RequestContext {
+			if k == audit.RequestContextExecutionPlan {
+				continue
+			}
 			fmt.Printf("  %s: %v\n", k, v)
 		}
 	}
@@ -248,7 +288,7 @@ func cmdShow(ctx context.Context, client *audit.ApprovalClient, args []string, o
 	return nil
 }
 
-func cmdApprove(ctx context.Context, args []string, auditURL string, creds authCreds) error {
+func cmdApprove(ctx context.Context, args []string, auditURL string, creds authCreds, output cliout.Format) error {
 	fs := flag.NewFlagSet("approve", flag.ExitOnError)
 	reason := fs.String("reason", "", "Reason for approval")
 	validFor := fs.Int("valid-for", 0, "Approval valid for N minutes (0 = no expiration)")
@@ -288,6 +328,10 @@ func cmdApprove(ctx context.Context, args []string, auditURL string, creds authC
 		return fmt.Errorf("parse response: %w", err)
 	}
 
+	if output.Structured() {
+		return cliout.Write(os.Stdout, output, approval)
+	}
+
 	fmt.Printf("Approved: %s\n", approval.ApprovalID)
 	fmt.Printf("  Status:      %s\n", approval.Status)
 	fmt.Printf("  Approved By: %s\n", approval.ResolvedBy)
@@ -298,7 +342,7 @@ func cmdApprove(ctx context.Context, args []string, auditURL string, creds authC
 	return nil
 }
 
-func cmdDeny(ctx context.Context, args []string, auditURL string, creds authCreds) error {
+func cmdDeny(ctx context.Context, args []string, auditURL string, creds authCreds, output cliout.Format) error {
 	fs := flag.NewFlagSet("deny", flag.ExitOnError)
 	reason := fs.String("reason", "", "Reason for denial (required)")
 	if err := fs.Parse(args); err != nil {
@@ -331,6 +375,10 @@ func cmdDeny(ctx context.Context, args []string, auditURL string, creds authCred
 		return fmt.Errorf("parse response: %w", err)
 	}
 
+	if output.Structured() {
+		return cliout.Write(os.Stdout, output, approval)
+	}
+
 	fmt.Printf("Denied: %s\n", approval.ApprovalID)
 	fmt.Printf("  Status:    %s\n", approval.Status)
 	fmt.Printf("  Denied By: %s\n", approval.ResolvedBy)
@@ -339,7 +387,7 @@ func cmdDeny(ctx context.Context, args []string, auditURL string, creds authCred
 	return nil
 }
 
-func cmdCancel(ctx context.Context, client *audit.ApprovalClient, args []string) error {
+func cmdCancel(ctx context.Context, client *audit.ApprovalClient, args []string, output cliout.Format) error {
 	if len(args) == 0 {
 		return fmt.Errorf("approval ID required")
 	}
@@ -354,6 +402,9 @@ func cmdCancel(ctx context.Context, client *audit.ApprovalClient, args []string)
 		return fmt.Errorf("cancel: %w", err)
 	}
 
+	if output.Structured() {
+		return cliout.Write(os.Stdout, output, map[string]string{"approval_id": approvalID, "status": "cancelled"})
+	}
 	fmt.Printf("Cancelled: %s\n", approvalID)
 	return nil
 }
@@ -449,6 +500,9 @@ func showPending(ctx context.Context, client *audit.ApprovalClient, seen map[str
 		fmt.Printf("  Tool:      %s\n", a.ToolName)
 		fmt.Printf("  Agent:     %s\n", a.AgentName)
 		fmt.Printf("  Requested: %s by %s\n", a.RequestedAt.Format("15:04:05"), a.RequestedBy)
+		if plan := a.ExecutionPlan(); plan != nil {
+			fmt.Printf("  Plan:      %s\n", plan.Summary)
+		}
 		if !a.ExpiresAt.IsZero() {
 			remaining := time.Until(a.ExpiresAt)
 			if remaining > 0 {
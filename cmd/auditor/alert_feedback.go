@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// resourceParams are the categoricalParams, in order of preference, that
+// name the resource an alert is about for false-positive matching.
+var resourceParams = []string{"namespace", "database", "context", "cluster", "host", "server", "target", "schema"}
+
+// alertResource returns the resource an event's tool call touched, or ""
+// when it names none.
+func alertResource(event *audit.Event) string {
+	if event.Tool == nil {
+		return ""
+	}
+	for _, p := range resourceParams {
+		if v, ok := event.Tool.Parameters[p].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// applyFeedback adjusts an alert for the false-positive feedback synced from
+// the audit service. An alert whose rule, agent and resource operators have
+// marked as a false positive is lowered one level; once the marks reach
+// -fp-suppress-after it is suppressed altogether.
+func (a *Auditor) applyFeedback(rule, agent, resource string, level AlertLevel) (AlertLevel, bool) {
+	a.mu.Lock()
+	count := 0
+	for _, s := range a.suppressions {
+		if s.Matches(rule, agent, resource) {
+			count += s.Count
+		}
+	}
+	a.mu.Unlock()
+	if count == 0 {
+		return level, false
+	}
+	if a.cfg.FPSuppressAfter > 0 && count >= a.cfg.FPSuppressAfter {
+		return level, true
+	}
+	switch level {
+	case AlertCritical:
+		return AlertWarning, false
+	case AlertWarning:
+		return AlertInfo, false
+	}
+	return level, false
+}
+
+// runAlertFeedbackSync refreshes the false-positive feedback, the active
+// maintenance windows and the runtime alert thresholds from the audit
+// service every interval.
+func (a *Auditor) runAlertFeedbackSync(auditServiceURL string, interval time.Duration) {
+	slog.Info("syncing alert false-positive feedback", "interval", interval, "url", auditServiceURL)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.syncAlertFeedback(auditServiceURL)
+	a.syncMaintenanceWindows(auditServiceURL)
+	a.syncRuntimeConfig(auditServiceURL)
+	for range ticker.C {
+		a.syncAlertFeedback(auditServiceURL)
+		a.syncMaintenanceWindows(auditServiceURL)
+		a.syncRuntimeConfig(auditServiceURL)
+	}
+}
+
+// syncAlertFeedback fetches /v1/alerts/suppressions. On failure the previous
+// feedback stays in effect.
+func (a *Auditor) syncAlertFeedback(auditServiceURL string) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(auditServiceURL, "/")+"/v1/alerts/suppressions", nil)
+	if err != nil {
+		slog.Error("failed to build alert feedback request", "err", err)
+		return
+	}
+	if a.cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.AuditAPIKey)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		slog.Warn("failed to fetch alert feedback", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("alert feedback request failed", "status", resp.StatusCode)
+		return
+	}
+	var out struct {
+		Suppressions []audit.AlertSuppression `json:"suppressions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		slog.Warn("failed to parse alert feedback", "err", err)
+		return
+	}
+	a.mu.Lock()
+	a.suppressions = out.Suppressions
+	a.mu.Unlock()
+}
+
+// reportAlert records a security alert with the audit service so that
+// operators can mark it as a false positive and govbot can report each
+// rule's precision.
+func (a *Auditor) reportAlert(alert SecurityAlert, suppressed bool, tenantID string) {
+	body, err := json.Marshal(audit.AlertRecord{
+		AlertID:    alert.ID,
+		Rule:       alert.Type,
+		Severity:   alert.Severity,
+		Agent:      alert.Agent,
+		Resource:   alert.Resource,
+		Message:    alert.Message,
+		EventID:    alert.EventID,
+		TraceID:    alert.TraceID,
+		Suppressed: suppressed,
+		TenantID:   tenantID,
+		CreatedAt:  alert.Timestamp.UTC(),
+
+		MaintenanceWindow: alert.MaintenanceWindow,
+	})
+	if err != nil {
+		slog.Error("failed to marshal alert", "err", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(a.cfg.AuditServiceURL, "/")+"/v1/alerts", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("failed to build alert report", "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.AuditAPIKey)
+	}
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		slog.Warn("failed to report alert to audit service", "alert_id", alert.ID, "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		slog.Warn("audit service rejected alert", "alert_id", alert.ID, "status", fmt.Sprint(resp.StatusCode))
+	}
+}
@@ -1,11 +1,25 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"helpdesk/internal/audit"
+	"helpdesk/internal/discovery"
+	"helpdesk/internal/i18n"
+	"helpdesk/internal/sms"
+	"helpdesk/internal/workhours"
 )
 
 // TestCheckFabricationMismatch_EmitsCriticalAlert verifies that Analyze fires a
@@ -82,6 +96,229 @@ func TestCheckFabricationMismatch_NoAlertOnCleanVerification(t *testing.T) {
 	}
 }
 
+// TestCheckOutOfBandChange_EmitsCriticalAlert verifies that an
+// out_of_band_change event from auditd raises a critical security alert.
+func TestCheckOutOfBandChange_EmitsCriticalAlert(t *testing.T) {
+	auditor := NewAuditor(Config{}, nil, nil)
+	auditor.Analyze(&audit.Event{
+		EventID:     "oob_test001",
+		Timestamp:   time.Now().UTC(),
+		EventType:   audit.EventTypeOutOfBandChange,
+		ActionClass: audit.ActionWrite,
+		OutOfBandChange: &audit.OutOfBandChange{
+			DBAuditEntry: audit.DBAuditEntry{User: "helpdesk_agent", Database: "prod", Command: "DELETE"},
+			Reason:       "no tool_execution on \"prod\" within 30s",
+		},
+	})
+
+	auditor.mu.Lock()
+	alerts := auditor.securityAlerts
+	auditor.mu.Unlock()
+	var found *SecurityAlert
+	for i := range alerts {
+		if alerts[i].Type == "out_of_band_change" {
+			found = &alerts[i]
+		}
+	}
+	if found == nil || found.Severity != string(AlertCritical) {
+		t.Fatalf("alerts = %+v, want a critical out_of_band_change alert", alerts)
+	}
+	if found.Details["user"] != "helpdesk_agent" {
+		t.Errorf("Details = %v, want the database user", found.Details)
+	}
+}
+
+// TestCheckBackupStale verifies that an old backup raises a warning and a
+// database without any successful backup a critical alert.
+func TestCheckBackupStale(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{}, []Notifier{rec}, nil)
+	for _, b := range []audit.BackupStale{
+		{Database: "prod-db", Tool: "pgbackrest", LastBackup: time.Now().Add(-50 * time.Hour), AgeSeconds: 50 * 3600, ThresholdSeconds: 26 * 3600},
+		{Database: "new-db", Tool: "wal-g", ThresholdSeconds: 26 * 3600, Reason: "no successful backup in the repository"},
+	} {
+		auditor.Analyze(&audit.Event{
+			EventID:     "bak_test001",
+			Timestamp:   time.Now().UTC(),
+			EventType:   audit.EventTypeBackupStale,
+			ActionClass: audit.ActionRead,
+			BackupStale: &b,
+		})
+	}
+
+	var alerts []Alert
+	for _, a := range rec.alerts {
+		if strings.Contains(a.Message, "BACKUP") {
+			alerts = append(alerts, a)
+		}
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("alerts = %+v, want 2 backup alerts", rec.alerts)
+	}
+	if a := alerts[0]; a.Level != AlertWarning || a.Details["age_hours"] != int64(50) {
+		t.Errorf("stale alert = %+v, want a warning with age_hours 50", a)
+	}
+	if a := alerts[1]; a.Level != AlertCritical || a.Details["database"] != "new-db" {
+		t.Errorf("missing-backup alert = %+v, want a critical alert for new-db", a)
+	}
+}
+
+// TestCheckInventoryDrift verifies that drift found by the inventory
+// reconciler raises a warning naming the resource.
+func TestCheckInventoryDrift(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{}, []Notifier{rec}, nil)
+	auditor.Analyze(&audit.Event{
+		EventID:   "inv_test001",
+		Timestamp: time.Now().UTC(),
+		EventType: audit.EventTypeInventoryDrift,
+		InventoryDrift: &audit.InventoryDrift{
+			ResourceType: "kubernetes", ResourceName: "billing", Cluster: "prod",
+			Problem: "namespace_missing", Detail: "namespace billing not found",
+		},
+	})
+
+	for _, a := range rec.alerts {
+		if strings.Contains(a.Message, "INVENTORY DRIFT") {
+			if a.Level != AlertWarning || a.Details["resource"] != "kubernetes/billing" || a.Details["problem"] != "namespace_missing" {
+				t.Errorf("alert = %+v, want a warning for kubernetes/billing", a)
+			}
+			return
+		}
+	}
+	t.Fatalf("alerts = %+v, want an inventory drift alert", rec.alerts)
+}
+
+// TestCheckApprovalSLABreach verifies that an approval SLA breach raises an
+// alert, critical for destructive actions.
+func TestCheckApprovalSLABreach(t *testing.T) {
+	for _, tt := range []struct {
+		class string
+		level AlertLevel
+	}{
+		{"destructive", AlertCritical},
+		{"write", AlertWarning},
+	} {
+		rec := &alertRecorder{}
+		auditor := NewAuditor(Config{}, []Notifier{rec}, nil)
+		auditor.Analyze(&audit.Event{
+			EventID:   "sla_test001",
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeApprovalSLABreach,
+			ApprovalSLABreach: &audit.ApprovalSLABreach{
+				ApprovalID: "apr_1", ActionClass: tt.class, Status: "pending",
+				ResourceType: "database", ResourceName: "orders", SLASecs: 900, WaitedSecs: 960,
+			},
+		})
+
+		var found bool
+		for _, a := range rec.alerts {
+			if strings.Contains(a.Message, "APPROVAL SLA BREACHED") {
+				found = true
+				if a.Level != tt.level || a.Details["approval_id"] != "apr_1" || a.Details["sla"] != "15m0s" || a.Details["waited"] != "16m0s" {
+					t.Errorf("%s: alert = %+v, want level %s", tt.class, a, tt.level)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: alerts = %+v, want an SLA breach alert", tt.class, rec.alerts)
+		}
+	}
+}
+
+// TestCheckSelfApproval verifies that an action approved by its own requester
+// raises a critical security alert, and one approved by someone else does not.
+func TestCheckSelfApproval(t *testing.T) {
+	for _, tt := range []struct {
+		approvedBy string
+		want       bool
+	}{
+		{"Alice@example.com", true},
+		{"bob@example.com", false},
+		{"approval_mode:auto", false},
+	} {
+		rec := &alertRecorder{}
+		auditor := NewAuditor(Config{}, []Notifier{rec}, nil)
+		auditor.Analyze(&audit.Event{
+			EventID:   "tool_test001",
+			Timestamp: time.Now().UTC(),
+			EventType: "tool_execution",
+			Tool:      &audit.ToolExecution{Name: "terminate_connection"},
+			Approval: &audit.Approval{
+				Required: true, Status: audit.ApprovalApproved, ApprovalID: "apr_1",
+				RequestedBy: "alice@example.com", ApprovedBy: tt.approvedBy,
+			},
+		})
+
+		var found bool
+		for _, a := range rec.alerts {
+			if strings.Contains(a.Message, "SELF-APPROVAL") {
+				found = true
+				if a.Level != AlertCritical || a.Details["approval_id"] != "apr_1" || a.Details["tool"] != "terminate_connection" {
+					t.Errorf("%s: alert = %+v, want critical", tt.approvedBy, a)
+				}
+			}
+		}
+		if found != tt.want {
+			t.Errorf("approved by %s: self-approval alert = %v, want %v", tt.approvedBy, found, tt.want)
+		}
+		auditor.mu.Lock()
+		recorded := len(auditor.securityAlerts) == 1 && auditor.securityAlerts[0].Type == "self_approval"
+		auditor.mu.Unlock()
+		if recorded != tt.want {
+			t.Errorf("approved by %s: security alerts = %+v", tt.approvedBy, auditor.securityAlerts)
+		}
+	}
+}
+
+// TestCheckSelfApproval_Pseudonymized verifies that a self-approval still
+// raises the alert when auditd stores pseudonyms instead of user IDs: the
+// auditor only ever sees the stored event.
+func TestCheckSelfApproval_Pseudonymized(t *testing.T) {
+	p := audit.NewPseudonymizer([]byte("pepper"))
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db"), Pseudonymizer: p})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if err := store.Record(ctx, &audit.Event{
+		EventID:   "tool_psn001",
+		EventType: audit.EventTypeToolExecution,
+		Session:   audit.Session{ID: "sess_1", UserID: "alice@example.com"},
+		Tool:      &audit.ToolExecution{Name: "terminate_connection"},
+		Approval: &audit.Approval{
+			Required: true, Status: audit.ApprovalApproved, ApprovalID: "apr_1",
+			RequestedBy: "alice@example.com", ApprovedBy: "Alice@example.com",
+		},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	events, err := store.Query(ctx, audit.QueryOptions{EventID: "tool_psn001"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Query = %v, %v", events, err)
+	}
+	if ap := events[0].Approval; !audit.IsPseudonym(ap.RequestedBy) || !audit.IsPseudonym(ap.ApprovedBy) {
+		t.Fatalf("stored approval = %+v, want pseudonyms", ap)
+	}
+
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{}, []Notifier{rec}, nil)
+	auditor.Analyze(&events[0])
+	var found bool
+	for _, a := range rec.alerts {
+		if strings.Contains(a.Message, "SELF-APPROVAL") {
+			found = true
+			if a.Details["requested_by"] != p.Pseudonym("alice@example.com") {
+				t.Errorf("alert names %v, want the pseudonym", a.Details["requested_by"])
+			}
+		}
+	}
+	if !found {
+		t.Error("self-approval of a pseudonymized event raised no alert")
+	}
+}
+
 // TestCheckFabricationMismatch_NoAlertOnOtherEventType verifies that non-verification
 // events are not mistakenly classified as fabrication mismatches.
 func TestCheckFabricationMismatch_NoAlertOnOtherEventType(t *testing.T) {
@@ -112,3 +349,1054 @@ func TestCheckFabricationMismatch_NoAlertOnOtherEventType(t *testing.T) {
 		}
 	}
 }
+
+// TestVerifyChainFromService_Incremental verifies that incremental runs ask
+// the audit service for ?incremental=true and full runs do not, and that a
+// broken segment raises a chain_tampering alert.
+func TestVerifyChainFromService_Incremental(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		json.NewEncoder(w).Encode(audit.ChainStatus{ //nolint:errcheck
+			Valid: false, BrokenAt: 2, BrokenSegment: "session:s1", Error: "broken link",
+		})
+	}))
+	defer srv.Close()
+
+	auditor := NewAuditor(Config{}, nil, nil)
+	auditor.verifyChainFromService(srv.URL, false)
+	auditor.verifyChainFromService(srv.URL, true)
+
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "incremental=true" {
+		t.Errorf("queries = %q, want [\"\" \"incremental=true\"]", queries)
+	}
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if len(auditor.securityAlerts) == 0 || auditor.securityAlerts[0].Type != "chain_tampering" {
+		t.Fatalf("expected chain_tampering alert, got %+v", auditor.securityAlerts)
+	}
+	if got := auditor.securityAlerts[0].Details["broken_segment"]; got != "session:s1" {
+		t.Errorf("broken_segment detail = %v, want session:s1", got)
+	}
+}
+
+// alertRecorder is a Notifier that keeps every alert it is sent.
+type alertRecorder struct{ alerts []Alert }
+
+func (r *alertRecorder) Name() string           { return "recorder" }
+func (r *alertRecorder) Send(alert Alert) error { r.alerts = append(r.alerts, alert); return nil }
+
+// TestCheckReasoningQuality_AlertsOnDrop verifies that a sustained drop in an
+// agent's reasoning scores raises one warning, and that it re-arms after the
+// scores recover.
+func TestCheckReasoningQuality_AlertsOnDrop(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{ReasoningWindow: 3, ReasoningDrop: 0.2}, []Notifier{rec}, nil)
+
+	feed := func(scores ...float64) {
+		for _, s := range scores {
+			auditor.Analyze(&audit.Event{
+				EventID:   "evt_rq",
+				Timestamp: time.Now().UTC(),
+				EventType: audit.EventTypeDelegation,
+				Session:   audit.Session{ID: "sess_rq"},
+				Decision: &audit.Decision{
+					Agent:            "postgres_database_agent",
+					Confidence:       0.9,
+					ReasoningChain:   []string{"step"},
+					ReasoningQuality: &audit.ReasoningQuality{Score: s},
+				},
+			})
+		}
+	}
+	drops := func() int {
+		n := 0
+		for _, a := range rec.alerts {
+			if a.Message == "reasoning quality dropped" {
+				n++
+			}
+		}
+		return n
+	}
+
+	feed(0.9, 0.85, 0.9, 0.9, 0.85, 0.9) // baseline 3 scores, window 3 scores
+	if n := drops(); n != 0 {
+		t.Fatalf("steady scores raised %d alerts", n)
+	}
+	feed(0.4, 0.4, 0.4, 0.4)
+	if n := drops(); n != 1 {
+		t.Fatalf("after drop: %d alerts, want exactly 1", n)
+	}
+	// Recovery re-arms the rule; a second drop alerts again.
+	feed(0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9)
+	feed(0.1, 0.1, 0.1)
+	if n := drops(); n != 2 {
+		t.Errorf("after recovery and second drop: %d alerts, want 2", n)
+	}
+}
+
+// TestCheckOverconfidence_AlertsOnce verifies that an overconfident agent
+// raises one warning per episode and that thin or calibrated agents do not.
+func TestCheckOverconfidence_AlertsOnce(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{CalibrationMinDecisions: 20, OverconfidenceGap: 0.15}, []Notifier{rec}, nil)
+
+	report := func(k8sRate float64) *audit.ConfidenceCalibration {
+		return &audit.ConfidenceCalibration{Agents: []audit.AgentCalibration{
+			{Agent: "k8s_agent", Decisions: 40, MeanConfidence: 0.92, SuccessRate: k8sRate, Overconfidence: 0.92 - k8sRate},
+			{Agent: "research_agent", Decisions: 5, MeanConfidence: 0.9, SuccessRate: 0.2, Overconfidence: 0.7},
+			{Agent: "postgres_database_agent", Decisions: 50, MeanConfidence: 0.85, SuccessRate: 0.8, Overconfidence: 0.05},
+		}}
+	}
+
+	auditor.checkOverconfidence(report(0.6))
+	auditor.checkOverconfidence(report(0.6))
+	if len(rec.alerts) != 1 || rec.alerts[0].Agent != "k8s_agent" {
+		t.Fatalf("alerts = %+v, want one for k8s_agent", rec.alerts)
+	}
+
+	auditor.checkOverconfidence(report(0.9)) // recovered: re-arms
+	auditor.checkOverconfidence(report(0.5))
+	if len(rec.alerts) != 2 {
+		t.Errorf("alerts after recovery and relapse = %d, want 2", len(rec.alerts))
+	}
+}
+
+// TestCheckLowResolution_AlertsOnce verifies that an agent whose users report
+// most delegations as unresolved raises one warning per episode, and that
+// agents with too little feedback do not.
+func TestCheckLowResolution_AlertsOnce(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{ResolutionMinFeedback: 10, MinResolutionRate: 0.5}, []Notifier{rec}, nil)
+
+	stats := func(k8sResolved int) []audit.AgentFeedbackStats {
+		return []audit.AgentFeedbackStats{
+			{Agent: "k8s_agent", Feedback: 20, Resolved: k8sResolved, NotResolved: 20 - k8sResolved, ResolutionRate: float64(k8sResolved) / 20},
+			{Agent: "research_agent", Feedback: 3, NotResolved: 3},
+			{Agent: "postgres_database_agent", Feedback: 30, Resolved: 27, NotResolved: 3, ResolutionRate: 0.9},
+		}
+	}
+
+	auditor.checkLowResolution(stats(6))
+	auditor.checkLowResolution(stats(6))
+	if len(rec.alerts) != 1 || rec.alerts[0].Agent != "k8s_agent" {
+		t.Fatalf("alerts = %+v, want one for k8s_agent", rec.alerts)
+	}
+
+	auditor.checkLowResolution(stats(15)) // recovered: re-arms
+	auditor.checkLowResolution(stats(4))
+	if len(rec.alerts) != 2 {
+		t.Errorf("alerts after recovery and relapse = %d, want 2", len(rec.alerts))
+	}
+}
+
+func TestSyslogBackend(t *testing.T) {
+	tests := []struct {
+		requested, goos, addr string
+		journald              bool
+		want                  string
+	}{
+		{"auto", "windows", "", false, "eventlog"},
+		{"auto", "linux", "", true, "journald"},
+		{"auto", "linux", "", false, "syslog"},
+		{"auto", "linux", "logs:514", true, "syslog"}, // remote syslog wins
+		{"auto", "darwin", "", false, "syslog"},
+		{"", "windows", "", false, "eventlog"},
+		{"syslog", "linux", "", true, "syslog"},
+	}
+	for _, tt := range tests {
+		if got := syslogBackend(tt.requested, tt.goos, "", tt.addr, tt.journald); got != tt.want {
+			t.Errorf("syslogBackend(%q, %q, addr=%q, journald=%v) = %q, want %q",
+				tt.requested, tt.goos, tt.addr, tt.journald, got, tt.want)
+		}
+	}
+	if _, err := newSystemLogNotifier("bogus", Config{}); err == nil {
+		t.Error("unknown backend should fail")
+	}
+}
+
+func TestJournaldNotifier_Send(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on Windows")
+	}
+	sock := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	n, err := NewJournaldNotifier(sock, "helpdesk-auditor")
+	if err != nil {
+		t.Fatalf("NewJournaldNotifier: %v", err)
+	}
+	err = n.Send(Alert{
+		Level:     AlertCritical,
+		Message:   "hash mismatch\nchain broken",
+		EventID:   "evt_1",
+		Agent:     "k8s_agent",
+		Timestamp: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+	nr, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	got := string(buf[:nr])
+	for _, want := range []string{"PRIORITY=2\n", "SYSLOG_IDENTIFIER=helpdesk-auditor\n", "HELPDESK_EVENT_ID=evt_1\n", "HELPDESK_ALERT_LEVEL=CRITICAL\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("datagram missing %q:\n%q", want, got)
+		}
+	}
+	// The multi-line message uses the binary length-prefixed form.
+	if !strings.HasPrefix(got, "MESSAGE\n") {
+		t.Errorf("datagram should start with a length-prefixed MESSAGE field: %q", got)
+	}
+	if strings.Contains(got, "HELPDESK_USER_ID") {
+		t.Error("empty fields should be omitted")
+	}
+
+	if _, err := NewJournaldNotifier(filepath.Join(t.TempDir(), "missing.sock"), "x"); err == nil {
+		t.Error("a missing socket should fail at construction")
+	}
+}
+
+// TestCheckHeartbeat verifies that a silent event stream and a quiet agent
+// each alert once per episode, and that the heartbeat URL is pinged with the
+// window's outcome.
+func TestCheckHeartbeat(t *testing.T) {
+	var pings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings = append(pings, r.Method+" "+r.URL.Path)
+	}))
+	defer srv.Close()
+
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{
+		HeartbeatWindow:    time.Minute,
+		HeartbeatMinEvents: 2,
+		HeartbeatAgentMin:  map[string]int{"k8s_agent": 1},
+		HeartbeatURL:       srv.URL + "/ping/abc",
+	}, []Notifier{rec}, nil)
+
+	feed := func(agents ...string) {
+		for _, agent := range agents {
+			auditor.Analyze(&audit.Event{
+				EventID:   "evt_hb",
+				Timestamp: time.Now().UTC(),
+				EventType: audit.EventTypeToolExecution,
+				Session:   audit.Session{ID: "sess_hb", AgentName: agent},
+			})
+		}
+	}
+
+	feed("k8s_agent", "postgres_database_agent")
+	auditor.checkHeartbeat()
+	if len(rec.alerts) != 0 {
+		t.Fatalf("healthy window raised %+v", rec.alerts)
+	}
+
+	auditor.checkHeartbeat() // nothing arrived
+	auditor.checkHeartbeat() // still silent: no repeat
+	if len(rec.alerts) != 2 || rec.alerts[0].Level != AlertCritical || rec.alerts[1].Agent != "k8s_agent" {
+		t.Fatalf("silent windows: alerts = %+v, want one critical stream alert and one agent warning", rec.alerts)
+	}
+
+	feed("postgres_database_agent", "postgres_database_agent") // stream recovers, k8s_agent still quiet
+	auditor.checkHeartbeat()
+	feed("k8s_agent", "k8s_agent") // everything recovers
+	auditor.checkHeartbeat()
+	auditor.checkHeartbeat() // silent again: alerts re-armed
+	if len(rec.alerts) != 4 {
+		t.Errorf("after recovery and relapse: %d alerts, want 4", len(rec.alerts))
+	}
+
+	want := []string{"GET /ping/abc", "POST /ping/abc/fail", "POST /ping/abc/fail", "POST /ping/abc/fail", "GET /ping/abc", "POST /ping/abc/fail"}
+	if strings.Join(pings, "|") != strings.Join(want, "|") {
+		t.Errorf("pings = %v, want %v", pings, want)
+	}
+}
+
+// TestCanaryEventsDoNotFeedHeartbeat verifies that auditd's canary events
+// cannot keep a silent event stream looking alive.
+func TestCanaryEventsDoNotFeedHeartbeat(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{
+		HeartbeatWindow:    time.Minute,
+		HeartbeatMinEvents: 1,
+	}, []Notifier{rec}, nil)
+
+	auditor.Analyze(&audit.Event{
+		EventID:   "cny_1",
+		Timestamp: time.Now().UTC(),
+		EventType: audit.EventTypeCanary,
+		Session:   audit.Session{ID: "auditd-canary"},
+	})
+	auditor.checkHeartbeat()
+	if len(rec.alerts) != 1 || rec.alerts[0].Level != AlertCritical {
+		t.Errorf("alerts = %+v, want the stream reported silent despite the canary", rec.alerts)
+	}
+}
+
+// TestCheckClockSkew_ClockProblemIsNotTampering verifies that an agent whose
+// clock runs behind raises one clock_skew warning, and that its events looking
+// out of order do not raise timestamp_anomaly while auditd received them in
+// order. Events whose receive order is also inverted still do.
+func TestCheckClockSkew_ClockProblemIsNotTampering(t *testing.T) {
+	auditor := NewAuditor(Config{ClockSkewThreshold: 30 * time.Second}, nil, nil)
+	now := time.Now().UTC()
+	feed := func(id, agent string, sent, received time.Time) {
+		auditor.Analyze(&audit.Event{
+			EventID:    id,
+			Timestamp:  sent,
+			ReceivedAt: &received,
+			EventType:  audit.EventTypeToolExecution,
+			Session:    audit.Session{ID: "s", AgentName: agent},
+		})
+	}
+	alertTypes := func() []string {
+		var types []string
+		for _, a := range auditor.securityAlerts {
+			types = append(types, a.Type)
+		}
+		return types
+	}
+
+	feed("evt_1", "k8s_agent", now, now)
+	feed("evt_2", "db_agent", now.Add(-2*time.Minute), now.Add(time.Second))
+	feed("evt_3", "db_agent", now.Add(-2*time.Minute+2*time.Second), now.Add(3*time.Second))
+	if got := alertTypes(); len(got) != 1 || got[0] != "clock_skew" {
+		t.Fatalf("alerts = %v, want a single clock_skew", got)
+	}
+	if a := auditor.securityAlerts[0]; a.Agent != "db_agent" {
+		t.Errorf("clock_skew agent = %q, want db_agent", a.Agent)
+	}
+
+	// Received a minute before the previous event: the order itself changed.
+	feed("evt_4", "k8s_agent", now.Add(-3*time.Minute), now.Add(-time.Minute))
+	if got := alertTypes(); len(got) != 3 || got[2] != "timestamp_anomaly" {
+		t.Errorf("alerts = %v, want clock_skew, then clock_skew and timestamp_anomaly for evt_4", got)
+	}
+}
+
+// TestCheckOutcomeTimeout verifies that an outcome_timeout event raises one
+// warning, is not taken for a slow call, and does not count as the agent's
+// heartbeat.
+func TestCheckOutcomeTimeout(t *testing.T) {
+	rec := &alertRecorder{}
+	auditor := NewAuditor(Config{
+		HeartbeatWindow:   time.Minute,
+		HeartbeatAgentMin: map[string]int{"k8s_agent": 1},
+	}, []Notifier{rec}, nil)
+
+	auditor.Analyze(&audit.Event{
+		EventID:   "orp_1",
+		Timestamp: time.Now().UTC(),
+		EventType: audit.EventTypeOutcomeTimeout,
+		ParentID:  "evt_crashed",
+		Session:   audit.Session{ID: "s", AgentName: "k8s_agent"},
+		Outcome:   &audit.Outcome{Status: audit.OutcomeTimedOut, Duration: 2 * time.Hour},
+	})
+	if len(rec.alerts) != 1 || rec.alerts[0].Level != AlertWarning {
+		t.Fatalf("alerts = %+v, want one warning", rec.alerts)
+	}
+	if got := auditor.securityAlerts[0]; got.Type != "outcome_timeout" || got.Agent != "k8s_agent" || got.Details["delegation_event_id"] != "evt_crashed" {
+		t.Errorf("security alert = %+v", got)
+	}
+
+	auditor.checkHeartbeat()
+	if len(rec.alerts) != 2 {
+		t.Errorf("alerts after heartbeat = %d, want k8s_agent reported silent", len(rec.alerts))
+	}
+}
+
+func TestParseAgentMinimums(t *testing.T) {
+	got, err := parseAgentMinimums(" postgres_database_agent=5, k8s_agent=1 ,")
+	if err != nil || len(got) != 2 || got["postgres_database_agent"] != 5 || got["k8s_agent"] != 1 {
+		t.Errorf("parseAgentMinimums = %v, %v", got, err)
+	}
+	for _, bad := range []string{"k8s_agent", "=3", "k8s_agent=0", "k8s_agent=x"} {
+		if _, err := parseAgentMinimums(bad); err == nil {
+			t.Errorf("parseAgentMinimums(%q): want error", bad)
+		}
+	}
+}
+
+// TestSMSNotifier_CriticalOnly verifies that only CRITICAL alerts are texted,
+// to every recipient.
+func TestSMSNotifier_CriticalOnly(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		sent = append(sent, r.PostForm.Get("To")+": "+r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	n := &SMSNotifier{
+		Client: &sms.Client{AccountSID: "AC1", AuthToken: "tok", From: "+15550100000", APIURL: srv.URL},
+		To:     []string{"+15550100001", "+15550100002"},
+	}
+	if err := n.Send(Alert{Level: AlertWarning, Message: "off hours"}); err != nil || len(sent) != 0 {
+		t.Fatalf("warning alert: err %v, sent %v", err, sent)
+	}
+	if err := n.Send(Alert{Level: AlertCritical, Message: "HASH MISMATCH", EventID: "tool_1", Agent: "k8s_agent"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(sent) != 2 || !strings.Contains(sent[0], "HASH MISMATCH (event tool_1, agent k8s_agent)") {
+		t.Errorf("sent = %v", sent)
+	}
+}
+
+// TestSMSNotifier_Locales verifies that security alerts are texted in each
+// recipient's locale, and that alerts without a translation stay English.
+func TestSMSNotifier_Locales(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		sent = append(sent, r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	recipients, err := i18n.ParseRecipients("+49*=de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &SMSNotifier{
+		Client:           &sms.Client{AccountSID: "AC1", AuthToken: "tok", From: "+15550100000", APIURL: srv.URL},
+		To:               []string{"+4915100000001", "+15550100001"},
+		RecipientLocales: recipients,
+	}
+	alert := Alert{
+		Type:    "high_volume",
+		Level:   AlertCritical,
+		Message: "High volume activity detected - possible attack or data exfiltration",
+		EventID: "tool_1",
+		Details: map[string]any{"events_per_minute": 120, "threshold": 100},
+	}
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(sent) != 2 ||
+		sent[0] != "[helpdesk CRITICAL] Ungewöhnlich hohe Aktivität - möglicher Angriff oder Datenabfluss (120 Ereignisse/Minute, Schwelle 100) (Ereignis tool_1)" ||
+		sent[1] != "[helpdesk CRITICAL] High volume activity detected - possible attack or data exfiltration (event tool_1)" {
+		t.Errorf("sent = %q", sent)
+	}
+
+	alert.Type = "custom:billing"
+	if got := alert.localMessage(nil, "de"); got != alert.Message {
+		t.Errorf("untranslated rule message = %q", got)
+	}
+	alert.Type, alert.Details = "prompt_injection", map[string]any{"score": 0.8734, "sources": "user_query"}
+	if got := alert.localMessage(nil, "es"); got != "SOSPECHA DE INYECCIÓN DE PROMPT (riesgo 0.87) en user_query" {
+		t.Errorf("Spanish prompt injection message = %q", got)
+	}
+}
+
+// TestBuildNotifiers_Plugin verifies that -notify-plugin adds a notifier that
+// feeds each alert to the plugin as JSON.
+func TestBuildNotifiers_Plugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "alert.json")
+	plugin := filepath.Join(dir, "to-ticketing")
+	os.WriteFile(plugin, []byte("#!/bin/sh\ncat > "+out+"\n"), 0755) //nolint:errcheck
+
+	notifiers := buildNotifiers(Config{NotifyPlugins: plugin})
+	if len(notifiers) != 1 || notifiers[0].Name() != "plugin:to-ticketing" {
+		t.Fatalf("notifiers = %v", notifiers)
+	}
+	if err := notifiers[0].Send(Alert{Level: AlertCritical, Message: "HASH MISMATCH", EventID: "tool_1"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil || got["level"] != "CRITICAL" || got["event_id"] != "tool_1" {
+		t.Errorf("plugin stdin = %s (%v)", data, err)
+	}
+}
+
+// TestCheckParamProfile verifies that, once a tool has been learned, a
+// namespace it has never touched alerts once (critical for destructive
+// calls) and a row count far outside its range is flagged as an outlier.
+func TestCheckParamProfile(t *testing.T) {
+	auditor := NewAuditor(Config{ProfileMinCalls: 5, ProfileOutlierZ: 4}, nil, nil)
+
+	call := func(ns string, class audit.ActionClass, rows int) {
+		auditor.Analyze(&audit.Event{
+			EventID:     "evt_pp",
+			Timestamp:   time.Now().UTC(),
+			EventType:   audit.EventTypeToolExecution,
+			ActionClass: class,
+			Session:     audit.Session{ID: "sess_pp"},
+			Tool: &audit.ToolExecution{
+				Name:         "delete_pod",
+				Agent:        "k8s_agent",
+				Parameters:   map[string]any{"namespace": ns, "grace_seconds": 30.0},
+				RowsAffected: rows,
+			},
+		})
+	}
+	alertsOf := func(typ string) []SecurityAlert {
+		auditor.mu.Lock()
+		defer auditor.mu.Unlock()
+		var out []SecurityAlert
+		for _, a := range auditor.securityAlerts {
+			if a.Type == typ {
+				out = append(out, a)
+			}
+		}
+		return out
+	}
+
+	// Learning period: new values are learned silently.
+	for i := 0; i < 5; i++ {
+		call([]string{"web", "api"}[i%2], audit.ActionRead, 10+i)
+	}
+	if n := len(alertsOf("param_first_seen")) + len(alertsOf("param_outlier")); n != 0 {
+		t.Fatalf("%d alerts during the learning period, want 0", n)
+	}
+
+	call("api", audit.ActionRead, 12)
+	call("kube-system", audit.ActionDestructive, 11)
+	call("kube-system", audit.ActionDestructive, 11) // already reported
+	first := alertsOf("param_first_seen")
+	if len(first) != 1 || first[0].Severity != string(AlertCritical) || first[0].Details["value"] != "kube-system" {
+		t.Fatalf("param_first_seen alerts = %+v, want one critical for kube-system", first)
+	}
+
+	call("web", audit.ActionRead, 5000)
+	out := alertsOf("param_outlier")
+	if len(out) != 1 || out[0].Details["param"] != "rows_affected" {
+		t.Errorf("param_outlier alerts = %+v, want one for rows_affected", out)
+	}
+}
+
+// TestParamProfiler_PersistsAcrossRestart verifies that a saved profile is
+// reloaded, so a restarted auditor does not relearn from scratch.
+func TestParamProfiler_PersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	p := newParamProfiler(2, 4, path)
+	p.observe("postgres_database_agent/get_locks", map[string]any{"database": "orders"}, 0)
+	p.observe("postgres_database_agent/get_locks", map[string]any{"database": "orders"}, 0)
+	if err := p.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	restarted := newParamProfiler(2, 4, path)
+	if f := restarted.observe("postgres_database_agent/get_locks", map[string]any{"database": "orders"}, 0); len(f) != 0 {
+		t.Errorf("known value after restart: findings = %+v, want none", f)
+	}
+	f := restarted.observe("postgres_database_agent/get_locks", map[string]any{"database": "billing"}, 0)
+	if len(f) != 1 || f[0].kind != "first_seen" || f[0].param != "database" {
+		t.Errorf("new value after restart: findings = %+v, want one first_seen", f)
+	}
+}
+
+// TestCheckPromptInjection verifies that injection found in tool output is
+// critical, in a user query only a warning, and that scores below the
+// threshold do not alert.
+func TestCheckPromptInjection(t *testing.T) {
+	auditor := NewAuditor(Config{InjectionThreshold: 0.5}, nil, nil)
+
+	analyze := func(id string, risk *audit.InjectionRisk) {
+		auditor.Analyze(&audit.Event{
+			EventID:       id,
+			Timestamp:     time.Now().UTC(),
+			EventType:     audit.EventTypeToolExecution,
+			Session:       audit.Session{ID: "sess_pi", AgentName: "k8s_agent"},
+			Tool:          &audit.ToolExecution{Name: "get_pod_logs"},
+			InjectionRisk: risk,
+		})
+	}
+	analyze("evt_low", &audit.InjectionRisk{Score: 0.3, Markers: []string{audit.InjectionMarkerBase64}, Sources: []string{"tool_output"}})
+	analyze("evt_output", &audit.InjectionRisk{Score: 0.7, Markers: []string{audit.InjectionMarkerOverride}, Sources: []string{"tool_output"}})
+	analyze("evt_query", &audit.InjectionRisk{Score: 0.7, Markers: []string{audit.InjectionMarkerOverride}, Sources: []string{"user_query"}})
+
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	var got []SecurityAlert
+	for _, a := range auditor.securityAlerts {
+		if a.Type == "prompt_injection" {
+			got = append(got, a)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d prompt_injection alerts, want 2: %+v", len(got), got)
+	}
+	if got[0].EventID != "evt_output" || got[0].Severity != string(AlertCritical) {
+		t.Errorf("tool output alert = %+v, want critical", got[0])
+	}
+	if got[1].EventID != "evt_query" || got[1].Severity != string(AlertWarning) || got[1].Details["tool"] != "get_pod_logs" {
+		t.Errorf("user query alert = %+v, want warning", got[1])
+	}
+}
+
+func TestCheckCapabilityViolation(t *testing.T) {
+	auditor := NewAuditor(Config{}, nil, nil)
+	auditor.Analyze(discovery.ManifestViolationEvent("gateway", "k8s_agent", []string{"tool exec_pod is not in the manifest"}))
+	auditor.Analyze(&audit.Event{
+		EventID:             "gov_other",
+		Timestamp:           time.Now().UTC(),
+		EventType:           audit.EventTypeGovernanceViolation,
+		GovernanceViolation: &audit.GovernanceViolation{Module: "audit", Severity: "fatal"},
+	})
+
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if len(auditor.securityAlerts) != 1 {
+		t.Fatalf("got %d security alerts, want 1: %+v", len(auditor.securityAlerts), auditor.securityAlerts)
+	}
+	got := auditor.securityAlerts[0]
+	if got.Type != "capability_violation" || got.Severity != string(AlertCritical) || !strings.Contains(got.Details["description"].(string), "exec_pod") {
+		t.Errorf("alert = %+v", got)
+	}
+}
+
+// TestAlertFeedback verifies that alerts matching operators' false positives
+// are down-weighted, then suppressed once enough accumulate, and that every
+// alert, suppressed or not, is reported to the audit service.
+func TestAlertFeedback(t *testing.T) {
+	var mu sync.Mutex
+	count := 1
+	reported := map[string]bool{} // alert ID -> suppressed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/alerts/suppressions":
+			json.NewEncoder(w).Encode(map[string]any{"suppressions": []audit.AlertSuppression{ //nolint:errcheck
+				{Rule: "prompt_injection", Agent: "k8s_agent", ResourcePattern: "staging-*", Count: count},
+			}})
+		case "/v1/alerts":
+			var rec audit.AlertRecord
+			json.NewDecoder(r.Body).Decode(&rec) //nolint:errcheck
+			reported[rec.AlertID] = rec.Suppressed
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	auditor := NewAuditor(Config{InjectionThreshold: 0.5, AuditServiceURL: srv.URL, FPSuppressAfter: 2}, nil, nil)
+	analyze := func(id, namespace string) {
+		auditor.Analyze(&audit.Event{
+			EventID:       id,
+			Timestamp:     time.Now().UTC(),
+			EventType:     audit.EventTypeToolExecution,
+			Session:       audit.Session{ID: "sess_fp", AgentName: "k8s_agent"},
+			Tool:          &audit.ToolExecution{Name: "get_pod_logs", Parameters: map[string]any{"namespace": namespace}},
+			InjectionRisk: &audit.InjectionRisk{Score: 0.7, Markers: []string{audit.InjectionMarkerOverride}, Sources: []string{"tool_output"}},
+		})
+	}
+
+	auditor.syncAlertFeedback(srv.URL)
+	analyze("evt_staging", "staging-1")
+	analyze("evt_prod", "prod")
+
+	mu.Lock()
+	count = 2
+	mu.Unlock()
+	auditor.syncAlertFeedback(srv.URL)
+	analyze("evt_suppressed", "staging-2")
+
+	auditor.mu.Lock()
+	alerts := append([]SecurityAlert(nil), auditor.securityAlerts...)
+	auditor.mu.Unlock()
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2 (third suppressed): %+v", len(alerts), alerts)
+	}
+	if alerts[0].EventID != "evt_staging" || alerts[0].Severity != string(AlertWarning) || alerts[0].Resource != "staging-1" {
+		t.Errorf("staging alert = %+v, want down-weighted to warning", alerts[0])
+	}
+	if alerts[1].EventID != "evt_prod" || alerts[1].Severity != string(AlertCritical) {
+		t.Errorf("prod alert = %+v, want critical", alerts[1])
+	}
+
+	// Reports are sent asynchronously.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(reported)
+		mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	suppressed := 0
+	for _, s := range reported {
+		if s {
+			suppressed++
+		}
+	}
+	if len(reported) != 3 || suppressed != 1 {
+		t.Errorf("reported = %v, want 3 alerts with 1 suppressed", reported)
+	}
+}
+
+// TestMaintenanceWindows verifies that alerts raised inside a synced
+// maintenance window are lowered one level and tagged with it, except for
+// integrity rules and resources the window does not cover.
+func TestMaintenanceWindows(t *testing.T) {
+	now := time.Now().UTC()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/maintenance-windows" || r.URL.Query().Get("active") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"windows": []audit.MaintenanceWindow{ //nolint:errcheck
+			{WindowID: "mw_1", Owner: "alice", Reason: "CHG-42", Resources: []string{"prod-*"}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		}})
+	}))
+	defer srv.Close()
+
+	auditor := NewAuditor(Config{}, nil, nil)
+	auditor.syncMaintenanceWindows(srv.URL)
+	event := func(id, namespace string) *audit.Event {
+		return &audit.Event{
+			EventID:   id,
+			Timestamp: now,
+			EventType: audit.EventTypeToolExecution,
+			Session:   audit.Session{ID: "sess_mw", AgentName: "k8s_agent"},
+			Tool:      &audit.ToolExecution{Name: "delete_pod", Parameters: map[string]any{"namespace": namespace}},
+		}
+	}
+	auditor.recordSecurityAlert("high_volume", AlertCritical, "volume", event("evt_prod", "prod-1"))
+	auditor.recordSecurityAlert("high_volume", AlertCritical, "volume", event("evt_staging", "staging"))
+	auditor.recordSecurityAlert("chain_tampering", AlertCritical, "tampering", event("evt_chain", "prod-1"))
+
+	auditor.mu.Lock()
+	alerts := append([]SecurityAlert(nil), auditor.securityAlerts...)
+	auditor.mu.Unlock()
+	if len(alerts) != 3 {
+		t.Fatalf("got %d alerts, want 3", len(alerts))
+	}
+	if a := alerts[0]; a.Severity != string(AlertWarning) || a.MaintenanceWindow != "mw_1" || a.Details["maintenance_window"] != "mw_1" {
+		t.Errorf("alert in window = %+v, want warning tagged mw_1", a)
+	}
+	if a := alerts[1]; a.Severity != string(AlertCritical) || a.MaintenanceWindow != "" {
+		t.Errorf("alert outside window's resources = %+v, want critical untagged", a)
+	}
+	if a := alerts[2]; a.Severity != string(AlertCritical) || a.MaintenanceWindow != "" {
+		t.Errorf("integrity alert in window = %+v, want critical untagged", a)
+	}
+}
+
+// TestCheckOffHoursCalendar verifies that users are judged by their own
+// team's hours and users in no team by every team's.
+func TestCheckOffHoursCalendar(t *testing.T) {
+	cal, err := workhours.Load([]byte(`
+teams:
+  dba-us:
+    timezone: America/New_York
+    hours: {mon-fri: "08-18"}
+    users: [alice]
+  dba-apac:
+    timezone: Asia/Kolkata
+    hours: {mon-fri: "09-18"}
+    users: [ravi]
+`))
+	if err != nil {
+		t.Fatalf("workhours.Load: %v", err)
+	}
+	auditor := NewAuditor(Config{WorkHours: cal}, nil, nil)
+	apacMorning := time.Date(2026, 7, 1, 4, 0, 0, 0, time.UTC) // Wed 09:30 IST, 00:00 EDT
+	for _, user := range []string{"ravi", "bob", "alice"} {
+		auditor.checkOffHours(&audit.Event{
+			EventID:   "evt_" + user,
+			Timestamp: apacMorning,
+			Session:   audit.Session{ID: "sess_" + user, UserID: user},
+		})
+	}
+
+	auditor.mu.Lock()
+	alerts := append([]SecurityAlert(nil), auditor.securityAlerts...)
+	auditor.mu.Unlock()
+	if len(alerts) != 1 || alerts[0].EventID != "evt_alice" || alerts[0].Details["teams"] != "dba-us" {
+		t.Errorf("alerts = %+v, want one off_hours alert for alice judged by dba-us", alerts)
+	}
+}
+
+// TestWatchSocket_ReconnectsAfterAuditdRestart verifies that the auditor
+// survives auditd closing the socket on shutdown: it reconnects and the
+// socket replays what was recorded while it was away.
+func TestWatchSocket_ReconnectsAfterAuditdRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	socketPath := fmt.Sprintf("/tmp/auditor_test_%d.sock", time.Now().UnixNano()%1e9)
+	t.Cleanup(func() { os.Remove(socketPath) })
+	openStore := func() *audit.Store {
+		store, err := audit.NewStore(audit.StoreConfig{DBPath: dbPath, SocketPath: socketPath})
+		if err != nil {
+			t.Fatalf("NewStore: %v", err)
+		}
+		return store
+	}
+	record := func(store *audit.Store, id string) {
+		if err := store.Record(context.Background(), &audit.Event{EventID: id, EventType: audit.EventTypeToolExecution, Session: audit.Session{ID: "s"}}); err != nil {
+			t.Fatalf("Record %s: %v", id, err)
+		}
+	}
+	waitForSeq := func(sock *audit.SocketClient, seq int64) {
+		deadline := time.Now().Add(10 * time.Second)
+		for sock.Seq() < seq {
+			if time.Now().After(deadline) {
+				t.Fatalf("auditor reached seq %d, want %d", sock.Seq(), seq)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	store := openStore()
+	// Start from a cursor of 0, so the first event is replayed even if it is
+	// recorded before auditd has read the hello.
+	cursorPath := filepath.Join(t.TempDir(), "cursor")
+	if err := os.WriteFile(cursorPath, []byte("0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sock := &audit.SocketClient{Path: socketPath, CursorPath: cursorPath}
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		if conn, err = sock.Dial(); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+
+	auditor := NewAuditor(Config{}, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		auditor.watchSocket(ctx, sock, conn)
+		close(done)
+	}()
+
+	record(store, "evt_before")
+	waitForSeq(sock, 1)
+
+	// auditd restarts; an event is recorded before the auditor reconnects.
+	store.Close()
+	store = openStore()
+	defer store.Close()
+	record(store, "evt_after")
+	waitForSeq(sock, 2)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchSocket did not return after cancel")
+	}
+	var ids []string
+	for _, e := range auditor.recentEvents {
+		ids = append(ids, e.EventID)
+	}
+	if strings.Join(ids, ",") != "evt_before,evt_after" {
+		t.Errorf("analysed %v, want [evt_before evt_after]", ids)
+	}
+}
+
+// TestCheckDetectHooks_Exec verifies that a long-lived exec hook sees every
+// event and that the alerts it replies with are raised as custom security
+// alerts.
+func TestCheckDetectHooks_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script hook")
+	}
+	hook := filepath.Join(t.TempDir(), "customer-tables")
+	script := `#!/bin/sh
+while read -r event; do
+  case "$event" in
+    *customers_eu*) echo '{"alerts":[{"rule":"customer_table","level":"critical","message":"tool touched customers_eu","details":{"table":"customers_eu"}}]}' ;;
+    *) echo '{}' ;;
+  esac
+done
+`
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	auditor := NewAuditor(Config{DetectHooks: hook, DetectHookTimeout: 5 * time.Second}, nil, nil)
+	defer auditor.shutdown()
+	if len(auditor.hooks) != 1 {
+		t.Fatalf("hooks = %v", auditor.hooks)
+	}
+
+	for i, query := range []string{"SELECT 1", "SELECT * FROM customers_eu"} {
+		auditor.Analyze(&audit.Event{
+			EventID:   fmt.Sprintf("tool_%d", i),
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeToolExecution,
+			Session:   audit.Session{ID: "sess_hook"},
+			Tool:      &audit.ToolExecution{Name: "run_query", Agent: "postgres_database_agent", Parameters: map[string]any{"query": query}},
+		})
+	}
+
+	var custom []SecurityAlert
+	for _, a := range auditor.securityAlerts {
+		if strings.HasPrefix(a.Type, "custom:") {
+			custom = append(custom, a)
+		}
+	}
+	if len(custom) != 1 {
+		t.Fatalf("custom alerts = %+v, want 1", custom)
+	}
+	a := custom[0]
+	if a.Type != "custom:customer_table" || a.Severity != "CRITICAL" || a.EventID != "tool_1" || a.Details["table"] != "customers_eu" || a.Details["hook"] != "customer-tables" {
+		t.Errorf("alert = %+v", a)
+	}
+}
+
+// TestCheckDetectHooks_HTTP verifies the HTTP form of a detect hook, and that
+// a hook that errors raises nothing.
+func TestCheckDetectHooks_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e audit.Event
+		json.NewDecoder(r.Body).Decode(&e) //nolint:errcheck
+		switch e.EventID {
+		case "evt_bad":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case "evt_alert":
+			w.Write([]byte(`{"alerts":[{"rule":"after_change_window","message":"write outside the change window"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	auditor := NewAuditor(Config{DetectHooks: srv.URL, DetectHookTimeout: 5 * time.Second}, nil, nil)
+	for _, id := range []string{"evt_none", "evt_bad", "evt_alert"} {
+		auditor.Analyze(&audit.Event{EventID: id, Timestamp: time.Now().UTC(), Session: audit.Session{ID: "sess_hook"}})
+	}
+
+	var custom []SecurityAlert
+	for _, a := range auditor.securityAlerts {
+		if strings.HasPrefix(a.Type, "custom:") {
+			custom = append(custom, a)
+		}
+	}
+	if len(custom) != 1 || custom[0].Type != "custom:after_change_window" || custom[0].Severity != "WARNING" || custom[0].EventID != "evt_alert" {
+		t.Errorf("custom alerts = %+v", custom)
+	}
+}
+
+// TestExecDetectHook_Timeout verifies that a hook that stops answering is
+// killed and left down rather than blocking every event.
+func TestExecDetectHook_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script hook")
+	}
+	hook := filepath.Join(t.TempDir(), "stuck")
+	os.WriteFile(hook, []byte("#!/bin/sh\nexec sleep 30\n"), 0755) //nolint:errcheck
+	hooks, err := parseDetectHooks(hook, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hooks[0].Close()
+
+	if _, err := hooks[0].Detect([]byte(`{}`)); err == nil || !strings.Contains(err.Error(), "no reply within 100ms") {
+		t.Errorf("stuck hook: %v", err)
+	}
+	start := time.Now()
+	if _, err := hooks[0].Detect([]byte(`{}`)); err == nil || !strings.Contains(err.Error(), "hook is down") {
+		t.Errorf("hook after timeout: %v", err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("down hook was waited on")
+	}
+
+	if _, err := parseDetectHooks("/no/such/hook", time.Second); err == nil {
+		t.Error("missing hook accepted")
+	}
+}
+
+func TestSyncRuntimeConfig(t *testing.T) {
+	settings := []map[string]string{
+		{"key": "auditor.max_events_per_minute", "value": "2", "source": "runtime"},
+		{"key": "auditor.injection_threshold", "value": "", "source": "startup"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/config" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"settings": settings}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	auditor := NewAuditor(Config{MaxEventsPerMinute: 100, InjectionThreshold: 0.5}, nil, nil)
+	auditor.syncRuntimeConfig(srv.URL)
+	if got := auditor.limits(); got.maxEventsPerMinute != 2 || got.injection != 0.5 {
+		t.Fatalf("limits = %+v, want the override and the flag value", got)
+	}
+	for i := range 3 {
+		auditor.checkHighVolume(&audit.Event{EventID: fmt.Sprintf("evt_%d", i)})
+	}
+	auditor.mu.Lock()
+	n := len(auditor.securityAlerts)
+	auditor.mu.Unlock()
+	if n != 1 {
+		t.Errorf("alerts = %d, want 1 high_volume alert at the runtime threshold", n)
+	}
+
+	// Removing the override restores the flag value.
+	settings = settings[1:]
+	auditor.syncRuntimeConfig(srv.URL)
+	if got := auditor.limits().maxEventsPerMinute; got != 100 {
+		t.Errorf("maxEventsPerMinute = %d after reset, want 100", got)
+	}
+}
+
+func TestWebhookNotifier_RoutesCriticalAlertsToOwningTeam(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]string{} // webhook -> alert messages
+	webhook := func(name string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			json.NewDecoder(r.Body).Decode(&payload) //nolint:errcheck
+			mu.Lock()
+			received[name] = append(received[name], payload["message"].(string))
+			mu.Unlock()
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+
+	n := &WebhookNotifier{URL: webhook("global"), TeamURLs: map[string]string{"payments": webhook("payments")}}
+	for _, alert := range []Alert{
+		{Level: AlertCritical, Team: "payments", Message: "owned critical"},
+		{Level: AlertWarning, Team: "payments", Message: "owned warning"},
+		{Level: AlertCritical, Team: "platform", Message: "team without webhook"},
+		{Level: AlertCritical, Message: "unowned critical"},
+	} {
+		if err := n.Send(alert); err != nil {
+			t.Fatalf("Send(%s): %v", alert.Message, err)
+		}
+	}
+	want := map[string][]string{
+		"payments": {"owned critical"},
+		"global":   {"owned warning", "team without webhook", "unowned critical"},
+	}
+	if fmt.Sprint(received) != fmt.Sprint(want) {
+		t.Errorf("received = %v, want %v", received, want)
+	}
+
+	// Without a global webhook, only team alerts are sent.
+	received = map[string][]string{}
+	n.URL = ""
+	n.Send(Alert{Level: AlertCritical, Message: "unowned critical"})                 //nolint:errcheck
+	n.Send(Alert{Level: AlertCritical, Team: "payments", Message: "owned critical"}) //nolint:errcheck
+	if fmt.Sprint(received) != fmt.Sprint(map[string][]string{"payments": {"owned critical"}}) {
+		t.Errorf("received = %v without a global webhook", received)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// checkResolutionFromService fetches per-agent delegation feedback from
+// /v1/events/feedback/stats over the calibration window and evaluates it.
+func (a *Auditor) checkResolutionFromService(auditServiceURL string) {
+	if a.cfg.MinResolutionRate <= 0 {
+		return
+	}
+	u := strings.TrimSuffix(auditServiceURL, "/") + "/v1/events/feedback/stats"
+	if a.cfg.CalibrationWindow > 0 {
+		u += "?since=" + url.QueryEscape(time.Now().Add(-a.cfg.CalibrationWindow).UTC().Format(time.RFC3339))
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		slog.Error("failed to build delegation feedback request", "err", err)
+		return
+	}
+	if a.cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.AuditAPIKey)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		slog.Error("failed to fetch delegation feedback", "url", u, "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("delegation feedback request failed", "status", resp.StatusCode)
+		return
+	}
+	var out struct {
+		Agents []audit.AgentFeedbackStats `json:"agents"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		slog.Error("failed to parse delegation feedback", "err", err)
+		return
+	}
+	a.checkLowResolution(out.Agents)
+}
+
+// checkLowResolution alerts when users report that an agent's delegations
+// often did not solve their problem, whatever the agent itself claimed.
+// Each agent alerts once and re-arms when its resolution rate recovers.
+func (a *Auditor) checkLowResolution(stats []audit.AgentFeedbackStats) {
+	for _, st := range stats {
+		if !st.IsUnderperforming(a.cfg.ResolutionMinFeedback, a.cfg.MinResolutionRate) {
+			delete(a.lowResolution, st.Agent)
+			continue
+		}
+		if a.lowResolution[st.Agent] {
+			continue
+		}
+		a.lowResolution[st.Agent] = true
+
+		syntheticEvent := &audit.Event{
+			EventID:   fmt.Sprintf("feedback_%d", time.Now().Unix()),
+			Timestamp: time.Now(),
+			EventType: "feedback_check",
+			Decision:  &audit.Decision{Agent: st.Agent},
+		}
+		a.alert(AlertWarning, "agent delegations often do not resolve the user's problem", syntheticEvent,
+			"agent", st.Agent,
+			"feedback", st.Feedback,
+			"not_resolved", st.NotResolved,
+			"resolution_rate", fmt.Sprintf("%.2f", st.ResolutionRate))
+	}
+}
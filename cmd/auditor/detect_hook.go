@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// detectHookRestartDelay is how long a hook process that died or stopped
+// answering stays down before it is started again, so a hook that crashes on
+// startup is not respawned for every event.
+const detectHookRestartDelay = 10 * time.Second
+
+// maxHookReply bounds one hook reply line.
+const maxHookReply = 1 << 20
+
+// hookAlert is one alert returned by a detection hook.
+type hookAlert struct {
+	Rule    string         `json:"rule"`
+	Level   string         `json:"level"` // INFO, WARNING or CRITICAL; default WARNING
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// hookReply is what a detection hook returns for one event.
+type hookReply struct {
+	Alerts []hookAlert `json:"alerts"`
+}
+
+// detectHook runs site-specific detections on an event.
+type detectHook interface {
+	Name() string
+	Detect(event []byte) ([]hookAlert, error)
+	Close()
+}
+
+// parseDetectHooks builds a hook for each entry of a comma-separated list:
+// http:// and https:// URLs are called per event, anything else is an
+// executable run as a long-lived process.
+func parseDetectHooks(list string, timeout time.Duration) ([]detectHook, error) {
+	var hooks []detectHook
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://") {
+			hooks = append(hooks, &httpDetectHook{url: entry, client: &http.Client{Timeout: timeout}})
+			continue
+		}
+		info, err := os.Stat(entry)
+		if err != nil {
+			return nil, fmt.Errorf("detect hook: %w", err)
+		}
+		if info.IsDir() || info.Mode()&0111 == 0 {
+			return nil, fmt.Errorf("detect hook %s: not an executable file", entry)
+		}
+		hooks = append(hooks, &execDetectHook{path: entry, timeout: timeout})
+	}
+	return hooks, nil
+}
+
+// parseHookReply decodes a hook's reply. An empty reply means no alerts.
+func parseHookReply(data []byte) ([]hookAlert, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var reply hookReply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, fmt.Errorf("invalid reply: %w", err)
+	}
+	return reply.Alerts, nil
+}
+
+// httpDetectHook POSTs each event to a URL and reads the alerts from the
+// response body.
+type httpDetectHook struct {
+	url    string
+	client *http.Client
+}
+
+func (h *httpDetectHook) Name() string { return h.url }
+
+func (h *httpDetectHook) Detect(event []byte) ([]hookAlert, error) {
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(event))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("hook returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHookReply))
+	if err != nil {
+		return nil, err
+	}
+	return parseHookReply(body)
+}
+
+func (h *httpDetectHook) Close() {}
+
+// execDetectHook feeds events, one JSON line each, to the stdin of a process
+// that stays up for the auditor's lifetime, and reads one JSON reply line per
+// event from its stdout. The process's stderr goes to the auditor's.
+type execDetectHook struct {
+	path    string
+	timeout time.Duration
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	replies   chan []byte // stdout lines; closed when the process exits
+	downUntil time.Time
+}
+
+func (h *execDetectHook) Name() string { return filepath.Base(h.path) }
+
+func (h *execDetectHook) Detect(event []byte) ([]hookAlert, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cmd == nil {
+		if time.Now().Before(h.downUntil) {
+			return nil, fmt.Errorf("hook is down until %s", h.downUntil.Format(time.RFC3339))
+		}
+		if err := h.start(); err != nil {
+			h.downUntil = time.Now().Add(detectHookRestartDelay)
+			return nil, err
+		}
+	}
+	if _, err := h.stdin.Write(append(event, '\n')); err != nil {
+		h.stop()
+		return nil, fmt.Errorf("write event: %w", err)
+	}
+	select {
+	case line, ok := <-h.replies:
+		if !ok {
+			h.stop()
+			return nil, fmt.Errorf("hook exited")
+		}
+		return parseHookReply(line)
+	case <-time.After(h.timeout):
+		h.stop()
+		return nil, fmt.Errorf("no reply within %s; hook restarted", h.timeout)
+	}
+}
+
+// start launches the hook process. Called with h.mu held.
+func (h *execDetectHook) start() error {
+	cmd := exec.Command(h.path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	replies := make(chan []byte, 1)
+	go func() {
+		defer close(replies)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), maxHookReply)
+		for scanner.Scan() {
+			replies <- bytes.Clone(scanner.Bytes())
+		}
+	}()
+	h.cmd, h.stdin, h.replies = cmd, stdin, replies
+	slog.Info("detect hook started", "hook", h.Name(), "pid", cmd.Process.Pid)
+	return nil
+}
+
+// stop kills the hook process; the next event after detectHookRestartDelay
+// starts a new one. Called with h.mu held.
+func (h *execDetectHook) stop() {
+	if h.cmd == nil {
+		return
+	}
+	cmd, replies := h.cmd, h.replies
+	h.stdin.Close()
+	cmd.Process.Kill() //nolint:errcheck
+	go func() {
+		for range replies {
+		}
+		cmd.Wait() //nolint:errcheck
+	}()
+	h.cmd, h.stdin, h.replies = nil, nil, nil
+	h.downUntil = time.Now().Add(detectHookRestartDelay)
+}
+
+func (h *execDetectHook) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stop()
+}
+
+// checkDetectHooks passes the event to every configured detection hook and
+// raises the alerts they return as custom:<rule> security alerts, so they get
+// false-positive feedback, incident webhooks and notifiers like built-in rules.
+func (a *Auditor) checkDetectHooks(event *audit.Event) {
+	if len(a.hooks) == 0 {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Warn("failed to marshal event for detect hooks", "err", err)
+		return
+	}
+	for _, h := range a.hooks {
+		alerts, err := h.Detect(data)
+		if err != nil {
+			slog.Warn("detect hook failed", "hook", h.Name(), "event_id", event.EventID, "err", err)
+			continue
+		}
+		for _, ha := range alerts {
+			level := AlertLevel(strings.ToUpper(ha.Level))
+			if level != AlertInfo && level != AlertCritical {
+				level = AlertWarning
+			}
+			rule := ha.Rule
+			if rule == "" {
+				rule = h.Name()
+			}
+			message := ha.Message
+			if message == "" {
+				message = "CUSTOM DETECTION — " + rule
+			}
+			keys := make([]string, 0, len(ha.Details))
+			for k := range ha.Details {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			keyvals := []any{"hook", h.Name()}
+			for _, k := range keys {
+				keyvals = append(keyvals, k, ha.Details[k])
+			}
+			a.recordSecurityAlert("custom:"+rule, level, message, event, keyvals...)
+		}
+	}
+}
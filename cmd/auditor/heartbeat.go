@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// heartbeatState counts the events seen in the current heartbeat window.
+// Guarded by Auditor.mu.
+type heartbeatState struct {
+	events      int
+	agentEvents map[string]int
+	// silent holds the streams ("" for all events, otherwise an agent name)
+	// already alerted on; each alerts once and re-arms when events return.
+	silent map[string]bool
+}
+
+// parseAgentMinimums parses "agent=N,agent2=M" into per-agent minimums.
+func parseAgentMinimums(s string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, n, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%q: want agent=count", part)
+		}
+		want, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || want < 1 {
+			return nil, fmt.Errorf("%q: count must be a positive integer", part)
+		}
+		out[strings.TrimSpace(name)] = want
+	}
+	return out, nil
+}
+
+// eventAgent returns the agent that emitted an event: the session owner,
+// or for delegation events without one, the agent delegated to.
+func eventAgent(event *audit.Event) string {
+	if event.Session.AgentName != "" {
+		return event.Session.AgentName
+	}
+	if event.Decision != nil {
+		return event.Decision.Agent
+	}
+	return ""
+}
+
+// trackHeartbeat counts an event towards the current heartbeat window.
+func (a *Auditor) trackHeartbeat(event *audit.Event) {
+	if a.cfg.HeartbeatWindow <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.heartbeat.events++
+	if agent := eventAgent(event); agent != "" {
+		a.heartbeat.agentEvents[agent]++
+	}
+}
+
+// runHeartbeat closes a heartbeat window every interval.
+func (a *Auditor) runHeartbeat(interval time.Duration) {
+	slog.Info("starting heartbeat monitoring", "window", interval,
+		"min_events", a.cfg.HeartbeatMinEvents, "agents", len(a.cfg.HeartbeatAgentMin),
+		"ping_url", a.cfg.HeartbeatURL != "")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.checkHeartbeat()
+	}
+}
+
+// checkHeartbeat closes the current window: it alerts on every stream that
+// fell below its minimum, re-arms streams that recovered, pings the
+// heartbeat URL and starts a new window. Silence of the whole event stream is
+// critical — it is as likely to mean tampering as an outage — while a single
+// quiet agent is a warning.
+func (a *Auditor) checkHeartbeat() {
+	a.mu.Lock()
+	total := a.heartbeat.events
+	counts := a.heartbeat.agentEvents
+	a.heartbeat.events = 0
+	a.heartbeat.agentEvents = make(map[string]int)
+	a.mu.Unlock()
+
+	window := a.cfg.HeartbeatWindow.String()
+	var silent []string
+
+	if total < a.cfg.HeartbeatMinEvents {
+		silent = append(silent, "all events")
+		if a.markSilent("") {
+			a.recordSecurityAlert("event_stream_silent", AlertCritical,
+				"Audit event stream went silent - possible outage or tampering",
+				heartbeatEvent(""),
+				"events", total,
+				"min_events", a.cfg.HeartbeatMinEvents,
+				"window", window)
+		}
+	} else {
+		a.clearSilent("")
+	}
+
+	agents := make([]string, 0, len(a.cfg.HeartbeatAgentMin))
+	for agent := range a.cfg.HeartbeatAgentMin {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+	for _, agent := range agents {
+		want := a.cfg.HeartbeatAgentMin[agent]
+		if counts[agent] >= want {
+			a.clearSilent(agent)
+			continue
+		}
+		silent = append(silent, agent)
+		if a.markSilent(agent) {
+			a.recordSecurityAlert("agent_silent", AlertWarning,
+				"Agent stopped emitting audit events",
+				heartbeatEvent(agent),
+				"agent", agent,
+				"events", counts[agent],
+				"min_events", want,
+				"window", window)
+		}
+	}
+
+	if a.cfg.HeartbeatURL != "" {
+		a.pingHeartbeat(silent)
+	}
+}
+
+// markSilent records stream as silent and reports whether it was not already.
+func (a *Auditor) markSilent(stream string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.heartbeat.silent[stream] {
+		return false
+	}
+	a.heartbeat.silent[stream] = true
+	return true
+}
+
+func (a *Auditor) clearSilent(stream string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.heartbeat.silent[stream] {
+		slog.Info("heartbeat recovered", "stream", stream)
+		delete(a.heartbeat.silent, stream)
+	}
+}
+
+// heartbeatEvent is the synthetic event heartbeat alerts are attached to.
+func heartbeatEvent(agent string) *audit.Event {
+	ev := &audit.Event{
+		EventID:   fmt.Sprintf("heartbeat_%d", time.Now().Unix()),
+		Timestamp: time.Now(),
+		EventType: "heartbeat_check",
+	}
+	if agent != "" {
+		ev.Decision = &audit.Decision{Agent: agent}
+	}
+	return ev
+}
+
+// pingHeartbeat reports the window to the external heartbeat URL: a plain
+// GET when every stream met its minimum, a POST to <url>/fail naming the
+// silent streams otherwise. Because the ping only happens while the auditor
+// runs, the external service also catches the auditor itself going down.
+func (a *Auditor) pingHeartbeat(silent []string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	var (
+		resp *http.Response
+		err  error
+	)
+	if len(silent) == 0 {
+		resp, err = client.Get(a.cfg.HeartbeatURL)
+	} else {
+		url := strings.TrimSuffix(a.cfg.HeartbeatURL, "/") + "/fail"
+		body := "silent: " + strings.Join(silent, ", ")
+		resp, err = client.Post(url, "text/plain", strings.NewReader(body))
+	}
+	if err != nil {
+		slog.Warn("heartbeat ping failed", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("heartbeat ping returned error", "status", resp.StatusCode)
+	}
+}
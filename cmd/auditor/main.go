@@ -7,37 +7,54 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
-	"log/syslog"
 	"net"
 	"net/http"
 	"net/smtp"
 	"os"
+	"os/signal"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/discovery"
+	"helpdesk/internal/i18n"
 	"helpdesk/internal/logging"
+	"helpdesk/internal/notifyplugin"
+	"helpdesk/internal/secrets"
+	"helpdesk/internal/shutdown"
+	"helpdesk/internal/sms"
+	"helpdesk/internal/workhours"
 )
 
 // Config holds auditor configuration from flags.
 type Config struct {
-	SocketPath string
-	LogAll     bool
-	OutputJSON bool
+	SocketPath   string
+	SocketCursor string // file persisting the last socket sequence, for replay after a restart
+	LogAll       bool
+	OutputJSON   bool
 
 	// Verification mode
-	Verify bool   // Run chain integrity verification
-	DBPath string // Path to audit database (for verify mode)
+	Verify      bool   // Run chain integrity verification
+	DBPath      string // Path to audit database (for verify mode)
+	Incremental bool   // Verify only events appended since the last checkpoint
 
 	// Webhook configuration
-	WebhookURL  string
-	WebhookAll  bool // Send all events, not just alerts
-	WebhookTest bool // Send test alert on startup
+	WebhookURL   string
+	WebhookAll   bool              // Send all events, not just alerts
+	WebhookTest  bool              // Send test alert on startup
+	TeamWebhooks map[string]string // team -> webhook for critical alerts about resources it owns, from the infra config
 
 	// Prometheus configuration
 	PrometheusAddr string
@@ -47,15 +64,50 @@ type Config struct {
 	SyslogNetwork string // "udp", "tcp", or "" for local
 	SyslogAddr    string // e.g., "localhost:514"
 	SyslogTag     string
+	SyslogBackend string // "auto", "syslog", "journald" or "eventlog"
 	SyslogTest    bool // Send test message on startup
 
 	// Security monitoring
-	AuditServiceURL    string        // URL of central audit service for periodic verification
-	VerifyInterval     time.Duration // How often to verify chain integrity (0 = disabled)
-	IncidentWebhookURL string        // URL to POST security incidents
-	MaxEventsPerMinute int           // Alert threshold for high-volume activity (0 = disabled)
-	AllowedHoursStart  int           // Start of allowed hours (0-23), -1 to disable
-	AllowedHoursEnd    int           // End of allowed hours (0-23)
+	AuditServiceURL    string              // URL of central audit service for periodic verification
+	VerifyInterval     time.Duration       // How often to verify chain integrity (0 = disabled)
+	VerifyFullInterval time.Duration       // How often a periodic verification re-hashes every event (0 = always)
+	IncidentWebhookURL string              // URL to POST security incidents
+	MaxEventsPerMinute int                 // Alert threshold for high-volume activity (0 = disabled)
+	WorkHours          *workhours.Calendar // Teams' working hours for the off-hours check (nil = disabled)
+	ClockSkewThreshold time.Duration       // Alert when an agent's clock is this far from auditd's (0 = disabled)
+
+	// Reasoning quality
+	ReasoningWindow int     // Delegations per agent in the recent reasoning-score average (0 = disabled)
+	ReasoningDrop   float64 // Alert when the recent average falls this far below the agent's baseline
+
+	// Confidence calibration (polled from AuditServiceURL)
+	CalibrationInterval     time.Duration // How often to check calibration (0 = disabled)
+	CalibrationWindow       time.Duration // How far back each check looks
+	CalibrationMinDecisions int           // Resolved decisions an agent needs before it can be flagged
+	OverconfidenceGap       float64       // Alert when mean confidence exceeds the success rate by this much
+
+	// Delegation feedback (polled from AuditServiceURL with calibration)
+	ResolutionMinFeedback int     // Feedback verdicts an agent needs before it can be flagged
+	MinResolutionRate     float64 // Alert when fewer than this share of an agent's delegations resolved the problem
+
+	// Heartbeat (dead-man switch)
+	HeartbeatWindow    time.Duration  // Window over which minimum event rates are checked (0 = disabled)
+	HeartbeatMinEvents int            // Alert when fewer events than this arrive in a window
+	HeartbeatAgentMin  map[string]int // Per-agent minimum events per window
+	HeartbeatURL       string         // Pinged after every healthy window; <url>/fail when silent
+
+	// Tool parameter profiling
+	ProfileMinCalls int     // Calls per agent tool learned before alerting (0 = disabled)
+	ProfileOutlierZ float64 // Alert when a numeric parameter is this many spreads from its mean
+	ProfileFile     string  // JSON file the learned profile persists to
+
+	// Prompt injection
+	InjectionThreshold float64 // Alert when an event's injection risk score reaches this (0 = disabled)
+
+	// False-positive feedback (reported to and synced from AuditServiceURL)
+	AuditAPIKey     string        // Bearer token for auditd
+	FPSyncInterval  time.Duration // How often to sync false-positive feedback, maintenance windows and runtime thresholds (0 = disabled)
+	FPSuppressAfter int           // Suppress alerts matching this many false positives; fewer down-weight (0 = never suppress)
 
 	// Email configuration
 	SMTPHost     string
@@ -65,32 +117,58 @@ type Config struct {
 	EmailFrom    string
 	EmailTo      string // comma-separated list
 	EmailTest    bool   // Send test email on startup
+
+	// SMS/WhatsApp configuration (Twilio), for CRITICAL alerts only
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFrom       string
+	SMSTo            string // comma-separated list
+
+	// Notification plugins
+	NotifyPlugins string // comma-separated executable paths
+
+	// Localization: emails and texts in each recipient's locale, webhooks in
+	// the default one
+	Locales          *i18n.Bundle
+	RecipientLocales i18n.Recipients
+
+	// Custom detection hooks
+	DetectHooks       string // comma-separated executable paths or URLs
+	DetectHookTimeout time.Duration
 }
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	cfg := Config{}
 
 	flag.StringVar(&cfg.SocketPath, "socket", "audit.sock", "Path to audit Unix socket")
+	flag.StringVar(&cfg.SocketCursor, "socket-cursor", "", "File recording the last event received, so a restarted auditor replays what it missed")
 	flag.BoolVar(&cfg.LogAll, "log-all", false, "Log all events, not just alerts")
 	flag.BoolVar(&cfg.OutputJSON, "json", false, "Output events as JSON lines")
 
 	// Verification mode
 	flag.BoolVar(&cfg.Verify, "verify", false, "Verify audit chain integrity and exit")
 	flag.StringVar(&cfg.DBPath, "db", "audit.db", "Path to audit database (for verify mode)")
+	flag.BoolVar(&cfg.Incremental, "incremental", false, "With -verify: check only events appended since the last verification checkpoint")
 
 	// Webhook
+	configFile := flag.String("config", os.Getenv("HELPDESK_AUDITOR_CONFIG"), "YAML file of notifier settings and alert thresholds overriding the flags of the same name; the auditor re-reads this file on SIGHUP")
 	flag.StringVar(&cfg.WebhookURL, "webhook", "", "Webhook URL for alerts (Slack, PagerDuty, etc.)")
 	flag.BoolVar(&cfg.WebhookAll, "webhook-all", false, "Send all events to webhook, not just alerts")
 	flag.BoolVar(&cfg.WebhookTest, "webhook-test", false, "Send a test alert on startup to verify webhook")
+	infraConfig := flag.String("infra-config", os.Getenv("HELPDESK_INFRA_CONFIG"), "Infrastructure config naming resource owners; CRITICAL alerts about a resource owned by a team with a webhook_url go there instead of -webhook; re-read on SIGHUP")
 
 	// Prometheus
 	flag.StringVar(&cfg.PrometheusAddr, "prometheus", "", "Address to expose Prometheus metrics (e.g., :9090)")
 
-	// Syslog (Linux only - macOS uses unified logging which doesn't support traditional syslog)
-	flag.BoolVar(&cfg.SyslogEnabled, "syslog", false, "Send alerts to syslog (Linux only)")
+	// Syslog: journald, Windows Event Log or traditional syslog, picked by
+	// --syslog-backend (macOS unified logging doesn't support traditional syslog)
+	flag.BoolVar(&cfg.SyslogEnabled, "syslog", false, "Send alerts to the system log (see --syslog-backend)")
+	flag.StringVar(&cfg.SyslogBackend, "syslog-backend", "auto", "System log backend: auto, syslog, journald or eventlog (auto picks eventlog on Windows, journald on Linux when available, else syslog)")
 	flag.StringVar(&cfg.SyslogNetwork, "syslog-network", "", "Syslog network: udp, tcp, or empty for local")
 	flag.StringVar(&cfg.SyslogAddr, "syslog-addr", "", "Syslog address (e.g., localhost:514)")
-	flag.StringVar(&cfg.SyslogTag, "syslog-tag", "helpdesk-auditor", "Syslog tag")
+	flag.StringVar(&cfg.SyslogTag, "syslog-tag", "helpdesk-auditor", "Syslog tag (journald SYSLOG_IDENTIFIER, Event Log source)")
 	flag.BoolVar(&cfg.SyslogTest, "syslog-test", false, "Send test message to syslog on startup")
 
 	// Email
@@ -102,13 +180,64 @@ func main() {
 	flag.StringVar(&cfg.EmailTo, "email-to", "", "Email recipients (comma-separated)")
 	flag.BoolVar(&cfg.EmailTest, "email-test", false, "Send test email on startup")
 
+	// SMS/WhatsApp flags
+	flag.StringVar(&cfg.TwilioAccountSID, "twilio-account-sid", os.Getenv("TWILIO_ACCOUNT_SID"), "Twilio account SID for SMS/WhatsApp alerts (auth token from TWILIO_AUTH_TOKEN)")
+	flag.StringVar(&cfg.TwilioFrom, "twilio-from", os.Getenv("TWILIO_FROM"), "Twilio sending number (E.164), or whatsapp:+1... for WhatsApp")
+	flag.StringVar(&cfg.SMSTo, "sms-to", "", "Phone numbers to text CRITICAL alerts to (comma-separated, E.164)")
+
+	// Notification plugins
+	flag.StringVar(&cfg.NotifyPlugins, "notify-plugin", os.Getenv("HELPDESK_NOTIFY_PLUGINS"), "Executables to run for every alert, with the alert as JSON on stdin (comma-separated paths)")
+
+	// Localization
+	locale := flag.String("locale", os.Getenv("HELPDESK_LOCALE"), "Default language of alert notifications, e.g. en (default), de or es")
+	localeDir := flag.String("locale-dir", os.Getenv("HELPDESK_LOCALE_DIR"), "Directory of <locale>.json message catalogs adding to or overriding the built-in ones (optional)")
+	recipientLocales := flag.String("recipient-locales", os.Getenv("HELPDESK_RECIPIENT_LOCALES"), "Per-recipient alert languages, e.g. *@emea.example.com=de,+34*=es (first match wins)")
+
 	// Security monitoring
 	flag.StringVar(&cfg.AuditServiceURL, "audit-service", "", "URL of central audit service for periodic verification (e.g., http://localhost:1199)")
 	flag.DurationVar(&cfg.VerifyInterval, "verify-interval", 0, "How often to verify chain integrity (e.g., 5m, 1h). 0 = disabled")
+	flag.DurationVar(&cfg.VerifyFullInterval, "verify-full-interval", 24*time.Hour, "How often periodic verification does a full scan; runs in between only check new events. 0 = always full")
 	flag.StringVar(&cfg.IncidentWebhookURL, "incident-webhook", "", "URL to POST security incidents for automated response")
 	flag.IntVar(&cfg.MaxEventsPerMinute, "max-events-per-minute", 0, "Alert on high event volume (0 = disabled)")
-	flag.IntVar(&cfg.AllowedHoursStart, "allowed-hours-start", -1, "Start of allowed operating hours (0-23), -1 = disabled")
-	flag.IntVar(&cfg.AllowedHoursEnd, "allowed-hours-end", -1, "End of allowed operating hours (0-23)")
+	hoursCalendar := flag.String("hours-calendar", os.Getenv("HELPDESK_HOURS_CALENDAR"), "YAML calendar of teams' working hours, timezones and holidays for the off-hours check (overrides -allowed-hours-start/-end)")
+	allowedStart := flag.Int("allowed-hours-start", -1, "Start of allowed operating hours (0-23, local time) when no -hours-calendar is given, -1 = disabled")
+	allowedEnd := flag.Int("allowed-hours-end", -1, "End of allowed operating hours (0-23, local time)")
+	flag.DurationVar(&cfg.ClockSkewThreshold, "clock-skew-threshold", 30*time.Second, "Alert when an agent's clock is this far from auditd's, from the receive time auditd stamps on each event (0 = disabled)")
+
+	// Reasoning quality
+	flag.IntVar(&cfg.ReasoningWindow, "reasoning-window", 20, "Delegations per agent averaged when watching reasoning quality (0 = disabled)")
+	flag.Float64Var(&cfg.ReasoningDrop, "reasoning-drop", 0.2, "Alert when an agent's recent reasoning score average drops this far below its baseline")
+
+	// Confidence calibration
+	flag.DurationVar(&cfg.CalibrationInterval, "calibration-interval", 0, "How often to check agent confidence calibration via -audit-service (e.g., 1h). 0 = disabled")
+	flag.DurationVar(&cfg.CalibrationWindow, "calibration-window", 7*24*time.Hour, "How far back each calibration check looks")
+	flag.IntVar(&cfg.CalibrationMinDecisions, "calibration-min-decisions", 20, "Resolved delegations an agent needs before it can be flagged as overconfident")
+	flag.Float64Var(&cfg.OverconfidenceGap, "overconfidence-gap", 0.15, "Alert when an agent's mean confidence exceeds its success rate by this much")
+	flag.IntVar(&cfg.ResolutionMinFeedback, "resolution-min-feedback", 10, "Delegation feedback verdicts an agent needs before a low resolution rate alerts")
+	flag.Float64Var(&cfg.MinResolutionRate, "min-resolution-rate", 0.5, "Alert when users report fewer than this share of an agent's delegations as resolved (0 = disabled)")
+
+	// Heartbeat
+	flag.DurationVar(&cfg.HeartbeatWindow, "heartbeat-window", 0, "Alert when the event stream goes quiet for this long (e.g., 15m). 0 = disabled")
+	flag.IntVar(&cfg.HeartbeatMinEvents, "heartbeat-min-events", 1, "Minimum events expected in each -heartbeat-window")
+	heartbeatAgents := flag.String("heartbeat-agents", "", "Per-agent minimum events per -heartbeat-window (e.g., postgres_database_agent=5,k8s_agent=1)")
+	flag.StringVar(&cfg.HeartbeatURL, "heartbeat-url", "", "URL pinged after every healthy -heartbeat-window, and <url>/fail when events stop (healthchecks.io-style)")
+
+	// Tool parameter profiling
+	flag.IntVar(&cfg.ProfileMinCalls, "profile-min-calls", 50, "Calls each agent tool is learned for before first-seen and outlier parameters alert (0 = disabled)")
+	flag.Float64Var(&cfg.ProfileOutlierZ, "profile-outlier-z", 4, "Alert when a numeric tool parameter is this many standard deviations from its mean")
+	flag.StringVar(&cfg.ProfileFile, "profile-file", "", "File the learned parameter profile is saved to and loaded from, so a restart does not relearn it")
+
+	// Custom detection hooks
+	flag.StringVar(&cfg.DetectHooks, "detect-hook", os.Getenv("HELPDESK_DETECT_HOOKS"), "Executables or http(s) URLs each event is passed to as JSON; they reply with alerts to raise (comma-separated)")
+	flag.DurationVar(&cfg.DetectHookTimeout, "detect-hook-timeout", 5*time.Second, "How long a detect hook may take to answer for one event")
+
+	// Prompt injection
+	flag.Float64Var(&cfg.InjectionThreshold, "injection-threshold", 0.5, "Alert when an event's prompt-injection risk score (set by auditd) reaches this (0 = disabled)")
+
+	// False-positive feedback
+	flag.StringVar(&cfg.AuditAPIKey, "audit-api-key", os.Getenv("HELPDESK_AUDIT_API_KEY"), "Bearer token for auditd authentication (used with -audit-service)")
+	flag.DurationVar(&cfg.FPSyncInterval, "fp-sync-interval", time.Minute, "How often to sync alert false-positive feedback, active maintenance windows and runtime alert thresholds from -audit-service. 0 = disabled")
+	flag.IntVar(&cfg.FPSuppressAfter, "fp-suppress-after", 3, "Suppress alerts whose rule, agent and resource were marked false positive this many times; fewer marks lower the severity (0 = never suppress)")
 
 	// Initialize logging first (strips --log-level from args)
 	args := logging.InitLogging(os.Args[1:])
@@ -116,10 +245,67 @@ func main() {
 	// Parse remaining flags
 	_ = flag.CommandLine.Parse(args)
 
-	// Allow SMTP password from environment
+	if err := audit.InstallAuditToken(context.Background(), cfg.AuditServiceURL); err != nil {
+		slog.Error("failed to configure the auditd access token", "err", err)
+		os.Exit(1)
+	}
+
+	// Allow SMTP password from environment; either may be a secrets reference
+	// (vault://, awssm://, gcpsm://, file://).
 	if cfg.SMTPPassword == "" {
 		cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
 	}
+	smtpPassword, err := secrets.Value(context.Background(), cfg.SMTPPassword)
+	if err != nil {
+		slog.Error("failed to resolve SMTP password", "err", err)
+		os.Exit(1)
+	}
+	cfg.SMTPPassword = smtpPassword
+	cfg.TwilioAuthToken, err = secrets.Value(context.Background(), os.Getenv("TWILIO_AUTH_TOKEN"))
+	if err != nil {
+		slog.Error("failed to resolve Twilio auth token", "err", err)
+		os.Exit(1)
+	}
+
+	if cfg.Locales, err = i18n.Load(*localeDir, *locale); err != nil {
+		slog.Error("invalid -locale or -locale-dir", "err", err)
+		os.Exit(1)
+	}
+	if cfg.RecipientLocales, err = i18n.ParseRecipients(*recipientLocales); err != nil {
+		slog.Error("invalid -recipient-locales", "err", err)
+		os.Exit(1)
+	}
+
+	cfg.HeartbeatAgentMin, err = parseAgentMinimums(*heartbeatAgents)
+	if err != nil {
+		slog.Error("invalid -heartbeat-agents", "err", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *hoursCalendar != "":
+		if cfg.WorkHours, err = workhours.LoadFile(*hoursCalendar); err != nil {
+			slog.Error("invalid -hours-calendar", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("off-hours check uses hours calendar", "file", *hoursCalendar, "teams", cfg.WorkHours.TeamNames())
+	case *allowedStart >= 0 && *allowedEnd >= 0:
+		if cfg.WorkHours, err = workhours.Daily(*allowedStart, *allowedEnd, time.Local); err != nil {
+			slog.Error("invalid -allowed-hours-start/-end", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	// Notifier settings and thresholds from -config and -infra-config are
+	// reloaded on SIGHUP on top of the flag values.
+	flagCfg := cfg
+	if cfg, err = loadConfigFile(flagCfg, *configFile, *infraConfig); err != nil {
+		slog.Error("invalid -config or -infra-config", "err", err)
+		os.Exit(1)
+	}
+	if len(cfg.TeamWebhooks) > 0 {
+		slog.Info("critical alerts routed to owning teams", "teams", len(cfg.TeamWebhooks))
+	}
 
 	// Allow log-all from environment
 	if !cfg.LogAll && (os.Getenv("HELPDESK_AUDITOR_LOG_ALL") == "true" || os.Getenv("HELPDESK_AUDITOR_LOG_ALL") == "1") {
@@ -182,11 +368,12 @@ func main() {
 			Timestamp: time.Now(),
 		}
 		for _, n := range notifiers {
-			if sl, ok := n.(*SyslogNotifier); ok {
-				if err := sl.Send(testAlert); err != nil {
-					slog.Error("syslog test failed", "err", err)
+			switch n.Name() {
+			case "syslog", "journald", "eventlog":
+				if err := n.Send(testAlert); err != nil {
+					slog.Error("syslog test failed", "backend", n.Name(), "err", err)
 				} else {
-					slog.Info("syslog test successful")
+					slog.Info("syslog test successful", "backend", n.Name())
 				}
 			}
 		}
@@ -228,8 +415,14 @@ func main() {
 		}()
 	}
 
+	// SIGINT/SIGTERM stops event processing; in-flight alert deliveries and
+	// the parameter profile are then flushed within HELPDESK_SHUTDOWN_TIMEOUT.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// Connect to the audit socket
-	conn, err := net.Dial("unix", cfg.SocketPath)
+	sock := &audit.SocketClient{Path: cfg.SocketPath, CursorPath: cfg.SocketCursor}
+	conn, err := sock.Dial()
 	if err != nil {
 		if cfg.AuditServiceURL != "" {
 			// Fall back to HTTP polling mode when the socket is not available.
@@ -238,7 +431,18 @@ func main() {
 			slog.Info("audit socket not available; switching to HTTP polling mode",
 				"socket", cfg.SocketPath, "url", cfg.AuditServiceURL)
 			auditor := NewAuditor(cfg, notifiers, metrics)
-			runHTTPPollingMode(cfg, auditor)
+			go auditor.watchReload(ctx, flagCfg, *configFile, *infraConfig)
+			if cfg.HeartbeatWindow > 0 {
+				go auditor.runHeartbeat(cfg.HeartbeatWindow)
+			}
+			if auditor.params != nil && cfg.ProfileFile != "" {
+				go auditor.runParamProfileSaver(time.Minute)
+			}
+			if cfg.FPSyncInterval > 0 {
+				go auditor.runAlertFeedbackSync(cfg.AuditServiceURL, cfg.FPSyncInterval)
+			}
+			runHTTPPollingMode(ctx, cfg, auditor)
+			auditor.shutdown()
 			return
 		}
 		slog.Error("failed to connect to audit socket", "path", cfg.SocketPath, "err", err)
@@ -252,34 +456,109 @@ func main() {
 
 	auditor := NewAuditor(cfg, notifiers, metrics)
 
+	// Reload notifier settings and thresholds on SIGHUP
+	go auditor.watchReload(ctx, flagCfg, *configFile, *infraConfig)
+
 	// Start periodic chain verification if configured
 	if cfg.VerifyInterval > 0 && cfg.AuditServiceURL != "" {
-		go auditor.runPeriodicVerification(cfg.AuditServiceURL, cfg.VerifyInterval)
+		go auditor.runPeriodicVerification(cfg.AuditServiceURL, cfg.VerifyInterval, cfg.VerifyFullInterval)
 	}
 
-	scanner := bufio.NewScanner(conn)
+	// Start periodic confidence calibration checks if configured
+	if cfg.CalibrationInterval > 0 && cfg.AuditServiceURL != "" {
+		go auditor.runPeriodicCalibration(cfg.AuditServiceURL, cfg.CalibrationInterval)
+	}
+
+	// Start the heartbeat (dead-man switch) if configured
+	if cfg.HeartbeatWindow > 0 {
+		go auditor.runHeartbeat(cfg.HeartbeatWindow)
+	}
+
+	// Persist the learned tool parameter profile if configured
+	if auditor.params != nil && cfg.ProfileFile != "" {
+		go auditor.runParamProfileSaver(time.Minute)
+	}
+
+	// Sync operators' false-positive feedback on alerts if configured
+	if cfg.FPSyncInterval > 0 && cfg.AuditServiceURL != "" {
+		go auditor.runAlertFeedbackSync(cfg.AuditServiceURL, cfg.FPSyncInterval)
+	}
 
+	auditor.watchSocket(ctx, sock, conn)
+	auditor.shutdown()
+	slog.Info("auditor stopped")
+}
+
+// watchSocket analyses events from the audit socket until ctx is done. When
+// auditd closes the socket — it is restarting — watchSocket reconnects with
+// backoff, and the socket replays the events recorded in the meantime.
+func (a *Auditor) watchSocket(ctx context.Context, sock *audit.SocketClient, conn net.Conn) {
+	backoff := time.Second
+	for {
+		closeOnStop := context.AfterFunc(ctx, func() { conn.Close() })
+		err := a.readSocket(sock, conn)
+		closeOnStop()
+		conn.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("audit socket closed; reconnecting", "err", err, "replay_after", sock.Seq())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			c, err := sock.Dial()
+			if err == nil {
+				conn, backoff = c, time.Second
+				slog.Info("reconnected to audit socket", "replay_after", sock.Seq())
+				break
+			}
+			backoff = min(2*backoff, 30*time.Second)
+			slog.Debug("audit socket reconnect failed", "err", err, "retry_in", backoff)
+		}
+	}
+}
+
+// readSocket analyses events from conn until it is closed.
+func (a *Auditor) readSocket(sock *audit.SocketClient, conn net.Conn) error {
+	scanner := bufio.NewScanner(conn)
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
 			continue
 		}
 
-		var event audit.Event
-		if err := json.Unmarshal(line, &event); err != nil {
+		event, err := sock.Decode(line)
+		if event == nil {
 			slog.Warn("failed to parse event", "err", err, "line", string(line))
 			continue
 		}
+		if err != nil {
+			slog.Warn("socket cursor not saved", "err", err)
+		}
 
-		auditor.Analyze(&event)
+		a.Analyze(event)
 	}
+	return scanner.Err()
+}
 
-	if err := scanner.Err(); err != nil {
-		slog.Error("socket read error", "err", err)
-		os.Exit(1)
+// shutdown waits, within HELPDESK_SHUTDOWN_TIMEOUT, for alert reports and
+// incident webhooks still in flight, saves the parameter profile and stops
+// the detect hooks.
+func (a *Auditor) shutdown() {
+	for _, h := range a.hooks {
+		h.Close()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdown.Timeout())
+	defer cancel()
+	shutdown.Drain(ctx)
+	if a.params != nil && a.cfg.ProfileFile != "" {
+		if err := a.params.save(); err != nil {
+			slog.Warn("failed to save parameter profile", "err", err)
+		}
 	}
-
-	slog.Info("auditor stopped (socket closed)")
 }
 
 // runVerifyMode verifies the integrity of the audit chain and exits.
@@ -288,9 +567,18 @@ func runVerifyMode(cfg Config) {
 	fmt.Println("========================")
 	fmt.Printf("Database: %s\n\n", cfg.DBPath)
 
+	// The chain key, when the audit service signs its segment index, lets
+	// verification detect a rewritten index as well as a rewritten chain.
+	chainKey, err := secrets.Getenv(context.Background(), "HELPDESK_AUDIT_CHAIN_KEY")
+	if err != nil {
+		fmt.Printf("ERROR: HELPDESK_AUDIT_CHAIN_KEY: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Open the audit store
 	store, err := audit.NewStore(audit.StoreConfig{
-		DBPath: cfg.DBPath,
+		DBPath:   cfg.DBPath,
+		ChainKey: []byte(chainKey),
 	})
 	if err != nil {
 		fmt.Printf("ERROR: Failed to open database: %v\n", err)
@@ -300,7 +588,7 @@ func runVerifyMode(cfg Config) {
 
 	// Verify the chain
 	ctx := context.Background()
-	status, err := store.VerifyIntegrity(ctx)
+	status, err := store.VerifySegments(ctx, audit.VerifyOptions{Incremental: cfg.Incremental})
 	if err != nil {
 		fmt.Printf("ERROR: Verification failed: %v\n", err)
 		os.Exit(1)
@@ -310,6 +598,15 @@ func runVerifyMode(cfg Config) {
 	fmt.Printf("Total Events:   %d\n", status.TotalEvents)
 	fmt.Printf("Hashed Events:  %d\n", status.HashedEvents)
 	fmt.Printf("Legacy Events:  %d (no hash chain)\n", status.LegacyEvents)
+	if status.Incremental {
+		fmt.Printf("Verified:       %d (new since last checkpoint)\n", status.VerifiedEvents)
+	}
+	if status.Segments > 1 {
+		fmt.Printf("Chain Segments: %d\n", status.Segments)
+	}
+	if status.UnsignedSegments > 0 {
+		fmt.Printf("Unsigned Index: %d segment(s) not checked against a chain key\n", status.UnsignedSegments)
+	}
 	fmt.Println()
 
 	if status.TotalEvents > 0 {
@@ -327,6 +624,9 @@ func runVerifyMode(cfg Config) {
 		os.Exit(0)
 	} else {
 		fmt.Println("Status: ✗ INVALID")
+		if status.BrokenSegment != "" {
+			fmt.Printf("Chain segment:  %s\n", status.BrokenSegment)
+		}
 		fmt.Printf("Chain broken at event index: %d\n", status.BrokenAt)
 		fmt.Printf("Error: %s\n", status.Error)
 		fmt.Println()
@@ -346,12 +646,14 @@ type Notifier interface {
 
 // Alert represents an alert to be sent.
 type Alert struct {
+	Type      string // security alert rule, e.g. "high_volume"; empty for others
 	Level     AlertLevel
 	Message   string
 	EventID   string
 	SessionID string
 	UserID    string
 	Agent     string
+	Team      string // team owning the event's resource, if any
 	Details   map[string]any
 	Timestamp time.Time
 }
@@ -359,20 +661,21 @@ type Alert struct {
 func buildNotifiers(cfg Config) []Notifier {
 	var notifiers []Notifier
 
-	if cfg.WebhookURL != "" {
-		notifiers = append(notifiers, &WebhookNotifier{URL: cfg.WebhookURL})
-		slog.Info("webhook notifier enabled", "url", cfg.WebhookURL)
+	if cfg.WebhookURL != "" || len(cfg.TeamWebhooks) > 0 {
+		notifiers = append(notifiers, &WebhookNotifier{URL: cfg.WebhookURL, TeamURLs: cfg.TeamWebhooks, Locales: cfg.Locales})
+		slog.Info("webhook notifier enabled", "url", cfg.WebhookURL, "team_webhooks", len(cfg.TeamWebhooks))
 	}
 
 	if cfg.SyslogEnabled {
-		n, err := NewSyslogNotifier(cfg.SyslogNetwork, cfg.SyslogAddr, cfg.SyslogTag)
+		backend := syslogBackend(cfg.SyslogBackend, runtime.GOOS, cfg.SyslogNetwork, cfg.SyslogAddr, journaldAvailable())
+		n, err := newSystemLogNotifier(backend, cfg)
 		if err != nil {
-			slog.Error("failed to create syslog notifier", "err", err)
+			slog.Error("failed to create system log notifier", "backend", backend, "err", err)
 		} else {
 			notifiers = append(notifiers, n)
-			slog.Info("syslog notifier enabled")
+			slog.Info("system log notifier enabled", "backend", backend)
 			// Warn on macOS where traditional syslog doesn't work
-			if runtime.GOOS == "darwin" {
+			if backend == "syslog" && runtime.GOOS == "darwin" {
 				slog.Warn("syslog on macOS may not work - macOS uses unified logging instead of traditional syslog")
 			}
 		}
@@ -386,21 +689,97 @@ func buildNotifiers(cfg Config) []Notifier {
 			Password: cfg.SMTPPassword,
 			From:     cfg.EmailFrom,
 			To:       strings.Split(cfg.EmailTo, ","),
+
+			Locales:          cfg.Locales,
+			RecipientLocales: cfg.RecipientLocales,
 		})
 		slog.Info("email notifier enabled", "to", cfg.EmailTo)
 	}
 
+	client := &sms.Client{AccountSID: cfg.TwilioAccountSID, AuthToken: cfg.TwilioAuthToken, From: cfg.TwilioFrom}
+	if client.Enabled() && cfg.SMSTo != "" {
+		n := &SMSNotifier{Client: client, Locales: cfg.Locales, RecipientLocales: cfg.RecipientLocales}
+		for _, to := range strings.Split(cfg.SMSTo, ",") {
+			n.To = append(n.To, strings.TrimSpace(to))
+		}
+		notifiers = append(notifiers, n)
+		slog.Info("sms notifier enabled", "to", cfg.SMSTo)
+	}
+
+	plugins, err := notifyplugin.ParseList(cfg.NotifyPlugins)
+	if err != nil {
+		slog.Error("failed to load notify plugins", "err", err)
+	}
+	for _, p := range plugins {
+		notifiers = append(notifiers, &PluginNotifier{Plugin: p})
+		slog.Info("plugin notifier enabled", "plugin", p.Path)
+	}
+
 	return notifiers
 }
 
-// WebhookNotifier sends alerts via HTTP POST.
+// syslogBackend resolves --syslog-backend. "auto" picks the Windows Event Log
+// on Windows, journald on Linux when its socket is present and no remote
+// syslog address is configured, and syslog everywhere else.
+func syslogBackend(requested, goos, network, addr string, journald bool) string {
+	if requested != "" && requested != "auto" {
+		return requested
+	}
+	switch {
+	case goos == "windows":
+		return "eventlog"
+	case goos == "linux" && journald && network == "" && addr == "":
+		return "journald"
+	default:
+		return "syslog"
+	}
+}
+
+// newSystemLogNotifier creates the notifier for a resolved syslog backend.
+func newSystemLogNotifier(backend string, cfg Config) (Notifier, error) {
+	switch backend {
+	case "syslog":
+		n, err := NewSyslogNotifier(cfg.SyslogNetwork, cfg.SyslogAddr, cfg.SyslogTag)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case "journald":
+		n, err := NewJournaldNotifier(journaldSocket, cfg.SyslogTag)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case "eventlog":
+		return NewEventLogNotifier(cfg.SyslogTag)
+	default:
+		return nil, fmt.Errorf("unknown syslog backend %q (supported: auto, syslog, journald, eventlog)", backend)
+	}
+}
+
+// WebhookNotifier sends alerts via HTTP POST. Slack messages are written in
+// the default locale; JSON payloads are not localized.
 type WebhookNotifier struct {
 	URL string
+	// TeamURLs maps a team to its webhook. Critical alerts about a resource
+	// the team owns go there instead of to URL.
+	TeamURLs map[string]string
+	Locales  *i18n.Bundle
 }
 
 func (w *WebhookNotifier) Name() string { return "webhook" }
 
-func (w *WebhookNotifier) Send(alert Alert) error {
+// url returns the webhook an alert goes to, or "" when there is none.
+func (w *WebhookNotifier) url(alert Alert) string {
+	if u := w.TeamURLs[alert.Team]; alert.Level == AlertCritical && alert.Team != "" && u != "" {
+		return u
+	}
+	return w.URL
+}
+
+// alertPayload is the JSON form of an alert posted to webhooks and fed to
+// notify plugins.
+func alertPayload(alert Alert) map[string]any {
 	payload := map[string]any{
 		"level":      string(alert.Level),
 		"message":    alert.Message,
@@ -411,16 +790,35 @@ func (w *WebhookNotifier) Send(alert Alert) error {
 		"timestamp":  alert.Timestamp.Format(time.RFC3339),
 		"details":    alert.Details,
 	}
+	if alert.Team != "" {
+		payload["team"] = alert.Team
+	}
+	return payload
+}
+
+func (w *WebhookNotifier) Send(alert Alert) error {
+	url := w.url(alert)
+	if url == "" {
+		return nil
+	}
+	payload := alertPayload(alert)
 
 	// Slack-compatible format
-	if strings.Contains(w.URL, "slack.com") {
+	if strings.Contains(url, "slack.com") {
 		emoji := ":warning:"
 		if alert.Level == AlertCritical {
 			emoji = ":rotating_light:"
 		}
+		l := w.Locales.Default()
 		payload = map[string]any{
-			"text": fmt.Sprintf("%s *[%s]* %s\n>Event: %s | Agent: %s | User: %s",
-				emoji, alert.Level, alert.Message, alert.EventID, alert.Agent, alert.UserID),
+			"text": w.Locales.T(l, "alert.slack", i18n.Vars{
+				"emoji":    emoji,
+				"level":    alert.Level,
+				"message":  alert.localMessage(w.Locales, l),
+				"event_id": alert.EventID,
+				"agent":    alert.Agent,
+				"user_id":  alert.UserID,
+			}),
 		}
 	}
 
@@ -429,7 +827,7 @@ func (w *WebhookNotifier) Send(alert Alert) error {
 		return err
 	}
 
-	resp, err := http.Post(w.URL, "application/json", bytes.NewReader(body))
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -441,43 +839,7 @@ func (w *WebhookNotifier) Send(alert Alert) error {
 	return nil
 }
 
-// SyslogNotifier sends alerts to syslog.
-type SyslogNotifier struct {
-	writer *syslog.Writer
-}
-
-func NewSyslogNotifier(network, addr, tag string) (*SyslogNotifier, error) {
-	var w *syslog.Writer
-	var err error
-
-	if network == "" && addr == "" {
-		w, err = syslog.New(syslog.LOG_ALERT|syslog.LOG_DAEMON, tag)
-	} else {
-		w, err = syslog.Dial(network, addr, syslog.LOG_ALERT|syslog.LOG_DAEMON, tag)
-	}
-	if err != nil {
-		return nil, err
-	}
-	return &SyslogNotifier{writer: w}, nil
-}
-
-func (s *SyslogNotifier) Name() string { return "syslog" }
-
-func (s *SyslogNotifier) Send(alert Alert) error {
-	msg := fmt.Sprintf("[%s] %s (event=%s agent=%s user=%s)",
-		alert.Level, alert.Message, alert.EventID, alert.Agent, alert.UserID)
-
-	switch alert.Level {
-	case AlertCritical:
-		return s.writer.Crit(msg)
-	case AlertWarning:
-		return s.writer.Warning(msg)
-	default:
-		return s.writer.Info(msg)
-	}
-}
-
-// EmailNotifier sends alerts via SMTP.
+// EmailNotifier sends alerts via SMTP, one email per recipient locale.
 type EmailNotifier struct {
 	Host     string
 	Port     string
@@ -485,6 +847,9 @@ type EmailNotifier struct {
 	Password string
 	From     string
 	To       []string
+
+	Locales          *i18n.Bundle
+	RecipientLocales i18n.Recipients
 }
 
 func (e *EmailNotifier) Name() string { return "email" }
@@ -496,29 +861,36 @@ func (e *EmailNotifier) Send(alert Alert) error {
 		return nil
 	}
 
-	slog.Debug("sending email", "to", e.To, "host", e.Host, "port", e.Port)
-	subject := fmt.Sprintf("[AUDIT %s] %s", alert.Level, alert.Message)
-	body := fmt.Sprintf(`Helpdesk Audit Alert
-
-Level: %s
-Message: %s
-
-Event ID: %s
-Session: %s
-User: %s
-Agent: %s
-Time: %s
+	var errs []error
+	for _, g := range e.RecipientLocales.Group(e.To, e.Locales.Default()) {
+		if err := e.send(g.To, alert, g.Locale); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-Details:
-%v
-`,
-		alert.Level, alert.Message,
-		alert.EventID, alert.SessionID, alert.UserID, alert.Agent,
-		alert.Timestamp.Format(time.RFC3339),
-		formatDetails(alert.Details))
+// send emails alert in locale l.
+func (e *EmailNotifier) send(to []string, alert Alert, l string) error {
+	slog.Debug("sending email", "to", to, "host", e.Host, "port", e.Port, "locale", l)
+	vars := i18n.Vars{
+		"level":      alert.Level,
+		"message":    alert.localMessage(e.Locales, l),
+		"event_id":   alert.EventID,
+		"session_id": alert.SessionID,
+		"user_id":    alert.UserID,
+		"agent":      alert.Agent,
+		"time":       alert.Timestamp.Format(time.RFC3339),
+		"details":    formatDetails(alert.Details),
+	}
+	if len(alert.Details) == 0 {
+		vars["details"] = e.Locales.T(l, "alert.details.none", nil)
+	}
+	subject := e.Locales.T(l, "alert.email.subject", vars)
+	body := e.Locales.T(l, "alert.email.body", vars)
 
 	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
-		e.From, strings.Join(e.To, ","), subject, body)
+		e.From, strings.Join(to, ","), subject, body)
 
 	addr := e.Host + ":" + e.Port
 
@@ -527,7 +899,7 @@ Details:
 		auth = smtp.PlainAuth("", e.User, e.Password, e.Host)
 	}
 
-	err := smtp.SendMail(addr, auth, e.From, e.To, []byte(msg))
+	err := smtp.SendMail(addr, auth, e.From, to, []byte(msg))
 	if err != nil {
 		slog.Debug("smtp.SendMail failed", "err", err)
 	} else {
@@ -536,6 +908,76 @@ Details:
 	return err
 }
 
+// SMSNotifier texts CRITICAL alerts through Twilio, for on-call staff who
+// may not have Slack or email at hand.
+type SMSNotifier struct {
+	Client *sms.Client
+	To     []string
+
+	Locales          *i18n.Bundle
+	RecipientLocales i18n.Recipients
+}
+
+func (s *SMSNotifier) Name() string { return "sms" }
+
+func (s *SMSNotifier) Send(alert Alert) error {
+	if alert.Level != AlertCritical {
+		return nil
+	}
+	var errs []error
+	for _, to := range s.To {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := s.Client.Send(ctx, to, s.text(alert, s.RecipientLocales.Locale(to, s.Locales.Default()))); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", to, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+// text is the SMS for alert in locale l.
+func (s *SMSNotifier) text(alert Alert, l string) string {
+	agent := ""
+	if alert.Agent != "" {
+		agent = s.Locales.T(l, "alert.sms.agent", i18n.Vars{"agent": alert.Agent})
+	}
+	return s.Locales.T(l, "alert.sms", i18n.Vars{
+		"level":    alert.Level,
+		"message":  alert.localMessage(s.Locales, l),
+		"event_id": alert.EventID,
+		"agent":    agent,
+	})
+}
+
+// localMessage returns the alert's message in locale l. Security alerts are
+// translated by rule ("alert.rule.<type>", filled from their details); other
+// alerts, and rules a catalog has no entry for, keep the English message.
+func (alert Alert) localMessage(locales *i18n.Bundle, l string) string {
+	if alert.Type == "" {
+		return alert.Message
+	}
+	vars := make(i18n.Vars, len(alert.Details))
+	for k, v := range alert.Details {
+		if f, ok := v.(float64); ok { // scores and means: two decimals at most
+			v = strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(f, 'f', 2, 64), "0"), ".")
+		}
+		vars[k] = v
+	}
+	return locales.Text(l, "alert.rule."+alert.Type, alert.Message, vars)
+}
+
+// PluginNotifier hands every alert to an exec-based notification plugin, for
+// systems with no native notifier. See package notifyplugin for the contract.
+type PluginNotifier struct {
+	Plugin *notifyplugin.Plugin
+}
+
+func (p *PluginNotifier) Name() string { return "plugin:" + p.Plugin.Name() }
+
+func (p *PluginNotifier) Send(alert Alert) error {
+	return p.Plugin.Send(context.Background(), "alert", alertPayload(alert))
+}
+
 func formatDetails(details map[string]any) string {
 	if len(details) == 0 {
 		return "(none)"
@@ -633,29 +1075,54 @@ type Auditor struct {
 	agentErrorCount map[string]int
 	agentCallCount  map[string]int
 	sessionQueries  map[string][]string
+	reasoning       map[string]*reasoningTrack
+	overconfident   map[string]bool
+	lowResolution   map[string]bool
+	heartbeat       heartbeatState
+	params          *paramProfiler // nil when parameter profiling is disabled
+	hooks           []detectHook
 	lastEventHash   string // For chain integrity verification
 	lastEventTime   time.Time
+	lastReceivedAt  time.Time       // auditd's receive time of the previous event, when it had one
+	clockSkewed     map[string]bool // agents whose clock skew has alerted
 
 	// Security monitoring
 	eventsThisMinute int
 	minuteStart      time.Time
-	securityAlerts   []SecurityAlert // Recent security alerts for incident creation
+	securityAlerts   []SecurityAlert           // Recent security alerts for incident creation
+	suppressions     []audit.AlertSuppression  // False-positive feedback synced from the audit service
+	maintenance      []audit.MaintenanceWindow // Active maintenance windows synced from the audit service
+	thresholds       thresholds                // Flag and -config thresholds with runtime overrides synced from the audit service
 	mu               sync.Mutex
 }
 
 // SecurityAlert represents a security-related alert for incident creation.
 type SecurityAlert struct {
+	ID        string    `json:"id"`
 	Type      string    `json:"type"`
 	Severity  string    `json:"severity"`
 	Message   string    `json:"message"`
 	EventID   string    `json:"event_id"`
 	TraceID   string    `json:"trace_id"`
+	Agent     string    `json:"agent,omitempty"`
+	Resource  string    `json:"resource,omitempty"`
 	Details   map[string]any `json:"details"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// MaintenanceWindow is the ID of the maintenance window the alert was
+	// raised in, if any; such alerts are lowered one level.
+	MaintenanceWindow string `json:"maintenance_window,omitempty"`
 }
 
 // NewAuditor creates a new auditor with initialized state.
 func NewAuditor(cfg Config, notifiers []Notifier, metrics *Metrics) *Auditor {
+	hooks, err := parseDetectHooks(cfg.DetectHooks, cfg.DetectHookTimeout)
+	if err != nil {
+		slog.Error("failed to load detect hooks", "err", err)
+	}
+	for _, h := range hooks {
+		slog.Info("detect hook enabled", "hook", h.Name())
+	}
 	return &Auditor{
 		cfg:             cfg,
 		notifiers:       notifiers,
@@ -664,8 +1131,16 @@ func NewAuditor(cfg Config, notifiers []Notifier, metrics *Metrics) *Auditor {
 		agentErrorCount: make(map[string]int),
 		agentCallCount:  make(map[string]int),
 		sessionQueries:  make(map[string][]string),
+		reasoning:       make(map[string]*reasoningTrack),
+		overconfident:   make(map[string]bool),
+		lowResolution:   make(map[string]bool),
+		clockSkewed:     make(map[string]bool),
+		heartbeat:       heartbeatState{agentEvents: make(map[string]int), silent: make(map[string]bool)},
+		params:          newParamProfiler(cfg.ProfileMinCalls, cfg.ProfileOutlierZ, cfg.ProfileFile),
+		hooks:           hooks,
 		minuteStart:     time.Now(),
 		securityAlerts:  make([]SecurityAlert, 0),
+		thresholds:      cfg.thresholds(),
 	}
 }
 
@@ -690,8 +1165,25 @@ func (a *Auditor) Analyze(event *audit.Event) {
 		a.sendEventToWebhook(event)
 	}
 
+	// auditd's canary events only prove the pipeline delivers. They are part
+	// of the chain but not activity: counting them would keep a silent
+	// stream looking alive to the heartbeat.
+	if event.EventType == audit.EventTypeCanary {
+		a.checkChainIntegrity(event)
+		return
+	}
+	// outcome_timeout events are auditd reporting an agent that went quiet
+	// mid-task. They name that agent, so they are kept out of its heartbeat
+	// too, and their duration is how long auditd waited, not a slow call.
+	if event.EventType == audit.EventTypeOutcomeTimeout {
+		a.checkChainIntegrity(event)
+		a.checkOutcomeTimeout(event)
+		return
+	}
+
 	// Track for pattern analysis
 	a.trackEvent(event)
+	a.trackHeartbeat(event)
 
 	// Run detection rules
 	a.checkLowConfidence(event)
@@ -700,6 +1192,7 @@ func (a *Auditor) Analyze(event *audit.Event) {
 	a.checkLongDuration(event)
 	a.checkRepeatedQueries(event)
 	a.checkEmptyReasoning(event)
+	a.checkReasoningQuality(event)
 	a.checkDangerousAction(event)
 	a.checkApprovalStatus(event)
 	a.checkChainIntegrity(event)
@@ -708,8 +1201,20 @@ func (a *Auditor) Analyze(event *audit.Event) {
 	a.checkHighVolume(event)
 	a.checkOffHours(event)
 	a.checkUnauthorizedDestructive(event)
+	a.checkClockSkew(event)
 	a.checkTimestampGap(event)
 	a.checkFabricationMismatch(event)
+	a.checkOutOfBandChange(event)
+	a.checkBackupStale(event)
+	a.checkInventoryDrift(event)
+	a.checkApprovalSLABreach(event)
+	a.checkSelfApproval(event)
+	a.checkParamProfile(event)
+	a.checkPromptInjection(event)
+	a.checkCapabilityViolation(event)
+
+	// Site-specific detections
+	a.checkDetectHooks(event)
 }
 
 // outputJSON prints the event as a JSON line.
@@ -753,7 +1258,7 @@ func (a *Auditor) sendEventToWebhook(event *audit.Event) {
 		Timestamp: event.Timestamp,
 	}
 
-	for _, n := range a.notifiers {
+	for _, n := range a.currentNotifiers() {
 		if wh, ok := n.(*WebhookNotifier); ok {
 			if err := wh.Send(alert); err != nil {
 				slog.Warn("webhook event send failed", "err", err)
@@ -996,6 +1501,7 @@ func (a *Auditor) checkCategoryMismatch(event *audit.Event) {
 		"k8s_agent":               {"kubernetes"},
 		"incident_agent":          {"incident"},
 		"research_agent":          {"research"},
+		"kb_agent":                {"research"},
 		"gateway":                 {"fleet"},
 	}
 
@@ -1145,6 +1651,65 @@ func (a *Auditor) checkEmptyReasoning(event *audit.Event) {
 	}
 }
 
+// reasoningTrack holds an agent's reasoning quality scores: the most recent
+// window and a running baseline of every score that has left the window.
+type reasoningTrack struct {
+	recent      []float64
+	baselineSum float64
+	baselineN   int
+	alerted     bool
+}
+
+// checkReasoningQuality alerts when an agent's average reasoning score over
+// the last ReasoningWindow delegations drops ReasoningDrop or more below its
+// baseline — the signature of a silent prompt or model regression. It fires
+// once per drop and re-arms when the average recovers halfway.
+func (a *Auditor) checkReasoningQuality(event *audit.Event) {
+	if a.cfg.ReasoningWindow <= 0 || a.cfg.ReasoningDrop <= 0 {
+		return
+	}
+	if event.EventType != audit.EventTypeDelegation || event.Decision == nil || event.Decision.ReasoningQuality == nil {
+		return
+	}
+
+	agent := event.Decision.Agent
+	tr := a.reasoning[agent]
+	if tr == nil {
+		tr = &reasoningTrack{}
+		a.reasoning[agent] = tr
+	}
+	tr.recent = append(tr.recent, event.Decision.ReasoningQuality.Score)
+	if len(tr.recent) > a.cfg.ReasoningWindow {
+		tr.baselineSum += tr.recent[0]
+		tr.baselineN++
+		tr.recent = tr.recent[1:]
+	}
+	// Compare only once the baseline is at least as long as the window.
+	if tr.baselineN < a.cfg.ReasoningWindow {
+		return
+	}
+
+	var sum float64
+	for _, s := range tr.recent {
+		sum += s
+	}
+	recentAvg := sum / float64(len(tr.recent))
+	baselineAvg := tr.baselineSum / float64(tr.baselineN)
+	drop := baselineAvg - recentAvg
+
+	switch {
+	case drop >= a.cfg.ReasoningDrop && !tr.alerted:
+		tr.alerted = true
+		a.alert(AlertWarning, "reasoning quality dropped", event,
+			"agent", agent,
+			"recent_avg", fmt.Sprintf("%.2f", recentAvg),
+			"baseline_avg", fmt.Sprintf("%.2f", baselineAvg),
+			"window", a.cfg.ReasoningWindow)
+	case drop < a.cfg.ReasoningDrop/2:
+		tr.alerted = false
+	}
+}
+
 // checkDangerousAction alerts on write or destructive operations.
 // Skips pre-authorization events (tool_invoked), policy-allowed actions,
 // and auto-approved chained operations.
@@ -1232,7 +1797,8 @@ func (a *Auditor) checkChainIntegrity(event *audit.Event) {
 
 // checkHighVolume detects unusually high event rates (potential attack or data exfiltration).
 func (a *Auditor) checkHighVolume(event *audit.Event) {
-	if a.cfg.MaxEventsPerMinute <= 0 {
+	limit := a.limits().maxEventsPerMinute
+	if limit <= 0 {
 		return
 	}
 
@@ -1251,7 +1817,7 @@ func (a *Auditor) checkHighVolume(event *audit.Event) {
 	eventCount = a.eventsThisMinute
 
 	// Only alert once per minute (when we first exceed threshold)
-	if a.eventsThisMinute == a.cfg.MaxEventsPerMinute+1 {
+	if a.eventsThisMinute == limit+1 {
 		shouldAlert = true
 	}
 	a.mu.Unlock()
@@ -1259,34 +1825,23 @@ func (a *Auditor) checkHighVolume(event *audit.Event) {
 	if shouldAlert {
 		a.recordSecurityAlert("high_volume", AlertCritical, "High volume activity detected - possible attack or data exfiltration", event,
 			"events_per_minute", eventCount,
-			"threshold", a.cfg.MaxEventsPerMinute)
+			"threshold", limit)
 	}
 }
 
-// checkOffHours detects activity outside allowed operating hours.
+// checkOffHours detects activity outside allowed operating hours: outside
+// the shifts of the user's teams, or of every team for a user in none.
 func (a *Auditor) checkOffHours(event *audit.Event) {
-	if a.cfg.AllowedHoursStart < 0 || a.cfg.AllowedHoursEnd < 0 {
+	if a.cfg.WorkHours == nil {
 		return
 	}
 
-	// Convert to local time for off-hours checking
-	localTime := event.Timestamp.Local()
-	hour := localTime.Hour()
-	inAllowedHours := false
-
-	if a.cfg.AllowedHoursStart <= a.cfg.AllowedHoursEnd {
-		// Simple range (e.g., 9-17)
-		inAllowedHours = hour >= a.cfg.AllowedHoursStart && hour < a.cfg.AllowedHoursEnd
-	} else {
-		// Overnight range (e.g., 22-6)
-		inAllowedHours = hour >= a.cfg.AllowedHoursStart || hour < a.cfg.AllowedHoursEnd
-	}
-
-	if !inAllowedHours {
+	onShift, teams := a.cfg.WorkHours.UserOnShift(event.Session.UserID, event.Timestamp)
+	if !onShift {
 		a.recordSecurityAlert("off_hours", AlertWarning, "Activity detected outside allowed hours", event,
-			"event_hour_local", hour,
-			"allowed_start", a.cfg.AllowedHoursStart,
-			"allowed_end", a.cfg.AllowedHoursEnd)
+			"event_time_utc", event.Timestamp.UTC().Format(time.RFC3339),
+			"user", event.Session.UserID,
+			"teams", strings.Join(teams, ","))
 	}
 }
 
@@ -1323,6 +1878,12 @@ func (a *Auditor) checkUnauthorizedDestructive(event *audit.Event) {
 
 // checkTimestampGap detects suspicious gaps in event timestamps (potential deletion).
 func (a *Auditor) checkTimestampGap(event *audit.Event) {
+	lastReceivedAt := a.lastReceivedAt
+	if event.ReceivedAt != nil {
+		a.lastReceivedAt = *event.ReceivedAt
+	} else {
+		a.lastReceivedAt = time.Time{}
+	}
 	if a.lastEventTime.IsZero() {
 		a.lastEventTime = event.Timestamp
 		return
@@ -1344,6 +1905,11 @@ func (a *Auditor) checkTimestampGap(event *audit.Event) {
 	if gap < clockSkewTolerance {
 		if event.Outcome != nil && gap+event.Outcome.Duration >= clockSkewTolerance {
 			// Gap fully explained by how long this event ran — not anomalous.
+		} else if event.ReceivedAt != nil && !lastReceivedAt.IsZero() &&
+			event.ReceivedAt.Sub(lastReceivedAt) >= clockSkewTolerance {
+			// auditd received the events in order; only the clocks that
+			// timestamped them disagree. That is a clock problem, reported by
+			// checkClockSkew, not manipulation.
 		} else {
 			a.recordSecurityAlert("timestamp_anomaly", AlertCritical,
 				"Event timestamp is before previous event - possible manipulation", event,
@@ -1366,6 +1932,55 @@ func (a *Auditor) checkTimestampGap(event *audit.Event) {
 	a.lastEventTime = event.Timestamp
 }
 
+// checkClockSkew alerts when an event's timestamp is further from the time
+// auditd received it than -clock-skew-threshold: the agent's clock is wrong,
+// which also makes its events look out of order. Each agent alerts once and
+// re-arms when its clock is back within the threshold.
+func (a *Auditor) checkClockSkew(event *audit.Event) {
+	threshold := a.limits().clockSkew
+	if threshold <= 0 || event.ReceivedAt == nil {
+		return
+	}
+	// Events that carry a duration are posted when they complete.
+	sent := event.Timestamp
+	if event.Outcome != nil {
+		sent = sent.Add(event.Outcome.Duration)
+	}
+	skew := sent.Sub(*event.ReceivedAt)
+	agent := eventAgent(event)
+
+	a.mu.Lock()
+	if skew.Abs() <= threshold {
+		delete(a.clockSkewed, agent)
+		a.mu.Unlock()
+		return
+	}
+	if a.clockSkewed[agent] {
+		a.mu.Unlock()
+		return
+	}
+	a.clockSkewed[agent] = true
+	a.mu.Unlock()
+
+	a.recordSecurityAlert("clock_skew", AlertWarning,
+		"Agent clock is off from the audit service - check time sync on the agent host", event,
+		"skew", skew.String(),
+		"received_at", event.ReceivedAt.Format(time.RFC3339Nano),
+		"threshold", threshold.String())
+}
+
+// checkOutcomeTimeout warns about a delegation auditd marked timed_out
+// because its outcome never arrived: the agent likely crashed mid-task, and
+// whatever it was doing may be half done.
+func (a *Auditor) checkOutcomeTimeout(event *audit.Event) {
+	keyvals := []any{"delegation_event_id", event.ParentID}
+	if event.Outcome != nil {
+		keyvals = append(keyvals, "waited", event.Outcome.Duration.Round(time.Second).String())
+	}
+	a.recordSecurityAlert("outcome_timeout", AlertWarning,
+		"Delegation never recorded an outcome - agent may have crashed mid-task", event, keyvals...)
+}
+
 // checkFabricationMismatch fires a critical security alert when a gateway reports
 // that an agent returned success but the audit trail contains no matching tool
 // executions — a strong signal of LLM response fabrication.
@@ -1386,6 +2001,157 @@ func (a *Auditor) checkFabricationMismatch(event *audit.Event) {
 		"trace_id", event.TraceID)
 }
 
+// checkOutOfBandChange raises a critical alert for every out_of_band_change
+// event: auditd found a database change made with an agent's credentials that
+// no agent tool call accounts for.
+func (a *Auditor) checkOutOfBandChange(event *audit.Event) {
+	if event.EventType != audit.EventTypeOutOfBandChange || event.OutOfBandChange == nil {
+		return
+	}
+	c := event.OutOfBandChange
+	a.recordSecurityAlert("out_of_band_change", AlertCritical,
+		"OUT-OF-BAND CHANGE — agent database credentials used outside the agent",
+		event,
+		"database", c.Database,
+		"user", c.User,
+		"application_name", c.ApplicationName,
+		"command", c.Command,
+		"reason", c.Reason)
+}
+
+// checkBackupStale alerts on backup_stale events from the database agent. A
+// database with no successful backup at all is critical; an old one is a
+// warning.
+func (a *Auditor) checkBackupStale(event *audit.Event) {
+	if event.EventType != audit.EventTypeBackupStale || event.BackupStale == nil {
+		return
+	}
+	b := event.BackupStale
+	if b.LastBackup.IsZero() {
+		a.alert(AlertCritical, "NO BACKUP — database has no successful backup", event,
+			"database", b.Database,
+			"tool", b.Tool,
+			"reason", b.Reason)
+		return
+	}
+	a.alert(AlertWarning, "BACKUP STALE — last successful backup is older than its threshold", event,
+		"database", b.Database,
+		"tool", b.Tool,
+		"last_backup", b.LastBackup.Format(time.RFC3339),
+		"age_hours", b.AgeSeconds/3600,
+		"threshold_hours", b.ThresholdSeconds/3600)
+}
+
+// checkInventoryDrift warns on inventory_drift events from the inventory
+// reconciler: policy checks on a stale entry match no rule, and operators
+// are sent to systems that are gone.
+func (a *Auditor) checkInventoryDrift(event *audit.Event) {
+	if event.EventType != audit.EventTypeInventoryDrift || event.InventoryDrift == nil {
+		return
+	}
+	d := event.InventoryDrift
+	a.alert(AlertWarning, "INVENTORY DRIFT — infrastructure config does not match what is live", event,
+		"resource", d.ResourceType+"/"+d.ResourceName,
+		"cluster", d.Cluster,
+		"problem", d.Problem,
+		"detail", d.Detail)
+}
+
+// checkApprovalSLABreach alerts on approval_sla_breach events from auditd:
+// an approval request was not resolved within the SLA of its action class.
+// A breach on a destructive action is critical, so it reaches the owning
+// team's channel while the operation is still waiting.
+func (a *Auditor) checkApprovalSLABreach(event *audit.Event) {
+	if event.EventType != audit.EventTypeApprovalSLABreach || event.ApprovalSLABreach == nil {
+		return
+	}
+	b := event.ApprovalSLABreach
+	level := AlertWarning
+	if b.ActionClass == string(audit.ActionDestructive) {
+		level = AlertCritical
+	}
+	msg := "APPROVAL SLA BREACHED — request still pending past its SLA"
+	if b.Status != "pending" {
+		msg = "APPROVAL SLA BREACHED — request resolved after its SLA"
+	}
+	resource := ""
+	if b.ResourceName != "" {
+		resource = b.ResourceType + "/" + b.ResourceName
+	}
+	a.alert(level, msg, event,
+		"approval_id", b.ApprovalID,
+		"action_class", b.ActionClass,
+		"tool", b.ToolName,
+		"resource", resource,
+		"status", b.Status,
+		"sla", (time.Duration(b.SLASecs) * time.Second).String(),
+		"waited", (time.Duration(b.WaitedSecs) * time.Second).String(),
+		"requested_by", b.RequestedBy)
+}
+
+// checkSelfApproval alerts on actions approved by the principal who requested
+// them. auditd refuses such approvals, so one reaching the audit trail means
+// the four-eyes check was bypassed (a direct database write, an old auditd,
+// or an approver identity spoofed in legacy mode).
+func (a *Auditor) checkSelfApproval(event *audit.Event) {
+	ap := event.Approval
+	if ap == nil || ap.Status != audit.ApprovalApproved || !audit.IsSelfApproval(ap.RequestedBy, ap.ApprovedBy) {
+		return
+	}
+	tool := ""
+	if event.Tool != nil {
+		tool = event.Tool.Name
+	}
+	a.recordSecurityAlert("self_approval", AlertCritical, "SELF-APPROVAL — requester approved their own action", event,
+		"approval_id", ap.ApprovalID,
+		"requested_by", ap.RequestedBy,
+		"approved_by", ap.ApprovedBy,
+		"tool", tool)
+}
+
+// checkPromptInjection alerts on events whose user query or tool output
+// auditd scored as a likely prompt injection. Injection in tool output is
+// critical: it comes from a system the agent reads, possibly compromised,
+// and goes straight back into the LLM.
+func (a *Auditor) checkPromptInjection(event *audit.Event) {
+	risk := event.InjectionRisk
+	threshold := a.limits().injection
+	if threshold <= 0 || risk == nil || risk.Score < threshold {
+		return
+	}
+	level := AlertWarning
+	if slices.Contains(risk.Sources, "tool_output") {
+		level = AlertCritical
+	}
+	tool := ""
+	if event.Tool != nil {
+		tool = event.Tool.Name
+	}
+	a.recordSecurityAlert("prompt_injection", level,
+		fmt.Sprintf("PROMPT INJECTION SUSPECTED (risk %.2f) in %s", risk.Score, strings.Join(risk.Sources, ", ")),
+		event,
+		"score", risk.Score,
+		"markers", strings.Join(risk.Markers, ","),
+		"sources", strings.Join(risk.Sources, ","),
+		"agent", eventAgent(event),
+		"tool", tool)
+}
+
+// checkCapabilityViolation alerts when the gateway or orchestrator refused an
+// agent for advertising capabilities beyond its signed capability manifest:
+// the agent's card was changed outside the release process.
+func (a *Auditor) checkCapabilityViolation(event *audit.Event) {
+	v := event.GovernanceViolation
+	if event.EventType != audit.EventTypeGovernanceViolation || v == nil || v.Module != discovery.ManifestViolationModule {
+		return
+	}
+	a.recordSecurityAlert("capability_violation", AlertCritical,
+		"CAPABILITY VIOLATION — agent refused for exceeding its signed manifest",
+		event,
+		"component", event.Session.ID,
+		"description", v.Description)
+}
+
 // recordSecurityAlert records a security alert and optionally sends to incident webhook.
 func (a *Auditor) recordSecurityAlert(alertType string, level AlertLevel, message string, event *audit.Event, keyvals ...any) {
 	// Build details map
@@ -1396,14 +2162,39 @@ func (a *Auditor) recordSecurityAlert(alertType string, level AlertLevel, messag
 		}
 	}
 
+	// Apply operators' false-positive feedback on this rule, agent and resource
+	agent, resource := eventAgent(event), alertResource(event)
+	level, suppressed := a.applyFeedback(alertType, agent, resource, level)
+
+	// Planned work inside a maintenance window is expected to trip some rules
+	level, window := a.applyMaintenance(alertType, event, resource, level)
+	if window != "" {
+		details["maintenance_window"] = window
+		keyvals = append(keyvals, "maintenance_window", window)
+	}
+
 	secAlert := SecurityAlert{
+		ID:        "alert_" + uuid.New().String()[:8],
 		Type:      alertType,
 		Severity:  string(level),
 		Message:   message,
 		EventID:   event.EventID,
 		TraceID:   event.TraceID,
+		Agent:     agent,
+		Resource:  resource,
 		Details:   details,
 		Timestamp: time.Now(),
+
+		MaintenanceWindow: window,
+	}
+
+	// Report to the audit service so operators can give feedback on it
+	if a.cfg.AuditServiceURL != "" {
+		shutdown.Go(func() { a.reportAlert(secAlert, suppressed, event.Session.TenantID) })
+	}
+	if suppressed {
+		slog.Debug("alert suppressed by false-positive feedback", "alert_id", secAlert.ID, "type", alertType, "agent", agent, "resource", resource)
+		return
 	}
 
 	// Store alert
@@ -1416,16 +2207,16 @@ func (a *Auditor) recordSecurityAlert(alertType string, level AlertLevel, messag
 	a.mu.Unlock()
 
 	// Send to incident webhook if configured
-	if a.cfg.IncidentWebhookURL != "" && level == AlertCritical {
-		go a.sendSecurityIncident(secAlert)
+	if url := a.incidentWebhook(); url != "" && level == AlertCritical {
+		shutdown.Go(func() { a.sendSecurityIncident(url, secAlert) })
 	}
 
 	// Also send through normal alert mechanism
-	a.alert(level, message, event, keyvals...)
+	a.sendAlert(alertType, level, message, event, append(keyvals, "alert_id", secAlert.ID)...)
 }
 
-// sendSecurityIncident POSTs a security incident to the configured webhook.
-func (a *Auditor) sendSecurityIncident(alert SecurityAlert) {
+// sendSecurityIncident POSTs a security incident to the incident webhook url.
+func (a *Auditor) sendSecurityIncident(url string, alert SecurityAlert) {
 	incident := map[string]any{
 		"type":        "security_incident",
 		"alert_type":  alert.Type,
@@ -1445,9 +2236,9 @@ func (a *Auditor) sendSecurityIncident(alert SecurityAlert) {
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Post(a.cfg.IncidentWebhookURL, "application/json", bytes.NewReader(body))
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
-		slog.Error("failed to send security incident", "url", a.cfg.IncidentWebhookURL, "err", err)
+		slog.Error("failed to send security incident", "url", url, "err", err)
 		return
 	}
 	defer resp.Body.Close()
@@ -1460,23 +2251,107 @@ func (a *Auditor) sendSecurityIncident(alert SecurityAlert) {
 }
 
 // runPeriodicVerification periodically verifies the audit chain integrity.
-func (a *Auditor) runPeriodicVerification(auditServiceURL string, interval time.Duration) {
-	slog.Info("starting periodic chain verification", "interval", interval, "url", auditServiceURL)
+// Runs are incremental except for one full scan every fullInterval, which also
+// catches events removed from the middle of a chain segment.
+func (a *Auditor) runPeriodicVerification(auditServiceURL string, interval, fullInterval time.Duration) {
+	slog.Info("starting periodic chain verification", "interval", interval, "full_interval", fullInterval, "url", auditServiceURL)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Run once at startup
-	a.verifyChainFromService(auditServiceURL)
+	// Run a full scan once at startup
+	a.verifyChainFromService(auditServiceURL, false)
+	lastFull := time.Now()
 
 	for range ticker.C {
-		a.verifyChainFromService(auditServiceURL)
+		full := fullInterval <= 0 || time.Since(lastFull) >= fullInterval
+		a.verifyChainFromService(auditServiceURL, !full)
+		if full {
+			lastFull = time.Now()
+		}
+	}
+}
+
+// runPeriodicCalibration checks agent confidence calibration, and the
+// resolution rates users report in delegation feedback, at the given interval.
+func (a *Auditor) runPeriodicCalibration(auditServiceURL string, interval time.Duration) {
+	slog.Info("starting periodic calibration checks", "interval", interval, "window", a.cfg.CalibrationWindow, "url", auditServiceURL)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.checkCalibrationFromService(auditServiceURL)
+	a.checkResolutionFromService(auditServiceURL)
+	for range ticker.C {
+		a.checkCalibrationFromService(auditServiceURL)
+		a.checkResolutionFromService(auditServiceURL)
+	}
+}
+
+// checkCalibrationFromService fetches calibration curves from the audit
+// service's /v1/events/calibration endpoint and evaluates them.
+func (a *Auditor) checkCalibrationFromService(auditServiceURL string) {
+	url := strings.TrimSuffix(auditServiceURL, "/") + "/v1/events/calibration"
+	if a.cfg.CalibrationWindow > 0 {
+		url += "?since=" + time.Now().Add(-a.cfg.CalibrationWindow).UTC().Format(time.RFC3339)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		slog.Error("failed to fetch calibration", "url", url, "err", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("calibration request failed", "status", resp.StatusCode)
+		return
+	}
+
+	var report audit.ConfidenceCalibration
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		slog.Error("failed to parse calibration response", "err", err)
+		return
+	}
+	a.checkOverconfidence(&report)
+}
+
+// checkOverconfidence alerts when an agent becomes systematically
+// overconfident: high reported confidence, but a success rate well below it.
+// Each agent alerts once and re-arms when it is no longer overconfident.
+func (a *Auditor) checkOverconfidence(report *audit.ConfidenceCalibration) {
+	for _, c := range report.Agents {
+		if !c.IsOverconfident(a.cfg.CalibrationMinDecisions, a.cfg.OverconfidenceGap) {
+			delete(a.overconfident, c.Agent)
+			continue
+		}
+		if a.overconfident[c.Agent] {
+			continue
+		}
+		a.overconfident[c.Agent] = true
+
+		syntheticEvent := &audit.Event{
+			EventID:   fmt.Sprintf("calibration_%d", time.Now().Unix()),
+			Timestamp: time.Now(),
+			EventType: "calibration_check",
+			Decision:  &audit.Decision{Agent: c.Agent},
+		}
+		a.alert(AlertWarning, "agent is systematically overconfident", syntheticEvent,
+			"agent", c.Agent,
+			"decisions", c.Decisions,
+			"mean_confidence", fmt.Sprintf("%.2f", c.MeanConfidence),
+			"success_rate", fmt.Sprintf("%.2f", c.SuccessRate),
+			"ece", fmt.Sprintf("%.3f", c.ECE))
 	}
 }
 
 // verifyChainFromService calls the audit service's /v1/verify endpoint.
-func (a *Auditor) verifyChainFromService(auditServiceURL string) {
+func (a *Auditor) verifyChainFromService(auditServiceURL string, incremental bool) {
 	url := strings.TrimSuffix(auditServiceURL, "/") + "/v1/verify"
+	if incremental {
+		url += "?incremental=true"
+	}
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Get(url)
@@ -1499,12 +2374,15 @@ func (a *Auditor) verifyChainFromService(auditServiceURL string) {
 
 	if status.Valid {
 		slog.Info("periodic chain verification passed",
+			"incremental", status.Incremental,
+			"verified_events", status.VerifiedEvents,
 			"total_events", status.TotalEvents,
 			"hashed_events", status.HashedEvents,
 			"legacy_events", status.LegacyEvents)
 	} else {
 		// Chain is broken - this is a critical security alert
 		slog.Error("CHAIN INTEGRITY VIOLATION DETECTED",
+			"broken_segment", status.BrokenSegment,
 			"broken_at", status.BrokenAt,
 			"error", status.Error,
 			"total_events", status.TotalEvents)
@@ -1520,6 +2398,7 @@ func (a *Auditor) verifyChainFromService(auditServiceURL string) {
 		a.recordSecurityAlert("chain_tampering", AlertCritical,
 			"AUDIT CHAIN TAMPERING DETECTED - Periodic verification failed",
 			syntheticEvent,
+			"broken_segment", status.BrokenSegment,
 			"broken_at_index", status.BrokenAt,
 			"error", status.Error,
 			"total_events", status.TotalEvents,
@@ -1537,6 +2416,12 @@ const (
 )
 
 func (a *Auditor) alert(level AlertLevel, message string, event *audit.Event, keyvals ...any) {
+	a.sendAlert("", level, message, event, keyvals...)
+}
+
+// sendAlert logs an alert and hands it to the notifiers. alertType names the
+// security alert rule that raised it, if any.
+func (a *Auditor) sendAlert(alertType string, level AlertLevel, message string, event *audit.Event, keyvals ...any) {
 	// Record metric
 	if a.metrics != nil {
 		a.metrics.RecordAlert(level)
@@ -1556,12 +2441,14 @@ func (a *Auditor) alert(level AlertLevel, message string, event *audit.Event, ke
 	}
 
 	alert := Alert{
+		Type:      alertType,
 		Level:     level,
 		Message:   message,
 		EventID:   event.EventID,
 		SessionID: event.Session.ID,
 		UserID:    event.Session.UserID,
 		Agent:     agent,
+		Team:      event.Team,
 		Details:   details,
 		Timestamp: event.Timestamp,
 	}
@@ -1593,7 +2480,7 @@ func (a *Auditor) alert(level AlertLevel, message string, event *audit.Event, ke
 	}
 
 	// Send to notifiers
-	for _, n := range a.notifiers {
+	for _, n := range a.currentNotifiers() {
 		if err := n.Send(alert); err != nil {
 			slog.Warn("notifier failed", "notifier", n.Name(), "err", err)
 		}
@@ -1608,8 +2495,9 @@ func truncate(s string, maxLen int) string {
 }
 
 // runHTTPPollingMode polls the audit service HTTP API for new events.
-// It is used when the Unix socket is unavailable but -audit-service is set.
-func runHTTPPollingMode(cfg Config, auditor *Auditor) {
+// It is used when the Unix socket is unavailable but -audit-service is set,
+// and returns when ctx is done.
+func runHTTPPollingMode(ctx context.Context, cfg Config, auditor *Auditor) {
 	baseURL := strings.TrimSuffix(cfg.AuditServiceURL, "/")
 	client := &http.Client{Timeout: 15 * time.Second}
 	pollInterval := 5 * time.Second
@@ -1643,7 +2531,12 @@ func runHTTPPollingMode(cfg Config, auditor *Auditor) {
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 		since := latestSeen
 		if since.IsZero() {
 			since = time.Now().UTC().Add(-time.Minute)
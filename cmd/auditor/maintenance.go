@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// maintenanceRules are the alert types planned work is expected to trip:
+// activity at odd hours or volumes, quiet agents, changes made outside the
+// helpdesk and unusual tool parameters. Integrity and security rules such as
+// chain_tampering or prompt_injection are never softened by a window.
+var maintenanceRules = map[string]bool{
+	"off_hours":           true,
+	"high_volume":         true,
+	"agent_silent":        true,
+	"event_stream_silent": true,
+	"outcome_timeout":     true,
+	"out_of_band_change":  true,
+	"param_first_seen":    true,
+	"param_outlier":       true,
+	"timestamp_gap":       true,
+}
+
+// applyMaintenance lowers an alert one level when it falls inside a
+// maintenance window synced from the audit service, returning the new level
+// and the window's ID ("" when no window applies).
+func (a *Auditor) applyMaintenance(rule string, event *audit.Event, resource string, level AlertLevel) (AlertLevel, string) {
+	if !maintenanceRules[rule] {
+		return level, ""
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	a.mu.Lock()
+	var mw *audit.MaintenanceWindow
+	for i := range a.maintenance {
+		w := &a.maintenance[i]
+		if (w.TenantID == "" || w.TenantID == event.Session.TenantID) && w.Covers(resource, at) {
+			mw = w
+			break
+		}
+	}
+	a.mu.Unlock()
+	if mw == nil {
+		return level, ""
+	}
+	switch level {
+	case AlertCritical:
+		level = AlertWarning
+	case AlertWarning:
+		level = AlertInfo
+	}
+	return level, mw.WindowID
+}
+
+// syncMaintenanceWindows fetches the active windows from
+// /v1/maintenance-windows. On failure the previous windows stay in effect.
+func (a *Auditor) syncMaintenanceWindows(auditServiceURL string) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(auditServiceURL, "/")+"/v1/maintenance-windows?active=true", nil)
+	if err != nil {
+		slog.Error("failed to build maintenance window request", "err", err)
+		return
+	}
+	if a.cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.AuditAPIKey)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		slog.Warn("failed to fetch maintenance windows", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("maintenance window request failed", "status", resp.StatusCode)
+		return
+	}
+	var out struct {
+		Windows []audit.MaintenanceWindow `json:"windows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		slog.Warn("failed to parse maintenance windows", "err", err)
+		return
+	}
+	a.mu.Lock()
+	a.maintenance = out.Windows
+	a.mu.Unlock()
+}
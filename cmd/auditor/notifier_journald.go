@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// journaldSocket is where systemd-journald accepts native protocol datagrams.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldAvailable reports whether the journald socket exists on this host
+// (or is bind-mounted into this container).
+func journaldAvailable() bool {
+	_, err := os.Stat(journaldSocket)
+	return err == nil
+}
+
+// JournaldNotifier sends alerts to systemd-journald over its native socket
+// protocol, so it works without a syslog daemon (e.g. in containers with the
+// journal socket mounted). Alert fields are stored as HELPDESK_* journal
+// fields and can be filtered with journalctl, e.g.
+// journalctl SYSLOG_IDENTIFIER=helpdesk-auditor HELPDESK_ALERT_LEVEL=CRITICAL.
+type JournaldNotifier struct {
+	socket string
+	tag    string
+}
+
+// NewJournaldNotifier creates a notifier writing to the journald socket at
+// path.
+func NewJournaldNotifier(path, tag string) (*JournaldNotifier, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("journald socket unavailable: %w", err)
+	}
+	return &JournaldNotifier{socket: path, tag: tag}, nil
+}
+
+func (j *JournaldNotifier) Name() string { return "journald" }
+
+func (j *JournaldNotifier) Send(alert Alert) error {
+	// syslog(3) priorities: 2 = crit, 4 = warning, 6 = info.
+	priority := "6"
+	switch alert.Level {
+	case AlertCritical:
+		priority = "2"
+	case AlertWarning:
+		priority = "4"
+	}
+	ts := alert.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	var buf bytes.Buffer
+	for _, f := range [][2]string{
+		{"MESSAGE", fmt.Sprintf("[%s] %s (event=%s agent=%s user=%s)",
+			alert.Level, alert.Message, alert.EventID, alert.Agent, alert.UserID)},
+		{"PRIORITY", priority},
+		{"SYSLOG_IDENTIFIER", j.tag},
+		{"HELPDESK_ALERT_LEVEL", string(alert.Level)},
+		{"HELPDESK_EVENT_ID", alert.EventID},
+		{"HELPDESK_SESSION_ID", alert.SessionID},
+		{"HELPDESK_AGENT", alert.Agent},
+		{"HELPDESK_USER_ID", alert.UserID},
+		{"HELPDESK_ALERT_TIME", ts.UTC().Format(time.RFC3339)},
+	} {
+		if f[1] != "" {
+			writeJournalField(&buf, f[0], f[1])
+		}
+	}
+
+	conn, err := net.Dial("unixgram", j.socket)
+	if err != nil {
+		return fmt.Errorf("dial journald: %w", err)
+	}
+	defer conn.Close()
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField appends one field in the journald native format: KEY=value
+// for single-line values; KEY, newline, a little-endian uint64 length and the
+// raw value for values containing newlines.
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(key + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value))) //nolint:errcheck
+	buf.WriteString(value + "\n")
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// SyslogNotifier sends alerts to syslog.
+type SyslogNotifier struct {
+	writer *syslog.Writer
+}
+
+func NewSyslogNotifier(network, addr, tag string) (*SyslogNotifier, error) {
+	var w *syslog.Writer
+	var err error
+
+	if network == "" && addr == "" {
+		w, err = syslog.New(syslog.LOG_ALERT|syslog.LOG_DAEMON, tag)
+	} else {
+		w, err = syslog.Dial(network, addr, syslog.LOG_ALERT|syslog.LOG_DAEMON, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogNotifier{writer: w}, nil
+}
+
+func (s *SyslogNotifier) Name() string { return "syslog" }
+
+func (s *SyslogNotifier) Send(alert Alert) error {
+	msg := fmt.Sprintf("[%s] %s (event=%s agent=%s user=%s)",
+		alert.Level, alert.Message, alert.EventID, alert.Agent, alert.UserID)
+
+	switch alert.Level {
+	case AlertCritical:
+		return s.writer.Crit(msg)
+	case AlertWarning:
+		return s.writer.Warning(msg)
+	default:
+		return s.writer.Info(msg)
+	}
+}
+
+// NewEventLogNotifier is only available on Windows.
+func NewEventLogNotifier(source string) (Notifier, error) {
+	return nil, fmt.Errorf("the Windows Event Log is not available on this platform")
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Event IDs written to the Application log. EventCreate-registered sources
+// accept IDs 1–1000.
+const (
+	eventIDCritical = 1
+	eventIDWarning  = 2
+	eventIDInfo     = 3
+)
+
+// EventLogNotifier sends alerts to the Windows Event Log (Application log).
+type EventLogNotifier struct {
+	log *eventlog.Log
+}
+
+// NewEventLogNotifier opens the Event Log under source. It first tries to
+// register source with the EventCreate message file so entries render
+// without a "description cannot be found" preamble; registration needs
+// administrator rights once per machine, and alerts are still written if it
+// fails.
+func NewEventLogNotifier(source string) (Notifier, error) {
+	if err := eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		slog.Debug("event log source not registered (already registered, or not running as administrator)", "source", source, "err", err)
+	}
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("open event log source %q: %w", source, err)
+	}
+	return &EventLogNotifier{log: l}, nil
+}
+
+func (e *EventLogNotifier) Name() string { return "eventlog" }
+
+func (e *EventLogNotifier) Send(alert Alert) error {
+	msg := fmt.Sprintf("[%s] %s (event=%s agent=%s user=%s)",
+		alert.Level, alert.Message, alert.EventID, alert.Agent, alert.UserID)
+
+	switch alert.Level {
+	case AlertCritical:
+		return e.log.Error(eventIDCritical, msg)
+	case AlertWarning:
+		return e.log.Warning(eventIDWarning, msg)
+	default:
+		return e.log.Info(eventIDInfo, msg)
+	}
+}
+
+// NewSyslogNotifier is not available on Windows: log/syslog does not build
+// there. Use --syslog-backend=eventlog (the default on Windows).
+func NewSyslogNotifier(network, addr, tag string) (Notifier, error) {
+	return nil, fmt.Errorf("syslog is not available on Windows; use --syslog-backend=eventlog")
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// categoricalParams are the tool parameters whose values are tracked as a
+// set per tool: the places an agent operates in. A compromised prompt that
+// moves an agent somewhere new shows up here first.
+var categoricalParams = map[string]bool{
+	"namespace": true,
+	"database":  true,
+	"context":   true,
+	"cluster":   true,
+	"host":      true,
+	"server":    true,
+	"target":    true,
+	"schema":    true,
+}
+
+// maxProfileValues caps the distinct values kept per parameter. A parameter
+// past the cap is high-cardinality by nature and stops raising first-seen
+// alerts.
+const maxProfileValues = 1000
+
+// runningStat is a Welford running mean and variance.
+type runningStat struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	M2   float64 `json:"m2"`
+}
+
+func (s *runningStat) add(x float64) {
+	s.N++
+	d := x - s.Mean
+	s.Mean += d / float64(s.N)
+	s.M2 += d * (x - s.Mean)
+}
+
+// score returns how many spreads x lies from the mean. The spread is floored
+// at 10% of the mean (and at 1) so that a parameter that has always had the
+// same value does not flag every small change.
+func (s *runningStat) score(x float64) float64 {
+	spread := 1.0
+	if s.N > 1 {
+		spread = math.Max(spread, math.Sqrt(s.M2/float64(s.N-1)))
+	}
+	spread = math.Max(spread, 0.1*math.Abs(s.Mean))
+	return math.Abs(x-s.Mean) / spread
+}
+
+// toolProfile is the learned parameter distribution of one agent's tool.
+type toolProfile struct {
+	Calls     int                        `json:"calls"`
+	Values    map[string]map[string]bool `json:"values"`
+	Saturated map[string]bool            `json:"saturated,omitempty"`
+	Numbers   map[string]*runningStat    `json:"numbers"`
+}
+
+// paramFinding is a parameter value that does not fit its tool's profile.
+type paramFinding struct {
+	kind  string // "first_seen" or "outlier"
+	param string
+	value any
+	score float64
+	mean  float64
+}
+
+// paramProfiler learns per-tool parameter distributions from tool_execution
+// events and reports values never seen before and numeric outliers once a
+// tool has been called minCalls times.
+type paramProfiler struct {
+	minCalls int
+	outlierZ float64
+	path     string // JSON file the profile persists to ("" = memory only)
+
+	mu    sync.Mutex
+	tools map[string]*toolProfile // keyed by agent/tool
+	dirty bool
+}
+
+// newParamProfiler returns a profiler, loading any profile saved at path.
+// It returns nil when profiling is disabled (minCalls <= 0).
+func newParamProfiler(minCalls int, outlierZ float64, path string) *paramProfiler {
+	if minCalls <= 0 {
+		return nil
+	}
+	p := &paramProfiler{minCalls: minCalls, outlierZ: outlierZ, path: path, tools: make(map[string]*toolProfile)}
+	if path == "" {
+		return p
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("failed to read parameter profile; starting empty", "path", path, "err", err)
+		}
+		return p
+	}
+	if err := json.Unmarshal(data, &p.tools); err != nil {
+		slog.Warn("failed to parse parameter profile; starting empty", "path", path, "err", err)
+		p.tools = make(map[string]*toolProfile)
+	}
+	return p
+}
+
+// observe adds a tool call to the profile of key and returns the parameter
+// values that did not fit it. Nothing is reported while the tool is still
+// being learned; each first-seen value is reported once and then learned.
+func (p *paramProfiler) observe(key string, params map[string]any, rowsAffected int) []paramFinding {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dirty = true
+
+	tp := p.tools[key]
+	if tp == nil {
+		tp = &toolProfile{}
+		p.tools[key] = tp
+	}
+	if tp.Values == nil {
+		tp.Values = make(map[string]map[string]bool)
+	}
+	if tp.Numbers == nil {
+		tp.Numbers = make(map[string]*runningStat)
+	}
+	learned := tp.Calls >= p.minCalls
+	tp.Calls++
+
+	numbers := make(map[string]float64)
+	if rowsAffected > 0 {
+		numbers["rows_affected"] = float64(rowsAffected)
+	}
+
+	var findings []paramFinding
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch v := params[name].(type) {
+		case float64:
+			numbers[name] = v
+		case int:
+			numbers[name] = float64(v)
+		case string:
+			if !categoricalParams[name] || v == "" || tp.Saturated[name] {
+				continue
+			}
+			seen := tp.Values[name]
+			if seen == nil {
+				seen = make(map[string]bool)
+				tp.Values[name] = seen
+			}
+			if seen[v] {
+				continue
+			}
+			if len(seen) >= maxProfileValues {
+				if tp.Saturated == nil {
+					tp.Saturated = make(map[string]bool)
+				}
+				tp.Saturated[name] = true
+				delete(tp.Values, name)
+				continue
+			}
+			seen[v] = true
+			if learned {
+				findings = append(findings, paramFinding{kind: "first_seen", param: name, value: v})
+			}
+		}
+	}
+
+	numNames := make([]string, 0, len(numbers))
+	for name := range numbers {
+		numNames = append(numNames, name)
+	}
+	sort.Strings(numNames)
+	for _, name := range numNames {
+		x := numbers[name]
+		st := tp.Numbers[name]
+		if st == nil {
+			st = &runningStat{}
+			tp.Numbers[name] = st
+		}
+		if learned && st.N >= p.minCalls && p.outlierZ > 0 {
+			if z := st.score(x); z > p.outlierZ {
+				findings = append(findings, paramFinding{kind: "outlier", param: name, value: x, score: z, mean: st.Mean})
+			}
+		}
+		st.add(x)
+	}
+	return findings
+}
+
+// save writes the profile to its file if it changed since the last save.
+// The file is replaced atomically so a crash never leaves it truncated.
+func (p *paramProfiler) save() error {
+	p.mu.Lock()
+	if p.path == "" || !p.dirty {
+		p.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(p.tools)
+	p.dirty = false
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal parameter profile: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), ".param-profile-*")
+	if err != nil {
+		return fmt.Errorf("write parameter profile: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write parameter profile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write parameter profile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write parameter profile: %w", err)
+	}
+	return nil
+}
+
+// runParamProfileSaver persists the parameter profile every interval.
+func (a *Auditor) runParamProfileSaver(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := a.params.save(); err != nil {
+			slog.Warn("failed to save parameter profile", "err", err)
+		}
+	}
+}
+
+// checkParamProfile alerts when a tool is called with a parameter value its
+// agent has never used (e.g. a namespace the k8s agent has never touched) or
+// with a numeric value far outside its usual range. First-seen values on
+// write or destructive calls are critical: they are how lateral movement
+// through a compromised prompt looks in the audit trail.
+func (a *Auditor) checkParamProfile(event *audit.Event) {
+	if a.params == nil || event.EventType != audit.EventTypeToolExecution || event.Tool == nil {
+		return
+	}
+	agent := event.Tool.Agent
+	if agent == "" {
+		agent = eventAgent(event)
+	}
+	tool := event.Tool.Name
+	for _, f := range a.params.observe(agent+"/"+tool, event.Tool.Parameters, event.Tool.RowsAffected) {
+		switch f.kind {
+		case "first_seen":
+			level := AlertWarning
+			if event.ActionClass == audit.ActionWrite || event.ActionClass == audit.ActionDestructive {
+				level = AlertCritical
+			}
+			a.recordSecurityAlert("param_first_seen", level,
+				fmt.Sprintf("First-seen %s %q for %s (%s)", f.param, f.value, tool, agent),
+				event,
+				"agent", agent,
+				"tool", tool,
+				"param", f.param,
+				"value", f.value,
+				"action_class", string(event.ActionClass))
+		case "outlier":
+			a.recordSecurityAlert("param_outlier", AlertWarning,
+				fmt.Sprintf("Unusual %s=%v for %s (%s), usually around %.0f", f.param, f.value, tool, agent, f.mean),
+				event,
+				"agent", agent,
+				"tool", tool,
+				"param", f.param,
+				"value", f.value,
+				"mean", f.mean,
+				"score", f.score)
+		}
+	}
+}
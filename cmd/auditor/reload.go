@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"helpdesk/internal/infra"
+	"helpdesk/internal/secrets"
+)
+
+// fileConfig is the -config file: notifier settings and alert thresholds
+// that override the flags of the same name. It is re-read on SIGHUP, so a
+// webhook URL or SMTP setting can change without restarting the auditor and
+// losing its in-memory pattern state. Unset keys keep their flag value.
+type fileConfig struct {
+	Webhook         *string `yaml:"webhook"`
+	IncidentWebhook *string `yaml:"incident_webhook"`
+
+	SMTPHost     *string `yaml:"smtp_host"`
+	SMTPPort     *string `yaml:"smtp_port"`
+	SMTPUser     *string `yaml:"smtp_user"`
+	SMTPPassword *string `yaml:"smtp_password"` // or a secrets reference (vault://, file://, ...)
+	EmailFrom    *string `yaml:"email_from"`
+	EmailTo      *string `yaml:"email_to"`
+	SMSTo        *string `yaml:"sms_to"`
+	NotifyPlugin *string `yaml:"notify_plugin"` // comma-separated executable paths
+
+	MaxEventsPerMinute *int           `yaml:"max_events_per_minute"`
+	InjectionThreshold *float64       `yaml:"injection_threshold"`
+	ClockSkewThreshold *time.Duration `yaml:"clock_skew_threshold"`
+}
+
+// loadConfigFile returns cfg with the settings of the -config file at path
+// applied, and the team webhooks of the -infra-config at infraPath. Either
+// path may be empty.
+func loadConfigFile(cfg Config, path, infraPath string) (Config, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("read config: %w", err)
+		}
+		var fc fileConfig
+		if err := yaml.Unmarshal(data, &fc); err != nil {
+			return cfg, fmt.Errorf("parse config %s: %w", path, err)
+		}
+		if cfg, err = fc.apply(cfg); err != nil {
+			return cfg, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	if infraPath != "" {
+		ic, err := infra.Load(infraPath)
+		if err != nil {
+			return cfg, fmt.Errorf("infra config: %w", err)
+		}
+		cfg.TeamWebhooks = ic.TeamWebhooks()
+	}
+	return cfg, nil
+}
+
+// apply returns cfg with the settings present in the file overriding it.
+func (fc fileConfig) apply(cfg Config) (Config, error) {
+	set := func(dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&cfg.WebhookURL, fc.Webhook)
+	set(&cfg.IncidentWebhookURL, fc.IncidentWebhook)
+	set(&cfg.SMTPHost, fc.SMTPHost)
+	set(&cfg.SMTPPort, fc.SMTPPort)
+	set(&cfg.SMTPUser, fc.SMTPUser)
+	set(&cfg.EmailFrom, fc.EmailFrom)
+	set(&cfg.EmailTo, fc.EmailTo)
+	set(&cfg.SMSTo, fc.SMSTo)
+	set(&cfg.NotifyPlugins, fc.NotifyPlugin)
+	if fc.SMTPPassword != nil {
+		password, err := secrets.Value(context.Background(), *fc.SMTPPassword)
+		if err != nil {
+			return cfg, fmt.Errorf("resolve smtp_password: %w", err)
+		}
+		cfg.SMTPPassword = password
+	}
+
+	if fc.MaxEventsPerMinute != nil {
+		if *fc.MaxEventsPerMinute < 0 {
+			return cfg, fmt.Errorf("max_events_per_minute must not be negative")
+		}
+		cfg.MaxEventsPerMinute = *fc.MaxEventsPerMinute
+	}
+	if fc.InjectionThreshold != nil {
+		if *fc.InjectionThreshold < 0 || *fc.InjectionThreshold > 1 {
+			return cfg, fmt.Errorf("injection_threshold must be between 0 and 1")
+		}
+		cfg.InjectionThreshold = *fc.InjectionThreshold
+	}
+	if fc.ClockSkewThreshold != nil {
+		if *fc.ClockSkewThreshold < 0 {
+			return cfg, fmt.Errorf("clock_skew_threshold must not be negative")
+		}
+		cfg.ClockSkewThreshold = *fc.ClockSkewThreshold
+	}
+	return cfg, nil
+}
+
+// thresholds are the alert thresholds that a SIGHUP reload and auditd's
+// runtime configuration (GET /v1/config, the auditor.* settings) can change
+// without a restart.
+type thresholds struct {
+	maxEventsPerMinute int
+	injection          float64
+	clockSkew          time.Duration
+}
+
+// thresholds returns the thresholds set by flags and the -config file.
+func (c Config) thresholds() thresholds {
+	return thresholds{
+		maxEventsPerMinute: c.MaxEventsPerMinute,
+		injection:          c.InjectionThreshold,
+		clockSkew:          c.ClockSkewThreshold,
+	}
+}
+
+// limits returns the thresholds in effect.
+func (a *Auditor) limits() thresholds {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.thresholds
+}
+
+// currentNotifiers returns the notifiers in effect.
+func (a *Auditor) currentNotifiers() []Notifier {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.notifiers
+}
+
+// incidentWebhook returns the incident webhook URL in effect.
+func (a *Auditor) incidentWebhook() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg.IncidentWebhookURL
+}
+
+// watchReload reloads the configuration on every SIGHUP until ctx is done.
+// flags is the configuration given by flags, before the -config file.
+func (a *Auditor) watchReload(ctx context.Context, flags Config, path, infraPath string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("SIGHUP received; reloading auditor configuration", "config", path, "infra_config", infraPath)
+			if err := a.reload(flags, path, infraPath); err != nil {
+				slog.Error("auditor configuration reload failed; keeping the previous configuration", "err", err)
+			}
+		}
+	}
+}
+
+// reload re-reads the -config and -infra-config files and swaps in the
+// notifiers, incident webhook and alert thresholds they give. Event
+// consumption and pattern state are untouched. On error nothing changes.
+func (a *Auditor) reload(flags Config, path, infraPath string) error {
+	cfg, err := loadConfigFile(flags, path, infraPath)
+	if err != nil {
+		return err
+	}
+	notifiers := buildNotifiers(cfg)
+
+	// The notifiers carry the webhook, email, SMS and plugin settings; of the
+	// rest, only the incident webhook and the thresholds are read after
+	// startup, always under a.mu.
+	a.mu.Lock()
+	a.notifiers = notifiers
+	a.cfg.IncidentWebhookURL = cfg.IncidentWebhookURL
+	a.cfg.MaxEventsPerMinute = cfg.MaxEventsPerMinute
+	a.cfg.InjectionThreshold = cfg.InjectionThreshold
+	a.cfg.ClockSkewThreshold = cfg.ClockSkewThreshold
+	a.thresholds = cfg.thresholds()
+	a.mu.Unlock()
+
+	// Runtime overrides from auditd take precedence over the file; re-apply
+	// them now rather than at the next sync.
+	if a.cfg.AuditServiceURL != "" && a.cfg.FPSyncInterval > 0 {
+		a.syncRuntimeConfig(a.cfg.AuditServiceURL)
+	}
+
+	t := a.limits()
+	slog.Info("auditor configuration reloaded",
+		"notifiers", len(notifiers),
+		"webhook", cfg.WebhookURL != "",
+		"team_webhooks", len(cfg.TeamWebhooks),
+		"incident_webhook", cfg.IncidentWebhookURL != "",
+		"email", cfg.SMTPHost != "" && cfg.EmailTo != "",
+		"max_events_per_minute", t.maxEventsPerMinute,
+		"injection_threshold", t.injection,
+		"clock_skew_threshold", t.clockSkew.String())
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func writeAuditorConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auditor.yaml")
+	writeAuditorConfig(t, path, `
+webhook: https://hooks.example.com/new
+smtp_host: smtp.example.com
+email_to: oncall@example.com
+max_events_per_minute: 200
+clock_skew_threshold: 1m
+`)
+	flags := Config{WebhookURL: "https://hooks.example.com/old", SMTPPort: "587", InjectionThreshold: 0.5, MaxEventsPerMinute: 100}
+
+	cfg, err := loadConfigFile(flags, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WebhookURL != "https://hooks.example.com/new" || cfg.SMTPHost != "smtp.example.com" || cfg.EmailTo != "oncall@example.com" {
+		t.Errorf("notifier settings = %+v", cfg)
+	}
+	if cfg.SMTPPort != "587" || cfg.InjectionThreshold != 0.5 {
+		t.Errorf("unset keys changed the flag values: port %q, injection %v", cfg.SMTPPort, cfg.InjectionThreshold)
+	}
+	if cfg.MaxEventsPerMinute != 200 || cfg.ClockSkewThreshold != time.Minute {
+		t.Errorf("thresholds = %d, %s", cfg.MaxEventsPerMinute, cfg.ClockSkewThreshold)
+	}
+
+	for _, bad := range []string{"injection_threshold: 2", "max_events_per_minute: -1", "webhook: [", "clock_skew_threshold: soon"} {
+		writeAuditorConfig(t, path, bad)
+		if _, err := loadConfigFile(flags, path, ""); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auditor.yaml")
+	writeAuditorConfig(t, path, "max_events_per_minute: 100\n")
+	flags := Config{MaxEventsPerMinute: 10}
+	cfg, err := loadConfigFile(flags, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuditor(cfg, buildNotifiers(cfg), nil)
+	a.Analyze(&audit.Event{EventID: "evt_1", Timestamp: time.Now().UTC(), EventType: "delegation_decision"})
+
+	writeAuditorConfig(t, path, "webhook: https://hooks.example.com/alerts\nincident_webhook: https://ir.example.com\nmax_events_per_minute: 500\n")
+	if err := a.reload(flags, path, ""); err != nil {
+		t.Fatal(err)
+	}
+	notifiers := a.currentNotifiers()
+	if len(notifiers) != 1 || notifiers[0].(*WebhookNotifier).URL != "https://hooks.example.com/alerts" {
+		t.Errorf("notifiers = %+v, want the new webhook", notifiers)
+	}
+	if got := a.limits().maxEventsPerMinute; got != 500 {
+		t.Errorf("max events per minute = %d, want 500", got)
+	}
+	if a.incidentWebhook() != "https://ir.example.com" {
+		t.Errorf("incident webhook = %q", a.incidentWebhook())
+	}
+	if len(a.recentEvents) != 1 {
+		t.Errorf("recent events = %d, want the pattern state kept across the reload", len(a.recentEvents))
+	}
+
+	// A broken file leaves the configuration in effect.
+	writeAuditorConfig(t, path, "max_events_per_minute: -5\n")
+	if err := a.reload(flags, path, ""); err == nil {
+		t.Fatal("reload of an invalid config succeeded")
+	}
+	if got := a.limits().maxEventsPerMinute; got != 500 || len(a.currentNotifiers()) != 1 {
+		t.Errorf("after a failed reload: max events %d, %d notifiers; want 500 and 1", got, len(a.currentNotifiers()))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syncRuntimeConfig fetches /v1/config and applies the auditor.* overrides
+// to the flag thresholds. A setting whose override was removed goes back to
+// its flag (or -config file) value. On failure the previous thresholds stay
+// in effect.
+func (a *Auditor) syncRuntimeConfig(auditServiceURL string) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(auditServiceURL, "/")+"/v1/config", nil)
+	if err != nil {
+		slog.Error("failed to build runtime config request", "err", err)
+		return
+	}
+	if a.cfg.AuditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.AuditAPIKey)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		slog.Warn("failed to fetch runtime config", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return // auditd predates runtime configuration
+	}
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("runtime config request failed", "status", resp.StatusCode)
+		return
+	}
+	var out struct {
+		Settings []struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		} `json:"settings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		slog.Warn("failed to parse runtime config", "err", err)
+		return
+	}
+
+	a.mu.Lock()
+	t := a.cfg.thresholds() // a SIGHUP reload may have changed the flag values
+	a.mu.Unlock()
+	for _, s := range out.Settings {
+		if s.Source != "runtime" {
+			continue
+		}
+		var err error
+		switch s.Key {
+		case "auditor.max_events_per_minute":
+			t.maxEventsPerMinute, err = strconv.Atoi(s.Value)
+		case "auditor.injection_threshold":
+			t.injection, err = strconv.ParseFloat(s.Value, 64)
+		case "auditor.clock_skew_threshold":
+			t.clockSkew, err = time.ParseDuration(s.Value)
+		}
+		if err != nil {
+			slog.Warn("ignoring runtime config with an invalid value", "key", s.Key, "value", s.Value, "err", err)
+			return
+		}
+	}
+
+	a.mu.Lock()
+	old := a.thresholds
+	a.thresholds = t
+	a.mu.Unlock()
+	if old != t {
+		slog.Info("alert thresholds changed by runtime config",
+			"max_events_per_minute", t.maxEventsPerMinute,
+			"injection_threshold", t.injection,
+			"clock_skew_threshold", t.clockSkew.String())
+	}
+}
@@ -10,12 +10,16 @@ import (
 	"strings"
 	"time"
 
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
 	"helpdesk/internal/fleet"
 	"helpdesk/internal/infra"
 	"helpdesk/internal/logging"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	remaining := logging.InitLogging(os.Args[1:])
 
 	var (
@@ -39,6 +43,11 @@ func main() {
 	flag.CommandLine.Var(&replanFlag, "replan", `On schema drift, replan from stored plan_description.\n--replan writes fresh plan to --job-file and stops; --replan=auto also re-executes`)
 	flag.CommandLine.Parse(remaining) //nolint:errcheck
 
+	if err := audit.InstallAuditToken(context.Background(), *auditURL); err != nil {
+		slog.Error("failed to configure the auditd access token", "err", err)
+		os.Exit(1)
+	}
+
 	if *jobFile == "" && *planDescription == "" {
 		slog.Error("--job-file or --plan-description is required")
 		flag.Usage()
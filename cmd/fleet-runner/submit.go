@@ -160,6 +160,7 @@ func recordJobOutcome(ctx context.Context, auditURL, auditAPIKey, jobID, errMsg
 		"event_type": "gateway_request",
 		"trace_id":   traceID,
 		"timestamp":  time.Now().UTC().Format(time.RFC3339Nano),
+		"producer":   audit.CurrentProducer(),
 		"outcome": map[string]any{
 			"status":        "error",
 			"error_message": errMsg,
@@ -40,7 +40,7 @@ func resolveTargets(cfg *infra.Config, targets fleet.Targets) ([]string, error)
 		// Include if any tag matches.
 		if len(targets.Tags) > 0 {
 			for _, wantTag := range targets.Tags {
-				for _, serverTag := range server.Tags {
+				for _, serverTag := range cfg.DatabaseTags(server) {
 					if serverTag == wantTag {
 						selected = append(selected, serverKey)
 						goto nextServer
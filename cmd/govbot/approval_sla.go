@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// slaAttainment counts, for one action class, the approval requests of the
+// window with an SLA (auditd -approval-sla) that were resolved within it and
+// that missed it. Requests still pending within their SLA are not counted.
+type slaAttainment struct {
+	ActionClass string
+	Met         int
+	Missed      int
+}
+
+// pct is the share of requests that met the SLA, in percent.
+func (s slaAttainment) pct() float64 {
+	return 100 * float64(s.Met) / float64(s.Met+s.Missed)
+}
+
+// summarizeApprovalSLA returns the SLA attainment of approvals per action
+// class, most breached first.
+func summarizeApprovalSLA(approvals []*audit.StoredApproval, now time.Time) []slaAttainment {
+	byClass := map[string]*slaAttainment{}
+	for _, a := range approvals {
+		if a.SLADueAt.IsZero() {
+			continue
+		}
+		breached := a.SLABreached(now)
+		if !breached && a.Status == "pending" {
+			continue
+		}
+		s := byClass[a.ActionClass]
+		if s == nil {
+			s = &slaAttainment{ActionClass: a.ActionClass}
+			byClass[a.ActionClass] = s
+		}
+		if breached {
+			s.Missed++
+		} else {
+			s.Met++
+		}
+	}
+	out := make([]slaAttainment, 0, len(byClass))
+	for _, s := range byClass {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if pi, pj := out[i].pct(), out[j].pct(); pi != pj {
+			return pi < pj
+		}
+		return out[i].ActionClass < out[j].ActionClass
+	})
+	return out
+}
+
+// formatSLAAttainment renders attainment for the summary, e.g.
+// "destructive 92% (23/25), write 100% (4/4)".
+func formatSLAAttainment(attainment []slaAttainment) string {
+	parts := make([]string, len(attainment))
+	for i, s := range attainment {
+		parts[i] = fmt.Sprintf("%s %.0f%% (%d/%d)", s.ActionClass, s.pct(), s.Met, s.Met+s.Missed)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// approvalSLAWarnings returns one warning per action class whose approvals
+// missed their SLA in the window.
+func approvalSLAWarnings(attainment []slaAttainment) []string {
+	var warnings []string
+	for _, s := range attainment {
+		if s.Missed > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%d of %d %s approval(s) missed their SLA (%.0f%% attainment) — check that approvers are notified and on call",
+				s.Missed, s.Met+s.Missed, s.ActionClass, s.pct()))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestSummarizeApprovalSLA(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	apr := func(class, status string, requested, resolved time.Duration) *audit.StoredApproval {
+		a := &audit.StoredApproval{ActionClass: class, Status: status,
+			RequestedAt: now.Add(-requested), SLADueAt: now.Add(-requested + 15*time.Minute)}
+		if status != "pending" {
+			a.ResolvedAt = a.RequestedAt.Add(resolved)
+		}
+		return a
+	}
+	approvals := []*audit.StoredApproval{
+		apr("destructive", "approved", time.Hour, 5*time.Minute),
+		apr("destructive", "approved", time.Hour, 10*time.Minute),
+		apr("destructive", "denied", time.Hour, 20*time.Minute), // resolved late
+		apr("destructive", "pending", time.Hour, 0),             // still pending past its SLA
+		apr("destructive", "pending", time.Minute, 0),           // pending within its SLA: not counted
+		apr("write", "approved", time.Hour, time.Minute),
+		{ActionClass: "write", Status: "approved", RequestedAt: now.Add(-time.Hour), ResolvedAt: now}, // no SLA
+	}
+
+	got := summarizeApprovalSLA(approvals, now)
+	if len(got) != 2 || got[0] != (slaAttainment{"destructive", 2, 2}) || got[1] != (slaAttainment{"write", 1, 0}) {
+		t.Fatalf("attainment = %+v", got)
+	}
+	if s := formatSLAAttainment(got); s != "destructive 50% (2/4), write 100% (1/1)" {
+		t.Errorf("summary = %q", s)
+	}
+	warnings := approvalSLAWarnings(got)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "2 of 4 destructive approval(s) missed their SLA (50% attainment)") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// rubberStampMinApprovals is the number of human decisions in the window
+// below which approver concentration is not reported: with only a handful of
+// approvals one approver handling most of them is expected.
+const rubberStampMinApprovals = 10
+
+// approverStats is one approver's decisions on the approval requests of the
+// window.
+type approverStats struct {
+	Approver      string
+	Approved      int
+	Denied        int
+	SelfApprovals int           // approved requests the approver had made themselves
+	MedianWait    time.Duration // median time from request to the approver's decision
+}
+
+// summarizeApprovers returns the decisions per approver, busiest first.
+// Only approved and denied requests count; expired and cancelled ones have
+// no approver.
+func summarizeApprovers(approvals []*audit.StoredApproval) []approverStats {
+	byApprover := map[string]*approverStats{}
+	waits := map[string][]time.Duration{}
+	for _, a := range approvals {
+		if (a.Status != "approved" && a.Status != "denied") || a.ResolvedBy == "" {
+			continue
+		}
+		s := byApprover[a.ResolvedBy]
+		if s == nil {
+			s = &approverStats{Approver: a.ResolvedBy}
+			byApprover[a.ResolvedBy] = s
+		}
+		if a.Status == "approved" {
+			s.Approved++
+			if audit.IsSelfApproval(a.RequestedBy, a.ResolvedBy) {
+				s.SelfApprovals++
+			}
+		} else {
+			s.Denied++
+		}
+		if wait, ok := a.TimeToResolution(); ok {
+			waits[a.ResolvedBy] = append(waits[a.ResolvedBy], wait)
+		}
+	}
+	out := make([]approverStats, 0, len(byApprover))
+	for name, s := range byApprover {
+		if w := waits[name]; len(w) > 0 {
+			sort.Slice(w, func(i, j int) bool { return w[i] < w[j] })
+			s.MedianWait = w[len(w)/2]
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ni, nj := out[i].Approved+out[i].Denied, out[j].Approved+out[j].Denied; ni != nj {
+			return ni > nj
+		}
+		return out[i].Approver < out[j].Approver
+	})
+	return out
+}
+
+// printApprovers logs one line per approver with their share of the
+// window's approvals.
+func printApprovers(stats []approverStats) {
+	total := 0
+	for _, s := range stats {
+		total += s.Approved
+	}
+	for _, s := range stats {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(s.Approved) / float64(total)
+		}
+		self := ""
+		if s.SelfApprovals > 0 {
+			self = fmt.Sprintf("  ⚠ %d SELF-APPROVED", s.SelfApprovals)
+		}
+		logf("  %-30s  approved=%-4d denied=%-4d share=%3.0f%%  median_wait=%s%s",
+			s.Approver, s.Approved, s.Denied, share, s.MedianWait.Round(time.Second), self)
+	}
+}
+
+// approverWarnings flags separation-of-duties problems: approvals granted by
+// their own requester (alerts), and a single approver granting most of the
+// window's approvals, a sign of rubber-stamping (warning).
+func approverWarnings(stats []approverStats) (alerts, warnings []string) {
+	total := 0
+	for _, s := range stats {
+		total += s.Approved
+	}
+	for _, s := range stats {
+		if s.SelfApprovals > 0 {
+			alerts = append(alerts, fmt.Sprintf(
+				"%s approved %d request(s) they made themselves — four-eyes check bypassed",
+				s.Approver, s.SelfApprovals))
+		}
+		if total >= rubberStampMinApprovals && 2*s.Approved > total {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s granted %d of %d approvals (%.0f%%) with %d denial(s) — spread approvals across the team",
+				s.Approver, s.Approved, total, 100*float64(s.Approved)/float64(total), s.Denied))
+		}
+	}
+	return alerts, warnings
+}
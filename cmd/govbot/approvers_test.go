@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestSummarizeApprovers(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	apr := func(status, requestedBy, resolvedBy string, wait time.Duration) *audit.StoredApproval {
+		a := &audit.StoredApproval{Status: status, RequestedBy: requestedBy, ResolvedBy: resolvedBy,
+			RequestedAt: now.Add(-time.Hour)}
+		if status != "pending" {
+			a.ResolvedAt = a.RequestedAt.Add(wait)
+		}
+		return a
+	}
+	var approvals []*audit.StoredApproval
+	for i := 0; i < 9; i++ {
+		approvals = append(approvals, apr("approved", "alice", "bob", time.Duration(i+1)*time.Minute))
+	}
+	approvals = append(approvals,
+		apr("approved", "Carol", "carol", 30*time.Second), // self-approval
+		apr("denied", "alice", "carol", 2*time.Minute),
+		apr("approved", "dave", "carol", 4*time.Minute),
+		apr("expired", "alice", "", 0),
+		apr("pending", "alice", "", 0),
+	)
+
+	got := summarizeApprovers(approvals)
+	if len(got) != 2 {
+		t.Fatalf("approvers = %+v, want bob and carol", got)
+	}
+	if got[0].Approver != "bob" || got[0].Approved != 9 || got[0].Denied != 0 || got[0].MedianWait != 5*time.Minute {
+		t.Errorf("bob = %+v", got[0])
+	}
+
+	if got[1].Approver != "carol" || got[1].Approved != 2 || got[1].Denied != 1 || got[1].SelfApprovals != 1 {
+		t.Errorf("carol = %+v", got[1])
+	}
+
+	alerts, warnings := approverWarnings(got)
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "carol approved 1 request(s) they made themselves") {
+		t.Errorf("alerts = %q", alerts)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "bob granted 9 of 11 approvals (82%)") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestApproverWarnings_FewApprovals(t *testing.T) {
+	// Below rubberStampMinApprovals a single approver is not flagged.
+	_, warnings := approverWarnings([]approverStats{{Approver: "bob", Approved: 5}})
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %q, want none", warnings)
+	}
+}
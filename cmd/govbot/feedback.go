@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// An agent underperforms when it has at least lowResolutionMinFeedback
+// verdicts in the window and users report fewer than half as resolved.
+const (
+	lowResolutionMinFeedback = 5
+	lowResolutionRate        = 0.5
+)
+
+// agentResolution is one agent's delegation feedback this window, with the
+// previous window of the same length for comparison.
+type agentResolution struct {
+	audit.AgentFeedbackStats
+	Previous *audit.AgentFeedbackStats // nil when the agent had no feedback in the previous window
+	Low      bool
+}
+
+// getDelegationFeedback fetches per-agent delegation feedback for [since, until).
+func getDelegationFeedback(gateway string, since, until time.Time) ([]audit.AgentFeedbackStats, error) {
+	path := "/api/v1/governance/events/feedback/stats?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339)) +
+		"&until=" + url.QueryEscape(until.UTC().Format(time.RFC3339))
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Agents []audit.AgentFeedbackStats `json:"agents"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode delegation feedback: %w", err)
+	}
+	return out.Agents, nil
+}
+
+// analyzeResolution pairs each agent with feedback this window with its
+// previous window and flags agents whose delegations mostly did not help.
+func analyzeResolution(current, previous []audit.AgentFeedbackStats) []agentResolution {
+	prev := make(map[string]audit.AgentFeedbackStats, len(previous))
+	for _, p := range previous {
+		prev[p.Agent] = p
+	}
+	var out []agentResolution
+	for _, c := range current {
+		r := agentResolution{AgentFeedbackStats: c}
+		if p, ok := prev[c.Agent]; ok && p.Feedback > 0 {
+			r.Previous = &p
+		}
+		r.Low = c.IsUnderperforming(lowResolutionMinFeedback, lowResolutionRate)
+		out = append(out, r)
+	}
+	return out
+}
+
+func printResolution(results []agentResolution, window string) {
+	logf("Delegation feedback (this %s vs the previous %s):", window, window)
+	logf("  %-28s  %8s  %8s  %12s  %10s  %9s", "Agent", "Feedback", "Resolved", "Not resolved", "Rate", "Previous")
+	for _, r := range results {
+		previous, flag := "-", ""
+		if r.Previous != nil {
+			previous = fmt.Sprintf("%.0f%%", r.Previous.ResolutionRate*100)
+		}
+		if r.Low {
+			flag = "  ⚠ LOW"
+		}
+		logf("  %-28s  %8d  %8d  %12d  %9.0f%%  %9s%s",
+			truncate(r.Agent, 28), r.Feedback, r.Resolved, r.NotResolved, r.ResolutionRate*100, previous, flag)
+	}
+}
+
+// lowResolutionWarning describes an underperforming agent for the
+// Compliance Summary.
+func lowResolutionWarning(r agentResolution, window string) string {
+	return fmt.Sprintf("agent %s did not resolve the problem in %d of %d delegations users rated this %s window (resolution rate %.0f%%) — review its recent traces",
+		r.Agent, r.NotResolved, r.Feedback, window, r.ResolutionRate*100)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestAnalyzeResolution(t *testing.T) {
+	current := []audit.AgentFeedbackStats{
+		{Agent: "k8s_agent", Feedback: 8, Resolved: 2, NotResolved: 6, ResolutionRate: 0.25},
+		{Agent: "research_agent", Feedback: 2, NotResolved: 2},
+		{Agent: "postgres_database_agent", Feedback: 12, Resolved: 11, NotResolved: 1, ResolutionRate: 0.92},
+	}
+	previous := []audit.AgentFeedbackStats{
+		{Agent: "k8s_agent", Feedback: 10, Resolved: 8, NotResolved: 2, ResolutionRate: 0.8},
+	}
+	got := analyzeResolution(current, previous)
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3", len(got))
+	}
+	if !got[0].Low || got[0].Previous == nil || got[0].Previous.ResolutionRate != 0.8 {
+		t.Errorf("k8s_agent = %+v, want low with previous 0.8", got[0])
+	}
+	if got[1].Low {
+		t.Error("research_agent flagged low with fewer than lowResolutionMinFeedback verdicts")
+	}
+	if got[2].Low || got[2].Previous != nil {
+		t.Errorf("postgres_database_agent = %+v, want not low and no previous", got[2])
+	}
+	if w := lowResolutionWarning(got[0], "24h"); !strings.Contains(w, "6 of 8") {
+		t.Errorf("warning = %q", w)
+	}
+}
@@ -25,6 +25,7 @@ type runSnapshot struct {
 	RunAt                time.Time
 	Window               string
 	Gateway              string
+	Tenant               string // empty = all tenants
 	Status               string // "healthy" | "warnings" | "alerts"
 	AlertCount           int
 	WarningCount         int
@@ -39,6 +40,11 @@ type runSnapshot struct {
 	StaleApprovals       int
 	DecisionsByResource    string // JSON object: resource → {allow,deny,...}
 	InvocationsByResource  string // JSON object: "resource:action" → {invoked,checked}
+	UncontrolledTraces     int
+	ToolExecutions         int
+	ToolErrors             int
+	ApprovalsResolved      int
+	ApprovalLatencySecs    int // mean request→resolution time of ApprovalsResolved
 }
 
 // historyClient abstracts compliance-run persistence.
@@ -147,7 +153,12 @@ func (h *localHistoryStore) createSchema() error {
     pending_approvals     INTEGER NOT NULL DEFAULT 0,
     stale_approvals       INTEGER NOT NULL DEFAULT 0,
     decisions_by_resource   TEXT,
-    invocations_by_resource TEXT
+    invocations_by_resource TEXT,
+    uncontrolled_traces   INTEGER NOT NULL DEFAULT 0,
+    tool_executions       INTEGER NOT NULL DEFAULT 0,
+    tool_errors           INTEGER NOT NULL DEFAULT 0,
+    approvals_resolved    INTEGER NOT NULL DEFAULT 0,
+    approval_latency_secs INTEGER NOT NULL DEFAULT 0
 )`, pk),
 		`CREATE INDEX IF NOT EXISTS idx_govbot_runs_run_at ON govbot_runs(run_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_govbot_runs_window  ON govbot_runs(window)`,
@@ -164,6 +175,14 @@ func (h *localHistoryStore) createSchema() error {
 	} else {
 		h.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN invocations_by_resource TEXT`) //nolint:errcheck
 	}
+	// The trend metric columns were added later still.
+	for _, col := range audit.GovbotTrendColumns {
+		if h.isPostgres {
+			h.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN IF NOT EXISTS ` + col + ` INTEGER NOT NULL DEFAULT 0`) //nolint:errcheck
+		} else {
+			h.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN ` + col + ` INTEGER NOT NULL DEFAULT 0`) //nolint:errcheck
+		}
+	}
 	return nil
 }
 
@@ -176,8 +195,10 @@ func (h *localHistoryStore) save(snap runSnapshot, retain int) error {
 		 chain_valid, policy_denies, policy_no_match,
 		 mutations_total, mutations_destructive,
 		 pending_approvals, stale_approvals,
-		 decisions_by_resource, invocations_by_resource)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		 decisions_by_resource, invocations_by_resource,
+		 uncontrolled_traces, tool_executions, tool_errors,
+		 approvals_resolved, approval_latency_secs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 
 	chainInt := 0
 	if snap.ChainValid {
@@ -201,6 +222,11 @@ func (h *localHistoryStore) save(snap runSnapshot, retain int) error {
 		snap.StaleApprovals,
 		snap.DecisionsByResource,
 		snap.InvocationsByResource,
+		snap.UncontrolledTraces,
+		snap.ToolExecutions,
+		snap.ToolErrors,
+		snap.ApprovalsResolved,
+		snap.ApprovalLatencySecs,
 	); err != nil {
 		return fmt.Errorf("insert govbot run: %w", err)
 	}
@@ -228,7 +254,9 @@ func (h *localHistoryStore) recent(window string, n int) ([]runSnapshot, error)
 		chain_valid, policy_denies, policy_no_match,
 		mutations_total, mutations_destructive,
 		pending_approvals, stale_approvals,
-		decisions_by_resource, invocations_by_resource`
+		decisions_by_resource, invocations_by_resource,
+		uncontrolled_traces, tool_executions, tool_errors,
+		approvals_resolved, approval_latency_secs`
 
 	if window != "" {
 		q = h.rebind(`SELECT ` + cols + `
@@ -260,6 +288,8 @@ func (h *localHistoryStore) recent(window string, n int) ([]runSnapshot, error)
 			&s.MutationsTotal, &s.MutationsDestructive,
 			&s.PendingApprovals, &s.StaleApprovals,
 			&s.DecisionsByResource, &s.InvocationsByResource,
+			&s.UncontrolledTraces, &s.ToolExecutions, &s.ToolErrors,
+			&s.ApprovalsResolved, &s.ApprovalLatencySecs,
 		); err != nil {
 			return nil, fmt.Errorf("scan govbot run: %w", err)
 		}
@@ -292,17 +322,20 @@ func (h *localHistoryStore) close() {
 type remoteHistoryClient struct {
 	auditURL   string // trimmed, no trailing slash
 	gatewayURL string
+	tenant     string
 	apiKey     string // Bearer token for auditd authentication
 	client     *http.Client
 }
 
 // openRemoteHistory creates a remoteHistoryClient targeting auditURL. gatewayURL
 // is the govbot's own gateway base URL and is included in query filters so that
-// a central IT deployment can distinguish runs from different teams.
-func openRemoteHistory(auditURL, gatewayURL, apiKey string) *remoteHistoryClient {
+// a central IT deployment can distinguish runs from different teams. tenant,
+// when set, narrows the trend block to runs reported for that tenant.
+func openRemoteHistory(auditURL, gatewayURL, tenant, apiKey string) *remoteHistoryClient {
 	return &remoteHistoryClient{
 		auditURL:   strings.TrimRight(auditURL, "/"),
 		gatewayURL: gatewayURL,
+		tenant:     tenant,
 		apiKey:     apiKey,
 		client:     &http.Client{Timeout: 10 * time.Second},
 	}
@@ -341,7 +374,7 @@ func (r *remoteHistoryClient) save(snap runSnapshot, retain int) error {
 }
 
 // recent retrieves the last n runs from auditd, filtered by window and this
-// client's gateway URL.
+// client's gateway URL and tenant.
 func (r *remoteHistoryClient) recent(window string, n int) ([]runSnapshot, error) {
 	u := fmt.Sprintf("%s/v1/govbot/runs?limit=%d", r.auditURL, n)
 	if window != "" {
@@ -350,6 +383,9 @@ func (r *remoteHistoryClient) recent(window string, n int) ([]runSnapshot, error
 	if r.gatewayURL != "" {
 		u += "&gateway=" + url.QueryEscape(r.gatewayURL)
 	}
+	if r.tenant != "" {
+		u += "&tenant_id=" + url.QueryEscape(r.tenant)
+	}
 	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return nil, fmt.Errorf("GET /v1/govbot/runs: %w", err)
@@ -434,6 +470,7 @@ func snapToRun(s runSnapshot) audit.GovbotRun {
 		RunAt:                s.RunAt,
 		Window:               s.Window,
 		Gateway:              s.Gateway,
+		Tenant:               s.Tenant,
 		Status:               s.Status,
 		AlertCount:           s.AlertCount,
 		WarningCount:         s.WarningCount,
@@ -448,6 +485,11 @@ func snapToRun(s runSnapshot) audit.GovbotRun {
 		StaleApprovals:       s.StaleApprovals,
 		DecisionsByResource:   s.DecisionsByResource,
 		InvocationsByResource: s.InvocationsByResource,
+		UncontrolledTraces:    s.UncontrolledTraces,
+		ToolExecutions:        s.ToolExecutions,
+		ToolErrors:            s.ToolErrors,
+		ApprovalsResolved:     s.ApprovalsResolved,
+		ApprovalLatencySecs:   s.ApprovalLatencySecs,
 	}
 }
 
@@ -457,6 +499,7 @@ func runToSnap(r audit.GovbotRun) runSnapshot {
 		RunAt:                r.RunAt,
 		Window:               r.Window,
 		Gateway:              r.Gateway,
+		Tenant:               r.Tenant,
 		Status:               r.Status,
 		AlertCount:           r.AlertCount,
 		WarningCount:         r.WarningCount,
@@ -471,6 +514,11 @@ func runToSnap(r audit.GovbotRun) runSnapshot {
 		StaleApprovals:       r.StaleApprovals,
 		DecisionsByResource:   r.DecisionsByResource,
 		InvocationsByResource: r.InvocationsByResource,
+		UncontrolledTraces:    r.UncontrolledTraces,
+		ToolExecutions:        r.ToolExecutions,
+		ToolErrors:            r.ToolErrors,
+		ApprovalsResolved:     r.ApprovalsResolved,
+		ApprovalLatencySecs:   r.ApprovalLatencySecs,
 	}
 }
 
@@ -221,6 +221,38 @@ func TestHistory_InvocationsByResource(t *testing.T) {
 	}
 }
 
+// TestHistory_TrendMetrics verifies the trend analysis metrics round-trip.
+func TestHistory_TrendMetrics(t *testing.T) {
+	h, err := openHistory(tempDB(t))
+	if err != nil {
+		t.Fatalf("openHistory: %v", err)
+	}
+	defer h.close()
+
+	s := makeSnap("warnings", "24h", 1, 0, true)
+	s.UncontrolledTraces = 2
+	s.ToolExecutions = 40
+	s.ToolErrors = 3
+	s.ApprovalsResolved = 4
+	s.ApprovalLatencySecs = 610
+	if err := h.save(s, 0); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	snaps, err := h.recent("24h", 1)
+	if err != nil || len(snaps) != 1 {
+		t.Fatalf("recent: %v (%d snaps)", err, len(snaps))
+	}
+	got := snaps[0]
+	if got.UncontrolledTraces != 2 || got.ToolExecutions != 40 || got.ToolErrors != 3 ||
+		got.ApprovalsResolved != 4 || got.ApprovalLatencySecs != 610 {
+		t.Errorf("trend metrics = %+v", got)
+	}
+	if run := snapToRun(s); runToSnap(run).ApprovalLatencySecs != 610 || run.ToolErrors != 3 {
+		t.Errorf("snapToRun/runToSnap lost trend metrics: %+v", run)
+	}
+}
+
 // TestHistory_PrintTable verifies that printTable runs without error on a
 // populated database (basic smoke test for the table rendering path).
 func TestHistory_PrintTable(t *testing.T) {
@@ -271,7 +303,7 @@ func TestHistory_RemoteClient(t *testing.T) {
 	srv := httptest.NewServer(mux)
 	defer srv.Close()
 
-	rc := openRemoteHistory(srv.URL, "http://gateway:8080", "")
+	rc := openRemoteHistory(srv.URL, "http://gateway:8080", "", "")
 
 	snap := makeSnap("healthy", "24h", 3, 15, true)
 	if err := rc.save(snap, 0); err != nil {
@@ -325,7 +357,7 @@ func TestHistory_RemoteClient_Retain(t *testing.T) {
 	srv := httptest.NewServer(mux)
 	defer srv.Close()
 
-	rc := openRemoteHistory(srv.URL, "http://gateway:8080", "")
+	rc := openRemoteHistory(srv.URL, "http://gateway:8080", "", "")
 	snap := makeSnap("healthy", "24h", 0, 0, true)
 	if err := rc.save(snap, 30); err != nil {
 		t.Fatalf("save: %v", err)
@@ -335,6 +367,47 @@ func TestHistory_RemoteClient_Retain(t *testing.T) {
 	}
 }
 
+// TestHistory_RemoteClient_Tenant verifies that a tenant-scoped client sends
+// the tenant with each saved run and filters the trend query by it.
+func TestHistory_RemoteClient_Tenant(t *testing.T) {
+	var savedTenant, queriedTenant string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/govbot/runs", func(w http.ResponseWriter, r *http.Request) {
+		var run audit.GovbotRun
+		if err := json.NewDecoder(r.Body).Decode(&run); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		savedTenant = run.Tenant
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /v1/govbot/runs", func(w http.ResponseWriter, r *http.Request) {
+		queriedTenant = r.URL.Query().Get("tenant_id")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]audit.GovbotRun{}) //nolint:errcheck
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	rc := openRemoteHistory(srv.URL, "http://gateway:8080", "acme", "")
+	snap := makeSnap("healthy", "24h", 0, 0, true)
+	snap.Tenant = "acme"
+	if err := rc.save(snap, 0); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := rc.recent("24h", 5); err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+	if savedTenant != "acme" {
+		t.Errorf("saved tenant = %q, want acme", savedTenant)
+	}
+	if queriedTenant != "acme" {
+		t.Errorf("tenant_id query param = %q, want acme", queriedTenant)
+	}
+}
+
 // TestCoveragePct verifies that coveragePct correctly parses the
 // InvocationsByResource JSON and computes checked/invoked × 100.
 func TestCoveragePct(t *testing.T) {
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// inventoryDrift is one resource the inventory reconciler found not live in
+// the window, with how often and when it was last found so.
+type inventoryDrift struct {
+	audit.InventoryDrift
+	Runs     int
+	LastSeen time.Time
+	NoMatch  int // policy decisions on the resource that fell to policy_name=default
+}
+
+// key is the resource as policy decisions name it: resource_type/resource_name.
+func (d inventoryDrift) key() string {
+	return d.ResourceType + "/" + d.ResourceName
+}
+
+// getInventoryDrift fetches the inventory_drift events since since.
+func getInventoryDrift(gateway string, since time.Time, limit int) ([]audit.Event, error) {
+	path := fmt.Sprintf("/api/v1/governance/events?event_type=%s&since=%s&limit=%d",
+		audit.EventTypeInventoryDrift, url.QueryEscape(since.UTC().Format(time.RFC3339)), limit)
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var events []audit.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("decode inventory drift events: %w", err)
+	}
+	return events, nil
+}
+
+// summarizeInventoryDrift groups drift events by resource and problem,
+// keeping the latest detail, and joins them with the resource's count of
+// policy decisions that matched no rule.
+func summarizeInventoryDrift(events []audit.Event, noMatch map[string]int) []inventoryDrift {
+	byKey := map[string]*inventoryDrift{}
+	for _, e := range events {
+		if e.InventoryDrift == nil {
+			continue
+		}
+		d := *e.InventoryDrift
+		k := d.Cluster + "\x00" + d.ResourceType + "/" + d.ResourceName + "\x00" + d.Problem
+		s := byKey[k]
+		if s == nil {
+			s = &inventoryDrift{InventoryDrift: d}
+			byKey[k] = s
+		}
+		s.Runs++
+		if e.Timestamp.After(s.LastSeen) {
+			s.LastSeen, s.Detail = e.Timestamp, d.Detail
+		}
+	}
+	out := make([]inventoryDrift, 0, len(byKey))
+	for _, s := range byKey {
+		s.NoMatch = noMatch[s.key()]
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].key() != out[j].key() {
+			return out[i].key() < out[j].key()
+		}
+		return out[i].Cluster < out[j].Cluster
+	})
+	return out
+}
+
+func printInventoryDrift(drift []inventoryDrift) {
+	logf("Inventory drift (%d resource(s) in the infrastructure config not live):", len(drift))
+	logf("  %-32s  %-12s  %-17s  %4s  %-11s  %8s", "Resource", "Cluster", "Problem", "Runs", "Last seen", "no_match")
+	for _, d := range drift {
+		logf("  %-32s  %-12s  %-17s  %4d  %-11s  %8d",
+			truncate(d.key(), 32), truncate(d.Cluster, 12), d.Problem, d.Runs, d.LastSeen.Format("01-02 15:04"), d.NoMatch)
+		if d.Detail != "" {
+			logf("    %s", truncate(d.Detail, 100))
+		}
+	}
+}
+
+// inventoryDriftWarnings returns a warning per drifted resource; the ones
+// with default-policy decisions are the likely cause of those decisions.
+func inventoryDriftWarnings(drift []inventoryDrift) []string {
+	var out []string
+	for _, d := range drift {
+		w := fmt.Sprintf("inventory drift: %s is %s", d.key(), d.Problem)
+		if d.Cluster != "" && d.Cluster != d.ResourceName {
+			w += " on cluster " + d.Cluster
+		}
+		if d.NoMatch > 0 {
+			w += fmt.Sprintf(" — %d policy decision(s) on it matched no rule (policy_name=default); fix or remove its infrastructure config entry", d.NoMatch)
+		} else {
+			w += " — fix or remove its infrastructure config entry"
+		}
+		out = append(out, w)
+	}
+	return out
+}
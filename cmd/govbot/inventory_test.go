@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestSummarizeInventoryDrift(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	drift := func(at time.Time, d audit.InventoryDrift) audit.Event {
+		return audit.Event{EventType: audit.EventTypeInventoryDrift, Timestamp: at, InventoryDrift: &d}
+	}
+	events := []audit.Event{
+		drift(t0.Add(2*time.Hour), audit.InventoryDrift{ResourceType: "database", ResourceName: "legacy", Problem: "unreachable", Detail: "timeout"}),
+		drift(t0, audit.InventoryDrift{ResourceType: "database", ResourceName: "legacy", Problem: "unreachable", Detail: "refused"}),
+		drift(t0, audit.InventoryDrift{ResourceType: "kubernetes", ResourceName: "payments", Cluster: "prod", Problem: "namespace_missing"}),
+		{EventType: audit.EventTypePolicyDecision},
+	}
+	got := summarizeInventoryDrift(events, map[string]int{"database/legacy": 4})
+	if len(got) != 2 {
+		t.Fatalf("got %d resources, want 2: %+v", len(got), got)
+	}
+	if got[0].key() != "database/legacy" || got[0].Runs != 2 || got[0].Detail != "timeout" || got[0].NoMatch != 4 {
+		t.Errorf("legacy = %+v, want 2 runs, latest detail, 4 no-match decisions", got[0])
+	}
+	if got[1].key() != "kubernetes/payments" || got[1].NoMatch != 0 {
+		t.Errorf("payments = %+v", got[1])
+	}
+
+	warnings := inventoryDriftWarnings(got)
+	if len(warnings) != 2 ||
+		!strings.Contains(warnings[0], "4 policy decision(s) on it matched no rule") ||
+		!strings.Contains(warnings[1], "namespace_missing on cluster prod") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
@@ -10,6 +10,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -21,14 +22,35 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/cliout"
+	"helpdesk/internal/i18n"
 )
 
 // gatewayAPIKey is the Bearer token sent to all gateway requests.
 // Set via -api-key flag or HELPDESK_CLIENT_API_KEY env var.
 var gatewayAPIKey string
 
+// gatewayTenant restricts every gateway query to one tenant when set.
+// Set via -tenant flag or HELPDESK_TENANT env var.
+var gatewayTenant string
+
+// reportLocales and reportLocale localize the phase titles, the summary and
+// the webhook post. Set via -locale/-locale-dir or HELPDESK_LOCALE and
+// HELPDESK_LOCALE_DIR; findings themselves stay English.
+var (
+	reportLocales *i18n.Bundle
+	reportLocale  string
+)
+
+// tr returns report message key in the report locale.
+func tr(key string, vars i18n.Vars) string {
+	return reportLocales.T(reportLocale, key, vars)
+}
+
 // ── Response types mirroring the gateway/auditd JSON shapes ──────────────────
 
 type governanceInfo struct {
@@ -87,6 +109,8 @@ type integrityStatus struct {
 // ── Main ─────────────────────────────────────────────────────────────────────
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	gateway       := flag.String("gateway", "http://localhost:8080", "Gateway base URL")
 	apiKey        := flag.String("api-key", os.Getenv("HELPDESK_CLIENT_API_KEY"), "Bearer token for gateway authentication")
 	auditAPIKey   := flag.String("audit-api-key", os.Getenv("HELPDESK_AUDIT_API_KEY"), "Bearer token for auditd authentication (used with -audit-url)")
@@ -97,8 +121,30 @@ func main() {
 	historyDB     := flag.String("history-db", "", "Path to govbot history database (SQLite path or postgres:// DSN). Used when -audit-url is not set.")
 	showHistory   := flag.Int("show-history", 0, "Print last N compliance runs and exit (requires -audit-url or -history-db)")
 	historyRetain := flag.Int("history-retain", 365, "Maximum number of runs to keep in local history database (ignored when -audit-url is set)")
+	tenant        := flag.String("tenant", os.Getenv("HELPDESK_TENANT"), "Report on a single tenant only (default: all tenants visible to the API key)")
+	trendRuns     := flag.Int("trend-runs", 8, "Number of previous runs the trend analysis compares this run against (requires -audit-url or -history-db)")
+	trendHTMLPath := flag.String("trend-html", "", "Also write the trend analysis as an HTML page with sparkline charts to this path")
+	locale        := flag.String("locale", os.Getenv("HELPDESK_LOCALE"), "Language of the report and webhook summary, e.g. en (default), de or es")
+	localeDir     := flag.String("locale-dir", os.Getenv("HELPDESK_LOCALE_DIR"), "Directory of <locale>.json message catalogs adding to or overriding the built-in ones (optional)")
+	var output cliout.Format
+	cliout.Register(flag.CommandLine, &output)
 	flag.Parse()
 	gatewayAPIKey = *apiKey
+	gatewayTenant = *tenant
+	if err := audit.InstallAuditToken(context.Background(), *auditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "could not configure the auditd access token: %v\n", err)
+		os.Exit(1)
+	}
+	bundle, err := i18n.Load(*localeDir, *locale)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -locale or -locale-dir: %v\n", err)
+		os.Exit(1)
+	}
+	reportLocales, reportLocale = bundle, bundle.Default()
+	if output.Structured() {
+		// stdout carries only the report; the phase log goes to stderr.
+		logOut = os.Stderr
+	}
 
 	// ── History: --show-history short-circuit ────────────────────────────────
 	// Reads and prints stored runs without contacting the gateway.
@@ -106,7 +152,7 @@ func main() {
 		var sh historyClient
 		switch {
 		case *auditURL != "":
-			sh = openRemoteHistory(*auditURL, "", *auditAPIKey) // no gateway filter: show all runs
+			sh = openRemoteHistory(*auditURL, "", *tenant, *auditAPIKey) // no gateway filter: show all runs
 		case *historyDB != "":
 			lh, err := openHistory(*historyDB)
 			if err != nil {
@@ -119,6 +165,17 @@ func main() {
 			fmt.Fprintln(os.Stderr, "-show-history requires -audit-url or -history-db")
 			os.Exit(1)
 		}
+		if output.Structured() {
+			snaps, err := sh.recent("", *showHistory)
+			if err == nil {
+				err = writeHistory(os.Stdout, output, snaps)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "print history: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if err := sh.printTable("", *showHistory); err != nil {
 			fmt.Fprintf(os.Stderr, "print history: %v\n", err)
 			os.Exit(1)
@@ -130,7 +187,7 @@ func main() {
 	var hist historyClient
 	switch {
 	case *auditURL != "":
-		hist = openRemoteHistory(*auditURL, *gateway, *auditAPIKey)
+		hist = openRemoteHistory(*auditURL, *gateway, *tenant, *auditAPIKey)
 	case *historyDB != "":
 		lh, herr := openHistory(*historyDB)
 		if herr != nil {
@@ -146,6 +203,7 @@ func main() {
 		RunAt:   time.Now().UTC(),
 		Window:  *sinceStr,
 		Gateway: *gateway,
+		Tenant:  *tenant,
 	}
 
 	since, err := parseLookback(*sinceStr)
@@ -155,6 +213,9 @@ func main() {
 	}
 
 	logf("Gateway:   %s", *gateway)
+	if *tenant != "" {
+		logf("Tenant:    %s", *tenant)
+	}
 	logf("Since:     last %s", *sinceStr)
 	logf("Webhook:   %v", *webhook != "")
 	logf("Dry run:   %v", *dryRun)
@@ -166,13 +227,13 @@ func main() {
 	default:
 		logf("History:   disabled")
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	var alerts []string
 	var warnings []string
 
 	// ── Phase 1: Governance Status ────────────────────────────────────────────
-	logPhase(1, "Governance Status")
+	logPhase(1, nil)
 
 	info, err := getGovernanceInfo(*gateway)
 	if err != nil {
@@ -205,10 +266,10 @@ func main() {
 
 	snap.ChainValid = info.Audit.ChainValid
 	snap.PendingApprovals = info.Approvals.PendingCount
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 2: Policy Overview ──────────────────────────────────────────────
-	logPhase(2, "Policy Overview")
+	logPhase(2, nil)
 
 	if !auditConfigured {
 		logf("Policy status unknown — requires governance data from auditd")
@@ -235,10 +296,10 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 3: Audit Activity ───────────────────────────────────────────────
-	logPhase(3, fmt.Sprintf("Audit Activity (last %s)", *sinceStr))
+	logPhase(3, i18n.Vars{"window": *sinceStr})
 
 	sinceTime := time.Now().Add(-since)
 	events, err := getEvents(*gateway, sinceTime, 1000)
@@ -250,12 +311,37 @@ func main() {
 			warnings = append(warnings, fmt.Sprintf("Failed to fetch audit events: %v", err))
 		}
 	} else {
-		logf("Events fetched:   %d", len(events))
-
-		// Count by event type
+		// Exact counts come from the SQL-side aggregation; the fetched events
+		// feed the per-event analysis below. Older auditd builds without the
+		// stats endpoint fall back to counting the fetched events.
 		typeCounts := make(map[string]int)
-		for _, e := range events {
-			typeCounts[string(e.EventType)]++
+		var orphans []audit.AgentOrphans
+		if stats, err := getEventStats(*gateway, sinceTime, time.Time{}); err == nil {
+			typeCounts = stats.ByType
+			orphans = stats.Orphans
+			for _, n := range stats.ByActionClass {
+				snap.ToolExecutions += n
+			}
+			snap.ToolErrors = stats.ToolErrors
+			logf("Events in window: %d", stats.TotalEvents)
+			logf("Events fetched:   %d", len(events))
+			if stats.TotalEvents > len(events) {
+				warnings = append(warnings, fmt.Sprintf(
+					"Only the most recent %d of %d events in the window were analyzed in detail — shorten -since for full coverage",
+					len(events), stats.TotalEvents,
+				))
+			}
+		} else {
+			logf("Events fetched:   %d", len(events))
+			for _, e := range events {
+				typeCounts[string(e.EventType)]++
+				if e.EventType == audit.EventTypeToolExecution {
+					snap.ToolExecutions++
+					if e.Outcome != nil && e.Outcome.Status == "error" {
+						snap.ToolErrors++
+					}
+				}
+			}
 		}
 		types := make([]string, 0, len(typeCounts))
 		for t := range typeCounts {
@@ -265,12 +351,26 @@ func main() {
 		for _, t := range types {
 			logf("  %-30s %d", t, typeCounts[t])
 		}
+
+		// Delegations auditd marked timed_out: the agent never reported back,
+		// usually because it crashed mid-task.
+		if len(orphans) > 0 {
+			logf("Orphaned delegations (no outcome recorded):")
+			for _, o := range orphans {
+				logf("  %-30s %d of %d (%.1f%%)", o.Agent, o.Orphaned, o.Delegations, o.Rate*100)
+				warnings = append(warnings, fmt.Sprintf(
+					"%d of %d delegations to %s never recorded an outcome (%.1f%%) — the agent may be crashing mid-task",
+					o.Orphaned, o.Delegations, o.Agent, o.Rate*100,
+				))
+			}
+		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 4: Policy Decision Analysis ────────────────────────────────────
-	logPhase(4, "Policy Decision Analysis")
+	logPhase(4, nil)
 
+	noMatchByResource := map[string]int{}
 	if len(events) > 0 {
 		type resourceStats struct {
 			allow           int
@@ -369,6 +469,7 @@ func main() {
 
 			for _, res := range resources {
 				rs := byResource[res]
+				noMatchByResource[res] = rs.noMatch
 				noMatchStr := ""
 				if rs.noMatch > 0 {
 					noMatchStr = fmt.Sprintf("%6d ⚠", rs.noMatch)
@@ -384,7 +485,7 @@ func main() {
 
 		// Denial / require-approval detail — security officer view
 		if len(blockedEvents) > 0 {
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("Blocked request details (%d):", len(blockedEvents))
 			for _, b := range blockedEvents {
 				logf("  [%s]  %s  action=%-12s  %s", strings.ToUpper(b.effect), b.timestamp, b.action, b.resource)
@@ -407,7 +508,7 @@ func main() {
 
 		// Unattributable decisions — no trace_id, cannot link to any session or origin
 		if unattributableDecisions > 0 {
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("Unattributable decisions: %d  ⚠", unattributableDecisions)
 			logf("  These policy decisions have no trace_id — they cannot be linked to")
 			logf("  any session, user, or call origin. Likely from agents using a local")
@@ -437,10 +538,20 @@ func main() {
 	} else {
 		logf("No events available for analysis")
 	}
-	fmt.Println()
+
+	// Infrastructure config entries the inventory reconciler found not live:
+	// decisions on them fall to policy_name=default.
+	if driftEvents, err := getInventoryDrift(*gateway, sinceTime, 1000); err != nil {
+		logf("WARNING: Could not fetch inventory drift: %v", err)
+	} else if drift := summarizeInventoryDrift(driftEvents, noMatchByResource); len(drift) > 0 {
+		fmt.Fprintln(logOut)
+		printInventoryDrift(drift)
+		warnings = append(warnings, inventoryDriftWarnings(drift)...)
+	}
+	fmt.Fprintln(logOut)
 
 	// ── Phase 5: Agent Enforcement Coverage ──────────────────────────────────
-	logPhase(5, "Agent Enforcement Coverage")
+	logPhase(5, nil)
 
 	// Cross-reference tool_execution and policy_decision events by trace_id.
 	// A trace that has tool executions but zero policy decisions means the agent
@@ -487,6 +598,7 @@ func main() {
 			}
 		}
 		sort.Strings(uncontrolledTraces)
+		snap.UncontrolledTraces = len(uncontrolledTraces)
 
 		controlled := len(traceHasToolExec) - len(uncontrolledTraces)
 		logf("Traces with tool executions: %d", len(traceHasToolExec))
@@ -525,7 +637,7 @@ func main() {
 		}
 
 		// Sub-check B: chk_* ratio
-		fmt.Println()
+		fmt.Fprintln(logOut)
 		logf("Policy decisions in window:  %d  (+ %d unattributable)", totalPolicyDecisions, unattributablePolicyDecisions)
 		if totalPolicyDecisions > 0 {
 			agentDecisions := totalPolicyDecisions - chkPolicyDecisions
@@ -551,10 +663,10 @@ func main() {
 			))
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 6: Pending Approvals ────────────────────────────────────────────
-	logPhase(6, "Pending Approvals")
+	logPhase(6, nil)
 
 	pending, err := getPendingApprovals(*gateway)
 	if err != nil {
@@ -591,10 +703,42 @@ func main() {
 		}
 		snap.StaleApprovals = staleCount
 	}
-	fmt.Println()
+
+	// Time to resolution of the approvals requested in the window feeds the
+	// approval latency trend; those with an SLA give its attainment, shown
+	// in the summary. Decisions per approver expose self-approvals and a
+	// single approver rubber-stamping most requests.
+	slaSummary := ""
+	if recentApprovals, err := getApprovalsSince(*gateway, sinceTime, 1000); err == nil {
+		var totalLatency time.Duration
+		for _, a := range recentApprovals {
+			if (a.Status == "approved" || a.Status == "denied") && !a.ResolvedAt.IsZero() {
+				totalLatency += a.ResolvedAt.Sub(a.RequestedAt)
+				snap.ApprovalsResolved++
+			}
+		}
+		if snap.ApprovalsResolved > 0 {
+			mean := totalLatency / time.Duration(snap.ApprovalsResolved)
+			snap.ApprovalLatencySecs = int(mean.Seconds())
+			logf("Resolved in window: %d  (mean time to resolution %s)", snap.ApprovalsResolved, mean.Round(time.Second))
+		}
+		if attainment := summarizeApprovalSLA(recentApprovals, time.Now()); len(attainment) > 0 {
+			slaSummary = formatSLAAttainment(attainment)
+			logf("SLA attainment: %s", slaSummary)
+			warnings = append(warnings, approvalSLAWarnings(attainment)...)
+		}
+		if approvers := summarizeApprovers(recentApprovals); len(approvers) > 0 {
+			logf("Decisions per approver:")
+			printApprovers(approvers)
+			approverAlerts, approverWarns := approverWarnings(approvers)
+			alerts = append(alerts, approverAlerts...)
+			warnings = append(warnings, approverWarns...)
+		}
+	}
+	fmt.Fprintln(logOut)
 
 	// ── Phase 7: Chain Integrity ──────────────────────────────────────────────
-	logPhase(7, "Chain Integrity")
+	logPhase(7, nil)
 
 	if !auditConfigured {
 		logf("Skipped — audit service not configured")
@@ -624,10 +768,10 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 8: Mutation Activity ────────────────────────────────────────────
-	logPhase(8, fmt.Sprintf("Mutation Activity (last %s)", *sinceStr))
+	logPhase(8, i18n.Vars{"window": *sinceStr})
 
 	if len(events) == 0 {
 		logf("No events available for mutation analysis")
@@ -683,19 +827,7 @@ func main() {
 		}
 
 		// Fetch previous period for day-over-day comparison.
-		prevStart := sinceTime.Add(-since)
-		prevEvents, prevErr := getEvents(*gateway, prevStart, 2000)
-		prevCount := 0
-		if prevErr == nil {
-			for i := range prevEvents {
-				e := &prevEvents[i]
-				if e.Timestamp.Before(sinceTime) &&
-					e.EventType == audit.EventTypeToolExecution &&
-					(e.ActionClass == audit.ActionWrite || e.ActionClass == audit.ActionDestructive) {
-					prevCount++
-				}
-			}
-		}
+		prevCount, prevErr := countMutations(*gateway, sinceTime.Add(-since), sinceTime)
 
 		totalMutations := len(mutations)
 
@@ -731,7 +863,7 @@ func main() {
 			logf("No write or destructive tool executions in this window")
 		} else {
 			// By class
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("By class:")
 			logf("  write:          %d", writeCount)
 			logf("  destructive:    %d", destructiveCount)
@@ -751,7 +883,7 @@ func main() {
 				}
 				return toolList[i].name < toolList[j].name
 			})
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("By tool:")
 			for i, t := range toolList {
 				if i >= 10 {
@@ -762,7 +894,7 @@ func main() {
 			}
 
 			// Hourly breakdown — fixed-width two-row grid
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("Hourly breakdown (UTC, 00–23):")
 			var hdrBuf, valBuf strings.Builder
 			hdrBuf.WriteString("  ")
@@ -798,7 +930,7 @@ func main() {
 			}
 
 			// By user
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("By user:")
 			var userList []kv
 			for user, count := range userCounts {
@@ -815,12 +947,12 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 9: Policy Coverage Analysis ────────────────────────────────────
-	logPhase(9, "Policy Coverage Analysis")
+	logPhase(9, nil)
 	logf("Note: reflects database + k8s agents only (incident + research not instrumented)")
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	if len(events) == 0 {
 		logf("No events available for coverage analysis")
@@ -889,7 +1021,7 @@ func main() {
 				logf("All %d resource-action pair(s) fully covered ✓", len(keys))
 			} else {
 				logf("Uncovered invocations (tool_invoked with no matching policy_decision):")
-				fmt.Println()
+				fmt.Fprintln(logOut)
 				for _, k := range gapKeys {
 					cs := byCov[k]
 					gap := cs.invoked - cs.checked
@@ -905,7 +1037,7 @@ func main() {
 						severity, k.resource, k.action, cs.invoked, cs.checked, gap, pct)
 				}
 				if fullyChecked > 0 {
-					fmt.Println()
+					fmt.Fprintln(logOut)
 					logf("  %d other resource-action pair(s): fully covered", fullyChecked)
 				}
 				for _, k := range gapKeys {
@@ -957,7 +1089,7 @@ func main() {
 					}
 				}
 				if len(deadRules) > 0 {
-					fmt.Println()
+					fmt.Fprintln(logOut)
 					logf("Dead policy rules (policy exists but no invocations observed):")
 					for _, dr := range deadRules {
 						logf("  ⚠ WARN  %s", dr)
@@ -967,12 +1099,12 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 10: Identity Coverage ───────────────────────────────────────────
-	logPhase(10, "Identity Coverage")
+	logPhase(10, nil)
 	logf("Checks what fraction of policy decisions carry verified identity (user_id/service).")
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	if len(events) == 0 {
 		logf("No events available for identity coverage analysis")
@@ -1021,7 +1153,7 @@ func main() {
 				logf("  ⚠ WARN  some requests are reaching the policy engine without identity")
 			}
 
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("Policy decisions with purpose:   %d / %d  (%d%%)", withPurpose, totalPol, purposePct)
 			if writeDestructiveTotal > 0 {
 				wdPct := writeDestructiveWithPurpose * 100 / writeDestructiveTotal
@@ -1036,12 +1168,12 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	// ── Phase 11: Purpose Coverage ────────────────────────────────────────────
-	logPhase(11, "Purpose Coverage")
+	logPhase(11, nil)
 	logf("Checks declared purposes on sensitive and write/destructive operations.")
-	fmt.Println()
+	fmt.Fprintln(logOut)
 
 	if len(events) == 0 {
 		logf("No events available for purpose coverage analysis")
@@ -1083,7 +1215,7 @@ func main() {
 		}
 
 		if sensitiveTotal > 0 {
-			fmt.Println()
+			fmt.Fprintln(logOut)
 			logf("Sensitive resource decisions:       %d total", sensitiveTotal)
 			logf("  Without declared purpose:         %d", sensitiveWithoutPurpose)
 			if sensitiveWithoutPurpose > 0 {
@@ -1102,10 +1234,123 @@ func main() {
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
+
+	// ── Phase 12: Trend Analysis ──────────────────────────────────────────────
+	logPhase(12, nil)
+
+	// Compare this window's metrics with the previous runs for the same
+	// window. Runs before the current one is saved, so prior holds only
+	// previous runs.
+	if hist == nil {
+		logf("Skipped — no history configured (-audit-url or -history-db)")
+	} else if prior, err := hist.recent(*sinceStr, *trendRuns); err != nil {
+		logf("WARNING: Could not fetch history: %v", err)
+	} else if len(prior) == 0 {
+		logf("No previous %s runs recorded yet", *sinceStr)
+	} else {
+		trends := analyzeTrends(snap, prior)
+		printTrends(trends, len(prior), *sinceStr)
+		for _, t := range trends {
+			if t.Regressed {
+				warnings = append(warnings, trendWarning(t, *sinceStr))
+			}
+		}
+		if *trendHTMLPath != "" {
+			if err := writeTrendHTML(*trendHTMLPath, trends, len(prior), *sinceStr, *tenant); err != nil {
+				logf("WARNING: Could not write trend HTML: %v", err)
+			} else {
+				logf("Trend charts written to %s", *trendHTMLPath)
+			}
+		}
+	}
+
+	// Precision of the auditor's alert rules does not need history: the
+	// alerts and operators' false-positive marks are kept by auditd.
+	if current, err := getAlertPrecision(*gateway, sinceTime, time.Now()); err != nil {
+		logf("WARNING: Could not fetch alert precision: %v", err)
+	} else if len(current) == 0 {
+		logf("No auditor alerts in this window")
+	} else {
+		previous, err := getAlertPrecision(*gateway, sinceTime.Add(-since), sinceTime)
+		if err != nil {
+			logf("WARNING: Could not fetch previous alert precision: %v", err)
+		}
+		results := analyzePrecision(current, previous)
+		printPrecision(results, *sinceStr)
+		for _, r := range results {
+			if r.Noisy {
+				warnings = append(warnings, noisyRuleWarning(r, *sinceStr))
+			}
+		}
+	}
+
+	// Users' and srebot's verdicts on whether delegations solved the problem.
+	if current, err := getDelegationFeedback(*gateway, sinceTime, time.Now()); err != nil {
+		logf("WARNING: Could not fetch delegation feedback: %v", err)
+	} else if len(current) == 0 {
+		logf("No delegation feedback in this window")
+	} else {
+		previous, err := getDelegationFeedback(*gateway, sinceTime.Add(-since), sinceTime)
+		if err != nil {
+			logf("WARNING: Could not fetch previous delegation feedback: %v", err)
+		}
+		results := analyzeResolution(current, previous)
+		printResolution(results, *sinceStr)
+		for _, r := range results {
+			if r.Low {
+				warnings = append(warnings, lowResolutionWarning(r, *sinceStr))
+			}
+		}
+	}
+
+	// Planned work declared in maintenance windows, during which the auditor
+	// lowered the alerts it raised.
+	if windows, err := getMaintenanceWindows(*gateway, sinceTime, time.Now()); err != nil {
+		logf("WARNING: Could not fetch maintenance windows: %v", err)
+	} else if len(windows) == 0 {
+		logf("No maintenance windows overlap this period")
+	} else {
+		printMaintenanceWindows(windows, *sinceStr)
+		warnings = append(warnings, maintenanceWarnings(windows)...)
+	}
+
+	// Who and what is driving risk: per-agent and per-user scorecards, with
+	// auditd comparing this window with the previous one.
+	if cards, err := getScorecards(*gateway, sinceTime, time.Now()); err != nil {
+		logf("WARNING: Could not fetch scorecards: %v", err)
+	} else if len(cards.Agents) == 0 && len(cards.Users) == 0 {
+		logf("No agent or user activity for scorecards in this window")
+	} else {
+		if len(cards.Agents) > 0 {
+			printScorecards("Agent", cards.Agents, *sinceStr)
+			warnings = append(warnings, scorecardWarnings("agent", cards.Agents, *sinceStr)...)
+		}
+		if len(cards.Users) > 0 {
+			printScorecards("User", cards.Users, *sinceStr)
+			warnings = append(warnings, scorecardWarnings("user", cards.Users, *sinceStr)...)
+		}
+	}
+	fmt.Fprintln(logOut)
+
+	// ── Phase 13: Component Versions ──────────────────────────────────────────
+	logPhase(13, nil)
+
+	// Every event names the build that recorded it: components running
+	// several builds at once, or behind the rest of the fleet, were missed
+	// by a rollout.
+	if len(events) == 0 {
+		logf("No events available for version analysis")
+	} else if cvs, unstamped := analyzeVersions(events); len(cvs) == 0 {
+		logf("No event in this window carries a producer version (%d event(s) from older builds)", unstamped)
+	} else {
+		printVersions(cvs, unstamped, *sinceStr)
+		warnings = append(warnings, versionWarnings(cvs, *sinceStr)...)
+	}
+	fmt.Fprintln(logOut)
 
-	// ── Phase 12: Summary ─────────────────────────────────────────────────────
-	logPhase(12, "Compliance Summary")
+	// ── Phase 14: Summary ─────────────────────────────────────────────────────
+	logPhase(14, nil)
 
 	overall := "✓ HEALTHY"
 	if len(alerts) > 0 {
@@ -1113,35 +1358,40 @@ func main() {
 	} else if len(warnings) > 0 {
 		overall = "⚠ WARNINGS"
 	}
-	logf("Overall status: %s", overall)
+	// overall is e.g. "✓ HEALTHY" — take the last word and lowercase it.
+	if parts := strings.Fields(overall); len(parts) > 0 {
+		snap.Status = strings.ToLower(parts[len(parts)-1])
+		// Shown in the report locale; history keeps the English status.
+		overall = parts[0] + " " + tr("govbot.status."+snap.Status, nil)
+	}
+	logf("%s", tr("govbot.overall", i18n.Vars{"status": overall}))
+	if slaSummary != "" {
+		logf("%s", tr("govbot.sla_attainment", i18n.Vars{"attainment": slaSummary}))
+	}
 
 	if len(alerts) > 0 {
-		logf("ALERTS (%d):", len(alerts))
+		logf("%s", tr("govbot.alerts", i18n.Vars{"n": len(alerts)}))
 		for _, a := range alerts {
 			logf("  [ALERT] %s", a)
 		}
 	}
 	if len(warnings) > 0 {
-		logf("Warnings (%d):", len(warnings))
+		logf("%s", tr("govbot.warnings", i18n.Vars{"n": len(warnings)}))
 		for _, w := range warnings {
 			logf("  [WARN]  %s", w)
 		}
 	}
 	if len(alerts) == 0 && len(warnings) == 0 {
-		logf("No issues detected")
+		logf("%s", tr("govbot.no_issues", nil))
 	}
 
+	snap.AlertCount = len(alerts)
+	snap.WarningCount = len(warnings)
+	snap.AlertsJSON = marshalJSON(alerts)
+	snap.WarningsJSON = marshalJSON(warnings)
+
 	// ── History: save snapshot and print trend ────────────────────────────────
 	if hist != nil {
-		// overall is e.g. "✓ HEALTHY" — take the last word and lowercase it.
-		if parts := strings.Fields(overall); len(parts) > 0 {
-			snap.Status = strings.ToLower(parts[len(parts)-1])
-		}
-		snap.AlertCount = len(alerts)
-		snap.WarningCount = len(warnings)
-		snap.AlertsJSON = marshalJSON(alerts)
-		snap.WarningsJSON = marshalJSON(warnings)
-
 		if err := hist.save(snap, *historyRetain); err != nil {
 			logf("WARNING: could not save history: %v", err)
 		} else {
@@ -1191,7 +1441,7 @@ func main() {
 					mutsArrow = "  ↑ above avg"
 				}
 
-				fmt.Println()
+				fmt.Fprintln(logOut)
 				logf("Historical Trend (last %d %s runs):", n, *sinceStr)
 				logf("  Status:     healthy %d  warnings %d  alerts %d", healthy, warnings_, alerts_)
 				logf("  Denials:    avg %.1f/run   today %d%s", avgDenies, snap.PolicyDenies, deniesArrow)
@@ -1217,9 +1467,9 @@ func main() {
 
 	// Post to webhook if configured
 	if *webhook != "" && !*dryRun {
-		fmt.Println()
+		fmt.Fprintln(logOut)
 		logf("Posting summary to webhook...")
-		if err := postWebhook(*webhook, overall, alerts, warnings, info, *sinceStr); err != nil {
+		if err := postWebhook(*webhook, overall, alerts, warnings, info, *sinceStr, slaSummary); err != nil {
 			logf("WARNING: Failed to post webhook: %v", err)
 		} else {
 			logf("Webhook posted")
@@ -1228,9 +1478,16 @@ func main() {
 		logf("[DRY RUN] Would post webhook")
 	}
 
-	fmt.Println()
+	fmt.Fprintln(logOut)
 	logf("Done.")
 
+	if output.Structured() {
+		if err := writeReport(os.Stdout, output, snap); err != nil {
+			fmt.Fprintf(os.Stderr, "write report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if len(alerts) > 0 {
 		os.Exit(2) // Distinct exit code for alerts — useful in CI/cron
 	}
@@ -1264,6 +1521,63 @@ func getEvents(gateway string, since time.Time, limit int) ([]audit.Event, error
 	return events, nil
 }
 
+// getEventStats fetches SQL-side aggregate counts for [since, until).
+// A zero until leaves the window open-ended.
+func getEventStats(gateway string, since, until time.Time) (*audit.EventStats, error) {
+	path := "/api/v1/governance/events/stats?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	if !until.IsZero() {
+		path += "&until=" + url.QueryEscape(until.UTC().Format(time.RFC3339))
+	}
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var stats audit.EventStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, fmt.Errorf("decode event stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// countMutations returns the number of write and destructive tool executions
+// in [from, until). It uses the stats endpoint and falls back to fetching
+// events from auditd builds that predate it.
+func countMutations(gateway string, from, until time.Time) (int, error) {
+	if stats, err := getEventStats(gateway, from, until); err == nil {
+		return stats.ByActionClass[string(audit.ActionWrite)] + stats.ByActionClass[string(audit.ActionDestructive)], nil
+	}
+	events, err := getEvents(gateway, from, 2000)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := range events {
+		e := &events[i]
+		if e.Timestamp.Before(until) &&
+			e.EventType == audit.EventTypeToolExecution &&
+			(e.ActionClass == audit.ActionWrite || e.ActionClass == audit.ActionDestructive) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// getApprovalsSince returns up to limit approval requests created at or
+// after since, newest first.
+func getApprovalsSince(gateway string, since time.Time, limit int) ([]*audit.StoredApproval, error) {
+	path := fmt.Sprintf("/api/v1/governance/approvals?since=%s&limit=%d",
+		url.QueryEscape(since.UTC().Format(time.RFC3339)), limit)
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []*audit.StoredApproval
+	if err := json.Unmarshal(body, &approvals); err != nil {
+		return nil, fmt.Errorf("decode approvals: %w", err)
+	}
+	return approvals, nil
+}
+
 func getPendingApprovals(gateway string) ([]*audit.StoredApproval, error) {
 	body, err := gatewayGET(gateway, "/api/v1/governance/approvals/pending")
 	if err != nil {
@@ -1289,6 +1603,13 @@ func getChainIntegrity(gateway string) (*integrityStatus, error) {
 }
 
 func gatewayGET(baseURL, path string) ([]byte, error) {
+	if gatewayTenant != "" {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + "tenant_id=" + url.QueryEscape(gatewayTenant)
+	}
 	req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("GET %s: %w", path, err)
@@ -1311,7 +1632,7 @@ func gatewayGET(baseURL, path string) ([]byte, error) {
 
 // ── Webhook ───────────────────────────────────────────────────────────────────
 
-func postWebhook(webhookURL, overall string, alerts, warnings []string, info *governanceInfo, window string) error {
+func postWebhook(webhookURL, overall string, alerts, warnings []string, info *governanceInfo, window, slaSummary string) error {
 	icon := ":white_check_mark:"
 	if len(alerts) > 0 {
 		icon = ":rotating_light:"
@@ -1320,23 +1641,32 @@ func postWebhook(webhookURL, overall string, alerts, warnings []string, info *go
 	}
 
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "%s *AI Governance Report* — %s\n", icon, overall)
-	fmt.Fprintf(&sb, "Window: last %s  |  Total events: %d  |  Pending approvals: %d  |  Chain: ",
-		window, info.Audit.EventsTotal, info.Approvals.PendingCount)
+	sb.WriteString(tr("govbot.webhook.title", i18n.Vars{"icon": icon, "status": overall}) + "\n")
+	if gatewayTenant != "" {
+		sb.WriteString(tr("govbot.webhook.tenant", i18n.Vars{"tenant": gatewayTenant}) + "\n")
+	}
+	sb.WriteString(tr("govbot.webhook.window", i18n.Vars{
+		"window":  window,
+		"events":  info.Audit.EventsTotal,
+		"pending": info.Approvals.PendingCount,
+	}))
 	if info.Audit.ChainValid {
-		sb.WriteString("✓ valid\n")
+		sb.WriteString(tr("govbot.webhook.chain_valid", nil) + "\n")
 	} else {
-		sb.WriteString("✗ *INVALID*\n")
+		sb.WriteString(tr("govbot.webhook.chain_invalid", nil) + "\n")
+	}
+	if slaSummary != "" {
+		sb.WriteString(tr("govbot.sla_attainment", i18n.Vars{"attainment": slaSummary}) + "\n")
 	}
 
 	if len(alerts) > 0 {
-		sb.WriteString("\n*Alerts:*\n")
+		sb.WriteString("\n" + tr("govbot.webhook.alerts", nil) + "\n")
 		for _, a := range alerts {
 			fmt.Fprintf(&sb, "• :red_circle: %s\n", a)
 		}
 	}
 	if len(warnings) > 0 {
-		sb.WriteString("\n*Warnings:*\n")
+		sb.WriteString("\n" + tr("govbot.webhook.warnings", nil) + "\n")
 		for _, w := range warnings {
 			fmt.Fprintf(&sb, "• :large_yellow_circle: %s\n", w)
 		}
@@ -1358,18 +1688,24 @@ func postWebhook(webhookURL, overall string, alerts, warnings []string, info *go
 
 // ── Output formatting ─────────────────────────────────────────────────────────
 
+// logOut receives the phase log: stdout, or stderr when -o json|yaml
+// reserves stdout for the report.
+var logOut io.Writer = os.Stdout
+
 func logf(format string, args ...any) {
 	ts := time.Now().Format("15:04:05")
-	fmt.Printf("[%s] %s\n", ts, fmt.Sprintf(format, args...))
+	fmt.Fprintf(logOut, "[%s] %s\n", ts, fmt.Sprintf(format, args...))
 }
 
-func logPhase(num int, name string) {
-	line := fmt.Sprintf("Phase %d: %s", num, name)
-	pad := 52 - len(line)
+// logPhase prints the heading of phase num, named in the report locale;
+// vars fill the name's placeholders, such as the look-back window.
+func logPhase(num int, vars i18n.Vars) {
+	line := tr("govbot.phase", i18n.Vars{"n": num, "name": tr(fmt.Sprintf("govbot.phase.%d", num), vars)})
+	pad := 52 - utf8.RuneCountInString(line)
 	if pad < 4 {
 		pad = 4
 	}
-	fmt.Println()
+	fmt.Fprintln(logOut)
 	logf("%s %s %s", strings.Repeat("─", 2), line, strings.Repeat("─", pad))
 }
 
@@ -1390,6 +1726,8 @@ func traceOriginFromPrefix(prefix string) string {
 		return "direct tool call (POST /api/v1/db|k8s/{tool})"
 	case "chk_":
 		return "direct governance check (POST /v1/governance/check)"
+	case "adm_":
+		return "Kubernetes admission review (k8s-admission)"
 	default:
 		return "unknown origin (external or pre-dating prefix scheme)"
 	}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// A maintenance window longer than longMaintenanceWindow lowers alerts for
+// too long to go unremarked.
+const longMaintenanceWindow = 24 * time.Hour
+
+// getMaintenanceWindows fetches the maintenance windows that overlap
+// [since, until), each with the number of alerts tagged with it.
+func getMaintenanceWindows(gateway string, since, until time.Time) ([]audit.MaintenanceWindow, error) {
+	path := "/api/v1/governance/maintenance-windows?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339)) +
+		"&until=" + url.QueryEscape(until.UTC().Format(time.RFC3339))
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Windows []audit.MaintenanceWindow `json:"windows"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode maintenance windows: %w", err)
+	}
+	return out.Windows, nil
+}
+
+func printMaintenanceWindows(windows []audit.MaintenanceWindow, window string) {
+	logf("Maintenance windows used this %s:", window)
+	logf("  %-12s  %-16s  %-17s  %-9s  %6s  %-20s  %s", "Window", "Owner", "Starts (UTC)", "Duration", "Alerts", "Resources", "Reason")
+	for _, mw := range windows {
+		resources := "(fleet-wide)"
+		if len(mw.Resources) > 0 {
+			resources = strings.Join(mw.Resources, ",")
+		}
+		logf("  %-12s  %-16s  %-17s  %-9s  %6d  %-20s  %s",
+			mw.WindowID, truncate(mw.Owner, 16), mw.StartsAt.UTC().Format("2006-01-02 15:04"),
+			mw.EndsAt.Sub(mw.StartsAt).Round(time.Minute), mw.Alerts, truncate(resources, 20), mw.Reason)
+	}
+}
+
+// maintenanceWarnings flags windows long enough to hide real problems.
+func maintenanceWarnings(windows []audit.MaintenanceWindow) []string {
+	var out []string
+	for _, mw := range windows {
+		if d := mw.EndsAt.Sub(mw.StartsAt); d > longMaintenanceWindow {
+			out = append(out, fmt.Sprintf("maintenance window %s (%s: %s) lasts %s and lowered %d alert(s) — split or shorten it",
+				mw.WindowID, mw.Owner, mw.Reason, d.Round(time.Hour), mw.Alerts))
+		}
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestMaintenanceWarnings(t *testing.T) {
+	start := time.Date(2026, 3, 7, 22, 0, 0, 0, time.UTC)
+	windows := []audit.MaintenanceWindow{
+		{WindowID: "mw_short", Owner: "alice", Reason: "CHG-42", StartsAt: start, EndsAt: start.Add(4 * time.Hour), Alerts: 3},
+		{WindowID: "mw_long", Owner: "bob", Reason: "migration", StartsAt: start, EndsAt: start.Add(72 * time.Hour), Alerts: 12},
+	}
+	got := maintenanceWarnings(windows)
+	if len(got) != 1 || !strings.Contains(got[0], "mw_long") || !strings.Contains(got[0], "12 alert(s)") {
+		t.Errorf("maintenanceWarnings = %q, want one warning for mw_long", got)
+	}
+}
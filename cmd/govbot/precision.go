@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// A rule is noisy when it fired at least noisyMinFired alerts in the window
+// and operators marked more than half of them as false positives.
+const (
+	noisyMinFired  = 5
+	noisyPrecision = 0.5
+)
+
+// rulePrecision is one auditor rule's precision this window, with the
+// previous window of the same length for comparison.
+type rulePrecision struct {
+	audit.RulePrecision
+	Previous *audit.RulePrecision // nil when the rule did not fire in the previous window
+	Noisy    bool
+}
+
+// getAlertPrecision fetches per-rule alert precision for [since, until).
+func getAlertPrecision(gateway string, since, until time.Time) ([]audit.RulePrecision, error) {
+	path := "/api/v1/governance/alerts/precision?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339)) +
+		"&until=" + url.QueryEscape(until.UTC().Format(time.RFC3339))
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Rules []audit.RulePrecision `json:"rules"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode alert precision: %w", err)
+	}
+	return out.Rules, nil
+}
+
+// analyzePrecision pairs each rule that fired this window with its previous
+// window and flags noisy rules. The current window's order is kept.
+func analyzePrecision(current, previous []audit.RulePrecision) []rulePrecision {
+	prev := make(map[string]audit.RulePrecision, len(previous))
+	for _, p := range previous {
+		prev[p.Rule] = p
+	}
+	var out []rulePrecision
+	for _, c := range current {
+		r := rulePrecision{RulePrecision: c}
+		if p, ok := prev[c.Rule]; ok && p.Fired > 0 {
+			r.Previous = &p
+		}
+		r.Noisy = c.Fired >= noisyMinFired && c.Precision < noisyPrecision
+		out = append(out, r)
+	}
+	return out
+}
+
+func printPrecision(results []rulePrecision, window string) {
+	logf("Auditor alert precision (this %s vs the previous %s):", window, window)
+	logf("  %-28s  %6s  %6s  %10s  %10s  %9s", "Rule", "Fired", "FP", "Suppressed", "Precision", "Previous")
+	for _, r := range results {
+		previous, flag := "-", ""
+		if r.Previous != nil {
+			previous = fmt.Sprintf("%.0f%%", r.Previous.Precision*100)
+		}
+		if r.Noisy {
+			flag = "  ⚠ NOISY"
+		}
+		logf("  %-28s  %6d  %6d  %10d  %9.0f%%  %9s%s",
+			truncate(r.Rule, 28), r.Fired, r.FalsePositives, r.Suppressed, r.Precision*100, previous, flag)
+	}
+}
+
+// noisyRuleWarning describes a noisy rule for the Compliance Summary.
+func noisyRuleWarning(r rulePrecision, window string) string {
+	return fmt.Sprintf("auditor rule %q is noisy: %d of %d alerts this %s window were false positives (precision %.0f%%) — tune or fix the rule",
+		r.Rule, r.FalsePositives, r.Fired, window, r.Precision*100)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestAnalyzePrecision(t *testing.T) {
+	current := []audit.RulePrecision{
+		{Rule: "param_first_seen", Fired: 10, FalsePositives: 7, Precision: 0.3},
+		{Rule: "off_hours", Fired: 2, FalsePositives: 2, Precision: 0},
+		{Rule: "prompt_injection", Fired: 4, Precision: 1},
+	}
+	previous := []audit.RulePrecision{
+		{Rule: "param_first_seen", Fired: 8, FalsePositives: 2, Precision: 0.75},
+	}
+	got := analyzePrecision(current, previous)
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3", len(got))
+	}
+	if !got[0].Noisy || got[0].Previous == nil || got[0].Previous.Precision != 0.75 {
+		t.Errorf("param_first_seen = %+v, want noisy with previous 0.75", got[0])
+	}
+	if got[1].Noisy {
+		t.Error("off_hours flagged noisy with fewer than noisyMinFired alerts")
+	}
+	if got[2].Noisy || got[2].Previous != nil {
+		t.Errorf("prompt_injection = %+v, want not noisy and no previous", got[2])
+	}
+	if w := noisyRuleWarning(got[0], "24h"); !strings.Contains(w, "7 of 10") {
+		t.Errorf("warning = %q", w)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+// reportRun converts a snapshot to the run shape auditd serves at
+// /v1/govbot/runs, so the -o json|yaml report, -show-history and the stored
+// history share one set of field names.
+func reportRun(s runSnapshot) audit.GovbotRun {
+	run := snapToRun(s)
+	if run.Alerts == nil {
+		run.Alerts = []string{}
+	}
+	if run.Warnings == nil {
+		run.Warnings = []string{}
+	}
+	return run
+}
+
+// writeReport writes this run's compliance report.
+func writeReport(w io.Writer, f cliout.Format, snap runSnapshot) error {
+	return cliout.Write(w, f, reportRun(snap))
+}
+
+// writeHistory writes stored runs, newest first.
+func writeHistory(w io.Writer, f cliout.Format, snaps []runSnapshot) error {
+	runs := make([]audit.GovbotRun, len(snaps))
+	for i, s := range snaps {
+		runs[i] = reportRun(s)
+	}
+	return cliout.Write(w, f, runs)
+}
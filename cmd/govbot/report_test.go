@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+func TestWriteReport_StableFields(t *testing.T) {
+	snap := makeSnap("alerts", "1h", 3, 1, false)
+	snap.AlertCount = 1
+	snap.AlertsJSON = marshalJSON([]string{"Audit hash chain integrity failure"})
+	snap.WarningsJSON = marshalJSON([]string(nil))
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, cliout.JSON, snap); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, buf.String())
+	}
+	if got["status"] != "alerts" || got["policy_denies"] != 3.0 || got["chain_valid"] != false {
+		t.Errorf("report = %v", got)
+	}
+	if w, ok := got["warnings"].([]any); !ok || len(w) != 0 {
+		t.Errorf("warnings = %#v, want an empty list rather than null", got["warnings"])
+	}
+
+	buf.Reset()
+	if err := writeReport(&buf, cliout.YAML, snap); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"status: alerts\n", "policy_denies: 3\n", "alerts:\n  - Audit hash chain integrity failure\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("YAML report missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteHistory_MatchesAuditdRuns(t *testing.T) {
+	snaps := []runSnapshot{makeSnap("healthy", "24h", 0, 2, true), makeSnap("warnings", "24h", 1, 0, true)}
+	var buf bytes.Buffer
+	if err := writeHistory(&buf, cliout.JSON, snaps); err != nil {
+		t.Fatal(err)
+	}
+	var runs []audit.GovbotRun
+	if err := json.Unmarshal(buf.Bytes(), &runs); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Status != "healthy" || runs[1].PolicyDenies != 1 {
+		t.Errorf("runs = %+v", runs)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// getScorecards fetches the per-agent and per-user governance scorecards for
+// [since, until), with trends against the previous window of the same length.
+func getScorecards(gateway string, since, until time.Time) (*audit.Scorecards, error) {
+	path := "/api/v1/governance/events/scorecards?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339)) +
+		"&until=" + url.QueryEscape(until.UTC().Format(time.RFC3339))
+	body, err := gatewayGET(gateway, path)
+	if err != nil {
+		return nil, err
+	}
+	var cards audit.Scorecards
+	if err := json.Unmarshal(body, &cards); err != nil {
+		return nil, fmt.Errorf("decode scorecards: %w", err)
+	}
+	return &cards, nil
+}
+
+// scorecardRate formats one rate with its trend arrow, or "-" when the window
+// had nothing to take it over.
+func scorecardRate(v float64, n int, trend string) string {
+	if n == 0 {
+		return "-"
+	}
+	if trend == "" {
+		trend = " "
+	}
+	return fmt.Sprintf("%.0f%% %s", v*100, trend)
+}
+
+func printScorecards(title string, cards []audit.Scorecard, window string) {
+	logf("%s scorecards (this %s; arrows compare with the previous %s):", title, window, window)
+	logf("  %-28s  %8s  %8s  %8s  %8s  %8s  %s", title, "Approved", "Denied", "Errors", "Conf", "Blast", "")
+	for _, c := range cards {
+		flag := ""
+		if len(c.Worsened) > 0 {
+			flag = "  ⚠ " + strings.Join(c.Worsened, ", ")
+		}
+		logf("  %-28s  %8s  %8s  %8s  %8s  %8s%s",
+			truncate(c.Name, 28),
+			scorecardRate(c.ApprovalCompliance, c.Destructive, c.Trend["approval_compliance"]),
+			scorecardRate(c.DenialRate, c.PolicyDecisions, c.Trend["denial_rate"]),
+			scorecardRate(c.ErrorRate, c.ToolExecutions, c.Trend["error_rate"]),
+			scorecardRate(c.MeanConfidence, c.Delegations, c.Trend["mean_confidence"]),
+			scorecardRate(c.BlastRadiusUsage, c.BlastRadiusChecks, c.Trend["blast_radius_usage"]),
+			flag)
+	}
+}
+
+// scorecardWarnings describes, for the Compliance Summary, every agent or
+// user that ran destructive operations without an approval.
+func scorecardWarnings(kind string, cards []audit.Scorecard, window string) []string {
+	var out []string
+	for _, c := range cards {
+		if unapproved := c.Destructive - c.DestructiveApproved; unapproved > 0 {
+			out = append(out, fmt.Sprintf("%s %s ran %d of %d destructive operation(s) this %s window without an approval (approval compliance %.0f%%)",
+				kind, c.Name, unapproved, c.Destructive, window, c.ApprovalCompliance*100))
+		}
+	}
+	return out
+}
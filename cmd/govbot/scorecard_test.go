@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestScorecardWarnings(t *testing.T) {
+	cards := []audit.Scorecard{
+		{Name: "k8s_agent", ScorecardMetrics: audit.ScorecardMetrics{Destructive: 4, DestructiveApproved: 3, ApprovalCompliance: 0.75}},
+		{Name: "postgres_database_agent", ScorecardMetrics: audit.ScorecardMetrics{Destructive: 2, DestructiveApproved: 2, ApprovalCompliance: 1}},
+		{Name: "research_agent"},
+	}
+	got := scorecardWarnings("agent", cards, "24h")
+	if len(got) != 1 || !strings.Contains(got[0], "agent k8s_agent ran 1 of 4") || !strings.Contains(got[0], "75%") {
+		t.Errorf("warnings = %q, want one for k8s_agent", got)
+	}
+}
+
+func TestScorecardRate(t *testing.T) {
+	if got := scorecardRate(0.5, 4, "↑"); got != "50% ↑" {
+		t.Errorf("scorecardRate = %q", got)
+	}
+	if got := scorecardRate(0, 0, ""); got != "-" {
+		t.Errorf("scorecardRate with nothing to rate = %q, want -", got)
+	}
+}
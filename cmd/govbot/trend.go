@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// minTrendBaseline is the number of previous runs a metric needs before a
+// regression can be flagged; with fewer, the spread is not meaningful.
+const minTrendBaseline = 3
+
+// trendMetric is one per-run metric tracked by the trend analysis. Higher
+// values are worse for every metric tracked.
+type trendMetric struct {
+	name string
+	unit string // "" for counts, "%" for rates, "s" for durations
+
+	// minDelta is the smallest increase over the baseline mean worth
+	// flagging, so that 0 → 1 denial is not reported as a regression.
+	minDelta float64
+
+	// value extracts the metric from a run. ok is false when the run has no
+	// data point, e.g. no approvals were resolved in its window.
+	value func(s runSnapshot) (v float64, ok bool)
+}
+
+var trendMetrics = []trendMetric{
+	{name: "Denials", minDelta: 3, value: func(s runSnapshot) (float64, bool) {
+		return float64(s.PolicyDenies), true
+	}},
+	{name: "Uncontrolled traces", minDelta: 1, value: func(s runSnapshot) (float64, bool) {
+		return float64(s.UncontrolledTraces), true
+	}},
+	{name: "Approval latency", unit: "s", minDelta: 300, value: func(s runSnapshot) (float64, bool) {
+		if s.ApprovalsResolved == 0 {
+			return 0, false
+		}
+		return float64(s.ApprovalLatencySecs), true
+	}},
+	{name: "Tool error rate", unit: "%", minDelta: 5, value: func(s runSnapshot) (float64, bool) {
+		if s.ToolExecutions == 0 {
+			return 0, false
+		}
+		return 100 * float64(s.ToolErrors) / float64(s.ToolExecutions), true
+	}},
+}
+
+// trendResult compares one metric of the current run with previous runs.
+type trendResult struct {
+	Name       string
+	Unit       string
+	Series     []float64 // data points oldest first, the current run last when HasCurrent
+	Current    float64
+	HasCurrent bool
+	Baseline   float64 // mean of the previous data points
+	Points     int     // number of previous data points
+	Regressed  bool
+}
+
+// analyzeTrends compares current with prior (newest first, as returned by
+// historyClient.recent). A metric regresses when the current value is more
+// than two standard deviations above the baseline mean, at least 1.5× the
+// mean, and at least the metric's minDelta above it.
+func analyzeTrends(current runSnapshot, prior []runSnapshot) []trendResult {
+	results := make([]trendResult, 0, len(trendMetrics))
+	for _, m := range trendMetrics {
+		r := trendResult{Name: m.name, Unit: m.unit}
+		for i := len(prior) - 1; i >= 0; i-- {
+			if v, ok := m.value(prior[i]); ok {
+				r.Series = append(r.Series, v)
+			}
+		}
+		r.Points = len(r.Series)
+		var sum float64
+		for _, v := range r.Series {
+			sum += v
+		}
+		if r.Points > 0 {
+			r.Baseline = sum / float64(r.Points)
+		}
+		r.Current, r.HasCurrent = m.value(current)
+		if r.HasCurrent {
+			r.Series = append(r.Series, r.Current)
+		}
+
+		if r.HasCurrent && r.Points >= minTrendBaseline {
+			var sq float64
+			for _, v := range r.Series[:r.Points] {
+				sq += (v - r.Baseline) * (v - r.Baseline)
+			}
+			stddev := math.Sqrt(sq / float64(r.Points))
+			r.Regressed = r.Current-r.Baseline >= m.minDelta &&
+				r.Current > r.Baseline+2*stddev &&
+				r.Current >= 1.5*r.Baseline
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// sparkBlocks are the eight bar heights of an ASCII sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as a row of block characters scaled between the
+// smallest and largest value. A flat series renders at the lowest height.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// formatTrendValue prints a metric value in its unit.
+func formatTrendValue(v float64, unit string) string {
+	switch unit {
+	case "s":
+		return (time.Duration(v) * time.Second).Round(time.Second).String()
+	case "%":
+		return fmt.Sprintf("%.1f%%", v)
+	}
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+// printTrends logs the trend table: one row per metric with its sparkline,
+// baseline mean and current value.
+func printTrends(results []trendResult, runs int, window string) {
+	logf("Compared with the previous %d %s run(s):", runs, window)
+	width := len("Trend")
+	for _, r := range results {
+		width = max(width, utf8.RuneCountInString(sparkline(r.Series)))
+	}
+	logf("  %-20s  %s  %10s  %10s", "Metric", "Trend"+strings.Repeat(" ", width-len("Trend")), "Baseline", "Current")
+	for _, r := range results {
+		line := sparkline(r.Series)
+		line += strings.Repeat(" ", width-utf8.RuneCountInString(line))
+		baseline, current, flag := "-", "N/A", ""
+		if r.Points > 0 {
+			baseline = formatTrendValue(r.Baseline, r.Unit)
+		}
+		if r.HasCurrent {
+			current = formatTrendValue(r.Current, r.Unit)
+		}
+		switch {
+		case r.Regressed:
+			flag = "  ↑ REGRESSION"
+		case r.HasCurrent && r.Points < minTrendBaseline:
+			flag = "  (not enough history)"
+		}
+		logf("  %-20s  %s  %10s  %10s%s", r.Name, line, baseline, current, flag)
+	}
+}
+
+// trendWarning describes a regressed metric for the Compliance Summary.
+func trendWarning(r trendResult, window string) string {
+	return fmt.Sprintf("%s regressed: %s this %s window vs a baseline of %s over the previous %d run(s)",
+		r.Name, formatTrendValue(r.Current, r.Unit), window, formatTrendValue(r.Baseline, r.Unit), r.Points)
+}
+
+// ── HTML rendering ────────────────────────────────────────────────────────────
+
+const (
+	svgSparkWidth  = 160
+	svgSparkHeight = 28
+)
+
+// svgSparkPoints returns the polyline points of an inline SVG sparkline.
+func svgSparkPoints(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	step := 0.0
+	if len(values) > 1 {
+		step = float64(svgSparkWidth-4) / float64(len(values)-1)
+	}
+	pts := make([]string, len(values))
+	for i, v := range values {
+		y := float64(svgSparkHeight) / 2
+		if hi > lo {
+			y = float64(svgSparkHeight-2) - (v-lo)/(hi-lo)*float64(svgSparkHeight-4)
+		}
+		pts[i] = fmt.Sprintf("%.1f,%.1f", 2+float64(i)*step, y)
+	}
+	return strings.Join(pts, " ")
+}
+
+var trendHTML = template.Must(template.New("trend").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>aiHelpDesk governance trends</title>
+<style>
+body{font-family:sans-serif;margin:2em}
+table{border-collapse:collapse}
+th,td{padding:4px 12px;text-align:left;border-bottom:1px solid #ddd}
+td.num{text-align:right}
+tr.regressed td{background:#fdecea}
+</style></head><body>
+<h2>Governance trends — {{.Window}} window</h2>
+<p>Generated {{.Generated}}{{if .Tenant}} for tenant {{.Tenant}}{{end}}, compared with the previous {{.Runs}} run(s).</p>
+<table>
+<tr><th>Metric</th><th>Trend</th><th>Baseline</th><th>Current</th><th></th></tr>
+{{range .Rows}}<tr{{if .Regressed}} class="regressed"{{end}}>
+<td>{{.Name}}</td>
+<td><svg width="{{$.Width}}" height="{{$.Height}}" role="img" aria-label="{{.Name}} trend"><polyline fill="none" stroke="{{if .Regressed}}#c62828{{else}}#1565c0{{end}}" stroke-width="1.5" points="{{.Points}}"/></svg></td>
+<td class="num">{{.Baseline}}</td>
+<td class="num">{{.Current}}</td>
+<td>{{if .Regressed}}regression{{end}}</td>
+</tr>
+{{end}}</table>
+</body></html>
+`))
+
+// renderTrendHTML writes the trend table as a standalone HTML page with an
+// inline SVG sparkline per metric.
+func renderTrendHTML(w io.Writer, results []trendResult, runs int, window, tenant string) error {
+	type row struct {
+		Name, Points, Baseline, Current string
+		Regressed                       bool
+	}
+	rows := make([]row, len(results))
+	for i, r := range results {
+		rows[i] = row{Name: r.Name, Points: svgSparkPoints(r.Series), Baseline: "-", Current: "N/A", Regressed: r.Regressed}
+		if r.Points > 0 {
+			rows[i].Baseline = formatTrendValue(r.Baseline, r.Unit)
+		}
+		if r.HasCurrent {
+			rows[i].Current = formatTrendValue(r.Current, r.Unit)
+		}
+	}
+	return trendHTML.Execute(w, map[string]any{
+		"Window":    window,
+		"Tenant":    tenant,
+		"Runs":      runs,
+		"Generated": time.Now().UTC().Format(time.RFC3339),
+		"Width":     svgSparkWidth,
+		"Height":    svgSparkHeight,
+		"Rows":      rows,
+	})
+}
+
+// writeTrendHTML renders the trend page to path.
+func writeTrendHTML(path string, results []trendResult, runs int, window, tenant string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderTrendHTML(f, results, runs, window, tenant); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// priorRuns builds previous runs newest first from denial counts given
+// oldest first, matching the order historyClient.recent returns.
+func priorRuns(denies ...int) []runSnapshot {
+	runs := make([]runSnapshot, len(denies))
+	for i, d := range denies {
+		runs[len(denies)-1-i] = runSnapshot{PolicyDenies: d}
+	}
+	return runs
+}
+
+func trendByName(t *testing.T, results []trendResult, name string) trendResult {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no trend result for %q", name)
+	return trendResult{}
+}
+
+func TestAnalyzeTrends_FlagsRegression(t *testing.T) {
+	prior := priorRuns(2, 3, 2, 1)
+	r := trendByName(t, analyzeTrends(runSnapshot{PolicyDenies: 12}, prior), "Denials")
+	if !r.Regressed {
+		t.Errorf("12 denials vs baseline %.1f: want regression", r.Baseline)
+	}
+	if r.Baseline != 2 || r.Points != 4 {
+		t.Errorf("baseline=%.1f points=%d, want 2 over 4 runs", r.Baseline, r.Points)
+	}
+	if want := []float64{2, 3, 2, 1, 12}; len(r.Series) != len(want) || r.Series[0] != 2 || r.Series[4] != 12 {
+		t.Errorf("series = %v, want %v (oldest first, current last)", r.Series, want)
+	}
+}
+
+func TestAnalyzeTrends_NoRegression(t *testing.T) {
+	cases := map[string]struct {
+		prior   []runSnapshot
+		current int
+	}{
+		"within spread":         {priorRuns(5, 20, 8, 15), 22},
+		"below minimum delta":   {priorRuns(0, 0, 0, 0), 2},
+		"not enough history":    {priorRuns(1, 1), 30},
+		"improvement over base": {priorRuns(10, 12, 11, 10), 3},
+	}
+	for name, tc := range cases {
+		r := trendByName(t, analyzeTrends(runSnapshot{PolicyDenies: tc.current}, tc.prior), "Denials")
+		if r.Regressed {
+			t.Errorf("%s: flagged %d vs baseline %.1f", name, tc.current, r.Baseline)
+		}
+	}
+}
+
+func TestAnalyzeTrends_SkipsRunsWithoutData(t *testing.T) {
+	prior := []runSnapshot{
+		{ToolExecutions: 100, ToolErrors: 2},
+		{}, // no executions: no error rate data point
+		{ToolExecutions: 50, ToolErrors: 1},
+		{ToolExecutions: 80, ToolErrors: 2},
+	}
+	current := runSnapshot{ToolExecutions: 40, ToolErrors: 10}
+	results := analyzeTrends(current, prior)
+
+	rate := trendByName(t, results, "Tool error rate")
+	if rate.Points != 3 || !rate.HasCurrent || rate.Current != 25 {
+		t.Errorf("error rate points=%d current=%.1f, want 3 previous points and 25%%", rate.Points, rate.Current)
+	}
+	if !rate.Regressed {
+		t.Errorf("error rate 25%% vs baseline %.1f%%: want regression", rate.Baseline)
+	}
+
+	latency := trendByName(t, results, "Approval latency")
+	if latency.HasCurrent || latency.Points != 0 || latency.Regressed {
+		t.Errorf("approval latency without resolved approvals = %+v, want no data", latency)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 7, 14}); got != "▁▅█" {
+		t.Errorf("sparkline = %q, want ▁▅█", got)
+	}
+	if got := sparkline([]float64{3, 3, 3}); got != "▁▁▁" {
+		t.Errorf("flat sparkline = %q, want ▁▁▁", got)
+	}
+	if got := sparkline(nil); got != "" {
+		t.Errorf("empty sparkline = %q", got)
+	}
+}
+
+func TestFormatTrendValue(t *testing.T) {
+	cases := []struct {
+		v    float64
+		unit string
+		want string
+	}{
+		{4, "", "4"},
+		{2.25, "", "2.2"},
+		{12.5, "%", "12.5%"},
+		{754, "s", "12m34s"},
+	}
+	for _, tc := range cases {
+		if got := formatTrendValue(tc.v, tc.unit); got != tc.want {
+			t.Errorf("formatTrendValue(%v, %q) = %q, want %q", tc.v, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func TestRenderTrendHTML(t *testing.T) {
+	results := analyzeTrends(runSnapshot{PolicyDenies: 12}, priorRuns(2, 3, 2, 1))
+	var buf bytes.Buffer
+	if err := renderTrendHTML(&buf, results, 4, "24h", "acme"); err != nil {
+		t.Fatalf("renderTrendHTML: %v", err)
+	}
+	page := buf.String()
+	for _, want := range []string{"<polyline", "Denials", `class="regressed"`, "tenant acme"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+}
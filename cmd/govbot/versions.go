@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// buildSeen is one version of a component seen in the window's events.
+type buildSeen struct {
+	Version   string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Events    int
+}
+
+// componentVersions is what the window's events say about one component's
+// builds. Current is the version that appeared last, i.e. the latest rollout.
+type componentVersions struct {
+	Component string
+	Current   buildSeen
+	Others    []buildSeen // the other versions, most recently seen first
+
+	// Stale are the other versions still recording events after Current
+	// first did: instances a rollout has not reached.
+	Stale []buildSeen
+}
+
+// analyzeVersions groups the events by the component that produced them. It
+// also returns how many events carry no producer, i.e. come from builds
+// older than producer stamping.
+func analyzeVersions(events []audit.Event) ([]componentVersions, int) {
+	builds := make(map[string]map[string]*buildSeen)
+	unstamped := 0
+	for _, e := range events {
+		if e.Producer == nil || e.Producer.Component == "" {
+			unstamped++
+			continue
+		}
+		byVersion := builds[e.Producer.Component]
+		if byVersion == nil {
+			byVersion = make(map[string]*buildSeen)
+			builds[e.Producer.Component] = byVersion
+		}
+		b := byVersion[e.Producer.Version]
+		if b == nil {
+			b = &buildSeen{Version: e.Producer.Version, FirstSeen: e.Timestamp, LastSeen: e.Timestamp}
+			byVersion[e.Producer.Version] = b
+		}
+		b.Events++
+		if e.Timestamp.Before(b.FirstSeen) {
+			b.FirstSeen = e.Timestamp
+		}
+		if e.Timestamp.After(b.LastSeen) {
+			b.LastSeen = e.Timestamp
+		}
+	}
+
+	out := make([]componentVersions, 0, len(builds))
+	for component, byVersion := range builds {
+		seen := make([]buildSeen, 0, len(byVersion))
+		for _, b := range byVersion {
+			seen = append(seen, *b)
+		}
+		sort.Slice(seen, func(i, j int) bool {
+			if !seen[i].FirstSeen.Equal(seen[j].FirstSeen) {
+				return seen[i].FirstSeen.After(seen[j].FirstSeen)
+			}
+			return seen[i].Version < seen[j].Version
+		})
+		cv := componentVersions{Component: component, Current: seen[0], Others: seen[1:]}
+		sort.Slice(cv.Others, func(i, j int) bool { return cv.Others[i].LastSeen.After(cv.Others[j].LastSeen) })
+		for _, b := range cv.Others {
+			if b.LastSeen.After(cv.Current.FirstSeen) {
+				cv.Stale = append(cv.Stale, b)
+			}
+		}
+		out = append(out, cv)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Component < out[j].Component })
+	return out, unstamped
+}
+
+// releasePattern is the release number at the start of a version, e.g.
+// "1.4.2" in "v1.4.2-3f9c2ab". Builds without one ("dev") are not ordered.
+var releasePattern = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)`)
+
+// compareReleases orders two versions by their release numbers. ok is false
+// when either has none.
+func compareReleases(a, b string) (cmp int, ok bool) {
+	ma, mb := releasePattern.FindStringSubmatch(a), releasePattern.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		return 0, false
+	}
+	as, bs := strings.Split(ma[1], "."), strings.Split(mb[1], ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// fleetRelease is the newest current version across components, which every
+// component should be running; "" when none has a release number.
+func fleetRelease(cvs []componentVersions) string {
+	newest := ""
+	for _, cv := range cvs {
+		if !releasePattern.MatchString(cv.Current.Version) {
+			continue
+		}
+		if c, _ := compareReleases(cv.Current.Version, newest); newest == "" || c > 0 {
+			newest = cv.Current.Version
+		}
+	}
+	return newest
+}
+
+func printVersions(cvs []componentVersions, unstamped int, window string) {
+	fleet := fleetRelease(cvs)
+	logf("Component builds seen in the last %s:", window)
+	logf("  %-24s  %-28s  %7s  %s", "Component", "Version", "Events", "Last seen")
+	for _, cv := range cvs {
+		flag := ""
+		if c, ok := compareReleases(cv.Current.Version, fleet); ok && c < 0 {
+			flag = "  ⚠ behind " + fleet
+		}
+		logf("  %-24s  %-28s  %7d  %s%s", truncate(cv.Component, 24), truncate(cv.Current.Version, 28),
+			cv.Current.Events, cv.Current.LastSeen.UTC().Format(time.RFC3339), flag)
+		for _, b := range cv.Others {
+			note := ""
+			for _, s := range cv.Stale {
+				if s.Version == b.Version {
+					note = "  ⚠ still running"
+				}
+			}
+			logf("  %-24s  %-28s  %7d  %s%s", "", truncate(b.Version, 28), b.Events, b.LastSeen.UTC().Format(time.RFC3339), note)
+		}
+	}
+	if unstamped > 0 {
+		logf("%d event(s) carry no producer — recorded by builds that predate version stamping", unstamped)
+	}
+}
+
+// versionWarnings describes, for the Compliance Summary, every component
+// running mixed versions and every component behind the fleet's newest
+// release.
+func versionWarnings(cvs []componentVersions, window string) []string {
+	fleet := fleetRelease(cvs)
+	var out []string
+	for _, cv := range cvs {
+		if len(cv.Stale) > 0 {
+			stale := make([]string, len(cv.Stale))
+			for i, s := range cv.Stale {
+				stale[i] = s.Version
+			}
+			out = append(out, fmt.Sprintf("%s is running mixed versions this %s window: %s still recorded events after %s was rolled out — some instances were not upgraded",
+				cv.Component, window, strings.Join(stale, ", "), cv.Current.Version))
+		}
+		if c, ok := compareReleases(cv.Current.Version, fleet); ok && c < 0 {
+			out = append(out, fmt.Sprintf("%s is outdated: it runs %s while other components run %s", cv.Component, cv.Current.Version, fleet))
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestAnalyzeVersions(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	ev := func(component, version string, minutes int) audit.Event {
+		return audit.Event{
+			Timestamp: t0.Add(time.Duration(minutes) * time.Minute),
+			Producer:  &audit.Producer{Component: component, Version: version},
+		}
+	}
+	events := []audit.Event{
+		// database-agent: v1.3.0 rolled out at 09:30, one v1.2.0 instance
+		// still recording at 10:00.
+		ev("database-agent", "v1.2.0-aaaaaaa", 0),
+		ev("database-agent", "v1.3.0-bbbbbbb", 30),
+		ev("database-agent", "v1.2.0-aaaaaaa", 60),
+		// k8s-agent: cleanly upgraded, but to an older release.
+		ev("k8s-agent", "v1.1.0-ccccccc", 0),
+		ev("k8s-agent", "v1.2.0-aaaaaaa", 20),
+		ev("k8s-agent", "v1.2.0-aaaaaaa", 40),
+		// auditd: a dev build is not ordered against releases.
+		ev("auditd", "dev", 10),
+		{Timestamp: t0},
+	}
+
+	cvs, unstamped := analyzeVersions(events)
+	if unstamped != 1 {
+		t.Errorf("unstamped = %d, want 1", unstamped)
+	}
+	if len(cvs) != 3 || cvs[0].Component != "auditd" || cvs[1].Component != "database-agent" || cvs[2].Component != "k8s-agent" {
+		t.Fatalf("components = %+v", cvs)
+	}
+	db := cvs[1]
+	if db.Current.Version != "v1.3.0-bbbbbbb" || len(db.Stale) != 1 || db.Stale[0].Version != "v1.2.0-aaaaaaa" {
+		t.Errorf("database-agent = %+v", db)
+	}
+	k8s := cvs[2]
+	if k8s.Current.Version != "v1.2.0-aaaaaaa" || k8s.Current.Events != 2 || len(k8s.Others) != 1 || len(k8s.Stale) != 0 {
+		t.Errorf("k8s-agent = %+v", k8s)
+	}
+	if got := fleetRelease(cvs); got != "v1.3.0-bbbbbbb" {
+		t.Errorf("fleetRelease = %q", got)
+	}
+
+	warnings := versionWarnings(cvs, "24h")
+	want := []string{
+		"database-agent is running mixed versions this 24h window: v1.2.0-aaaaaaa still recorded events after v1.3.0-bbbbbbb was rolled out — some instances were not upgraded",
+		"k8s-agent is outdated: it runs v1.2.0-aaaaaaa while other components run v1.3.0-bbbbbbb",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings:\n%s\nwant:\n%s", strings.Join(warnings, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareReleases(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.10.0-abc", "v1.9.3-def", 1, true},
+		{"1.2", "v1.2.0", 0, true},
+		{"v0.9.0", "v1.0.0", -1, true},
+		{"dev", "v1.0.0", 0, false},
+	} {
+		got, ok := compareReleases(tc.a, tc.b)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("compareReleases(%q, %q) = %d, %v; want %d, %v", tc.a, tc.b, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// exportPageSize is how many events each request of an export fetches.
+const exportPageSize = 1000
+
+// exportHeader is the CSV column set compliance signs off against. Add
+// columns at the end only: reviewers diff exports quarter to quarter.
+var exportHeader = []string{"event_id", "timestamp", "resource", "action", "effect", "policy", "trace_origin", "approver"}
+
+// exportDecision is the part of a policy_decision event an export reads.
+type exportDecision struct {
+	EventID   string    `json:"event_id"`
+	Timestamp time.Time `json:"timestamp"`
+	TraceID   string    `json:"trace_id"`
+	Approval  *struct {
+		ApprovedBy string `json:"approved_by"`
+	} `json:"approval"`
+	PolicyDecision *struct {
+		ResourceType string `json:"resource_type"`
+		ResourceName string `json:"resource_name"`
+		Action       string `json:"action"`
+		Effect       string `json:"effect"`
+		PolicyName   string `json:"policy_name"`
+	} `json:"policy_decision"`
+}
+
+// runExport writes every policy decision in [since, until) matching the
+// filters to path ("-" for stdout) as CSV, oldest first. Unlike --list it is
+// not capped by --limit, and it exits 0 once the file is written whatever the
+// decisions were: compliance reviews the file, not the exit code.
+func runExport(client *http.Client, eventsURL, approvalsURL string, since, until time.Time, session, trace, tracePrefix, effectFilter, path string) int {
+	decisions, err := fetchDecisions(client, eventsURL, since, until, session, trace, tracePrefix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 3
+	}
+	var rows [][]string
+	approvers := approverLookup{client: client, url: approvalsURL, byTrace: map[string][]*audit.StoredApproval{}}
+	for _, d := range decisions {
+		pd := d.PolicyDecision
+		if pd == nil || (effectFilter != "" && pd.Effect != effectFilter) {
+			continue
+		}
+		approver, err := approvers.approver(d)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: looking up the approver of", d.EventID+":", err)
+			return 3
+		}
+		origin := ""
+		if p, ok := audit.LookupTracePrefix(d.TraceID); ok {
+			origin = p.Origin
+		}
+		rows = append(rows, []string{
+			d.EventID,
+			d.Timestamp.UTC().Format(time.RFC3339Nano),
+			pd.ResourceType + ":" + pd.ResourceName,
+			pd.Action,
+			pd.Effect,
+			pd.PolicyName,
+			origin,
+			approver,
+		})
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 3
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeExportCSV(w, rows); err != nil {
+		fmt.Fprintln(os.Stderr, "error: writing export:", err)
+		return 3
+	}
+	if path != "-" {
+		fmt.Fprintf(os.Stderr, "Exported %d policy decision(s) from %s to %s to %s\n",
+			len(rows), since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339), path)
+	}
+	return 0
+}
+
+// writeExportCSV writes the header and rows.
+func writeExportCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportHeader); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// fetchDecisions pages through the policy decisions in [since, until) and
+// returns them oldest first. The events API returns a trace's events oldest
+// first and everything else newest first, so each page moves the window
+// past its last event in whichever direction it was sorted.
+func fetchDecisions(client *http.Client, eventsURL string, since, until time.Time, session, trace, tracePrefix string) ([]exportDecision, error) {
+	seen := make(map[string]bool)
+	var out []exportDecision
+	for {
+		q := url.Values{}
+		q.Set("event_type", "policy_decision")
+		q.Set("since", since.UTC().Format(time.RFC3339Nano))
+		q.Set("until", until.UTC().Format(time.RFC3339Nano))
+		q.Set("limit", fmt.Sprint(exportPageSize))
+		if session != "" {
+			q.Set("session_id", session)
+		}
+		if trace != "" {
+			q.Set("trace_id", trace)
+		}
+		if tracePrefix != "" {
+			q.Set("trace_id_prefix", tracePrefix)
+		}
+		var page []exportDecision
+		if err := getJSON(client, eventsURL+"?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, d := range page {
+			if !seen[d.EventID] {
+				seen[d.EventID] = true
+				out = append(out, d)
+				added++
+			}
+		}
+		if len(page) < exportPageSize || added == 0 {
+			break
+		}
+		// Events sharing the boundary timestamp are fetched again and
+		// skipped as seen, so none is lost between pages.
+		first, last := page[0].Timestamp, page[len(page)-1].Timestamp
+		if first.After(last) {
+			until = last.Add(time.Nanosecond)
+		} else {
+			since = last
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Timestamp.Equal(out[j].Timestamp) {
+			return out[i].Timestamp.Before(out[j].Timestamp)
+		}
+		return out[i].EventID < out[j].EventID
+	})
+	return out, nil
+}
+
+// approverLookup finds who resolved the approval a decision required,
+// fetching each trace's approvals once.
+type approverLookup struct {
+	client  *http.Client
+	url     string
+	byTrace map[string][]*audit.StoredApproval
+}
+
+// approver returns who approved the decision: the principal recorded on an
+// auto-approved decision, or whoever resolved the approval requested in the
+// same trace for the same resource. It is empty for decisions that needed no
+// approval, and for approvals still pending or expired.
+func (l *approverLookup) approver(d exportDecision) (string, error) {
+	if d.Approval != nil && d.Approval.ApprovedBy != "" {
+		return d.Approval.ApprovedBy, nil
+	}
+	if d.PolicyDecision.Effect != "require_approval" || d.TraceID == "" {
+		return "", nil
+	}
+	approvals, ok := l.byTrace[d.TraceID]
+	if !ok {
+		q := url.Values{}
+		q.Set("trace_id", d.TraceID)
+		if err := getJSON(l.client, l.url+"?"+q.Encode(), &approvals); err != nil {
+			return "", err
+		}
+		l.byTrace[d.TraceID] = approvals
+	}
+	for _, a := range approvals {
+		if a.ResolvedBy == "" || (a.ResourceName != "" && a.ResourceName != d.PolicyDecision.ResourceName) {
+			continue
+		}
+		return a.ResolvedBy, nil
+	}
+	return "", nil
+}
+
+// getJSON GETs endpoint and decodes the JSON response into v.
+func getJSON(client *http.Client, endpoint string, v any) error {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
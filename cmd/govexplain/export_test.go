@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeAuditd serves /v1/events newest first with since and until, and
+// /v1/approvals by trace ID.
+type fakeAuditd struct {
+	events    []json.RawMessage // oldest first
+	stamps    []time.Time
+	approvals map[string]string
+}
+
+func (f *fakeAuditd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if r.URL.Path == "/v1/approvals" {
+		out := []map[string]string{}
+		if by, ok := f.approvals[q.Get("trace_id")]; ok {
+			out = append(out, map[string]string{"trace_id": q.Get("trace_id"), "status": "approved", "resolved_by": by})
+		}
+		json.NewEncoder(w).Encode(out) //nolint:errcheck
+		return
+	}
+	since, _ := time.Parse(time.RFC3339Nano, q.Get("since"))
+	until, _ := time.Parse(time.RFC3339Nano, q.Get("until"))
+	limit := 100
+	fmt.Sscan(q.Get("limit"), &limit) //nolint:errcheck
+	out := []json.RawMessage{}
+	for i := len(f.events) - 1; i >= 0 && len(out) < limit; i-- {
+		if !f.stamps[i].Before(since) && f.stamps[i].Before(until) {
+			out = append(out, f.events[i])
+		}
+	}
+	json.NewEncoder(w).Encode(out) //nolint:errcheck
+}
+
+func TestRunExport(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &fakeAuditd{approvals: map[string]string{"tr_approved": "alice@example.com"}}
+	add := func(id string, ts time.Time, effect, traceID string) {
+		f.events = append(f.events, decisionJSON(id, ts, effect, traceID))
+		f.stamps = append(f.stamps, ts)
+	}
+	// More than a page, with several decisions sharing the page boundary's
+	// timestamp, and one outside the range on each side.
+	add("pol_before", start.Add(-time.Second), "allow", "chk_1")
+	for i := 0; i < exportPageSize+5; i++ {
+		ts := start.Add(time.Duration(i) * time.Second)
+		if i >= 3 && i < 8 {
+			ts = start.Add(3 * time.Second)
+		}
+		add(fmt.Sprintf("pol_%04d", i), ts, "allow", "dt_1")
+	}
+	add("pol_approval", start.Add(2*time.Hour), "require_approval", "tr_approved")
+	add("pol_after", start.Add(24*time.Hour), "deny", "tr_1")
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "decisions.csv")
+	code := runExport(srv.Client(), srv.URL+"/v1/events", srv.URL+"/v1/approvals",
+		start, start.Add(3*time.Hour), "", "", "", "", path)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(records) != exportPageSize+7 {
+		t.Fatalf("rows = %d, want header + %d decisions", len(records), exportPageSize+6)
+	}
+	if got := records[0]; fmt.Sprint(got) != fmt.Sprint(exportHeader) {
+		t.Errorf("header = %v", got)
+	}
+	if got := records[1]; got[0] != "pol_0000" || got[1] != "2026-01-01T00:00:00Z" || got[2] != "database:prod-db" ||
+		got[3] != "write" || got[4] != "allow" || got[5] != "p" || got[6] != "direct_tool" || got[7] != "" {
+		t.Errorf("first row = %v", got)
+	}
+	if got := records[len(records)-1]; got[0] != "pol_approval" || got[4] != "require_approval" || got[6] != "query" || got[7] != "alice@example.com" {
+		t.Errorf("last row = %v, want the approved decision", got)
+	}
+	seen := map[string]bool{}
+	for _, r := range records[1:] {
+		if seen[r[0]] {
+			t.Errorf("%s exported twice", r[0])
+		}
+		seen[r[0]] = true
+	}
+
+	// --effect narrows the export.
+	if code := runExport(srv.Client(), srv.URL+"/v1/events", srv.URL+"/v1/approvals",
+		start, start.Add(48*time.Hour), "", "", "", "deny", path); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "event_id,timestamp,resource,action,effect,policy,trace_origin,approver\n"+
+		"pol_after,2026-01-02T00:00:00Z,database:prod-db,write,deny,p,query,\n" {
+		t.Errorf("deny export = %q", data)
+	}
+}
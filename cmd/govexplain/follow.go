@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/audit/auditpb"
+	"helpdesk/internal/cliout"
+)
+
+// decisionSource delivers policy_decision events recorded from now on to
+// emit, oldest first, until ctx is done or it fails for good.
+type decisionSource func(ctx context.Context, emit func(json.RawMessage)) error
+
+// followFilter holds the list filters --follow applies to each new event.
+// The polling source also passes them to the server; the subscription
+// cannot, so every source checks them here.
+type followFilter struct {
+	session, trace, tracePrefix, effect string
+}
+
+func (f followFilter) match(e parsedEvent) bool {
+	if f.effect != "" && e.effStr != f.effect {
+		return false
+	}
+	var meta struct {
+		TraceID string `json:"trace_id"`
+		Session struct {
+			ID string `json:"id"`
+		} `json:"session"`
+	}
+	_ = json.Unmarshal(e.event, &meta)
+	return (f.session == "" || meta.Session.ID == f.session) &&
+		(f.trace == "" || meta.TraceID == f.trace) &&
+		strings.HasPrefix(meta.TraceID, f.tracePrefix)
+}
+
+// runFollow prints each new policy decision from src as it arrives, until
+// interrupted. Like --list, it exits with the worst decision it printed.
+func runFollow(src decisionSource, filter followFilter, output cliout.Format) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintln(os.Stderr, "Following policy decisions (Ctrl+C to stop)...")
+
+	f := newFollowPrinter(os.Stdout, output)
+	err := src(ctx, func(raw json.RawMessage) {
+		if e, ok := parseEvent(raw); ok && filter.match(e) {
+			f.print(e)
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 3
+	}
+	return f.result
+}
+
+// followPrinter prints followed events one at a time in the chosen format:
+// explanations, table rows, one JSON object per line, or YAML documents.
+type followPrinter struct {
+	w      io.Writer
+	output cliout.Format
+	n      int
+	result int
+}
+
+func newFollowPrinter(w io.Writer, output cliout.Format) *followPrinter {
+	return &followPrinter{w: w, output: output}
+}
+
+// followRow lays out table rows without buffering them, so each row is
+// printed as soon as its event arrives.
+const followRow = "%-14s  %-14s  %-16s  %-11s  %-28s  %-28s  %s\n"
+
+func (f *followPrinter) print(e parsedEvent) {
+	f.result = worseCode(f.result, effectToCode(e.effStr))
+	switch f.output {
+	case cliout.JSON:
+		var buf bytes.Buffer
+		if json.Compact(&buf, e.event) == nil {
+			fmt.Fprintln(f.w, buf.String())
+		}
+	case cliout.YAML:
+		fmt.Fprintln(f.w, "---")
+		_ = cliout.WriteRaw(f.w, cliout.YAML, e.event)
+	case cliout.Table:
+		if f.n == 0 {
+			fmt.Fprintf(f.w, followRow, toAny(tableHeader)...)
+		}
+		fmt.Fprintf(f.w, followRow, toAny(tableColumns(e))...)
+	default:
+		if f.n > 0 {
+			fmt.Fprintln(f.w, listSeparator)
+		}
+		printExplanation(f.w, e)
+	}
+	f.n++
+}
+
+func toAny(cols []string) []any {
+	out := make([]any, len(cols))
+	for i, c := range cols {
+		out[i] = c
+	}
+	return out
+}
+
+// pollDecisions returns a source that polls an events list endpoint
+// (auditd's /v1/events or the gateway's /api/v1/governance/events) every
+// interval. The cursor is the newest timestamp seen; since is inclusive, so
+// events at exactly that timestamp are skipped by ID.
+func pollDecisions(client *http.Client, endpoint string, filter followFilter, interval time.Duration) decisionSource {
+	return func(ctx context.Context, emit func(json.RawMessage)) error {
+		q := url.Values{}
+		q.Set("event_type", "policy_decision")
+		if filter.session != "" {
+			q.Set("session_id", filter.session)
+		}
+		if filter.trace != "" {
+			q.Set("trace_id", filter.trace)
+		}
+		if filter.tracePrefix != "" {
+			q.Set("trace_id_prefix", filter.tracePrefix)
+		}
+
+		// Start after the newest existing decision, by the server's clock.
+		q.Set("limit", "1")
+		newest, err := fetchEvents(ctx, client, endpoint, q)
+		if err != nil {
+			return err
+		}
+		var cursor time.Time
+		seen := map[string]bool{}
+		for _, e := range newest {
+			cursor, seen = e.ts, map[string]bool{e.id: true}
+		}
+
+		q.Set("limit", "500")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+			if !cursor.IsZero() {
+				q.Set("since", cursor.UTC().Format(time.RFC3339Nano))
+			}
+			events, err := fetchEvents(ctx, client, endpoint, q)
+			if err != nil {
+				var fatal *fatalHTTPError
+				if errors.As(err, &fatal) {
+					return err
+				}
+				if ctx.Err() == nil {
+					fmt.Fprintln(os.Stderr, "warning: poll failed, retrying:", err)
+				}
+				continue
+			}
+			for _, e := range events {
+				if seen[e.id] {
+					continue
+				}
+				emit(e.raw)
+				if e.ts.After(cursor) {
+					cursor, seen = e.ts, map[string]bool{}
+				}
+				seen[e.id] = true
+			}
+		}
+	}
+}
+
+// polledEvent is one event from a poll with the fields the cursor needs.
+type polledEvent struct {
+	id  string
+	ts  time.Time
+	raw json.RawMessage
+}
+
+// fatalHTTPError is a client error (bad filter, missing credentials) that
+// retrying will not fix.
+type fatalHTTPError struct {
+	code int
+	body string
+}
+
+func (e *fatalHTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.body)
+}
+
+// fetchEvents GETs endpoint with q and returns the events oldest first.
+func fetchEvents(ctx context.Context, client *http.Client, endpoint string, q url.Values) ([]polledEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(body))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, &fatalHTTPError{code: resp.StatusCode, body: msg}
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, msg)
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, fmt.Errorf("parse events: %w", err)
+	}
+	events := make([]polledEvent, 0, len(raws))
+	for _, raw := range raws {
+		var meta struct {
+			EventID   string    `json:"event_id"`
+			Timestamp time.Time `json:"timestamp"`
+		}
+		if json.Unmarshal(raw, &meta) != nil {
+			continue
+		}
+		events = append(events, polledEvent{id: meta.EventID, ts: meta.Timestamp, raw: raw})
+	}
+	// The API lists newest first (oldest first for trace filters); reversing
+	// first keeps events with the same timestamp in recording order.
+	if n := len(events); n > 1 && events[0].ts.After(events[n-1].ts) {
+		slices.Reverse(events)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ts.Before(events[j].ts) })
+	return events, nil
+}
+
+// subscribeDecisions returns a source that streams policy decisions from
+// auditd's gRPC SubscribeEvents, which pushes each event as it is recorded.
+// A broken stream is resumed from the last sequence number received, so no
+// decision is skipped while auditd restarts.
+func subscribeDecisions(addr, apiKey string) decisionSource {
+	return func(ctx context.Context, emit func(json.RawMessage)) error {
+		store, err := audit.NewGRPCStore(addr, apiKey)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		client := store.Client()
+
+		var lastSeq *int64
+		backoff := time.Second
+		for {
+			stream, err := client.SubscribeEvents(ctx, &auditpb.SubscribeEventsRequest{
+				ReplayAfter: lastSeq,
+				EventTypes:  []string{string(audit.EventTypePolicyDecision)},
+			})
+			for err == nil {
+				var ev *auditpb.AuditEvent
+				if ev, err = stream.Recv(); err == nil {
+					seq := ev.Seq
+					lastSeq, backoff = &seq, time.Second
+					emit(ev.EventJson)
+				}
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			switch status.Code(err) {
+			case codes.Unauthenticated, codes.PermissionDenied, codes.InvalidArgument, codes.Unimplemented:
+				return fmt.Errorf("subscribe: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "warning: subscription ended (%v), resubscribing in %s\n", status.Convert(err).Message(), backoff)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, 30*time.Second)
+		}
+	}
+}
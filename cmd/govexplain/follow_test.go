@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"helpdesk/internal/audit/auditpb"
+	"helpdesk/internal/cliout"
+)
+
+func decisionJSON(id string, ts time.Time, effect, traceID string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(
+		`{"event_id":%q,"timestamp":%q,"event_type":"policy_decision","trace_id":%q,"session":{"id":"s1"},"policy_decision":{"resource_type":"database","resource_name":"prod-db","action":"write","effect":%q,"policy_name":"p","explanation":"explained %s"}}`,
+		id, ts.Format(time.RFC3339Nano), traceID, effect, id))
+}
+
+// fakeEvents serves /v1/events like auditd: newest first, since inclusive.
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []json.RawMessage
+	stamps []time.Time
+}
+
+func (f *fakeEvents) add(id string, ts time.Time, effect string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, decisionJSON(id, ts, effect, "tr_1"))
+	f.stamps = append(f.stamps, ts)
+}
+
+func (f *fakeEvents) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		since, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	limit := 100
+	fmt.Sscan(r.URL.Query().Get("limit"), &limit) //nolint:errcheck
+	out := []json.RawMessage{}
+	for i := len(f.events) - 1; i >= 0 && len(out) < limit; i-- {
+		if !f.stamps[i].Before(since) {
+			out = append(out, f.events[i])
+		}
+	}
+	json.NewEncoder(w).Encode(out) //nolint:errcheck
+}
+
+func TestPollDecisions_EmitsEachNewDecisionOnce(t *testing.T) {
+	base := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+	fake := &fakeEvents{}
+	fake.add("pol_old", base, "allow")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan string, 10)
+	src := pollDecisions(srv.Client(), srv.URL, followFilter{}, 10*time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		done <- src(ctx, func(raw json.RawMessage) {
+			e, _ := parseEvent(raw)
+			var id string
+			_ = json.Unmarshal(e.raw["event_id"], &id)
+			got <- id
+		})
+	}()
+
+	time.Sleep(30 * time.Millisecond) // the first poll has set the cursor
+	fake.add("pol_a", base.Add(time.Second), "deny")
+	fake.add("pol_b", base.Add(time.Second), "allow") // same timestamp as pol_a
+	fake.add("pol_c", base.Add(2*time.Second), "require_approval")
+
+	var ids []string
+	for len(ids) < 3 {
+		select {
+		case id := <-got:
+			ids = append(ids, id)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %v, want pol_a, pol_b, pol_c", ids)
+		}
+	}
+	time.Sleep(50 * time.Millisecond) // further polls must not repeat anything
+	cancel()
+	<-done
+	close(got)
+	for id := range got {
+		ids = append(ids, id)
+	}
+	if strings.Join(ids, ",") != "pol_a,pol_b,pol_c" {
+		t.Errorf("emitted %v, want each new decision once, oldest first", ids)
+	}
+}
+
+func TestPollDecisions_StopsOnClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	err := pollDecisions(srv.Client(), srv.URL, followFilter{}, time.Millisecond)(context.Background(), func(json.RawMessage) {})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want the HTTP 401", err)
+	}
+}
+
+// fakeSubscriber ends the first subscription after two events, as auditd
+// does when it shuts down, and serves the rest to the resubscription.
+type fakeSubscriber struct {
+	auditpb.UnimplementedAuditServiceServer
+	mu      sync.Mutex
+	replays []string
+}
+
+func (f *fakeSubscriber) SubscribeEvents(req *auditpb.SubscribeEventsRequest, stream grpc.ServerStreamingServer[auditpb.AuditEvent]) error {
+	f.mu.Lock()
+	replay := "live"
+	if req.ReplayAfter != nil {
+		replay = fmt.Sprint(*req.ReplayAfter)
+	}
+	f.replays = append(f.replays, replay)
+	first := len(f.replays) == 1
+	f.mu.Unlock()
+
+	if strings.Join(req.EventTypes, ",") != "policy_decision" {
+		return status.Error(codes.InvalidArgument, "want policy_decision only")
+	}
+	send := func(seq int64, id string) error {
+		return stream.Send(&auditpb.AuditEvent{Seq: seq, EventId: id, EventJson: decisionJSON(id, time.Now(), "allow", "tr_1")})
+	}
+	if first {
+		if err := send(1, "pol_1"); err != nil {
+			return err
+		}
+		if err := send(2, "pol_2"); err != nil {
+			return err
+		}
+		return status.Error(codes.Unavailable, "shutting down")
+	}
+	if err := send(3, "pol_3"); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestSubscribeDecisions_ResumesAfterStreamEnds(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeSubscriber{}
+	gs := grpc.NewServer()
+	auditpb.RegisterAuditServiceServer(gs, fake)
+	go gs.Serve(ln) //nolint:errcheck
+	defer gs.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var ids []string
+	err = subscribeDecisions(ln.Addr().String(), "")(ctx, func(raw json.RawMessage) {
+		e, _ := parseEvent(raw)
+		var id string
+		_ = json.Unmarshal(e.raw["event_id"], &id)
+		if ids = append(ids, id); len(ids) == 3 {
+			cancel()
+		}
+	})
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("subscription stopped early: %v (received %v)", err, ids)
+	}
+	if strings.Join(ids, ",") != "pol_1,pol_2,pol_3" {
+		t.Errorf("received %v", ids)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if strings.Join(fake.replays, ",") != "live,2" {
+		t.Errorf("subscriptions = %v, want live then replay after seq 2", fake.replays)
+	}
+}
+
+func TestFollowPrinter(t *testing.T) {
+	now := time.Now()
+	events := []json.RawMessage{
+		decisionJSON("pol_1", now, "allow", "chk_1"),
+		decisionJSON("pol_2", now, "require_approval", "chk_2"),
+		decisionJSON("pol_3", now, "deny", "sess_3"),
+	}
+	filter := followFilter{tracePrefix: "chk_"}
+
+	tests := []struct {
+		output cliout.Format
+		want   []string
+	}{
+		{"", []string{"pol_1  ", "explained pol_1\n" + listSeparator + "\npol_2"}},
+		{cliout.Table, []string{"EVENT ", "ALLOW", "REQUIRE_APPROVAL", "database:prod-db"}},
+		{cliout.JSON, []string{`{"event_id":"pol_1",`, "}\n{\"event_id\":\"pol_2\","}},
+		{cliout.YAML, []string{"---\nevent_id: pol_1\n", "---\nevent_id: pol_2\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.output), func(t *testing.T) {
+			var buf bytes.Buffer
+			p := newFollowPrinter(&buf, tt.output)
+			for _, raw := range events {
+				if e, ok := parseEvent(raw); ok && filter.match(e) {
+					p.print(e)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			if strings.Contains(buf.String(), "pol_3") {
+				t.Errorf("filtered-out event printed:\n%s", buf.String())
+			}
+			if p.result != 2 {
+				t.Errorf("result = %d, want 2 (require_approval, no deny)", p.result)
+			}
+		})
+	}
+}
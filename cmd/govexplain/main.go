@@ -13,6 +13,19 @@
 //     govexplain --auditd http://localhost:1199 --list --since 1h
 //     govexplain --auditd http://localhost:1199 --list --effect deny
 //
+//     With --follow, list mode keeps running and explains each new decision
+//     as it is recorded:
+//     govexplain --auditd http://localhost:1199 --list --follow
+//
+//     With --export, list mode writes every decision in a date range to a CSV
+//     file for audit sign-off:
+//     govexplain --list --since 2026-01-01T00:00:00Z --until 2026-04-01T00:00:00Z \
+//     --export decisions.csv
+//
+// -o json|yaml prints the decision trace or the audit events instead of the
+// explanation text; -o table prints list mode as one row per event. The exit
+// code is the same in every output format.
+//
 // Exit codes:
 //
 //	0  allowed (or all events allowed in list mode)
@@ -22,6 +35,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -34,11 +48,16 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/cliout"
 	"helpdesk/internal/infra"
 	"helpdesk/internal/policy"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	gateway := flag.String("gateway", envOrDefault("HELPDESK_GATEWAY_URL", "http://localhost:8080"), "Gateway base URL (requires gateway + auditd)")
 	auditd := flag.String("auditd", envOrDefault("HELPDESK_AUDIT_URL", ""), "Auditd base URL — bypasses the gateway (e.g. http://localhost:1199)")
 	policyFile := flag.String("policy-file", envOrDefault("HELPDESK_POLICY_FILE", ""), "Policy file for local evaluation — no server required (e.g. policies.yaml)")
@@ -52,7 +71,9 @@ func main() {
 	purpose := flag.String("purpose", "", "Declared purpose: diagnostic, remediation, maintenance, compliance, emergency")
 	sensitivity := flag.String("sensitivity", "", "Comma-separated sensitivity classes (e.g. pii,critical)")
 	apiKey := flag.String("api-key", envOrDefault("HELPDESK_CLIENT_API_KEY", ""), "Bearer token for gateway/auditd authentication (or set HELPDESK_CLIENT_API_KEY)")
-	asJSON := flag.Bool("json", false, "Output raw JSON instead of human-readable text")
+	var output cliout.Format
+	cliout.Register(flag.CommandLine, &output)
+	asJSON := flag.Bool("json", false, "Same as -o json")
 
 	// List mode flags
 	list := flag.Bool("list", false, "List policy decisions (batch retrospective mode)")
@@ -62,9 +83,20 @@ func main() {
 	tracePrefix := flag.String("trace-prefix", "", "Filter by trace ID prefix (e.g. chk_, sess_, dbagent_)")
 	effect := flag.String("effect", "", "Filter by effect: allow, deny, require_approval")
 	limit := flag.Int("limit", 20, "Maximum number of events to show in list mode")
-	table := flag.Bool("table", false, "Compact tabular output: one row per event")
+	table := flag.Bool("table", false, "Same as -o table: one row per event in list mode")
+	follow := flag.Bool("follow", false, "List mode: keep running and explain new decisions as they are recorded")
+	interval := flag.Duration("interval", 2*time.Second, "Poll interval for --follow without --auditd-grpc")
+	auditdGRPC := flag.String("auditd-grpc", envOrDefault("HELPDESK_AUDIT_GRPC_ADDR", ""), "Auditd gRPC address (e.g. localhost:1299); --follow subscribes to new decisions instead of polling")
+	export := flag.String("export", "", "List mode: write every decision from --since to --until to this CSV file (- for stdout) instead of printing them")
+	until := flag.String("until", "", "With --export, only events older than this: duration (1h, 30m) or RFC3339 timestamp (default: now)")
 
 	flag.Parse()
+	switch {
+	case *asJSON:
+		output = cliout.JSON
+	case *table:
+		output = cliout.Table
+	}
 
 	// Local mode: when --policy-file (or HELPDESK_POLICY_FILE) is set and the
 	// request is a hypothetical check (--resource + --action), evaluate the
@@ -77,23 +109,75 @@ func main() {
 			os.Exit(3)
 		}
 		resolvedTags, resolvedSensitivity := resolveFromInfra(*infraConfig, parts[0], parts[1], *tags, *sensitivity)
-		os.Exit(runLocalExplain(*policyFile, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, *asJSON))
+		os.Exit(runLocalExplain(*policyFile, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, output))
 	}
 
+	if err := audit.InstallAuditToken(context.Background(), *auditd); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(3)
+	}
 	client := &http.Client{Timeout: 10 * time.Second}
 	if *apiKey != "" {
 		client.Transport = &bearerTransport{base: http.DefaultTransport, token: *apiKey}
 	}
 
+	if *export != "" {
+		if !*list || *follow {
+			fmt.Fprintln(os.Stderr, "error: --export needs --list and cannot be combined with --follow")
+			os.Exit(3)
+		}
+		if *since == "" {
+			fmt.Fprintln(os.Stderr, "error: --export needs --since to bound the date range")
+			os.Exit(3)
+		}
+		from, err := parseSince(*since)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: --since:", err)
+			os.Exit(3)
+		}
+		to := time.Now()
+		if *until != "" {
+			if to, err = parseSince(*until); err != nil {
+				fmt.Fprintln(os.Stderr, "error: --until:", err)
+				os.Exit(3)
+			}
+		}
+		eventsURL := strings.TrimRight(*gateway, "/") + "/api/v1/governance/events"
+		approvalsURL := strings.TrimRight(*gateway, "/") + "/api/v1/governance/approvals"
+		if *auditd != "" {
+			eventsURL = strings.TrimRight(*auditd, "/") + "/v1/events"
+			approvalsURL = strings.TrimRight(*auditd, "/") + "/v1/approvals"
+		}
+		os.Exit(runExport(client, eventsURL, approvalsURL, from, to, *session, *trace, *tracePrefix, *effect, *export))
+	}
+
+	if *follow {
+		if *since != "" {
+			fmt.Fprintln(os.Stderr, "error: --since cannot be combined with --follow, which shows decisions recorded from now on")
+			os.Exit(3)
+		}
+		filter := followFilter{session: *session, trace: *trace, tracePrefix: *tracePrefix, effect: *effect}
+		var src decisionSource
+		switch {
+		case *auditdGRPC != "":
+			src = subscribeDecisions(*auditdGRPC, *apiKey)
+		case *auditd != "":
+			src = pollDecisions(client, strings.TrimRight(*auditd, "/")+"/v1/events", filter, *interval)
+		default:
+			src = pollDecisions(client, strings.TrimRight(*gateway, "/")+"/api/v1/governance/events", filter, *interval)
+		}
+		os.Exit(runFollow(src, filter, output))
+	}
+
 	// --auditd bypasses the gateway and talks directly to auditd.
 	// auditd exposes /v1/governance/explain and /v1/events/{id} natively.
 	if *auditd != "" {
 		base := strings.TrimRight(*auditd, "/")
 		if *list {
-			os.Exit(runList(client, base+"/v1/events", *since, *session, *trace, *tracePrefix, *effect, *limit, *asJSON, *table))
+			os.Exit(runList(client, base+"/v1/events", *since, *session, *trace, *tracePrefix, *effect, *limit, output))
 		}
 		if *event != "" {
-			os.Exit(runRetrospectiveDirect(client, *auditd, *event, *asJSON))
+			os.Exit(runRetrospectiveDirect(client, *auditd, *event, output))
 		}
 		if *resource == "" || *action == "" {
 			printUsage()
@@ -105,16 +189,16 @@ func main() {
 			os.Exit(3)
 		}
 		resolvedTags, resolvedSensitivity := resolveFromInfra(*infraConfig, parts[0], parts[1], *tags, *sensitivity)
-		os.Exit(runHypotheticalDirect(client, *auditd, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, *asJSON))
+		os.Exit(runHypotheticalDirect(client, *auditd, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, output))
 	}
 
 	if *list {
 		base := strings.TrimRight(*gateway, "/")
-		os.Exit(runList(client, base+"/api/v1/governance/events", *since, *session, *trace, *tracePrefix, *effect, *limit, *asJSON, *table))
+		os.Exit(runList(client, base+"/api/v1/governance/events", *since, *session, *trace, *tracePrefix, *effect, *limit, output))
 	}
 
 	if *event != "" {
-		os.Exit(runRetrospective(client, *gateway, *event, *asJSON))
+		os.Exit(runRetrospective(client, *gateway, *event, output))
 	}
 
 	if *resource == "" || *action == "" {
@@ -129,7 +213,7 @@ func main() {
 	}
 
 	resolvedTags, resolvedSensitivity := resolveFromInfra(*infraConfig, parts[0], parts[1], *tags, *sensitivity)
-	os.Exit(runHypothetical(client, *gateway, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, *asJSON))
+	os.Exit(runHypothetical(client, *gateway, parts[0], parts[1], *action, resolvedTags, *userID, *role, *purpose, resolvedSensitivity, output))
 }
 
 // resolveFromInfra loads the infra config (if a path is given) and fills in
@@ -147,8 +231,8 @@ func resolveFromInfra(infraPath, resourceType, resourceName, tags, sensitivity s
 	if tags == "" {
 		switch resourceType {
 		case "database":
-			if db, ok := cfg.DBServers[resourceName]; ok && len(db.Tags) > 0 {
-				tags = strings.Join(db.Tags, ",")
+			if db, ok := cfg.DBServers[resourceName]; ok {
+				tags = strings.Join(cfg.DatabaseTags(db), ",")
 			}
 		case "kubernetes":
 			if k8s, ok := cfg.K8sClusters[resourceName]; ok && len(k8s.Tags) > 0 {
@@ -181,6 +265,14 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "  List (direct):               govexplain --auditd http://localhost:1199 --list [--since 1h] [--effect deny]")
 	fmt.Fprintln(os.Stderr, "  List (via gateway):          govexplain --list [--since 1h] [--session ID] [--limit 50]")
 	fmt.Fprintln(os.Stderr, "  List by trace prefix:        govexplain --auditd http://localhost:1199 --list --trace-prefix chk_")
+	fmt.Fprintln(os.Stderr, "  Follow new decisions:        govexplain --auditd http://localhost:1199 --list --follow [--effect deny]")
+	fmt.Fprintln(os.Stderr, "  Export to CSV:               govexplain --list --since 2026-01-01T00:00:00Z --until 2026-04-01T00:00:00Z --export decisions.csv")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Output:")
+	fmt.Fprintln(os.Stderr, "  -o table|json|yaml   Explanation text (list mode: one row per event), or the trace/events")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Exit codes:")
+	fmt.Fprintln(os.Stderr, "  0 allowed, 1 denied, 2 requires approval, 3 error; list mode reports the worst decision listed")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Authentication:")
 	fmt.Fprintln(os.Stderr, "  --api-key KEY   Bearer token for gateway/auditd (or set HELPDESK_CLIENT_API_KEY)")
@@ -188,12 +280,22 @@ func printUsage() {
 
 // parsedEvent holds a decoded audit event alongside its extracted effect string.
 type parsedEvent struct {
+	event  json.RawMessage
 	raw    map[string]json.RawMessage
 	effStr string
 }
 
+// parseEvent decodes one audit event returned by the events API.
+func parseEvent(raw json.RawMessage) (parsedEvent, bool) {
+	var ev map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return parsedEvent{}, false
+	}
+	return parsedEvent{event: raw, raw: ev, effStr: extractEffect(ev)}, true
+}
+
 // runList fetches multiple policy_decision events and prints their explanations.
-func runList(client *http.Client, baseURL, since, session, trace, tracePrefix, effectFilter string, limit int, asJSON, asTable bool) int {
+func runList(client *http.Client, baseURL, since, session, trace, tracePrefix, effectFilter string, limit int, output cliout.Format) int {
 	q := url.Values{}
 	q.Set("event_type", "policy_decision")
 	if session != "" {
@@ -232,11 +334,6 @@ func runList(client *http.Client, baseURL, since, session, trace, tracePrefix, e
 		return 3
 	}
 
-	if asJSON {
-		fmt.Println(string(body))
-		return 0
-	}
-
 	var events []json.RawMessage
 	if err := json.Unmarshal(body, &events); err != nil {
 		fmt.Fprintln(os.Stderr, "error parsing response:", err)
@@ -249,18 +346,14 @@ func runList(client *http.Client, baseURL, since, session, trace, tracePrefix, e
 		if len(filtered) >= limit {
 			break
 		}
-		var ev map[string]json.RawMessage
-		if err := json.Unmarshal(raw, &ev); err != nil {
-			continue
-		}
-		effStr := extractEffect(ev)
-		if effectFilter != "" && effStr != effectFilter {
+		e, ok := parseEvent(raw)
+		if !ok || (effectFilter != "" && e.effStr != effectFilter) {
 			continue
 		}
-		filtered = append(filtered, parsedEvent{ev, effStr})
+		filtered = append(filtered, e)
 	}
 
-	if len(filtered) == 0 {
+	if len(filtered) == 0 && !output.Structured() {
 		fmt.Println("No policy decision events found.")
 		return 0
 	}
@@ -268,97 +361,115 @@ func runList(client *http.Client, baseURL, since, session, trace, tracePrefix, e
 	// Compute worst exit code across all events.
 	result := 0
 	for _, e := range filtered {
-		code := effectToCode(e.effStr)
-		if code == 1 || (result != 1 && code == 2) {
-			result = code
-		}
+		result = worseCode(result, effectToCode(e.effStr))
 	}
 
-	if asTable {
-		return printTable(filtered)
+	switch {
+	case output.Structured():
+		out := make([]json.RawMessage, len(filtered))
+		for i, e := range filtered {
+			out[i] = e.event
+		}
+		if err := cliout.Write(os.Stdout, output, out); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 3
+		}
+		return result
+	case output == cliout.Table:
+		printTable(filtered)
+		return result
 	}
 
-	sep := strings.Repeat("─", 60)
 	for i, e := range filtered {
 		if i > 0 {
-			fmt.Println(sep)
+			fmt.Println(listSeparator)
 		}
+		printExplanation(os.Stdout, e)
+	}
 
-		var eventID, ts string
-		if v, ok := e.raw["event_id"]; ok {
-			_ = json.Unmarshal(v, &eventID)
-		}
-		if v, ok := e.raw["timestamp"]; ok {
-			_ = json.Unmarshal(v, &ts)
-			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
-				ts = t.Local().Format("2006-01-02 15:04:05")
-			}
+	return result
+}
+
+// listSeparator separates explanations in list mode.
+var listSeparator = strings.Repeat("─", 60)
+
+// printExplanation prints an event's ID, time and policy explanation.
+func printExplanation(w io.Writer, e parsedEvent) {
+	var eventID, ts string
+	if v, ok := e.raw["event_id"]; ok {
+		_ = json.Unmarshal(v, &eventID)
+	}
+	if v, ok := e.raw["timestamp"]; ok {
+		_ = json.Unmarshal(v, &ts)
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			ts = t.Local().Format("2006-01-02 15:04:05")
 		}
-		fmt.Printf("%s  %s\n", eventID, ts)
+	}
+	fmt.Fprintf(w, "%s  %s\n", eventID, ts)
 
-		if pdRaw, ok := e.raw["policy_decision"]; ok {
-			var pd map[string]json.RawMessage
-			if json.Unmarshal(pdRaw, &pd) == nil {
-				if expl, ok := pd["explanation"]; ok {
-					var s string
-					if json.Unmarshal(expl, &s) == nil && s != "" {
-						fmt.Println(s)
-					}
+	if pdRaw, ok := e.raw["policy_decision"]; ok {
+		var pd map[string]json.RawMessage
+		if json.Unmarshal(pdRaw, &pd) == nil {
+			if expl, ok := pd["explanation"]; ok {
+				var s string
+				if json.Unmarshal(expl, &s) == nil && s != "" {
+					fmt.Fprintln(w, s)
 				}
 			}
 		}
 	}
-
-	return result
 }
 
 // printTable renders policy decision events as a compact tabular summary.
 // Columns: EVENT  TIME  EFFECT  ACTION  RESOURCE  POLICY  TRACE
-func printTable(events []parsedEvent) int {
+func printTable(events []parsedEvent) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "EVENT\tTIME\tEFFECT\tACTION\tRESOURCE\tPOLICY\tTRACE")
-
+	_, _ = fmt.Fprintln(w, strings.Join(tableHeader, "\t"))
 	for _, e := range events {
-		var eventID, ts, traceID string
-		var resourceType, resourceName, action, policyName string
+		_, _ = fmt.Fprintln(w, strings.Join(tableColumns(e), "\t"))
+	}
+	_ = w.Flush()
+}
+
+var tableHeader = []string{"EVENT", "TIME", "EFFECT", "ACTION", "RESOURCE", "POLICY", "TRACE"}
+
+// tableColumns returns the printTable columns for one event.
+func tableColumns(e parsedEvent) []string {
+	var eventID, ts, traceID string
+	var resourceType, resourceName, action, policyName string
 
-		if v, ok := e.raw["event_id"]; ok {
-			_ = json.Unmarshal(v, &eventID)
+	if v, ok := e.raw["event_id"]; ok {
+		_ = json.Unmarshal(v, &eventID)
+	}
+	if v, ok := e.raw["timestamp"]; ok {
+		_ = json.Unmarshal(v, &ts)
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			ts = t.Local().Format("01-02 15:04:05")
 		}
-		if v, ok := e.raw["timestamp"]; ok {
-			_ = json.Unmarshal(v, &ts)
-			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
-				ts = t.Local().Format("01-02 15:04:05")
+	}
+	if v, ok := e.raw["trace_id"]; ok {
+		_ = json.Unmarshal(v, &traceID)
+	}
+	if pdRaw, ok := e.raw["policy_decision"]; ok {
+		var pd map[string]json.RawMessage
+		if json.Unmarshal(pdRaw, &pd) == nil {
+			if v, ok := pd["resource_type"]; ok {
+				_ = json.Unmarshal(v, &resourceType)
 			}
-		}
-		if v, ok := e.raw["trace_id"]; ok {
-			_ = json.Unmarshal(v, &traceID)
-		}
-		if pdRaw, ok := e.raw["policy_decision"]; ok {
-			var pd map[string]json.RawMessage
-			if json.Unmarshal(pdRaw, &pd) == nil {
-				if v, ok := pd["resource_type"]; ok {
-					_ = json.Unmarshal(v, &resourceType)
-				}
-				if v, ok := pd["resource_name"]; ok {
-					_ = json.Unmarshal(v, &resourceName)
-				}
-				if v, ok := pd["action"]; ok {
-					_ = json.Unmarshal(v, &action)
-				}
-				if v, ok := pd["policy_name"]; ok {
-					_ = json.Unmarshal(v, &policyName)
-				}
+			if v, ok := pd["resource_name"]; ok {
+				_ = json.Unmarshal(v, &resourceName)
+			}
+			if v, ok := pd["action"]; ok {
+				_ = json.Unmarshal(v, &action)
+			}
+			if v, ok := pd["policy_name"]; ok {
+				_ = json.Unmarshal(v, &policyName)
 			}
 		}
-
-		resource := resourceType + ":" + resourceName
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			eventID, ts, strings.ToUpper(e.effStr), action, resource, policyName, traceID)
 	}
 
-	_ = w.Flush()
-	return 0
+	resource := resourceType + ":" + resourceName
+	return []string{eventID, ts, strings.ToUpper(e.effStr), action, resource, policyName, traceID}
 }
 
 // parseSince parses --since as a duration or RFC3339 timestamp.
@@ -386,6 +497,15 @@ func parseSince(s string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("expected duration (e.g. 1h, 30m, 7d, 2w) or RFC3339 timestamp, got %q", s)
 }
 
+// worseCode combines exit codes across events: any deny wins, then any
+// require_approval.
+func worseCode(result, code int) int {
+	if code == 1 || (result != 1 && code == 2) {
+		return code
+	}
+	return result
+}
+
 func effectToCode(effect string) int {
 	switch effect {
 	case "allow":
@@ -401,7 +521,7 @@ func effectToCode(effect string) int {
 
 // runHypotheticalDirect talks to auditd's native /v1/governance/explain endpoint.
 // Only auditd needs to be running — no gateway required.
-func runHypotheticalDirect(client *http.Client, auditdURL, resourceType, resourceName, action, tags, userID, role, purpose, sensitivity string, asJSON bool) int {
+func runHypotheticalDirect(client *http.Client, auditdURL, resourceType, resourceName, action, tags, userID, role, purpose, sensitivity string, output cliout.Format) int {
 	q := url.Values{}
 	q.Set("resource_type", resourceType)
 	q.Set("resource_name", resourceName)
@@ -422,17 +542,17 @@ func runHypotheticalDirect(client *http.Client, auditdURL, resourceType, resourc
 		q.Set("sensitivity", sensitivity)
 	}
 	endpoint := strings.TrimRight(auditdURL, "/") + "/v1/governance/explain?" + q.Encode()
-	return doExplainRequest(client, endpoint, asJSON)
+	return doExplainRequest(client, endpoint, output)
 }
 
 // runRetrospectiveDirect talks to auditd's native /v1/events/{id} endpoint.
 // Only auditd needs to be running — no gateway required.
-func runRetrospectiveDirect(client *http.Client, auditdURL, eventID string, asJSON bool) int {
+func runRetrospectiveDirect(client *http.Client, auditdURL, eventID string, output cliout.Format) int {
 	endpoint := strings.TrimRight(auditdURL, "/") + "/v1/events/" + url.PathEscape(eventID)
-	return doExplainRequest(client, endpoint, asJSON)
+	return doExplainRequest(client, endpoint, output)
 }
 
-func runHypothetical(client *http.Client, gateway, resourceType, resourceName, action, tags, userID, role, purpose, sensitivity string, asJSON bool) int {
+func runHypothetical(client *http.Client, gateway, resourceType, resourceName, action, tags, userID, role, purpose, sensitivity string, output cliout.Format) int {
 	q := url.Values{}
 	q.Set("resource_type", resourceType)
 	q.Set("resource_name", resourceName)
@@ -454,15 +574,15 @@ func runHypothetical(client *http.Client, gateway, resourceType, resourceName, a
 	}
 
 	endpoint := strings.TrimRight(gateway, "/") + "/api/v1/governance/explain?" + q.Encode()
-	return doExplainRequest(client, endpoint, asJSON)
+	return doExplainRequest(client, endpoint, output)
 }
 
-func runRetrospective(client *http.Client, gateway, eventID string, asJSON bool) int {
+func runRetrospective(client *http.Client, gateway, eventID string, output cliout.Format) int {
 	endpoint := strings.TrimRight(gateway, "/") + "/api/v1/governance/events/" + url.PathEscape(eventID)
-	return doExplainRequest(client, endpoint, asJSON)
+	return doExplainRequest(client, endpoint, output)
 }
 
-func doExplainRequest(client *http.Client, endpoint string, asJSON bool) int {
+func doExplainRequest(client *http.Client, endpoint string, output cliout.Format) int {
 	resp, err := client.Get(endpoint)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
@@ -481,8 +601,11 @@ func doExplainRequest(client *http.Client, endpoint string, asJSON bool) int {
 		return 3
 	}
 
-	if asJSON {
-		fmt.Println(string(body))
+	if output.Structured() {
+		if err := cliout.WriteRaw(os.Stdout, output, body); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 3
+		}
 		return exitCodeFromJSON(body)
 	}
 
@@ -561,7 +684,7 @@ func extractEffect(m map[string]json.RawMessage) string {
 // runLocalExplain evaluates a hypothetical policy check entirely in-process —
 // no gateway or auditd required. Used when --policy-file (or HELPDESK_POLICY_FILE)
 // is set.
-func runLocalExplain(policyFile, resourceType, resourceName, action, tagsStr, userID, role, purpose, sensitivityStr string, asJSON bool) int {
+func runLocalExplain(policyFile, resourceType, resourceName, action, tagsStr, userID, role, purpose, sensitivityStr string, output cliout.Format) int {
 	cfg, err := policy.LoadFile(policyFile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error loading policy file:", err)
@@ -605,9 +728,11 @@ func runLocalExplain(policyFile, resourceType, resourceName, action, tagsStr, us
 
 	trace := engine.Explain(req)
 
-	if asJSON {
-		out, _ := json.MarshalIndent(trace, "", "  ")
-		fmt.Println(string(out))
+	if output.Structured() {
+		if err := cliout.Write(os.Stdout, output, trace); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 3
+		}
 		return effectToCode(string(trace.Decision.Effect))
 	}
 
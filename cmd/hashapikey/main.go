@@ -11,10 +11,13 @@ import (
 	"os"
 
 	"golang.org/x/term"
+	"helpdesk/internal/buildinfo"
 	"helpdesk/internal/identity"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	var key string
 
 	if len(os.Args) >= 2 {
@@ -30,12 +30,16 @@ import (
 	"time"
 
 	"golang.org/x/term"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
 	"helpdesk/internal/client"
 )
 
 const version = "dev"
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	var (
 		gatewayURL   = flag.String("gateway", envOrDefault("HELPDESK_GATEWAY_URL", "http://localhost:8080"), "Gateway `URL`")
 		auditURL     = flag.String("audit-url", os.Getenv("HELPDESK_AUDIT_URL"), "Auditd base `URL` for trace verification (optional)")
@@ -75,6 +79,11 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if err := audit.InstallAuditToken(ctx, *auditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "helpdesk-client: %v\n", err)
+		os.Exit(1)
+	}
+
 	// --plan-fleet-job: does not require a pre-ping (the fleet plan endpoint handles its own auth).
 	if *planFleetJob != "" {
 		if err := runFleetPlan(ctx, cfg, *planFleetJob, *targetHints); err != nil {
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"helpdesk/agentutil"
+	"helpdesk/agentutil/serve"
+	"helpdesk/internal/auditd"
+	"helpdesk/internal/gateway"
+	"helpdesk/internal/logging"
+)
+
+const allInOneUsage = `usage: helpdesk all-in-one [flags] [console|web ...]
+
+Runs a complete helpdesk on one machine for evaluation and demos: auditd with
+a SQLite database, the gateway, the orchestrator, and stub agents that answer
+with canned findings instead of touching real databases or clusters.
+
+auditd, the gateway, the stub agents and the orchestrator run inside this
+process, which logs to <data-dir>/logs/all-in-one.log. Without HELPDESK_MODEL_VENDOR,
+HELPDESK_MODEL_NAME and HELPDESK_API_KEY the orchestrator is skipped; query the
+gateway instead.
+
+Arguments after the flags are passed to the orchestrator (default: console).
+
+Flags:`
+
+// stubAgents are the agents all-in-one serves in-process, with the canned
+// finding each answers every request with.
+var stubAgents = []struct {
+	name, description, finding string
+}{
+	{
+		"postgres_database_agent",
+		"PostgreSQL database troubleshooting agent (all-in-one stub).",
+		"Connection count is 182 of max_connections 200; 41 sessions have been idle in transaction for more than 10 minutes, the oldest holding a lock on public.orders.",
+	},
+	{
+		"k8s_agent",
+		"Kubernetes troubleshooting agent (all-in-one stub).",
+		"Pod checkout-7d9f in namespace shop is in CrashLoopBackOff (12 restarts in 30m); the last container exit was OOMKilled at a 512Mi memory limit.",
+	},
+	{
+		"sysadmin_agent",
+		"Host and VM troubleshooting agent (all-in-one stub).",
+		"Host db-1: /var/lib/postgresql is 94% full; load average 3.1 on 4 CPUs; no failed systemd units.",
+	},
+}
+
+// allInOne is a running all-in-one deployment.
+type allInOne struct {
+	dataDir    string
+	auditURL   string
+	gatewayURL string
+	agentURLs  []string
+	services   []*service
+	log        io.Closer
+}
+
+// service is auditd or the gateway, running in this process.
+type service struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the service's Run returns
+	err    error         // what Run returned; set before done is closed
+}
+
+// runAllInOne implements "helpdesk all-in-one". auditd, the gateway, the
+// stub agents and the orchestrator all run in this process, wired to each
+// other; the services stop when the orchestrator exits or on SIGINT/SIGTERM.
+// It returns the exit code.
+func runAllInOne(args []string) int {
+	fs := flag.NewFlagSet("all-in-one", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), allInOneUsage)
+		fs.PrintDefaults()
+	}
+	dataDir := fs.String("data-dir", "helpdesk-data", "Directory for the audit database, socket and service logs")
+	auditAddr := fs.String("audit-addr", "localhost:1199", "Address auditd listens on")
+	gatewayAddr := fs.String("gateway-addr", "localhost:8080", "Address the gateway listens on")
+	agentPort := fs.Int("agent-port", 1100, "First of the consecutive ports the stub agents listen on")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	a := &allInOne{
+		dataDir:    *dataDir,
+		auditURL:   "http://" + *auditAddr,
+		gatewayURL: "http://" + *gatewayAddr,
+	}
+	defer a.stop()
+	if err := a.start(ctx, *auditAddr, *gatewayAddr, *agentPort); err != nil {
+		fmt.Fprintln(os.Stderr, "all-in-one failed to start:", err)
+		return 1
+	}
+
+	if os.Getenv("HELPDESK_MODEL_VENDOR") == "" || os.Getenv("HELPDESK_MODEL_NAME") == "" || os.Getenv("HELPDESK_API_KEY") == "" {
+		a.printServices()
+		<-ctx.Done()
+		return 0
+	}
+
+	// The orchestrator runs in this process too, on the terminal, with its
+	// log going to all-in-one.log like the services'.
+	done := make(chan error, 1)
+	go func() { done <- runOrchestrator(ctx, fs.Args()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "all-in-one: orchestrator exited:", err)
+			return 1
+		}
+		return 0
+	case <-ctx.Done():
+		// The console may be blocked reading the terminal; stop the services
+		// without waiting for it.
+		return 0
+	}
+}
+
+// start brings up auditd, the stub agents and the gateway, in that order,
+// and points everything started afterwards at them through the environment.
+func (a *allInOne) start(ctx context.Context, auditAddr, gatewayAddr string, agentPort int) error {
+	if err := os.MkdirAll(filepath.Join(a.dataDir, "logs"), 0o755); err != nil {
+		return err
+	}
+	// Everything logs through this process; keep the log off the
+	// orchestrator's console.
+	logPath := filepath.Join(a.dataDir, "logs", "all-in-one.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	logging.SetOutput(logFile)
+	a.log = logFile
+
+	var addrs []string
+	for i := range stubAgents {
+		addr := "localhost:" + strconv.Itoa(agentPort+i)
+		addrs = append(addrs, addr)
+		a.agentURLs = append(a.agentURLs, "http://"+addr)
+	}
+	for k, v := range map[string]string{
+		"HELPDESK_AUDIT_ENABLED": "true",
+		"HELPDESK_AUDIT_URL":     a.auditURL,
+		"HELPDESK_AUDIT_ADDR":    auditAddr,
+		"HELPDESK_AUDIT_DB":      filepath.Join(a.dataDir, "audit.db"),
+		"HELPDESK_AUDIT_SOCKET":  filepath.Join(a.dataDir, "audit.sock"),
+		"HELPDESK_GATEWAY_ADDR":  gatewayAddr,
+		"HELPDESK_AGENT_URLS":    strings.Join(a.agentURLs, ","),
+	} {
+		os.Setenv(k, v) //nolint:errcheck
+	}
+
+	if err := a.startService(ctx, "auditd", auditd.Run, a.auditURL+"/health"); err != nil {
+		return fmt.Errorf("%w; see %s", err, logPath)
+	}
+	if err := serveStubAgents(ctx, addrs); err != nil {
+		return err
+	}
+	if err := a.startService(ctx, "gateway", gateway.Run, a.gatewayURL+"/health"); err != nil {
+		return fmt.Errorf("%w; see %s", err, logPath)
+	}
+	slog.Info("all-in-one started",
+		"auditd", a.auditURL,
+		"gateway", a.gatewayURL,
+		"agents", strings.Join(a.agentURLs, ","),
+		"data_dir", a.dataDir)
+	return nil
+}
+
+// startService runs a service in this process and waits until health
+// answers. The service has a context of its own rather than ctx, so that
+// stop shuts the services down one after the other once the orchestrator
+// is gone.
+func (a *allInOne) startService(ctx context.Context, name string, run func(context.Context, []string) error, health string) error {
+	svcCtx, cancel := context.WithCancel(context.Background())
+	s := &service{name: name, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.err = run(svcCtx, nil)
+	}()
+	a.services = append(a.services, s)
+
+	deadline := time.After(60 * time.Second)
+	client := &http.Client{Timeout: 2 * time.Second}
+	for {
+		if resp, err := client.Get(health); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				slog.Info("service ready", "service", name)
+				return nil
+			}
+		}
+		select {
+		case <-s.done:
+			return fmt.Errorf("%s stopped during startup: %v", name, s.err)
+		case <-deadline:
+			return fmt.Errorf("%s not healthy after 60s", name)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// stop shuts the services down in reverse start order; each drains within
+// HELPDESK_SHUTDOWN_TIMEOUT.
+func (a *allInOne) stop() {
+	for i := len(a.services) - 1; i >= 0; i-- {
+		s := a.services[i]
+		s.cancel()
+		<-s.done
+		if s.err != nil {
+			slog.Warn("service stopped with an error", "service", s.name, "err", s.err)
+		}
+	}
+	a.services = nil
+	if a.log != nil {
+		logging.SetOutput(os.Stderr)
+		a.log.Close()
+		a.log = nil
+	}
+}
+
+// printServices tells the user where to send requests when no model is
+// configured and the orchestrator is skipped.
+func (a *allInOne) printServices() {
+	fmt.Printf(`
+helpdesk all-in-one is running without the orchestrator (no model configured).
+
+  Gateway:  %s
+  auditd:   %s
+  Agents:   %s
+  Data:     %s
+
+Try:
+  curl -s -X POST %s/api/v1/query -H 'Content-Type: application/json' \
+    -d '{"agent":"db","message":"Why are connections piling up?"}'
+  curl -s %s/v1/events
+
+Press Ctrl-C to stop.
+`, a.gatewayURL, a.auditURL, strings.Join(a.agentURLs, ", "), a.dataDir, a.gatewayURL, a.auditURL)
+}
+
+// serveStubAgents serves the stub agents on addrs until ctx is done and waits
+// until each answers for its agent card.
+func serveStubAgents(ctx context.Context, addrs []string) error {
+	errs := make(chan error, len(stubAgents))
+	for i, s := range stubAgents {
+		a, err := newStubAgent(s.name, s.description, s.finding)
+		if err != nil {
+			return err
+		}
+		cfg := agentutil.Config{ListenAddr: addrs[i]}
+		go func() {
+			if err := serve.Serve(ctx, a, cfg); err != nil {
+				errs <- fmt.Errorf("stub agent %s: %w", a.Name(), err)
+			}
+		}()
+	}
+	for _, addr := range addrs {
+		for tries := 0; ; tries++ {
+			if checkAgentHealth("http://"+addr) == nil {
+				break
+			}
+			select {
+			case err := <-errs:
+				return err
+			case <-time.After(100 * time.Millisecond):
+			}
+			if tries == 50 {
+				return errors.New("stub agent on " + addr + " did not start")
+			}
+		}
+	}
+	return nil
+}
+
+// newStubAgent returns an agent that answers every request with finding,
+// without calling a model or touching infrastructure.
+func newStubAgent(name, description, finding string) (agent.Agent, error) {
+	return agent.New(agent.Config{
+		Name:        name,
+		Description: description,
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = name
+				ev.LLMResponse = model.LLMResponse{
+					Content: genai.NewContentFromText(stubReply(name, finding, ctx.UserContent()), genai.RoleModel),
+				}
+				yield(ev, nil)
+			}
+		},
+	})
+}
+
+// stubReply is a stub agent's answer to request.
+func stubReply(name, finding string, request *genai.Content) string {
+	var question []string
+	if request != nil {
+		for _, p := range request.Parts {
+			if p != nil && p.Text != "" {
+				question = append(question, p.Text)
+			}
+		}
+	}
+	return fmt.Sprintf("[%s, all-in-one stub] Request: %q\n\nFinding (canned demo data, no system was contacted): %s",
+		name, strings.Join(question, " "), finding)
+}
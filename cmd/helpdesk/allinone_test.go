@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"helpdesk/internal/auditd"
+)
+
+func TestStubReply(t *testing.T) {
+	got := stubReply("k8s_agent", "pod is OOMKilled", genai.NewContentFromText("why is checkout down?", genai.RoleUser))
+	for _, want := range []string{"k8s_agent", "why is checkout down?", "pod is OOMKilled", "no system was contacted"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reply %q does not contain %q", got, want)
+		}
+	}
+	if got := stubReply("k8s_agent", "f", nil); !strings.Contains(got, `Request: ""`) {
+		t.Errorf("reply without request = %q", got)
+	}
+}
+
+func TestServeStubAgents(t *testing.T) {
+	var addrs []string
+	for range stubAgents {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, "127.0.0.1:"+strconv.Itoa(l.Addr().(*net.TCPAddr).Port))
+		l.Close()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := serveStubAgents(ctx, addrs); err != nil {
+		t.Fatalf("serveStubAgents: %v", err)
+	}
+
+	for i, s := range stubAgents {
+		resp, err := http.Get("http://" + addrs[i] + "/.well-known/agent-card.json")
+		if err != nil {
+			t.Fatalf("%s card: %v", s.name, err)
+		}
+		var card struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(resp.Body).Decode(&card) //nolint:errcheck
+		resp.Body.Close()
+		if card.Name != s.name {
+			t.Errorf("card on %s names %q, want %q", addrs[i], card.Name, s.name)
+		}
+	}
+}
+
+func TestStartService_Auditd(t *testing.T) {
+	dir := t.TempDir()
+	addr := freeAddr(t)
+	t.Setenv("HELPDESK_AUDIT_ADDR", addr)
+	t.Setenv("HELPDESK_AUDIT_DB", filepath.Join(dir, "audit.db"))
+	t.Setenv("HELPDESK_AUDIT_SOCKET", filepath.Join(dir, "audit.sock"))
+
+	a := &allInOne{}
+	if err := a.startService(context.Background(), "auditd", auditd.Run, "http://"+addr+"/health"); err != nil {
+		t.Fatalf("startService: %v", err)
+	}
+	s := a.services[0]
+	a.stop()
+	select {
+	case <-s.done:
+	default:
+		t.Fatal("stop returned before auditd did")
+	}
+	if s.err != nil {
+		t.Errorf("auditd stopped with %v", s.err)
+	}
+	if _, err := http.Get("http://" + addr + "/health"); err == nil {
+		t.Error("auditd still answers after stop")
+	}
+}
+
+func TestStartService_FailsFast(t *testing.T) {
+	a := &allInOne{}
+	failing := func(context.Context, []string) error { return errors.New("bad config") }
+	err := a.startService(context.Background(), "gateway", failing, "http://"+freeAddr(t)+"/health")
+	if err == nil || !strings.Contains(err.Error(), "gateway") || !strings.Contains(err.Error(), "bad config") {
+		t.Errorf("err = %v, want the gateway's own error", err)
+	}
+	a.stop()
+}
+
+// TestRunOrchestrator_ReturnsErrors checks that the orchestrator reports a
+// bad configuration to all-in-one instead of exiting the shared process.
+func TestRunOrchestrator_ReturnsErrors(t *testing.T) {
+	t.Setenv("HELPDESK_MODEL_VENDOR", "")
+	err := runOrchestrator(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "HELPDESK_MODEL_VENDOR") {
+		t.Errorf("err = %v, want the missing model settings", err)
+	}
+}
+
+// freeAddr returns a loopback address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
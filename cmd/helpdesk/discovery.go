@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,9 @@ import (
 	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/discovery"
 )
 
 // agentCardResponse represents the relevant fields from /.well-known/agent-card.json
@@ -149,3 +153,13 @@ func fetchAgentCard(baseURL string) (*a2a.AgentCard, error) {
 
 	return &card, nil
 }
+
+// checkAgentManifest verifies an agent's signed capability manifest and
+// returns every way its card exceeds it (nil when it stays within it).
+func checkAgentManifest(cfg AgentConfig, pub ed25519.PublicKey) []string {
+	card, err := fetchAgentCard(cfg.URL)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return discovery.CheckAgentManifest(cfg.URL, cfg.Name, card, nil, pub, audit.ToolClassification)
+}
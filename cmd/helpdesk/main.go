@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -21,17 +23,37 @@ import (
 	"google.golang.org/adk/tool"
 
 	"helpdesk/agentutil"
+	"helpdesk/internal/agentlimit"
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/discovery"
+	"helpdesk/internal/infra"
 	"helpdesk/internal/logging"
 	"helpdesk/prompts"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	remainingArgs := logging.InitLogging(os.Args[1:])
 
+	if len(remainingArgs) > 0 && remainingArgs[0] == "all-in-one" {
+		os.Exit(runAllInOne(remainingArgs[1:]))
+	}
+
+	if err := runOrchestrator(context.Background(), remainingArgs); err != nil {
+		slog.Error("orchestrator failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// runOrchestrator discovers the agents, builds the orchestrator and runs the
+// ADK launcher (console by default) with args until it returns. all-in-one
+// calls it in-process once its services are up.
+func runOrchestrator(ctx context.Context, args []string) error {
 	// Extract --purpose flag before remaining args are forwarded to the launcher.
 	// Falls back to HELPDESK_SESSION_PURPOSE env var.
-	sessionPurpose, remainingArgs := extractPurposeFlag(remainingArgs)
+	sessionPurpose, args := extractPurposeFlag(args)
 	if sessionPurpose == "" {
 		sessionPurpose = os.Getenv("HELPDESK_SESSION_PURPOSE")
 	}
@@ -39,16 +61,16 @@ func main() {
 		slog.Info("session purpose set", "purpose", sessionPurpose)
 	}
 
-	ctx := context.Background()
-
 	cfg := agentutil.Config{
 		ModelVendor: os.Getenv("HELPDESK_MODEL_VENDOR"),
 		ModelName:   os.Getenv("HELPDESK_MODEL_NAME"),
 		APIKey:      os.Getenv("HELPDESK_API_KEY"),
 	}
 	if cfg.ModelVendor == "" || cfg.ModelName == "" || cfg.APIKey == "" {
-		slog.Error("missing required environment variables: HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME, HELPDESK_API_KEY")
-		os.Exit(1)
+		return errors.New("missing required environment variables: HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME, HELPDESK_API_KEY")
+	}
+	if err := audit.InstallAuditToken(ctx, os.Getenv("HELPDESK_AUDIT_URL")); err != nil {
+		return fmt.Errorf("failed to configure the auditd access token: %w", err)
 	}
 
 	// Discover agents from URLs or load from config file.
@@ -74,8 +96,7 @@ func main() {
 		var err error
 		agentConfigs, err = loadAgentsConfig(agentsConfigPath)
 		if err != nil {
-			slog.Error("failed to load agents config", "path", agentsConfigPath, "err", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load agents config %s: %w", agentsConfigPath, err)
 		}
 		slog.Info("loaded agent configs from file", "path", agentsConfigPath)
 	}
@@ -93,8 +114,7 @@ func main() {
 	// Create the LLM model
 	llmModel, err := agentutil.NewLLM(ctx, cfg)
 	if err != nil {
-		slog.Error("failed to create model", "err", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create model: %w", err)
 	}
 
 	// Check if audit mode is enabled
@@ -135,31 +155,68 @@ func main() {
 			var err error
 			auditor, err = audit.NewStore(auditCfg)
 			if err != nil {
-				slog.Error("failed to create audit store", "err", err)
-				os.Exit(1)
+				return fmt.Errorf("failed to create audit store: %w", err)
 			}
 			slog.Info("audit logging enabled (local)", "db", auditCfg.DBPath, "socket", auditCfg.SocketPath)
 		}
 		defer func() { _ = auditor.Close() }()
 	}
 
+	// With a manifest key configured, agents must serve a capability manifest
+	// signed with it and advertise nothing beyond it; the rest are refused.
+	var manifestKey ed25519.PublicKey
+	if pubKey := os.Getenv("HELPDESK_MANIFEST_PUBLIC_KEY"); pubKey != "" {
+		manifestKey, err = infra.ParsePublicKey(pubKey)
+		if err != nil {
+			return fmt.Errorf("invalid HELPDESK_MANIFEST_PUBLIC_KEY: %w", err)
+		}
+	}
+
 	// Create agent registry for delegate tool
 	agentRegistry := audit.NewAgentRegistry()
+	agentLimiter, err := agentlimit.FromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid agent concurrency limits: %w", err)
+	}
+	agentRegistry.SetLimiter(agentLimiter)
 	var unavailableAgents []string
+	var acceptedConfigs []AgentConfig
 	for _, cfg := range agentConfigs {
 		if err := checkAgentHealth(cfg.URL); err != nil {
 			slog.Warn("agent unavailable", "agent", cfg.Name, "url", cfg.URL, "err", err)
 			unavailableAgents = append(unavailableAgents, cfg.Name)
 			continue
 		}
+		if manifestKey != nil {
+			if problems := checkAgentManifest(cfg, manifestKey); len(problems) > 0 {
+				slog.Error("agent exceeds its capability manifest — refusing it", "agent", cfg.Name, "problems", strings.Join(problems, "; "))
+				agentRegistry.Refuse(cfg.Name, strings.Join(problems, "; "))
+				unavailableAgents = append(unavailableAgents, cfg.Name)
+				if auditor != nil {
+					if err := auditor.Record(ctx, discovery.ManifestViolationEvent("helpdesk_orchestrator", cfg.Name, problems)); err != nil {
+						slog.Warn("failed to record capability manifest violation", "agent", cfg.Name, "err", err)
+					}
+				}
+				continue
+			}
+		}
 		agentRegistry.Register(cfg.Name, cfg.URL)
+		// Agents that verify A2A request signatures reject unsigned calls.
+		signingKey, err := audit.ResolveA2ASigningKey(ctx, cfg.Name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve A2A signing key for %s: %w", cfg.Name, err)
+		}
+		if signingKey != nil {
+			agentRegistry.SetSigningKey(cfg.Name, signingKey)
+		}
+		acceptedConfigs = append(acceptedConfigs, cfg)
 		slog.Info("agent available", "agent", cfg.Name)
 	}
 
 	// Create remote agent proxies for non-audit mode
 	var remoteAgents []agent.Agent
 	if !auditEnabled {
-		remoteAgents, _ = createRemoteAgents(agentConfigs)
+		remoteAgents, _ = createRemoteAgents(acceptedConfigs, agentRegistry)
 	}
 
 	// Build the instruction: infrastructure first (so model sees the data before workflow),
@@ -171,8 +228,7 @@ func main() {
 	if infraConfigPath != "" {
 		infraConfig, err := loadInfraConfig(infraConfigPath)
 		if err != nil {
-			slog.Error("failed to load infrastructure config", "path", infraConfigPath, "err", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load infrastructure config %s: %w", infraConfigPath, err)
 		}
 		instruction = buildInfraPromptSection(infraConfig)
 		slog.Info("infrastructure config loaded", "db_servers", len(infraConfig.DBServers), "k8s_clusters", len(infraConfig.K8sClusters), "vms", len(infraConfig.VMs))
@@ -204,8 +260,7 @@ func main() {
 		userID := os.Getenv("USER")
 		delegateTool, guard, err := audit.DelegateTool(auditor, os.Getenv("HELPDESK_AUDIT_URL"), os.Getenv("HELPDESK_AUDIT_API_KEY"), agentRegistry, sessionID, userID, "helpdesk_orchestrator", sessionPurpose)
 		if err != nil {
-			slog.Error("failed to create delegate tool", "err", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to create delegate tool: %w", err)
 		}
 		tools = append(tools, delegateTool)
 		slog.Info("delegate_to_agent tool created", "session_id", sessionID)
@@ -214,8 +269,7 @@ func main() {
 		// the _delegation_required calls injected by NoDelegationCallback.
 		correctionTool, err := audit.NoDelegationCorrectionTool(auditor, sessionID)
 		if err != nil {
-			slog.Error("failed to create correction tool", "err", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to create correction tool: %w", err)
 		}
 		tools = append(tools, correctionTool)
 
@@ -253,8 +307,7 @@ func main() {
 
 	rootAgent, err := llmagent.New(agentConfig)
 	if err != nil {
-		slog.Error("failed to create root agent", "err", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create root agent: %w", err)
 	}
 
 	slog.Info("orchestrator initialized", "available_agents", len(agentConfigs)-len(unavailableAgents))
@@ -264,8 +317,7 @@ func main() {
 
 	agentLoader, err := agent.NewMultiLoader(rootAgent, remoteAgents...)
 	if err != nil {
-		slog.Error("failed to create agent loader", "err", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create agent loader: %w", err)
 	}
 
 	artifactService := artifact.InMemoryService()
@@ -278,7 +330,7 @@ func main() {
 	}
 
 	// Build launcher arguments
-	launcherArgs := remainingArgs
+	launcherArgs := args
 
 	// Add streaming mode from env var if set (for container deployments)
 	if streamingMode := os.Getenv("HELPDESK_STREAMING_MODE"); streamingMode != "" {
@@ -290,8 +342,8 @@ func main() {
 	}
 
 	l := full.NewLauncher()
-	if err = l.Execute(ctx, config, launcherArgs); err != nil {
-		slog.Error("failed to launch", "err", err, "usage", l.CommandLineSyntax())
-		os.Exit(1)
+	if err := l.Execute(ctx, config, launcherArgs); err != nil {
+		return fmt.Errorf("failed to launch: %w (usage: %s)", err, l.CommandLineSyntax())
 	}
+	return nil
 }
@@ -3,15 +3,21 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"iter"
 	"log/slog"
 	"os"
 	"strings"
 
+	"github.com/a2aproject/a2a-go/a2a"
 	"github.com/google/uuid"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/agent/remoteagent"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+
+	"helpdesk/internal/agentlimit"
+	"helpdesk/internal/audit"
 )
 
 // AgentConfig holds configuration for a remote agent.
@@ -82,6 +88,20 @@ func loadInfraConfig(path string) (*InfraConfig, error) {
 	return &config, nil
 }
 
+// promptEndpoint returns a key=value connection string with every secret field
+// dropped, for display in the orchestrator prompt.
+func promptEndpoint(connStr string) string {
+	var kept []string
+	for _, field := range strings.Fields(connStr) {
+		k, _, _ := strings.Cut(field, "=")
+		if k == "password" || k == "sslpassword" {
+			continue
+		}
+		kept = append(kept, field)
+	}
+	return strings.Join(kept, " ")
+}
+
 // buildInfraPromptSection generates the managed infrastructure section for the agent prompt.
 // Only database servers are listed — K8s clusters and VMs are referenced inline where applicable.
 func buildInfraPromptSection(config *InfraConfig) string {
@@ -103,9 +123,14 @@ func buildInfraPromptSection(config *InfraConfig) string {
 
 	for id, db := range config.DBServers {
 		fmt.Fprintf(&sb, "**%s** — %s\n", id, db.Name)
-		fmt.Fprintf(&sb, "- connection_string: `%s`\n", db.ConnectionString)
+		// The alias is the connection_string: agents resolve it (and its
+		// credentials) at execution time, so no DSN or password enters the prompt.
+		fmt.Fprintf(&sb, "- connection_string: `%s`\n", id)
+		if ep := promptEndpoint(db.ConnectionString); ep != "" {
+			fmt.Fprintf(&sb, "- endpoint: `%s`\n", ep)
+		}
 		// Add a ready-to-use delegation example for this specific database
-		fmt.Fprintf(&sb, "- To check this database, delegate: \"Check if the database is reachable using connection_string: %s\"\n", db.ConnectionString)
+		fmt.Fprintf(&sb, "- To check this database, delegate: \"Check if the database is reachable using connection_string: %s\"\n", id)
 
 		if db.K8sCluster != "" {
 			// Expand the K8s cluster reference inline.
@@ -192,7 +217,8 @@ func saveReportFunc(ctx agent.CallbackContext, llmResponse *model.LLMResponse, l
 
 // createRemoteAgents creates remote agent proxies for available agents.
 // It checks agent health and only returns agents that are reachable.
-func createRemoteAgents(configs []AgentConfig) ([]agent.Agent, []string) {
+// Requests to agents with a signing key in registry are signed.
+func createRemoteAgents(configs []AgentConfig, registry *audit.AgentRegistry) ([]agent.Agent, []string) {
 	var agents []agent.Agent
 	var unavailable []string
 
@@ -209,10 +235,18 @@ func createRemoteAgents(configs []AgentConfig) ([]agent.Agent, []string) {
 			continue
 		}
 
+		var beforeRequest []remoteagent.BeforeA2ARequestCallback
+		if key := registry.SigningKey(cfg.Name); key != nil {
+			beforeRequest = append(beforeRequest, func(_ agent.CallbackContext, req *a2a.MessageSendParams) (*session.Event, error) {
+				return nil, audit.SignA2AMessage(req.Message, key)
+			})
+		}
+
 		remoteAgent, err := remoteagent.NewA2A(remoteagent.A2AConfig{
-			Name:        cfg.Name,
-			Description: cfg.Description,
-			AgentCard:   card,
+			Name:                   cfg.Name,
+			Description:            cfg.Description,
+			AgentCard:              card,
+			BeforeRequestCallbacks: beforeRequest,
 		})
 		if err != nil {
 			slog.Warn("failed to create agent proxy", "agent", cfg.Name, "err", err)
@@ -220,6 +254,15 @@ func createRemoteAgents(configs []AgentConfig) ([]agent.Agent, []string) {
 			continue
 		}
 
+		if limiter := registry.Limiter(); limiter != nil {
+			remoteAgent, err = limitAgent(remoteAgent, limiter)
+			if err != nil {
+				slog.Warn("failed to create agent proxy", "agent", cfg.Name, "err", err)
+				unavailable = append(unavailable, cfg.Name)
+				continue
+			}
+		}
+
 		slog.Info("agent available", "agent", cfg.Name)
 		agents = append(agents, remoteAgent)
 	}
@@ -227,6 +270,31 @@ func createRemoteAgents(configs []AgentConfig) ([]agent.Agent, []string) {
 	return agents, unavailable
 }
 
+// limitAgent wraps a remote agent so each transfer to it holds one of its
+// concurrency slots for the length of the run. A transfer to an agent at its
+// cap ends with an error event instead of piling more work onto it.
+func limitAgent(remote agent.Agent, limiter *agentlimit.Limiter) (agent.Agent, error) {
+	return agent.New(agent.Config{
+		Name:        remote.Name(),
+		Description: remote.Description(),
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				release, err := limiter.Acquire(ic, remote.Name())
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				defer release()
+				for event, err := range remote.Run(ic) {
+					if !yield(event, err) {
+						return
+					}
+				}
+			}
+		},
+	})
+}
+
 // Ensure saveReportFunc has the correct signature.
 var _ llmagent.AfterModelCallback = saveReportFunc
 
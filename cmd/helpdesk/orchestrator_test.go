@@ -134,6 +134,23 @@ func TestLoadAgentsConfig_MissingFile(t *testing.T) {
 
 // --- buildInfraPromptSection tests ---
 
+func TestBuildInfraPromptSection_AliasOnlyNoPassword(t *testing.T) {
+	config := &InfraConfig{
+		DBServers: map[string]DBServer{
+			"prod-db": {Name: "Production DB", ConnectionString: "host=db.example.com user=app password=hunter2"},
+		},
+	}
+
+	result := buildInfraPromptSection(config)
+
+	if !strings.Contains(result, "connection_string: `prod-db`") {
+		t.Errorf("prompt should hand out the alias as connection_string:\n%s", result)
+	}
+	if strings.Contains(result, "hunter2") {
+		t.Errorf("prompt leaks the password:\n%s", result)
+	}
+}
+
 func TestBuildInfraPromptSection_Full(t *testing.T) {
 	config := &InfraConfig{
 		DBServers: map[string]DBServer{
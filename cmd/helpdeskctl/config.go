@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"helpdesk/internal/cliout"
+)
+
+const configUsage = `usage: helpdeskctl config <command> [arguments]
+
+Commands:
+  show                                    every runtime setting and its value
+  set [--reason text] key=value [...]     override settings until reset
+  reset [--reason text] key [...]         return settings to their startup values
+
+Runtime settings change alert thresholds, notifier URLs and retention without
+restarting auditd or the auditor. Overrides are stored in the audit database
+and each change is recorded as a config_change event.`
+
+// runtimeSetting is one setting as GET /v1/config returns it.
+type runtimeSetting struct {
+	Key         string     `json:"key"`
+	Component   string     `json:"component"`
+	Description string     `json:"description"`
+	Value       string     `json:"value"`
+	Source      string     `json:"source"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// cmdConfig implements "helpdeskctl config": auditd's runtime configuration.
+func cmdConfig(ctx context.Context, src *auditSource, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("config "+cmd, flag.ExitOnError)
+	var output cliout.Format
+	cliout.Register(fs, &output)
+
+	var out struct {
+		Settings []runtimeSetting `json:"settings"`
+	}
+	var body []byte
+	var err error
+	switch cmd {
+	case "show":
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		body, err = src.call(ctx, http.MethodGet, "/v1/config", nil, &out)
+
+	case "set", "reset":
+		reason := fs.String("reason", "", "Why the settings are changed, recorded with the change")
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		if fs.NArg() == 0 {
+			return fmt.Errorf("%s", configUsage)
+		}
+		settings := map[string]*string{}
+		for _, arg := range fs.Args() {
+			if cmd == "reset" {
+				settings[arg] = nil
+				continue
+			}
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || key == "" {
+				return fmt.Errorf("invalid setting %q: expected key=value", arg)
+			}
+			settings[key] = &value
+		}
+		body, err = src.call(ctx, http.MethodPut, "/v1/config",
+			map[string]any{"settings": settings, "reason": *reason}, &out)
+
+	default:
+		return fmt.Errorf("unknown config command %q\n%s", cmd, configUsage)
+	}
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		return cliout.WriteRaw(os.Stdout, output, body)
+	}
+	return renderRuntimeConfig(os.Stdout, out.Settings)
+}
+
+// renderRuntimeConfig lists the runtime settings.
+func renderRuntimeConfig(w io.Writer, settings []runtimeSetting) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tCHANGED BY\tCHANGED AT")
+	for _, s := range settings {
+		value, changedAt := s.Value, "-"
+		if value == "" && s.Component != "auditd" && s.Source == "startup" {
+			value = "(" + s.Component + " flag)"
+		}
+		if s.UpdatedAt != nil {
+			changedAt = s.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Key, dash(value), s.Source, dash(s.UpdatedBy), changedAt)
+	}
+	return tw.Flush()
+}
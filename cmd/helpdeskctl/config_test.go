@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCmdConfig_SetReset(t *testing.T) {
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v1/config" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		got = append(got, body)
+		w.Write([]byte(`{"settings":[]}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	src := newAuditSource(srv.URL, "")
+	if err := cmdConfig(context.Background(), src, []string{"set", "auditor.max_events_per_minute"}); err == nil {
+		t.Error("set accepted a setting without a value")
+	}
+	if err := cmdConfig(context.Background(), src, []string{"set", "--reason", "batch job", "-o", "json", "auditor.max_events_per_minute=600"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := cmdConfig(context.Background(), src, []string{"reset", "-o", "json", "auditor.max_events_per_minute"}); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("requests = %v", got)
+	}
+	if s := got[0]["settings"].(map[string]any); got[0]["reason"] != "batch job" || s["auditor.max_events_per_minute"] != "600" {
+		t.Errorf("set body = %v", got[0])
+	}
+	if s := got[1]["settings"].(map[string]any); s["auditor.max_events_per_minute"] != nil || len(s) != 1 {
+		t.Errorf("reset body = %v", got[1])
+	}
+}
+
+func TestRenderRuntimeConfig(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderRuntimeConfig(&buf, []runtimeSetting{
+		{Key: "auditd.calibration_retention", Component: "auditd", Value: "2160h0m0s", Source: "startup"},
+		{Key: "auditor.max_events_per_minute", Component: "auditor", Source: "startup"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "2160h0m0s") || !strings.Contains(buf.String(), "(auditor flag)") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"helpdesk/internal/cliout"
+	"helpdesk/internal/infra"
+)
+
+// Operations of an inventory change proposed by discover.
+const (
+	changeAdd     = "add"     // a resource found live is not in the config
+	changeUpdate  = "update"  // a config entry gains what was found live
+	changeMissing = "missing" // a config entry was not found live; left for review
+)
+
+// infraChange is one difference between the config and what discover found.
+type infraChange struct {
+	Op     string `json:"op"`
+	Path   string `json:"path"` // e.g. db_servers.orders or k8s_clusters.prod.namespaces
+	Detail string `json:"detail"`
+}
+
+// discoverReport is the result of "helpdeskctl discover".
+type discoverReport struct {
+	Changes  []infraChange `json:"changes"`
+	Notes    []string      `json:"notes,omitempty"` // contexts or endpoints that could not be scanned, credentials to wire up
+	Proposed *infra.Config `json:"proposed"`
+}
+
+// systemNamespaces are never proposed: no database or workload of an
+// operator lives there.
+var systemNamespaces = map[string]bool{"kube-system": true, "kube-public": true, "kube-node-lease": true}
+
+// discoverer finds clusters, namespaces and databases.
+type discoverer struct {
+	kubeconfig string   // "" = KUBECONFIG or ~/.kube/config
+	contexts   []string // contexts to scan; empty = all
+	timeout    time.Duration
+	// clientset connects to a kubeconfig context; replaced in tests.
+	clientset func(kubeconfig, context string) (kubernetes.Interface, error)
+	// scanned are the cluster keys whose scan succeeded.
+	scanned map[string]bool
+	notes   []string
+}
+
+// cmdDiscover implements "helpdeskctl discover". It works offline (it does
+// not contact auditd) and returns the exit code: 0 when nothing differs from
+// --infra, 1 on a usage or read error, 2 when changes are proposed.
+func cmdDiscover(args []string) int {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Kubeconfig file to scan (default: KUBECONFIG or ~/.kube/config)")
+	contexts := fs.String("context", "", "Comma-separated kubeconfig contexts to scan (default: all contexts)")
+	endpoints := fs.String("endpoint", "", "Comma-separated PostgreSQL host:port endpoints to probe")
+	noK8s := fs.Bool("no-kubernetes", false, "Do not scan kubeconfig contexts")
+	infraFile := fs.String("infra", "", "Existing infrastructure config to diff the proposal against (JSON)")
+	outFile := fs.String("write", "", "Write the proposed infrastructure config to this file")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout of each cluster and endpoint")
+	var output cliout.Format
+	cliout.Register(fs, &output)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	existing := &infra.Config{}
+	if *infraFile != "" {
+		var err error
+		if existing, err = infra.Load(*infraFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	d := &discoverer{kubeconfig: *kubeconfig, contexts: splitList(*contexts), timeout: *timeout, clientset: kubeClientset}
+	ctx := context.Background()
+	found := &infra.Config{
+		DBServers:   map[string]infra.DBServer{},
+		K8sClusters: map[string]infra.K8sCluster{},
+		Credentials: map[string]infra.Credential{},
+	}
+	if !*noK8s {
+		d.scanKubernetes(ctx, found)
+	}
+	d.probeEndpoints(splitList(*endpoints), found)
+	report := d.propose(existing, found)
+
+	var err error
+	if *outFile != "" {
+		data, _ := json.MarshalIndent(report.Proposed, "", "  ")
+		if err = os.WriteFile(*outFile, append(data, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: write proposed config: %v\n", err)
+			return 1
+		}
+	}
+	switch {
+	case output.Structured():
+		err = cliout.Write(os.Stdout, output, report)
+	case *infraFile == "" && *outFile == "":
+		// Bootstrapping: the proposal itself is the output.
+		renderDiscoverNotes(os.Stderr, report)
+		err = json.NewEncoder(os.Stdout).Encode(report.Proposed)
+	default:
+		err = renderDiscover(os.Stdout, report, *infraFile, *outFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(report.Changes) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// kubeClientset connects to a context of a kubeconfig file.
+func kubeClientset(kubeconfig, kubeContext string) (kubernetes.Interface, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// scanKubernetes adds the clusters of the kubeconfig's contexts, their
+// namespaces and the PostgreSQL services in them to found.
+func (d *discoverer) scanKubernetes(ctx context.Context, found *infra.Config) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = d.kubeconfig
+	raw, err := rules.Load()
+	if err != nil {
+		d.notes = append(d.notes, "kubeconfig: "+err.Error())
+		return
+	}
+	names := d.contexts
+	if len(names) == 0 {
+		for name := range raw.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		if _, ok := raw.Contexts[name]; !ok {
+			d.notes = append(d.notes, fmt.Sprintf("context %s: not in the kubeconfig", name))
+			continue
+		}
+		cs, err := d.clientset(d.kubeconfig, name)
+		if err == nil {
+			scanCtx, cancel := context.WithTimeout(ctx, d.timeout)
+			err = d.scanCluster(scanCtx, cs, name, found)
+			cancel()
+		}
+		if err != nil {
+			d.notes = append(d.notes, fmt.Sprintf("context %s: %v", name, err))
+		}
+	}
+}
+
+// scanCluster adds one context's cluster, namespaces and databases to found.
+// The cluster is keyed by its context name.
+func (d *discoverer) scanCluster(ctx context.Context, cs kubernetes.Interface, kubeContext string, found *infra.Config) error {
+	nsList, err := cs.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+	cluster := infra.K8sCluster{Name: kubeContext, Context: kubeContext, Kubeconfig: d.kubeconfig,
+		Namespaces: map[string]infra.K8sNamespace{}}
+	for _, ns := range nsList.Items {
+		if !systemNamespaces[ns.Name] {
+			cluster.Namespaces[ns.Name] = infra.K8sNamespace{}
+		}
+	}
+
+	svcList, err := cs.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list services: %w", err)
+	}
+	// Secrets are only read for the connection details operators (e.g.
+	// CloudNativePG, Zalando) store next to the password; the password
+	// itself is never copied.
+	secretsByNS := map[string][]corev1.Secret{}
+	if secList, err := cs.CoreV1().Secrets("").List(ctx, metav1.ListOptions{}); err != nil {
+		d.notes = append(d.notes, fmt.Sprintf("context %s: list secrets: %v; database names and users default to postgres", kubeContext, err))
+	} else {
+		for _, s := range secList.Items {
+			secretsByNS[s.Namespace] = append(secretsByNS[s.Namespace], s)
+		}
+	}
+
+	for _, svc := range svcList.Items {
+		port, ok := postgresPort(svc)
+		if !ok || systemNamespaces[svc.Namespace] {
+			continue
+		}
+		base := strings.TrimSuffix(svc.Name, "-rw")
+		db := infra.DBServer{
+			Name:           base,
+			K8sCluster:     kubeContext,
+			K8sNamespace:   svc.Namespace,
+			K8sPodSelector: selectorString(svc.Spec.Selector),
+		}
+		dbname, user := "postgres", "postgres"
+		secret := connectionSecret(svc, secretsByNS[svc.Namespace])
+		if secret != nil {
+			dbname = secretValue(secret, dbname, "dbname", "database")
+			user = secretValue(secret, user, "user", "username")
+			if p, err := strconv.Atoi(secretValue(secret, "", "port")); err == nil {
+				port = p
+			}
+		}
+		db.ConnectionString = fmt.Sprintf("host=%s.%s.svc port=%d dbname=%s user=%s", svc.Name, svc.Namespace, port, dbname, user)
+
+		key := uniqueKey(found.DBServers, base, kubeContext+"-"+svc.Namespace+"-"+base)
+		if secret != nil && len(secret.Data["password"]) > 0 {
+			alias := key + "-password"
+			env := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(alias))
+			found.Credentials[alias] = infra.Credential{Env: env}
+			db.Credential = alias
+			d.notes = append(d.notes, fmt.Sprintf("credential %s: the password is in secret %s/%s (key password); export it as %s or mount the secret and switch the credential to file",
+				alias, secret.Namespace, secret.Name, env))
+		}
+		found.DBServers[key] = db
+	}
+
+	found.K8sClusters[kubeContext] = cluster
+	if d.scanned == nil {
+		d.scanned = map[string]bool{}
+	}
+	d.scanned[kubeContext] = true
+	return nil
+}
+
+// postgresPort returns the PostgreSQL port of a service that fronts a
+// primary. Headless services and replica endpoints (-ro, -r, -repl) are
+// skipped so that each database is proposed once.
+func postgresPort(svc corev1.Service) (int, bool) {
+	if svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		return 0, false
+	}
+	for _, suffix := range []string{"-ro", "-r", "-repl", "-replica"} {
+		if strings.HasSuffix(svc.Name, suffix) {
+			return 0, false
+		}
+	}
+	for _, p := range svc.Spec.Ports {
+		if p.Port == 5432 || p.Name == "postgres" || p.Name == "postgresql" {
+			return int(p.Port), true
+		}
+	}
+	return 0, false
+}
+
+// connectionSecret returns the secret in the service's namespace whose host
+// names the service, as database operators write them.
+func connectionSecret(svc corev1.Service, secrets []corev1.Secret) *corev1.Secret {
+	for i := range secrets {
+		host, _, _ := strings.Cut(string(secrets[i].Data["host"]), ".")
+		if host == svc.Name {
+			return &secrets[i]
+		}
+	}
+	return nil
+}
+
+// secretValue returns the first of keys set in the secret, or def.
+func secretValue(s *corev1.Secret, def string, keys ...string) string {
+	for _, k := range keys {
+		if v := strings.TrimSpace(string(s.Data[k])); v != "" {
+			return v
+		}
+	}
+	return def
+}
+
+// selectorString renders a service selector as a label selector.
+func selectorString(sel map[string]string) string {
+	parts := make([]string, 0, len(sel))
+	for k, v := range sel {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// uniqueKey returns key, or fallback when key is taken.
+func uniqueKey[V any](m map[string]V, key, fallback string) string {
+	if _, taken := m[key]; !taken {
+		return key
+	}
+	return fallback
+}
+
+// probeEndpoints adds each host:port that answers the PostgreSQL protocol
+// as a standalone database.
+func (d *discoverer) probeEndpoints(endpoints []string, found *infra.Config) {
+	for _, ep := range endpoints {
+		host, port, err := net.SplitHostPort(ep)
+		if err != nil {
+			host, port = ep, "5432"
+		}
+		if err := probePostgres(net.JoinHostPort(host, port), d.timeout); err != nil {
+			d.notes = append(d.notes, fmt.Sprintf("endpoint %s: %v", ep, err))
+			continue
+		}
+		key := uniqueKey(found.DBServers, strings.NewReplacer(".", "-", ":", "-").Replace(host), strings.NewReplacer(".", "-", ":", "-").Replace(host+"-"+port))
+		found.DBServers[key] = infra.DBServer{
+			Name:             host,
+			ConnectionString: fmt.Sprintf("host=%s port=%s dbname=postgres user=postgres", host, port),
+		}
+	}
+}
+
+// probePostgres reports whether addr speaks the PostgreSQL protocol. It
+// sends an SSLRequest, which any server answers with S or N before asking
+// for credentials.
+func probePostgres(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("not reachable: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	if _, err := conn.Write([]byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}); err != nil {
+		return fmt.Errorf("not reachable: %w", err)
+	}
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil || (reply[0] != 'S' && reply[0] != 'N') {
+		return fmt.Errorf("does not answer as PostgreSQL")
+	}
+	return nil
+}
+
+// propose merges what was found into a copy of the existing config and
+// lists the changes. Nothing is removed: entries not found live are listed
+// as missing, for an operator to decide on.
+func (d *discoverer) propose(existing, found *infra.Config) discoverReport {
+	proposed := cloneConfig(existing)
+	report := discoverReport{Changes: []infraChange{}, Notes: d.notes, Proposed: proposed}
+	add := func(op, path, format string, args ...any) {
+		report.Changes = append(report.Changes, infraChange{Op: op, Path: path, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	// Clusters are matched by context. clusterKey maps a found cluster to
+	// its key in the proposal.
+	clusterKey := map[string]string{}
+	for _, fk := range sortedKeys(found.K8sClusters) {
+		fc := found.K8sClusters[fk]
+		key := ""
+		for _, ek := range sortedKeys(existing.K8sClusters) {
+			if existing.K8sClusters[ek].Context == fc.Context {
+				key = ek
+				break
+			}
+		}
+		if key == "" {
+			key = uniqueKey(proposed.K8sClusters, fk, fk+"-discovered")
+			proposed.K8sClusters[key] = fc
+			clusterKey[fk] = key
+			add(changeAdd, "k8s_clusters."+key, "context %s, %d namespace(s)", fc.Context, len(fc.Namespaces))
+			continue
+		}
+		clusterKey[fk] = key
+		ec := proposed.K8sClusters[key]
+		if len(ec.Namespaces) == 0 {
+			continue // no allowlist: every namespace is already allowed
+		}
+		var added []string
+		for _, ns := range sortedKeys(fc.Namespaces) {
+			if _, ok := ec.Namespaces[ns]; !ok {
+				ec.Namespaces[ns] = infra.K8sNamespace{}
+				added = append(added, ns)
+			}
+		}
+		if len(added) > 0 {
+			add(changeUpdate, "k8s_clusters."+key+".namespaces", "+ %s", strings.Join(added, ", "))
+		}
+		for _, ns := range sortedKeys(ec.Namespaces) {
+			if _, ok := fc.Namespaces[ns]; !ok && !containsString(added, ns) {
+				add(changeMissing, "k8s_clusters."+key+".namespaces."+ns, "namespace does not exist on context %s", fc.Context)
+			}
+		}
+	}
+
+	// Databases are matched by endpoint, or by cluster, namespace and pod
+	// selector.
+	matched := map[string]bool{}
+	for _, fk := range sortedKeys(found.DBServers) {
+		fdb := found.DBServers[fk]
+		if fdb.K8sCluster != "" {
+			fdb.K8sCluster = clusterKey[fdb.K8sCluster]
+		}
+		ek := matchDB(existing, fdb)
+		if ek != "" {
+			matched[ek] = true
+			if edb := proposed.DBServers[ek]; edb.K8sCluster != "" && edb.K8sPodSelector == "" && fdb.K8sPodSelector != "" {
+				edb.K8sPodSelector = fdb.K8sPodSelector
+				proposed.DBServers[ek] = edb
+				add(changeUpdate, "db_servers."+ek+".k8s_pod_selector", "%s", fdb.K8sPodSelector)
+			}
+			continue
+		}
+		key := uniqueKey(proposed.DBServers, fk, fk+"-discovered")
+		if fdb.Credential != "" {
+			alias := uniqueKey(proposed.Credentials, fdb.Credential, key+"-password")
+			proposed.Credentials[alias] = found.Credentials[fdb.Credential]
+			fdb.Credential = alias
+		}
+		proposed.DBServers[key] = fdb
+		where := "endpoint " + dbEndpoint(fdb.ConnectionString)
+		if fdb.K8sCluster != "" {
+			where = fmt.Sprintf("service in %s/%s", fdb.K8sCluster, fdb.K8sNamespace)
+		}
+		add(changeAdd, "db_servers."+key, "%s (%s)", where, fdb.ConnectionString)
+	}
+	for _, ek := range sortedKeys(existing.DBServers) {
+		edb := existing.DBServers[ek]
+		if matched[ek] || edb.K8sCluster == "" || !d.scanned[scannedContext(existing, edb.K8sCluster)] {
+			continue
+		}
+		add(changeMissing, "db_servers."+ek, "no PostgreSQL service in %s/%s", edb.K8sCluster, edb.K8sNamespace)
+	}
+
+	if len(proposed.Credentials) == 0 {
+		proposed.Credentials = nil
+	}
+	return report
+}
+
+// matchDB returns the key of the existing database fdb was found as, if any.
+func matchDB(existing *infra.Config, fdb infra.DBServer) string {
+	ep := dbEndpoint(fdb.ConnectionString)
+	for _, ek := range sortedKeys(existing.DBServers) {
+		edb := existing.DBServers[ek]
+		if dbEndpoint(edb.ConnectionString) == ep {
+			return ek
+		}
+		if fdb.K8sCluster != "" && edb.K8sCluster == fdb.K8sCluster && edb.K8sNamespace == fdb.K8sNamespace &&
+			(edb.K8sPodSelector == "" || edb.K8sPodSelector == fdb.K8sPodSelector) {
+			return ek
+		}
+	}
+	return ""
+}
+
+// dbEndpoint returns host:port of a key=value connection string.
+func dbEndpoint(connStr string) string {
+	host, port := "", "5432"
+	for _, field := range strings.Fields(connStr) {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			switch k {
+			case "host":
+				host = v
+			case "port":
+				port = v
+			}
+		}
+	}
+	return host + ":" + port
+}
+
+// scannedContext returns the context of a cluster in the config.
+func scannedContext(cfg *infra.Config, clusterKey string) string {
+	return cfg.K8sClusters[clusterKey].Context
+}
+
+// cloneConfig copies the maps discover may add to.
+func cloneConfig(c *infra.Config) *infra.Config {
+	out := *c
+	out.DBServers = map[string]infra.DBServer{}
+	for k, v := range c.DBServers {
+		out.DBServers[k] = v
+	}
+	out.K8sClusters = map[string]infra.K8sCluster{}
+	for k, v := range c.K8sClusters {
+		if v.Namespaces != nil {
+			ns := make(map[string]infra.K8sNamespace, len(v.Namespaces))
+			for n, t := range v.Namespaces {
+				ns[n] = t
+			}
+			v.Namespaces = ns
+		}
+		out.K8sClusters[k] = v
+	}
+	out.Credentials = map[string]infra.Credential{}
+	for k, v := range c.Credentials {
+		out.Credentials[k] = v
+	}
+	return &out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// renderDiscover prints the proposed changes as a diff against the config.
+func renderDiscover(w io.Writer, report discoverReport, infraFile, outFile string) error {
+	var b strings.Builder
+	marks := map[string]string{changeAdd: "+", changeUpdate: "~", changeMissing: "?"}
+	for _, c := range report.Changes {
+		fmt.Fprintf(&b, "%s %-40s  %s\n", marks[c.Op], c.Path, c.Detail)
+	}
+	switch {
+	case len(report.Changes) == 0 && infraFile != "":
+		fmt.Fprintf(&b, "OK: %s matches what was found\n", infraFile)
+	case len(report.Changes) > 0:
+		fmt.Fprintf(&b, "\n%d change(s) proposed (+ add, ~ update, ? not found live, left in place)\n", len(report.Changes))
+	}
+	if outFile != "" {
+		fmt.Fprintf(&b, "Proposed config written to %s; review it before replacing %s\n", outFile, dash(infraFile))
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	renderDiscoverNotes(w, report)
+	return nil
+}
+
+// renderDiscoverNotes prints what could not be scanned and what to wire up.
+func renderDiscoverNotes(w io.Writer, report discoverReport) {
+	if len(report.Notes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nNotes:")
+	for _, n := range report.Notes {
+		fmt.Fprintf(w, "  - %s\n", n)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"helpdesk/internal/infra"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster: {server: "https://prod.example.com"}
+- name: staging
+  cluster: {server: "https://staging.example.com"}
+contexts:
+- name: prod
+  context: {cluster: prod, user: ops}
+- name: staging
+  context: {cluster: staging, user: ops}
+users:
+- name: ops
+  user: {token: x}
+current-context: prod
+`
+
+func prodClientset() kubernetes.Interface {
+	ns := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	svc := func(namespace, name string, port int32) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.0.0.1",
+				Ports:     []corev1.ServicePort{{Port: port}},
+				Selector:  map[string]string{"cnpg.io/cluster": "orders", "role": "primary"},
+			},
+		}
+	}
+	return fake.NewSimpleClientset(
+		ns("default"), ns("kube-system"), ns("database"), ns("payments"),
+		svc("database", "orders-rw", 5432), svc("database", "orders-ro", 5432), svc("payments", "web", 80),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders-app", Namespace: "database"},
+			Data: map[string][]byte{
+				"host": []byte("orders-rw"), "dbname": []byte("orders"), "user": []byte("app"), "password": []byte("s3cret"),
+			},
+		},
+	)
+}
+
+func TestDiscover(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d := &discoverer{kubeconfig: kubeconfig, timeout: 5 * time.Second,
+		clientset: func(_, kubeContext string) (kubernetes.Interface, error) {
+			if kubeContext == "prod" {
+				return prodClientset(), nil
+			}
+			return nil, fmt.Errorf("connection refused")
+		}}
+	found := &infra.Config{DBServers: map[string]infra.DBServer{}, K8sClusters: map[string]infra.K8sCluster{},
+		Credentials: map[string]infra.Credential{}}
+	d.scanKubernetes(context.Background(), found)
+
+	existing := &infra.Config{
+		K8sClusters: map[string]infra.K8sCluster{
+			"prod-cluster": {Name: "prod", Context: "prod", Namespaces: map[string]infra.K8sNamespace{"database": {}, "legacy": {}}},
+		},
+		DBServers: map[string]infra.DBServer{
+			"legacy-db": {Name: "legacy", ConnectionString: "host=legacy.legacy.svc dbname=legacy", K8sCluster: "prod-cluster", K8sNamespace: "legacy"},
+		},
+	}
+	report := d.propose(existing, found)
+
+	var got []string
+	for _, c := range report.Changes {
+		got = append(got, c.Op+" "+c.Path)
+	}
+	want := []string{
+		"update k8s_clusters.prod-cluster.namespaces",
+		"missing k8s_clusters.prod-cluster.namespaces.legacy",
+		"add db_servers.orders",
+		"missing db_servers.legacy-db",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	orders := report.Proposed.DBServers["orders"]
+	if orders.ConnectionString != "host=orders-rw.database.svc port=5432 dbname=orders user=app" ||
+		orders.K8sCluster != "prod-cluster" || orders.K8sPodSelector != "cnpg.io/cluster=orders,role=primary" {
+		t.Errorf("orders = %+v", orders)
+	}
+	if cred := report.Proposed.Credentials[orders.Credential]; cred.Env != "ORDERS_PASSWORD" {
+		t.Errorf("credential %q = %+v, want env ORDERS_PASSWORD", orders.Credential, cred)
+	}
+	if _, ok := report.Proposed.DBServers["legacy-db"]; !ok {
+		t.Error("legacy-db was removed from the proposal; entries not found live must be left for review")
+	}
+	if !strings.Contains(strings.Join(report.Notes, "\n"), "context staging: connection refused") {
+		t.Errorf("notes = %q, want the unreachable staging context", report.Notes)
+	}
+
+	// The proposal is a valid config, and never carries the password.
+	data, _ := json.Marshal(report.Proposed)
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("proposal contains the secret's password")
+	}
+	if _, err := infra.Parse(data); err != nil {
+		t.Errorf("proposal does not parse: %v", err)
+	}
+}
+
+func TestDiscover_Bootstrap(t *testing.T) {
+	d := &discoverer{timeout: 5 * time.Second}
+	found := &infra.Config{DBServers: map[string]infra.DBServer{}, K8sClusters: map[string]infra.K8sCluster{},
+		Credentials: map[string]infra.Credential{}}
+	if err := d.scanCluster(context.Background(), prodClientset(), "prod", found); err != nil {
+		t.Fatal(err)
+	}
+	report := d.propose(&infra.Config{}, found)
+	if len(report.Changes) != 2 || report.Changes[0].Path != "k8s_clusters.prod" || report.Changes[1].Path != "db_servers.orders" {
+		t.Errorf("changes = %+v, want the cluster and the database added", report.Changes)
+	}
+	if ns := report.Proposed.K8sClusters["prod"].Namespaces; len(ns) != 3 {
+		t.Errorf("namespaces = %v, want default, database and payments", ns)
+	}
+}
+
+func TestProbePostgres(t *testing.T) {
+	serve := func(reply []byte) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { l.Close() })
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			buf := make([]byte, 8)
+			conn.Read(buf)    //nolint:errcheck
+			conn.Write(reply) //nolint:errcheck
+		}()
+		return l.Addr().String()
+	}
+	if err := probePostgres(serve([]byte("N")), time.Second); err != nil {
+		t.Errorf("PostgreSQL server: %v", err)
+	}
+	if err := probePostgres(serve([]byte("HTTP/1.1 400")), time.Second); err == nil {
+		t.Error("non-PostgreSQL server accepted")
+	}
+}
@@ -0,0 +1,179 @@
+// Package main implements helpdeskctl, an operator and auditor CLI for
+// inspecting what aiHelpDesk did. It reads from the audit service (auditd).
+//
+//	helpdeskctl replay --session sess_abc               # chronological text replay
+//	helpdeskctl replay --session sess_abc --format html --output sess_abc.html
+//	helpdeskctl postmortem --trace tr_xyz --incident INC-123 --narrative
+//	helpdeskctl manifest sign --key ops.pem k8s_agent.json   # sign a capability manifest
+//	helpdeskctl route --query "why is prod-db slow?"    # routing decision only; no tools run
+//	helpdeskctl validate --policy policies.yaml --infra infra.json   # CI check of config files
+//	helpdeskctl discover --infra infra.json --write infra.proposed.json  # inventory from live clusters
+//	helpdeskctl subscriptions create --frequency daily --agent k8s_agent --email me@example.com
+//	helpdeskctl maintenance create --reason "CHG-42 upgrade" --resource 'prod-db-*' --duration 4h
+//	helpdeskctl subject erase --user alice@example.com --reason DSR-2026-017
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/logging"
+)
+
+func main() {
+	buildinfo.HandleVersionFlag()
+
+	args := logging.InitLogging(os.Args[1:])
+
+	auditURL := os.Getenv("HELPDESK_AUDIT_URL")
+	apiKey := os.Getenv("HELPDESK_AUDIT_API_KEY")
+
+	fs := flag.NewFlagSet("helpdeskctl", flag.ExitOnError)
+	fs.StringVar(&auditURL, "url", auditURL, "URL of the audit service (or set HELPDESK_AUDIT_URL)")
+	fs.StringVar(&apiKey, "api-key", apiKey, "Bearer token for auditd authentication (or set HELPDESK_AUDIT_API_KEY)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: helpdeskctl [options] <command> [arguments]
+
+Commands:
+  replay --session <id> [--format text|html] [--output file]
+                      Reconstruct a session from the audit trail: user queries,
+                      delegation reasoning, tool calls (redacted), approvals and
+                      outcomes, in chronological order
+  postmortem --trace <id> [--incident <id>] [--narrative] [--output file]
+                      Assemble a draft postmortem from a trace's audit trail:
+                      timeline, actions, approvals, tool outputs and outcome,
+                      written next to the incident bundle with --incident;
+                      --narrative has the LLM draft the summary and lessons
+  manifest sign --key <file> [--output file] <manifest.json>
+                      Sign an agent capability manifest for agents to serve
+                      (offline; does not contact auditd)
+  route --query <text> [--gateway URL] [--expect agent] [-o table|json|yaml]
+                      Ask the gateway which agent it would route the query to,
+                      with confidence and reasoning, without delegating it or
+                      running any tool (talks to the gateway, not auditd)
+  validate [--policy file] [--infra file] [-o table|json|yaml]
+                      Check a policy file and an infrastructure config: schema,
+                      unreachable rules, policy resources missing from infra
+                      and infra resources no policy covers; exit code 2 on
+                      problems, for CI (offline; does not contact auditd)
+  discover [--kubeconfig file] [--context c1,c2] [--endpoint host:port,...]
+           [--infra file] [--write file] [-o table|json|yaml]
+                      Propose an infrastructure config from what is live:
+                      clusters and namespaces of the kubeconfig contexts,
+                      PostgreSQL services (with connection details from their
+                      secrets) and endpoints that answer as PostgreSQL; with
+                      --infra, list the changes to that file; exit code 2 when
+                      there are any (does not contact auditd)
+  subscriptions list|create|update|get|preview|delete [arguments] [-o table|json|yaml]
+                      Manage your scheduled report subscriptions: daily or
+                      weekly reports filtered by agent, resource and event
+                      type, delivered by email or webhook
+  maintenance list|create|update|end|get|delete [arguments] [-o table|json|yaml]
+                      Declare planned work: alerts the auditor raises on the
+                      named resources inside a window are lowered and tagged,
+                      and policies may allow changes only inside one
+  subject erase|pseudonymize [arguments] [-o table|json|yaml]
+                      Erase a user's free text from the audit trail, or
+                      pseudonymize stored user IDs; every rewrite is audited
+                      and the hash chain stays verifiable
+  config show|set|reset [arguments] [-o table|json|yaml]
+                      Show or change runtime settings (alert thresholds,
+                      notifier URLs, retention) without restarting auditd or
+                      the auditor; every change is audited
+
+Options:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Environment Variables:
+  HELPDESK_AUDIT_URL      URL of the audit service (e.g., http://localhost:1199)
+  HELPDESK_AUDIT_API_KEY  Bearer token for auditd authentication
+  HELPDESK_AUDIT_TOKEN    auditd access token, sent as X-Audit-Token
+  HELPDESK_GATEWAY_URL    Gateway URL for route (default http://localhost:8080)
+  HELPDESK_CLIENT_API_KEY Bearer token for gateway authentication (route)
+  HELPDESK_CLIENT_USER    User ID sent to the gateway (route)
+  HELPDESK_INCIDENT_DIR   Incident bundle directory (postmortem --incident)
+  HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME, HELPDESK_API_KEY
+                          LLM for postmortem --narrative
+
+Examples:
+  helpdeskctl replay --session sess_abc
+  helpdeskctl replay --session sess_abc --format html --output sess_abc.html
+  helpdeskctl postmortem --trace tr_xyz --incident INC-123 --narrative
+  helpdeskctl manifest sign --key ops.pem --output k8s_agent.signed.json k8s_agent.json
+  helpdeskctl route --query "why is prod-db slow?" --expect postgres_database_agent
+  helpdeskctl validate --policy policies.yaml --infra infra.json
+  helpdeskctl discover > infra.json
+  helpdeskctl discover --infra infra.json --write infra.proposed.json
+  helpdeskctl subscriptions create --frequency weekly --resource 'prod-*' --webhook https://hooks.example.com/gov
+  helpdeskctl maintenance create --reason "CHG-42 postgres upgrade" --resource 'prod-db-*' --start 2026-03-07T22:00:00Z --duration 4h
+  helpdeskctl subject erase --user alice@example.com --reason DSR-2026-017
+  helpdeskctl config set --reason "noisy batch job" auditor.max_events_per_minute=600
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if rest[0] == "manifest" {
+		if err := cmdManifest(rest[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if rest[0] == "route" {
+		os.Exit(cmdRoute(rest[1:]))
+	}
+	if rest[0] == "validate" {
+		os.Exit(cmdValidate(rest[1:]))
+	}
+	if rest[0] == "discover" {
+		os.Exit(cmdDiscover(rest[1:]))
+	}
+	if auditURL == "" {
+		fmt.Fprintln(os.Stderr, "Error: audit service URL required (use --url or set HELPDESK_AUDIT_URL)")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if err := audit.InstallAuditToken(ctx, auditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	src := newAuditSource(auditURL, apiKey)
+
+	var err error
+	switch rest[0] {
+	case "replay":
+		err = cmdReplay(ctx, src, rest[1:])
+	case "postmortem":
+		err = cmdPostmortem(ctx, src, rest[1:])
+	case "subscriptions":
+		err = cmdSubscriptions(ctx, src, rest[1:])
+	case "maintenance":
+		err = cmdMaintenance(ctx, src, rest[1:])
+	case "subject":
+		err = cmdSubject(ctx, src, rest[1:])
+	case "config":
+		err = cmdConfig(ctx, src, rest[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", rest[0])
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
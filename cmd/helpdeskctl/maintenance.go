@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+const maintenanceUsage = `usage: helpdeskctl maintenance <command> [arguments]
+
+Commands:
+  list [--active] [--since DURATION]  current and upcoming windows, or those
+                                      overlapping the last DURATION
+  create --reason text [--owner user] [--resource r1,r2] [--start T] (--end T | --duration D)
+  update <id> [--reason ...] [--owner ...] [--resource ...] [--start T] [--end T | --duration D]
+  end <id>                            end a window now
+  get <id>
+  delete <id>
+
+Times are RFC3339 (2026-03-07T22:00:00Z); --start defaults to now on create.
+--resource takes databases, namespaces or hosts, globs like prod-* allowed;
+without it the window is fleet-wide.`
+
+// cmdMaintenance implements "helpdeskctl maintenance": declaring the planned
+// work windows during which the auditor lowers its alerts.
+func cmdMaintenance(ctx context.Context, src *auditSource, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", maintenanceUsage)
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("maintenance "+cmd, flag.ExitOnError)
+	var output cliout.Format
+	cliout.Register(fs, &output)
+
+	switch cmd {
+	case "list":
+		active := fs.Bool("active", false, "Only windows active now")
+		since := fs.Duration("since", 0, "Windows overlapping the last DURATION, instead of current and upcoming ones")
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		q := url.Values{}
+		switch {
+		case *active:
+			q.Set("active", "true")
+		case *since > 0:
+			q.Set("since", time.Now().Add(-*since).UTC().Format(time.RFC3339))
+		default:
+			q.Set("since", time.Now().UTC().Format(time.RFC3339))
+		}
+		var out struct {
+			Windows []audit.MaintenanceWindow `json:"windows"`
+		}
+		body, err := src.call(ctx, http.MethodGet, "/v1/maintenance-windows?"+q.Encode(), nil, &out)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderMaintenanceWindows(os.Stdout, out.Windows, time.Now())
+
+	case "create", "update", "end":
+		var id string
+		if cmd != "create" {
+			if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+				return fmt.Errorf("usage: helpdeskctl maintenance %s <id> [flags]", cmd)
+			}
+			id, args = args[0], args[1:]
+		}
+		set := maintenanceFlags(fs)
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		var mw audit.MaintenanceWindow
+		method, path := http.MethodPost, "/v1/maintenance-windows"
+		if cmd == "create" {
+			mw.StartsAt = time.Now().UTC()
+		} else {
+			method, path = http.MethodPut, "/v1/maintenance-windows/"+url.PathEscape(id)
+			if _, err := src.call(ctx, http.MethodGet, path, nil, &mw); err != nil {
+				return err
+			}
+		}
+		if err := set(&mw); err != nil {
+			return err
+		}
+		if cmd == "end" {
+			mw.EndsAt = time.Now().UTC()
+		}
+		var saved audit.MaintenanceWindow
+		body, err := src.call(ctx, method, path, mw, &saved)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderMaintenanceWindow(os.Stdout, &saved)
+
+	case "get", "delete":
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("usage: helpdeskctl maintenance %s <id>", cmd)
+		}
+		id := args[0]
+		fs.Parse(args[1:]) //nolint:errcheck // ExitOnError
+		path := "/v1/maintenance-windows/" + url.PathEscape(id)
+		if cmd == "delete" {
+			if _, err := src.call(ctx, http.MethodDelete, path, nil, nil); err != nil {
+				return err
+			}
+			fmt.Printf("Maintenance window %s deleted\n", id)
+			return nil
+		}
+		var mw audit.MaintenanceWindow
+		body, err := src.call(ctx, http.MethodGet, path, nil, &mw)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderMaintenanceWindow(os.Stdout, &mw)
+	}
+	return fmt.Errorf("unknown maintenance command %q\n%s", cmd, maintenanceUsage)
+}
+
+// maintenanceFlags registers the settable window fields on fs. The returned
+// function applies the flags given on the command line to a window, leaving
+// the others as they are, so update changes only what was named. --duration
+// counts from the window's (possibly updated) start.
+func maintenanceFlags(fs *flag.FlagSet) func(*audit.MaintenanceWindow) error {
+	owner := fs.String("owner", "", "Who is doing the work (default: you)")
+	reason := fs.String("reason", "", "Why, e.g. a change ticket")
+	resources := fs.String("resource", "", "Comma-separated databases, namespaces or hosts; globs like prod-* allowed (empty = fleet-wide)")
+	start := fs.String("start", "", "Start time, RFC3339")
+	end := fs.String("end", "", "End time, RFC3339")
+	duration := fs.Duration("duration", 0, "Length of the window, instead of --end")
+	return func(mw *audit.MaintenanceWindow) error {
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "owner":
+				mw.Owner = *owner
+			case "reason":
+				mw.Reason = *reason
+			case "resource":
+				mw.Resources = splitList(*resources)
+			case "start":
+				if t, perr := time.Parse(time.RFC3339, *start); perr != nil {
+					err = fmt.Errorf("--start: %w", perr)
+				} else {
+					mw.StartsAt = t
+				}
+			case "end":
+				if t, perr := time.Parse(time.RFC3339, *end); perr != nil {
+					err = fmt.Errorf("--end: %w", perr)
+				} else {
+					mw.EndsAt = t
+				}
+			}
+		})
+		if err == nil && *duration > 0 {
+			mw.EndsAt = mw.StartsAt.Add(*duration)
+		}
+		return err
+	}
+}
+
+// renderMaintenanceWindows prints one line per window.
+func renderMaintenanceWindows(w io.Writer, windows []audit.MaintenanceWindow, now time.Time) error {
+	if len(windows) == 0 {
+		_, err := fmt.Fprintln(w, "No maintenance windows.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tOWNER\tSTARTS\tENDS\tRESOURCES\tALERTS\tREASON")
+	for _, mw := range windows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			mw.WindowID, maintenanceState(&mw, now), mw.Owner, mw.StartsAt.UTC().Format(time.RFC3339),
+			mw.EndsAt.UTC().Format(time.RFC3339), maintenanceResources(&mw), mw.Alerts, truncate(mw.Reason, 40))
+	}
+	return tw.Flush()
+}
+
+// renderMaintenanceWindow prints one window in full.
+func renderMaintenanceWindow(w io.Writer, mw *audit.MaintenanceWindow) error {
+	_, err := fmt.Fprintf(w, `Maintenance window %s (%s)
+  Owner:      %s
+  Reason:     %s
+  Resources:  %s
+  Starts:     %s
+  Ends:       %s
+  Created by: %s
+`, mw.WindowID, maintenanceState(mw, time.Now()), mw.Owner, mw.Reason, maintenanceResources(mw),
+		mw.StartsAt.UTC().Format(time.RFC3339), mw.EndsAt.UTC().Format(time.RFC3339), dash(mw.CreatedBy))
+	return err
+}
+
+func maintenanceState(mw *audit.MaintenanceWindow, now time.Time) string {
+	switch {
+	case mw.ActiveAt(now):
+		return "active"
+	case now.Before(mw.StartsAt):
+		return "scheduled"
+	}
+	return "ended"
+}
+
+func maintenanceResources(mw *audit.MaintenanceWindow) string {
+	if len(mw.Resources) == 0 {
+		return "(fleet-wide)"
+	}
+	return strings.Join(mw.Resources, ",")
+}
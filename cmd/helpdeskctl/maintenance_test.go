@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestCmdMaintenance_UpdateExtendsFromStart(t *testing.T) {
+	start := time.Date(2026, 3, 7, 22, 0, 0, 0, time.UTC)
+	stored := audit.MaintenanceWindow{
+		WindowID: "mw_1", Owner: "alice", Reason: "CHG-42", Resources: []string{"prod-db-*"},
+		StartsAt: start, EndsAt: start.Add(2 * time.Hour),
+	}
+	var put audit.MaintenanceWindow
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/maintenance-windows/mw_1" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(stored) //nolint:errcheck
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&put) //nolint:errcheck
+			json.NewEncoder(w).Encode(put)       //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+
+	src := newAuditSource(srv.URL, "")
+	if err := cmdMaintenance(context.Background(), src, []string{"update", "mw_1", "--duration", "6h", "-o", "json"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !put.EndsAt.Equal(start.Add(6*time.Hour)) || put.Owner != "alice" || len(put.Resources) != 1 {
+		t.Errorf("PUT body = %+v", put)
+	}
+
+	if err := cmdMaintenance(context.Background(), src, []string{"update", "mw_1", "--start", "tonight"}); err == nil {
+		t.Error("update accepted a malformed --start")
+	}
+}
+
+func TestMaintenanceState(t *testing.T) {
+	start := time.Date(2026, 3, 7, 22, 0, 0, 0, time.UTC)
+	mw := &audit.MaintenanceWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	for _, tt := range []struct {
+		at   time.Time
+		want string
+	}{
+		{start.Add(-time.Minute), "scheduled"},
+		{start, "active"},
+		{start.Add(time.Hour), "ended"},
+	} {
+		if got := maintenanceState(mw, tt.at); got != tt.want {
+			t.Errorf("maintenanceState(%v) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"helpdesk/internal/discovery"
+	"helpdesk/internal/infra"
+)
+
+// cmdManifest implements "helpdeskctl manifest". It works offline and does
+// not need auditd.
+func cmdManifest(args []string) error {
+	if len(args) == 0 || args[0] != "sign" {
+		return fmt.Errorf("usage: helpdeskctl manifest sign --key <file> [--output file] <manifest.json>")
+	}
+	fs := flag.NewFlagSet("manifest sign", flag.ExitOnError)
+	keyFile := fs.String("key", "", "Ed25519 private key file (PEM or base64) to sign with (required)")
+	output := fs.String("output", "", "Write the signed manifest to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *keyFile == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: helpdeskctl manifest sign --key <file> [--output file] <manifest.json>")
+	}
+
+	keyData, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return fmt.Errorf("read signing key: %w", err)
+	}
+	key, err := infra.ParsePrivateKey(string(keyData))
+	if err != nil {
+		return err
+	}
+	payload, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+	signed, err := discovery.SignManifest(payload, key)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(signed)
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+// maxPostmortemOutput caps each tool output quoted in a postmortem.
+const maxPostmortemOutput = 1500
+
+// cmdPostmortem implements "helpdeskctl postmortem".
+func cmdPostmortem(ctx context.Context, src *auditSource, args []string) error {
+	fs := flag.NewFlagSet("postmortem", flag.ExitOnError)
+	trace := fs.String("trace", "", "Trace ID of the incident's investigation (required)")
+	incident := fs.String("incident", "", "Incident ID; the postmortem is written next to its bundle")
+	incidentDir := fs.String("incident-dir", envOr("HELPDESK_INCIDENT_DIR", "."), "Directory holding incidents.json and the bundles (or set HELPDESK_INCIDENT_DIR)")
+	narrative := fs.Bool("narrative", false, "Draft the summary, impact, root cause and lessons with the LLM (HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME, HELPDESK_API_KEY)")
+	output := fs.String("output", "", "Write the postmortem to this file (default: next to the incident bundle with --incident, else stdout)")
+	limit := fs.Int("limit", 1000, "Maximum number of events to fetch for the trace")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *trace == "" {
+		return fmt.Errorf("--trace is required")
+	}
+
+	events, err := src.events(ctx, url.Values{"trace_id": {*trace}, "limit": {strconv.Itoa(*limit)}})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("no audit events found for trace %s", *trace)
+	}
+	approvals, err := src.approvals.ListApprovals(ctx, audit.ApprovalListOptions{TraceID: *trace, Limit: *limit})
+	if err != nil {
+		return fmt.Errorf("list approvals for trace %s: %w", *trace, err)
+	}
+
+	pm := buildPostmortem(*trace, events, approvals)
+	path := *output
+	if *incident != "" {
+		entry, err := findIncident(*incidentDir, *incident)
+		if err != nil {
+			return err
+		}
+		pm.Incident = entry
+		if entry == nil {
+			fmt.Fprintf(os.Stderr, "Warning: incident %s not found in %s; writing the postmortem there\n",
+				*incident, filepath.Join(*incidentDir, "incidents.json"))
+			pm.Incident = &incidentEntry{IncidentID: *incident}
+		}
+		if path == "" {
+			path = postmortemPath(*incidentDir, pm.Incident)
+		}
+	}
+
+	if *narrative {
+		complete, err := newCompleter(ctx)
+		if err != nil {
+			return fmt.Errorf("narrative needs an LLM: %w", err)
+		}
+		n, err := draftNarrative(ctx, complete, pm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: narrative not drafted, sections left for the author: %v\n", err)
+		} else {
+			pm.Narrative = n
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := renderPostmortem(w, pm); err != nil {
+		return err
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Postmortem draft for %s (%d events) written to %s\n", *trace, len(events), path)
+	}
+	return nil
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// ── Incidents ─────────────────────────────────────────────────────────────────
+
+// incidentEntry is an entry of the incident agent's incidents.json index.
+type incidentEntry struct {
+	IncidentID  string `json:"incident_id"`
+	InfraKey    string `json:"infra_key"`
+	Description string `json:"description"`
+	Timestamp   string `json:"timestamp"`
+	BundlePath  string `json:"bundle_path"`
+}
+
+// findIncident returns the incidents.json entry of id in dir, or nil when the
+// index or the entry doesn't exist.
+func findIncident(dir, id string) (*incidentEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "incidents.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []incidentEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(dir, "incidents.json"), err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].IncidentID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// postmortemPath places the postmortem next to the incident's bundle.
+func postmortemPath(dir string, inc *incidentEntry) string {
+	if inc.BundlePath != "" {
+		dir = filepath.Dir(inc.BundlePath)
+	}
+	return filepath.Join(dir, fmt.Sprintf("incident-%s-postmortem.md", inc.IncidentID))
+}
+
+// ── Assembly ──────────────────────────────────────────────────────────────────
+
+// postmortem is a draft postmortem assembled from one trace.
+type postmortem struct {
+	TraceID   string
+	Incident  *incidentEntry // nil without --incident
+	Users     []string
+	Agents    []string
+	Start     time.Time
+	End       time.Time
+	Timeline  []replayStep
+	Actions   []postmortemAction
+	Approvals []audit.StoredApproval
+	Outcome   string // status of the last outcome recorded for the trace
+	Response  string // the final response to the user, redacted
+	Errors    []string
+	EventIDs  int
+	Narrative *postmortemNarrative // nil until drafted
+}
+
+// postmortemAction is a tool call made during the incident.
+type postmortemAction struct {
+	Time        time.Time
+	Agent       string
+	Tool        string
+	ActionClass string
+	Status      string
+	ApprovalID  string
+	Parameters  string
+	Output      string
+	EventID     string
+}
+
+// postmortemNarrative holds the sections a person (or the LLM) writes.
+type postmortemNarrative struct {
+	Summary        string   `json:"summary"`
+	Impact         string   `json:"impact"`
+	RootCause      string   `json:"root_cause"`
+	Resolution     string   `json:"resolution"`
+	LessonsLearned []string `json:"lessons_learned"`
+	ActionItems    []string `json:"action_items"`
+}
+
+// buildPostmortem assembles the factual sections from a trace's events and
+// approvals. The timeline is the session replay minus the agents' internal
+// reasoning and policy checks, which the actions and approvals summarize.
+func buildPostmortem(traceID string, events []audit.Event, approvals []audit.StoredApproval) *postmortem {
+	pm := &postmortem{TraceID: traceID, Approvals: approvals, EventIDs: len(events)}
+	replay := buildReplay(traceID, events, approvals)
+	pm.Start, pm.End = replay.Start, replay.End
+	for _, s := range replay.Steps {
+		if s.Kind == stepReasoning || s.Kind == stepPolicy {
+			continue
+		}
+		pm.Timeline = append(pm.Timeline, s)
+	}
+
+	sorted := append([]audit.Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	users, agents := map[string]bool{}, map[string]bool{}
+	for i := range sorted {
+		e := &sorted[i]
+		if u := eventUser(e); u != "" && !users[u] && e.EventType == audit.EventTypeGatewayRequest {
+			users[u] = true
+			pm.Users = append(pm.Users, u)
+		}
+		switch e.EventType {
+		case audit.EventTypeToolExecution:
+			a := toolAction(e)
+			if a.Agent != "" && !agents[a.Agent] {
+				agents[a.Agent] = true
+				pm.Agents = append(pm.Agents, a.Agent)
+			}
+			pm.Actions = append(pm.Actions, a)
+			if e.Tool != nil && e.Tool.Error != "" {
+				pm.Errors = append(pm.Errors, fmt.Sprintf("%s: %s", e.Tool.Name, redactString(e.Tool.Error)))
+			}
+		case audit.EventTypeOutcome, audit.EventTypeGatewayRequest:
+			if e.Outcome != nil && e.Outcome.Status != "" {
+				pm.Outcome = e.Outcome.Status
+			}
+			if e.Output != nil && e.Output.Response != "" {
+				pm.Response = redactString(e.Output.Response)
+			}
+		}
+	}
+	return pm
+}
+
+func toolAction(e *audit.Event) postmortemAction {
+	a := postmortemAction{Time: e.Timestamp, Agent: e.Session.AgentName, ActionClass: string(e.ActionClass), EventID: e.EventID}
+	if e.Outcome != nil {
+		a.Status = e.Outcome.Status
+	}
+	if e.Approval != nil {
+		a.ApprovalID = e.Approval.ApprovalID
+	}
+	if t := e.Tool; t != nil {
+		a.Tool = t.Name
+		if t.Agent != "" {
+			a.Agent = t.Agent
+		}
+		if len(t.Parameters) > 0 {
+			b, _ := json.Marshal(redactParams(t.Parameters))
+			a.Parameters = string(b)
+		}
+		a.Output = truncate(redactString(t.Result), maxPostmortemOutput)
+		if t.Error != "" {
+			a.Output = truncate(redactString(t.Error), maxPostmortemOutput)
+		}
+	}
+	if a.ActionClass == "" {
+		a.ActionClass = string(audit.ClassifyTool(a.Tool))
+	}
+	return a
+}
+
+// mutating reports whether the action changed something.
+func (a postmortemAction) mutating() bool {
+	return a.ActionClass == string(audit.ActionWrite) || a.ActionClass == string(audit.ActionDestructive)
+}
+
+// ── Narrative ─────────────────────────────────────────────────────────────────
+
+// newCompleter returns the LLM used for the narrative. Tests replace it.
+var newCompleter = func(ctx context.Context) (agentutil.TextCompleter, error) {
+	cfg := agentutil.Config{
+		ModelVendor: os.Getenv("HELPDESK_MODEL_VENDOR"),
+		ModelName:   os.Getenv("HELPDESK_MODEL_NAME"),
+		APIKey:      os.Getenv("HELPDESK_API_KEY"),
+	}
+	if cfg.ModelVendor == "" || cfg.ModelName == "" || cfg.APIKey == "" {
+		return nil, fmt.Errorf("set HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME and HELPDESK_API_KEY")
+	}
+	return agentutil.NewTextCompleter(ctx, cfg)
+}
+
+// draftNarrative asks the LLM for the narrative sections, given the facts
+// assembled from the audit trail.
+func draftNarrative(ctx context.Context, complete agentutil.TextCompleter, pm *postmortem) (*postmortemNarrative, error) {
+	var facts strings.Builder
+	if err := renderPostmortem(&facts, pm); err != nil {
+		return nil, err
+	}
+	prompt := `You are drafting a blameless incident postmortem. Below are the facts
+assembled from the audit trail of the incident: timeline, actions taken,
+approvals, tool outputs and outcome. Write the narrative sections using only
+these facts; where the facts don't say, write "Unknown — to be confirmed".
+
+Respond with ONLY a JSON object, no markdown fences:
+{
+  "summary": "<2-4 sentences: what happened and how it ended>",
+  "impact": "<who or what was affected and for how long, as far as the facts show>",
+  "root_cause": "<the most likely root cause, stating the evidence>",
+  "resolution": "<what was done to mitigate or resolve it>",
+  "lessons_learned": ["<lesson>", ...],
+  "action_items": ["<concrete follow-up>", ...]
+}
+
+## Facts
+
+` + facts.String()
+	out, err := complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	out = strings.TrimSpace(out)
+	out = strings.TrimPrefix(out, "```json")
+	out = strings.TrimPrefix(out, "```")
+	out = strings.TrimSuffix(out, "```")
+	var n postmortemNarrative
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &n); err != nil {
+		return nil, fmt.Errorf("LLM returned invalid JSON: %w", err)
+	}
+	return &n, nil
+}
+
+// ── Rendering ─────────────────────────────────────────────────────────────────
+
+const toBeWritten = "_To be written._"
+
+// renderPostmortem writes the postmortem as markdown.
+func renderPostmortem(w io.Writer, pm *postmortem) error {
+	var b strings.Builder
+	title := "trace " + pm.TraceID
+	if pm.Incident != nil {
+		title = pm.Incident.IncidentID
+	}
+	fmt.Fprintf(&b, "# Postmortem: %s (draft)\n\n", title)
+
+	b.WriteString("| | |\n|---|---|\n")
+	if inc := pm.Incident; inc != nil {
+		fmt.Fprintf(&b, "| Incident | %s |\n", mdCell(inc.IncidentID))
+		if inc.Description != "" {
+			fmt.Fprintf(&b, "| Description | %s |\n", mdCell(inc.Description))
+		}
+		if inc.InfraKey != "" {
+			fmt.Fprintf(&b, "| Infrastructure | %s |\n", mdCell(inc.InfraKey))
+		}
+		if inc.BundlePath != "" {
+			fmt.Fprintf(&b, "| Diagnostic bundle | `%s` |\n", inc.BundlePath)
+		}
+	}
+	fmt.Fprintf(&b, "| Trace | `%s` |\n", pm.TraceID)
+	if !pm.Start.IsZero() {
+		fmt.Fprintf(&b, "| Window (UTC) | %s → %s (%s) |\n", pm.Start.UTC().Format(time.RFC3339),
+			pm.End.UTC().Format(time.RFC3339), pm.End.Sub(pm.Start).Round(time.Second))
+	}
+	if len(pm.Users) > 0 {
+		fmt.Fprintf(&b, "| Reported by | %s |\n", mdCell(strings.Join(pm.Users, ", ")))
+	}
+	if len(pm.Agents) > 0 {
+		fmt.Fprintf(&b, "| Agents | %s |\n", mdCell(strings.Join(pm.Agents, ", ")))
+	}
+	if pm.Outcome != "" {
+		fmt.Fprintf(&b, "| Outcome | %s |\n", pm.Outcome)
+	}
+
+	n := pm.Narrative
+	if n == nil {
+		n = &postmortemNarrative{}
+	}
+	section(&b, "Summary", n.Summary)
+	section(&b, "Impact", n.Impact)
+	section(&b, "Root cause", n.RootCause)
+	section(&b, "Resolution", n.Resolution)
+
+	b.WriteString("\n## Timeline\n\n| Time (UTC) | Actor | Event | Status |\n|---|---|---|---|\n")
+	for _, s := range pm.Timeline {
+		fmt.Fprintf(&b, "| %s | %s | %s: %s | %s |\n", s.Time.UTC().Format("15:04:05"), mdCell(s.Actor),
+			s.Kind, mdCell(truncate(firstLine(s.Summary), 200)), s.Status)
+	}
+
+	b.WriteString("\n## Actions taken\n\n")
+	if len(pm.Actions) == 0 {
+		b.WriteString("No tools ran.\n")
+	} else {
+		mutations := 0
+		for _, a := range pm.Actions {
+			if a.mutating() {
+				mutations++
+			}
+		}
+		fmt.Fprintf(&b, "%d tool call(s), %d of them changing state.\n\n", len(pm.Actions), mutations)
+		b.WriteString("| Time (UTC) | Agent | Tool | Class | Status | Approval |\n|---|---|---|---|---|---|\n")
+		for _, a := range pm.Actions {
+			tool := a.Tool
+			if a.mutating() {
+				tool = "**" + tool + "**"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", a.Time.UTC().Format("15:04:05"), mdCell(a.Agent),
+				tool, a.ActionClass, a.Status, a.ApprovalID)
+		}
+	}
+
+	b.WriteString("\n## Approvals\n\n")
+	if len(pm.Approvals) == 0 {
+		b.WriteString("No approvals were requested.\n")
+	} else {
+		b.WriteString("| Requested (UTC) | Action | Requested by | Resolved by | Status | Reason |\n|---|---|---|---|---|---|\n")
+		for _, a := range pm.Approvals {
+			fmt.Fprintf(&b, "| %s | %s %s on %s/%s | %s | %s | %s | %s |\n", a.RequestedAt.UTC().Format("15:04:05"),
+				a.ActionClass, a.ToolName, a.ResourceType, a.ResourceName, mdCell(a.RequestedBy),
+				mdCell(a.ResolvedBy), a.Status, mdCell(a.ResolutionReason))
+		}
+	}
+
+	if len(pm.Actions) > 0 {
+		b.WriteString("\n## Tool outputs\n")
+		for _, a := range pm.Actions {
+			fmt.Fprintf(&b, "\n### %s %s (%s)\n\n", a.Time.UTC().Format("15:04:05"), a.Tool, a.Agent)
+			if a.Parameters != "" {
+				fmt.Fprintf(&b, "Parameters: `%s`\n\n", a.Parameters)
+			}
+			if a.Output == "" {
+				b.WriteString("_No output recorded._\n")
+				continue
+			}
+			fmt.Fprintf(&b, "```text\n%s\n```\n", strings.TrimRight(a.Output, "\n"))
+		}
+	}
+
+	b.WriteString("\n## Outcome\n\n")
+	if pm.Outcome == "" {
+		b.WriteString("No outcome recorded.\n")
+	} else {
+		fmt.Fprintf(&b, "Status: **%s**\n", pm.Outcome)
+	}
+	if len(pm.Errors) > 0 {
+		b.WriteString("\nErrors:\n\n")
+		for _, e := range pm.Errors {
+			fmt.Fprintf(&b, "- %s\n", firstLine(e))
+		}
+	}
+	if pm.Response != "" {
+		fmt.Fprintf(&b, "\nFinal response:\n\n> %s\n", strings.ReplaceAll(truncate(pm.Response, maxPostmortemOutput), "\n", "\n> "))
+	}
+
+	list(&b, "Lessons learned", n.LessonsLearned)
+	list(&b, "Action items", n.ActionItems)
+
+	fmt.Fprintf(&b, "\n---\n\n_Draft generated by `helpdeskctl postmortem` from %d audit events", pm.EventIDs)
+	if pm.Narrative != nil {
+		b.WriteString("; the narrative sections were drafted by an LLM from the same facts")
+	}
+	b.WriteString(". Review before publishing._\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func section(b *strings.Builder, heading, text string) {
+	if strings.TrimSpace(text) == "" {
+		text = toBeWritten
+	}
+	fmt.Fprintf(b, "\n## %s\n\n%s\n", heading, strings.TrimSpace(text))
+}
+
+func list(b *strings.Builder, heading string, items []string) {
+	fmt.Fprintf(b, "\n## %s\n\n", heading)
+	if len(items) == 0 {
+		b.WriteString(toBeWritten + "\n")
+		return
+	}
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", strings.TrimSpace(item))
+	}
+}
+
+// mdCell makes s safe for a markdown table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/audit"
+)
+
+func TestBuildPostmortem(t *testing.T) {
+	events, approvals := replayFixture()
+	pm := buildPostmortem("tr_1", events, approvals)
+
+	for _, s := range pm.Timeline {
+		if s.Kind == stepPolicy || s.Kind == stepReasoning {
+			t.Errorf("timeline has a %s step; the postmortem leaves those out", s.Kind)
+		}
+	}
+	if len(pm.Actions) != 1 || pm.Actions[0].Tool != "terminate_connection" || !pm.Actions[0].mutating() {
+		t.Fatalf("actions = %+v, want the destructive terminate_connection", pm.Actions)
+	}
+	if pm.Actions[0].ApprovalID != "apr_1" || pm.Outcome != "success" {
+		t.Errorf("approval=%q outcome=%q, want apr_1 and success", pm.Actions[0].ApprovalID, pm.Outcome)
+	}
+	if len(pm.Users) != 1 || pm.Users[0] != "alice" {
+		t.Errorf("users = %v, want [alice]", pm.Users)
+	}
+
+	var out bytes.Buffer
+	if err := renderPostmortem(&out, pm); err != nil {
+		t.Fatalf("renderPostmortem: %v", err)
+	}
+	doc := out.String()
+	for _, want := range []string{
+		"# Postmortem: trace tr_1 (draft)", "## Timeline", "| 09:00:30 | postgres_database_agent | **terminate_connection** | destructive | success | apr_1 |",
+		"confirmed with app team", "## Tool outputs", "terminated pid 4242", "Status: **success**",
+		"## Root cause\n\n" + toBeWritten,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("postmortem missing %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "hunter2") {
+		t.Errorf("postmortem leaks a secret:\n%s", doc)
+	}
+}
+
+func TestDraftNarrative(t *testing.T) {
+	events, approvals := replayFixture()
+	pm := buildPostmortem("tr_1", events, approvals)
+
+	var prompt string
+	complete := agentutil.TextCompleter(func(_ context.Context, p string) (string, error) {
+		prompt = p
+		return "```json\n" + `{"summary":"A stuck query on prod was terminated.","root_cause":"Long-running report query.","lessons_learned":["Add a statement_timeout"],"action_items":["Set statement_timeout on the reporting role"]}` + "\n```", nil
+	})
+	n, err := draftNarrative(context.Background(), complete, pm)
+	if err != nil {
+		t.Fatalf("draftNarrative: %v", err)
+	}
+	if !strings.Contains(prompt, "terminate_connection") || strings.Contains(prompt, "hunter2") {
+		t.Errorf("prompt should carry the redacted facts:\n%s", prompt)
+	}
+	pm.Narrative = n
+
+	var out bytes.Buffer
+	if err := renderPostmortem(&out, pm); err != nil {
+		t.Fatalf("renderPostmortem: %v", err)
+	}
+	for _, want := range []string{"## Summary\n\nA stuck query on prod was terminated.", "- Set statement_timeout on the reporting role", "drafted by an LLM"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("postmortem missing %q", want)
+		}
+	}
+
+	bad := agentutil.TextCompleter(func(context.Context, string) (string, error) { return "Sure! Here is", nil })
+	if _, err := draftNarrative(context.Background(), bad, pm); err == nil {
+		t.Error("draftNarrative accepted a non-JSON answer")
+	}
+}
+
+func TestCmdPostmortem_WritesNextToBundle(t *testing.T) {
+	events, approvals := replayFixture()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out any = approvals
+		if r.URL.Path == "/v1/events" {
+			var matched []audit.Event
+			for _, e := range events {
+				if e.TraceID == r.URL.Query().Get("trace_id") {
+					matched = append(matched, e)
+				}
+			}
+			out = matched
+		}
+		json.NewEncoder(w).Encode(out) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	bundles := filepath.Join(dir, "bundles")
+	if err := os.Mkdir(bundles, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	index := `[{"incident_id":"a1b2c3d4","infra_key":"prod-db","description":"stuck query","bundle_path":"` +
+		filepath.Join(bundles, "incident-a1b2c3d4-20260501-090000.tar.gz") + `"}]`
+	if err := os.WriteFile(filepath.Join(dir, "incidents.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := newAuditSource(srv.URL, "")
+	if err := cmdPostmortem(context.Background(), src, []string{"--trace", "tr_1", "--incident", "a1b2c3d4", "--incident-dir", dir}); err != nil {
+		t.Fatalf("cmdPostmortem: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(bundles, "incident-a1b2c3d4-postmortem.md"))
+	if err != nil {
+		t.Fatalf("postmortem not written next to the bundle: %v", err)
+	}
+	if !strings.Contains(string(data), "# Postmortem: a1b2c3d4 (draft)") || !strings.Contains(string(data), "| Infrastructure | prod-db |") {
+		t.Errorf("postmortem = %s", data)
+	}
+
+	if err := cmdPostmortem(context.Background(), src, []string{"--trace", "tr_missing"}); err == nil {
+		t.Error("expected an error for a trace without events")
+	}
+}
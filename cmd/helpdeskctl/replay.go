@@ -0,0 +1,585 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// maxReplayOutput caps tool results and responses in a replay; the full text
+// stays in the audit trail.
+const maxReplayOutput = 2000
+
+// cmdReplay implements "helpdeskctl replay".
+func cmdReplay(ctx context.Context, src *auditSource, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	session := fs.String("session", "", "Session ID to replay (required)")
+	format := fs.String("format", "text", "Output format: text or html")
+	output := fs.String("output", "", "Write the replay to this file instead of stdout")
+	limit := fs.Int("limit", 1000, "Maximum number of events to fetch per session and per trace")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *session == "" {
+		return fmt.Errorf("--session is required")
+	}
+	if *format != "text" && *format != "html" {
+		return fmt.Errorf("--format must be text or html, got %q", *format)
+	}
+
+	events, approvals, err := loadSession(ctx, src, *session, *limit)
+	if err != nil {
+		return err
+	}
+	replay := buildReplay(*session, events, approvals)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "html" {
+		err = renderReplayHTML(w, replay)
+	} else {
+		err = renderReplayText(w, replay)
+	}
+	if err == nil && *output != "" {
+		fmt.Fprintf(os.Stderr, "Replay of %s (%d steps) written to %s\n", *session, len(replay.Steps), *output)
+	}
+	return err
+}
+
+// ── Loading ───────────────────────────────────────────────────────────────────
+
+// auditSource reads events and approvals from auditd.
+type auditSource struct {
+	baseURL   string
+	apiKey    string
+	client    *http.Client
+	approvals *audit.ApprovalClient
+}
+
+func newAuditSource(baseURL, apiKey string) *auditSource {
+	baseURL = strings.TrimRight(baseURL, "/")
+	approvals := audit.NewApprovalClient(baseURL)
+	if apiKey != "" {
+		approvals = approvals.WithAPIKey(apiKey)
+	}
+	return &auditSource{
+		baseURL:   baseURL,
+		apiKey:    apiKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		approvals: approvals,
+	}
+}
+
+// events queries GET /v1/events with the given filters.
+func (s *auditSource) events(ctx context.Context, q url.Values) ([]audit.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/v1/events?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET /v1/events: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /v1/events: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var events []audit.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("decode events: %w", err)
+	}
+	return events, nil
+}
+
+// loadSession fetches the events recorded under sessionID, then every event
+// of the traces those events belong to — sub-agents record their tool calls
+// under their own sessions but the caller's trace — and the approvals
+// requested in those traces.
+func loadSession(ctx context.Context, src *auditSource, sessionID string, limit int) ([]audit.Event, []audit.StoredApproval, error) {
+	events, err := src.events(ctx, url.Values{"session_id": {sessionID}, "limit": {strconv.Itoa(limit)}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil, fmt.Errorf("no audit events found for session %s", sessionID)
+	}
+
+	seen := make(map[string]bool, len(events))
+	for _, e := range events {
+		seen[e.EventID] = true
+	}
+	var approvals []audit.StoredApproval
+	for _, traceID := range sessionTraces(events) {
+		traceEvents, err := src.events(ctx, url.Values{"trace_id": {traceID}, "limit": {strconv.Itoa(limit)}})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range traceEvents {
+			if !seen[e.EventID] {
+				seen[e.EventID] = true
+				events = append(events, e)
+			}
+		}
+		traceApprovals, err := src.approvals.ListApprovals(ctx, audit.ApprovalListOptions{TraceID: traceID, Limit: limit})
+		if err != nil {
+			return nil, nil, fmt.Errorf("list approvals for trace %s: %w", traceID, err)
+		}
+		approvals = append(approvals, traceApprovals...)
+	}
+	return events, approvals, nil
+}
+
+// sessionTraces returns the distinct trace IDs of events, in first-seen order.
+func sessionTraces(events []audit.Event) []string {
+	var traces []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if e.TraceID != "" && !seen[e.TraceID] {
+			seen[e.TraceID] = true
+			traces = append(traces, e.TraceID)
+		}
+	}
+	return traces
+}
+
+// ── Reconstruction ────────────────────────────────────────────────────────────
+
+// Replay step kinds.
+const (
+	stepQuery     = "query"
+	stepRouting   = "routing"
+	stepReasoning = "reasoning"
+	stepPolicy    = "policy"
+	stepTool      = "tool"
+	stepApproval  = "approval"
+	stepOutcome   = "outcome"
+	stepEvent     = "event"
+)
+
+// sessionReplay is a session reconstructed from the audit trail.
+type sessionReplay struct {
+	SessionID string
+	UserID    string
+	Traces    []string
+	Start     time.Time
+	End       time.Time
+	Steps     []replayStep
+}
+
+// replayStep is one entry of the replay timeline.
+type replayStep struct {
+	Time    time.Time
+	Kind    string
+	Actor   string // agent, user or approver behind the step
+	Summary string
+	Status  string // outcome or effect, e.g. "success", "error", "deny"
+	EventID string // audit event or approval the step was built from
+	Details []replayDetail
+}
+
+type replayDetail struct {
+	Label string
+	Value string
+}
+
+func (s *replayStep) add(label, value string) {
+	if value != "" {
+		s.Details = append(s.Details, replayDetail{Label: label, Value: value})
+	}
+}
+
+// buildReplay orders events and approvals into a chronological timeline.
+// tool_invoked events are left out: the policy decision and tool execution
+// that follow them carry the same information.
+func buildReplay(sessionID string, events []audit.Event, approvals []audit.StoredApproval) *sessionReplay {
+	r := &sessionReplay{SessionID: sessionID, Traces: sessionTraces(events)}
+	for i := range events {
+		e := &events[i]
+		if r.UserID == "" {
+			r.UserID = eventUser(e)
+		}
+		if e.EventType == audit.EventTypeToolInvoked {
+			continue
+		}
+		r.Steps = append(r.Steps, eventStep(e))
+	}
+	for i := range approvals {
+		r.Steps = append(r.Steps, approvalSteps(&approvals[i])...)
+	}
+	sort.SliceStable(r.Steps, func(i, j int) bool { return r.Steps[i].Time.Before(r.Steps[j].Time) })
+	if n := len(r.Steps); n > 0 {
+		r.Start, r.End = r.Steps[0].Time, r.Steps[n-1].Time
+	}
+	return r
+}
+
+func eventUser(e *audit.Event) string {
+	if e.Principal != nil && e.Principal.EffectiveID() != "" {
+		return e.Principal.EffectiveID()
+	}
+	return e.Session.UserID
+}
+
+// eventStep renders one audit event as a replay step.
+func eventStep(e *audit.Event) replayStep {
+	step := replayStep{Time: e.Timestamp, EventID: e.EventID, Actor: e.Session.AgentName}
+	if e.Outcome != nil {
+		step.Status = e.Outcome.Status
+	}
+
+	switch e.EventType {
+	case audit.EventTypeGatewayRequest:
+		step.Kind = stepQuery
+		step.Actor = eventUser(e)
+		step.Summary = e.Input.UserQuery
+		if e.Decision != nil {
+			step.add("endpoint", e.Decision.UserIntent)
+			step.add("agent", e.Decision.Agent)
+		}
+		step.add("purpose", e.Purpose)
+		if step.Summary == "" && e.Tool != nil {
+			step.Summary = "direct tool call: " + e.Tool.Name
+		}
+		addTool(&step, e.Tool)
+		if e.Output != nil {
+			step.add("response", truncate(redactString(e.Output.Response), maxReplayOutput))
+		}
+		if e.Outcome != nil {
+			step.add("error", e.Outcome.ErrorMessage)
+		}
+
+	case audit.EventTypeDelegation:
+		step.Kind = stepRouting
+		step.Actor = eventUser(e)
+		d := e.Decision
+		if d == nil {
+			step.Summary = e.Input.UserQuery
+			break
+		}
+		step.Summary = fmt.Sprintf("routed to %s (%s, confidence %.2f)", d.Agent, d.RequestCategory, d.Confidence)
+		step.add("intent", d.UserIntent)
+		for i, reason := range d.ReasoningChain {
+			step.add(fmt.Sprintf("reasoning %d", i+1), reason)
+		}
+		for _, alt := range d.AlternativesConsidered {
+			step.add("rejected "+alt.Agent, alt.RejectedBecause)
+		}
+		if len(d.SimilarTraces) > 0 {
+			step.add("prior resolutions", strings.Join(d.SimilarTraces, ", "))
+		}
+		if q := d.ReasoningQuality; q != nil {
+			quality := fmt.Sprintf("%.2f", q.Score)
+			if len(q.Flags) > 0 {
+				quality += " (" + strings.Join(q.Flags, ", ") + ")"
+			}
+			step.add("reasoning quality", quality)
+		}
+
+	case audit.EventTypeAgentReasoning:
+		step.Kind = stepReasoning
+		if ar := e.AgentReasoning; ar != nil {
+			step.Summary = ar.Reasoning
+			step.add("next tools", strings.Join(ar.ToolCalls, ", "))
+		}
+
+	case audit.EventTypePolicyDecision:
+		step.Kind = stepPolicy
+		if pd := e.PolicyDecision; pd != nil {
+			step.Status = pd.Effect
+			step.Summary = fmt.Sprintf("%s on %s/%s", pd.Action, pd.ResourceType, pd.ResourceName)
+			step.add("policy", pd.PolicyName)
+			step.add("message", pd.Message)
+			step.add("explanation", pd.Explanation)
+			if pd.DryRun {
+				step.add("mode", "dry run")
+			}
+		}
+
+	case audit.EventTypeToolExecution:
+		step.Kind = stepTool
+		if e.Tool != nil {
+			if e.Tool.Agent != "" {
+				step.Actor = e.Tool.Agent
+			}
+			step.Summary = e.Tool.Name
+		}
+		addTool(&step, e.Tool)
+		if a := e.Approval; a != nil && a.ApprovalID != "" {
+			step.add("approval", a.ApprovalID)
+		}
+		if e.Outcome != nil && (e.Tool == nil || e.Tool.Error == "") {
+			step.add("error", e.Outcome.ErrorMessage)
+		}
+
+	case audit.EventTypeOutcome:
+		step.Kind = stepOutcome
+		step.Summary = "delegation " + step.Status
+		if e.Output != nil {
+			step.add("response", truncate(redactString(e.Output.Response), maxReplayOutput))
+		}
+		if e.Outcome != nil {
+			step.add("error", e.Outcome.ErrorMessage)
+			if p := e.Outcome.Phases; p != nil {
+				step.add("phases", fmt.Sprintf("queue %dms, llm %dms, tools %dms, approval %dms",
+					p.QueueMs, p.LLMMs, p.ToolsMs, p.ApprovalMs))
+			}
+		}
+
+	default:
+		step.Kind = stepEvent
+		step.Summary = string(e.EventType)
+		if e.Tool != nil {
+			step.Summary += ": " + e.Tool.Name
+		}
+		if e.Outcome != nil {
+			step.add("error", e.Outcome.ErrorMessage)
+		}
+		if sr := e.SecurityResponse; sr != nil {
+			step.add("playbook", fmt.Sprintf("%s step %d: %s", sr.Playbook, sr.Step, sr.Action))
+			step.add("detail", sr.Detail)
+		}
+		if rb := e.RollbackExecution; rb != nil {
+			step.add("rollback", rb.RollbackID+" of "+rb.OriginalEventID)
+		}
+	}
+	return step
+}
+
+// addTool adds a tool call's redacted parameters, command and output.
+func addTool(step *replayStep, t *audit.ToolExecution) {
+	if t == nil {
+		return
+	}
+	if len(t.Parameters) > 0 {
+		b, _ := json.Marshal(redactParams(t.Parameters))
+		step.add("parameters", string(b))
+	}
+	step.add("command", redactString(t.RawCommand))
+	if len(t.Argv) > 0 {
+		argv := make([]string, len(t.Argv))
+		for i, a := range t.Argv {
+			argv[i] = redactString(a)
+		}
+		step.add("argv", strings.Join(argv, " "))
+	}
+	step.add("result", truncate(redactString(t.Result), maxReplayOutput))
+	step.add("error", redactString(t.Error))
+	if t.RowsAffected > 0 {
+		step.add("rows affected", strconv.Itoa(t.RowsAffected))
+	}
+	if t.Duration > 0 {
+		step.add("duration", t.Duration.Round(time.Millisecond).String())
+	}
+}
+
+// approvalSteps renders an approval as a request step and, once resolved,
+// a resolution step.
+func approvalSteps(a *audit.StoredApproval) []replayStep {
+	req := replayStep{
+		Time:    a.RequestedAt,
+		Kind:    stepApproval,
+		Actor:   a.RequestedBy,
+		EventID: a.ApprovalID,
+		Status:  "pending",
+		Summary: fmt.Sprintf("approval requested: %s %s on %s/%s", a.ActionClass, a.ToolName, a.ResourceType, a.ResourceName),
+	}
+	req.add("policy", a.PolicyName)
+	req.add("approver role", a.ApproverRole)
+	if plan := a.ExecutionPlan(); plan != nil {
+		req.add("plan", strings.TrimSpace(plan.Format("")))
+	}
+	steps := []replayStep{req}
+	if a.ResolvedAt.IsZero() {
+		return steps
+	}
+	res := replayStep{
+		Time:    a.ResolvedAt,
+		Kind:    stepApproval,
+		Actor:   a.ResolvedBy,
+		EventID: a.ApprovalID,
+		Status:  a.Status,
+		Summary: fmt.Sprintf("approval %s %s", a.ApprovalID, a.Status),
+	}
+	res.add("reason", a.ResolutionReason)
+	res.add("waited", a.ResolvedAt.Sub(a.RequestedAt).Round(time.Second).String())
+	return append(steps, res)
+}
+
+// ── Redaction ─────────────────────────────────────────────────────────────────
+
+// Parameter names whose values are never shown, and the connection-string
+// password forms the agent command sandbox masks in argv.
+var (
+	sensitiveKeyRe = regexp.MustCompile(`(?i)pass(word|wd)?|secret|token|api[_-]?key|credential|private[_-]?key`)
+	urlPasswordRe  = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+	kvPasswordRe   = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+)
+
+const redacted = "***"
+
+// redactParams returns a copy of params with sensitive values masked.
+func redactParams(params map[string]any) map[string]any {
+	out := make(map[string]any, len(params))
+	for k, v := range params {
+		if sensitiveKeyRe.MatchString(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return redactString(v)
+	case map[string]any:
+		return redactParams(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// redactString masks passwords embedded in connection strings.
+func redactString(s string) string {
+	s = urlPasswordRe.ReplaceAllString(s, "${1}"+redacted+"@")
+	return kvPasswordRe.ReplaceAllString(s, "${1}"+redacted)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "… (truncated)"
+}
+
+// ── Rendering ─────────────────────────────────────────────────────────────────
+
+// renderReplayText writes the replay as an indented chronological log.
+func renderReplayText(w io.Writer, r *sessionReplay) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session %s\n", r.SessionID)
+	if r.UserID != "" {
+		fmt.Fprintf(&b, "User:    %s\n", r.UserID)
+	}
+	if len(r.Traces) > 0 {
+		fmt.Fprintf(&b, "Traces:  %s\n", strings.Join(r.Traces, ", "))
+	}
+	if !r.Start.IsZero() {
+		fmt.Fprintf(&b, "Span:    %s → %s (%s)\n", r.Start.UTC().Format(time.RFC3339), r.End.UTC().Format(time.RFC3339),
+			r.End.Sub(r.Start).Round(time.Second))
+	}
+	fmt.Fprintf(&b, "Steps:   %d\n\n", len(r.Steps))
+
+	for _, s := range r.Steps {
+		head := fmt.Sprintf("%s  %-9s", s.Time.UTC().Format("15:04:05.000"), strings.ToUpper(s.Kind))
+		if s.Actor != "" {
+			head += "  [" + s.Actor + "]"
+		}
+		fmt.Fprintf(&b, "%s  %s", head, firstLine(s.Summary))
+		if s.Status != "" {
+			fmt.Fprintf(&b, "  (%s)", s.Status)
+		}
+		b.WriteString("\n")
+		if rest := restLines(s.Summary); rest != "" {
+			writeIndented(&b, rest, "                 ")
+		}
+		for _, d := range s.Details {
+			writeIndented(&b, d.Label+": "+d.Value, "                 ")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return first
+}
+
+func restLines(s string) string {
+	_, rest, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(rest)
+}
+
+func writeIndented(b *strings.Builder, s, indent string) {
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString(indent + line + "\n")
+	}
+}
+
+var replayHTML = template.Must(template.New("replay").Funcs(template.FuncMap{
+	"ts": func(t time.Time) string { return t.UTC().Format("15:04:05.000") },
+	"rfc3339": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Session replay — {{.SessionID}}</title>
+<style>
+body{font-family:sans-serif;margin:2em;max-width:1100px}
+.meta td{padding:2px 12px 2px 0}
+.step{border-left:4px solid #90a4ae;margin:10px 0;padding:4px 12px}
+.step.query{border-color:#1565c0}.step.routing,.step.reasoning{border-color:#6a1b9a}
+.step.policy{border-color:#ef6c00}.step.tool{border-color:#2e7d32}
+.step.approval{border-color:#f9a825}.step.outcome{border-color:#00838f}
+.step.error,.step.deny,.step.denied{background:#fdecea}
+.head{color:#555;font-size:90%}.kind{font-weight:bold;text-transform:uppercase}
+.summary{white-space:pre-wrap;margin:4px 0}
+dl{margin:4px 0;display:grid;grid-template-columns:max-content auto;gap:2px 12px;font-size:90%}
+dt{color:#555}dd{margin:0;white-space:pre-wrap;font-family:monospace}
+</style></head><body>
+<h2>Session replay — {{.SessionID}}</h2>
+<table class="meta">
+{{if .UserID}}<tr><td>User</td><td>{{.UserID}}</td></tr>{{end}}
+{{if .Traces}}<tr><td>Traces</td><td>{{range $i, $t := .Traces}}{{if $i}}, {{end}}{{$t}}{{end}}</td></tr>{{end}}
+<tr><td>Span</td><td>{{rfc3339 .Start}} → {{rfc3339 .End}}</td></tr>
+<tr><td>Steps</td><td>{{len .Steps}}</td></tr>
+</table>
+{{range .Steps}}<div class="step {{.Kind}} {{.Status}}">
+<div class="head">{{ts .Time}} · <span class="kind">{{.Kind}}</span>{{if .Actor}} · {{.Actor}}{{end}}{{if .Status}} · {{.Status}}{{end}}{{if .EventID}} · {{.EventID}}{{end}}</div>
+<div class="summary">{{.Summary}}</div>
+{{if .Details}}<dl>{{range .Details}}<dt>{{.Label}}</dt><dd>{{.Value}}</dd>{{end}}</dl>{{end}}
+</div>
+{{end}}</body></html>
+`))
+
+// renderReplayHTML writes the replay as a standalone HTML page.
+func renderReplayHTML(w io.Writer, r *sessionReplay) error {
+	return replayHTML.Execute(w, r)
+}
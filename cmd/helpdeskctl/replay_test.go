@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+var replayT0 = time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+func replayFixture() ([]audit.Event, []audit.StoredApproval) {
+	at := func(sec int) time.Time { return replayT0.Add(time.Duration(sec) * time.Second) }
+	events := []audit.Event{
+		{
+			EventID: "tool_1", EventType: audit.EventTypeToolExecution, Timestamp: at(30), TraceID: "tr_1",
+			Session: audit.Session{ID: "db_sess", AgentName: "postgres_database_agent"},
+			Tool: &audit.ToolExecution{
+				Name: "terminate_connection", Agent: "postgres_database_agent",
+				Parameters: map[string]any{
+					"connection_string": "postgres://app:hunter2@db:5432/prod",
+					"password":          "hunter2",
+					"pid":               float64(4242),
+				},
+				Result: "terminated pid 4242",
+			},
+			Outcome:  &audit.Outcome{Status: "success"},
+			Approval: &audit.Approval{Required: true, Status: audit.ApprovalApproved, ApprovalID: "apr_1"},
+		},
+		{
+			EventID: "gw_1", EventType: audit.EventTypeGatewayRequest, Timestamp: at(0), TraceID: "tr_1",
+			Session:  audit.Session{ID: "sess_abc", UserID: "alice"},
+			Input:    audit.Input{UserQuery: "kill the stuck query on prod"},
+			Decision: &audit.Decision{Agent: "database", UserIntent: "/api/v1/query"},
+			Output:   &audit.Output{Response: "Done: pid 4242 terminated"},
+			Outcome:  &audit.Outcome{Status: "success"},
+		},
+		{
+			EventID: "rt_1", EventType: audit.EventTypeDelegation, Timestamp: at(1), TraceID: "tr_1",
+			Session: audit.Session{ID: "sess_abc"},
+			Decision: &audit.Decision{
+				Agent: "postgres_database_agent", RequestCategory: audit.CategoryDatabase, Confidence: 0.9,
+				ReasoningChain: []string{"user mentions a stuck query", "prod is a postgres server"},
+			},
+		},
+		{
+			EventID: "inv_1", EventType: audit.EventTypeToolInvoked, Timestamp: at(2), TraceID: "tr_1",
+			Session: audit.Session{ID: "db_sess"},
+		},
+		{
+			EventID: "pol_1", EventType: audit.EventTypePolicyDecision, Timestamp: at(3), TraceID: "tr_1",
+			Session: audit.Session{ID: "db_sess"},
+			PolicyDecision: &audit.PolicyDecision{
+				ResourceType: "database", ResourceName: "prod", Action: "destructive",
+				Effect: "require_approval", PolicyName: "prod-guard",
+			},
+		},
+	}
+	approvals := []audit.StoredApproval{{
+		ApprovalID: "apr_1", TraceID: "tr_1", Status: "approved",
+		ActionClass: "destructive", ToolName: "terminate_connection",
+		ResourceType: "database", ResourceName: "prod",
+		RequestedBy: "alice", RequestedAt: at(4),
+		ResolvedBy: "bob", ResolvedAt: at(20), ResolutionReason: "confirmed with app team",
+	}}
+	return events, approvals
+}
+
+func TestBuildReplay_ChronologicalAndRedacted(t *testing.T) {
+	events, approvals := replayFixture()
+	r := buildReplay("sess_abc", events, approvals)
+
+	var kinds []string
+	for _, s := range r.Steps {
+		kinds = append(kinds, s.Kind)
+	}
+	want := []string{stepQuery, stepRouting, stepPolicy, stepApproval, stepApproval, stepTool}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("steps = %v, want %v", kinds, want)
+	}
+	if r.UserID != "alice" || len(r.Traces) != 1 || r.Traces[0] != "tr_1" {
+		t.Errorf("user=%q traces=%v", r.UserID, r.Traces)
+	}
+	if got := r.End.Sub(r.Start); got != 30*time.Second {
+		t.Errorf("span = %s, want 30s", got)
+	}
+
+	tool := r.Steps[5]
+	if tool.Actor != "postgres_database_agent" || tool.Summary != "terminate_connection" {
+		t.Errorf("tool step = %+v", tool)
+	}
+	var params string
+	for _, d := range tool.Details {
+		if d.Label == "parameters" {
+			params = d.Value
+		}
+	}
+	if strings.Contains(params, "hunter2") {
+		t.Errorf("parameters not redacted: %s", params)
+	}
+	if !strings.Contains(params, "postgres://app:***@db") || !strings.Contains(params, `"pid":4242`) {
+		t.Errorf("parameters = %s", params)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	cases := map[string]string{
+		"psql postgres://u:s3cret@h/db":       "psql postgres://u:***@h/db",
+		"host=db user=u password=s3cret":      "host=db user=u password=***",
+		"host=db password='two words' port=5": "host=db password=*** port=5",
+		"SELECT 1":                            "SELECT 1",
+	}
+	for in, want := range cases {
+		if got := redactString(in); got != want {
+			t.Errorf("redactString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenderReplay(t *testing.T) {
+	events, approvals := replayFixture()
+	r := buildReplay("sess_abc", events, approvals)
+
+	var text bytes.Buffer
+	if err := renderReplayText(&text, r); err != nil {
+		t.Fatalf("renderReplayText: %v", err)
+	}
+	for _, want := range []string{
+		"Session sess_abc", "QUERY", "[alice]  kill the stuck query on prod",
+		"reasoning 1: user mentions a stuck query", "approval apr_1 approved", "waited: 16s",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text replay missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var page bytes.Buffer
+	if err := renderReplayHTML(&page, r); err != nil {
+		t.Fatalf("renderReplayHTML: %v", err)
+	}
+	if !strings.Contains(page.String(), `class="step tool success"`) || strings.Contains(page.String(), "hunter2") {
+		t.Errorf("HTML replay missing tool step or leaks a secret")
+	}
+}
+
+func TestLoadSession_FollowsTraces(t *testing.T) {
+	events, approvals := replayFixture()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		var out any
+		switch r.URL.Path {
+		case "/v1/events":
+			var matched []audit.Event
+			for _, e := range events {
+				if (q.Get("session_id") != "" && e.Session.ID == q.Get("session_id")) ||
+					(q.Get("trace_id") != "" && e.TraceID == q.Get("trace_id")) {
+					matched = append(matched, e)
+				}
+			}
+			out = matched
+		case "/v1/approvals":
+			out = approvals
+		}
+		json.NewEncoder(w).Encode(out) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	src := newAuditSource(srv.URL, "k")
+	got, gotApprovals, err := loadSession(context.Background(), src, "sess_abc", 100)
+	if err != nil {
+		t.Fatalf("loadSession: %v", err)
+	}
+	// The two sess_abc events pull in the sub-agent's events through tr_1.
+	if len(got) != len(events) || len(gotApprovals) != 1 {
+		t.Errorf("events=%d approvals=%d, want %d and 1", len(got), len(gotApprovals), len(events))
+	}
+
+	if _, _, err := loadSession(context.Background(), src, "sess_missing", 100); err == nil {
+		t.Error("expected an error for a session without events")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+// routeSimulation is the gateway's POST /api/v1/route response.
+type routeSimulation struct {
+	TraceID          string                  `json:"trace_id"`
+	EventID          string                  `json:"event_id"`
+	Query            string                  `json:"query"`
+	AvailableAgents  []string                `json:"available_agents"`
+	Decision         *audit.Decision         `json:"decision"`
+	ReasoningQuality *audit.ReasoningQuality `json:"reasoning_quality"`
+	DurationMs       int64                   `json:"duration_ms"`
+}
+
+// cmdRoute implements "helpdeskctl route". It asks the gateway which agent
+// it would route a query to, without delegating the query or running any
+// tool, and returns the exit code: 0 on success, 1 on error, 2 when --expect
+// names a different agent than the one chosen.
+func cmdRoute(args []string) int {
+	fs := flag.NewFlagSet("route", flag.ExitOnError)
+	query := fs.String("query", "", "Natural-language query to route (required)")
+	gatewayURL := fs.String("gateway", envOrDefault("HELPDESK_GATEWAY_URL", "http://localhost:8080"), "Gateway URL (or set HELPDESK_GATEWAY_URL)")
+	apiKey := fs.String("api-key", os.Getenv("HELPDESK_CLIENT_API_KEY"), "Bearer token for gateway authentication (or set HELPDESK_CLIENT_API_KEY)")
+	user := fs.String("user", os.Getenv("HELPDESK_CLIENT_USER"), "User ID recorded with the simulation (or set HELPDESK_CLIENT_USER)")
+	expect := fs.String("expect", "", "Exit with code 2 unless the query is routed to this agent (internal name, e.g. postgres_database_agent)")
+	var output cliout.Format
+	cliout.Register(fs, &output)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *query == "" {
+		fmt.Fprintln(os.Stderr, `usage: helpdeskctl route --query "..." [--expect agent] [-o table|json|yaml]`)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	sim, body, err := simulateRoute(ctx, &http.Client{}, *gatewayURL, *apiKey, *user, *query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if output.Structured() {
+		err = cliout.WriteRaw(os.Stdout, output, body)
+	} else {
+		err = renderRoute(os.Stdout, sim)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *expect != "" && sim.Decision.Agent != *expect {
+		fmt.Fprintf(os.Stderr, "routed to %s, expected %s\n", sim.Decision.Agent, *expect)
+		return 2
+	}
+	return 0
+}
+
+// simulateRoute calls POST /api/v1/route and returns the decoded response
+// together with its raw body.
+func simulateRoute(ctx context.Context, client *http.Client, gatewayURL, apiKey, user, query string) (*routeSimulation, []byte, error) {
+	payload, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return nil, nil, err
+	}
+	url := strings.TrimRight(gatewayURL, "/") + "/api/v1/route"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("POST /api/v1/route: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read routing simulation: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("POST /api/v1/route: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var sim routeSimulation
+	if err := json.Unmarshal(body, &sim); err != nil {
+		return nil, nil, fmt.Errorf("decode routing simulation: %w", err)
+	}
+	if sim.Decision == nil {
+		return nil, nil, fmt.Errorf("gateway returned no routing decision")
+	}
+	return &sim, body, nil
+}
+
+// renderRoute prints a routing simulation for people.
+func renderRoute(w io.Writer, sim *routeSimulation) error {
+	var b strings.Builder
+	row := func(label, value string) {
+		fmt.Fprintf(&b, "%-12s %s\n", label+":", value)
+	}
+	d := sim.Decision
+	row("Query", sim.Query)
+	row("Agent", d.Agent)
+	row("Category", string(d.RequestCategory))
+	row("Confidence", fmt.Sprintf("%.2f", d.Confidence))
+	row("Intent", d.UserIntent)
+	for i, reason := range d.ReasoningChain {
+		label := ""
+		if i == 0 {
+			label = "Reasoning:"
+		}
+		fmt.Fprintf(&b, "%-12s %d. %s\n", label, i+1, reason)
+	}
+	for i, alt := range d.AlternativesConsidered {
+		label := ""
+		if i == 0 {
+			label = "Rejected:"
+		}
+		fmt.Fprintf(&b, "%-12s %s — %s\n", label, alt.Agent, alt.RejectedBecause)
+	}
+	if q := sim.ReasoningQuality; q != nil {
+		quality := fmt.Sprintf("%.2f", q.Score)
+		if len(q.Flags) > 0 {
+			quality += " (" + strings.Join(q.Flags, ", ") + ")"
+		}
+		row("Quality", quality)
+	}
+	row("Offered", strings.Join(sim.AvailableAgents, ", "))
+	row("Took", (time.Duration(sim.DurationMs) * time.Millisecond).String())
+	if sim.EventID != "" {
+		row("Recorded", fmt.Sprintf("%s (trace %s)", sim.EventID, sim.TraceID))
+	} else {
+		row("Recorded", "no (auditing is not enabled on the gateway)")
+	}
+	b.WriteString("\nSimulation only: the query was not sent to the agent and no tools ran.\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const routeFixture = `{
+  "trace_id": "sim_1a2b3c4d",
+  "event_id": "sim_5e6f7a8b",
+  "query": "why is prod-db slow?",
+  "available_agents": ["k8s_agent", "postgres_database_agent"],
+  "decision": {
+    "agent": "postgres_database_agent",
+    "request_category": "database",
+    "confidence": 0.91,
+    "user_intent": "find the cause of slow queries on prod-db",
+    "reasoning_chain": ["prod-db is a PostgreSQL server", "slowness needs live pg_stat_activity data"],
+    "alternatives_considered": [{"agent": "k8s_agent", "rejected_because": "no pod or cluster mentioned"}]
+  },
+  "reasoning_quality": {"score": 0.55, "length": 0.4, "consistency": 1, "flags": ["short"]},
+  "duration_ms": 840
+}`
+
+func TestSimulateRoute(t *testing.T) {
+	var gotBody map[string]string
+	var gotAuth, gotUser string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/route" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth, gotUser = r.Header.Get("Authorization"), r.Header.Get("X-User")
+		json.NewDecoder(r.Body).Decode(&gotBody) //nolint:errcheck
+		w.Write([]byte(routeFixture))            //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	sim, raw, err := simulateRoute(context.Background(), srv.Client(), srv.URL+"/", "key", "alice", "why is prod-db slow?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotBody["query"] != "why is prod-db slow?" || gotAuth != "Bearer key" || gotUser != "alice" {
+		t.Errorf("request body = %v, Authorization = %q, X-User = %q", gotBody, gotAuth, gotUser)
+	}
+	if sim.Decision.Agent != "postgres_database_agent" || string(raw) != routeFixture {
+		t.Errorf("decision = %+v", sim.Decision)
+	}
+
+	var buf bytes.Buffer
+	if err := renderRoute(&buf, sim); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Agent:       postgres_database_agent\n",
+		"Confidence:  0.91\n",
+		"Reasoning:   1. prod-db is a PostgreSQL server\n             2. slowness",
+		"Rejected:    k8s_agent — no pod or cluster mentioned\n",
+		"Quality:     0.55 (short)\n",
+		"Recorded:    sim_5e6f7a8b (trace sim_1a2b3c4d)\n",
+		"no tools ran",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSimulateRoute_GatewayError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"agent routing failed: LLM routing not configured"}`, http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	_, _, err := simulateRoute(context.Background(), srv.Client(), srv.URL, "", "", "q")
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("err = %v, want the gateway's 503 and message", err)
+	}
+}
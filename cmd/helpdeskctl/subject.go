@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+const subjectUsage = `usage: helpdeskctl subject <command> [arguments]
+
+Commands:
+  erase --user ID --reason text   erase the free text of every event recorded
+                                  for a user and replace the user's identifiers
+  pseudonymize                    replace the user IDs of events stored before
+                                  pseudonymization was enabled
+
+Both rewrite stored events. Each rewrite is itself recorded as an
+event_redaction event whose tombstones keep the hash chain verifiable.`
+
+// cmdSubject implements "helpdeskctl subject": the GDPR-style handling of a
+// data subject's stored events.
+func cmdSubject(ctx context.Context, src *auditSource, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", subjectUsage)
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("subject "+cmd, flag.ExitOnError)
+	var output cliout.Format
+	cliout.Register(fs, &output)
+
+	switch cmd {
+	case "erase":
+		user := fs.String("user", "", "User ID whose events are erased (required)")
+		reason := fs.String("reason", "", "Why, e.g. the erasure request reference (required)")
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		if *user == "" || *reason == "" {
+			return fmt.Errorf("usage: helpdeskctl subject erase --user ID --reason text")
+		}
+		var out struct {
+			Subject      string            `json:"subject"`
+			ErasedEvents int               `json:"erased_events"`
+			Redactions   []audit.Redaction `json:"redactions"`
+		}
+		body, err := src.call(ctx, http.MethodPost, "/v1/subjects/erase",
+			map[string]string{"user_id": *user, "reason": *reason}, &out)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderErasure(os.Stdout, out.Subject, out.ErasedEvents, out.Redactions)
+
+	case "pseudonymize":
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		var out struct {
+			Events int `json:"pseudonymized_events"`
+		}
+		body, err := src.call(ctx, http.MethodPost, "/v1/subjects/pseudonymize", nil, &out)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		fmt.Printf("Pseudonymized the user IDs of %d stored events\n", out.Events)
+		return nil
+	}
+	return fmt.Errorf("unknown subject command %q\n%s", cmd, subjectUsage)
+}
+
+// renderErasure summarizes an erasure.
+func renderErasure(w io.Writer, subject string, events int, redactions []audit.Redaction) error {
+	if events == 0 {
+		_, err := fmt.Fprintln(w, "No events recorded for this user.")
+		return err
+	}
+	ids := make([]string, 0, len(redactions))
+	for _, red := range redactions {
+		ids = append(ids, red.RedactionID)
+	}
+	_, err := fmt.Fprintf(w, `Erased %d events
+  Now recorded as: %s
+  Redactions:      %s
+`, events, subject, strings.Join(ids, ", "))
+	return err
+}
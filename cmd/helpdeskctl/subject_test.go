@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestCmdSubject_Erase(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/subjects/erase" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)                                                 //nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]any{"subject": "[erased]", "erased_events": 3}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	src := newAuditSource(srv.URL, "")
+	if err := cmdSubject(context.Background(), src, []string{"erase", "--user", "alice", "--reason", "DSR-7", "-o", "json"}); err != nil {
+		t.Fatalf("erase: %v", err)
+	}
+	if got["user_id"] != "alice" || got["reason"] != "DSR-7" {
+		t.Errorf("request body = %v", got)
+	}
+	if err := cmdSubject(context.Background(), src, []string{"erase", "--user", "alice"}); err == nil {
+		t.Error("erase without --reason was accepted")
+	}
+}
+
+func TestRenderErasure(t *testing.T) {
+	var buf bytes.Buffer
+	renderErasure(&buf, "psn_1a2b3c4d_00", 2, []audit.Redaction{{RedactionID: "red_1"}}) //nolint:errcheck
+	if !strings.Contains(buf.String(), "Erased 2 events") || !strings.Contains(buf.String(), "red_1") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/cliout"
+)
+
+const subscriptionsUsage = `usage: helpdeskctl subscriptions <command> [arguments]
+
+Commands:
+  list [--owner user]              subscriptions in your tenant
+  create --frequency daily|weekly [--hour N] [filters] [delivery]
+  update <id> [--frequency ...] [--hour N] [filters] [delivery]
+  get <id>                         one subscription, with its last delivery
+  preview <id>                     the report it would deliver now
+  delete <id>
+
+Filters:  --agent name  --resource name-or-glob  --event-type t1,t2
+Delivery: --email a@x,b@y  --webhook URL`
+
+// cmdSubscriptions implements "helpdeskctl subscriptions": managing the
+// caller's scheduled report subscriptions in auditd.
+func cmdSubscriptions(ctx context.Context, src *auditSource, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", subscriptionsUsage)
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("subscriptions "+cmd, flag.ExitOnError)
+	var output cliout.Format
+	cliout.Register(fs, &output)
+
+	switch cmd {
+	case "list":
+		owner := fs.String("owner", "", "Only subscriptions owned by this user")
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		q := url.Values{}
+		if *owner != "" {
+			q.Set("owner", *owner)
+		}
+		var subs []audit.ReportSubscription
+		body, err := src.call(ctx, http.MethodGet, "/v1/report-subscriptions?"+q.Encode(), nil, &subs)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderSubscriptions(os.Stdout, subs)
+
+	case "create", "update":
+		var id string
+		if cmd == "update" {
+			if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+				return fmt.Errorf("usage: helpdeskctl subscriptions update <id> [flags]")
+			}
+			id, args = args[0], args[1:]
+		}
+		set := subscriptionFlags(fs)
+		fs.Parse(args) //nolint:errcheck // ExitOnError
+		var sub audit.ReportSubscription
+		method, path := http.MethodPost, "/v1/report-subscriptions"
+		if cmd == "update" {
+			method, path = http.MethodPut, "/v1/report-subscriptions/"+url.PathEscape(id)
+			if _, err := src.call(ctx, http.MethodGet, path, nil, &sub); err != nil {
+				return err
+			}
+		}
+		set(&sub)
+		var saved audit.ReportSubscription
+		body, err := src.call(ctx, method, path, sub, &saved)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderSubscription(os.Stdout, &saved)
+
+	case "get", "preview", "delete":
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("usage: helpdeskctl subscriptions %s <id>", cmd)
+		}
+		id := args[0]
+		fs.Parse(args[1:]) //nolint:errcheck // ExitOnError
+		path := "/v1/report-subscriptions/" + url.PathEscape(id)
+		switch cmd {
+		case "delete":
+			if _, err := src.call(ctx, http.MethodDelete, path, nil, nil); err != nil {
+				return err
+			}
+			fmt.Printf("Subscription %s deleted\n", id)
+			return nil
+		case "preview":
+			var rep audit.SubscriptionReport
+			body, err := src.call(ctx, http.MethodGet, path+"/preview", nil, &rep)
+			if err != nil {
+				return err
+			}
+			if output.Structured() {
+				return cliout.WriteRaw(os.Stdout, output, body)
+			}
+			return renderSubscriptionReport(os.Stdout, &rep)
+		}
+		var sub audit.ReportSubscription
+		body, err := src.call(ctx, http.MethodGet, path, nil, &sub)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return cliout.WriteRaw(os.Stdout, output, body)
+		}
+		return renderSubscription(os.Stdout, &sub)
+	}
+	return fmt.Errorf("unknown subscriptions command %q\n%s", cmd, subscriptionsUsage)
+}
+
+// subscriptionFlags registers the settable subscription fields on fs. The
+// returned function applies the flags given on the command line to a
+// subscription, leaving the others as they are, so update changes only what
+// was named.
+func subscriptionFlags(fs *flag.FlagSet) func(*audit.ReportSubscription) {
+	name := fs.String("name", "", "Name shown in the report's subject")
+	frequency := fs.String("frequency", "", "daily or weekly (weekly reports go out on Mondays)")
+	hour := fs.Int("hour", 0, "UTC hour of delivery, 0-23")
+	agent := fs.String("agent", "", "Only events credited to this agent (empty = all)")
+	resource := fs.String("resource", "", "Only events on this database, namespace or host; globs like prod-* allowed")
+	eventTypes := fs.String("event-type", "", "Comma-separated event types to include (empty = all)")
+	email := fs.String("email", "", "Comma-separated email recipients")
+	webhook := fs.String("webhook", "", "URL the report is POSTed to as JSON")
+	owner := fs.String("owner", "", "Owner, when auditd runs without authentication")
+	return func(sub *audit.ReportSubscription) {
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				sub.Name = *name
+			case "frequency":
+				sub.Frequency = *frequency
+			case "hour":
+				sub.Hour = *hour
+			case "agent":
+				sub.Agent = *agent
+			case "resource":
+				sub.Resource = *resource
+			case "event-type":
+				sub.EventTypes = splitList(*eventTypes)
+			case "email":
+				sub.Email = splitList(*email)
+			case "webhook":
+				sub.WebhookURL = *webhook
+			case "owner":
+				sub.Owner = *owner
+			}
+		})
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// call sends a JSON request to auditd and decodes the response into out
+// (when non-nil), returning the raw response body.
+func (s *auditSource) call(ctx context.Context, method, path string, in, out any) ([]byte, error) {
+	var reqBody io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	route := method + " " + strings.SplitN(path, "?", 2)[0]
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", route, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read response: %w", route, err)
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return nil, fmt.Errorf("%s: HTTP %d: %s", route, resp.StatusCode, e.Error)
+		}
+		return nil, fmt.Errorf("%s: HTTP %d: %s", route, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return nil, fmt.Errorf("%s: decode response: %w", route, err)
+		}
+	}
+	return body, nil
+}
+
+// renderSubscriptions prints one line per subscription.
+func renderSubscriptions(w io.Writer, subs []audit.ReportSubscription) error {
+	if len(subs) == 0 {
+		_, err := fmt.Fprintln(w, "No report subscriptions.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tOWNER\tSCHEDULE\tFILTERS\tDELIVERY\tNEXT RUN\tLAST ERROR")
+	for _, sub := range subs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			sub.SubscriptionID, dash(sub.Name), sub.Owner, subscriptionSchedule(&sub),
+			dash(subscriptionFilters(&sub)), subscriptionDelivery(&sub),
+			sub.NextRunAt.UTC().Format(time.RFC3339), dash(truncate(sub.LastError, 40)))
+	}
+	return tw.Flush()
+}
+
+// renderSubscription prints one subscription in full.
+func renderSubscription(w io.Writer, sub *audit.ReportSubscription) error {
+	lastSent := "never"
+	if sub.LastSentAt != nil {
+		lastSent = sub.LastSentAt.UTC().Format(time.RFC3339)
+	}
+	_, err := fmt.Fprintf(w, `Subscription %s
+  Name:      %s
+  Owner:     %s
+  Schedule:  %s
+  Filters:   %s
+  Delivery:  %s
+  Next run:  %s
+  Last sent: %s
+  Last error: %s
+`, sub.SubscriptionID, dash(sub.Name), sub.Owner, subscriptionSchedule(sub), dash(subscriptionFilters(sub)),
+		subscriptionDelivery(sub), sub.NextRunAt.UTC().Format(time.RFC3339), lastSent, dash(sub.LastError))
+	return err
+}
+
+// renderSubscriptionReport prints a report preview.
+func renderSubscriptionReport(w io.Writer, rep *audit.SubscriptionReport) error {
+	fmt.Fprintf(w, "Report preview for %s, %s to %s\n\n", rep.SubscriptionID, rep.Since, rep.Until)
+	fmt.Fprintf(w, "Events:            %d\n", rep.TotalEvents)
+	fmt.Fprintf(w, "Tool executions:   %d (%d failed, %d destructive)\n", rep.ToolExecutions, rep.ToolErrors, rep.Destructive)
+	fmt.Fprintf(w, "Policy decisions:  %d (%d denied, %d needed approval)\n", rep.PolicyDecisions, rep.Denied, rep.ApprovalsNeeded)
+	if len(rep.TopResources) > 0 {
+		fmt.Fprintln(w, "\nTop resources:")
+		for _, c := range rep.TopResources {
+			fmt.Fprintf(w, "  %-30s %d\n", c.Name, c.Count)
+		}
+	}
+	if rep.NotableTotal > 0 {
+		fmt.Fprintf(w, "\nNotable events (%d):\n", rep.NotableTotal)
+		for _, e := range rep.Notable {
+			fmt.Fprintf(w, "  %s  %s  %s\n", e.Timestamp.UTC().Format(time.RFC3339), e.EventID, e.Summary)
+		}
+	}
+	return nil
+}
+
+func subscriptionSchedule(sub *audit.ReportSubscription) string {
+	return fmt.Sprintf("%s %02d:00 UTC", sub.Frequency, sub.Hour)
+}
+
+func subscriptionFilters(sub *audit.ReportSubscription) string {
+	var parts []string
+	if sub.Agent != "" {
+		parts = append(parts, "agent="+sub.Agent)
+	}
+	if sub.Resource != "" {
+		parts = append(parts, "resource="+sub.Resource)
+	}
+	if len(sub.EventTypes) > 0 {
+		parts = append(parts, "types="+strings.Join(sub.EventTypes, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func subscriptionDelivery(sub *audit.ReportSubscription) string {
+	parts := append([]string{}, sub.Email...)
+	if sub.WebhookURL != "" {
+		parts = append(parts, sub.WebhookURL)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestCmdSubscriptions_UpdateChangesOnlyNamedFields(t *testing.T) {
+	stored := audit.ReportSubscription{
+		SubscriptionID: "rsub_1", Frequency: audit.ReportDaily, Hour: 8, Agent: "k8s_agent",
+		Email: []string{"alice@example.com"}, Owner: "alice",
+	}
+	var put audit.ReportSubscription
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/report-subscriptions/rsub_1" || r.Header.Get("Authorization") != "Bearer k" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(stored) //nolint:errcheck
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&put) //nolint:errcheck
+			json.NewEncoder(w).Encode(put)       //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+
+	src := newAuditSource(srv.URL, "k")
+	err := cmdSubscriptions(context.Background(), src, []string{"update", "rsub_1", "--frequency", "weekly", "--event-type", "policy_decision, tool_execution", "-o", "json"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if put.Frequency != audit.ReportWeekly || put.Hour != 8 || put.Agent != "k8s_agent" ||
+		len(put.Email) != 1 || strings.Join(put.EventTypes, ",") != "policy_decision,tool_execution" {
+		t.Errorf("PUT body = %+v", put)
+	}
+}
+
+func TestAuditSourceCall_ReportsServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"only the subscription's owner or an admin may change it"}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	_, err := newAuditSource(srv.URL, "").call(context.Background(), http.MethodDelete, "/v1/report-subscriptions/rsub_1", nil, nil)
+	if err == nil || err.Error() != "DELETE /v1/report-subscriptions/rsub_1: HTTP 403: only the subscription's owner or an admin may change it" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helpdesk/internal/cliout"
+	"helpdesk/internal/infra"
+	"helpdesk/internal/policy"
+)
+
+// Checks reported by validate.
+const (
+	checkSchema            = "schema"             // a file does not parse or violates the schema
+	checkUnreachableRule   = "unreachable_rule"   // a policy rule never decides a request
+	checkUnknownResource   = "unknown_resource"   // a policy names resources infra does not define
+	checkUncoveredResource = "uncovered_resource" // an infra resource no policy applies to
+)
+
+// finding is one problem validate found.
+type finding struct {
+	File    string `json:"file"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// validateReport is the result of "helpdeskctl validate".
+type validateReport struct {
+	Files    []string  `json:"files"`
+	Valid    bool      `json:"valid"`
+	Findings []finding `json:"findings"`
+}
+
+// cmdValidate implements "helpdeskctl validate". It works offline and
+// returns the exit code: 0 when the files are valid, 1 on a usage error or
+// an unreadable file, 2 when problems were found.
+func cmdValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	policyFile := fs.String("policy", "", "Policy file to validate (YAML)")
+	infraFile := fs.String("infra", "", "Infrastructure config to validate (JSON); with --policy, the two are cross-checked")
+	var output cliout.Format
+	cliout.Register(fs, &output)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *policyFile == "" && *infraFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: helpdeskctl validate [--policy policies.yaml] [--infra infra.json] [-o table|json|yaml]")
+		return 1
+	}
+
+	var policyData, infraData []byte
+	var err error
+	if *policyFile != "" {
+		if policyData, err = os.ReadFile(*policyFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read policy file: %v\n", err)
+			return 1
+		}
+	}
+	if *infraFile != "" {
+		if infraData, err = os.ReadFile(*infraFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read infrastructure config: %v\n", err)
+			return 1
+		}
+	}
+	report := validateFiles(*policyFile, policyData, *infraFile, infraData)
+
+	if output.Structured() {
+		err = cliout.Write(os.Stdout, output, report)
+	} else {
+		err = renderValidate(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !report.Valid {
+		return 2
+	}
+	return 0
+}
+
+// validateFiles checks the policy and infra files given (a name of "" skips
+// that file) and, when both parse, cross-checks them.
+func validateFiles(policyName string, policyData []byte, infraName string, infraData []byte) validateReport {
+	report := validateReport{Findings: []finding{}}
+	add := func(file, check, format string, args ...any) {
+		report.Findings = append(report.Findings, finding{File: file, Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var cfg *policy.Config
+	if policyName != "" {
+		report.Files = append(report.Files, policyName)
+		var err error
+		if cfg, err = policy.LoadStrict(policyData); err != nil {
+			add(policyName, checkSchema, "%v", err)
+		} else {
+			for _, issue := range policy.Lint(cfg) {
+				add(policyName, checkUnreachableRule, "%s", issue)
+			}
+		}
+	}
+
+	var ic *infra.Config
+	if infraName != "" {
+		report.Files = append(report.Files, infraName)
+		var err error
+		if ic, err = infra.Parse(infraData); err != nil {
+			add(infraName, checkSchema, "%v", err)
+		} else {
+			for _, msg := range infraReferenceProblems(ic) {
+				add(infraName, checkSchema, "%s", msg)
+			}
+		}
+	}
+
+	if cfg != nil && ic != nil {
+		inv := newInventory(ic)
+		for _, msg := range inv.unknownResources(cfg) {
+			add(policyName, checkUnknownResource, "%s (not in %s)", msg, filepath.Base(infraName))
+		}
+		for _, msg := range inv.uncoveredResources(cfg) {
+			add(infraName, checkUncoveredResource, "%s", msg)
+		}
+	}
+
+	report.Valid = len(report.Findings) == 0
+	return report
+}
+
+// infraReferenceProblems reports databases hosted on clusters or VMs the
+// config does not define, and resources owned by teams it does not define.
+func infraReferenceProblems(ic *infra.Config) []string {
+	var out []string
+	team := func(where, name string) {
+		if name == "" {
+			return
+		}
+		if _, ok := ic.Teams[name]; !ok {
+			out = append(out, fmt.Sprintf("%s: team %q is not defined in teams", where, name))
+		}
+	}
+	for _, id := range sortedKeys(ic.DBServers) {
+		db := ic.DBServers[id]
+		team("db_servers."+id, db.Team)
+		if db.K8sCluster != "" {
+			if _, ok := ic.K8sClusters[db.K8sCluster]; !ok {
+				out = append(out, fmt.Sprintf("db_servers.%s: k8s_cluster %q is not defined in k8s_clusters", id, db.K8sCluster))
+			}
+		}
+		if db.VMName != "" {
+			if _, ok := ic.VMs[db.VMName]; !ok {
+				out = append(out, fmt.Sprintf("db_servers.%s: vm_name %q is not defined in vms", id, db.VMName))
+			}
+		}
+	}
+	for _, id := range sortedKeys(ic.K8sClusters) {
+		team("k8s_clusters."+id, ic.K8sClusters[id].Team)
+	}
+	for _, id := range sortedKeys(ic.VMs) {
+		team("vms."+id, ic.VMs[id].Team)
+	}
+	return out
+}
+
+// inventoryResource is a resource the agents check policy against, named as
+// they name it: databases by their name, hosts by their db_servers ID and
+// Kubernetes resources by namespace.
+type inventoryResource struct {
+	Type        string
+	Name        string
+	Source      string // where infra defines it, e.g. db_servers.prod-db
+	Tags        []string
+	Sensitivity []string
+	AnyName     bool // a cluster without a namespace allowlist: any namespace
+}
+
+// inventory is the policy-relevant view of an infra config.
+type inventory struct {
+	resources []inventoryResource
+}
+
+func newInventory(ic *infra.Config) inventory {
+	var inv inventory
+	clusterNamespaces := make(map[string][]string)
+	for _, id := range sortedKeys(ic.DBServers) {
+		db := ic.DBServers[id]
+		source := "db_servers." + id
+		tags := ic.DatabaseTags(db)
+		inv.resources = append(inv.resources,
+			inventoryResource{Type: "database", Name: db.Name, Source: source, Tags: tags, Sensitivity: db.Sensitivity},
+			inventoryResource{Type: "host", Name: id, Source: source, Tags: tags, Sensitivity: db.Sensitivity})
+		if db.K8sCluster != "" {
+			ns := db.K8sNamespace
+			if ns == "" {
+				ns = "default"
+			}
+			clusterNamespaces[db.K8sCluster] = append(clusterNamespaces[db.K8sCluster], ns)
+		}
+	}
+	for _, id := range sortedKeys(ic.K8sClusters) {
+		cluster := ic.K8sClusters[id]
+		source := "k8s_clusters." + id
+		if len(cluster.Namespaces) == 0 {
+			inv.resources = append(inv.resources, inventoryResource{Type: "kubernetes", Source: source,
+				Tags: cluster.Tags, Sensitivity: cluster.Sensitivity, AnyName: true})
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, ns := range sortedKeys(cluster.Namespaces) {
+			seen[ns] = true
+			inv.resources = append(inv.resources, inventoryResource{Type: "kubernetes", Name: ns, Source: source + ".namespaces." + ns,
+				Tags: cluster.NamespaceTags(ns), Sensitivity: cluster.Sensitivity})
+		}
+		for _, ns := range clusterNamespaces[id] {
+			if !seen[ns] {
+				seen[ns] = true
+				inv.resources = append(inv.resources, inventoryResource{Type: "kubernetes", Name: ns, Source: source,
+					Tags: cluster.Tags, Sensitivity: cluster.Sensitivity})
+			}
+		}
+	}
+	return inv
+}
+
+// inventoryTypes are the resource types whose resources infra defines.
+var inventoryTypes = map[string]bool{"database": true, "host": true, "kubernetes": true}
+
+// unknownResources reports the resource specs of cfg's policies that select
+// database, host or Kubernetes resources by name, namespace, tags or
+// sensitivity and match none that infra defines.
+func (inv inventory) unknownResources(cfg *policy.Config) []string {
+	var out []string
+	forEachPolicy(cfg, func(where string, p policy.Policy) {
+		for i, r := range p.Resources {
+			if !inventoryTypes[r.Type] || !selective(r.Match) {
+				continue
+			}
+			found := false
+			for _, res := range inv.resources {
+				if specMatches(r, res) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				out = append(out, fmt.Sprintf("%s resource %d (%s) matches no %s resource", where, i, describeSpec(r), r.Type))
+			}
+		}
+	})
+	return out
+}
+
+// uncoveredResources reports the infra resources no enabled policy applies
+// to, whatever the principal or tool: the engine's default effect decides
+// every request on them.
+func (inv inventory) uncoveredResources(cfg *policy.Config) []string {
+	var out []string
+	for _, res := range inv.resources {
+		covered := false
+		forEachPolicy(cfg, func(_ string, p policy.Policy) {
+			if !p.IsEnabled() {
+				return
+			}
+			for _, r := range p.Resources {
+				if specMatches(r, res) {
+					covered = true
+				}
+			}
+		})
+		if !covered {
+			name := res.Name
+			if res.AnyName {
+				name = "any namespace"
+			}
+			out = append(out, fmt.Sprintf("%s: %s %q is matched by no policy; the default effect decides every request on it", res.Source, res.Type, name))
+		}
+	}
+	return out
+}
+
+// forEachPolicy calls fn for the global policies and then each tenant's,
+// with a description of where the policy is defined.
+func forEachPolicy(cfg *policy.Config, fn func(where string, p policy.Policy)) {
+	for _, p := range cfg.Policies {
+		fn(fmt.Sprintf("policy %q", p.Name), p)
+	}
+	for _, tenant := range sortedKeys(cfg.Tenants) {
+		for _, p := range cfg.Tenants[tenant].Policies {
+			fn(fmt.Sprintf("tenant %q policy %q", tenant, p.Name), p)
+		}
+	}
+}
+
+// selective reports whether m selects resources by anything infra defines.
+func selective(m policy.ResourceMatch) bool {
+	return m.Name != "" || m.NamePattern != "" || m.Namespace != "" || len(m.Tags) > 0 || len(m.Sensitivity) > 0
+}
+
+// specMatches reports whether policy resource spec r can match res for some
+// tool. Kubernetes resources are checked by namespace, so a namespace
+// criterion is compared with the namespace name.
+func specMatches(r policy.Resource, res inventoryResource) bool {
+	m := r.Match
+	if r.Type != res.Type || !m.Tags.Matches(res.Tags) || !containsAll(res.Sensitivity, m.Sensitivity) {
+		return false
+	}
+	if res.AnyName {
+		return true
+	}
+	if m.Name != "" && m.Name != res.Name {
+		return false
+	}
+	if m.Namespace != "" && m.Namespace != res.Name {
+		return false
+	}
+	if m.NamePattern != "" {
+		if matched, _ := filepath.Match(m.NamePattern, res.Name); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// describeSpec summarises the criteria of a resource spec.
+func describeSpec(r policy.Resource) string {
+	m := r.Match
+	var parts []string
+	if m.Name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", m.Name))
+	}
+	if m.NamePattern != "" {
+		parts = append(parts, fmt.Sprintf("name_pattern %q", m.NamePattern))
+	}
+	if m.Namespace != "" {
+		parts = append(parts, fmt.Sprintf("namespace %q", m.Namespace))
+	}
+	if len(m.Tags) > 0 {
+		parts = append(parts, "tags ["+strings.Join(m.Tags, ", ")+"]")
+	}
+	if len(m.Sensitivity) > 0 {
+		parts = append(parts, "sensitivity ["+strings.Join(m.Sensitivity, ", ")+"]")
+	}
+	return r.Type + " " + strings.Join(parts, ", ")
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderValidate prints a validation report for people.
+func renderValidate(w io.Writer, report validateReport) error {
+	var b strings.Builder
+	for _, f := range report.Findings {
+		fmt.Fprintf(&b, "%s: [%s] %s\n", f.File, f.Check, f.Message)
+	}
+	if report.Valid {
+		fmt.Fprintf(&b, "OK: %s\n", strings.Join(report.Files, ", "))
+	} else {
+		fmt.Fprintf(&b, "\n%d problem(s) found in %s\n", len(report.Findings), strings.Join(report.Files, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
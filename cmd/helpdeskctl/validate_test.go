@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const validatePolicy = `
+version: "1"
+policies:
+  - name: prod-guard
+    priority: 100
+    resources:
+      - type: database
+        match:
+          tags: [production]
+    rules:
+      - action: [read, write]
+        effect: allow
+      - action: destructive
+        effect: deny
+  - name: orders-write
+    priority: 50
+    resources:
+      - type: database
+        match:
+          name: Orders
+          tags: [production]
+    rules:
+      - action: write
+        effect: require_approval
+  - name: billing
+    resources:
+      - type: database
+        match:
+          name_pattern: "Billing*"
+      - type: kubernetes
+        match:
+          namespace: payments
+      - type: fleet_job
+        match:
+          name: nightly
+    rules:
+      - action: read
+        effect: allow
+`
+
+const validateInfra = `{
+  "db_servers": {
+    "orders-db": {"name": "Orders", "connection_string": "host=orders", "tags": ["production"], "k8s_cluster": "prod", "k8s_namespace": "orders"},
+    "reports-db": {"name": "Reports", "connection_string": "host=reports", "vm_name": "reports-vm"}
+  },
+  "k8s_clusters": {
+    "prod": {"name": "Prod", "context": "prod", "tags": ["production"], "namespaces": {"web": {}}, "team": "platform"}
+  }
+}`
+
+func TestValidateFiles(t *testing.T) {
+	report := validateFiles("policies.yaml", []byte(validatePolicy), "infra.json", []byte(validateInfra))
+	if report.Valid {
+		t.Fatal("report is valid, want problems")
+	}
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, f.File+" "+f.Check+" "+f.Message)
+	}
+	want := []string{
+		`policies.yaml unreachable_rule policy "orders-write" rule 0: unreachable: every write request it matches is decided first by policy "prod-guard" rule 0`,
+		`infra.json schema db_servers.reports-db: vm_name "reports-vm" is not defined in vms`,
+		`infra.json schema k8s_clusters.prod: team "platform" is not defined in teams`,
+		`policies.yaml unknown_resource policy "billing" resource 0 (database name_pattern "Billing*") matches no database resource (not in infra.json)`,
+		`policies.yaml unknown_resource policy "billing" resource 1 (kubernetes namespace "payments") matches no kubernetes resource (not in infra.json)`,
+		`infra.json uncovered_resource db_servers.orders-db: host "orders-db" is matched by no policy; the default effect decides every request on it`,
+		`infra.json uncovered_resource db_servers.reports-db: database "Reports" is matched by no policy; the default effect decides every request on it`,
+		`infra.json uncovered_resource db_servers.reports-db: host "reports-db" is matched by no policy; the default effect decides every request on it`,
+		`infra.json uncovered_resource k8s_clusters.prod.namespaces.web: kubernetes "web" is matched by no policy; the default effect decides every request on it`,
+		`infra.json uncovered_resource k8s_clusters.prod: kubernetes "orders" is matched by no policy; the default effect decides every request on it`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var buf bytes.Buffer
+	if err := renderValidate(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n10 problem(s) found in policies.yaml, infra.json\n") {
+		t.Errorf("rendered:\n%s", buf.String())
+	}
+}
+
+func TestValidateFiles_Schema(t *testing.T) {
+	report := validateFiles("policies.yaml", []byte("policies:\n  - name: p\n    resources: [{type: database}]\n    rules: [{action: read, effect: allow, mesage: hi}]\n"), "", nil)
+	if report.Valid || len(report.Findings) != 1 || report.Findings[0].Check != checkSchema ||
+		!strings.Contains(report.Findings[0].Message, "mesage") {
+		t.Errorf("findings = %+v, want the unknown field", report.Findings)
+	}
+
+	report = validateFiles("", nil, "infra.json", []byte(`{"db_servers": {"a": {"name": "A", "connection_string": "host=a"}}}`))
+	if !report.Valid || len(report.Findings) != 0 {
+		t.Errorf("findings = %+v, want none for infra alone", report.Findings)
+	}
+	var buf bytes.Buffer
+	if err := renderValidate(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "OK: infra.json\n" {
+		t.Errorf("rendered %q", buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+// Command inventory reconciles the infrastructure config with live state. It
+// checks, through the gateway's direct tool endpoints, that every database
+// accepts a connection and that every cluster responds and has the
+// namespaces the config names, and records each mismatch as an
+// inventory_drift audit event. Stale inventory is a common reason for policy
+// decisions falling through to policy_name=default: govbot reports the drift
+// next to its policy decision analysis.
+//
+// Flow:
+//
+//	infra config → inventory → gateway /api/v1/{db,k8s}/* → agents
+//	                         → auditd /v1/events (inventory_drift)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/infra"
+)
+
+func main() {
+	buildinfo.HandleVersionFlag()
+
+	gateway := flag.String("gateway", envOrDefault("HELPDESK_GATEWAY_URL", "http://localhost:8080"), "Gateway base URL")
+	apiKey := flag.String("api-key", os.Getenv("HELPDESK_CLIENT_API_KEY"), "Bearer token for gateway authentication")
+	infraConfig := flag.String("infra-config", os.Getenv("HELPDESK_INFRA_CONFIG"), "Infrastructure config to reconcile (required)")
+	auditURL := flag.String("audit-url", os.Getenv("HELPDESK_AUDIT_URL"), "Auditd service URL drift events are recorded to (e.g. http://auditd:1199); empty only reports drift")
+	auditAPIKey := flag.String("audit-api-key", os.Getenv("HELPDESK_AUDIT_API_KEY"), "Bearer token for auditd authentication")
+	interval := flag.Duration("interval", 0, "Reconcile every interval; 0 runs once and exits 2 when drift is found")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout of each check")
+	flag.Parse()
+
+	if *infraConfig == "" {
+		fmt.Fprintln(os.Stderr, "error: -infra-config is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := audit.InstallAuditToken(ctx, *auditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var store *audit.RemoteStore
+	if *auditURL != "" {
+		store = audit.NewRemoteStore(*auditURL).WithAPIKey(*auditAPIKey)
+	}
+	gw := &gatewayClient{baseURL: *gateway, apiKey: *apiKey, client: &http.Client{}}
+
+	for {
+		drift, err := reconcileOnce(ctx, *infraConfig, gw, store, *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			if *interval == 0 {
+				os.Exit(1)
+			}
+		}
+		if *interval == 0 {
+			if drift {
+				os.Exit(2)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// reconcileOnce loads the config, so that edits are picked up between runs,
+// reconciles it and records the drift. It reports whether drift was found.
+func reconcileOnce(ctx context.Context, path string, gw *gatewayClient, store *audit.RemoteStore, timeout time.Duration) (bool, error) {
+	cfg, err := infra.Load(path)
+	if err != nil {
+		return false, err
+	}
+	r := &reconciler{cfg: cfg, gateway: gw, timeout: timeout}
+	rep := r.run(ctx)
+	printReport(rep)
+
+	if store != nil {
+		for _, event := range driftEvents(rep, time.Now().UTC()) {
+			if err := store.Record(ctx, event); err != nil {
+				slog.Error("failed to record inventory drift event", "event_id", event.EventID, "err", err)
+			}
+		}
+	}
+	return len(rep.Drift) > 0, nil
+}
+
+// printReport prints a run's drift and the checks that could not be made.
+func printReport(rep *report) {
+	fmt.Printf("[%s] inventory reconciliation (trace %s): %d checked, %d drifted, %d failed\n",
+		time.Now().Format("15:04:05"), rep.TraceID, rep.Checked, len(rep.Drift), len(rep.Errors))
+	for _, d := range rep.Drift {
+		resource := d.ResourceType + "/" + d.ResourceName
+		if d.Problem == "namespace_missing" {
+			resource += " (cluster " + d.Cluster + ")"
+		}
+		fmt.Printf("  DRIFT  %-40s  %-17s  %s\n", resource, d.Problem, d.Detail)
+	}
+	for _, e := range rep.Errors {
+		fmt.Printf("  ERROR  %s\n", e)
+	}
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
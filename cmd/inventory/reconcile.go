@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+)
+
+// toolError is a tool call the agent ran and that failed: the database did
+// not accept a connection, the cluster did not answer. It is drift, unlike a
+// gateway or policy error, which says nothing about the resource.
+type toolError struct {
+	msg string
+}
+
+func (e *toolError) Error() string { return e.msg }
+
+// gatewayClient calls the gateway's direct tool endpoints, so that every
+// check is policy-checked and audited like any other tool call.
+type gatewayClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// callTool posts args to a direct tool endpoint and returns the tool's
+// structured data. A tool that ran and failed (HTTP 422) is a *toolError.
+func (g *gatewayClient) callTool(ctx context.Context, traceID, path string, args map[string]any) (json.RawMessage, error) {
+	body, _ := json.Marshal(args)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(g.baseURL, "/")+path+"?format=json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("POST %s: %w", path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Purpose", "diagnostic")
+	req.Header.Set("X-Trace-ID", traceID)
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("POST %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var out struct {
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}
+	json.Unmarshal(respBody, &out) //nolint:errcheck // a non-JSON body is reported below
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return out.Data, nil
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return nil, &toolError{msg: out.Error}
+	case out.Error != "":
+		return nil, fmt.Errorf("POST %s: HTTP %d: %s", path, resp.StatusCode, out.Error)
+	default:
+		return nil, fmt.Errorf("POST %s: HTTP %d: %s", path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+}
+
+// report is the outcome of one reconciliation run.
+type report struct {
+	TraceID string                 `json:"trace_id"`
+	Checked int                    `json:"checked"`
+	Drift   []audit.InventoryDrift `json:"drift"`
+	// Errors are checks that could not be made (gateway down, policy
+	// denial). They are not drift: nothing was learned about the resource.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// reconciler compares an infrastructure config with what is live.
+type reconciler struct {
+	cfg     *infra.Config
+	gateway *gatewayClient
+	timeout time.Duration // per check
+}
+
+// run checks every database and cluster in the config. Databases must
+// accept a connection; clusters must answer and have every namespace the
+// config names, in its allowlist or as the home of a database.
+func (r *reconciler) run(ctx context.Context) *report {
+	rep := &report{TraceID: audit.NewTraceIDWithPrefix("inv_"), Drift: []audit.InventoryDrift{}}
+
+	for _, name := range sortedKeys(r.cfg.DBServers) {
+		rep.Checked++
+		_, err := r.call(ctx, rep.TraceID, "/api/v1/db/check_connection", map[string]any{"connection_string": name})
+		if te, ok := err.(*toolError); ok {
+			rep.Drift = append(rep.Drift, audit.InventoryDrift{
+				ResourceType: "database", ResourceName: name, Problem: "unreachable", Detail: te.msg,
+			})
+		} else if err != nil {
+			rep.Errors = append(rep.Errors, "database "+name+": "+err.Error())
+		}
+	}
+
+	for _, name := range sortedKeys(r.cfg.K8sClusters) {
+		rep.Checked++
+		data, err := r.call(ctx, rep.TraceID, "/api/v1/k8s/get_namespaces", map[string]any{"cluster": name})
+		if te, ok := err.(*toolError); ok {
+			rep.Drift = append(rep.Drift, audit.InventoryDrift{
+				ResourceType: "kubernetes", ResourceName: name, Cluster: name, Problem: "not_responding", Detail: te.msg,
+			})
+			continue
+		} else if err != nil {
+			rep.Errors = append(rep.Errors, "cluster "+name+": "+err.Error())
+			continue
+		}
+		var live struct {
+			Namespaces []struct {
+				Name string `json:"name"`
+			} `json:"namespaces"`
+		}
+		if err := json.Unmarshal(data, &live); err != nil {
+			rep.Errors = append(rep.Errors, "cluster "+name+": decode namespaces: "+err.Error())
+			continue
+		}
+		exists := map[string]bool{}
+		for _, ns := range live.Namespaces {
+			exists[ns.Name] = true
+		}
+		for _, ns := range r.expectedNamespaces(name) {
+			rep.Checked++
+			if !exists[ns] {
+				rep.Drift = append(rep.Drift, audit.InventoryDrift{
+					ResourceType: "kubernetes", ResourceName: ns, Cluster: name, Problem: "namespace_missing",
+					Detail: fmt.Sprintf("namespace %q does not exist on cluster %q", ns, name),
+				})
+			}
+		}
+	}
+	return rep
+}
+
+// call makes one check, bounded by the per-check timeout.
+func (r *reconciler) call(ctx context.Context, traceID, path string, args map[string]any) (json.RawMessage, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	return r.gateway.callTool(ctx, traceID, path, args)
+}
+
+// expectedNamespaces returns the namespaces the config names on a cluster:
+// its allowlist and the namespaces of the databases it hosts.
+func (r *reconciler) expectedNamespaces(cluster string) []string {
+	set := map[string]bool{}
+	for ns := range r.cfg.K8sClusters[cluster].Namespaces {
+		set[ns] = true
+	}
+	for _, db := range r.cfg.DBServers {
+		if db.K8sCluster == cluster && db.K8sNamespace != "" {
+			set[db.K8sNamespace] = true
+		}
+	}
+	return sortedKeys(set)
+}
+
+// driftEvents returns one inventory_drift event per drifted resource, all
+// under the run's trace ID.
+func driftEvents(rep *report, now time.Time) []*audit.Event {
+	events := make([]*audit.Event, 0, len(rep.Drift))
+	for i := range rep.Drift {
+		d := rep.Drift[i]
+		events = append(events, &audit.Event{
+			EventID:        "inv_" + uuid.New().String()[:8],
+			Timestamp:      now,
+			EventType:      audit.EventTypeInventoryDrift,
+			TraceID:        rep.TraceID,
+			ActionClass:    audit.ActionRead,
+			Session:        audit.Session{ID: "inventory", AgentName: "inventory"},
+			Outcome:        &audit.Outcome{Status: "success"},
+			InventoryDrift: &d,
+		})
+	}
+	return events
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
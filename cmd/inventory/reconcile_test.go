@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+)
+
+// fakeGateway answers check_connection and get_namespaces like the gateway's
+// direct tool endpoints with ?format=json.
+func fakeGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "json" || r.Header.Get("X-Trace-ID") == "" {
+			t.Errorf("%s: format=%q trace=%q", r.URL.Path, r.URL.Query().Get("format"), r.Header.Get("X-Trace-ID"))
+		}
+		var args map[string]string
+		json.NewDecoder(r.Body).Decode(&args) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/db/check_connection" && args["connection_string"] == "orders":
+			w.Write([]byte(`{"text":"connected"}`)) //nolint:errcheck
+		case r.URL.Path == "/api/v1/db/check_connection" && args["connection_string"] == "legacy":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error":"ERROR — connection refused"}`)) //nolint:errcheck
+		case r.URL.Path == "/api/v1/db/check_connection":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"denied by policy"}`)) //nolint:errcheck
+		case r.URL.Path == "/api/v1/k8s/get_namespaces" && args["cluster"] == "prod":
+			w.Write([]byte(`{"data":{"namespaces":[{"name":"checkout"},{"name":"database"}],"count":2}}`)) //nolint:errcheck
+		case r.URL.Path == "/api/v1/k8s/get_namespaces":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error":"ERROR — cluster unreachable"}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestReconcile(t *testing.T) {
+	srv := fakeGateway(t)
+	defer srv.Close()
+
+	cfg := &infra.Config{
+		DBServers: map[string]infra.DBServer{
+			"orders":  {Name: "orders", K8sCluster: "prod", K8sNamespace: "database"},
+			"legacy":  {Name: "legacy"},
+			"billing": {Name: "billing", K8sCluster: "prod", K8sNamespace: "billing"},
+		},
+		K8sClusters: map[string]infra.K8sCluster{
+			"prod": {Name: "prod", Namespaces: map[string]infra.K8sNamespace{"checkout": {}, "payments": {}}},
+			"edge": {Name: "edge"},
+		},
+	}
+	r := &reconciler{cfg: cfg, gateway: &gatewayClient{baseURL: srv.URL, client: srv.Client()}, timeout: 5 * time.Second}
+	rep := r.run(context.Background())
+
+	if !strings.HasPrefix(rep.TraceID, "inv_") {
+		t.Errorf("trace ID = %q, want inv_ prefix", rep.TraceID)
+	}
+	// 3 databases, 2 clusters and prod's 4 expected namespaces.
+	if rep.Checked != 9 {
+		t.Errorf("checked = %d, want 9", rep.Checked)
+	}
+	want := []string{
+		"database/legacy unreachable",
+		"kubernetes/edge not_responding",
+		"kubernetes/billing namespace_missing",
+		"kubernetes/payments namespace_missing",
+	}
+	var got []string
+	for _, d := range rep.Drift {
+		got = append(got, d.ResourceType+"/"+d.ResourceName+" "+d.Problem)
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("drift = %v, want %v", got, want)
+	}
+	if len(rep.Errors) != 1 || !strings.Contains(rep.Errors[0], "billing") || !strings.Contains(rep.Errors[0], "denied by policy") {
+		t.Errorf("errors = %v, want the billing policy denial only", rep.Errors)
+	}
+
+	events := driftEvents(rep, time.Now())
+	if len(events) != len(rep.Drift) {
+		t.Fatalf("events = %d, want %d", len(events), len(rep.Drift))
+	}
+	for i, e := range events {
+		if e.EventType != audit.EventTypeInventoryDrift || e.TraceID != rep.TraceID || !strings.HasPrefix(e.EventID, "inv_") {
+			t.Errorf("event %d = %s %s %s", i, e.EventID, e.EventType, e.TraceID)
+		}
+		if e.InventoryDrift.ResourceName != rep.Drift[i].ResourceName {
+			t.Errorf("event %d drift = %+v, want %+v", i, e.InventoryDrift, rep.Drift[i])
+		}
+	}
+}
@@ -29,9 +29,13 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"helpdesk/internal/buildinfo"
 )
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	port := flag.Int("port", 9999, "Port to serve JWKS on")
 	addr := flag.String("addr", "127.0.0.1", "Address to bind the JWKS server on (use 0.0.0.0 to accept connections from Docker containers)")
 	sub := flag.String("sub", "alice@example.com", "JWT sub claim (user identity)")
@@ -0,0 +1,138 @@
+// Package main implements k8s-admission, a ValidatingAdmissionWebhook that
+// checks changes made by an agent's Kubernetes service account against the
+// audit service (auditd). It is defense in depth behind the in-agent policy
+// enforcer: a change is denied when policy denies it, or when auditd holds no
+// approved trail for it (an approval, or an allow decision the agent's
+// enforcer recorded). Changes by every other user are admitted untouched.
+//
+//	k8s-admission --audit-url http://auditd:1199 \
+//	  --service-accounts system:serviceaccount:helpdesk:k8s-agent \
+//	  --tls-cert /certs/tls.crt --tls-key /certs/tls.key
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/logging"
+)
+
+func main() {
+	buildinfo.HandleVersionFlag()
+
+	listenAddr := flag.String("listen", envOrDefault("HELPDESK_ADMISSION_ADDR", ":8443"), "HTTPS listen address")
+	tlsCert := flag.String("tls-cert", envOrDefault("HELPDESK_ADMISSION_TLS_CERT", ""), "TLS certificate file (the API server only calls webhooks over HTTPS)")
+	tlsKey := flag.String("tls-key", envOrDefault("HELPDESK_ADMISSION_TLS_KEY", ""), "TLS private key file")
+	auditURL := flag.String("audit-url", envOrDefault("HELPDESK_AUDIT_URL", ""), "URL of the audit service (e.g., http://auditd:1199)")
+	apiKey := flag.String("api-key", envOrDefault("HELPDESK_AUDIT_API_KEY", ""), "Bearer token for auditd authentication (or set HELPDESK_AUDIT_API_KEY)")
+	agentName := flag.String("agent", envOrDefault("HELPDESK_ADMISSION_AGENT", "k8s_agent"), "Agent whose audit trail authorizes changes")
+	serviceAccounts := flag.String("service-accounts", envOrDefault("HELPDESK_ADMISSION_SERVICE_ACCOUNTS", ""), "Comma-separated usernames to govern (system:serviceaccount:<namespace>:<name>)")
+	window := flag.Duration("window", 15*time.Minute, "How recent an approval or allow decision must be to authorize a change")
+	failOpen := flag.Bool("fail-open", os.Getenv("HELPDESK_ADMISSION_FAIL_OPEN") == "true", "Admit changes when auditd cannot be reached (default: deny)")
+
+	// InitLogging must run before flag.Parse so it can strip --log-level.
+	remaining := logging.InitLogging(os.Args[1:])
+	flag.CommandLine.Parse(remaining) //nolint:errcheck
+
+	if *auditURL == "" {
+		slog.Error("audit service URL required (use --audit-url or set HELPDESK_AUDIT_URL)")
+		os.Exit(1)
+	}
+	if err := audit.InstallAuditToken(context.Background(), *auditURL); err != nil {
+		slog.Error("failed to configure the auditd access token", "err", err)
+		os.Exit(1)
+	}
+	governed := make(map[string]bool)
+	for _, sa := range strings.Split(*serviceAccounts, ",") {
+		if sa = strings.TrimSpace(sa); sa != "" {
+			governed[sa] = true
+		}
+	}
+	if len(governed) == 0 {
+		slog.Error("no service accounts to govern (use --service-accounts or set HELPDESK_ADMISSION_SERVICE_ACCOUNTS)")
+		os.Exit(1)
+	}
+
+	a := &admitter{
+		auditURL:        strings.TrimRight(*auditURL, "/"),
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+		agentName:       *agentName,
+		serviceAccounts: governed,
+		window:          *window,
+		failOpen:        *failOpen,
+		now:             time.Now,
+	}
+	events := audit.NewRemoteStore(a.auditURL)
+	a.approvals = audit.NewApprovalClient(a.auditURL)
+	if *apiKey != "" {
+		a.httpClient.Transport = &bearerTransport{base: http.DefaultTransport, token: *apiKey}
+		events = events.WithAPIKey(*apiKey)
+		a.approvals = a.approvals.WithAPIKey(*apiKey)
+	}
+	a.events = events
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /validate", a.handleValidate)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("GET /version", buildinfo.Handler())
+	srv := &http.Server{Addr: *listenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down admission webhook...")
+		srv.Shutdown(context.Background()) //nolint:errcheck
+	}()
+
+	slog.Info("admission webhook starting",
+		"version", buildinfo.Version,
+		"listen", *listenAddr,
+		"audit_url", a.auditURL,
+		"agent", a.agentName,
+		"service_accounts", len(governed),
+		"window", a.window,
+		"fail_open", a.failOpen)
+
+	var err error
+	if *tlsCert != "" && *tlsKey != "" {
+		err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		slog.Warn("no TLS certificate configured; serving plain HTTP (the API server will not call this endpoint directly)")
+		err = srv.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		slog.Error("server error", "err", err)
+		os.Exit(1)
+	}
+}
+
+type bearerTransport struct {
+	base  http.RoundTripper
+	token string
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
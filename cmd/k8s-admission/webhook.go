@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/policy"
+)
+
+// admissionTracePrefix marks the policy decisions this webhook records, so
+// they are never mistaken for the agent's own audit trail.
+const admissionTracePrefix = "adm_"
+
+// eventQuerier is the subset of audit.RemoteStore the webhook needs.
+type eventQuerier interface {
+	Query(ctx context.Context, opts audit.QueryOptions) ([]audit.Event, error)
+}
+
+// admitter validates resource changes made by an agent's service account
+// against auditd. A change is admitted only when policy does not deny it and
+// auditd holds an approved trail for it:
+//
+//   - policy allows it, and the agent recorded an allow decision (or holds an
+//     approved approval) for the same namespace and action within the window;
+//   - policy requires approval, and an approval for the same namespace and
+//     action was granted within the window and is still valid.
+//
+// Changes by any other user are admitted untouched.
+type admitter struct {
+	auditURL   string
+	httpClient *http.Client // for POST /v1/governance/check; carries the API key
+	events     eventQuerier
+	approvals  *audit.ApprovalClient
+
+	agentName       string          // agent whose trail is checked (e.g. k8s_agent)
+	serviceAccounts map[string]bool // governed usernames (system:serviceaccount:<ns>:<name>)
+	window          time.Duration   // how far back a matching trail entry may be
+	failOpen        bool            // admit when auditd cannot be reached
+
+	now func() time.Time
+}
+
+// policyCheckRequest and policyCheckResponse mirror auditd's
+// POST /v1/governance/check body and response.
+type policyCheckRequest struct {
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	Action       string `json:"action"`
+	TraceID      string `json:"trace_id,omitempty"`
+	AgentName    string `json:"agent_name,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
+
+type policyCheckResponse struct {
+	Effect     string `json:"effect"`
+	PolicyName string `json:"policy_name"`
+	Message    string `json:"message,omitempty"`
+	EventID    string `json:"event_id"`
+}
+
+// handleValidate handles POST /validate, the ValidatingAdmissionWebhook endpoint.
+func (a *admitter) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, "invalid AdmissionReview: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	resp := a.review(r.Context(), review.Request)
+	resp.UID = review.Request.UID
+	review.Response = resp
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review) //nolint:errcheck
+}
+
+// review decides a single admission request.
+func (a *admitter) review(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if !a.serviceAccounts[req.UserInfo.Username] {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+	if req.DryRun != nil && *req.DryRun {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	action := policy.ActionWrite
+	if req.Operation == admissionv1.Delete {
+		action = policy.ActionDestructive
+	}
+	// Policies name Kubernetes resources by namespace; a cluster-scoped
+	// object (such as a Namespace) is checked under its own name.
+	resourceName := req.Namespace
+	if resourceName == "" {
+		resourceName = req.Name
+	}
+	traceID := admissionTracePrefix + string(req.UID)
+	target := req.Resource.Resource
+	if req.SubResource != "" {
+		target += "/" + req.SubResource
+	}
+	log := slog.With("operation", req.Operation, "resource", target, "name", req.Name,
+		"namespace", req.Namespace, "user", req.UserInfo.Username, "trace_id", traceID)
+
+	check, err := a.checkPolicy(ctx, policyCheckRequest{
+		ResourceType: "kubernetes",
+		ResourceName: resourceName,
+		Action:       string(action),
+		TraceID:      traceID,
+		AgentName:    a.agentName,
+		Note:         fmt.Sprintf("admission: %s %s %q by %s", req.Operation, target, req.Name, req.UserInfo.Username),
+	})
+	if err != nil {
+		return a.unavailable(log, err)
+	}
+	annotations := map[string]string{
+		"helpdesk/policy":   check.PolicyName,
+		"helpdesk/trace-id": traceID,
+	}
+
+	switch policy.Effect(check.Effect) {
+	case policy.EffectDeny:
+		log.Warn("admission denied by policy", "policy", check.PolicyName)
+		return denied(annotations, fmt.Sprintf("denied by helpdesk policy %s: %s", check.PolicyName, check.Message))
+
+	case policy.EffectRequireApproval:
+		approval, err := a.findApproval(ctx, resourceName, action)
+		if err != nil {
+			return a.unavailable(log, err)
+		}
+		if approval == "" {
+			log.Warn("admission denied: no approved approval", "policy", check.PolicyName)
+			return denied(annotations, fmt.Sprintf("%s on %s %q requires an approval granted in helpdesk within %s",
+				action, target, resourceName, a.window))
+		}
+		annotations["helpdesk/approval-id"] = approval
+		log.Info("admission allowed by approval", "approval_id", approval)
+
+	case policy.EffectAllow:
+		approval, err := a.findApproval(ctx, resourceName, action)
+		if err != nil {
+			return a.unavailable(log, err)
+		}
+		decision := ""
+		if approval == "" {
+			if decision, err = a.findAllowDecision(ctx, resourceName, action); err != nil {
+				return a.unavailable(log, err)
+			}
+		}
+		if approval == "" && decision == "" {
+			log.Warn("admission denied: no audit trail", "policy", check.PolicyName)
+			return denied(annotations, fmt.Sprintf("no helpdesk audit trail for %s on %s %q by %s in the last %s",
+				action, target, resourceName, a.agentName, a.window))
+		}
+		if approval != "" {
+			annotations["helpdesk/approval-id"] = approval
+		} else {
+			annotations["helpdesk/decision-id"] = decision
+		}
+		log.Info("admission allowed by audit trail", "approval_id", approval, "decision_id", decision)
+
+	default:
+		return a.unavailable(log, fmt.Errorf("unexpected policy effect %q", check.Effect))
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true, AuditAnnotations: annotations}
+}
+
+// checkPolicy evaluates the change with auditd, which records the decision.
+func (a *admitter) checkPolicy(ctx context.Context, req policyCheckRequest) (*policyCheckResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.auditURL+"/v1/governance/check", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("policy check: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("policy check returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	var out policyCheckResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode policy check: %w", err)
+	}
+	return &out, nil
+}
+
+// findApproval returns the ID of an approval for the agent covering action on
+// the namespace, granted within the window and still valid, or "".
+func (a *admitter) findApproval(ctx context.Context, resourceName string, action policy.ActionClass) (string, error) {
+	approvals, err := a.approvals.ListApprovals(ctx, audit.ApprovalListOptions{
+		Status:    "approved",
+		AgentName: a.agentName,
+		Limit:     100,
+	})
+	if err != nil {
+		return "", fmt.Errorf("list approvals: %w", err)
+	}
+	now := a.now()
+	for _, ap := range approvals {
+		if ap.ResourceType != "kubernetes" || ap.ResourceName != resourceName || ap.ActionClass != string(action) {
+			continue
+		}
+		if now.Sub(ap.ResolvedAt) > a.window {
+			continue
+		}
+		if !ap.ApprovalValidUntil.IsZero() && now.After(ap.ApprovalValidUntil) {
+			continue
+		}
+		return ap.ApprovalID, nil
+	}
+	return "", nil
+}
+
+// findAllowDecision returns the event ID of an allow decision the agent's
+// enforcer recorded for action on the namespace within the window, or "".
+// Pre-flight and post-execution checks do not count, nor do the decisions
+// this webhook records itself.
+func (a *admitter) findAllowDecision(ctx context.Context, resourceName string, action policy.ActionClass) (string, error) {
+	events, err := a.events.Query(ctx, audit.QueryOptions{
+		EventType: audit.EventTypePolicyDecision,
+		Agent:     a.agentName,
+		Since:     a.now().Add(-a.window),
+	})
+	if err != nil {
+		return "", fmt.Errorf("query policy decisions: %w", err)
+	}
+	now := a.now()
+	for _, e := range events {
+		d := e.PolicyDecision
+		if d == nil || strings.HasPrefix(e.TraceID, admissionTracePrefix) || now.Sub(e.Timestamp) > a.window {
+			continue
+		}
+		if d.ResourceType != "kubernetes" || d.ResourceName != resourceName || d.Action != string(action) {
+			continue
+		}
+		if d.Effect != string(policy.EffectAllow) || d.Preflight || d.PostExecution {
+			continue
+		}
+		return e.EventID, nil
+	}
+	return "", nil
+}
+
+// unavailable answers when auditd cannot be consulted: deny by default, or
+// admit with -fail-open.
+func (a *admitter) unavailable(log *slog.Logger, err error) *admissionv1.AdmissionResponse {
+	if a.failOpen {
+		log.Warn("auditd unavailable; admitting (fail-open)", "err", err)
+		return &admissionv1.AdmissionResponse{Allowed: true, Warnings: []string{"helpdesk audit check skipped: " + err.Error()}}
+	}
+	log.Error("auditd unavailable; denying", "err", err)
+	return denied(nil, "helpdesk audit service unavailable: "+err.Error())
+}
+
+func denied(annotations map[string]string, msg string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed:          false,
+		AuditAnnotations: annotations,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: msg,
+		},
+	}
+}
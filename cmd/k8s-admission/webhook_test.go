@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"helpdesk/internal/audit"
+)
+
+const agentSA = "system:serviceaccount:helpdesk:k8s-agent"
+
+var testNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeAuditd serves the three auditd endpoints the webhook reads.
+type fakeAuditd struct {
+	effect    string
+	approvals []audit.StoredApproval
+	events    []audit.Event
+	checks    []policyCheckRequest
+	down      bool
+}
+
+func (f *fakeAuditd) start(t *testing.T) *admitter {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/governance/check":
+			var req policyCheckRequest
+			json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+			f.checks = append(f.checks, req)
+			json.NewEncoder(w).Encode(policyCheckResponse{Effect: f.effect, PolicyName: "k8s-policy", Message: "not in prod"}) //nolint:errcheck
+		case "/v1/approvals":
+			json.NewEncoder(w).Encode(f.approvals) //nolint:errcheck
+		case "/v1/events":
+			json.NewEncoder(w).Encode(f.events) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &admitter{
+		auditURL:        srv.URL,
+		httpClient:      srv.Client(),
+		events:          audit.NewRemoteStore(srv.URL),
+		approvals:       audit.NewApprovalClient(srv.URL),
+		agentName:       "k8s_agent",
+		serviceAccounts: map[string]bool{agentSA: true},
+		window:          15 * time.Minute,
+		now:             func() time.Time { return testNow },
+	}
+}
+
+func deletePod(user string) *admissionv1.AdmissionRequest {
+	return &admissionv1.AdmissionRequest{
+		UID:       "uid-1",
+		Operation: admissionv1.Delete,
+		Namespace: "payments",
+		Name:      "api-7f9c",
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		UserInfo:  authnv1.UserInfo{Username: user},
+	}
+}
+
+func allowDecision(traceID, namespace, action string, at time.Time) audit.Event {
+	return audit.Event{
+		EventID:   "pol_" + traceID,
+		TraceID:   traceID,
+		Timestamp: at,
+		PolicyDecision: &audit.PolicyDecision{
+			ResourceType: "kubernetes", ResourceName: namespace, Action: action, Effect: "allow",
+		},
+	}
+}
+
+func TestReview_OtherUsersUntouched(t *testing.T) {
+	f := &fakeAuditd{effect: "deny"}
+	a := f.start(t)
+	if resp := a.review(t.Context(), deletePod("system:serviceaccount:kube-system:gc")); !resp.Allowed {
+		t.Errorf("change by another user was denied: %+v", resp.Result)
+	}
+	if len(f.checks) != 0 {
+		t.Error("auditd was consulted for an ungoverned user")
+	}
+}
+
+func TestReview_PolicyDeny(t *testing.T) {
+	f := &fakeAuditd{effect: "deny", events: []audit.Event{allowDecision("tr_1", "payments", "destructive", testNow)}}
+	resp := f.start(t).review(t.Context(), deletePod(agentSA))
+	if resp.Allowed || resp.Result.Code != http.StatusForbidden || !strings.Contains(resp.Result.Message, "not in prod") {
+		t.Errorf("resp = %+v, want a 403 carrying the policy message", resp.Result)
+	}
+	c := f.checks[0]
+	if c.ResourceName != "payments" || c.Action != "destructive" || c.AgentName != "k8s_agent" || !strings.HasPrefix(c.TraceID, "adm_") {
+		t.Errorf("policy check = %+v", c)
+	}
+}
+
+func TestReview_AllowNeedsAgentDecision(t *testing.T) {
+	f := &fakeAuditd{effect: "allow"}
+	a := f.start(t)
+	if resp := a.review(t.Context(), deletePod(agentSA)); resp.Allowed {
+		t.Error("change without an audit trail was admitted")
+	}
+
+	// Decisions recorded by the webhook itself, for another namespace, or
+	// outside the window do not count.
+	f.events = []audit.Event{
+		allowDecision("adm_uid-0", "payments", "destructive", testNow),
+		allowDecision("tr_2", "billing", "destructive", testNow),
+		allowDecision("tr_3", "payments", "destructive", testNow.Add(-time.Hour)),
+	}
+	if resp := a.review(t.Context(), deletePod(agentSA)); resp.Allowed {
+		t.Error("change was admitted on an unrelated trail")
+	}
+
+	f.events = append(f.events, allowDecision("tr_4", "payments", "destructive", testNow.Add(-time.Minute)))
+	resp := a.review(t.Context(), deletePod(agentSA))
+	if !resp.Allowed || resp.AuditAnnotations["helpdesk/decision-id"] != "pol_tr_4" {
+		t.Errorf("resp = %+v, want admitted on the agent's decision", resp)
+	}
+}
+
+func TestReview_RequireApproval(t *testing.T) {
+	f := &fakeAuditd{effect: "require_approval", events: []audit.Event{allowDecision("tr_1", "payments", "destructive", testNow)}}
+	a := f.start(t)
+	if resp := a.review(t.Context(), deletePod(agentSA)); resp.Allowed {
+		t.Error("change needing approval was admitted on an allow decision")
+	}
+
+	f.approvals = []audit.StoredApproval{
+		{ApprovalID: "apr_expired", ResourceType: "kubernetes", ResourceName: "payments", ActionClass: "destructive",
+			ResolvedAt: testNow.Add(-time.Minute), ApprovalValidUntil: testNow.Add(-time.Second)},
+		{ApprovalID: "apr_write", ResourceType: "kubernetes", ResourceName: "payments", ActionClass: "write",
+			ResolvedAt: testNow.Add(-time.Minute)},
+	}
+	if resp := a.review(t.Context(), deletePod(agentSA)); resp.Allowed {
+		t.Error("change was admitted on an expired or narrower approval")
+	}
+
+	f.approvals = append(f.approvals, audit.StoredApproval{ApprovalID: "apr_ok", ResourceType: "kubernetes",
+		ResourceName: "payments", ActionClass: "destructive", ResolvedAt: testNow.Add(-time.Minute)})
+	resp := a.review(t.Context(), deletePod(agentSA))
+	if !resp.Allowed || resp.AuditAnnotations["helpdesk/approval-id"] != "apr_ok" {
+		t.Errorf("resp = %+v, want admitted on apr_ok", resp)
+	}
+}
+
+func TestReview_AuditdUnavailable(t *testing.T) {
+	f := &fakeAuditd{down: true}
+	a := f.start(t)
+	if resp := a.review(t.Context(), deletePod(agentSA)); resp.Allowed {
+		t.Error("fail-closed webhook admitted a change without auditd")
+	}
+	a.failOpen = true
+	if resp := a.review(t.Context(), deletePod(agentSA)); !resp.Allowed || len(resp.Warnings) == 0 {
+		t.Errorf("fail-open resp = %+v, want admitted with a warning", resp)
+	}
+}
+
+func TestHandleValidate(t *testing.T) {
+	f := &fakeAuditd{effect: "deny"}
+	a := f.start(t)
+	body, _ := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  deletePod(agentSA),
+	})
+	w := httptest.NewRecorder()
+	a.handleValidate(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(w.Body).Decode(&review); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if review.Kind != "AdmissionReview" || review.Response == nil || review.Response.UID != "uid-1" || review.Response.Allowed {
+		t.Errorf("review = %+v", review)
+	}
+
+	w = httptest.NewRecorder()
+	a.handleValidate(w, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("empty review: status = %d, want 400", w.Code)
+	}
+}
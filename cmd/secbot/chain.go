@@ -0,0 +1,91 @@
+package main
+
+import "helpdesk/internal/audit"
+
+const (
+	// chainWindow is how many further events may arrive before an event whose
+	// prev_hash has not been seen is reported. auditd writes each event to the
+	// socket from its own goroutine, so neighbours can arrive out of order.
+	chainWindow = 32
+
+	// chainMemory bounds how many hashes the tracker remembers.
+	chainMemory = 1024
+)
+
+// chainTracker detects breaks in the audit hash chain as seen on the live
+// stream. It reports chain_tampering when two events claim the same
+// predecessor (a fork) or when an event's predecessor never shows up within
+// chainWindow events. A single event failing its own hash check is reported
+// separately as hash_mismatch.
+type chainTracker struct {
+	seen    map[string]bool   // event_hash of recently seen events
+	claimed map[string]string // prev_hash → event_id that links to it
+	order   []*audit.Event    // remembered events, oldest first (for eviction)
+	pending []pendingLink     // events whose predecessor has not been seen yet
+	count   int               // events seen since the last reset
+}
+
+type pendingLink struct {
+	event audit.Event
+	at    int
+}
+
+// check records event and returns the offending event when tampering is
+// detected, or nil.
+func (c *chainTracker) check(event *audit.Event) *audit.Event {
+	if event.EventHash == "" {
+		return nil
+	}
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+		c.claimed = make(map[string]string)
+	}
+	c.count++
+
+	var offending *audit.Event
+	if event.PrevHash != "" {
+		if other, ok := c.claimed[event.PrevHash]; ok && other != event.EventID {
+			ev := *event
+			offending = &ev
+		}
+		c.claimed[event.PrevHash] = event.EventID
+	}
+	c.seen[event.EventHash] = true
+	ev := *event
+	c.order = append(c.order, &ev)
+	if len(c.order) > chainMemory {
+		old := c.order[0]
+		c.order = c.order[1:]
+		delete(c.seen, old.EventHash)
+		if c.claimed[old.PrevHash] == old.EventID {
+			delete(c.claimed, old.PrevHash)
+		}
+	}
+
+	// Events arriving during the warm-up window may link to history from
+	// before this connection; only track links once the window is full.
+	if event.PrevHash != "" && !c.seen[event.PrevHash] && c.count > chainWindow {
+		c.pending = append(c.pending, pendingLink{event: *event, at: c.count})
+	}
+
+	kept := c.pending[:0]
+	for _, p := range c.pending {
+		switch {
+		case c.seen[p.event.PrevHash]:
+			// Predecessor arrived late; chain is intact.
+		case c.count-p.at >= chainWindow:
+			if offending == nil {
+				e := p.event
+				offending = &e
+			}
+		default:
+			kept = append(kept, p)
+		}
+	}
+	c.pending = kept
+	return offending
+}
+
+// reset forgets all link state, e.g. after a stream reconnect where events
+// may have been missed.
+func (c *chainTracker) reset() { *c = chainTracker{} }
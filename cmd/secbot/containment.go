@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// Containment actions. Unlike query_agent, each containment step carries its
+// own approval gate: the engine requests an approval immediately before
+// acting, unless break-glass is active.
+const (
+	actionPauseSession = "pause_session"
+	actionRevokeAgent  = "revoke_agent"
+	actionScaleToZero  = "scale_to_zero"
+)
+
+var containmentActions = map[string]bool{
+	actionPauseSession: true,
+	actionRevokeAgent:  true,
+	actionScaleToZero:  true,
+}
+
+// containmentAlertTypes are the only alerts that may trigger containment.
+// Noisier detections (high_volume, injection heuristics) are limited to
+// investigation and notification.
+var containmentAlertTypes = map[string]bool{
+	"unauthorized_destructive": true,
+	"chain_tampering":          true,
+}
+
+// validateContainmentStep checks that a containment step is only reachable
+// from containment-eligible alerts.
+func validateContainmentStep(pb *ResponsePlaybook, where string) error {
+	for _, at := range pb.AlertTypes {
+		if !containmentAlertTypes[at] {
+			return fmt.Errorf("%s: containment is only allowed for unauthorized_destructive and chain_tampering alerts (got %q)", where, at)
+		}
+	}
+	return nil
+}
+
+// execContainment runs one containment step: approval (unless break-glass),
+// then the action itself through the gateway.
+func (e *responseEngine) execContainment(ctx context.Context, st ResponseStep, ac alertContext) stepResult {
+	var target string
+	var call func() error
+	switch st.Action {
+	case actionPauseSession:
+		if ac.SessionID == "" && ac.UserID == "" {
+			return stepResult{Status: "failed", Detail: "alert event has no session or user to pause"}
+		}
+		target = fmt.Sprintf("session=%s user=%s", ac.SessionID, ac.UserID)
+		call = func() error {
+			payload := map[string]any{
+				"session_id": ac.SessionID,
+				"user_id":    ac.UserID,
+				"reason":     fmt.Sprintf("secbot %s: %s on %s", ac.Playbook, ac.AlertType, ac.EventID),
+			}
+			if st.TTL > 0 {
+				payload["ttl_minutes"] = int(st.TTL.Minutes())
+			}
+			_, err := gatewayPOST(e.gateway, "/api/v1/admin/killswitch/sessions", payload)
+			return err
+		}
+
+	case actionRevokeAgent:
+		agent := st.Agent
+		if agent == "" {
+			agent = ac.Agent
+		}
+		if agent == "" {
+			return stepResult{Status: "failed", Detail: "no agent to revoke (set agent or alert on a tool event)"}
+		}
+		target = "agent=" + agent
+		call = func() error {
+			_, err := gatewayPOST(e.gateway, "/api/v1/admin/killswitch/agents/"+agent+"/revoke", map[string]any{
+				"reason": fmt.Sprintf("secbot %s: %s on %s", ac.Playbook, ac.AlertType, ac.EventID),
+			})
+			return err
+		}
+
+	case actionScaleToZero:
+		namespace := renderMessage(st.Namespace, ac)
+		deployment := renderMessage(st.Deployment, ac)
+		if namespace == "" {
+			namespace = ac.Namespace
+		}
+		if deployment == "" {
+			deployment = ac.Deployment
+		}
+		if namespace == "" || deployment == "" {
+			return stepResult{Status: "failed", Detail: "namespace and deployment could not be resolved"}
+		}
+		target = fmt.Sprintf("deployment=%s/%s", namespace, deployment)
+		call = func() error {
+			args := map[string]any{
+				"namespace":  namespace,
+				"deployment": deployment,
+				"replicas":   0,
+			}
+			if st.KubeContext != "" {
+				args["context"] = st.KubeContext
+			}
+			_, err := gatewayPOST(e.gateway, "/api/v1/k8s/scale_deployment", args)
+			return err
+		}
+
+	default:
+		return stepResult{Status: "failed", Detail: "unknown containment action " + st.Action}
+	}
+
+	gate := "break-glass"
+	if !e.breakGlass {
+		res := e.requestApproval(ctx, st, ac, st.Action,
+			fmt.Sprintf("secbot requests %s (%s) after %s on %s", st.Action, target, ac.AlertType, ac.EventID))
+		if res.Status != "success" {
+			return res
+		}
+		gate = res.Detail
+	}
+	if err := call(); err != nil {
+		return stepResult{Status: "failed", Detail: fmt.Sprintf("%s: %v", target, err)}
+	}
+	return stepResult{Status: "success", Detail: fmt.Sprintf("%s contained (%s)", target, gate)}
+}
+
+// requestApproval blocks until an approval for this step is resolved.
+// toolName identifies the gated action in the approval queue.
+func (e *responseEngine) requestApproval(ctx context.Context, st ResponseStep, ac alertContext, toolName, defaultReason string) stepResult {
+	if e.approvals == nil {
+		return stepResult{Status: "failed", Detail: "no audit service configured for approvals (-audit-service)"}
+	}
+	timeout := st.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Minute
+	}
+	reason := renderMessage(st.Message, ac)
+	if reason == "" {
+		reason = defaultReason
+	}
+	approval, err := e.approvals.RequestApprovalAndWait(ctx, audit.ApprovalCreateRequest{
+		TraceID:     ac.TraceID,
+		ActionClass: string(audit.ActionWrite),
+		ToolName:    toolName,
+		AgentName:   "secbot",
+		RequestedBy: "secbot",
+		Context: map[string]any{
+			"playbook":         ac.Playbook,
+			"alert_type":       ac.AlertType,
+			"trigger_event_id": ac.EventID,
+			"reason":           reason,
+		},
+	}, timeout)
+	if err != nil {
+		return stepResult{Status: "failed", Detail: err.Error()}
+	}
+	if approval.Status != string(audit.ApprovalApproved) {
+		return stepResult{Status: "denied", Detail: fmt.Sprintf("approval %s %s", approval.ApprovalID, approval.Status)}
+	}
+	return stepResult{Status: "success", Detail: fmt.Sprintf("approval %s granted by %s", approval.ApprovalID, approval.ResolvedBy)}
+}
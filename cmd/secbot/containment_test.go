@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestValidateResponsePlaybook_Containment(t *testing.T) {
+	ok := ResponsePlaybook{Name: "p", AlertTypes: []string{"unauthorized_destructive", "chain_tampering"},
+		Steps: []ResponseStep{{Action: actionPauseSession}, {Action: actionRevokeAgent}, {Action: actionScaleToZero}}}
+	if err := validateResponsePlaybook(&ok); err != nil {
+		t.Errorf("containment for eligible alerts: %v", err)
+	}
+
+	for _, at := range []string{"high_volume", "*"} {
+		pb := ResponsePlaybook{Name: "p", AlertTypes: []string{at}, Steps: []ResponseStep{{Action: actionPauseSession}}}
+		if err := validateResponsePlaybook(&pb); err == nil || !strings.Contains(err.Error(), "containment") {
+			t.Errorf("alert %q: err = %v, want containment restriction", at, err)
+		}
+	}
+}
+
+func TestExecContainment(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]any{}
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer gw.Close()
+
+	ac := alertContext{
+		AlertType:  "unauthorized_destructive",
+		EventID:    "tool_1",
+		SessionID:  "sess_1",
+		UserID:     "mallory",
+		Agent:      "k8s_agent",
+		Namespace:  "payments",
+		Deployment: "api",
+		Playbook:   "contain",
+	}
+
+	t.Run("approval required without break-glass", func(t *testing.T) {
+		e := &responseEngine{gateway: gw.URL, client: http.DefaultClient}
+		res := e.execContainment(context.Background(), ResponseStep{Action: actionPauseSession}, ac)
+		if res.Status != "failed" || !strings.Contains(res.Detail, "approvals") {
+			t.Errorf("got %+v, want failed (no approval client)", res)
+		}
+		if len(bodies) != 0 {
+			t.Errorf("gateway called without approval: %v", bodies)
+		}
+	})
+
+	e := &responseEngine{gateway: gw.URL, client: http.DefaultClient, breakGlass: true}
+	tests := []struct {
+		step  ResponseStep
+		path  string
+		check func(map[string]any) bool
+	}{
+		{ResponseStep{Action: actionPauseSession}, "/api/v1/admin/killswitch/sessions",
+			func(b map[string]any) bool { return b["user_id"] == "mallory" && b["session_id"] == "sess_1" }},
+		{ResponseStep{Action: actionRevokeAgent}, "/api/v1/admin/killswitch/agents/k8s_agent/revoke",
+			func(b map[string]any) bool { return strings.Contains(b["reason"].(string), "tool_1") }},
+		{ResponseStep{Action: actionScaleToZero}, "/api/v1/k8s/scale_deployment",
+			func(b map[string]any) bool {
+				return b["namespace"] == "payments" && b["deployment"] == "api" && b["replicas"] == float64(0)
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.step.Action, func(t *testing.T) {
+			res := e.execContainment(context.Background(), tt.step, ac)
+			if res.Status != "success" || !strings.Contains(res.Detail, "break-glass") {
+				t.Fatalf("got %+v", res)
+			}
+			mu.Lock()
+			body, ok := bodies[tt.path]
+			mu.Unlock()
+			if !ok || !tt.check(body) {
+				t.Errorf("gateway %s body = %v", tt.path, body)
+			}
+		})
+	}
+
+	t.Run("scale_to_zero without target", func(t *testing.T) {
+		res := e.execContainment(context.Background(), ResponseStep{Action: actionScaleToZero}, alertContext{})
+		if res.Status != "failed" {
+			t.Errorf("got %+v, want failed", res)
+		}
+	})
+}
+
+func chainEvent(id, prev string) *audit.Event {
+	return &audit.Event{EventID: id, EventHash: "h_" + id, PrevHash: prev}
+}
+
+func TestChainTracker(t *testing.T) {
+	warmUp := func(c *chainTracker) string {
+		prev := ""
+		for i := 0; i <= chainWindow; i++ {
+			id := fmt.Sprintf("w%d", i)
+			c.check(chainEvent(id, prev))
+			prev = "h_" + id
+		}
+		return prev
+	}
+
+	t.Run("intact chain with reordering", func(t *testing.T) {
+		var c chainTracker
+		prev := warmUp(&c)
+		// b arrives before its predecessor a.
+		if got := c.check(chainEvent("b", "h_a")); got != nil {
+			t.Fatalf("reported %s", got.EventID)
+		}
+		if got := c.check(chainEvent("a", prev)); got != nil {
+			t.Fatalf("reported %s", got.EventID)
+		}
+		prev = "h_b"
+		for i := 0; i < chainWindow*2; i++ {
+			id := fmt.Sprintf("n%d", i)
+			if got := c.check(chainEvent(id, prev)); got != nil {
+				t.Fatalf("reported %s", got.EventID)
+			}
+			prev = "h_" + id
+		}
+	})
+
+	t.Run("fork", func(t *testing.T) {
+		var c chainTracker
+		prev := warmUp(&c)
+		c.check(chainEvent("a", prev))
+		got := c.check(chainEvent("evil", prev))
+		if got == nil || got.EventID != "evil" {
+			t.Fatalf("got %v, want evil", got)
+		}
+	})
+
+	t.Run("missing predecessor", func(t *testing.T) {
+		var c chainTracker
+		prev := warmUp(&c)
+		c.check(chainEvent("gap", "h_deleted"))
+		prev = "h_gap"
+		var got *audit.Event
+		for i := 0; i < chainWindow && got == nil; i++ {
+			id := fmt.Sprintf("n%d", i)
+			got = c.check(chainEvent(id, prev))
+			prev = "h_" + id
+		}
+		if got == nil || got.EventID != "gap" {
+			t.Fatalf("got %v, want gap", got)
+		}
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
 )
 
 // volumeTracker tracks event counts for high-volume detection.
@@ -58,8 +59,15 @@ type a2aResponse struct {
 // Set via -api-key flag or HELPDESK_CLIENT_API_KEY env var.
 var gatewayAPIKey string
 
+// playbookEngine executes response playbooks for matching alerts.
+// Nil when -playbooks is not set (every alert creates an incident bundle).
+var playbookEngine *responseEngine
+
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	socketPath := flag.String("socket", "/tmp/helpdesk-audit.sock", "Path to audit Unix socket")
+	socketCursor := flag.String("socket-cursor", "", "File recording the last event received, so a restarted secbot replays what it missed")
 	auditServiceURL := flag.String("audit-service", "", "URL of audit HTTP service for polling mode (alternative to Unix socket)")
 	gateway := flag.String("gateway", "http://localhost:8080", "Gateway base URL")
 	apiKey := flag.String("api-key", os.Getenv("HELPDESK_CLIENT_API_KEY"), "Bearer token for gateway authentication")
@@ -69,8 +77,49 @@ func main() {
 	maxEventsPerMinute := flag.Int("max-events-per-minute", 100, "Alert threshold for high-volume detection")
 	dryRun := flag.Bool("dry-run", false, "Log alerts but don't create incidents")
 	verbose := flag.Bool("verbose", false, "Log all received events")
+	playbooksPath := flag.String("playbooks", "", "Path to response playbooks YAML (alert type → ordered response steps)")
+	breakGlass := flag.Bool("break-glass", os.Getenv("HELPDESK_SECBOT_BREAK_GLASS") == "true", "Run playbook containment steps without waiting for approval (emergency use only)")
+	auditAPIKey := flag.String("audit-api-key", os.Getenv("HELPDESK_AUDIT_API_KEY"), "API key for auditd (playbook approvals and security_response events)")
 	flag.Parse()
 	gatewayAPIKey = *apiKey
+	if err := audit.InstallAuditToken(context.Background(), *auditServiceURL, os.Getenv("HELPDESK_AUDIT_URL")); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *playbooksPath != "" {
+		playbooks, err := loadResponsePlaybooks(*playbooksPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		callbackHost, callbackPort := callbackAddr(*listen)
+		playbookEngine = &responseEngine{
+			playbooks:   playbooks,
+			gateway:     *gateway,
+			infraKey:    *infraKey,
+			callbackURL: fmt.Sprintf("http://%s:%s/callback", callbackHost, callbackPort),
+			dryRun:      *dryRun,
+			breakGlass:  *breakGlass,
+			client:      &http.Client{Timeout: 30 * time.Second},
+		}
+		// Approvals and step auditing need the auditd HTTP API. In socket mode
+		// fall back to HELPDESK_AUDIT_URL.
+		auditURL := *auditServiceURL
+		if auditURL == "" {
+			auditURL = os.Getenv("HELPDESK_AUDIT_URL")
+		}
+		if auditURL != "" {
+			approvals := audit.NewApprovalClient(auditURL)
+			recorder := audit.NewRemoteStore(auditURL)
+			if *auditAPIKey != "" {
+				approvals = approvals.WithAPIKey(*auditAPIKey)
+				recorder = recorder.WithAPIKey(*auditAPIKey)
+			}
+			playbookEngine.approvals = approvals
+			playbookEngine.recorder = recorder
+		}
+	}
 
 	// Initialize volume tracker
 	volTracker := &volumeTracker{
@@ -92,6 +141,12 @@ func main() {
 	logf("Cooldown:      %s", *cooldown)
 	logf("Max events/min: %d", *maxEventsPerMinute)
 	logf("Dry run:       %v", *dryRun)
+	if playbookEngine != nil {
+		logf("Playbooks:     %d (%s)", len(playbookEngine.playbooks), *playbooksPath)
+		if playbookEngine.breakGlass {
+			logf("BREAK-GLASS:   containment steps run without approval")
+		}
+	}
 	fmt.Println()
 
 	// Start callback server
@@ -122,13 +177,17 @@ func main() {
 
 		var lastIncidentTime time.Time
 		eventCount := 0
+		var chain chainTracker
+		// The socket client keeps the replay cursor across reconnects, so
+		// events recorded while the audit service restarts are not missed.
+		sock := &audit.SocketClient{Path: *socketPath, CursorPath: *socketCursor}
 
 		for {
 			if ctx.Err() != nil {
 				return
 			}
 
-			conn, err := net.Dial("unix", *socketPath)
+			conn, err := sock.Dial()
 			if err != nil {
 				logf("WARN: Cannot connect to audit socket: %v — retrying in 5s", err)
 				select {
@@ -139,6 +198,7 @@ func main() {
 				continue
 			}
 			logf("Connected to audit stream")
+			chain.reset()
 
 			// eventCh buffers parsed events so the reader goroutine is never
 			// blocked by slow downstream work.
@@ -154,13 +214,16 @@ func main() {
 					if len(line) == 0 {
 						continue
 					}
-					var event audit.Event
-					if err := json.Unmarshal(line, &event); err != nil {
+					event, err := sock.Decode(line)
+					if event == nil {
 						logf("WARN: Failed to parse event: %v", err)
 						continue
 					}
+					if err != nil {
+						logf("WARN: %v", err)
+					}
 					select {
-					case eventCh <- event:
+					case eventCh <- *event:
 					case <-ctx.Done():
 						return
 					}
@@ -177,6 +240,12 @@ func main() {
 					logf("EVENT #%d: %s (type=%s)", eventCount, event.EventID, event.EventType)
 				}
 
+				// The chain tracker must see every event to keep its link state.
+				if broken := chain.check(&event); broken != nil {
+					lastIncidentTime = processAlert("chain_tampering", broken, lastIncidentTime,
+						*cooldown, *gateway, *infraKey, *listen, *dryRun)
+				}
+
 				alertType := volTracker.recordAndCheck()
 				if alertType == "" {
 					alertType = detectSecurityAlert(&event)
@@ -292,6 +361,7 @@ func alertTypeList() []string {
 	return []string{
 		"high_volume",
 		"hash_mismatch",
+		"chain_tampering",
 		"unauthorized_destructive",
 		"potential_sql_injection",
 		"potential_command_injection",
@@ -314,6 +384,8 @@ func gatewayPOST(baseURL, path string, payload map[string]any) (*a2aResponse, er
 	if gatewayAPIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+gatewayAPIKey)
 	}
+	// Incident-driven: jump ahead of background work queued for an agent.
+	req.Header.Set("X-Priority", "incident")
 	client := &http.Client{Timeout: 60 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -437,6 +509,17 @@ func processAlert(
 		return lastIncidentTime
 	}
 
+	if playbookEngine != nil {
+		if pb := matchPlaybook(playbookEngine.playbooks, alertType); pb != nil {
+			// Playbooks may block on a human approval, so they run off the
+			// event-processing path. The cooldown still applies.
+			ev := *event
+			go playbookEngine.run(context.Background(), pb, alertType, &ev)
+			fmt.Println()
+			return time.Now()
+		}
+	}
+
 	if dryRun {
 		logf("  [DRY RUN] Would create incident bundle")
 		fmt.Println()
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"helpdesk/internal/audit"
+)
+
+// Response playbook actions.
+const (
+	actionCreateBundle    = "create_bundle"
+	actionNotify          = "notify"
+	actionRequireApproval = "require_approval"
+	actionQueryAgent      = "query_agent"
+	actionAnnotate        = "annotate"
+)
+
+// activeActions are steps that change infrastructure state. Every active step
+// must be preceded by a require_approval step in the same playbook, except
+// containment actions, which gate themselves (see containment.go).
+var activeActions = map[string]bool{
+	actionQueryAgent:   true,
+	actionPauseSession: true,
+	actionRevokeAgent:  true,
+	actionScaleToZero:  true,
+}
+
+// ResponsePlaybook maps one or more alert types to an ordered list of
+// response steps.
+type ResponsePlaybook struct {
+	Name       string         `yaml:"name"`
+	AlertTypes []string       `yaml:"alert_types"`
+	Steps      []ResponseStep `yaml:"steps"`
+}
+
+// ResponseStep is one action in a response playbook. Which fields apply
+// depends on Action:
+//
+//	create_bundle:    layers (default [os, storage])
+//	notify:           webhook, message
+//	require_approval: timeout (default 15m), message
+//	query_agent:      agent, message, purpose (default "remediation")
+//	annotate:         kind, ref, url, message (note)
+//	pause_session:    ttl, timeout, message
+//	revoke_agent:     agent (default: agent of the alerting event), timeout, message
+//	scale_to_zero:    namespace, deployment, context, timeout, message
+//
+// message, ref and url are text/templates rendered against alertContext.
+type ResponseStep struct {
+	Action  string        `yaml:"action"`
+	Layers  []string      `yaml:"layers,omitempty"`
+	Webhook string        `yaml:"webhook,omitempty"`
+	Agent   string        `yaml:"agent,omitempty"`
+	Message string        `yaml:"message,omitempty"`
+	Purpose string        `yaml:"purpose,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// External reference attached to the alert's trace (annotate).
+	Kind string `yaml:"kind,omitempty"`
+	Ref  string `yaml:"ref,omitempty"`
+	URL  string `yaml:"url,omitempty"`
+
+	// Containment targets.
+	Namespace   string        `yaml:"namespace,omitempty"`
+	Deployment  string        `yaml:"deployment,omitempty"`
+	KubeContext string        `yaml:"context,omitempty"`
+	TTL         time.Duration `yaml:"ttl,omitempty"`
+}
+
+// playbookFile is the top-level shape of a secbot playbooks YAML file.
+type playbookFile struct {
+	Playbooks []ResponsePlaybook `yaml:"playbooks"`
+}
+
+// loadResponsePlaybooks reads and validates a playbooks YAML file.
+func loadResponsePlaybooks(path string) ([]ResponsePlaybook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read playbooks: %w", err)
+	}
+	var f playbookFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse playbooks: %w", err)
+	}
+	for i := range f.Playbooks {
+		if err := validateResponsePlaybook(&f.Playbooks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return f.Playbooks, nil
+}
+
+func validateResponsePlaybook(pb *ResponsePlaybook) error {
+	if pb.Name == "" {
+		return fmt.Errorf("playbook without name")
+	}
+	if len(pb.AlertTypes) == 0 {
+		return fmt.Errorf("playbook %q: alert_types is required", pb.Name)
+	}
+	if len(pb.Steps) == 0 {
+		return fmt.Errorf("playbook %q: at least one step is required", pb.Name)
+	}
+	approved := false
+	for i, st := range pb.Steps {
+		where := fmt.Sprintf("playbook %q step %d (%s)", pb.Name, i+1, st.Action)
+		switch st.Action {
+		case actionCreateBundle:
+		case actionNotify:
+			if st.Webhook == "" {
+				return fmt.Errorf("%s: webhook is required", where)
+			}
+		case actionRequireApproval:
+			approved = true
+		case actionQueryAgent:
+			if st.Agent == "" || st.Message == "" {
+				return fmt.Errorf("%s: agent and message are required", where)
+			}
+		case actionAnnotate:
+			if st.Kind == "" || (st.Ref == "" && st.URL == "") {
+				return fmt.Errorf("%s: kind and one of ref or url are required", where)
+			}
+		case actionPauseSession, actionRevokeAgent, actionScaleToZero:
+			if err := validateContainmentStep(pb, where); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: unknown action", where)
+		}
+		if activeActions[st.Action] && !containmentActions[st.Action] && !approved {
+			return fmt.Errorf("%s: active response requires a preceding require_approval step", where)
+		}
+		for field, tmpl := range map[string]string{"message": st.Message, "ref": st.Ref, "url": st.URL} {
+			if tmpl == "" {
+				continue
+			}
+			if _, err := template.New(field).Parse(tmpl); err != nil {
+				return fmt.Errorf("%s: invalid %s template: %w", where, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchPlaybook returns the first playbook that handles alertType, or nil.
+func matchPlaybook(playbooks []ResponsePlaybook, alertType string) *ResponsePlaybook {
+	for i := range playbooks {
+		for _, at := range playbooks[i].AlertTypes {
+			if at == alertType || at == "*" {
+				return &playbooks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// alertContext is the data available to step message templates.
+type alertContext struct {
+	AlertType  string
+	EventID    string
+	TraceID    string
+	SessionID  string
+	UserID     string
+	Tool       string
+	Agent      string
+	Namespace  string
+	Deployment string
+	Playbook   string
+}
+
+func newAlertContext(alertType string, event *audit.Event, playbook string) alertContext {
+	ac := alertContext{
+		AlertType: alertType,
+		EventID:   event.EventID,
+		TraceID:   event.TraceID,
+		SessionID: event.Session.ID,
+		UserID:    event.Session.UserID,
+		Playbook:  playbook,
+	}
+	if ac.UserID == "" && event.Principal != nil {
+		ac.UserID = event.Principal.EffectiveID()
+	}
+	if event.Tool != nil {
+		ac.Tool = event.Tool.Name
+		ac.Agent = event.Tool.Agent
+		ac.Namespace, _ = event.Tool.Parameters["namespace"].(string)
+		ac.Deployment, _ = event.Tool.Parameters["deployment"].(string)
+	}
+	if ac.Agent == "" {
+		ac.Agent = event.Session.AgentName
+	}
+	return ac
+}
+
+func renderMessage(tmpl string, ac alertContext) string {
+	t, err := template.New("msg").Parse(tmpl)
+	if err != nil {
+		return tmpl
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ac); err != nil {
+		return tmpl
+	}
+	return buf.String()
+}
+
+// responseEngine executes response playbooks. Infrastructure-facing steps go
+// through the gateway (so they are authorized and audited there); approval
+// gates go through auditd; every step is additionally recorded as a
+// security_response audit event when an audit recorder is configured.
+type responseEngine struct {
+	playbooks   []ResponsePlaybook
+	gateway     string
+	infraKey    string
+	callbackURL string
+	dryRun      bool
+	breakGlass  bool // containment steps skip their approval gate
+
+	approvals *audit.ApprovalClient // nil → require_approval steps fail closed
+	recorder  audit.Auditor         // nil → steps are only logged locally
+	client    *http.Client
+}
+
+// stepResult is the outcome of one executed step.
+type stepResult struct {
+	Status string
+	Detail string
+}
+
+// run executes pb for the given alert, stopping at the first failed or denied
+// step. It returns the per-step results.
+func (e *responseEngine) run(ctx context.Context, pb *ResponsePlaybook, alertType string, event *audit.Event) []stepResult {
+	ac := newAlertContext(alertType, event, pb.Name)
+	logf("  Playbook:  %s (%d steps)", pb.Name, len(pb.Steps))
+
+	var results []stepResult
+	for i, st := range pb.Steps {
+		var res stepResult
+		if e.dryRun {
+			res = stepResult{Status: "dry_run", Detail: "would execute " + st.Action}
+		} else {
+			res = e.execStep(ctx, i+1, st, ac)
+		}
+		logf("  Step %d %-17s %s %s", i+1, st.Action, res.Status, truncate(res.Detail, 80))
+		e.recordStep(ctx, pb.Name, i+1, st.Action, ac, event, res)
+		results = append(results, res)
+		if res.Status != "success" && res.Status != "dry_run" {
+			for j := i + 1; j < len(pb.Steps); j++ {
+				skipped := stepResult{Status: "skipped", Detail: fmt.Sprintf("step %d %s", i+1, res.Status)}
+				e.recordStep(ctx, pb.Name, j+1, pb.Steps[j].Action, ac, event, skipped)
+				results = append(results, skipped)
+			}
+			break
+		}
+	}
+	return results
+}
+
+func (e *responseEngine) execStep(ctx context.Context, step int, st ResponseStep, ac alertContext) stepResult {
+	if containmentActions[st.Action] {
+		return e.execContainment(ctx, st, ac)
+	}
+	switch st.Action {
+	case actionCreateBundle:
+		layers := st.Layers
+		if len(layers) == 0 {
+			layers = []string{"os", "storage"}
+		}
+		description := fmt.Sprintf("Security alert: %s (event: %s, trace: %s, playbook: %s)",
+			ac.AlertType, ac.EventID, ac.TraceID, ac.Playbook)
+		resp, err := gatewayPOST(e.gateway, "/api/v1/incidents", map[string]any{
+			"infra_key":    e.infraKey,
+			"description":  description,
+			"callback_url": e.callbackURL,
+			"layers":       layers,
+		})
+		if err != nil {
+			return stepResult{Status: "failed", Detail: err.Error()}
+		}
+		return stepResult{Status: "success", Detail: fmt.Sprintf("incident requested (%d chars)", len(resp.Text))}
+
+	case actionNotify:
+		// The webhook is called directly, not through the gateway, so nothing
+		// else audits it: record the notification before it goes out and do
+		// not send it unrecorded.
+		if e.recorder == nil {
+			return stepResult{Status: "failed", Detail: "notify needs auditd (-audit-service or HELPDESK_AUDIT_URL) to record the notification"}
+		}
+		msg := renderMessage(st.Message, ac)
+		if msg == "" {
+			msg = fmt.Sprintf("secbot: %s alert on event %s (playbook %s)", ac.AlertType, ac.EventID, ac.Playbook)
+		}
+		detail := fmt.Sprintf("to %s: %s", webhookHost(st.Webhook), msg)
+		if err := e.recordNotification(ctx, ac, step, detail); err != nil {
+			return stepResult{Status: "failed", Detail: "not sent: " + err.Error()}
+		}
+		if err := e.postWebhook(ctx, st.Webhook, msg); err != nil {
+			return stepResult{Status: "failed", Detail: err.Error()}
+		}
+		return stepResult{Status: "success", Detail: detail}
+
+	case actionRequireApproval:
+		return e.requestApproval(ctx, st, ac, "secbot_playbook",
+			fmt.Sprintf("secbot playbook %s requests active response to %s", ac.Playbook, ac.AlertType))
+
+	case actionQueryAgent:
+		purpose := st.Purpose
+		if purpose == "" {
+			purpose = "remediation"
+		}
+		resp, err := gatewayPOST(e.gateway, "/api/v1/query", map[string]any{
+			"agent":        st.Agent,
+			"message":      renderMessage(st.Message, ac),
+			"user":         "secbot",
+			"purpose":      purpose,
+			"purpose_note": fmt.Sprintf("secbot playbook %s: %s on %s", ac.Playbook, ac.AlertType, ac.EventID),
+		})
+		if err != nil {
+			return stepResult{Status: "failed", Detail: err.Error()}
+		}
+		return stepResult{Status: "success", Detail: resp.Text}
+
+	case actionAnnotate:
+		if ac.TraceID == "" {
+			return stepResult{Status: "failed", Detail: "alert event has no trace to annotate"}
+		}
+		ref, url := renderMessage(st.Ref, ac), renderMessage(st.URL, ac)
+		_, err := gatewayPOST(e.gateway, "/api/v1/governance/traces/"+ac.TraceID+"/annotations", map[string]any{
+			"kind":   st.Kind,
+			"ref":    ref,
+			"url":    url,
+			"note":   renderMessage(st.Message, ac),
+			"source": "secbot",
+		})
+		if err != nil {
+			return stepResult{Status: "failed", Detail: err.Error()}
+		}
+		return stepResult{Status: "success", Detail: strings.TrimSpace(st.Kind + " " + ref + " " + url)}
+	}
+	return stepResult{Status: "failed", Detail: "unknown action " + st.Action}
+}
+
+// postWebhook sends a Slack-compatible {"text": ...} payload.
+func (e *responseEngine) postWebhook(ctx context.Context, url, text string) error {
+	data, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookHost returns the host of a webhook URL. Webhook URLs often embed
+// their credential in the path, so only the host goes into the audit trail.
+func webhookHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return u.Host
+}
+
+// recordNotification writes the security_response event of a notify step
+// before the notification is sent, with status "sending"; the step's own
+// event, recorded by run, carries the outcome.
+func (e *responseEngine) recordNotification(ctx context.Context, ac alertContext, step int, detail string) error {
+	ev := &audit.Event{
+		EventType: audit.EventTypeSecurityResponse,
+		TraceID:   ac.TraceID,
+		ParentID:  ac.EventID,
+		Session:   audit.Session{ID: "secbot", AgentName: "secbot"},
+		SecurityResponse: &audit.SecurityResponse{
+			Playbook:       ac.Playbook,
+			Step:           step,
+			Action:         actionNotify,
+			AlertType:      ac.AlertType,
+			TriggerEventID: ac.EventID,
+			Status:         "sending",
+			Detail:         truncate(detail, 500),
+		},
+	}
+	if err := e.recorder.Record(ctx, ev); err != nil {
+		return fmt.Errorf("failed to record the notification: %w", err)
+	}
+	return nil
+}
+
+// recordStep writes a security_response audit event for one step.
+func (e *responseEngine) recordStep(ctx context.Context, playbook string, step int, action string, ac alertContext, trigger *audit.Event, res stepResult) {
+	if e.recorder == nil {
+		return
+	}
+	status := "success"
+	if res.Status != "success" && res.Status != "dry_run" {
+		status = "error"
+	}
+	ev := &audit.Event{
+		EventType: audit.EventTypeSecurityResponse,
+		TraceID:   trigger.TraceID,
+		ParentID:  trigger.EventID,
+		Session:   audit.Session{ID: "secbot", AgentName: "secbot"},
+		SecurityResponse: &audit.SecurityResponse{
+			Playbook:       playbook,
+			Step:           step,
+			Action:         action,
+			AlertType:      ac.AlertType,
+			TriggerEventID: trigger.EventID,
+			Status:         res.Status,
+			Detail:         truncate(strings.TrimSpace(res.Detail), 500),
+		},
+		Outcome: &audit.Outcome{Status: status},
+	}
+	if activeActions[action] {
+		ev.ActionClass = audit.ActionWrite
+	}
+	if err := e.recorder.Record(ctx, ev); err != nil {
+		logf("WARN: failed to record security_response event: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []*audit.Event
+}
+
+func (r *recordingAuditor) Record(_ context.Context, e *audit.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+func (r *recordingAuditor) RecordOutcome(context.Context, string, *audit.Outcome) error { return nil }
+func (r *recordingAuditor) Query(context.Context, audit.QueryOptions) ([]audit.Event, error) {
+	return nil, nil
+}
+func (r *recordingAuditor) Close() error { return nil }
+
+func writePlaybooks(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "playbooks.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadResponsePlaybooks(t *testing.T) {
+	path := writePlaybooks(t, `
+playbooks:
+  - name: contain-destructive
+    alert_types: [unauthorized_destructive]
+    steps:
+      - action: create_bundle
+        layers: [os, storage, database]
+      - action: notify
+        webhook: http://hooks.example/x
+        message: "{{.AlertType}} by {{.UserID}}"
+      - action: require_approval
+        timeout: 10m
+      - action: query_agent
+        agent: k8s
+        message: "Cordon namespace for session {{.SessionID}}"
+`)
+	pbs, err := loadResponsePlaybooks(path)
+	if err != nil {
+		t.Fatalf("loadResponsePlaybooks: %v", err)
+	}
+	if len(pbs) != 1 || len(pbs[0].Steps) != 4 {
+		t.Fatalf("got %+v", pbs)
+	}
+	if pbs[0].Steps[2].Timeout.Minutes() != 10 {
+		t.Errorf("timeout = %v, want 10m", pbs[0].Steps[2].Timeout)
+	}
+}
+
+func TestValidateResponsePlaybook_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		pb   ResponsePlaybook
+		want string
+	}{
+		{"no name", ResponsePlaybook{AlertTypes: []string{"x"}, Steps: []ResponseStep{{Action: actionCreateBundle}}}, "without name"},
+		{"no alert types", ResponsePlaybook{Name: "p", Steps: []ResponseStep{{Action: actionCreateBundle}}}, "alert_types"},
+		{"no steps", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}}, "at least one step"},
+		{"unknown action", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}, Steps: []ResponseStep{{Action: "nuke"}}}, "unknown action"},
+		{"notify without webhook", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}, Steps: []ResponseStep{{Action: actionNotify}}}, "webhook"},
+		{"annotate without ref or url", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}, Steps: []ResponseStep{{Action: actionAnnotate, Kind: "jira"}}}, "ref or url"},
+		{"active without approval", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}, Steps: []ResponseStep{
+			{Action: actionQueryAgent, Agent: "k8s", Message: "cordon"},
+			{Action: actionRequireApproval},
+		}}, "require_approval"},
+		{"bad template", ResponsePlaybook{Name: "p", AlertTypes: []string{"x"}, Steps: []ResponseStep{
+			{Action: actionNotify, Webhook: "http://x", Message: "{{.AlertType"},
+		}}, "template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResponsePlaybook(&tt.pb)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPlaybook(t *testing.T) {
+	pbs := []ResponsePlaybook{
+		{Name: "a", AlertTypes: []string{"hash_mismatch"}},
+		{Name: "fallback", AlertTypes: []string{"*"}},
+	}
+	if pb := matchPlaybook(pbs, "hash_mismatch"); pb == nil || pb.Name != "a" {
+		t.Errorf("hash_mismatch matched %v", pb)
+	}
+	if pb := matchPlaybook(pbs, "high_volume"); pb == nil || pb.Name != "fallback" {
+		t.Errorf("high_volume matched %v", pb)
+	}
+	if pb := matchPlaybook(pbs[:1], "high_volume"); pb != nil {
+		t.Errorf("expected no match, got %s", pb.Name)
+	}
+}
+
+func TestResponseEngine_Run(t *testing.T) {
+	var mu sync.Mutex
+	var gatewayPaths []string
+	var webhookText string
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gatewayPaths = append(gatewayPaths, r.URL.Path)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(a2aResponse{Agent: "incident", Text: "ok"}) //nolint:errcheck
+	}))
+	defer gw.Close()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		mu.Lock()
+		webhookText = body["text"]
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	rec := &recordingAuditor{}
+	e := &responseEngine{
+		gateway:  gw.URL,
+		infraKey: "security-incident",
+		recorder: rec,
+		client:   http.DefaultClient,
+	}
+	pb := &ResponsePlaybook{
+		Name:       "contain",
+		AlertTypes: []string{"unauthorized_destructive"},
+		Steps: []ResponseStep{
+			{Action: actionCreateBundle},
+			{Action: actionNotify, Webhook: hook.URL, Message: "{{.AlertType}} on {{.EventID}}"},
+			{Action: actionRequireApproval}, // no approval client → fails closed
+			{Action: actionQueryAgent, Agent: "k8s", Message: "cordon"},
+		},
+	}
+	event := &audit.Event{EventID: "tool_1", TraceID: "tr_1"}
+
+	results := e.run(context.Background(), pb, "unauthorized_destructive", event)
+
+	want := []string{"success", "success", "failed", "skipped"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		if results[i].Status != w {
+			t.Errorf("step %d status = %q, want %q", i+1, results[i].Status, w)
+		}
+	}
+	if len(gatewayPaths) != 1 || gatewayPaths[0] != "/api/v1/incidents" {
+		t.Errorf("gateway paths = %v, want only /api/v1/incidents", gatewayPaths)
+	}
+	if webhookText != "unauthorized_destructive on tool_1" {
+		t.Errorf("webhook text = %q", webhookText)
+	}
+
+	// The notify step is recorded before the webhook call, then with its
+	// outcome like every other step.
+	wantSteps := []int{1, 2, 2, 3, 4}
+	if len(rec.events) != len(wantSteps) {
+		t.Fatalf("recorded %d events, want %d", len(rec.events), len(wantSteps))
+	}
+	for i, ev := range rec.events {
+		if ev.EventType != audit.EventTypeSecurityResponse || ev.SecurityResponse == nil {
+			t.Fatalf("event %d: unexpected %+v", i, ev)
+		}
+		if ev.TraceID != "tr_1" || ev.SecurityResponse.TriggerEventID != "tool_1" {
+			t.Errorf("event %d not linked to trigger: trace=%q trigger=%q", i, ev.TraceID, ev.SecurityResponse.TriggerEventID)
+		}
+		if ev.SecurityResponse.Step != wantSteps[i] {
+			t.Errorf("event %d step = %d, want %d", i, ev.SecurityResponse.Step, wantSteps[i])
+		}
+	}
+	if sr := rec.events[1].SecurityResponse; sr.Action != actionNotify || sr.Status != "sending" {
+		t.Errorf("event 2 = %s %s, want notify sending", sr.Action, sr.Status)
+	}
+	if strings.Contains(rec.events[1].SecurityResponse.Detail, hook.URL) {
+		t.Errorf("recorded detail %q contains the full webhook URL", rec.events[1].SecurityResponse.Detail)
+	}
+}
+
+// failingAuditor refuses every event, like an unreachable auditd.
+type failingAuditor struct{ recordingAuditor }
+
+func (*failingAuditor) Record(context.Context, *audit.Event) error {
+	return errors.New("auditd unreachable")
+}
+
+func TestResponseEngine_NotifyNotSentUnrecorded(t *testing.T) {
+	sent := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer hook.Close()
+	pb := &ResponsePlaybook{Name: "page", Steps: []ResponseStep{{Action: actionNotify, Webhook: hook.URL, Message: "alert"}}}
+
+	for name, recorder := range map[string]audit.Auditor{
+		"no recorder":      nil,
+		"recorder failing": &failingAuditor{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := &responseEngine{recorder: recorder, client: http.DefaultClient}
+			results := e.run(context.Background(), pb, "hash_mismatch", &audit.Event{EventID: "e1"})
+			if len(results) != 1 || results[0].Status != "failed" {
+				t.Errorf("results = %+v, want one failure", results)
+			}
+			if sent != 0 {
+				t.Errorf("webhook called %d times, want 0", sent)
+			}
+		})
+	}
+}
+
+func TestResponseEngine_Annotate(t *testing.T) {
+	var gotPath string
+	var got map[string]any
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got) //nolint:errcheck
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"annotation_id":"ann_1"}`)) //nolint:errcheck
+	}))
+	defer gw.Close()
+
+	e := &responseEngine{gateway: gw.URL, client: http.DefaultClient}
+	pb := &ResponsePlaybook{Name: "page", Steps: []ResponseStep{{
+		Action: actionAnnotate,
+		Kind:   "pagerduty",
+		Ref:    "secbot-{{.EventID}}",
+		URL:    "https://acme.pagerduty.com/alerts?dedup_key=secbot-{{.EventID}}",
+	}}}
+	results := e.run(context.Background(), pb, "unauthorized_destructive", &audit.Event{EventID: "tool_9", TraceID: "tr_9"})
+	if len(results) != 1 || results[0].Status != "success" {
+		t.Fatalf("results = %+v, want one success", results)
+	}
+	if gotPath != "/api/v1/governance/traces/tr_9/annotations" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if got["kind"] != "pagerduty" || got["ref"] != "secbot-tool_9" || got["source"] != "secbot" {
+		t.Errorf("payload = %v", got)
+	}
+
+	// An alert without a trace has nothing to annotate.
+	results = e.run(context.Background(), pb, "unauthorized_destructive", &audit.Event{EventID: "tool_10"})
+	if results[0].Status != "failed" {
+		t.Errorf("status without trace = %q, want failed", results[0].Status)
+	}
+}
+
+func TestResponseEngine_DryRun(t *testing.T) {
+	rec := &recordingAuditor{}
+	e := &responseEngine{gateway: "http://127.0.0.1:1", recorder: rec, dryRun: true, client: http.DefaultClient}
+	pb := &ResponsePlaybook{Name: "p", Steps: []ResponseStep{
+		{Action: actionRequireApproval},
+		{Action: actionQueryAgent, Agent: "k8s", Message: "cordon"},
+	}}
+	results := e.run(context.Background(), pb, "hash_mismatch", &audit.Event{EventID: "e1"})
+	for i, r := range results {
+		if r.Status != "dry_run" {
+			t.Errorf("step %d status = %q, want dry_run", i+1, r.Status)
+		}
+	}
+	if len(rec.events) != 2 {
+		t.Errorf("recorded %d events, want 2", len(rec.events))
+	}
+}
@@ -13,10 +13,13 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
 	"time"
+
+	"helpdesk/internal/buildinfo"
 )
 
 // a2aResponse mirrors the gateway JSON response shape.
@@ -45,6 +48,8 @@ var anomalyKeywords = []string{
 }
 
 func main() {
+	buildinfo.HandleVersionFlag()
+
 	gateway := flag.String("gateway", "http://localhost:8080", "Gateway base URL")
 	conn := flag.String("conn", "", "PostgreSQL libpq connection string (required, e.g. host=db port=5432 dbname=mydb user=... password=...)")
 	apiKey := flag.String("api-key", envOrDefault("HELPDESK_CLIENT_API_KEY", ""), "Bearer token for gateway authentication")
@@ -55,6 +60,8 @@ func main() {
 	purpose := flag.String("purpose", "diagnostic", "X-Purpose header sent with every gateway request (e.g. diagnostic, remediation, maintenance)")
 	symptom := flag.String("symptom", "Users are reporting database connectivity issues.",
 		"Symptom description sent to the AI agent for diagnosis")
+	snapshotDB := flag.String("snapshot-db", "",
+		"Registered database name; when set, take pg_stat_statements snapshots before the diagnosis and after the incident, and print what changed")
 	flag.Parse()
 
 	if *conn == "" {
@@ -129,6 +136,16 @@ func main() {
 		}
 		logf("-force flag set, continuing anyway...")
 	}
+
+	// Snapshot statement statistics now, so the "after" snapshot at the end
+	// shows what ran during the incident.
+	snapshotLabel := fmt.Sprintf("srebot-%d", time.Now().Unix())
+	if *snapshotDB != "" {
+		logf("POST /api/v1/databases/%s/statement-snapshots  label=%s-before", *snapshotDB, snapshotLabel)
+		if _, err := statementSnapshot(*gateway, *apiKey, *purpose, *snapshotDB, snapshotLabel+"-before", ""); err != nil {
+			logf("WARNING: statement snapshot failed: %v", err)
+		}
+	}
 	fmt.Println()
 
 	// ── Phase 3: AI Diagnosis ─────────────────────────────────────────
@@ -202,6 +219,20 @@ func main() {
 		logf("Interrupted.")
 	}
 
+	if *snapshotDB != "" {
+		fmt.Println()
+		logPhase(6, "Statement Comparison")
+		logf("POST /api/v1/databases/%s/statement-snapshots  label=%s-after", *snapshotDB, snapshotLabel)
+		snapResp, err := statementSnapshot(*gateway, *apiKey, *purpose, *snapshotDB, snapshotLabel+"-after", snapshotLabel+"-before")
+		if err != nil {
+			logf("WARNING: statement snapshot failed: %v", err)
+		} else if i := strings.Index(snapResp.Text, "=== Changes since"); i != -1 {
+			printBox(snapResp.Text[i:])
+		} else {
+			printBox(snapResp.Text)
+		}
+	}
+
 	logf("Done.")
 }
 
@@ -218,6 +249,8 @@ func gatewayGET(baseURL, path, apiKey, purpose string) ([]byte, error) {
 	if purpose != "" {
 		req.Header.Set("X-Purpose", purpose)
 	}
+	// Incident-driven: jump ahead of background work queued for an agent.
+	req.Header.Set("X-Priority", "incident")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("GET %s: %w", path, err)
@@ -247,6 +280,8 @@ func gatewayPOST(baseURL, path, apiKey, purpose string, payload map[string]any)
 	if purpose != "" {
 		req.Header.Set("X-Purpose", purpose)
 	}
+	// Incident-driven: jump ahead of background work queued for an agent.
+	req.Header.Set("X-Priority", "incident")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("POST %s: %w", path, err)
@@ -267,6 +302,16 @@ func gatewayPOST(baseURL, path, apiKey, purpose string, payload map[string]any)
 
 // ── Anomaly detection ─────────────────────────────────────────────────────
 
+// statementSnapshot takes a labelled pg_stat_statements snapshot of db,
+// compared against the snapshot labelled compareTo when it is set.
+func statementSnapshot(baseURL, apiKey, purpose, db, label, compareTo string) (*a2aResponse, error) {
+	payload := map[string]any{"label": label}
+	if compareTo != "" {
+		payload["compare_to"] = compareTo
+	}
+	return gatewayPOST(baseURL, "/api/v1/databases/"+url.PathEscape(db)+"/statement-snapshots", apiKey, purpose, payload)
+}
+
 func hasAnomaly(text string) bool {
 	lower := strings.ToLower(text)
 	for _, kw := range anomalyKeywords {
@@ -67,6 +67,11 @@ if [[ "$VENDOR_LC" == "gemini" || "$VENDOR_LC" == "google" ]]; then
     AGENT_URLS="${AGENT_URLS},http://localhost:1106"
 fi
 
+# Add the knowledge base agent when a runbook/postmortem directory is configured.
+if [[ -n "${HELPDESK_KB_DIR:-}" ]]; then
+    AGENT_URLS="${AGENT_URLS},http://localhost:1107"
+fi
+
 PIDS=()
 LOGDIR="/tmp"
 
@@ -102,7 +107,7 @@ READONLY_GOVERNED=false
 for arg in "$@"; do
     case "$arg" in
         --stop)
-            for name in auditd database-agent k8s-agent sysadmin-agent incident-agent research-agent gateway auditor secbot; do
+            for name in auditd database-agent k8s-agent sysadmin-agent incident-agent research-agent kb-agent gateway auditor secbot; do
                 pkill -f "helpdesk.*${name}\|${SCRIPT_DIR}/${name}" 2>/dev/null || true
             done
             echo "Sent stop signal to helpdesk services."
@@ -221,6 +226,7 @@ K8S_AGENT_AUDIT_KEY="${K8S_AGENT_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 SYSADMIN_AGENT_AUDIT_KEY="${SYSADMIN_AGENT_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 INCIDENT_AGENT_AUDIT_KEY="${INCIDENT_AGENT_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 RESEARCH_AGENT_AUDIT_KEY="${RESEARCH_AGENT_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
+KB_AGENT_AUDIT_KEY="${KB_AGENT_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 GATEWAY_AUDIT_KEY="${GATEWAY_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 ORCHESTRATOR_AUDIT_KEY="${ORCHESTRATOR_API_KEY:-${HELPDESK_AUDIT_API_KEY:-}}"
 
@@ -262,6 +268,11 @@ if [[ "$VENDOR_LC" == "gemini" || "$VENDOR_LC" == "google" ]]; then
     HELPDESK_AUDIT_ENABLED="$AUDIT_ENABLED" HELPDESK_AUDIT_URL="$AUDIT_URL" HELPDESK_AUDIT_API_KEY="$RESEARCH_AGENT_AUDIT_KEY" HELPDESK_POLICY_ENABLED="$POLICY_ENABLED" HELPDESK_APPROVAL_ENABLED="${HELPDESK_APPROVAL_ENABLED:-}" HELPDESK_APPROVAL_TIMEOUT="${HELPDESK_APPROVAL_TIMEOUT:-5m}" start_bg research-agent "$SCRIPT_DIR/research-agent"
 fi
 
+# Start the knowledge base agent when HELPDESK_KB_DIR is set
+if [[ -n "${HELPDESK_KB_DIR:-}" ]]; then
+    HELPDESK_AUDIT_ENABLED="$AUDIT_ENABLED" HELPDESK_AUDIT_URL="$AUDIT_URL" HELPDESK_AUDIT_API_KEY="$KB_AGENT_AUDIT_KEY" HELPDESK_POLICY_ENABLED="$POLICY_ENABLED" HELPDESK_KB_DIR="$HELPDESK_KB_DIR" start_bg kb-agent "$SCRIPT_DIR/kb-agent"
+fi
+
 # Give agents a moment to bind their ports.
 sleep 2
 
@@ -0,0 +1,366 @@
+// Package agentlimit caps the number of calls in flight to each sub-agent, so
+// one busy client cannot saturate an agent and starve interactive users. A
+// call over an agent's cap waits for a free slot for up to the queue timeout
+// and is then rejected with an *OverloadError that says when to retry.
+//
+// Waiting calls are served by priority (see Priority), then in arrival order,
+// so incident-driven calls never queue behind background compliance scans.
+package agentlimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQueueTimeout is how long a call over the cap waits for a slot when
+// HELPDESK_AGENT_QUEUE_TIMEOUT is not set.
+const DefaultQueueTimeout = 10 * time.Second
+
+// AnyAgent is the Limits key whose cap applies to agents not listed by name.
+const AnyAgent = "*"
+
+// Config configures a Limiter.
+type Config struct {
+	// Limits caps concurrent calls per agent name. The AnyAgent entry
+	// applies to agents not listed; an agent with no entry is not limited.
+	Limits map[string]int
+	// QueueTimeout is how long a call over the cap waits for a slot before
+	// it is rejected. Zero rejects it at once.
+	QueueTimeout time.Duration
+	// MaxQueued bounds the calls waiting per agent; a call beyond it is
+	// rejected at once. Zero means no bound other than QueueTimeout.
+	MaxQueued int
+}
+
+// OverloadError is returned by Acquire when an agent has no free slot.
+type OverloadError struct {
+	Agent      string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *OverloadError) Error() string {
+	return fmt.Sprintf("agent %s is at its limit of %d concurrent calls; retry after %s", e.Agent, e.Limit, e.RetryAfter)
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, for a
+// Retry-After header.
+func (e *OverloadError) RetryAfterSeconds() int {
+	s := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
+
+// Stat is a snapshot of one limited agent.
+type Stat struct {
+	Agent    string `json:"agent"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+	Queued   int    `json:"queued"`
+	Rejected int64  `json:"rejected"`
+}
+
+// QueueStat is a snapshot of one priority's queue for a limited agent.
+// Admitted and WaitTotal count every call that got a slot at this priority,
+// including those that got one without waiting.
+type QueueStat struct {
+	Agent     string        `json:"agent"`
+	Priority  Priority      `json:"priority"`
+	Queued    int           `json:"queued"`
+	Admitted  int64         `json:"admitted"`
+	WaitTotal time.Duration `json:"wait_total"`
+}
+
+// Limiter hands out per-agent call slots. A nil *Limiter limits nothing.
+type Limiter struct {
+	cfg Config
+
+	mu     sync.Mutex
+	agents map[string]*slots
+}
+
+// slots is one agent's state; every field is guarded by Limiter.mu.
+type slots struct {
+	limit    int
+	inFlight int
+	waiters  []*waiter // highest priority first, then in arrival order
+	rejected int64
+	admitted [numPriorities]int64
+	waited   [numPriorities]time.Duration
+}
+
+// waiter is a call queued for a slot. ready receives nil when the call is
+// handed a slot, or an *OverloadError when it is evicted by a call of higher
+// priority.
+type waiter struct {
+	priority Priority
+	since    time.Time
+	ready    chan error
+}
+
+// New returns a Limiter for cfg.
+func New(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, agents: make(map[string]*slots)}
+}
+
+// FromEnv builds a Limiter from HELPDESK_AGENT_CONCURRENCY (see ParseLimits),
+// HELPDESK_AGENT_QUEUE_TIMEOUT and HELPDESK_AGENT_QUEUE_DEPTH. It returns nil
+// when HELPDESK_AGENT_CONCURRENCY is not set.
+func FromEnv() (*Limiter, error) {
+	spec := os.Getenv("HELPDESK_AGENT_CONCURRENCY")
+	if spec == "" {
+		return nil, nil
+	}
+	limits, err := ParseLimits(spec)
+	if err != nil {
+		return nil, fmt.Errorf("HELPDESK_AGENT_CONCURRENCY: %w", err)
+	}
+	cfg := Config{Limits: limits, QueueTimeout: DefaultQueueTimeout}
+	if v := os.Getenv("HELPDESK_AGENT_QUEUE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("HELPDESK_AGENT_QUEUE_TIMEOUT: invalid duration %q", v)
+		}
+		cfg.QueueTimeout = d
+	}
+	if v := os.Getenv("HELPDESK_AGENT_QUEUE_DEPTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("HELPDESK_AGENT_QUEUE_DEPTH: invalid count %q", v)
+		}
+		cfg.MaxQueued = n
+	}
+	return New(cfg), nil
+}
+
+// ParseLimits parses a comma-separated list of agent=N pairs, e.g.
+// "postgres_database_agent=4,k8s_agent=2,*=8".
+func ParseLimits(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || name == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid entry %q: want agent=N with N >= 1", pair)
+		}
+		limits[name] = n
+	}
+	if len(limits) == 0 {
+		return nil, fmt.Errorf("no limits in %q", spec)
+	}
+	return limits, nil
+}
+
+// Acquire takes a slot for a call to agent, waiting up to the queue timeout
+// when the agent is at its cap. Waiting calls are served by the priority set
+// on ctx with WithPriority (PriorityNormal by default), then in arrival order.
+// The returned release function must be called when the call ends; calling it
+// more than once is harmless. Acquire returns an *OverloadError when no slot
+// frees up in time or the call is evicted from a full queue, or ctx's error
+// when ctx ends first.
+func (l *Limiter) Acquire(ctx context.Context, agent string) (release func(), err error) {
+	s := l.slotsFor(agent)
+	if s == nil {
+		return func() {}, nil
+	}
+	p, _ := PriorityFromContext(ctx)
+
+	l.mu.Lock()
+	if s.inFlight < s.limit {
+		s.inFlight++
+		s.admit(p, 0)
+		l.mu.Unlock()
+		return l.releaser(s), nil
+	}
+	if l.cfg.QueueTimeout <= 0 || !l.makeRoom(agent, s, p) {
+		s.rejected++
+		l.mu.Unlock()
+		return nil, l.overload(agent, s)
+	}
+	w := &waiter{priority: p, since: time.Now(), ready: make(chan error, 1)}
+	s.enqueue(w)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-w.ready:
+		return l.granted(s, err)
+	case <-timer.C:
+		l.mu.Lock()
+		if s.dequeue(w) {
+			s.rejected++
+			l.mu.Unlock()
+			return nil, l.overload(agent, s)
+		}
+		l.mu.Unlock()
+		// A slot was handed over (or the call evicted) as the timer fired.
+		return l.granted(s, <-w.ready)
+	case <-ctx.Done():
+		l.mu.Lock()
+		if s.dequeue(w) {
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		l.mu.Unlock()
+		if err := <-w.ready; err == nil {
+			l.release(s)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of every limited agent that has been called,
+// sorted by agent name.
+func (l *Limiter) Stats() []Stat {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make([]Stat, 0, len(l.agents))
+	for name, s := range l.agents {
+		stats = append(stats, Stat{Agent: name, Limit: s.limit, InFlight: s.inFlight, Queued: len(s.waiters), Rejected: s.rejected})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Agent < stats[j].Agent })
+	return stats
+}
+
+// QueueStats returns a snapshot of every priority's queue for every limited
+// agent that has been called, sorted by agent name and then priority.
+func (l *Limiter) QueueStats() []QueueStat {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make([]QueueStat, 0, len(l.agents)*len(Priorities))
+	for name, s := range l.agents {
+		queued := make(map[Priority]int)
+		for _, w := range s.waiters {
+			queued[w.priority]++
+		}
+		for _, p := range Priorities {
+			stats = append(stats, QueueStat{Agent: name, Priority: p, Queued: queued[p], Admitted: s.admitted[p], WaitTotal: s.waited[p]})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Agent != stats[j].Agent {
+			return stats[i].Agent < stats[j].Agent
+		}
+		return stats[i].Priority < stats[j].Priority
+	})
+	return stats
+}
+
+// slotsFor returns agent's slots, creating them on first use, or nil when the
+// agent is not limited.
+func (l *Limiter) slotsFor(agent string) *slots {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.agents[agent]; ok {
+		return s
+	}
+	n, ok := l.cfg.Limits[agent]
+	if !ok {
+		n = l.cfg.Limits[AnyAgent]
+	}
+	if n < 1 {
+		return nil
+	}
+	s := &slots{limit: n}
+	l.agents[agent] = s
+	return s
+}
+
+// makeRoom reports whether a call at priority p may join s's queue. When the
+// queue is full it evicts the newest waiter of a lower priority, if any.
+// l.mu must be held.
+func (l *Limiter) makeRoom(agent string, s *slots, p Priority) bool {
+	if l.cfg.MaxQueued <= 0 || len(s.waiters) < l.cfg.MaxQueued {
+		return true
+	}
+	last := s.waiters[len(s.waiters)-1]
+	if last.priority >= p {
+		return false
+	}
+	s.waiters = s.waiters[:len(s.waiters)-1]
+	s.rejected++
+	last.ready <- l.overload(agent, s)
+	return true
+}
+
+// granted finishes an Acquire whose waiter was answered with err.
+func (l *Limiter) granted(s *slots, err error) (func(), error) {
+	if err != nil {
+		return nil, err
+	}
+	return l.releaser(s), nil
+}
+
+// release frees one of s's slots, handing it straight to the first waiter.
+func (l *Limiter) release(s *slots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(s.waiters) == 0 {
+		s.inFlight--
+		return
+	}
+	w := s.waiters[0]
+	s.waiters = s.waiters[1:]
+	s.admit(w.priority, time.Since(w.since))
+	w.ready <- nil
+}
+
+func (l *Limiter) releaser(s *slots) func() {
+	var once sync.Once
+	return func() { once.Do(func() { l.release(s) }) }
+}
+
+func (l *Limiter) overload(agent string, s *slots) *OverloadError {
+	retry := l.cfg.QueueTimeout
+	if retry < time.Second {
+		retry = time.Second
+	}
+	return &OverloadError{Agent: agent, Limit: s.limit, RetryAfter: retry}
+}
+
+// enqueue adds w behind every waiter of its priority or higher.
+func (s *slots) enqueue(w *waiter) {
+	i := sort.Search(len(s.waiters), func(i int) bool { return s.waiters[i].priority < w.priority })
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[i+1:], s.waiters[i:])
+	s.waiters[i] = w
+}
+
+// dequeue removes w from the queue, reporting whether it was still queued.
+func (s *slots) dequeue(w *waiter) bool {
+	for i, q := range s.waiters {
+		if q == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *slots) admit(p Priority, waited time.Duration) {
+	s.admitted[p]++
+	s.waited[p] += waited
+}
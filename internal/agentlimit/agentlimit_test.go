@@ -0,0 +1,236 @@
+package agentlimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimits(t *testing.T) {
+	got, err := ParseLimits(" postgres_database_agent=4, k8s_agent=2,*=8 ")
+	if err != nil {
+		t.Fatalf("ParseLimits: %v", err)
+	}
+	if len(got) != 3 || got["postgres_database_agent"] != 4 || got["k8s_agent"] != 2 || got[AnyAgent] != 8 {
+		t.Errorf("ParseLimits = %v", got)
+	}
+	for _, bad := range []string{"", "k8s_agent", "k8s_agent=0", "k8s_agent=many", "=3"} {
+		if _, err := ParseLimits(bad); err == nil {
+			t.Errorf("ParseLimits(%q) accepted", bad)
+		}
+	}
+}
+
+func TestLimiter_RejectsOverflowWithoutQueue(t *testing.T) {
+	l := New(Config{Limits: map[string]int{"db": 2}})
+	ctx := context.Background()
+	r1, err := l.Acquire(ctx, "db")
+	if err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+	if _, err := l.Acquire(ctx, "db"); err != nil {
+		t.Fatalf("second Acquire: %v", err)
+	}
+	_, err = l.Acquire(ctx, "db")
+	var overload *OverloadError
+	if !errors.As(err, &overload) || overload.Limit != 2 || overload.RetryAfterSeconds() != 1 {
+		t.Fatalf("third Acquire: err = %v, want an OverloadError for limit 2", err)
+	}
+	// Other agents are not limited; releasing frees a slot, once.
+	if _, err := l.Acquire(ctx, "k8s"); err != nil {
+		t.Errorf("unlimited agent: %v", err)
+	}
+	r1()
+	r1()
+	if _, err := l.Acquire(ctx, "db"); err != nil {
+		t.Errorf("Acquire after release: %v", err)
+	}
+	if _, err := l.Acquire(ctx, "db"); err == nil {
+		t.Error("a double release freed two slots")
+	}
+	stats := l.Stats()
+	if len(stats) != 1 || stats[0] != (Stat{Agent: "db", Limit: 2, InFlight: 2, Rejected: 2}) {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
+
+func TestLimiter_QueuesUntilSlotFrees(t *testing.T) {
+	l := New(Config{Limits: map[string]int{AnyAgent: 1}, QueueTimeout: 5 * time.Second, MaxQueued: 1})
+	ctx := context.Background()
+	release, _ := l.Acquire(ctx, "db")
+
+	got := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(ctx, "db")
+		if err == nil {
+			r()
+		}
+		got <- err
+	}()
+	// Wait for the second call to queue; a third finds the queue full.
+	for deadline := time.Now().Add(time.Second); l.Stats()[0].Queued == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("second call never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var overload *OverloadError
+	if _, err := l.Acquire(ctx, "db"); !errors.As(err, &overload) || overload.RetryAfterSeconds() != 5 {
+		t.Errorf("Acquire with a full queue: %v, want an OverloadError", err)
+	}
+	release()
+	if err := <-got; err != nil {
+		t.Errorf("queued Acquire: %v", err)
+	}
+}
+
+func TestLimiter_QueueTimeoutAndCancel(t *testing.T) {
+	l := New(Config{Limits: map[string]int{"db": 1}, QueueTimeout: 20 * time.Millisecond})
+	l.Acquire(context.Background(), "db") //nolint:errcheck
+
+	var overload *OverloadError
+	if _, err := l.Acquire(context.Background(), "db"); !errors.As(err, &overload) {
+		t.Errorf("Acquire past the queue timeout: %v, want an OverloadError", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Acquire(ctx, "db"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire with a cancelled context: %v", err)
+	}
+}
+
+func TestLimiter_Nil(t *testing.T) {
+	var l *Limiter
+	release, err := l.Acquire(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("nil Limiter: %v", err)
+	}
+	release()
+	if l.Stats() != nil {
+		t.Error("nil Limiter has stats")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("HELPDESK_AGENT_CONCURRENCY", "")
+	if l, err := FromEnv(); l != nil || err != nil {
+		t.Errorf("unset: %v, %v; want nil, nil", l, err)
+	}
+	t.Setenv("HELPDESK_AGENT_CONCURRENCY", "db=3")
+	t.Setenv("HELPDESK_AGENT_QUEUE_TIMEOUT", "0s")
+	t.Setenv("HELPDESK_AGENT_QUEUE_DEPTH", "4")
+	l, err := FromEnv()
+	if err != nil || l.cfg.Limits["db"] != 3 || l.cfg.QueueTimeout != 0 || l.cfg.MaxQueued != 4 {
+		t.Errorf("FromEnv = %+v, %v", l, err)
+	}
+	t.Setenv("HELPDESK_AGENT_QUEUE_TIMEOUT", "soon")
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv accepted an invalid queue timeout")
+	}
+}
+
+func TestLimiter_ServesQueueByPriority(t *testing.T) {
+	l := New(Config{Limits: map[string]int{"db": 1}, QueueTimeout: 5 * time.Second})
+	release, _ := l.Acquire(context.Background(), "db")
+
+	// Queue a background scan, then a normal call, then an incident call;
+	// the freed slots must go to them in the reverse order.
+	order := make(chan Priority, 3)
+	for i, p := range []Priority{PriorityBackground, PriorityNormal, PriorityIncident} {
+		go func() {
+			r, err := l.Acquire(WithPriority(context.Background(), p), "db")
+			if err != nil {
+				t.Errorf("Acquire at %s: %v", p, err)
+				order <- p
+				return
+			}
+			order <- p
+			r()
+		}()
+		waitQueued(t, l, i+1)
+	}
+	release()
+	for _, want := range []Priority{PriorityIncident, PriorityNormal, PriorityBackground} {
+		if got := <-order; got != want {
+			t.Errorf("slot went to %s, want %s", got, want)
+		}
+	}
+
+	var admitted int64
+	for _, q := range l.QueueStats() {
+		admitted += q.Admitted
+		if q.Priority != PriorityNormal && q.Admitted == 1 && q.WaitTotal <= 0 {
+			t.Errorf("%s: no wait time recorded for a queued call", q.Priority)
+		}
+	}
+	if admitted != 4 {
+		t.Errorf("QueueStats admitted %d calls, want 4", admitted)
+	}
+}
+
+func TestLimiter_IncidentEvictsBackgroundFromFullQueue(t *testing.T) {
+	l := New(Config{Limits: map[string]int{"db": 1}, QueueTimeout: 5 * time.Second, MaxQueued: 1})
+	release, _ := l.Acquire(context.Background(), "db")
+
+	evicted := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(WithPriority(context.Background(), PriorityBackground), "db")
+		evicted <- err
+	}()
+	waitQueued(t, l, 1)
+
+	// A normal call cannot displace a normal call, but an incident call
+	// displaces the background scan.
+	incident := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(WithPriority(context.Background(), PriorityIncident), "db")
+		if err == nil {
+			r()
+		}
+		incident <- err
+	}()
+	var overload *OverloadError
+	if err := <-evicted; !errors.As(err, &overload) {
+		t.Fatalf("background call: %v, want an OverloadError", err)
+	}
+	waitQueued(t, l, 1)
+	if _, err := l.Acquire(context.Background(), "db"); !errors.As(err, &overload) {
+		t.Errorf("normal call with a queue full of incident calls: %v, want an OverloadError", err)
+	}
+	release()
+	if err := <-incident; err != nil {
+		t.Errorf("incident call: %v", err)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	for s, want := range map[string]Priority{"incident": PriorityIncident, " High ": PriorityIncident, "normal": PriorityNormal, "background": PriorityBackground} {
+		if p, ok := ParsePriority(s); !ok || p != want {
+			t.Errorf("ParsePriority(%q) = %s, %v", s, p, ok)
+		}
+	}
+	if _, ok := ParsePriority("urgent"); ok {
+		t.Error("ParsePriority accepted an unknown name")
+	}
+	if PriorityForPurpose("emergency") != PriorityIncident || PriorityForPurpose("compliance") != PriorityBackground || PriorityForPurpose("diagnostic") != PriorityNormal {
+		t.Error("PriorityForPurpose mapping")
+	}
+	if p, ok := PriorityFromContext(context.Background()); ok || p != PriorityNormal {
+		t.Errorf("PriorityFromContext without a priority = %s, %v", p, ok)
+	}
+	if p, _ := PriorityFromContext(WithPriority(context.Background(), 7)); p != PriorityIncident {
+		t.Errorf("out-of-range priority not clamped: %s", p)
+	}
+}
+
+// waitQueued waits until n calls are queued for the limiter's only agent.
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); l.Stats()[0].Queued != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue never reached %d calls (stats %+v)", n, l.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,88 @@
+package agentlimit
+
+import (
+	"context"
+	"strings"
+)
+
+// Priority orders the calls waiting for an agent's slots. A freed slot goes
+// to the highest-priority waiter, and a full queue makes room for a call by
+// evicting the newest waiter of a lower priority.
+type Priority int
+
+const (
+	// PriorityBackground is for scheduled and automated work such as
+	// compliance scans, which can wait.
+	PriorityBackground Priority = iota
+	// PriorityNormal is the default: interactive queries and tool calls.
+	PriorityNormal
+	// PriorityIncident is for incident-driven troubleshooting (secbot,
+	// srebot, incident bundles, emergency purpose).
+	PriorityIncident
+
+	numPriorities = int(PriorityIncident) + 1
+)
+
+// Priorities lists every priority, lowest first.
+var Priorities = []Priority{PriorityBackground, PriorityNormal, PriorityIncident}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBackground:
+		return "background"
+	case PriorityIncident:
+		return "incident"
+	default:
+		return "normal"
+	}
+}
+
+// MarshalText encodes p by name, so JSON carries "incident" rather than 2.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// ParsePriority parses a priority name as sent in the X-Priority header.
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "background", "low":
+		return PriorityBackground, true
+	case "normal":
+		return PriorityNormal, true
+	case "incident", "high":
+		return PriorityIncident, true
+	}
+	return PriorityNormal, false
+}
+
+// PriorityForPurpose maps a declared request purpose to a priority:
+// emergency work is incident priority, compliance work is background and
+// everything else is normal.
+func PriorityForPurpose(purpose string) Priority {
+	switch purpose {
+	case "emergency":
+		return PriorityIncident
+	case "compliance":
+		return PriorityBackground
+	}
+	return PriorityNormal
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose calls Acquire slots at p. An
+// out-of-range p is clamped to the nearest priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	p = max(PriorityBackground, min(p, PriorityIncident))
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set by WithPriority, or
+// PriorityNormal and false when none was set.
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok {
+		return PriorityNormal, false
+	}
+	return p, true
+}
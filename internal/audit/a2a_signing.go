@@ -0,0 +1,336 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/google/uuid"
+
+	"helpdesk/internal/secrets"
+)
+
+// A2A request signing authenticates the gateway and orchestrator to the
+// agents they call. The caller signs each A2A message with an HMAC-SHA256
+// key it shares with that agent; the agent rejects messages that are
+// unsigned, badly signed, stale, or replayed. A process that can reach an
+// agent on the network but does not hold its key cannot invoke its tools
+// behind the gateway's back.
+const (
+	// A2ASignatureMetaKey is the message metadata key carrying the signature.
+	A2ASignatureMetaKey = "a2a_signature"
+
+	// A2ASignedAtMetaKey is the message metadata key carrying the signing
+	// time, in Unix seconds.
+	A2ASignedAtMetaKey = "a2a_signed_at"
+
+	// A2ASigningKeyEnv is the shared secret an agent verifies requests with,
+	// and the key callers use for agents without a key of their own.
+	A2ASigningKeyEnv = "HELPDESK_A2A_SIGNING_KEY"
+
+	// DefaultA2ASignatureWindow is how far a signing time may be from the
+	// agent's clock. Message IDs are remembered for this long to stop replays.
+	DefaultA2ASignatureWindow = 5 * time.Minute
+
+	// A2ASignatureViolationModule is the GovernanceViolation.Module of the
+	// events recorded when an agent rejects a request.
+	A2ASignatureViolationModule = "a2a_signature"
+
+	// A2ASignatureHeader carries the signature of a direct tool call
+	// (POST /tool/{name}). Those are plain JSON rather than A2A messages, so
+	// their signature travels in headers instead of metadata.
+	A2ASignatureHeader = "X-A2A-Signature"
+
+	// A2ASignedAtHeader carries the signing time of a direct tool call, in
+	// Unix seconds.
+	A2ASignedAtHeader = "X-A2A-Signed-At"
+
+	// A2ANonceHeader carries the random ID of a direct tool call, remembered
+	// like a message ID to stop replays.
+	A2ANonceHeader = "X-A2A-Nonce"
+)
+
+// Errors returned by A2AVerifier.Verify.
+var (
+	ErrA2AUnsigned         = errors.New("A2A request is not signed")
+	ErrA2ABadSignature     = errors.New("A2A request signature is invalid")
+	ErrA2ASignatureExpired = errors.New("A2A request signature is outside the allowed window")
+	ErrA2AReplayed         = errors.New("A2A request was already received")
+)
+
+// A2ASigningKeyEnvFor returns the environment variable holding the key a
+// caller uses for agentName: HELPDESK_A2A_SIGNING_KEY_ followed by the agent
+// name upper-cased, with characters other than letters and digits replaced
+// by underscores (e.g. HELPDESK_A2A_SIGNING_KEY_POSTGRES_DATABASE_AGENT).
+func A2ASigningKeyEnvFor(agentName string) string {
+	var b strings.Builder
+	b.WriteString(A2ASigningKeyEnv + "_")
+	for _, r := range strings.ToUpper(agentName) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// ResolveA2ASigningKey returns the key a caller signs requests to agentName
+// with: the agent's own key when set, otherwise the shared key. Either may be
+// a secrets reference. It returns nil when neither is set.
+func ResolveA2ASigningKey(ctx context.Context, agentName string) ([]byte, error) {
+	for _, env := range []string{A2ASigningKeyEnvFor(agentName), A2ASigningKeyEnv} {
+		v, err := secrets.Getenv(ctx, env)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", env, err)
+		}
+		if v != "" {
+			return []byte(v), nil
+		}
+	}
+	return nil, nil
+}
+
+// SignA2AMessage signs msg with key, adding the signing time and signature
+// to its metadata. The signature covers the message ID, parts and the rest
+// of the metadata, so none of them can be changed in transit. It is a no-op
+// when key is empty.
+func SignA2AMessage(msg *a2a.Message, key []byte) error {
+	if len(key) == 0 {
+		return nil
+	}
+	if msg.Metadata == nil {
+		msg.Metadata = make(map[string]any)
+	}
+	delete(msg.Metadata, A2ASignatureMetaKey)
+	msg.Metadata[A2ASignedAtMetaKey] = strconv.FormatInt(time.Now().Unix(), 10)
+	payload, err := a2aSigningPayload(string(msg.ID), msg.Parts, msg.Metadata)
+	if err != nil {
+		return err
+	}
+	msg.Metadata[A2ASignatureMetaKey] = a2aSignature(key, payload)
+	return nil
+}
+
+// SignA2AToolRequest signs a direct tool call with key, setting the signing
+// time, a fresh nonce and the signature as headers on req. The signature
+// covers the method, path, signing time, nonce and body. It is a no-op when
+// key is empty.
+func SignA2AToolRequest(req *http.Request, body, key []byte) {
+	if len(key) == 0 {
+		return
+	}
+	signedAt := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.New().String()
+	req.Header.Set(A2ASignedAtHeader, signedAt)
+	req.Header.Set(A2ANonceHeader, nonce)
+	req.Header.Set(A2ASignatureHeader, a2aSignature(key, a2aToolSigningPayload(req.Method, req.URL.Path, signedAt, nonce, body)))
+}
+
+func a2aToolSigningPayload(method, path, signedAt, nonce string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return []byte(method + "\n" + path + "\n" + signedAt + "\n" + nonce + "\n" + hex.EncodeToString(sum[:]))
+}
+
+// a2aSigningPayload builds the signed bytes. Parts and metadata are reduced
+// to canonical JSON (generic values, sorted keys) so that the caller, which
+// holds typed values, and the agent, which holds decoded JSON, agree on them.
+func a2aSigningPayload(messageID string, parts any, metadata map[string]any) ([]byte, error) {
+	meta := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		if k != A2ASignatureMetaKey {
+			meta[k] = v
+		}
+	}
+	canonParts, err := canonicalJSON(parts)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize message parts: %w", err)
+	}
+	canonMeta, err := canonicalJSON(meta)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize message metadata: %w", err)
+	}
+	payload := []byte(messageID + "\n")
+	payload = append(payload, canonParts...)
+	payload = append(payload, '\n')
+	payload = append(payload, canonMeta...)
+	return payload, nil
+}
+
+func canonicalJSON(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+func a2aSignature(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// A2AVerifier checks the signatures on incoming A2A requests and remembers
+// the message IDs it accepted so that a captured request cannot be replayed.
+type A2AVerifier struct {
+	key    []byte
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time // message ID -> signing time
+}
+
+// NewA2AVerifier returns a verifier for key. A window <= 0 uses
+// DefaultA2ASignatureWindow.
+func NewA2AVerifier(key []byte, window time.Duration) *A2AVerifier {
+	if window <= 0 {
+		window = DefaultA2ASignatureWindow
+	}
+	return &A2AVerifier{key: key, window: window, seen: make(map[string]time.Time)}
+}
+
+// Verify checks the signature of the message in an A2A JSON-RPC body.
+// Requests that carry no message (e.g. tasks/get) are rejected as unsigned:
+// with signing enabled every call must come from a key holder.
+func (v *A2AVerifier) Verify(body []byte, now time.Time) error {
+	var req struct {
+		Params struct {
+			Message *struct {
+				ID       string         `json:"messageId"`
+				Parts    any            `json:"parts"`
+				Metadata map[string]any `json:"metadata"`
+			} `json:"message"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Params.Message == nil {
+		return ErrA2AUnsigned
+	}
+	msg := req.Params.Message
+	sig, _ := msg.Metadata[A2ASignatureMetaKey].(string)
+	signedAtStr, _ := msg.Metadata[A2ASignedAtMetaKey].(string)
+	if sig == "" || signedAtStr == "" || msg.ID == "" {
+		return ErrA2AUnsigned
+	}
+	payload, err := a2aSigningPayload(msg.ID, msg.Parts, msg.Metadata)
+	if err != nil {
+		return ErrA2ABadSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(a2aSignature(v.key, payload))) {
+		return ErrA2ABadSignature
+	}
+	return v.accept(msg.ID, signedAtStr, now)
+}
+
+// VerifyToolRequest checks the signature headers of a direct tool call whose
+// body has already been read. Nonces share the replay window with message IDs.
+func (v *A2AVerifier) VerifyToolRequest(r *http.Request, body []byte, now time.Time) error {
+	sig := r.Header.Get(A2ASignatureHeader)
+	signedAtStr := r.Header.Get(A2ASignedAtHeader)
+	nonce := r.Header.Get(A2ANonceHeader)
+	if sig == "" || signedAtStr == "" || nonce == "" {
+		return ErrA2AUnsigned
+	}
+	payload := a2aToolSigningPayload(r.Method, r.URL.Path, signedAtStr, nonce, body)
+	if !hmac.Equal([]byte(sig), []byte(a2aSignature(v.key, payload))) {
+		return ErrA2ABadSignature
+	}
+	return v.accept("tool:"+nonce, signedAtStr, now)
+}
+
+// accept checks that a validly signed request is fresh and has not been seen
+// before, then remembers id for the rest of the window.
+func (v *A2AVerifier) accept(id, signedAtStr string, now time.Time) error {
+	unix, err := strconv.ParseInt(signedAtStr, 10, 64)
+	if err != nil {
+		return ErrA2ABadSignature
+	}
+	signedAt := time.Unix(unix, 0)
+	if d := now.Sub(signedAt); d > v.window || d < -v.window {
+		return ErrA2ASignatureExpired
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for seenID, t := range v.seen {
+		if now.Sub(t) > v.window {
+			delete(v.seen, seenID)
+		}
+	}
+	if _, ok := v.seen[id]; ok {
+		return ErrA2AReplayed
+	}
+	v.seen[id] = signedAt
+	return nil
+}
+
+// A2ASignatureMiddleware rejects A2A requests that fail verification with
+// 401 before they reach next. Each rejection is logged and, when auditor is
+// non-nil, recorded as a governance_violation event so that direct calls
+// bypassing the gateway show up in the audit trail.
+func A2ASignatureMiddleware(verifier *A2AVerifier, auditor Auditor, agentName string, next http.Handler) http.Handler {
+	return signatureMiddleware(func(_ *http.Request, body []byte) error {
+		return verifier.Verify(body, time.Now())
+	}, auditor, agentName, next)
+}
+
+// A2AToolSignatureMiddleware is A2ASignatureMiddleware for direct tool calls,
+// which carry their signature in headers (see SignA2AToolRequest).
+func A2AToolSignatureMiddleware(verifier *A2AVerifier, auditor Auditor, agentName string, next http.Handler) http.Handler {
+	return signatureMiddleware(func(r *http.Request, body []byte) error {
+		return verifier.VerifyToolRequest(r, body, time.Now())
+	}, auditor, agentName, next)
+}
+
+func signatureMiddleware(verify func(*http.Request, []byte) error, auditor Auditor, agentName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err := verify(r, body); err != nil {
+			slog.Warn("rejected A2A request", "agent", agentName, "remote_addr", r.RemoteAddr, "err", err)
+			if auditor != nil {
+				event := &Event{
+					EventID:   "gov_" + uuid.New().String()[:8],
+					Timestamp: time.Now().UTC(),
+					EventType: EventTypeGovernanceViolation,
+					Session:   Session{ID: agentName},
+					GovernanceViolation: &GovernanceViolation{
+						Module:      A2ASignatureViolationModule,
+						Severity:    "warning",
+						Description: fmt.Sprintf("agent %s rejected a request from %s: %v", agentName, r.RemoteAddr, err),
+						Remediation: "Check for processes calling the agent directly instead of through the gateway, and that callers share the agent's signing key",
+					},
+				}
+				if err := auditor.Record(r.Context(), event); err != nil {
+					slog.Warn("failed to record rejected A2A request", "err", err)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}) //nolint:errcheck
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a2aproject/a2a-go/a2a"
+)
+
+var testA2AKey = []byte("test-a2a-signing-key")
+
+// signedA2ABody builds a signed message/send JSON-RPC body the way the
+// gateway does.
+func signedA2ABody(t *testing.T, key []byte, text string) []byte {
+	t.Helper()
+	msg := a2a.NewMessage(a2a.MessageRoleUser, a2a.TextPart{Text: text})
+	msg.Metadata = map[string]any{
+		"trace_id":         "tr_abc",
+		"user_id":          "alice@example.com",
+		"roles":            []string{"dba"},
+		"purpose_explicit": true,
+	}
+	if err := SignA2AMessage(msg, key); err != nil {
+		t.Fatalf("SignA2AMessage: %v", err)
+	}
+	b, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "message/send",
+		"params":  a2a.MessageSendParams{Message: msg},
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+func TestA2AVerifier_AcceptsSignedRequest(t *testing.T) {
+	v := NewA2AVerifier(testA2AKey, 0)
+	if err := v.Verify(signedA2ABody(t, testA2AKey, "check replication lag"), time.Now()); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestA2AVerifier_Rejects(t *testing.T) {
+	body := signedA2ABody(t, testA2AKey, "check replication lag")
+	tests := []struct {
+		name string
+		body []byte
+		key  []byte
+		now  time.Time
+		want error
+	}{
+		{"unsigned", makeA2ABody(t, map[string]any{"trace_id": "tr_abc"}, "delete pod"), testA2AKey, time.Now(), ErrA2AUnsigned},
+		{"no message", []byte(`{"method":"tasks/get","params":{"id":"t1"}}`), testA2AKey, time.Now(), ErrA2AUnsigned},
+		{"wrong key", body, []byte("other-key"), time.Now(), ErrA2ABadSignature},
+		{"tampered text", bytes.Replace(body, []byte("check replication lag"), []byte("delete_pod web-0 now"), 1), testA2AKey, time.Now(), ErrA2ABadSignature},
+		{"tampered principal", bytes.Replace(body, []byte("alice@example.com"), []byte("admin@example.com"), 1), testA2AKey, time.Now(), ErrA2ABadSignature},
+		{"stale", body, testA2AKey, time.Now().Add(10 * time.Minute), ErrA2ASignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewA2AVerifier(tt.key, 0).Verify(tt.body, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestA2AVerifier_RejectsReplay(t *testing.T) {
+	v := NewA2AVerifier(testA2AKey, 0)
+	body := signedA2ABody(t, testA2AKey, "restart the deployment")
+	if err := v.Verify(body, time.Now()); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if err := v.Verify(body, time.Now()); !errors.Is(err, ErrA2AReplayed) {
+		t.Errorf("replayed Verify() = %v, want %v", err, ErrA2AReplayed)
+	}
+}
+
+func TestA2ASignatureMiddleware(t *testing.T) {
+	var recorded []*Event
+	auditor := auditorFunc(func(_ context.Context, e *Event) error {
+		recorded = append(recorded, e)
+		return nil
+	})
+	reached := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		w.WriteHeader(http.StatusOK)
+	})
+	h := A2ASignatureMiddleware(NewA2AVerifier(testA2AKey, 0), auditor, "k8s_agent", next)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/invoke", bytes.NewReader(signedA2ABody(t, testA2AKey, "list pods"))))
+	if rec.Code != http.StatusOK || reached != 1 {
+		t.Fatalf("signed request: status = %d, reached = %d", rec.Code, reached)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/invoke", bytes.NewReader(makeA2ABody(t, nil, "delete pod web-0"))))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request: status = %d, want 401", rec.Code)
+	}
+	if reached != 1 {
+		t.Errorf("unsigned request reached the agent")
+	}
+	if len(recorded) != 1 || recorded[0].GovernanceViolation == nil || recorded[0].GovernanceViolation.Module != A2ASignatureViolationModule {
+		t.Fatalf("expected one a2a_signature governance violation, got %+v", recorded)
+	}
+	if !strings.Contains(recorded[0].GovernanceViolation.Description, "k8s_agent") {
+		t.Errorf("description = %q, want agent name", recorded[0].GovernanceViolation.Description)
+	}
+}
+
+// signedToolRequest builds a signed direct tool call the way the gateway does.
+func signedToolRequest(key []byte, path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	SignA2AToolRequest(req, []byte(body), key)
+	return req
+}
+
+func TestA2AVerifier_VerifyToolRequest(t *testing.T) {
+	const body = `{"args":{"container_name":"pg-1"}}`
+	signed := signedToolRequest(testA2AKey, "/tool/register_infra_db", body)
+
+	retarget := signedToolRequest(testA2AKey, "/tool/register_infra_db", body)
+	retarget.URL.Path = "/tool/restart_container"
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		body string
+		key  []byte
+		now  time.Time
+		want error
+	}{
+		{"signed", signed, body, testA2AKey, time.Now(), nil},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/tool/register_infra_db", nil), body, testA2AKey, time.Now(), ErrA2AUnsigned},
+		{"wrong key", signed, body, []byte("other-key"), time.Now(), ErrA2ABadSignature},
+		{"tampered body", signed, `{"args":{"container_name":"pg-2"}}`, testA2AKey, time.Now(), ErrA2ABadSignature},
+		{"other tool", retarget, body, testA2AKey, time.Now(), ErrA2ABadSignature},
+		{"stale", signed, body, testA2AKey, time.Now().Add(10 * time.Minute), ErrA2ASignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewA2AVerifier(tt.key, 0).VerifyToolRequest(tt.req, []byte(tt.body), tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyToolRequest() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestA2AVerifier_VerifyToolRequestRejectsReplay(t *testing.T) {
+	const body = `{"args":{}}`
+	v := NewA2AVerifier(testA2AKey, 0)
+	req := signedToolRequest(testA2AKey, "/tool/check_host", body)
+	if err := v.VerifyToolRequest(req, []byte(body), time.Now()); err != nil {
+		t.Fatalf("first VerifyToolRequest: %v", err)
+	}
+	if err := v.VerifyToolRequest(req, []byte(body), time.Now()); !errors.Is(err, ErrA2AReplayed) {
+		t.Errorf("replayed VerifyToolRequest() = %v, want %v", err, ErrA2AReplayed)
+	}
+}
+
+func TestA2AToolSignatureMiddleware(t *testing.T) {
+	var recorded []*Event
+	auditor := auditorFunc(func(_ context.Context, e *Event) error {
+		recorded = append(recorded, e)
+		return nil
+	})
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	})
+	h := A2AToolSignatureMiddleware(NewA2AVerifier(testA2AKey, 0), auditor, "sysadmin_agent", next)
+
+	const body = `{"args":{"container_name":"pg-1"}}`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signedToolRequest(testA2AKey, "/tool/restart_container", body))
+	if rec.Code != http.StatusOK || gotBody != body {
+		t.Fatalf("signed request: status = %d, body reaching tool = %q", rec.Code, gotBody)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tool/restart_container", strings.NewReader(body)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request: status = %d, want 401", rec.Code)
+	}
+	if len(recorded) != 1 || recorded[0].GovernanceViolation == nil || recorded[0].GovernanceViolation.Module != A2ASignatureViolationModule {
+		t.Fatalf("expected one a2a_signature governance violation, got %+v", recorded)
+	}
+}
+
+func TestA2ASigningKeyEnvFor(t *testing.T) {
+	if got := A2ASigningKeyEnvFor("postgres_database_agent"); got != "HELPDESK_A2A_SIGNING_KEY_POSTGRES_DATABASE_AGENT" {
+		t.Errorf("got %q", got)
+	}
+	if got := A2ASigningKeyEnvFor("k8s-agent"); got != "HELPDESK_A2A_SIGNING_KEY_K8S_AGENT" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -49,6 +49,14 @@ var ToolClassification = map[string]ActionClass{
 	"drop_replication_slot":       ActionDestructive,
 	"reset_pg_setting":            ActionWrite,
 	"reset_cache_stats":           ActionWrite,
+	"get_patroni_status":          ActionRead,
+	"trigger_switchover":          ActionDestructive,
+	"pause_autofailover":          ActionWrite,
+	"resume_autofailover":         ActionWrite,
+	"get_statement_snapshot":      ActionRead,
+	"reset_statement_stats":       ActionWrite,
+	"get_backup_status":           ActionRead,
+	"verify_backup_repo":          ActionRead,
 	"get_status_summary":          ActionRead,
 	"get_pg_settings":             ActionRead,
 	"get_extensions":              ActionRead,
@@ -74,9 +82,16 @@ var ToolClassification = map[string]ActionClass{
 	"describe_pod":       ActionRead,
 	"get_pod_resources": ActionRead,
 	"get_node_status":   ActionRead,
+	"get_pvc_status":    ActionRead,
+	"get_storageclass":  ActionRead,
+	"get_namespaces":    ActionRead,
+	"describe_pv":       ActionRead,
 	"scale_deployment":   ActionDestructive,
 	"restart_deployment": ActionDestructive,
 	"delete_pod":         ActionDestructive,
+	"cordon_node":        ActionDestructive,
+	"drain_node":         ActionDestructive,
+	"uncordon_node":      ActionDestructive,
 
 	// Rollback operations — same action class as the original mutation they reverse
 	"rollback_scale_deployment": ActionDestructive,
@@ -0,0 +1,330 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+)
+
+// ErrAlertNotFound is returned when an alert ID does not exist.
+var ErrAlertNotFound = errors.New("alert not found")
+
+// AlertRecord is a security alert raised by the auditor, as reported to
+// auditd, together with the operator's feedback on it. Alerts are the
+// auditor's output, not audit events, and are kept outside the hash chain.
+type AlertRecord struct {
+	AlertID    string    `json:"alert_id"`
+	Rule       string    `json:"rule"`               // alert type, e.g. "param_first_seen"
+	Severity   string    `json:"severity"`           // severity after feedback was applied
+	Agent      string    `json:"agent,omitempty"`    // agent the triggering event came from
+	Resource   string    `json:"resource,omitempty"` // namespace, database or host the event touched
+	Message    string    `json:"message"`
+	EventID    string    `json:"event_id,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	Suppressed bool      `json:"suppressed,omitempty"` // matched enough false positives to be silenced
+	TenantID   string    `json:"tenant_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// MaintenanceWindow is the window the alert was raised in, which lowered
+	// its severity; empty outside maintenance.
+	MaintenanceWindow string `json:"maintenance_window,omitempty"`
+
+	// Operator feedback. ResourcePattern is a path.Match glob over Resource
+	// that future alerts of the same rule and agent must match to count as
+	// the same false positive; empty means the exact resource.
+	FalsePositive   bool       `json:"false_positive,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	ResourcePattern string     `json:"resource_pattern,omitempty"`
+	AckedBy         string     `json:"acked_by,omitempty"`
+	AckedAt         *time.Time `json:"acked_at,omitempty"`
+}
+
+// AlertSuppression is a rule + agent + resource pattern that operators have
+// marked as a false positive Count times. The auditor down-weights matching
+// alerts and silences them once Count reaches its threshold.
+type AlertSuppression struct {
+	Rule            string `json:"rule"`
+	Agent           string `json:"agent"`
+	ResourcePattern string `json:"resource_pattern"`
+	Count           int    `json:"count"`
+}
+
+// Matches reports whether an alert falls under the suppression.
+func (s AlertSuppression) Matches(rule, agent, resource string) bool {
+	if s.Rule != rule || s.Agent != agent {
+		return false
+	}
+	ok, err := path.Match(s.ResourcePattern, resource)
+	return err == nil && ok
+}
+
+// RulePrecision is how often one alert rule was right over a window:
+// the share of its delivered alerts that operators did not mark as false
+// positives.
+type RulePrecision struct {
+	Rule           string  `json:"rule"`
+	Fired          int     `json:"fired"`           // alerts delivered (not suppressed)
+	FalsePositives int     `json:"false_positives"` // of those, marked false positive
+	Suppressed     int     `json:"suppressed"`      // alerts silenced by earlier feedback
+	Precision      float64 `json:"precision"`       // (fired - false_positives) / fired; 1 when nothing fired
+}
+
+// AlertStore persists auditor alerts and the feedback on them. It shares the
+// same *sql.DB connection as the audit Store.
+type AlertStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewAlertStore creates the security_alerts table (if absent) and returns a
+// ready-to-use AlertStore.
+func NewAlertStore(db *sql.DB, isPostgres bool) (*AlertStore, error) {
+	s := &AlertStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS security_alerts (
+    alert_id         TEXT NOT NULL PRIMARY KEY,
+    rule             TEXT NOT NULL,
+    severity         TEXT NOT NULL DEFAULT '',
+    agent            TEXT NOT NULL DEFAULT '',
+    resource         TEXT NOT NULL DEFAULT '',
+    message          TEXT NOT NULL DEFAULT '',
+    event_id         TEXT NOT NULL DEFAULT '',
+    trace_id         TEXT NOT NULL DEFAULT '',
+    suppressed       INTEGER NOT NULL DEFAULT 0,
+    tenant_id        TEXT NOT NULL DEFAULT '',
+    created_at       TEXT NOT NULL,
+    false_positive   INTEGER NOT NULL DEFAULT 0,
+    reason           TEXT NOT NULL DEFAULT '',
+    resource_pattern TEXT NOT NULL DEFAULT '',
+    acked_by         TEXT NOT NULL DEFAULT '',
+    acked_at         TEXT NOT NULL DEFAULT '',
+    maintenance_window TEXT NOT NULL DEFAULT ''
+)`); err != nil {
+		return nil, fmt.Errorf("create security_alerts schema: %w", err)
+	}
+	// Migrate tables created before maintenance windows. SQLite has no ADD
+	// COLUMN IF NOT EXISTS, so the duplicate-column error is ignored.
+	if isPostgres {
+		db.Exec(`ALTER TABLE security_alerts ADD COLUMN IF NOT EXISTS maintenance_window TEXT NOT NULL DEFAULT ''`) //nolint:errcheck
+	} else {
+		db.Exec(`ALTER TABLE security_alerts ADD COLUMN maintenance_window TEXT NOT NULL DEFAULT ''`) //nolint:errcheck
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_security_alerts_created
+    ON security_alerts(created_at)`); err != nil {
+		return nil, fmt.Errorf("create security_alerts index: %w", err)
+	}
+	return s, nil
+}
+
+// Record stores an alert reported by the auditor. Reporting the same alert
+// ID twice is a no-op, so the auditor may retry.
+func (s *AlertStore) Record(ctx context.Context, a *AlertRecord) error {
+	if a.AlertID == "" || a.Rule == "" {
+		return fmt.Errorf("alert_id and rule are required")
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO security_alerts
+    (alert_id, rule, severity, agent, resource, message, event_id, trace_id, suppressed, tenant_id, created_at, maintenance_window)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(alert_id) DO NOTHING`),
+		a.AlertID, a.Rule, a.Severity, a.Agent, a.Resource, a.Message, a.EventID, a.TraceID,
+		boolToInt(a.Suppressed), a.TenantID, a.CreatedAt.UTC().Format(annotationTimeFormat), a.MaintenanceWindow)
+	if err != nil {
+		return fmt.Errorf("insert alert: %w", err)
+	}
+	return nil
+}
+
+// Get returns one alert.
+func (s *AlertStore) Get(ctx context.Context, alertID string) (*AlertRecord, error) {
+	all, err := s.query(ctx, "WHERE alert_id = ?", alertID)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, ErrAlertNotFound
+	}
+	return &all[0], nil
+}
+
+// List returns alerts created at or after since, newest first. rule filters
+// by alert type when non-empty.
+func (s *AlertStore) List(ctx context.Context, since time.Time, rule string, limit int) ([]AlertRecord, error) {
+	where, args := "WHERE created_at >= ?", []any{since.UTC().Format(annotationTimeFormat)}
+	if rule != "" {
+		where += " AND rule = ?"
+		args = append(args, rule)
+	}
+	all, err := s.query(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.After(all[j].CreatedAt) })
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// MarkFalsePositive records that an alert was a false positive. pattern is
+// the resource glob future alerts must match to be treated the same way;
+// empty means the alert's own resource. Marking an alert again replaces the
+// earlier feedback.
+func (s *AlertStore) MarkFalsePositive(ctx context.Context, alertID, reason, pattern, by string) (*AlertRecord, error) {
+	a, err := s.Get(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if pattern == "" {
+		pattern = a.Resource
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid resource_pattern %q: %w", pattern, err)
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE security_alerts
+SET false_positive = 1, reason = ?, resource_pattern = ?, acked_by = ?, acked_at = ?
+WHERE alert_id = ?`), reason, pattern, by, now.Format(annotationTimeFormat), alertID); err != nil {
+		return nil, fmt.Errorf("mark alert false positive: %w", err)
+	}
+	a.FalsePositive, a.Reason, a.ResourcePattern, a.AckedBy, a.AckedAt = true, reason, pattern, by, &now
+	return a, nil
+}
+
+// Suppressions groups the false-positive feedback by rule, agent and
+// resource pattern, most frequent first.
+func (s *AlertStore) Suppressions(ctx context.Context) ([]AlertSuppression, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT rule, agent, resource_pattern, COUNT(*)
+FROM security_alerts
+WHERE false_positive = 1
+GROUP BY rule, agent, resource_pattern
+ORDER BY COUNT(*) DESC, rule, agent, resource_pattern`)
+	if err != nil {
+		return nil, fmt.Errorf("list alert suppressions: %w", err)
+	}
+	defer rows.Close()
+	out := []AlertSuppression{}
+	for rows.Next() {
+		var sup AlertSuppression
+		if err := rows.Scan(&sup.Rule, &sup.Agent, &sup.ResourcePattern, &sup.Count); err != nil {
+			return nil, fmt.Errorf("scan alert suppression: %w", err)
+		}
+		out = append(out, sup)
+	}
+	return out, rows.Err()
+}
+
+// Precision returns per-rule precision for alerts created in [since, until),
+// lowest precision first. A zero until means now.
+func (s *AlertStore) Precision(ctx context.Context, since, until time.Time) ([]RulePrecision, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT rule,
+       SUM(CASE WHEN suppressed = 0 THEN 1 ELSE 0 END),
+       SUM(CASE WHEN suppressed = 0 AND false_positive = 1 THEN 1 ELSE 0 END),
+       SUM(CASE WHEN suppressed = 1 THEN 1 ELSE 0 END)
+FROM security_alerts
+WHERE created_at >= ? AND created_at < ?
+GROUP BY rule`), since.UTC().Format(annotationTimeFormat), until.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("query alert precision: %w", err)
+	}
+	defer rows.Close()
+	out := []RulePrecision{}
+	for rows.Next() {
+		var p RulePrecision
+		if err := rows.Scan(&p.Rule, &p.Fired, &p.FalsePositives, &p.Suppressed); err != nil {
+			return nil, fmt.Errorf("scan alert precision: %w", err)
+		}
+		p.Precision = 1
+		if p.Fired > 0 {
+			p.Precision = float64(p.Fired-p.FalsePositives) / float64(p.Fired)
+		}
+		out = append(out, p)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Precision != out[j].Precision {
+			return out[i].Precision < out[j].Precision
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out, rows.Err()
+}
+
+// CountByMaintenanceWindow returns how many alerts created in [since,
+// until) each maintenance window tagged. A zero until means now.
+func (s *AlertStore) CountByMaintenanceWindow(ctx context.Context, since, until time.Time) (map[string]int, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT maintenance_window, COUNT(*)
+FROM security_alerts
+WHERE maintenance_window <> '' AND created_at >= ? AND created_at < ?
+GROUP BY maintenance_window`), since.UTC().Format(annotationTimeFormat), until.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("count alerts by maintenance window: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan maintenance window alert count: %w", err)
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
+
+func (s *AlertStore) query(ctx context.Context, where string, args ...any) ([]AlertRecord, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT alert_id, rule, severity, agent, resource, message, event_id, trace_id, suppressed, tenant_id,
+       created_at, false_positive, reason, resource_pattern, acked_by, acked_at, maintenance_window
+FROM security_alerts
+`+where+`
+ORDER BY created_at, alert_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AlertRecord
+	for rows.Next() {
+		var a AlertRecord
+		var suppressed, falsePositive int
+		var createdAt, ackedAt string
+		if err := rows.Scan(&a.AlertID, &a.Rule, &a.Severity, &a.Agent, &a.Resource, &a.Message,
+			&a.EventID, &a.TraceID, &suppressed, &a.TenantID, &createdAt, &falsePositive,
+			&a.Reason, &a.ResourcePattern, &a.AckedBy, &ackedAt, &a.MaintenanceWindow); err != nil {
+			return nil, fmt.Errorf("scan alert: %w", err)
+		}
+		a.Suppressed = suppressed != 0
+		a.FalsePositive = falsePositive != 0
+		a.CreatedAt = parseFlexTime(createdAt)
+		if ackedAt != "" {
+			t := parseFlexTime(ackedAt)
+			a.AckedAt = &t
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertStore_FeedbackAndPrecision(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := NewAlertStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewAlertStore: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, a := range []*AlertRecord{
+		{AlertID: "alert_1", Rule: "param_first_seen", Agent: "k8s_agent", Resource: "staging-1", CreatedAt: base},
+		{AlertID: "alert_2", Rule: "param_first_seen", Agent: "k8s_agent", Resource: "staging-2", CreatedAt: base.Add(time.Minute)},
+		{AlertID: "alert_3", Rule: "param_first_seen", Agent: "k8s_agent", Resource: "prod", CreatedAt: base.Add(2 * time.Minute)},
+		{AlertID: "alert_4", Rule: "param_first_seen", Agent: "k8s_agent", Resource: "staging-3", Suppressed: true, CreatedAt: base.Add(3 * time.Minute)},
+		{AlertID: "alert_5", Rule: "prompt_injection", Agent: "k8s_agent", CreatedAt: base.Add(4 * time.Minute)},
+	} {
+		if err := as.Record(ctx, a); err != nil {
+			t.Fatalf("Record %d: %v", i, err)
+		}
+	}
+	// Re-reporting an alert is a no-op.
+	if err := as.Record(ctx, &AlertRecord{AlertID: "alert_1", Rule: "other"}); err != nil {
+		t.Fatalf("Record duplicate: %v", err)
+	}
+
+	if _, err := as.MarkFalsePositive(ctx, "alert_1", "staging churn", "staging-*", "alice"); err != nil {
+		t.Fatalf("MarkFalsePositive: %v", err)
+	}
+	a, err := as.MarkFalsePositive(ctx, "alert_2", "staging churn", "staging-*", "bob")
+	if err != nil {
+		t.Fatalf("MarkFalsePositive: %v", err)
+	}
+	if !a.FalsePositive || a.AckedBy != "bob" || a.AckedAt == nil || a.Rule != "param_first_seen" {
+		t.Errorf("marked = %+v", a)
+	}
+	if _, err := as.MarkFalsePositive(ctx, "alert_3", "bad glob", "[", "alice"); err == nil {
+		t.Error("MarkFalsePositive with invalid pattern succeeded")
+	}
+	if _, err := as.MarkFalsePositive(ctx, "alert_missing", "x", "", "alice"); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("MarkFalsePositive(missing) err = %v, want ErrAlertNotFound", err)
+	}
+
+	sups, err := as.Suppressions(ctx)
+	if err != nil {
+		t.Fatalf("Suppressions: %v", err)
+	}
+	if len(sups) != 1 || sups[0].Count != 2 || !sups[0].Matches("param_first_seen", "k8s_agent", "staging-9") || sups[0].Matches("param_first_seen", "k8s_agent", "prod") {
+		t.Fatalf("Suppressions = %+v, want one staging-* suppression with count 2", sups)
+	}
+
+	prec, err := as.Precision(ctx, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Precision: %v", err)
+	}
+	if len(prec) != 2 {
+		t.Fatalf("Precision = %+v, want 2 rules", prec)
+	}
+	if p := prec[0]; p.Rule != "param_first_seen" || p.Fired != 3 || p.FalsePositives != 2 || p.Suppressed != 1 {
+		t.Errorf("param_first_seen precision = %+v", p)
+	}
+	if p := prec[1]; p.Rule != "prompt_injection" || p.Precision != 1 {
+		t.Errorf("prompt_injection precision = %+v", p)
+	}
+
+	list, err := as.List(ctx, base, "param_first_seen", 2)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].AlertID != "alert_4" || list[1].AlertID != "alert_3" {
+		t.Errorf("List = %+v, want alert_4, alert_3", list)
+	}
+}
@@ -54,6 +54,18 @@ type Approval struct {
 
 	// ExpiresAt is when the approval expires (for time-limited approvals).
 	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// ApprovalID is the auditd approval request that authorised the action,
+	// when a human approved it. auditd links the event's outcome back to it.
+	ApprovalID string `json:"approval_id,omitempty"`
+}
+
+// IsSelfApproval reports whether approvedBy is the principal who requested
+// the action. Identities are compared case-insensitively, as identity
+// providers differ in how they case user IDs; an empty side never matches.
+func IsSelfApproval(requestedBy, approvedBy string) bool {
+	requestedBy, approvedBy = strings.TrimSpace(requestedBy), strings.TrimSpace(approvedBy)
+	return requestedBy != "" && strings.EqualFold(requestedBy, approvedBy)
 }
 
 // IsValid returns true if the approval is valid and not expired.
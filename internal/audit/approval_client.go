@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"helpdesk/internal/identity"
 )
 
 // ApprovalClient provides an HTTP client for agents to interact with the approval API.
@@ -70,6 +74,7 @@ func (c *ApprovalClient) WithUser(userID string) *ApprovalClient {
 type ApprovalCreateRequest struct {
 	EventID      string         `json:"event_id,omitempty"`
 	TraceID      string         `json:"trace_id,omitempty"`
+	TenantID     string         `json:"tenant_id,omitempty"` // defaults to the tenant of the principal in ctx
 	ActionClass  string         `json:"action_class"`
 	ToolName     string         `json:"tool_name,omitempty"`
 	AgentName    string         `json:"agent_name,omitempty"`
@@ -81,6 +86,11 @@ type ApprovalCreateRequest struct {
 	ApproverRole string         `json:"approver_role,omitempty"`
 	ExpiresInMin int            `json:"expires_in_minutes,omitempty"`
 	CallbackURL  string         `json:"callback_url,omitempty"`
+	DryRun       bool           `json:"dry_run,omitempty"` // set by DryRunApproval
+
+	// IdempotencyKey is sent as the Idempotency-Key header. A caller that
+	// retries CreateApproval with the same key creates one approval.
+	IdempotencyKey string `json:"-"`
 }
 
 // ApprovalCreateResponse is the response from creating an approval.
@@ -92,6 +102,9 @@ type ApprovalCreateResponse struct {
 
 // CreateApproval creates a new approval request.
 func (c *ApprovalClient) CreateApproval(ctx context.Context, req ApprovalCreateRequest) (*ApprovalCreateResponse, error) {
+	if req.TenantID == "" {
+		req.TenantID = PrincipalFromContext(ctx).Tenant
+	}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -102,6 +115,9 @@ func (c *ApprovalClient) CreateApproval(ctx context.Context, req ApprovalCreateR
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if req.IdempotencyKey != "" {
+		httpReq.Header.Set(IdempotencyKeyHeader, req.IdempotencyKey)
+	}
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -126,6 +142,31 @@ func (c *ApprovalClient) CreateApproval(ctx context.Context, req ApprovalCreateR
 	return &result, nil
 }
 
+// ApprovalDryRunResponse is the response to a dry-run approval request.
+type ApprovalDryRunResponse struct {
+	DryRun bool   `json:"dry_run"`
+	Status string `json:"status"` // the status the approval would have been created with
+	Notify bool   `json:"notify"` // auditd has a notifier configured for approvers
+}
+
+// DryRunApproval submits req as a dry run: auditd authenticates and
+// validates it exactly as CreateApproval but creates no approval and
+// notifies no one.
+func (c *ApprovalClient) DryRunApproval(ctx context.Context, req ApprovalCreateRequest) (*ApprovalDryRunResponse, error) {
+	if req.TenantID == "" {
+		req.TenantID = PrincipalFromContext(ctx).Tenant
+	}
+	req.DryRun = true
+	var result ApprovalDryRunResponse
+	if err := c.postJSON(ctx, "/v1/approvals", req, http.StatusOK, &result); err != nil {
+		return nil, err
+	}
+	if !result.DryRun {
+		return nil, fmt.Errorf("auditd does not support dry-run approvals")
+	}
+	return &result, nil
+}
+
 // GetApproval retrieves an approval by ID.
 func (c *ApprovalClient) GetApproval(ctx context.Context, approvalID string) (*StoredApproval, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/approvals/"+approvalID, nil)
@@ -165,6 +206,10 @@ func (c *ApprovalClient) GetApproval(ctx context.Context, approvalID string) (*S
 func (c *ApprovalClient) WaitForApproval(ctx context.Context, approvalID string, timeout time.Duration) (*StoredApproval, error) {
 	const pollChunk = 90 * time.Second // stay under the server's 120 s cap
 
+	// The wait is an approval phase of the request, not tool execution time.
+	start := time.Now()
+	defer func() { PhaseTimerFromContext(ctx).AddApprovalWait(time.Since(start)) }()
+
 	// Preserve the auth transport from c.httpClient (set by WithAPIKey/WithUser).
 	transport := c.httpClient.Transport
 	if transport == nil {
@@ -232,6 +277,7 @@ type ApprovalListOptions struct {
 	TraceID     string
 	RequestedBy string
 	ToolName    string
+	TenantID    string
 	Limit       int
 }
 
@@ -253,6 +299,9 @@ func (c *ApprovalClient) ListApprovals(ctx context.Context, opts ApprovalListOpt
 	if opts.ToolName != "" {
 		q.Set("tool_name", opts.ToolName)
 	}
+	if opts.TenantID != "" {
+		q.Set("tenant_id", opts.TenantID)
+	}
 	if opts.Limit > 0 {
 		q.Set("limit", fmt.Sprintf("%d", opts.Limit))
 	}
@@ -415,3 +464,136 @@ func (c *ApprovalClient) CancelApproval(ctx context.Context, approvalID, cancell
 
 	return nil
 }
+
+// PlanCreateRequest is the request body for proposing a remediation plan.
+type PlanCreateRequest struct {
+	Title       string                     `json:"title"`
+	TraceID     string                     `json:"trace_id,omitempty"`
+	TenantID    string                     `json:"tenant_id,omitempty"` // defaults to the tenant of the principal in ctx
+	AgentName   string                     `json:"agent_name,omitempty"`
+	RequestedBy string                     `json:"requested_by"`
+	Principal   identity.ResolvedPrincipal `json:"principal,omitempty"`
+	Purpose     string                     `json:"purpose,omitempty"`
+	PurposeNote string                     `json:"purpose_note,omitempty"`
+	Steps       []RemediationPlanStep      `json:"steps"`
+}
+
+// CreatePlan proposes a remediation plan. auditd evaluates every step against
+// policy and returns the stored plan, or an error if any step is denied.
+func (c *ApprovalClient) CreatePlan(ctx context.Context, req PlanCreateRequest) (*RemediationPlan, error) {
+	if req.TenantID == "" {
+		req.TenantID = PrincipalFromContext(ctx).Tenant
+	}
+	var plan RemediationPlan
+	if err := c.postJSON(ctx, "/v1/remediation-plans", req, http.StatusCreated, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetPlan retrieves a remediation plan by ID.
+func (c *ApprovalClient) GetPlan(ctx context.Context, planID string) (*RemediationPlan, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/remediation-plans/"+url.PathEscape(planID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("remediation plan not found: %s", planID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+	var plan RemediationPlan
+	if err := json.Unmarshal(respBody, &plan); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return &plan, nil
+}
+
+// PlanStepRejectedError is returned by ExecutePlanStep when auditd refuses
+// the call: it is not the next approved step of the plan.
+type PlanStepRejectedError struct {
+	PlanID string
+	Reason string
+}
+
+func (e *PlanStepRejectedError) Error() string {
+	return fmt.Sprintf("rejected by remediation plan %s: %s", e.PlanID, e.Reason)
+}
+
+// ExecutePlanStep claims the plan step matching call just before it runs.
+// A call that is not the next approved step yields *PlanStepRejectedError.
+func (c *ApprovalClient) ExecutePlanStep(ctx context.Context, planID string, call PlanStepCall) (*RemediationPlanStep, error) {
+	var step RemediationPlanStep
+	err := c.postJSON(ctx, "/v1/remediation-plans/"+url.PathEscape(planID)+"/execute", call, http.StatusOK, &step)
+	var se *statusError
+	if errors.As(err, &se) && (se.status == http.StatusConflict || se.status == http.StatusNotFound) {
+		return nil, &PlanStepRejectedError{PlanID: planID, Reason: se.message()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &step, nil
+}
+
+// statusError is an unexpected HTTP status from auditd.
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+// message returns the "error" field of a JSON error body, or the raw body.
+func (e *statusError) message() string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal([]byte(e.body), &body) == nil && body.Error != "" {
+		return body.Error
+	}
+	return strings.TrimSpace(e.body)
+}
+
+// postJSON POSTs body to path and decodes a want-status response into out.
+func (c *ApprovalClient) postJSON(ctx context.Context, path string, body any, want int, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != want {
+		return &statusError{status: resp.StatusCode, body: string(respBody)}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
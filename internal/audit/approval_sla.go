@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseApprovalSLAs parses "class=duration,class=duration" (e.g.
+// "destructive=15m,write=1h") into the time approvals of each action class
+// have to be resolved in.
+func ParseApprovalSLAs(s string) (map[ActionClass]time.Duration, error) {
+	slas := map[ActionClass]time.Duration{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid approval SLA %q: expected class=duration", part)
+		}
+		class := ActionClass(strings.TrimSpace(name))
+		switch class {
+		case ActionRead, ActionWrite, ActionDestructive, ActionEscalation:
+		default:
+			return nil, fmt.Errorf("invalid approval SLA %q: unknown action class %q", part, class)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid approval SLA %q: expected a positive duration such as 15m", part)
+		}
+		slas[class] = d
+	}
+	return slas, nil
+}
+
+// TimeToResolution returns how long the request waited for a decision, and
+// false while it is still pending.
+func (req *StoredApproval) TimeToResolution() (time.Duration, bool) {
+	if req.Status == "pending" || req.ResolvedAt.IsZero() {
+		return 0, false
+	}
+	return req.ResolvedAt.Sub(req.RequestedAt), true
+}
+
+// SLABreached reports whether the request had an SLA and was not resolved
+// within it by now.
+func (req *StoredApproval) SLABreached(now time.Time) bool {
+	if req.SLADueAt.IsZero() {
+		return false
+	}
+	if !req.SLABreachedAt.IsZero() {
+		return true
+	}
+	if req.Status == "pending" || req.ResolvedAt.IsZero() {
+		return now.After(req.SLADueAt)
+	}
+	return req.ResolvedAt.After(req.SLADueAt)
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseApprovalSLAs(t *testing.T) {
+	slas, err := ParseApprovalSLAs(" destructive=15m, write=1h ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slas) != 2 || slas[ActionDestructive] != 15*time.Minute || slas[ActionWrite] != time.Hour {
+		t.Errorf("slas = %v", slas)
+	}
+	for _, bad := range []string{"destructive", "delete=15m", "write=soon", "write=-1m", "write=0s"} {
+		if _, err := ParseApprovalSLAs(bad); err == nil {
+			t.Errorf("ParseApprovalSLAs(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestStoredApproval_SLABreached(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	due := t0.Add(15 * time.Minute)
+	tests := []struct {
+		name string
+		req  StoredApproval
+		now  time.Time
+		want bool
+	}{
+		{"no SLA", StoredApproval{Status: "pending", RequestedAt: t0}, t0.Add(time.Hour), false},
+		{"pending in time", StoredApproval{Status: "pending", RequestedAt: t0, SLADueAt: due}, t0.Add(10 * time.Minute), false},
+		{"pending overdue", StoredApproval{Status: "pending", RequestedAt: t0, SLADueAt: due}, t0.Add(20 * time.Minute), true},
+		{"resolved in time", StoredApproval{Status: "approved", RequestedAt: t0, SLADueAt: due, ResolvedAt: t0.Add(5 * time.Minute)}, t0.Add(time.Hour), false},
+		{"resolved late", StoredApproval{Status: "denied", RequestedAt: t0, SLADueAt: due, ResolvedAt: t0.Add(20 * time.Minute)}, t0.Add(time.Hour), true},
+	}
+	for _, tt := range tests {
+		if got := tt.req.SLABreached(tt.now); got != tt.want {
+			t.Errorf("%s: SLABreached = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	resolved := StoredApproval{Status: "approved", RequestedAt: t0, ResolvedAt: t0.Add(90 * time.Second)}
+	if d, ok := resolved.TimeToResolution(); !ok || d != 90*time.Second {
+		t.Errorf("TimeToResolution = %s, %v; want 1m30s", d, ok)
+	}
+	pending := StoredApproval{Status: "pending", RequestedAt: t0}
+	if _, ok := pending.TimeToResolution(); ok {
+		t.Error("pending request has a time to resolution")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +26,7 @@ type StoredApproval struct {
 	ApprovalID string `json:"approval_id"`
 	EventID    string `json:"event_id,omitempty"`
 	TraceID    string `json:"trace_id,omitempty"`
+	TenantID   string `json:"tenant_id,omitempty"`
 
 	// Status
 	Status string `json:"status"` // pending, approved, denied, expired, cancelled
@@ -36,6 +38,10 @@ type StoredApproval struct {
 	ResourceType string `json:"resource_type,omitempty"`
 	ResourceName string `json:"resource_name,omitempty"`
 
+	// Team is the team owning the resource, from the infrastructure config.
+	// Its channel gets the notifications instead of the global webhook.
+	Team string `json:"team,omitempty"`
+
 	// Principal
 	RequestedBy string    `json:"requested_by"`
 	RequestedAt time.Time `json:"requested_at"`
@@ -48,6 +54,15 @@ type StoredApproval struct {
 	ResolvedAt       time.Time `json:"resolved_at,omitempty"`
 	ResolutionReason string    `json:"resolution_reason,omitempty"`
 
+	// TimeToResolutionSecs is how long the request waited for a decision
+	// (resolved_at - requested_at); 0 while pending. Derived, not stored.
+	TimeToResolutionSecs float64 `json:"time_to_resolution_secs,omitempty"`
+
+	// SLA: the time the request must be resolved by, from the SLA of its
+	// action class, and when auditd recorded that it was not.
+	SLADueAt      time.Time `json:"sla_due_at,omitempty"`
+	SLABreachedAt time.Time `json:"sla_breached_at,omitempty"`
+
 	// Expiration
 	ExpiresAt         time.Time `json:"expires_at,omitempty"`
 	ApprovalValidUntil time.Time `json:"approval_valid_until,omitempty"`
@@ -60,6 +75,10 @@ type StoredApproval struct {
 	CallbackURL    string    `json:"callback_url,omitempty"`
 	CallbackSentAt time.Time `json:"callback_sent_at,omitempty"`
 
+	// Execution is the outcome of the tool call this approval authorised.
+	// Nil until the agent records the execution.
+	Execution *ApprovalExecution `json:"execution,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -111,7 +130,12 @@ func createApprovalTables(db *sql.DB, isPostgres bool) error {
 		callback_url TEXT,
 		callback_sent_at TEXT,
 		created_at TEXT DEFAULT '',
-		updated_at TEXT DEFAULT ''
+		updated_at TEXT DEFAULT '',
+		tenant_id TEXT,
+		execution TEXT,
+		team TEXT,
+		sla_due_at TEXT,
+		sla_breached_at TEXT
 	);
 	`, pkDef)
 
@@ -119,6 +143,24 @@ func createApprovalTables(db *sql.DB, isPostgres bool) error {
 		return err
 	}
 
+	// Migrate tables created before tenant scoping. SQLite has no
+	// ADD COLUMN IF NOT EXISTS, so the duplicate-column error is ignored.
+	// The same applies to execution, added for post-approval result linking,
+	// team, added for ownership routing, and the SLA columns.
+	if isPostgres {
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN IF NOT EXISTS tenant_id TEXT")       //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN IF NOT EXISTS execution TEXT")       //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN IF NOT EXISTS team TEXT")            //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN IF NOT EXISTS sla_due_at TEXT")      //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN IF NOT EXISTS sla_breached_at TEXT") //nolint:errcheck
+	} else {
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN tenant_id TEXT")       //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN execution TEXT")       //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN team TEXT")            //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN sla_due_at TEXT")      //nolint:errcheck
+		db.Exec("ALTER TABLE approval_requests ADD COLUMN sla_breached_at TEXT") //nolint:errcheck
+	}
+
 	// Create indexes
 	indexes := `
 	CREATE INDEX IF NOT EXISTS idx_approvals_status ON approval_requests(status);
@@ -128,6 +170,7 @@ func createApprovalTables(db *sql.DB, isPostgres bool) error {
 	CREATE INDEX IF NOT EXISTS idx_approvals_expires ON approval_requests(expires_at);
 	CREATE INDEX IF NOT EXISTS idx_approvals_agent ON approval_requests(agent_name);
 	CREATE INDEX IF NOT EXISTS idx_approvals_tool ON approval_requests(tool_name);
+	CREATE INDEX IF NOT EXISTS idx_approvals_tenant ON approval_requests(tenant_id);
 	`
 	_, err := db.Exec(indexes)
 	return err
@@ -158,8 +201,8 @@ func (s *ApprovalStore) CreateRequest(ctx context.Context, req *StoredApproval)
 			action_class, tool_name, agent_name, resource_type, resource_name,
 			requested_by, requested_at, request_context,
 			expires_at, policy_name, approver_role, callback_url,
-			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			created_at, updated_at, tenant_id, team, sla_due_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`),
 		req.ApprovalID,
 		req.EventID,
@@ -179,6 +222,9 @@ func (s *ApprovalStore) CreateRequest(ctx context.Context, req *StoredApproval)
 		req.CallbackURL,
 		req.CreatedAt.Format(time.RFC3339Nano),
 		req.UpdatedAt.Format(time.RFC3339Nano),
+		req.TenantID,
+		req.Team,
+		formatTimeOrNull(req.SLADueAt),
 	)
 	return err
 }
@@ -191,7 +237,8 @@ func (s *ApprovalStore) GetRequest(ctx context.Context, approvalID string) (*Sto
 			requested_by, requested_at, request_context,
 			resolved_by, resolved_at, resolution_reason,
 			expires_at, approval_valid_until, policy_name, approver_role,
-			callback_url, callback_sent_at, created_at, updated_at
+			callback_url, callback_sent_at, created_at, updated_at, tenant_id, execution, team,
+			sla_due_at, sla_breached_at
 		FROM approval_requests WHERE approval_id = ?
 	`), approvalID)
 
@@ -206,7 +253,8 @@ func (s *ApprovalStore) GetRequestByTraceAndTool(ctx context.Context, traceID, t
 			requested_by, requested_at, request_context,
 			resolved_by, resolved_at, resolution_reason,
 			expires_at, approval_valid_until, policy_name, approver_role,
-			callback_url, callback_sent_at, created_at, updated_at
+			callback_url, callback_sent_at, created_at, updated_at, tenant_id, execution, team,
+			sla_due_at, sla_breached_at
 		FROM approval_requests
 		WHERE trace_id = ? AND tool_name = ?
 		ORDER BY created_at DESC LIMIT 1
@@ -222,6 +270,8 @@ type ApprovalQueryOptions struct {
 	TraceID     string
 	RequestedBy string
 	ToolName    string
+	TenantID    string
+	Team        string
 	Since       time.Time
 	Limit       int
 }
@@ -234,7 +284,8 @@ func (s *ApprovalStore) ListRequests(ctx context.Context, opts ApprovalQueryOpti
 			requested_by, requested_at, request_context,
 			resolved_by, resolved_at, resolution_reason,
 			expires_at, approval_valid_until, policy_name, approver_role,
-			callback_url, callback_sent_at, created_at, updated_at
+			callback_url, callback_sent_at, created_at, updated_at, tenant_id, execution, team,
+			sla_due_at, sla_breached_at
 		FROM approval_requests WHERE 1=1
 	`
 	var args []any
@@ -259,6 +310,14 @@ func (s *ApprovalStore) ListRequests(ctx context.Context, opts ApprovalQueryOpti
 		query += " AND tool_name = ?"
 		args = append(args, opts.ToolName)
 	}
+	if opts.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+	if opts.Team != "" {
+		query += " AND team = ?"
+		args = append(args, opts.Team)
+	}
 	if !opts.Since.IsZero() {
 		query += " AND created_at >= ?"
 		args = append(args, opts.Since.Format(time.RFC3339Nano))
@@ -398,6 +457,58 @@ func (s *ApprovalStore) Cancel(ctx context.Context, approvalID, cancelledBy, rea
 	return nil
 }
 
+// ApprovalExecution is the outcome of the tool call an approval authorised,
+// linked from the tool_execution event that carries the approval ID.
+type ApprovalExecution struct {
+	EventID      string    `json:"event_id"`
+	ToolName     string    `json:"tool_name,omitempty"`
+	Status       string    `json:"status"` // outcome status of the tool_execution event: success, error
+	RowsAffected int       `json:"rows_affected,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ExecutedAt   time.Time `json:"executed_at"`
+}
+
+// Summary renders the execution for approvers, e.g.
+// "executed: yes, rows_affected: 3, status: success".
+func (e *ApprovalExecution) Summary() string {
+	if e == nil {
+		return "executed: no"
+	}
+	s := "executed: yes"
+	if e.RowsAffected > 0 {
+		s += fmt.Sprintf(", rows_affected: %d", e.RowsAffected)
+	}
+	s += ", status: " + e.Status
+	if e.Error != "" {
+		s += ", error: " + e.Error
+	}
+	return s
+}
+
+// RecordExecution links the outcome of the authorised tool call to an
+// approved request. Only the first execution is kept: an approval authorises
+// one action, and a later call reusing it must not overwrite the record the
+// approver was told about.
+func (s *ApprovalStore) RecordExecution(ctx context.Context, approvalID string, exec ApprovalExecution) (*StoredApproval, error) {
+	data, err := json.Marshal(exec)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		UPDATE approval_requests
+		SET execution = ?, updated_at = ?
+		WHERE approval_id = ? AND status = 'approved' AND (execution IS NULL OR execution = '')
+	`), string(data), now.Format(time.RFC3339Nano), approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("approval %s not found, not approved or already executed", approvalID)
+	}
+	return s.GetRequest(ctx, approvalID)
+}
+
 // ExpireRequests expires all pending requests past their expiration time.
 // Returns the number of expired requests.
 func (s *ApprovalStore) ExpireRequests(ctx context.Context) (int, error) {
@@ -453,6 +564,59 @@ func (s *ApprovalStore) ExpireRequests(ctx context.Context) (int, error) {
 	return int(affected), nil
 }
 
+// MarkSLABreaches marks the requests that missed their SLA as of now: still
+// pending past sla_due_at, or resolved after it. Each request is marked, and
+// returned, once; a request another auditd replica marked first is skipped.
+func (s *ApprovalStore) MarkSLABreaches(ctx context.Context, now time.Time) ([]*StoredApproval, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT approval_id, event_id, trace_id, status,
+			action_class, tool_name, agent_name, resource_type, resource_name,
+			requested_by, requested_at, request_context,
+			resolved_by, resolved_at, resolution_reason,
+			expires_at, approval_valid_until, policy_name, approver_role,
+			callback_url, callback_sent_at, created_at, updated_at, tenant_id, execution, team,
+			sla_due_at, sla_breached_at
+		FROM approval_requests
+		WHERE sla_due_at IS NOT NULL AND sla_breached_at IS NULL AND sla_due_at < ?
+			AND (status = 'pending' OR resolved_at > sla_due_at)
+	`), now.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*StoredApproval
+	for rows.Next() {
+		req, err := scanStoredApprovalFromRows(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if req.SLABreached(now) {
+			candidates = append(candidates, req)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var breached []*StoredApproval
+	for _, req := range candidates {
+		result, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+			UPDATE approval_requests SET sla_breached_at = ?
+			WHERE approval_id = ? AND sla_breached_at IS NULL
+		`), now.UTC().Format(time.RFC3339Nano), req.ApprovalID)
+		if err != nil {
+			return breached, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+		req.SLABreachedAt = now.UTC()
+		breached = append(breached, req)
+	}
+	return breached, nil
+}
+
 // WaitForResolution blocks until the approval is resolved or context is cancelled.
 func (s *ApprovalStore) WaitForResolution(ctx context.Context, approvalID string) (*StoredApproval, error) {
 	// First check if already resolved
@@ -547,7 +711,8 @@ func scanStoredApproval(row *sql.Row) (*StoredApproval, error) {
 	var eventID, traceID, toolName, agentName, resourceType, resourceName sql.NullString
 	var requestContext, resolvedBy, resolvedAt, resolutionReason sql.NullString
 	var expiresAt, validUntil, policyName, approverRole sql.NullString
-	var callbackURL, callbackSentAt sql.NullString
+	var callbackURL, callbackSentAt, tenantID, execution, team sql.NullString
+	var slaDueAt, slaBreachedAt sql.NullString
 	var requestedAt, createdAt, updatedAt string
 
 	err := row.Scan(
@@ -556,7 +721,8 @@ func scanStoredApproval(row *sql.Row) (*StoredApproval, error) {
 		&req.RequestedBy, &requestedAt, &requestContext,
 		&resolvedBy, &resolvedAt, &resolutionReason,
 		&expiresAt, &validUntil, &policyName, &approverRole,
-		&callbackURL, &callbackSentAt, &createdAt, &updatedAt,
+		&callbackURL, &callbackSentAt, &createdAt, &updatedAt, &tenantID, &execution, &team,
+		&slaDueAt, &slaBreachedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -576,10 +742,18 @@ func scanStoredApproval(row *sql.Row) (*StoredApproval, error) {
 	req.PolicyName = policyName.String
 	req.ApproverRole = approverRole.String
 	req.CallbackURL = callbackURL.String
+	req.TenantID = tenantID.String
+	req.Team = team.String
 
 	if requestContext.Valid {
 		json.Unmarshal([]byte(requestContext.String), &req.RequestContext)
 	}
+	if execution.Valid && execution.String != "" {
+		var exec ApprovalExecution
+		if json.Unmarshal([]byte(execution.String), &exec) == nil {
+			req.Execution = &exec
+		}
+	}
 
 	req.RequestedAt, _ = time.Parse(time.RFC3339Nano, requestedAt)
 	req.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
@@ -597,6 +771,15 @@ func scanStoredApproval(row *sql.Row) (*StoredApproval, error) {
 	if callbackSentAt.Valid {
 		req.CallbackSentAt, _ = time.Parse(time.RFC3339Nano, callbackSentAt.String)
 	}
+	if slaDueAt.Valid {
+		req.SLADueAt, _ = time.Parse(time.RFC3339Nano, slaDueAt.String)
+	}
+	if slaBreachedAt.Valid {
+		req.SLABreachedAt, _ = time.Parse(time.RFC3339Nano, slaBreachedAt.String)
+	}
+	if d, ok := req.TimeToResolution(); ok {
+		req.TimeToResolutionSecs = d.Seconds()
+	}
 
 	return &req, nil
 }
@@ -606,7 +789,8 @@ func scanStoredApprovalFromRows(rows *sql.Rows) (*StoredApproval, error) {
 	var eventID, traceID, toolName, agentName, resourceType, resourceName sql.NullString
 	var requestContext, resolvedBy, resolvedAt, resolutionReason sql.NullString
 	var expiresAt, validUntil, policyName, approverRole sql.NullString
-	var callbackURL, callbackSentAt sql.NullString
+	var callbackURL, callbackSentAt, tenantID, execution, team sql.NullString
+	var slaDueAt, slaBreachedAt sql.NullString
 	var requestedAt, createdAt, updatedAt string
 
 	err := rows.Scan(
@@ -615,7 +799,8 @@ func scanStoredApprovalFromRows(rows *sql.Rows) (*StoredApproval, error) {
 		&req.RequestedBy, &requestedAt, &requestContext,
 		&resolvedBy, &resolvedAt, &resolutionReason,
 		&expiresAt, &validUntil, &policyName, &approverRole,
-		&callbackURL, &callbackSentAt, &createdAt, &updatedAt,
+		&callbackURL, &callbackSentAt, &createdAt, &updatedAt, &tenantID, &execution, &team,
+		&slaDueAt, &slaBreachedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -632,10 +817,18 @@ func scanStoredApprovalFromRows(rows *sql.Rows) (*StoredApproval, error) {
 	req.PolicyName = policyName.String
 	req.ApproverRole = approverRole.String
 	req.CallbackURL = callbackURL.String
+	req.TenantID = tenantID.String
+	req.Team = team.String
 
 	if requestContext.Valid {
 		json.Unmarshal([]byte(requestContext.String), &req.RequestContext)
 	}
+	if execution.Valid && execution.String != "" {
+		var exec ApprovalExecution
+		if json.Unmarshal([]byte(execution.String), &exec) == nil {
+			req.Execution = &exec
+		}
+	}
 
 	req.RequestedAt, _ = time.Parse(time.RFC3339Nano, requestedAt)
 	req.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
@@ -653,6 +846,15 @@ func scanStoredApprovalFromRows(rows *sql.Rows) (*StoredApproval, error) {
 	if callbackSentAt.Valid {
 		req.CallbackSentAt, _ = time.Parse(time.RFC3339Nano, callbackSentAt.String)
 	}
+	if slaDueAt.Valid {
+		req.SLADueAt, _ = time.Parse(time.RFC3339Nano, slaDueAt.String)
+	}
+	if slaBreachedAt.Valid {
+		req.SLABreachedAt, _ = time.Parse(time.RFC3339Nano, slaBreachedAt.String)
+	}
+	if d, ok := req.TimeToResolution(); ok {
+		req.TimeToResolutionSecs = d.Seconds()
+	}
 
 	return &req, nil
 }
@@ -670,3 +872,79 @@ func ptrToTime(t *time.Time) time.Time {
 	}
 	return *t
 }
+
+// EraseSubjectRecords replaces ids as requester and as resolver of approval
+// requests with subject. A request's context (the tool arguments and the
+// requester's query) is cleared along with its requester, and a resolution
+// reason is erased along with its resolver.
+//
+// The subject's pending requests are cancelled first: approvers are checked
+// against the requester by raw ID, so a pending request left with a
+// pseudonymous requester could be approved by the erased subject themself.
+func (s *ApprovalStore) EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var idArgs []any
+	for _, id := range ids {
+		idArgs = append(idArgs, id)
+	}
+	inIDs := "(?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	if err := s.cancelPendingOf(ctx, inIDs, idArgs); err != nil {
+		return 0, err
+	}
+
+	args := append([]any{subject}, idArgs...)
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		UPDATE approval_requests SET requested_by = ?, request_context = NULL
+		WHERE requested_by IN `+inIDs), args...)
+	if err != nil {
+		return 0, fmt.Errorf("erase subject from approval_requests.requested_by: %w", err)
+	}
+	requested, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	resolved, err := eraseSubjectRows(ctx, s.db, s.isPostgres, "approval_requests", "resolved_by", []string{"resolution_reason"}, ids, subject)
+	return requested + resolved, err
+}
+
+// cancelPendingOf cancels the pending requests whose requester is one of
+// idArgs (matched by the IN list inIDs) and wakes their waiters.
+func (s *ApprovalStore) cancelPendingOf(ctx context.Context, inIDs string, idArgs []any) error {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT approval_id FROM approval_requests
+		WHERE status = 'pending' AND requested_by IN `+inIDs), idArgs...)
+	if err != nil {
+		return fmt.Errorf("find pending approvals of erased subject: %w", err)
+	}
+	var pending []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		pending = append(pending, id)
+	}
+	_ = rows.Close()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	args := append([]any{now, now}, idArgs...)
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		UPDATE approval_requests
+		SET status = 'cancelled',
+			resolved_at = ?,
+			resolution_reason = 'Requester erased',
+			updated_at = ?
+		WHERE status = 'pending' AND requested_by IN `+inIDs), args...); err != nil {
+		return fmt.Errorf("cancel pending approvals of erased subject: %w", err)
+	}
+	for _, id := range pending {
+		s.notifyWaiters(id)
+	}
+	return nil
+}
@@ -373,3 +373,21 @@ func TestApprovalRule_Matches(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSelfApproval(t *testing.T) {
+	tests := []struct {
+		requestedBy, approvedBy string
+		want                    bool
+	}{
+		{"alice@example.com", "alice@example.com", true},
+		{"Alice@Example.com", " alice@example.com", true},
+		{"alice@example.com", "bob@example.com", false},
+		{"", "", false},
+		{"", "bob@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsSelfApproval(tt.requestedBy, tt.approvedBy); got != tt.want {
+			t.Errorf("IsSelfApproval(%q, %q) = %v, want %v", tt.requestedBy, tt.approvedBy, got, tt.want)
+		}
+	}
+}
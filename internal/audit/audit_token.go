@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"helpdesk/internal/secrets"
+)
+
+const (
+	// AuditTokenEnv is the access token a client presents to auditd: the
+	// value of auditd's HELPDESK_AUDIT_WRITE_TOKEN, or HELPDESK_AUDIT_READ_TOKEN
+	// for a client that only queries. It may be a secrets reference.
+	AuditTokenEnv = "HELPDESK_AUDIT_TOKEN"
+
+	// AuditTokenHeader carries the access token. auditd also accepts the token
+	// as the Authorization bearer token, but a client whose bearer token is a
+	// service account API key needs the separate header.
+	AuditTokenHeader = "X-Audit-Token"
+)
+
+// auditTokenTransport adds the access token to requests bound for auditd.
+type auditTokenTransport struct {
+	base  http.RoundTripper
+	token string
+
+	mu    sync.RWMutex
+	hosts map[string]bool // host:port of the auditd URLs
+}
+
+func (t *auditTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	match := t.hosts[req.URL.Host]
+	t.mu.RUnlock()
+	if !match || req.Header.Get(AuditTokenHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(AuditTokenHeader, t.token)
+	return t.base.RoundTrip(req)
+}
+
+var (
+	auditTokenMu        sync.Mutex
+	installedAuditToken *auditTokenTransport
+)
+
+// InstallAuditToken resolves HELPDESK_AUDIT_TOKEN and, when it is set, wraps
+// http.DefaultTransport so that every request the process sends to the hosts
+// of auditURLs carries the token in X-Audit-Token, and GRPCStore sends it as
+// metadata. Requests to other hosts are left alone, so the token never reaches
+// webhooks or model APIs. Empty URLs are skipped, and calling it again adds
+// hosts (all-in-one runs several services in one process).
+//
+// Call it before building HTTP clients: RemoteStore and ApprovalClient capture
+// http.DefaultTransport when they are created.
+func InstallAuditToken(ctx context.Context, auditURLs ...string) error {
+	token, err := secrets.Getenv(ctx, AuditTokenEnv)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", AuditTokenEnv, err)
+	}
+	if token == "" {
+		return nil
+	}
+	var hosts []string
+	for _, raw := range auditURLs {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid audit URL %q", raw)
+		}
+		hosts = append(hosts, u.Host)
+	}
+
+	auditTokenMu.Lock()
+	defer auditTokenMu.Unlock()
+	if installedAuditToken == nil {
+		installedAuditToken = &auditTokenTransport{
+			base:  http.DefaultTransport,
+			token: token,
+			hosts: make(map[string]bool),
+		}
+		http.DefaultTransport = installedAuditToken
+	}
+	installedAuditToken.mu.Lock()
+	for _, h := range hosts {
+		installedAuditToken.hosts[h] = true
+	}
+	installedAuditToken.mu.Unlock()
+	return nil
+}
+
+// auditToken returns the installed access token, or "" when there is none.
+func auditToken() string {
+	auditTokenMu.Lock()
+	defer auditTokenMu.Unlock()
+	if installedAuditToken == nil {
+		return ""
+	}
+	return installedAuditToken.token
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInstallAuditToken_OnlyAuditdHosts(t *testing.T) {
+	var auditdGot, otherGot string
+	auditd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditdGot = r.Header.Get(AuditTokenHeader)
+	}))
+	defer auditd.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherGot = r.Header.Get(AuditTokenHeader)
+	}))
+	defer other.Close()
+
+	t.Setenv(AuditTokenEnv, "w-token")
+	if err := InstallAuditToken(context.Background(), auditd.URL, ""); err != nil {
+		t.Fatalf("InstallAuditToken: %v", err)
+	}
+	for _, url := range []string{auditd.URL + "/v1/events", other.URL + "/hook"} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+	if auditdGot != "w-token" {
+		t.Errorf("auditd got %s %q, want w-token", AuditTokenHeader, auditdGot)
+	}
+	if otherGot != "" {
+		t.Errorf("other host got %s %q, want none", AuditTokenHeader, otherGot)
+	}
+}
+
+func TestInstallAuditToken_InvalidURL(t *testing.T) {
+	t.Setenv(AuditTokenEnv, "w-token")
+	if err := InstallAuditToken(context.Background(), "auditd:1199"); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
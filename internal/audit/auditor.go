@@ -3,7 +3,8 @@ package audit
 import "context"
 
 // Auditor is the interface for recording audit events.
-// Implemented by both Store (local SQLite) and RemoteStore (HTTP client).
+// Implemented by Store (local SQLite), RemoteStore (HTTP client) and
+// GRPCStore (gRPC client).
 type Auditor interface {
 	// Record persists an audit event.
 	Record(ctx context.Context, event *Event) error
@@ -22,4 +23,5 @@ type Auditor interface {
 var (
 	_ Auditor = (*Store)(nil)
 	_ Auditor = (*RemoteStore)(nil)
+	_ Auditor = (*GRPCStore)(nil)
 )
@@ -0,0 +1,1921 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: audit.proto
+
+package auditpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResolveApprovalRequest_Resolution int32
+
+const (
+	ResolveApprovalRequest_RESOLUTION_UNSPECIFIED ResolveApprovalRequest_Resolution = 0
+	ResolveApprovalRequest_RESOLUTION_APPROVE     ResolveApprovalRequest_Resolution = 1
+	ResolveApprovalRequest_RESOLUTION_DENY        ResolveApprovalRequest_Resolution = 2
+	ResolveApprovalRequest_RESOLUTION_CANCEL      ResolveApprovalRequest_Resolution = 3
+)
+
+// Enum value maps for ResolveApprovalRequest_Resolution.
+var (
+	ResolveApprovalRequest_Resolution_name = map[int32]string{
+		0: "RESOLUTION_UNSPECIFIED",
+		1: "RESOLUTION_APPROVE",
+		2: "RESOLUTION_DENY",
+		3: "RESOLUTION_CANCEL",
+	}
+	ResolveApprovalRequest_Resolution_value = map[string]int32{
+		"RESOLUTION_UNSPECIFIED": 0,
+		"RESOLUTION_APPROVE":     1,
+		"RESOLUTION_DENY":        2,
+		"RESOLUTION_CANCEL":      3,
+	}
+)
+
+func (x ResolveApprovalRequest_Resolution) Enum() *ResolveApprovalRequest_Resolution {
+	p := new(ResolveApprovalRequest_Resolution)
+	*p = x
+	return p
+}
+
+func (x ResolveApprovalRequest_Resolution) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResolveApprovalRequest_Resolution) Descriptor() protoreflect.EnumDescriptor {
+	return file_audit_proto_enumTypes[0].Descriptor()
+}
+
+func (ResolveApprovalRequest_Resolution) Type() protoreflect.EnumType {
+	return &file_audit_proto_enumTypes[0]
+}
+
+func (x ResolveApprovalRequest_Resolution) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResolveApprovalRequest_Resolution.Descriptor instead.
+func (ResolveApprovalRequest_Resolution) EnumDescriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{13, 0}
+}
+
+// AuditEvent is one recorded event.
+type AuditEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// seq is the event's position in the audit log (audit_events.id). Set on
+	// SubscribeEvents; zero on QueryEvents.
+	Seq       int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	EventId   string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType string                 `protobuf:"bytes,3,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	TraceId   string                 `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SessionId string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Agent     string                 `protobuf:"bytes,6,opt,name=agent,proto3" json:"agent,omitempty"`
+	TenantId  string                 `protobuf:"bytes,7,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	EventHash string                 `protobuf:"bytes,9,opt,name=event_hash,json=eventHash,proto3" json:"event_hash,omitempty"`
+	// event_json is the complete event as stored.
+	EventJson     []byte `protobuf:"bytes,10,opt,name=event_json,json=eventJson,proto3" json:"event_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_audit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AuditEvent) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetEventHash() string {
+	if x != nil {
+		return x.EventHash
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetEventJson() []byte {
+	if x != nil {
+		return x.EventJson
+	}
+	return nil
+}
+
+type RecordEventRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// event_json is an audit.Event; auditd fills in the hash chain fields.
+	EventJson     []byte `protobuf:"bytes,1,opt,name=event_json,json=eventJson,proto3" json:"event_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordEventRequest) Reset() {
+	*x = RecordEventRequest{}
+	mi := &file_audit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordEventRequest) ProtoMessage() {}
+
+func (x *RecordEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordEventRequest.ProtoReflect.Descriptor instead.
+func (*RecordEventRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RecordEventRequest) GetEventJson() []byte {
+	if x != nil {
+		return x.EventJson
+	}
+	return nil
+}
+
+type RecordEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventHash     string                 `protobuf:"bytes,2,opt,name=event_hash,json=eventHash,proto3" json:"event_hash,omitempty"`
+	PrevHash      string                 `protobuf:"bytes,3,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordEventResponse) Reset() {
+	*x = RecordEventResponse{}
+	mi := &file_audit_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordEventResponse) ProtoMessage() {}
+
+func (x *RecordEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordEventResponse.ProtoReflect.Descriptor instead.
+func (*RecordEventResponse) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RecordEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordEventResponse) GetEventHash() string {
+	if x != nil {
+		return x.EventHash
+	}
+	return ""
+}
+
+func (x *RecordEventResponse) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+type RecordOutcomeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // success, error, timeout
+	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordOutcomeRequest) Reset() {
+	*x = RecordOutcomeRequest{}
+	mi := &file_audit_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordOutcomeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordOutcomeRequest) ProtoMessage() {}
+
+func (x *RecordOutcomeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordOutcomeRequest.ProtoReflect.Descriptor instead.
+func (*RecordOutcomeRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RecordOutcomeRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordOutcomeRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RecordOutcomeRequest) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *RecordOutcomeRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+type RecordOutcomeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordOutcomeResponse) Reset() {
+	*x = RecordOutcomeResponse{}
+	mi := &file_audit_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordOutcomeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordOutcomeResponse) ProtoMessage() {}
+
+func (x *RecordOutcomeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordOutcomeResponse.ProtoReflect.Descriptor instead.
+func (*RecordOutcomeResponse) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{4}
+}
+
+type QueryEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TraceId       string                 `protobuf:"bytes,2,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	TraceIdPrefix string                 `protobuf:"bytes,3,opt,name=trace_id_prefix,json=traceIdPrefix,proto3" json:"trace_id_prefix,omitempty"`
+	EventTypes    []string               `protobuf:"bytes,4,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	Agent         string                 `protobuf:"bytes,5,opt,name=agent,proto3" json:"agent,omitempty"`
+	ActionClass   string                 `protobuf:"bytes,6,opt,name=action_class,json=actionClass,proto3" json:"action_class,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=since,proto3" json:"since,omitempty"`
+	OutcomeStatus string                 `protobuf:"bytes,8,opt,name=outcome_status,json=outcomeStatus,proto3" json:"outcome_status,omitempty"`
+	Origin        string                 `protobuf:"bytes,9,opt,name=origin,proto3" json:"origin,omitempty"`
+	ToolName      string                 `protobuf:"bytes,10,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	TenantId      string                 `protobuf:"bytes,11,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,12,opt,name=limit,proto3" json:"limit,omitempty"` // default 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEventsRequest) Reset() {
+	*x = QueryEventsRequest{}
+	mi := &file_audit_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEventsRequest) ProtoMessage() {}
+
+func (x *QueryEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEventsRequest.ProtoReflect.Descriptor instead.
+func (*QueryEventsRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *QueryEventsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetTraceIdPrefix() string {
+	if x != nil {
+		return x.TraceIdPrefix
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetActionClass() string {
+	if x != nil {
+		return x.ActionClass
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetOutcomeStatus() string {
+	if x != nil {
+		return x.OutcomeStatus
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SubscribeEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// replay_after replays every event with a larger seq before streaming
+	// live events. Unset streams live events only.
+	ReplayAfter *int64 `protobuf:"varint,1,opt,name=replay_after,json=replayAfter,proto3,oneof" json:"replay_after,omitempty"`
+	// event_types restricts the stream; empty streams every type.
+	EventTypes    []string `protobuf:"bytes,2,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	TenantId      string   `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventsRequest) Reset() {
+	*x = SubscribeEventsRequest{}
+	mi := &file_audit_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventsRequest) ProtoMessage() {}
+
+func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubscribeEventsRequest) GetReplayAfter() int64 {
+	if x != nil && x.ReplayAfter != nil {
+		return *x.ReplayAfter
+	}
+	return 0
+}
+
+func (x *SubscribeEventsRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *SubscribeEventsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+// Approval is an approval request and its resolution.
+type Approval struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ApprovalId         string                 `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	Status             string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // pending, approved, denied, expired, cancelled
+	EventId            string                 `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TraceId            string                 `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	TenantId           string                 `protobuf:"bytes,5,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ActionClass        string                 `protobuf:"bytes,6,opt,name=action_class,json=actionClass,proto3" json:"action_class,omitempty"`
+	ToolName           string                 `protobuf:"bytes,7,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	AgentName          string                 `protobuf:"bytes,8,opt,name=agent_name,json=agentName,proto3" json:"agent_name,omitempty"`
+	ResourceType       string                 `protobuf:"bytes,9,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceName       string                 `protobuf:"bytes,10,opt,name=resource_name,json=resourceName,proto3" json:"resource_name,omitempty"`
+	RequestedBy        string                 `protobuf:"bytes,11,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	RequestedAt        *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=requested_at,json=requestedAt,proto3" json:"requested_at,omitempty"`
+	ResolvedBy         string                 `protobuf:"bytes,13,opt,name=resolved_by,json=resolvedBy,proto3" json:"resolved_by,omitempty"`
+	ResolvedAt         *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=resolved_at,json=resolvedAt,proto3" json:"resolved_at,omitempty"`
+	ResolutionReason   string                 `protobuf:"bytes,15,opt,name=resolution_reason,json=resolutionReason,proto3" json:"resolution_reason,omitempty"`
+	ExpiresAt          *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	ApprovalValidUntil *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=approval_valid_until,json=approvalValidUntil,proto3" json:"approval_valid_until,omitempty"`
+	PolicyName         string                 `protobuf:"bytes,18,opt,name=policy_name,json=policyName,proto3" json:"policy_name,omitempty"`
+	ApproverRole       string                 `protobuf:"bytes,19,opt,name=approver_role,json=approverRole,proto3" json:"approver_role,omitempty"`
+	// approval_json is the complete approval, including its request context
+	// and execution record, as returned by the REST API.
+	ApprovalJson  []byte `protobuf:"bytes,20,opt,name=approval_json,json=approvalJson,proto3" json:"approval_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Approval) Reset() {
+	*x = Approval{}
+	mi := &file_audit_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Approval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Approval) ProtoMessage() {}
+
+func (x *Approval) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Approval.ProtoReflect.Descriptor instead.
+func (*Approval) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Approval) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *Approval) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Approval) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *Approval) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *Approval) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *Approval) GetActionClass() string {
+	if x != nil {
+		return x.ActionClass
+	}
+	return ""
+}
+
+func (x *Approval) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *Approval) GetAgentName() string {
+	if x != nil {
+		return x.AgentName
+	}
+	return ""
+}
+
+func (x *Approval) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *Approval) GetResourceName() string {
+	if x != nil {
+		return x.ResourceName
+	}
+	return ""
+}
+
+func (x *Approval) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *Approval) GetRequestedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RequestedAt
+	}
+	return nil
+}
+
+func (x *Approval) GetResolvedBy() string {
+	if x != nil {
+		return x.ResolvedBy
+	}
+	return ""
+}
+
+func (x *Approval) GetResolvedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResolvedAt
+	}
+	return nil
+}
+
+func (x *Approval) GetResolutionReason() string {
+	if x != nil {
+		return x.ResolutionReason
+	}
+	return ""
+}
+
+func (x *Approval) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Approval) GetApprovalValidUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ApprovalValidUntil
+	}
+	return nil
+}
+
+func (x *Approval) GetPolicyName() string {
+	if x != nil {
+		return x.PolicyName
+	}
+	return ""
+}
+
+func (x *Approval) GetApproverRole() string {
+	if x != nil {
+		return x.ApproverRole
+	}
+	return ""
+}
+
+func (x *Approval) GetApprovalJson() []byte {
+	if x != nil {
+		return x.ApprovalJson
+	}
+	return nil
+}
+
+type CreateApprovalRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	EventId      string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TraceId      string                 `protobuf:"bytes,2,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	TenantId     string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ActionClass  string                 `protobuf:"bytes,4,opt,name=action_class,json=actionClass,proto3" json:"action_class,omitempty"`
+	ToolName     string                 `protobuf:"bytes,5,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	AgentName    string                 `protobuf:"bytes,6,opt,name=agent_name,json=agentName,proto3" json:"agent_name,omitempty"`
+	ResourceType string                 `protobuf:"bytes,7,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceName string                 `protobuf:"bytes,8,opt,name=resource_name,json=resourceName,proto3" json:"resource_name,omitempty"`
+	RequestedBy  string                 `protobuf:"bytes,9,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	// request_context_json is a JSON object shown to the approver.
+	RequestContextJson []byte `protobuf:"bytes,10,opt,name=request_context_json,json=requestContextJson,proto3" json:"request_context_json,omitempty"`
+	PolicyName         string `protobuf:"bytes,11,opt,name=policy_name,json=policyName,proto3" json:"policy_name,omitempty"`
+	ApproverRole       string `protobuf:"bytes,12,opt,name=approver_role,json=approverRole,proto3" json:"approver_role,omitempty"`
+	ExpiresInMinutes   int32  `protobuf:"varint,13,opt,name=expires_in_minutes,json=expiresInMinutes,proto3" json:"expires_in_minutes,omitempty"` // default 60
+	CallbackUrl        string `protobuf:"bytes,14,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *CreateApprovalRequest) Reset() {
+	*x = CreateApprovalRequest{}
+	mi := &file_audit_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateApprovalRequest) ProtoMessage() {}
+
+func (x *CreateApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateApprovalRequest.ProtoReflect.Descriptor instead.
+func (*CreateApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateApprovalRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetActionClass() string {
+	if x != nil {
+		return x.ActionClass
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetAgentName() string {
+	if x != nil {
+		return x.AgentName
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetResourceName() string {
+	if x != nil {
+		return x.ResourceName
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetRequestContextJson() []byte {
+	if x != nil {
+		return x.RequestContextJson
+	}
+	return nil
+}
+
+func (x *CreateApprovalRequest) GetPolicyName() string {
+	if x != nil {
+		return x.PolicyName
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetApproverRole() string {
+	if x != nil {
+		return x.ApproverRole
+	}
+	return ""
+}
+
+func (x *CreateApprovalRequest) GetExpiresInMinutes() int32 {
+	if x != nil {
+		return x.ExpiresInMinutes
+	}
+	return 0
+}
+
+func (x *CreateApprovalRequest) GetCallbackUrl() string {
+	if x != nil {
+		return x.CallbackUrl
+	}
+	return ""
+}
+
+type GetApprovalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApprovalId    string                 `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetApprovalRequest) Reset() {
+	*x = GetApprovalRequest{}
+	mi := &file_audit_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetApprovalRequest) ProtoMessage() {}
+
+func (x *GetApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetApprovalRequest.ProtoReflect.Descriptor instead.
+func (*GetApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+type ListApprovalsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Agent         string                 `protobuf:"bytes,2,opt,name=agent,proto3" json:"agent,omitempty"`
+	TraceId       string                 `protobuf:"bytes,3,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	RequestedBy   string                 `protobuf:"bytes,4,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	ToolName      string                 `protobuf:"bytes,5,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=since,proto3" json:"since,omitempty"`
+	TenantId      string                 `protobuf:"bytes,7,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"` // default 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListApprovalsRequest) Reset() {
+	*x = ListApprovalsRequest{}
+	mi := &file_audit_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListApprovalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListApprovalsRequest) ProtoMessage() {}
+
+func (x *ListApprovalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListApprovalsRequest.ProtoReflect.Descriptor instead.
+func (*ListApprovalsRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListApprovalsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListApprovalsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListApprovalsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListApprovalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Approvals     []*Approval            `protobuf:"bytes,1,rep,name=approvals,proto3" json:"approvals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListApprovalsResponse) Reset() {
+	*x = ListApprovalsResponse{}
+	mi := &file_audit_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListApprovalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListApprovalsResponse) ProtoMessage() {}
+
+func (x *ListApprovalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListApprovalsResponse.ProtoReflect.Descriptor instead.
+func (*ListApprovalsResponse) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListApprovalsResponse) GetApprovals() []*Approval {
+	if x != nil {
+		return x.Approvals
+	}
+	return nil
+}
+
+type WaitForApprovalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApprovalId    string                 `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	Timeout       *durationpb.Duration   `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"` // default 30s, at most 120s
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WaitForApprovalRequest) Reset() {
+	*x = WaitForApprovalRequest{}
+	mi := &file_audit_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WaitForApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitForApprovalRequest) ProtoMessage() {}
+
+func (x *WaitForApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitForApprovalRequest.ProtoReflect.Descriptor instead.
+func (*WaitForApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WaitForApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *WaitForApprovalRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+type ResolveApprovalRequest struct {
+	state      protoimpl.MessageState            `protogen:"open.v1"`
+	ApprovalId string                            `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	Resolution ResolveApprovalRequest_Resolution `protobuf:"varint,2,opt,name=resolution,proto3,enum=helpdesk.audit.v1.ResolveApprovalRequest_Resolution" json:"resolution,omitempty"`
+	// resolved_by names the approver when auditd runs without authentication;
+	// with it, the authenticated principal is used.
+	ResolvedBy      string `protobuf:"bytes,3,opt,name=resolved_by,json=resolvedBy,proto3" json:"resolved_by,omitempty"`
+	Reason          string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	ValidForMinutes int32  `protobuf:"varint,5,opt,name=valid_for_minutes,json=validForMinutes,proto3" json:"valid_for_minutes,omitempty"` // approvals only
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ResolveApprovalRequest) Reset() {
+	*x = ResolveApprovalRequest{}
+	mi := &file_audit_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveApprovalRequest) ProtoMessage() {}
+
+func (x *ResolveApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveApprovalRequest.ProtoReflect.Descriptor instead.
+func (*ResolveApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResolveApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetResolution() ResolveApprovalRequest_Resolution {
+	if x != nil {
+		return x.Resolution
+	}
+	return ResolveApprovalRequest_RESOLUTION_UNSPECIFIED
+}
+
+func (x *ResolveApprovalRequest) GetResolvedBy() string {
+	if x != nil {
+		return x.ResolvedBy
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetValidForMinutes() int32 {
+	if x != nil {
+		return x.ValidForMinutes
+	}
+	return 0
+}
+
+type CheckPolicyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceType  string                 `protobuf:"bytes,1,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"` // database, kubernetes
+	ResourceName  string                 `protobuf:"bytes,2,opt,name=resource_name,json=resourceName,proto3" json:"resource_name,omitempty"`
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"` // read, write, destructive
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	TraceId       string                 `protobuf:"bytes,5,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	AgentName     string                 `protobuf:"bytes,7,opt,name=agent_name,json=agentName,proto3" json:"agent_name,omitempty"`
+	Note          string                 `protobuf:"bytes,8,opt,name=note,proto3" json:"note,omitempty"`
+	RowsAffected  int32                  `protobuf:"varint,9,opt,name=rows_affected,json=rowsAffected,proto3" json:"rows_affected,omitempty"`
+	PodsAffected  int32                  `protobuf:"varint,10,opt,name=pods_affected,json=podsAffected,proto3" json:"pods_affected,omitempty"`
+	XactAgeSecs   int32                  `protobuf:"varint,11,opt,name=xact_age_secs,json=xactAgeSecs,proto3" json:"xact_age_secs,omitempty"`
+	PostExecution bool                   `protobuf:"varint,12,opt,name=post_execution,json=postExecution,proto3" json:"post_execution,omitempty"`
+	Principal     *Principal             `protobuf:"bytes,13,opt,name=principal,proto3" json:"principal,omitempty"`
+	Purpose       string                 `protobuf:"bytes,14,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	PurposeNote   string                 `protobuf:"bytes,15,opt,name=purpose_note,json=purposeNote,proto3" json:"purpose_note,omitempty"`
+	Sensitivity   []string               `protobuf:"bytes,16,rep,name=sensitivity,proto3" json:"sensitivity,omitempty"`
+	ToolName      string                 `protobuf:"bytes,17,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPolicyRequest) Reset() {
+	*x = CheckPolicyRequest{}
+	mi := &file_audit_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPolicyRequest) ProtoMessage() {}
+
+func (x *CheckPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPolicyRequest.ProtoReflect.Descriptor instead.
+func (*CheckPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CheckPolicyRequest) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetResourceName() string {
+	if x != nil {
+		return x.ResourceName
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CheckPolicyRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetAgentName() string {
+	if x != nil {
+		return x.AgentName
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetRowsAffected() int32 {
+	if x != nil {
+		return x.RowsAffected
+	}
+	return 0
+}
+
+func (x *CheckPolicyRequest) GetPodsAffected() int32 {
+	if x != nil {
+		return x.PodsAffected
+	}
+	return 0
+}
+
+func (x *CheckPolicyRequest) GetXactAgeSecs() int32 {
+	if x != nil {
+		return x.XactAgeSecs
+	}
+	return 0
+}
+
+func (x *CheckPolicyRequest) GetPostExecution() bool {
+	if x != nil {
+		return x.PostExecution
+	}
+	return false
+}
+
+func (x *CheckPolicyRequest) GetPrincipal() *Principal {
+	if x != nil {
+		return x.Principal
+	}
+	return nil
+}
+
+func (x *CheckPolicyRequest) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetPurposeNote() string {
+	if x != nil {
+		return x.PurposeNote
+	}
+	return ""
+}
+
+func (x *CheckPolicyRequest) GetSensitivity() []string {
+	if x != nil {
+		return x.Sensitivity
+	}
+	return nil
+}
+
+func (x *CheckPolicyRequest) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+// Principal identifies the user or service on whose behalf an agent acts.
+type Principal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Roles         []string               `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	Service       string                 `protobuf:"bytes,3,opt,name=service,proto3" json:"service,omitempty"`
+	AuthMethod    string                 `protobuf:"bytes,4,opt,name=auth_method,json=authMethod,proto3" json:"auth_method,omitempty"`
+	Tenant        string                 `protobuf:"bytes,5,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Principal) Reset() {
+	*x = Principal{}
+	mi := &file_audit_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Principal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Principal) ProtoMessage() {}
+
+func (x *Principal) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Principal.ProtoReflect.Descriptor instead.
+func (*Principal) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Principal) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Principal) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *Principal) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Principal) GetAuthMethod() string {
+	if x != nil {
+		return x.AuthMethod
+	}
+	return ""
+}
+
+func (x *Principal) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+type CheckPolicyResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Effect           string                 `protobuf:"bytes,1,opt,name=effect,proto3" json:"effect,omitempty"` // allow, deny, require_approval
+	PolicyName       string                 `protobuf:"bytes,2,opt,name=policy_name,json=policyName,proto3" json:"policy_name,omitempty"`
+	Message          string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Explanation      string                 `protobuf:"bytes,4,opt,name=explanation,proto3" json:"explanation,omitempty"`
+	RequiresApproval bool                   `protobuf:"varint,5,opt,name=requires_approval,json=requiresApproval,proto3" json:"requires_approval,omitempty"`
+	EventId          string                 `protobuf:"bytes,6,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TraceId          string                 `protobuf:"bytes,7,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	// trace_json is the policy.DecisionTrace the decision was derived from.
+	TraceJson []byte `protobuf:"bytes,8,opt,name=trace_json,json=traceJson,proto3" json:"trace_json,omitempty"`
+	// policy_version changes whenever the policy configuration or the
+	// emergency freeze state does; clients caching decisions drop them then.
+	PolicyVersion string `protobuf:"bytes,9,opt,name=policy_version,json=policyVersion,proto3" json:"policy_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPolicyResponse) Reset() {
+	*x = CheckPolicyResponse{}
+	mi := &file_audit_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPolicyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPolicyResponse) ProtoMessage() {}
+
+func (x *CheckPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audit_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPolicyResponse.ProtoReflect.Descriptor instead.
+func (*CheckPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_audit_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CheckPolicyResponse) GetEffect() string {
+	if x != nil {
+		return x.Effect
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetPolicyName() string {
+	if x != nil {
+		return x.PolicyName
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetExplanation() string {
+	if x != nil {
+		return x.Explanation
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetRequiresApproval() bool {
+	if x != nil {
+		return x.RequiresApproval
+	}
+	return false
+}
+
+func (x *CheckPolicyResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *CheckPolicyResponse) GetTraceJson() []byte {
+	if x != nil {
+		return x.TraceJson
+	}
+	return nil
+}
+
+func (x *CheckPolicyResponse) GetPolicyVersion() string {
+	if x != nil {
+		return x.PolicyVersion
+	}
+	return ""
+}
+
+var File_audit_proto protoreflect.FileDescriptor
+
+const file_audit_proto_rawDesc = "" +
+	"\n" +
+	"\vaudit.proto\x12\x11helpdesk.audit.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbd\x02\n" +
+	"\n" +
+	"AuditEvent\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x03 \x01(\tR\teventType\x12\x19\n" +
+	"\btrace_id\x18\x04 \x01(\tR\atraceId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12\x14\n" +
+	"\x05agent\x18\x06 \x01(\tR\x05agent\x12\x1b\n" +
+	"\ttenant_id\x18\a \x01(\tR\btenantId\x128\n" +
+	"\ttimestamp\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"event_hash\x18\t \x01(\tR\teventHash\x12\x1d\n" +
+	"\n" +
+	"event_json\x18\n" +
+	" \x01(\fR\teventJson\"3\n" +
+	"\x12RecordEventRequest\x12\x1d\n" +
+	"\n" +
+	"event_json\x18\x01 \x01(\fR\teventJson\"l\n" +
+	"\x13RecordEventResponse\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_hash\x18\x02 \x01(\tR\teventHash\x12\x1b\n" +
+	"\tprev_hash\x18\x03 \x01(\tR\bprevHash\"\xa5\x01\n" +
+	"\x14RecordOutcomeRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x125\n" +
+	"\bduration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\bduration\"\x17\n" +
+	"\x15RecordOutcomeResponse\"\x91\x03\n" +
+	"\x12QueryEventsRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x19\n" +
+	"\btrace_id\x18\x02 \x01(\tR\atraceId\x12&\n" +
+	"\x0ftrace_id_prefix\x18\x03 \x01(\tR\rtraceIdPrefix\x12\x1f\n" +
+	"\vevent_types\x18\x04 \x03(\tR\n" +
+	"eventTypes\x12\x14\n" +
+	"\x05agent\x18\x05 \x01(\tR\x05agent\x12!\n" +
+	"\faction_class\x18\x06 \x01(\tR\vactionClass\x120\n" +
+	"\x05since\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12%\n" +
+	"\x0eoutcome_status\x18\b \x01(\tR\routcomeStatus\x12\x16\n" +
+	"\x06origin\x18\t \x01(\tR\x06origin\x12\x1b\n" +
+	"\ttool_name\x18\n" +
+	" \x01(\tR\btoolName\x12\x1b\n" +
+	"\ttenant_id\x18\v \x01(\tR\btenantId\x12\x14\n" +
+	"\x05limit\x18\f \x01(\x05R\x05limit\"\x8f\x01\n" +
+	"\x16SubscribeEventsRequest\x12&\n" +
+	"\freplay_after\x18\x01 \x01(\x03H\x00R\vreplayAfter\x88\x01\x01\x12\x1f\n" +
+	"\vevent_types\x18\x02 \x03(\tR\n" +
+	"eventTypes\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantIdB\x0f\n" +
+	"\r_replay_after\"\xa0\x06\n" +
+	"\bApproval\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12\x19\n" +
+	"\btrace_id\x18\x04 \x01(\tR\atraceId\x12\x1b\n" +
+	"\ttenant_id\x18\x05 \x01(\tR\btenantId\x12!\n" +
+	"\faction_class\x18\x06 \x01(\tR\vactionClass\x12\x1b\n" +
+	"\ttool_name\x18\a \x01(\tR\btoolName\x12\x1d\n" +
+	"\n" +
+	"agent_name\x18\b \x01(\tR\tagentName\x12#\n" +
+	"\rresource_type\x18\t \x01(\tR\fresourceType\x12#\n" +
+	"\rresource_name\x18\n" +
+	" \x01(\tR\fresourceName\x12!\n" +
+	"\frequested_by\x18\v \x01(\tR\vrequestedBy\x12=\n" +
+	"\frequested_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\vrequestedAt\x12\x1f\n" +
+	"\vresolved_by\x18\r \x01(\tR\n" +
+	"resolvedBy\x12;\n" +
+	"\vresolved_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"resolvedAt\x12+\n" +
+	"\x11resolution_reason\x18\x0f \x01(\tR\x10resolutionReason\x129\n" +
+	"\n" +
+	"expires_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12L\n" +
+	"\x14approval_valid_until\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\x12approvalValidUntil\x12\x1f\n" +
+	"\vpolicy_name\x18\x12 \x01(\tR\n" +
+	"policyName\x12#\n" +
+	"\rapprover_role\x18\x13 \x01(\tR\fapproverRole\x12#\n" +
+	"\rapproval_json\x18\x14 \x01(\fR\fapprovalJson\"\xff\x03\n" +
+	"\x15CreateApprovalRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x19\n" +
+	"\btrace_id\x18\x02 \x01(\tR\atraceId\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\x12!\n" +
+	"\faction_class\x18\x04 \x01(\tR\vactionClass\x12\x1b\n" +
+	"\ttool_name\x18\x05 \x01(\tR\btoolName\x12\x1d\n" +
+	"\n" +
+	"agent_name\x18\x06 \x01(\tR\tagentName\x12#\n" +
+	"\rresource_type\x18\a \x01(\tR\fresourceType\x12#\n" +
+	"\rresource_name\x18\b \x01(\tR\fresourceName\x12!\n" +
+	"\frequested_by\x18\t \x01(\tR\vrequestedBy\x120\n" +
+	"\x14request_context_json\x18\n" +
+	" \x01(\fR\x12requestContextJson\x12\x1f\n" +
+	"\vpolicy_name\x18\v \x01(\tR\n" +
+	"policyName\x12#\n" +
+	"\rapprover_role\x18\f \x01(\tR\fapproverRole\x12,\n" +
+	"\x12expires_in_minutes\x18\r \x01(\x05R\x10expiresInMinutes\x12!\n" +
+	"\fcallback_url\x18\x0e \x01(\tR\vcallbackUrl\"5\n" +
+	"\x12GetApprovalRequest\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\"\x84\x02\n" +
+	"\x14ListApprovalsRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x14\n" +
+	"\x05agent\x18\x02 \x01(\tR\x05agent\x12\x19\n" +
+	"\btrace_id\x18\x03 \x01(\tR\atraceId\x12!\n" +
+	"\frequested_by\x18\x04 \x01(\tR\vrequestedBy\x12\x1b\n" +
+	"\ttool_name\x18\x05 \x01(\tR\btoolName\x120\n" +
+	"\x05since\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1b\n" +
+	"\ttenant_id\x18\a \x01(\tR\btenantId\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x05R\x05limit\"R\n" +
+	"\x15ListApprovalsResponse\x129\n" +
+	"\tapprovals\x18\x01 \x03(\v2\x1b.helpdesk.audit.v1.ApprovalR\tapprovals\"n\n" +
+	"\x16WaitForApprovalRequest\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\x123\n" +
+	"\atimeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\xe2\x02\n" +
+	"\x16ResolveApprovalRequest\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\x12T\n" +
+	"\n" +
+	"resolution\x18\x02 \x01(\x0e24.helpdesk.audit.v1.ResolveApprovalRequest.ResolutionR\n" +
+	"resolution\x12\x1f\n" +
+	"\vresolved_by\x18\x03 \x01(\tR\n" +
+	"resolvedBy\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12*\n" +
+	"\x11valid_for_minutes\x18\x05 \x01(\x05R\x0fvalidForMinutes\"l\n" +
+	"\n" +
+	"Resolution\x12\x1a\n" +
+	"\x16RESOLUTION_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12RESOLUTION_APPROVE\x10\x01\x12\x13\n" +
+	"\x0fRESOLUTION_DENY\x10\x02\x12\x15\n" +
+	"\x11RESOLUTION_CANCEL\x10\x03\"\xc4\x04\n" +
+	"\x12CheckPolicyRequest\x12#\n" +
+	"\rresource_type\x18\x01 \x01(\tR\fresourceType\x12#\n" +
+	"\rresource_name\x18\x02 \x01(\tR\fresourceName\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x19\n" +
+	"\btrace_id\x18\x05 \x01(\tR\atraceId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x06 \x01(\tR\tsessionId\x12\x1d\n" +
+	"\n" +
+	"agent_name\x18\a \x01(\tR\tagentName\x12\x12\n" +
+	"\x04note\x18\b \x01(\tR\x04note\x12#\n" +
+	"\rrows_affected\x18\t \x01(\x05R\frowsAffected\x12#\n" +
+	"\rpods_affected\x18\n" +
+	" \x01(\x05R\fpodsAffected\x12\"\n" +
+	"\rxact_age_secs\x18\v \x01(\x05R\vxactAgeSecs\x12%\n" +
+	"\x0epost_execution\x18\f \x01(\bR\rpostExecution\x12:\n" +
+	"\tprincipal\x18\r \x01(\v2\x1c.helpdesk.audit.v1.PrincipalR\tprincipal\x12\x18\n" +
+	"\apurpose\x18\x0e \x01(\tR\apurpose\x12!\n" +
+	"\fpurpose_note\x18\x0f \x01(\tR\vpurposeNote\x12 \n" +
+	"\vsensitivity\x18\x10 \x03(\tR\vsensitivity\x12\x1b\n" +
+	"\ttool_name\x18\x11 \x01(\tR\btoolName\"\x8d\x01\n" +
+	"\tPrincipal\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles\x12\x18\n" +
+	"\aservice\x18\x03 \x01(\tR\aservice\x12\x1f\n" +
+	"\vauth_method\x18\x04 \x01(\tR\n" +
+	"authMethod\x12\x16\n" +
+	"\x06tenant\x18\x05 \x01(\tR\x06tenant\"\xb3\x02\n" +
+	"\x13CheckPolicyResponse\x12\x16\n" +
+	"\x06effect\x18\x01 \x01(\tR\x06effect\x12\x1f\n" +
+	"\vpolicy_name\x18\x02 \x01(\tR\n" +
+	"policyName\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12 \n" +
+	"\vexplanation\x18\x04 \x01(\tR\vexplanation\x12+\n" +
+	"\x11requires_approval\x18\x05 \x01(\bR\x10requiresApproval\x12\x19\n" +
+	"\bevent_id\x18\x06 \x01(\tR\aeventId\x12\x19\n" +
+	"\btrace_id\x18\a \x01(\tR\atraceId\x12\x1d\n" +
+	"\n" +
+	"trace_json\x18\b \x01(\fR\ttraceJson\x12%\n" +
+	"\x0epolicy_version\x18\t \x01(\tR\rpolicyVersion2\x8d\b\n" +
+	"\fAuditService\x12\\\n" +
+	"\vRecordEvent\x12%.helpdesk.audit.v1.RecordEventRequest\x1a&.helpdesk.audit.v1.RecordEventResponse\x12a\n" +
+	"\fRecordEvents\x12%.helpdesk.audit.v1.RecordEventRequest\x1a&.helpdesk.audit.v1.RecordEventResponse(\x010\x01\x12b\n" +
+	"\rRecordOutcome\x12'.helpdesk.audit.v1.RecordOutcomeRequest\x1a(.helpdesk.audit.v1.RecordOutcomeResponse\x12U\n" +
+	"\vQueryEvents\x12%.helpdesk.audit.v1.QueryEventsRequest\x1a\x1d.helpdesk.audit.v1.AuditEvent0\x01\x12]\n" +
+	"\x0fSubscribeEvents\x12).helpdesk.audit.v1.SubscribeEventsRequest\x1a\x1d.helpdesk.audit.v1.AuditEvent0\x01\x12W\n" +
+	"\x0eCreateApproval\x12(.helpdesk.audit.v1.CreateApprovalRequest\x1a\x1b.helpdesk.audit.v1.Approval\x12Q\n" +
+	"\vGetApproval\x12%.helpdesk.audit.v1.GetApprovalRequest\x1a\x1b.helpdesk.audit.v1.Approval\x12b\n" +
+	"\rListApprovals\x12'.helpdesk.audit.v1.ListApprovalsRequest\x1a(.helpdesk.audit.v1.ListApprovalsResponse\x12Y\n" +
+	"\x0fWaitForApproval\x12).helpdesk.audit.v1.WaitForApprovalRequest\x1a\x1b.helpdesk.audit.v1.Approval\x12Y\n" +
+	"\x0fResolveApproval\x12).helpdesk.audit.v1.ResolveApprovalRequest\x1a\x1b.helpdesk.audit.v1.Approval\x12\\\n" +
+	"\vCheckPolicy\x12%.helpdesk.audit.v1.CheckPolicyRequest\x1a&.helpdesk.audit.v1.CheckPolicyResponseB!Z\x1fhelpdesk/internal/audit/auditpbb\x06proto3"
+
+var (
+	file_audit_proto_rawDescOnce sync.Once
+	file_audit_proto_rawDescData []byte
+)
+
+func file_audit_proto_rawDescGZIP() []byte {
+	file_audit_proto_rawDescOnce.Do(func() {
+		file_audit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_audit_proto_rawDesc), len(file_audit_proto_rawDesc)))
+	})
+	return file_audit_proto_rawDescData
+}
+
+var file_audit_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_audit_proto_goTypes = []any{
+	(ResolveApprovalRequest_Resolution)(0), // 0: helpdesk.audit.v1.ResolveApprovalRequest.Resolution
+	(*AuditEvent)(nil),                     // 1: helpdesk.audit.v1.AuditEvent
+	(*RecordEventRequest)(nil),             // 2: helpdesk.audit.v1.RecordEventRequest
+	(*RecordEventResponse)(nil),            // 3: helpdesk.audit.v1.RecordEventResponse
+	(*RecordOutcomeRequest)(nil),           // 4: helpdesk.audit.v1.RecordOutcomeRequest
+	(*RecordOutcomeResponse)(nil),          // 5: helpdesk.audit.v1.RecordOutcomeResponse
+	(*QueryEventsRequest)(nil),             // 6: helpdesk.audit.v1.QueryEventsRequest
+	(*SubscribeEventsRequest)(nil),         // 7: helpdesk.audit.v1.SubscribeEventsRequest
+	(*Approval)(nil),                       // 8: helpdesk.audit.v1.Approval
+	(*CreateApprovalRequest)(nil),          // 9: helpdesk.audit.v1.CreateApprovalRequest
+	(*GetApprovalRequest)(nil),             // 10: helpdesk.audit.v1.GetApprovalRequest
+	(*ListApprovalsRequest)(nil),           // 11: helpdesk.audit.v1.ListApprovalsRequest
+	(*ListApprovalsResponse)(nil),          // 12: helpdesk.audit.v1.ListApprovalsResponse
+	(*WaitForApprovalRequest)(nil),         // 13: helpdesk.audit.v1.WaitForApprovalRequest
+	(*ResolveApprovalRequest)(nil),         // 14: helpdesk.audit.v1.ResolveApprovalRequest
+	(*CheckPolicyRequest)(nil),             // 15: helpdesk.audit.v1.CheckPolicyRequest
+	(*Principal)(nil),                      // 16: helpdesk.audit.v1.Principal
+	(*CheckPolicyResponse)(nil),            // 17: helpdesk.audit.v1.CheckPolicyResponse
+	(*timestamppb.Timestamp)(nil),          // 18: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),            // 19: google.protobuf.Duration
+}
+var file_audit_proto_depIdxs = []int32{
+	18, // 0: helpdesk.audit.v1.AuditEvent.timestamp:type_name -> google.protobuf.Timestamp
+	19, // 1: helpdesk.audit.v1.RecordOutcomeRequest.duration:type_name -> google.protobuf.Duration
+	18, // 2: helpdesk.audit.v1.QueryEventsRequest.since:type_name -> google.protobuf.Timestamp
+	18, // 3: helpdesk.audit.v1.Approval.requested_at:type_name -> google.protobuf.Timestamp
+	18, // 4: helpdesk.audit.v1.Approval.resolved_at:type_name -> google.protobuf.Timestamp
+	18, // 5: helpdesk.audit.v1.Approval.expires_at:type_name -> google.protobuf.Timestamp
+	18, // 6: helpdesk.audit.v1.Approval.approval_valid_until:type_name -> google.protobuf.Timestamp
+	18, // 7: helpdesk.audit.v1.ListApprovalsRequest.since:type_name -> google.protobuf.Timestamp
+	8,  // 8: helpdesk.audit.v1.ListApprovalsResponse.approvals:type_name -> helpdesk.audit.v1.Approval
+	19, // 9: helpdesk.audit.v1.WaitForApprovalRequest.timeout:type_name -> google.protobuf.Duration
+	0,  // 10: helpdesk.audit.v1.ResolveApprovalRequest.resolution:type_name -> helpdesk.audit.v1.ResolveApprovalRequest.Resolution
+	16, // 11: helpdesk.audit.v1.CheckPolicyRequest.principal:type_name -> helpdesk.audit.v1.Principal
+	2,  // 12: helpdesk.audit.v1.AuditService.RecordEvent:input_type -> helpdesk.audit.v1.RecordEventRequest
+	2,  // 13: helpdesk.audit.v1.AuditService.RecordEvents:input_type -> helpdesk.audit.v1.RecordEventRequest
+	4,  // 14: helpdesk.audit.v1.AuditService.RecordOutcome:input_type -> helpdesk.audit.v1.RecordOutcomeRequest
+	6,  // 15: helpdesk.audit.v1.AuditService.QueryEvents:input_type -> helpdesk.audit.v1.QueryEventsRequest
+	7,  // 16: helpdesk.audit.v1.AuditService.SubscribeEvents:input_type -> helpdesk.audit.v1.SubscribeEventsRequest
+	9,  // 17: helpdesk.audit.v1.AuditService.CreateApproval:input_type -> helpdesk.audit.v1.CreateApprovalRequest
+	10, // 18: helpdesk.audit.v1.AuditService.GetApproval:input_type -> helpdesk.audit.v1.GetApprovalRequest
+	11, // 19: helpdesk.audit.v1.AuditService.ListApprovals:input_type -> helpdesk.audit.v1.ListApprovalsRequest
+	13, // 20: helpdesk.audit.v1.AuditService.WaitForApproval:input_type -> helpdesk.audit.v1.WaitForApprovalRequest
+	14, // 21: helpdesk.audit.v1.AuditService.ResolveApproval:input_type -> helpdesk.audit.v1.ResolveApprovalRequest
+	15, // 22: helpdesk.audit.v1.AuditService.CheckPolicy:input_type -> helpdesk.audit.v1.CheckPolicyRequest
+	3,  // 23: helpdesk.audit.v1.AuditService.RecordEvent:output_type -> helpdesk.audit.v1.RecordEventResponse
+	3,  // 24: helpdesk.audit.v1.AuditService.RecordEvents:output_type -> helpdesk.audit.v1.RecordEventResponse
+	5,  // 25: helpdesk.audit.v1.AuditService.RecordOutcome:output_type -> helpdesk.audit.v1.RecordOutcomeResponse
+	1,  // 26: helpdesk.audit.v1.AuditService.QueryEvents:output_type -> helpdesk.audit.v1.AuditEvent
+	1,  // 27: helpdesk.audit.v1.AuditService.SubscribeEvents:output_type -> helpdesk.audit.v1.AuditEvent
+	8,  // 28: helpdesk.audit.v1.AuditService.CreateApproval:output_type -> helpdesk.audit.v1.Approval
+	8,  // 29: helpdesk.audit.v1.AuditService.GetApproval:output_type -> helpdesk.audit.v1.Approval
+	12, // 30: helpdesk.audit.v1.AuditService.ListApprovals:output_type -> helpdesk.audit.v1.ListApprovalsResponse
+	8,  // 31: helpdesk.audit.v1.AuditService.WaitForApproval:output_type -> helpdesk.audit.v1.Approval
+	8,  // 32: helpdesk.audit.v1.AuditService.ResolveApproval:output_type -> helpdesk.audit.v1.Approval
+	17, // 33: helpdesk.audit.v1.AuditService.CheckPolicy:output_type -> helpdesk.audit.v1.CheckPolicyResponse
+	23, // [23:34] is the sub-list for method output_type
+	12, // [12:23] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_audit_proto_init() }
+func file_audit_proto_init() {
+	if File_audit_proto != nil {
+		return
+	}
+	file_audit_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_audit_proto_rawDesc), len(file_audit_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_audit_proto_goTypes,
+		DependencyIndexes: file_audit_proto_depIdxs,
+		EnumInfos:         file_audit_proto_enumTypes,
+		MessageInfos:      file_audit_proto_msgTypes,
+	}.Build()
+	File_audit_proto = out.File
+	file_audit_proto_goTypes = nil
+	file_audit_proto_depIdxs = nil
+}
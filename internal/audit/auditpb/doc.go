@@ -0,0 +1,5 @@
+// Package auditpb holds the gRPC API for auditd, generated from audit.proto.
+// auditd serves it next to the REST API when started with -grpc-listen.
+package auditpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative audit.proto
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CalibrationSnapshot is one agent's confidence calibration as computed by
+// auditd's periodic calibration job. A series of snapshots shows how an
+// agent's calibration drifts — e.g. after a prompt or model change.
+type CalibrationSnapshot struct {
+	ID          int64     `json:"id,omitempty"`
+	ComputedAt  time.Time `json:"computed_at"`
+	WindowStart time.Time `json:"window_start"` // decisions since this time were binned
+	TenantID    string    `json:"tenant_id,omitempty"`
+	AgentCalibration
+}
+
+// CalibrationSnapshotStore persists CalibrationSnapshots. It shares the same
+// *sql.DB connection as the audit Store.
+type CalibrationSnapshotStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewCalibrationSnapshotStore creates the calibration_snapshots table (if
+// absent) and returns a ready-to-use CalibrationSnapshotStore.
+func NewCalibrationSnapshotStore(db *sql.DB, isPostgres bool) (*CalibrationSnapshotStore, error) {
+	s := &CalibrationSnapshotStore{db: db, isPostgres: isPostgres}
+	pk := "INTEGER PRIMARY KEY AUTOINCREMENT"
+	if isPostgres {
+		pk = "BIGSERIAL PRIMARY KEY"
+	}
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS calibration_snapshots (
+    id              %s,
+    computed_at     TEXT    NOT NULL,
+    window_start    TEXT    NOT NULL,
+    tenant_id       TEXT    NOT NULL DEFAULT '',
+    agent           TEXT    NOT NULL DEFAULT '',
+    decisions       INTEGER NOT NULL DEFAULT 0,
+    failed          INTEGER NOT NULL DEFAULT 0,
+    mean_confidence REAL    NOT NULL DEFAULT 0,
+    success_rate    REAL    NOT NULL DEFAULT 0,
+    overconfidence  REAL    NOT NULL DEFAULT 0,
+    ece             REAL    NOT NULL DEFAULT 0,
+    bins_json       TEXT
+)`, pk),
+		`CREATE INDEX IF NOT EXISTS idx_calibration_snapshots_agent ON calibration_snapshots(agent, computed_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("create calibration_snapshots schema: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Save records the overall curve (agent "") and every per-agent curve of
+// report as snapshots taken at computedAt.
+func (s *CalibrationSnapshotStore) Save(ctx context.Context, computedAt, windowStart time.Time, tenantID string, report *ConfidenceCalibration) error {
+	curves := append([]AgentCalibration{report.Overall}, report.Agents...)
+	for _, c := range curves {
+		binsJSON, _ := json.Marshal(c.Bins)
+		if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `INSERT INTO calibration_snapshots
+			(computed_at, window_start, tenant_id, agent, decisions, failed,
+			 mean_confidence, success_rate, overconfidence, ece, bins_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			computedAt.UTC().Format(sqliteTimeFormat), windowStart.UTC().Format(sqliteTimeFormat), tenantID,
+			c.Agent, c.Decisions, c.Failed, c.MeanConfidence, c.SuccessRate, c.Overconfidence, c.ECE,
+			string(binsJSON)); err != nil {
+			return fmt.Errorf("insert calibration snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// History returns up to limit snapshots for agent ("" = the all-agent curve)
+// and tenant, newest first.
+func (s *CalibrationSnapshotStore) History(ctx context.Context, agent, tenantID string, limit int) ([]CalibrationSnapshot, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT id, computed_at, window_start, tenant_id, agent, decisions, failed,
+		       mean_confidence, success_rate, overconfidence, ece, COALESCE(bins_json, '')
+		FROM calibration_snapshots
+		WHERE agent = ? AND tenant_id = ?
+		ORDER BY computed_at DESC, id DESC
+		LIMIT ?`), agent, tenantID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query calibration snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CalibrationSnapshot
+	for rows.Next() {
+		var snap CalibrationSnapshot
+		var computedAt, windowStart, binsJSON string
+		if err := rows.Scan(&snap.ID, &computedAt, &windowStart, &snap.TenantID, &snap.Agent,
+			&snap.Decisions, &snap.Failed, &snap.MeanConfidence, &snap.SuccessRate,
+			&snap.Overconfidence, &snap.ECE, &binsJSON); err != nil {
+			return nil, fmt.Errorf("scan calibration snapshot: %w", err)
+		}
+		snap.ComputedAt, _ = time.Parse(sqliteTimeFormat, computedAt)
+		snap.WindowStart, _ = time.Parse(sqliteTimeFormat, windowStart)
+		if binsJSON != "" {
+			json.Unmarshal([]byte(binsJSON), &snap.Bins) //nolint:errcheck
+		}
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+// Prune deletes snapshots computed before cutoff and returns how many were removed.
+func (s *CalibrationSnapshotStore) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres,
+		`DELETE FROM calibration_snapshots WHERE computed_at < ?`), cutoff.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return 0, fmt.Errorf("prune calibration snapshots: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,558 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChainSharding selects how the audit hash chain is split into segments.
+//
+// With a single global chain every Record call is serialized behind one lock
+// and verification must re-hash the whole log. Sharding links each event only
+// to the previous event of its own segment, so writes to different segments
+// prepare and hash their events without waiting on each other's chain heads
+// (the insert itself stays serialized, see Store.Record) and segments verify
+// independently. Each segment's head is kept in a signed index
+// (audit_chain_segments) so truncating or rewriting the tail of a segment is
+// still detected.
+type ChainSharding string
+
+const (
+	ChainShardingNone    ChainSharding = ""        // one global chain (default)
+	ChainShardingSession ChainSharding = "session" // one chain per session ID
+	ChainShardingDay     ChainSharding = "day"     // one chain per UTC day of the event timestamp
+)
+
+// ParseChainSharding validates a sharding mode from configuration.
+// "global" and "none" are accepted as aliases for the default.
+func ParseChainSharding(s string) (ChainSharding, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none", "global":
+		return ChainShardingNone, nil
+	case "session":
+		return ChainShardingSession, nil
+	case "day":
+		return ChainShardingDay, nil
+	}
+	return "", fmt.Errorf("invalid chain sharding %q: want global, session or day", s)
+}
+
+// segmentFor returns the chain segment event is linked into. Events without
+// a session ID stay on the global chain under session sharding.
+func (m ChainSharding) segmentFor(event *Event) string {
+	switch m {
+	case ChainShardingSession:
+		if event.Session.ID != "" {
+			return "session:" + event.Session.ID
+		}
+	case ChainShardingDay:
+		return "day:" + event.Timestamp.UTC().Format("2006-01-02")
+	}
+	return ""
+}
+
+// maxCachedSegments bounds the in-memory segment heads. Idle heads beyond the
+// bound are dropped and reloaded from the index on their next write.
+const maxCachedSegments = 4096
+
+// segmentState is the tail of one chain segment as recorded in the index.
+type segmentState struct {
+	firstEventID string
+	headID       int64 // audit_events.id of the head event
+	headEventID  string
+	headHash     string
+	count        int64
+}
+
+// segmentHead is the in-memory tail of one chain segment. mu serializes
+// writers to the segment; refs is guarded by Store.segMu.
+type segmentHead struct {
+	mu     sync.Mutex
+	refs   int
+	loaded bool
+	segmentState
+}
+
+// acquireSegment returns the locked head of segment seg.
+func (s *Store) acquireSegment(seg string) *segmentHead {
+	s.segMu.Lock()
+	h, ok := s.segments[seg]
+	if !ok {
+		h = &segmentHead{segmentState: segmentState{headHash: GenesisHash}}
+		s.segments[seg] = h
+	}
+	h.refs++
+	s.segMu.Unlock()
+
+	h.mu.Lock()
+	return h
+}
+
+// releaseSegment unlocks h and drops it from the cache when the cache is over
+// its bound and nobody else is waiting on it.
+func (s *Store) releaseSegment(seg string, h *segmentHead) {
+	h.mu.Unlock()
+
+	s.segMu.Lock()
+	h.refs--
+	if h.refs == 0 && len(s.segments) > maxCachedSegments {
+		delete(s.segments, seg)
+	}
+	s.segMu.Unlock()
+}
+
+// loadSegment fills h from the segment index, or, for a segment written before
+// the index existed (the legacy global chain), from audit_events itself.
+func (s *Store) loadSegment(ctx context.Context, seg string, h *segmentHead) error {
+	err := s.db.QueryRowContext(ctx, rebind(s.isPostgres, `
+		SELECT first_event_id, head_id, head_event_id, head_hash, event_count
+		FROM audit_chain_segments WHERE segment = ?`), seg,
+	).Scan(&h.firstEventID, &h.headID, &h.headEventID, &h.headHash, &h.count)
+	if err == nil {
+		h.loaded = true
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	var minID, maxID int64
+	if err := s.db.QueryRowContext(ctx, rebind(s.isPostgres, `
+		SELECT COUNT(*), COALESCE(MIN(id), 0), COALESCE(MAX(id), 0)
+		FROM audit_events WHERE chain_segment = ?`), seg,
+	).Scan(&h.count, &minID, &maxID); err != nil {
+		return err
+	}
+	h.headHash = GenesisHash
+	if h.count > 0 {
+		var hash sql.NullString
+		if err := s.db.QueryRowContext(ctx, rebind(s.isPostgres,
+			`SELECT event_id FROM audit_events WHERE id = ?`), minID,
+		).Scan(&h.firstEventID); err != nil {
+			return err
+		}
+		if err := s.db.QueryRowContext(ctx, rebind(s.isPostgres,
+			`SELECT event_id, event_hash FROM audit_events WHERE id = ?`), maxID,
+		).Scan(&h.headEventID, &hash); err != nil {
+			return err
+		}
+		h.headID = maxID
+		if hash.Valid && hash.String != "" {
+			h.headHash = hash.String
+		}
+	}
+	h.loaded = true
+	return nil
+}
+
+// upsertSegmentIndex records h as the new head of seg inside tx.
+func (s *Store) upsertSegmentIndex(ctx context.Context, tx *sql.Tx, seg string, h segmentState) error {
+	_, err := tx.ExecContext(ctx, rebind(s.isPostgres, `
+		INSERT INTO audit_chain_segments
+			(segment, first_event_id, head_id, head_event_id, head_hash, event_count, signature, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(segment) DO UPDATE SET
+			head_id = excluded.head_id, head_event_id = excluded.head_event_id,
+			head_hash = excluded.head_hash, event_count = excluded.event_count,
+			signature = excluded.signature, updated_at = excluded.updated_at`),
+		seg, h.firstEventID, h.headID, h.headEventID, h.headHash, h.count,
+		s.sign(segmentPayload(seg, h.firstEventID, h.headID, h.headEventID, h.headHash, h.count)),
+		time.Now().UTC().Format(sqliteTimeFormat),
+	)
+	return err
+}
+
+func createChainSegmentTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_chain_segments (
+		segment            TEXT PRIMARY KEY,
+		first_event_id     TEXT NOT NULL,
+		head_id            BIGINT NOT NULL,
+		head_event_id      TEXT NOT NULL,
+		head_hash          TEXT NOT NULL,
+		event_count        BIGINT NOT NULL,
+		signature          TEXT NOT NULL,
+		verified_id        BIGINT NOT NULL DEFAULT 0,
+		verified_hash      TEXT NOT NULL DEFAULT '',
+		verified_signature TEXT NOT NULL DEFAULT '',
+		verified_at        TEXT NOT NULL DEFAULT '',
+		updated_at         TEXT NOT NULL
+	);
+	`)
+	return err
+}
+
+// ── Index signatures ─────────────────────────────────────────────────────────
+
+func segmentPayload(seg, firstEventID string, headID int64, headEventID, headHash string, count int64) string {
+	return fmt.Sprintf("segment\n%s\n%s\n%d\n%s\n%s\n%d", seg, firstEventID, headID, headEventID, headHash, count)
+}
+
+func checkpointPayload(seg string, verifiedID int64, verifiedHash string) string {
+	return fmt.Sprintf("checkpoint\n%s\n%d\n%s", seg, verifiedID, verifiedHash)
+}
+
+// sign returns "hmac-sha256:<hex>" when a chain key is configured and a plain
+// "sha256:<hex>" digest otherwise. The digest catches corruption but not a
+// deliberate rewrite; configure StoreConfig.ChainKey for the latter.
+func (s *Store) sign(payload string) string {
+	if len(s.chainKey) > 0 {
+		mac := hmac.New(sha256.New, s.chainKey)
+		mac.Write([]byte(payload))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// checkSignature reports whether sig matches payload, and whether the check
+// was keyed. With a chain key configured only keyed signatures are accepted;
+// without one, a keyed signature cannot be checked and is passed as unkeyed.
+func (s *Store) checkSignature(sig, payload string) (ok, keyed bool) {
+	switch {
+	case strings.HasPrefix(sig, "hmac-sha256:"):
+		if len(s.chainKey) == 0 {
+			return true, false
+		}
+		return hmac.Equal([]byte(sig), []byte(s.sign(payload))), true
+	case strings.HasPrefix(sig, "sha256:") && len(s.chainKey) == 0:
+		return sig == s.sign(payload), false
+	}
+	return false, len(s.chainKey) > 0
+}
+
+// adoptChainKey re-signs an index written without a key the first time the
+// store is opened with one. Once any entry carries a keyed signature it is
+// never done again, so an unkeyed entry forged later is not laundered.
+func (s *Store) adoptChainKey() error {
+	if len(s.chainKey) == 0 {
+		return nil
+	}
+	var keyed int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM audit_chain_segments WHERE signature LIKE 'hmac-sha256:%'`).Scan(&keyed); err != nil {
+		return err
+	}
+	if keyed > 0 {
+		return nil
+	}
+	rows, err := s.db.Query(`
+		SELECT segment, first_event_id, head_id, head_event_id, head_hash, event_count,
+		       signature, verified_id, verified_hash, verified_signature
+		FROM audit_chain_segments`)
+	if err != nil {
+		return err
+	}
+	var entries []segmentIndexRow
+	for rows.Next() {
+		var r segmentIndexRow
+		if err := rows.Scan(&r.segment, &r.firstEventID, &r.headID, &r.headEventID, &r.headHash, &r.count,
+			&r.signature, &r.verifiedID, &r.verifiedHash, &r.verifiedSig); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	keyless := &Store{}
+	for _, r := range entries {
+		payload := segmentPayload(r.segment, r.firstEventID, r.headID, r.headEventID, r.headHash, r.count)
+		if r.signature != keyless.sign(payload) {
+			continue // corrupt already: leave it for verification to report
+		}
+		verifiedSig := ""
+		checkpoint := checkpointPayload(r.segment, r.verifiedID, r.verifiedHash)
+		if r.verifiedID > 0 && r.verifiedSig == keyless.sign(checkpoint) {
+			verifiedSig = s.sign(checkpoint)
+		}
+		if _, err := s.db.Exec(rebind(s.isPostgres,
+			`UPDATE audit_chain_segments SET signature = ?, verified_signature = ? WHERE segment = ?`),
+			s.sign(payload), verifiedSig, r.segment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── Verification ─────────────────────────────────────────────────────────────
+
+// VerifyOptions controls Store.VerifySegments.
+type VerifyOptions struct {
+	// Incremental re-hashes only the events appended to each segment since its
+	// last successful verification checkpoint. A full pass (the default)
+	// re-hashes every event and also detects deleted events in the middle of a
+	// segment; run one periodically.
+	Incremental bool
+}
+
+// verifyWorkers bounds how many segments are verified concurrently.
+const verifyWorkers = 4
+
+type segmentIndexRow struct {
+	segment                             string
+	firstEventID, headEventID, headHash string
+	headID, count                       int64
+	signature                           string
+	verifiedID                          int64
+	verifiedHash, verifiedSig           string
+}
+
+type segmentResult struct {
+	segment  string
+	verified int
+	brokenAt int
+	err      string
+	unsigned bool
+}
+
+// VerifySegments verifies every chain segment, in parallel, against the signed
+// segment index and returns the combined status.
+func (s *Store) VerifySegments(ctx context.Context, opts VerifyOptions) (ChainStatus, error) {
+	index := map[string]*segmentIndexRow{}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT segment, first_event_id, head_id, head_event_id, head_hash, event_count,
+		       signature, verified_id, verified_hash, verified_signature
+		FROM audit_chain_segments`)
+	if err != nil {
+		return ChainStatus{}, fmt.Errorf("query chain segments: %w", err)
+	}
+	for rows.Next() {
+		var r segmentIndexRow
+		if err := rows.Scan(&r.segment, &r.firstEventID, &r.headID, &r.headEventID, &r.headHash, &r.count,
+			&r.signature, &r.verifiedID, &r.verifiedHash, &r.verifiedSig); err != nil {
+			rows.Close()
+			return ChainStatus{}, fmt.Errorf("scan chain segment: %w", err)
+		}
+		index[r.segment] = &r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ChainStatus{}, err
+	}
+
+	// Segments that have events but no index entry: the legacy global chain
+	// before its first write under this version.
+	segRows, err := s.db.QueryContext(ctx, `SELECT DISTINCT chain_segment FROM audit_events`)
+	if err != nil {
+		return ChainStatus{}, fmt.Errorf("query chain segments: %w", err)
+	}
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	for segRows.Next() {
+		var name string
+		if err := segRows.Scan(&name); err != nil {
+			segRows.Close()
+			return ChainStatus{}, fmt.Errorf("scan chain segment: %w", err)
+		}
+		if _, ok := index[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	segRows.Close()
+	if err := segRows.Err(); err != nil {
+		return ChainStatus{}, err
+	}
+	sort.Strings(names)
+	tombstones, err := s.redactedHashes(ctx)
+	if err != nil {
+		return ChainStatus{}, err
+	}
+
+	results := make([]segmentResult, len(names))
+	sem := make(chan struct{}, verifyWorkers)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errMu sync.Mutex
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			res, err := s.verifySegment(ctx, name, index[name], opts.Incremental, tombstones)
+			if err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
+				return
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return ChainStatus{}, firstErr
+	}
+
+	status := ChainStatus{Valid: true, BrokenAt: -1, Segments: len(names), Incremental: opts.Incremental}
+	for _, res := range results {
+		status.VerifiedEvents += res.verified
+		if res.unsigned {
+			status.UnsignedSegments++
+		}
+		if res.err != "" && status.Valid {
+			status.Valid = false
+			status.BrokenAt = res.brokenAt
+			status.BrokenSegment = res.segment
+			status.Error = res.err
+			if res.segment != "" {
+				status.Error = "segment " + res.segment + ": " + res.err
+			}
+		}
+	}
+	if err := s.fillChainTotals(ctx, &status); err != nil {
+		return ChainStatus{}, err
+	}
+	return status, nil
+}
+
+// verifySegment checks one segment's chain and, when it is indexed, that the
+// chain ends exactly at the signed head. On success the verification
+// checkpoint is advanced to the head.
+func (s *Store) verifySegment(ctx context.Context, seg string, idx *segmentIndexRow, incremental bool, tombstones map[string][]Tombstone) (segmentResult, error) {
+	res := segmentResult{segment: seg, brokenAt: -1}
+	fail := func(at int, format string, args ...any) (segmentResult, error) {
+		res.brokenAt = at
+		res.err = fmt.Sprintf(format, args...)
+		return res, nil
+	}
+
+	prev, fromID := GenesisHash, int64(0)
+	q := `SELECT raw_json FROM audit_events WHERE chain_segment = ? AND id > ?`
+	args := []any{seg, int64(0)}
+	if idx != nil {
+		ok, keyed := s.checkSignature(idx.signature,
+			segmentPayload(seg, idx.firstEventID, idx.headID, idx.headEventID, idx.headHash, idx.count))
+		if !ok {
+			return fail(0, "segment index signature mismatch")
+		}
+		res.unsigned = !keyed
+		if incremental && idx.verifiedID > 0 {
+			if ok, _ := s.checkSignature(idx.verifiedSig, checkpointPayload(seg, idx.verifiedID, idx.verifiedHash)); ok {
+				prev, fromID = idx.verifiedHash, idx.verifiedID
+			}
+		}
+		if fromID == idx.headID {
+			return res, nil // nothing appended since the last checkpoint
+		}
+		// Events appended after the index was read belong to the next pass.
+		q += ` AND id <= ?`
+		args = append(args, idx.headID)
+	}
+	args[1] = fromID
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, q+` ORDER BY id ASC`), args...)
+	if err != nil {
+		return res, fmt.Errorf("query segment %q: %w", seg, err)
+	}
+	var events []Event
+	for rows.Next() {
+		var rawJSON string
+		if err := rows.Scan(&rawJSON); err != nil {
+			rows.Close()
+			return res, fmt.Errorf("scan event: %w", err)
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(rawJSON), &event); err != nil {
+			rows.Close()
+			return res, fmt.Errorf("unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+	res.verified = len(events)
+
+	for i := range events {
+		if events[i].ChainSegment != seg {
+			return fail(i, "event %s claims segment %q", events[i].EventID, events[i].ChainSegment)
+		}
+	}
+	if brokenAt, err := verifyChainFrom(events, prev, tombstones); err != nil {
+		return fail(brokenAt, "%s", err.Error())
+	}
+	if idx == nil {
+		return res, nil
+	}
+
+	if len(events) == 0 {
+		return fail(0, "segment is empty but its index head is %s", idx.headEventID)
+	}
+	last := events[len(events)-1]
+	lastHash := last.EventHash
+	if lastHash == "" {
+		lastHash = ComputeEventHash(&last)
+	}
+	if last.EventID != idx.headEventID || lastHash != idx.headHash {
+		return fail(len(events)-1, "segment ends at %s but its index head is %s (events removed from the tail?)",
+			last.EventID, idx.headEventID)
+	}
+	if fromID == 0 && int64(len(events)) != idx.count {
+		return fail(len(events)-1, "segment has %d events but its index records %d", len(events), idx.count)
+	}
+
+	_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		UPDATE audit_chain_segments
+		SET verified_id = ?, verified_hash = ?, verified_signature = ?, verified_at = ?
+		WHERE segment = ?`),
+		idx.headID, idx.headHash, s.sign(checkpointPayload(seg, idx.headID, idx.headHash)),
+		time.Now().UTC().Format(sqliteTimeFormat), seg)
+	if err != nil {
+		return res, fmt.Errorf("save verification checkpoint for %q: %w", seg, err)
+	}
+	return res, nil
+}
+
+// fillChainTotals sets the event counts and first/last event of status from
+// the whole log rather than from the (possibly incremental) events re-hashed.
+func (s *Store) fillChainTotals(ctx context.Context, status *ChainStatus) error {
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(CASE WHEN event_hash IS NOT NULL AND event_hash <> '' THEN 1 END)
+		FROM audit_events`,
+	).Scan(&status.TotalEvents, &status.HashedEvents); err != nil {
+		return fmt.Errorf("count events: %w", err)
+	}
+	status.LegacyEvents = status.TotalEvents - status.HashedEvents
+	if status.TotalEvents == 0 {
+		return nil
+	}
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT event_id FROM audit_events ORDER BY id ASC LIMIT 1`,
+	).Scan(&status.FirstEventID); err != nil {
+		return fmt.Errorf("query first event: %w", err)
+	}
+	var rawJSON string
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT raw_json FROM audit_events ORDER BY id DESC LIMIT 1`,
+	).Scan(&rawJSON); err != nil {
+		return fmt.Errorf("query last event: %w", err)
+	}
+	var last Event
+	if err := json.Unmarshal([]byte(rawJSON), &last); err != nil {
+		return fmt.Errorf("unmarshal event: %w", err)
+	}
+	status.LastEventID = last.EventID
+	status.LastHash = last.EventHash
+	if status.LastHash == "" {
+		status.LastHash = ComputeEventHash(&last)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newShardedStore(t testing.TB, sharding ChainSharding, key []byte) *Store {
+	t.Helper()
+	store, err := NewStore(StoreConfig{
+		DBPath:        filepath.Join(t.TempDir(), "audit.db"),
+		ChainSharding: sharding,
+		ChainKey:      key,
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func recordN(t *testing.T, store *Store, session string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := store.Record(context.Background(), &Event{
+			EventType: EventTypeDelegation,
+			Session:   Session{ID: session},
+			Input:     Input{UserQuery: fmt.Sprintf("%s #%d", session, i)},
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+}
+
+func TestParseChainSharding(t *testing.T) {
+	for in, want := range map[string]ChainSharding{
+		"": ChainShardingNone, "global": ChainShardingNone, "Session": ChainShardingSession, "day": ChainShardingDay,
+	} {
+		if got, err := ParseChainSharding(in); err != nil || got != want {
+			t.Errorf("ParseChainSharding(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseChainSharding("hourly"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestChainSegments_ConcurrentSessions(t *testing.T) {
+	store := newShardedStore(t, ChainShardingSession, []byte("k"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordN(t, store, fmt.Sprintf("sess_%d", i), 10)
+		}()
+	}
+	wg.Wait()
+
+	status, err := store.VerifyIntegrity(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	if !status.Valid {
+		t.Fatalf("chain invalid: %s", status.Error)
+	}
+	if status.Segments != 4 || status.TotalEvents != 40 || status.VerifiedEvents != 40 {
+		t.Errorf("segments=%d total=%d verified=%d, want 4/40/40", status.Segments, status.TotalEvents, status.VerifiedEvents)
+	}
+	if status.UnsignedSegments != 0 {
+		t.Errorf("unsigned segments = %d, want 0 with a chain key", status.UnsignedSegments)
+	}
+
+	events, _ := store.Query(context.Background(), QueryOptions{SessionID: "sess_2"})
+	for _, e := range events {
+		if e.ChainSegment != "session:sess_2" {
+			t.Errorf("event %s segment = %q, want session:sess_2", e.EventID, e.ChainSegment)
+		}
+	}
+}
+
+func TestChainSegments_IncrementalVerify(t *testing.T) {
+	store := newShardedStore(t, ChainShardingSession, nil)
+	recordN(t, store, "a", 5)
+	recordN(t, store, "b", 5)
+
+	ctx := context.Background()
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Fatalf("full verify: %+v, %v", status, err)
+	}
+
+	recordN(t, store, "a", 2)
+	status, err := store.VerifySegments(ctx, VerifyOptions{Incremental: true})
+	if err != nil || !status.Valid {
+		t.Fatalf("incremental verify: %+v, %v", status, err)
+	}
+	if status.VerifiedEvents != 2 || status.TotalEvents != 12 {
+		t.Errorf("verified=%d total=%d, want 2/12 (only new events re-hashed)", status.VerifiedEvents, status.TotalEvents)
+	}
+	if status.UnsignedSegments != 2 {
+		t.Errorf("unsigned segments = %d, want 2 without a chain key", status.UnsignedSegments)
+	}
+}
+
+func TestChainSegments_TailTruncationDetected(t *testing.T) {
+	store := newShardedStore(t, ChainShardingSession, []byte("k"))
+	recordN(t, store, "a", 3)
+	recordN(t, store, "b", 3)
+
+	// Drop the newest event of segment b: its own chain still links up, but
+	// the signed index head no longer matches.
+	if _, err := store.DB().Exec(`DELETE FROM audit_events WHERE id = (
+		SELECT MAX(id) FROM audit_events WHERE chain_segment = 'session:b')`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	status, err := store.VerifyIntegrity(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	if status.Valid || status.BrokenSegment != "session:b" {
+		t.Errorf("valid=%v broken_segment=%q, want invalid in session:b", status.Valid, status.BrokenSegment)
+	}
+}
+
+func TestChainSegments_ForgedIndexRejected(t *testing.T) {
+	store := newShardedStore(t, ChainShardingDay, []byte("k"))
+	recordN(t, store, "a", 3)
+
+	// Truncate the segment and rewrite its index to match, without the key.
+	ctx := context.Background()
+	store.DB().Exec(`DELETE FROM audit_events WHERE id = (SELECT MAX(id) FROM audit_events)`) //nolint:errcheck
+	var headID int64
+	var day, headEventID, headHash, firstEventID string
+	store.DB().QueryRow(`SELECT id, event_id, event_hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&headID, &headEventID, &headHash) //nolint:errcheck
+	store.DB().QueryRow(`SELECT segment, first_event_id FROM audit_chain_segments`).Scan(&day, &firstEventID)                                //nolint:errcheck
+	forger := &Store{}
+	store.DB().Exec(`UPDATE audit_chain_segments SET head_id = ?, head_event_id = ?, head_hash = ?, event_count = 2, signature = ?`, //nolint:errcheck
+		headID, headEventID, headHash, forger.sign(segmentPayload(day, firstEventID, headID, headEventID, headHash, 2)))
+
+	status, err := store.VerifyIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	if status.Valid {
+		t.Error("chain with a forged unkeyed index should be invalid when a chain key is configured")
+	}
+}
+
+func TestChainSegments_AdoptChainKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	keyless, err := NewStore(StoreConfig{DBPath: path, ChainSharding: ChainShardingSession})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	recordN(t, keyless, "a", 3)
+	keyless.Close()
+
+	keyed, err := NewStore(StoreConfig{DBPath: path, ChainSharding: ChainShardingSession, ChainKey: []byte("k")})
+	if err != nil {
+		t.Fatalf("NewStore with key: %v", err)
+	}
+	defer keyed.Close()
+	status, err := keyed.VerifyIntegrity(context.Background())
+	if err != nil || !status.Valid {
+		t.Fatalf("verify after adopting key: %+v, %v", status, err)
+	}
+	if status.UnsignedSegments != 0 {
+		t.Errorf("unsigned segments = %d, want 0 after re-signing", status.UnsignedSegments)
+	}
+}
+
+func TestChainSegments_CheckpointPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	first, err := NewStore(StoreConfig{DBPath: path})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	recordN(t, first, "a", 4)
+	if status, err := first.VerifyIntegrity(context.Background()); err != nil || !status.Valid {
+		t.Fatalf("full verify: %+v, %v", status, err)
+	}
+	first.Close()
+
+	// A new process picks up the checkpoint: nothing new means nothing re-hashed.
+	second, err := NewStore(StoreConfig{DBPath: path})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer second.Close()
+	status, err := second.VerifySegments(context.Background(), VerifyOptions{Incremental: true})
+	if err != nil || !status.Valid {
+		t.Fatalf("incremental verify: %+v, %v", status, err)
+	}
+	if status.VerifiedEvents != 0 || status.TotalEvents != 4 || !status.Incremental {
+		t.Errorf("verified=%d total=%d incremental=%v, want 0/4/true", status.VerifiedEvents, status.TotalEvents, status.Incremental)
+	}
+
+	// Tampering after the checkpoint is still caught incrementally.
+	recordN(t, second, "a", 2)
+	if _, err := second.DB().Exec(`UPDATE audit_events SET raw_json = REPLACE(raw_json, 'a #1', 'a #9')
+		WHERE id = (SELECT MAX(id) FROM audit_events)`); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	status, err = second.VerifySegments(context.Background(), VerifyOptions{Incremental: true})
+	if err != nil {
+		t.Fatalf("incremental verify: %v", err)
+	}
+	if status.Valid {
+		t.Error("tampered event appended after the checkpoint should fail incremental verification")
+	}
+}
+
+// A cursor consumer (the SIEM forwarder, bus relay, WORM export) must see
+// every event even while several segments are written concurrently: ids have
+// to commit in order, or "id > cursor" skips a late-committing lower id.
+func TestChainSegments_ConcurrentWritesCursor(t *testing.T) {
+	store := newShardedStore(t, ChainShardingSession, nil)
+	ctx := context.Background()
+
+	const writers, perWriter = 8, 25
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordN(t, store, fmt.Sprintf("sess_%d", i), perWriter)
+		}()
+	}
+	go func() { wg.Wait(); done.Store(true) }()
+
+	seen := map[string]bool{}
+	var cursor int64
+	for {
+		finished := done.Load()
+		batch, err := store.EventsAfter(ctx, cursor, 7)
+		if err != nil {
+			t.Fatalf("EventsAfter: %v", err)
+		}
+		for _, se := range batch {
+			if se.Seq <= cursor {
+				t.Fatalf("seq %d not after cursor %d", se.Seq, cursor)
+			}
+			seen[se.Event.EventID] = true
+			cursor = se.Seq
+		}
+		if finished && len(batch) == 0 {
+			break
+		}
+	}
+	if len(seen) != writers*perWriter {
+		t.Errorf("cursor saw %d events, want %d", len(seen), writers*perWriter)
+	}
+}
+
+// BenchmarkRecord_ParallelSegments records from concurrent writers, each on
+// its own session. Compare global with session: with sharding, writers only
+// wait for each other on the insert and commit (kept in id order), while
+// scoring, pseudonymization, encryption and hashing run in parallel.
+func BenchmarkRecord_ParallelSegments(b *testing.B) {
+	for _, sharding := range []ChainSharding{ChainShardingNone, ChainShardingSession} {
+		name := string(sharding)
+		if name == "" {
+			name = "global"
+		}
+		b.Run(name, func(b *testing.B) {
+			store := newShardedStore(b, sharding, []byte("bench-key"))
+			var writer atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				session := fmt.Sprintf("sess_%d", writer.Add(1))
+				for i := 0; pb.Next(); i++ {
+					if err := store.Record(context.Background(), &Event{
+						EventType: EventTypeDelegation,
+						Session:   Session{ID: session},
+						Input:     Input{UserQuery: fmt.Sprintf("%s #%d", session, i)},
+					}); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+	}
+}
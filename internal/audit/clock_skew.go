@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ClockSkewOptions selects the window, tenant and agent for ClockSkewStats.
+type ClockSkewOptions struct {
+	Since     time.Time     // inclusive lower bound on received_at; zero = unbounded
+	TenantID  string        // filter by tenant; empty = all tenants
+	Agent     string        // filter by agent; empty = all agents
+	Threshold time.Duration // flag agents whose median skew exceeds this; 0 = flag none
+}
+
+// AgentClockSkew is how far one agent's clock is from auditd's, from the
+// events it posted. A skew is the event's timestamp (moved to its end for
+// events that carry a duration, since those are posted on completion) minus
+// the time auditd received it: positive when the agent's clock runs ahead,
+// negative when it runs behind. Transit time makes every sample slightly
+// negative, so the median is what to compare against a threshold.
+type AgentClockSkew struct {
+	Agent    string    `json:"agent"`
+	Events   int       `json:"events"`
+	MedianMs int64     `json:"median_ms"`
+	MinMs    int64     `json:"min_ms"`
+	MaxMs    int64     `json:"max_ms"`
+	LastMs   int64     `json:"last_ms"`
+	LastSeen time.Time `json:"last_seen"`
+	Exceeded bool      `json:"exceeded,omitempty"` // |median| is over the threshold
+}
+
+// ClockSkewStats reports per-agent clock skew over a window.
+type ClockSkewStats struct {
+	Since       string           `json:"since,omitempty"`
+	ThresholdMs int64            `json:"threshold_ms,omitempty"`
+	Agents      []AgentClockSkew `json:"agents"`
+}
+
+// ClockSkewStats computes the clock skew of every agent that posted events in
+// the window, sorted by agent name. Events recorded by auditd itself have no
+// receive time and are not counted.
+func (s *Store) ClockSkewStats(ctx context.Context, opts ClockSkewOptions) (ClockSkewStats, error) {
+	stats := ClockSkewStats{ThresholdMs: opts.Threshold.Milliseconds(), Agents: []AgentClockSkew{}}
+
+	query := `
+		SELECT COALESCE(NULLIF(session_agent, ''), decision_agent, ''), timestamp, received_at,
+			COALESCE(outcome_duration_ms, 0)
+		FROM audit_events
+		WHERE received_at IS NOT NULL AND received_at != ''`
+	var args []any
+	if !opts.Since.IsZero() {
+		query += " AND received_at >= ?"
+		args = append(args, opts.Since.UTC().Format(sqliteTimeFormat))
+		stats.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+	if opts.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+	if opts.Agent != "" {
+		query += " AND COALESCE(NULLIF(session_agent, ''), decision_agent, '') = ?"
+		args = append(args, opts.Agent)
+	}
+	query += " ORDER BY received_at"
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query), args...)
+	if err != nil {
+		return stats, fmt.Errorf("query clock skew: %w", err)
+	}
+	defer rows.Close()
+
+	byAgent := map[string]*AgentClockSkew{}
+	samples := map[string][]int64{}
+	for rows.Next() {
+		var agent, ts, received string
+		var durationMs int64
+		if err := rows.Scan(&agent, &ts, &received, &durationMs); err != nil {
+			return stats, fmt.Errorf("scan clock skew: %w", err)
+		}
+		sent, err1 := time.Parse(time.RFC3339Nano, ts)
+		recv, err2 := time.Parse(time.RFC3339Nano, received)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		skew := sent.Add(time.Duration(durationMs) * time.Millisecond).Sub(recv).Milliseconds()
+		a := byAgent[agent]
+		if a == nil {
+			a = &AgentClockSkew{Agent: agent, MinMs: skew, MaxMs: skew}
+			byAgent[agent] = a
+		}
+		a.Events++
+		if skew < a.MinMs {
+			a.MinMs = skew
+		}
+		if skew > a.MaxMs {
+			a.MaxMs = skew
+		}
+		a.LastMs = skew
+		a.LastSeen = recv
+		samples[agent] = append(samples[agent], skew)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("read clock skew: %w", err)
+	}
+
+	for agent, a := range byAgent {
+		xs := samples[agent]
+		sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+		a.MedianMs = xs[len(xs)/2]
+		if opts.Threshold > 0 {
+			a.Exceeded = absMs(a.MedianMs) > opts.Threshold.Milliseconds()
+		}
+		stats.Agents = append(stats.Agents, *a)
+	}
+	sort.Slice(stats.Agents, func(i, j int) bool { return stats.Agents[i].Agent < stats.Agents[j].Agent })
+	return stats, nil
+}
+
+func absMs(ms int64) int64 {
+	if ms < 0 {
+		return -ms
+	}
+	return ms
+}
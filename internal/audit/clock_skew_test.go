@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClockSkewStats_PerAgent(t *testing.T) {
+	store := newShardedStore(t, ChainShardingNone, nil)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	record := func(agent string, sentOffset time.Duration, duration time.Duration) {
+		t.Helper()
+		received := now
+		e := &Event{
+			EventType:  EventTypeToolExecution,
+			Timestamp:  now.Add(sentOffset),
+			ReceivedAt: &received,
+			Session:    Session{ID: "s", AgentName: agent},
+		}
+		if duration > 0 {
+			e.Outcome = &Outcome{Status: "success", Duration: duration}
+		}
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	// db_agent's clock runs two minutes behind.
+	for _, jitter := range []time.Duration{-50, 0, 20} {
+		record("db_agent", -2*time.Minute+jitter*time.Millisecond, 0)
+	}
+	// k8s_agent is in sync; its long-running event is timestamped at start.
+	record("k8s_agent", -10*time.Millisecond, 0)
+	record("k8s_agent", -30*time.Second, 30*time.Second)
+	// Events auditd records itself have no receive time.
+	if err := store.Record(ctx, &Event{EventType: EventTypeCanary, Session: Session{ID: "c", AgentName: "auditd"}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	stats, err := store.ClockSkewStats(ctx, ClockSkewOptions{Since: now.Add(-time.Hour), Threshold: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("ClockSkewStats: %v", err)
+	}
+	if len(stats.Agents) != 2 {
+		t.Fatalf("agents = %+v, want db_agent and k8s_agent", stats.Agents)
+	}
+	db, k8s := stats.Agents[0], stats.Agents[1]
+	if db.Agent != "db_agent" || db.Events != 3 || db.MedianMs != -120000 || db.MinMs != -120050 || db.MaxMs != -119980 || !db.Exceeded {
+		t.Errorf("db_agent = %+v", db)
+	}
+	if k8s.Agent != "k8s_agent" || k8s.Events != 2 || k8s.Exceeded || k8s.MinMs != -10 || k8s.MaxMs != 0 {
+		t.Errorf("k8s_agent = %+v", k8s)
+	}
+
+	only, err := store.ClockSkewStats(ctx, ClockSkewOptions{Agent: "k8s_agent"})
+	if err != nil {
+		t.Fatalf("ClockSkewStats: %v", err)
+	}
+	if len(only.Agents) != 1 || only.Agents[0].Agent != "k8s_agent" {
+		t.Errorf("agent filter = %+v", only.Agents)
+	}
+}
+
+func TestComputeEventHash_CoversReceivedAt(t *testing.T) {
+	received := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := &Event{EventID: "evt_1", Timestamp: received, EventType: EventTypeToolExecution, ReceivedAt: &received}
+	e.EventHash = ComputeEventHash(e)
+
+	moved := received.Add(time.Minute)
+	e.ReceivedAt = &moved
+	if VerifyEventHash(e) {
+		t.Error("changing received_at did not break the event hash")
+	}
+	e.ReceivedAt = nil
+	if ComputeEventHash(e) == e.EventHash {
+		t.Error("hash without received_at matches the hash with it")
+	}
+}
@@ -0,0 +1,252 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SQLiteOptions tunes the SQLite backend for long-running instances. The zero
+// value keeps the defaults the store has always used: DELETE journal mode and
+// no automatic reclaiming of free pages.
+type SQLiteOptions struct {
+	// JournalMode is "delete" (default when empty) or "wal". WAL lets readers
+	// run alongside the writer but keeps recent commits in the -wal file
+	// until a checkpoint copies them into the main database file.
+	JournalMode string
+
+	// WALAutoCheckpoint is the WAL size in pages at which SQLite checkpoints
+	// automatically. 0 keeps SQLite's default (1000 pages). Only used in WAL
+	// mode.
+	WALAutoCheckpoint int
+
+	// IncrementalVacuum switches the database to auto_vacuum=INCREMENTAL so
+	// Compact can release free pages in bounded steps without rewriting the
+	// whole file. An existing database is converted once at startup by
+	// copying it with VACUUM INTO and swapping the copy in.
+	IncrementalVacuum bool
+}
+
+func (o SQLiteOptions) journalMode() string {
+	if o.JournalMode == "" {
+		return "delete"
+	}
+	return o.JournalMode
+}
+
+func (o SQLiteOptions) validate() error {
+	switch o.journalMode() {
+	case "delete", "wal":
+	default:
+		return fmt.Errorf("sqlite journal mode %q: must be delete or wal", o.JournalMode)
+	}
+	if o.WALAutoCheckpoint < 0 {
+		return fmt.Errorf("sqlite wal autocheckpoint must be >= 0, got %d", o.WALAutoCheckpoint)
+	}
+	return nil
+}
+
+// openSQLite opens the SQLite database at path with the pragmas from opts.
+func openSQLite(path string, opts SQLiteOptions) (*sql.DB, error) {
+	mode := opts.journalMode()
+	// Set journal_mode and synchronous=FULL via DSN pragmas so the driver
+	// applies them at connection-open time.  Post-open PRAGMA statements via
+	// modernc.org/sqlite do not reliably persist writes to disk, so the DSN
+	// approach is required.  DELETE mode (the default) ensures every
+	// committed transaction is flushed to the main .db file immediately; in
+	// WAL mode commits land in the -wal file and reach the main file at the
+	// next checkpoint.  FULL sync prevents data loss on power failure.
+	// busy_timeout=5000ms: SQLite waits up to 5 s for a write lock rather
+	// than returning SQLITE_BUSY immediately.  Required because recordPlaybookRunComplete
+	// goroutines can hold the write lock while a new recordPlaybookRunStart
+	// INSERT arrives on a concurrent HTTP request.
+	sqliteDSN := "file:" + path + "?_pragma=journal_mode(" + mode + ")"
+	if mode == "wal" && opts.WALAutoCheckpoint > 0 {
+		sqliteDSN += "&_pragma=wal_autocheckpoint(" + strconv.Itoa(opts.WALAutoCheckpoint) + ")"
+	}
+	sqliteDSN += "&_pragma=synchronous(full)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", sqliteDSN)
+	if err != nil {
+		return nil, fmt.Errorf("open audit database: %w", err)
+	}
+	// Limit to one open connection so writes are serialised.
+	db.SetMaxOpenConns(1)
+
+	// Verify the journal mode took effect.
+	var journalMode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err == nil {
+		slog.Info("sqlite journal mode", "mode", journalMode, "path", path)
+		if journalMode != mode {
+			slog.Warn("sqlite journal mode differs from configured mode — writes may not persist to disk",
+				"mode", journalMode, "configured", mode)
+		}
+	}
+	return db, nil
+}
+
+// enableIncrementalVacuum puts the database at path into auto_vacuum=INCREMENTAL.
+// SQLite only applies a new auto_vacuum mode to an existing database when the
+// file is rebuilt, so a populated database is copied with VACUUM INTO (which
+// writes the copy in the new mode) and the copy is renamed over the original.
+// The rename is atomic and happens before the store serves anything. db is
+// closed on the swap path; the returned handle replaces it.
+func enableIncrementalVacuum(db *sql.DB, path string, opts SQLiteOptions) (*sql.DB, error) {
+	var autoVacuum, pageCount int
+	if err := db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+		return db, err
+	}
+	if autoVacuum == 2 {
+		return db, nil
+	}
+	if _, err := db.Exec("PRAGMA auto_vacuum=INCREMENTAL"); err != nil {
+		return db, err
+	}
+	if err := db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return db, err
+	}
+	if pageCount == 0 {
+		// A new database picks the mode up when its first table is created.
+		return db, nil
+	}
+
+	tmp := path + ".compact"
+	os.Remove(tmp) //nolint:errcheck // leftover from an interrupted conversion
+	if _, err := db.Exec("VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp) //nolint:errcheck
+		return db, fmt.Errorf("vacuum into %s: %w", tmp, err)
+	}
+	if err := db.Close(); err != nil {
+		os.Remove(tmp) //nolint:errcheck
+		return nil, err
+	}
+	// Old journal/WAL files belong to the database being replaced.
+	os.Remove(path + "-wal") //nolint:errcheck
+	os.Remove(path + "-shm") //nolint:errcheck
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp) //nolint:errcheck
+		return nil, fmt.Errorf("swap compacted database: %w", err)
+	}
+	slog.Info("sqlite converted to incremental auto-vacuum", "path", path)
+	return openSQLite(path, opts)
+}
+
+// StorageStats describes the size and fragmentation of the audit database.
+type StorageStats struct {
+	Backend            string  `json:"backend"` // "sqlite" or "postgres"
+	SizeBytes          int64   `json:"size_bytes"`
+	FreeBytes          int64   `json:"free_bytes"`          // SQLite: bytes held by free pages
+	FragmentationRatio float64 `json:"fragmentation_ratio"` // FreeBytes / SizeBytes
+	PageSize           int64   `json:"page_size,omitempty"`
+	PageCount          int64   `json:"page_count,omitempty"`
+	FreelistCount      int64   `json:"freelist_count,omitempty"`
+	AutoVacuum         string  `json:"auto_vacuum,omitempty"` // none, full, incremental
+	JournalMode        string  `json:"journal_mode,omitempty"`
+	WALSizeBytes       int64   `json:"wal_size_bytes,omitempty"`
+}
+
+// StorageStats reports the database size and, for SQLite, how much of the
+// file is free pages left behind by deletes.
+func (s *Store) StorageStats(ctx context.Context) (StorageStats, error) {
+	if s.isPostgres {
+		st := StorageStats{Backend: "postgres"}
+		err := s.db.QueryRowContext(ctx, "SELECT pg_database_size(current_database())").Scan(&st.SizeBytes)
+		return st, err
+	}
+
+	st := StorageStats{Backend: "sqlite"}
+	var autoVacuum int
+	for _, p := range []struct {
+		pragma string
+		dest   any
+	}{
+		{"page_size", &st.PageSize},
+		{"page_count", &st.PageCount},
+		{"freelist_count", &st.FreelistCount},
+		{"auto_vacuum", &autoVacuum},
+		{"journal_mode", &st.JournalMode},
+	} {
+		if err := s.db.QueryRowContext(ctx, "PRAGMA "+p.pragma).Scan(p.dest); err != nil {
+			return st, fmt.Errorf("pragma %s: %w", p.pragma, err)
+		}
+	}
+	st.AutoVacuum = [...]string{"none", "full", "incremental"}[autoVacuum%3]
+	st.SizeBytes = st.PageSize * st.PageCount
+	st.FreeBytes = st.PageSize * st.FreelistCount
+	if st.SizeBytes > 0 {
+		st.FragmentationRatio = float64(st.FreeBytes) / float64(st.SizeBytes)
+	}
+	if fi, err := os.Stat(s.path + "-wal"); err == nil {
+		st.WALSizeBytes = fi.Size()
+	}
+	return st, nil
+}
+
+// CompactionResult reports one Compact run.
+type CompactionResult struct {
+	Method         string        `json:"method"` // incremental_vacuum, vacuum, or none
+	BeforeBytes    int64         `json:"before_bytes"`
+	AfterBytes     int64         `json:"after_bytes"`
+	ReclaimedBytes int64         `json:"reclaimed_bytes"`
+	Duration       time.Duration `json:"duration_ns"`
+	At             time.Time     `json:"at"`
+}
+
+// Compact returns free pages in the SQLite database file to the filesystem.
+// With auto_vacuum=INCREMENTAL it runs incremental_vacuum, releasing at most
+// maxPages pages (0 = all) so the write lock is held briefly; otherwise it
+// runs a full VACUUM, which rewrites the file and blocks writers until done.
+// In WAL mode the WAL is checkpointed and truncated afterwards. PostgreSQL
+// manages its own space (autovacuum), so Compact is a no-op there.
+func (s *Store) Compact(ctx context.Context, maxPages int) (CompactionResult, error) {
+	res := CompactionResult{Method: "none", At: time.Now().UTC()}
+	before, err := s.StorageStats(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.BeforeBytes = before.SizeBytes
+	res.AfterBytes = before.SizeBytes
+	if s.isPostgres {
+		return res, nil
+	}
+
+	start := time.Now()
+	if before.AutoVacuum == "incremental" {
+		res.Method = "incremental_vacuum"
+		// incremental_vacuum frees one page per result row step, so the rows
+		// must be drained for it to release more than a single page.
+		rows, err := s.db.QueryContext(ctx, "PRAGMA incremental_vacuum("+strconv.Itoa(maxPages)+")")
+		if err != nil {
+			return res, fmt.Errorf("incremental vacuum: %w", err)
+		}
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return res, fmt.Errorf("incremental vacuum: %w", err)
+		}
+	} else {
+		res.Method = "vacuum"
+		if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+			return res, fmt.Errorf("vacuum: %w", err)
+		}
+	}
+	if before.JournalMode == "wal" {
+		if _, err := s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			return res, fmt.Errorf("wal checkpoint: %w", err)
+		}
+	}
+	res.Duration = time.Since(start)
+
+	after, err := s.StorageStats(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.AfterBytes = after.SizeBytes
+	res.ReclaimedBytes = res.BeforeBytes - res.AfterBytes
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fragment fills a scratch table and deletes it again, leaving free pages
+// behind the way retention pruning does.
+func fragment(t *testing.T, s *Store) {
+	t.Helper()
+	db := s.DB()
+	if _, err := db.Exec("CREATE TABLE scratch (v TEXT)"); err != nil {
+		t.Fatalf("create scratch: %v", err)
+	}
+	blob := strings.Repeat("x", 4000)
+	for i := 0; i < 200; i++ {
+		if _, err := db.Exec("INSERT INTO scratch (v) VALUES (?)", blob); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM scratch"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestStore_StorageStatsAndCompact(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	fragment(t, store)
+	st, err := store.StorageStats(ctx)
+	if err != nil {
+		t.Fatalf("StorageStats: %v", err)
+	}
+	if st.Backend != "sqlite" || st.JournalMode != "delete" || st.AutoVacuum != "none" {
+		t.Errorf("stats = %+v, want sqlite/delete/none", st)
+	}
+	if st.FreelistCount == 0 || st.FragmentationRatio <= 0.5 {
+		t.Errorf("freelist = %d, ratio = %.2f; want mostly free pages", st.FreelistCount, st.FragmentationRatio)
+	}
+
+	res, err := store.Compact(ctx, 0)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if res.Method != "vacuum" || res.ReclaimedBytes <= 0 {
+		t.Errorf("result = %+v, want vacuum with bytes reclaimed", res)
+	}
+	st, _ = store.StorageStats(ctx)
+	if st.FreelistCount != 0 {
+		t.Errorf("freelist after compaction = %d, want 0", st.FreelistCount)
+	}
+}
+
+func TestStore_IncrementalVacuum_ConvertsExistingDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	store, err := NewStore(StoreConfig{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Record(ctx, &Event{EventType: EventTypeDelegation, Session: Session{ID: "s1"}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	store.Close()
+
+	store, err = NewStore(StoreConfig{DBPath: dbPath, SQLite: SQLiteOptions{IncrementalVacuum: true}})
+	if err != nil {
+		t.Fatalf("NewStore (incremental): %v", err)
+	}
+	defer store.Close()
+	st, err := store.StorageStats(ctx)
+	if err != nil {
+		t.Fatalf("StorageStats: %v", err)
+	}
+	if st.AutoVacuum != "incremental" {
+		t.Fatalf("auto_vacuum = %q, want incremental", st.AutoVacuum)
+	}
+	events, err := store.Query(ctx, QueryOptions{SessionID: "s1"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("events after conversion = %d (err %v), want 1", len(events), err)
+	}
+
+	fragment(t, store)
+	before, _ := store.StorageStats(ctx)
+	res, err := store.Compact(ctx, 10)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if res.Method != "incremental_vacuum" {
+		t.Errorf("method = %q, want incremental_vacuum", res.Method)
+	}
+	after, _ := store.StorageStats(ctx)
+	if got := before.FreelistCount - after.FreelistCount; got != 10 {
+		t.Errorf("pages released = %d, want 10 (bounded by maxPages)", got)
+	}
+	if _, err := store.Compact(ctx, 0); err != nil {
+		t.Fatalf("Compact all: %v", err)
+	}
+	if st, _ := store.StorageStats(ctx); st.FreelistCount != 0 {
+		t.Errorf("freelist after full incremental vacuum = %d, want 0", st.FreelistCount)
+	}
+}
+
+func TestStore_WALJournalMode(t *testing.T) {
+	store, err := NewStore(StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "audit.db"),
+		SQLite: SQLiteOptions{JournalMode: "wal", WALAutoCheckpoint: 100},
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	fragment(t, store)
+	if st, _ := store.StorageStats(ctx); st.JournalMode != "wal" || st.WALSizeBytes == 0 {
+		t.Errorf("stats = %+v, want wal mode with a non-empty WAL", st)
+	}
+	if _, err := store.Compact(ctx, 0); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if st, _ := store.StorageStats(ctx); st.WALSizeBytes != 0 {
+		t.Errorf("WAL size after compaction = %d, want 0 (truncated)", st.WALSizeBytes)
+	}
+}
+
+func TestSQLiteOptions_Validate(t *testing.T) {
+	if _, err := NewStore(StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "audit.db"),
+		SQLite: SQLiteOptions{JournalMode: "memory"},
+	}); err == nil {
+		t.Error("expected error for unsupported journal mode")
+	}
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ConfidenceCalibrationOptions selects the delegation decisions that
+// ConfidenceCalibration bins.
+type ConfidenceCalibrationOptions struct {
+	Since    time.Time // inclusive lower bound on timestamp; zero = unbounded
+	Until    time.Time // exclusive upper bound on timestamp; zero = unbounded
+	Agent    string    // restrict to one agent; empty = all agents
+	TenantID string    // filter by tenant; empty = all tenants
+}
+
+// ConfidenceBin is one point of a calibration curve: the routing decisions
+// whose self-reported confidence fell in Range, and how often they succeeded.
+type ConfidenceBin struct {
+	Range          string  `json:"range"` // "90-100%", "80-89%", ...
+	Decisions      int     `json:"decisions"`
+	Succeeded      int     `json:"succeeded"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	SuccessRate    float64 `json:"success_rate"` // Succeeded/Decisions; 0 when Decisions==0
+	// Gap is MeanConfidence - SuccessRate: positive when the agent claims more
+	// certainty than its outcomes support.
+	Gap float64 `json:"gap"`
+}
+
+// AgentCalibration is the calibration curve of one agent, or of all agents
+// when Agent is empty.
+type AgentCalibration struct {
+	Agent          string  `json:"agent,omitempty"`
+	Decisions      int     `json:"decisions"`
+	Failed         int     `json:"failed"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	SuccessRate    float64 `json:"success_rate"`
+	// Overconfidence is MeanConfidence - SuccessRate over all decisions.
+	Overconfidence float64 `json:"overconfidence"`
+	// ECE is the expected calibration error: the decision-weighted mean of
+	// |Gap| across bins. 0 is perfectly calibrated.
+	ECE  float64         `json:"ece"`
+	Bins []ConfidenceBin `json:"bins"`
+}
+
+// IsOverconfident reports whether the agent is systematically overconfident:
+// at least minDecisions resolved decisions, high mean confidence, and a
+// success rate at least maxGap below it.
+func (c AgentCalibration) IsOverconfident(minDecisions int, maxGap float64) bool {
+	return c.Decisions >= minDecisions && c.MeanConfidence >= highConfidence && c.Overconfidence >= maxGap
+}
+
+// ConfidenceCalibration compares the confidence routing decisions reported
+// with how the routed requests turned out.
+type ConfidenceCalibration struct {
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	// Unresolved counts decisions left out because their outcome is neither
+	// success nor error yet (pending, denied, awaiting approval).
+	Unresolved int                `json:"unresolved"`
+	Overall    AgentCalibration   `json:"overall"`
+	Agents     []AgentCalibration `json:"agents"`
+}
+
+// highConfidence is the mean confidence at which IsOverconfident starts to apply.
+const highConfidence = 0.7
+
+var confidenceBins = []bandDef{
+	{"<50%", 0.00, 0.50, 0},
+	{"50-69%", 0.50, 0.70, 0},
+	{"70-79%", 0.70, 0.80, 0},
+	{"80-89%", 0.80, 0.90, 0},
+	{"90-100%", 0.90, 1.01, 0},
+}
+
+// ConfidenceCalibration bins delegation_decision events by their reported
+// confidence and compares each bin with the decisions' outcomes. A decision's
+// outcome is that of the gateway_request in its trace when there is one — a
+// gateway routing decision is recorded as successful the moment it is made —
+// and otherwise the outcome recorded on the decision itself. Decisions
+// without a confidence are skipped.
+func (s *Store) ConfidenceCalibration(ctx context.Context, opts ConfidenceCalibrationOptions) (*ConfidenceCalibration, error) {
+	report := &ConfidenceCalibration{Agents: []AgentCalibration{}}
+
+	where := ` WHERE d.event_type = '` + string(EventTypeDelegation) + `' AND d.decision_confidence > 0`
+	var args []any
+	if !opts.Since.IsZero() {
+		where += " AND d.timestamp >= ?"
+		args = append(args, opts.Since.UTC().Format(sqliteTimeFormat))
+		report.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+	if !opts.Until.IsZero() {
+		where += " AND d.timestamp < ?"
+		args = append(args, opts.Until.UTC().Format(sqliteTimeFormat))
+		report.Until = opts.Until.UTC().Format(time.RFC3339)
+	}
+	if opts.Agent != "" {
+		where += " AND d.decision_agent = ?"
+		args = append(args, opts.Agent)
+	}
+	if opts.TenantID != "" {
+		where += " AND d.tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT COALESCE(d.decision_agent, ''), d.decision_confidence,
+		       COALESCE((SELECT g.outcome_status FROM audit_events g
+		                 WHERE g.trace_id = d.trace_id AND d.trace_id <> ''
+		                   AND g.event_type = '`+string(EventTypeGatewayRequest)+`'
+		                 ORDER BY g.id LIMIT 1),
+		                d.outcome_status, '')
+		FROM audit_events d`+where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("calibration query: %w", err)
+	}
+	defer rows.Close()
+
+	type accum struct {
+		n, ok   []int
+		confSum []float64
+	}
+	newAccum := func() *accum {
+		return &accum{
+			n:       make([]int, len(confidenceBins)),
+			ok:      make([]int, len(confidenceBins)),
+			confSum: make([]float64, len(confidenceBins)),
+		}
+	}
+	overall := newAccum()
+	byAgent := make(map[string]*accum)
+	for rows.Next() {
+		var agent, status string
+		var conf float64
+		if err := rows.Scan(&agent, &conf, &status); err != nil {
+			return nil, fmt.Errorf("scan calibration row: %w", err)
+		}
+		if status != "success" && status != "error" {
+			report.Unresolved++
+			continue
+		}
+		bin := len(confidenceBins) - 1
+		for i, b := range confidenceBins {
+			if conf >= b.min && conf < b.max {
+				bin = i
+				break
+			}
+		}
+		a := byAgent[agent]
+		if a == nil {
+			a = newAccum()
+			byAgent[agent] = a
+		}
+		for _, acc := range []*accum{overall, a} {
+			acc.n[bin]++
+			acc.confSum[bin] += conf
+			if status == "success" {
+				acc.ok[bin]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	curve := func(agent string, acc *accum) AgentCalibration {
+		c := AgentCalibration{Agent: agent, Bins: make([]ConfidenceBin, len(confidenceBins))}
+		var confSum float64
+		var ok int
+		for i, b := range confidenceBins {
+			bin := ConfidenceBin{Range: b.label, Decisions: acc.n[i], Succeeded: acc.ok[i]}
+			if bin.Decisions > 0 {
+				bin.MeanConfidence = round3(acc.confSum[i] / float64(bin.Decisions))
+				bin.SuccessRate = round3(float64(bin.Succeeded) / float64(bin.Decisions))
+				bin.Gap = round3(bin.MeanConfidence - bin.SuccessRate)
+			}
+			c.Bins[i] = bin
+			c.Decisions += bin.Decisions
+			ok += bin.Succeeded
+			confSum += acc.confSum[i]
+		}
+		if c.Decisions == 0 {
+			return c
+		}
+		c.Failed = c.Decisions - ok
+		c.MeanConfidence = round3(confSum / float64(c.Decisions))
+		c.SuccessRate = round3(float64(ok) / float64(c.Decisions))
+		c.Overconfidence = round3(c.MeanConfidence - c.SuccessRate)
+		var ece float64
+		for _, bin := range c.Bins {
+			ece += float64(bin.Decisions) / float64(c.Decisions) * math.Abs(bin.Gap)
+		}
+		c.ECE = round3(ece)
+		return c
+	}
+
+	report.Overall = curve("", overall)
+	for agent, acc := range byAgent {
+		report.Agents = append(report.Agents, curve(agent, acc))
+	}
+	sort.Slice(report.Agents, func(i, j int) bool { return report.Agents[i].Agent < report.Agents[j].Agent })
+	return report, nil
+}
+
+func round3(v float64) float64 { return math.Round(v*1000) / 1000 }
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recordRouting records a delegation_decision with the given confidence and
+// outcome. When viaGateway is set the decision itself is marked successful
+// and the outcome goes on a gateway_request in the same trace, as the
+// gateway's LLM router records them.
+func recordRouting(t *testing.T, s *Store, n int, agent string, conf float64, status string, viaGateway bool) {
+	t.Helper()
+	ctx := context.Background()
+	trace := fmt.Sprintf("tr_%s_%d", agent, n)
+	ts := time.Now().UTC().Add(time.Duration(n) * time.Millisecond)
+	own := status
+	if viaGateway {
+		own = "success"
+	}
+	err := s.Record(ctx, &Event{
+		EventID: fmt.Sprintf("rt_%s_%d", agent, n), Timestamp: ts, EventType: EventTypeDelegation, TraceID: trace,
+		Session:  Session{ID: trace},
+		Decision: &Decision{Agent: agent, Confidence: conf, ReasoningChain: []string{"routing"}},
+		Outcome:  &Outcome{Status: own},
+	})
+	if err != nil {
+		t.Fatalf("record decision: %v", err)
+	}
+	if viaGateway {
+		err = s.Record(ctx, &Event{
+			EventID: fmt.Sprintf("gw_%s_%d", agent, n), Timestamp: ts.Add(time.Second), EventType: EventTypeGatewayRequest, TraceID: trace,
+			Session:  Session{ID: trace},
+			Decision: &Decision{Agent: agent, Confidence: 1.0},
+			Outcome:  &Outcome{Status: status},
+		})
+		if err != nil {
+			t.Fatalf("record gateway request: %v", err)
+		}
+	}
+}
+
+func TestStore_ConfidenceCalibration(t *testing.T) {
+	s, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	// k8s_agent claims 0.95 but half of its routed requests fail at the gateway.
+	for i := 0; i < 10; i++ {
+		status := "success"
+		if i%2 == 0 {
+			status = "error"
+		}
+		recordRouting(t, s, i, "k8s_agent", 0.95, status, true)
+	}
+	// postgres_database_agent claims 0.75 and succeeds 3 times in 4.
+	for i := 0; i < 4; i++ {
+		status := "success"
+		if i == 0 {
+			status = "error"
+		}
+		recordRouting(t, s, i, "postgres_database_agent", 0.75, status, false)
+	}
+	recordRouting(t, s, 99, "postgres_database_agent", 0.75, "pending", false)
+
+	report, err := s.ConfidenceCalibration(context.Background(), ConfidenceCalibrationOptions{})
+	if err != nil {
+		t.Fatalf("ConfidenceCalibration: %v", err)
+	}
+	if report.Overall.Decisions != 14 || report.Unresolved != 1 {
+		t.Errorf("overall decisions=%d unresolved=%d, want 14 and 1 (gateway_request events must not count)",
+			report.Overall.Decisions, report.Unresolved)
+	}
+	if len(report.Agents) != 2 {
+		t.Fatalf("agents = %+v, want 2", report.Agents)
+	}
+
+	k8s := report.Agents[0]
+	if k8s.Agent != "k8s_agent" || k8s.SuccessRate != 0.5 || k8s.Overconfidence != 0.45 {
+		t.Errorf("k8s_agent = %+v, want success rate 0.5 from gateway outcomes", k8s)
+	}
+	if top := k8s.Bins[len(k8s.Bins)-1]; top.Range != "90-100%" || top.Decisions != 10 || top.Gap != 0.45 {
+		t.Errorf("k8s_agent 90-100%% bin = %+v", top)
+	}
+	if !k8s.IsOverconfident(10, 0.15) {
+		t.Error("k8s_agent should be overconfident")
+	}
+
+	pg := report.Agents[1]
+	if pg.Decisions != 4 || pg.Failed != 1 || pg.Overconfidence != 0 || pg.ECE != 0 {
+		t.Errorf("postgres_database_agent = %+v, want well calibrated", pg)
+	}
+	if pg.IsOverconfident(1, 0.15) {
+		t.Error("postgres_database_agent should not be overconfident")
+	}
+
+	only, err := s.ConfidenceCalibration(context.Background(), ConfidenceCalibrationOptions{Agent: "postgres_database_agent"})
+	if err != nil || len(only.Agents) != 1 || only.Overall.Decisions != 4 {
+		t.Errorf("agent filter: err=%v report=%+v", err, only)
+	}
+}
+
+func TestCalibrationSnapshotStore(t *testing.T) {
+	s, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+	snaps, err := NewCalibrationSnapshotStore(s.DB(), s.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewCalibrationSnapshotStore: %v", err)
+	}
+	ctx := context.Background()
+
+	t0 := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, rate := range []float64{0.9, 0.7} {
+		report := &ConfidenceCalibration{
+			Overall: AgentCalibration{Decisions: 10, SuccessRate: rate},
+			Agents: []AgentCalibration{{
+				Agent: "k8s_agent", Decisions: 10, SuccessRate: rate,
+				Bins: []ConfidenceBin{{Range: "90-100%", Decisions: 10}},
+			}},
+		}
+		at := t0.Add(time.Duration(i) * time.Hour)
+		if err := snaps.Save(ctx, at, at.Add(-24*time.Hour), "", report); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	hist, err := snaps.History(ctx, "k8s_agent", "", 10)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(hist) != 2 || hist[0].SuccessRate != 0.7 || !hist[0].ComputedAt.Equal(t0.Add(time.Hour)) {
+		t.Fatalf("history = %+v, want 2 snapshots newest first", hist)
+	}
+	if len(hist[0].Bins) != 1 || hist[0].Bins[0].Range != "90-100%" {
+		t.Errorf("bins = %+v", hist[0].Bins)
+	}
+	if other, _ := snaps.History(ctx, "k8s_agent", "acme", 10); len(other) != 0 {
+		t.Errorf("tenant acme sees %d fleet-wide snapshots", len(other))
+	}
+
+	n, err := snaps.Prune(ctx, t0.Add(30*time.Minute))
+	if err != nil || n != 2 { // overall + k8s_agent from the first run
+		t.Errorf("Prune removed %d (err %v), want 2", n, err)
+	}
+}
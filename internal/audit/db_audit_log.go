@@ -0,0 +1,284 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Database log formats accepted by ParseDBAuditLog. Both are structured, so
+// the user, database and application_name of every entry are known without
+// depending on the server's log_line_prefix.
+const (
+	DBLogFormatCSV  = "csvlog"  // log_destination = 'csvlog'
+	DBLogFormatJSON = "jsonlog" // log_destination = 'jsonlog' (PostgreSQL 15+)
+)
+
+// Sources of a DBAuditEntry.
+const (
+	DBAuditSourcePGAudit      = "pgaudit"
+	DBAuditSourceLogStatement = "log_statement"
+)
+
+// DBAuditEntry is one statement from a monitored database's server log, from
+// pgaudit ("AUDIT: SESSION,...") or log_statement ("statement: ...").
+type DBAuditEntry struct {
+	LoggedAt        time.Time `json:"logged_at"`
+	User            string    `json:"user"`
+	Database        string    `json:"database"`
+	ApplicationName string    `json:"application_name,omitempty"`
+	Source          string    `json:"source"`           // pgaudit or log_statement
+	Class           string    `json:"class"`            // pgaudit class: READ, WRITE, FUNCTION, ROLE, DDL, MISC
+	Command         string    `json:"command"`          // e.g. DELETE, ALTER TABLE
+	Object          string    `json:"object,omitempty"` // e.g. public.orders (pgaudit object audit only)
+	Statement       string    `json:"statement"`
+}
+
+// Mutates reports whether the statement changes data, schema or privileges.
+func (e DBAuditEntry) Mutates() bool {
+	switch e.Class {
+	case "WRITE", "DDL", "ROLE":
+		return true
+	}
+	return false
+}
+
+// OutOfBandChange is the payload of an out_of_band_change event: a database
+// change made with an agent's credentials that no tool_execution accounts for.
+type OutOfBandChange struct {
+	DBAuditEntry
+	Reason string `json:"reason"`
+}
+
+// ParseDBAuditLog reads a PostgreSQL csvlog or jsonlog stream and returns its
+// pgaudit and log_statement entries. Other log lines (connections, errors,
+// checkpoints) are skipped.
+func ParseDBAuditLog(r io.Reader, format string) ([]DBAuditEntry, error) {
+	switch format {
+	case DBLogFormatCSV:
+		return parseCSVLog(r)
+	case DBLogFormatJSON:
+		return parseJSONLog(r)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (want %s or %s)", format, DBLogFormatCSV, DBLogFormatJSON)
+	}
+}
+
+// csvlog columns used here; see "Using CSV-Format Log Output" in the
+// PostgreSQL documentation.
+const (
+	csvLogTime         = 0
+	csvUserName        = 1
+	csvDatabaseName    = 2
+	csvMessage         = 13
+	csvApplicationName = 22
+)
+
+func parseCSVLog(r io.Reader) ([]DBAuditEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // the column count varies between PostgreSQL versions
+	var entries []DBAuditEntry
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csvlog record %d: %w", line, err)
+		}
+		if len(rec) <= csvApplicationName {
+			return nil, fmt.Errorf("csvlog record %d: %d columns, want at least %d", line, len(rec), csvApplicationName+1)
+		}
+		e, ok, err := parseLogMessage(rec[csvMessage])
+		if err != nil {
+			return nil, fmt.Errorf("csvlog record %d: %w", line, err)
+		}
+		if !ok {
+			continue
+		}
+		if e.LoggedAt, err = parseLogTime(rec[csvLogTime]); err != nil {
+			return nil, fmt.Errorf("csvlog record %d: %w", line, err)
+		}
+		e.User, e.Database, e.ApplicationName = rec[csvUserName], rec[csvDatabaseName], rec[csvApplicationName]
+		entries = append(entries, e)
+	}
+}
+
+func parseJSONLog(r io.Reader) ([]DBAuditEntry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var entries []DBAuditEntry
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var rec struct {
+			Timestamp       string `json:"timestamp"`
+			User            string `json:"user"`
+			DBName          string `json:"dbname"`
+			ApplicationName string `json:"application_name"`
+			Message         string `json:"message"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("jsonlog line %d: %w", line, err)
+		}
+		e, ok, err := parseLogMessage(rec.Message)
+		if err != nil {
+			return nil, fmt.Errorf("jsonlog line %d: %w", line, err)
+		}
+		if !ok {
+			continue
+		}
+		if e.LoggedAt, err = parseLogTime(rec.Timestamp); err != nil {
+			return nil, fmt.Errorf("jsonlog line %d: %w", line, err)
+		}
+		e.User, e.Database, e.ApplicationName = rec.User, rec.DBName, rec.ApplicationName
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read jsonlog: %w", err)
+	}
+	return entries, nil
+}
+
+// parseLogTime parses log_time as PostgreSQL writes it: "2026-05-01 12:00:00.123 UTC".
+func parseLogTime(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05.999 MST", "2006-01-02 15:04:05 MST", "2006-01-02 15:04:05.999-07", "2006-01-02 15:04:05-07"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid log time %q", s)
+}
+
+// parseLogMessage extracts a statement entry from a log message. ok is false
+// for messages that are not pgaudit or log_statement output.
+func parseLogMessage(msg string) (e DBAuditEntry, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(msg, "AUDIT: "):
+		// AUDIT_TYPE,STATEMENT_ID,SUBSTATEMENT_ID,CLASS,COMMAND,OBJECT_TYPE,OBJECT_NAME,STATEMENT,PARAMETER
+		cr := csv.NewReader(strings.NewReader(strings.TrimPrefix(msg, "AUDIT: ")))
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		f, err := cr.Read()
+		if err != nil || len(f) < 8 {
+			return e, false, fmt.Errorf("malformed pgaudit message %q", msg)
+		}
+		return DBAuditEntry{
+			Source:    DBAuditSourcePGAudit,
+			Class:     f[3],
+			Command:   f[4],
+			Object:    f[6],
+			Statement: f[7],
+		}, true, nil
+
+	case strings.HasPrefix(msg, "statement: "):
+		stmt := strings.TrimSpace(strings.TrimPrefix(msg, "statement: "))
+		class, command := classifyStatement(stmt)
+		return DBAuditEntry{
+			Source:    DBAuditSourceLogStatement,
+			Class:     class,
+			Command:   command,
+			Statement: stmt,
+		}, true, nil
+	}
+	return e, false, nil
+}
+
+// classifyStatement maps a logged statement onto pgaudit's classes by its
+// leading keyword, for servers that use log_statement instead of pgaudit.
+func classifyStatement(stmt string) (class, command string) {
+	fields := strings.Fields(strings.ToUpper(stmt))
+	if len(fields) == 0 {
+		return "MISC", ""
+	}
+	command = fields[0]
+	switch command {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN":
+		return "READ", command
+	case "INSERT", "UPDATE", "DELETE", "TRUNCATE", "COPY", "MERGE":
+		return "WRITE", command
+	case "CREATE", "ALTER", "DROP", "COMMENT", "REINDEX", "CLUSTER":
+		if len(fields) > 1 && (fields[1] == "ROLE" || fields[1] == "USER") {
+			return "ROLE", command + " " + fields[1]
+		}
+		return "DDL", command
+	case "GRANT", "REVOKE":
+		return "ROLE", command
+	}
+	return "MISC", command
+}
+
+// DBAuditCorrelation configures CorrelateDBAudit.
+type DBAuditCorrelation struct {
+	// AgentUsers are the database roles the agent connects as. Entries from
+	// other users are ignored.
+	AgentUsers []string
+	// ApplicationName is the application_name the agent's connections set.
+	// When non-empty, a change from an agent user under any other
+	// application_name is out of band even if a tool call ran at the time.
+	ApplicationName string
+	// Tolerance is how far apart a statement and its tool_execution may be.
+	Tolerance time.Duration
+}
+
+// CorrelateDBAudit matches every change made by an agent user against the
+// agent's tool_execution events and returns the changes none accounts for.
+// A tool call accounts for every statement logged within Tolerance of it on
+// the same database (when the event records one), since one tool call may
+// run several statements.
+func CorrelateDBAudit(entries []DBAuditEntry, toolEvents []Event, opts DBAuditCorrelation) []OutOfBandChange {
+	users := make(map[string]bool, len(opts.AgentUsers))
+	for _, u := range opts.AgentUsers {
+		users[u] = true
+	}
+	var out []OutOfBandChange
+	for _, e := range entries {
+		if !users[e.User] || !e.Mutates() {
+			continue
+		}
+		if opts.ApplicationName != "" && e.ApplicationName != opts.ApplicationName {
+			out = append(out, OutOfBandChange{DBAuditEntry: e,
+				Reason: fmt.Sprintf("application_name %q is not the agent's (%q)", e.ApplicationName, opts.ApplicationName)})
+			continue
+		}
+		if !hasToolCallNear(e, toolEvents, opts.Tolerance) {
+			out = append(out, OutOfBandChange{DBAuditEntry: e,
+				Reason: fmt.Sprintf("no tool_execution on %q within %s", e.Database, opts.Tolerance)})
+		}
+	}
+	return out
+}
+
+func hasToolCallNear(e DBAuditEntry, toolEvents []Event, tolerance time.Duration) bool {
+	for _, ev := range toolEvents {
+		if ev.Tool == nil {
+			continue
+		}
+		d := ev.Timestamp.Sub(e.LoggedAt)
+		if d < -tolerance || d > tolerance {
+			continue
+		}
+		if db := toolCallDatabase(ev.Tool); db != "" && db != e.Database {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// toolCallDatabase returns the dbname of the connection string a database
+// tool call recorded, or "" when it recorded none.
+func toolCallDatabase(t *ToolExecution) string {
+	cs, _ := t.Parameters["connection_string"].(string)
+	for _, part := range strings.Fields(cs) {
+		if v, ok := strings.CutPrefix(part, "dbname="); ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// csvLine builds a 23-column csvlog record with the given fields set.
+func csvLine(logTime, user, db, message, app string) string {
+	cols := make([]string, 23)
+	cols[csvLogTime], cols[csvUserName], cols[csvDatabaseName] = logTime, user, db
+	cols[csvMessage] = `"` + strings.ReplaceAll(message, `"`, `""`) + `"`
+	cols[csvApplicationName] = app
+	return strings.Join(cols, ",")
+}
+
+func TestParseDBAuditLog_CSV(t *testing.T) {
+	log := strings.Join([]string{
+		csvLine("2026-05-01 12:00:00.250 UTC", "helpdesk_agent", "prod",
+			`AUDIT: SESSION,1,1,WRITE,DELETE,TABLE,public.orders,"DELETE FROM orders WHERE id = 7",<not logged>`, "psql"),
+		csvLine("2026-05-01 12:00:01 UTC", "helpdesk_agent", "prod", "connection authorized: user=helpdesk_agent", ""),
+		csvLine("2026-05-01 12:00:02.000 UTC", "alice", "prod", "statement: alter table orders add column note text", "psql"),
+	}, "\n")
+
+	entries, err := ParseDBAuditLog(strings.NewReader(log), DBLogFormatCSV)
+	if err != nil {
+		t.Fatalf("ParseDBAuditLog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (the connection line is skipped)", len(entries))
+	}
+	e := entries[0]
+	want := time.Date(2026, 5, 1, 12, 0, 0, 250e6, time.UTC)
+	if !e.LoggedAt.Equal(want) || e.User != "helpdesk_agent" || e.ApplicationName != "psql" || e.Source != DBAuditSourcePGAudit {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e.Class != "WRITE" || e.Object != "public.orders" || e.Statement != "DELETE FROM orders WHERE id = 7" || !e.Mutates() {
+		t.Errorf("entry 0 statement = %+v", e)
+	}
+	if e := entries[1]; e.Source != DBAuditSourceLogStatement || e.Class != "DDL" || e.Command != "ALTER" {
+		t.Errorf("entry 1 = %+v", e)
+	}
+}
+
+func TestParseDBAuditLog_JSON(t *testing.T) {
+	log := `{"timestamp":"2026-05-01 12:00:00.000 UTC","user":"helpdesk_agent","dbname":"prod","application_name":"helpdesk_database_agent","message":"statement: SELECT 1"}
+{"timestamp":"2026-05-01 12:00:01.000 UTC","user":"helpdesk_agent","dbname":"prod","message":"statement: GRANT ALL ON orders TO mallory"}
+`
+	entries, err := ParseDBAuditLog(strings.NewReader(log), DBLogFormatJSON)
+	if err != nil {
+		t.Fatalf("ParseDBAuditLog: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Mutates() || entries[1].Class != "ROLE" || entries[1].Database != "prod" {
+		t.Errorf("entries = %+v", entries)
+	}
+	if _, err := ParseDBAuditLog(strings.NewReader(log), "stderr"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestCorrelateDBAudit(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(user, app, db string, offset time.Duration) DBAuditEntry {
+		return DBAuditEntry{LoggedAt: at.Add(offset), User: user, Database: db, ApplicationName: app,
+			Class: "WRITE", Command: "DELETE", Statement: "DELETE FROM orders"}
+	}
+	tools := []Event{{
+		Timestamp: at,
+		EventType: EventTypeToolExecution,
+		Tool: &ToolExecution{Name: "cancel_query", Agent: "postgres_database_agent",
+			Parameters: map[string]any{"connection_string": "host=db1 dbname=prod user=helpdesk_agent password=***"}},
+	}}
+	opts := DBAuditCorrelation{AgentUsers: []string{"helpdesk_agent"}, ApplicationName: "helpdesk_database_agent", Tolerance: 30 * time.Second}
+
+	entries := []DBAuditEntry{
+		entry("helpdesk_agent", "helpdesk_database_agent", "prod", 5*time.Second),    // matched by the tool call
+		entry("alice", "psql", "prod", time.Hour),                                    // not an agent user
+		entry("helpdesk_agent", "psql", "prod", time.Second),                         // wrong application_name
+		entry("helpdesk_agent", "helpdesk_database_agent", "prod", 10*time.Minute),   // no tool call near it
+		entry("helpdesk_agent", "helpdesk_database_agent", "billing", 5*time.Second), // tool call was on another database
+	}
+	got := CorrelateDBAudit(entries, tools, opts)
+	if len(got) != 3 {
+		t.Fatalf("got %d out-of-band changes, want 3: %+v", len(got), got)
+	}
+	if !strings.Contains(got[0].Reason, "application_name") || !strings.Contains(got[1].Reason, "no tool_execution") || got[2].Database != "billing" {
+		t.Errorf("out-of-band changes = %+v", got)
+	}
+
+	// Reads are never flagged.
+	read := entry("helpdesk_agent", "psql", "prod", time.Hour)
+	read.Class = "READ"
+	if got := CorrelateDBAudit([]DBAuditEntry{read}, nil, opts); len(got) != 0 {
+		t.Errorf("read flagged: %+v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultDecisionWebhookMaxAge is how old a decision may be and still be
+// delivered when DecisionWebhook.MaxAge is unset.
+const DefaultDecisionWebhookMaxAge = time.Hour
+
+// DecisionSignatureHeader carries the HMAC-SHA256 of a decision webhook body,
+// as "sha256=<hex>", in the style of GitHub's X-Hub-Signature-256.
+const DecisionSignatureHeader = "X-Helpdesk-Signature-256"
+
+// DecisionWebhook POSTs every enforced deny and require_approval policy
+// decision to a URL, so external systems (firewall automation, CI gates) can
+// react to governance outcomes without polling the events API.
+//
+// It is an EventBus: the store's outbox relay wakes it on every Record and
+// retries failed deliveries, so delivery is at-least-once and receivers
+// should de-duplicate on X-Helpdesk-Event-Id. Other events, and dry-run
+// decisions (which enforced nothing), are skipped. Decisions older than
+// MaxAge are dropped rather than delivered late: a reaction to them would
+// come too late, and it keeps the first run from replaying history.
+type DecisionWebhook struct {
+	URL    string
+	Secret []byte        // HMAC-SHA256 key signing each body
+	MaxAge time.Duration // default DefaultDecisionWebhookMaxAge
+	Client *http.Client
+}
+
+// NewDecisionWebhook returns a webhook that signs each body with secret.
+func NewDecisionWebhook(url string, secret []byte) *DecisionWebhook {
+	return &DecisionWebhook{URL: url, Secret: secret, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements EventBus.
+func (w *DecisionWebhook) Name() string { return "decision-webhook" }
+
+// SignDecision returns the DecisionSignatureHeader value for body.
+func SignDecision(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish implements EventBus. The body is the recorded event, unchanged.
+func (w *DecisionWebhook) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil // not an event this webhook can judge; nothing to deliver
+	}
+	pd := e.PolicyDecision
+	if e.EventType != EventTypePolicyDecision || pd == nil || pd.DryRun ||
+		(pd.Effect != "deny" && pd.Effect != "require_approval") {
+		return nil
+	}
+	maxAge := w.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultDecisionWebhookMaxAge
+	}
+	if age := time.Since(e.Timestamp); age > maxAge {
+		slog.Warn("decision webhook: dropping decision too old to deliver", "event_id", e.EventID, "age", age.Round(time.Second))
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Helpdesk-Event-Id", e.EventID)
+	req.Header.Set("X-Helpdesk-Effect", pd.Effect)
+	req.Header.Set(DecisionSignatureHeader, SignDecision(w.Secret, payload))
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	// A 4xx other than a timeout or rate limit will not succeed on retry, and
+	// retrying would hold up every later decision.
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		slog.Error("decision webhook rejected decision", "event_id", e.EventID, "status", resp.StatusCode, "body", strings.TrimSpace(string(body)))
+		return nil
+	}
+	return fmt.Errorf("decision webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Close implements EventBus.
+func (w *DecisionWebhook) Close() error { return nil }
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDecisionWebhook_DeliversEnforcedDenialsAndApprovals(t *testing.T) {
+	secret := []byte("s3cret")
+	var mu sync.Mutex
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(DecisionSignatureHeader) != SignDecision(secret, body) {
+			t.Errorf("bad signature %q", r.Header.Get(DecisionSignatureHeader))
+		}
+		var e Event
+		json.Unmarshal(body, &e) //nolint:errcheck
+		if r.Header.Get("X-Helpdesk-Event-Id") != e.EventID {
+			t.Errorf("X-Helpdesk-Event-Id = %q, want %q", r.Header.Get("X-Helpdesk-Event-Id"), e.EventID)
+		}
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(StoreConfig{
+		DBPath:     filepath.Join(t.TempDir(), "test.db"),
+		EventBuses: []EventBus{NewDecisionWebhook(srv.URL, secret)},
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	decision := func(id, effect string, dryRun bool, ts time.Time) *Event {
+		return &Event{
+			EventID:        id,
+			Timestamp:      ts,
+			EventType:      EventTypePolicyDecision,
+			Session:        Session{ID: "s"},
+			PolicyDecision: &PolicyDecision{ResourceType: "database", ResourceName: "prod-db", Action: "write", Effect: effect, DryRun: dryRun},
+		}
+	}
+	now := time.Now().UTC()
+	for _, e := range []*Event{
+		{EventID: "tool_1", EventType: EventTypeToolExecution, Session: Session{ID: "s"}},
+		decision("pol_allow", "allow", false, now),
+		decision("pol_deny", "deny", false, now),
+		decision("pol_dry", "deny", true, now),
+		decision("pol_stale", "deny", false, now.Add(-2*time.Hour)),
+		decision("pol_approval", "require_approval", false, now),
+	} {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record %s: %v", e.EventID, err)
+		}
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[0].EventID != "pol_deny" || got[1].EventID != "pol_approval" {
+		ids := make([]string, len(got))
+		for i, e := range got {
+			ids[i] = e.EventID
+		}
+		t.Errorf("delivered %v, want [pol_deny pol_approval]", ids)
+	}
+}
+
+func TestDecisionWebhook_Statuses(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	wh := NewDecisionWebhook(srv.URL, []byte("k"))
+	payload, _ := json.Marshal(Event{
+		EventID:        "pol_1",
+		Timestamp:      time.Now(),
+		EventType:      EventTypePolicyDecision,
+		PolicyDecision: &PolicyDecision{Effect: "deny"},
+	})
+	// Server errors are retried by the relay; a rejection is final.
+	if err := wh.Publish(context.Background(), "", "", payload); err == nil {
+		t.Error("503: want an error so the relay retries")
+	}
+	status = http.StatusBadRequest
+	if err := wh.Publish(context.Background(), "", "", payload); err != nil {
+		t.Errorf("400: %v, want the decision dropped", err)
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/google/uuid"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
+
+	"helpdesk/internal/agentlimit"
 )
 
 // DelegateArgs contains the structured reasoning for a delegation decision.
@@ -57,21 +59,59 @@ type DelegateResult struct {
 
 // AgentRegistry maps agent names to their URLs for delegation.
 type AgentRegistry struct {
-	agents map[string]string // name -> URL
+	agents  map[string]string   // name -> URL
+	refused map[string]string   // name -> why delegation to it is refused
+	keys    map[string][]byte   // name -> A2A signing key
+	limiter *agentlimit.Limiter // per-agent concurrency caps (nil = unlimited)
 }
 
 // NewAgentRegistry creates a new agent registry.
 func NewAgentRegistry() *AgentRegistry {
 	return &AgentRegistry{
-		agents: make(map[string]string),
+		agents:  make(map[string]string),
+		refused: make(map[string]string),
+		keys:    make(map[string][]byte),
 	}
 }
 
+// Refuse marks an agent as one that must never be delegated to, e.g. because
+// it advertises capabilities beyond its signed manifest.
+func (r *AgentRegistry) Refuse(name, reason string) {
+	delete(r.agents, name)
+	r.refused[name] = reason
+}
+
+// Refused returns why delegation to an agent is refused, or "" if it is not.
+func (r *AgentRegistry) Refused(name string) string {
+	return r.refused[name]
+}
+
 // Register adds an agent to the registry.
 func (r *AgentRegistry) Register(name, url string) {
 	r.agents[name] = url
 }
 
+// SetSigningKey sets the key A2A requests to an agent are signed with.
+func (r *AgentRegistry) SetSigningKey(name string, key []byte) {
+	r.keys[name] = key
+}
+
+// SigningKey returns the key A2A requests to an agent are signed with, or
+// nil when requests to it are sent unsigned.
+func (r *AgentRegistry) SigningKey(name string) []byte {
+	return r.keys[name]
+}
+
+// SetLimiter sets the per-agent concurrency caps delegations are held to.
+func (r *AgentRegistry) SetLimiter(l *agentlimit.Limiter) {
+	r.limiter = l
+}
+
+// Limiter returns the per-agent concurrency caps, or nil when there are none.
+func (r *AgentRegistry) Limiter() *agentlimit.Limiter {
+	return r.limiter
+}
+
 // Get returns the URL for an agent, or empty string if not found.
 func (r *AgentRegistry) Get(name string) string {
 	return r.agents[name]
@@ -129,6 +169,10 @@ func DelegateToolWithTrace(auditor Auditor, auditURL, auditAPIKey string, regist
 			"action_class", actionClass,
 			"reasoning", args.ReasoningChain)
 
+		// Look up how similar requests to this agent were resolved before,
+		// so the sub-agent can start from a known fix.
+		similar := findSimilarTraces(auditURL, auditAPIKey, args.Agent, args.UserIntent, traceID)
+
 		// Create audit event
 		event := &Event{
 			EventID:     "evt_" + uuid.New().String()[:8],
@@ -152,6 +196,7 @@ func DelegateToolWithTrace(auditor Auditor, auditURL, auditAPIKey string, regist
 				Confidence:      args.Confidence,
 				UserIntent:      args.UserIntent,
 				ReasoningChain:  args.ReasoningChain,
+				SimilarTraces:   traceIDs(similar),
 				AlternativesConsidered: func() []Alternative {
 					alts := make([]Alternative, len(args.AlternativesConsidered))
 					for i, a := range args.AlternativesConsidered {
@@ -170,6 +215,24 @@ func DelegateToolWithTrace(auditor Auditor, auditURL, auditAPIKey string, regist
 			}
 		}
 
+		// Refuse agents that failed capability manifest verification.
+		if reason := registry.Refused(args.Agent); reason != "" {
+			outcome := &Outcome{
+				Status:       "denied",
+				ErrorMessage: fmt.Sprintf("agent %q refused: %s", args.Agent, reason),
+				Duration:     time.Since(start),
+			}
+			if auditor != nil {
+				_ = auditor.RecordOutcome(context.Background(), event.EventID, outcome)
+			}
+			return DelegateResult{
+				Agent:    args.Agent,
+				Response: fmt.Sprintf("Error: delegation to %q is refused: it advertises capabilities beyond its signed capability manifest. Do not retry; inform the user.", args.Agent),
+				Duration: time.Since(start).String(),
+				EventID:  event.EventID,
+			}, nil
+		}
+
 		// Look up the agent URL
 		agentURL := registry.Get(args.Agent)
 		if agentURL == "" {
@@ -200,13 +263,36 @@ func DelegateToolWithTrace(auditor Auditor, auditURL, auditAPIKey string, regist
 			})
 		}
 
+		// Hold one of the agent's concurrency slots for the call, queued at
+		// the session purpose's priority. An agent at its cap is reported
+		// back to the LLM rather than failing the turn.
+		slotCtx := agentlimit.WithPriority(callCtx, agentlimit.PriorityForPurpose(sessionPurpose))
+		release, err := registry.Limiter().Acquire(slotCtx, args.Agent)
+		if err != nil {
+			outcome := &Outcome{
+				Status:       "error",
+				ErrorMessage: err.Error(),
+				Duration:     time.Since(start),
+			}
+			if auditor != nil {
+				_ = auditor.RecordOutcome(context.Background(), event.EventID, outcome)
+			}
+			return DelegateResult{
+				Agent:    args.Agent,
+				Response: fmt.Sprintf("Error: %v. The agent is busy with other requests; tell the user to retry shortly.", err),
+				Duration: time.Since(start).String(),
+				EventID:  event.EventID,
+			}, nil
+		}
+
 		// Call the agent via A2A
 		slog.Debug("calling agent via A2A",
 			"agent", args.Agent,
 			"url", agentURL,
 			"message", args.Message,
 			"trace_id", traceID)
-		response, err := callAgentWithTrace(callCtx, agentURL, args.Message, traceID)
+		response, err := callAgentWithTrace(callCtx, agentURL, args.Message+formatResolutionHints(similar), traceID, registry.SigningKey(args.Agent))
+		release()
 		duration := time.Since(start)
 		slog.Debug("agent response received",
 			"agent", args.Agent,
@@ -275,7 +361,8 @@ func DelegateToolWithTrace(auditor Auditor, auditURL, auditAPIKey string, regist
 		Description: `Delegate a task to a specialist agent. You MUST use this tool for ALL delegations.
 Before calling, provide your reasoning chain explaining why this agent was chosen.
 Available agents: postgres_database_agent (database issues), k8s_agent (Kubernetes issues),
-incident_agent (incident bundles), research_agent (web search for current info).`,
+incident_agent (incident bundles), research_agent (web search for current info),
+kb_agent (internal runbooks and postmortems: how a known issue was fixed before).`,
 	}, delegateFunc)
 	if err != nil {
 		return nil, nil, err
@@ -284,7 +371,7 @@ incident_agent (incident bundles), research_agent (web search for current info).
 }
 
 // callAgentWithTrace sends a message to an A2A agent with trace_id in metadata.
-func callAgentWithTrace(ctx context.Context, agentURL, message, traceID string) (string, error) {
+func callAgentWithTrace(ctx context.Context, agentURL, message, traceID string, signingKey []byte) (string, error) {
 	// Fetch agent card
 	cardURL := strings.TrimSuffix(agentURL, "/") + "/.well-known/agent-card.json"
 	card, err := fetchAgentCard(ctx, cardURL)
@@ -327,6 +414,9 @@ func callAgentWithTrace(ctx context.Context, agentURL, message, traceID string)
 		if tc.Principal.AuthMethod != "" {
 			meta["auth_method"] = tc.Principal.AuthMethod
 		}
+		if tc.Principal.Tenant != "" {
+			meta["tenant"] = tc.Principal.Tenant
+		}
 		if tc.Purpose != "" {
 			meta["purpose"] = tc.Purpose
 		}
@@ -340,6 +430,9 @@ func callAgentWithTrace(ctx context.Context, agentURL, message, traceID string)
 	if len(meta) > 0 {
 		msg.Metadata = meta
 	}
+	if err := SignA2AMessage(msg, signingKey); err != nil {
+		return "", fmt.Errorf("signing A2A request: %w", err)
+	}
 	result, err := client.SendMessage(ctx, &a2a.MessageSendParams{Message: msg})
 	if err != nil {
 		return "", fmt.Errorf("sending message: %w", err)
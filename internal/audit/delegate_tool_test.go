@@ -217,3 +217,23 @@ func TestFormatVerificationBlock_Clean(t *testing.T) {
 		t.Errorf("clean block missing explicit 'VERIFICATION CLEAN' signal: %s", block)
 	}
 }
+
+func TestAgentRegistry_Refuse(t *testing.T) {
+	r := NewAgentRegistry()
+	r.Register("k8s_agent", "http://k8s:1102")
+	r.Register("postgres_database_agent", "http://db:1100")
+	r.Refuse("k8s_agent", "tool delete_namespace is not in the manifest")
+
+	if got := r.Get("k8s_agent"); got != "" {
+		t.Errorf("Get(refused) = %q, want empty", got)
+	}
+	if got := r.Refused("k8s_agent"); got == "" {
+		t.Error("Refused(k8s_agent) is empty")
+	}
+	if got := r.Refused("postgres_database_agent"); got != "" {
+		t.Errorf("Refused(accepted agent) = %q, want empty", got)
+	}
+	if names := r.List(); len(names) != 1 || names[0] != "postgres_database_agent" {
+		t.Errorf("List() = %v", names)
+	}
+}
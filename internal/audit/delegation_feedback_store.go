@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DelegationFeedback is the requester's verdict on whether a delegation
+// actually solved their problem. It is submitted by the end user, or by an
+// automation such as srebot that can tell whether the symptom went away.
+//
+// Feedback lives outside the hash chain: it arrives after the event is
+// recorded and can be revised. There is one feedback per event; submitting
+// again replaces it.
+type DelegationFeedback struct {
+	EventID     string    `json:"event_id"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	Agent       string    `json:"agent"`
+	Resolved    bool      `json:"resolved"`
+	Comment     string    `json:"comment,omitempty"`
+	Source      string    `json:"source,omitempty"` // "user" (default) or the automation that submitted it, e.g. "srebot"
+	SubmittedBy string    `json:"submitted_by,omitempty"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// AgentFeedbackStats aggregates delegation feedback for one agent.
+type AgentFeedbackStats struct {
+	Agent          string  `json:"agent"`
+	Feedback       int     `json:"feedback"`
+	Resolved       int     `json:"resolved"`
+	NotResolved    int     `json:"not_resolved"`
+	ResolutionRate float64 `json:"resolution_rate"`
+}
+
+// DelegationFeedbackStore persists delegation feedback. It shares the same
+// *sql.DB connection as the audit Store.
+type DelegationFeedbackStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewDelegationFeedbackStore creates the delegation_feedback table (if
+// absent) and returns a ready-to-use DelegationFeedbackStore.
+func NewDelegationFeedbackStore(db *sql.DB, isPostgres bool) (*DelegationFeedbackStore, error) {
+	s := &DelegationFeedbackStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS delegation_feedback (
+    event_id     TEXT    NOT NULL PRIMARY KEY,
+    trace_id     TEXT    NOT NULL DEFAULT '',
+    agent        TEXT    NOT NULL DEFAULT '',
+    resolved     INTEGER NOT NULL,
+    comment      TEXT    NOT NULL DEFAULT '',
+    source       TEXT    NOT NULL DEFAULT 'user',
+    submitted_by TEXT    NOT NULL DEFAULT '',
+    tenant_id    TEXT    NOT NULL DEFAULT '',
+    submitted_at TEXT    NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create delegation_feedback schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_delegation_feedback_agent
+    ON delegation_feedback(agent, submitted_at)`); err != nil {
+		return nil, fmt.Errorf("create delegation_feedback index: %w", err)
+	}
+	return s, nil
+}
+
+// Submit records fb, replacing any earlier feedback on the same event.
+// SubmittedAt is assigned when zero and Source defaults to "user".
+func (s *DelegationFeedbackStore) Submit(ctx context.Context, fb *DelegationFeedback) error {
+	if fb.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	if fb.Agent == "" {
+		return fmt.Errorf("agent is required")
+	}
+	if fb.Source == "" {
+		fb.Source = "user"
+	}
+	if fb.SubmittedAt.IsZero() {
+		fb.SubmittedAt = time.Now().UTC()
+	}
+	resolved := 0
+	if fb.Resolved {
+		resolved = 1
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO delegation_feedback
+    (event_id, trace_id, agent, resolved, comment, source, submitted_by, tenant_id, submitted_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(event_id) DO UPDATE SET
+    resolved     = excluded.resolved,
+    comment      = excluded.comment,
+    source       = excluded.source,
+    submitted_by = excluded.submitted_by,
+    submitted_at = excluded.submitted_at`),
+		fb.EventID, fb.TraceID, fb.Agent, resolved, fb.Comment, fb.Source,
+		fb.SubmittedBy, fb.TenantID, fb.SubmittedAt.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("submit delegation feedback: %w", err)
+	}
+	return nil
+}
+
+// Get returns the feedback on an event, or nil when there is none.
+func (s *DelegationFeedbackStore) Get(ctx context.Context, eventID string) (*DelegationFeedback, error) {
+	var (
+		fb          DelegationFeedback
+		resolved    int
+		submittedAt string
+	)
+	err := s.db.QueryRowContext(ctx, rebind(s.isPostgres, `
+SELECT event_id, trace_id, agent, resolved, comment, source, submitted_by, tenant_id, submitted_at
+FROM delegation_feedback WHERE event_id = ?`), eventID).Scan(
+		&fb.EventID, &fb.TraceID, &fb.Agent, &resolved, &fb.Comment, &fb.Source,
+		&fb.SubmittedBy, &fb.TenantID, &submittedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get delegation feedback: %w", err)
+	}
+	fb.Resolved = resolved != 0
+	fb.SubmittedAt = parseFlexTime(submittedAt)
+	return &fb, nil
+}
+
+// StatsByAgent aggregates the feedback submitted in [since, until) per
+// agent, ordered by agent name. A zero until means no upper bound; an empty
+// tenantID means all tenants.
+func (s *DelegationFeedbackStore) StatsByAgent(ctx context.Context, since, until time.Time, tenantID string) ([]AgentFeedbackStats, error) {
+	query := `
+SELECT agent, COUNT(*), COALESCE(SUM(resolved), 0)
+FROM delegation_feedback
+WHERE submitted_at >= ?`
+	args := []any{since.UTC().Format(annotationTimeFormat)}
+	if !until.IsZero() {
+		query += " AND submitted_at < ?"
+		args = append(args, until.UTC().Format(annotationTimeFormat))
+	}
+	if tenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	query += " GROUP BY agent ORDER BY agent"
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("delegation feedback stats: %w", err)
+	}
+	defer rows.Close()
+
+	out := []AgentFeedbackStats{}
+	for rows.Next() {
+		var st AgentFeedbackStats
+		if err := rows.Scan(&st.Agent, &st.Feedback, &st.Resolved); err != nil {
+			return nil, fmt.Errorf("scan delegation feedback stats: %w", err)
+		}
+		st.NotResolved = st.Feedback - st.Resolved
+		if st.Feedback > 0 {
+			st.ResolutionRate = float64(st.Resolved) / float64(st.Feedback)
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// IsUnderperforming reports whether the agent has at least minFeedback
+// verdicts and resolves fewer than minRate of them.
+func (st AgentFeedbackStats) IsUnderperforming(minFeedback int, minRate float64) bool {
+	return st.Feedback >= minFeedback && st.ResolutionRate < minRate
+}
+
+// EraseSubjectRecords replaces ids as feedback submitter with subject and
+// erases the comments they wrote.
+func (s *DelegationFeedbackStore) EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error) {
+	return eraseSubjectRows(ctx, s.db, s.isPostgres, "delegation_feedback", "submitted_by", []string{"comment"}, ids, subject)
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDelegationFeedbackStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	fs, err := NewDelegationFeedbackStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewDelegationFeedbackStore: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, fb := range []*DelegationFeedback{
+		{EventID: "evt_1", Agent: "postgres_database_agent", Resolved: false, SubmittedAt: base},
+		{EventID: "evt_2", Agent: "postgres_database_agent", Resolved: true, SubmittedAt: base.Add(time.Minute)},
+		{EventID: "evt_3", Agent: "k8s_agent", Resolved: false, Source: "srebot", SubmittedAt: base.Add(2 * time.Minute)},
+		{EventID: "evt_4", Agent: "k8s_agent", Resolved: true, TenantID: "acme", SubmittedAt: base.Add(-time.Hour)},
+		// A second verdict on evt_1 replaces the first.
+		{EventID: "evt_1", Agent: "postgres_database_agent", Resolved: true, Comment: "worked after a retry", SubmittedAt: base.Add(3 * time.Minute)},
+	} {
+		if err := fs.Submit(ctx, fb); err != nil {
+			t.Fatalf("Submit(%+v): %v", fb, err)
+		}
+	}
+
+	got, err := fs.Get(ctx, "evt_1")
+	if err != nil || got == nil {
+		t.Fatalf("Get(evt_1) = %v, %v", got, err)
+	}
+	if !got.Resolved || got.Comment != "worked after a retry" || got.Source != "user" || !got.SubmittedAt.Equal(base.Add(3*time.Minute)) {
+		t.Errorf("Get(evt_1) = %+v, want the later verdict", got)
+	}
+	if got, err := fs.Get(ctx, "evt_missing"); err != nil || got != nil {
+		t.Errorf("Get(evt_missing) = %v, %v; want nil, nil", got, err)
+	}
+
+	stats, err := fs.StatsByAgent(ctx, base, time.Time{}, "")
+	if err != nil {
+		t.Fatalf("StatsByAgent: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("StatsByAgent = %+v, want two agents", stats)
+	}
+	if k8s := stats[0]; k8s.Agent != "k8s_agent" || k8s.Feedback != 1 || k8s.NotResolved != 1 || k8s.ResolutionRate != 0 {
+		t.Errorf("k8s_agent = %+v, want one unresolved (evt_4 is before the window)", k8s)
+	}
+	if pg := stats[1]; pg.Feedback != 2 || pg.Resolved != 2 || pg.ResolutionRate != 1 {
+		t.Errorf("postgres_database_agent = %+v, want two resolved", pg)
+	}
+	if !stats[0].IsUnderperforming(1, 0.5) || stats[0].IsUnderperforming(2, 0.5) || stats[1].IsUnderperforming(1, 0.5) {
+		t.Error("IsUnderperforming: want only k8s_agent with min feedback 1")
+	}
+
+	tenant, err := fs.StatsByAgent(ctx, base.Add(-2*time.Hour), base, "acme")
+	if err != nil || len(tenant) != 1 || tenant[0].Agent != "k8s_agent" || tenant[0].Resolved != 1 {
+		t.Errorf("StatsByAgent(acme) = %+v, %v", tenant, err)
+	}
+
+	if err := fs.Submit(ctx, &DelegationFeedback{EventID: "evt_5"}); err == nil {
+		t.Error("Submit accepted feedback without an agent")
+	}
+}
@@ -3,8 +3,10 @@ package audit
 
 import (
 	"encoding/json"
+	"sync"
 	"time"
 
+	"helpdesk/internal/buildinfo"
 	"helpdesk/internal/identity"
 )
 
@@ -29,6 +31,11 @@ const (
 	// "retrying" or "resolved". These events are intentionally NOT "error"
 	// so they never flip a journey's outcome status to failure.
 	EventTypeToolRetry EventType = "tool_retry"
+	// EventTypeToolArgsRejected is emitted when a tool call's arguments fail
+	// the tool's input schema (unknown parameter, wrong type, out-of-range
+	// value, value outside an enum) and the tool is not run. outcome_status is
+	// "rejected", which never overrides a journey's real outcome.
+	EventTypeToolArgsRejected EventType = "tool_args_rejected"
 	// EventTypeVerificationOutcome is emitted once after the full
 	// post-mutation verification loop completes with a non-OK status.
 	// It carries the final VerifyStatus as outcome_status using the
@@ -73,6 +80,88 @@ const (
 	// EventTypeRollbackVerified is emitted after the post-rollback verification
 	// loop confirms the resource returned to the expected pre-mutation state.
 	EventTypeRollbackVerified EventType = "rollback_verified"
+
+	// EventTypeSecurityResponse is emitted by secbot for every step of an
+	// automated security response playbook (bundle creation, notification,
+	// approval gate, agent action). It links the step back to the alert and
+	// the audit event that triggered it.
+	EventTypeSecurityResponse EventType = "security_response"
+
+	// EventTypeEmergencyFreeze and EventTypeEmergencyUnfreeze record changes to
+	// the fleet-wide emergency read-only switch. The acting principal is in
+	// Session.UserID and the stated reason in Input.UserQuery, so both are
+	// covered by the event hash.
+	EventTypeEmergencyFreeze   EventType = "emergency_freeze"
+	EventTypeEmergencyUnfreeze EventType = "emergency_unfreeze"
+
+	// EventTypeOutOfBandChange is emitted by auditd when an ingested database
+	// log shows a change made with an agent's credentials that no
+	// tool_execution event accounts for — the credentials were used outside
+	// the agent. The OutOfBandChange payload carries the logged statement.
+	EventTypeOutOfBandChange EventType = "out_of_band_change"
+
+	// EventTypeServiceShutdown is recorded by auditd as the last event before
+	// a graceful shutdown, after in-flight writes have drained. A chain whose
+	// newest event is anything else was not closed cleanly.
+	EventTypeServiceShutdown EventType = "service_shutdown"
+
+	// EventTypeRoutingSimulation is recorded by the gateway for POST
+	// /api/v1/route: the routing decision the LLM would make for a query,
+	// without the query being delegated or any tool run. It carries the same
+	// Decision as a delegation_decision but never starts a journey.
+	EventTypeRoutingSimulation EventType = "routing_simulation"
+
+	// EventTypeBackupStale is recorded by the database agent when a backup
+	// check finds no successful backup, or one older than the database's
+	// max_age_hours. The BackupStale payload carries the ages.
+	EventTypeBackupStale EventType = "backup_stale"
+
+	// EventTypeResearchResult is recorded by the research agent for each
+	// answer it returns: the sources it cites and whether the answer came
+	// from its response cache instead of a new web search.
+	EventTypeResearchResult EventType = "research_result"
+
+	// EventTypeCanary is a synthetic event auditd records on a schedule to
+	// check that events are chained, streamed to subscribers and queryable
+	// within an SLA. It describes no real activity; consumers that count
+	// activity skip it.
+	EventTypeCanary EventType = "canary"
+
+	// EventTypeOutcomeTimeout is recorded by auditd for a delegation whose
+	// outcome never arrived within its orphan timeout, usually because the
+	// agent crashed mid-task. ParentID is the delegation event, whose
+	// outcome_status is set to "timed_out"; Session.AgentName is the agent
+	// that was delegated to.
+	EventTypeOutcomeTimeout EventType = "outcome_timeout"
+
+	// EventTypeStartupReport is recorded by each agent when it starts: the
+	// results of its startup self-test (LLM, command binaries, policy,
+	// auditd, approval flow) and of the governed-mode checks. An agent that
+	// must pass the self-test before it serves records one per attempt.
+	EventTypeStartupReport EventType = "startup_report"
+
+	// EventTypeRedaction is recorded by auditd when stored events are
+	// rewritten to erase a data subject or pseudonymize user IDs. Its
+	// tombstones keep the rewritten events verifiable. See EraseSubject.
+	EventTypeRedaction EventType = "event_redaction"
+
+	// EventTypeConfigChange is recorded by auditd when runtime settings are
+	// changed through PUT /v1/config. The acting principal is in
+	// Session.UserID, the stated reason in Input.UserQuery and the changed
+	// settings in the ConfigChange payload.
+	EventTypeConfigChange EventType = "config_change"
+
+	// EventTypeInventoryDrift is recorded by the inventory reconciler for
+	// each infrastructure config entry that does not match what is live: a
+	// database that cannot be reached, a cluster that does not respond or a
+	// namespace that does not exist. The InventoryDrift payload names it.
+	EventTypeInventoryDrift EventType = "inventory_drift"
+
+	// EventTypeApprovalSLABreach is recorded by auditd when an approval
+	// request is not resolved within the SLA of its action class
+	// (-approval-sla): once, when it is found still pending past its due
+	// time or resolved after it. The ApprovalSLABreach payload describes it.
+	EventTypeApprovalSLABreach EventType = "approval_sla_breach"
 )
 
 // RequestCategory classifies the type of user request.
@@ -102,6 +191,37 @@ type Decision struct {
 	UserIntent             string          `json:"user_intent"`
 	ReasoningChain         []string        `json:"reasoning_chain"`
 	AlternativesConsidered []Alternative   `json:"alternatives_considered"`
+
+	// SimilarTraces lists the past traces whose resolutions were passed to
+	// the agent as hints with this delegation. See findSimilarTraces.
+	SimilarTraces []string `json:"similar_traces,omitempty"`
+
+	// ReasoningQuality is set by the audit store when a delegation_decision
+	// is recorded. See ScoreReasoning.
+	ReasoningQuality *ReasoningQuality `json:"reasoning_quality,omitempty"`
+}
+
+// Producer identifies the build of the component that recorded an event.
+type Producer struct {
+	Component string `json:"component"` // binary name, e.g. "database-agent"
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+}
+
+// CurrentProducer returns the running binary as an event producer.
+var CurrentProducer = sync.OnceValue(func() Producer {
+	info := buildinfo.Get()
+	return Producer{Component: info.Component, Version: info.Version, Commit: info.Commit}
+})
+
+// StampProducer records the running binary as the event's producer, unless
+// it already has one. The Auditor implementations call it; code that posts
+// events to auditd directly must call it too.
+func StampProducer(event *Event) {
+	if event.Producer == nil {
+		p := CurrentProducer()
+		event.Producer = &p
+	}
 }
 
 // Session identifies the user session context.
@@ -111,6 +231,7 @@ type Session struct {
 	AgentName       string    `json:"agent_name,omitempty"` // name of the agent that owns this session (e.g. "helpdesk_orchestrator")
 	StartedAt       time.Time `json:"started_at"`
 	DelegationCount int       `json:"delegation_count"`
+	TenantID        string    `json:"tenant_id,omitempty"` // tenant of the calling principal; empty for untenanted deployments
 }
 
 // Input captures the user's request and context.
@@ -139,12 +260,19 @@ type ToolExecution struct {
 	// This is filled in by the agent when available.
 	RawCommand string `json:"raw_command,omitempty"`
 
+	// Argv is the exact argument vector executed by the agent's command
+	// sandbox (binary first), with connection-string passwords masked.
+	Argv []string `json:"argv,omitempty"`
+
 	// Result is a summary of the tool's output.
 	Result string `json:"result,omitempty"`
 
 	// Error contains any error message if the tool failed.
 	Error string `json:"error,omitempty"`
 
+	// RowsAffected is the row count reported by a DML command, when known.
+	RowsAffected int `json:"rows_affected,omitempty"`
+
 	// Duration is how long the tool execution took.
 	Duration time.Duration `json:"duration_ms,omitempty"`
 
@@ -159,6 +287,8 @@ type Outcome struct {
 	Status       string        `json:"status"` // success, error, timeout
 	ErrorMessage string        `json:"error_message,omitempty"`
 	Duration     time.Duration `json:"duration_ms"`
+	// Phases breaks Duration down by phase; set on delegation_outcome events.
+	Phases *PhaseTimings `json:"phases,omitempty"`
 }
 
 // PolicyDecision captures the outcome of a policy evaluation.
@@ -175,6 +305,7 @@ type PolicyDecision struct {
 	Note         string   `json:"note,omitempty"`         // diagnostic context (e.g. why tags are missing)
 	DryRun       bool     `json:"dry_run,omitempty"`      // true when policy is in dry-run mode
 	PostExecution bool    `json:"post_execution,omitempty"` // true for post-execution blast-radius checks
+	Preflight    bool     `json:"preflight,omitempty"`      // true for batch pre-flight checks of planned steps; nothing has run yet
 
 	// Explainability fields — populated by agentutil.PolicyEnforcer when using engine.Explain().
 	// Trace is the JSON-serialised policy.DecisionTrace (stored as raw JSON to avoid import cycles).
@@ -197,6 +328,10 @@ type PolicyDecision struct {
 
 	// Sensitivity — data sensitivity classes of the resource accessed.
 	Sensitivity []string `json:"sensitivity,omitempty"`
+
+	// CachedFrom is set when an agent reused a remote policy decision instead
+	// of asking auditd again: it is the event ID of the decision reused.
+	CachedFrom string `json:"cached_from,omitempty"`
 }
 
 // DiagnosticHypothesis is one ranked candidate root-cause produced by an
@@ -280,6 +415,18 @@ type RollbackExecution struct {
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
+// SecurityResponse is set on security_response events. One event is recorded
+// per executed playbook step.
+type SecurityResponse struct {
+	Playbook       string `json:"playbook"`
+	Step           int    `json:"step"`             // 1-based step index
+	Action         string `json:"action"`           // create_bundle, notify, require_approval, query_agent, ...
+	AlertType      string `json:"alert_type"`       // e.g. "unauthorized_destructive"
+	TriggerEventID string `json:"trigger_event_id"` // the audit event that raised the alert
+	Status         string `json:"status"`           // "success", "failed", "denied", "skipped", "dry_run"
+	Detail         string `json:"detail,omitempty"`
+}
+
 // GovernanceViolation records a compliance violation when a required governance
 // module is disabled or misconfigured in fix mode (HELPDESK_OPERATING_MODE=fix).
 type GovernanceViolation struct {
@@ -290,6 +437,76 @@ type GovernanceViolation struct {
 	Remediation   string `json:"remediation,omitempty"`
 }
 
+// StartupReport is set on startup_report events.
+type StartupReport struct {
+	Component     string          `json:"component"`      // e.g. "postgres_database_agent"
+	Version       string          `json:"version"`        // build version of the agent
+	OperatingMode string          `json:"operating_mode"` // "readonly", "readonly-governed" or "fix"
+	Passed        bool            `json:"passed"`         // no check failed
+	Required      bool            `json:"required"`       // the agent does not serve until a report passes
+	Attempt       int             `json:"attempt"`        // 1 for the first self-test, counting retries
+	Checks        []SelfTestCheck `json:"checks"`
+}
+
+// SelfTestCheck is one check of a startup self-test.
+type SelfTestCheck struct {
+	Name       string `json:"name"`   // "llm", "binary:psql", "policy", "audit", "approval", "governance:<module>"
+	Status     string `json:"status"` // "pass", "warn", "fail" or "skip"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// BackupStale is set on backup_stale events.
+type BackupStale struct {
+	Database         string    `json:"database"`
+	Tool             string    `json:"tool"`                  // "pgbackrest" or "wal-g"
+	LastBackup       time.Time `json:"last_backup,omitempty"` // zero when no successful backup exists
+	AgeSeconds       int64     `json:"age_seconds,omitempty"`
+	ThresholdSeconds int64     `json:"threshold_seconds"`
+	Reason           string    `json:"reason"`
+}
+
+// InventoryDrift is set on inventory_drift events.
+type InventoryDrift struct {
+	ResourceType string `json:"resource_type"`     // "database" or "kubernetes", as in policy checks
+	ResourceName string `json:"resource_name"`     // infra config key; the namespace for a missing namespace
+	Cluster      string `json:"cluster,omitempty"` // the cluster of a kubernetes resource
+	Problem      string `json:"problem"`           // "unreachable", "not_responding" or "namespace_missing"
+	Detail       string `json:"detail,omitempty"`  // the check's error
+}
+
+// ApprovalSLABreach is set on approval_sla_breach events.
+type ApprovalSLABreach struct {
+	ApprovalID   string    `json:"approval_id"`
+	ActionClass  string    `json:"action_class"`
+	ToolName     string    `json:"tool_name,omitempty"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	ResourceName string    `json:"resource_name,omitempty"`
+	RequestedBy  string    `json:"requested_by"`
+	RequestedAt  time.Time `json:"requested_at"`
+	DueAt        time.Time `json:"due_at"`
+	SLASecs      int64     `json:"sla_secs"`
+	Status       string    `json:"status"`      // the request's status when the breach was found
+	WaitedSecs   int64     `json:"waited_secs"` // time to resolution, or time waited so far when pending
+	ResolvedBy   string    `json:"resolved_by,omitempty"`
+}
+
+// Citation is a source an agent's answer draws on.
+type Citation struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Domain      string    `json:"domain,omitempty"`
+	RetrievedAt time.Time `json:"retrieved_at"` // when the search that found it ran
+}
+
+// ResearchResult is set on research_result events.
+type ResearchResult struct {
+	Query         string     `json:"query"`
+	SearchQueries []string   `json:"search_queries,omitempty"` // queries the model sent to the search engine
+	Citations     []Citation `json:"citations,omitempty"`
+	Cached        bool       `json:"cached,omitempty"` // served from the response cache; no search ran
+}
+
 // Event is a single audit event for delegation decisions.
 type Event struct {
 	EventID   string    `json:"event_id"`
@@ -301,12 +518,38 @@ type Event struct {
 	ParentID string `json:"parent_id,omitempty"` // immediate parent event (causality)
 	Origin   string `json:"origin,omitempty"`    // "direct_tool" | "agent" (A2A/LLM) | "gateway"
 
+	// CorrelationID is the caller's own request ID, sent to the gateway in
+	// the X-Correlation-ID header. It is opaque to helpdesk.
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// TraceParent is the W3C traceparent of the request that produced the
+	// event, when the caller sent one. The OTLP export parents the event's
+	// span on it.
+	TraceParent string `json:"traceparent,omitempty"`
+
+	// ReceivedAt is when auditd received the event, stamped by auditd over
+	// whatever the client sent. Timestamp is the client's clock; the
+	// difference between the two is the client's clock skew (plus transit).
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+
+	// Team is the team owning the resource the event is about, stamped by
+	// the store from the infrastructure config (StoreConfig.ResourceOwner).
+	Team string `json:"team,omitempty"`
+
+	// Producer is the build of the component that recorded the event,
+	// stamped by the audit client that sends it (or by the store, for events
+	// recorded in-process). Absent on events from older builds.
+	Producer *Producer `json:"producer,omitempty"`
+
 	// Action classification for approval workflow
 	ActionClass ActionClass `json:"action_class,omitempty"` // read, write, destructive
 
 	// Hash chain for tamper evidence
 	PrevHash  string `json:"prev_hash,omitempty"`  // hash of previous event
 	EventHash string `json:"event_hash,omitempty"` // hash of this event
+	// ChainSegment names the chain this event is linked into ("" = the global
+	// chain). Set by the store according to StoreConfig.ChainSharding.
+	ChainSegment string `json:"chain_segment,omitempty"`
 
 	// Principal is the verified identity of the caller. Set on gateway_request
 	// and other entry-point events so every journey has an identity anchor.
@@ -326,6 +569,29 @@ type Event struct {
 	DelegationVerification *DelegationVerification `json:"delegation_verification,omitempty"`
 	Outcome                *Outcome                `json:"outcome,omitempty"`
 	RollbackExecution      *RollbackExecution      `json:"rollback_execution,omitempty"`
+	SecurityResponse       *SecurityResponse       `json:"security_response,omitempty"`
+	OutOfBandChange        *OutOfBandChange        `json:"out_of_band_change,omitempty"`
+	BackupStale            *BackupStale            `json:"backup_stale,omitempty"`
+	ResearchResult         *ResearchResult         `json:"research_result,omitempty"`
+	StartupReport          *StartupReport          `json:"startup_report,omitempty"`
+
+	// InjectionRisk is set by the audit store when the event's user query or
+	// tool output shows prompt-injection markers. See ScoreInjection.
+	InjectionRisk *InjectionRisk `json:"injection_risk,omitempty"`
+
+	// Redaction is set on event_redaction events. RedactedBy is set on the
+	// events a redaction rewrote: the ID of the last one.
+	Redaction  *Redaction `json:"redaction,omitempty"`
+	RedactedBy string     `json:"redacted_by,omitempty"`
+
+	// ConfigChange is set on config_change events.
+	ConfigChange *ConfigChange `json:"config_change,omitempty"`
+
+	// InventoryDrift is set on inventory_drift events.
+	InventoryDrift *InventoryDrift `json:"inventory_drift,omitempty"`
+
+	// ApprovalSLABreach is set on approval_sla_breach events.
+	ApprovalSLABreach *ApprovalSLABreach `json:"approval_sla_breach,omitempty"`
 }
 
 // MarshalJSON returns the JSON encoding of the event.
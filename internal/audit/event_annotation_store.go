@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event dispositions recorded by investigators.
+const (
+	DispositionFalsePositive = "false_positive"
+	DispositionConfirmed     = "confirmed"
+	DispositionBenign        = "benign"
+	DispositionNeedsReview   = "needs_review"
+)
+
+// validDispositions are the values EventAnnotation.Disposition may take.
+var validDispositions = map[string]bool{
+	DispositionFalsePositive: true,
+	DispositionConfirmed:     true,
+	DispositionBenign:        true,
+	DispositionNeedsReview:   true,
+}
+
+// EventAnnotation is an investigator's note on one audit event: free text,
+// a disposition, and the alerts it relates to.
+//
+// Annotations are immutable and live outside the hash chain, so they can be
+// added at any time without touching the events they describe. To change a
+// disposition, add another annotation: an event's disposition is that of
+// its most recent annotation that has one.
+type EventAnnotation struct {
+	AnnotationID string    `json:"annotation_id"`
+	EventID      string    `json:"event_id"`
+	Disposition  string    `json:"disposition,omitempty"` // false_positive, confirmed, benign, needs_review
+	Note         string    `json:"note,omitempty"`
+	AlertIDs     []string  `json:"alert_ids,omitempty"` // linked auditor or secbot alert IDs
+	CreatedBy    string    `json:"created_by,omitempty"`
+	TenantID     string    `json:"tenant_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// EventAnnotationStore persists event annotations. It shares the same *sql.DB
+// connection as the audit Store. It has no update or delete: annotations are
+// an append-only investigation record.
+type EventAnnotationStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewEventAnnotationStore creates the event_annotations table (if absent) and
+// returns a ready-to-use EventAnnotationStore.
+func NewEventAnnotationStore(db *sql.DB, isPostgres bool) (*EventAnnotationStore, error) {
+	s := &EventAnnotationStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS event_annotations (
+    annotation_id TEXT NOT NULL PRIMARY KEY,
+    event_id      TEXT NOT NULL,
+    disposition   TEXT NOT NULL DEFAULT '',
+    note          TEXT NOT NULL DEFAULT '',
+    alert_ids     TEXT NOT NULL DEFAULT '[]',
+    created_by    TEXT NOT NULL DEFAULT '',
+    tenant_id     TEXT NOT NULL DEFAULT '',
+    created_at    TEXT NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create event_annotations schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_annotations_event
+    ON event_annotations(event_id)`); err != nil {
+		return nil, fmt.Errorf("create event_annotations index: %w", err)
+	}
+	return s, nil
+}
+
+// ValidDisposition reports whether d is a known disposition.
+func ValidDisposition(d string) bool {
+	return validDispositions[d]
+}
+
+// Create validates and inserts a. AnnotationID and CreatedAt are assigned
+// when empty. An annotation needs an event and at least one of a
+// disposition, a note or a linked alert.
+func (s *EventAnnotationStore) Create(ctx context.Context, a *EventAnnotation) error {
+	if a.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	a.Disposition = strings.ToLower(strings.TrimSpace(a.Disposition))
+	if a.Disposition != "" && !ValidDisposition(a.Disposition) {
+		return fmt.Errorf("invalid disposition %q", a.Disposition)
+	}
+	if a.Disposition == "" && a.Note == "" && len(a.AlertIDs) == 0 {
+		return fmt.Errorf("disposition, note or alert_ids is required")
+	}
+	if a.AnnotationID == "" {
+		a.AnnotationID = "evann_" + uuid.New().String()[:8]
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	alertIDs, _ := json.Marshal(a.AlertIDs)
+	if a.AlertIDs == nil {
+		alertIDs = []byte("[]")
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO event_annotations
+    (annotation_id, event_id, disposition, note, alert_ids, created_by, tenant_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		a.AnnotationID, a.EventID, a.Disposition, a.Note, string(alertIDs),
+		a.CreatedBy, a.TenantID, a.CreatedAt.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("insert event annotation: %w", err)
+	}
+	return nil
+}
+
+// List returns the annotations of one event, oldest first.
+func (s *EventAnnotationStore) List(ctx context.Context, eventID string) ([]EventAnnotation, error) {
+	return s.query(ctx, "WHERE event_id = ?", eventID)
+}
+
+// EventIDsByDisposition returns the IDs of the events whose current
+// disposition is disposition, in the order they were last dispositioned.
+func (s *EventAnnotationStore) EventIDsByDisposition(ctx context.Context, disposition string) ([]string, error) {
+	all, err := s.query(ctx, "WHERE disposition != ''")
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]int, len(all)) // event ID -> index of its latest annotation
+	for i, a := range all {
+		latest[a.EventID] = i
+	}
+	var ids []string
+	for i, a := range all {
+		if latest[a.EventID] == i && a.Disposition == disposition {
+			ids = append(ids, a.EventID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *EventAnnotationStore) query(ctx context.Context, where string, args ...any) ([]EventAnnotation, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT annotation_id, event_id, disposition, note, alert_ids, created_by, tenant_id, created_at
+FROM event_annotations
+`+where+`
+ORDER BY created_at, annotation_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list event annotations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []EventAnnotation
+	for rows.Next() {
+		var a EventAnnotation
+		var alertIDs, createdAt string
+		if err := rows.Scan(&a.AnnotationID, &a.EventID, &a.Disposition, &a.Note, &alertIDs,
+			&a.CreatedBy, &a.TenantID, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan event annotation: %w", err)
+		}
+		json.Unmarshal([]byte(alertIDs), &a.AlertIDs) //nolint:errcheck
+		a.CreatedAt = parseFlexTime(createdAt)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// EraseSubjectRecords replaces ids as annotation author with subject and
+// erases the notes they wrote.
+func (s *EventAnnotationStore) EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error) {
+	return eraseSubjectRows(ctx, s.db, s.isPostgres, "event_annotations", "created_by", []string{"note"}, ids, subject)
+}
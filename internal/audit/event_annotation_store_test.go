@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventAnnotationStore_CreateList(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := NewEventAnnotationStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewEventAnnotationStore: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, a := range []*EventAnnotation{
+		{EventID: "tool_a", Disposition: "Needs_Review", Note: "odd namespace", CreatedAt: base},
+		{EventID: "tool_a", Disposition: DispositionFalsePositive, AlertIDs: []string{"alert_1"}, CreatedAt: base.Add(time.Minute)},
+		{EventID: "tool_b", Disposition: DispositionConfirmed, CreatedAt: base.Add(2 * time.Minute)},
+		{EventID: "tool_c", Note: "looked at it", CreatedAt: base.Add(3 * time.Minute)},
+	} {
+		if err := as.Create(ctx, a); err != nil {
+			t.Fatalf("Create(%+v): %v", a, err)
+		}
+	}
+
+	got, err := as.List(ctx, "tool_a")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 2 || got[0].Disposition != DispositionNeedsReview || got[1].Disposition != DispositionFalsePositive {
+		t.Fatalf("List(tool_a) = %+v, want needs_review then false_positive", got)
+	}
+	if len(got[1].AlertIDs) != 1 || got[1].AlertIDs[0] != "alert_1" {
+		t.Errorf("AlertIDs = %v, want [alert_1]", got[1].AlertIDs)
+	}
+
+	// The latest disposition wins: tool_a is no longer needs_review.
+	for disposition, want := range map[string][]string{
+		DispositionFalsePositive: {"tool_a"},
+		DispositionNeedsReview:   nil,
+		DispositionConfirmed:     {"tool_b"},
+	} {
+		ids, err := as.EventIDsByDisposition(ctx, disposition)
+		if err != nil {
+			t.Fatalf("EventIDsByDisposition(%s): %v", disposition, err)
+		}
+		if len(ids) != len(want) || (len(want) > 0 && ids[0] != want[0]) {
+			t.Errorf("EventIDsByDisposition(%s) = %v, want %v", disposition, ids, want)
+		}
+	}
+
+	for name, a := range map[string]*EventAnnotation{
+		"no event":            {Note: "x"},
+		"unknown disposition": {EventID: "tool_a", Disposition: "maybe"},
+		"empty":               {EventID: "tool_a"},
+	} {
+		if err := as.Create(ctx, a); err == nil {
+			t.Errorf("%s: Create succeeded, want error", name)
+		}
+	}
+}
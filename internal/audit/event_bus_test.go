@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type published struct {
+	topic, key string
+	eventID    string
+}
+
+// fakeBus records publishes and can be told to fail the next N calls.
+type fakeBus struct {
+	mu       sync.Mutex
+	msgs     []published
+	failNext int
+}
+
+func (b *fakeBus) Name() string { return "fake" }
+func (b *fakeBus) Close() error { return nil }
+func (b *fakeBus) Publish(_ context.Context, topic, key string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failNext > 0 {
+		b.failNext--
+		return errors.New("broker down")
+	}
+	var ev Event
+	json.Unmarshal(payload, &ev) //nolint:errcheck
+	b.msgs = append(b.msgs, published{topic: topic, key: key, eventID: ev.EventID})
+	return nil
+}
+
+func (b *fakeBus) snapshot() []published {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]published(nil), b.msgs...)
+}
+
+func TestBusTopic(t *testing.T) {
+	if got := BusTopic("", EventTypeToolExecution); got != "helpdesk.audit.tool_execution" {
+		t.Errorf("BusTopic default = %q", got)
+	}
+	if got := BusTopic("acme", EventTypePolicyDecision); got != "acme.policy_decision" {
+		t.Errorf("BusTopic custom = %q", got)
+	}
+}
+
+func TestStore_EventBusRelay(t *testing.T) {
+	bus := &fakeBus{failNext: 1}
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewStore(StoreConfig{DBPath: dbPath, EventBuses: []EventBus{bus}})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	store.Record(ctx, &Event{EventID: "evt_1", EventType: EventTypeToolExecution, TraceID: "tr_a", Session: Session{ID: "s"}}) //nolint:errcheck
+	store.Record(ctx, &Event{EventID: "evt_2", EventType: EventTypePolicyDecision, Session: Session{ID: "sess_b"}})            //nolint:errcheck
+
+	// The first publish fails; the relay retries on the next wake and
+	// delivers both events in order.
+	deadline := time.Now().Add(3 * time.Second)
+	for len(bus.snapshot()) < 2 && time.Now().Before(deadline) {
+		store.relays[0].notify()
+		time.Sleep(20 * time.Millisecond)
+	}
+	msgs := bus.snapshot()
+	if len(msgs) != 2 {
+		t.Fatalf("published %d events, want 2", len(msgs))
+	}
+	if msgs[0] != (published{"helpdesk.audit.tool_execution", "tr_a", "evt_1"}) {
+		t.Errorf("msg[0] = %+v", msgs[0])
+	}
+	if msgs[1].key != "sess_b" {
+		t.Errorf("msg[1] key = %q, want session fallback", msgs[1].key)
+	}
+	store.Close()
+
+	// Reopening resumes from the cursor: no duplicates for already-published events.
+	bus2 := &fakeBus{}
+	store2, err := NewStore(StoreConfig{DBPath: dbPath, EventBuses: []EventBus{bus2}})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store2.Close()
+	store2.Record(ctx, &Event{EventID: "evt_3", EventType: EventTypeToolExecution, Session: Session{ID: "s"}}) //nolint:errcheck
+	deadline = time.Now().Add(3 * time.Second)
+	for len(bus2.snapshot()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := bus2.snapshot(); len(got) != 1 || got[0].eventID != "evt_3" {
+		t.Errorf("after reopen published %+v, want only evt_3", got)
+	}
+}
+
+func TestKafkaRESTBus_Publish(t *testing.T) {
+	var gotPath, gotCT string
+	var body struct {
+		Records []struct {
+			Key   string          `json:"key"`
+			Value json.RawMessage `json:"value"`
+		} `json:"records"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotCT = r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		if body.Records[0].Key == "bad" {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":40403,"error":"no such topic"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":7}]}`))
+	}))
+	defer srv.Close()
+
+	bus := NewKafkaRESTBus(srv.URL + "/")
+	if err := bus.Publish(context.Background(), "helpdesk.audit.tool_execution", "tr_1", []byte(`{"event_id":"evt_1"}`)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if gotPath != "/topics/helpdesk.audit.tool_execution" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotCT != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("content-type = %q", gotCT)
+	}
+	if body.Records[0].Key != "tr_1" || !strings.Contains(string(body.Records[0].Value), "evt_1") {
+		t.Errorf("unexpected record: %+v", body.Records[0])
+	}
+	if err := bus.Publish(context.Background(), "t", "bad", []byte(`{}`)); err == nil {
+		t.Error("expected error for per-record error_code")
+	}
+}
+
+// fakeNATSServer accepts one connection and speaks just enough of the NATS
+// protocol for NATSBus: INFO, CONNECT, PING/PONG, SUB, HPUB (+ JetStream ack).
+func fakeNATSServer(t *testing.T, jetStream bool) (addr string, got chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp listen unavailable: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got = make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "INFO {\"headers\":true}\r\n") //nolint:errcheck
+		var inbox string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			f := strings.Fields(line)
+			switch {
+			case line == "PING":
+				io.WriteString(conn, "PONG\r\n") //nolint:errcheck
+			case len(f) > 0 && f[0] == "SUB":
+				inbox = f[1]
+			case len(f) > 0 && f[0] == "HPUB":
+				total, _ := strconv.Atoi(f[len(f)-1])
+				buf := make([]byte, total+2)
+				io.ReadFull(r, buf) //nolint:errcheck
+				got <- f[1] + " " + string(buf[:total])
+				if jetStream {
+					ack := `{"stream":"AUDIT","seq":1}`
+					fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", inbox, len(ack), ack)
+				}
+			}
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func TestNATSBus_Publish(t *testing.T) {
+	for _, js := range []bool{false, true} {
+		t.Run(fmt.Sprintf("jetstream=%v", js), func(t *testing.T) {
+			addr, got := fakeNATSServer(t, js)
+			bus, err := NewNATSBus("nats://"+addr, js)
+			if err != nil {
+				t.Fatalf("NewNATSBus: %v", err)
+			}
+			defer bus.Close()
+			if err := bus.Publish(context.Background(), "helpdesk.audit.tool_execution", "tr_9", []byte(`{"event_id":"evt_9"}`)); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			select {
+			case msg := <-got:
+				for _, want := range []string{"helpdesk.audit.tool_execution", "Nats-Msg-Id: evt_9", "Helpdesk-Trace-Id: tr_9", `{"event_id":"evt_9"}`} {
+					if !strings.Contains(msg, want) {
+						t.Errorf("message missing %q: %q", want, msg)
+					}
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("server received nothing")
+			}
+		})
+	}
+}
+
+func TestNewNATSBus_InvalidScheme(t *testing.T) {
+	if _, err := NewNATSBus("http://localhost:4222", false); err == nil {
+		t.Error("expected error for non-nats scheme")
+	}
+	b, err := NewNATSBus("nats://user:pw@localhost", false)
+	if err != nil {
+		t.Fatalf("NewNATSBus: %v", err)
+	}
+	if b.addr != "localhost:4222" || b.user != "user" || b.pass != "pw" {
+		t.Errorf("parsed bus = %+v", b)
+	}
+}
@@ -0,0 +1,313 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatsOptions selects the window and tenant for Stats.
+type StatsOptions struct {
+	Since    time.Time // inclusive lower bound on timestamp; zero = unbounded
+	Until    time.Time // exclusive upper bound on timestamp; zero = unbounded
+	TenantID string    // filter by tenant; empty = all tenants
+}
+
+// EventStats is a SQL-side aggregation of the audit trail over a time window.
+// It answers the "how many" questions govbot and dashboards ask without
+// shipping every event to the caller.
+type EventStats struct {
+	Since       string         `json:"since,omitempty"`
+	Until       string         `json:"until,omitempty"`
+	TotalEvents int            `json:"total_events"`
+	ByType      map[string]int `json:"by_type"`
+	// ByEffect counts policy_decision events by effect
+	// ("allow", "deny", "require_approval").
+	ByEffect map[string]int `json:"by_effect"`
+	// ByActionClass counts tool_execution events by action class
+	// ("read", "write", "destructive").
+	ByActionClass map[string]int `json:"by_action_class"`
+	// ToolErrors counts tool_execution events whose outcome was an error.
+	ToolErrors int `json:"tool_errors"`
+	// ByResource breaks policy decisions down per "resource_type/resource_name",
+	// sorted by resource.
+	ByResource []ResourceDecisionCounts `json:"by_resource"`
+	// SampledOut counts events dropped by the sampling policy. They are
+	// included in TotalEvents, ByType and ByActionClass.
+	SampledOut int `json:"sampled_out"`
+	// Orphans lists the agents with delegations whose outcome never arrived
+	// (outcome_status "timed_out", set by auditd's orphan job), sorted by agent.
+	Orphans []AgentOrphans `json:"orphans"`
+}
+
+// AgentOrphans is the per-agent row of EventStats.Orphans.
+type AgentOrphans struct {
+	Agent       string  `json:"agent"`
+	Delegations int     `json:"delegations"`
+	Orphaned    int     `json:"orphaned"`
+	Rate        float64 `json:"rate"` // Orphaned / Delegations
+}
+
+// ResourceDecisionCounts is the per-resource row of EventStats.ByResource.
+type ResourceDecisionCounts struct {
+	Resource        string `json:"resource"`
+	Allow           int    `json:"allow"`
+	Deny            int    `json:"deny"`
+	RequireApproval int    `json:"require_approval"`
+	// NoMatch counts allow/deny decisions that matched no rule
+	// (policy_name empty or "default") — usually missing infra tags.
+	NoMatch int `json:"no_match"`
+}
+
+// Stats aggregates events in the window with GROUP BY queries served by the
+// (event_type, timestamp) index, so the cost does not grow with the number of
+// events a caller would otherwise have to fetch.
+func (s *Store) Stats(ctx context.Context, opts StatsOptions) (EventStats, error) {
+	stats := EventStats{
+		ByType:        map[string]int{},
+		ByEffect:      map[string]int{},
+		ByActionClass: map[string]int{},
+		ByResource:    []ResourceDecisionCounts{},
+		Orphans:       []AgentOrphans{},
+	}
+
+	where := " WHERE 1=1"
+	sampledWhere := " WHERE 1=1"
+	var args []any
+	if !opts.Since.IsZero() {
+		where += " AND timestamp >= ?"
+		sampledWhere += " AND minute >= ?"
+		args = append(args, opts.Since.UTC().Format(sqliteTimeFormat))
+		stats.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+	if !opts.Until.IsZero() {
+		where += " AND timestamp < ?"
+		sampledWhere += " AND minute < ?"
+		args = append(args, opts.Until.UTC().Format(sqliteTimeFormat))
+		stats.Until = opts.Until.UTC().Format(time.RFC3339)
+	}
+	if opts.TenantID != "" {
+		where += " AND tenant_id = ?"
+		sampledWhere += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+
+	// By event type.
+	err := s.groupCount(ctx, `SELECT event_type, COUNT(*) FROM audit_events`+where+` GROUP BY event_type`, args,
+		func(key string, n int) {
+			stats.ByType[key] = n
+			stats.TotalEvents += n
+		})
+	if err != nil {
+		return stats, fmt.Errorf("count events by type: %w", err)
+	}
+
+	// Tool executions by action class.
+	err = s.groupCount(ctx, `SELECT COALESCE(action_class, ''), COUNT(*) FROM audit_events`+where+
+		` AND event_type = '`+string(EventTypeToolExecution)+`' GROUP BY COALESCE(action_class, '')`, args,
+		func(key string, n int) {
+			if key == "" {
+				key = "unknown"
+			}
+			stats.ByActionClass[key] += n
+		})
+	if err != nil {
+		return stats, fmt.Errorf("count tool executions by action class: %w", err)
+	}
+	err = s.groupCount(ctx, `SELECT outcome_status, COUNT(*) FROM audit_events`+where+
+		` AND event_type = '`+string(EventTypeToolExecution)+`' AND outcome_status = 'error' GROUP BY outcome_status`, args,
+		func(_ string, n int) { stats.ToolErrors += n })
+	if err != nil {
+		return stats, fmt.Errorf("count tool execution errors: %w", err)
+	}
+
+	// Events dropped by the sampling policy, counted per minute.
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT event_type, action_class, SUM(count) FROM sampled_event_counts`+sampledWhere+`
+		GROUP BY event_type, action_class`), args...)
+	if err != nil {
+		return stats, fmt.Errorf("count sampled-out events: %w", err)
+	}
+	for rows.Next() {
+		var eventType, actionClass string
+		var n int
+		if err := rows.Scan(&eventType, &actionClass, &n); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("scan sampled-out counts: %w", err)
+		}
+		stats.SampledOut += n
+		stats.TotalEvents += n
+		stats.ByType[eventType] += n
+		if eventType == string(EventTypeToolExecution) {
+			if actionClass == "" {
+				actionClass = "unknown"
+			}
+			stats.ByActionClass[actionClass] += n
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return stats, err
+	}
+
+	// Delegations per agent, and how many of them timed out without an outcome.
+	rows, err = s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT COALESCE(decision_agent, ''), COUNT(*),
+		       SUM(CASE WHEN outcome_status = '`+OutcomeTimedOut+`' THEN 1 ELSE 0 END)
+		FROM audit_events`+where+` AND event_type = '`+string(EventTypeDelegation)+`'
+		GROUP BY 1 ORDER BY 1`), args...)
+	if err != nil {
+		return stats, fmt.Errorf("count orphaned delegations: %w", err)
+	}
+	for rows.Next() {
+		var o AgentOrphans
+		if err := rows.Scan(&o.Agent, &o.Delegations, &o.Orphaned); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("scan orphaned delegations: %w", err)
+		}
+		if o.Orphaned > 0 {
+			o.Rate = float64(o.Orphaned) / float64(o.Delegations)
+			stats.Orphans = append(stats.Orphans, o)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return stats, err
+	}
+
+	// Policy decisions by resource, effect and whether a rule matched.
+	// outcome_status holds the effect, with "deny" stored as "denied".
+	rows, err = s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT COALESCE(resource_type, ''), COALESCE(resource_name, ''), COALESCE(outcome_status, ''),
+		       CASE WHEN COALESCE(policy_name, '') IN ('', 'default') THEN 1 ELSE 0 END,
+		       COUNT(*)
+		FROM audit_events`+where+` AND event_type = '`+string(EventTypePolicyDecision)+`'
+		GROUP BY 1, 2, 3, 4`), args...)
+	if err != nil {
+		return stats, fmt.Errorf("count policy decisions: %w", err)
+	}
+	defer rows.Close()
+
+	byResource := make(map[string]*ResourceDecisionCounts)
+	for rows.Next() {
+		var resType, resName, effect string
+		var noMatch, n int
+		if err := rows.Scan(&resType, &resName, &effect, &noMatch, &n); err != nil {
+			return stats, fmt.Errorf("scan policy decision counts: %w", err)
+		}
+		if effect == "denied" {
+			effect = "deny"
+		}
+		stats.ByEffect[effect] += n
+
+		key := resType + "/" + resName
+		rc := byResource[key]
+		if rc == nil {
+			rc = &ResourceDecisionCounts{Resource: key}
+			byResource[key] = rc
+		}
+		switch effect {
+		case "allow":
+			rc.Allow += n
+		case "deny":
+			rc.Deny += n
+		case "require_approval":
+			rc.RequireApproval += n
+		}
+		if noMatch == 1 && (effect == "allow" || effect == "deny") {
+			rc.NoMatch += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+	for _, rc := range byResource {
+		stats.ByResource = append(stats.ByResource, *rc)
+	}
+	sort.Slice(stats.ByResource, func(i, j int) bool {
+		return stats.ByResource[i].Resource < stats.ByResource[j].Resource
+	})
+	return stats, nil
+}
+
+// groupCount runs a two-column (key, count) GROUP BY query.
+func (s *Store) groupCount(ctx context.Context, query string, args []any, fn func(key string, n int)) error {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return err
+		}
+		fn(key, n)
+	}
+	return rows.Err()
+}
+
+// backfillDecisionColumns populates resource_type, resource_name and
+// policy_name for events recorded before those columns existed. Rows are
+// processed in id order in small batches; a row whose raw_json carries no
+// policy decision gets empty strings so it is not revisited.
+func backfillDecisionColumns(db *sql.DB, isPostgres bool) error {
+	const batch = 500
+	types := "'" + strings.Join([]string{string(EventTypePolicyDecision), string(EventTypeToolInvoked)}, "', '") + "'"
+	var lastID int64
+	for {
+		rows, err := db.Query(rebind(isPostgres, `
+			SELECT id, raw_json FROM audit_events
+			WHERE resource_type IS NULL AND event_type IN (`+types+`) AND id > ?
+			ORDER BY id LIMIT ?`), lastID, batch)
+		if err != nil {
+			return err
+		}
+		type fill struct {
+			id                   int64
+			resType, resName, pn string
+		}
+		var fills []fill
+		for rows.Next() {
+			var id int64
+			var raw string
+			if err := rows.Scan(&id, &raw); err != nil {
+				rows.Close()
+				return err
+			}
+			var e Event
+			f := fill{id: id}
+			if json.Unmarshal([]byte(raw), &e) == nil && e.PolicyDecision != nil {
+				f.resType = e.PolicyDecision.ResourceType
+				f.resName = e.PolicyDecision.ResourceName
+				f.pn = e.PolicyDecision.PolicyName
+			}
+			fills = append(fills, f)
+		}
+		err = rows.Err()
+		// Close the cursor before writing: SQLite runs on a single connection.
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if len(fills) == 0 {
+			return nil
+		}
+
+		for _, f := range fills {
+			if _, err := db.Exec(rebind(isPostgres,
+				`UPDATE audit_events SET resource_type = ?, resource_name = ?, policy_name = ? WHERE id = ?`),
+				f.resType, f.resName, f.pn, f.id); err != nil {
+				return err
+			}
+			lastID = f.id
+		}
+	}
+}
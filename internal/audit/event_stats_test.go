@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func recordDecision(t *testing.T, store *Store, resource, effect, policy string) {
+	t.Helper()
+	if err := store.Record(context.Background(), &Event{
+		EventType: EventTypePolicyDecision,
+		Session:   Session{ID: "s"},
+		PolicyDecision: &PolicyDecision{
+			ResourceType: "database", ResourceName: resource,
+			Action: "write", Effect: effect, PolicyName: policy,
+		},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+}
+
+func TestStats_CountsByTypeEffectAndResource(t *testing.T) {
+	store := newShardedStore(t, ChainShardingNone, nil)
+	ctx := context.Background()
+
+	recordDecision(t, store, "prod", "allow", "db-policy")
+	recordDecision(t, store, "prod", "deny", "default")
+	recordDecision(t, store, "staging", "require_approval", "db-policy")
+	for _, class := range []ActionClass{ActionRead, ActionWrite, ActionDestructive} {
+		outcome := &Outcome{Status: "success"}
+		if class == ActionDestructive {
+			outcome = &Outcome{Status: "error", ErrorMessage: "permission denied"}
+		}
+		if err := store.Record(ctx, &Event{
+			EventType: EventTypeToolExecution, ActionClass: class, Session: Session{ID: "s"}, Outcome: outcome,
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	stats, err := store.Stats(ctx, StatsOptions{Since: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalEvents != 6 || stats.ByType["policy_decision"] != 3 || stats.ByType["tool_execution"] != 3 {
+		t.Errorf("total=%d by_type=%v, want 6 with 3 decisions and 3 executions", stats.TotalEvents, stats.ByType)
+	}
+	if stats.ByEffect["allow"] != 1 || stats.ByEffect["deny"] != 1 || stats.ByEffect["require_approval"] != 1 {
+		t.Errorf("by_effect = %v", stats.ByEffect)
+	}
+	if stats.ByActionClass["write"] != 1 || stats.ByActionClass["destructive"] != 1 {
+		t.Errorf("by_action_class = %v", stats.ByActionClass)
+	}
+	if stats.ToolErrors != 1 {
+		t.Errorf("tool_errors = %d, want 1", stats.ToolErrors)
+	}
+	want := []ResourceDecisionCounts{
+		{Resource: "database/prod", Allow: 1, Deny: 1, NoMatch: 1},
+		{Resource: "database/staging", RequireApproval: 1},
+	}
+	if len(stats.ByResource) != len(want) {
+		t.Fatalf("by_resource = %+v, want %+v", stats.ByResource, want)
+	}
+	for i := range want {
+		if stats.ByResource[i] != want[i] {
+			t.Errorf("by_resource[%d] = %+v, want %+v", i, stats.ByResource[i], want[i])
+		}
+	}
+
+	// A window that ends before anything was recorded is empty.
+	empty, err := store.Stats(ctx, StatsOptions{Until: time.Now().Add(-time.Hour)})
+	if err != nil || empty.TotalEvents != 0 {
+		t.Errorf("past window: total=%d, %v; want 0", empty.TotalEvents, err)
+	}
+}
+
+func TestStats_BackfillsLegacyRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	store, err := NewStore(StoreConfig{DBPath: path})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	recordDecision(t, store, "prod", "deny", "db-policy")
+	// Simulate a row written before the decision columns existed.
+	if _, err := store.DB().Exec(`UPDATE audit_events SET resource_type = NULL, resource_name = NULL, policy_name = NULL`); err != nil {
+		t.Fatalf("clear columns: %v", err)
+	}
+	store.Close()
+
+	store, err = NewStore(StoreConfig{DBPath: path})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	stats, err := store.Stats(context.Background(), StatsOptions{})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(stats.ByResource) != 1 || stats.ByResource[0].Resource != "database/prod" || stats.ByResource[0].Deny != 1 {
+		t.Errorf("by_resource = %+v, want one deny on database/prod", stats.ByResource)
+	}
+}
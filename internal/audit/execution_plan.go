@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RequestContextExecutionPlan is the approval request_context key that holds
+// the ExecutionPlan of the tool call awaiting approval.
+const RequestContextExecutionPlan = "execution_plan"
+
+// ExecutionPlan tells an approver what a tool call will actually do, beyond
+// its tool name and action class. Tools build it from the state they inspect
+// before acting (the session behind a PID, the current replica count of a
+// deployment) and the policy enforcer attaches it to the approval request.
+type ExecutionPlan struct {
+	// Operation is the tool that will run, e.g. "terminate_connection".
+	Operation string `json:"operation"`
+
+	// Summary is a one-line statement of the effect, e.g.
+	// "scale deployment api in prod from 5 to 0 replicas".
+	Summary string `json:"summary"`
+
+	// Details is the structured state the summary was derived from.
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// NewExecutionPlan builds a plan whose Details are the JSON fields of details,
+// typically a struct the tool already uses for its own pre-execution checks.
+// A details value that does not encode to a JSON object is dropped.
+func NewExecutionPlan(operation, summary string, details any) *ExecutionPlan {
+	plan := &ExecutionPlan{Operation: operation, Summary: summary}
+	if details == nil {
+		return plan
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return plan
+	}
+	var m map[string]any
+	if json.Unmarshal(b, &m) == nil && len(m) > 0 {
+		plan.Details = m
+	}
+	return plan
+}
+
+// ExecutionPlan returns the plan attached to the approval request, or nil
+// when the requesting tool did not provide one.
+func (a *StoredApproval) ExecutionPlan() *ExecutionPlan {
+	raw, ok := a.RequestContext[RequestContextExecutionPlan]
+	if !ok || raw == nil {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var plan ExecutionPlan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil
+	}
+	if plan.Summary == "" && len(plan.Details) == 0 {
+		return nil
+	}
+	return &plan
+}
+
+// Format renders the plan as plain text: the summary, then one "key: value"
+// line per detail in key order. Every line is prefixed with indent.
+func (p *ExecutionPlan) Format(indent string) string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	summary := p.Summary
+	if summary == "" {
+		summary = p.Operation
+	}
+	b.WriteString(indent + summary + "\n")
+
+	keys := make([]string, 0, len(p.Details))
+	width := 0
+	for k := range p.Details {
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s  %-*s  %s\n", indent, width+1, k+":", formatPlanValue(p.Details[k]))
+	}
+	return b.String()
+}
+
+// formatPlanValue prints decoded JSON values the way an operator reads them:
+// whole numbers without exponent, lists comma-separated.
+func formatPlanValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatPlanValue(item)
+		}
+		return strings.Join(parts, ", ")
+	case nil:
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewExecutionPlan_DetailsFromStruct(t *testing.T) {
+	type scale struct {
+		Deployment string `json:"deployment"`
+		Current    int    `json:"current_replicas"`
+		Target     int    `json:"target_replicas"`
+	}
+	plan := NewExecutionPlan("scale_deployment", "scale deployment api from 5 to 0 replicas",
+		scale{Deployment: "api", Current: 5, Target: 0})
+	if plan.Details["deployment"] != "api" || plan.Details["current_replicas"] != float64(5) {
+		t.Errorf("Details = %v", plan.Details)
+	}
+
+	if p := NewExecutionPlan("x", "y", "not an object"); p.Details != nil {
+		t.Errorf("non-object details should be dropped, got %v", p.Details)
+	}
+}
+
+func TestStoredApproval_ExecutionPlan(t *testing.T) {
+	plan := NewExecutionPlan("terminate_connection", "terminate PID 42",
+		map[string]any{"pid": 42, "locked_tables": []string{"orders", "users"}})
+
+	// Round-trip through JSON as the approval store and API do.
+	b, _ := json.Marshal(map[string]any{RequestContextExecutionPlan: plan, "tags": []string{"prod"}})
+	var reqCtx map[string]any
+	if err := json.Unmarshal(b, &reqCtx); err != nil {
+		t.Fatal(err)
+	}
+	a := &StoredApproval{RequestContext: reqCtx}
+	got := a.ExecutionPlan()
+	if got == nil || got.Operation != "terminate_connection" || got.Summary != "terminate PID 42" {
+		t.Fatalf("ExecutionPlan() = %+v", got)
+	}
+
+	out := got.Format("  ")
+	for _, want := range []string{"  terminate PID 42\n", "locked_tables:  orders, users\n", "pid:            42\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format() = %q, want to contain %q", out, want)
+		}
+	}
+
+	if (&StoredApproval{RequestContext: map[string]any{"tags": nil}}).ExecutionPlan() != nil {
+		t.Error("ExecutionPlan() should be nil without a plan")
+	}
+}
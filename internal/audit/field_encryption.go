@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EncryptedPrefix marks an event field value encrypted at rest:
+// "enc:v1:<data key ID>:<base64 nonce+ciphertext>".
+const EncryptedPrefix = "enc:v1:"
+
+// RedactedField replaces an encrypted field for callers not allowed to read it.
+const RedactedField = "[encrypted]"
+
+// encryptableFields are the event fields that may be encrypted at rest, by
+// name. Each returns a pointer to the field, or nil when the event has none.
+var encryptableFields = map[string]func(*Event) *string{
+	"input.user_query": func(e *Event) *string { return &e.Input.UserQuery },
+	"output.response": func(e *Event) *string {
+		if e.Output == nil {
+			return nil
+		}
+		return &e.Output.Response
+	},
+	"tool.raw_command": func(e *Event) *string {
+		if e.Tool == nil {
+			return nil
+		}
+		return &e.Tool.RawCommand
+	},
+	"tool.result": func(e *Event) *string {
+		if e.Tool == nil {
+			return nil
+		}
+		return &e.Tool.Result
+	},
+}
+
+// EncryptableFields returns the names of the fields that may be encrypted.
+func EncryptableFields() []string {
+	names := make([]string, 0, len(encryptableFields))
+	for name := range encryptableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseEncryptedFields parses a comma-separated list of field names, e.g.
+// "tool.raw_command,input.user_query".
+func ParseEncryptedFields(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if _, ok := encryptableFields[f]; !ok {
+			return nil, fmt.Errorf("field %q cannot be encrypted (want one of %s)", f, strings.Join(EncryptableFields(), ", "))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// FieldEncryption encrypts designated event fields at rest with envelope
+// encryption: values are sealed with AES-256-GCM under a data key, and the
+// data key is stored only wrapped by a key encryption key (KEK) held in a KMS.
+type FieldEncryption struct {
+	// Fields are the fields to encrypt (see EncryptableFields).
+	Fields []string
+	// KEK wraps and unwraps the data keys.
+	KEK KeyEncryptionKey
+}
+
+// fieldCipher seals and opens field values. The current data key encrypts
+// new values; older data keys are unwrapped on first use to read old ones.
+type fieldCipher struct {
+	db         *sql.DB
+	isPostgres bool
+	kek        KeyEncryptionKey
+	fields     []string
+
+	keyID string // current data key
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD // data key ID → unwrapped key
+}
+
+func createFieldKeyTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_field_keys (
+		key_id      TEXT PRIMARY KEY,
+		kek_id      TEXT NOT NULL,
+		wrapped_key TEXT NOT NULL,
+		created_at  TEXT NOT NULL
+	);
+	`)
+	return err
+}
+
+// newFieldCipher adopts the newest data key wrapped by cfg.KEK, creating and
+// storing one when there is none.
+func newFieldCipher(ctx context.Context, db *sql.DB, isPostgres bool, cfg FieldEncryption) (*fieldCipher, error) {
+	if cfg.KEK == nil {
+		return nil, errors.New("field encryption needs a key encryption key")
+	}
+	for _, f := range cfg.Fields {
+		if _, ok := encryptableFields[f]; !ok {
+			return nil, fmt.Errorf("field %q cannot be encrypted", f)
+		}
+	}
+	if err := createFieldKeyTable(db); err != nil {
+		return nil, fmt.Errorf("create audit_field_keys: %w", err)
+	}
+	c := &fieldCipher{db: db, isPostgres: isPostgres, kek: cfg.KEK, fields: cfg.Fields, keys: map[string]cipher.AEAD{}}
+
+	var keyID string
+	err := db.QueryRowContext(ctx, rebind(isPostgres,
+		`SELECT key_id FROM audit_field_keys WHERE kek_id = ? ORDER BY created_at DESC LIMIT 1`),
+		cfg.KEK.ID()).Scan(&keyID)
+	switch {
+	case err == nil:
+		if _, err := c.key(ctx, keyID); err != nil {
+			return nil, err
+		}
+		c.keyID = keyID
+		return c, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("load data key: %w", err)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	wrapped, err := cfg.KEK.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	keyID = "dk_" + uuid.New().String()[:8]
+	if _, err := db.ExecContext(ctx, rebind(isPostgres,
+		`INSERT INTO audit_field_keys (key_id, kek_id, wrapped_key, created_at) VALUES (?, ?, ?, ?)`),
+		keyID, cfg.KEK.ID(), base64.StdEncoding.EncodeToString(wrapped), time.Now().UTC().Format(sqliteTimeFormat)); err != nil {
+		return nil, fmt.Errorf("store data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	c.keys[keyID] = aead
+	c.keyID = keyID
+	slog.Info("field encryption data key created", "key_id", keyID, "kek", cfg.KEK.ID())
+	return c, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// key returns the data key with the given ID, unwrapping it on first use.
+func (c *fieldCipher) key(ctx context.Context, keyID string) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.keys[keyID]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	var wrapped string
+	err := c.db.QueryRowContext(ctx, rebind(c.isPostgres,
+		`SELECT wrapped_key FROM audit_field_keys WHERE key_id = ?`), keyID).Scan(&wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("load data key %s: %w", keyID, err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("data key %s: %w", keyID, err)
+	}
+	dataKey, err := c.kek.Unwrap(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key %s: %w", keyID, err)
+	}
+	if aead, err = newAEAD(dataKey); err != nil {
+		return nil, fmt.Errorf("data key %s: %w", keyID, err)
+	}
+	c.mu.Lock()
+	c.keys[keyID] = aead
+	c.mu.Unlock()
+	return aead, nil
+}
+
+// encrypt seals the designated fields of e in place. The field name is bound
+// to the ciphertext, so a value cannot be moved to another field.
+func (c *fieldCipher) encrypt(ctx context.Context, e *Event) error {
+	aead, err := c.key(ctx, c.keyID)
+	if err != nil {
+		return err
+	}
+	for _, name := range c.fields {
+		p := encryptableFields[name](e)
+		if p == nil || *p == "" || strings.HasPrefix(*p, EncryptedPrefix) {
+			continue
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		sealed := aead.Seal(nonce, nonce, []byte(*p), []byte(name))
+		*p = EncryptedPrefix + c.keyID + ":" + base64.StdEncoding.EncodeToString(sealed)
+	}
+	return nil
+}
+
+// decrypt opens one encrypted value of the named field.
+func (c *fieldCipher) decrypt(ctx context.Context, name, v string) (string, error) {
+	keyID, data, ok := strings.Cut(strings.TrimPrefix(v, EncryptedPrefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	aead, err := c.key(ctx, keyID)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", name, err)
+	}
+	return string(plain), nil
+}
+
+// reveal decrypts the value at p in place, redacting it when that fails.
+func (s *Store) reveal(ctx context.Context, name string, p *string) {
+	if p == nil || !strings.HasPrefix(*p, EncryptedPrefix) {
+		return
+	}
+	if s.fieldCipher == nil {
+		*p = RedactedField
+		return
+	}
+	plain, err := s.fieldCipher.decrypt(ctx, name, *p)
+	if err != nil {
+		slog.Warn("failed to decrypt event field", "field", name, "err", err)
+		*p = RedactedField
+		return
+	}
+	*p = plain
+}
+
+// DecryptFields decrypts the encrypted fields of events in place. A value
+// that cannot be decrypted, e.g. because the store has no key for it, is
+// redacted instead.
+func (s *Store) DecryptFields(ctx context.Context, events []Event) {
+	for i := range events {
+		for name, field := range encryptableFields {
+			s.reveal(ctx, name, field(&events[i]))
+		}
+	}
+}
+
+// DecryptJourneys decrypts the user queries of journeys in place, like
+// DecryptFields.
+func (s *Store) DecryptJourneys(ctx context.Context, journeys []JourneySummary) {
+	for i := range journeys {
+		s.reveal(ctx, "input.user_query", &journeys[i].UserQuery)
+	}
+}
+
+// RedactFields replaces every encrypted field of events with RedactedField.
+func RedactFields(events []Event) {
+	for i := range events {
+		for _, field := range encryptableFields {
+			if p := field(&events[i]); p != nil && strings.HasPrefix(*p, EncryptedPrefix) {
+				*p = RedactedField
+			}
+		}
+	}
+}
+
+// RedactJourneys replaces encrypted journey user queries with RedactedField.
+func RedactJourneys(journeys []JourneySummary) {
+	for i := range journeys {
+		if strings.HasPrefix(journeys[i].UserQuery, EncryptedPrefix) {
+			journeys[i].UserQuery = RedactedField
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore_FieldEncryption(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	enc := FieldEncryption{Fields: []string{"tool.raw_command", "input.user_query"}, KEK: NewLocalKey([]byte("kek-secret"))}
+	store, err := NewStore(StoreConfig{DBPath: dbPath, FieldEncryption: enc})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Record(ctx, &Event{
+		EventID:   "evt_enc",
+		EventType: EventTypeToolExecution,
+		Session:   Session{ID: "sess_enc"},
+		Input:     Input{UserQuery: "SELECT ssn FROM customers"},
+		Tool:      &ToolExecution{Name: "run_sql", RawCommand: "SELECT ssn FROM customers", Result: "3 rows"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// Neither the event nor its columns hold the plaintext.
+	var raw, userQuery, toolJSON string
+	if err := store.DB().QueryRow(`SELECT raw_json, user_query, tool_json FROM audit_events WHERE event_id = 'evt_enc'`).
+		Scan(&raw, &userQuery, &toolJSON); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{raw, userQuery, toolJSON} {
+		if strings.Contains(v, "ssn") {
+			t.Errorf("plaintext stored: %s", v)
+		}
+	}
+	if !strings.Contains(raw, "3 rows") {
+		t.Error("undesignated field was encrypted")
+	}
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity = %+v, %v", status, err)
+	}
+
+	events, err := store.Query(ctx, QueryOptions{EventID: "evt_enc"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Query: %v, %d events", err, len(events))
+	}
+	redacted := append([]Event(nil), events...)
+	redacted[0].Tool = &ToolExecution{RawCommand: events[0].Tool.RawCommand}
+	RedactFields(redacted)
+	if redacted[0].Input.UserQuery != RedactedField || redacted[0].Tool.RawCommand != RedactedField {
+		t.Errorf("redacted = %+v", redacted[0])
+	}
+
+	// A reopened store reuses the data key; one without it redacts.
+	store.Close()
+	store, err = NewStore(StoreConfig{DBPath: dbPath, FieldEncryption: enc})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	store.DecryptFields(ctx, events)
+	if events[0].Tool.RawCommand != "SELECT ssn FROM customers" || events[0].Input.UserQuery != "SELECT ssn FROM customers" {
+		t.Errorf("decrypted = %q, %q", events[0].Tool.RawCommand, events[0].Input.UserQuery)
+	}
+	store.Close()
+
+	plain, err := NewStore(StoreConfig{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("reopen without key: %v", err)
+	}
+	defer plain.Close()
+	events, _ = plain.Query(ctx, QueryOptions{EventID: "evt_enc"})
+	plain.DecryptFields(ctx, events)
+	if events[0].Tool.RawCommand != RedactedField {
+		t.Errorf("without key: raw_command = %q, want redacted", events[0].Tool.RawCommand)
+	}
+}
+
+func TestParseEncryptedFields(t *testing.T) {
+	fields, err := ParseEncryptedFields(" tool.raw_command, input.user_query,")
+	if err != nil || len(fields) != 2 {
+		t.Errorf("ParseEncryptedFields = %v, %v", fields, err)
+	}
+	if _, err := ParseEncryptedFields("tool.parameters"); err == nil {
+		t.Error("accepted a field that cannot be encrypted")
+	}
+}
+
+func TestAWSKMSKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in) //nolint:errcheck
+		if in["KeyId"] != "alias/helpdesk" || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// A stand-in KMS whose "encryption" is reversing the bytes.
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			json.NewEncoder(w).Encode(map[string]string{"CiphertextBlob": reverseB64(in["Plaintext"])}) //nolint:errcheck
+		case "TrentService.Decrypt":
+			json.NewEncoder(w).Encode(map[string]string{"Plaintext": reverseB64(in["CiphertextBlob"])}) //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+
+	kek := &AWSKMSKey{KeyID: "alias/helpdesk", Region: "us-east-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: srv.URL}
+	wrapped, err := kek.Wrap(context.Background(), []byte("data-key"))
+	if err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	if string(wrapped) != "yek-atad" {
+		t.Errorf("wrapped = %q", wrapped)
+	}
+	key, err := kek.Unwrap(context.Background(), wrapped)
+	if err != nil || string(key) != "data-key" {
+		t.Errorf("Unwrap = %q, %v", key, err)
+	}
+}
+
+func reverseB64(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
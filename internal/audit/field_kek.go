@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"helpdesk/internal/awssig"
+)
+
+// KeyEncryptionKey wraps the data keys that encrypt event fields. The key
+// itself never leaves its KMS; only wrapped data keys are stored.
+type KeyEncryptionKey interface {
+	// ID identifies the key, so a changed key gets a new data key.
+	ID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// ParseKeyEncryptionKey returns the KEK a -field-key value names:
+// "awskms://<key ID, ARN or alias>" for AWS KMS, otherwise a local key derived
+// from the value itself (typically resolved from a secrets reference).
+func ParseKeyEncryptionKey(v string) (KeyEncryptionKey, error) {
+	if keyID, ok := strings.CutPrefix(v, "awskms://"); ok {
+		if keyID == "" {
+			return nil, errors.New("awskms:// needs a key ID, ARN or alias")
+		}
+		return &AWSKMSKey{KeyID: keyID}, nil
+	}
+	if v == "" {
+		return nil, errors.New("field key is empty")
+	}
+	return NewLocalKey([]byte(v)), nil
+}
+
+// LocalKey is a KEK held by the process itself: AES-256-GCM under the
+// SHA-256 of a secret. It suits deployments whose secret store, rather than
+// a KMS, guards the key.
+type LocalKey struct {
+	key [32]byte
+}
+
+// NewLocalKey derives a local KEK from secret.
+func NewLocalKey(secret []byte) *LocalKey {
+	return &LocalKey{key: sha256.Sum256(secret)}
+}
+
+// ID is a fingerprint of the key, not the key.
+func (k *LocalKey) ID() string {
+	sum := sha256.Sum256(k.key[:])
+	return "local:" + hex.EncodeToString(sum[:8])
+}
+
+// Wrap seals dataKey.
+func (k *LocalKey) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(k.key[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+// Unwrap opens a key sealed by Wrap.
+func (k *LocalKey) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(k.key[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
+
+// AWSKMSKey wraps data keys with an AWS KMS symmetric key (Encrypt/Decrypt)
+// using a SigV4-signed request. The region is taken from a key ARN when
+// present.
+//
+// Zero fields fall back to AWS_REGION (or AWS_DEFAULT_REGION),
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN. Endpoint
+// overrides https://kms.<region>.amazonaws.com (also via AWS_ENDPOINT_URL_KMS).
+type AWSKMSKey struct {
+	KeyID           string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string
+	Client          *http.Client
+}
+
+// ID is the key's ID, ARN or alias.
+func (k *AWSKMSKey) ID() string { return "awskms:" + k.KeyID }
+
+// Wrap encrypts dataKey with the KMS key.
+func (k *AWSKMSKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	var out struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	err := k.call(ctx, "Encrypt", map[string]any{"KeyId": k.KeyID, "Plaintext": dataKey}, &out)
+	return out.CiphertextBlob, err
+}
+
+// Unwrap decrypts a data key wrapped by Wrap.
+func (k *AWSKMSKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var out struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	err := k.call(ctx, "Decrypt", map[string]any{"KeyId": k.KeyID, "CiphertextBlob": wrapped}, &out)
+	return out.Plaintext, err
+}
+
+// call invokes a KMS action. []byte fields travel base64-encoded, as KMS
+// expects.
+func (k *AWSKMSKey) call(ctx context.Context, action string, in map[string]any, out any) error {
+	region := k.Region
+	if strings.HasPrefix(k.KeyID, "arn:") {
+		if parts := strings.Split(k.KeyID, ":"); len(parts) > 3 && parts[3] != "" {
+			region = parts[3]
+		}
+	}
+	region = firstNonEmpty(region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return errors.New("AWS region is not set")
+	}
+	keyID := firstNonEmpty(k.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secret := firstNonEmpty(k.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if keyID == "" || secret == "" {
+		return errors.New("AWS credentials are not set")
+	}
+	token := firstNonEmpty(k.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))
+	endpoint := strings.TrimRight(firstNonEmpty(k.Endpoint, os.Getenv("AWS_ENDPOINT_URL_KMS"),
+		"https://kms."+region+".amazonaws.com"), "/")
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	awssig.Sign(req, body, keyID, secret, region, "kms", time.Now().UTC())
+
+	client := k.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("KMS %s: status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("KMS %s: %v", action, err)
+	}
+	return nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
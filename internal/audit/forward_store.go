@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SequencedEvent pairs an audit event with its insertion sequence number
+// (the audit_events.id column). The sequence is monotonic in insertion order,
+// which is also hash-chain order, so it is a safe resume point for consumers
+// that must neither skip nor repeat events across restarts.
+type SequencedEvent struct {
+	Seq   int64
+	Event Event
+	Raw   json.RawMessage
+}
+
+// EventsAfter returns up to limit events whose sequence number is strictly
+// greater than afterSeq, ordered by sequence ascending.
+func (s *Store) EventsAfter(ctx context.Context, afterSeq int64, limit int) ([]SequencedEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres,
+		`SELECT id, raw_json FROM audit_events WHERE id > ? ORDER BY id ASC LIMIT ?`),
+		afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query events after %d: %w", afterSeq, err)
+	}
+	defer rows.Close()
+
+	var out []SequencedEvent
+	for rows.Next() {
+		var se SequencedEvent
+		var raw string
+		if err := rows.Scan(&se.Seq, &raw); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		if err := json.Unmarshal([]byte(raw), &se.Event); err != nil {
+			return nil, fmt.Errorf("unmarshal event %d: %w", se.Seq, err)
+		}
+		se.Raw = json.RawMessage(raw)
+		out = append(out, se)
+	}
+	return out, rows.Err()
+}
+
+// ForwardCursorStore persists per-sink delivery cursors for the event
+// forwarders (SIEM, event bus). Each cursor records the last audit_events.id
+// successfully delivered to that sink. It shares the same *sql.DB connection
+// as the audit Store.
+type ForwardCursorStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewForwardCursorStore creates the forward_cursors table (if absent) and
+// returns a ready-to-use ForwardCursorStore.
+func NewForwardCursorStore(db *sql.DB, isPostgres bool) (*ForwardCursorStore, error) {
+	s := &ForwardCursorStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS forward_cursors (
+    sink       TEXT    NOT NULL PRIMARY KEY,
+    last_seq   INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT    NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create forward_cursors schema: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the last delivered sequence number for sink, or 0 when the sink
+// has never delivered anything.
+func (s *ForwardCursorStore) Get(ctx context.Context, sink string) (int64, error) {
+	var seq int64
+	err := s.db.QueryRowContext(ctx, rebind(s.isPostgres,
+		`SELECT last_seq FROM forward_cursors WHERE sink = ?`), sink).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// Set records seq as the last delivered sequence number for sink.
+func (s *ForwardCursorStore) Set(ctx context.Context, sink string, seq int64) error {
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO forward_cursors (sink, last_seq, updated_at) VALUES (?, ?, ?)
+ON CONFLICT(sink) DO UPDATE SET last_seq = excluded.last_seq, updated_at = excluded.updated_at`),
+		sink, seq, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func newForwardTestStores(t *testing.T) (*Store, *ForwardCursorStore) {
+	t.Helper()
+	store, err := NewStore(StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cs, err := NewForwardCursorStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewForwardCursorStore: %v", err)
+	}
+	return store, cs
+}
+
+func TestStore_EventsAfter(t *testing.T) {
+	store, _ := newForwardTestStores(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		ev := &Event{EventID: fmt.Sprintf("evt_%d", i), EventType: EventTypeToolExecution, Session: Session{ID: "s1"}}
+		if err := store.Record(ctx, ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	first, err := store.EventsAfter(ctx, 0, 3)
+	if err != nil {
+		t.Fatalf("EventsAfter: %v", err)
+	}
+	if len(first) != 3 {
+		t.Fatalf("got %d events, want 3", len(first))
+	}
+	if first[0].Event.EventID != "evt_0" || first[2].Event.EventID != "evt_2" {
+		t.Errorf("unexpected order: %s..%s", first[0].Event.EventID, first[2].Event.EventID)
+	}
+	if len(first[0].Raw) == 0 {
+		t.Error("expected raw JSON to be populated")
+	}
+
+	rest, err := store.EventsAfter(ctx, first[2].Seq, 10)
+	if err != nil {
+		t.Fatalf("EventsAfter: %v", err)
+	}
+	if len(rest) != 2 || rest[0].Event.EventID != "evt_3" {
+		t.Fatalf("got %d events starting at %v, want evt_3..evt_4", len(rest), rest)
+	}
+}
+
+func TestForwardCursorStore_GetSet(t *testing.T) {
+	_, cs := newForwardTestStores(t)
+	ctx := context.Background()
+
+	seq, err := cs.Get(ctx, "splunk")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if seq != 0 {
+		t.Errorf("initial cursor = %d, want 0", seq)
+	}
+
+	if err := cs.Set(ctx, "splunk", 42); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cs.Set(ctx, "splunk", 57); err != nil {
+		t.Fatalf("Set (update): %v", err)
+	}
+	if seq, _ := cs.Get(ctx, "splunk"); seq != 57 {
+		t.Errorf("cursor = %d, want 57", seq)
+	}
+	if seq, _ := cs.Get(ctx, "elastic"); seq != 0 {
+		t.Errorf("independent sink cursor = %d, want 0", seq)
+	}
+}
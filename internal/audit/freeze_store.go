@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FreezeState is the fleet-wide emergency read-only switch. While Frozen is
+// true every write and destructive tool call is denied by policy checks,
+// regardless of what the policy file would otherwise allow.
+type FreezeState struct {
+	Frozen    bool      `json:"frozen"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at,omitempty"`
+}
+
+// FreezeStore persists the emergency freeze state in a single-row table so
+// that a freeze survives auditd restarts. It shares the same *sql.DB
+// connection as the audit Store.
+type FreezeStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewFreezeStore creates the emergency_freeze table (if absent) and returns a
+// ready-to-use FreezeStore.
+func NewFreezeStore(db *sql.DB, isPostgres bool) (*FreezeStore, error) {
+	s := &FreezeStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS emergency_freeze (
+    id         INTEGER NOT NULL PRIMARY KEY,
+    frozen     INTEGER NOT NULL DEFAULT 0,
+    reason     TEXT    NOT NULL DEFAULT '',
+    changed_by TEXT    NOT NULL DEFAULT '',
+    changed_at TEXT    NOT NULL DEFAULT ''
+)`); err != nil {
+		return nil, fmt.Errorf("create emergency_freeze schema: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the current freeze state. A store that has never been frozen
+// returns the zero FreezeState.
+func (s *FreezeStore) Get(ctx context.Context) (FreezeState, error) {
+	var st FreezeState
+	var frozen int
+	var changedAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT frozen, reason, changed_by, changed_at FROM emergency_freeze WHERE id = 1`).
+		Scan(&frozen, &st.Reason, &st.ChangedBy, &changedAt)
+	if err == sql.ErrNoRows {
+		return FreezeState{}, nil
+	}
+	if err != nil {
+		return FreezeState{}, fmt.Errorf("get freeze state: %w", err)
+	}
+	st.Frozen = frozen != 0
+	if changedAt != "" {
+		st.ChangedAt = parseFlexTime(changedAt)
+	}
+	return st, nil
+}
+
+// Set replaces the freeze state. ChangedAt defaults to now when zero.
+func (s *FreezeStore) Set(ctx context.Context, st FreezeState) error {
+	if st.ChangedAt.IsZero() {
+		st.ChangedAt = time.Now().UTC()
+	}
+	frozen := 0
+	if st.Frozen {
+		frozen = 1
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO emergency_freeze (id, frozen, reason, changed_by, changed_at) VALUES (1, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET frozen = excluded.frozen, reason = excluded.reason,
+    changed_by = excluded.changed_by, changed_at = excluded.changed_at`),
+		frozen, st.Reason, st.ChangedBy, st.ChangedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("set freeze state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreezeStore_GetSet(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	fs, err := NewFreezeStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewFreezeStore: %v", err)
+	}
+
+	st, err := fs.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get (empty): %v", err)
+	}
+	if st.Frozen {
+		t.Fatal("new store should not be frozen")
+	}
+
+	if err := fs.Set(ctx, FreezeState{Frozen: true, Reason: "incident 42", ChangedBy: "alice"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	st, err = fs.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !st.Frozen || st.Reason != "incident 42" || st.ChangedBy != "alice" || st.ChangedAt.IsZero() {
+		t.Errorf("got %+v", st)
+	}
+
+	if err := fs.Set(ctx, FreezeState{Frozen: false, ChangedBy: "bob"}); err != nil {
+		t.Fatalf("Set (unfreeze): %v", err)
+	}
+	st, _ = fs.Get(ctx)
+	if st.Frozen || st.ChangedBy != "bob" {
+		t.Errorf("after unfreeze: %+v", st)
+	}
+
+	// Re-opening against the same DB keeps the state.
+	fs2, err := NewFreezeStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewFreezeStore (reopen): %v", err)
+	}
+	if st, _ := fs2.Get(ctx); st.ChangedBy != "bob" {
+		t.Errorf("state not persisted: %+v", st)
+	}
+}
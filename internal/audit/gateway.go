@@ -3,7 +3,9 @@ package audit
 import (
 	"context"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +16,58 @@ import (
 type GatewayAuditor struct {
 	auditor         Auditor
 	approvalManager *ApprovalManager
+	reads           *ReadAuditPolicy // nil = read requests are not recorded
+}
+
+// DefaultAlwaysAuditedReads are the gateway path prefixes whose reads are
+// recorded on every request regardless of the sample rate: reads of the audit
+// trail, approvals and policies themselves.
+var DefaultAlwaysAuditedReads = []string{"/api/v1/governance/"}
+
+// ReadAuditPolicy controls which gateway read (GET) requests are recorded.
+// Reads are not audited by default; they are far more frequent than actions.
+type ReadAuditPolicy struct {
+	// SampleRate is the fraction of ordinary reads recorded, from 0 to 1.
+	SampleRate float64
+	// Always lists path prefixes recorded on every request. When nil,
+	// DefaultAlwaysAuditedReads is used.
+	Always []string
+
+	sample func() float64 // for tests; default rand.Float64
+}
+
+// SetReadAuditPolicy enables auditing of read requests. A nil policy
+// disables it.
+func (a *GatewayAuditor) SetReadAuditPolicy(p *ReadAuditPolicy) {
+	a.reads = p
+}
+
+// ShouldRecordRead reports whether a read of path should be recorded under
+// the read audit policy.
+func (a *GatewayAuditor) ShouldRecordRead(path string) bool {
+	if a == nil || a.auditor == nil || a.reads == nil {
+		return false
+	}
+	always := a.reads.Always
+	if always == nil {
+		always = DefaultAlwaysAuditedReads
+	}
+	for _, prefix := range always {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	if a.reads.SampleRate >= 1 {
+		return true
+	}
+	if a.reads.SampleRate <= 0 {
+		return false
+	}
+	sample := a.reads.sample
+	if sample == nil {
+		sample = rand.Float64
+	}
+	return sample() < a.reads.SampleRate
 }
 
 // NewGatewayAuditor creates a new gateway auditor.
@@ -185,7 +239,7 @@ func categorizeAgent(agent string) RequestCategory {
 		return CategoryKubernetes
 	case "incident_agent":
 		return CategoryIncident
-	case "research_agent":
+	case "research_agent", "kb_agent":
 		return CategoryResearch
 	case "sysadmin_agent":
 		return CategorySysadmin
@@ -255,3 +309,11 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.status = code
 	r.ResponseWriter.WriteHeader(code)
 }
+
+// Flush passes through to the wrapped writer so streamed (SSE) responses
+// still reach the client as they are written.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
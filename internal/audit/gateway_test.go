@@ -104,6 +104,7 @@ func TestCategorizeAgent(t *testing.T) {
 		{"k8s_agent", CategoryKubernetes},
 		{"incident_agent", CategoryIncident},
 		{"research_agent", CategoryResearch},
+		{"kb_agent", CategoryResearch},
 		{"sysadmin_agent", CategorySysadmin},
 		{"unknown_agent", CategoryUnknown},
 		{"", CategoryUnknown},
@@ -321,3 +322,35 @@ func TestGatewayAuditor_RecordEvent_NilAuditor(t *testing.T) {
 		t.Errorf("expected nil error from no-op auditor, got %v", err)
 	}
 }
+
+func TestGatewayAuditor_ShouldRecordRead(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	defer store.Close()
+	a := NewGatewayAuditor(store)
+
+	if a.ShouldRecordRead("/api/v1/governance/events") {
+		t.Error("reads recorded without a read audit policy")
+	}
+
+	draw := 0.5
+	a.SetReadAuditPolicy(&ReadAuditPolicy{SampleRate: 0.25, sample: func() float64 { return draw }})
+	if !a.ShouldRecordRead("/api/v1/governance/approvals/pending") {
+		t.Error("governance read not recorded regardless of sampling")
+	}
+	if a.ShouldRecordRead("/api/v1/agents") {
+		t.Error("read recorded although the draw is above the sample rate")
+	}
+	draw = 0.1
+	if !a.ShouldRecordRead("/api/v1/agents") {
+		t.Error("read not recorded although the draw is below the sample rate")
+	}
+
+	noop := NewGatewayAuditor(nil)
+	noop.SetReadAuditPolicy(&ReadAuditPolicy{SampleRate: 1})
+	if noop.ShouldRecordRead("/api/v1/governance/events") {
+		t.Error("no-op auditor records reads")
+	}
+}
@@ -9,12 +9,13 @@ import (
 
 // GovbotRun represents one govbot compliance-run snapshot persisted to the
 // audit store. All govbot instances (across teams/gateways) can write to the
-// same database; the Gateway field distinguishes their origin.
+// same database; the Gateway and Tenant fields distinguish their origin.
 type GovbotRun struct {
 	ID                   int64     `json:"id,omitempty"`
 	RunAt                time.Time `json:"run_at"`
 	Window               string    `json:"window"`
 	Gateway              string    `json:"gateway"`
+	Tenant               string    `json:"tenant,omitempty"` // empty for fleet-wide (all-tenant) reports
 	Status               string    `json:"status"` // healthy | warnings | alerts
 	AlertCount           int       `json:"alert_count"`
 	WarningCount         int       `json:"warning_count"`
@@ -29,6 +30,19 @@ type GovbotRun struct {
 	StaleApprovals         int    `json:"stale_approvals"`
 	DecisionsByResource    string `json:"decisions_by_resource,omitempty"`
 	InvocationsByResource  string `json:"invocations_by_resource,omitempty"`
+	// Trend metrics. Runs recorded before these existed read back as zero.
+	UncontrolledTraces  int `json:"uncontrolled_traces"`
+	ToolExecutions      int `json:"tool_executions"`
+	ToolErrors          int `json:"tool_errors"`
+	ApprovalsResolved   int `json:"approvals_resolved"`
+	ApprovalLatencySecs int `json:"approval_latency_secs"` // mean request→resolution time of ApprovalsResolved
+}
+
+// GovbotTrendColumns are the govbot_runs columns holding GovbotRun's trend
+// metrics, in field order. govbot's local history database shares them.
+var GovbotTrendColumns = []string{
+	"uncontrolled_traces", "tool_executions", "tool_errors",
+	"approvals_resolved", "approval_latency_secs",
 }
 
 // GovbotStore persists GovbotRun snapshots. It shares the same *sql.DB
@@ -72,7 +86,13 @@ func (s *GovbotStore) createSchema() error {
     pending_approvals     INTEGER NOT NULL DEFAULT 0,
     stale_approvals       INTEGER NOT NULL DEFAULT 0,
     decisions_by_resource   TEXT,
-    invocations_by_resource TEXT
+    invocations_by_resource TEXT,
+    tenant                TEXT    NOT NULL DEFAULT '',
+    uncontrolled_traces   INTEGER NOT NULL DEFAULT 0,
+    tool_executions       INTEGER NOT NULL DEFAULT 0,
+    tool_errors           INTEGER NOT NULL DEFAULT 0,
+    approvals_resolved    INTEGER NOT NULL DEFAULT 0,
+    approval_latency_secs INTEGER NOT NULL DEFAULT 0
 )`, pk),
 		`CREATE INDEX IF NOT EXISTS idx_govbot_runs_run_at ON govbot_runs(run_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_govbot_runs_window  ON govbot_runs(window)`,
@@ -85,10 +105,21 @@ func (s *GovbotStore) createSchema() error {
 	}
 	// Migration: add invocations_by_resource to databases created before this
 	// column existed. SQLite returns an error on duplicate column; ignore it.
+	// The same applies to tenant, added for per-tenant reports, and to the
+	// trend metric columns.
 	if s.isPostgres {
 		s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN IF NOT EXISTS invocations_by_resource TEXT`) //nolint:errcheck
+		s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT ''`) //nolint:errcheck
 	} else {
 		s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN invocations_by_resource TEXT`) //nolint:errcheck
+		s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN tenant TEXT NOT NULL DEFAULT ''`) //nolint:errcheck
+	}
+	for _, col := range GovbotTrendColumns {
+		if s.isPostgres {
+			s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN IF NOT EXISTS ` + col + ` INTEGER NOT NULL DEFAULT 0`) //nolint:errcheck
+		} else {
+			s.db.Exec(`ALTER TABLE govbot_runs ADD COLUMN ` + col + ` INTEGER NOT NULL DEFAULT 0`) //nolint:errcheck
+		}
 	}
 	return nil
 }
@@ -107,8 +138,10 @@ func (s *GovbotStore) SaveRun(run GovbotRun) error {
 		 chain_valid, policy_denies, policy_no_match,
 		 mutations_total, mutations_destructive,
 		 pending_approvals, stale_approvals,
-		 decisions_by_resource, invocations_by_resource)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		 decisions_by_resource, invocations_by_resource, tenant,
+		 uncontrolled_traces, tool_executions, tool_errors,
+		 approvals_resolved, approval_latency_secs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	_, err := s.db.Exec(q,
 		run.RunAt.UTC().Format(time.RFC3339),
 		run.Window, run.Gateway, run.Status,
@@ -118,37 +151,42 @@ func (s *GovbotStore) SaveRun(run GovbotRun) error {
 		run.PolicyDenies, run.PolicyNoMatch,
 		run.MutationsTotal, run.MutationsDestructive,
 		run.PendingApprovals, run.StaleApprovals,
-		run.DecisionsByResource, run.InvocationsByResource,
+		run.DecisionsByResource, run.InvocationsByResource, run.Tenant,
+		run.UncontrolledTraces, run.ToolExecutions, run.ToolErrors,
+		run.ApprovalsResolved, run.ApprovalLatencySecs,
 	)
 	return err
 }
 
-// Prune deletes the oldest govbot_runs for the given gateway, keeping at most
-// retain rows. It is a no-op when retain ≤ 0 or gateway is empty.
-func (s *GovbotStore) Prune(gateway string, retain int) error {
+// Prune deletes the oldest govbot_runs for the given gateway and tenant,
+// keeping at most retain rows. It is a no-op when retain ≤ 0 or gateway is empty.
+func (s *GovbotStore) Prune(gateway, tenant string, retain int) error {
 	if retain <= 0 || gateway == "" {
 		return nil
 	}
 	q := rebind(s.isPostgres, `DELETE FROM govbot_runs
-		WHERE gateway = ?
+		WHERE gateway = ? AND tenant = ?
 		AND id NOT IN (
 			SELECT id FROM govbot_runs
-			WHERE gateway = ?
+			WHERE gateway = ? AND tenant = ?
 			ORDER BY run_at DESC LIMIT ?
 		)`)
-	_, err := s.db.Exec(q, gateway, gateway, retain)
+	_, err := s.db.Exec(q, gateway, tenant, gateway, tenant, retain)
 	return err
 }
 
 // RecentRuns returns the last limit runs, newest first. Pass window="" to
-// return runs across all windows. Pass gateway="" to return all gateways.
-func (s *GovbotStore) RecentRuns(window, gateway string, limit int) ([]GovbotRun, error) {
+// return runs across all windows, gateway="" to return all gateways and
+// tenant="" to return runs for every tenant.
+func (s *GovbotStore) RecentRuns(window, gateway, tenant string, limit int) ([]GovbotRun, error) {
 	cols := `id, run_at, window, gateway, status,
 		alert_count, warning_count, alerts_json, warnings_json,
 		chain_valid, policy_denies, policy_no_match,
 		mutations_total, mutations_destructive,
 		pending_approvals, stale_approvals,
-		decisions_by_resource, invocations_by_resource`
+		decisions_by_resource, invocations_by_resource, tenant,
+		uncontrolled_traces, tool_executions, tool_errors,
+		approvals_resolved, approval_latency_secs`
 
 	base := "SELECT " + cols + " FROM govbot_runs"
 	var where []string
@@ -161,6 +199,10 @@ func (s *GovbotStore) RecentRuns(window, gateway string, limit int) ([]GovbotRun
 		where = append(where, "gateway = ?")
 		args = append(args, gateway)
 	}
+	if tenant != "" {
+		where = append(where, "tenant = ?")
+		args = append(args, tenant)
+	}
 	q := base
 	if len(where) > 0 {
 		q += " WHERE " + where[0]
@@ -188,7 +230,9 @@ func (s *GovbotStore) RecentRuns(window, gateway string, limit int) ([]GovbotRun
 			&chainInt, &r.PolicyDenies, &r.PolicyNoMatch,
 			&r.MutationsTotal, &r.MutationsDestructive,
 			&r.PendingApprovals, &r.StaleApprovals,
-			&r.DecisionsByResource, &r.InvocationsByResource,
+			&r.DecisionsByResource, &r.InvocationsByResource, &r.Tenant,
+			&r.UncontrolledTraces, &r.ToolExecutions, &r.ToolErrors,
+			&r.ApprovalsResolved, &r.ApprovalLatencySecs,
 		); err != nil {
 			return nil, err
 		}
@@ -43,7 +43,7 @@ func TestGovbotStore_SaveAndRecentRuns(t *testing.T) {
 		}
 	}
 
-	runs, err := gs.RecentRuns("24h", "http://gw:8080", 10)
+	runs, err := gs.RecentRuns("24h", "http://gw:8080", "", 10)
 	if err != nil {
 		t.Fatalf("RecentRuns: %v", err)
 	}
@@ -58,6 +58,29 @@ func TestGovbotStore_SaveAndRecentRuns(t *testing.T) {
 	}
 }
 
+func TestGovbotStore_TrendMetrics(t *testing.T) {
+	gs := newGovbotTestStore(t)
+
+	r := makeRun("http://gw:8080", "warnings")
+	r.UncontrolledTraces = 1
+	r.ToolExecutions = 25
+	r.ToolErrors = 5
+	r.ApprovalsResolved = 2
+	r.ApprovalLatencySecs = 95
+	if err := gs.SaveRun(r); err != nil {
+		t.Fatalf("SaveRun: %v", err)
+	}
+	runs, err := gs.RecentRuns("24h", "", "", 1)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("RecentRuns: %v (%d runs)", err, len(runs))
+	}
+	got := runs[0]
+	if got.UncontrolledTraces != 1 || got.ToolExecutions != 25 || got.ToolErrors != 5 ||
+		got.ApprovalsResolved != 2 || got.ApprovalLatencySecs != 95 {
+		t.Errorf("trend metrics = %+v", got)
+	}
+}
+
 func TestGovbotStore_Prune(t *testing.T) {
 	gs := newGovbotTestStore(t)
 
@@ -73,11 +96,11 @@ func TestGovbotStore_Prune(t *testing.T) {
 		}
 	}
 
-	if err := gs.Prune(gw, retain); err != nil {
+	if err := gs.Prune(gw, "", retain); err != nil {
 		t.Fatalf("Prune: %v", err)
 	}
 
-	runs, err := gs.RecentRuns("24h", gw, 100)
+	runs, err := gs.RecentRuns("24h", gw, "", 100)
 	if err != nil {
 		t.Fatalf("RecentRuns: %v", err)
 	}
@@ -101,12 +124,12 @@ func TestGovbotStore_Prune_IsolatesGateway(t *testing.T) {
 	}
 
 	// Prune gw1 to 2; gw2 must be untouched
-	if err := gs.Prune(gw1, 2); err != nil {
+	if err := gs.Prune(gw1, "", 2); err != nil {
 		t.Fatalf("Prune: %v", err)
 	}
 
-	gw1Runs, _ := gs.RecentRuns("", gw1, 100)
-	gw2Runs, _ := gs.RecentRuns("", gw2, 100)
+	gw1Runs, _ := gs.RecentRuns("", gw1, "", 100)
+	gw2Runs, _ := gs.RecentRuns("", gw2, "", 100)
 
 	if len(gw1Runs) != 2 {
 		t.Errorf("gw1: expected 2 after prune, got %d", len(gw1Runs))
@@ -122,15 +145,15 @@ func TestGovbotStore_Prune_Noop(t *testing.T) {
 	gs.SaveRun(makeRun(gw, "healthy")) //nolint:errcheck
 
 	// retain=0 → no-op
-	if err := gs.Prune(gw, 0); err != nil {
+	if err := gs.Prune(gw, "", 0); err != nil {
 		t.Fatalf("Prune(retain=0): %v", err)
 	}
 	// empty gateway → no-op
-	if err := gs.Prune("", 5); err != nil {
+	if err := gs.Prune("", "", 5); err != nil {
 		t.Fatalf("Prune(gateway=''): %v", err)
 	}
 
-	runs, _ := gs.RecentRuns("", gw, 100)
+	runs, _ := gs.RecentRuns("", gw, "", 100)
 	if len(runs) != 1 {
 		t.Errorf("expected 1 run unchanged, got %d", len(runs))
 	}
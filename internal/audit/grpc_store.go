@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"helpdesk/internal/audit/auditpb"
+)
+
+// GRPCStore sends audit events to the central audit service over its gRPC
+// API (auditd -grpc-listen). All calls share one HTTP/2 connection, so a
+// busy agent pays neither a connection nor an HTTP/JSON request per event.
+// It implements the same interface as RemoteStore.
+type GRPCStore struct {
+	conn   *grpc.ClientConn
+	client auditpb.AuditServiceClient
+}
+
+// NewGRPCStore creates a gRPC client for the audit service at addr
+// (host:port). apiKey, when set, is sent as a Bearer token on every call,
+// as RemoteStore.WithAPIKey does, and so is the access token installed by
+// InstallAuditToken. Like the REST API, the connection is not
+// encrypted; run auditd behind a TLS-terminating proxy or on a trusted network.
+func NewGRPCStore(addr, apiKey string) (*GRPCStore, error) {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if apiKey != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerCredentials(apiKey)))
+	}
+	if token := auditToken(); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(auditTokenCredentials(token)))
+	}
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial audit service: %w", err)
+	}
+	return &GRPCStore{conn: conn, client: auditpb.NewAuditServiceClient(conn)}, nil
+}
+
+// bearerCredentials sends an API key in the authorization metadata, which
+// auditd passes to its identity provider as the Authorization header.
+type bearerCredentials string
+
+func (b bearerCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (bearerCredentials) RequireTransportSecurity() bool { return false }
+
+// auditTokenCredentials sends an access token in the x-audit-token metadata,
+// which auditd reads as the X-Audit-Token header.
+type auditTokenCredentials string
+
+func (t auditTokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-audit-token": string(t)}, nil
+}
+
+func (auditTokenCredentials) RequireTransportSecurity() bool { return false }
+
+// Client returns the generated client, for RPCs beyond the Auditor interface
+// (approvals, policy checks, event subscriptions).
+func (g *GRPCStore) Client() auditpb.AuditServiceClient { return g.client }
+
+// Record sends an event to the audit service and copies back the hash chain
+// fields it computed.
+func (g *GRPCStore) Record(ctx context.Context, event *Event) error {
+	stampTenant(ctx, event)
+	stampCorrelation(ctx, event)
+	stampTraceParent(ctx, event)
+	StampProducer(event)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	resp, err := g.client.RecordEvent(ctx, &auditpb.RecordEventRequest{EventJson: body})
+	if err != nil {
+		return fmt.Errorf("send event: %w", err)
+	}
+	event.EventID = resp.EventId
+	event.EventHash = resp.EventHash
+	event.PrevHash = resp.PrevHash
+	return nil
+}
+
+// RecordOutcome updates an event with its outcome.
+func (g *GRPCStore) RecordOutcome(ctx context.Context, eventID string, outcome *Outcome) error {
+	_, err := g.client.RecordOutcome(ctx, &auditpb.RecordOutcomeRequest{
+		EventId:      eventID,
+		Status:       outcome.Status,
+		ErrorMessage: outcome.ErrorMessage,
+		Duration:     durationpb.New(outcome.Duration),
+	})
+	if err != nil {
+		return fmt.Errorf("send outcome: %w", err)
+	}
+	return nil
+}
+
+// Query retrieves events from the audit service.
+func (g *GRPCStore) Query(ctx context.Context, opts QueryOptions) ([]Event, error) {
+	req := &auditpb.QueryEventsRequest{
+		SessionId:     opts.SessionID,
+		TraceId:       opts.TraceID,
+		TraceIdPrefix: opts.TraceIDPrefix,
+		Agent:         opts.Agent,
+		ActionClass:   string(opts.ActionClass),
+		OutcomeStatus: opts.OutcomeStatus,
+		Origin:        opts.Origin,
+		ToolName:      opts.ToolName,
+		TenantId:      opts.TenantID,
+		Limit:         int32(opts.Limit),
+	}
+	if opts.EventType != "" {
+		req.EventTypes = []string{string(opts.EventType)}
+	} else {
+		for _, t := range opts.EventTypes {
+			req.EventTypes = append(req.EventTypes, string(t))
+		}
+	}
+	if !opts.Since.IsZero() {
+		req.Since = timestamppb.New(opts.Since)
+	}
+
+	stream, err := g.client.QueryEvents(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	var events []Event
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query events: %w", err)
+		}
+		var ev Event
+		if err := json.Unmarshal(msg.EventJson, &ev); err != nil {
+			return nil, fmt.Errorf("decode event %s: %w", msg.EventId, err)
+		}
+		events = append(events, ev)
+	}
+}
+
+// Close closes the connection to the audit service.
+func (g *GRPCStore) Close() error {
+	return g.conn.Close()
+}
@@ -17,6 +17,11 @@ const GenesisHash = "00000000000000000000000000000000000000000000000000000000000
 // The hash is computed over the canonical JSON representation of the event,
 // excluding the EventHash field itself (to avoid circular dependency).
 func ComputeEventHash(event *Event) string {
+	var receivedAt string
+	if event.ReceivedAt != nil {
+		receivedAt = event.ReceivedAt.Format("2006-01-02T15:04:05.999999999Z07:00")
+	}
+
 	// Create a copy without the hash field for consistent hashing
 	hashInput := struct {
 		EventID     string      `json:"event_id"`
@@ -24,8 +29,13 @@ func ComputeEventHash(event *Event) string {
 		EventType   EventType   `json:"event_type"`
 		TraceID     string      `json:"trace_id,omitempty"`
 		ParentID    string      `json:"parent_id,omitempty"`
+		Correlation string      `json:"correlation_id,omitempty"`
+		TraceParent string      `json:"traceparent,omitempty"`
+		ReceivedAt  string      `json:"received_at,omitempty"`
+		Producer    *Producer   `json:"producer,omitempty"`
 		ActionClass ActionClass `json:"action_class,omitempty"`
 		PrevHash    string      `json:"prev_hash,omitempty"`
+		Segment     string      `json:"chain_segment,omitempty"`
 		Session     Session     `json:"session"`
 		Input       Input       `json:"input"`
 		Output      *Output     `json:"output,omitempty"`
@@ -33,14 +43,25 @@ func ComputeEventHash(event *Event) string {
 		Approval    *Approval   `json:"approval,omitempty"`
 		Decision    *Decision   `json:"decision,omitempty"`
 		Outcome     *Outcome    `json:"outcome,omitempty"`
+		Injection   *InjectionRisk `json:"injection_risk,omitempty"`
+		Redaction   *Redaction  `json:"redaction,omitempty"`
+		Config      *ConfigChange  `json:"config_change,omitempty"`
+		Team        string         `json:"team,omitempty"`
+		Drift       *InventoryDrift `json:"inventory_drift,omitempty"`
+		SLABreach   *ApprovalSLABreach `json:"approval_sla_breach,omitempty"`
 	}{
 		EventID:     event.EventID,
 		Timestamp:   event.Timestamp.Format("2006-01-02T15:04:05.999999999Z07:00"),
 		EventType:   event.EventType,
 		TraceID:     event.TraceID,
 		ParentID:    event.ParentID,
+		Correlation: event.CorrelationID,
+		TraceParent: event.TraceParent,
+		ReceivedAt:  receivedAt,
+		Producer:    event.Producer,
 		ActionClass: event.ActionClass,
 		PrevHash:    event.PrevHash,
+		Segment:     event.ChainSegment,
 		Session:     event.Session,
 		Input:       event.Input,
 		Output:      event.Output,
@@ -48,6 +69,12 @@ func ComputeEventHash(event *Event) string {
 		Approval:    event.Approval,
 		Decision:    event.Decision,
 		Outcome:     event.Outcome,
+		Injection:   event.InjectionRisk,
+		Redaction:   event.Redaction,
+		Config:      event.ConfigChange,
+		Team:        event.Team,
+		Drift:       event.InventoryDrift,
+		SLABreach:   event.ApprovalSLABreach,
 	}
 
 	data, err := json.Marshal(hashInput)
@@ -73,41 +100,70 @@ func VerifyEventHash(event *Event) bool {
 // Events must be in chronological order.
 // Returns the index of the first broken link, or -1 if chain is valid.
 func VerifyChain(events []Event) (int, error) {
-	if len(events) == 0 {
-		return -1, nil
-	}
+	return verifyChainFrom(events, GenesisHash, nil)
+}
 
+// verifyChainFrom verifies events that continue a chain whose previous hash is
+// prev. With prev == GenesisHash the first event must start a chain. An event
+// rewritten by a redaction keeps its original hash and verifies against one
+// of its tombstones instead.
+func verifyChainFrom(events []Event, prev string, tombstones map[string][]Tombstone) (int, error) {
 	for i, event := range events {
 		// Verify event's own hash
-		if event.EventHash != "" && !VerifyEventHash(&event) {
+		if event.EventHash != "" && !VerifyEventHash(&event) && !redactedHashValid(&event, tombstones[event.EventID]) {
 			return i, fmt.Errorf("event %s has invalid hash", event.EventID)
 		}
 
-		// Verify chain link (PrevHash matches previous event's hash)
+		expectedPrevHash := prev
 		if i > 0 {
+			// Verify chain link (PrevHash matches previous event's hash)
 			prevEvent := events[i-1]
-			expectedPrevHash := prevEvent.EventHash
+			expectedPrevHash = prevEvent.EventHash
 			if expectedPrevHash == "" {
 				// Compute hash for legacy events
 				expectedPrevHash = ComputeEventHash(&prevEvent)
 			}
-
-			if event.PrevHash != "" && event.PrevHash != expectedPrevHash {
-				return i, fmt.Errorf("event %s has broken chain link: prev_hash=%s, expected=%s",
-					event.EventID, event.PrevHash[:16]+"...", expectedPrevHash[:16]+"...")
-			}
-		} else {
+		} else if prev == GenesisHash {
 			// First event should have genesis hash or empty
 			if event.PrevHash != "" && event.PrevHash != GenesisHash {
 				return i, fmt.Errorf("first event %s has invalid prev_hash (expected genesis or empty)",
 					event.EventID)
 			}
+			continue
+		}
+
+		if event.PrevHash != "" && event.PrevHash != expectedPrevHash {
+			return i, fmt.Errorf("event %s has broken chain link: prev_hash=%s, expected=%s",
+				event.EventID, shortHash(event.PrevHash), shortHash(expectedPrevHash))
 		}
 	}
 
 	return -1, nil
 }
 
+// redactedHashValid reports whether event is a rewrite vouched for by one of
+// its tombstones.
+func redactedHashValid(event *Event, tombstones []Tombstone) bool {
+	if len(tombstones) == 0 {
+		return false
+	}
+	computed := ComputeEventHash(event)
+	for _, t := range tombstones {
+		if t.OriginalHash == event.EventHash && t.RedactedHash == computed {
+			return true
+		}
+	}
+	return false
+}
+
+// shortHash abbreviates a hash for error messages.
+func shortHash(h string) string {
+	if len(h) > 16 {
+		return h[:16] + "..."
+	}
+	return h
+}
+
 // ChainStatus represents the integrity status of the audit chain.
 type ChainStatus struct {
 	Valid        bool   `json:"valid"`
@@ -119,6 +175,13 @@ type ChainStatus struct {
 	FirstEventID string `json:"first_event_id,omitempty"`
 	LastEventID  string `json:"last_event_id,omitempty"`
 	LastHash     string `json:"last_hash,omitempty"`
+
+	// Segment-level detail, set by Store.VerifySegments.
+	Incremental      bool   `json:"incremental,omitempty"`       // only events since each segment's checkpoint were re-hashed
+	Segments         int    `json:"segments,omitempty"`          // chain segments checked
+	BrokenSegment    string `json:"broken_segment,omitempty"`    // segment holding the first break (BrokenAt indexes into it)
+	VerifiedEvents   int    `json:"verified_events,omitempty"`   // events re-hashed in this pass (< TotalEvents when incremental)
+	UnsignedSegments int    `json:"unsigned_segments,omitempty"` // index entries not checked against a chain key
 }
 
 // VerifyChainStatus performs a full chain verification and returns status.
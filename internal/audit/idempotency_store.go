@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyHeader carries a client-chosen key on POST /v1/events and
+// POST /v1/approvals. Requests repeated with the same key within auditd's
+// dedup window get the first response back instead of recording twice.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyPendingTimeout is how long a claimed key may stay without a
+// response before another request can take it over (auditd died mid-request).
+const idempotencyPendingTimeout = time.Minute
+
+// IdempotencyRecord is a key claimed by a request and, once that request
+// succeeded, the response it got. Status is 0 while the request is running.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	Status      int
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Pending reports whether the request that claimed the key is still running.
+func (r *IdempotencyRecord) Pending() bool { return r.Status == 0 }
+
+// IdempotencyStore persists idempotency keys so retried creates are
+// deduplicated across auditd restarts. It shares the same *sql.DB connection
+// as the audit Store.
+type IdempotencyStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewIdempotencyStore creates the idempotency_keys table (if absent) and
+// returns a ready-to-use IdempotencyStore.
+func NewIdempotencyStore(db *sql.DB, isPostgres bool) (*IdempotencyStore, error) {
+	s := &IdempotencyStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope        TEXT    NOT NULL,
+    idem_key     TEXT    NOT NULL,
+    request_hash TEXT    NOT NULL,
+    status       INTEGER NOT NULL DEFAULT 0,
+    body         TEXT    NOT NULL DEFAULT '',
+    created_at   TEXT    NOT NULL,
+    PRIMARY KEY (scope, idem_key)
+)`); err != nil {
+		return nil, fmt.Errorf("create idempotency_keys schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_idempotency_created ON idempotency_keys(created_at)`); err != nil {
+		return nil, fmt.Errorf("create idempotency_keys index: %w", err)
+	}
+	return s, nil
+}
+
+// Claim reserves key within scope for a request whose body hashes to
+// requestHash. It returns true when the caller now owns the key and should
+// run the request, then Complete or Release it. Otherwise it returns the
+// record already holding the key: a pending one, or a completed one whose
+// response should be replayed. Keys older than window, and pending keys
+// abandoned for longer than a minute, are dropped first.
+func (s *IdempotencyStore) Claim(ctx context.Context, scope, key, requestHash string, window time.Duration) (*IdempotencyRecord, bool, error) {
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+DELETE FROM idempotency_keys WHERE scope = ? AND idem_key = ?
+    AND (created_at < ? OR (status = 0 AND created_at < ?))`),
+		scope, key,
+		now.Add(-window).Format(sqliteTimeFormat),
+		now.Add(-idempotencyPendingTimeout).Format(sqliteTimeFormat)); err != nil {
+		return nil, false, fmt.Errorf("expire idempotency key: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO idempotency_keys (scope, idem_key, request_hash, status, body, created_at)
+VALUES (?, ?, ?, 0, '', ?)
+ON CONFLICT (scope, idem_key) DO NOTHING`),
+		scope, key, requestHash, now.Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, false, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, true, nil
+	}
+
+	rec := &IdempotencyRecord{Scope: scope, Key: key}
+	var body, createdAt string
+	err = s.db.QueryRowContext(ctx, rebind(s.isPostgres, `
+SELECT request_hash, status, body, created_at FROM idempotency_keys WHERE scope = ? AND idem_key = ?`),
+		scope, key).Scan(&rec.RequestHash, &rec.Status, &body, &createdAt)
+	if err == sql.ErrNoRows {
+		// Released between the insert and the lookup; let the caller retry.
+		return &IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash}, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get idempotency key: %w", err)
+	}
+	rec.Body = []byte(body)
+	rec.CreatedAt = parseFlexTime(createdAt)
+	return rec, false, nil
+}
+
+// Complete saves the response of the request that claimed key.
+func (s *IdempotencyStore) Complete(ctx context.Context, scope, key string, status int, body []byte) error {
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE idempotency_keys SET status = ?, body = ? WHERE scope = ? AND idem_key = ?`),
+		status, string(body), scope, key)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release gives up a claimed key after the request failed, so a retry with
+// the same key runs again.
+func (s *IdempotencyStore) Release(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+DELETE FROM idempotency_keys WHERE scope = ? AND idem_key = ?`), scope, key)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes keys created before cutoff and returns how many it removed.
+func (s *IdempotencyStore) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+DELETE FROM idempotency_keys WHERE created_at < ?`), cutoff.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
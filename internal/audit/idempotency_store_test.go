@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestIdempotencyStore(t *testing.T) *IdempotencyStore {
+	t.Helper()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	is, err := NewIdempotencyStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewIdempotencyStore: %v", err)
+	}
+	return is
+}
+
+func TestIdempotencyStore_ClaimCompleteReplay(t *testing.T) {
+	ctx := context.Background()
+	is := newTestIdempotencyStore(t)
+
+	if _, claimed, err := is.Claim(ctx, "POST /v1/events", "k1", "h1", time.Hour); err != nil || !claimed {
+		t.Fatalf("first Claim = %v, %v; want claimed", claimed, err)
+	}
+	rec, claimed, err := is.Claim(ctx, "POST /v1/events", "k1", "h1", time.Hour)
+	if err != nil || claimed || !rec.Pending() {
+		t.Fatalf("Claim while running = %+v, %v, %v; want pending", rec, claimed, err)
+	}
+
+	if err := is.Complete(ctx, "POST /v1/events", "k1", 200, []byte(`{"event_id":"evt_1"}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	rec, claimed, err = is.Claim(ctx, "POST /v1/events", "k1", "h1", time.Hour)
+	if err != nil || claimed || rec.Status != 200 || string(rec.Body) != `{"event_id":"evt_1"}` || rec.RequestHash != "h1" {
+		t.Fatalf("Claim after Complete = %+v, %v, %v; want the saved response", rec, claimed, err)
+	}
+
+	// Keys are per scope.
+	if _, claimed, _ := is.Claim(ctx, "POST /v1/approvals", "k1", "h1", time.Hour); !claimed {
+		t.Error("same key in another scope was not claimable")
+	}
+}
+
+func TestIdempotencyStore_ReleaseAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	is := newTestIdempotencyStore(t)
+
+	is.Claim(ctx, "s", "failed", "h", time.Hour) //nolint:errcheck
+	if err := is.Release(ctx, "s", "failed"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, claimed, _ := is.Claim(ctx, "s", "failed", "h", time.Hour); !claimed {
+		t.Error("released key was not claimable")
+	}
+
+	is.Claim(ctx, "s", "old", "h", time.Hour)       //nolint:errcheck
+	is.Complete(ctx, "s", "old", 200, []byte("{}")) //nolint:errcheck
+	time.Sleep(5 * time.Millisecond)
+	if _, claimed, _ := is.Claim(ctx, "s", "old", "h2", time.Millisecond); !claimed {
+		t.Error("key older than the window was not claimable")
+	}
+
+	n, err := is.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil || n != 2 {
+		t.Errorf("Purge = %d, %v; want 2", n, err)
+	}
+}
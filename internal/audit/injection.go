@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// InjectionRisk is a heuristic prompt-injection score of the text an event
+// carries: the user's query and the tool output that is fed back to the LLM.
+// It is attached by the audit store when the event is recorded, and only
+// when something was found.
+type InjectionRisk struct {
+	// Score is the overall risk from 0.0 (nothing found) to 1.0.
+	Score float64 `json:"score"`
+
+	// Markers name the heuristics that matched.
+	Markers []string `json:"markers,omitempty"`
+
+	// Sources are where the markers were found: "user_query" or "tool_output".
+	Sources []string `json:"sources,omitempty"`
+
+	// Classifier is the score of the optional classifier, when one is
+	// configured and answered.
+	Classifier *float64 `json:"classifier,omitempty"`
+}
+
+// Prompt-injection markers.
+const (
+	InjectionMarkerOverride      = "instruction_override"
+	InjectionMarkerRoleSpoof     = "role_spoof"
+	InjectionMarkerExfilURL      = "exfiltration_url"
+	InjectionMarkerBase64        = "base64_blob"
+	InjectionMarkerHiddenText    = "hidden_characters"
+	InjectionMarkerToolDirective = "tool_directive"
+)
+
+// injectionMarkerWeights is how much each marker adds to the score.
+var injectionMarkerWeights = map[string]float64{
+	InjectionMarkerOverride:      0.7,
+	InjectionMarkerRoleSpoof:     0.5,
+	InjectionMarkerExfilURL:      0.5,
+	InjectionMarkerToolDirective: 0.4,
+	InjectionMarkerBase64:        0.3,
+	InjectionMarkerHiddenText:    0.3,
+}
+
+var (
+	// overrideRe matches attempts to replace the model's instructions.
+	overrideRe = regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[^.\n]{0,40}\b(previous|prior|above|earlier|all|your|system)\b[^.\n]{0,20}\b(instructions?|prompts?|rules|directives|guidelines)\b|\byou are now\b|\bnew instructions\s*:`)
+
+	// roleSpoofRe matches chat-template and role markers smuggled into text.
+	roleSpoofRe = regexp.MustCompile(`(?i)<\|(im_start|im_end|system|assistant)\|>|\[/?(INST|SYS)\]|<</?SYS>>|(^|\n)\s*(system|assistant)\s*:`)
+
+	// toolDirectiveRe matches text telling the agent which tool to call next.
+	toolDirectiveRe = regexp.MustCompile(`(?i)\b(call|invoke|run|execute|use)\s+(the\s+)?(tool|function)\b|\b(terminate|drop|delete|grant|revoke)\b[^.\n]{0,40}\bimmediately\b`)
+
+	// urlRe finds http(s) URLs.
+	urlRe = regexp.MustCompile(`(?i)https?://[^\s"'<>)\]]+`)
+
+	// exfilHostRe matches hosts commonly used to receive exfiltrated data.
+	exfilHostRe = regexp.MustCompile(`(?i)(webhook\.site|requestbin|pipedream\.net|ngrok(-free)?\.(io|app)|burpcollaborator\.net|interact\.sh|oast\.(fun|pro|live|site)|pastebin\.com|transfer\.sh)`)
+
+	// exfilParamRe matches query parameters that carry data out.
+	exfilParamRe = regexp.MustCompile(`(?i)[?&](data|d|q|payload|secret|token|key|password|exfil|leak)=[^&\s]{16,}`)
+
+	// markdownImageRe matches markdown images, which a renderer fetches
+	// without a click.
+	markdownImageRe = regexp.MustCompile(`!\[[^\]]*\]\(\s*https?://`)
+
+	// base64Re matches long base64 runs.
+	base64Re = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+)
+
+// ScoreInjection rates the prompt-injection risk of an event's user query
+// and tool output. It returns nil when no marker matched.
+func ScoreInjection(userQuery, toolOutput string) *InjectionRisk {
+	risk := &InjectionRisk{}
+	seen := make(map[string]bool)
+	for _, src := range []struct{ name, text string }{
+		{"user_query", userQuery},
+		{"tool_output", toolOutput},
+	} {
+		markers := injectionMarkers(src.text)
+		if len(markers) == 0 {
+			continue
+		}
+		risk.Sources = append(risk.Sources, src.name)
+		for _, m := range markers {
+			if !seen[m] {
+				seen[m] = true
+				risk.Markers = append(risk.Markers, m)
+			}
+		}
+	}
+	if len(risk.Markers) == 0 {
+		return nil
+	}
+	// Combine as independent evidence: 1 - Π(1 - w).
+	remaining := 1.0
+	for _, m := range risk.Markers {
+		remaining *= 1 - injectionMarkerWeights[m]
+	}
+	risk.Score = math.Round((1-remaining)*100) / 100
+	return risk
+}
+
+// injectionMarkers returns the markers that match text.
+func injectionMarkers(text string) []string {
+	if text == "" {
+		return nil
+	}
+	var out []string
+	if overrideRe.MatchString(text) {
+		out = append(out, InjectionMarkerOverride)
+	}
+	if roleSpoofRe.MatchString(text) {
+		out = append(out, InjectionMarkerRoleSpoof)
+	}
+	if hasExfilURL(text) {
+		out = append(out, InjectionMarkerExfilURL)
+	}
+	if toolDirectiveRe.MatchString(text) {
+		out = append(out, InjectionMarkerToolDirective)
+	}
+	if hasEncodedText(text) {
+		out = append(out, InjectionMarkerBase64)
+	}
+	if hasHiddenCharacters(text) {
+		out = append(out, InjectionMarkerHiddenText)
+	}
+	return out
+}
+
+// hasExfilURL reports a URL that points at a data-collection host, carries a
+// long value in a query parameter, or is embedded as a markdown image.
+func hasExfilURL(text string) bool {
+	if markdownImageRe.MatchString(text) {
+		return true
+	}
+	for _, u := range urlRe.FindAllString(text, -1) {
+		if exfilHostRe.MatchString(u) || exfilParamRe.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEncodedText reports a base64 run that decodes to readable text: a way
+// to smuggle instructions past a human reviewer. Binary payloads such as
+// hashes or certificates decode to non-text and are ignored.
+func hasEncodedText(text string) bool {
+	for _, run := range base64Re.FindAllString(text, 5) {
+		data, err := base64.StdEncoding.DecodeString(run)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(run, "="))
+			if err != nil {
+				continue
+			}
+		}
+		printable := 0
+		for _, b := range data {
+			if b == '\n' || b == '\t' || (b >= 0x20 && b < 0x7f) {
+				printable++
+			}
+		}
+		if len(data) > 0 && float64(printable)/float64(len(data)) > 0.95 && bytes.ContainsRune(data, ' ') {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHiddenCharacters reports zero-width and bidirectional-control
+// characters, used to hide instructions in otherwise harmless text.
+func hasHiddenCharacters(text string) bool {
+	for _, r := range text {
+		switch {
+		case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069, r == 0xFEFF:
+			return true
+		case r >= 0xE0000 && r <= 0xE007F: // Unicode tag characters
+			return true
+		}
+	}
+	return false
+}
+
+// injectionText returns the user query and tool output of an event to scan.
+func injectionText(e *Event) (userQuery, toolOutput string) {
+	userQuery = e.Input.UserQuery
+	if e.Tool != nil {
+		toolOutput = e.Tool.Result
+	}
+	if e.Output != nil && e.Output.Response != "" {
+		if toolOutput != "" {
+			toolOutput += "\n"
+		}
+		toolOutput += e.Output.Response
+	}
+	return userQuery, toolOutput
+}
+
+// InjectionClassifier scores text for prompt injection, from 0.0 to 1.0.
+type InjectionClassifier interface {
+	Classify(ctx context.Context, text string) (float64, error)
+}
+
+// HTTPInjectionClassifier calls a classifier service that accepts
+// {"text": "..."} and answers {"score": 0.0-1.0}.
+type HTTPInjectionClassifier struct {
+	URL    string
+	Client *http.Client // defaults to a client with a 2s timeout
+}
+
+// Classify implements InjectionClassifier.
+func (c *HTTPInjectionClassifier) Classify(ctx context.Context, text string) (float64, error) {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Second}
+	}
+	body, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build classifier request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("call injection classifier: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("injection classifier returned %s", resp.Status)
+	}
+	var out struct {
+		Score float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode classifier response: %w", err)
+	}
+	if out.Score < 0 || out.Score > 1 {
+		return 0, fmt.Errorf("injection classifier score %v out of range", out.Score)
+	}
+	return out.Score, nil
+}
+
+// scoreInjection scores an event with the heuristics and, when configured,
+// the classifier; the higher score wins. A classifier failure only costs the
+// event its classifier score.
+func (s *Store) scoreInjection(ctx context.Context, e *Event) *InjectionRisk {
+	userQuery, toolOutput := injectionText(e)
+	risk := ScoreInjection(userQuery, toolOutput)
+	if s.classifier == nil || (userQuery == "" && toolOutput == "") {
+		return risk
+	}
+	score, err := s.classifier.Classify(ctx, strings.TrimSpace(userQuery+"\n"+toolOutput))
+	if err != nil {
+		slog.Warn("injection classifier failed; using heuristics only", "event_id", e.EventID, "err", err)
+		return risk
+	}
+	if score == 0 && risk == nil {
+		return nil
+	}
+	if risk == nil {
+		risk = &InjectionRisk{}
+	}
+	risk.Classifier = &score
+	risk.Score = math.Max(risk.Score, score)
+	return risk
+}
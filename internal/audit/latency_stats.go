@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyOptions selects the window, tenant and agent for LatencyStats.
+type LatencyOptions struct {
+	Since    time.Time // inclusive lower bound on timestamp; zero = unbounded
+	Until    time.Time // exclusive upper bound on timestamp; zero = unbounded
+	TenantID string    // filter by tenant; empty = all tenants
+	Agent    string    // filter by agent; empty = all agents
+}
+
+// Percentiles summarises a set of durations, in milliseconds.
+type Percentiles struct {
+	Count int   `json:"count"`
+	P50Ms int64 `json:"p50_ms"`
+	P90Ms int64 `json:"p90_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+// AgentLatency is the latency of the requests one agent completed, overall
+// and per phase, from its delegation_outcome events.
+type AgentLatency struct {
+	Agent    string      `json:"agent"`
+	Total    Percentiles `json:"total"`
+	Queue    Percentiles `json:"queue"`
+	LLM      Percentiles `json:"llm"`
+	Tools    Percentiles `json:"tools"`
+	Approval Percentiles `json:"approval"`
+}
+
+// ToolLatency is the execution time of one agent's tool, from its
+// tool_execution events.
+type ToolLatency struct {
+	Agent string `json:"agent"`
+	Tool  string `json:"tool"`
+	Percentiles
+}
+
+// LatencyStats reports request and tool latency percentiles over a window.
+type LatencyStats struct {
+	Since  string         `json:"since,omitempty"`
+	Until  string         `json:"until,omitempty"`
+	Agents []AgentLatency `json:"agents"`
+	Tools  []ToolLatency  `json:"tools"`
+}
+
+// LatencyStats computes per-agent request latency, broken down by phase, and
+// per-tool execution latency. Agents are sorted by name and tools by agent,
+// then by p90 descending so the slowest tools come first.
+func (s *Store) LatencyStats(ctx context.Context, opts LatencyOptions) (LatencyStats, error) {
+	stats := LatencyStats{Agents: []AgentLatency{}, Tools: []ToolLatency{}}
+
+	where := " WHERE 1=1"
+	var args []any
+	if !opts.Since.IsZero() {
+		where += " AND timestamp >= ?"
+		args = append(args, opts.Since.UTC().Format(sqliteTimeFormat))
+		stats.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+	if !opts.Until.IsZero() {
+		where += " AND timestamp < ?"
+		args = append(args, opts.Until.UTC().Format(sqliteTimeFormat))
+		stats.Until = opts.Until.UTC().Format(time.RFC3339)
+	}
+	if opts.TenantID != "" {
+		where += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+
+	// Requests, from the phases recorded on delegation_outcome events.
+	agentWhere, agentArgs := where, args
+	if opts.Agent != "" {
+		agentWhere += " AND session_agent = ?"
+		agentArgs = append(append([]any{}, args...), opts.Agent)
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT COALESCE(session_agent, ''), raw_json FROM audit_events`+agentWhere+`
+		AND event_type = '`+string(EventTypeOutcome)+`'`), agentArgs...)
+	if err != nil {
+		return stats, fmt.Errorf("query request latency: %w", err)
+	}
+	type phaseSamples struct{ total, queue, llm, tools, approval []int64 }
+	byAgent := map[string]*phaseSamples{}
+	for rows.Next() {
+		var agent, raw string
+		if err := rows.Scan(&agent, &raw); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("scan request latency: %w", err)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil || e.Outcome == nil || e.Outcome.Phases == nil {
+			continue
+		}
+		ps := byAgent[agent]
+		if ps == nil {
+			ps = &phaseSamples{}
+			byAgent[agent] = ps
+		}
+		p := e.Outcome.Phases
+		ps.total = append(ps.total, e.Outcome.Duration.Milliseconds())
+		ps.queue = append(ps.queue, p.QueueMs)
+		ps.llm = append(ps.llm, p.LLMMs)
+		ps.tools = append(ps.tools, p.ToolsMs)
+		ps.approval = append(ps.approval, p.ApprovalMs)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("read request latency: %w", err)
+	}
+	for agent, ps := range byAgent {
+		stats.Agents = append(stats.Agents, AgentLatency{
+			Agent:    agent,
+			Total:    percentiles(ps.total),
+			Queue:    percentiles(ps.queue),
+			LLM:      percentiles(ps.llm),
+			Tools:    percentiles(ps.tools),
+			Approval: percentiles(ps.approval),
+		})
+	}
+	sort.Slice(stats.Agents, func(i, j int) bool { return stats.Agents[i].Agent < stats.Agents[j].Agent })
+
+	// Tools, from the duration recorded on tool_execution events.
+	toolWhere, toolArgs := where, args
+	if opts.Agent != "" {
+		toolWhere += " AND decision_agent = ?"
+		toolArgs = append(append([]any{}, args...), opts.Agent)
+	}
+	rows, err = s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT COALESCE(decision_agent, ''), COALESCE(tool_name, ''), COALESCE(outcome_duration_ms, 0) FROM audit_events`+toolWhere+`
+		AND event_type = '`+string(EventTypeToolExecution)+`'`), toolArgs...)
+	if err != nil {
+		return stats, fmt.Errorf("query tool latency: %w", err)
+	}
+	type toolKey struct{ agent, tool string }
+	byTool := map[toolKey][]int64{}
+	for rows.Next() {
+		var k toolKey
+		var ms int64
+		if err := rows.Scan(&k.agent, &k.tool, &ms); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("scan tool latency: %w", err)
+		}
+		byTool[k] = append(byTool[k], ms)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("read tool latency: %w", err)
+	}
+	for k, samples := range byTool {
+		stats.Tools = append(stats.Tools, ToolLatency{Agent: k.agent, Tool: k.tool, Percentiles: percentiles(samples)})
+	}
+	sort.Slice(stats.Tools, func(i, j int) bool {
+		a, b := stats.Tools[i], stats.Tools[j]
+		if a.Agent != b.Agent {
+			return a.Agent < b.Agent
+		}
+		if a.P90Ms != b.P90Ms {
+			return a.P90Ms > b.P90Ms
+		}
+		return a.Tool < b.Tool
+	})
+	return stats, nil
+}
+
+// percentiles returns nearest-rank percentiles of samples, which it sorts.
+func percentiles(samples []int64) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rank := func(p float64) int64 {
+		i := int(math.Ceil(p * float64(len(samples))))
+		return samples[max(i, 1)-1]
+	}
+	return Percentiles{
+		Count: len(samples),
+		P50Ms: rank(0.50),
+		P90Ms: rank(0.90),
+		P99Ms: rank(0.99),
+		MaxMs: samples[len(samples)-1],
+	}
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyStats_PhasesAndTools(t *testing.T) {
+	store := newShardedStore(t, ChainShardingNone, nil)
+	ctx := context.Background()
+
+	for i := 1; i <= 10; i++ {
+		if err := store.Record(ctx, &Event{
+			EventType: EventTypeOutcome,
+			Session:   Session{ID: "s", AgentName: "k8s_agent"},
+			Outcome: &Outcome{Status: "success", Duration: time.Duration(i) * time.Second, Phases: &PhaseTimings{
+				QueueMs: 10, LLMMs: int64(i) * 600, ToolsMs: int64(i) * 400, ApprovalMs: 0,
+			}},
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	for tool, ms := range map[string][]int64{"get_pods": {100, 200, 300}, "get_events": {50}} {
+		for _, d := range ms {
+			if err := store.Record(ctx, &Event{
+				EventType: EventTypeToolExecution,
+				Session:   Session{ID: "s"},
+				Tool:      &ToolExecution{Name: tool, Agent: "k8s_agent"},
+				Outcome:   &Outcome{Status: "success", Duration: time.Duration(d) * time.Millisecond},
+			}); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+	}
+	// Outcomes without phases (e.g. orchestrator delegations) are ignored.
+	if err := store.Record(ctx, &Event{
+		EventType: EventTypeOutcome, Session: Session{ID: "s", AgentName: "k8s_agent"},
+		Outcome: &Outcome{Status: "success", Duration: time.Hour},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	stats, err := store.LatencyStats(ctx, LatencyOptions{Since: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("LatencyStats: %v", err)
+	}
+	if len(stats.Agents) != 1 {
+		t.Fatalf("agents = %+v, want k8s_agent only", stats.Agents)
+	}
+	a := stats.Agents[0]
+	if a.Agent != "k8s_agent" || a.Total.Count != 10 || a.Total.P50Ms != 5000 || a.Total.P90Ms != 9000 || a.Total.MaxMs != 10000 {
+		t.Errorf("total = %+v", a.Total)
+	}
+	if a.LLM.P90Ms != 5400 || a.Tools.P50Ms != 2000 || a.Queue.MaxMs != 10 {
+		t.Errorf("phases: llm=%+v tools=%+v queue=%+v", a.LLM, a.Tools, a.Queue)
+	}
+	if len(stats.Tools) != 2 || stats.Tools[0].Tool != "get_pods" || stats.Tools[0].Count != 3 || stats.Tools[0].P50Ms != 200 || stats.Tools[1].Tool != "get_events" {
+		t.Errorf("tools = %+v, want get_pods (slowest) then get_events", stats.Tools)
+	}
+
+	stats, err = store.LatencyStats(ctx, LatencyOptions{Agent: "db_agent"})
+	if err != nil {
+		t.Fatalf("LatencyStats: %v", err)
+	}
+	if len(stats.Agents) != 0 || len(stats.Tools) != 0 {
+		t.Errorf("db_agent stats = %+v, want empty", stats)
+	}
+}
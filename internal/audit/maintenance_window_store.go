@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrMaintenanceWindowNotFound is returned when a maintenance window ID does
+// not exist.
+var ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+
+// MaintenanceWindow is a planned period of work on some resources. Alerts the
+// auditor raises inside an active window are downgraded and tagged with it,
+// and policies can treat requests inside a window differently (see the
+// maintenance_window policy condition).
+type MaintenanceWindow struct {
+	WindowID  string    `json:"window_id"`
+	Owner     string    `json:"owner"`               // who is doing the work and answers for it
+	Reason    string    `json:"reason"`              // e.g. a change ticket
+	Resources []string  `json:"resources,omitempty"` // database, namespace or host names; globs like prod-* allowed; empty = fleet-wide
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Alerts is the number of auditor alerts tagged with this window. It is
+	// only filled in by the list endpoint.
+	Alerts int `json:"alerts,omitempty"`
+}
+
+// Validate checks the fields a caller sets.
+func (mw *MaintenanceWindow) Validate() error {
+	if strings.TrimSpace(mw.Owner) == "" {
+		return fmt.Errorf("owner is required")
+	}
+	if strings.TrimSpace(mw.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if mw.StartsAt.IsZero() || mw.EndsAt.IsZero() {
+		return fmt.Errorf("starts_at and ends_at are required")
+	}
+	if !mw.EndsAt.After(mw.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	for _, r := range mw.Resources {
+		if r == "" {
+			return fmt.Errorf("resources must not contain empty entries")
+		}
+		if _, err := path.Match(r, ""); err != nil {
+			return fmt.Errorf("invalid resource pattern %q: %w", r, err)
+		}
+	}
+	return nil
+}
+
+// ActiveAt reports whether at falls inside the window.
+func (mw *MaintenanceWindow) ActiveAt(at time.Time) bool {
+	return !at.Before(mw.StartsAt) && at.Before(mw.EndsAt)
+}
+
+// Covers reports whether the window is active at at for resource. A
+// fleet-wide window covers every resource, including none; a scoped window
+// covers only the resources its patterns match.
+func (mw *MaintenanceWindow) Covers(resource string, at time.Time) bool {
+	if !mw.ActiveAt(at) {
+		return false
+	}
+	if len(mw.Resources) == 0 {
+		return true
+	}
+	for _, p := range mw.Resources {
+		if ok, err := path.Match(p, resource); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CoveringWindow returns the first of windows that covers resource at at,
+// or nil.
+func CoveringWindow(windows []MaintenanceWindow, resource string, at time.Time) *MaintenanceWindow {
+	for i := range windows {
+		if windows[i].Covers(resource, at) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// MaintenanceWindowStore persists maintenance windows. It shares the same
+// *sql.DB connection as the audit Store.
+type MaintenanceWindowStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewMaintenanceWindowStore creates the maintenance_windows table (if absent)
+// and returns a ready-to-use MaintenanceWindowStore.
+func NewMaintenanceWindowStore(db *sql.DB, isPostgres bool) (*MaintenanceWindowStore, error) {
+	s := &MaintenanceWindowStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS maintenance_windows (
+    window_id  TEXT NOT NULL PRIMARY KEY,
+    owner      TEXT NOT NULL,
+    reason     TEXT NOT NULL,
+    resources  TEXT NOT NULL DEFAULT '[]',
+    starts_at  TEXT NOT NULL,
+    ends_at    TEXT NOT NULL,
+    created_by TEXT NOT NULL DEFAULT '',
+    tenant_id  TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create maintenance_windows schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends
+    ON maintenance_windows(ends_at)`); err != nil {
+		return nil, fmt.Errorf("create maintenance_windows index: %w", err)
+	}
+	return s, nil
+}
+
+// Create stores a new window. The caller sets WindowID and CreatedBy.
+func (s *MaintenanceWindowStore) Create(ctx context.Context, mw *MaintenanceWindow) error {
+	if mw.WindowID == "" {
+		return fmt.Errorf("window_id is required")
+	}
+	if err := mw.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	mw.CreatedAt, mw.UpdatedAt = now, now
+	mw.StartsAt, mw.EndsAt = mw.StartsAt.UTC(), mw.EndsAt.UTC()
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO maintenance_windows
+    (window_id, owner, reason, resources, starts_at, ends_at, created_by, tenant_id, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		mw.WindowID, mw.Owner, mw.Reason, marshalStrings(mw.Resources),
+		mw.StartsAt.Format(annotationTimeFormat), mw.EndsAt.Format(annotationTimeFormat),
+		mw.CreatedBy, mw.TenantID, now.Format(annotationTimeFormat), now.Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("insert maintenance window: %w", err)
+	}
+	return nil
+}
+
+// Get returns one window.
+func (s *MaintenanceWindowStore) Get(ctx context.Context, id string) (*MaintenanceWindow, error) {
+	all, err := s.query(ctx, "WHERE window_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, ErrMaintenanceWindowNotFound
+	}
+	return &all[0], nil
+}
+
+// List returns the windows that overlap [since, until), earliest start
+// first. A zero since or until leaves that end of the range open.
+func (s *MaintenanceWindowStore) List(ctx context.Context, since, until time.Time) ([]MaintenanceWindow, error) {
+	where, args := "WHERE 1=1", []any{}
+	if !since.IsZero() {
+		where += " AND ends_at > ?"
+		args = append(args, since.UTC().Format(annotationTimeFormat))
+	}
+	if !until.IsZero() {
+		where += " AND starts_at < ?"
+		args = append(args, until.UTC().Format(annotationTimeFormat))
+	}
+	return s.query(ctx, where, args...)
+}
+
+// Update replaces a window's owner, reason, resources and times.
+func (s *MaintenanceWindowStore) Update(ctx context.Context, mw *MaintenanceWindow) error {
+	if err := mw.Validate(); err != nil {
+		return err
+	}
+	old, err := s.Get(ctx, mw.WindowID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	mw.CreatedBy, mw.TenantID, mw.CreatedAt, mw.UpdatedAt = old.CreatedBy, old.TenantID, old.CreatedAt, now
+	mw.StartsAt, mw.EndsAt = mw.StartsAt.UTC(), mw.EndsAt.UTC()
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE maintenance_windows
+SET owner = ?, reason = ?, resources = ?, starts_at = ?, ends_at = ?, updated_at = ?
+WHERE window_id = ?`),
+		mw.Owner, mw.Reason, marshalStrings(mw.Resources), mw.StartsAt.Format(annotationTimeFormat),
+		mw.EndsAt.Format(annotationTimeFormat), now.Format(annotationTimeFormat), mw.WindowID); err != nil {
+		return fmt.Errorf("update maintenance window: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a window.
+func (s *MaintenanceWindowStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `DELETE FROM maintenance_windows WHERE window_id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("delete maintenance window: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMaintenanceWindowNotFound
+	}
+	return nil
+}
+
+func (s *MaintenanceWindowStore) query(ctx context.Context, where string, args ...any) ([]MaintenanceWindow, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT window_id, owner, reason, resources, starts_at, ends_at, created_by, tenant_id, created_at, updated_at
+FROM maintenance_windows
+`+where+`
+ORDER BY starts_at, window_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	out := []MaintenanceWindow{}
+	for rows.Next() {
+		var mw MaintenanceWindow
+		var resources, startsAt, endsAt, createdAt, updatedAt string
+		if err := rows.Scan(&mw.WindowID, &mw.Owner, &mw.Reason, &resources, &startsAt, &endsAt,
+			&mw.CreatedBy, &mw.TenantID, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan maintenance window: %w", err)
+		}
+		json.Unmarshal([]byte(resources), &mw.Resources) //nolint:errcheck
+		if len(mw.Resources) == 0 {
+			mw.Resources = nil
+		}
+		mw.StartsAt = parseFlexTime(startsAt)
+		mw.EndsAt = parseFlexTime(endsAt)
+		mw.CreatedAt = parseFlexTime(createdAt)
+		mw.UpdatedAt = parseFlexTime(updatedAt)
+		out = append(out, mw)
+	}
+	return out, rows.Err()
+}
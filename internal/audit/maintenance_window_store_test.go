@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowStore_CRUD(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ms, err := NewMaintenanceWindowStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewMaintenanceWindowStore: %v", err)
+	}
+
+	base := time.Date(2026, 3, 7, 22, 0, 0, 0, time.UTC)
+	mw := &MaintenanceWindow{
+		WindowID: "mw_1", Owner: "alice", Reason: "CHG-42 postgres upgrade",
+		Resources: []string{"prod-db-*"}, StartsAt: base, EndsAt: base.Add(4 * time.Hour), CreatedBy: "alice",
+	}
+	if err := ms.Create(ctx, mw); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := ms.Create(ctx, &MaintenanceWindow{WindowID: "mw_bad", Owner: "alice", Reason: "x", StartsAt: base, EndsAt: base}); err == nil {
+		t.Error("Create accepted a window that ends when it starts")
+	}
+	if err := ms.Create(ctx, &MaintenanceWindow{WindowID: "mw_2", Owner: "bob", Reason: "node drain", StartsAt: base.Add(24 * time.Hour), EndsAt: base.Add(25 * time.Hour)}); err != nil {
+		t.Fatalf("Create fleet-wide: %v", err)
+	}
+
+	got, err := ms.Get(ctx, "mw_1")
+	if err != nil || got.Owner != "alice" || len(got.Resources) != 1 || !got.StartsAt.Equal(base) {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if !got.Covers("prod-db-3", base.Add(time.Hour)) || got.Covers("staging-db", base.Add(time.Hour)) ||
+		got.Covers("prod-db-3", base.Add(4*time.Hour)) {
+		t.Error("Covers: wrong resource or time matching")
+	}
+
+	// List returns the windows overlapping a range.
+	for _, tt := range []struct {
+		since, until time.Time
+		want         int
+	}{
+		{time.Time{}, time.Time{}, 2},
+		{base.Add(time.Hour), base.Add(2 * time.Hour), 1},
+		{base.Add(5 * time.Hour), base.Add(6 * time.Hour), 0},
+		{base.Add(3 * time.Hour), time.Time{}, 2},
+	} {
+		list, err := ms.List(ctx, tt.since, tt.until)
+		if err != nil || len(list) != tt.want {
+			t.Errorf("List(%v, %v) = %d windows, %v; want %d", tt.since, tt.until, len(list), err, tt.want)
+		}
+	}
+	if w := CoveringWindow([]MaintenanceWindow{*got}, "prod-db-1", base); w == nil || w.WindowID != "mw_1" {
+		t.Errorf("CoveringWindow = %+v", w)
+	}
+
+	got.EndsAt = base.Add(6 * time.Hour)
+	got.Resources = nil
+	if err := ms.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, _ = ms.Get(ctx, "mw_1")
+	if !got.EndsAt.Equal(base.Add(6*time.Hour)) || got.Resources != nil || got.CreatedBy != "alice" {
+		t.Errorf("after Update = %+v", got)
+	}
+
+	if err := ms.Delete(ctx, "mw_1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := ms.Get(ctx, "mw_1"); !errors.Is(err, ErrMaintenanceWindowNotFound) {
+		t.Errorf("Get after Delete: %v", err)
+	}
+	if err := ms.Delete(ctx, "mw_1"); !errors.Is(err, ErrMaintenanceWindowNotFound) {
+		t.Errorf("second Delete: %v", err)
+	}
+
+	// Alerts tagged with a window are counted per window.
+	as, err := NewAlertStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewAlertStore: %v", err)
+	}
+	for i, id := range []string{"mw_2", "mw_2", ""} {
+		if err := as.Record(ctx, &AlertRecord{AlertID: "alert_" + string(rune('a'+i)), Rule: "off_hours", MaintenanceWindow: id, CreatedAt: base}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	counts, err := as.CountByMaintenanceWindow(ctx, base.Add(-time.Hour), base.Add(time.Hour))
+	if err != nil || len(counts) != 1 || counts["mw_2"] != 2 {
+		t.Errorf("CountByMaintenanceWindow = %v, %v", counts, err)
+	}
+	if a, _ := as.Get(ctx, "alert_a"); a.MaintenanceWindow != "mw_2" {
+		t.Errorf("alert maintenance_window = %q", a.MaintenanceWindow)
+	}
+}
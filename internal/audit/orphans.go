@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutcomeTimedOut is the outcome_status auditd gives a delegation whose
+// outcome was never recorded. An outcome that arrives later replaces it.
+const OutcomeTimedOut = "timed_out"
+
+// orphanBatch bounds how many delegations one MarkOrphanedDelegations call
+// marks, so a backlog is worked off over several sweeps.
+const orphanBatch = 500
+
+// MarkOrphanedDelegations sets outcome_status to "timed_out" on delegation
+// events recorded in [since, before) that still have no outcome, and returns
+// the events it marked, oldest first. reason is stored as the outcome error.
+// A delegation whose outcome arrives between the lookup and the update is
+// left alone.
+func (s *Store) MarkOrphanedDelegations(ctx context.Context, since, before time.Time, reason string) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+		SELECT raw_json FROM audit_events
+		WHERE event_type = ? AND COALESCE(outcome_status, '') = ''
+		  AND timestamp >= ? AND timestamp < ?
+		ORDER BY id LIMIT ?`),
+		string(EventTypeDelegation),
+		since.UTC().Format(sqliteTimeFormat),
+		before.UTC().Format(sqliteTimeFormat),
+		orphanBatch)
+	if err != nil {
+		return nil, fmt.Errorf("query orphaned delegations: %w", err)
+	}
+	var candidates []Event
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan orphaned delegation: %w", err)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			continue
+		}
+		candidates = append(candidates, e)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read orphaned delegations: %w", err)
+	}
+
+	var marked []Event
+	for _, e := range candidates {
+		res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+			UPDATE audit_events SET outcome_status = ?, outcome_error = ?
+			WHERE event_id = ? AND COALESCE(outcome_status, '') = ''`),
+			OutcomeTimedOut, reason, e.EventID)
+		if err != nil {
+			return marked, fmt.Errorf("mark orphaned delegation %s: %w", e.EventID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			marked = append(marked, e)
+		}
+	}
+	return marked, nil
+}
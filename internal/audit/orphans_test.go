@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMarkOrphanedDelegations(t *testing.T) {
+	store := newShardedStore(t, ChainShardingNone, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	delegate := func(id, agent string, age time.Duration) {
+		t.Helper()
+		if err := store.Record(ctx, &Event{
+			EventID:   id,
+			Timestamp: now.Add(-age),
+			EventType: EventTypeDelegation,
+			Session:   Session{ID: "s"},
+			Decision:  &Decision{Agent: agent},
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	delegate("evt_crashed", "k8s_agent", 2*time.Hour)
+	delegate("evt_answered", "k8s_agent", 2*time.Hour)
+	delegate("evt_running", "k8s_agent", time.Minute)
+	delegate("evt_ancient", "k8s_agent", 30*24*time.Hour)
+	delegate("evt_db", "postgres_database_agent", 2*time.Hour)
+	if err := store.RecordOutcome(ctx, "evt_answered", &Outcome{Status: "success"}); err != nil {
+		t.Fatalf("RecordOutcome: %v", err)
+	}
+
+	marked, err := store.MarkOrphanedDelegations(ctx, now.Add(-7*24*time.Hour), now.Add(-time.Hour), "no outcome recorded within 1h")
+	if err != nil {
+		t.Fatalf("MarkOrphanedDelegations: %v", err)
+	}
+	if len(marked) != 2 || marked[0].EventID != "evt_crashed" || marked[1].EventID != "evt_db" {
+		t.Fatalf("marked = %+v, want evt_crashed and evt_db", marked)
+	}
+	again, _ := store.MarkOrphanedDelegations(ctx, now.Add(-7*24*time.Hour), now.Add(-time.Hour), "x")
+	if len(again) != 0 {
+		t.Errorf("second sweep marked %d, want 0", len(again))
+	}
+
+	events, _ := store.Query(ctx, QueryOptions{OutcomeStatus: OutcomeTimedOut})
+	if len(events) != 2 {
+		t.Errorf("timed_out events = %d, want 2", len(events))
+	}
+
+	stats, err := store.Stats(ctx, StatsOptions{Since: now.Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(stats.Orphans) != 2 {
+		t.Fatalf("orphans = %+v, want k8s_agent and postgres_database_agent", stats.Orphans)
+	}
+	if k8s := stats.Orphans[0]; k8s.Agent != "k8s_agent" || k8s.Delegations != 3 || k8s.Orphaned != 1 || k8s.Rate < 0.33 || k8s.Rate > 0.34 {
+		t.Errorf("k8s_agent = %+v, want 1 of 3", k8s)
+	}
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PhaseTimings breaks a request's duration down by where the time went, so
+// that "why did this take 45 seconds" is answerable from the audit trail.
+// Recorded on the delegation_outcome event an agent emits when it finishes
+// a request.
+type PhaseTimings struct {
+	// QueueMs is the time from the request arriving at the agent until the
+	// agent started working on it.
+	QueueMs int64 `json:"queue_ms"`
+	// LLMMs is the time spent in model reasoning: whatever was not spent
+	// queueing, executing tools or waiting for approval.
+	LLMMs int64 `json:"llm_ms"`
+	// ToolsMs is the total tool execution time; ByToolMs splits it per tool.
+	// Tools the model runs in parallel each count their full duration.
+	ToolsMs  int64            `json:"tools_ms"`
+	ByToolMs map[string]int64 `json:"by_tool_ms,omitempty"`
+	// ApprovalMs is the time spent waiting for human approvals.
+	ApprovalMs int64 `json:"approval_ms"`
+}
+
+// PhaseTimer accumulates the phases of one request as it runs.
+// TraceMiddlewareWithAudit creates one per request and places it in the
+// request context; the A2A executor marks the start of work, ToolAuditor
+// adds tool executions and ApprovalClient adds approval waits. All methods
+// are safe on a nil *PhaseTimer.
+type PhaseTimer struct {
+	mu       sync.Mutex
+	received time.Time
+	started  time.Time
+	tools    map[string]time.Duration
+	approval time.Duration
+}
+
+// NewPhaseTimer returns a timer for a request that arrived at received.
+func NewPhaseTimer(received time.Time) *PhaseTimer {
+	return &PhaseTimer{received: received, tools: map[string]time.Duration{}}
+}
+
+// Start marks the moment the agent started working on the request, ending
+// the queueing phase. Only the first call counts.
+func (t *PhaseTimer) Start() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started.IsZero() {
+		t.started = time.Now()
+	}
+}
+
+// AddTool adds one execution of tool.
+func (t *PhaseTimer) AddTool(tool string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tools[tool] += d
+}
+
+// AddApprovalWait adds time spent blocked on a human approval.
+func (t *PhaseTimer) AddApprovalWait(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.approval += d
+}
+
+// Timings returns the breakdown of a request that finished at end.
+func (t *PhaseTimer) Timings(end time.Time) PhaseTimings {
+	if t == nil {
+		return PhaseTimings{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out PhaseTimings
+	total := end.Sub(t.received)
+	var queue time.Duration
+	if !t.started.IsZero() {
+		queue = t.started.Sub(t.received)
+	}
+	var tools time.Duration
+	for name, d := range t.tools {
+		if out.ByToolMs == nil {
+			out.ByToolMs = make(map[string]int64, len(t.tools))
+		}
+		out.ByToolMs[name] = d.Milliseconds()
+		tools += d
+	}
+	out.QueueMs = queue.Milliseconds()
+	out.ToolsMs = tools.Milliseconds()
+	out.ApprovalMs = t.approval.Milliseconds()
+	out.LLMMs = max(total-queue-tools-t.approval, 0).Milliseconds()
+	return out
+}
+
+type phaseTimerKey struct{}
+
+// WithPhaseTimer returns a context carrying t.
+func WithPhaseTimer(ctx context.Context, t *PhaseTimer) context.Context {
+	return context.WithValue(ctx, phaseTimerKey{}, t)
+}
+
+// PhaseTimerFromContext returns the request's PhaseTimer, or nil.
+func PhaseTimerFromContext(ctx context.Context) *PhaseTimer {
+	t, _ := ctx.Value(phaseTimerKey{}).(*PhaseTimer)
+	return t
+}
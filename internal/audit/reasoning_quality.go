@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// ReasoningQuality is a heuristic score of a delegation's reasoning chain,
+// attached to delegation_decision events when they are recorded. It catches
+// reasoning that is present but hollow — checkEmptyReasoning only catches
+// reasoning that is missing.
+type ReasoningQuality struct {
+	// Score is the overall quality from 0.0 (empty or incoherent) to 1.0.
+	Score float64 `json:"score"`
+
+	// Length rates how much reasoning was given (1.0 at reasoningFullWords words).
+	Length float64 `json:"length"`
+
+	// Grounding rates how much the reasoning cites concrete values from tool
+	// outputs earlier in the same trace. Nil when the trace has no tool
+	// output to cite yet (e.g. the first routing decision of a request).
+	Grounding *float64 `json:"grounding,omitempty"`
+
+	// Consistency is 0 when the reasoning contradicts the chosen agent and 1 otherwise.
+	Consistency float64 `json:"consistency"`
+
+	// Flags name the heuristics that lowered the score.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Reasoning quality flags.
+const (
+	ReasoningFlagEmpty         = "empty"
+	ReasoningFlagShort         = "short"
+	ReasoningFlagUngrounded    = "ungrounded"
+	ReasoningFlagContradiction = "contradicts_choice"
+)
+
+const (
+	// reasoningFullWords is the chain length (in words) that earns a full length score.
+	reasoningFullWords = 20
+
+	// Component weights; Grounding's weight is redistributed when it is not scored.
+	reasoningWeightLength      = 0.4
+	reasoningWeightGrounding   = 0.3
+	reasoningWeightConsistency = 0.3
+)
+
+// evidenceTokenRe matches the distinctive tokens of a tool output that a
+// grounded reasoning step would repeat: numbers, identifiers with digits or
+// separators (pid 4242, db-prod-1, pg_stat_activity, 10.0.0.5).
+var evidenceTokenRe = regexp.MustCompile(`[A-Za-z0-9]+(?:[._:/-][A-Za-z0-9]+)+|[A-Za-z_]*[0-9][A-Za-z0-9_]*`)
+
+// ScoreReasoning rates the reasoning chain of a delegation decision.
+// toolOutputs are the results of tool executions that preceded the decision
+// in the same trace; they may be empty.
+func ScoreReasoning(d *Decision, toolOutputs []string) *ReasoningQuality {
+	q := &ReasoningQuality{Consistency: 1}
+	text := strings.ToLower(strings.Join(d.ReasoningChain, " "))
+	words := len(strings.Fields(text))
+	if words == 0 {
+		q.Flags = append(q.Flags, ReasoningFlagEmpty)
+		q.Consistency = 0
+		return q
+	}
+
+	q.Length = math.Min(1, float64(words)/reasoningFullWords)
+	if q.Length < 0.5 {
+		q.Flags = append(q.Flags, ReasoningFlagShort)
+	}
+
+	if evidence := evidenceTokens(toolOutputs); len(evidence) > 0 {
+		cited := 0
+		for tok := range evidence {
+			if strings.Contains(text, tok) {
+				cited++
+			}
+		}
+		g := math.Min(1, float64(cited)/2)
+		q.Grounding = &g
+		if cited == 0 {
+			q.Flags = append(q.Flags, ReasoningFlagUngrounded)
+		}
+	}
+
+	if contradictsChoice(d, text) {
+		q.Consistency = 0
+		q.Flags = append(q.Flags, ReasoningFlagContradiction)
+	}
+
+	sum := reasoningWeightLength*q.Length + reasoningWeightConsistency*q.Consistency
+	weight := reasoningWeightLength + reasoningWeightConsistency
+	if q.Grounding != nil {
+		sum += reasoningWeightGrounding * *q.Grounding
+		weight += reasoningWeightGrounding
+	}
+	q.Score = math.Round(sum/weight*100) / 100
+	return q
+}
+
+// evidenceTokens returns the lowercased distinctive tokens of the outputs.
+// Single digits are too common in prose to count as a citation.
+func evidenceTokens(outputs []string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, out := range outputs {
+		for _, tok := range evidenceTokenRe.FindAllString(out, -1) {
+			if len(tok) >= 2 {
+				tokens[strings.ToLower(tok)] = true
+			}
+		}
+	}
+	return tokens
+}
+
+// contradictsChoice reports whether the reasoning argues against the agent it
+// chose: the chosen agent is also listed as rejected, or the reasoning names
+// rejected alternatives but never the chosen agent or its category.
+func contradictsChoice(d *Decision, text string) bool {
+	if d.Agent == "" {
+		return false
+	}
+	mentionsAlternative := false
+	for _, alt := range d.AlternativesConsidered {
+		if strings.EqualFold(alt.Agent, d.Agent) {
+			return true
+		}
+		if mentionsAgent(text, alt.Agent) {
+			mentionsAlternative = true
+		}
+	}
+	if !mentionsAlternative {
+		return false
+	}
+	if d.RequestCategory != "" && d.RequestCategory != CategoryUnknown &&
+		strings.Contains(text, string(d.RequestCategory)) {
+		return false
+	}
+	return !mentionsAgent(text, d.Agent)
+}
+
+// mentionsAgent reports whether text names the agent, either in full or by a
+// distinctive part of its name ("postgres" for postgres_database_agent).
+func mentionsAgent(text, agent string) bool {
+	agent = strings.ToLower(agent)
+	if agent == "" {
+		return false
+	}
+	if strings.Contains(text, agent) {
+		return true
+	}
+	for _, part := range strings.Split(agent, "_") {
+		if len(part) >= 4 && part != "agent" && strings.Contains(text, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScoreReasoning(t *testing.T) {
+	dbAlts := []Alternative{{Agent: "k8s_agent", RejectedBecause: "no pods involved"}}
+	cases := []struct {
+		name      string
+		decision  Decision
+		outputs   []string
+		wantFlags []string
+		minScore  float64
+		maxScore  float64
+	}{
+		{
+			name:      "empty",
+			decision:  Decision{Agent: "postgres_database_agent"},
+			wantFlags: []string{ReasoningFlagEmpty},
+			maxScore:  0,
+		},
+		{
+			name: "short",
+			decision: Decision{
+				Agent:          "postgres_database_agent",
+				ReasoningChain: []string{"database question"},
+			},
+			wantFlags: []string{ReasoningFlagShort},
+			maxScore:  0.7,
+		},
+		{
+			name: "detailed and grounded",
+			decision: Decision{
+				Agent: "postgres_database_agent", RequestCategory: CategoryDatabase,
+				ReasoningChain: []string{
+					"get_lock_info shows pid 4242 holding an exclusive lock on public.orders",
+					"the blocked sessions all wait on that lock so the postgres agent should terminate it",
+				},
+				AlternativesConsidered: dbAlts,
+			},
+			outputs:  []string{"pid 4242 holds AccessExclusiveLock on public.orders since 09:14:02"},
+			minScore: 1,
+			maxScore: 1,
+		},
+		{
+			name: "ignores tool output",
+			decision: Decision{
+				Agent: "postgres_database_agent", RequestCategory: CategoryDatabase,
+				ReasoningChain: []string{
+					"the user wants the stuck query gone and the database agent can terminate sessions",
+					"this is a routine request",
+				},
+			},
+			outputs:   []string{"pid 4242 holds AccessExclusiveLock on public.orders since 09:14:02"},
+			wantFlags: []string{ReasoningFlagUngrounded},
+			maxScore:  0.75,
+		},
+		{
+			name: "argues for an alternative",
+			decision: Decision{
+				Agent: "postgres_database_agent",
+				ReasoningChain: []string{
+					"the pods in the payments namespace are crash looping",
+					"k8s_agent can read the pod logs and events for the deployment",
+				},
+				AlternativesConsidered: dbAlts,
+			},
+			wantFlags: []string{ReasoningFlagContradiction},
+			maxScore:  0.6,
+		},
+		{
+			name: "chosen agent also rejected",
+			decision: Decision{
+				Agent: "k8s_agent",
+				ReasoningChain: []string{
+					"the deployment is failing to roll out and the k8s agent can inspect the replica sets",
+				},
+				AlternativesConsidered: []Alternative{{Agent: "k8s_agent", RejectedBecause: "not kubernetes"}},
+			},
+			wantFlags: []string{ReasoningFlagContradiction},
+			maxScore:  0.6,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := ScoreReasoning(&tc.decision, tc.outputs)
+			if q.Score < tc.minScore || q.Score > tc.maxScore {
+				t.Errorf("score = %.2f, want in [%.2f, %.2f] (%+v)", q.Score, tc.minScore, tc.maxScore, q)
+			}
+			for _, f := range tc.wantFlags {
+				if !slices.Contains(q.Flags, f) {
+					t.Errorf("flags = %v, want %q", q.Flags, f)
+				}
+			}
+			if len(tc.wantFlags) == 0 && len(q.Flags) > 0 {
+				t.Errorf("flags = %v, want none", q.Flags)
+			}
+			if (q.Grounding != nil) != (len(tc.outputs) > 0) {
+				t.Errorf("grounding = %v with %d outputs", q.Grounding, len(tc.outputs))
+			}
+		})
+	}
+}
+
+// TestStore_RecordScoresReasoning verifies that the store scores delegation
+// reasoning against tool output already recorded for the trace, and that the
+// score is covered by the event hash.
+func TestStore_RecordScoresReasoning(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	tool := &Event{
+		EventID: "tool_1", Timestamp: now, EventType: EventTypeToolExecution, TraceID: "tr_q",
+		Session: Session{ID: "db_sess"},
+		Tool:    &ToolExecution{Name: "get_lock_info", Result: "pid 4242 holds AccessExclusiveLock on public.orders"},
+	}
+	decision := &Event{
+		EventID: "evt_q", Timestamp: now.Add(time.Second), EventType: EventTypeDelegation, TraceID: "tr_q",
+		Session: Session{ID: "sess_q"},
+		Decision: &Decision{
+			Agent: "postgres_database_agent", RequestCategory: CategoryDatabase,
+			ReasoningChain: []string{"pid 4242 is blocking writes to public.orders", "the database agent can terminate it"},
+		},
+	}
+	for _, e := range []*Event{tool, decision} {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record %s: %v", e.EventID, err)
+		}
+	}
+
+	got, err := store.Query(ctx, QueryOptions{EventID: "evt_q"})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Query: %v (%d events)", err, len(got))
+	}
+	q := got[0].Decision.ReasoningQuality
+	if q == nil || q.Grounding == nil || *q.Grounding != 1 {
+		t.Fatalf("reasoning quality = %+v, want grounded score", q)
+	}
+	if !VerifyEventHash(&got[0]) {
+		t.Error("event hash does not cover the reasoning score")
+	}
+}
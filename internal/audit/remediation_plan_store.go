@@ -0,0 +1,434 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Remediation plan statuses.
+const (
+	PlanPending           = "pending"            // no mutating step approved yet
+	PlanPartiallyApproved = "partially_approved" // some steps approved, others still pending
+	PlanApproved          = "approved"           // every step decided; executable steps remain
+	PlanDenied            = "denied"             // denied as a whole; nothing more may run
+	PlanCompleted         = "completed"          // every executable mutating step has run
+)
+
+// Remediation plan step statuses.
+const (
+	PlanStepPending     = "pending"      // awaiting an approver
+	PlanStepApproved    = "approved"     // approved by a human
+	PlanStepDenied      = "denied"       // denied; the step may not run
+	PlanStepNotRequired = "not_required" // policy allows the step without approval
+)
+
+// ErrPlanConflict is returned (wrapped) when a decision or step execution is
+// not possible in the plan's current state: the step was already decided, is
+// out of order, awaits approval, or is not part of the plan at all.
+var ErrPlanConflict = errors.New("remediation plan conflict")
+
+// RemediationPlan is an ordered set of tool calls proposed by an agent and
+// reviewed as one change record. Approvers approve or deny the whole plan or
+// individual steps; the agent's policy enforcer then only lets a write or
+// destructive tool call through when it is the next approved step.
+type RemediationPlan struct {
+	PlanID      string `json:"plan_id"` // "rpl_" + uuid[:8]
+	Title       string `json:"title"`
+	TraceID     string `json:"trace_id,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	AgentName   string `json:"agent_name,omitempty"`
+	RequestedBy string `json:"requested_by"`
+	Status      string `json:"status"`
+
+	Steps []RemediationPlanStep `json:"steps"`
+
+	// ResolvedBy and ResolutionReason record a whole-plan approval or denial.
+	ResolvedBy       string `json:"resolved_by,omitempty"`
+	ResolutionReason string `json:"resolution_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RemediationPlanStep is one tool call of a RemediationPlan.
+type RemediationPlanStep struct {
+	Index        int    `json:"index"`
+	ToolName     string `json:"tool_name,omitempty"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	ActionClass  string `json:"action_class"` // read, write, destructive
+	Description  string `json:"description,omitempty"`
+
+	// Blast-radius estimates supplied by the agent for the approver.
+	EstimatedRows int `json:"estimated_rows,omitempty"`
+	EstimatedPods int `json:"estimated_pods,omitempty"`
+
+	// PolicyName is the policy that decided the step when the plan was proposed.
+	PolicyName string `json:"policy_name,omitempty"`
+
+	Status    string    `json:"status"`
+	DecidedBy string    `json:"decided_by,omitempty"`
+	DecidedAt time.Time `json:"decided_at,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+
+	ExecutedAt      time.Time `json:"executed_at,omitempty"`
+	ExecutedTraceID string    `json:"executed_trace_id,omitempty"`
+}
+
+// Mutates reports whether the step is a write or destructive action. Only
+// mutating steps are validated at execution time; reads run freely.
+func (s *RemediationPlanStep) Mutates() bool {
+	return s.ActionClass == string(ActionWrite) || s.ActionClass == string(ActionDestructive)
+}
+
+// Executed reports whether the step has been claimed for execution.
+func (s *RemediationPlanStep) Executed() bool { return !s.ExecutedAt.IsZero() }
+
+// PlanStepCall identifies a tool call being validated against a plan.
+type PlanStepCall struct {
+	ToolName     string `json:"tool_name,omitempty"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	ActionClass  string `json:"action_class"`
+	TraceID      string `json:"trace_id,omitempty"`
+}
+
+// matches reports whether call is this step. The tool name is compared only
+// when both sides know it.
+func (s *RemediationPlanStep) matches(call PlanStepCall) bool {
+	if s.ResourceType != call.ResourceType || s.ResourceName != call.ResourceName || s.ActionClass != call.ActionClass {
+		return false
+	}
+	return s.ToolName == "" || call.ToolName == "" || s.ToolName == call.ToolName
+}
+
+// refreshStatus derives the plan status from its steps. A denied plan stays denied.
+func (p *RemediationPlan) refreshStatus() {
+	if p.Status == PlanDenied {
+		return
+	}
+	pending, approved, remaining := 0, 0, 0
+	for i := range p.Steps {
+		s := &p.Steps[i]
+		switch s.Status {
+		case PlanStepPending:
+			pending++
+		case PlanStepApproved:
+			approved++
+		}
+		if s.Mutates() && s.Status != PlanStepDenied && !s.Executed() {
+			remaining++
+		}
+	}
+	switch {
+	case pending > 0 && approved > 0:
+		p.Status = PlanPartiallyApproved
+	case pending > 0:
+		p.Status = PlanPending
+	case remaining == 0:
+		p.Status = PlanCompleted
+	default:
+		p.Status = PlanApproved
+	}
+}
+
+// RemediationPlanListOptions specifies filters for listing plans.
+type RemediationPlanListOptions struct {
+	Status    string
+	AgentName string
+	TraceID   string
+	TenantID  string
+	Limit     int
+}
+
+// RemediationPlanStore persists remediation plans. Steps are stored as a JSON
+// column; decisions and executions rewrite them under mu, so concurrent
+// approvers and agents cannot both claim the same step.
+type RemediationPlanStore struct {
+	db         *sql.DB
+	isPostgres bool
+	mu         sync.Mutex
+}
+
+// NewRemediationPlanStore creates the remediation_plans table (if absent) and
+// returns a ready-to-use store using the given shared database connection.
+func NewRemediationPlanStore(db *sql.DB, isPostgres bool) (*RemediationPlanStore, error) {
+	s := &RemediationPlanStore{db: db, isPostgres: isPostgres}
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS remediation_plans (
+    plan_id           TEXT NOT NULL PRIMARY KEY,
+    title             TEXT NOT NULL DEFAULT '',
+    trace_id          TEXT NOT NULL DEFAULT '',
+    tenant_id         TEXT NOT NULL DEFAULT '',
+    agent_name        TEXT NOT NULL DEFAULT '',
+    requested_by      TEXT NOT NULL DEFAULT '',
+    status            TEXT NOT NULL DEFAULT 'pending',
+    steps             TEXT NOT NULL DEFAULT '[]',
+    resolved_by       TEXT NOT NULL DEFAULT '',
+    resolution_reason TEXT NOT NULL DEFAULT '',
+    created_at        TEXT NOT NULL,
+    updated_at        TEXT NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS idx_remediation_plans_status ON remediation_plans(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_remediation_plans_trace  ON remediation_plans(trace_id)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("create remediation_plans schema: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Create stores a new plan. PlanID is generated if empty; step indexes and the
+// plan status are derived from the steps, whose Status must already be set.
+func (s *RemediationPlanStore) Create(ctx context.Context, p *RemediationPlan) error {
+	if p.PlanID == "" {
+		p.PlanID = "rpl_" + uuid.New().String()[:8]
+	}
+	for i := range p.Steps {
+		p.Steps[i].Index = i
+	}
+	p.Status = ""
+	p.refreshStatus()
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("marshal steps: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		INSERT INTO remediation_plans
+			(plan_id, title, trace_id, tenant_id, agent_name, requested_by, status,
+			 steps, resolved_by, resolution_reason, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		p.PlanID, p.Title, p.TraceID, p.TenantID, p.AgentName, p.RequestedBy, p.Status,
+		string(steps), p.ResolvedBy, p.ResolutionReason,
+		p.CreatedAt.Format(time.RFC3339Nano), p.UpdatedAt.Format(time.RFC3339Nano),
+	)
+	return err
+}
+
+const remediationPlanColumns = `plan_id, title, trace_id, tenant_id, agent_name, requested_by, status,
+		       steps, resolved_by, resolution_reason, created_at, updated_at`
+
+// Get returns a plan by ID. Returns sql.ErrNoRows if not found.
+func (s *RemediationPlanStore) Get(ctx context.Context, planID string) (*RemediationPlan, error) {
+	row := s.db.QueryRowContext(ctx, rebind(s.isPostgres,
+		`SELECT `+remediationPlanColumns+` FROM remediation_plans WHERE plan_id = ?`), planID)
+	return scanRemediationPlan(row)
+}
+
+// List returns plans matching opts, newest first.
+func (s *RemediationPlanStore) List(ctx context.Context, opts RemediationPlanListOptions) ([]*RemediationPlan, error) {
+	query := `SELECT ` + remediationPlanColumns + ` FROM remediation_plans WHERE 1=1`
+	var args []any
+	if opts.Status != "" {
+		query += " AND status = ?"
+		args = append(args, opts.Status)
+	}
+	if opts.AgentName != "" {
+		query += " AND agent_name = ?"
+		args = append(args, opts.AgentName)
+	}
+	if opts.TraceID != "" {
+		query += " AND trace_id = ?"
+		args = append(args, opts.TraceID)
+	}
+	if opts.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+	query += " ORDER BY created_at DESC"
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []*RemediationPlan
+	for rows.Next() {
+		p, err := scanRemediationPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+// Decide approves or denies a plan. step < 0 decides the whole plan: approval
+// approves every pending step, denial stops the plan and denies every step
+// that has not run. Otherwise only the given step, which must be pending, is
+// decided. Returns sql.ErrNoRows for an unknown plan and ErrPlanConflict when
+// the decision is not possible.
+func (s *RemediationPlanStore) Decide(ctx context.Context, planID string, step int, approve bool, by, reason string) (*RemediationPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.Get(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == PlanDenied || p.Status == PlanCompleted {
+		return nil, fmt.Errorf("%w: plan %s is %s", ErrPlanConflict, planID, p.Status)
+	}
+
+	now := time.Now().UTC()
+	decide := func(st *RemediationPlanStep, status string) {
+		st.Status, st.DecidedBy, st.DecidedAt, st.Reason = status, by, now, reason
+	}
+	switch {
+	case step < 0 && approve:
+		n := 0
+		for i := range p.Steps {
+			if p.Steps[i].Status == PlanStepPending {
+				decide(&p.Steps[i], PlanStepApproved)
+				n++
+			}
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("%w: plan %s has no steps awaiting approval", ErrPlanConflict, planID)
+		}
+		p.ResolvedBy, p.ResolutionReason = by, reason
+	case step < 0:
+		for i := range p.Steps {
+			if !p.Steps[i].Executed() && p.Steps[i].Status != PlanStepDenied {
+				decide(&p.Steps[i], PlanStepDenied)
+			}
+		}
+		p.Status = PlanDenied
+		p.ResolvedBy, p.ResolutionReason = by, reason
+	default:
+		if step >= len(p.Steps) {
+			return nil, fmt.Errorf("%w: plan %s has no step %d", ErrPlanConflict, planID, step)
+		}
+		st := &p.Steps[step]
+		if st.Status != PlanStepPending {
+			return nil, fmt.Errorf("%w: step %d is already %s", ErrPlanConflict, step, st.Status)
+		}
+		if approve {
+			decide(st, PlanStepApproved)
+		} else {
+			decide(st, PlanStepDenied)
+		}
+	}
+	p.refreshStatus()
+	if err := s.save(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Execute validates a write or destructive tool call against the plan and
+// claims the matching step. Steps run in plan order: the call must match the
+// first mutating step that has neither run nor been denied, and that step
+// must be approved (or need no approval). Read calls are not tracked.
+// Returns sql.ErrNoRows for an unknown plan and ErrPlanConflict when the call
+// may not run.
+func (s *RemediationPlanStore) Execute(ctx context.Context, planID string, call PlanStepCall) (*RemediationPlanStep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.Get(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == PlanDenied {
+		return nil, fmt.Errorf("%w: plan %s was denied", ErrPlanConflict, planID)
+	}
+
+	var next, match, denied *RemediationPlanStep
+	for i := range p.Steps {
+		st := &p.Steps[i]
+		if !st.Mutates() || st.Executed() {
+			continue
+		}
+		if st.Status == PlanStepDenied {
+			if denied == nil && st.matches(call) {
+				denied = st
+			}
+			continue
+		}
+		if next == nil {
+			next = st
+		}
+		if st.matches(call) {
+			match = st
+			break
+		}
+	}
+	switch {
+	case match == nil && denied != nil:
+		return nil, fmt.Errorf("%w: step %d was denied", ErrPlanConflict, denied.Index)
+	case match == nil:
+		return nil, fmt.Errorf("%w: no remaining step of plan %s is a %s of %s %q",
+			ErrPlanConflict, planID, call.ActionClass, call.ResourceType, call.ResourceName)
+	case match != next:
+		return nil, fmt.Errorf("%w: step %d must run before step %d", ErrPlanConflict, next.Index, match.Index)
+	case match.Status == PlanStepPending:
+		return nil, fmt.Errorf("%w: step %d is awaiting approval", ErrPlanConflict, match.Index)
+	}
+
+	match.ExecutedAt = time.Now().UTC()
+	match.ExecutedTraceID = call.TraceID
+	claimed := *match
+	p.refreshStatus()
+	if err := s.save(ctx, p); err != nil {
+		return nil, err
+	}
+	return &claimed, nil
+}
+
+// save writes back the mutable fields of p. Callers hold s.mu.
+func (s *RemediationPlanStore) save(ctx context.Context, p *RemediationPlan) error {
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("marshal steps: %w", err)
+	}
+	p.UpdatedAt = time.Now().UTC()
+	_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		UPDATE remediation_plans
+		SET status = ?, steps = ?, resolved_by = ?, resolution_reason = ?, updated_at = ?
+		WHERE plan_id = ?
+	`), p.Status, string(steps), p.ResolvedBy, p.ResolutionReason, p.UpdatedAt.Format(time.RFC3339Nano), p.PlanID)
+	return err
+}
+
+type remediationPlanScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRemediationPlan(row remediationPlanScanner) (*RemediationPlan, error) {
+	var p RemediationPlan
+	var steps, createdAt, updatedAt string
+	if err := row.Scan(
+		&p.PlanID, &p.Title, &p.TraceID, &p.TenantID, &p.AgentName, &p.RequestedBy, &p.Status,
+		&steps, &p.ResolvedBy, &p.ResolutionReason, &createdAt, &updatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan remediation_plan: %w", err)
+	}
+	if err := json.Unmarshal([]byte(steps), &p.Steps); err != nil {
+		return nil, fmt.Errorf("unmarshal steps of %s: %w", p.PlanID, err)
+	}
+	p.CreatedAt = parseFlexTime(createdAt)
+	p.UpdatedAt = parseFlexTime(updatedAt)
+	return &p, nil
+}
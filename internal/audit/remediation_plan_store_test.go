@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func newTestPlanStore(t *testing.T) *RemediationPlanStore {
+	t.Helper()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ps, err := NewRemediationPlanStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewRemediationPlanStore: %v", err)
+	}
+	return ps
+}
+
+// createTestPlan stores inspect → cancel → terminate on prod-db, with the
+// cancel and terminate steps awaiting approval.
+func createTestPlan(t *testing.T, ps *RemediationPlanStore) *RemediationPlan {
+	t.Helper()
+	p := &RemediationPlan{
+		Title:       "clear blocking session",
+		AgentName:   "db-agent",
+		RequestedBy: "alice",
+		Steps: []RemediationPlanStep{
+			{ToolName: "get_session_info", ResourceType: "database", ResourceName: "prod-db", ActionClass: "read", Status: PlanStepNotRequired},
+			{ToolName: "cancel_query", ResourceType: "database", ResourceName: "prod-db", ActionClass: "write", Status: PlanStepPending},
+			{ToolName: "terminate_connection", ResourceType: "database", ResourceName: "prod-db", ActionClass: "destructive", Status: PlanStepPending, EstimatedRows: 1},
+		},
+	}
+	if err := ps.Create(context.Background(), p); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return p
+}
+
+func planCall(tool, action string) PlanStepCall {
+	return PlanStepCall{ToolName: tool, ResourceType: "database", ResourceName: "prod-db", ActionClass: action, TraceID: "tr_exec"}
+}
+
+func TestRemediationPlanStore_CreateGetList(t *testing.T) {
+	ps := newTestPlanStore(t)
+	ctx := context.Background()
+	p := createTestPlan(t, ps)
+	if p.Status != PlanPending || p.Steps[2].Index != 2 {
+		t.Fatalf("created plan = status %q, step index %d", p.Status, p.Steps[2].Index)
+	}
+
+	got, err := ps.Get(ctx, p.PlanID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Title != p.Title || len(got.Steps) != 3 || got.Steps[2].EstimatedRows != 1 {
+		t.Errorf("Get = %+v", got)
+	}
+	if _, err := ps.Get(ctx, "rpl_missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Get(missing) err = %v, want sql.ErrNoRows", err)
+	}
+
+	plans, err := ps.List(ctx, RemediationPlanListOptions{Status: PlanPending})
+	if err != nil || len(plans) != 1 {
+		t.Errorf("List(pending) = %d plans, err %v; want 1", len(plans), err)
+	}
+	plans, _ = ps.List(ctx, RemediationPlanListOptions{AgentName: "k8s-agent"})
+	if len(plans) != 0 {
+		t.Errorf("List(other agent) = %d plans, want 0", len(plans))
+	}
+}
+
+func TestRemediationPlanStore_StagedStepApproval(t *testing.T) {
+	ps := newTestPlanStore(t)
+	ctx := context.Background()
+	p := createTestPlan(t, ps)
+
+	p, err := ps.Decide(ctx, p.PlanID, 1, true, "bob", "cancel is fine")
+	if err != nil {
+		t.Fatalf("Decide(step 1): %v", err)
+	}
+	if p.Status != PlanPartiallyApproved || p.Steps[1].DecidedBy != "bob" {
+		t.Errorf("after approving step 1: status %q, step %+v", p.Status, p.Steps[1])
+	}
+	if _, err := ps.Decide(ctx, p.PlanID, 1, false, "bob", ""); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("re-deciding step 1: err = %v, want ErrPlanConflict", err)
+	}
+
+	// Step 2 cannot jump the queue, and awaits approval once step 1 has run.
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("terminate_connection", "destructive")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("out-of-order execute: err = %v, want ErrPlanConflict", err)
+	}
+	step, err := ps.Execute(ctx, p.PlanID, planCall("cancel_query", "write"))
+	if err != nil || step.Index != 1 {
+		t.Fatalf("Execute(step 1) = %+v, %v", step, err)
+	}
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("terminate_connection", "destructive")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("unapproved execute: err = %v, want ErrPlanConflict", err)
+	}
+
+	if _, err := ps.Decide(ctx, p.PlanID, 2, true, "bob", ""); err != nil {
+		t.Fatalf("Decide(step 2): %v", err)
+	}
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("terminate_connection", "destructive")); err != nil {
+		t.Fatalf("Execute(step 2): %v", err)
+	}
+	got, _ := ps.Get(ctx, p.PlanID)
+	if got.Status != PlanCompleted || got.Steps[2].ExecutedTraceID != "tr_exec" {
+		t.Errorf("final plan = status %q, step 2 %+v", got.Status, got.Steps[2])
+	}
+
+	// Each step runs once.
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("cancel_query", "write")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("re-executing step 1: err = %v, want ErrPlanConflict", err)
+	}
+}
+
+func TestRemediationPlanStore_WholePlanDecisions(t *testing.T) {
+	ps := newTestPlanStore(t)
+	ctx := context.Background()
+
+	approved, err := ps.Decide(ctx, createTestPlan(t, ps).PlanID, -1, true, "bob", "")
+	if err != nil {
+		t.Fatalf("approve plan: %v", err)
+	}
+	if approved.Status != PlanApproved || approved.Steps[1].Status != PlanStepApproved || approved.Steps[2].Status != PlanStepApproved {
+		t.Errorf("approved plan = %+v", approved)
+	}
+	if approved.Steps[0].Status != PlanStepNotRequired {
+		t.Errorf("read step status = %q, want not_required", approved.Steps[0].Status)
+	}
+	if _, err := ps.Execute(ctx, approved.PlanID, planCall("drop_table", "destructive")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("call outside the plan: err = %v, want ErrPlanConflict", err)
+	}
+
+	denied, err := ps.Decide(ctx, createTestPlan(t, ps).PlanID, -1, false, "bob", "too risky")
+	if err != nil {
+		t.Fatalf("deny plan: %v", err)
+	}
+	if denied.Status != PlanDenied || denied.ResolutionReason != "too risky" {
+		t.Errorf("denied plan = %+v", denied)
+	}
+	if _, err := ps.Execute(ctx, denied.PlanID, planCall("cancel_query", "write")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("execute on denied plan: err = %v, want ErrPlanConflict", err)
+	}
+	if _, err := ps.Decide(ctx, denied.PlanID, -1, true, "bob", ""); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("approving a denied plan: err = %v, want ErrPlanConflict", err)
+	}
+}
+
+func TestRemediationPlanStore_DeniedStepIsSkipped(t *testing.T) {
+	ps := newTestPlanStore(t)
+	ctx := context.Background()
+	p := createTestPlan(t, ps)
+
+	ps.Decide(ctx, p.PlanID, 1, false, "bob", "")
+	p, err := ps.Decide(ctx, p.PlanID, 2, true, "bob", "")
+	if err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if p.Status != PlanApproved {
+		t.Errorf("status = %q, want approved", p.Status)
+	}
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("cancel_query", "write")); !errors.Is(err, ErrPlanConflict) {
+		t.Errorf("denied step: err = %v, want ErrPlanConflict", err)
+	}
+	if _, err := ps.Execute(ctx, p.PlanID, planCall("terminate_connection", "destructive")); err != nil {
+		t.Errorf("step after a denied step: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // RemoteStore sends audit events to a central audit service via HTTP.
@@ -52,6 +54,15 @@ func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 // Record sends an event to the audit service.
 // The service handles hash chain computation.
 func (r *RemoteStore) Record(ctx context.Context, event *Event) error {
+	stampTenant(ctx, event)
+	stampCorrelation(ctx, event)
+	stampTraceParent(ctx, event)
+	StampProducer(event)
+	// Name the event here rather than in the service, so sending it again
+	// (a retry after a lost response) is recognised as the same event.
+	if event.EventID == "" {
+		event.EventID = "evt_" + uuid.New().String()[:8]
+	}
 	body, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("marshal event: %w", err)
@@ -62,6 +73,7 @@ func (r *RemoteStore) Record(ctx context.Context, event *Event) error {
 		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, event.EventID)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
@@ -138,6 +150,9 @@ func (r *RemoteStore) Query(ctx context.Context, opts QueryOptions) ([]Event, er
 	if !opts.Since.IsZero() {
 		url += "since=" + opts.Since.Format(time.RFC3339) + "&"
 	}
+	if opts.TenantID != "" {
+		url += "tenant_id=" + opts.TenantID + "&"
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -0,0 +1,303 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrSubscriptionNotFound is returned when a report subscription ID does not exist.
+var ErrSubscriptionNotFound = errors.New("report subscription not found")
+
+// Report subscription frequencies. Daily reports cover the 24 hours before
+// delivery, weekly ones the 7 days before a Monday delivery.
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// ReportSubscription is one user's request for a periodic governance report,
+// narrowed to the agent, resource and event types they care about and
+// delivered to their own email addresses and/or webhook.
+type ReportSubscription struct {
+	SubscriptionID string   `json:"subscription_id"`
+	Name           string   `json:"name,omitempty"`
+	Frequency      string   `json:"frequency"`             // daily or weekly
+	Hour           int      `json:"hour"`                  // UTC hour of delivery, 0-23
+	Agent          string   `json:"agent,omitempty"`       // only events credited to this agent
+	Resource       string   `json:"resource,omitempty"`    // only events on this database, namespace or host
+	EventTypes     []string `json:"event_types,omitempty"` // only these event types; empty = all
+	Email          []string `json:"email,omitempty"`
+	WebhookURL     string   `json:"webhook_url,omitempty"`
+	Owner          string   `json:"owner"`
+	TenantID       string   `json:"tenant_id,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	NextRunAt  time.Time  `json:"next_run_at"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"` // last delivery failure; cleared by a success
+}
+
+// Validate checks the fields a caller sets.
+func (sub *ReportSubscription) Validate() error {
+	if sub.Frequency != ReportDaily && sub.Frequency != ReportWeekly {
+		return fmt.Errorf("frequency must be %q or %q", ReportDaily, ReportWeekly)
+	}
+	if sub.Hour < 0 || sub.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	if len(sub.Email) == 0 && sub.WebhookURL == "" {
+		return fmt.Errorf("at least one of email and webhook_url is required")
+	}
+	for _, addr := range sub.Email {
+		if !strings.Contains(addr, "@") || strings.ContainsAny(addr, "\r\n,") {
+			return fmt.Errorf("invalid email address %q", addr)
+		}
+	}
+	if sub.WebhookURL != "" {
+		u, err := url.Parse(sub.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook_url must be an http or https URL")
+		}
+	}
+	for _, t := range sub.EventTypes {
+		if t == "" {
+			return fmt.Errorf("event_types must not contain empty entries")
+		}
+	}
+	return nil
+}
+
+// Period is how much history one report covers.
+func (sub *ReportSubscription) Period() time.Duration {
+	if sub.Frequency == ReportWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// NextRun returns the first delivery time strictly after t: the next
+// sub.Hour:00 UTC, on a Monday for weekly reports.
+func (sub *ReportSubscription) NextRun(t time.Time) time.Time {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day(), sub.Hour, 0, 0, 0, time.UTC)
+	for !next.After(t) || (sub.Frequency == ReportWeekly && next.Weekday() != time.Monday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ReportSubscriptionStore persists report subscriptions. It shares the same
+// *sql.DB connection as the audit Store.
+type ReportSubscriptionStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewReportSubscriptionStore creates the report_subscriptions table (if
+// absent) and returns a ready-to-use ReportSubscriptionStore.
+func NewReportSubscriptionStore(db *sql.DB, isPostgres bool) (*ReportSubscriptionStore, error) {
+	s := &ReportSubscriptionStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS report_subscriptions (
+    subscription_id TEXT NOT NULL PRIMARY KEY,
+    name            TEXT NOT NULL DEFAULT '',
+    frequency       TEXT NOT NULL,
+    hour            INTEGER NOT NULL DEFAULT 0,
+    agent           TEXT NOT NULL DEFAULT '',
+    resource        TEXT NOT NULL DEFAULT '',
+    event_types     TEXT NOT NULL DEFAULT '[]',
+    email           TEXT NOT NULL DEFAULT '[]',
+    webhook_url     TEXT NOT NULL DEFAULT '',
+    owner           TEXT NOT NULL DEFAULT '',
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    created_at      TEXT NOT NULL,
+    updated_at      TEXT NOT NULL,
+    next_run_at     TEXT NOT NULL,
+    last_sent_at    TEXT NOT NULL DEFAULT '',
+    last_error      TEXT NOT NULL DEFAULT ''
+)`); err != nil {
+		return nil, fmt.Errorf("create report_subscriptions schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_report_subscriptions_next_run
+    ON report_subscriptions(next_run_at)`); err != nil {
+		return nil, fmt.Errorf("create report_subscriptions index: %w", err)
+	}
+	return s, nil
+}
+
+// Create stores a new subscription and schedules its first delivery. The
+// caller sets SubscriptionID and Owner.
+func (s *ReportSubscriptionStore) Create(ctx context.Context, sub *ReportSubscription) error {
+	if sub.SubscriptionID == "" || sub.Owner == "" {
+		return fmt.Errorf("subscription_id and owner are required")
+	}
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	sub.CreatedAt, sub.UpdatedAt = now, now
+	sub.NextRunAt = sub.NextRun(now)
+	sub.LastSentAt, sub.LastError = nil, ""
+	eventTypes, email := marshalStrings(sub.EventTypes), marshalStrings(sub.Email)
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO report_subscriptions
+    (subscription_id, name, frequency, hour, agent, resource, event_types, email, webhook_url,
+     owner, tenant_id, created_at, updated_at, next_run_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		sub.SubscriptionID, sub.Name, sub.Frequency, sub.Hour, sub.Agent, sub.Resource, eventTypes, email,
+		sub.WebhookURL, sub.Owner, sub.TenantID, now.Format(annotationTimeFormat),
+		now.Format(annotationTimeFormat), sub.NextRunAt.Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("insert report subscription: %w", err)
+	}
+	return nil
+}
+
+// Get returns one subscription.
+func (s *ReportSubscriptionStore) Get(ctx context.Context, id string) (*ReportSubscription, error) {
+	all, err := s.query(ctx, "WHERE subscription_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return &all[0], nil
+}
+
+// List returns the subscriptions in a tenant (empty = all tenants), narrowed
+// to one owner when owner is non-empty, oldest first.
+func (s *ReportSubscriptionStore) List(ctx context.Context, tenantID, owner string) ([]ReportSubscription, error) {
+	where, args := "WHERE 1=1", []any{}
+	if tenantID != "" {
+		where += " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	if owner != "" {
+		where += " AND owner = ?"
+		args = append(args, owner)
+	}
+	return s.query(ctx, where, args...)
+}
+
+// Update replaces a subscription's settings. A change of frequency or hour
+// reschedules the next delivery.
+func (s *ReportSubscriptionStore) Update(ctx context.Context, sub *ReportSubscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	old, err := s.Get(ctx, sub.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	sub.Owner, sub.TenantID, sub.CreatedAt = old.Owner, old.TenantID, old.CreatedAt
+	sub.LastSentAt, sub.LastError = old.LastSentAt, old.LastError
+	sub.UpdatedAt, sub.NextRunAt = now, old.NextRunAt
+	if sub.Frequency != old.Frequency || sub.Hour != old.Hour {
+		sub.NextRunAt = sub.NextRun(now)
+	}
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE report_subscriptions
+SET name = ?, frequency = ?, hour = ?, agent = ?, resource = ?, event_types = ?, email = ?,
+    webhook_url = ?, updated_at = ?, next_run_at = ?
+WHERE subscription_id = ?`),
+		sub.Name, sub.Frequency, sub.Hour, sub.Agent, sub.Resource, marshalStrings(sub.EventTypes),
+		marshalStrings(sub.Email), sub.WebhookURL, now.Format(annotationTimeFormat),
+		sub.NextRunAt.Format(annotationTimeFormat), sub.SubscriptionID); err != nil {
+		return fmt.Errorf("update report subscription: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a subscription.
+func (s *ReportSubscriptionStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `DELETE FROM report_subscriptions WHERE subscription_id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("delete report subscription: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// Due returns the subscriptions whose next delivery is at or before now.
+func (s *ReportSubscriptionStore) Due(ctx context.Context, now time.Time) ([]ReportSubscription, error) {
+	return s.query(ctx, "WHERE next_run_at <= ?", now.UTC().Format(annotationTimeFormat))
+}
+
+// MarkRun records a delivery attempt and schedules the next one after now.
+// A nil deliveryErr records a successful send. Deliveries missed while the
+// service was down are skipped rather than sent in a burst.
+func (s *ReportSubscriptionStore) MarkRun(ctx context.Context, id string, now time.Time, deliveryErr error) error {
+	sub, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now = now.UTC()
+	next := sub.NextRun(now).Format(annotationTimeFormat)
+	if deliveryErr != nil {
+		_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE report_subscriptions SET next_run_at = ?, last_error = ? WHERE subscription_id = ?`),
+			next, deliveryErr.Error(), id)
+	} else {
+		_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+UPDATE report_subscriptions SET next_run_at = ?, last_sent_at = ?, last_error = '' WHERE subscription_id = ?`),
+			next, now.Format(annotationTimeFormat), id)
+	}
+	if err != nil {
+		return fmt.Errorf("mark report subscription run: %w", err)
+	}
+	return nil
+}
+
+func (s *ReportSubscriptionStore) query(ctx context.Context, where string, args ...any) ([]ReportSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT subscription_id, name, frequency, hour, agent, resource, event_types, email, webhook_url,
+       owner, tenant_id, created_at, updated_at, next_run_at, last_sent_at, last_error
+FROM report_subscriptions
+`+where+`
+ORDER BY created_at, subscription_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list report subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	out := []ReportSubscription{}
+	for rows.Next() {
+		var sub ReportSubscription
+		var eventTypes, email, createdAt, updatedAt, nextRunAt, lastSentAt string
+		if err := rows.Scan(&sub.SubscriptionID, &sub.Name, &sub.Frequency, &sub.Hour, &sub.Agent,
+			&sub.Resource, &eventTypes, &email, &sub.WebhookURL, &sub.Owner, &sub.TenantID,
+			&createdAt, &updatedAt, &nextRunAt, &lastSentAt, &sub.LastError); err != nil {
+			return nil, fmt.Errorf("scan report subscription: %w", err)
+		}
+		json.Unmarshal([]byte(eventTypes), &sub.EventTypes) //nolint:errcheck
+		json.Unmarshal([]byte(email), &sub.Email)           //nolint:errcheck
+		sub.CreatedAt = parseFlexTime(createdAt)
+		sub.UpdatedAt = parseFlexTime(updatedAt)
+		sub.NextRunAt = parseFlexTime(nextRunAt)
+		if lastSentAt != "" {
+			t := parseFlexTime(lastSentAt)
+			sub.LastSentAt = &t
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// marshalStrings encodes a string list column; nil is stored as [].
+func marshalStrings(v []string) string {
+	if v == nil {
+		v = []string{}
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
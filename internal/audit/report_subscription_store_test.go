@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReportSubscription_NextRun(t *testing.T) {
+	// 2026-03-04 is a Wednesday.
+	at := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		freq string
+		hour int
+		want time.Time
+	}{
+		{ReportDaily, 8, time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC)},
+		{ReportDaily, 10, time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)},
+		{ReportWeekly, 8, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{ReportWeekly, 0, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		sub := &ReportSubscription{Frequency: tt.freq, Hour: tt.hour}
+		if got := sub.NextRun(at); !got.Equal(tt.want) {
+			t.Errorf("%s at %02d:00: NextRun = %s, want %s", tt.freq, tt.hour, got, tt.want)
+		}
+	}
+	// A run exactly at the delivery time schedules the next one.
+	sub := &ReportSubscription{Frequency: ReportWeekly, Hour: 8}
+	monday := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	if got := sub.NextRun(monday); !got.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("NextRun(%s) = %s, want a week later", monday, got)
+	}
+}
+
+func TestReportSubscription_Validate(t *testing.T) {
+	valid := ReportSubscription{Frequency: ReportDaily, Email: []string{"dba@example.com"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for name, mutate := range map[string]func(*ReportSubscription){
+		"frequency":   func(s *ReportSubscription) { s.Frequency = "hourly" },
+		"hour":        func(s *ReportSubscription) { s.Hour = 24 },
+		"no delivery": func(s *ReportSubscription) { s.Email = nil },
+		"email":       func(s *ReportSubscription) { s.Email = []string{"a@b\r\nBcc: x@y"} },
+		"webhook":     func(s *ReportSubscription) { s.WebhookURL = "file:///etc/passwd" },
+		"event type":  func(s *ReportSubscription) { s.EventTypes = []string{""} },
+	} {
+		sub := valid
+		mutate(&sub)
+		if err := sub.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted %+v", name, sub)
+		}
+	}
+}
+
+func TestReportSubscriptionStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	subs, err := NewReportSubscriptionStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewReportSubscriptionStore: %v", err)
+	}
+
+	for _, sub := range []*ReportSubscription{
+		{SubscriptionID: "rsub_1", Frequency: ReportDaily, Email: []string{"alice@example.com"}, Owner: "alice", TenantID: "payments"},
+		{SubscriptionID: "rsub_2", Frequency: ReportWeekly, WebhookURL: "https://hooks.example.com/r", Owner: "bob", TenantID: "payments"},
+		{SubscriptionID: "rsub_3", Frequency: ReportDaily, Email: []string{"carol@example.com"}, Owner: "carol", TenantID: "search"},
+	} {
+		if err := subs.Create(ctx, sub); err != nil {
+			t.Fatalf("Create %s: %v", sub.SubscriptionID, err)
+		}
+	}
+	if err := subs.Create(ctx, &ReportSubscription{SubscriptionID: "rsub_4", Frequency: "hourly", Email: []string{"x@y"}, Owner: "x"}); err == nil {
+		t.Error("Create accepted an invalid frequency")
+	}
+
+	got, err := subs.List(ctx, "payments", "")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("List(payments) = %d subscriptions, %v; want 2", len(got), err)
+	}
+	if got, _ := subs.List(ctx, "", "carol"); len(got) != 1 || got[0].SubscriptionID != "rsub_3" {
+		t.Errorf("List(owner=carol) = %+v, want rsub_3", got)
+	}
+
+	// Updating the schedule reschedules; owner and tenant stay put.
+	upd := &ReportSubscription{SubscriptionID: "rsub_1", Frequency: ReportWeekly, Hour: 6, Agent: "k8s_agent",
+		EventTypes: []string{"tool_execution"}, Email: []string{"alice@example.com"}, Owner: "mallory", TenantID: "search"}
+	if err := subs.Update(ctx, upd); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	sub, err := subs.Get(ctx, "rsub_1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if sub.Owner != "alice" || sub.TenantID != "payments" || sub.Frequency != ReportWeekly ||
+		sub.NextRunAt.Weekday() != time.Monday || sub.NextRunAt.Hour() != 6 ||
+		len(sub.EventTypes) != 1 || sub.Agent != "k8s_agent" {
+		t.Errorf("after Update: %+v", sub)
+	}
+
+	// Nothing is due yet; after its delivery time, a run is recorded and the
+	// next one is scheduled.
+	if due, _ := subs.Due(ctx, time.Now()); len(due) != 0 {
+		t.Errorf("Due(now) = %d, want 0", len(due))
+	}
+	later := sub.NextRunAt.Add(time.Minute)
+	due, err := subs.Due(ctx, later)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 3 {
+		t.Fatalf("Due(%s) = %d subscriptions, want 3", later, len(due))
+	}
+	if err := subs.MarkRun(ctx, "rsub_2", later, errors.New("webhook returned 500")); err != nil {
+		t.Fatalf("MarkRun failed delivery: %v", err)
+	}
+	if err := subs.MarkRun(ctx, "rsub_1", later, nil); err != nil {
+		t.Fatalf("MarkRun: %v", err)
+	}
+	s2, _ := subs.Get(ctx, "rsub_2")
+	if s2.LastError != "webhook returned 500" || s2.LastSentAt != nil || !s2.NextRunAt.After(later) {
+		t.Errorf("after failed run: %+v", s2)
+	}
+	s1, _ := subs.Get(ctx, "rsub_1")
+	if s1.LastError != "" || s1.LastSentAt == nil || !s1.NextRunAt.After(later) {
+		t.Errorf("after run: %+v", s1)
+	}
+
+	if err := subs.Delete(ctx, "rsub_3"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := subs.Get(ctx, "rsub_3"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrSubscriptionNotFound", err)
+	}
+	if err := subs.Delete(ctx, "rsub_3"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("second Delete: %v, want ErrSubscriptionNotFound", err)
+	}
+}
+
+func TestStore_SubscriptionReport(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	n := 0
+	record := func(e *Event) {
+		n++
+		e.EventID = fmt.Sprintf("evt_%d", n)
+		e.Timestamp = base.Add(time.Duration(n) * time.Minute)
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	tool := func(agent, ns, status string, class ActionClass) *Event {
+		return &Event{
+			EventType:   EventTypeToolExecution,
+			ActionClass: class,
+			Session:     Session{ID: "s", AgentName: agent},
+			Tool:        &ToolExecution{Name: "scale_deployment", Agent: agent, Parameters: map[string]any{"namespace": ns}},
+			Outcome:     &Outcome{Status: status, ErrorMessage: map[bool]string{true: "timeout"}[status == "error"]},
+		}
+	}
+	record(tool("k8s_agent", "prod-payments", "success", ActionWrite))
+	record(tool("k8s_agent", "prod-payments", "error", ActionWrite))
+	record(tool("k8s_agent", "prod-search", "success", ActionDestructive))
+	record(tool("k8s_agent", "staging", "success", ActionRead))
+	record(tool("postgres_agent", "prod-payments", "success", ActionRead))
+	record(&Event{
+		EventType:      EventTypePolicyDecision,
+		Session:        Session{ID: "s", AgentName: "k8s_agent"},
+		PolicyDecision: &PolicyDecision{ResourceType: "kubernetes", ResourceName: "prod-payments", Action: "destructive", Effect: "deny", PolicyName: "no-deletes"},
+	})
+	record(&Event{
+		EventType:      EventTypePolicyDecision,
+		Session:        Session{ID: "s", AgentName: "k8s_agent"},
+		PolicyDecision: &PolicyDecision{ResourceType: "kubernetes", ResourceName: "prod-payments", Action: "write", Effect: "deny", PolicyName: "no-writes", DryRun: true},
+	})
+
+	sub := &ReportSubscription{SubscriptionID: "rsub_1", Frequency: ReportDaily, Agent: "k8s_agent", Resource: "prod-*"}
+	rep, err := store.SubscriptionReport(ctx, sub, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SubscriptionReport: %v", err)
+	}
+	if rep.TotalEvents != 5 || rep.ToolExecutions != 3 || rep.ToolErrors != 1 || rep.Destructive != 1 ||
+		rep.PolicyDecisions != 2 || rep.Denied != 1 {
+		t.Errorf("counts = %+v", rep)
+	}
+	// The failed call, the destructive call and the enforced denial; not the dry run.
+	if rep.NotableTotal != 3 || len(rep.Notable) != 3 || rep.Notable[2].Summary != "destructive on kubernetes prod-payments denied by no-deletes" {
+		t.Errorf("notable = %+v", rep.Notable)
+	}
+	if len(rep.TopResources) != 2 || rep.TopResources[0] != (ReportCount{Name: "prod-payments", Count: 4}) {
+		t.Errorf("top resources = %+v", rep.TopResources)
+	}
+
+	// Event types narrow the query itself.
+	sub = &ReportSubscription{SubscriptionID: "rsub_2", Frequency: ReportDaily, EventTypes: []string{"policy_decision"}}
+	rep, err = store.SubscriptionReport(ctx, sub, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SubscriptionReport: %v", err)
+	}
+	if rep.TotalEvents != 2 || rep.ByEventType["policy_decision"] != 2 || rep.ToolExecutions != 0 {
+		t.Errorf("event type filter: %+v", rep)
+	}
+}
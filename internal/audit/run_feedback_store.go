@@ -422,3 +422,9 @@ func scanRunFeedbackRow(s feedbackScanner) (*RunFeedback, error) {
 	}
 	return &fb, nil
 }
+
+// EraseSubjectRecords replaces ids as feedback operator with subject and
+// erases the verdict notes they wrote.
+func (s *RunFeedbackStore) EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error) {
+	return eraseSubjectRows(ctx, s.db, s.isPostgres, "run_feedback", "operator", []string{"verdict_notes"}, ids, subject)
+}
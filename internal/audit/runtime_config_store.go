@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RuntimeSetting describes a setting that can be changed while the services
+// run, through PUT /v1/config, instead of by restarting them with new flags.
+// Only settings that are safe to change live are listed: alert thresholds,
+// notifier URLs and retention periods. Credentials, storage and
+// authorization settings stay flags.
+type RuntimeSetting struct {
+	Key         string `json:"key"`
+	Component   string `json:"component"` // "auditd" applies it itself; "auditor" syncs it from auditd
+	Kind        string `json:"kind"`      // "url", "duration", "int" or "float"
+	Description string `json:"description"`
+}
+
+// RuntimeSettings are the settings that can be changed at runtime.
+var RuntimeSettings = []RuntimeSetting{
+	{Key: "auditd.approval_webhook_url", Component: "auditd", Kind: "url",
+		Description: "Webhook approval and freeze notifications are posted to (empty disables it)"},
+	{Key: "auditd.calibration_retention", Component: "auditd", Kind: "duration",
+		Description: "How long calibration snapshots are kept (0 keeps them forever)"},
+	{Key: "auditor.max_events_per_minute", Component: "auditor", Kind: "int",
+		Description: "Alert on more events than this in a minute (0 disables the check)"},
+	{Key: "auditor.injection_threshold", Component: "auditor", Kind: "float",
+		Description: "Alert when an event's prompt-injection risk score reaches this, 0 to 1 (0 disables the check)"},
+	{Key: "auditor.clock_skew_threshold", Component: "auditor", Kind: "duration",
+		Description: "Alert when an agent's clock is this far from auditd's (0 disables the check)"},
+}
+
+// LookupRuntimeSetting returns the runtime setting named key.
+func LookupRuntimeSetting(key string) (RuntimeSetting, bool) {
+	for _, s := range RuntimeSettings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return RuntimeSetting{}, false
+}
+
+// Normalize validates value for the setting and returns it in canonical
+// form: durations as Go duration strings, numbers without redundant digits.
+func (s RuntimeSetting) Normalize(value string) (string, error) {
+	switch s.Kind {
+	case "url":
+		if value == "" {
+			return "", nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("%s: expected an http(s) URL", s.Key)
+		}
+		return value, nil
+	case "duration":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return "", fmt.Errorf("%s: expected a non-negative duration such as 30s or 720h", s.Key)
+		}
+		return d.String(), nil
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%s: expected a non-negative integer", s.Key)
+		}
+		return strconv.Itoa(n), nil
+	case "float":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 || f > 1 {
+			return "", fmt.Errorf("%s: expected a number between 0 and 1", s.Key)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%s: unknown kind %q", s.Key, s.Kind)
+}
+
+// Display returns value as it may be shown in API responses and audit
+// events. Webhook URLs often embed a token in their path or query, so URLs
+// are cut down to scheme and host.
+func (s RuntimeSetting) Display(value string) string {
+	if s.Kind != "url" || value == "" {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	if (u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.User == nil {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
+
+// RuntimeConfigEntry is a runtime override of a setting.
+type RuntimeConfigEntry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConfigChange is the payload of a config_change event: the runtime settings
+// one request changed. Values are shown as RuntimeSetting.Display returns
+// them.
+type ConfigChange struct {
+	Changes []SettingChange `json:"changes"`
+}
+
+// SettingChange is one setting's change. Reset is true when the runtime
+// override was removed and the setting went back to its startup value.
+type SettingChange struct {
+	Key   string `json:"key"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	Reset bool   `json:"reset,omitempty"`
+}
+
+// RuntimeConfigStore persists runtime setting overrides so that they survive
+// restarts. It shares the same *sql.DB connection as the audit Store.
+type RuntimeConfigStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewRuntimeConfigStore creates the runtime_config table (if absent) and
+// returns a ready-to-use RuntimeConfigStore.
+func NewRuntimeConfigStore(db *sql.DB, isPostgres bool) (*RuntimeConfigStore, error) {
+	s := &RuntimeConfigStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS runtime_config (
+    name       TEXT NOT NULL PRIMARY KEY,
+    value      TEXT NOT NULL,
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at TEXT NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create runtime_config schema: %w", err)
+	}
+	return s, nil
+}
+
+// All returns the stored overrides by key. Overrides of settings that are
+// no longer in RuntimeSettings are skipped.
+func (s *RuntimeConfigStore) All(ctx context.Context) (map[string]RuntimeConfigEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, value, updated_by, updated_at FROM runtime_config`)
+	if err != nil {
+		return nil, fmt.Errorf("list runtime config: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]RuntimeConfigEntry{}
+	for rows.Next() {
+		var e RuntimeConfigEntry
+		var updatedAt string
+		if err := rows.Scan(&e.Key, &e.Value, &e.UpdatedBy, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan runtime config: %w", err)
+		}
+		if _, ok := LookupRuntimeSetting(e.Key); !ok {
+			continue
+		}
+		e.UpdatedAt = parseFlexTime(updatedAt)
+		out[e.Key] = e
+	}
+	return out, rows.Err()
+}
+
+// Set stores an override. The caller validates the value with
+// RuntimeSetting.Normalize.
+func (s *RuntimeConfigStore) Set(ctx context.Context, e RuntimeConfigEntry) error {
+	if _, ok := LookupRuntimeSetting(e.Key); !ok {
+		return fmt.Errorf("unknown runtime setting %q", e.Key)
+	}
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO runtime_config (name, value, updated_by, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET value = excluded.value, updated_by = excluded.updated_by,
+    updated_at = excluded.updated_at`),
+		e.Key, e.Value, e.UpdatedBy, e.UpdatedAt.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("set runtime config: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an override, if any.
+func (s *RuntimeConfigStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `DELETE FROM runtime_config WHERE name = ?`), key); err != nil {
+		return fmt.Errorf("delete runtime config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestRuntimeSetting_Normalize(t *testing.T) {
+	tests := []struct {
+		key, in, want string
+		wantErr       bool
+	}{
+		{"auditd.calibration_retention", "720h", "720h0m0s", false},
+		{"auditd.calibration_retention", "-1h", "", true},
+		{"auditd.approval_webhook_url", "", "", false},
+		{"auditd.approval_webhook_url", "ftp://example.com", "", true},
+		{"auditor.max_events_per_minute", "0500", "500", false},
+		{"auditor.max_events_per_minute", "many", "", true},
+		{"auditor.injection_threshold", "0.70", "0.7", false},
+		{"auditor.injection_threshold", "1.5", "", true},
+	}
+	for _, tt := range tests {
+		s, ok := LookupRuntimeSetting(tt.key)
+		if !ok {
+			t.Fatalf("no setting %s", tt.key)
+		}
+		got, err := s.Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: Normalize(%q) = %q, %v", tt.key, tt.in, got, err)
+		}
+	}
+}
+
+func TestRuntimeSetting_Display(t *testing.T) {
+	s, _ := LookupRuntimeSetting("auditd.approval_webhook_url")
+	for in, want := range map[string]string{
+		"https://hooks.slack.com/services/T0/B0/secret": "https://hooks.slack.com/…",
+		"https://alerts.example.com":                    "https://alerts.example.com",
+		"":                                              "",
+	} {
+		if got := s.Display(in); got != want {
+			t.Errorf("Display(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if n, _ := LookupRuntimeSetting("auditor.max_events_per_minute"); n.Display("500") != "500" {
+		t.Error("non-URL values should be shown as they are")
+	}
+}
+
+func TestRuntimeConfigStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	rc, err := NewRuntimeConfigStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewRuntimeConfigStore: %v", err)
+	}
+
+	if err := rc.Set(ctx, RuntimeConfigEntry{Key: "no.such_setting", Value: "1"}); err == nil {
+		t.Error("Set accepted an unknown setting")
+	}
+	for _, v := range []string{"100", "500"} {
+		if err := rc.Set(ctx, RuntimeConfigEntry{Key: "auditor.max_events_per_minute", Value: v, UpdatedBy: "alice"}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	all, err := rc.All(ctx)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	e := all["auditor.max_events_per_minute"]
+	if len(all) != 1 || e.Value != "500" || e.UpdatedBy != "alice" || e.UpdatedAt.IsZero() {
+		t.Errorf("All = %+v", all)
+	}
+
+	if err := rc.Delete(ctx, "auditor.max_events_per_minute"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if all, _ := rc.All(ctx); len(all) != 0 {
+		t.Errorf("after Delete: %+v", all)
+	}
+}
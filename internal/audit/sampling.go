@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SamplingPolicy thins out high-volume, low-value events before they are
+// persisted. A rate of N keeps one event in N of its class; 0 or 1 keeps
+// every event. Events that carry governance weight are never sampled (see
+// alwaysKept), and every event sampled out is still counted in
+// sampled_event_counts so that Stats stays accurate.
+type SamplingPolicy struct {
+	// ReadToolExecutions is the rate for successful tool_execution events
+	// with action class read.
+	ReadToolExecutions int
+
+	// EventTypes holds per-type rates for other low-value event types,
+	// e.g. tool_invoked or gateway_request. Types that are always kept may
+	// not be listed.
+	EventTypes map[EventType]int
+}
+
+// neverSampledTypes are event types kept regardless of the sampling policy:
+// policy decisions, approvals, violations and everything an incident review
+// or regulator would ask for.
+var neverSampledTypes = map[EventType]bool{
+	EventTypePolicyDecision:         true,
+	EventTypeGovernanceViolation:    true,
+	EventTypeDelegationVerification: true,
+	EventTypeGateAcknowledged:       true,
+	EventTypeRollbackInitiated:      true,
+	EventTypeRollbackExecuted:       true,
+	EventTypeRollbackVerified:       true,
+	EventTypeSecurityResponse:       true,
+	EventTypeEmergencyFreeze:        true,
+	EventTypeEmergencyUnfreeze:      true,
+	EventTypeOutOfBandChange:        true,
+	EventTypeBackupStale:            true,
+	EventTypeStartupReport:          true,
+	EventTypeRedaction:              true,
+	EventTypeConfigChange:           true,
+	EventTypeInventoryDrift:         true,
+	EventTypeApprovalSLABreach:      true,
+}
+
+// Validate reports rates that are negative or target a type that is always
+// kept.
+func (p SamplingPolicy) Validate() error {
+	if p.ReadToolExecutions < 0 {
+		return fmt.Errorf("sampling rate for read tool executions must not be negative")
+	}
+	for t, n := range p.EventTypes {
+		if n < 0 {
+			return fmt.Errorf("sampling rate for %s must not be negative", t)
+		}
+		if neverSampledTypes[t] {
+			return fmt.Errorf("%s events are always kept and cannot be sampled", t)
+		}
+	}
+	return nil
+}
+
+// ParseSamplingRates parses "type=N,type=N" (e.g. "tool_invoked=10") into
+// per-type sampling rates.
+func ParseSamplingRates(s string) (map[EventType]int, error) {
+	rates := map[EventType]int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid sampling rate %q: expected type=N", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid sampling rate %q: N must be a positive integer", part)
+		}
+		rates[EventType(strings.TrimSpace(name))] = n
+	}
+	return rates, nil
+}
+
+// alwaysKept reports whether an event carries enough weight that it must be
+// persisted whatever the policy: writes, destructive actions, policy and
+// approval records, failed tool executions and events flagged for prompt
+// injection.
+func alwaysKept(e *Event) bool {
+	if neverSampledTypes[e.EventType] {
+		return true
+	}
+	if e.ActionClass == ActionWrite || e.ActionClass == ActionDestructive || e.ActionClass == ActionEscalation {
+		return true
+	}
+	if e.PolicyDecision != nil || e.Approval != nil || e.InjectionRisk != nil {
+		return true
+	}
+	return e.Outcome != nil && e.Outcome.Status != "" && e.Outcome.Status != "success"
+}
+
+// sampler applies a SamplingPolicy with one counter per class, keeping the
+// first event of every N so a quiet system still records its reads.
+type sampler struct {
+	policy SamplingPolicy
+	mu     sync.Mutex
+	seen   map[string]uint64
+}
+
+func newSampler(p SamplingPolicy) *sampler {
+	if p.ReadToolExecutions <= 1 && len(p.EventTypes) == 0 {
+		return nil
+	}
+	return &sampler{policy: p, seen: make(map[string]uint64)}
+}
+
+// keep reports whether e should be persisted. A nil sampler keeps everything.
+func (s *sampler) keep(e *Event) bool {
+	if s == nil || alwaysKept(e) {
+		return true
+	}
+	class, rate := string(e.EventType), s.policy.EventTypes[e.EventType]
+	if e.EventType == EventTypeToolExecution && e.ActionClass == ActionRead {
+		class, rate = "read_tool_execution", s.policy.ReadToolExecutions
+	}
+	if rate <= 1 {
+		return true
+	}
+	s.mu.Lock()
+	n := s.seen[class]
+	s.seen[class] = n + 1
+	s.mu.Unlock()
+	return n%uint64(rate) == 0
+}
+
+// createSampledCountsTable creates the per-minute counters for events that
+// were sampled out.
+func createSampledCountsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS sampled_event_counts (
+    minute       TEXT    NOT NULL,
+    tenant_id    TEXT    NOT NULL DEFAULT '',
+    event_type   TEXT    NOT NULL,
+    action_class TEXT    NOT NULL DEFAULT '',
+    count        INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (minute, tenant_id, event_type, action_class)
+)`)
+	return err
+}
+
+// countSampledOut adds a sampled-out event to its minute's counter.
+func (s *Store) countSampledOut(ctx context.Context, e *Event) error {
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+		INSERT INTO sampled_event_counts (minute, tenant_id, event_type, action_class, count)
+		VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT(minute, tenant_id, event_type, action_class)
+		DO UPDATE SET count = sampled_event_counts.count + 1`),
+		e.Timestamp.UTC().Truncate(time.Minute).Format(sqliteTimeFormat),
+		e.Session.TenantID, string(e.EventType), string(e.ActionClass))
+	if err != nil {
+		return fmt.Errorf("count sampled-out event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSampling_KeepsOneInNReadsAndAllImportantEvents(t *testing.T) {
+	store, err := NewStore(StoreConfig{
+		DBPath:   filepath.Join(t.TempDir(), "test.db"),
+		Sampling: SamplingPolicy{ReadToolExecutions: 5, EventTypes: map[EventType]int{EventTypeToolInvoked: 2}},
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	record := func(e *Event) {
+		t.Helper()
+		e.Session = Session{ID: "s"}
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		record(&Event{EventType: EventTypeToolExecution, ActionClass: ActionRead, Outcome: &Outcome{Status: "success"}})
+		record(&Event{EventType: EventTypeToolInvoked, ActionClass: ActionRead})
+	}
+	// Never sampled: writes, destructive actions, failed reads, policy decisions.
+	record(&Event{EventType: EventTypeToolExecution, ActionClass: ActionWrite, Outcome: &Outcome{Status: "success"}})
+	record(&Event{EventType: EventTypeToolExecution, ActionClass: ActionDestructive, Outcome: &Outcome{Status: "success"}})
+	record(&Event{EventType: EventTypeToolExecution, ActionClass: ActionRead, Outcome: &Outcome{Status: "error"}})
+	recordDecision(t, store, "prod", "allow", "db-policy")
+
+	var stored int
+	if err := store.DB().QueryRow(`SELECT COUNT(*) FROM audit_events`).Scan(&stored); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	// 2 of 10 reads, 5 of 10 tool_invoked, and all 4 important events.
+	if stored != 11 {
+		t.Errorf("stored %d events, want 11", stored)
+	}
+
+	stats, err := store.Stats(ctx, StatsOptions{Since: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.SampledOut != 13 || stats.TotalEvents != 24 {
+		t.Errorf("sampled_out=%d total=%d, want 13 and 24", stats.SampledOut, stats.TotalEvents)
+	}
+	if stats.ByType["tool_execution"] != 13 || stats.ByType["tool_invoked"] != 10 {
+		t.Errorf("by_type = %v", stats.ByType)
+	}
+	if stats.ByActionClass["read"] != 11 || stats.ByActionClass["write"] != 1 || stats.ToolErrors != 1 {
+		t.Errorf("by_action_class = %v, tool_errors = %d", stats.ByActionClass, stats.ToolErrors)
+	}
+
+	// The chain is unaffected by events that were never stored.
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity = %+v, %v", status, err)
+	}
+}
+
+func TestSamplingPolicy_Validate(t *testing.T) {
+	if err := (SamplingPolicy{EventTypes: map[EventType]int{EventTypePolicyDecision: 10}}).Validate(); err == nil {
+		t.Error("expected error sampling policy_decision events")
+	}
+	if err := (SamplingPolicy{ReadToolExecutions: -1}).Validate(); err == nil {
+		t.Error("expected error for a negative rate")
+	}
+}
+
+func TestParseSamplingRates(t *testing.T) {
+	rates, err := ParseSamplingRates(" tool_invoked=10, gateway_request=3 ,")
+	if err != nil {
+		t.Fatalf("ParseSamplingRates: %v", err)
+	}
+	if len(rates) != 2 || rates[EventTypeToolInvoked] != 10 || rates[EventTypeGatewayRequest] != 3 {
+		t.Errorf("rates = %v", rates)
+	}
+	for _, bad := range []string{"tool_invoked", "tool_invoked=0", "tool_invoked=x"} {
+		if _, err := ParseSamplingRates(bad); err == nil {
+			t.Errorf("ParseSamplingRates(%q): expected error", bad)
+		}
+	}
+}
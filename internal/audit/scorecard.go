@@ -0,0 +1,350 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScorecardOptions selects the window Scorecards reports on. Trends compare
+// it with the previous window of the same length, which ends at Since.
+type ScorecardOptions struct {
+	Since    time.Time // inclusive lower bound on timestamp; required
+	Until    time.Time // exclusive upper bound on timestamp; required
+	TenantID string    // filter by tenant; empty = all tenants
+}
+
+// ScorecardMetrics are the governance metrics of one agent or user over a
+// window. A rate is 0 when the count it is taken over is 0.
+type ScorecardMetrics struct {
+	ToolExecutions int     `json:"tool_executions"`
+	ToolErrors     int     `json:"tool_errors"`
+	ErrorRate      float64 `json:"error_rate"`
+	// Destructive tool executions, and how many of them carried an approved
+	// or auto-approved approval.
+	Destructive         int     `json:"destructive"`
+	DestructiveApproved int     `json:"destructive_approved"`
+	ApprovalCompliance  float64 `json:"approval_compliance"`
+	PolicyDecisions     int     `json:"policy_decisions"`
+	Denied              int     `json:"denied"`
+	DenialRate          float64 `json:"denial_rate"`
+	// Delegations that reported a routing confidence, and its mean.
+	Delegations    int     `json:"delegations"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	// Blast-radius conditions (max_rows_affected, max_pods_affected)
+	// evaluated on policy decisions: how many, how many were exceeded, and
+	// the mean and largest share of the limit the operations used.
+	BlastRadiusChecks   int     `json:"blast_radius_checks"`
+	BlastRadiusExceeded int     `json:"blast_radius_exceeded"`
+	BlastRadiusUsage    float64 `json:"blast_radius_usage"`
+	BlastRadiusPeak     float64 `json:"blast_radius_peak"`
+
+	confidenceSum float64
+	usageSum      float64
+}
+
+// Scorecard is one agent's or user's metrics, with the previous window's
+// for comparison.
+type Scorecard struct {
+	Name string `json:"name"`
+	ScorecardMetrics
+	// Previous is nil when the agent or user was not active in the previous window.
+	Previous *ScorecardMetrics `json:"previous,omitempty"`
+	// Trend maps each rate present in both windows to "↑", "↓" or "→".
+	Trend map[string]string `json:"trend"`
+	// Worsened lists the rates whose trend points towards more risk: error,
+	// denial and blast-radius usage up, or approval compliance down.
+	Worsened []string `json:"worsened"`
+}
+
+// Scorecards is the per-agent and per-user governance scorecard for a window.
+type Scorecards struct {
+	Since         string      `json:"since"`
+	Until         string      `json:"until"`
+	PreviousSince string      `json:"previous_since"`
+	Agents        []Scorecard `json:"agents"`
+	Users         []Scorecard `json:"users"`
+}
+
+// scorecardTrendDelta is the smallest change of a rate shown as ↑ or ↓.
+const scorecardTrendDelta = 0.02
+
+// scorecardRates are the rates Scorecard.Trend covers. risk is the sign of
+// the change that means more risk: 1 for up, -1 for down, 0 for neither.
+// value reports false when the window has nothing to take the rate over.
+var scorecardRates = []struct {
+	name  string
+	risk  float64
+	value func(m *ScorecardMetrics) (float64, bool)
+}{
+	{"error_rate", 1, func(m *ScorecardMetrics) (float64, bool) { return m.ErrorRate, m.ToolExecutions > 0 }},
+	{"approval_compliance", -1, func(m *ScorecardMetrics) (float64, bool) { return m.ApprovalCompliance, m.Destructive > 0 }},
+	{"denial_rate", 1, func(m *ScorecardMetrics) (float64, bool) { return m.DenialRate, m.PolicyDecisions > 0 }},
+	{"mean_confidence", 0, func(m *ScorecardMetrics) (float64, bool) { return m.MeanConfidence, m.Delegations > 0 }},
+	{"blast_radius_usage", 1, func(m *ScorecardMetrics) (float64, bool) { return m.BlastRadiusUsage, m.BlastRadiusChecks > 0 }},
+}
+
+// Scorecards computes the governance scorecard of every agent and user active
+// in the window, from tool_execution, policy_decision and delegation_decision
+// events. An event is credited to the agent that recorded it (the tool's
+// agent, the delegated-to agent, or the session's agent for policy
+// decisions) and to the user or service the policy decision names, or
+// otherwise any other event in its trace names.
+func (s *Store) Scorecards(ctx context.Context, opts ScorecardOptions) (*Scorecards, error) {
+	if opts.Since.IsZero() || opts.Until.IsZero() || !opts.Since.Before(opts.Until) {
+		return nil, fmt.Errorf("scorecards need a window with since before until")
+	}
+	prevSince := opts.Since.Add(-opts.Until.Sub(opts.Since))
+	agents, users, err := s.scorecardWindow(ctx, opts.Since, opts.Until, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	prevAgents, prevUsers, err := s.scorecardWindow(ctx, prevSince, opts.Since, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &Scorecards{
+		Since:         opts.Since.UTC().Format(time.RFC3339),
+		Until:         opts.Until.UTC().Format(time.RFC3339),
+		PreviousSince: prevSince.UTC().Format(time.RFC3339),
+		Agents:        compareScorecards(agents, prevAgents),
+		Users:         compareScorecards(users, prevUsers),
+	}, nil
+}
+
+// scorecardWindow accumulates the metrics of every agent and user in [since, until).
+func (s *Store) scorecardWindow(ctx context.Context, since, until time.Time, tenantID string) (agents, users map[string]*ScorecardMetrics, err error) {
+	query := `SELECT raw_json FROM audit_events WHERE timestamp >= ? AND timestamp < ? AND event_type IN (?, ?, ?, ?)`
+	args := []any{
+		since.UTC().Format(sqliteTimeFormat), until.UTC().Format(sqliteTimeFormat),
+		string(EventTypeToolExecution), string(EventTypePolicyDecision),
+		string(EventTypeDelegation), string(EventTypeGatewayRequest),
+	}
+	if tenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query+" ORDER BY id"), args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scorecard query: %w", err)
+	}
+	var events []Event
+	traceUser := make(map[string]string)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan scorecard event: %w", err)
+		}
+		var e Event
+		if json.Unmarshal([]byte(raw), &e) != nil {
+			continue
+		}
+		if u := scorecardUser(&e); u != "" && e.TraceID != "" && traceUser[e.TraceID] == "" {
+			traceUser[e.TraceID] = u
+		}
+		events = append(events, e)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agents = make(map[string]*ScorecardMetrics)
+	users = make(map[string]*ScorecardMetrics)
+	metrics := func(m map[string]*ScorecardMetrics, name string) *ScorecardMetrics {
+		if name == "" {
+			return nil
+		}
+		if m[name] == nil {
+			m[name] = &ScorecardMetrics{}
+		}
+		return m[name]
+	}
+	for i := range events {
+		e := &events[i]
+		if e.EventType == EventTypeGatewayRequest {
+			continue
+		}
+		user := scorecardUser(e)
+		if user == "" {
+			user = traceUser[e.TraceID]
+		}
+		for _, m := range []*ScorecardMetrics{metrics(agents, scorecardAgent(e)), metrics(users, user)} {
+			if m != nil {
+				m.add(e)
+			}
+		}
+	}
+	for _, m := range agents {
+		m.finish()
+	}
+	for _, m := range users {
+		m.finish()
+	}
+	return agents, users, nil
+}
+
+// scorecardAgent is the agent an event is credited to.
+func scorecardAgent(e *Event) string {
+	switch {
+	case e.EventType == EventTypeToolExecution && e.Tool != nil && e.Tool.Agent != "":
+		return e.Tool.Agent
+	case e.EventType == EventTypeDelegation && e.Decision != nil:
+		return e.Decision.Agent
+	}
+	return e.Session.AgentName
+}
+
+// scorecardUser is the user or service an event names itself.
+func scorecardUser(e *Event) string {
+	if pd := e.PolicyDecision; pd != nil {
+		if pd.UserID != "" {
+			return pd.UserID
+		}
+		if pd.Service != "" {
+			return pd.Service
+		}
+	}
+	return e.Session.UserID
+}
+
+// add counts one event.
+func (m *ScorecardMetrics) add(e *Event) {
+	switch e.EventType {
+	case EventTypeToolExecution:
+		m.ToolExecutions++
+		if e.Outcome != nil && e.Outcome.Status == "error" {
+			m.ToolErrors++
+		}
+		if e.ActionClass == ActionDestructive {
+			m.Destructive++
+			if e.Approval != nil && (e.Approval.Status == ApprovalApproved || e.Approval.Status == ApprovalAutoApproved) {
+				m.DestructiveApproved++
+			}
+		}
+	case EventTypePolicyDecision:
+		if e.PolicyDecision == nil {
+			return
+		}
+		m.PolicyDecisions++
+		if e.PolicyDecision.Effect == "deny" {
+			m.Denied++
+		}
+		for _, c := range blastRadiusConditions(e.PolicyDecision.Trace) {
+			m.BlastRadiusChecks++
+			if !c.Passed {
+				m.BlastRadiusExceeded++
+			}
+			var affected, limit int
+			var unit string
+			if n, _ := fmt.Sscanf(c.Detail, "%d %s affected, limit is %d", &affected, &unit, &limit); n == 3 && limit > 0 {
+				usage := float64(affected) / float64(limit)
+				m.usageSum += usage
+				if usage > m.BlastRadiusPeak {
+					m.BlastRadiusPeak = usage
+				}
+			}
+		}
+	case EventTypeDelegation:
+		if e.Decision != nil && e.Decision.Confidence > 0 {
+			m.Delegations++
+			m.confidenceSum += e.Decision.Confidence
+		}
+	}
+}
+
+// finish turns the counts into rates.
+func (m *ScorecardMetrics) finish() {
+	ratio := func(n, d int) float64 {
+		if d == 0 {
+			return 0
+		}
+		return round3(float64(n) / float64(d))
+	}
+	m.ErrorRate = ratio(m.ToolErrors, m.ToolExecutions)
+	m.ApprovalCompliance = ratio(m.DestructiveApproved, m.Destructive)
+	m.DenialRate = ratio(m.Denied, m.PolicyDecisions)
+	if m.Delegations > 0 {
+		m.MeanConfidence = round3(m.confidenceSum / float64(m.Delegations))
+	}
+	if m.BlastRadiusChecks > 0 {
+		m.BlastRadiusUsage = round3(m.usageSum / float64(m.BlastRadiusChecks))
+	}
+	m.BlastRadiusPeak = round3(m.BlastRadiusPeak)
+}
+
+// blastRadiusCondition is the part of a policy.ConditionTrace Scorecards
+// reads. The trace is kept as raw JSON to avoid importing the policy package.
+type blastRadiusCondition struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"` // "<n> rows affected, limit is <limit>"
+}
+
+// blastRadiusConditions returns the max_rows_affected and max_pods_affected
+// conditions of the rule a policy decision trace matched.
+func blastRadiusConditions(trace json.RawMessage) []blastRadiusCondition {
+	if len(trace) == 0 {
+		return nil
+	}
+	var t struct {
+		PoliciesEvaluated []struct {
+			Rules []struct {
+				Conditions []blastRadiusCondition `json:"conditions"`
+			} `json:"rules"`
+		} `json:"policies_evaluated"`
+	}
+	if json.Unmarshal(trace, &t) != nil {
+		return nil
+	}
+	var out []blastRadiusCondition
+	for _, p := range t.PoliciesEvaluated {
+		for _, r := range p.Rules {
+			for _, c := range r.Conditions {
+				if c.Name == "max_rows_affected" || c.Name == "max_pods_affected" {
+					out = append(out, c)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// compareScorecards builds the scorecards of the current window, sorted by
+// name, with each rate's trend against the previous window.
+func compareScorecards(current, previous map[string]*ScorecardMetrics) []Scorecard {
+	out := make([]Scorecard, 0, len(current))
+	for name, m := range current {
+		sc := Scorecard{Name: name, ScorecardMetrics: *m, Trend: map[string]string{}, Worsened: []string{}}
+		if p := previous[name]; p != nil {
+			sc.Previous = p
+			for _, r := range scorecardRates {
+				cur, ok := r.value(m)
+				prev, prevOK := r.value(p)
+				if !ok || !prevOK {
+					continue
+				}
+				delta := cur - prev
+				switch {
+				case delta >= scorecardTrendDelta:
+					sc.Trend[r.name] = "↑"
+				case delta <= -scorecardTrendDelta:
+					sc.Trend[r.name] = "↓"
+				default:
+					sc.Trend[r.name] = "→"
+					continue
+				}
+				if delta*r.risk > 0 {
+					sc.Worsened = append(sc.Worsened, r.name)
+				}
+			}
+		}
+		out = append(out, sc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
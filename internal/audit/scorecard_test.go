@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScorecards(t *testing.T) {
+	store := newShardedStore(t, ChainShardingNone, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	n := 0
+	record := func(age time.Duration, e Event) {
+		t.Helper()
+		n++
+		e.EventID = "evt_" + string(rune('a'+n))
+		e.Timestamp = now.Add(-age)
+		if e.Session.ID == "" {
+			e.Session.ID = "s"
+		}
+		if err := store.Record(ctx, &e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	blast := func(affected int, passed bool) json.RawMessage {
+		trace, _ := json.Marshal(map[string]any{"policies_evaluated": []any{map[string]any{
+			"rules": []any{map[string]any{"conditions": []any{map[string]any{
+				"name": "max_rows_affected", "passed": passed,
+				"detail": fmt.Sprintf("%d rows affected, limit is 100", affected),
+			}}}},
+		}}})
+		return trace
+	}
+
+	// Previous day: the database agent ran one approved destructive call.
+	record(36*time.Hour, Event{EventType: EventTypePolicyDecision, TraceID: "tr_old", Session: Session{AgentName: "db_agent"},
+		PolicyDecision: &PolicyDecision{Effect: "allow", UserID: "alice"}})
+	record(36*time.Hour, Event{EventType: EventTypeToolExecution, TraceID: "tr_old", ActionClass: ActionDestructive,
+		Tool: &ToolExecution{Agent: "db_agent"}, Approval: &Approval{Status: ApprovalApproved}, Outcome: &Outcome{Status: "success"}})
+
+	// This day: a denial, an unapproved destructive call that failed, a
+	// blast-radius check at 80% and one exceeded, and a routing decision.
+	record(2*time.Hour, Event{EventType: EventTypePolicyDecision, TraceID: "tr_1", Session: Session{AgentName: "db_agent"},
+		PolicyDecision: &PolicyDecision{Effect: "deny", UserID: "alice"}})
+	record(2*time.Hour, Event{EventType: EventTypePolicyDecision, TraceID: "tr_1", Session: Session{AgentName: "db_agent"},
+		PolicyDecision: &PolicyDecision{Effect: "allow", UserID: "alice", Trace: blast(80, true)}})
+	record(2*time.Hour, Event{EventType: EventTypeToolExecution, TraceID: "tr_1", ActionClass: ActionDestructive,
+		Tool: &ToolExecution{Agent: "db_agent"}, Outcome: &Outcome{Status: "error"}})
+	record(time.Hour, Event{EventType: EventTypePolicyDecision, TraceID: "tr_2", Session: Session{AgentName: "db_agent"},
+		PolicyDecision: &PolicyDecision{Effect: "deny", Service: "srebot", Trace: blast(150, false)}})
+	record(time.Hour, Event{EventType: EventTypeDelegation, TraceID: "tr_3", Session: Session{UserID: "bob"},
+		Decision: &Decision{Agent: "k8s_agent", Confidence: 0.9}})
+
+	cards, err := store.Scorecards(ctx, ScorecardOptions{Since: now.Add(-24 * time.Hour), Until: now})
+	if err != nil {
+		t.Fatalf("Scorecards: %v", err)
+	}
+	if len(cards.Agents) != 2 || cards.Agents[0].Name != "db_agent" || cards.Agents[1].Name != "k8s_agent" {
+		t.Fatalf("agents = %+v, want db_agent and k8s_agent", cards.Agents)
+	}
+	db := cards.Agents[0]
+	if db.ToolExecutions != 1 || db.ErrorRate != 1 || db.Destructive != 1 || db.ApprovalCompliance != 0 {
+		t.Errorf("db_agent tool metrics = %+v", db.ScorecardMetrics)
+	}
+	if db.PolicyDecisions != 3 || db.Denied != 2 || db.DenialRate != 0.667 {
+		t.Errorf("db_agent policy metrics = %+v", db.ScorecardMetrics)
+	}
+	if db.BlastRadiusChecks != 2 || db.BlastRadiusExceeded != 1 || db.BlastRadiusUsage != 1.15 || db.BlastRadiusPeak != 1.5 {
+		t.Errorf("db_agent blast radius = %+v", db.ScorecardMetrics)
+	}
+	if db.Previous == nil || db.Trend["approval_compliance"] != "↓" || db.Trend["denial_rate"] != "↑" || db.Trend["error_rate"] != "↑" {
+		t.Errorf("db_agent trend = %v (previous %+v)", db.Trend, db.Previous)
+	}
+	if len(db.Worsened) != 3 {
+		t.Errorf("db_agent worsened = %v, want error_rate, approval_compliance and denial_rate", db.Worsened)
+	}
+	if k8s := cards.Agents[1]; k8s.Delegations != 1 || k8s.MeanConfidence != 0.9 || k8s.Previous != nil || len(k8s.Trend) != 0 {
+		t.Errorf("k8s_agent = %+v", k8s)
+	}
+
+	// The tool execution has no user of its own: it is alice's through its trace.
+	if len(cards.Users) != 3 || cards.Users[0].Name != "alice" || cards.Users[1].Name != "bob" || cards.Users[2].Name != "srebot" {
+		t.Fatalf("users = %+v, want alice, bob and srebot", cards.Users)
+	}
+	if alice := cards.Users[0]; alice.ToolExecutions != 1 || alice.PolicyDecisions != 2 || alice.Destructive != 1 {
+		t.Errorf("alice = %+v", alice.ScorecardMetrics)
+	}
+
+	if _, err := store.Scorecards(ctx, ScorecardOptions{Since: now, Until: now}); err == nil {
+		t.Error("Scorecards accepted an empty window")
+	}
+}
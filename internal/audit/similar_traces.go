@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// similarTraceLookback bounds how far back the orchestrator looks for
+	// delegations that resolved a similar request.
+	similarTraceLookback = 90 * 24 * time.Hour
+	// similarTraceCandidates caps the past delegations scored per lookup.
+	similarTraceCandidates = 200
+	// maxSimilarTraces caps the hints added to one delegation.
+	maxSimilarTraces = 3
+	// minIntentSimilarity is the lowest intent similarity that counts as a match.
+	minIntentSimilarity = 0.4
+)
+
+// SimilarTrace is a past delegation whose user intent resembles the current
+// one and which completed successfully.
+type SimilarTrace struct {
+	TraceID    string
+	EventID    string
+	UserIntent string
+	Similarity float64
+	// Tools lists the tools the audit trail confirmed for the delegation.
+	Tools []string
+}
+
+// findSimilarTraces queries auditd for successful delegations to the same
+// agent whose user intent resembles intent, and returns the closest ones
+// together with the tools their delegation verification confirmed.
+// Delegations without confirmed tools, with a verification mismatch, or in
+// the current trace are skipped. Returns nil when auditURL is empty or
+// auditd cannot be reached: hints are best effort and never block a delegation.
+func findSimilarTraces(auditURL, apiKey, agent, intent, traceID string) []SimilarTrace {
+	if auditURL == "" || intent == "" {
+		return nil
+	}
+	want := intentTerms(intent)
+	if len(want) == 0 {
+		return nil
+	}
+	since := time.Now().Add(-similarTraceLookback).UTC().Format(time.RFC3339)
+
+	decisions := fetchEvents(auditURL, apiKey, url.Values{
+		"event_type":     {string(EventTypeDelegation)},
+		"agent":          {agent},
+		"outcome_status": {"success"},
+		"since":          {since},
+		"limit":          {strconv.Itoa(similarTraceCandidates)},
+	})
+	var matches []SimilarTrace
+	for _, ev := range decisions {
+		if ev.Decision == nil || ev.TraceID == "" || ev.TraceID == traceID {
+			continue
+		}
+		score := termSimilarity(want, intentTerms(ev.Decision.UserIntent))
+		if score < minIntentSimilarity {
+			continue
+		}
+		matches = append(matches, SimilarTrace{
+			TraceID:    ev.TraceID,
+			EventID:    ev.EventID,
+			UserIntent: ev.Decision.UserIntent,
+			Similarity: score,
+		})
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+
+	verifs := make(map[string]*DelegationVerification)
+	for _, ev := range fetchEvents(auditURL, apiKey, url.Values{
+		"event_type": {string(EventTypeDelegationVerification)},
+		"since":      {since},
+		"limit":      {"1000"},
+	}) {
+		if ev.DelegationVerification != nil {
+			verifs[ev.DelegationVerification.DelegationEventID] = ev.DelegationVerification
+		}
+	}
+
+	var out []SimilarTrace
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		v := verifs[m.EventID]
+		if v == nil || v.Mismatch || len(v.ToolsConfirmed) == 0 || seen[m.TraceID] {
+			continue
+		}
+		seen[m.TraceID] = true
+		m.Tools = uniqueStrings(v.ToolsConfirmed)
+		out = append(out, m)
+		if len(out) == maxSimilarTraces {
+			break
+		}
+	}
+	return out
+}
+
+// fetchEvents runs one GET /v1/events query against auditd. It returns nil
+// on any error.
+func fetchEvents(auditURL, apiKey string, q url.Values) []Event {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(auditURL, "/")+"/v1/events?"+q.Encode(), nil) //nolint:noctx
+	if err != nil {
+		return nil
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Debug("similar traces: fetch failed", "err", err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Debug("similar traces: unexpected status", "status", resp.StatusCode)
+		return nil
+	}
+	var events []Event
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		slog.Debug("similar traces: decode failed", "err", err)
+		return nil
+	}
+	return events
+}
+
+// intentStopWords are words too common in user intents to signal similarity.
+var intentStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"that": true, "this": true, "what": true, "why": true, "how": true, "are": true,
+	"was": true, "its": true, "our": true, "user": true, "wants": true, "want": true,
+	"check": true, "please": true, "help": true, "about": true, "there": true,
+}
+
+// intentTerms returns the set of lower-cased words in s worth comparing.
+func intentTerms(s string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(w) < 3 || intentStopWords[w] {
+			continue
+		}
+		terms[w] = true
+	}
+	return terms
+}
+
+// termSimilarity is the Jaccard similarity of two term sets.
+func termSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// formatResolutionHints builds the block appended to the delegated message
+// that tells the sub-agent how similar requests were resolved before. The
+// hints are context, not instructions: the sub-agent must still diagnose the
+// current system before acting.
+func formatResolutionHints(traces []SimilarTrace) string {
+	if len(traces) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n---[PRIOR RESOLUTIONS | from the audit trail]\n")
+	sb.WriteString("Similar requests were previously resolved as follows. Treat these as hints only: verify the current state before reusing any step.\n")
+	for _, t := range traces {
+		sb.WriteString("- Previously, \"" + t.UserIntent + "\" was resolved by " + strings.Join(t.Tools, ", ") + " (trace " + t.TraceID + ")\n")
+	}
+	sb.WriteString("---")
+	return sb.String()
+}
+
+// traceIDs returns the trace IDs of traces, in order.
+func traceIDs(traces []SimilarTrace) []string {
+	if len(traces) == 0 {
+		return nil
+	}
+	ids := make([]string, len(traces))
+	for i, t := range traces {
+		ids[i] = t.TraceID
+	}
+	return ids
+}
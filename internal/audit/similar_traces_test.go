@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveSimilarTraces returns an auditd stand-in that answers delegation
+// queries with decisions and verification queries with verifs.
+func serveSimilarTraces(t *testing.T, decisions, verifs []Event) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		out := verifs
+		if q.Get("event_type") == string(EventTypeDelegation) {
+			if q.Get("agent") != "postgres_database_agent" || q.Get("outcome_status") != "success" {
+				t.Errorf("decision query = %s, want agent and outcome_status filters", r.URL.RawQuery)
+			}
+			out = decisions
+		}
+		json.NewEncoder(w).Encode(out) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func pastDecision(eventID, traceID, intent string) Event {
+	return Event{EventID: eventID, TraceID: traceID, EventType: EventTypeDelegation,
+		Decision: &Decision{Agent: "postgres_database_agent", UserIntent: intent}}
+}
+
+func pastVerification(eventID string, mismatch bool, tools ...string) Event {
+	return Event{EventType: EventTypeDelegationVerification,
+		DelegationVerification: &DelegationVerification{DelegationEventID: eventID, ToolsConfirmed: tools, Mismatch: mismatch}}
+}
+
+func TestFindSimilarTraces(t *testing.T) {
+	srv := serveSimilarTraces(t,
+		[]Event{
+			pastDecision("evt_1", "tr_old1", "Terminate the stuck report query on prod-db"),
+			pastDecision("evt_2", "tr_old2", "Check replication lag on the replica"),
+			pastDecision("evt_3", "tr_old3", "Kill the stuck report query on prod-db"),
+			pastDecision("evt_4", "tr_now", "Terminate the stuck report query on prod-db"),
+			pastDecision("evt_5", "tr_old5", "Terminate stuck report query prod-db"),
+		},
+		[]Event{
+			pastVerification("evt_1", false, "get_active_connections", "terminate_connection", "get_active_connections"),
+			pastVerification("evt_2", false, "get_replication_status"),
+			pastVerification("evt_3", true, "get_active_connections"),
+			pastVerification("evt_4", false, "terminate_connection"),
+		})
+
+	got := findSimilarTraces(srv.URL, "", "postgres_database_agent", "Terminate the stuck report query on prod-db", "tr_now")
+
+	// tr_old2 is unrelated, tr_old3 had a verification mismatch, tr_now is
+	// the current trace and tr_old5 has no verification.
+	if len(got) != 1 || got[0].TraceID != "tr_old1" {
+		t.Fatalf("findSimilarTraces = %+v, want only tr_old1", got)
+	}
+	if strings.Join(got[0].Tools, ",") != "get_active_connections,terminate_connection" {
+		t.Errorf("Tools = %v, want deduplicated confirmed tools", got[0].Tools)
+	}
+
+	hint := formatResolutionHints(got)
+	for _, want := range []string{"[PRIOR RESOLUTIONS", `"Terminate the stuck report query on prod-db" was resolved by get_active_connections, terminate_connection (trace tr_old1)`, "hints only"} {
+		if !strings.Contains(hint, want) {
+			t.Errorf("hint missing %q:\n%s", want, hint)
+		}
+	}
+}
+
+func TestFindSimilarTraces_Unavailable(t *testing.T) {
+	if got := findSimilarTraces("", "", "postgres_database_agent", "stuck query", "tr_1"); got != nil {
+		t.Errorf("no audit URL: got %v, want nil", got)
+	}
+	if got := findSimilarTraces("http://127.0.0.1:1", "", "postgres_database_agent", "stuck query", "tr_1"); got != nil {
+		t.Errorf("unreachable auditd: got %v, want nil", got)
+	}
+	if formatResolutionHints(nil) != "" || traceIDs(nil) != nil {
+		t.Error("no similar traces should add no hint and no trace IDs")
+	}
+}
+
+func TestTermSimilarity(t *testing.T) {
+	a := intentTerms("Why is the orders table bloated?")
+	if a["the"] || a["why"] || !a["orders"] || !a["bloated"] {
+		t.Errorf("intentTerms = %v", a)
+	}
+	if s := termSimilarity(a, intentTerms("orders table bloated after bulk delete")); s < minIntentSimilarity {
+		t.Errorf("similar intents scored %.2f", s)
+	}
+	if s := termSimilarity(a, intentTerms("restart the nginx pod")); s != 0 {
+		t.Errorf("unrelated intents scored %.2f, want 0", s)
+	}
+}
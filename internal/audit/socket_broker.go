@@ -0,0 +1,335 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SocketLive is the SocketHello.ReplayAfter value that asks for sequenced
+// frames starting with the next event, without replaying history.
+const SocketLive int64 = -1
+
+// SocketHello is the optional first line a subscriber writes to the audit
+// socket. Subscribers that send it receive SocketFrame lines and, after a
+// reconnect, every event recorded since ReplayAfter. Subscribers that send
+// nothing receive one raw event per line, as before.
+type SocketHello struct {
+	ReplayAfter int64 `json:"replay_after"`
+}
+
+// SocketFrame is one line delivered to a subscriber that sent a SocketHello.
+// Seq is the event's audit_events.id, the cursor to resume from.
+type SocketFrame struct {
+	Seq   int64           `json:"seq"`
+	Event json.RawMessage `json:"event"`
+}
+
+// socketBufferSize is the number of events buffered per subscriber before it
+// is considered lagging.
+var socketBufferSize = 1024
+
+// socketWriteTimeout bounds a single write; a consumer that stalls longer
+// (e.g. secbot mid-incident-creation for longer than this) is dropped.
+const socketWriteTimeout = 5 * time.Second
+
+// socketHelloTimeout is how long a new connection is given to send its
+// SocketHello before it is treated as a legacy, live-only subscriber. Events
+// recorded meanwhile are buffered, not lost.
+var socketHelloTimeout = 500 * time.Millisecond
+
+type socketMessage struct {
+	seq int64
+	raw []byte
+}
+
+// socketSubscriber is one audit socket connection. Its writer goroutine owns
+// the connection; Record only ever does a non-blocking send to ch.
+type socketSubscriber struct {
+	conn   net.Conn
+	ch     chan socketMessage
+	lagged atomic.Bool
+	hello  *SocketHello    // preset for in-process subscribers; read from conn otherwise
+	done   <-chan struct{} // closed when an in-process subscriber goes away
+
+	// Writer-goroutine state.
+	framed     bool
+	cursor     int64 // highest seq delivered
+	replayedTo int64 // live events at or below this were delivered by replay
+}
+
+// socketBroker fans recorded events out to the audit socket's subscribers
+// and to in-process ones (Subscribe).
+// Each subscriber has its own buffer and writer so one slow consumer never
+// delays Record or the others. A framed subscriber that overflows its buffer
+// catches up from the database instead of losing events; a legacy one is
+// disconnected so it reconnects.
+type socketBroker struct {
+	store    *Store
+	listener net.Listener // nil until the audit socket is started
+	done     chan struct{}
+
+	mu   sync.Mutex
+	subs map[*socketSubscriber]struct{}
+}
+
+func newSocketBroker(store *Store) *socketBroker {
+	return &socketBroker{
+		store: store,
+		done:  make(chan struct{}),
+		subs:  make(map[*socketSubscriber]struct{}),
+	}
+}
+
+// accept serves audit socket connections until the listener is closed.
+func (b *socketBroker) accept(listener net.Listener) {
+	b.mu.Lock()
+	b.listener = listener
+	b.mu.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return // Listener closed
+		}
+		b.add(&socketSubscriber{conn: conn})
+	}
+}
+
+// add registers a subscriber and starts its writer. Registration comes
+// before the handshake so events recorded while it runs are buffered.
+func (b *socketBroker) add(sub *socketSubscriber) {
+	sub.ch = make(chan socketMessage, socketBufferSize)
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	go b.serve(sub)
+}
+
+// publish queues an event for every subscriber. It never blocks.
+func (b *socketBroker) publish(seq int64, eventJSON []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) == 0 {
+		return
+	}
+	// Copy the payload; eventJSON is owned by the caller.
+	msg := socketMessage{seq: seq, raw: append([]byte(nil), eventJSON...)}
+	for sub := range b.subs {
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.lagged.Store(true)
+		}
+	}
+}
+
+// count returns the number of connected subscribers.
+func (b *socketBroker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *socketBroker) close() {
+	close(b.done)
+	b.mu.Lock()
+	if b.listener != nil {
+		b.listener.Close()
+	}
+	for sub := range b.subs {
+		sub.conn.Close()
+	}
+	b.subs = make(map[*socketSubscriber]struct{})
+	b.mu.Unlock()
+}
+
+func (b *socketBroker) remove(sub *socketSubscriber) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+	sub.conn.Close()
+}
+
+// serve runs the handshake, the replay and then the live loop for one
+// subscriber until its connection fails or the broker closes.
+func (b *socketBroker) serve(sub *socketSubscriber) {
+	defer b.remove(sub)
+
+	hello, ok := SocketHello{}, false
+	if sub.hello != nil {
+		hello, ok = *sub.hello, true
+	} else {
+		hello, ok = readSocketHello(sub.conn)
+	}
+	if ok {
+		sub.framed = true
+		if hello.ReplayAfter >= 0 {
+			sub.cursor = hello.ReplayAfter
+			if err := b.replay(sub); err != nil {
+				slog.Warn("audit socket: replay failed", "after", hello.ReplayAfter, "err", err)
+				return
+			}
+		} else {
+			// Live only, but start the cursor at the current head so a
+			// later catch-up does not replay history.
+			head, err := b.store.lastSeq(context.Background())
+			if err != nil {
+				slog.Warn("audit socket: read stream head", "err", err)
+				return
+			}
+			sub.cursor = head
+		}
+	}
+
+	for {
+		var msg socketMessage
+		select {
+		case msg = <-sub.ch:
+		case <-b.done:
+			return
+		case <-sub.done:
+			return
+		}
+		if sub.lagged.Load() {
+			if !sub.framed {
+				slog.Warn("audit socket: dropping lagging subscriber", "buffered", socketBufferSize)
+				return
+			}
+			// Events were dropped from the buffer; discard the rest and
+			// fetch everything after the cursor from the database.
+			sub.lagged.Store(false)
+			drain(sub.ch)
+			if err := b.replay(sub); err != nil {
+				slog.Warn("audit socket: catch-up failed", "after", sub.cursor, "err", err)
+				return
+			}
+			continue
+		}
+		if sub.framed && msg.seq <= sub.replayedTo {
+			continue
+		}
+		if err := sub.write(msg.seq, msg.raw); err != nil {
+			return
+		}
+	}
+}
+
+// replay sends every stored event after the subscriber's cursor.
+func (b *socketBroker) replay(sub *socketSubscriber) error {
+	ctx := context.Background()
+	for {
+		batch, err := b.store.EventsAfter(ctx, sub.cursor, 500)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			sub.replayedTo = sub.cursor
+			return nil
+		}
+		for _, se := range batch {
+			if err := sub.write(se.Seq, se.Raw); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (sub *socketSubscriber) write(seq int64, raw []byte) error {
+	line := raw
+	if sub.framed {
+		var err error
+		if line, err = json.Marshal(SocketFrame{Seq: seq, Event: raw}); err != nil {
+			return err
+		}
+	}
+	// raw is shared by every subscriber; never append to it.
+	buf := make([]byte, len(line)+1)
+	copy(buf, line)
+	buf[len(line)] = '\n'
+	sub.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+	if _, err := sub.conn.Write(buf); err != nil {
+		return err
+	}
+	if seq > sub.cursor {
+		sub.cursor = seq
+	}
+	return nil
+}
+
+// readSocketHello reads the optional handshake line. It reports false for
+// legacy subscribers, which send nothing.
+func readSocketHello(conn net.Conn) (SocketHello, bool) {
+	var hello SocketHello
+	conn.SetReadDeadline(time.Now().Add(socketHelloTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return hello, false
+	}
+	if err := json.Unmarshal(line, &hello); err != nil {
+		slog.Warn("audit socket: ignoring malformed hello", "err", err)
+		return hello, false
+	}
+	return hello, true
+}
+
+// Subscribe streams recorded events to an in-process consumer, with the
+// same guarantees as a framed audit socket subscriber: replayAfter >= 0
+// first replays the stored events after it, SocketLive starts with the next
+// event, and a consumer that falls behind is caught up from the database.
+// The channel is closed when ctx is done or the stream fails.
+func (s *Store) Subscribe(ctx context.Context, replayAfter int64) <-chan SequencedEvent {
+	out := make(chan SequencedEvent)
+	server, client := net.Pipe()
+	s.broker.add(&socketSubscriber{
+		conn:  server,
+		hello: &SocketHello{ReplayAfter: replayAfter},
+		done:  ctx.Done(),
+	})
+	go func() {
+		defer close(out)
+		defer client.Close()
+		sc := bufio.NewScanner(client)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for sc.Scan() {
+			var frame SocketFrame
+			if err := json.Unmarshal(sc.Bytes(), &frame); err != nil {
+				slog.Warn("audit subscription: bad frame", "err", err)
+				return
+			}
+			se := SequencedEvent{Seq: frame.Seq, Raw: frame.Event}
+			if err := json.Unmarshal(frame.Event, &se.Event); err != nil {
+				slog.Warn("audit subscription: bad event", "seq", frame.Seq, "err", err)
+				continue
+			}
+			select {
+			case out <- se:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// lastSeq returns the sequence number of the newest stored event, or 0.
+func (s *Store) lastSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM audit_events`).Scan(&seq)
+	return seq, err
+}
+
+func drain(ch chan socketMessage) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
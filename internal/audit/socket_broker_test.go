@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readQueries decodes n frames from conn through the client and returns
+// their user queries, which recordN numbers per session.
+func readQueries(t *testing.T, c *SocketClient, conn net.Conn, n int) []string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	sc := bufio.NewScanner(conn)
+	var queries []string
+	for len(queries) < n && sc.Scan() {
+		ev, err := c.Decode(sc.Bytes())
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		queries = append(queries, ev.Input.UserQuery)
+	}
+	if len(queries) < n {
+		t.Fatalf("received %v, want %d events (err %v)", queries, n, sc.Err())
+	}
+	return queries
+}
+
+func TestSocketBroker_ReplayFromCursorFile(t *testing.T) {
+	store, socketPath := newStoreWithSocket(t)
+	recordN(t, store, "before", 3)
+
+	cursor := filepath.Join(t.TempDir(), "cursor")
+	if err := os.WriteFile(cursor, []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := &SocketClient{Path: socketPath, CursorPath: cursor}
+	conn, err := c.Dial()
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	got := readQueries(t, c, conn, 2)
+	if got[0] != "before #1" || got[1] != "before #2" {
+		t.Fatalf("replayed %v, want before #1, before #2", got)
+	}
+
+	// Live events follow the replay without duplicates.
+	recordN(t, store, "live", 1)
+	if got := readQueries(t, c, conn, 1); got[0] != "live #0" {
+		t.Errorf("live event = %v", got)
+	}
+	if b, _ := os.ReadFile(cursor); string(b) != "4\n" {
+		t.Errorf("cursor file = %q, want 4", b)
+	}
+}
+
+func TestSocketBroker_ReconnectReplaysMissedEvents(t *testing.T) {
+	store, socketPath := newStoreWithSocket(t)
+	recordN(t, store, "history", 2)
+
+	c := &SocketClient{Path: socketPath}
+	conn, err := c.Dial()
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	// The first connection starts live: history is not replayed.
+	time.Sleep(50 * time.Millisecond)
+	recordN(t, store, "first", 1)
+	if got := readQueries(t, c, conn, 1); got[0] != "first #0" {
+		t.Fatalf("first connection got %v", got)
+	}
+	conn.Close()
+
+	// Events recorded while disconnected are replayed on the next Dial.
+	recordN(t, store, "missed", 2)
+	conn, err = c.Dial()
+	if err != nil {
+		t.Fatalf("redial: %v", err)
+	}
+	defer conn.Close()
+	got := readQueries(t, c, conn, 2)
+	if got[0] != "missed #0" || got[1] != "missed #1" {
+		t.Errorf("after reconnect got %v, want missed #0, missed #1", got)
+	}
+}
+
+func TestSocketBroker_LegacySubscriberGetsRawEvents(t *testing.T) {
+	old := socketHelloTimeout
+	socketHelloTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { socketHelloTimeout = old })
+
+	store, socketPath := newStoreWithSocket(t)
+	legacy, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer legacy.Close()
+	framed, err := (&SocketClient{Path: socketPath}).Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer framed.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	recordN(t, store, "both", 1)
+	for name, conn := range map[string]net.Conn{"legacy": legacy, "framed": framed} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		sc := bufio.NewScanner(conn)
+		if !sc.Scan() {
+			t.Fatalf("%s subscriber received nothing", name)
+		}
+		line := sc.Text()
+		if isFrame := line[:7] == `{"seq":`; isFrame != (name == "framed") {
+			t.Errorf("%s subscriber got %s", name, line)
+		}
+	}
+}
+
+// TestSocketBroker_LaggingSubscriberCatchesUp overflows a subscriber's buffer
+// and checks that it still receives every event, in order, from the store.
+func TestSocketBroker_LaggingSubscriberCatchesUp(t *testing.T) {
+	old := socketBufferSize
+	socketBufferSize = 2
+	t.Cleanup(func() { socketBufferSize = old })
+
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	b := store.broker
+
+	// net.Pipe is unbuffered: the writer blocks until the client reads, so
+	// publishing while the client is not reading overflows the buffer.
+	server, client := net.Pipe()
+	defer client.Close()
+	sub := &socketSubscriber{conn: server}
+	b.add(sub)
+	if _, err := client.Write([]byte(`{"replay_after":0}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	recordN(t, store, "burst", 6)
+	for seq := int64(1); seq <= 6; seq++ {
+		b.publish(seq, []byte(fmt.Sprintf(`{"input":{"user_query":"burst #%d"}}`, seq-1)))
+	}
+	if !sub.lagged.Load() {
+		t.Fatal("subscriber should be lagging")
+	}
+
+	c := &SocketClient{}
+	got := readQueries(t, c, client, 6)
+	for i, id := range got {
+		if want := fmt.Sprintf("burst #%d", i); id != want {
+			t.Fatalf("event %d = %s, want %s (all: %v)", i, id, want, got)
+		}
+	}
+}
+
+func TestStore_Subscribe(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	recordN(t, store, "old", 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := store.Subscribe(ctx, 1)
+	recordN(t, store, "new", 1)
+
+	var got []string
+	for se := range events {
+		got = append(got, fmt.Sprintf("%d %s", se.Seq, se.Event.Input.UserQuery))
+		if len(got) == 2 {
+			cancel()
+		}
+	}
+	if len(got) != 2 || got[0] != "2 old #1" || got[1] != "3 new #0" {
+		t.Errorf("Subscribe delivered %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SocketClient subscribes to the audit socket with replay. Every Dial asks
+// the broker for the events recorded after the last one Decode returned, so
+// a consumer that reconnects after an audit service restart sees each event.
+// With CursorPath set the cursor is also persisted, so the consumer's own
+// restarts resume where it stopped; otherwise the first Dial starts live.
+type SocketClient struct {
+	Path       string // audit socket path
+	CursorPath string // optional file holding the last sequence received
+
+	mu     sync.Mutex
+	seq    int64
+	loaded bool // seq is a real cursor (from a Decode or the cursor file)
+}
+
+// Dial connects to the audit socket and sends the replay handshake.
+func (c *SocketClient) Dial() (net.Conn, error) {
+	c.mu.Lock()
+	if !c.loaded && c.CursorPath != "" {
+		if b, err := os.ReadFile(c.CursorPath); err == nil {
+			if seq, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err == nil {
+				c.seq, c.loaded = seq, true
+			}
+		}
+	}
+	hello := SocketHello{ReplayAfter: SocketLive}
+	if c.loaded {
+		hello.ReplayAfter = c.seq
+	}
+	c.mu.Unlock()
+
+	conn, err := net.Dial("unix", c.Path)
+	if err != nil {
+		return nil, err
+	}
+	line, _ := json.Marshal(hello)
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send socket hello: %w", err)
+	}
+	return conn, nil
+}
+
+// Decode parses one line read from a connection returned by Dial and
+// advances the cursor past it.
+func (c *SocketClient) Decode(line []byte) (*Event, error) {
+	var frame SocketFrame
+	if err := json.Unmarshal(line, &frame); err != nil {
+		return nil, fmt.Errorf("parse frame: %w", err)
+	}
+	var event Event
+	if err := json.Unmarshal(frame.Event, &event); err != nil {
+		return nil, fmt.Errorf("parse event %d: %w", frame.Seq, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if frame.Seq > c.seq || !c.loaded {
+		c.seq, c.loaded = frame.Seq, true
+		if c.CursorPath != "" {
+			if err := os.WriteFile(c.CursorPath, []byte(strconv.FormatInt(c.seq, 10)+"\n"), 0o600); err != nil {
+				return &event, fmt.Errorf("save socket cursor: %w", err)
+			}
+		}
+	}
+	return &event, nil
+}
+
+// Seq returns the sequence number of the last event decoded.
+func (c *SocketClient) Seq() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
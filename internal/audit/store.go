@@ -29,15 +29,42 @@ const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z"
 type Store struct {
 	db         *sql.DB
 	isPostgres bool   // true when connected to PostgreSQL
+	path       string // SQLite database file; empty for PostgreSQL
 	socketPath string
-	listeners  []net.Conn
-	mu         sync.RWMutex
-	lastHash   string     // hash of the last recorded event (for chain)
+	broker     *socketBroker // fans events out to socket and in-process subscribers
+	lastHash   string     // hash of the last recorded event in any segment
 	hashMu     sync.Mutex // protects lastHash
+
+	sharding ChainSharding
+	chainKey []byte
+	segMu    sync.Mutex              // protects segments
+	segments map[string]*segmentHead // chain segment → head; writers lock the head
+	seqMu    sync.Mutex              // serializes id assignment through commit and publish
+
+	sampler       *sampler            // nil when every event is kept
+	classifier    InjectionClassifier // optional prompt-injection classifier
+	fieldCipher   *fieldCipher        // nil when no fields are encrypted
+	pseudonymizer *Pseudonymizer      // nil when user IDs are stored as given
+	resourceOwner func(*Event) string // nil when events are not stamped with a team
+
+	relays     []*busRelay        // event bus outbox relays (nil when no bus configured)
+	busCancel  context.CancelFunc // stops the relays
+	busWG      sync.WaitGroup
 }
 
 // StoreConfig configures the audit store.
 type StoreConfig struct {
+	// ChainSharding splits the hash chain into independently verifiable
+	// segments so that writes to different segments prepare and hash their
+	// events without waiting on each other's chain heads. Inserts stay
+	// serialized across segments.
+	// The zero value keeps a single global chain.
+	ChainSharding ChainSharding
+
+	// ChainKey, when set, HMAC-signs the chain segment index so that a
+	// rewritten segment cannot be passed off by also rewriting its index.
+	ChainKey []byte
+
 	// DBPath is the path to the SQLite database file.
 	// Kept for backward compatibility; takes effect when DSN is empty.
 	DBPath string
@@ -51,6 +78,38 @@ type StoreConfig struct {
 	// SocketPath is the path to the Unix socket for real-time notifications.
 	// If empty, notifications are disabled.
 	SocketPath string
+
+	// EventBuses are optional message-bus publishers. Every recorded event is
+	// relayed to each bus on topic BusTopic(BusTopicPrefix, event_type) with
+	// the trace ID as key. Delivery is at-least-once via a persisted cursor.
+	EventBuses []EventBus
+
+	// BusTopicPrefix prefixes bus topics/subjects. Defaults to
+	// DefaultBusTopicPrefix ("helpdesk.audit").
+	BusTopicPrefix string
+
+	// Sampling keeps only a fraction of high-volume, low-value events such
+	// as read-only tool executions. The zero value keeps every event.
+	Sampling SamplingPolicy
+
+	// InjectionClassifier, when set, scores user queries and tool outputs
+	// for prompt injection alongside the built-in heuristics.
+	InjectionClassifier InjectionClassifier
+
+	// FieldEncryption, when it names fields, encrypts them at rest. The hash
+	// chain covers the encrypted values, so verification needs no key.
+	FieldEncryption FieldEncryption
+
+	// Pseudonymizer, when set, replaces user identifiers with pseudonyms
+	// before events are stored. See EraseSubject for erasing a data subject.
+	Pseudonymizer *Pseudonymizer
+
+	// ResourceOwner, when set, names the team owning the resource an event
+	// is about. Record stamps it into Event.Team unless the event has one.
+	ResourceOwner func(event *Event) string
+
+	// SQLite only (ignored for PostgreSQL): see SQLiteOptions.
+	SQLite SQLiteOptions
 }
 
 // IsPostgres reports whether the store is backed by PostgreSQL.
@@ -79,6 +138,10 @@ func rebind(isPostgres bool, query string) string {
 // NewStore creates a new audit store with the given configuration.
 func NewStore(cfg StoreConfig) (*Store, error) {
 	// Resolve the effective DSN: prefer cfg.DSN, fall back to cfg.DBPath.
+	if err := cfg.Sampling.Validate(); err != nil {
+		return nil, err
+	}
+
 	dsn := cfg.DSN
 	if dsn == "" {
 		dsn = cfg.DBPath
@@ -106,31 +169,16 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 				return nil, fmt.Errorf("create audit directory: %w", err)
 			}
 		}
-		// Set journal_mode=DELETE and synchronous=FULL via DSN pragmas so
-		// the driver applies them at connection-open time.  Post-open PRAGMA
-		// statements via modernc.org/sqlite do not reliably persist writes to
-		// disk (WAL pages stay in process memory), so the DSN approach is
-		// required.  DELETE mode ensures every committed transaction is flushed
-		// to the main .db file immediately; FULL sync prevents data loss on
-		// power failure.
-		// busy_timeout=5000ms: SQLite waits up to 5 s for a write lock rather
-		// than returning SQLITE_BUSY immediately.  Required because recordPlaybookRunComplete
-		// goroutines can hold the write lock while a new recordPlaybookRunStart
-		// INSERT arrives on a concurrent HTTP request.
-		sqliteDSN := "file:" + dsn + "?_pragma=journal_mode(delete)&_pragma=synchronous(full)&_pragma=busy_timeout(5000)"
-		db, err = sql.Open("sqlite", sqliteDSN)
+		if err := cfg.SQLite.validate(); err != nil {
+			return nil, err
+		}
+		db, err = openSQLite(dsn, cfg.SQLite)
 		if err != nil {
-			return nil, fmt.Errorf("open audit database: %w", err)
+			return nil, err
 		}
-		// Limit to one open connection so writes are serialised.
-		db.SetMaxOpenConns(1)
-
-		// Verify the journal mode and confirm writes reach disk.
-		var journalMode string
-		if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err == nil {
-			slog.Info("sqlite journal mode", "mode", journalMode, "path", dsn)
-			if journalMode != "delete" {
-				slog.Warn("sqlite NOT in delete mode — writes may not persist to disk", "mode", journalMode)
+		if cfg.SQLite.IncrementalVacuum {
+			if db, err = enableIncrementalVacuum(db, dsn, cfg.SQLite); err != nil {
+				return nil, fmt.Errorf("enable incremental vacuum: %w", err)
 			}
 		}
 	}
@@ -142,17 +190,38 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 	}
 
 	s := &Store{
-		db:         db,
-		isPostgres: isPostgres,
-		socketPath: cfg.SocketPath,
-		lastHash:   GenesisHash,
+		db:            db,
+		isPostgres:    isPostgres,
+		socketPath:    cfg.SocketPath,
+		lastHash:      GenesisHash,
+		sharding:      cfg.ChainSharding,
+		chainKey:      cfg.ChainKey,
+		segments:      make(map[string]*segmentHead),
+		sampler:       newSampler(cfg.Sampling),
+		classifier:    cfg.InjectionClassifier,
+		pseudonymizer: cfg.Pseudonymizer,
+		resourceOwner: cfg.ResourceOwner,
 	}
+	if !isPostgres {
+		s.path = dsn
+	}
+	s.broker = newSocketBroker(s)
 
 	// Initialize lastHash from the most recent event
 	if err := s.initLastHash(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("init last hash: %w", err)
 	}
+	if err := s.adoptChainKey(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sign chain segment index: %w", err)
+	}
+	if len(cfg.FieldEncryption.Fields) > 0 {
+		if s.fieldCipher, err = newFieldCipher(context.Background(), db, isPostgres, cfg.FieldEncryption); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("field encryption: %w", err)
+		}
+	}
 
 	// Start Unix socket listener if configured.
 	if cfg.SocketPath != "" {
@@ -162,9 +231,44 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 		}
 	}
 
+	// Start event bus relays if configured.
+	if len(cfg.EventBuses) > 0 {
+		if err := s.startBusRelays(cfg.EventBuses, cfg.BusTopicPrefix); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("start event bus relays: %w", err)
+		}
+	}
+
 	return s, nil
 }
 
+// startBusRelays launches one outbox relay goroutine per event bus.
+func (s *Store) startBusRelays(buses []EventBus, prefix string) error {
+	cursors, err := NewForwardCursorStore(s.db, s.isPostgres)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.busCancel = cancel
+	for _, bus := range buses {
+		r := &busRelay{
+			store:    s,
+			bus:      bus,
+			cursors:  cursors,
+			prefix:   prefix,
+			interval: 5 * time.Second,
+			wake:     make(chan struct{}, 1),
+		}
+		s.relays = append(s.relays, r)
+		s.busWG.Add(1)
+		go func() {
+			defer s.busWG.Done()
+			r.run(ctx)
+		}()
+	}
+	return nil
+}
+
 // initLastHash loads the hash of the most recent event from the database.
 func (s *Store) initLastHash() error {
 	var hash sql.NullString
@@ -216,8 +320,15 @@ func createTables(db *sql.DB, isPostgres bool) error {
 		purpose TEXT,
 		purpose_note TEXT,
 		origin TEXT,
+		tenant_id TEXT,
+		correlation_id TEXT,
+		received_at TEXT,
+		chain_segment TEXT NOT NULL DEFAULT '',
 		tool_name TEXT,
 		tool_json TEXT,
+		resource_type TEXT,
+		resource_name TEXT,
+		policy_name TEXT,
 		approval_status TEXT,
 		approval_json TEXT,
 		decision_agent TEXT,
@@ -257,6 +368,13 @@ func createTables(db *sql.DB, isPostgres bool) error {
 		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "purpose TEXT",
 		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "purpose_note TEXT",
 		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "origin TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "tenant_id TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "chain_segment TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "resource_type TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "resource_name TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "policy_name TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "correlation_id TEXT",
+		"ALTER TABLE audit_events ADD COLUMN " + ifNotExists + "received_at TEXT",
 	}
 	for _, m := range migrations {
 		db.Exec(m) //nolint:errcheck
@@ -267,18 +385,35 @@ func createTables(db *sql.DB, isPostgres bool) error {
 	CREATE INDEX IF NOT EXISTS idx_events_timestamp ON audit_events(timestamp);
 	CREATE INDEX IF NOT EXISTS idx_events_session ON audit_events(session_id);
 	CREATE INDEX IF NOT EXISTS idx_events_type ON audit_events(event_type);
+	CREATE INDEX IF NOT EXISTS idx_events_type_time ON audit_events(event_type, timestamp);
 	CREATE INDEX IF NOT EXISTS idx_events_agent ON audit_events(decision_agent);
 	CREATE INDEX IF NOT EXISTS idx_events_trace ON audit_events(trace_id);
 	CREATE INDEX IF NOT EXISTS idx_events_parent ON audit_events(parent_id);
 	CREATE INDEX IF NOT EXISTS idx_events_action_class ON audit_events(action_class);
 	CREATE INDEX IF NOT EXISTS idx_events_tool ON audit_events(tool_name);
 	CREATE INDEX IF NOT EXISTS idx_events_approval ON audit_events(approval_status);
+	CREATE INDEX IF NOT EXISTS idx_events_tenant ON audit_events(tenant_id);
+	CREATE INDEX IF NOT EXISTS idx_events_correlation ON audit_events(correlation_id);
+	CREATE INDEX IF NOT EXISTS idx_events_chain_segment ON audit_events(chain_segment, id);
 	`
-	_, err := db.Exec(indexes)
-	return err
+	if _, err := db.Exec(indexes); err != nil {
+		return err
+	}
+	if err := backfillDecisionColumns(db, isPostgres); err != nil {
+		return fmt.Errorf("backfill policy decision columns: %w", err)
+	}
+	if err := createSampledCountsTable(db); err != nil {
+		return fmt.Errorf("create sampled_event_counts: %w", err)
+	}
+	return createChainSegmentTable(db)
 }
 
-// Record persists an audit event and notifies listeners.
+// eventSeqLockKey is the PostgreSQL advisory lock that orders event inserts
+// across auditd replicas sharing one database.
+const eventSeqLockKey int64 = 0x68646175646974 // "hdaudit"
+
+// Record persists an audit event and notifies listeners. An event dropped by
+// the sampling policy is only counted: it is not chained, stored or published.
 func (s *Store) Record(ctx context.Context, event *Event) error {
 	// Generate event ID if not set.
 	if event.EventID == "" {
@@ -287,12 +422,56 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now().UTC()
 	}
+	stampTenant(ctx, event)
+	stampCorrelation(ctx, event)
+	stampTraceParent(ctx, event)
+	if event.Team == "" && s.resourceOwner != nil {
+		event.Team = s.resourceOwner(event)
+	}
+	// An event posted to auditd carries its sender's producer (or none, from
+	// an older build); only events recorded in-process are this binary's.
+	if event.ReceivedAt == nil {
+		StampProducer(event)
+	}
+	// Score prompt-injection risk first: a risky event is never sampled out,
+	// and the score is covered by the chain.
+	if event.InjectionRisk == nil {
+		event.InjectionRisk = s.scoreInjection(ctx, event)
+	}
+	if !s.sampler.keep(event) {
+		return s.countSampledOut(ctx, event)
+	}
 
-	// Compute hash chain - hold lock through DB write to prevent race conditions
-	s.hashMu.Lock()
-	defer s.hashMu.Unlock()
+	// Score the reasoning before hashing so the score is covered by the chain.
+	if event.EventType == EventTypeDelegation && event.Decision != nil && event.Decision.ReasoningQuality == nil {
+		event.Decision.ReasoningQuality = ScoreReasoning(event.Decision, s.traceToolOutputs(ctx, event.TraceID))
+	}
 
-	event.PrevHash = s.lastHash
+	// Compute hash chain - hold the segment lock through the DB write so the
+	// segment's links stay in insertion order. Other segments hash and encrypt
+	// in parallel; only the insert itself is serialized (see seqMu below).
+	seg := s.sharding.segmentFor(event)
+	head := s.acquireSegment(seg)
+	defer s.releaseSegment(seg, head)
+	if !head.loaded {
+		if err := s.loadSegment(ctx, seg, head); err != nil {
+			return fmt.Errorf("load chain segment %q: %w", seg, err)
+		}
+	}
+
+	// Pseudonymize user IDs and encrypt designated fields before hashing, so
+	// the chain covers exactly what is stored.
+	if s.pseudonymizer != nil {
+		s.pseudonymizer.apply(event)
+	}
+	if s.fieldCipher != nil {
+		if err := s.fieldCipher.encrypt(ctx, event); err != nil {
+			return fmt.Errorf("encrypt event fields: %w", err)
+		}
+	}
+
+	event.ChainSegment = seg
+	event.PrevHash = head.headHash
 	event.EventHash = ComputeEventHash(event)
 
 	rawJSON, err := json.Marshal(event)
@@ -336,6 +515,13 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		}
 	}
 
+	// Only events posted to auditd carry a receive time; the column stays
+	// empty for the rest so clock-skew stats skip them.
+	var receivedAtVal string
+	if event.ReceivedAt != nil {
+		receivedAtVal = event.ReceivedAt.UTC().Format(sqliteTimeFormat)
+	}
+
 	// Extract purpose at the top level for indexed querying.
 	// Top-level Event.Purpose is set on gateway_request anchor events.
 	// PolicyDecision.Purpose is set on policy_decision events.
@@ -360,6 +546,15 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		decisionAgent = toolAgent
 	}
 
+	// Resource and policy of a decision are surfaced as columns so the
+	// governance stats endpoint can aggregate them without parsing raw_json.
+	var resourceType, resourceName, policyName string
+	if event.PolicyDecision != nil {
+		resourceType = event.PolicyDecision.ResourceType
+		resourceName = event.PolicyDecision.ResourceName
+		policyName = event.PolicyDecision.PolicyName
+	}
+
 	var approvalStatus string
 	var approvalJSON []byte
 	if event.Approval != nil {
@@ -367,17 +562,38 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		approvalJSON, _ = json.Marshal(event.Approval)
 	}
 
-	_, err = s.db.ExecContext(ctx, rebind(s.isPostgres, `
+	// Events must commit in id order: the forwarder, bus relay, WORM export
+	// and socket replay all resume from "id > cursor" and would skip a lower
+	// id that commits after a higher one. SQLite serializes writers already;
+	// on PostgreSQL concurrent segments would not, so hold seqMu (this
+	// process) and an advisory lock (other auditd replicas) until commit.
+	s.seqMu.Lock()
+	defer s.seqMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin insert event: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if s.isPostgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventSeqLockKey); err != nil {
+			return fmt.Errorf("lock event sequence: %w", err)
+		}
+	}
+
+	var rowID int64
+	err = tx.QueryRowContext(ctx, rebind(s.isPostgres, `
 		INSERT INTO audit_events (
 			event_id, timestamp, event_type, trace_id, parent_id, action_class,
-			prev_hash, event_hash,
+			prev_hash, event_hash, chain_segment,
 			session_id, session_agent, user_id, user_query,
-			purpose, purpose_note, origin,
+			purpose, purpose_note, origin, tenant_id, correlation_id, received_at,
 			tool_name, tool_json,
+			resource_type, resource_name, policy_name,
 			approval_status, approval_json,
 			decision_agent, decision_category, decision_confidence, decision_json,
 			outcome_status, outcome_error, outcome_duration_ms, raw_json
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`),
 		event.EventID,
 		event.Timestamp.UTC().Format(sqliteTimeFormat),
@@ -387,6 +603,7 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		string(event.ActionClass),
 		event.PrevHash,
 		event.EventHash,
+		event.ChainSegment,
 		event.Session.ID,
 		event.Session.AgentName,
 		event.Session.UserID,
@@ -394,8 +611,14 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		purposeVal,
 		purposeNoteVal,
 		event.Origin,
+		event.Session.TenantID,
+		event.CorrelationID,
+		receivedAtVal,
 		toolName,
 		string(toolJSON),
+		resourceType,
+		resourceName,
+		policyName,
 		approvalStatus,
 		string(approvalJSON),
 		decisionAgent,
@@ -406,20 +629,69 @@ func (s *Store) Record(ctx context.Context, event *Event) error {
 		outcomeError,
 		outcomeDurationMs,
 		string(rawJSON),
-	)
+	).Scan(&rowID)
 	if err != nil {
 		return fmt.Errorf("insert event: %w", err)
 	}
 
-	// Update lastHash only after successful write
+	next := segmentState{
+		firstEventID: head.firstEventID,
+		headID:       rowID,
+		headEventID:  event.EventID,
+		headHash:     event.EventHash,
+		count:        head.count + 1,
+	}
+	if next.count == 1 {
+		next.firstEventID = event.EventID
+	}
+	if err := s.upsertSegmentIndex(ctx, tx, seg, next); err != nil {
+		return fmt.Errorf("update chain segment index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit event: %w", err)
+	}
+
+	// Advance the segment head only after a successful write
+	head.segmentState = next
+	s.hashMu.Lock()
 	s.lastHash = event.EventHash
+	s.hashMu.Unlock()
 
 	// Notify listeners.
-	s.notifyListeners(rawJSON)
+	s.broker.publish(rowID, rawJSON)
+	for _, r := range s.relays {
+		r.notify()
+	}
 
 	return nil
 }
 
+// traceToolOutputs returns the results and errors of the tool executions
+// already recorded for a trace, for grounding a reasoning score. Lookup
+// failures only cost the score its grounding component.
+func (s *Store) traceToolOutputs(ctx context.Context, traceID string) []string {
+	if traceID == "" {
+		return nil
+	}
+	events, err := s.Query(ctx, QueryOptions{TraceID: traceID, EventType: EventTypeToolExecution, Limit: 50})
+	if err != nil {
+		slog.Debug("reasoning score: tool output lookup failed", "trace_id", traceID, "err", err)
+		return nil
+	}
+	var outputs []string
+	for _, e := range events {
+		if e.Tool == nil {
+			continue
+		}
+		for _, out := range []string{e.Tool.Result, e.Tool.Error} {
+			if out != "" {
+				outputs = append(outputs, out)
+			}
+		}
+	}
+	return outputs
+}
+
 // RecordOutcome updates an existing delegation event with its outcome.
 func (s *Store) RecordOutcome(ctx context.Context, eventID string, outcome *Outcome) error {
 	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
@@ -444,6 +716,17 @@ func (s *Store) Query(ctx context.Context, opts QueryOptions) ([]Event, error) {
 		query += " AND event_id = ?"
 		args = append(args, opts.EventID)
 	}
+	if opts.EventIDs != nil {
+		if len(opts.EventIDs) == 0 {
+			return nil, nil
+		}
+		placeholders := make([]string, len(opts.EventIDs))
+		for i, id := range opts.EventIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += " AND event_id IN (" + strings.Join(placeholders, ",") + ")"
+	}
 	if opts.SessionID != "" {
 		query += " AND session_id = ?"
 		args = append(args, opts.SessionID)
@@ -467,6 +750,10 @@ func (s *Store) Query(ctx context.Context, opts QueryOptions) ([]Event, error) {
 		query += " AND timestamp >= ?"
 		args = append(args, opts.Since.UTC().Format(sqliteTimeFormat))
 	}
+	if !opts.Until.IsZero() {
+		query += " AND timestamp < ?"
+		args = append(args, opts.Until.UTC().Format(sqliteTimeFormat))
+	}
 	if opts.MinConfidence > 0 {
 		query += " AND decision_confidence >= ?"
 		args = append(args, opts.MinConfidence)
@@ -503,6 +790,14 @@ func (s *Store) Query(ctx context.Context, opts QueryOptions) ([]Event, error) {
 		query += " AND origin = ?"
 		args = append(args, opts.Origin)
 	}
+	if opts.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, opts.TenantID)
+	}
+	if opts.CorrelationID != "" {
+		query += " AND correlation_id = ?"
+		args = append(args, opts.CorrelationID)
+	}
 
 	// Chronological order for trace/prefix queries, reverse chronological otherwise
 	if opts.TraceID != "" || opts.TraceIDPrefix != "" {
@@ -542,11 +837,13 @@ func (s *Store) Query(ctx context.Context, opts QueryOptions) ([]Event, error) {
 // QueryOptions specifies filters for querying events.
 type QueryOptions struct {
 	EventID        string         // filter by exact event ID (returns at most one event)
+	EventIDs       []string       // filter by a set of event IDs; an empty non-nil slice matches nothing
 	SessionID      string
 	EventType      EventType
 	EventTypes     []EventType    // filter by multiple event types (OR); ignored when EventType is set
 	Agent          string
 	Since          time.Time
+	Until          time.Time      // exclusive upper bound on timestamp; zero = unbounded
 	MinConfidence  float64
 	MaxConfidence  float64
 	Limit          int
@@ -557,6 +854,8 @@ type QueryOptions struct {
 	ApprovalStatus ApprovalStatus // filter by approval status
 	OutcomeStatus  string         // filter by outcome_status (e.g. "error", "denied", "allow")
 	Origin         string         // filter by origin (e.g. "direct_tool", "agent", "gateway")
+	TenantID       string         // filter by tenant; empty = all tenants
+	CorrelationID  string         // filter by client-supplied correlation ID
 }
 
 // JourneyOptions specifies filters for QueryJourneys.
@@ -574,6 +873,7 @@ type JourneyOptions struct {
 	TraceIDPrefix   string        // filter by trace ID prefix (e.g. "plan_" for planner journeys)
 	Origin          string        // filter by dispatch origin (e.g. "agent", "gateway"); post-aggregation
 	IncidentOnly    bool          // only journeys linked to a playbook_run (incident_run_id != "")
+	TenantID        string        // filter by tenant of the anchor event; empty = all tenants
 }
 
 // DelegationSummary captures one orchestrator-to-sub-agent delegation turn:
@@ -587,6 +887,8 @@ type DelegationSummary struct {
 type IncidentJourneyRef struct {
 	Phase   string `json:"phase"`    // "triage" | "remediation" | "triage+remediation"
 	TraceID string `json:"trace_id"` // argument to: vault journeys <trace_id>
+	// Annotations are the external references attached to the trace.
+	Annotations []TraceAnnotation `json:"annotations,omitempty"`
 }
 
 // JourneySummary summarises a single end-to-end user request (one trace_id).
@@ -620,6 +922,10 @@ type JourneySummary struct {
 	// IncidentRunID is the plr_* playbook run ID for journeys associated with an
 	// incident run. Empty for ad-hoc or non-incident journeys.
 	IncidentRunID string `json:"incident_run_id,omitempty"`
+	// Annotations are the external references (PagerDuty incident, Jira
+	// ticket, Slack thread) attached to this trace. Filled in by auditd from
+	// the TraceAnnotationStore; QueryJourneys leaves it empty.
+	Annotations []TraceAnnotation `json:"annotations,omitempty"`
 }
 
 // QueryJourneys returns journey summaries for traces anchored by a
@@ -663,13 +969,20 @@ func (s *Store) QueryJourneys(ctx context.Context, opts JourneyOptions) ([]Journ
 		args1 = append(args1, opts.TraceIDPrefix+"%")
 	}
 	if opts.UserID != "" {
-		q1 += " AND user_id = ?"
-		args1 = append(args1, opts.UserID)
+		ids := s.SubjectIDs(opts.UserID)
+		q1 += " AND user_id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		for _, id := range ids {
+			args1 = append(args1, id)
+		}
 	}
 	if opts.Purpose != "" {
 		q1 += " AND purpose = ?"
 		args1 = append(args1, opts.Purpose)
 	}
+	if opts.TenantID != "" {
+		q1 += " AND tenant_id = ?"
+		args1 = append(args1, opts.TenantID)
+	}
 	if !opts.From.IsZero() {
 		q1 += " AND timestamp >= ?"
 		args1 = append(args1, opts.From.UTC().Format(sqliteTimeFormat))
@@ -996,12 +1309,13 @@ func (s *Store) QueryJourneys(ctx context.Context, opts JourneyOptions) ([]Journ
 // outcomePriority returns the severity rank for a journey outcome string.
 // Higher priority outcomes win when aggregating events within a trace.
 //
-//	unverified_claim(9) > error(8) > denied(7) > escalation_required(6) > verified_failed(5)
+//	unverified_claim(9) > error(8) = timed_out(8) > denied(7) > escalation_required(6) > verified_failed(5)
 //	> verified_warning(4) > approved(3) > verified_ok(2) > success(1) > verified(0.5) > unknown(0)
 func outcomePriority(o string) int {
 	switch o {
 	case "unverified_claim":    return 9
 	case "error":               return 8
+	case "timed_out":           return 8 // no outcome ever arrived; as bad as an error
 	case "denied":              return 7
 	case "escalation_required": return 6
 	case "verified_failed":     return 5
@@ -1025,37 +1339,16 @@ func filterJourneys(journeys []JourneySummary, keep func(JourneySummary) bool) [
 	return out
 }
 
-// VerifyIntegrity verifies the hash chain integrity of the audit log.
+// VerifyIntegrity verifies the hash chain integrity of the audit log: every
+// chain segment is re-hashed in full and checked against the segment index.
+//
+// Each segment is verified in insertion order (id ASC), NOT by the Timestamp
+// field. Events can arrive out of timestamp order (e.g., a gateway event has
+// Timestamp=request-start but is inserted AFTER the agent event that was
+// recorded mid-request), so sorting by timestamp would produce a different
+// order than the chain was built in.
 func (s *Store) VerifyIntegrity(ctx context.Context) (ChainStatus, error) {
-	// Query events in insertion order (id ASC).
-	// The hash chain links events in the order they were inserted into the DB,
-	// NOT by their Timestamp field. Events can arrive out of timestamp order
-	// (e.g., a gateway event has Timestamp=request-start but is inserted AFTER
-	// the agent event that was recorded mid-request), so sorting by timestamp
-	// would produce a different order than the chain was built in.
-	rows, err := s.db.QueryContext(ctx, `SELECT raw_json FROM audit_events ORDER BY id ASC`)
-	if err != nil {
-		return ChainStatus{}, fmt.Errorf("query events for verify: %w", err)
-	}
-	defer rows.Close()
-
-	var events []Event
-	for rows.Next() {
-		var rawJSON string
-		if err := rows.Scan(&rawJSON); err != nil {
-			return ChainStatus{}, fmt.Errorf("scan event: %w", err)
-		}
-		var event Event
-		if err := json.Unmarshal([]byte(rawJSON), &event); err != nil {
-			return ChainStatus{}, fmt.Errorf("unmarshal event: %w", err)
-		}
-		events = append(events, event)
-	}
-	if err := rows.Err(); err != nil {
-		return ChainStatus{}, err
-	}
-
-	return VerifyChainStatus(events), nil
+	return s.VerifySegments(ctx, VerifyOptions{})
 }
 
 // GetLastHash returns the hash of the most recent event.
@@ -1072,13 +1365,15 @@ func (s *Store) DB() *sql.DB {
 
 // Close closes the store and releases resources.
 func (s *Store) Close() error {
-	s.mu.Lock()
-	for _, conn := range s.listeners {
-		conn.Close()
+	if s.busCancel != nil {
+		s.busCancel()
+		s.busWG.Wait()
+		for _, r := range s.relays {
+			r.bus.Close()
+		}
 	}
-	s.listeners = nil
-	s.mu.Unlock()
 
+	s.broker.close()
 	if s.socketPath != "" {
 		os.Remove(s.socketPath)
 	}
@@ -1095,70 +1390,6 @@ func (s *Store) startSocketListener() error {
 	if err != nil {
 		return err
 	}
-
-	go func() {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				return // Listener closed
-			}
-
-			s.mu.Lock()
-			s.listeners = append(s.listeners, conn)
-			s.mu.Unlock()
-		}
-	}()
-
+	go s.broker.accept(listener)
 	return nil
 }
-
-// notifyListeners sends an event to all connected listeners.
-// Writes are dispatched in a goroutine so Record() returns immediately and
-// slow consumers don't block audit ingestion. Dead connections are pruned
-// after each notification pass.
-func (s *Store) notifyListeners(eventJSON []byte) {
-	// Snapshot the listener list under a read lock — no I/O while holding the lock,
-	// and no lock-upgrade race from the old RLock→Lock pattern.
-	s.mu.RLock()
-	if len(s.listeners) == 0 {
-		s.mu.RUnlock()
-		return
-	}
-	conns := make([]net.Conn, len(s.listeners))
-	copy(conns, s.listeners)
-	s.mu.RUnlock()
-
-	// Copy the payload; eventJSON is owned by the caller and must not be mutated.
-	data := make([]byte, len(eventJSON)+1)
-	copy(data, eventJSON)
-	data[len(eventJSON)] = '\n'
-
-	go func() {
-		var dead []net.Conn
-		for _, conn := range conns {
-			// Allow clients up to 5 s to drain their receive buffer before
-			// we consider them dead (e.g. secbot may be mid-incident-creation).
-			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-			if _, err := conn.Write(data); err != nil {
-				conn.Close()
-				dead = append(dead, conn)
-			}
-		}
-		if len(dead) == 0 {
-			return
-		}
-		deadSet := make(map[net.Conn]bool, len(dead))
-		for _, c := range dead {
-			deadSet[c] = true
-		}
-		s.mu.Lock()
-		live := make([]net.Conn, 0, len(s.listeners))
-		for _, c := range s.listeners {
-			if !deadSet[c] {
-				live = append(live, c)
-			}
-		}
-		s.listeners = live
-		s.mu.Unlock()
-	}()
-}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"helpdesk/internal/identity"
 )
 
 func TestStore_HashChain(t *testing.T) {
@@ -237,8 +239,8 @@ func newStoreWithSocket(t *testing.T) (*Store, string) {
 //     RLock→Lock mid-loop), or the connection was silently dropped after 100 ms
 //     even when the consumer was legitimately busy (e.g. secbot creating an incident).
 //
-//   - New behaviour: writes are dispatched in a background goroutine with a 5 s
-//     deadline; Record() returns immediately regardless of listener speed.
+//   - New behaviour: each subscriber has its own buffer and writer goroutine
+//     with a 5 s deadline; Record() returns immediately regardless of listener speed.
 func TestStore_NotifyListeners_SlowConsumerDoesNotBlock(t *testing.T) {
 	store, socketPath := newStoreWithSocket(t)
 
@@ -349,9 +351,7 @@ func TestStore_NotifyListeners_DeadListenerIsPruned(t *testing.T) {
 
 	// Wait for the async pruning goroutine to finish, then check listener count.
 	time.Sleep(200 * time.Millisecond)
-	store.mu.RLock()
-	n := len(store.listeners)
-	store.mu.RUnlock()
+	n := store.broker.count()
 	if n != 1 {
 		t.Errorf("after pruning dead connection: want 1 live listener, got %d", n)
 	}
@@ -435,6 +435,150 @@ func TestStore_Query(t *testing.T) {
 	}
 }
 
+// TestStore_QueryByTenant verifies that the tenant is taken from the event's
+// principal when Session.TenantID is unset and that QueryOptions.TenantID
+// restricts results to that tenant.
+func TestStore_QueryByTenant(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	events := []*Event{
+		{EventID: "evt_t1", Timestamp: time.Now(), EventType: EventTypeGatewayRequest,
+			Session: Session{ID: "s1"}, Principal: &identity.ResolvedPrincipal{UserID: "alice", Tenant: "acme"}},
+		{EventID: "evt_t2", Timestamp: time.Now(), EventType: EventTypeDelegation,
+			Session: Session{ID: "s1", TenantID: "acme"}},
+		{EventID: "evt_t3", Timestamp: time.Now(), EventType: EventTypeDelegation,
+			Session: Session{ID: "s2", TenantID: "globex"}},
+	}
+	for _, e := range events {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("failed to record event: %v", err)
+		}
+	}
+
+	results, err := store.Query(ctx, QueryOptions{TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("query by tenant failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("query by tenant: got %d results, want 2", len(results))
+	}
+	for _, e := range results {
+		if e.Session.TenantID != "acme" {
+			t.Errorf("event %s: TenantID = %q, want acme", e.EventID, e.Session.TenantID)
+		}
+	}
+
+	results, err = store.Query(ctx, QueryOptions{})
+	if err != nil {
+		t.Fatalf("unscoped query failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("unscoped query: got %d results, want 3", len(results))
+	}
+}
+
+func TestStore_QueryByCorrelationID(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := WithCorrelationID(context.Background(), "ticket-42")
+	if err := store.Record(ctx, &Event{EventID: "evt_c1", Timestamp: time.Now(),
+		EventType: EventTypeGatewayRequest, Session: Session{ID: "s1"}}); err != nil {
+		t.Fatalf("failed to record event: %v", err)
+	}
+	if err := store.Record(context.Background(), &Event{EventID: "evt_c2", Timestamp: time.Now(),
+		EventType: EventTypeGatewayRequest, Session: Session{ID: "s2"}}); err != nil {
+		t.Fatalf("failed to record event: %v", err)
+	}
+
+	results, err := store.Query(context.Background(), QueryOptions{CorrelationID: "ticket-42"})
+	if err != nil {
+		t.Fatalf("query by correlation ID failed: %v", err)
+	}
+	if len(results) != 1 || results[0].EventID != "evt_c1" {
+		t.Fatalf("query by correlation ID: got %v, want only evt_c1", results)
+	}
+	if results[0].CorrelationID != "ticket-42" {
+		t.Errorf("CorrelationID = %q, want ticket-42", results[0].CorrelationID)
+	}
+	if !VerifyEventHash(&results[0]) {
+		t.Error("hash does not verify for an event with a correlation ID")
+	}
+}
+
+func TestStore_ResourceOwner(t *testing.T) {
+	store, err := NewStore(StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "audit.db"),
+		ResourceOwner: func(e *Event) string {
+			if e.PolicyDecision != nil && e.PolicyDecision.ResourceName == "orders" {
+				return "payments"
+			}
+			return ""
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, e := range []*Event{
+		{EventID: "pol_owned", EventType: EventTypePolicyDecision, PolicyDecision: &PolicyDecision{ResourceType: "database", ResourceName: "orders"}},
+		{EventID: "pol_unowned", EventType: EventTypePolicyDecision, PolicyDecision: &PolicyDecision{ResourceType: "database", ResourceName: "scratch"}},
+		{EventID: "pol_sent", EventType: EventTypePolicyDecision, Team: "dba", PolicyDecision: &PolicyDecision{ResourceType: "database", ResourceName: "orders"}},
+	} {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("failed to record event: %v", err)
+		}
+	}
+
+	for id, want := range map[string]string{"pol_owned": "payments", "pol_unowned": "", "pol_sent": "dba"} {
+		results, err := store.Query(ctx, QueryOptions{EventID: id})
+		if err != nil || len(results) != 1 {
+			t.Fatalf("query %s: %v, %v", id, results, err)
+		}
+		if results[0].Team != want {
+			t.Errorf("%s: Team = %q, want %q", id, results[0].Team, want)
+		}
+		if !VerifyEventHash(&results[0]) {
+			t.Errorf("%s: hash does not verify", id)
+		}
+	}
+}
+
+func TestStore_QueryTimeRange(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"evt_dec", "evt_jan", "evt_apr"} {
+		ts := start.AddDate(0, 3*i-1, 0)
+		if err := store.Record(context.Background(), &Event{EventID: id, Timestamp: ts,
+			EventType: EventTypePolicyDecision, Session: Session{ID: "s1"}}); err != nil {
+			t.Fatalf("failed to record event: %v", err)
+		}
+	}
+
+	results, err := store.Query(context.Background(), QueryOptions{Since: start, Until: start.AddDate(0, 3, 0)})
+	if err != nil {
+		t.Fatalf("query by time range failed: %v", err)
+	}
+	if len(results) != 1 || results[0].EventID != "evt_jan" {
+		t.Fatalf("query by time range: got %v, want only evt_jan", results)
+	}
+}
+
 // TestQueryJourneys verifies that QueryJourneys groups events by trace_id and
 // surfaces the right user_query, agent, tools_used, and outcome.
 func TestQueryJourneys(t *testing.T) {
@@ -2382,3 +2526,42 @@ func TestQueryJourneys_IncidentRunID(t *testing.T) {
 		t.Errorf("tr_adhoc IncidentRunID = %q, want empty (no associated run)", got)
 	}
 }
+
+func TestStore_RecordStampsProducer(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	// Recorded in-process: this binary produced it.
+	local := &Event{EventID: "evt_local", Timestamp: time.Now(), EventType: EventTypeToolExecution}
+	if err := store.Record(ctx, local); err != nil {
+		t.Fatal(err)
+	}
+	// Posted to auditd: the sender's producer, or none from an older build.
+	received := time.Now()
+	posted := &Event{EventID: "evt_posted", Timestamp: time.Now(), EventType: EventTypeToolExecution, ReceivedAt: &received}
+	if err := store.Record(ctx, posted); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := store.Query(ctx, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]Event)
+	for _, e := range events {
+		byID[e.EventID] = e
+	}
+	if p := byID["evt_local"].Producer; p == nil || *p != CurrentProducer() || p.Version == "" {
+		t.Errorf("local producer = %+v, want %+v", p, CurrentProducer())
+	}
+	if p := byID["evt_posted"].Producer; p != nil {
+		t.Errorf("posted producer = %+v, want none", p)
+	}
+	if e := byID["evt_local"]; !VerifyEventHash(&e) {
+		t.Error("hash of the stamped event does not verify")
+	}
+}
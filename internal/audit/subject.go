@@ -0,0 +1,517 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ── Pseudonymization ─────────────────────────────────────────────────────────
+
+// PseudonymPrefix starts every pseudonym: "psn_<pepper version>_<HMAC>".
+const PseudonymPrefix = "psn_"
+
+// ErasedField replaces free text erased from a stored event.
+const ErasedField = "[erased]"
+
+// ErrNoPepper is returned by PseudonymizeStored when the store has no
+// Pseudonymizer.
+var ErrNoPepper = errors.New("no pseudonymization pepper is configured")
+
+// Pseudonymizer replaces user identifiers with a stable HMAC of the
+// identifier under a secret pepper. The same user always maps to the same
+// pseudonym under a pepper, so events stay attributable to one person
+// without naming them. Peppers rotate: new pseudonyms use the current one,
+// and lookups also try the previous ones.
+type Pseudonymizer struct {
+	peppers [][]byte // current first
+}
+
+// NewPseudonymizer returns a pseudonymizer using current for new pseudonyms
+// and also accepting pseudonyms made under previous.
+func NewPseudonymizer(current []byte, previous ...[]byte) *Pseudonymizer {
+	p := &Pseudonymizer{peppers: [][]byte{current}}
+	for _, pp := range previous {
+		if len(pp) > 0 {
+			p.peppers = append(p.peppers, pp)
+		}
+	}
+	return p
+}
+
+// IsPseudonym reports whether id is a pseudonym rather than a raw identifier.
+func IsPseudonym(id string) bool { return strings.HasPrefix(id, PseudonymPrefix) }
+
+func pseudonymWith(pepper []byte, id string) string {
+	version := sha256.Sum256(pepper)
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(id))
+	return PseudonymPrefix + hex.EncodeToString(version[:4]) + "_" + hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// Pseudonym returns id's pseudonym under the current pepper. Empty IDs and
+// IDs that already are pseudonyms are returned unchanged.
+func (p *Pseudonymizer) Pseudonym(id string) string {
+	if id == "" || IsPseudonym(id) {
+		return id
+	}
+	return pseudonymWith(p.peppers[0], id)
+}
+
+// Pseudonyms returns id's pseudonym under every pepper, current first.
+func (p *Pseudonymizer) Pseudonyms(id string) []string {
+	out := make([]string, 0, len(p.peppers))
+	for _, pepper := range p.peppers {
+		out = append(out, pseudonymWith(pepper, id))
+	}
+	return out
+}
+
+// apply pseudonymizes the user identifiers of e: the session user, the
+// principal, the requester and approver of an approval, the user of a policy
+// decision and the requester and resolver of an approval SLA breach. It
+// reports whether anything changed.
+func (p *Pseudonymizer) apply(e *Event) bool {
+	changed := false
+	swap := func(id *string) {
+		if ps := p.Pseudonym(*id); ps != *id {
+			*id, changed = ps, true
+		}
+	}
+	swap(&e.Session.UserID)
+	if e.Principal != nil {
+		swap(&e.Principal.UserID)
+		swap(&e.Principal.OperatorID)
+	}
+	if a := e.Approval; a != nil {
+		// A self-approval must still read as one to the auditor, which only
+		// sees pseudonyms: IsSelfApproval ignores case, the HMAC does not.
+		self := IsSelfApproval(a.RequestedBy, a.ApprovedBy)
+		swap(&a.RequestedBy)
+		switch {
+		case self:
+			a.ApprovedBy = a.RequestedBy
+		case a.Status != ApprovalAutoApproved:
+			// An auto-approval names the policy that approved, not a person.
+			swap(&a.ApprovedBy)
+		}
+	}
+	if e.PolicyDecision != nil {
+		swap(&e.PolicyDecision.UserID)
+	}
+	if b := e.ApprovalSLABreach; b != nil {
+		swap(&b.RequestedBy)
+		swap(&b.ResolvedBy)
+	}
+	return changed
+}
+
+// SubjectIDs returns the values a user identifier may be stored as for id:
+// id itself and, when user identifiers are pseudonymized, its pseudonyms.
+func (s *Store) SubjectIDs(id string) []string {
+	if s.pseudonymizer == nil || IsPseudonym(id) {
+		return []string{id}
+	}
+	return append([]string{id}, s.pseudonymizer.Pseudonyms(id)...)
+}
+
+// SubjectPseudonym returns what an erased data subject is recorded as: the
+// pseudonym of id, or ErasedField when no pepper is configured.
+func (s *Store) SubjectPseudonym(id string) string {
+	if s.pseudonymizer == nil {
+		return ErasedField
+	}
+	return s.pseudonymizer.Pseudonym(id)
+}
+
+// ── Redaction ────────────────────────────────────────────────────────────────
+
+// Redaction kinds.
+const (
+	RedactionErasure          = "erasure"
+	RedactionPseudonymization = "pseudonymization"
+)
+
+// Redaction is the payload of an event_redaction event: a rewrite of stored
+// events, for erasing a data subject or pseudonymizing stored user IDs.
+// Rewritten events keep their original event_hash, so the chain still links;
+// each tombstone vouches for the hash of the rewritten content. As the
+// redaction event is itself chained, tombstones cannot be forged without
+// breaking the chain.
+type Redaction struct {
+	RedactionID string `json:"redaction_id"`
+	Kind        string `json:"kind"`
+	// Subject is the data subject's pseudonym (or ErasedField when no pepper
+	// is configured), never the raw identifier.
+	Subject    string      `json:"subject,omitempty"`
+	Reason     string      `json:"reason,omitempty"`
+	Tombstones []Tombstone `json:"tombstones"`
+}
+
+// Tombstone records one rewritten event.
+type Tombstone struct {
+	EventID      string `json:"event_id"`
+	OriginalHash string `json:"original_hash"`
+	RedactedHash string `json:"redacted_hash"`
+}
+
+// redactBatch bounds the tombstones of one event_redaction event.
+const redactBatch = 500
+
+// EraseSubject erases a data subject from audit_events. Events recorded for
+// userID (or its pseudonyms) have their free text replaced by ErasedField.
+// In those events and in every other event that names the subject (as the
+// approver of someone else's action, say), the subject's identifiers are
+// replaced by the subject's pseudonym, or by ErasedField when no pepper is
+// configured. The erasure is recorded as event_redaction events by by. It
+// returns the redactions recorded, none when nothing matched. Records kept
+// outside audit_events are erased through SubjectRecords.
+func (s *Store) EraseSubject(ctx context.Context, userID, reason, by string) ([]Redaction, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	subject := s.SubjectPseudonym(userID)
+	ids := s.SubjectIDs(userID)
+	// user_id finds the subject's own events; approvers, principals and SLA
+	// breaches are only in raw_json, so any event that quotes one of the IDs
+	// is loaded and eraseEvent decides what, if anything, names the subject.
+	where := "(user_id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	args := make([]any, 0, 2*len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	for _, id := range ids {
+		quoted, _ := json.Marshal(id)
+		where += ` OR raw_json LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(string(quoted))+"%")
+	}
+	where += ")"
+	match := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		match[id] = true
+	}
+
+	var out []Redaction
+	var afterID int64
+	for {
+		rows, err := s.loadForRedaction(ctx, where, args, afterID)
+		if err != nil {
+			return out, err
+		}
+		if len(rows) == 0 {
+			return out, nil
+		}
+		afterID = rows[len(rows)-1].id
+		red, err := s.redact(ctx, Redaction{Kind: RedactionErasure, Subject: subject, Reason: reason}, by, rows,
+			func(e *Event) bool { return eraseEvent(e, match, subject) })
+		if err != nil {
+			return out, err
+		}
+		if red != nil {
+			out = append(out, *red)
+		}
+	}
+}
+
+// escapeLike escapes the LIKE wildcards of s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// PseudonymizeStored replaces the user identifiers of stored events that are
+// not pseudonyms yet, recording event_redaction events by by. It returns the
+// number of events rewritten.
+func (s *Store) PseudonymizeStored(ctx context.Context, by string) (int, error) {
+	if s.pseudonymizer == nil {
+		return 0, ErrNoPepper
+	}
+	n := 0
+	var afterID int64
+	for {
+		// Approvers and SLA breaches are only in raw_json: load every event
+		// that may carry one and let apply skip those already pseudonymized.
+		rows, err := s.loadForRedaction(ctx,
+			"((user_id <> '' AND user_id NOT LIKE 'psn\\_%' ESCAPE '\\') OR approval_json <> '' OR event_type = ?)",
+			[]any{string(EventTypeApprovalSLABreach)}, afterID)
+		if err != nil {
+			return n, err
+		}
+		if len(rows) == 0 {
+			return n, nil
+		}
+		afterID = rows[len(rows)-1].id
+		red, err := s.redact(ctx, Redaction{Kind: RedactionPseudonymization}, by, rows, s.pseudonymizer.apply)
+		if err != nil {
+			return n, err
+		}
+		if red != nil {
+			n += len(red.Tombstones)
+		}
+	}
+}
+
+type storedEvent struct {
+	id    int64
+	event Event
+}
+
+// loadForRedaction returns up to redactBatch events matching where, after
+// row afterID, oldest first. Redaction events themselves are never matched.
+func (s *Store) loadForRedaction(ctx context.Context, where string, args []any, afterID int64) ([]storedEvent, error) {
+	q := `SELECT id, raw_json FROM audit_events WHERE ` + where + ` AND id > ? AND event_type <> ? ORDER BY id ASC LIMIT ?`
+	args = append(append([]any{}, args...), afterID, string(EventTypeRedaction), redactBatch)
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, q), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events to redact: %w", err)
+	}
+	defer rows.Close()
+	var out []storedEvent
+	for rows.Next() {
+		var se storedEvent
+		var rawJSON string
+		if err := rows.Scan(&se.id, &rawJSON); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		if err := json.Unmarshal([]byte(rawJSON), &se.event); err != nil {
+			return nil, fmt.Errorf("unmarshal event: %w", err)
+		}
+		out = append(out, se)
+	}
+	return out, rows.Err()
+}
+
+// redact rewrites rows with rewrite. The tombstones are recorded first, in a
+// chained event_redaction event, and the events are rewritten after: should
+// the rewrite fail, the untouched events still verify against their own
+// hashes. It returns nil when rewrite changed nothing.
+func (s *Store) redact(ctx context.Context, red Redaction, by string, rows []storedEvent, rewrite func(*Event) bool) (*Redaction, error) {
+	red.RedactionID = "red_" + uuid.New().String()[:8]
+	var changed []storedEvent
+	for _, se := range rows {
+		e := se.event
+		if !rewrite(&e) {
+			continue
+		}
+		e.RedactedBy = red.RedactionID
+		if e.EventHash != "" {
+			red.Tombstones = append(red.Tombstones, Tombstone{
+				EventID:      e.EventID,
+				OriginalHash: e.EventHash,
+				RedactedHash: ComputeEventHash(&e),
+			})
+		}
+		changed = append(changed, storedEvent{id: se.id, event: e})
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	if err := s.Record(ctx, &Event{
+		EventID:   red.RedactionID,
+		EventType: EventTypeRedaction,
+		Session:   Session{ID: "redaction_" + red.RedactionID, UserID: by},
+		Redaction: &red,
+	}); err != nil {
+		return nil, fmt.Errorf("record redaction: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	for _, se := range changed {
+		if err := updateRedacted(ctx, tx, s.isPostgres, se); err != nil {
+			return nil, fmt.Errorf("rewrite event %s: %w", se.event.EventID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit redaction: %w", err)
+	}
+	slog.Info("stored events redacted", "redaction_id", red.RedactionID, "kind", red.Kind, "events", len(changed), "by", by)
+	return &red, nil
+}
+
+// updateRedacted writes a rewritten event and the columns derived from it.
+func updateRedacted(ctx context.Context, tx *sql.Tx, isPostgres bool, se storedEvent) error {
+	e := &se.event
+	rawJSON, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var toolJSON, decisionJSON, approvalJSON []byte
+	if e.Tool != nil {
+		toolJSON, _ = json.Marshal(e.Tool)
+	}
+	if e.Decision != nil {
+		decisionJSON, _ = json.Marshal(e.Decision)
+	}
+	if e.Approval != nil {
+		approvalJSON, _ = json.Marshal(e.Approval)
+	}
+	purposeNote := e.PurposeNote
+	if e.Purpose == "" && e.PolicyDecision != nil {
+		purposeNote = e.PolicyDecision.PurposeNote
+	}
+	_, err = tx.ExecContext(ctx, rebind(isPostgres, `
+		UPDATE audit_events
+		SET user_id = ?, user_query = ?, purpose_note = ?, tool_json = ?, decision_json = ?, approval_json = ?, raw_json = ?
+		WHERE id = ?`),
+		e.Session.UserID, e.Input.UserQuery, purposeNote,
+		string(toolJSON), string(decisionJSON), string(approvalJSON), string(rawJSON), se.id)
+	return err
+}
+
+// eraseEvent replaces the identifiers of e that are in ids with subject and,
+// when e was recorded for the subject, its free text with ErasedField.
+// Structure (event type, tools, agents, resources, outcomes, timings) is kept
+// so the trail still shows what happened. An event that only names the
+// subject as, say, its approver keeps the requester's text.
+func eraseEvent(e *Event, ids map[string]bool, subject string) bool {
+	before, _ := json.Marshal(e)
+	erase := func(v *string) {
+		if *v != "" {
+			*v = ErasedField
+		}
+	}
+	eraseAll := func(vs []string) {
+		for i := range vs {
+			erase(&vs[i])
+		}
+	}
+	swap := func(id *string) bool {
+		if *id != "" && ids[*id] {
+			*id = subject
+			return true
+		}
+		return false
+	}
+
+	own := swap(&e.Session.UserID)
+	if p := e.Principal; p != nil {
+		own = swap(&p.UserID) || own
+		own = swap(&p.OperatorID) || own
+	}
+	if pd := e.PolicyDecision; pd != nil {
+		own = swap(&pd.UserID) || own
+	}
+	if a := e.Approval; a != nil {
+		if swap(&a.RequestedBy) {
+			erase(&a.Justification)
+		}
+		swap(&a.ApprovedBy)
+	}
+	if b := e.ApprovalSLABreach; b != nil {
+		swap(&b.RequestedBy)
+		swap(&b.ResolvedBy)
+	}
+
+	if own {
+		erase(&e.PurposeNote)
+		erase(&e.Input.UserQuery)
+		if e.Output != nil {
+			erase(&e.Output.Response)
+		}
+		if t := e.Tool; t != nil {
+			erase(&t.RawCommand)
+			erase(&t.Result)
+			erase(&t.Error)
+			eraseAll(t.Argv)
+			for k := range t.Parameters {
+				t.Parameters[k] = ErasedField
+			}
+		}
+		if d := e.Decision; d != nil {
+			erase(&d.UserIntent)
+			eraseAll(d.ReasoningChain)
+		}
+		if a := e.Approval; a != nil {
+			erase(&a.Justification)
+		}
+		if pd := e.PolicyDecision; pd != nil {
+			erase(&pd.PurposeNote)
+		}
+		if ar := e.AgentReasoning; ar != nil {
+			erase(&ar.Reasoning)
+		}
+		if rr := e.ResearchResult; rr != nil {
+			erase(&rr.Query)
+			eraseAll(rr.SearchQueries)
+		}
+	}
+	after, _ := json.Marshal(e)
+	return string(before) != string(after)
+}
+
+// ── Records outside audit_events ─────────────────────────────────────────────
+
+// SubjectRecords is a store outside audit_events that keeps user identifiers
+// or free text written by users: approval requests, annotations, feedback.
+// These tables are not hash-chained, so they are rewritten in place.
+type SubjectRecords interface {
+	// EraseSubjectRecords replaces ids in the store's identity columns with
+	// subject and erases the free text of the records the subject wrote. It
+	// returns the number of records changed.
+	EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error)
+}
+
+// eraseSubjectRows replaces ids in idColumn of table with subject and sets
+// the non-empty textColumns of those rows to ErasedField.
+func eraseSubjectRows(ctx context.Context, db *sql.DB, isPostgres bool, table, idColumn string, textColumns []string, ids []string, subject string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	set := idColumn + " = ?"
+	args := []any{subject}
+	for _, c := range textColumns {
+		set += fmt.Sprintf(", %s = CASE WHEN COALESCE(%s, '') = '' THEN %s ELSE ? END", c, c, c)
+		args = append(args, ErasedField)
+	}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	res, err := db.ExecContext(ctx, rebind(isPostgres,
+		"UPDATE "+table+" SET "+set+" WHERE "+idColumn+" IN (?"+strings.Repeat(", ?", len(ids)-1)+")"), args...)
+	if err != nil {
+		return 0, fmt.Errorf("erase subject from %s.%s: %w", table, idColumn, err)
+	}
+	return res.RowsAffected()
+}
+
+// redactedHashes returns the tombstones of every recorded redaction by event
+// ID, for verifying rewritten events.
+func (s *Store) redactedHashes(ctx context.Context) (map[string][]Tombstone, error) {
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres,
+		`SELECT raw_json FROM audit_events WHERE event_type = ? ORDER BY id ASC`), string(EventTypeRedaction))
+	if err != nil {
+		return nil, fmt.Errorf("query redactions: %w", err)
+	}
+	defer rows.Close()
+	out := map[string][]Tombstone{}
+	for rows.Next() {
+		var rawJSON string
+		if err := rows.Scan(&rawJSON); err != nil {
+			return nil, fmt.Errorf("scan redaction: %w", err)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(rawJSON), &e); err != nil {
+			return nil, fmt.Errorf("unmarshal redaction: %w", err)
+		}
+		if e.Redaction == nil {
+			continue
+		}
+		for _, t := range e.Redaction.Tombstones {
+			out[t.EventID] = append(out[t.EventID], t)
+		}
+	}
+	return out, rows.Err()
+}
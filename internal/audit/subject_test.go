@@ -0,0 +1,328 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPseudonymizer(t *testing.T) {
+	old := NewPseudonymizer([]byte("pepper-1"))
+	p := NewPseudonymizer([]byte("pepper-2"), []byte("pepper-1"))
+
+	ps := p.Pseudonym("alice@example.com")
+	if !IsPseudonym(ps) || strings.Contains(ps, "alice") {
+		t.Fatalf("Pseudonym = %q", ps)
+	}
+	if p.Pseudonym("alice@example.com") != ps {
+		t.Error("pseudonym is not stable")
+	}
+	if p.Pseudonym(ps) != ps || p.Pseudonym("") != "" {
+		t.Error("pseudonyms and empty IDs must pass through")
+	}
+	if p.Pseudonym("bob@example.com") == ps {
+		t.Error("two users share a pseudonym")
+	}
+	// After rotation the old pseudonym is still among the candidates.
+	all := p.Pseudonyms("alice@example.com")
+	if len(all) != 2 || all[0] != ps || all[1] != old.Pseudonym("alice@example.com") {
+		t.Errorf("Pseudonyms = %v", all)
+	}
+}
+
+func TestStore_EraseSubject(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	record := func(e *Event) {
+		t.Helper()
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	record(&Event{EventID: "evt_a1", EventType: EventTypeDelegation, TraceID: "tr_a", Session: Session{ID: "s1", UserID: "alice"},
+		Input: Input{UserQuery: "why is the orders db of Alice Smith slow?"}, Decision: &Decision{Agent: "database", UserIntent: "Alice Smith's orders"}})
+	record(&Event{EventID: "evt_b1", EventType: EventTypeToolExecution, Session: Session{ID: "s2", UserID: "bob"},
+		Tool: &ToolExecution{Name: "run_sql", RawCommand: "SELECT 1"}})
+	record(&Event{EventID: "evt_a2", EventType: EventTypeToolExecution, Session: Session{ID: "s1", UserID: "alice"},
+		Tool: &ToolExecution{Name: "run_sql", RawCommand: "SELECT * FROM orders WHERE owner = 'Alice Smith'", Result: "3 rows"}})
+
+	reds, err := store.EraseSubject(ctx, "alice", "GDPR art. 17 request", "dpo")
+	if err != nil {
+		t.Fatalf("EraseSubject: %v", err)
+	}
+	if len(reds) != 1 || len(reds[0].Tombstones) != 2 || reds[0].Subject != ErasedField {
+		t.Fatalf("redactions = %+v", reds)
+	}
+
+	for _, id := range []string{"evt_a1", "evt_a2"} {
+		events, _ := store.Query(ctx, QueryOptions{EventID: id})
+		if len(events) != 1 || strings.Contains(events[0].String(), "Alice") || strings.Contains(events[0].String(), `"alice"`) {
+			t.Errorf("%s not erased: %s", id, events[0].String())
+		}
+		if events[0].RedactedBy != reds[0].RedactionID {
+			t.Errorf("%s: redacted_by = %q", id, events[0].RedactedBy)
+		}
+	}
+	var userQuery string
+	if err := store.DB().QueryRow(`SELECT user_query FROM audit_events WHERE event_id = 'evt_a1'`).Scan(&userQuery); err != nil || userQuery != ErasedField {
+		t.Errorf("user_query column = %q, %v", userQuery, err)
+	}
+	if events, _ := store.Query(ctx, QueryOptions{EventID: "evt_b1"}); events[0].Tool.RawCommand != "SELECT 1" {
+		t.Error("another user's event was erased")
+	}
+	if events, _ := store.Query(ctx, QueryOptions{EventType: EventTypeRedaction}); len(events) != 1 || events[0].Session.UserID != "dpo" {
+		t.Errorf("erasure not audited: %+v", events)
+	}
+
+	// The rewritten events verify against their tombstones.
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Fatalf("VerifyIntegrity after erasure = %+v, %v", status, err)
+	}
+
+	// Tampering with an erased event is still detected.
+	if _, err := store.DB().Exec(`UPDATE audit_events SET raw_json = REPLACE(raw_json, '"run_sql"', '"drop_table"') WHERE event_id = 'evt_a2'`); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := store.VerifyIntegrity(ctx); status.Valid {
+		t.Error("tampered erased event passed verification")
+	}
+
+	// Nothing left to erase.
+	if reds, err := store.EraseSubject(ctx, "carol", "", "dpo"); err != nil || len(reds) != 0 {
+		t.Errorf("EraseSubject(unknown) = %+v, %v", reds, err)
+	}
+}
+
+func TestStore_Pseudonymization(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	ctx := context.Background()
+	plain, err := NewStore(StoreConfig{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := plain.Record(ctx, &Event{EventID: "evt_old", EventType: EventTypeDelegation, TraceID: "tr_old",
+		Session: Session{ID: "s1", UserID: "alice"}, Input: Input{UserQuery: "check my orders db"}, Decision: &Decision{Agent: "database"}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	plain.Close()
+
+	p := NewPseudonymizer([]byte("pepper"))
+	store, err := NewStore(StoreConfig{DBPath: dbPath, Pseudonymizer: p})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	if err := store.Record(ctx, &Event{EventID: "evt_new", EventType: EventTypeDelegation, TraceID: "tr_new",
+		Session: Session{ID: "s2", UserID: "alice"}, Input: Input{UserQuery: "check my orders db"}, Decision: &Decision{Agent: "database"}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	events, _ := store.Query(ctx, QueryOptions{EventID: "evt_new"})
+	if got := events[0].Session.UserID; got != p.Pseudonym("alice") {
+		t.Errorf("new event user_id = %q, want pseudonym", got)
+	}
+
+	// Journeys are found by the raw ID, stored raw or pseudonymized.
+	journeys, err := store.QueryJourneys(ctx, JourneyOptions{UserID: "alice"})
+	if err != nil || len(journeys) != 2 {
+		t.Errorf("QueryJourneys = %d journeys, %v", len(journeys), err)
+	}
+
+	n, err := store.PseudonymizeStored(ctx, "dpo")
+	if err != nil || n != 1 {
+		t.Fatalf("PseudonymizeStored = %d, %v", n, err)
+	}
+	events, _ = store.Query(ctx, QueryOptions{EventID: "evt_old"})
+	if got := events[0].Session.UserID; got != p.Pseudonym("alice") {
+		t.Errorf("backfilled user_id = %q, want pseudonym", got)
+	}
+	if n, _ := store.PseudonymizeStored(ctx, "dpo"); n != 0 {
+		t.Errorf("second backfill rewrote %d events", n)
+	}
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity = %+v, %v", status, err)
+	}
+
+	// Erasing by raw ID reaches the pseudonymized events.
+	reds, err := store.EraseSubject(ctx, "alice", "", "dpo")
+	if err != nil || len(reds) != 1 || len(reds[0].Tombstones) != 2 || reds[0].Subject != p.Pseudonym("alice") {
+		t.Errorf("EraseSubject = %+v, %v", reds, err)
+	}
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity after erasure = %+v, %v", status, err)
+	}
+}
+
+func TestPseudonymizer_ApprovalIdentities(t *testing.T) {
+	p := NewPseudonymizer([]byte("pepper"))
+	e := &Event{
+		Approval:          &Approval{Status: ApprovalApproved, RequestedBy: "alice", ApprovedBy: "bob"},
+		ApprovalSLABreach: &ApprovalSLABreach{RequestedBy: "alice", ResolvedBy: "bob"},
+	}
+	if !p.apply(e) {
+		t.Fatal("apply changed nothing")
+	}
+	if e.Approval.ApprovedBy != p.Pseudonym("bob") || e.Approval.RequestedBy != p.Pseudonym("alice") {
+		t.Errorf("approval = %+v", e.Approval)
+	}
+	if e.ApprovalSLABreach.ResolvedBy != p.Pseudonym("bob") || e.ApprovalSLABreach.RequestedBy != p.Pseudonym("alice") {
+		t.Errorf("sla breach = %+v", e.ApprovalSLABreach)
+	}
+
+	// An auto-approval is approved by a policy, not a person.
+	auto := &Event{Approval: &Approval{Status: ApprovalAutoApproved, ApprovedBy: "read-only-policy"}}
+	p.apply(auto)
+	if auto.Approval.ApprovedBy != "read-only-policy" {
+		t.Errorf("auto-approval approver = %q", auto.Approval.ApprovedBy)
+	}
+}
+
+func TestStore_EraseSubject_ApproverOnly(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	// alice never ran anything; she approved bob's action and let another
+	// request breach its SLA.
+	for _, e := range []*Event{
+		{EventID: "evt_b1", EventType: EventTypeToolExecution, Session: Session{ID: "s2", UserID: "bob"},
+			Tool:     &ToolExecution{Name: "run_sql", RawCommand: "DELETE FROM orders"},
+			Approval: &Approval{Status: ApprovalApproved, RequestedBy: "bob", ApprovedBy: "alice", Justification: "bob's cleanup"}},
+		{EventID: "evt_sla", EventType: EventTypeApprovalSLABreach, Session: Session{ID: "auditd"},
+			ApprovalSLABreach: &ApprovalSLABreach{ApprovalID: "apr_1", RequestedBy: "bob", ResolvedBy: "alice"}},
+		{EventID: "evt_c1", EventType: EventTypeToolExecution, Session: Session{ID: "s3", UserID: "carol"},
+			Tool: &ToolExecution{Name: "run_sql", Parameters: map[string]any{"owner": "alice"}}},
+	} {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	reds, err := store.EraseSubject(ctx, "alice", "art. 17", "dpo")
+	if err != nil {
+		t.Fatalf("EraseSubject: %v", err)
+	}
+	if len(reds) != 1 || len(reds[0].Tombstones) != 2 {
+		t.Fatalf("redactions = %+v, want evt_b1 and evt_sla rewritten", reds)
+	}
+
+	events, _ := store.Query(ctx, QueryOptions{EventID: "evt_b1"})
+	a := events[0].Approval
+	if a.ApprovedBy != ErasedField || a.RequestedBy != "bob" || a.Justification != "bob's cleanup" {
+		t.Errorf("approval = %+v, want only the approver erased", a)
+	}
+	if events[0].Session.UserID != "bob" || events[0].Tool.RawCommand != "DELETE FROM orders" {
+		t.Errorf("the requester's event lost its own content: %s", events[0].String())
+	}
+	events, _ = store.Query(ctx, QueryOptions{EventID: "evt_sla"})
+	if b := events[0].ApprovalSLABreach; b.ResolvedBy != ErasedField || b.RequestedBy != "bob" {
+		t.Errorf("sla breach = %+v", b)
+	}
+	// A tool argument that happens to equal the ID is not an identity field.
+	events, _ = store.Query(ctx, QueryOptions{EventID: "evt_c1"})
+	if events[0].Tool.Parameters["owner"] != "alice" || events[0].RedactedBy != "" {
+		t.Errorf("unrelated event rewritten: %s", events[0].String())
+	}
+	if status, err := store.VerifyIntegrity(ctx); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity after erasure = %+v, %v", status, err)
+	}
+}
+
+func TestStore_PseudonymizeStored_Approver(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	ctx := context.Background()
+	plain, err := NewStore(StoreConfig{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	// Recorded by a service, approved by a person: only raw_json names alice.
+	if err := plain.Record(ctx, &Event{EventID: "evt_svc", EventType: EventTypeToolExecution,
+		Session:  Session{ID: "s1"},
+		Approval: &Approval{Status: ApprovalApproved, ApprovedBy: "alice"}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	plain.Close()
+
+	p := NewPseudonymizer([]byte("pepper"))
+	store, err := NewStore(StoreConfig{DBPath: dbPath, Pseudonymizer: p})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	if n, err := store.PseudonymizeStored(ctx, "dpo"); err != nil || n != 1 {
+		t.Fatalf("PseudonymizeStored = %d, %v", n, err)
+	}
+	events, _ := store.Query(ctx, QueryOptions{EventID: "evt_svc"})
+	if got := events[0].Approval.ApprovedBy; got != p.Pseudonym("alice") {
+		t.Errorf("approved_by = %q, want pseudonym", got)
+	}
+	if n, _ := store.PseudonymizeStored(ctx, "dpo"); n != 0 {
+		t.Errorf("second backfill rewrote %d events", n)
+	}
+}
+
+func TestSubjectRecords_Erase(t *testing.T) {
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	approvals, err := NewApprovalStore(store.DB(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := NewEventAnnotationStore(store.DB(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine := &StoredApproval{ActionClass: "write", RequestedBy: "alice", RequestContext: map[string]any{"query": "drop alice's table"}}
+	theirs := &StoredApproval{ActionClass: "write", RequestedBy: "bob"}
+	for _, req := range []*StoredApproval{mine, theirs} {
+		if err := approvals.CreateRequest(ctx, req); err != nil {
+			t.Fatalf("CreateRequest: %v", err)
+		}
+	}
+	if err := approvals.Deny(ctx, theirs.ApprovalID, "alice", "not during the freeze, Bob"); err != nil {
+		t.Fatalf("Deny: %v", err)
+	}
+	if err := annotations.Create(ctx, &EventAnnotation{EventID: "evt_1", Note: "alice checked this", CreatedBy: "alice"}); err != nil {
+		t.Fatalf("Create annotation: %v", err)
+	}
+
+	ids, subject := store.SubjectIDs("alice"), store.SubjectPseudonym("alice")
+	if n, err := approvals.EraseSubjectRecords(ctx, ids, subject); err != nil || n != 2 {
+		t.Fatalf("approvals.EraseSubjectRecords = %d, %v", n, err)
+	}
+	if n, err := annotations.EraseSubjectRecords(ctx, ids, subject); err != nil || n != 1 {
+		t.Fatalf("annotations.EraseSubjectRecords = %d, %v", n, err)
+	}
+
+	got, _ := approvals.GetRequest(ctx, mine.ApprovalID)
+	if got.RequestedBy != subject || got.RequestContext != nil {
+		t.Errorf("requested approval = %+v", got)
+	}
+	// It was still pending, so erasure cancelled it: otherwise alice could
+	// approve it, their raw ID no longer matching the pseudonymous requester.
+	if got.Status != "cancelled" || got.ResolutionReason != "Requester erased" {
+		t.Errorf("pending approval of the erased requester: status %q, reason %q", got.Status, got.ResolutionReason)
+	}
+	if err := approvals.Approve(ctx, mine.ApprovalID, "alice", "", 0); err == nil {
+		t.Error("erased requester approved their own request")
+	}
+	got, _ = approvals.GetRequest(ctx, theirs.ApprovalID)
+	if got.RequestedBy != "bob" || got.ResolvedBy != subject || got.ResolutionReason != ErasedField {
+		t.Errorf("resolved approval = %+v", got)
+	}
+	list, _ := annotations.List(ctx, "evt_1")
+	if len(list) != 1 || list[0].CreatedBy != subject || list[0].Note != ErasedField {
+		t.Errorf("annotations = %+v", list)
+	}
+}
@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxReportTop bounds the top-resources and top-agents lists of a report,
+// and maxReportNotable the notable events it lists one by one.
+const (
+	maxReportTop     = 10
+	maxReportNotable = 20
+)
+
+// reportResourceParams are the tool parameters, in order of preference, that
+// name the resource a tool call touched.
+var reportResourceParams = []string{"namespace", "database", "context", "cluster", "host", "server", "target", "schema"}
+
+// ReportCount is one row of a report's top lists.
+type ReportCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ReportEvent is an event a subscriber should look at individually: an
+// enforced denial or approval requirement, a failed or destructive tool
+// call, or a governance violation.
+type ReportEvent struct {
+	EventID   string    `json:"event_id"`
+	Timestamp time.Time `json:"timestamp"`
+	EventType string    `json:"event_type"`
+	Agent     string    `json:"agent,omitempty"`
+	Resource  string    `json:"resource,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Summary   string    `json:"summary"`
+}
+
+// SubscriptionReport is what one report subscription delivers: a summary of
+// the events in its window that match its filters.
+type SubscriptionReport struct {
+	SubscriptionID string   `json:"subscription_id"`
+	Name           string   `json:"name,omitempty"`
+	Frequency      string   `json:"frequency"`
+	Since          string   `json:"since"`
+	Until          string   `json:"until"`
+	Agent          string   `json:"agent,omitempty"`
+	Resource       string   `json:"resource,omitempty"`
+	EventTypes     []string `json:"event_types,omitempty"`
+
+	TotalEvents     int            `json:"total_events"`
+	ByEventType     map[string]int `json:"by_event_type"`
+	ToolExecutions  int            `json:"tool_executions"`
+	ToolErrors      int            `json:"tool_errors"`
+	Destructive     int            `json:"destructive"`
+	PolicyDecisions int            `json:"policy_decisions"`
+	Denied          int            `json:"denied"`
+	ApprovalsNeeded int            `json:"approvals_needed"`
+	TopResources    []ReportCount  `json:"top_resources"`
+	TopAgents       []ReportCount  `json:"top_agents"`
+	// Notable lists the first maxReportNotable notable events, oldest
+	// first; NotableTotal counts all of them.
+	Notable      []ReportEvent `json:"notable"`
+	NotableTotal int           `json:"notable_total"`
+}
+
+// SubscriptionReport builds the report for sub over [since, until). Events
+// are narrowed to sub's tenant and event types in the query, and to its agent
+// and resource afterwards; Resource may be a path.Match glob.
+func (s *Store) SubscriptionReport(ctx context.Context, sub *ReportSubscription, since, until time.Time) (*SubscriptionReport, error) {
+	query := `SELECT raw_json FROM audit_events WHERE timestamp >= ? AND timestamp < ?`
+	args := []any{since.UTC().Format(sqliteTimeFormat), until.UTC().Format(sqliteTimeFormat)}
+	if sub.TenantID != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, sub.TenantID)
+	}
+	if len(sub.EventTypes) > 0 {
+		query += " AND event_type IN (?" + strings.Repeat(", ?", len(sub.EventTypes)-1) + ")"
+		for _, t := range sub.EventTypes {
+			args = append(args, t)
+		}
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query+" ORDER BY id"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("subscription report query: %w", err)
+	}
+	defer rows.Close()
+
+	rep := &SubscriptionReport{
+		SubscriptionID: sub.SubscriptionID,
+		Name:           sub.Name,
+		Frequency:      sub.Frequency,
+		Since:          since.UTC().Format(time.RFC3339),
+		Until:          until.UTC().Format(time.RFC3339),
+		Agent:          sub.Agent,
+		Resource:       sub.Resource,
+		EventTypes:     sub.EventTypes,
+		ByEventType:    map[string]int{},
+		Notable:        []ReportEvent{},
+	}
+	resources, agents := map[string]int{}, map[string]int{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("scan subscription report event: %w", err)
+		}
+		var e Event
+		if json.Unmarshal([]byte(raw), &e) != nil {
+			continue
+		}
+		agent, resource := scorecardAgent(&e), reportResource(&e)
+		if sub.Agent != "" && agent != sub.Agent {
+			continue
+		}
+		if sub.Resource != "" {
+			if ok, _ := path.Match(sub.Resource, resource); !ok {
+				continue
+			}
+		}
+
+		rep.TotalEvents++
+		rep.ByEventType[string(e.EventType)]++
+		if agent != "" {
+			agents[agent]++
+		}
+		if resource != "" {
+			resources[resource]++
+		}
+		if summary := rep.count(&e); summary != "" {
+			rep.NotableTotal++
+			if len(rep.Notable) < maxReportNotable {
+				rep.Notable = append(rep.Notable, ReportEvent{
+					EventID:   e.EventID,
+					Timestamp: e.Timestamp,
+					EventType: string(e.EventType),
+					Agent:     agent,
+					Resource:  resource,
+					TraceID:   e.TraceID,
+					Summary:   summary,
+				})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rep.TopResources = topReportCounts(resources)
+	rep.TopAgents = topReportCounts(agents)
+	return rep, nil
+}
+
+// count adds an event to the report's counters and returns a one-line
+// summary when the event is notable, or "".
+func (rep *SubscriptionReport) count(e *Event) string {
+	switch e.EventType {
+	case EventTypeToolExecution:
+		rep.ToolExecutions++
+		name := ""
+		if e.Tool != nil {
+			name = e.Tool.Name
+		}
+		destructive := e.ActionClass == ActionDestructive
+		if destructive {
+			rep.Destructive++
+		}
+		if e.Outcome != nil && e.Outcome.Status == "error" {
+			rep.ToolErrors++
+			return fmt.Sprintf("%s failed: %s", name, e.Outcome.ErrorMessage)
+		}
+		if destructive {
+			return fmt.Sprintf("destructive call %s", name)
+		}
+	case EventTypePolicyDecision:
+		pd := e.PolicyDecision
+		if pd == nil {
+			return ""
+		}
+		rep.PolicyDecisions++
+		if pd.DryRun {
+			return ""
+		}
+		switch pd.Effect {
+		case "deny":
+			rep.Denied++
+			return fmt.Sprintf("%s on %s %s denied by %s", pd.Action, pd.ResourceType, pd.ResourceName, pd.PolicyName)
+		case "require_approval":
+			rep.ApprovalsNeeded++
+			return fmt.Sprintf("%s on %s %s required approval (%s)", pd.Action, pd.ResourceType, pd.ResourceName, pd.PolicyName)
+		}
+	case EventTypeGovernanceViolation:
+		if v := e.GovernanceViolation; v != nil {
+			return "governance violation: " + v.Description
+		}
+		return "governance violation"
+	}
+	return ""
+}
+
+// reportResource is the database, namespace or host an event concerns, or ""
+// when it names none.
+func reportResource(e *Event) string {
+	if pd := e.PolicyDecision; pd != nil && pd.ResourceName != "" {
+		return pd.ResourceName
+	}
+	if e.Tool != nil {
+		for _, p := range reportResourceParams {
+			if v, ok := e.Tool.Parameters[p].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// topReportCounts returns the largest counts, ties broken by name.
+func topReportCounts(m map[string]int) []ReportCount {
+	out := make([]ReportCount, 0, len(m))
+	for name, n := range m {
+		out = append(out, ReportCount{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > maxReportTop {
+		out = out[:maxReportTop]
+	}
+	return out
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	"helpdesk/internal/shutdown"
 )
 
 // ToolAuditor wraps tool executions with audit logging.
@@ -17,8 +20,22 @@ type ToolAuditor struct {
 	sessionID  string
 	traceID    string             // Static trace ID (fallback)
 	traceStore *CurrentTraceStore // Dynamic trace ID from incoming requests
+
+	grantsMu sync.Mutex
+	grants   map[string]approvalGrant // trace ID → approval not yet linked to a mutation
+}
+
+// approvalGrant is an approved request waiting for the mutation it authorised.
+type approvalGrant struct {
+	approval  Approval
+	grantedAt time.Time
 }
 
+// approvalGrantTTL bounds how long a grant waits for its mutation. The
+// mutation follows the policy check within the same tool call, so anything
+// older was never executed (e.g. blocked by a later pre-execution check).
+const approvalGrantTTL = 10 * time.Minute
+
 // NewToolAuditor creates a new tool auditor for an agent.
 // If auditor is nil, auditing is disabled (no-op).
 func NewToolAuditor(auditor Auditor, agentName, sessionID, traceID string) *ToolAuditor {
@@ -51,11 +68,22 @@ func (ta *ToolAuditor) getTraceID() string {
 	return ta.traceID
 }
 
+// record stamps the current request's traceparent on event and records it.
+// Tool callbacks often run with a context that lacks the request's
+// TraceContext, so the trace store is the reliable source.
+func (ta *ToolAuditor) record(ctx context.Context, event *Event) error {
+	if event.TraceParent == "" && ta.traceStore != nil {
+		event.TraceParent = ta.traceStore.TraceParent()
+	}
+	return ta.auditor.Record(ctx, event)
+}
+
 // ToolCall represents a tool invocation to be audited.
 type ToolCall struct {
 	Name       string
 	Parameters map[string]any
-	RawCommand string // e.g., the actual SQL query or kubectl command
+	RawCommand string   // e.g., the actual SQL query or kubectl command
+	Argv       []string // exact argv executed, passwords masked (see agentutil.AuditArgv)
 	// PreState is the resource state captured immediately before a mutation.
 	// Agents populate this for reversible operations to enable rollback.
 	// Must be valid JSON (or nil). Stored verbatim in the audit event.
@@ -64,13 +92,15 @@ type ToolCall struct {
 
 // ToolResult represents the result of a tool invocation.
 type ToolResult struct {
-	Output string
-	Error  string
+	Output       string
+	Error        string
+	RowsAffected int // row count of a DML command, when known
 }
 
 // RecordToolCall records a tool execution event.
 // Call this after the tool has executed with its result.
 func (ta *ToolAuditor) RecordToolCall(ctx context.Context, call ToolCall, result ToolResult, duration time.Duration) {
+	PhaseTimerFromContext(ctx).AddTool(call.Name, duration)
 	if ta.auditor == nil {
 		return
 	}
@@ -101,11 +131,13 @@ func (ta *ToolAuditor) RecordToolCall(ctx context.Context, call ToolCall, result
 			Name:       call.Name,
 			Parameters: call.Parameters,
 			RawCommand: call.RawCommand,
+			Argv:       call.Argv,
 			Result:     truncateString(result.Output, 500),
-			Error:      result.Error,
-			Duration:   duration,
-			Agent:      ta.agentName, // Track which agent executed this tool
-			PreState:   call.PreState,
+			Error:        result.Error,
+			RowsAffected: result.RowsAffected,
+			Duration:     duration,
+			Agent:        ta.agentName, // Track which agent executed this tool
+			PreState:     call.PreState,
 		},
 		// No Decision for tool executions - they're not LLM decisions
 		Outcome: &Outcome{
@@ -134,25 +166,106 @@ func (ta *ToolAuditor) RecordToolCall(ctx context.Context, call ToolCall, result
 		}
 	}
 
-	if err := ta.auditor.Record(ctx, event); err != nil {
+	// Link the mutation to the human approval that authorised it, so auditd
+	// can report the outcome on the approval record.
+	if event.Approval == nil && (actionClass == ActionWrite || actionClass == ActionDestructive) {
+		if grant, ok := ta.takeApprovalGrant(traceID); ok {
+			event.Approval = &grant
+		}
+	}
+
+	if err := ta.record(ctx, event); err != nil {
 		slog.Warn("failed to record tool audit event", "tool", call.Name, "err", err)
 	}
 }
 
+// RecordApprovalGrant notes that an approved request authorised the tool
+// call in progress on the current trace. The next write or destructive
+// tool_execution event on that trace carries the approval, with its ID, and
+// consumes the grant. Call this from the policy enforcer when it lets a call
+// through on an existing approval.
+func (ta *ToolAuditor) RecordApprovalGrant(approvalID, requestedBy, approvedBy string, approvedAt time.Time) {
+	if ta == nil || approvalID == "" {
+		return
+	}
+	traceID := ta.getTraceID()
+	if traceID == "" {
+		return
+	}
+	now := time.Now()
+	ta.grantsMu.Lock()
+	defer ta.grantsMu.Unlock()
+	if ta.grants == nil {
+		ta.grants = make(map[string]approvalGrant)
+	}
+	for id, g := range ta.grants {
+		if now.Sub(g.grantedAt) > approvalGrantTTL {
+			delete(ta.grants, id)
+		}
+	}
+	ta.grants[traceID] = approvalGrant{
+		approval: Approval{
+			Required:    true,
+			Status:      ApprovalApproved,
+			RequestedBy: requestedBy,
+			ApprovedBy:  approvedBy,
+			ApprovedAt:  approvedAt,
+			ApprovalID:  approvalID,
+		},
+		grantedAt: now,
+	}
+}
+
+// takeApprovalGrant removes and returns the grant waiting on traceID.
+func (ta *ToolAuditor) takeApprovalGrant(traceID string) (Approval, bool) {
+	if traceID == "" {
+		return Approval{}, false
+	}
+	ta.grantsMu.Lock()
+	defer ta.grantsMu.Unlock()
+	g, ok := ta.grants[traceID]
+	if !ok || time.Since(g.grantedAt) > approvalGrantTTL {
+		return Approval{}, false
+	}
+	delete(ta.grants, traceID)
+	return g.approval, true
+}
+
 // RecordPolicyDecision records a policy evaluation result to the audit trail.
 // Call this from PolicyEnforcer after every Evaluate(), before returning to the caller.
 func (ta *ToolAuditor) RecordPolicyDecision(ctx context.Context, pd PolicyDecision) {
 	if ta.auditor == nil {
 		return
 	}
+	if err := ta.record(ctx, ta.policyDecisionEvent(ctx, pd)); err != nil {
+		slog.Warn("failed to record policy decision event", "err", err)
+	}
+}
+
+// RecordCachedPolicyDecision records a decision reused from an earlier policy
+// check (pd.CachedFrom). The event is built immediately, so it carries the
+// current trace ID, and recorded in the background: a cache hit exists to
+// save a round-trip to the audit service. Shutdown waits for the write.
+func (ta *ToolAuditor) RecordCachedPolicyDecision(ctx context.Context, pd PolicyDecision) {
+	if ta.auditor == nil {
+		return
+	}
+	event := ta.policyDecisionEvent(ctx, pd)
+	shutdown.Go(func() {
+		if err := ta.record(context.WithoutCancel(ctx), event); err != nil {
+			slog.Warn("failed to record cached policy decision event", "err", err)
+		}
+	})
+}
 
+func (ta *ToolAuditor) policyDecisionEvent(ctx context.Context, pd PolicyDecision) *Event {
 	event := &Event{
 		EventID:        "pol_" + uuid.New().String()[:8],
 		Timestamp:      time.Now().UTC(),
 		EventType:      EventTypePolicyDecision,
 		TraceID:        ta.getTraceID(),
 		ActionClass:    ActionClass(pd.Action),
-		Session:        Session{ID: ta.sessionID},
+		Session:        Session{ID: ta.sessionID, AgentName: ta.agentName},
 		PolicyDecision: &pd,
 	}
 
@@ -174,10 +287,7 @@ func (ta *ToolAuditor) RecordPolicyDecision(ctx context.Context, pd PolicyDecisi
 			PolicyName:  "approval_mode:auto",
 		}
 	}
-
-	if err := ta.auditor.Record(ctx, event); err != nil {
-		slog.Warn("failed to record policy decision event", "err", err)
-	}
+	return event
 }
 
 // RecordToolInvoked emits an unconditional tool_invoked event at the very start
@@ -210,7 +320,7 @@ func (ta *ToolAuditor) RecordToolInvoked(ctx context.Context, resourceType, reso
 		},
 	}
 
-	if err := ta.auditor.Record(ctx, event); err != nil {
+	if err := ta.record(ctx, event); err != nil {
 		slog.Warn("failed to record tool invoked event", "resource", resourceName, "err", err)
 	}
 }
@@ -243,11 +353,104 @@ func (ta *ToolAuditor) RecordToolRetry(ctx context.Context, toolName string, att
 		Outcome:     &Outcome{Status: status},
 	}
 
-	if err := ta.auditor.Record(ctx, event); err != nil {
+	if err := ta.record(ctx, event); err != nil {
 		slog.Warn("failed to record tool retry event", "tool", toolName, "attempt", attempt, "err", err)
 	}
 }
 
+// RecordBackupStale records a backup check that found no successful backup,
+// or one older than the database's threshold.
+func (ta *ToolAuditor) RecordBackupStale(ctx context.Context, toolName string, stale BackupStale) {
+	if ta.auditor == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("backup stale on %s: %s", stale.Database, stale.Reason)
+	event := &Event{
+		EventID:     "bak_" + uuid.New().String()[:8],
+		Timestamp:   time.Now().UTC(),
+		EventType:   EventTypeBackupStale,
+		TraceID:     ta.getTraceID(),
+		ActionClass: ActionRead,
+		Session:     Session{ID: ta.sessionID},
+		Tool: &ToolExecution{
+			Name:       toolName,
+			Agent:      ta.agentName,
+			Parameters: map[string]any{"database": stale.Database},
+		},
+		Input:       Input{UserQuery: summary},
+		Outcome:     &Outcome{Status: "stale"},
+		BackupStale: &stale,
+	}
+
+	if err := ta.record(ctx, event); err != nil {
+		slog.Warn("failed to record backup stale event", "database", stale.Database, "err", err)
+	}
+}
+
+// RecordResearchResult records an answer of the research agent with the
+// sources it cites.
+func (ta *ToolAuditor) RecordResearchResult(ctx context.Context, result ResearchResult) {
+	if ta.auditor == nil {
+		return
+	}
+
+	status := "success"
+	if result.Cached {
+		status = "cached"
+	}
+	event := &Event{
+		EventID:        "res_" + uuid.New().String()[:8],
+		Timestamp:      time.Now().UTC(),
+		EventType:      EventTypeResearchResult,
+		TraceID:        ta.getTraceID(),
+		ActionClass:    ActionRead,
+		Session:        Session{ID: ta.sessionID},
+		Input:          Input{UserQuery: result.Query},
+		Outcome:        &Outcome{Status: status},
+		ResearchResult: &result,
+	}
+
+	if err := ta.record(ctx, event); err != nil {
+		slog.Warn("failed to record research result event", "citations", len(result.Citations), "err", err)
+	}
+}
+
+// RecordToolArgsRejected records a tool call refused because its arguments
+// failed validation. params are the arguments as received; reason is the
+// validation error returned to the caller.
+func (ta *ToolAuditor) RecordToolArgsRejected(ctx context.Context, toolName string, params map[string]any, reason string) {
+	if ta.auditor == nil {
+		return
+	}
+
+	var origin string
+	if tc := TraceContextFromContext(ctx); tc != nil {
+		origin = tc.Origin
+	}
+	event := &Event{
+		EventID:     "arg_" + uuid.New().String()[:8],
+		Timestamp:   time.Now().UTC(),
+		EventType:   EventTypeToolArgsRejected,
+		TraceID:     ta.getTraceID(),
+		Origin:      origin,
+		ActionClass: ClassifyTool(toolName),
+		Session:     Session{ID: ta.sessionID},
+		Tool: &ToolExecution{
+			Name:       toolName,
+			Agent:      ta.agentName,
+			Parameters: params,
+			Error:      reason,
+		},
+		Input:   Input{UserQuery: fmt.Sprintf("rejected arguments for %s", toolName)},
+		Outcome: &Outcome{Status: "rejected", ErrorMessage: reason},
+	}
+
+	if err := ta.record(ctx, event); err != nil {
+		slog.Warn("failed to record tool args rejection", "tool", toolName, "err", err)
+	}
+}
+
 // RecordToolVerification records the final outcome of a post-mutation verification
 // loop. It is a no-op when verifyStatus is "ok" or empty (the parent
 // tool_execution event already carries "success" in that case).
@@ -283,7 +486,7 @@ func (ta *ToolAuditor) RecordToolVerification(ctx context.Context, toolName, ver
 		Outcome:   &Outcome{Status: outcomeStatus},
 	}
 
-	if err := ta.auditor.Record(ctx, event); err != nil {
+	if err := ta.record(ctx, event); err != nil {
 		slog.Warn("failed to record tool verification event", "tool", toolName, "err", err)
 	}
 }
@@ -308,7 +511,7 @@ func (ta *ToolAuditor) RecordAgentReasoning(ctx context.Context, reasoning strin
 		},
 	}
 
-	if err := ta.auditor.Record(ctx, event); err != nil {
+	if err := ta.record(ctx, event); err != nil {
 		slog.Warn("failed to record agent reasoning event", "err", err)
 	}
 }
@@ -205,6 +205,28 @@ func TestRecordAgentReasoning_DynamicTraceID(t *testing.T) {
 	}
 }
 
+func TestRecordAgentReasoning_TraceParentFromStore(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	store := newToolAuditTestStore(t)
+	traceStore := &CurrentTraceStore{}
+	traceStore.Set("tr_w3c")
+	traceStore.SetTraceParent(tp)
+	ta := NewToolAuditorWithTraceStore(store, "agent", "sess", traceStore)
+
+	ta.RecordAgentReasoning(context.Background(), "I need to call get_nodes.", []string{"get_nodes"})
+
+	events, err := store.Query(context.Background(), QueryOptions{EventType: EventTypeAgentReasoning})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("no events")
+	}
+	if events[0].TraceParent != tp {
+		t.Errorf("TraceParent = %q, want %q", events[0].TraceParent, tp)
+	}
+}
+
 func TestRecordToolRetry_NilAuditor(t *testing.T) {
 	ta := NewToolAuditor(nil, "test-agent", "sess-1", "trace-1")
 	// Should be a no-op and not panic.
@@ -446,3 +468,39 @@ func TestRecordToolVerification_EscalationRequired(t *testing.T) {
 		t.Errorf("Outcome.Status = %q, want escalation_required (passed through unchanged)", events[0].Outcome.Status)
 	}
 }
+
+func TestRecordToolCall_LinksApprovalGrant(t *testing.T) {
+	store := newToolAuditTestStore(t)
+	ta := NewToolAuditor(store, "db-agent", "sess-grant", "trace-grant")
+	ctx := context.Background()
+
+	ta.RecordApprovalGrant("apr_1234", "alice", "bob", time.Now().UTC())
+
+	// A read on the same trace leaves the grant in place.
+	ta.RecordToolCall(ctx, ToolCall{Name: "get_active_connections"}, ToolResult{Output: "ok"}, time.Millisecond)
+	ta.RecordToolCall(ctx, ToolCall{Name: "terminate_connection"}, ToolResult{Output: "t", RowsAffected: 1}, time.Millisecond)
+	// The grant is consumed by the first mutation.
+	ta.RecordToolCall(ctx, ToolCall{Name: "cancel_query"}, ToolResult{Output: "t"}, time.Millisecond)
+
+	events, err := store.Query(ctx, QueryOptions{EventType: EventTypeToolExecution})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	byTool := map[string]Event{}
+	for _, e := range events {
+		byTool[e.Tool.Name] = e
+	}
+	if a := byTool["get_active_connections"].Approval; a != nil {
+		t.Errorf("read tool carries approval %+v", a)
+	}
+	term := byTool["terminate_connection"]
+	if term.Approval == nil || term.Approval.ApprovalID != "apr_1234" || term.Approval.ApprovedBy != "bob" {
+		t.Errorf("terminate_connection approval = %+v, want apr_1234 approved by bob", term.Approval)
+	}
+	if term.Tool.RowsAffected != 1 {
+		t.Errorf("RowsAffected = %d, want 1", term.Tool.RowsAffected)
+	}
+	if a := byTool["cancel_query"].Approval; a != nil {
+		t.Errorf("second mutation carries approval %+v, want grant consumed", a)
+	}
+}
@@ -12,6 +12,9 @@ import (
 // traceContextKey is the context key for trace information.
 type traceContextKey struct{}
 
+// correlationIDKey is the context key for a client-supplied correlation ID.
+type correlationIDKey struct{}
+
 // TraceContext carries trace information through the request chain.
 type TraceContext struct {
 	// TraceID is the top-level request identifier that correlates all events.
@@ -44,6 +47,15 @@ type TraceContext struct {
 
 	// ApprovalSession is the session ID when ApprovalMode == "session".
 	ApprovalSession string `json:"approval_session,omitempty"`
+
+	// RemediationPlan is the ID of an approved remediation plan this request
+	// executes. The policy enforcer only lets write and destructive tool calls
+	// through when they are the plan's next approved step.
+	RemediationPlan string `json:"remediation_plan,omitempty"`
+
+	// TraceParent is the caller's W3C traceparent, propagated unchanged so
+	// the audit events can be exported as spans of the caller's trace.
+	TraceParent string `json:"traceparent,omitempty"`
 }
 
 // NewTraceID generates a new trace ID with the default "tr_" prefix.
@@ -132,11 +144,47 @@ func PurposeExplicitFromContext(ctx context.Context) bool {
 	return false
 }
 
+// stampTenant fills event.Session.TenantID when the caller left it empty,
+// taking the tenant from the event's principal or, failing that, from the
+// principal carried in ctx. It runs before hashing so the tenant is covered
+// by the hash chain.
+func stampTenant(ctx context.Context, event *Event) {
+	if event.Session.TenantID != "" {
+		return
+	}
+	if event.Principal != nil && event.Principal.Tenant != "" {
+		event.Session.TenantID = event.Principal.Tenant
+		return
+	}
+	event.Session.TenantID = PrincipalFromContext(ctx).Tenant
+}
+
+// WithCorrelationID adds a client-supplied correlation ID to ctx. Events
+// recorded with the returned context carry it in Event.CorrelationID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried in ctx, or "".
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// stampCorrelation fills event.CorrelationID from ctx when the caller left
+// it empty. Like stampTenant, it runs before hashing.
+func stampCorrelation(ctx context.Context, event *Event) {
+	if event.CorrelationID == "" {
+		event.CorrelationID = CorrelationIDFromContext(ctx)
+	}
+}
+
 // CurrentTraceStore provides thread-safe storage for the current trace ID.
 // Used when context propagation isn't available (e.g., ADK tools).
 type CurrentTraceStore struct {
-	mu      sync.RWMutex
-	traceID string
+	mu          sync.RWMutex
+	traceID     string
+	traceParent string
 }
 
 // Set stores the current trace ID.
@@ -153,9 +201,24 @@ func (s *CurrentTraceStore) Get() string {
 	return s.traceID
 }
 
-// Clear clears the current trace ID.
+// SetTraceParent stores the W3C traceparent that came with the current request.
+func (s *CurrentTraceStore) SetTraceParent(traceParent string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traceParent = traceParent
+}
+
+// TraceParent retrieves the current W3C traceparent, or "" if the request had none.
+func (s *CurrentTraceStore) TraceParent() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.traceParent
+}
+
+// Clear clears the current trace ID and traceparent.
 func (s *CurrentTraceStore) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.traceID = ""
+	s.traceParent = ""
 }
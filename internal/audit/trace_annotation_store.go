@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Known TraceAnnotation kinds. Kind is free-form; these are the values
+// secbot, govbot and the timeline views recognise.
+const (
+	AnnotationPagerDuty = "pagerduty"
+	AnnotationJira      = "jira"
+	AnnotationSlack     = "slack"
+	AnnotationLink      = "link"
+)
+
+// TraceAnnotation attaches an external reference — a PagerDuty incident, a
+// Jira ticket, a Slack thread — to a trace, so that post-incident review can
+// jump from the audit timeline to the other systems and back.
+//
+// Annotations live outside the hash chain: they are notes about a trace, not
+// part of what happened in it, and may be added long after the trace ended.
+type TraceAnnotation struct {
+	AnnotationID string    `json:"annotation_id"`
+	TraceID      string    `json:"trace_id"`
+	Kind         string    `json:"kind"`                 // pagerduty, jira, slack, link
+	Ref          string    `json:"ref,omitempty"`        // external ID, e.g. "INC-4821" or "PD-Q1W2E3"
+	URL          string    `json:"url,omitempty"`        // deep link into the external system
+	Note         string    `json:"note,omitempty"`       // free-text context
+	Source       string    `json:"source,omitempty"`     // component that attached it, e.g. "secbot"
+	CreatedBy    string    `json:"created_by,omitempty"` // principal that attached it
+	TenantID     string    `json:"tenant_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// annotationTimeFormat is fixed-width so that created_at sorts as text.
+const annotationTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// TraceAnnotationStore persists trace annotations. It shares the same *sql.DB
+// connection as the audit Store.
+type TraceAnnotationStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewTraceAnnotationStore creates the trace_annotations table (if absent) and
+// returns a ready-to-use TraceAnnotationStore.
+func NewTraceAnnotationStore(db *sql.DB, isPostgres bool) (*TraceAnnotationStore, error) {
+	s := &TraceAnnotationStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS trace_annotations (
+    annotation_id TEXT NOT NULL PRIMARY KEY,
+    trace_id      TEXT NOT NULL,
+    kind          TEXT NOT NULL,
+    ref           TEXT NOT NULL DEFAULT '',
+    url           TEXT NOT NULL DEFAULT '',
+    note          TEXT NOT NULL DEFAULT '',
+    source        TEXT NOT NULL DEFAULT '',
+    created_by    TEXT NOT NULL DEFAULT '',
+    tenant_id     TEXT NOT NULL DEFAULT '',
+    created_at    TEXT NOT NULL
+)`); err != nil {
+		return nil, fmt.Errorf("create trace_annotations schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_trace_annotations_trace
+    ON trace_annotations(trace_id)`); err != nil {
+		return nil, fmt.Errorf("create trace_annotations index: %w", err)
+	}
+	return s, nil
+}
+
+// Create validates and inserts a. AnnotationID and CreatedAt are assigned
+// when empty. An annotation needs a trace, a kind, and a ref or URL to
+// point at.
+func (s *TraceAnnotationStore) Create(ctx context.Context, a *TraceAnnotation) error {
+	if a.TraceID == "" {
+		return fmt.Errorf("trace_id is required")
+	}
+	a.Kind = strings.ToLower(strings.TrimSpace(a.Kind))
+	if a.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	if a.Ref == "" && a.URL == "" {
+		return fmt.Errorf("ref or url is required")
+	}
+	if a.AnnotationID == "" {
+		a.AnnotationID = "ann_" + uuid.New().String()[:8]
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO trace_annotations
+    (annotation_id, trace_id, kind, ref, url, note, source, created_by, tenant_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		a.AnnotationID, a.TraceID, a.Kind, a.Ref, a.URL, a.Note, a.Source,
+		a.CreatedBy, a.TenantID, a.CreatedAt.UTC().Format(annotationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("insert trace annotation: %w", err)
+	}
+	return nil
+}
+
+// List returns the annotations of one trace, oldest first.
+func (s *TraceAnnotationStore) List(ctx context.Context, traceID string) ([]TraceAnnotation, error) {
+	byTrace, err := s.ListForTraces(ctx, []string{traceID})
+	if err != nil {
+		return nil, err
+	}
+	return byTrace[traceID], nil
+}
+
+// ListForTraces returns the annotations of several traces keyed by trace ID,
+// each list oldest first. Traces without annotations are absent from the map.
+func (s *TraceAnnotationStore) ListForTraces(ctx context.Context, traceIDs []string) (map[string][]TraceAnnotation, error) {
+	out := make(map[string][]TraceAnnotation)
+	if len(traceIDs) == 0 {
+		return out, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(traceIDs)), ",")
+	args := make([]any, len(traceIDs))
+	for i, id := range traceIDs {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, `
+SELECT annotation_id, trace_id, kind, ref, url, note, source, created_by, tenant_id, created_at
+FROM trace_annotations
+WHERE trace_id IN (`+placeholders+`)
+ORDER BY created_at, annotation_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list trace annotations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a TraceAnnotation
+		var createdAt string
+		if err := rows.Scan(&a.AnnotationID, &a.TraceID, &a.Kind, &a.Ref, &a.URL, &a.Note,
+			&a.Source, &a.CreatedBy, &a.TenantID, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan trace annotation: %w", err)
+		}
+		a.CreatedAt = parseFlexTime(createdAt)
+		out[a.TraceID] = append(out[a.TraceID], a)
+	}
+	return out, rows.Err()
+}
+
+// EraseSubjectRecords replaces ids as annotation author with subject and
+// erases the notes they wrote.
+func (s *TraceAnnotationStore) EraseSubjectRecords(ctx context.Context, ids []string, subject string) (int64, error) {
+	return eraseSubjectRows(ctx, s.db, s.isPostgres, "trace_annotations", "created_by", []string{"note"}, ids, subject)
+}
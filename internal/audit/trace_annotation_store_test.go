@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTraceAnnotationStore_CreateList(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := NewTraceAnnotationStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewTraceAnnotationStore: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, a := range []*TraceAnnotation{
+		{TraceID: "tr_a", Kind: "Jira", Ref: "OPS-12", CreatedAt: base.Add(2 * time.Second)},
+		{TraceID: "tr_a", Kind: AnnotationPagerDuty, Ref: "PD-1", URL: "https://pd.example/incidents/PD-1", Source: "secbot", CreatedAt: base.Add(500 * time.Millisecond)},
+		{TraceID: "tr_b", Kind: AnnotationSlack, URL: "https://slack.example/archives/C1/p1"},
+	} {
+		if err := as.Create(ctx, a); err != nil {
+			t.Fatalf("Create(%+v): %v", a, err)
+		}
+		if a.AnnotationID == "" {
+			t.Error("AnnotationID not assigned")
+		}
+	}
+
+	got, err := as.List(ctx, "tr_a")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 2 || got[0].Ref != "PD-1" || got[1].Ref != "OPS-12" {
+		t.Fatalf("List(tr_a) = %+v, want PD-1 then OPS-12", got)
+	}
+	if got[1].Kind != AnnotationJira {
+		t.Errorf("Kind = %q, want normalised %q", got[1].Kind, AnnotationJira)
+	}
+	if got[0].Source != "secbot" || !got[0].CreatedAt.Equal(base.Add(500*time.Millisecond)) {
+		t.Errorf("annotation = %+v", got[0])
+	}
+
+	byTrace, err := as.ListForTraces(ctx, []string{"tr_a", "tr_b", "tr_none"})
+	if err != nil {
+		t.Fatalf("ListForTraces: %v", err)
+	}
+	if len(byTrace["tr_a"]) != 2 || len(byTrace["tr_b"]) != 1 || len(byTrace["tr_none"]) != 0 {
+		t.Errorf("ListForTraces = %+v", byTrace)
+	}
+
+	for _, bad := range []*TraceAnnotation{
+		{Kind: AnnotationJira, Ref: "OPS-1"},
+		{TraceID: "tr_a", Ref: "OPS-1"},
+		{TraceID: "tr_a", Kind: AnnotationJira},
+	} {
+		if err := as.Create(ctx, bad); err == nil {
+			t.Errorf("Create(%+v) succeeded, want validation error", bad)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package audit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -28,11 +30,14 @@ func TraceMiddleware(store *CurrentTraceStore, next http.Handler) http.Handler {
 //  2. Emits a gateway_request anchor event before dispatching to the next handler,
 //     making the request visible as a journey in the audit log without requiring
 //     an upstream orchestrator or gateway.
+//  3. Emits a delegation_outcome event when the request completes, with its
+//     duration broken down by phase (see PhaseTimings).
 //
 // agentName is used as the decision_agent on the anchor event.
 // auditor may be nil, in which case anchor events are not emitted (step 2 is skipped).
 func TraceMiddlewareWithAudit(store *CurrentTraceStore, auditor Auditor, agentName string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timer := NewPhaseTimer(time.Now())
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			slog.Debug("trace middleware: failed to read body", "err", err)
@@ -54,6 +59,7 @@ func TraceMiddlewareWithAudit(store *CurrentTraceStore, auditor Auditor, agentNa
 			traceID = NewTraceIDWithPrefix("ar_")
 		}
 		store.Set(traceID)
+		store.SetTraceParent(parsed.traceParent)
 		defer store.Clear()
 
 		slog.Debug("trace middleware: trace_id set", "trace_id", traceID, "generated", parsed.traceID == "")
@@ -72,12 +78,15 @@ func TraceMiddlewareWithAudit(store *CurrentTraceStore, auditor Auditor, agentNa
 			PurposeExplicit: parsed.purposeExplicit,
 			ApprovalMode:    parsed.approvalMode,
 			ApprovalSession: parsed.approvalSession,
+			RemediationPlan: parsed.remediationPlan,
+			TraceParent:     parsed.traceParent,
 		}
-		r = r.WithContext(WithTraceContext(r.Context(), tc))
+		r = r.WithContext(WithPhaseTimer(WithTraceContext(r.Context(), tc), timer))
 
 		// Emit the gateway_request anchor event. This is what makes the request
 		// visible as a journey: QueryJourneys Q1 anchors on gateway_request events
 		// (with no tool_name) or delegation_decision events.
+		var anchor *Event
 		if auditor != nil && parsed.userQuery != "" {
 			sessionID := parsed.contextID
 			if sessionID == "" {
@@ -92,7 +101,7 @@ func TraceMiddlewareWithAudit(store *CurrentTraceStore, auditor Auditor, agentNa
 				Principal:   &p,
 				Purpose:     tc.Purpose,
 				PurposeNote: tc.PurposeNote,
-				Session:     Session{ID: sessionID, UserID: p.EffectiveID()},
+				Session:     Session{ID: sessionID, UserID: p.EffectiveID(), TenantID: p.Tenant},
 				Input:       Input{UserQuery: parsed.userQuery},
 				// Tool.Agent is stored as decision_agent so the journey summary
 				// can show which agent handled the request.
@@ -100,16 +109,53 @@ func TraceMiddlewareWithAudit(store *CurrentTraceStore, auditor Auditor, agentNa
 			}
 			if err := auditor.Record(r.Context(), event); err != nil {
 				slog.Warn("trace middleware: failed to record anchor event", "err", err)
+			} else {
+				anchor = event
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		if anchor == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		recordRequestOutcome(r.Context(), auditor, agentName, anchor, timer, rec.status)
 	})
 }
 
+// recordRequestOutcome emits the delegation_outcome event that closes the
+// journey anchored by anchor, with the request's phase timings.
+func recordRequestOutcome(ctx context.Context, auditor Auditor, agentName string, anchor *Event, timer *PhaseTimer, status int) {
+	end := time.Now()
+	phases := timer.Timings(end)
+	outcome := &Outcome{Status: "success", Duration: end.Sub(timer.received), Phases: &phases}
+	if status >= http.StatusBadRequest {
+		outcome.Status = "error"
+		outcome.ErrorMessage = fmt.Sprintf("HTTP %d", status)
+	}
+	session := anchor.Session
+	session.AgentName = agentName
+	event := &Event{
+		EventID:   "out_" + uuid.New().String()[:8],
+		Timestamp: end.UTC(),
+		EventType: EventTypeOutcome,
+		TraceID:   anchor.TraceID,
+		ParentID:  anchor.EventID,
+		Origin:    "agent",
+		Session:   session,
+		Outcome:   outcome,
+	}
+	// The client may be gone by now; the outcome is still worth recording.
+	if err := auditor.Record(context.WithoutCancel(ctx), event); err != nil {
+		slog.Warn("trace middleware: failed to record outcome event", "err", err)
+	}
+}
+
 // a2aRequestData holds the fields extracted from an incoming A2A JSON-RPC request.
 type a2aRequestData struct {
 	traceID     string
+	traceParent string // W3C traceparent, validated; "" when absent or malformed
 	userQuery   string
 	contextID   string
 	// Identity and purpose propagated from the upstream gateway/orchestrator:
@@ -117,12 +163,14 @@ type a2aRequestData struct {
 	roles           []string
 	service         string
 	authMethod      string
+	tenant          string
 	purpose         string
 	purposeNote     string
 	purposeExplicit bool
 	// Approval context forwarded from the gateway for chained runs:
 	approvalMode    string
 	approvalSession string
+	remediationPlan string
 }
 
 // resolvedPrincipal reconstructs the ResolvedPrincipal from A2A metadata fields.
@@ -132,6 +180,7 @@ func (d a2aRequestData) resolvedPrincipal() identity.ResolvedPrincipal {
 		Roles:      d.roles,
 		Service:    d.service,
 		AuthMethod: d.authMethod,
+		Tenant:     d.tenant,
 	}
 }
 
@@ -160,6 +209,11 @@ func parseA2ARequest(body []byte) a2aRequestData {
 		if id, ok := meta["trace_id"].(string); ok {
 			out.traceID = id
 		}
+		if s, ok := meta["traceparent"].(string); ok {
+			if tp, valid := ParseTraceParent(s); valid {
+				out.traceParent = tp.String()
+			}
+		}
 		if uid, ok := meta["user_id"].(string); ok {
 			out.userID = uid
 		}
@@ -169,6 +223,9 @@ func parseA2ARequest(body []byte) a2aRequestData {
 		if am, ok := meta["auth_method"].(string); ok {
 			out.authMethod = am
 		}
+		if t, ok := meta["tenant"].(string); ok {
+			out.tenant = t
+		}
 		if p, ok := meta["purpose"].(string); ok {
 			out.purpose = p
 		}
@@ -184,6 +241,9 @@ func parseA2ARequest(body []byte) a2aRequestData {
 		if as, ok := meta["approval_session"].(string); ok {
 			out.approvalSession = as
 		}
+		if rp, ok := meta["remediation_plan"].(string); ok {
+			out.remediationPlan = rp
+		}
 		// roles may arrive as []any (JSON array) or []string.
 		if rawRoles, ok := meta["roles"]; ok {
 			switch v := rawRoles.(type) {
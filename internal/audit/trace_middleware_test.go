@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // makeA2ABody builds a minimal A2A message/send JSON body with the given
@@ -352,6 +353,51 @@ func TestTraceMiddlewareWithAudit_EmitsAnchorEvent(t *testing.T) {
 	}
 }
 
+// TestTraceMiddlewareWithAudit_EmitsOutcomeWithPhases verifies that the
+// request's phases, reported through the PhaseTimer in its context, are
+// recorded on a delegation_outcome event when it completes.
+func TestTraceMiddlewareWithAudit_EmitsOutcomeWithPhases(t *testing.T) {
+	store := &CurrentTraceStore{}
+	recorded := make([]*Event, 0)
+	auditor := auditorFunc(func(_ context.Context, e *Event) error {
+		recorded = append(recorded, e)
+		return nil
+	})
+
+	handler := TraceMiddlewareWithAudit(store, auditor, "test-agent", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timer := PhaseTimerFromContext(r.Context())
+		time.Sleep(20 * time.Millisecond)
+		timer.Start()
+		timer.AddTool("get_pods", 30*time.Millisecond)
+		timer.AddTool("get_pods", 10*time.Millisecond)
+		timer.AddApprovalWait(5 * time.Millisecond)
+		time.Sleep(60 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := makeA2ABody(t, map[string]any{"trace_id": "tr_phase"}, "Why are pods restarting?")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/invoke", bytes.NewReader(body)))
+
+	if len(recorded) != 2 {
+		t.Fatalf("recorded %d events, want anchor and outcome", len(recorded))
+	}
+	anchor, out := recorded[0], recorded[1]
+	if out.EventType != EventTypeOutcome || out.TraceID != "tr_phase" || out.ParentID != anchor.EventID || out.Session.AgentName != "test-agent" {
+		t.Fatalf("outcome event = %+v", out)
+	}
+	if out.Outcome == nil || out.Outcome.Status != "success" || out.Outcome.Phases == nil {
+		t.Fatalf("outcome = %+v, want success with phases", out.Outcome)
+	}
+	p := out.Outcome.Phases
+	if p.QueueMs < 20 || p.ToolsMs != 40 || p.ByToolMs["get_pods"] != 40 || p.ApprovalMs != 5 {
+		t.Errorf("phases = %+v, want queue >= 20ms, tools 40ms, approval 5ms", p)
+	}
+	// The remaining 60ms of work, less tools and approval, is reasoning.
+	if sum := p.QueueMs + p.LLMMs + p.ToolsMs + p.ApprovalMs; sum > out.Outcome.Duration.Milliseconds() || p.LLMMs < 10 {
+		t.Errorf("phases %+v do not add up to duration %s", p, out.Outcome.Duration)
+	}
+}
+
 // auditorFunc is a test helper that adapts a function to the Auditor interface.
 type auditorFunc func(context.Context, *Event) error
 
@@ -359,3 +405,32 @@ func (f auditorFunc) Record(ctx context.Context, e *Event) error
 func (f auditorFunc) RecordOutcome(_ context.Context, _ string, _ *Outcome) error         { return nil }
 func (f auditorFunc) Query(_ context.Context, _ QueryOptions) ([]Event, error)            { return nil, nil }
 func (f auditorFunc) Close() error                                                        { return nil }
+
+func TestTraceMiddleware_TraceParentPropagated(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	store := &CurrentTraceStore{}
+	var storeTP string
+	handler := TraceMiddleware(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeTP = store.TraceParent()
+		if tc := TraceContextFromContext(r.Context()); tc == nil || tc.TraceParent != tp {
+			t.Errorf("TraceContext.TraceParent = %+v, want %q", tc, tp)
+		}
+	}))
+
+	body := makeA2ABody(t, map[string]any{"trace_id": "tr_w3c", "traceparent": tp}, "hello")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/invoke", bytes.NewReader(body)))
+
+	if storeTP != tp {
+		t.Errorf("trace store traceparent = %q, want %q", storeTP, tp)
+	}
+	if store.TraceParent() != "" {
+		t.Errorf("trace store traceparent not cleared after the request: %q", store.TraceParent())
+	}
+}
+
+func TestParseA2ARequest_MalformedTraceParentDropped(t *testing.T) {
+	body := makeA2ABody(t, map[string]any{"trace_id": "tr_x", "traceparent": "not-a-traceparent"}, "")
+	if d := parseA2ARequest(body); d.traceParent != "" {
+		t.Errorf("traceParent = %q, want empty for a malformed header", d.traceParent)
+	}
+}
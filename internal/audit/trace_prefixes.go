@@ -0,0 +1,49 @@
+package audit
+
+import "strings"
+
+// TracePrefix describes one trace_id prefix and the kind of request that
+// mints it. Clients read the table from GET /api/v1/trace-prefixes instead
+// of hard-coding the scheme.
+type TracePrefix struct {
+	Prefix      string `json:"prefix"`
+	Origin      string `json:"origin"`
+	Description string `json:"description"`
+}
+
+// TracePrefixes is the trace_id prefix scheme. A prefix that extends another
+// (tr_flj_ extends tr_) is listed first so a linear scan finds the most
+// specific match. docs/AUDIT.md §2.2 mirrors this table.
+var TracePrefixes = []TracePrefix{
+	{"tr_flj_", "fleet_job", "Fleet job: tr_ + job ID; one trace per job"},
+	{"tr_rbk_", "rollback", "Rollback execution: tr_ + rollback ID"},
+	{"tr_rpl_", "remediation_plan", "Remediation plan: tr_ + plan ID"},
+	{"authz_", "gateway", "Gateway request outside /api/v1 recorded by the authorization wrapper"},
+	{"plan_", "fleet_planner", "Fleet job planning via POST /api/v1/fleet/plan"},
+	{"mode_", "operating_mode", "Write or destructive tool call blocked by the readonly-governed operating mode"},
+	{"api_", "gateway", "Other /api/v1 call without a more specific origin"},
+	{"adm_", "k8s_admission", "Kubernetes admission review by k8s-admission: adm_ + request UID"},
+	{"chk_", "governance_check", "Direct governance check via POST /v1/governance/check"},
+	{"frz_", "emergency_freeze", "Emergency freeze or unfreeze of the fleet"},
+	{"oob_", "out_of_band", "Out-of-band database change found by POST /v1/db-audit/logs"},
+	{"sdn_", "shutdown", "auditd graceful shutdown"},
+	{"cny_", "canary", "Synthetic canary event recorded by auditd to verify end-to-end delivery"},
+	{"inv_", "inventory", "Inventory reconciliation run by the inventory command; one trace per run"},
+	{"sim_", "routing_simulation", "Routing simulation via POST /api/v1/route"},
+	{"ar_", "agent_request", "A2A request sent straight to an agent without a trace ID"},
+	{"dt_", "direct_tool", "Direct tool call via POST /api/v1/db|k8s/{tool} or /api/v1/agents/{agent}/tools/{tool}"},
+	{"ks_", "kill_switch", "Kill-switch change via /api/v1/admin/killswitch"},
+	{"sa_", "playbook_step", "Approved playbook step executed by the gateway"},
+	{"tr_", "query", "Natural-language query via POST /api/v1/query (orchestrator-routed)"},
+}
+
+// LookupTracePrefix returns the most specific entry in TracePrefixes whose
+// prefix starts traceID, and false when none does.
+func LookupTracePrefix(traceID string) (TracePrefix, bool) {
+	for _, p := range TracePrefixes {
+		if strings.HasPrefix(traceID, p.Prefix) {
+			return p, true
+		}
+	}
+	return TracePrefix{}, false
+}
@@ -0,0 +1,33 @@
+package audit
+
+import "testing"
+
+func TestLookupTracePrefix(t *testing.T) {
+	tests := map[string]string{
+		"tr_flj_4dd009b7":  "fleet_job",
+		"tr_rbk_a1b2c3d4":  "rollback",
+		"tr_0123456789ab":  "query",
+		"dt_0123456789ab":  "direct_tool",
+		"adm_uid-1":        "k8s_admission",
+		"api_0123456789ab": "gateway",
+	}
+	for traceID, want := range tests {
+		p, ok := LookupTracePrefix(traceID)
+		if !ok || p.Origin != want {
+			t.Errorf("LookupTracePrefix(%q) = %+v, %v; want origin %q", traceID, p, ok, want)
+		}
+	}
+	if _, ok := LookupTracePrefix("custom-123"); ok {
+		t.Error("LookupTracePrefix matched an ID with no known prefix")
+	}
+}
+
+func TestTracePrefixes_SpecificBeforeGeneral(t *testing.T) {
+	for i, p := range TracePrefixes {
+		for _, earlier := range TracePrefixes[:i] {
+			if len(earlier.Prefix) < len(p.Prefix) && p.Prefix[:len(earlier.Prefix)] == earlier.Prefix {
+				t.Errorf("%q is listed after %q, which shadows it", p.Prefix, earlier.Prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"strings"
+)
+
+// traceParentKey is the context key for a W3C traceparent header value.
+type traceParentKey struct{}
+
+// TraceParent is a parsed W3C Trace Context traceparent header
+// (https://www.w3.org/TR/trace-context/#traceparent-header):
+// "<version>-<trace-id>-<parent-id>-<trace-flags>" in lowercase hex.
+type TraceParent struct {
+	Version  string // 2 hex digits; "ff" is invalid
+	TraceID  string // 32 hex digits, not all zero
+	ParentID string // 16 hex digits, not all zero: the caller's span
+	Flags    string // 2 hex digits; 01 = sampled
+}
+
+// ParseTraceParent parses a traceparent header value. It returns false for
+// anything the W3C spec says a receiver must ignore, in which case the
+// caller should drop the header rather than propagate it. Versions above 00
+// are accepted as long as the 00 fields parse, as the spec requires.
+func ParseTraceParent(s string) (TraceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return TraceParent{}, false
+	}
+	tp := TraceParent{Version: parts[0], TraceID: parts[1], ParentID: parts[2], Flags: parts[3]}
+	if !isLowerHex(tp.Version, 2) || tp.Version == "ff" ||
+		!isLowerHex(tp.TraceID, 32) || isAllZero(tp.TraceID) ||
+		!isLowerHex(tp.ParentID, 16) || isAllZero(tp.ParentID) ||
+		!isLowerHex(tp.Flags, 2) {
+		return TraceParent{}, false
+	}
+	if tp.Version == "00" && len(parts) != 4 {
+		return TraceParent{}, false
+	}
+	return tp, true
+}
+
+// String formats tp as a version 00 traceparent header value.
+func (tp TraceParent) String() string {
+	return "00-" + tp.TraceID + "-" + tp.ParentID + "-" + tp.Flags
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isAllZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// WithTraceParent adds a validated traceparent value to ctx. Events recorded
+// with the returned context carry it in Event.TraceParent.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParentFromContext returns the traceparent carried in ctx, either set
+// by WithTraceParent or in the TraceContext. Returns "" when there is none.
+func TraceParentFromContext(ctx context.Context) string {
+	if tp, _ := ctx.Value(traceParentKey{}).(string); tp != "" {
+		return tp
+	}
+	if tc := TraceContextFromContext(ctx); tc != nil {
+		return tc.TraceParent
+	}
+	return ""
+}
+
+// stampTraceParent fills event.TraceParent from ctx when the caller left it
+// empty. Like stampTenant, it runs before hashing.
+func stampTraceParent(ctx context.Context, event *Event) {
+	if event.TraceParent == "" {
+		event.TraceParent = TraceParentFromContext(ctx)
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tp, ok := ParseTraceParent(valid)
+	if !ok {
+		t.Fatalf("ParseTraceParent(%q) rejected a valid header", valid)
+	}
+	if tp.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.ParentID != "00f067aa0ba902b7" || tp.Flags != "01" {
+		t.Errorf("ParseTraceParent(%q) = %+v", valid, tp)
+	}
+	if tp.String() != valid {
+		t.Errorf("String() = %q, want %q", tp.String(), valid)
+	}
+
+	// A future version is parsed as 00 and propagated as 00.
+	if tp, ok := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok || tp.String() != valid {
+		t.Errorf("future version: got %q, %v; want %q", tp.String(), ok, valid)
+	}
+
+	for _, bad := range []string{
+		"",
+		"garbage",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",    // forbidden version
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",    // zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",    // zero parent ID
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",    // uppercase
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",     // short trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xx", // extra field on version 00
+	} {
+		if _, ok := ParseTraceParent(bad); ok {
+			t.Errorf("ParseTraceParent(%q) accepted an invalid header", bad)
+		}
+	}
+}
+
+func TestTraceParentFromContext(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := TraceParentFromContext(context.Background()); got != "" {
+		t.Errorf("empty context: got %q", got)
+	}
+	if got := TraceParentFromContext(WithTraceParent(context.Background(), tp)); got != tp {
+		t.Errorf("WithTraceParent: got %q, want %q", got, tp)
+	}
+	ctx := WithTraceContext(context.Background(), &TraceContext{TraceID: "tr_1", TraceParent: tp})
+	if got := TraceParentFromContext(ctx); got != tp {
+		t.Errorf("TraceContext: got %q, want %q", got, tp)
+	}
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// WORMExport records one object written to write-once (WORM) storage by the
+// auditd export. Each object holds a contiguous run of events from a single
+// UTC day, so the day's objects in sequence order are that day's audit trail.
+type WORMExport struct {
+	Key         string    `json:"key"`
+	VersionID   string    `json:"version_id"`
+	Day         string    `json:"day"` // UTC date of the events, YYYY-MM-DD
+	FirstSeq    int64     `json:"first_seq"`
+	LastSeq     int64     `json:"last_seq"`
+	Events      int       `json:"events"`
+	SHA256      string    `json:"sha256"` // of the object body
+	LockMode    string    `json:"lock_mode"`
+	RetainUntil time.Time `json:"retain_until"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// WORMExportStore persists the record of exported objects. The highest
+// exported sequence number is the export's resume point. It shares the same
+// *sql.DB connection as the audit Store.
+type WORMExportStore struct {
+	db         *sql.DB
+	isPostgres bool
+}
+
+// NewWORMExportStore creates the worm_exports table (if absent) and returns a
+// ready-to-use WORMExportStore.
+func NewWORMExportStore(db *sql.DB, isPostgres bool) (*WORMExportStore, error) {
+	s := &WORMExportStore{db: db, isPostgres: isPostgres}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS worm_exports (
+    object_key   TEXT    NOT NULL,
+    version_id   TEXT    NOT NULL,
+    day          TEXT    NOT NULL,
+    first_seq    INTEGER NOT NULL,
+    last_seq     INTEGER NOT NULL,
+    events       INTEGER NOT NULL,
+    sha256       TEXT    NOT NULL,
+    lock_mode    TEXT    NOT NULL,
+    retain_until TEXT    NOT NULL,
+    uploaded_at  TEXT    NOT NULL,
+    PRIMARY KEY (object_key, version_id)
+)`); err != nil {
+		return nil, fmt.Errorf("create worm_exports schema: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_worm_exports_day ON worm_exports(day)`); err != nil {
+		return nil, fmt.Errorf("create worm_exports index: %w", err)
+	}
+	return s, nil
+}
+
+// Record stores an uploaded object. Re-uploading the same key after a crash
+// yields a new version, which is recorded alongside the first.
+func (s *WORMExportStore) Record(ctx context.Context, e WORMExport) error {
+	if e.UploadedAt.IsZero() {
+		e.UploadedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(ctx, rebind(s.isPostgres, `
+INSERT INTO worm_exports (object_key, version_id, day, first_seq, last_seq, events, sha256, lock_mode, retain_until, uploaded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(object_key, version_id) DO NOTHING`),
+		e.Key, e.VersionID, e.Day, e.FirstSeq, e.LastSeq, e.Events, e.SHA256, e.LockMode,
+		e.RetainUntil.UTC().Format(time.RFC3339), e.UploadedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("record worm export %s: %w", e.Key, err)
+	}
+	return nil
+}
+
+// LastSeq returns the highest exported sequence number, or 0 when nothing has
+// been exported yet.
+func (s *WORMExportStore) LastSeq(ctx context.Context) (int64, error) {
+	var seq sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(last_seq) FROM worm_exports`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("read worm export position: %w", err)
+	}
+	return seq.Int64, nil
+}
+
+// List returns exported objects in sequence order, limited to one UTC day
+// (YYYY-MM-DD) when day is non-empty.
+func (s *WORMExportStore) List(ctx context.Context, day string, limit int) ([]WORMExport, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	query := `SELECT object_key, version_id, day, first_seq, last_seq, events, sha256, lock_mode, retain_until, uploaded_at
+FROM worm_exports`
+	args := []any{}
+	if day != "" {
+		query += ` WHERE day = ?`
+		args = append(args, day)
+	}
+	query += ` ORDER BY first_seq ASC, uploaded_at ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.isPostgres, query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list worm exports: %w", err)
+	}
+	defer rows.Close()
+	var out []WORMExport
+	for rows.Next() {
+		var e WORMExport
+		var retainUntil, uploadedAt string
+		if err := rows.Scan(&e.Key, &e.VersionID, &e.Day, &e.FirstSeq, &e.LastSeq, &e.Events,
+			&e.SHA256, &e.LockMode, &retainUntil, &uploadedAt); err != nil {
+			return nil, fmt.Errorf("scan worm export: %w", err)
+		}
+		e.RetainUntil = parseFlexTime(retainUntil)
+		e.UploadedAt = parseFlexTime(uploadedAt)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWORMExportStore_RecordAndList(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ws, err := NewWORMExportStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewWORMExportStore: %v", err)
+	}
+
+	if seq, err := ws.LastSeq(ctx); err != nil || seq != 0 {
+		t.Fatalf("LastSeq (empty) = %d, %v; want 0", seq, err)
+	}
+
+	retain := time.Date(2033, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range []WORMExport{
+		{Key: "audit/2026-05-01/00000000000000000001-00000000000000000010.jsonl", VersionID: "v1", Day: "2026-05-01", FirstSeq: 1, LastSeq: 10, Events: 10},
+		{Key: "audit/2026-05-02/00000000000000000011-00000000000000000012.jsonl", VersionID: "v2", Day: "2026-05-02", FirstSeq: 11, LastSeq: 12, Events: 2},
+		// The same key uploaded again after a crash: a second version.
+		{Key: "audit/2026-05-02/00000000000000000011-00000000000000000012.jsonl", VersionID: "v3", Day: "2026-05-02", FirstSeq: 11, LastSeq: 12, Events: 2},
+	} {
+		e.LockMode, e.RetainUntil = "COMPLIANCE", retain
+		if err := ws.Record(ctx, e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	if seq, _ := ws.LastSeq(ctx); seq != 12 {
+		t.Errorf("LastSeq = %d, want 12", seq)
+	}
+	all, err := ws.List(ctx, "", 0)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("List = %d exports, %v; want 3", len(all), err)
+	}
+	day, _ := ws.List(ctx, "2026-05-02", 0)
+	if len(day) != 2 || day[0].VersionID != "v2" || !day[0].RetainUntil.Equal(retain) {
+		t.Errorf("List(2026-05-02) = %+v", day)
+	}
+}
@@ -0,0 +1,98 @@
+package auditd
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// accessTokenHeader carries an access token from a client that already uses
+// the Authorization header for its own identity (a service account API key).
+// Clients set it from HELPDESK_AUDIT_TOKEN (see audit.InstallAuditToken).
+const accessTokenHeader = audit.AuditTokenHeader
+
+// Token scopes.
+const (
+	tokenScopeRead  = "read"
+	tokenScopeWrite = "write"
+)
+
+// tokenExemptRoutes are the mutating routes that take no access token: they
+// are anonymous by design and check their own credential. Every other route
+// that is not a GET needs the write token.
+var tokenExemptRoutes = map[string]bool{
+	"POST /v1/approvals/{approvalID}/link": true, // signed approval link
+	"POST /v1/sms/inbound":                 true, // X-Twilio-Signature
+}
+
+var errAccessToken = errors.New("missing or invalid audit access token")
+
+// accessTokens is a minimal split between event ingest and queries that works
+// with or without a users file: one shared token for the agents, the gateway
+// and operators' tools, which write events, resolve approvals and change
+// state, and another for dashboards, which only query. The write token is also accepted wherever the
+// read token is, since writers read too. A scope whose token is empty is not
+// checked.
+type accessTokens struct {
+	read  string
+	write string
+}
+
+func (t accessTokens) enabled() bool { return t.read != "" || t.write != "" }
+
+// scope returns the token scope a route needs, or "" for routes the tokens do
+// not cover: anonymous GET routes (health, metrics, signed approval link
+// pages) and tokenExemptRoutes. Every other mutation needs the write token,
+// so a route added without thought is protected rather than open.
+func (t accessTokens) scope(pattern string) string {
+	if tokenExemptRoutes[pattern] {
+		return ""
+	}
+	if !strings.HasPrefix(pattern, "GET ") {
+		return tokenScopeWrite
+	}
+	if authz.DefaultAuditdPermissions[pattern].AllowAnonymous {
+		return ""
+	}
+	return tokenScopeRead
+}
+
+// check reports whether r carries a token valid for pattern, either in
+// X-Audit-Token or as the Authorization bearer token.
+func (t accessTokens) check(pattern string, r *http.Request) error {
+	var accepted []string
+	switch t.scope(pattern) {
+	case tokenScopeWrite:
+		if t.write == "" {
+			return nil
+		}
+		accepted = []string{t.write}
+	case tokenScopeRead:
+		if t.read == "" {
+			return nil
+		}
+		accepted = []string{t.read, t.write}
+	default:
+		return nil
+	}
+
+	presented := []string{r.Header.Get(accessTokenHeader)}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = append(presented, bearer)
+	}
+	for _, p := range presented {
+		if p == "" {
+			continue
+		}
+		for _, a := range accepted {
+			if a != "" && subtle.ConstantTimeCompare([]byte(p), []byte(a)) == 1 {
+				return nil
+			}
+		}
+	}
+	return errAccessToken
+}
@@ -0,0 +1,270 @@
+package auditd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func TestAccessTokens_Check(t *testing.T) {
+	tokens := accessTokens{read: "r-token", write: "w-token"}
+
+	tests := []struct {
+		name    string
+		pattern string
+		header  string
+		value   string
+		wantErr bool
+	}{
+		{"ingest without token", "POST /v1/events", "", "", true},
+		{"ingest with read token", "POST /v1/events", accessTokenHeader, "r-token", true},
+		{"ingest with write token", "POST /v1/events", accessTokenHeader, "w-token", false},
+		{"ingest with write bearer", "POST /v1/events/{eventID}/outcome", "Authorization", "Bearer w-token", false},
+		{"approve with read token", "POST /v1/approvals/{approvalID}/approve", "Authorization", "Bearer r-token", true},
+		{"query without token", "GET /v1/events", "", "", true},
+		{"query with read token", "GET /v1/events", accessTokenHeader, "r-token", false},
+		{"query with write token", "GET /v1/approvals/pending", accessTokenHeader, "w-token", false},
+		{"query with wrong token", "GET /v1/events", accessTokenHeader, "nope", true},
+		{"anonymous route", "GET /health", "", "", false},
+		{"signed approval link", "POST /v1/approvals/{approvalID}/link", "", "", false},
+		{"freeze without token", "POST /v1/freeze", "", "", true},
+		{"config with read token", "PUT /v1/config", accessTokenHeader, "r-token", true},
+		{"erase with write token", "POST /v1/subjects/erase", accessTokenHeader, "w-token", false},
+		{"unmapped mutation", "POST /v1/approval/sessions", "", "", true},
+		{"twilio webhook", "POST /v1/sms/inbound", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			err := tokens.check(tt.pattern, r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("check(%q) = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errAccessToken) {
+				t.Errorf("err = %v, want errAccessToken", err)
+			}
+		})
+	}
+}
+
+func TestAccessTokens_UnsetScopeNotChecked(t *testing.T) {
+	writeOnly := accessTokens{write: "w-token"}
+	r := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+	if err := writeOnly.check("GET /v1/events", r); err != nil {
+		t.Errorf("query with no read token configured: %v", err)
+	}
+	if err := writeOnly.check("POST /v1/events", r); err == nil {
+		t.Error("ingest without the write token was accepted")
+	}
+	if (accessTokens{}).enabled() {
+		t.Error("zero accessTokens reports enabled")
+	}
+}
+
+func TestTokenExemptRoutes_AreAnonymous(t *testing.T) {
+	for pattern := range tokenExemptRoutes {
+		if !authz.DefaultAuditdPermissions[pattern].AllowAnonymous {
+			t.Errorf("token-exempt route %q is not anonymous in authz", pattern)
+		}
+	}
+}
+
+// registeredRoutes returns the patterns passed to mux.HandleFunc in the
+// package's non-test sources.
+func registeredRoutes(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "HandleFunc" {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if pattern, err := strconv.Unquote(lit.Value); err == nil {
+					seen[pattern] = true
+				}
+			}
+			return true
+		})
+	}
+	var routes []string
+	for pattern := range seen {
+		routes = append(routes, pattern)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+func TestAccessTokens_EveryMutationNeedsWriteToken(t *testing.T) {
+	tokens := accessTokens{read: "r-token", write: "w-token"}
+	routes := registeredRoutes(t)
+	if len(routes) < 100 {
+		t.Fatalf("found only %d registered routes; is the parser still finding mux.HandleFunc calls?", len(routes))
+	}
+
+	for _, pattern := range routes {
+		if strings.HasPrefix(pattern, "GET ") || tokenExemptRoutes[pattern] {
+			continue
+		}
+		t.Run(pattern, func(t *testing.T) {
+			for _, tc := range []struct {
+				token   string
+				wantErr bool
+			}{{"", true}, {"r-token", true}, {"w-token", false}} {
+				r := httptest.NewRequest(http.MethodPost, "/", nil)
+				if tc.token != "" {
+					r.Header.Set("Authorization", "Bearer "+tc.token)
+				}
+				if err := tokens.check(pattern, r); (err != nil) != tc.wantErr {
+					t.Errorf("token %q: check = %v, wantErr %v", tc.token, err, tc.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// TestAccessTokens_ServiceAccountClient runs auditd with both a write token
+// and a users file, where the Authorization header carries the service
+// account key, and checks that the REST and gRPC clients present the token
+// from HELPDESK_AUDIT_TOKEN in X-Audit-Token.
+func TestAccessTokens_ServiceAccountClient(t *testing.T) {
+	dir := t.TempDir()
+	hash, err := identity.HashAPIKey("agent-key")
+	if err != nil {
+		t.Fatalf("HashAPIKey: %v", err)
+	}
+	usersFile := filepath.Join(dir, "users.yaml")
+	users := fmt.Sprintf("service_accounts:\n  - id: k8s_agent\n    roles: [service]\n    api_key_hash: %q\n", hash)
+	if err := os.WriteFile(usersFile, []byte(users), 0600); err != nil {
+		t.Fatalf("write users file: %v", err)
+	}
+	addr, grpcAddr := freeAddr(t), freeAddr(t)
+	t.Setenv("HELPDESK_AUDIT_WRITE_TOKEN", "w-token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, []string{
+			"-listen", addr,
+			"-grpc-listen", grpcAddr,
+			"-db", filepath.Join(dir, "audit.db"),
+			"-socket", filepath.Join(dir, "audit.sock"),
+			"-users-file", usersFile,
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	auditURL := "http://" + addr
+	waitHealthy(t, auditURL+"/health", done)
+
+	newEvent := func() *audit.Event {
+		return &audit.Event{
+			EventID:   "tool_" + strconv.FormatInt(time.Now().UnixNano(), 36),
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeToolExecution,
+			Session:   audit.Session{ID: "sess_token_test"},
+		}
+	}
+
+	// Without the token, the service account key alone is refused.
+	req, _ := http.NewRequest(http.MethodPost, auditURL+"/v1/events", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer agent-key")
+	resp, err := (&http.Client{Transport: &http.Transport{}}).Do(req)
+	if err != nil {
+		t.Fatalf("POST /v1/events: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("service account key without token: status = %d, want 401", resp.StatusCode)
+	}
+
+	t.Setenv(audit.AuditTokenEnv, "w-token")
+	if err := audit.InstallAuditToken(ctx, auditURL); err != nil {
+		t.Fatalf("InstallAuditToken: %v", err)
+	}
+
+	store := audit.NewRemoteStore(auditURL).WithAPIKey("agent-key")
+	if err := store.Record(ctx, newEvent()); err != nil {
+		t.Errorf("REST client with service account key and token: %v", err)
+	}
+
+	grpcStore, err := audit.NewGRPCStore(grpcAddr, "agent-key")
+	if err != nil {
+		t.Fatalf("NewGRPCStore: %v", err)
+	}
+	defer grpcStore.Close()
+	if err := grpcStore.Record(ctx, newEvent()); err != nil {
+		t.Errorf("gRPC client with service account key and token: %v", err)
+	}
+}
+
+// freeAddr returns a loopback address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// waitHealthy polls url until it answers, failing the test if the server
+// stops first or does not come up in time.
+func waitHealthy(t *testing.T, url string, done <-chan error) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-done:
+			t.Fatalf("auditd stopped: %v", err)
+		default:
+		}
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("auditd did not answer on %s", url)
+}
@@ -0,0 +1,166 @@
+package auditd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// alertServer handles the auditor's security alerts and the operators'
+// false-positive feedback on them.
+type alertServer struct {
+	store *audit.AlertStore
+}
+
+// handleRecord stores an alert reported by the auditor.
+// POST /v1/alerts
+func (s *alertServer) handleRecord(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var a audit.AlertRecord
+	if err := json.Unmarshal(body, &a); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if a.AlertID == "" || a.Rule == "" {
+		http.Error(w, "alert_id and rule are required", http.StatusBadRequest)
+		return
+	}
+	// Feedback is only set through the false-positive endpoint.
+	a.FalsePositive, a.Reason, a.ResourcePattern, a.AckedBy, a.AckedAt = false, "", "", "", nil
+	if err := s.store.Record(r.Context(), &a); err != nil {
+		slog.Error("failed to record alert", "err", err)
+		http.Error(w, "failed to record alert", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a) //nolint:errcheck
+}
+
+// handleList returns recent alerts, newest first.
+// GET /v1/alerts?since=<RFC3339>&rule=<type>&limit=<n>
+func (s *alertServer) handleList(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-24 * time.Hour)
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid since: expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	all, err := s.store.List(r.Context(), since, r.URL.Query().Get("rule"), limit)
+	if err != nil {
+		slog.Error("failed to list alerts", "err", err)
+		http.Error(w, "failed to list alerts", http.StatusInternalServerError)
+		return
+	}
+	alerts := []audit.AlertRecord{}
+	for _, a := range all {
+		if inTenant(r, a.TenantID) {
+			alerts = append(alerts, a)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"alerts": alerts, "count": len(alerts)}) //nolint:errcheck
+}
+
+// handleFalsePositive marks an alert as a false positive. The auditor
+// down-weights, and eventually silences, later alerts of the same rule and
+// agent on resources matching resource_pattern.
+// POST /v1/alerts/{alertID}/false-positive {"reason": "...", "resource_pattern": "staging-*"}
+func (s *alertServer) handleFalsePositive(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason          string `json:"reason"`
+		ResourcePattern string `json:"resource_pattern"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	alertID := r.PathValue("alertID")
+	existing, err := s.store.Get(r.Context(), alertID)
+	if err == nil && !inTenant(r, existing.TenantID) {
+		err = audit.ErrAlertNotFound
+	}
+	if errors.Is(err, audit.ErrAlertNotFound) {
+		http.Error(w, "alert not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get alert", "alert_id", alertID, "err", err)
+		http.Error(w, "failed to get alert", http.StatusInternalServerError)
+		return
+	}
+	by := authz.PrincipalFromContext(r.Context()).EffectiveID()
+	a, err := s.store.MarkFalsePositive(r.Context(), alertID, req.Reason, req.ResourcePattern, by)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("alert marked false positive", "alert_id", alertID, "rule", a.Rule, "agent", a.Agent, "pattern", a.ResourcePattern, "by", by)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a) //nolint:errcheck
+}
+
+// handleSuppressions returns the false-positive feedback grouped by rule,
+// agent and resource pattern. The auditor polls it.
+// GET /v1/alerts/suppressions
+func (s *alertServer) handleSuppressions(w http.ResponseWriter, r *http.Request) {
+	sups, err := s.store.Suppressions(r.Context())
+	if err != nil {
+		slog.Error("failed to list alert suppressions", "err", err)
+		http.Error(w, "failed to list alert suppressions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"suppressions": sups}) //nolint:errcheck
+}
+
+// handlePrecision returns per-rule alert precision for a window.
+// GET /v1/alerts/precision?since=<RFC3339>&until=<RFC3339>
+func (s *alertServer) handlePrecision(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	var until time.Time
+	for param, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+	rules, err := s.store.Precision(r.Context(), since, until)
+	if err != nil {
+		slog.Error("failed to compute alert precision", "err", err)
+		http.Error(w, "failed to compute alert precision", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"rules": rules}) //nolint:errcheck
+}
@@ -0,0 +1,115 @@
+package auditd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newAlertServer(t *testing.T) *alertServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := audit.NewAlertStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewAlertStore: %v", err)
+	}
+	return &alertServer{store: as}
+}
+
+func TestAlertHandlers_FalsePositiveFeedback(t *testing.T) {
+	srv := newAlertServer(t)
+	alice := identity.ResolvedPrincipal{UserID: "alice", Tenant: "payments", AuthMethod: "api_key"}
+	bob := identity.ResolvedPrincipal{UserID: "bob", Tenant: "billing", AuthMethod: "api_key"}
+
+	for _, rec := range []map[string]any{
+		{"alert_id": "alert_1", "rule": "param_first_seen", "agent": "k8s_agent", "resource": "staging-1", "tenant_id": "payments"},
+		// Feedback fields from the reporter are ignored.
+		{"alert_id": "alert_2", "rule": "param_first_seen", "agent": "k8s_agent", "resource": "prod", "tenant_id": "payments", "false_positive": true},
+	} {
+		data, _ := json.Marshal(rec)
+		w := httptest.NewRecorder()
+		srv.handleRecord(w, httptest.NewRequest(http.MethodPost, "/v1/alerts", bytes.NewReader(data)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("record status = %d, want 201; body: %s", w.Code, w.Body.String())
+		}
+	}
+
+	markFP := func(alertID string, principal identity.ResolvedPrincipal, body map[string]any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/v1/alerts/"+alertID+"/false-positive", bytes.NewReader(data))
+		req.SetPathValue("alertID", alertID)
+		req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+		w := httptest.NewRecorder()
+		srv.handleFalsePositive(w, req)
+		return w
+	}
+	for name, tc := range map[string]struct {
+		alertID   string
+		principal identity.ResolvedPrincipal
+		body      map[string]any
+		want      int
+	}{
+		"missing reason": {"alert_1", alice, map[string]any{}, http.StatusBadRequest},
+		"bad pattern":    {"alert_1", alice, map[string]any{"reason": "x", "resource_pattern": "["}, http.StatusBadRequest},
+		"unknown alert":  {"alert_9", alice, map[string]any{"reason": "x"}, http.StatusNotFound},
+		"other tenant":   {"alert_1", bob, map[string]any{"reason": "x"}, http.StatusNotFound},
+	} {
+		if w := markFP(tc.alertID, tc.principal, tc.body); w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, tc.want)
+		}
+	}
+	w := markFP("alert_1", alice, map[string]any{"reason": "staging churn", "resource_pattern": "staging-*"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("false-positive status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var marked audit.AlertRecord
+	json.NewDecoder(w.Body).Decode(&marked) //nolint:errcheck
+	if !marked.FalsePositive || marked.AckedBy != "alice" || marked.ResourcePattern != "staging-*" {
+		t.Errorf("marked = %+v", marked)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleSuppressions(w, httptest.NewRequest(http.MethodGet, "/v1/alerts/suppressions", nil))
+	var sups struct {
+		Suppressions []audit.AlertSuppression `json:"suppressions"`
+	}
+	json.NewDecoder(w.Body).Decode(&sups) //nolint:errcheck
+	if len(sups.Suppressions) != 1 || sups.Suppressions[0].ResourcePattern != "staging-*" {
+		t.Errorf("suppressions = %+v, want one staging-* entry", sups.Suppressions)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handlePrecision(w, httptest.NewRequest(http.MethodGet, "/v1/alerts/precision", nil))
+	var prec struct {
+		Rules []audit.RulePrecision `json:"rules"`
+	}
+	json.NewDecoder(w.Body).Decode(&prec) //nolint:errcheck
+	if len(prec.Rules) != 1 || prec.Rules[0].Fired != 2 || prec.Rules[0].FalsePositives != 1 {
+		t.Errorf("precision = %+v, want 2 fired, 1 false positive", prec.Rules)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/alerts", nil)
+	req = req.WithContext(authz.WithPrincipal(req.Context(), bob))
+	w = httptest.NewRecorder()
+	srv.handleList(w, req)
+	var list struct {
+		Count int `json:"count"`
+	}
+	json.NewDecoder(w.Body).Decode(&list) //nolint:errcheck
+	if list.Count != 0 {
+		t.Errorf("billing tenant sees %d payments alerts, want 0", list.Count)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package auditd
 
 import (
 	"context"
@@ -11,6 +11,7 @@ import (
 
 	"helpdesk/internal/audit"
 	"helpdesk/internal/authz"
+	"helpdesk/internal/infra"
 )
 
 // approvalServer handles approval-related HTTP endpoints.
@@ -18,6 +19,13 @@ type approvalServer struct {
 	store     *audit.ApprovalStore
 	notifier  *ApprovalNotifier
 	authorizer *authz.Authorizer
+	links     *approvalLinkSigner // nil disables emailed approve/deny links
+	infra     *infra.Config       // names the team owning each resource; may be nil
+
+	// slas is the time requests of each action class must be resolved in
+	// (-approval-sla); events records the breaches. See approval_sla.go.
+	slas   map[audit.ActionClass]time.Duration
+	events *audit.Store
 }
 
 // isFleetApproval returns true when the approval record belongs to a fleet job.
@@ -29,6 +37,7 @@ func isFleetApproval(a *audit.StoredApproval) bool {
 type CreateApprovalRequest struct {
 	EventID      string         `json:"event_id,omitempty"`
 	TraceID      string         `json:"trace_id,omitempty"`
+	TenantID     string         `json:"tenant_id,omitempty"`
 	ActionClass  string         `json:"action_class"`
 	ToolName     string         `json:"tool_name,omitempty"`
 	AgentName    string         `json:"agent_name,omitempty"`
@@ -40,6 +49,10 @@ type CreateApprovalRequest struct {
 	ApproverRole string         `json:"approver_role,omitempty"`
 	ExpiresInMin int            `json:"expires_in_minutes,omitempty"`
 	CallbackURL  string         `json:"callback_url,omitempty"`
+
+	// DryRun validates the request and returns 200 without creating the
+	// approval or notifying anyone. Agents use it in their startup self-test.
+	DryRun bool `json:"dry_run,omitempty"`
 }
 
 // ApproveRequest is the JSON body for approving a request.
@@ -76,16 +89,27 @@ func (s *approvalServer) handleCreateApproval(w http.ResponseWriter, r *http.Req
 		http.Error(w, "requested_by is required", http.StatusBadRequest)
 		return
 	}
+	if req.DryRun {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"dry_run": true,
+			"status":  "pending",
+			"notify":  s.notifier != nil,
+		})
+		return
+	}
 
 	approval := &audit.StoredApproval{
 		EventID:        req.EventID,
 		TraceID:        req.TraceID,
+		TenantID:       authz.PrincipalFromContext(r.Context()).TenantScope(req.TenantID),
 		Status:         "pending",
 		ActionClass:    req.ActionClass,
 		ToolName:       req.ToolName,
 		AgentName:      req.AgentName,
 		ResourceType:   req.ResourceType,
 		ResourceName:   req.ResourceName,
+		Team:           ownerTeam(s.infra, req.ResourceType, req.ResourceName),
 		RequestedBy:    req.RequestedBy,
 		RequestContext: req.Context,
 		PolicyName:     req.PolicyName,
@@ -99,6 +123,10 @@ func (s *approvalServer) handleCreateApproval(w http.ResponseWriter, r *http.Req
 		// Default expiration: 60 minutes
 		approval.ExpiresAt = time.Now().UTC().Add(60 * time.Minute)
 	}
+	if sla := s.slas[audit.ActionClass(req.ActionClass)]; sla > 0 {
+		approval.RequestedAt = time.Now().UTC()
+		approval.SLADueAt = approval.RequestedAt.Add(sla)
+	}
 
 	if err := s.store.CreateRequest(r.Context(), approval); err != nil {
 		slog.Error("failed to create approval request", "err", err)
@@ -111,6 +139,7 @@ func (s *approvalServer) handleCreateApproval(w http.ResponseWriter, r *http.Req
 		"action_class", approval.ActionClass,
 		"tool", approval.ToolName,
 		"agent", approval.AgentName,
+		"team", approval.Team,
 		"requested_by", approval.RequestedBy)
 
 	// Send notification
@@ -118,13 +147,17 @@ func (s *approvalServer) handleCreateApproval(w http.ResponseWriter, r *http.Req
 		s.notifier.NotifyCreated(r.Context(), approval)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(map[string]any{
+	resp := map[string]any{
 		"approval_id": approval.ApprovalID,
 		"status":      approval.Status,
 		"expires_at":  approval.ExpiresAt.Format(time.RFC3339),
-	})
+	}
+	if !approval.SLADueAt.IsZero() {
+		resp["sla_due_at"] = approval.SLADueAt.Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (s *approvalServer) handleGetApproval(w http.ResponseWriter, r *http.Request) {
@@ -135,8 +168,8 @@ func (s *approvalServer) handleGetApproval(w http.ResponseWriter, r *http.Reques
 	}
 
 	approval, err := s.store.GetRequest(r.Context(), approvalID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err != nil || !inTenant(r, approval.TenantID) {
+		http.Error(w, "approval not found", http.StatusNotFound)
 		return
 	}
 
@@ -164,6 +197,18 @@ func (s *approvalServer) handleListApprovals(w http.ResponseWriter, r *http.Requ
 	if v := r.URL.Query().Get("tool_name"); v != "" {
 		opts.ToolName = v
 	}
+	if v := r.URL.Query().Get("team"); v != "" {
+		opts.Team = v
+	}
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid since: expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		opts.Since = t
+	}
+	opts.TenantID = tenantScope(r)
 	if v := r.URL.Query().Get("limit"); v != "" {
 		if limit, err := strconv.Atoi(v); err == nil && limit > 0 {
 			opts.Limit = limit
@@ -202,7 +247,7 @@ func (s *approvalServer) handleApprove(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch the existing record to determine required role and enforce four-eyes.
 	existing, err := s.store.GetRequest(r.Context(), approvalID)
-	if err != nil {
+	if err != nil || !inTenant(r, existing.TenantID) {
 		http.Error(w, "approval not found", http.StatusNotFound)
 		return
 	}
@@ -222,16 +267,17 @@ func (s *approvalServer) handleApprove(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		req.ApprovedBy = principal.EffectiveID()
-		// Four-eyes: approver must differ from the requester for all approval types.
-		if req.ApprovedBy == existing.RequestedBy {
-			http.Error(w, "four-eyes constraint: approver and requester must be different people", http.StatusForbidden)
-			return
-		}
 	} else if req.ApprovedBy == "" {
 		// Legacy unauthenticated mode: approved_by from body is required.
 		http.Error(w, "approved_by is required", http.StatusBadRequest)
 		return
 	}
+	// Four-eyes: approver must differ from the requester for all approval
+	// types, including the approved_by a legacy-mode caller states.
+	if audit.IsSelfApproval(existing.RequestedBy, req.ApprovedBy) {
+		http.Error(w, "four-eyes constraint: approver and requester must be different people", http.StatusForbidden)
+		return
+	}
 
 	var validFor time.Duration
 	if req.ValidForMin > 0 {
@@ -282,7 +328,7 @@ func (s *approvalServer) handleDeny(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch the existing record to determine required role.
 	existing, err := s.store.GetRequest(r.Context(), approvalID)
-	if err != nil {
+	if err != nil || !inTenant(r, existing.TenantID) {
 		http.Error(w, "approval not found", http.StatusNotFound)
 		return
 	}
@@ -423,8 +469,9 @@ func (s *approvalServer) handleWaitForApproval(w http.ResponseWriter, r *http.Re
 
 func (s *approvalServer) handlePendingApprovals(w http.ResponseWriter, r *http.Request) {
 	approvals, err := s.store.ListRequests(r.Context(), audit.ApprovalQueryOptions{
-		Status: "pending",
-		Limit:  100,
+		Status:   "pending",
+		TenantID: tenantScope(r),
+		Limit:    100,
 	})
 	if err != nil {
 		slog.Error("failed to list pending approvals", "err", err)
@@ -452,6 +499,7 @@ func (s *approvalServer) startExpirationWorker(ctx context.Context) {
 			} else if expired > 0 {
 				slog.Info("expired approval requests", "count", expired)
 			}
+			s.recordSLABreaches(context.Background(), time.Now().UTC())
 		}
 	}
 }
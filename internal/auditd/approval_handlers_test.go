@@ -1,4 +1,4 @@
-package main
+package auditd
 
 import (
 	"bytes"
@@ -189,6 +189,39 @@ func TestHandleApprove_Legacy_MissingApprovedBy(t *testing.T) {
 	}
 }
 
+func TestHandleApprove_Legacy_SelfApprovalRejected(t *testing.T) {
+	// Without an identity provider approved_by is only stated, but a request
+	// must still not be approved in the requester's own name.
+	s := newApprovalSrv(t, "")
+	id := seedApproval(t, s, mutationApproval("Alice"))
+
+	w := doApprove(t, s, id, map[string]any{"approved_by": "alice"}, nil)
+
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "four-eyes") {
+		t.Fatalf("status = %d, want 403 (self-approval must be rejected); body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleApprove_ErasedRequesterCannotSelfApprove(t *testing.T) {
+	// Erasure replaces the requester with a pseudonym; the raw ID must not
+	// then pass the four-eyes check on the subject's own pending request.
+	s := newApprovalSrv(t, "")
+	id := seedApproval(t, s, mutationApproval("alice"))
+	if _, err := s.store.EraseSubjectRecords(context.Background(), []string{"alice"}, "subj_0123456789abcdef"); err != nil {
+		t.Fatalf("EraseSubjectRecords: %v", err)
+	}
+
+	w := doApprove(t, s, id, map[string]any{"approved_by": "alice"}, nil)
+
+	if w.Code == http.StatusOK {
+		t.Fatalf("erased requester approved their own request; body: %s", w.Body.String())
+	}
+	got, _ := s.store.GetRequest(context.Background(), id)
+	if got.Status != "cancelled" {
+		t.Errorf("status = %q, want cancelled", got.Status)
+	}
+}
+
 func TestHandleDeny_Legacy_OK(t *testing.T) {
 	s := newApprovalSrv(t, "")
 	id := seedApproval(t, s, mutationApproval("some-operator"))
@@ -392,6 +425,33 @@ func TestHandleApprove_Auth_FourEyes_MutationEnforced(t *testing.T) {
 	}
 }
 
+// ── Tenant scoping ───────────────────────────────────────────────────────────
+
+func TestHandleApprove_Auth_OtherTenantNotFound(t *testing.T) {
+	// A tenant-bound approver must not see, let alone resolve, another tenant's request.
+	s := newApprovalSrv(t, testUsersYAML+`
+  - id: dana@acme.com
+    roles: [dba]
+    tenant: acme
+`)
+	foreign := mutationApproval("someone@globex.com")
+	foreign.TenantID = "globex"
+	id := seedApproval(t, s, foreign)
+
+	w := doApprove(t, s, id, map[string]any{}, map[string]string{"X-User": "dana@acme.com"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404; body: %s", w.Code, w.Body.String())
+	}
+
+	own := mutationApproval("someone@acme.com")
+	own.TenantID = "acme"
+	id = seedApproval(t, s, own)
+	w = doApprove(t, s, id, map[string]any{}, map[string]string{"X-User": "dana@acme.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("own tenant: status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+}
+
 // ── Fleet approval record metadata ───────────────────────────────────────────
 
 func TestHandleCreateJobApproval_SetsResourceTypeAndRole(t *testing.T) {
@@ -456,3 +516,134 @@ func TestHandleCreateJobApproval_SetsResourceTypeAndRole(t *testing.T) {
 		t.Errorf("agent_name = %q, want fleet-runner", stored.AgentName)
 	}
 }
+
+func TestHandleCreateApproval_DryRun(t *testing.T) {
+	s := newApprovalSrv(t, "")
+
+	data, _ := json.Marshal(map[string]any{
+		"action_class": "write",
+		"requested_by": "k8s_agent",
+		"dry_run":      true,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/v1/approvals", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	s.handleCreateApproval(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		DryRun bool `json:"dry_run"`
+		Notify bool `json:"notify"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.DryRun || resp.Notify {
+		t.Errorf("response = %+v, want dry_run without a notifier", resp)
+	}
+
+	stored, err := s.store.ListRequests(context.Background(), audit.ApprovalQueryOptions{})
+	if err != nil {
+		t.Fatalf("ListRequests: %v", err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("dry run stored %d approvals, want none", len(stored))
+	}
+}
+
+// ── Execution linking ─────────────────────────────────────────────────────────
+
+func TestRecordEvent_LinksApprovalExecution(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := audit.NewApprovalStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewApprovalStore: %v", err)
+	}
+	ctx := context.Background()
+
+	a := mutationApproval("alice")
+	a.ExpiresAt = time.Now().UTC().Add(time.Hour)
+	if err := as.CreateRequest(ctx, a); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	if err := as.Approve(ctx, a.ApprovalID, "bob", "", 0); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	srv := &server{store: store, approvals: as}
+	post := func(eventID, status string, rows int) {
+		t.Helper()
+		event := audit.Event{
+			EventID:   eventID,
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeToolExecution,
+			Tool:      &audit.ToolExecution{Name: "terminate_connection", RowsAffected: rows},
+			Outcome:   &audit.Outcome{Status: status},
+			Approval:  &audit.Approval{Required: true, Status: audit.ApprovalApproved, ApprovalID: a.ApprovalID},
+		}
+		data, _ := json.Marshal(event)
+		req := httptest.NewRequest(http.MethodPost, "/v1/events", bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		srv.handleRecordEvent(w, req)
+		if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+			t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+		}
+	}
+
+	post("tool_first", "success", 3)
+	// A second execution on the same approval must not overwrite the first.
+	post("tool_second", "error", 0)
+
+	got, err := as.GetRequest(ctx, a.ApprovalID)
+	if err != nil {
+		t.Fatalf("GetRequest: %v", err)
+	}
+	if got.Execution == nil {
+		t.Fatal("Execution is nil, want the linked tool_execution")
+	}
+	if got.Execution.EventID != "tool_first" || got.Execution.Status != "success" || got.Execution.RowsAffected != 3 {
+		t.Errorf("Execution = %+v, want tool_first/success/3 rows", got.Execution)
+	}
+	if s := got.Execution.Summary(); !strings.Contains(s, "executed: yes") || !strings.Contains(s, "rows_affected: 3") {
+		t.Errorf("Summary = %q", s)
+	}
+
+	var pending *audit.ApprovalExecution
+	if s := pending.Summary(); s != "executed: no" {
+		t.Errorf("nil Summary = %q, want executed: no", s)
+	}
+}
+
+func TestHandleListApprovals_Since(t *testing.T) {
+	s := newApprovalSrv(t, "")
+	seedApproval(t, s, mutationApproval("alice"))
+
+	list := func(since string) (int, []*audit.StoredApproval) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/v1/approvals?since="+since, nil)
+		w := httptest.NewRecorder()
+		s.handleListApprovals(w, req)
+		var got []*audit.StoredApproval
+		if w.Code == http.StatusOK {
+			json.NewDecoder(w.Body).Decode(&got) //nolint:errcheck
+		}
+		return w.Code, got
+	}
+
+	if code, got := list(time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)); code != http.StatusOK || len(got) != 1 {
+		t.Errorf("since an hour ago: status=%d approvals=%d, want 200 and 1", code, len(got))
+	}
+	if code, got := list(time.Now().Add(time.Hour).UTC().Format(time.RFC3339)); code != http.StatusOK || len(got) != 0 {
+		t.Errorf("since an hour ahead: status=%d approvals=%d, want 200 and 0", code, len(got))
+	}
+	if code, _ := list("yesterday"); code != http.StatusBadRequest {
+		t.Errorf("invalid since: status=%d, want 400", code)
+	}
+}
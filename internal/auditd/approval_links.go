@@ -0,0 +1,249 @@
+package auditd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// Approval link actions.
+const (
+	linkActionApprove = "approve"
+	linkActionDeny    = "deny"
+)
+
+var errInvalidLinkToken = errors.New("invalid or expired approval link")
+
+// approvalLinkSigner signs and verifies the tokens in emailed approve/deny
+// links. A token binds an approval ID, an action, the recipient it was sent to
+// and an expiry. It is single-use because an approval can only be resolved
+// once: after either link is used, both stop working.
+type approvalLinkSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+func newApprovalLinkSigner(key string) *approvalLinkSigner {
+	if key == "" {
+		return nil
+	}
+	return &approvalLinkSigner{key: []byte(key), now: time.Now}
+}
+
+// sign returns a token for action on approvalID by approver, valid until expiry.
+func (s *approvalLinkSigner) sign(approvalID, action, approver string, expiry time.Time) string {
+	claims := approver + "\n" + strconv.FormatInt(expiry.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(claims)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(approvalID, action, claims))
+}
+
+// verify checks token for action on approvalID and returns the approver it
+// was issued to.
+func (s *approvalLinkSigner) verify(approvalID, action, token string) (string, error) {
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", errInvalidLinkToken
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return "", errInvalidLinkToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.mac(approvalID, action, string(claims))) {
+		return "", errInvalidLinkToken
+	}
+	approver, exp, _ := strings.Cut(string(claims), "\n")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || approver == "" || !s.now().Before(time.Unix(unix, 0)) {
+		return "", errInvalidLinkToken
+	}
+	return approver, nil
+}
+
+func (s *approvalLinkSigner) mac(approvalID, action, claims string) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(approvalID + "\n" + action + "\n" + claims))
+	return h.Sum(nil)
+}
+
+// link returns the URL of the confirmation page for action on the approval.
+// Links expire with the approval, or after an hour if it has no expiry.
+func (s *approvalLinkSigner) link(baseURL string, approval *audit.StoredApproval, action, approver string) string {
+	expiry := approval.ExpiresAt
+	if expiry.IsZero() {
+		expiry = s.now().Add(time.Hour)
+	}
+	q := url.Values{
+		"action": {action},
+		"token":  {s.sign(approval.ApprovalID, action, approver, expiry)},
+	}
+	return baseURL + "/v1/approvals/" + url.PathEscape(approval.ApprovalID) + "/link?" + q.Encode()
+}
+
+// linkPage is the confirmation and result page for approval links. It is
+// deliberately plain so it renders on a phone without any assets.
+var linkPage = template.Must(template.New("link").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>aiHelpDesk approval</title>
+<style>body{font-family:sans-serif;max-width:32em;margin:1em auto;padding:0 1em}th{text-align:left;padding-right:1em}textarea{width:100%}button{font-size:1.2em;padding:.5em 1.5em;margin-top:1em}</style>
+</head><body>
+<h1>{{.Title}}</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{with .Approval}}<table>
+<tr><th>Approval</th><td>{{.ApprovalID}}</td></tr>
+<tr><th>Action</th><td>{{.ActionClass}}</td></tr>
+{{if .ToolName}}<tr><th>Tool</th><td>{{.ToolName}}</td></tr>{{end}}
+<tr><th>Agent</th><td>{{.AgentName}}</td></tr>
+<tr><th>Resource</th><td>{{.ResourceType}}/{{.ResourceName}}</td></tr>
+<tr><th>Requested by</th><td>{{.RequestedBy}}</td></tr>
+<tr><th>Status</th><td>{{.Status}}</td></tr>
+</table>{{end}}
+{{if .Plan}}<pre>{{.Plan}}</pre>{{end}}
+{{if .Token}}<form method="post">
+<input type="hidden" name="action" value="{{.Action}}"><input type="hidden" name="token" value="{{.Token}}">
+<p><label>Reason<br><textarea name="reason" rows="3"></textarea></label></p>
+<button type="submit">{{if eq .Action "approve"}}Approve{{else}}Deny{{end}} as {{.Approver}}</button>
+</form>{{end}}
+</body></html>
+`))
+
+type linkPageData struct {
+	Title    string
+	Message  string
+	Approval *audit.StoredApproval
+	Plan     string
+	Action   string
+	Token    string
+	Approver string
+}
+
+func renderLinkPage(w http.ResponseWriter, status int, data linkPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	if err := linkPage.Execute(w, data); err != nil {
+		slog.Error("failed to render approval link page", "err", err)
+	}
+}
+
+// checkLink validates an approval link request and loads its approval. On
+// failure it renders the error page and returns ok false.
+func (s *approvalServer) checkLink(w http.ResponseWriter, r *http.Request, action, token string) (approval *audit.StoredApproval, approver string, ok bool) {
+	if s.links == nil {
+		renderLinkPage(w, http.StatusNotFound, linkPageData{Title: "Approval links are disabled"})
+		return nil, "", false
+	}
+	approvalID := r.PathValue("approvalID")
+	if action != linkActionApprove && action != linkActionDeny {
+		renderLinkPage(w, http.StatusBadRequest, linkPageData{Title: "Invalid approval link"})
+		return nil, "", false
+	}
+	approver, err := s.links.verify(approvalID, action, token)
+	if err != nil {
+		renderLinkPage(w, http.StatusForbidden, linkPageData{Title: "Invalid approval link",
+			Message: "This link is invalid or has expired. Use the approvals CLI instead."})
+		return nil, "", false
+	}
+	approval, err = s.store.GetRequest(r.Context(), approvalID)
+	if err != nil {
+		renderLinkPage(w, http.StatusNotFound, linkPageData{Title: "Approval not found"})
+		return nil, "", false
+	}
+	if approval.Status != "pending" {
+		renderLinkPage(w, http.StatusConflict, linkPageData{Title: "Approval already resolved",
+			Message: "This request is no longer pending; the link has been used or has expired.", Approval: approval})
+		return nil, "", false
+	}
+	// Four-eyes applies to link approvals as it does to the API.
+	if action == linkActionApprove && audit.IsSelfApproval(approval.RequestedBy, approver) {
+		renderLinkPage(w, http.StatusForbidden, linkPageData{Title: "Approval not allowed",
+			Message: "The requester cannot approve their own request.", Approval: approval})
+		return nil, "", false
+	}
+	return approval, approver, true
+}
+
+// handleLinkPage handles GET /v1/approvals/{approvalID}/link?action=&token=.
+// It only shows the request and a confirmation button: mail scanners and link
+// previews fetch URLs, so a GET must never resolve the approval.
+func (s *approvalServer) handleLinkPage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	action, token := q.Get("action"), q.Get("token")
+	approval, approver, ok := s.checkLink(w, r, action, token)
+	if !ok {
+		return
+	}
+	title := "Approve this request?"
+	if action == linkActionDeny {
+		title = "Deny this request?"
+	}
+	var plan string
+	if p := approval.ExecutionPlan(); p != nil {
+		plan = p.Format("")
+	}
+	renderLinkPage(w, http.StatusOK, linkPageData{
+		Title:    title,
+		Approval: approval,
+		Plan:     plan,
+		Action:   action,
+		Token:    token,
+		Approver: approver,
+	})
+}
+
+// handleLinkConfirm handles POST /v1/approvals/{approvalID}/link, submitted
+// from the confirmation page. The token is the only credential: it proves the
+// caller received the approval email.
+func (s *approvalServer) handleLinkConfirm(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	if err := r.ParseForm(); err != nil {
+		renderLinkPage(w, http.StatusBadRequest, linkPageData{Title: "Invalid request"})
+		return
+	}
+	action := r.PostForm.Get("action")
+	approval, approver, ok := s.checkLink(w, r, action, r.PostForm.Get("token"))
+	if !ok {
+		return
+	}
+	reason := strings.TrimSpace(r.PostForm.Get("reason"))
+	if reason == "" {
+		reason = "via email link"
+	}
+
+	var err error
+	if action == linkActionApprove {
+		err = s.store.Approve(r.Context(), approval.ApprovalID, approver, reason, 0)
+	} else {
+		err = s.store.Deny(r.Context(), approval.ApprovalID, approver, reason)
+	}
+	if err != nil {
+		// Lost a race with another resolution.
+		renderLinkPage(w, http.StatusConflict, linkPageData{Title: "Approval already resolved", Message: err.Error()})
+		return
+	}
+	slog.Info("approval resolved via email link",
+		"approval_id", approval.ApprovalID,
+		"action", action,
+		"resolved_by", approver)
+
+	resolved, _ := s.store.GetRequest(r.Context(), approval.ApprovalID)
+	if s.notifier != nil && resolved != nil {
+		s.notifier.NotifyResolved(r.Context(), resolved)
+	}
+	title := "Approved"
+	if action == linkActionDeny {
+		title = "Denied"
+	}
+	renderLinkPage(w, http.StatusOK, linkPageData{Title: title, Approval: resolved})
+}
@@ -0,0 +1,127 @@
+package auditd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApprovalLinkSigner_Verify(t *testing.T) {
+	s := newApprovalLinkSigner("test-key")
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	token := s.sign("apr_1", linkActionApprove, "alice@example.com", now.Add(time.Hour))
+
+	if got, err := s.verify("apr_1", linkActionApprove, token); err != nil || got != "alice@example.com" {
+		t.Fatalf("verify = %q, %v; want alice@example.com", got, err)
+	}
+	for name, check := range map[string]func() error{
+		"other action":   func() error { _, err := s.verify("apr_1", linkActionDeny, token); return err },
+		"other approval": func() error { _, err := s.verify("apr_2", linkActionApprove, token); return err },
+		"other key": func() error {
+			_, err := newApprovalLinkSigner("other-key").verify("apr_1", linkActionApprove, token)
+			return err
+		},
+		"tampered": func() error {
+			forged := s.sign("apr_1", linkActionApprove, "mallory@example.com", now.Add(time.Hour))
+			_, sig, _ := strings.Cut(token, ".")
+			claims, _, _ := strings.Cut(forged, ".")
+			_, err := s.verify("apr_1", linkActionApprove, claims+"."+sig)
+			return err
+		},
+		"expired": func() error {
+			s.now = func() time.Time { return now.Add(2 * time.Hour) }
+			defer func() { s.now = func() time.Time { return now } }()
+			_, err := s.verify("apr_1", linkActionApprove, token)
+			return err
+		},
+	} {
+		if err := check(); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+// linkMux routes the link endpoints so the handlers see the approvalID path value.
+func linkMux(srv *approvalServer) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/approvals/{approvalID}/link", srv.handleLinkPage)
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/link", srv.handleLinkConfirm)
+	return mux
+}
+
+func submitLink(mux http.Handler, link string) *httptest.ResponseRecorder {
+	u, _ := url.Parse(link)
+	form := url.Values{"action": {u.Query().Get("action")}, "token": {u.Query().Get("token")}, "reason": {"looks safe"}}
+	req := httptest.NewRequest(http.MethodPost, u.Path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestApprovalLinks_ApproveOnce(t *testing.T) {
+	s := newApprovalSrv(t, "")
+	s.links = newApprovalLinkSigner("test-key")
+	mux := linkMux(s.approvalServer)
+	id := seedApproval(t, s, mutationApproval("carol@example.com"))
+	approval, _ := s.store.GetRequest(t.Context(), id)
+	link := s.links.link("http://auditd:1199", approval, linkActionApprove, "alice@example.com")
+
+	// Opening the link only shows the confirmation page.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(link, "http://auditd:1199"), nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Approve as alice@example.com") {
+		t.Fatalf("GET status = %d, body %s", w.Code, w.Body.String())
+	}
+	if a, _ := s.store.GetRequest(t.Context(), id); a.Status != "pending" {
+		t.Fatalf("GET resolved the approval: status %s", a.Status)
+	}
+
+	if w := submitLink(mux, link); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, body %s", w.Code, w.Body.String())
+	}
+	a, _ := s.store.GetRequest(t.Context(), id)
+	if a.Status != "approved" || a.ResolvedBy != "alice@example.com" || a.ResolutionReason != "looks safe" {
+		t.Errorf("approval = %+v", a)
+	}
+
+	// The link and its deny counterpart are spent.
+	if w := submitLink(mux, link); w.Code != http.StatusConflict {
+		t.Errorf("reused link: status = %d, want 409", w.Code)
+	}
+	deny := s.links.link("http://auditd:1199", approval, linkActionDeny, "alice@example.com")
+	if w := submitLink(mux, deny); w.Code != http.StatusConflict {
+		t.Errorf("deny after approve: status = %d, want 409", w.Code)
+	}
+}
+
+func TestApprovalLinks_Rejected(t *testing.T) {
+	s := newApprovalSrv(t, "")
+	mux := linkMux(s.approvalServer)
+	id := seedApproval(t, s, mutationApproval("alice@example.com"))
+	approval, _ := s.store.GetRequest(t.Context(), id)
+	signer := newApprovalLinkSigner("test-key")
+
+	if w := submitLink(mux, signer.link("", approval, linkActionApprove, "bob@example.com")); w.Code != http.StatusNotFound {
+		t.Errorf("links disabled: status = %d, want 404", w.Code)
+	}
+	s.links = signer
+	if w := submitLink(mux, signer.link("", approval, linkActionApprove, "alice@example.com")); w.Code != http.StatusForbidden {
+		t.Errorf("self-approval: status = %d, want 403", w.Code)
+	}
+	if w := submitLink(mux, newApprovalLinkSigner("other-key").link("", approval, linkActionApprove, "bob@example.com")); w.Code != http.StatusForbidden {
+		t.Errorf("forged link: status = %d, want 403", w.Code)
+	}
+	if a, _ := s.store.GetRequest(t.Context(), id); a.Status != "pending" {
+		t.Errorf("status = %s, want pending", a.Status)
+	}
+
+	// The requester may still deny their own request.
+	if w := submitLink(mux, signer.link("", approval, linkActionDeny, "alice@example.com")); w.Code != http.StatusOK {
+		t.Errorf("deny: status = %d, body %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,627 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/i18n"
+	"helpdesk/internal/notifyplugin"
+	"helpdesk/internal/shutdown"
+	"helpdesk/internal/sms"
+)
+
+// ApprovalNotifier sends notifications for approval events.
+type ApprovalNotifier struct {
+	webhookMu    sync.RWMutex
+	webhookURL   string            // changeable at runtime; read with webhook()
+	teamWebhooks map[string]string // team -> its channel, for approvals of resources it owns
+	callbackURLs map[string]string // approvalID -> callbackURL
+	baseURL      string            // Base URL for approve/deny links in emails
+	links        *approvalLinkSigner
+
+	// Email configuration
+	smtpHost     string
+	smtpPort     string
+	smtpUser     string
+	smtpPassword string
+	emailFrom    string
+	emailTo      []string
+
+	// SMS/WhatsApp configuration
+	sms        *sms.Client
+	smsTo      []string
+	smsReplies bool // recipients can answer YES/NO; set once smsServer is up
+
+	plugins []*notifyplugin.Plugin
+
+	// Localization: emails and texts go out in each recipient's locale,
+	// webhooks in the default one.
+	locales          *i18n.Bundle
+	recipientLocales i18n.Recipients
+
+	notifyExecuted bool // also notify when an approved action has run
+}
+
+// ApprovalNotifierConfig configures the approval notifier.
+type ApprovalNotifierConfig struct {
+	WebhookURL   string
+	// TeamWebhooks maps a team to its channel. Approvals of resources owned
+	// by a team with a channel are posted there instead of to WebhookURL.
+	TeamWebhooks map[string]string
+	BaseURL      string // Base URL for approve/deny links (e.g., http://localhost:1199)
+	// Links signs one-time approve/deny links for each email recipient. When
+	// nil, emails show curl commands instead.
+	Links        *approvalLinkSigner
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	EmailFrom    string
+	EmailTo      string // comma-separated
+
+	// SMS sends a text (or WhatsApp message) for each new request to SMSTo
+	// (comma-separated).
+	SMS   *sms.Client
+	SMSTo string
+
+	// Plugins are exec-based notifiers that get every approval and freeze
+	// notification as JSON on stdin.
+	Plugins []*notifyplugin.Plugin
+
+	// NotifyExecuted sends a follow-up through the same channels when the
+	// action an approval authorised has run, with its outcome.
+	NotifyExecuted bool
+
+	// Locales holds the message catalogs (nil: built-in, English default).
+	// RecipientLocales picks the locale of each email and SMS recipient.
+	Locales          *i18n.Bundle
+	RecipientLocales i18n.Recipients
+}
+
+// NewApprovalNotifier creates a new approval notifier.
+func NewApprovalNotifier(cfg ApprovalNotifierConfig) *ApprovalNotifier {
+	var emailTo []string
+	if cfg.EmailTo != "" {
+		for _, e := range strings.Split(cfg.EmailTo, ",") {
+			emailTo = append(emailTo, strings.TrimSpace(e))
+		}
+	}
+	var smsTo []string
+	if cfg.SMS.Enabled() && cfg.SMSTo != "" {
+		for _, n := range strings.Split(cfg.SMSTo, ",") {
+			smsTo = append(smsTo, strings.TrimSpace(n))
+		}
+	}
+
+	return &ApprovalNotifier{
+		webhookURL:   cfg.WebhookURL,
+		teamWebhooks: cfg.TeamWebhooks,
+		baseURL:      strings.TrimSuffix(cfg.BaseURL, "/"),
+		links:        cfg.Links,
+		callbackURLs: make(map[string]string),
+		smtpHost:     cfg.SMTPHost,
+		smtpPort:     cfg.SMTPPort,
+		smtpUser:     cfg.SMTPUser,
+		smtpPassword: cfg.SMTPPassword,
+		emailFrom:    cfg.EmailFrom,
+		emailTo:      emailTo,
+		sms:          cfg.SMS,
+		smsTo:        smsTo,
+		plugins:      cfg.Plugins,
+
+		locales:          cfg.Locales,
+		recipientLocales: cfg.RecipientLocales,
+
+		notifyExecuted: cfg.NotifyExecuted,
+	}
+}
+
+// webhook returns the webhook URL in effect.
+func (n *ApprovalNotifier) webhook() string {
+	n.webhookMu.RLock()
+	defer n.webhookMu.RUnlock()
+	return n.webhookURL
+}
+
+// SetWebhookURL replaces the webhook URL (empty disables the webhook). It is
+// how runtime configuration changes reach the notifier.
+func (n *ApprovalNotifier) SetWebhookURL(url string) {
+	n.webhookMu.Lock()
+	n.webhookURL = url
+	n.webhookMu.Unlock()
+}
+
+// webhookFor returns the webhook an approval's notifications go to: the
+// channel of the team owning its resource, else the global webhook.
+func (n *ApprovalNotifier) webhookFor(approval *audit.StoredApproval) string {
+	if url := n.teamWebhooks[approval.Team]; approval.Team != "" && url != "" {
+		return url
+	}
+	return n.webhook()
+}
+
+// IsEnabled returns true if any notification method is configured.
+func (n *ApprovalNotifier) IsEnabled() bool {
+	return n.webhook() != "" || len(n.teamWebhooks) > 0 || (n.smtpHost != "" && len(n.emailTo) > 0) || len(n.smsTo) > 0 || len(n.plugins) > 0
+}
+
+// RegisterCallback registers a callback URL for an approval ID.
+func (n *ApprovalNotifier) RegisterCallback(approvalID, callbackURL string) {
+	if callbackURL != "" {
+		n.callbackURLs[approvalID] = callbackURL
+	}
+}
+
+// NotifyCreated sends notifications when a new approval request is created.
+func (n *ApprovalNotifier) NotifyCreated(ctx context.Context, approval *audit.StoredApproval) {
+	if !n.IsEnabled() {
+		return
+	}
+
+	// Register callback URL if provided
+	if approval.CallbackURL != "" {
+		n.RegisterCallback(approval.ApprovalID, approval.CallbackURL)
+	}
+
+	// Send webhook notification
+	if url := n.webhookFor(approval); url != "" {
+		shutdown.Go(func() { n.sendWebhook(url, approval, "created") })
+	}
+
+	// Send email notification
+	if n.smtpHost != "" && len(n.emailTo) > 0 {
+		shutdown.Go(func() { n.sendEmail(approval, "created") })
+	}
+
+	// Send SMS/WhatsApp notification
+	if len(n.smsTo) > 0 {
+		shutdown.Go(func() { n.sendSMS(approval) })
+	}
+
+	n.sendPlugins("approval_created", approvalPayload(approval, "created"))
+}
+
+// sendSMS texts a new approval request to every SMS recipient.
+func (n *ApprovalNotifier) sendSMS(approval *audit.StoredApproval) {
+	for _, to := range n.smsTo {
+		text := smsApprovalText(n.locales, n.recipientLocale(to), approval, n.smsReplies)
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := n.sms.Send(ctx, to, text)
+		cancel()
+		if err != nil {
+			slog.Error("failed to send approval SMS", "err", err, "approval_id", approval.ApprovalID, "to", to)
+		} else {
+			slog.Info("approval SMS sent", "approval_id", approval.ApprovalID, "to", to)
+		}
+	}
+}
+
+// NotifyResolved sends notifications when an approval request is resolved.
+func (n *ApprovalNotifier) NotifyResolved(ctx context.Context, approval *audit.StoredApproval) {
+	// Send callback to registered URL
+	if callbackURL, ok := n.callbackURLs[approval.ApprovalID]; ok {
+		shutdown.Go(func() { n.sendCallback(callbackURL, approval) })
+		delete(n.callbackURLs, approval.ApprovalID)
+	}
+
+	// Send webhook notification
+	if url := n.webhookFor(approval); url != "" {
+		shutdown.Go(func() { n.sendWebhook(url, approval, "resolved") })
+	}
+	n.sendPlugins("approval_resolved", approvalPayload(approval, "resolved"))
+
+	// Send email notification (only for denials)
+	if n.smtpHost != "" && len(n.emailTo) > 0 && approval.Status == "denied" {
+		shutdown.Go(func() { n.sendEmail(approval, "resolved") })
+	}
+}
+
+// NotifyExecuted tells the approver how the approved action went. It is
+// opt-in (ApprovalNotifierConfig.NotifyExecuted) and uses the same webhook,
+// email and plugin channels as the request.
+func (n *ApprovalNotifier) NotifyExecuted(ctx context.Context, approval *audit.StoredApproval) {
+	if !n.notifyExecuted || approval.Execution == nil {
+		return
+	}
+	if url := n.webhookFor(approval); url != "" {
+		shutdown.Go(func() { n.sendWebhook(url, approval, "executed") })
+	}
+	if n.smtpHost != "" && len(n.emailTo) > 0 {
+		shutdown.Go(func() { n.sendEmail(approval, "executed") })
+	}
+	n.sendPlugins("approval_executed", approvalPayload(approval, "executed"))
+}
+
+// sendPlugins runs every notify plugin with payload, each in the background.
+func (n *ApprovalNotifier) sendPlugins(event string, payload map[string]any) {
+	for _, p := range n.plugins {
+		shutdown.Go(func() {
+			if err := p.Send(context.Background(), event, payload); err != nil {
+				slog.Error("approval notify plugin failed", "plugin", p.Name(), "event", event, "err", err)
+			} else {
+				slog.Debug("approval notify plugin ran", "plugin", p.Name(), "event", event)
+			}
+		})
+	}
+}
+
+// approvalPayload is the JSON form of an approval event posted to generic
+// webhooks and fed to notify plugins.
+func approvalPayload(approval *audit.StoredApproval, eventType string) map[string]any {
+	payload := map[string]any{
+		"event_type":  "approval_" + eventType,
+		"approval_id": approval.ApprovalID,
+		"status":      approval.Status,
+		"action":      approval.ActionClass,
+		"tool":        approval.ToolName,
+		"agent":       approval.AgentName,
+		"resource":    approval.ResourceType + "/" + approval.ResourceName,
+		"requested_by": approval.RequestedBy,
+		"requested_at": approval.RequestedAt.Format(time.RFC3339),
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}
+
+	if approval.ResolvedBy != "" {
+		payload["resolved_by"] = approval.ResolvedBy
+		payload["resolved_at"] = approval.ResolvedAt.Format(time.RFC3339)
+		payload["reason"] = approval.ResolutionReason
+	}
+
+	if !approval.ExpiresAt.IsZero() {
+		payload["expires_at"] = approval.ExpiresAt.Format(time.RFC3339)
+	}
+	if approval.Team != "" {
+		payload["team"] = approval.Team
+	}
+
+	if plan := approval.ExecutionPlan(); plan != nil {
+		payload["execution_plan"] = plan
+	}
+	if approval.Execution != nil {
+		payload["execution"] = approval.Execution
+	}
+	return payload
+}
+
+// sendWebhook sends a webhook notification to webhookURL.
+func (n *ApprovalNotifier) sendWebhook(webhookURL string, approval *audit.StoredApproval, eventType string) {
+	payload := approvalPayload(approval, eventType)
+
+	// Slack-compatible format
+	if strings.Contains(webhookURL, "slack.com") {
+		emoji := ":hourglass:"
+		color := "#FFA500" // orange for pending
+		title := "created"
+
+		switch approval.Status {
+		case "approved":
+			emoji = ":white_check_mark:"
+			color = "#36A64F" // green
+			title = "approved"
+		case "denied":
+			emoji = ":x:"
+			color = "#FF0000" // red
+			title = "denied"
+		case "expired":
+			emoji = ":alarm_clock:"
+			color = "#808080" // gray
+			title = "expired"
+		case "cancelled":
+			emoji = ":no_entry_sign:"
+			color = "#808080"
+			title = "cancelled"
+		}
+		if eventType == "executed" {
+			emoji, color, title = ":gear:", "#36A64F", "executed"
+			if approval.Execution.Status != "success" {
+				emoji, color, title = ":warning:", "#FF0000", "failed"
+			}
+		}
+
+		l := n.locales.Default()
+		label := func(key string) string { return n.locales.T(l, "label."+key, nil) }
+		text := fmt.Sprintf("%s *%s*\n", emoji, n.locales.T(l, "approval.slack."+title, nil))
+		text += fmt.Sprintf("*%s:* `%s`\n", label("id"), approval.ApprovalID)
+		text += fmt.Sprintf("*%s:* %s\n", label("action"), approval.ActionClass)
+		if approval.ToolName != "" {
+			text += fmt.Sprintf("*%s:* %s\n", label("tool"), approval.ToolName)
+		}
+		if approval.AgentName != "" {
+			text += fmt.Sprintf("*%s:* %s\n", label("agent"), approval.AgentName)
+		}
+		text += fmt.Sprintf("*%s:* %s\n", label("requested_by"), approval.RequestedBy)
+		if plan := approval.ExecutionPlan(); plan != nil {
+			text += fmt.Sprintf("*%s:* %s\n", label("plan"), plan.Summary)
+		}
+
+		if approval.ResolvedBy != "" {
+			text += fmt.Sprintf("*%s:* %s\n", label("resolved_by"), approval.ResolvedBy)
+			if approval.ResolutionReason != "" {
+				text += fmt.Sprintf("*%s:* %s\n", label("reason"), approval.ResolutionReason)
+			}
+		}
+		if eventType == "executed" {
+			text += fmt.Sprintf("*%s:* %s\n", label("result"), approval.Execution.Summary())
+		}
+
+		payload = map[string]any{
+			"attachments": []map[string]any{
+				{
+					"color": color,
+					"text":  text,
+					"ts":    time.Now().Unix(),
+				},
+			},
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("failed to marshal webhook payload", "err", err)
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("failed to send approval webhook", "err", err, "approval_id", approval.ApprovalID)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		slog.Error("approval webhook returned error", "status", resp.StatusCode, "approval_id", approval.ApprovalID)
+	} else {
+		slog.Debug("approval webhook sent", "approval_id", approval.ApprovalID, "event", eventType)
+	}
+}
+
+// sendCallback sends a callback to the registered URL when approval is resolved.
+func (n *ApprovalNotifier) sendCallback(callbackURL string, approval *audit.StoredApproval) {
+	payload := map[string]any{
+		"approval_id":       approval.ApprovalID,
+		"status":            approval.Status,
+		"resolved_by":       approval.ResolvedBy,
+		"resolved_at":       approval.ResolvedAt.Format(time.RFC3339),
+		"resolution_reason": approval.ResolutionReason,
+	}
+
+	if !approval.ApprovalValidUntil.IsZero() {
+		payload["valid_until"] = approval.ApprovalValidUntil.Format(time.RFC3339)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("failed to marshal callback payload", "err", err)
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("failed to send approval callback", "err", err, "approval_id", approval.ApprovalID, "url", callbackURL)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		slog.Error("approval callback returned error", "status", resp.StatusCode, "approval_id", approval.ApprovalID)
+	} else {
+		slog.Info("approval callback sent", "approval_id", approval.ApprovalID, "status", approval.Status)
+	}
+}
+
+// sendEmail sends an email notification, one per recipient locale.
+func (n *ApprovalNotifier) sendEmail(approval *audit.StoredApproval, eventType string) {
+	if eventType == "created" && n.links != nil && n.baseURL != "" {
+		// Signed links name their recipient, so each gets their own email.
+		for _, to := range n.emailTo {
+			l := n.recipientLocale(to)
+			subject, body := n.createdEmail(l, approval, n.locales.T(l, "approval.email.links.signed", i18n.Vars{
+				"approve_url": n.links.link(n.baseURL, approval, linkActionApprove, to),
+				"deny_url":    n.links.link(n.baseURL, approval, linkActionDeny, to),
+			}))
+			n.deliver([]string{to}, subject, body, approval.ApprovalID)
+		}
+		return
+	}
+
+	for _, g := range n.recipientLocales.Group(n.emailTo, n.locales.Default()) {
+		subject, body := n.approvalEmail(g.Locale, approval, eventType)
+		n.deliver(g.To, subject, body, approval.ApprovalID)
+	}
+}
+
+// approvalEmail builds the email for an approval event in locale l.
+func (n *ApprovalNotifier) approvalEmail(l string, approval *audit.StoredApproval, eventType string) (subject, body string) {
+	switch eventType {
+	case "created":
+		// Build approve/deny links if baseURL is configured
+		var actionLinks string
+		if n.baseURL != "" {
+			actionLinks = n.locales.T(l, "approval.email.links.curl", i18n.Vars{
+				"base_url":    n.baseURL,
+				"approval_id": approval.ApprovalID,
+			})
+		}
+		return n.createdEmail(l, approval, actionLinks)
+
+	case "executed":
+		vars := approvalVars(approval)
+		vars["executed_at"] = approval.Execution.ExecutedAt.Format(time.RFC3339)
+		vars["event_id"] = approval.Execution.EventID
+		vars["result"] = approval.Execution.Summary()
+		return n.locales.T(l, "approval.email.executed.subject", vars),
+			n.locales.T(l, "approval.email.executed.body", vars)
+	}
+
+	vars := approvalVars(approval)
+	vars["status"] = n.locales.Text(l, "approval.status."+approval.Status, approval.Status, nil)
+	vars["status_upper"] = strings.ToUpper(vars["status"].(string))
+	return n.locales.T(l, "approval.email.resolved.subject", vars),
+		n.locales.T(l, "approval.email.resolved.body", vars)
+}
+
+// createdEmail builds the email announcing a new approval request.
+func (n *ApprovalNotifier) createdEmail(l string, approval *audit.StoredApproval, actionLinks string) (subject, body string) {
+	vars := approvalVars(approval)
+	vars["plan"] = n.executionPlanSection(l, approval)
+	vars["links"] = actionLinks
+	return n.locales.T(l, "approval.email.created.subject", vars),
+		n.locales.T(l, "approval.email.created.body", vars)
+}
+
+// approvalVars are the placeholders every approval email can use.
+func approvalVars(approval *audit.StoredApproval) i18n.Vars {
+	return i18n.Vars{
+		"approval_id":  approval.ApprovalID,
+		"action":       approval.ActionClass,
+		"tool":         approval.ToolName,
+		"agent":        approval.AgentName,
+		"requested_at": approval.RequestedAt.Format(time.RFC3339),
+		"requested_by": approval.RequestedBy,
+		"expires_at":   approval.ExpiresAt.Format(time.RFC3339),
+		"resolved_at":  approval.ResolvedAt.Format(time.RFC3339),
+		"resolved_by":  approval.ResolvedBy,
+		"reason":       approval.ResolutionReason,
+	}
+}
+
+// recipientLocale is the locale emails and texts to recipient are written in.
+func (n *ApprovalNotifier) recipientLocale(recipient string) string {
+	return n.recipientLocales.Locale(recipient, n.locales.Default())
+}
+
+// deliver sends one approval email to the given recipients.
+func (n *ApprovalNotifier) deliver(to []string, subject, body, approvalID string) {
+	if err := n.sendMail(to, subject, body); err != nil {
+		slog.Error("failed to send approval email", "err", err, "approval_id", approvalID)
+	} else {
+		slog.Info("approval email sent", "approval_id", approvalID, "to", to)
+	}
+}
+
+// sendMail sends a plain-text email through the configured SMTP server.
+// Report subscriptions use it too, so every email auditd sends shares one
+// SMTP configuration.
+func (n *ApprovalNotifier) sendMail(to []string, subject, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		n.emailFrom, strings.Join(to, ","), subject, body)
+
+	var auth smtp.Auth
+	if n.smtpUser != "" && n.smtpPassword != "" {
+		auth = smtp.PlainAuth("", n.smtpUser, n.smtpPassword, n.smtpHost)
+	}
+	return smtp.SendMail(n.smtpHost+":"+n.smtpPort, auth, n.emailFrom, to, []byte(msg))
+}
+
+// executionPlanSection renders the approval's execution plan for the
+// "created" email, or "" when the requesting tool attached none.
+func (n *ApprovalNotifier) executionPlanSection(l string, approval *audit.StoredApproval) string {
+	plan := approval.ExecutionPlan()
+	if plan == nil {
+		return ""
+	}
+	return n.locales.T(l, "approval.email.plan", i18n.Vars{"plan": plan.Format("  ")})
+}
+
+// NotifyFreeze alerts on an emergency freeze state change through every
+// configured channel. Freezes are rare and fleet-wide, so unlike approval
+// resolutions they are always emailed.
+func (n *ApprovalNotifier) NotifyFreeze(st audit.FreezeState) {
+	if n.webhook() != "" {
+		shutdown.Go(func() { n.sendFreezeWebhook(st) })
+	}
+	if n.smtpHost != "" && len(n.emailTo) > 0 {
+		shutdown.Go(func() { n.sendFreezeEmail(st) })
+	}
+	payload := freezePayload(st)
+	n.sendPlugins(payload["event_type"].(string), payload)
+}
+
+func (n *ApprovalNotifier) freezeTitle(l string, st audit.FreezeState) string {
+	if st.Frozen {
+		return n.locales.T(l, "freeze.title.frozen", nil)
+	}
+	return n.locales.T(l, "freeze.title.lifted", nil)
+}
+
+// freezePayload is the JSON form of a freeze change posted to generic
+// webhooks and fed to notify plugins.
+func freezePayload(st audit.FreezeState) map[string]any {
+	eventType := string(audit.EventTypeEmergencyUnfreeze)
+	if st.Frozen {
+		eventType = string(audit.EventTypeEmergencyFreeze)
+	}
+	return map[string]any{
+		"event_type": eventType,
+		"frozen":     st.Frozen,
+		"reason":     st.Reason,
+		"changed_by": st.ChangedBy,
+		"timestamp":  st.ChangedAt.Format(time.RFC3339),
+	}
+}
+
+// sendFreezeWebhook posts a freeze change to the approval webhook.
+func (n *ApprovalNotifier) sendFreezeWebhook(st audit.FreezeState) {
+	payload := freezePayload(st)
+	webhookURL := n.webhook()
+	if strings.Contains(webhookURL, "slack.com") {
+		emoji, color := ":rotating_light:", "#FF0000"
+		if !st.Frozen {
+			emoji, color = ":white_check_mark:", "#36A64F"
+		}
+		l := n.locales.Default()
+		text := fmt.Sprintf("%s *%s*\n*%s:* %s\n", emoji, n.freezeTitle(l, st), n.locales.T(l, "label.by", nil), st.ChangedBy)
+		if st.Reason != "" {
+			text += fmt.Sprintf("*%s:* %s\n", n.locales.T(l, "label.reason", nil), st.Reason)
+		}
+		payload = map[string]any{
+			"attachments": []map[string]any{
+				{"color": color, "text": text, "ts": time.Now().Unix()},
+			},
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("failed to marshal freeze webhook payload", "err", err)
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("failed to send freeze webhook", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		slog.Error("freeze webhook returned error", "status", resp.StatusCode)
+	}
+}
+
+// sendFreezeEmail emails a freeze change to the approval recipients.
+func (n *ApprovalNotifier) sendFreezeEmail(st audit.FreezeState) {
+	for _, g := range n.recipientLocales.Group(n.emailTo, n.locales.Default()) {
+		title := n.freezeTitle(g.Locale, st)
+		subject := "[" + strings.ToUpper(title) + "]"
+		body := n.locales.T(g.Locale, "freeze.email.body", i18n.Vars{
+			"title":      title,
+			"changed_at": st.ChangedAt.Format(time.RFC3339),
+			"changed_by": st.ChangedBy,
+			"reason":     st.Reason,
+		})
+		if err := n.sendMail(g.To, subject, body); err != nil {
+			slog.Error("failed to send freeze email", "err", err)
+		}
+	}
+}
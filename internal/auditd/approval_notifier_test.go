@@ -0,0 +1,106 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/i18n"
+	"helpdesk/internal/notifyplugin"
+	"helpdesk/internal/shutdown"
+)
+
+// TestApprovalNotifier_Plugins verifies that notify plugins get approval and
+// freeze notifications as JSON, named by HELPDESK_NOTIFY_EVENT.
+func TestApprovalNotifier_Plugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "to-pager")
+	script := "#!/bin/sh\ncat > " + dir + "/\"$HELPDESK_NOTIFY_EVENT\".json\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := notifyplugin.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewApprovalNotifier(ApprovalNotifierConfig{Plugins: []*notifyplugin.Plugin{plugin}})
+	if !n.IsEnabled() {
+		t.Fatal("notifier with only a plugin is not enabled")
+	}
+
+	n.NotifyCreated(context.Background(), &audit.StoredApproval{
+		ApprovalID:   "apr_1",
+		Status:       "pending",
+		ActionClass:  "destructive",
+		ToolName:     "delete_pod",
+		ResourceType: "pod",
+		ResourceName: "web-1",
+		RequestedBy:  "alice",
+		RequestedAt:  time.Now(),
+	})
+	n.NotifyFreeze(audit.FreezeState{Frozen: true, Reason: "incident", ChangedBy: "bob", ChangedAt: time.Now()})
+	shutdown.Drain(context.Background())
+
+	read := func(event string) map[string]any {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, event+".json"))
+		if err != nil {
+			t.Fatalf("plugin not run for %s: %v", event, err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatalf("%s payload %q: %v", event, data, err)
+		}
+		return payload
+	}
+	if p := read("approval_created"); p["approval_id"] != "apr_1" || p["resource"] != "pod/web-1" {
+		t.Errorf("approval_created payload = %v", p)
+	}
+	freeze := string(audit.EventTypeEmergencyFreeze)
+	if p := read(freeze); p["frozen"] != true || !strings.Contains(p["reason"].(string), "incident") {
+		t.Errorf("%s payload = %v", freeze, p)
+	}
+}
+
+// TestApprovalNotifier_Locales verifies that emails and texts are written in
+// each recipient's locale and that English output is unchanged.
+func TestApprovalNotifier_Locales(t *testing.T) {
+	recipients, err := i18n.ParseRecipients("*@emea.example.com=de,+34*=es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewApprovalNotifier(ApprovalNotifierConfig{RecipientLocales: recipients})
+	approval := &audit.StoredApproval{
+		ApprovalID:  "apr_1",
+		Status:      "denied",
+		ActionClass: "destructive",
+		ToolName:    "delete_pod",
+		RequestedBy: "alice",
+	}
+
+	subject, _ := n.approvalEmail(n.recipientLocale("ops@us.example.com"), approval, "created")
+	if subject != "[APPROVAL REQUIRED] destructive - delete_pod" {
+		t.Errorf("English subject = %q", subject)
+	}
+	subject, body := n.approvalEmail(n.recipientLocale("ops@EMEA.example.com"), approval, "resolved")
+	if subject != "[GENEHMIGUNG ABGELEHNT] destructive - delete_pod" || !strings.Contains(body, "Status:          abgelehnt") {
+		t.Errorf("German email = %q\n%s", subject, body)
+	}
+
+	if text := smsApprovalText(nil, n.recipientLocale("+34600000000"), approval, true); !strings.HasPrefix(text, "[helpdesk] Aprobación necesaria: destructive delete_pod") ||
+		!strings.HasSuffix(text, "Responda YES apr_1 o NO apr_1 [motivo].") {
+		t.Errorf("Spanish SMS = %q", text)
+	}
+	if text := smsApprovalText(nil, "en", approval, false); text != "[helpdesk] Approval needed: destructive delete_pod, requested by alice. ID apr_1." {
+		t.Errorf("English SMS = %q", text)
+	}
+}
@@ -0,0 +1,72 @@
+package auditd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// recordSLABreaches records an approval_sla_breach event for every approval
+// request that has missed its SLA since the last check: still pending past
+// its due time, or resolved after it. The auditor alerts on these events,
+// routed like other alerts to the team owning the resource.
+func (s *approvalServer) recordSLABreaches(ctx context.Context, now time.Time) int {
+	if s.events == nil {
+		return 0
+	}
+	breached, err := s.store.MarkSLABreaches(ctx, now)
+	if err != nil {
+		slog.Error("failed to check approval SLAs", "err", err)
+	}
+	for _, a := range breached {
+		event := slaBreachEvent(a, now)
+		if err := s.events.Record(ctx, event); err != nil {
+			slog.Warn("failed to record approval_sla_breach event", "approval_id", a.ApprovalID, "err", err)
+			continue
+		}
+		slog.Warn("approval SLA breached",
+			"approval_id", a.ApprovalID, "action_class", a.ActionClass, "status", a.Status,
+			"team", a.Team, "waited", time.Duration(event.ApprovalSLABreach.WaitedSecs)*time.Second)
+	}
+	return len(breached)
+}
+
+// slaBreachEvent describes an approval that missed its SLA as of now.
+func slaBreachEvent(a *audit.StoredApproval, now time.Time) *audit.Event {
+	waited, resolved := a.TimeToResolution()
+	if !resolved {
+		waited = now.Sub(a.RequestedAt)
+	}
+	return &audit.Event{
+		EventID:     "sla_" + uuid.New().String()[:8],
+		Timestamp:   now,
+		EventType:   audit.EventTypeApprovalSLABreach,
+		TraceID:     a.TraceID,
+		ParentID:    a.EventID,
+		ActionClass: audit.ActionClass(a.ActionClass),
+		Team:        a.Team,
+		Session: audit.Session{
+			UserID:    a.RequestedBy,
+			AgentName: a.AgentName,
+			TenantID:  a.TenantID,
+		},
+		ApprovalSLABreach: &audit.ApprovalSLABreach{
+			ApprovalID:   a.ApprovalID,
+			ActionClass:  a.ActionClass,
+			ToolName:     a.ToolName,
+			ResourceType: a.ResourceType,
+			ResourceName: a.ResourceName,
+			RequestedBy:  a.RequestedBy,
+			RequestedAt:  a.RequestedAt,
+			DueAt:        a.SLADueAt,
+			SLASecs:      int64(a.SLADueAt.Sub(a.RequestedAt).Seconds()),
+			Status:       a.Status,
+			WaitedSecs:   int64(waited.Seconds()),
+			ResolvedBy:   a.ResolvedBy,
+		},
+	}
+}
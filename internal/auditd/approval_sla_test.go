@@ -0,0 +1,133 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+func newSLAApprovalSrv(t *testing.T) *approvalServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	as, err := audit.NewApprovalStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewApprovalStore: %v", err)
+	}
+	return &approvalServer{
+		store:      as,
+		authorizer: authz.NewAuthorizer(authz.DefaultAuditdPermissions, false),
+		slas:       map[audit.ActionClass]time.Duration{audit.ActionDestructive: 15 * time.Minute},
+		events:     store,
+	}
+}
+
+func TestCreateApproval_SetsSLADueAt(t *testing.T) {
+	s := newSLAApprovalSrv(t)
+	create := func(class string) map[string]any {
+		body, _ := json.Marshal(CreateApprovalRequest{ActionClass: class, RequestedBy: "alice", ToolName: "drop_table"})
+		w := httptest.NewRecorder()
+		s.handleCreateApproval(w, httptest.NewRequest(http.MethodPost, "/v1/approvals", bytes.NewReader(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %s: status %d: %s", class, w.Code, w.Body)
+		}
+		var resp map[string]any
+		json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+		return resp
+	}
+
+	resp := create("destructive")
+	if _, ok := resp["sla_due_at"]; !ok {
+		t.Errorf("response = %v, want sla_due_at", resp)
+	}
+	stored, err := s.store.GetRequest(context.Background(), resp["approval_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.SLADueAt.Sub(stored.RequestedAt); got != 15*time.Minute {
+		t.Errorf("SLA = %s, want 15m", got)
+	}
+
+	if resp := create("write"); resp["sla_due_at"] != nil {
+		t.Errorf("write approval has an SLA: %v", resp)
+	}
+}
+
+func TestRecordSLABreaches(t *testing.T) {
+	s := newSLAApprovalSrv(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	seed := func(name string, requested time.Duration, sla time.Duration) string {
+		a := &audit.StoredApproval{
+			ApprovalID:  "apr_" + name,
+			TraceID:     "tr_" + name,
+			ActionClass: "destructive",
+			ToolName:    "drop_table",
+			RequestedBy: "alice",
+			RequestedAt: now.Add(-requested),
+			SLADueAt:    now.Add(-requested + sla),
+			ExpiresAt:   now.Add(time.Hour),
+			Team:        "payments",
+		}
+		if err := s.store.CreateRequest(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		return a.ApprovalID
+	}
+	seed("overdue", 20*time.Minute, 15*time.Minute)
+	seed("waiting", 5*time.Minute, 15*time.Minute)
+	if err := s.store.Approve(ctx, seed("fast", time.Minute, 15*time.Minute), "bob", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.Deny(ctx, seed("late", 30*time.Minute, 15*time.Minute), "bob", "too risky"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := s.recordSLABreaches(ctx, now.Add(time.Second)); n != 2 {
+		t.Errorf("first check: %d breaches, want 2 (overdue, late)", n)
+	}
+	// An hour later the waiting request has breached too; the others are
+	// not recorded again and the fast one met its SLA.
+	if n := s.recordSLABreaches(ctx, now.Add(time.Hour)); n != 1 {
+		t.Errorf("second check: %d breaches, want 1 (waiting)", n)
+	}
+
+	events, err := s.events.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeApprovalSLABreach})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		b := e.ApprovalSLABreach
+		if b == nil || e.Team != "payments" || e.TraceID != "tr_"+b.ApprovalID[len("apr_"):] || b.SLASecs != 900 {
+			t.Errorf("event = %+v, breach = %+v", e, b)
+			continue
+		}
+		got = append(got, b.ApprovalID+"/"+b.Status)
+		if b.ApprovalID == "apr_late" && (b.WaitedSecs < 1799 || b.ResolvedBy != "bob") {
+			t.Errorf("late breach = %+v, want waited 30m, resolved by bob", b)
+		}
+	}
+	sort.Strings(got)
+	want := []string{"apr_late/denied", "apr_overdue/pending", "apr_waiting/pending"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("breaches = %v, want %v", got, want)
+	}
+
+	fast, _ := s.store.GetRequest(ctx, "apr_fast")
+	if fast.TimeToResolutionSecs <= 0 || !fast.SLABreachedAt.IsZero() {
+		t.Errorf("fast = %+v, want a time to resolution and no breach", fast)
+	}
+}
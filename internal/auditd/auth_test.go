@@ -1,4 +1,4 @@
-package main
+package auditd
 
 import (
 	"errors"
@@ -0,0 +1,144 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// Thresholds the calibration job uses to log overconfident agents. The
+// auditor applies its own, configurable ones to the same curves.
+const (
+	calibrationLogMinDecisions = 20
+	calibrationLogGap          = 0.15
+)
+
+// calibrationServer compares the confidence agents report on routing
+// decisions with how the routed requests turned out.
+type calibrationServer struct {
+	events    *audit.Store
+	snapshots *audit.CalibrationSnapshotStore
+
+	// window is how far back each job run looks; retention is how long
+	// snapshots are kept, changeable at runtime (see setRetention).
+	window    time.Duration
+	mu        sync.Mutex
+	retention time.Duration
+}
+
+// setRetention replaces the snapshot retention period for later job runs.
+func (s *calibrationServer) setRetention(d time.Duration) {
+	s.mu.Lock()
+	s.retention = d
+	s.mu.Unlock()
+}
+
+// handleCalibration returns live calibration curves, overall and per agent.
+// GET /v1/events/calibration?since=<RFC3339>&until=<RFC3339>&agent=<name>
+func (s *calibrationServer) handleCalibration(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := audit.ConfidenceCalibrationOptions{Agent: q.Get("agent"), TenantID: tenantScope(r)}
+	for param, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+
+	report, err := s.events.ConfidenceCalibration(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to compute confidence calibration", "err", err)
+		http.Error(w, "failed to compute confidence calibration", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report) //nolint:errcheck
+}
+
+// handleHistory returns the calibration job's snapshots for one agent, newest
+// first. Omit agent for the all-agent curve.
+// GET /v1/events/calibration/history?agent=<name>&limit=50
+func (s *calibrationServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 50
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	snaps, err := s.snapshots.History(r.Context(), q.Get("agent"), tenantScope(r), limit)
+	if err != nil {
+		slog.Error("failed to list calibration snapshots", "err", err)
+		http.Error(w, "failed to list calibration snapshots", http.StatusInternalServerError)
+		return
+	}
+	if snaps == nil {
+		snaps = []audit.CalibrationSnapshot{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snaps) //nolint:errcheck
+}
+
+// startCalibrationJob snapshots the fleet-wide calibration curves every
+// interval until ctx is cancelled.
+func (s *calibrationServer) startCalibrationJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.runCalibration(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runCalibration(ctx, now)
+		}
+	}
+}
+
+// runCalibration computes the curves over the trailing window, saves them
+// and prunes snapshots older than the retention period.
+func (s *calibrationServer) runCalibration(ctx context.Context, now time.Time) {
+	since := now.Add(-s.window)
+	report, err := s.events.ConfidenceCalibration(ctx, audit.ConfidenceCalibrationOptions{Since: since})
+	if err != nil {
+		slog.Error("calibration job: failed to compute calibration", "err", err)
+		return
+	}
+	if err := s.snapshots.Save(ctx, now, since, "", report); err != nil {
+		slog.Error("calibration job: failed to save snapshots", "err", err)
+		return
+	}
+	for _, c := range report.Agents {
+		if c.IsOverconfident(calibrationLogMinDecisions, calibrationLogGap) {
+			slog.Warn("calibration job: agent is overconfident",
+				"agent", c.Agent,
+				"decisions", c.Decisions,
+				"mean_confidence", c.MeanConfidence,
+				"success_rate", c.SuccessRate)
+		}
+	}
+	s.mu.Lock()
+	retention := s.retention
+	s.mu.Unlock()
+	if retention > 0 {
+		if n, err := s.snapshots.Prune(ctx, now.Add(-retention)); err != nil {
+			slog.Warn("calibration job: failed to prune snapshots", "err", err)
+		} else if n > 0 {
+			slog.Debug("calibration job: pruned snapshots", "count", n)
+		}
+	}
+	slog.Debug("calibration job: snapshot saved", "decisions", report.Overall.Decisions, "agents", len(report.Agents))
+}
@@ -0,0 +1,76 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func newCalibrationServer(t *testing.T) *calibrationServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	snaps, err := audit.NewCalibrationSnapshotStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewCalibrationSnapshotStore: %v", err)
+	}
+	return &calibrationServer{events: store, snapshots: snaps, window: 24 * time.Hour, retention: 48 * time.Hour}
+}
+
+func TestCalibrationHandlers(t *testing.T) {
+	srv := newCalibrationServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for i, status := range []string{"success", "error", "error"} {
+		err := srv.events.Record(ctx, &audit.Event{
+			EventID: fmt.Sprintf("evt_c%d", i), Timestamp: now.Add(time.Duration(i) * time.Second),
+			EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "sess_c"},
+			Decision: &audit.Decision{Agent: "k8s_agent", Confidence: 0.9},
+			Outcome:  &audit.Outcome{Status: status},
+		})
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleCalibration(w, httptest.NewRequest(http.MethodGet, "/v1/events/calibration?agent=k8s_agent", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var report audit.ConfidenceCalibration
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(report.Agents) != 1 || report.Agents[0].Failed != 2 {
+		t.Errorf("report = %+v, want k8s_agent with 2 failures", report)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleCalibration(w, httptest.NewRequest(http.MethodGet, "/v1/events/calibration?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: status = %d, want 400", w.Code)
+	}
+
+	// One job run snapshots the overall and the k8s_agent curve.
+	srv.runCalibration(ctx, now.Add(time.Minute))
+	w = httptest.NewRecorder()
+	srv.handleHistory(w, httptest.NewRequest(http.MethodGet, "/v1/events/calibration/history?agent=k8s_agent", nil))
+	var hist []audit.CalibrationSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &hist); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if len(hist) != 1 || hist[0].Decisions != 3 || hist[0].Agent != "k8s_agent" {
+		t.Errorf("history = %+v, want one k8s_agent snapshot of 3 decisions", hist)
+	}
+}
@@ -0,0 +1,328 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// Canary pipeline legs, in the order a run checks them.
+const (
+	canaryLegRecord       = "record"
+	canaryLegSubscription = "subscription"
+	canaryLegQuery        = "query"
+	canaryLegChain        = "chain"
+	canaryLegNotify       = "notify"
+)
+
+// canaryPollInterval is how often the query leg looks for the canary event.
+const canaryPollInterval = 100 * time.Millisecond
+
+// CanaryConfig configures the synthetic canary job.
+type CanaryConfig struct {
+	Interval   time.Duration // how often a canary event is recorded; 0 disables the job
+	SLA        time.Duration // how long every leg has to see the event
+	WebhookURL string        // test notification channel; the notify leg is skipped when empty
+	AlertURL   string        // pinged after a healthy run, <url>/fail otherwise (healthchecks.io-style)
+}
+
+// canaryLeg is the result of one pipeline leg in a canary run.
+type canaryLeg struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// canaryRun is the result of one canary run.
+type canaryRun struct {
+	EventID string      `json:"event_id"`
+	TraceID string      `json:"trace_id"`
+	At      time.Time   `json:"at"`
+	OK      bool        `json:"ok"`
+	Legs    []canaryLeg `json:"legs"`
+}
+
+// failed returns the names of the legs that failed.
+func (r *canaryRun) failed() []string {
+	var names []string
+	for _, l := range r.Legs {
+		if !l.OK && !l.Skipped {
+			names = append(names, l.Name)
+		}
+	}
+	return names
+}
+
+// canaryServer records a clearly marked synthetic event on a schedule and
+// checks that it makes it through every leg of the pipeline: it is recorded,
+// reaches audit socket subscribers, can be queried back, carries a valid
+// chain hash, and (when configured) is announced on a test notification
+// channel, all within the SLA. A leg that breaks silently — a stuck socket,
+// a query path that no longer sees new rows — shows up as a failed run, is
+// saved as a canary_failed alert and fails the external alert URL.
+type canaryServer struct {
+	store  *audit.Store
+	alerts *audit.AlertStore
+	cfg    CanaryConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	runs     int64
+	failures int64
+	last     *canaryRun
+}
+
+func newCanaryServer(store *audit.Store, alerts *audit.AlertStore, cfg CanaryConfig) *canaryServer {
+	if cfg.SLA <= 0 {
+		cfg.SLA = 30 * time.Second
+	}
+	return &canaryServer{store: store, alerts: alerts, cfg: cfg, client: &http.Client{Timeout: cfg.SLA}}
+}
+
+// startCanaryJob runs a canary every interval until ctx is cancelled.
+func (s *canaryServer) startCanaryJob(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.report(ctx, s.run(ctx))
+		}
+	}
+}
+
+// run records one canary event and checks every leg.
+func (s *canaryServer) run(ctx context.Context) *canaryRun {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.SLA)
+	defer cancel()
+
+	now := time.Now().UTC()
+	event := &audit.Event{
+		EventID:   "cny_" + uuid.New().String()[:8],
+		Timestamp: now,
+		EventType: audit.EventTypeCanary,
+		TraceID:   audit.NewTraceIDWithPrefix("cny_"),
+		Session:   audit.Session{ID: "auditd-canary", UserID: "auditd"},
+		Outcome:   &audit.Outcome{Status: "success"},
+	}
+	run := &canaryRun{EventID: event.EventID, TraceID: event.TraceID, At: now}
+
+	// Subscribe before recording so the live stream cannot miss the event.
+	subCtx, stopSub := context.WithCancel(ctx)
+	defer stopSub()
+	stream := s.store.Subscribe(subCtx, audit.SocketLive)
+
+	start := time.Now()
+	leg := func(name string, err error) {
+		l := canaryLeg{Name: name, OK: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			l.Error = err.Error()
+		}
+		run.Legs = append(run.Legs, l)
+	}
+
+	if err := s.store.Record(ctx, event); err != nil {
+		leg(canaryLegRecord, err)
+		run.Legs = append(run.Legs,
+			canaryLeg{Name: canaryLegSubscription, Skipped: true},
+			canaryLeg{Name: canaryLegQuery, Skipped: true},
+			canaryLeg{Name: canaryLegChain, Skipped: true},
+			canaryLeg{Name: canaryLegNotify, Skipped: true})
+		return run
+	}
+	leg(canaryLegRecord, nil)
+
+	leg(canaryLegSubscription, waitForCanary(ctx, stream, event.EventID))
+
+	stored, err := s.queryCanary(ctx, event.EventID)
+	leg(canaryLegQuery, err)
+
+	switch {
+	case stored == nil:
+		run.Legs = append(run.Legs, canaryLeg{Name: canaryLegChain, Skipped: true})
+	case stored.EventHash == "" || stored.PrevHash == "":
+		leg(canaryLegChain, errors.New("stored canary has no chain hash"))
+	case !audit.VerifyEventHash(stored):
+		leg(canaryLegChain, errors.New("stored canary does not match its event_hash"))
+	default:
+		leg(canaryLegChain, nil)
+	}
+
+	if s.cfg.WebhookURL == "" {
+		run.Legs = append(run.Legs, canaryLeg{Name: canaryLegNotify, Skipped: true})
+	} else {
+		leg(canaryLegNotify, s.notify(ctx, event))
+	}
+
+	run.OK = len(run.failed()) == 0
+	return run
+}
+
+// waitForCanary reads the live stream until the canary event arrives.
+func waitForCanary(ctx context.Context, stream <-chan audit.SequencedEvent, eventID string) error {
+	for {
+		select {
+		case se, ok := <-stream:
+			if !ok {
+				return errors.New("subscription closed before the canary arrived")
+			}
+			if se.Event.EventID == eventID {
+				return nil
+			}
+		case <-ctx.Done():
+			return errors.New("canary not delivered to subscribers within the SLA")
+		}
+	}
+}
+
+// queryCanary polls the query path until the canary event is visible.
+func (s *canaryServer) queryCanary(ctx context.Context, eventID string) (*audit.Event, error) {
+	for {
+		events, err := s.store.Query(ctx, audit.QueryOptions{EventID: eventID})
+		if err == nil && len(events) > 0 {
+			return &events[0], nil
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return nil, fmt.Errorf("canary not queryable within the SLA: %w", err)
+			}
+			return nil, errors.New("canary not queryable within the SLA")
+		case <-time.After(canaryPollInterval):
+		}
+	}
+}
+
+// notify announces the canary on the test notification channel, in the same
+// {"text": ...} shape as approval webhooks so a Slack test channel works.
+func (s *canaryServer) notify(ctx context.Context, event *audit.Event) error {
+	body, _ := json.Marshal(map[string]string{
+		"text":     "aiHelpDesk canary " + event.EventID + " (synthetic, no action needed)",
+		"event_id": event.EventID,
+		"trace_id": event.TraceID,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("canary webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// report keeps the run for GET /v1/canary and raises failures: a log line,
+// a canary_failed alert, and the external alert URL.
+func (s *canaryServer) report(ctx context.Context, run *canaryRun) {
+	s.mu.Lock()
+	s.runs++
+	if !run.OK {
+		s.failures++
+	}
+	s.last = run
+	s.mu.Unlock()
+
+	failed := run.failed()
+	if len(failed) == 0 {
+		slog.Debug("canary: pipeline healthy", "event_id", run.EventID)
+	} else {
+		slog.Error("canary: audit pipeline leg failed",
+			"event_id", run.EventID,
+			"failed", strings.Join(failed, ","))
+		if s.alerts != nil {
+			var msgs []string
+			for _, l := range run.Legs {
+				if !l.OK && !l.Skipped {
+					msgs = append(msgs, l.Name+": "+l.Error)
+				}
+			}
+			err := s.alerts.Record(ctx, &audit.AlertRecord{
+				AlertID:  "alert_" + strings.TrimPrefix(run.EventID, "cny_"),
+				Rule:     "canary_failed",
+				Severity: "CRITICAL",
+				Resource: "audit_pipeline",
+				Message:  "Canary event did not make it through the audit pipeline: " + strings.Join(msgs, "; "),
+				EventID:  run.EventID,
+				TraceID:  run.TraceID,
+			})
+			if err != nil {
+				slog.Warn("canary: failed to save alert", "err", err)
+			}
+		}
+	}
+	if s.cfg.AlertURL != "" {
+		s.ping(failed)
+	}
+}
+
+// ping reports the run to the external alert URL: a plain GET when every leg
+// passed, a POST to <url>/fail naming the failed legs otherwise. As with the
+// auditor's heartbeat, the external service also notices auditd going down.
+func (s *canaryServer) ping(failed []string) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	if len(failed) == 0 {
+		resp, err = s.client.Get(s.cfg.AlertURL)
+	} else {
+		url := strings.TrimSuffix(s.cfg.AlertURL, "/") + "/fail"
+		resp, err = s.client.Post(url, "text/plain", strings.NewReader("failed: "+strings.Join(failed, ", ")))
+	}
+	if err != nil {
+		slog.Warn("canary ping failed", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("canary ping returned error", "status", resp.StatusCode)
+	}
+}
+
+// handleCanary returns the latest canary run and the run counters.
+// GET /v1/canary
+func (s *canaryServer) handleCanary(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resp := struct {
+		Enabled  bool          `json:"enabled"`
+		Interval time.Duration `json:"interval_ns"`
+		SLA      time.Duration `json:"sla_ns"`
+		Runs     int64         `json:"runs"`
+		Failures int64         `json:"failures"`
+		Last     *canaryRun    `json:"last,omitempty"`
+	}{s.cfg.Interval > 0, s.cfg.Interval, s.cfg.SLA, s.runs, s.failures, s.last}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+// handleRunCanary runs a canary now and returns its result.
+// POST /v1/canary/run
+func (s *canaryServer) handleRunCanary(w http.ResponseWriter, r *http.Request) {
+	run := s.run(r.Context())
+	s.report(r.Context(), run)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run) //nolint:errcheck
+}
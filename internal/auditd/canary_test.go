@@ -0,0 +1,140 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func newTestCanaryServer(t *testing.T, cfg CanaryConfig) *canaryServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	alerts, err := audit.NewAlertStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewAlertStore: %v", err)
+	}
+	return newCanaryServer(store, alerts, cfg)
+}
+
+func TestCanary_HealthyRun(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cs := newTestCanaryServer(t, CanaryConfig{
+		SLA:        5 * time.Second,
+		WebhookURL: srv.URL + "/hook",
+		AlertURL:   srv.URL + "/ping",
+	})
+	ctx := context.Background()
+	run := cs.run(ctx)
+	cs.report(ctx, run)
+
+	if !run.OK {
+		t.Fatalf("run failed: %+v", run.Legs)
+	}
+	var names []string
+	for _, l := range run.Legs {
+		names = append(names, l.Name)
+	}
+	if got := strings.Join(names, ","); got != "record,subscription,query,chain,notify" {
+		t.Errorf("legs = %s", got)
+	}
+	if !strings.HasPrefix(run.EventID, "cny_") || !strings.HasPrefix(run.TraceID, "cny_") {
+		t.Errorf("canary IDs = %s / %s, want cny_ prefixes", run.EventID, run.TraceID)
+	}
+	events, _ := cs.store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeCanary})
+	if len(events) != 1 {
+		t.Errorf("stored canaries = %d, want 1", len(events))
+	}
+	mu.Lock()
+	if got := strings.Join(hits, "|"); got != "POST /hook|GET /ping" {
+		t.Errorf("webhook hits = %s, want the test notification then a healthy ping", got)
+	}
+	mu.Unlock()
+
+	w := httptest.NewRecorder()
+	cs.handleCanary(w, httptest.NewRequest(http.MethodGet, "/v1/canary", nil))
+	var status struct {
+		Runs     int64      `json:"runs"`
+		Failures int64      `json:"failures"`
+		Last     *canaryRun `json:"last"`
+	}
+	json.NewDecoder(w.Body).Decode(&status) //nolint:errcheck
+	if status.Runs != 1 || status.Failures != 0 || status.Last == nil || status.Last.EventID != run.EventID {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestCanary_FailedLegRaisesAlert(t *testing.T) {
+	var mu sync.Mutex
+	var pings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pings = append(pings, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/hook" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	cs := newTestCanaryServer(t, CanaryConfig{
+		SLA:        5 * time.Second,
+		WebhookURL: srv.URL + "/hook",
+		AlertURL:   srv.URL + "/ping",
+	})
+	ctx := context.Background()
+
+	w := httptest.NewRecorder()
+	cs.handleRunCanary(w, httptest.NewRequest(http.MethodPost, "/v1/canary/run", nil))
+	var run canaryRun
+	json.NewDecoder(w.Body).Decode(&run) //nolint:errcheck
+	if run.OK {
+		t.Fatal("run with a failing webhook reported OK")
+	}
+	if failed := (&run).failed(); len(failed) != 1 || failed[0] != canaryLegNotify {
+		t.Errorf("failed legs = %v, want [notify]", failed)
+	}
+
+	alerts, err := cs.alerts.List(ctx, time.Time{}, "canary_failed", 10)
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("canary_failed alerts = %d (err %v), want 1", len(alerts), err)
+	}
+	if alerts[0].EventID != run.EventID || !strings.Contains(alerts[0].Message, "notify") {
+		t.Errorf("alert = %+v", alerts[0])
+	}
+	mu.Lock()
+	if pings[len(pings)-1] != "POST /ping/fail" {
+		t.Errorf("last ping = %s, want POST /ping/fail", pings[len(pings)-1])
+	}
+	mu.Unlock()
+}
+
+func TestCanary_NotifySkippedWithoutWebhook(t *testing.T) {
+	cs := newTestCanaryServer(t, CanaryConfig{SLA: 5 * time.Second})
+	run := cs.run(context.Background())
+	if !run.OK {
+		t.Fatalf("run failed: %+v", run.Legs)
+	}
+	if last := run.Legs[len(run.Legs)-1]; last.Name != canaryLegNotify || !last.Skipped {
+		t.Errorf("notify leg = %+v, want skipped", last)
+	}
+}
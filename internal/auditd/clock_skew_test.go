@@ -0,0 +1,71 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestClockSkew_ReceivedAtStampedAndReported(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	srv := &server{store: store}
+
+	// The agent's clock runs two minutes behind, and it sends its own
+	// received_at, which auditd must overwrite.
+	forged := time.Now().Add(-time.Hour).UTC()
+	before := time.Now().UTC()
+	data, _ := json.Marshal(audit.Event{
+		EventID:    "evt_skewed",
+		Timestamp:  before.Add(-2 * time.Minute),
+		ReceivedAt: &forged,
+		EventType:  audit.EventTypeToolExecution,
+		Session:    audit.Session{ID: "s", AgentName: "db_agent"},
+	})
+	w := httptest.NewRecorder()
+	srv.handleRecordEvent(w, httptest.NewRequest(http.MethodPost, "/v1/events", bytes.NewReader(data)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("record status = %d; body: %s", w.Code, w.Body.String())
+	}
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{EventID: "evt_skewed"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Query = %d events (err %v)", len(events), err)
+	}
+	if got := events[0].ReceivedAt; got == nil || got.Before(before) {
+		t.Errorf("received_at = %v, want auditd's receive time (not the client's %v)", got, forged)
+	}
+	if !audit.VerifyEventHash(&events[0]) {
+		t.Error("stored event does not match its hash")
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleClockSkew(w, httptest.NewRequest(http.MethodGet, "/v1/events/clock-skew?threshold=30s", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("clock-skew status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var stats audit.ClockSkewStats
+	json.NewDecoder(w.Body).Decode(&stats) //nolint:errcheck
+	if len(stats.Agents) != 1 || stats.Agents[0].Agent != "db_agent" || !stats.Agents[0].Exceeded {
+		t.Fatalf("agents = %+v, want db_agent flagged", stats.Agents)
+	}
+	if ms := stats.Agents[0].MedianMs; ms > -119000 || ms < -125000 {
+		t.Errorf("median skew = %dms, want about -120000", ms)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleClockSkew(w, httptest.NewRequest(http.MethodGet, "/v1/events/clock-skew?threshold=soon", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad threshold status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// compactionServer reclaims free pages in the SQLite audit database and
+// reports its size. Audit events themselves are never deleted (the hash chain
+// depends on them), but the other tables are pruned and leave free pages
+// behind that SQLite keeps in the file until it is vacuumed.
+type compactionServer struct {
+	store *audit.Store
+
+	// minFreeRatio is the fragmentation ratio (free bytes / file size) at
+	// which a scheduled run compacts; below it the run is skipped.
+	minFreeRatio float64
+	// maxPages bounds the pages one incremental_vacuum releases (0 = all).
+	maxPages int
+
+	mu        sync.Mutex
+	runs      int64
+	reclaimed int64
+	last      *audit.CompactionResult
+}
+
+// startCompactionJob checks fragmentation every interval until ctx is
+// cancelled and compacts when it reaches minFreeRatio.
+func (s *compactionServer) startCompactionJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runScheduled(ctx)
+		}
+	}
+}
+
+// runScheduled compacts when the database is fragmented enough to be worth it.
+func (s *compactionServer) runScheduled(ctx context.Context) {
+	st, err := s.store.StorageStats(ctx)
+	if err != nil {
+		slog.Error("compaction job: failed to read storage stats", "err", err)
+		return
+	}
+	if st.Backend != "sqlite" || st.FragmentationRatio < s.minFreeRatio {
+		slog.Debug("compaction job: skipped", "fragmentation_ratio", st.FragmentationRatio, "min", s.minFreeRatio)
+		return
+	}
+	if _, err := s.compact(ctx, s.maxPages); err != nil {
+		slog.Error("compaction job: failed to compact", "err", err)
+	}
+}
+
+// compact runs one compaction and records its result.
+func (s *compactionServer) compact(ctx context.Context, maxPages int) (audit.CompactionResult, error) {
+	res, err := s.store.Compact(ctx, maxPages)
+	if err != nil {
+		return res, err
+	}
+	s.mu.Lock()
+	s.runs++
+	if res.ReclaimedBytes > 0 {
+		s.reclaimed += res.ReclaimedBytes
+	}
+	s.last = &res
+	s.mu.Unlock()
+	slog.Info("audit database compacted",
+		"method", res.Method,
+		"before_bytes", res.BeforeBytes,
+		"after_bytes", res.AfterBytes,
+		"reclaimed_bytes", res.ReclaimedBytes,
+		"duration", res.Duration)
+	return res, nil
+}
+
+// handleStorage returns database size and fragmentation, and the most recent
+// compaction run.
+// GET /v1/storage
+func (s *compactionServer) handleStorage(w http.ResponseWriter, r *http.Request) {
+	st, err := s.store.StorageStats(r.Context())
+	if err != nil {
+		slog.Error("failed to read storage stats", "err", err)
+		http.Error(w, "failed to read storage stats", http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	resp := struct {
+		audit.StorageStats
+		Compactions    int64                   `json:"compactions"`
+		ReclaimedBytes int64                   `json:"reclaimed_bytes_total"`
+		LastCompaction *audit.CompactionResult `json:"last_compaction,omitempty"`
+	}{st, s.runs, s.reclaimed, s.last}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+// handleCompact compacts the database now, regardless of fragmentation.
+// POST /v1/storage/compact?max_pages=<n>
+func (s *compactionServer) handleCompact(w http.ResponseWriter, r *http.Request) {
+	maxPages := s.maxPages
+	if v := r.URL.Query().Get("max_pages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid max_pages: expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		maxPages = n
+	}
+	res, err := s.compact(r.Context(), maxPages)
+	if err != nil {
+		slog.Error("failed to compact audit database", "err", err)
+		http.Error(w, "failed to compact audit database", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res) //nolint:errcheck
+}
+
+// handleMetrics exposes storage metrics in the Prometheus text format.
+// GET /metrics
+func (s *compactionServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	st, err := s.store.StorageStats(r.Context())
+	if err != nil {
+		slog.Error("failed to read storage stats", "err", err)
+		http.Error(w, "failed to read storage stats", http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	runs, reclaimed := s.runs, s.reclaimed
+	var lastAt float64
+	if s.last != nil {
+		lastAt = float64(s.last.At.UnixNano()) / 1e9
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	gauge := func(name, help string, value any) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		_, _ = fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		_, _ = fmt.Fprintf(w, "%s{backend=%q} %v\n\n", name, st.Backend, value)
+	}
+	counter := func(name, help string, value int64) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		_, _ = fmt.Fprintf(w, "# TYPE %s counter\n", name)
+		_, _ = fmt.Fprintf(w, "%s %d\n\n", name, value)
+	}
+	gauge("auditd_db_size_bytes", "Size of the audit database", st.SizeBytes)
+	gauge("auditd_db_free_bytes", "Bytes held by free pages in the SQLite audit database", st.FreeBytes)
+	gauge("auditd_db_fragmentation_ratio", "Free bytes as a fraction of the audit database size", st.FragmentationRatio)
+	gauge("auditd_db_wal_size_bytes", "Size of the SQLite write-ahead log", st.WALSizeBytes)
+	counter("auditd_compactions_total", "Compaction runs since startup", runs)
+	counter("auditd_compaction_reclaimed_bytes_total", "Bytes returned to the filesystem by compaction since startup", reclaimed)
+	_, _ = fmt.Fprintf(w, "# HELP auditd_last_compaction_timestamp_seconds Unix time of the last compaction (0 if none)\n")
+	_, _ = fmt.Fprintf(w, "# TYPE auditd_last_compaction_timestamp_seconds gauge\n")
+	_, _ = fmt.Fprintf(w, "auditd_last_compaction_timestamp_seconds %v\n", lastAt)
+}
@@ -0,0 +1,110 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func newCompactionServer(t *testing.T, minFreeRatio float64) *compactionServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return &compactionServer{store: store, minFreeRatio: minFreeRatio}
+}
+
+// leaveFreePages fills and empties a scratch table, as retention pruning does.
+func leaveFreePages(t *testing.T, store *audit.Store) {
+	t.Helper()
+	db := store.DB()
+	if _, err := db.Exec("CREATE TABLE scratch (v TEXT)"); err != nil {
+		t.Fatalf("create scratch: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := db.Exec("INSERT INTO scratch (v) VALUES (?)", strings.Repeat("x", 4000)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM scratch"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestCompactionHandlers(t *testing.T) {
+	srv := newCompactionServer(t, 0.2)
+	leaveFreePages(t, srv.store)
+
+	w := httptest.NewRecorder()
+	srv.handleStorage(w, httptest.NewRequest(http.MethodGet, "/v1/storage", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var stats struct {
+		Backend            string  `json:"backend"`
+		FreeBytes          int64   `json:"free_bytes"`
+		FragmentationRatio float64 `json:"fragmentation_ratio"`
+		Compactions        int64   `json:"compactions"`
+	}
+	json.NewDecoder(w.Body).Decode(&stats) //nolint:errcheck
+	if stats.Backend != "sqlite" || stats.FreeBytes == 0 || stats.Compactions != 0 {
+		t.Errorf("storage = %+v, want sqlite with free pages and no compactions", stats)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleCompact(w, httptest.NewRequest(http.MethodPost, "/v1/storage/compact?max_pages=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("negative max_pages: status = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleCompact(w, httptest.NewRequest(http.MethodPost, "/v1/storage/compact", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("compact status = %d: %s", w.Code, w.Body.String())
+	}
+	var res audit.CompactionResult
+	json.NewDecoder(w.Body).Decode(&res) //nolint:errcheck
+	if res.Method != "vacuum" || res.ReclaimedBytes <= 0 {
+		t.Errorf("result = %+v, want vacuum with bytes reclaimed", res)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleMetrics(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`auditd_db_size_bytes{backend="sqlite"} `,
+		`auditd_db_free_bytes{backend="sqlite"} 0`,
+		"auditd_compactions_total 1",
+		"# TYPE auditd_compaction_reclaimed_bytes_total counter",
+		"auditd_last_compaction_timestamp_seconds ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestCompactionJob_SkipsBelowThreshold(t *testing.T) {
+	ctx := context.Background()
+
+	srv := newCompactionServer(t, 0.99)
+	leaveFreePages(t, srv.store)
+	srv.runScheduled(ctx)
+	if srv.runs != 0 {
+		t.Errorf("runs = %d, want 0 below the free-page threshold", srv.runs)
+	}
+
+	srv.minFreeRatio = 0.2
+	srv.runScheduled(ctx)
+	if srv.runs != 1 || srv.last == nil || srv.last.ReclaimedBytes <= 0 {
+		t.Errorf("runs = %d, last = %+v; want one compaction that reclaimed space", srv.runs, srv.last)
+	}
+}
@@ -0,0 +1,221 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// configServer owns the runtime configuration: the settings in
+// audit.RuntimeSettings, which PUT /v1/config changes without a restart.
+// Overrides are persisted in the RuntimeConfigStore and take precedence over
+// flags, so a change made in a Kubernetes deployment survives pod restarts.
+// Settings auditd applies itself take effect immediately; the auditor picks
+// up its settings on its next sync of GET /v1/config.
+type configServer struct {
+	store      *audit.RuntimeConfigStore
+	auditStore *audit.Store
+
+	// startup holds the flag values of the settings auditd applies, and
+	// apply puts a value of such a setting into effect.
+	startup map[string]string
+	apply   map[string]func(value string)
+
+	mu        sync.Mutex // serializes changes
+	overrides map[string]audit.RuntimeConfigEntry
+}
+
+// configSetting is one setting in GET /v1/config.
+type configSetting struct {
+	audit.RuntimeSetting
+	// Value is the value in effect. It is empty for an auditor setting
+	// without an override: the auditor then uses its own flag.
+	Value     string     `json:"value"`
+	Source    string     `json:"source"` // "runtime" (an override) or "startup" (flags)
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// newConfigServer loads the persisted overrides and applies those of the
+// settings auditd owns.
+func newConfigServer(store *audit.RuntimeConfigStore, auditStore *audit.Store, startup map[string]string, apply map[string]func(string)) (*configServer, error) {
+	overrides, err := store.All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	s := &configServer{store: store, auditStore: auditStore, startup: startup, apply: apply, overrides: overrides}
+	for key, e := range overrides {
+		if fn := apply[key]; fn != nil {
+			fn(e.Value)
+		}
+		setting, _ := audit.LookupRuntimeSetting(key)
+		slog.Info("runtime config override in effect", "key", key, "value", setting.Display(e.Value),
+			"by", e.UpdatedBy, "since", e.UpdatedAt)
+	}
+	return s, nil
+}
+
+// settings returns every runtime setting with its value in effect. Callers
+// hold s.mu.
+func (s *configServer) settings() []configSetting {
+	out := make([]configSetting, 0, len(audit.RuntimeSettings))
+	for _, rs := range audit.RuntimeSettings {
+		cs := configSetting{RuntimeSetting: rs, Value: rs.Display(s.startup[rs.Key]), Source: "startup"}
+		if e, ok := s.overrides[rs.Key]; ok {
+			at := e.UpdatedAt
+			cs.Value, cs.Source, cs.UpdatedBy, cs.UpdatedAt = rs.Display(e.Value), "runtime", e.UpdatedBy, &at
+		}
+		out = append(out, cs)
+	}
+	return out
+}
+
+// effective returns the raw value of key in effect. Callers hold s.mu.
+func (s *configServer) effective(key string) string {
+	if e, ok := s.overrides[key]; ok {
+		return e.Value
+	}
+	return s.startup[key]
+}
+
+// handleGet returns the runtime settings.
+// GET /v1/config
+func (s *configServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	settings := s.settings()
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"settings": settings}) //nolint:errcheck
+}
+
+// handleUpdate changes runtime settings. A null value removes the override,
+// returning the setting to its startup value. All settings are validated
+// before any is changed, and the change is recorded as one config_change
+// event.
+// PUT /v1/config {"settings": {"auditor.max_events_per_minute": "500"}, "reason": "..."}
+func (s *configServer) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Settings map[string]*string `json:"settings"`
+		Reason   string             `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Settings) == 0 {
+		writeJSONError(w, "settings is required", http.StatusBadRequest)
+		return
+	}
+	principal := authz.PrincipalFromContext(r.Context())
+	if principal.Tenant != "" {
+		writeJSONError(w, "runtime configuration is fleet-wide; tenant-scoped callers cannot change it", http.StatusForbidden)
+		return
+	}
+	actor := principal.EffectiveID()
+	if actor == "" {
+		actor = "anonymous"
+	}
+
+	keys := make([]string, 0, len(req.Settings))
+	values := map[string]string{}
+	for key, v := range req.Settings {
+		setting, ok := audit.LookupRuntimeSetting(key)
+		if !ok {
+			writeJSONError(w, "unknown runtime setting "+key, http.StatusBadRequest)
+			return
+		}
+		if v != nil {
+			norm, err := setting.Normalize(*v)
+			if err != nil {
+				writeJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			values[key] = norm
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	changes := []audit.SettingChange{}
+	var persistErr error
+	for _, key := range keys {
+		setting, _ := audit.LookupRuntimeSetting(key)
+		old := s.effective(key)
+		_, overridden := s.overrides[key]
+		value, set := values[key]
+		switch {
+		case set && overridden && old == value, !set && !overridden:
+			continue // nothing to change
+		case set:
+			e := audit.RuntimeConfigEntry{Key: key, Value: value, UpdatedBy: actor, UpdatedAt: now}
+			if persistErr = s.store.Set(r.Context(), e); persistErr != nil {
+				break
+			}
+			s.overrides[key] = e
+		default:
+			if persistErr = s.store.Delete(r.Context(), key); persistErr != nil {
+				break
+			}
+			delete(s.overrides, key)
+			value = s.startup[key]
+		}
+		if persistErr != nil {
+			break
+		}
+		if fn := s.apply[key]; fn != nil {
+			fn(value)
+		}
+		changes = append(changes, audit.SettingChange{
+			Key:   key,
+			Old:   setting.Display(old),
+			New:   setting.Display(value),
+			Reset: !set,
+		})
+		slog.Info("runtime config changed", "key", key, "old", setting.Display(old), "new", setting.Display(value),
+			"reset", !set, "by", actor)
+	}
+
+	// Settings changed before a failure stay changed, so they are recorded
+	// either way.
+	if len(changes) > 0 {
+		event := &audit.Event{
+			EventID:      "cfg_" + uuid.New().String()[:8],
+			Timestamp:    now,
+			EventType:    audit.EventTypeConfigChange,
+			TraceID:      r.Header.Get("X-Trace-ID"),
+			ActionClass:  audit.ActionWrite,
+			Session:      audit.Session{ID: "runtime_config", UserID: actor},
+			Input:        audit.Input{UserQuery: req.Reason},
+			Outcome:      &audit.Outcome{Status: "success"},
+			ConfigChange: &audit.ConfigChange{Changes: changes},
+		}
+		if event.TraceID == "" {
+			event.TraceID = audit.NewTraceIDWithPrefix("cfg_")
+		}
+		if err := s.auditStore.Record(r.Context(), event); err != nil {
+			slog.Error("failed to record config change event", "event_id", event.EventID, "err", err)
+		}
+	}
+
+	if persistErr != nil {
+		writeJSONError(w, "failed to persist runtime config: "+persistErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+		"settings": s.settings(),
+		"changes":  changes,
+	})
+}
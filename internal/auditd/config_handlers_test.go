@@ -0,0 +1,102 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func TestConfigServer(t *testing.T) {
+	store := newTestAuditStore(t)
+	rc, err := audit.NewRuntimeConfigStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewRuntimeConfigStore: %v", err)
+	}
+	notifier := NewApprovalNotifier(ApprovalNotifierConfig{WebhookURL: "https://hooks.example.com/startup"})
+	newServer := func() *configServer {
+		s, err := newConfigServer(rc, store,
+			map[string]string{"auditd.approval_webhook_url": "https://hooks.example.com/startup"},
+			map[string]func(string){"auditd.approval_webhook_url": notifier.SetWebhookURL})
+		if err != nil {
+			t.Fatalf("newConfigServer: %v", err)
+		}
+		return s
+	}
+	srv := newServer()
+	put := func(body string, p identity.ResolvedPrincipal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/v1/config", strings.NewReader(body))
+		req = req.WithContext(authz.WithPrincipal(req.Context(), p))
+		w := httptest.NewRecorder()
+		srv.handleUpdate(w, req)
+		return w
+	}
+	sec := identity.ResolvedPrincipal{UserID: "sec", Roles: []string{"security"}, AuthMethod: "api_key"}
+
+	for body, want := range map[string]int{
+		`{"settings":{"no.such_setting":"1"}}`:             http.StatusBadRequest,
+		`{"settings":{"auditor.injection_threshold":"2"}}`: http.StatusBadRequest,
+		`{"settings":{}}`: http.StatusBadRequest,
+		`not json`:        http.StatusBadRequest,
+	} {
+		if w := put(body, sec); w.Code != want {
+			t.Errorf("%s: status = %d, want %d", body, w.Code, want)
+		}
+	}
+	tenanted := sec
+	tenanted.Tenant = "acme"
+	if w := put(`{"settings":{"auditor.max_events_per_minute":"10"}}`, tenanted); w.Code != http.StatusForbidden {
+		t.Errorf("tenant-scoped caller: status = %d, want 403", w.Code)
+	}
+
+	w := put(`{"settings":{"auditor.max_events_per_minute":"600","auditd.approval_webhook_url":"https://hooks.slack.com/services/T/B/secret"},"reason":"batch job"}`, sec)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if got := notifier.webhook(); got != "https://hooks.slack.com/services/T/B/secret" {
+		t.Errorf("notifier webhook = %q", got)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("response shows the webhook URL: %s", w.Body)
+	}
+
+	events, _ := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeConfigChange})
+	if len(events) != 1 || events[0].ConfigChange == nil || len(events[0].ConfigChange.Changes) != 2 {
+		t.Fatalf("config_change events = %+v", events)
+	}
+	ev := events[0]
+	if ev.Session.UserID != "sec" || ev.Input.UserQuery != "batch job" || strings.Contains(ev.String(), "secret") {
+		t.Errorf("event = %s", ev.String())
+	}
+
+	// Overrides survive a restart; a null value resets to the startup value.
+	srv = newServer()
+	var got struct {
+		Settings []configSetting       `json:"settings"`
+		Changes  []audit.SettingChange `json:"changes"`
+	}
+	w = put(`{"settings":{"auditd.approval_webhook_url":null}}`, sec)
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("reset: status %d, %v", w.Code, err)
+	}
+	if len(got.Changes) != 1 || !got.Changes[0].Reset || notifier.webhook() != "https://hooks.example.com/startup" {
+		t.Errorf("reset: changes = %+v, webhook = %q", got.Changes, notifier.webhook())
+	}
+	for _, s := range got.Settings {
+		if s.Key == "auditor.max_events_per_minute" && (s.Value != "600" || s.Source != "runtime" || s.UpdatedBy != "sec") {
+			t.Errorf("after restart: %+v", s)
+		}
+	}
+
+	// Repeating a change changes nothing and records nothing.
+	put(`{"settings":{"auditor.max_events_per_minute":"600"}}`, sec)
+	if events, _ := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeConfigChange}); len(events) != 2 {
+		t.Errorf("config_change events = %d, want 2", len(events))
+	}
+}
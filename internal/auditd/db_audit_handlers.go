@@ -0,0 +1,168 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// maxDBAuditLogBytes bounds one ingested log segment.
+const maxDBAuditLogBytes = 32 << 20
+
+// dbAuditServer ingests server logs from monitored databases and flags
+// changes made with the agent's credentials that no tool call accounts for.
+type dbAuditServer struct {
+	auditStore *audit.Store
+	agentName  string // agent whose tool_execution events account for changes
+	opts       audit.DBAuditCorrelation
+}
+
+// dbAuditFinding is one out-of-band change in the ingest response.
+type dbAuditFinding struct {
+	EventID string `json:"event_id"`
+	audit.OutOfBandChange
+}
+
+// handleIngest handles POST /v1/db-audit/logs?format=csvlog|jsonlog. The body
+// is a segment of a database's csvlog or jsonlog output with pgaudit or
+// log_statement enabled. Every change by an agent user is matched against the
+// agent's tool_execution events, and each unmatched change is recorded as an
+// out_of_band_change event. Re-ingesting an overlapping segment does not
+// record a change twice.
+func (s *dbAuditServer) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if len(s.opts.AgentUsers) == 0 {
+		writeJSONError(w, "database log correlation is not configured (set -db-audit-users)", http.StatusServiceUnavailable)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = audit.DBLogFormatCSV
+	}
+	entries, err := audit.ParseDBAuditLog(http.MaxBytesReader(w, r.Body, maxDBAuditLogBytes), format)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var earliest time.Time
+	for _, e := range entries {
+		if earliest.IsZero() || e.LoggedAt.Before(earliest) {
+			earliest = e.LoggedAt
+		}
+	}
+	var changes []audit.OutOfBandChange
+	if len(entries) > 0 {
+		tools, err := s.auditStore.Query(r.Context(), audit.QueryOptions{
+			EventType: audit.EventTypeToolExecution,
+			Agent:     s.agentName,
+			Since:     earliest.Add(-s.opts.Tolerance),
+		})
+		if err != nil {
+			slog.Error("failed to query tool executions", "err", err)
+			writeJSONError(w, "failed to query tool executions", http.StatusInternalServerError)
+			return
+		}
+		changes = audit.CorrelateDBAudit(entries, tools, s.opts)
+	}
+
+	seen, err := s.recordedChanges(r.Context(), earliest)
+	if err != nil {
+		slog.Error("failed to query out-of-band changes", "err", err)
+		writeJSONError(w, "failed to query out-of-band changes", http.StatusInternalServerError)
+		return
+	}
+	findings := []dbAuditFinding{}
+	for _, c := range changes {
+		key := changeKey(c)
+		if id, ok := seen[key]; ok {
+			findings = append(findings, dbAuditFinding{EventID: id, OutOfBandChange: c})
+			continue
+		}
+		event := &audit.Event{
+			EventID:         "oob_" + uuid.New().String()[:8],
+			Timestamp:       time.Now().UTC(),
+			EventType:       audit.EventTypeOutOfBandChange,
+			TraceID:         audit.NewTraceIDWithPrefix("oob_"),
+			ActionClass:     changeActionClass(c),
+			Session:         audit.Session{ID: "db_audit", UserID: c.User},
+			OutOfBandChange: &c,
+		}
+		if err := s.auditStore.Record(r.Context(), event); err != nil {
+			slog.Error("failed to record out-of-band change", "err", err)
+			writeJSONError(w, "failed to record out-of-band change", http.StatusInternalServerError)
+			return
+		}
+		seen[key] = event.EventID
+		slog.Error("OUT-OF-BAND DATABASE CHANGE: agent credentials used outside the agent",
+			"event_id", event.EventID,
+			"database", c.Database,
+			"user", c.User,
+			"application_name", c.ApplicationName,
+			"command", c.Command,
+			"logged_at", c.LoggedAt,
+			"reason", c.Reason)
+		findings = append(findings, dbAuditFinding{EventID: event.EventID, OutOfBandChange: c})
+	}
+
+	agentChanges := 0
+	users := make(map[string]bool, len(s.opts.AgentUsers))
+	for _, u := range s.opts.AgentUsers {
+		users[u] = true
+	}
+	for _, e := range entries {
+		if users[e.User] && e.Mutates() {
+			agentChanges++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+		"entries":       len(entries),
+		"agent_changes": agentChanges,
+		"out_of_band":   findings,
+	})
+}
+
+// recordedChanges returns the out-of-band changes already recorded for
+// statements logged at or after since, keyed by changeKey.
+func (s *dbAuditServer) recordedChanges(ctx context.Context, since time.Time) (map[string]string, error) {
+	seen := make(map[string]string)
+	if since.IsZero() {
+		return seen, nil
+	}
+	// Events are recorded after the statements they describe, so every
+	// earlier finding for this segment was recorded at or after since.
+	events, err := s.auditStore.Query(ctx, audit.QueryOptions{
+		EventType: audit.EventTypeOutOfBandChange,
+		Since:     since,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if e.OutOfBandChange != nil {
+			seen[changeKey(*e.OutOfBandChange)] = e.EventID
+		}
+	}
+	return seen, nil
+}
+
+func changeKey(c audit.OutOfBandChange) string {
+	return c.LoggedAt.UTC().Format(time.RFC3339Nano) + "\x00" + c.Database + "\x00" + c.User + "\x00" + c.Statement
+}
+
+// changeActionClass rates dropping or truncating as destructive and every
+// other change as a write, in line with the agents' own tool classes.
+func changeActionClass(c audit.OutOfBandChange) audit.ActionClass {
+	if strings.HasPrefix(c.Command, "DROP") || strings.HasPrefix(c.Command, "TRUNCATE") {
+		return audit.ActionDestructive
+	}
+	return audit.ActionWrite
+}
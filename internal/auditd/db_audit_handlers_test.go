@@ -0,0 +1,105 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// jsonLogLine builds a jsonlog line for a statement run as helpdesk_agent on prod.
+func jsonLogLine(at time.Time, app, statement string) string {
+	b, _ := json.Marshal(map[string]string{
+		"timestamp":        at.UTC().Format("2006-01-02 15:04:05.000 MST"),
+		"user":             "helpdesk_agent",
+		"dbname":           "prod",
+		"application_name": app,
+		"message":          "statement: " + statement,
+	})
+	return string(b)
+}
+
+func postDBLog(srv *dbAuditServer, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/db-audit/logs?format=jsonlog", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.handleIngest(w, req)
+	return w
+}
+
+func TestHandleDBAuditIngest_FlagsOutOfBandChanges(t *testing.T) {
+	store := newTestAuditStore(t)
+	ctx := context.Background()
+	toolAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	if err := store.Record(ctx, &audit.Event{
+		EventID:   "tool_1",
+		Timestamp: toolAt,
+		EventType: audit.EventTypeToolExecution,
+		Tool: &audit.ToolExecution{Name: "cancel_query", Agent: "postgres_database_agent",
+			Parameters: map[string]any{"connection_string": "host=db1 dbname=prod user=helpdesk_agent"}},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	srv := &dbAuditServer{
+		auditStore: store,
+		agentName:  "postgres_database_agent",
+		opts:       audit.DBAuditCorrelation{AgentUsers: []string{"helpdesk_agent"}, Tolerance: 30 * time.Second},
+	}
+	body := strings.Join([]string{
+		jsonLogLine(toolAt.Add(2*time.Second), "helpdesk_database_agent", "SELECT pg_cancel_backend(4242)"),
+		jsonLogLine(toolAt.Add(3*time.Second), "helpdesk_database_agent", "UPDATE orders SET status = 'void'"),
+		jsonLogLine(toolAt.Add(20*time.Minute), "psql", "DROP TABLE orders"),
+	}, "\n")
+
+	w := postDBLog(srv, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Entries      int              `json:"entries"`
+		AgentChanges int              `json:"agent_changes"`
+		OutOfBand    []dbAuditFinding `json:"out_of_band"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+	if resp.Entries != 3 || resp.AgentChanges != 2 || len(resp.OutOfBand) != 1 {
+		t.Fatalf("resp = %+v, want 3 entries, 2 agent changes, 1 out of band", resp)
+	}
+	finding := resp.OutOfBand[0]
+	if finding.Command != "DROP" || !strings.HasPrefix(finding.EventID, "oob_") {
+		t.Errorf("finding = %+v", finding)
+	}
+
+	events, err := store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeOutOfBandChange})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 1 || events[0].ActionClass != audit.ActionDestructive || events[0].OutOfBandChange.Statement != "DROP TABLE orders" {
+		t.Fatalf("recorded events = %+v", events)
+	}
+
+	// Re-ingesting the same segment reports the change without recording it again.
+	w = postDBLog(srv, body)
+	json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+	if len(resp.OutOfBand) != 1 || resp.OutOfBand[0].EventID != finding.EventID {
+		t.Errorf("re-ingest findings = %+v, want the original event", resp.OutOfBand)
+	}
+	events, _ = store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeOutOfBandChange})
+	if len(events) != 1 {
+		t.Errorf("re-ingest recorded %d events, want 1", len(events))
+	}
+}
+
+func TestHandleDBAuditIngest_Errors(t *testing.T) {
+	srv := &dbAuditServer{auditStore: newTestAuditStore(t)}
+	if w := postDBLog(srv, ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unconfigured: status = %d, want 503", w.Code)
+	}
+	srv.opts.AgentUsers = []string{"helpdesk_agent"}
+	if w := postDBLog(srv, "not json"); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed log: status = %d, want 400", w.Code)
+	}
+}
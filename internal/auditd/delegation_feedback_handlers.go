@@ -0,0 +1,133 @@
+package auditd
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// delegationFeedbackServer handles requesters' verdicts on whether a
+// delegation solved their problem, kept outside the hash chain.
+type delegationFeedbackServer struct {
+	store  *audit.DelegationFeedbackStore
+	events *audit.Store
+}
+
+// handleSubmit records whether a delegation resolved the requester's problem,
+// replacing earlier feedback on the same event.
+// POST /v1/events/{eventID}/feedback {"resolved": true, "comment": "...", "source": "srebot"}
+func (s *delegationFeedbackServer) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Resolved *bool  `json:"resolved"`
+		Comment  string `json:"comment"`
+		Source   string `json:"source"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Resolved == nil {
+		http.Error(w, "resolved is required", http.StatusBadRequest)
+		return
+	}
+
+	eventID := r.PathValue("eventID")
+	events, err := s.events.Query(r.Context(), audit.QueryOptions{EventID: eventID, Limit: 1})
+	if err != nil {
+		slog.Error("failed to query event", "event_id", eventID, "err", err)
+		http.Error(w, "failed to query event", http.StatusInternalServerError)
+		return
+	}
+	if len(events) == 0 || !inTenant(r, events[0].Session.TenantID) {
+		http.Error(w, "event not found", http.StatusNotFound)
+		return
+	}
+	ev := events[0]
+	if !isDelegation(ev) {
+		http.Error(w, "feedback can only be given on delegation_decision or gateway_request events", http.StatusBadRequest)
+		return
+	}
+
+	fb := &audit.DelegationFeedback{
+		EventID:     eventID,
+		TraceID:     ev.TraceID,
+		Agent:       ev.Decision.Agent,
+		Resolved:    *req.Resolved,
+		Comment:     req.Comment,
+		Source:      req.Source,
+		SubmittedBy: authz.PrincipalFromContext(r.Context()).EffectiveID(),
+		TenantID:    ev.Session.TenantID,
+	}
+	if err := s.store.Submit(r.Context(), fb); err != nil {
+		slog.Error("failed to record delegation feedback", "err", err)
+		http.Error(w, "failed to record delegation feedback", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("delegation feedback recorded", "event_id", fb.EventID, "agent", fb.Agent, "resolved", fb.Resolved, "by", fb.SubmittedBy)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fb) //nolint:errcheck
+}
+
+// handleGet returns the feedback on an event.
+// GET /v1/events/{eventID}/feedback
+func (s *delegationFeedbackServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	fb, err := s.store.Get(r.Context(), r.PathValue("eventID"))
+	if err != nil {
+		slog.Error("failed to get delegation feedback", "err", err)
+		http.Error(w, "failed to get delegation feedback", http.StatusInternalServerError)
+		return
+	}
+	if fb == nil || !inTenant(r, fb.TenantID) {
+		http.Error(w, "no feedback for this event", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fb) //nolint:errcheck
+}
+
+// handleStats returns per-agent resolution rates for a window.
+// GET /v1/events/feedback/stats?since=<RFC3339>&until=<RFC3339>
+func (s *delegationFeedbackServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	var until time.Time
+	for param, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+	agents, err := s.store.StatsByAgent(r.Context(), since, until, tenantScope(r))
+	if err != nil {
+		slog.Error("failed to compute delegation feedback stats", "err", err)
+		http.Error(w, "failed to compute delegation feedback stats", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"agents": agents}) //nolint:errcheck
+}
+
+// isDelegation reports whether ev handed a request to an agent: an
+// orchestrator or gateway routing decision, or a gateway request to a named
+// agent.
+func isDelegation(ev audit.Event) bool {
+	if ev.Decision == nil || ev.Decision.Agent == "" {
+		return false
+	}
+	return ev.EventType == audit.EventTypeDelegation || ev.EventType == audit.EventTypeGatewayRequest
+}
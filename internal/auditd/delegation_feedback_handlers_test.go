@@ -0,0 +1,115 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+// newDelegationFeedbackServer returns a delegationFeedbackServer over a fresh
+// temp-dir SQLite store holding two delegations and a tool execution.
+func newDelegationFeedbackServer(t *testing.T) *delegationFeedbackServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	for _, ev := range []*audit.Event{
+		{EventID: "evt_1", TraceID: "tr_1", EventType: audit.EventTypeDelegation, Decision: &audit.Decision{Agent: "postgres_database_agent"}},
+		{EventID: "gw_1", TraceID: "tr_2", EventType: audit.EventTypeGatewayRequest, Decision: &audit.Decision{Agent: "k8s_agent"}},
+		{EventID: "tool_1", TraceID: "tr_1", EventType: audit.EventTypeToolExecution},
+	} {
+		ev.Timestamp = time.Now().UTC()
+		ev.Session = audit.Session{ID: "sess_1", TenantID: "payments"}
+		if err := store.Record(context.Background(), ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	fs, err := audit.NewDelegationFeedbackStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewDelegationFeedbackStore: %v", err)
+	}
+	return &delegationFeedbackServer{store: fs, events: store}
+}
+
+func postDelegationFeedback(t *testing.T, srv *delegationFeedbackServer, eventID string, principal identity.ResolvedPrincipal, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/v1/events/"+eventID+"/feedback", bytes.NewReader(data))
+	req.SetPathValue("eventID", eventID)
+	req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+	srv.handleSubmit(w, req)
+	return w
+}
+
+func TestDelegationFeedbackHandlers(t *testing.T) {
+	srv := newDelegationFeedbackServer(t)
+	alice := identity.ResolvedPrincipal{UserID: "alice", Tenant: "payments", AuthMethod: "api_key"}
+	srebot := identity.ResolvedPrincipal{Service: "srebot", Tenant: "payments", AuthMethod: "api_key"}
+
+	w := postDelegationFeedback(t, srv, "evt_1", alice, map[string]any{"resolved": false, "comment": "still slow"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var fb audit.DelegationFeedback
+	json.NewDecoder(w.Body).Decode(&fb) //nolint:errcheck
+	if fb.Agent != "postgres_database_agent" || fb.TraceID != "tr_1" || fb.SubmittedBy != "alice" || fb.Source != "user" || fb.Resolved {
+		t.Errorf("feedback = %+v, want alice's not-resolved verdict on postgres_database_agent", fb)
+	}
+	if w := postDelegationFeedback(t, srv, "gw_1", srebot, map[string]any{"resolved": true, "source": "srebot"}); w.Code != http.StatusOK {
+		t.Errorf("gateway request: status = %d, want 200", w.Code)
+	}
+
+	for name, tc := range map[string]struct {
+		eventID   string
+		principal identity.ResolvedPrincipal
+		body      map[string]any
+		want      int
+	}{
+		"missing resolved": {"evt_1", alice, map[string]any{"comment": "x"}, http.StatusBadRequest},
+		"not a delegation": {"tool_1", alice, map[string]any{"resolved": true}, http.StatusBadRequest},
+		"unknown event":    {"evt_missing", alice, map[string]any{"resolved": true}, http.StatusNotFound},
+		"other tenant":     {"evt_1", identity.ResolvedPrincipal{UserID: "bob", Tenant: "search"}, map[string]any{"resolved": true}, http.StatusNotFound},
+	} {
+		if w := postDelegationFeedback(t, srv, tc.eventID, tc.principal, tc.body); w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, tc.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events/evt_1/feedback", nil)
+	req.SetPathValue("eventID", "evt_1")
+	w = httptest.NewRecorder()
+	srv.handleGet(w, req)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte("still slow")) {
+		t.Errorf("get: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleStats(w, httptest.NewRequest(http.MethodGet, "/v1/events/feedback/stats", nil))
+	var stats struct {
+		Agents []audit.AgentFeedbackStats `json:"agents"`
+	}
+	json.NewDecoder(w.Body).Decode(&stats) //nolint:errcheck
+	if len(stats.Agents) != 2 || stats.Agents[0].Agent != "k8s_agent" || stats.Agents[0].Resolved != 1 || stats.Agents[1].NotResolved != 1 {
+		t.Errorf("stats = %+v", stats.Agents)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleStats(w, httptest.NewRequest(http.MethodGet, "/v1/events/feedback/stats?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package auditd
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// eventAnnotationServer handles investigators' annotations on audit events:
+// notes, dispositions and linked alerts, kept outside the hash chain.
+type eventAnnotationServer struct {
+	store  *audit.EventAnnotationStore
+	events *audit.Store
+}
+
+// handleCreate attaches an annotation to an event. Annotations cannot be
+// edited or deleted; a later annotation supersedes an earlier disposition.
+// POST /v1/events/{eventID}/annotations {"disposition": "false_positive", "note": "...", "alert_ids": ["..."]}
+func (s *eventAnnotationServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var a audit.EventAnnotation
+	if err := json.Unmarshal(body, &a); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if a.Disposition != "" && !audit.ValidDisposition(a.Disposition) {
+		http.Error(w, "disposition must be one of false_positive, confirmed, benign, needs_review", http.StatusBadRequest)
+		return
+	}
+	if a.Disposition == "" && a.Note == "" && len(a.AlertIDs) == 0 {
+		http.Error(w, "disposition, note or alert_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	eventID := r.PathValue("eventID")
+	events, err := s.events.Query(r.Context(), audit.QueryOptions{EventID: eventID, Limit: 1})
+	if err != nil {
+		slog.Error("failed to query event", "event_id", eventID, "err", err)
+		http.Error(w, "failed to query event", http.StatusInternalServerError)
+		return
+	}
+	if len(events) == 0 || !inTenant(r, events[0].Session.TenantID) {
+		http.Error(w, "event not found", http.StatusNotFound)
+		return
+	}
+
+	principal := authz.PrincipalFromContext(r.Context())
+	a.AnnotationID = ""
+	a.EventID = eventID
+	a.CreatedBy = principal.EffectiveID()
+	a.TenantID = events[0].Session.TenantID
+	a.CreatedAt = time.Time{}
+	if err := s.store.Create(r.Context(), &a); err != nil {
+		slog.Error("failed to create event annotation", "err", err)
+		http.Error(w, "failed to create event annotation", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("event annotated", "event_id", a.EventID, "disposition", a.Disposition, "by", a.CreatedBy)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a) //nolint:errcheck
+}
+
+// handleList returns the annotations of an event, oldest first.
+// GET /v1/events/{eventID}/annotations
+func (s *eventAnnotationServer) handleList(w http.ResponseWriter, r *http.Request) {
+	all, err := s.store.List(r.Context(), r.PathValue("eventID"))
+	if err != nil {
+		slog.Error("failed to list event annotations", "err", err)
+		http.Error(w, "failed to list event annotations", http.StatusInternalServerError)
+		return
+	}
+	annotations := []audit.EventAnnotation{}
+	for _, a := range all {
+		if inTenant(r, a.TenantID) {
+			annotations = append(annotations, a)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"annotations": annotations, "count": len(annotations)}) //nolint:errcheck
+}
@@ -0,0 +1,125 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+// newEventAnnotationServer returns an eventAnnotationServer and an event
+// query server sharing a fresh temp-dir SQLite store with two events in it.
+func newEventAnnotationServer(t *testing.T) (*eventAnnotationServer, *server) {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	for _, id := range []string{"tool_1", "tool_2"} {
+		if err := store.Record(context.Background(), &audit.Event{
+			EventID:   id,
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeToolExecution,
+			Session:   audit.Session{ID: "sess_1", TenantID: "payments"},
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	as, err := audit.NewEventAnnotationStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewEventAnnotationStore: %v", err)
+	}
+	return &eventAnnotationServer{store: as, events: store}, &server{store: store, eventAnnotations: as}
+}
+
+func postEventAnnotation(t *testing.T, srv *eventAnnotationServer, eventID string, principal identity.ResolvedPrincipal, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/v1/events/"+eventID+"/annotations", bytes.NewReader(data))
+	req.SetPathValue("eventID", eventID)
+	req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+	srv.handleCreate(w, req)
+	return w
+}
+
+func TestEventAnnotationHandlers_CreateListQuery(t *testing.T) {
+	srv, events := newEventAnnotationServer(t)
+	alice := identity.ResolvedPrincipal{UserID: "alice", Tenant: "payments", AuthMethod: "api_key"}
+
+	w := postEventAnnotation(t, srv, "tool_1", alice, map[string]any{
+		"disposition": "false_positive",
+		"note":        "scheduled maintenance",
+		"alert_ids":   []string{"alert_42"},
+		// Server-assigned fields are ignored.
+		"event_id":   "tool_2",
+		"created_by": "mallory",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body: %s", w.Code, w.Body.String())
+	}
+	var created audit.EventAnnotation
+	json.NewDecoder(w.Body).Decode(&created) //nolint:errcheck
+	if created.EventID != "tool_1" || created.CreatedBy != "alice" || created.TenantID != "payments" {
+		t.Errorf("created = %+v, want event tool_1 by alice in payments", created)
+	}
+
+	for name, tc := range map[string]struct {
+		eventID string
+		body    map[string]any
+		want    int
+	}{
+		"unknown event":       {"tool_missing", map[string]any{"note": "x"}, http.StatusNotFound},
+		"invalid disposition": {"tool_1", map[string]any{"disposition": "maybe"}, http.StatusBadRequest},
+		"empty":               {"tool_1", map[string]any{}, http.StatusBadRequest},
+	} {
+		if w := postEventAnnotation(t, srv, tc.eventID, alice, tc.body); w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, tc.want)
+		}
+	}
+
+	// Another tenant cannot annotate the event.
+	bob := identity.ResolvedPrincipal{UserID: "bob", Tenant: "search", AuthMethod: "api_key"}
+	if w := postEventAnnotation(t, srv, "tool_1", bob, map[string]any{"disposition": "confirmed"}); w.Code != http.StatusNotFound {
+		t.Errorf("other tenant: status = %d, want 404", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events/tool_1/annotations", nil)
+	req.SetPathValue("eventID", "tool_1")
+	w = httptest.NewRecorder()
+	srv.handleList(w, req)
+	var list struct {
+		Annotations []audit.EventAnnotation `json:"annotations"`
+		Count       int                     `json:"count"`
+	}
+	json.NewDecoder(w.Body).Decode(&list) //nolint:errcheck
+	if list.Count != 1 || list.Annotations[0].Note != "scheduled maintenance" {
+		t.Errorf("list = %+v, want the one annotation", list)
+	}
+
+	for disposition, want := range map[string]int{"false_positive": 1, "confirmed": 0} {
+		w = httptest.NewRecorder()
+		events.handleQueryEvents(w, httptest.NewRequest(http.MethodGet, "/v1/events?disposition="+disposition, nil))
+		var got []audit.Event
+		json.NewDecoder(w.Body).Decode(&got) //nolint:errcheck
+		if len(got) != want || (want == 1 && got[0].EventID != "tool_1") {
+			t.Errorf("disposition=%s: got %d events, want %d", disposition, len(got), want)
+		}
+	}
+	w = httptest.NewRecorder()
+	events.handleQueryEvents(w, httptest.NewRequest(http.MethodGet, "/v1/events?disposition=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid disposition filter: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package auditd
+
+import (
+	"net/http"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// fieldAccess decides who reads encrypted event fields in clear. Callers
+// holding one of roles (or the admin role) get them decrypted; everyone else
+// gets audit.RedactedField in their place. A nil fieldAccess redacts.
+type fieldAccess struct {
+	store      *audit.Store
+	authorizer *authz.Authorizer
+	roles      []string
+}
+
+// allowed reports whether the caller of r may read encrypted fields.
+func (f *fieldAccess) allowed(r *http.Request) bool {
+	if f == nil {
+		return false
+	}
+	return f.authorizer.Require(authz.PrincipalFromContext(r.Context()), f.roles...) == nil
+}
+
+// events decrypts or redacts the encrypted fields of events for r's caller.
+func (f *fieldAccess) events(r *http.Request, events []audit.Event) {
+	if f.allowed(r) {
+		f.store.DecryptFields(r.Context(), events)
+		return
+	}
+	audit.RedactFields(events)
+}
+
+// journeys decrypts or redacts the user queries of journeys for r's caller.
+func (f *fieldAccess) journeys(r *http.Request, journeys []audit.JourneySummary) {
+	if f.allowed(r) {
+		f.store.DecryptJourneys(r.Context(), journeys)
+		return
+	}
+	audit.RedactJourneys(journeys)
+}
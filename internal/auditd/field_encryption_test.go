@@ -0,0 +1,63 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func TestHandleQueryEvents_EncryptedFields(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+		FieldEncryption: audit.FieldEncryption{
+			Fields: []string{"tool.raw_command"},
+			KEK:    audit.NewLocalKey([]byte("test-kek")),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Record(context.Background(), &audit.Event{
+		EventType: audit.EventTypeToolExecution,
+		Session:   audit.Session{ID: "sess_enc"},
+		Tool:      &audit.ToolExecution{Name: "run_sql", RawCommand: "SELECT card_number FROM payments"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	srv := &server{store: store, fields: &fieldAccess{
+		store:      store,
+		authorizer: authz.NewAuthorizer(authz.DefaultAuditdPermissions, true),
+		roles:      []string{"security"},
+	}}
+	query := func(roles ...string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/v1/events?session_id=sess_enc", nil)
+		req = req.WithContext(authz.WithPrincipal(req.Context(), identity.ResolvedPrincipal{UserID: "alice", Roles: roles, AuthMethod: "api_key"}))
+		w := httptest.NewRecorder()
+		srv.handleQueryEvents(w, req)
+		var events []audit.Event
+		if err := json.NewDecoder(w.Body).Decode(&events); err != nil || len(events) != 1 {
+			t.Fatalf("decode: %v (%d events)", err, len(events))
+		}
+		return events[0].Tool.RawCommand
+	}
+
+	if got := query("security"); got != "SELECT card_number FROM payments" {
+		t.Errorf("security caller: raw_command = %q, want plaintext", got)
+	}
+	if got := query("admin"); got != "SELECT card_number FROM payments" {
+		t.Errorf("admin caller: raw_command = %q, want plaintext", got)
+	}
+	if got := query("dba"); got != audit.RedactedField {
+		t.Errorf("dba caller: raw_command = %q, want %q", got, audit.RedactedField)
+	}
+}
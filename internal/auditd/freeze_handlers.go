@@ -0,0 +1,156 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/policy"
+)
+
+// freezeServer owns the fleet-wide emergency read-only switch. The state is
+// persisted in the FreezeStore and cached in memory so that every policy check
+// can consult it without a database round-trip.
+type freezeServer struct {
+	store      *audit.FreezeStore
+	auditStore *audit.Store
+	notifier   *ApprovalNotifier
+
+	mu    sync.RWMutex
+	state audit.FreezeState
+}
+
+// newFreezeServer loads the persisted freeze state. A freeze that was active
+// when auditd stopped is still active after restart.
+func newFreezeServer(store *audit.FreezeStore, auditStore *audit.Store, notifier *ApprovalNotifier) (*freezeServer, error) {
+	st, err := store.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if st.Frozen {
+		slog.Error("EMERGENCY FREEZE is active: write and destructive actions are denied fleet-wide",
+			"reason", st.Reason, "by", st.ChangedBy, "since", st.ChangedAt)
+	}
+	return &freezeServer{store: store, auditStore: auditStore, notifier: notifier, state: st}, nil
+}
+
+// current returns the cached freeze state. Safe on a nil receiver.
+func (s *freezeServer) current() audit.FreezeState {
+	if s == nil {
+		return audit.FreezeState{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// freezeDenial returns a non-empty deny message when action must be blocked
+// by the emergency freeze.
+func (s *freezeServer) freezeDenial(action policy.ActionClass) string {
+	if action != policy.ActionWrite && action != policy.ActionDestructive {
+		return ""
+	}
+	st := s.current()
+	if !st.Frozen {
+		return ""
+	}
+	return fmt.Sprintf("emergency freeze in effect (set by %s: %s); %s actions are denied until unfrozen",
+		st.ChangedBy, st.Reason, action)
+}
+
+// handleGet returns the current freeze state.
+// GET /v1/freeze
+func (s *freezeServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.current()) //nolint:errcheck
+}
+
+// handleFreeze activates the emergency freeze. A reason is mandatory.
+// POST /v1/freeze {"reason": "..."}
+func (s *freezeServer) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		writeJSONError(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	s.change(w, r, true, req.Reason)
+}
+
+// handleUnfreeze lifts the emergency freeze.
+// DELETE /v1/freeze
+func (s *freezeServer) handleUnfreeze(w http.ResponseWriter, r *http.Request) {
+	reason := r.URL.Query().Get("reason")
+	if !s.current().Frozen {
+		writeJSONError(w, "not frozen", http.StatusConflict)
+		return
+	}
+	s.change(w, r, false, reason)
+}
+
+// change persists, audits and alerts on a freeze state transition.
+func (s *freezeServer) change(w http.ResponseWriter, r *http.Request, frozen bool, reason string) {
+	actor := authz.PrincipalFromContext(r.Context()).EffectiveID()
+	if actor == "" {
+		actor = "anonymous"
+	}
+	st := audit.FreezeState{
+		Frozen:    frozen,
+		Reason:    reason,
+		ChangedBy: actor,
+		ChangedAt: time.Now().UTC(),
+	}
+	if err := s.store.Set(r.Context(), st); err != nil {
+		writeJSONError(w, "failed to persist freeze state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.state = st
+	s.mu.Unlock()
+
+	eventType := audit.EventTypeEmergencyFreeze
+	if frozen {
+		slog.Error("EMERGENCY FREEZE enabled: write and destructive actions are now denied fleet-wide",
+			"by", actor, "reason", reason)
+	} else {
+		eventType = audit.EventTypeEmergencyUnfreeze
+		slog.Warn("emergency freeze lifted", "by", actor, "reason", reason)
+	}
+
+	event := &audit.Event{
+		EventID:     "frz_" + uuid.New().String()[:8],
+		Timestamp:   st.ChangedAt,
+		EventType:   eventType,
+		TraceID:     r.Header.Get("X-Trace-ID"),
+		ActionClass: audit.ActionWrite,
+		Session:     audit.Session{ID: "emergency_freeze", UserID: actor},
+		Input:       audit.Input{UserQuery: reason},
+		Outcome:     &audit.Outcome{Status: "success"},
+	}
+	if event.TraceID == "" {
+		event.TraceID = audit.NewTraceIDWithPrefix("frz_")
+	}
+	if err := s.auditStore.Record(r.Context(), event); err != nil {
+		slog.Error("failed to record freeze event", "event_id", event.EventID, "err", err)
+	}
+	if s.notifier != nil {
+		s.notifier.NotifyFreeze(st)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st) //nolint:errcheck
+}
@@ -0,0 +1,111 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newTestFreezeServer(t *testing.T, store *audit.Store) *freezeServer {
+	t.Helper()
+	fs, err := audit.NewFreezeStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewFreezeStore: %v", err)
+	}
+	srv, err := newFreezeServer(fs, store, nil)
+	if err != nil {
+		t.Fatalf("newFreezeServer: %v", err)
+	}
+	return srv
+}
+
+func freezeRequest(method, body string) *http.Request {
+	req := httptest.NewRequest(method, "/v1/freeze", strings.NewReader(body))
+	ctx := authz.WithPrincipal(req.Context(), identity.ResolvedPrincipal{UserID: "alice", AuthMethod: "api_key"})
+	return req.WithContext(ctx)
+}
+
+func TestFreezeHandlers_FreezeAndUnfreeze(t *testing.T) {
+	store := newTestAuditStore(t)
+	srv := newTestFreezeServer(t, store)
+
+	w := httptest.NewRecorder()
+	srv.handleFreeze(w, freezeRequest(http.MethodPost, `{}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("freeze without reason: status = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleUnfreeze(w, freezeRequest(http.MethodDelete, ""))
+	if w.Code != http.StatusConflict {
+		t.Errorf("unfreeze while not frozen: status = %d, want 409", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleFreeze(w, freezeRequest(http.MethodPost, `{"reason":"suspected credential leak"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("freeze: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if st := srv.current(); !st.Frozen || st.ChangedBy != "alice" {
+		t.Errorf("state after freeze = %+v", st)
+	}
+
+	// A restarted server picks up the persisted freeze.
+	if st := newTestFreezeServer(t, store).current(); !st.Frozen {
+		t.Error("freeze not persisted across restart")
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleUnfreeze(w, freezeRequest(http.MethodDelete, ""))
+	if w.Code != http.StatusOK || srv.current().Frozen {
+		t.Fatalf("unfreeze: status = %d, state = %+v", w.Code, srv.current())
+	}
+
+	for _, et := range []audit.EventType{audit.EventTypeEmergencyFreeze, audit.EventTypeEmergencyUnfreeze} {
+		events, err := store.Query(context.Background(), audit.QueryOptions{EventType: et})
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(events) != 1 || events[0].Session.UserID != "alice" {
+			t.Errorf("%s events = %+v, want one by alice", et, events)
+		}
+	}
+}
+
+func TestHandlePolicyCheck_EmergencyFreeze(t *testing.T) {
+	store := newTestAuditStore(t)
+	freeze := newTestFreezeServer(t, store)
+	gs := &governanceServer{
+		policyEngine: makeEngine(t, minimalPolicyYAML),
+		auditStore:   store,
+		freeze:       freeze,
+	}
+	w := httptest.NewRecorder()
+	freeze.handleFreeze(w, freezeRequest(http.MethodPost, `{"reason":"incident 42"}`))
+
+	check := func(action string) PolicyCheckResponse {
+		t.Helper()
+		body := strings.NewReader(`{"resource_type":"database","resource_name":"dev-db","action":"` + action + `"}`)
+		w := httptest.NewRecorder()
+		gs.handlePolicyCheck(w, httptest.NewRequest(http.MethodPost, "/v1/governance/check", body))
+		var resp PolicyCheckResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := check("write"); resp.Effect != "deny" || resp.PolicyName != "emergency_freeze" {
+		t.Errorf("write while frozen: effect=%q policy=%q, want deny/emergency_freeze", resp.Effect, resp.PolicyName)
+	}
+	if resp := check("read"); resp.Effect != "allow" {
+		t.Errorf("read while frozen: effect=%q, want allow", resp.Effect)
+	}
+}
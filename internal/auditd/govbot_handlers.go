@@ -1,4 +1,4 @@
-package main
+package auditd
 
 import (
 	"encoding/json"
@@ -25,10 +25,10 @@ func (s *govbotServer) handleSaveRun(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to save run: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	// Prune old rows for this gateway if the caller supplied ?retain=N.
+	// Prune old rows for this gateway and tenant if the caller supplied ?retain=N.
 	if retainStr := r.URL.Query().Get("retain"); retainStr != "" {
 		if retain, err := strconv.Atoi(retainStr); err == nil && retain > 0 {
-			if err := s.store.Prune(run.Gateway, retain); err != nil {
+			if err := s.store.Prune(run.Gateway, run.Tenant, retain); err != nil {
 				slog.Warn("failed to prune govbot runs", "gateway", run.Gateway, "err", err)
 			}
 		}
@@ -37,11 +37,12 @@ func (s *govbotServer) handleSaveRun(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleGetRuns returns recent govbot run snapshots.
-// GET /v1/govbot/runs?window=24h&gateway=http://...&limit=50
+// GET /v1/govbot/runs?window=24h&gateway=http://...&tenant_id=payments&limit=50
 func (s *govbotServer) handleGetRuns(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	window := q.Get("window")
 	gateway := q.Get("gateway")
+	tenant := tenantScope(r)
 	limit := 50
 	if l := q.Get("limit"); l != "" {
 		if n, err := strconv.Atoi(l); err == nil && n > 0 {
@@ -49,7 +50,7 @@ func (s *govbotServer) handleGetRuns(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	runs, err := s.store.RecentRuns(window, gateway, limit)
+	runs, err := s.store.RecentRuns(window, gateway, tenant, limit)
 	if err != nil {
 		http.Error(w, "failed to query runs: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -1,11 +1,17 @@
-package main
+package auditd
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,7 +29,48 @@ type governanceServer struct {
 	notifier      *ApprovalNotifier
 	policyEngine  *policy.Engine
 	policyFile    string
-	infraConfig   *infra.Config // loaded from HELPDESK_INFRA_CONFIG for tag resolution
+	infraConfig   *infra.Config      // loaded from HELPDESK_INFRA_CONFIG for tag resolution
+	freeze        *freezeServer      // emergency read-only switch; nil = never frozen
+	maintenance   *maintenanceServer // maintenance window registry; nil = no windows
+	fields        *fieldAccess       // who reads encrypted event fields; nil = nobody
+
+	// infoTTL caches GET /v1/governance/info per tenant scope; 0 disables.
+	// The payload verifies the chain and counts pending approvals, which is
+	// too much work to repeat for every dashboard poll.
+	infoTTL   time.Duration
+	infoMu    sync.Mutex
+	infoCache map[string]cachedInfo
+
+	configVersionOnce sync.Once
+	configVersion     string
+}
+
+// policyVersionHeader carries policyVersion on policy check responses. Agents
+// that cache decisions drop their cache when it changes.
+const policyVersionHeader = "X-Policy-Version"
+
+// policyVersion identifies everything besides the request that a policy
+// decision depends on: the loaded policy configuration, the emergency freeze
+// state and the maintenance windows in effect. It changes whenever any does.
+func (s *governanceServer) policyVersion() string {
+	s.configVersionOnce.Do(func() {
+		b, _ := json.Marshal(s.policyEngine.Config())
+		sum := sha256.Sum256(b)
+		s.configVersion = hex.EncodeToString(sum[:8])
+	})
+	v := s.configVersion
+	if st := s.freeze.current(); !st.ChangedAt.IsZero() {
+		v += "-" + strconv.FormatInt(st.ChangedAt.UnixNano(), 36)
+	}
+	if mv := s.maintenance.activeVersion(time.Now()); mv != "" {
+		v += "-mw" + mv
+	}
+	return v
+}
+
+type cachedInfo struct {
+	info    GovernanceInfo
+	expires time.Time
 }
 
 // GovernanceInfo is the response for GET /v1/governance/info.
@@ -140,6 +187,31 @@ func newGovernanceServer(
 }
 
 func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request) {
+	var info GovernanceInfo
+	if s.infoTTL <= 0 {
+		info = s.buildInfo(r)
+	} else {
+		// Hold the lock while building so concurrent misses compute once.
+		tenant := tenantScope(r)
+		s.infoMu.Lock()
+		c, ok := s.infoCache[tenant]
+		if !ok || time.Now().After(c.expires) {
+			c = cachedInfo{info: s.buildInfo(r), expires: time.Now().Add(s.infoTTL)}
+			if s.infoCache == nil {
+				s.infoCache = make(map[string]cachedInfo)
+			}
+			s.infoCache[tenant] = c
+		}
+		s.infoMu.Unlock()
+		info = c.info
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// buildInfo assembles the governance info payload for the caller's tenant.
+func (s *governanceServer) buildInfo(r *http.Request) GovernanceInfo {
 	info := GovernanceInfo{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
@@ -147,7 +219,7 @@ func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request)
 	// Policy information
 	if s.policyEngine != nil {
 		cfg := s.policyEngine.Config()
-		policies := cfg.Policies
+		policies := cfg.PoliciesFor(tenantScope(r))
 		policySummaries := make([]PolicySummary, 0, len(policies))
 		totalRules := 0
 
@@ -186,6 +258,9 @@ func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request)
 					if rule.Conditions.Schedule != nil {
 						rs.Conditions = append(rs.Conditions, "time-based")
 					}
+					if rule.Conditions.MaintenanceWindow != nil {
+						rs.Conditions = append(rs.Conditions, "maintenance window")
+					}
 				}
 
 				rules = append(rules, rs)
@@ -225,8 +300,9 @@ func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request)
 	// Get pending approval count
 	if s.approvalStore != nil {
 		pending, err := s.approvalStore.ListRequests(r.Context(), audit.ApprovalQueryOptions{
-			Status: "pending",
-			Limit:  1000,
+			Status:   "pending",
+			TenantID: tenantScope(r),
+			Limit:    1000,
 		})
 		if err == nil {
 			info.Approvals.PendingCount = len(pending)
@@ -244,8 +320,9 @@ func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request)
 	}
 
 	if s.auditStore != nil {
-		// Get event count and chain status
-		status, err := s.auditStore.VerifyIntegrity(r.Context())
+		// Get event count and chain status. Incremental verification only
+		// re-hashes events appended since the last checkpoint.
+		status, err := s.auditStore.VerifySegments(r.Context(), audit.VerifyOptions{Incremental: true})
 		if err == nil {
 			info.Audit.EventsTotal = status.TotalEvents
 			info.Audit.ChainValid = status.Valid
@@ -258,8 +335,7 @@ func (s *governanceServer) handleGetInfo(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(info)
+	return info
 }
 
 // handleGetPolicySummary returns a human-readable policy summary.
@@ -274,9 +350,10 @@ func (s *governanceServer) handleGetPolicySummary(w http.ResponseWriter, r *http
 	}
 
 	cfg := s.policyEngine.Config()
-	policySummaries := make([]map[string]any, 0, len(cfg.Policies))
+	policies := cfg.PoliciesFor(tenantScope(r))
+	policySummaries := make([]map[string]any, 0, len(policies))
 
-	for _, pol := range cfg.Policies {
+	for _, pol := range policies {
 		entry := map[string]any{
 			"name":    pol.Name,
 			"enabled": pol.IsEnabled(),
@@ -346,6 +423,7 @@ func (s *governanceServer) handleGetPolicySummary(w http.ResponseWriter, r *http
 //	user_id        optional  evaluate as a specific user
 //	service        optional  evaluate as a service account (e.g. "fleet-runner")
 //	role           optional  evaluate with a specific role
+//	tenant         optional  evaluate with that tenant's policy overrides
 //	purpose        optional  declared purpose: diagnostic, remediation, maintenance, compliance, emergency
 //	sensitivity    optional  comma-separated sensitivity classes, e.g. "pii,critical"
 func (s *governanceServer) handleExplain(w http.ResponseWriter, r *http.Request) {
@@ -406,6 +484,7 @@ func (s *governanceServer) handleExplain(w http.ResponseWriter, r *http.Request)
 		Principal: policy.RequestPrincipal{
 			UserID:  q.Get("user_id"),
 			Service: q.Get("service"),
+			Tenant:  q.Get("tenant"),
 			Roles: func() []string {
 				if r := q.Get("role"); r != "" {
 					return []string{r}
@@ -455,7 +534,7 @@ func (s *governanceServer) tagsFromInfra(resourceType, resourceName string) []st
 	switch resourceType {
 	case "database":
 		if db, ok := s.infraConfig.DBServers[resourceName]; ok {
-			return db.Tags
+			return s.infraConfig.DatabaseTags(db)
 		}
 	case "kubernetes":
 		if k8s, ok := s.infraConfig.K8sClusters[resourceName]; ok {
@@ -584,6 +663,23 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 		req.TraceID = "chk_" + uuid.New().String()[:8]
 	}
 
+	resp := s.checkPolicy(r.Context(), req, false)
+
+	httpStatus := http.StatusOK
+	if resp.Effect == string(policy.EffectDeny) {
+		httpStatus = http.StatusForbidden
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(policyVersionHeader, s.policyVersion())
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkPolicy evaluates one validated check request and records the decision
+// as a pol_* audit event. preflight marks checks made ahead of execution by
+// POST /v1/governance/check/batch.
+func (s *governanceServer) checkPolicy(ctx context.Context, req PolicyCheckRequest, preflight bool) PolicyCheckResponse {
 	tags := req.Tags
 	// Auto-resolve tags from infra config when not supplied by the agent.
 	if len(tags) == 0 {
@@ -601,6 +697,7 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 			UserID:  req.Principal.UserID,
 			Roles:   req.Principal.Roles,
 			Service: req.Principal.Service,
+			Tenant:  req.Principal.Tenant,
 		},
 		Resource: policy.RequestResource{
 			Type:        req.ResourceType,
@@ -619,8 +716,17 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 			PurposeNote:  req.PurposeNote,
 		},
 	}
+	if mw := s.maintenance.covering(req.Principal.Tenant, req.ResourceName, time.Now()); mw != nil {
+		polReq.Context.MaintenanceWindow = mw.WindowID
+	}
 
 	trace := s.policyEngine.Explain(polReq)
+	// The emergency freeze overrides whatever the policy file decided for
+	// write and destructive actions.
+	if msg := s.freeze.freezeDenial(polReq.Action); msg != "" {
+		trace.Decision = policy.Decision{Effect: policy.EffectDeny, PolicyName: "emergency_freeze", Message: msg}
+		trace.Explanation = msg
+	}
 	decision := trace.Decision
 
 	// Serialize trace for the audit record.
@@ -640,7 +746,7 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 		EventType:   audit.EventTypePolicyDecision,
 		TraceID:     req.TraceID,
 		ActionClass: audit.ActionClass(req.Action),
-		Session:     audit.Session{ID: sessionID},
+		Session:     audit.Session{ID: sessionID, AgentName: req.AgentName, TenantID: req.Principal.Tenant},
 		PolicyDecision: &audit.PolicyDecision{
 			ResourceType:  req.ResourceType,
 			ResourceName:  req.ResourceName,
@@ -652,6 +758,7 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 			Message:       decision.Message,
 			Note:          req.Note,
 			PostExecution: req.PostExecution,
+			Preflight:     preflight,
 			Trace:         traceJSON,
 			Explanation:   trace.Explanation,
 			Sensitivity:   sensitivity,
@@ -664,7 +771,7 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 		},
 	}
 
-	if err := s.auditStore.Record(r.Context(), event); err != nil {
+	if err := s.auditStore.Record(ctx, event); err != nil {
 		// Don't fail the response — policy evaluation succeeded; only persistence failed.
 		slog.Error("failed to record policy check event", "event_id", eventID, "err", err)
 	}
@@ -691,7 +798,7 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 			"action", req.Action)
 	}
 
-	resp := PolicyCheckResponse{
+	return PolicyCheckResponse{
 		Effect:           string(decision.Effect),
 		PolicyName:       decision.PolicyName,
 		Message:          decision.Message,
@@ -701,14 +808,117 @@ func (s *governanceServer) handlePolicyCheck(w http.ResponseWriter, r *http.Requ
 		EventID:          eventID,
 		TraceID:          req.TraceID,
 	}
+}
 
-	httpStatus := http.StatusOK
-	if decision.IsDenied() {
-		httpStatus = http.StatusForbidden
+// maxPolicyCheckBatchSteps bounds one batch check; a remediation plan is a
+// handful of steps, not a fleet.
+const maxPolicyCheckBatchSteps = 50
+
+// PolicyCheckBatchRequest is the body for POST /v1/governance/check/batch.
+// The caller identity, trace and purpose are shared by every step; a step
+// that sets its own value overrides them.
+type PolicyCheckBatchRequest struct {
+	TraceID     string                     `json:"trace_id,omitempty"`
+	SessionID   string                     `json:"session_id,omitempty"`
+	AgentName   string                     `json:"agent_name,omitempty"`
+	Principal   identity.ResolvedPrincipal `json:"principal,omitempty"`
+	Purpose     string                     `json:"purpose,omitempty"`
+	PurposeNote string                     `json:"purpose_note,omitempty"`
+	Steps       []PolicyCheckRequest       `json:"steps"`
+}
+
+// PolicyCheckBatchResponse is returned by POST /v1/governance/check/batch.
+type PolicyCheckBatchResponse struct {
+	// Effect is the most restrictive step effect: deny, then
+	// require_approval, then allow.
+	Effect        string                `json:"effect"`
+	Steps         []PolicyCheckResponse `json:"steps"`                    // one per request step, in order
+	DeniedSteps   []int                 `json:"denied_steps,omitempty"`   // indexes of denied steps
+	ApprovalSteps []int                 `json:"approval_steps,omitempty"` // indexes of steps that need approval
+	TraceID       string                `json:"trace_id"`
+}
+
+// handlePolicyCheckBatch handles POST /v1/governance/check/batch.
+// It evaluates every step of a planned multi-step action before the first one
+// runs, so an agent learns up front that, say, step 3 of inspect → cancel →
+// terminate would be denied or needs approval. Each step is recorded as a
+// pol_* event with preflight set; the agent still checks each step as it
+// executes, since conditions such as blast radius are only known then.
+//
+// Always returns 200 when the request is valid: a denied step is part of the
+// consolidated answer, not a failure of the batch.
+func (s *governanceServer) handlePolicyCheckBatch(w http.ResponseWriter, r *http.Request) {
+	if s.policyEngine == nil {
+		writeJSONError(w, "policy engine not configured; set HELPDESK_POLICY_FILE and HELPDESK_POLICY_ENABLED", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req PolicyCheckBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Steps) == 0 {
+		writeJSONError(w, "steps is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Steps) > maxPolicyCheckBatchSteps {
+		writeJSONError(w, fmt.Sprintf("at most %d steps per batch", maxPolicyCheckBatchSteps), http.StatusBadRequest)
+		return
+	}
+	if req.TraceID == "" {
+		if req.AgentName != "" {
+			writeJSONError(w, "agent requests must include trace_id", http.StatusBadRequest)
+			return
+		}
+		req.TraceID = "chk_" + uuid.New().String()[:8]
+	}
+
+	for i := range req.Steps {
+		step := &req.Steps[i]
+		if step.ResourceType == "" || step.ResourceName == "" || step.Action == "" {
+			writeJSONError(w, fmt.Sprintf("steps[%d]: resource_type, resource_name and action are required", i), http.StatusBadRequest)
+			return
+		}
+		if step.PostExecution {
+			writeJSONError(w, fmt.Sprintf("steps[%d]: post_execution checks cannot be pre-flighted", i), http.StatusBadRequest)
+			return
+		}
+		if step.TraceID == "" {
+			step.TraceID = req.TraceID
+		}
+		if step.SessionID == "" {
+			step.SessionID = req.SessionID
+		}
+		if step.AgentName == "" {
+			step.AgentName = req.AgentName
+		}
+		if step.Principal.EffectiveID() == "" && len(step.Principal.Roles) == 0 {
+			step.Principal = req.Principal
+		}
+		if step.Purpose == "" {
+			step.Purpose, step.PurposeNote = req.Purpose, req.PurposeNote
+		}
+	}
+
+	resp := PolicyCheckBatchResponse{Effect: string(policy.EffectAllow), TraceID: req.TraceID}
+	for i, step := range req.Steps {
+		sr := s.checkPolicy(r.Context(), step, true)
+		resp.Steps = append(resp.Steps, sr)
+		switch policy.Effect(sr.Effect) {
+		case policy.EffectDeny:
+			resp.DeniedSteps = append(resp.DeniedSteps, i)
+			resp.Effect = string(policy.EffectDeny)
+		case policy.EffectRequireApproval:
+			resp.ApprovalSteps = append(resp.ApprovalSteps, i)
+			if resp.Effect != string(policy.EffectDeny) {
+				resp.Effect = string(policy.EffectRequireApproval)
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
+	w.Header().Set(policyVersionHeader, s.policyVersion())
 	json.NewEncoder(w).Encode(resp)
 }
 
@@ -735,6 +945,7 @@ func (s *governanceServer) handleGetEvent(w http.ResponseWriter, r *http.Request
 		http.Error(w, "event not found", http.StatusNotFound)
 		return
 	}
+	s.fields.events(r, events)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(events[0])
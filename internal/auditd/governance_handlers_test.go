@@ -1,4 +1,4 @@
-package main
+package auditd
 
 import (
 	"context"
@@ -1336,3 +1336,230 @@ policies:
 		t.Errorf("Effect = %q, want allow (cancel_query not covered by tool-specific policy)", resp.Effect)
 	}
 }
+
+func TestGovernanceInfo_CachedWithinTTL(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	gs := &governanceServer{auditStore: store, infoTTL: time.Minute}
+
+	getTotal := func() int {
+		w := httptest.NewRecorder()
+		gs.handleGetInfo(w, httptest.NewRequest(http.MethodGet, "/v1/governance/info", nil))
+		var info GovernanceInfo
+		if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return info.Audit.EventsTotal
+	}
+	record := func() {
+		if err := store.Record(context.Background(), &audit.Event{
+			EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "s"},
+		}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	record()
+	if got := getTotal(); got != 1 {
+		t.Fatalf("events_total = %d, want 1", got)
+	}
+	record()
+	if got := getTotal(); got != 1 {
+		t.Errorf("events_total = %d within TTL, want cached 1", got)
+	}
+
+	gs.infoTTL = 0
+	if got := getTotal(); got != 2 {
+		t.Errorf("events_total = %d with caching disabled, want 2", got)
+	}
+}
+
+// --- handleEventStats ---
+
+func TestEventStats_Handler(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	srv := &server{store: store}
+	if err := store.Record(context.Background(), &audit.Event{
+		EventType:      audit.EventTypePolicyDecision,
+		Session:        audit.Session{ID: "s"},
+		PolicyDecision: &audit.PolicyDecision{ResourceType: "database", ResourceName: "prod", Effect: "deny", PolicyName: "db-policy"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	since := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	srv.handleEventStats(w, httptest.NewRequest(http.MethodGet, "/v1/events/stats?since="+since, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var stats audit.EventStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if stats.TotalEvents != 1 || stats.ByEffect["deny"] != 1 {
+		t.Errorf("total=%d by_effect=%v, want 1 deny", stats.TotalEvents, stats.ByEffect)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleEventStats(w, httptest.NewRequest(http.MethodGet, "/v1/events/stats?until=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad until: status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandlePolicyCheck_PolicyVersionHeader(t *testing.T) {
+	gs := &governanceServer{
+		policyEngine: makeEngine(t, minimalPolicyYAML),
+		auditStore:   newTestAuditStore(t),
+		freeze:       &freezeServer{},
+	}
+	check := func() string {
+		t.Helper()
+		body := strings.NewReader(`{"resource_type":"database","resource_name":"dev-db","action":"read"}`)
+		w := httptest.NewRecorder()
+		gs.handlePolicyCheck(w, httptest.NewRequest(http.MethodPost, "/v1/governance/check", body))
+		v := w.Header().Get(policyVersionHeader)
+		if v == "" {
+			t.Fatalf("%s header not set", policyVersionHeader)
+		}
+		return v
+	}
+
+	v1 := check()
+	if v := check(); v != v1 {
+		t.Errorf("version changed between checks: %q → %q", v1, v)
+	}
+
+	// Freezing (or unfreezing) the fleet changes decisions, so the version moves.
+	gs.freeze.state = audit.FreezeState{Frozen: true, ChangedAt: time.Now()}
+	if v := check(); v == v1 {
+		t.Errorf("version %q unchanged after freeze", v)
+	}
+
+	other := &governanceServer{policyEngine: makeEngine(t, strings.Replace(minimalPolicyYAML, "effect: deny", "effect: allow", 1)), auditStore: gs.auditStore}
+	if other.policyVersion() == v1 {
+		t.Error("different policy files share a version")
+	}
+}
+
+// remediationPolicyYAML gives each step of inspect → cancel → terminate a
+// different effect.
+const remediationPolicyYAML = `
+version: "1"
+policies:
+  - name: remediation-policy
+    resources:
+      - type: database
+    rules:
+      - action: read
+        effect: allow
+      - action: write
+        effect: require_approval
+      - action: destructive
+        effect: deny
+        message: "no terminations"
+`
+
+func postPolicyCheckBatch(t *testing.T, gs *governanceServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/governance/check/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	gs.handlePolicyCheckBatch(w, req)
+	return w
+}
+
+func TestHandlePolicyCheckBatch_Consolidated(t *testing.T) {
+	store := newTestAuditStore(t)
+	gs := &governanceServer{policyEngine: makeEngine(t, remediationPolicyYAML), auditStore: store}
+
+	w := postPolicyCheckBatch(t, gs, `{"trace_id":"tr_plan","agent_name":"db-agent","steps":[
+		{"resource_type":"database","resource_name":"prod-db","action":"read","tool_name":"get_session_info"},
+		{"resource_type":"database","resource_name":"prod-db","action":"write","tool_name":"cancel_query"},
+		{"resource_type":"database","resource_name":"prod-db","action":"destructive","tool_name":"terminate_connection"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp PolicyCheckBatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Effect != "deny" {
+		t.Errorf("Effect = %q, want deny (most restrictive step)", resp.Effect)
+	}
+	if len(resp.Steps) != 3 {
+		t.Fatalf("got %d step decisions, want 3", len(resp.Steps))
+	}
+	for i, want := range []string{"allow", "require_approval", "deny"} {
+		if resp.Steps[i].Effect != want {
+			t.Errorf("step %d effect = %q, want %q", i, resp.Steps[i].Effect, want)
+		}
+		if resp.Steps[i].TraceID != "tr_plan" {
+			t.Errorf("step %d trace_id = %q, want the batch trace", i, resp.Steps[i].TraceID)
+		}
+	}
+	if len(resp.DeniedSteps) != 1 || resp.DeniedSteps[0] != 2 {
+		t.Errorf("DeniedSteps = %v, want [2]", resp.DeniedSteps)
+	}
+	if len(resp.ApprovalSteps) != 1 || resp.ApprovalSteps[0] != 1 {
+		t.Errorf("ApprovalSteps = %v, want [1]", resp.ApprovalSteps)
+	}
+
+	// Every step is recorded, marked as a preflight.
+	events, err := store.Query(context.Background(), audit.QueryOptions{TraceID: "tr_plan"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("recorded %d events, want 3", len(events))
+	}
+	for _, ev := range events {
+		if ev.PolicyDecision == nil || !ev.PolicyDecision.Preflight {
+			t.Errorf("event %s not recorded as a preflight decision", ev.EventID)
+		}
+	}
+}
+
+func TestHandlePolicyCheckBatch_ApprovalOnly(t *testing.T) {
+	gs := &governanceServer{policyEngine: makeEngine(t, remediationPolicyYAML), auditStore: newTestAuditStore(t)}
+	w := postPolicyCheckBatch(t, gs, `{"steps":[
+		{"resource_type":"database","resource_name":"prod-db","action":"read"},
+		{"resource_type":"database","resource_name":"prod-db","action":"write"}]}`)
+	var resp PolicyCheckBatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Effect != "require_approval" {
+		t.Errorf("Effect = %q, want require_approval", resp.Effect)
+	}
+	if !strings.HasPrefix(resp.TraceID, "chk_") {
+		t.Errorf("TraceID = %q, want a synthetic chk_* ID for a direct call", resp.TraceID)
+	}
+}
+
+func TestHandlePolicyCheckBatch_Validation(t *testing.T) {
+	gs := &governanceServer{policyEngine: makeEngine(t, remediationPolicyYAML), auditStore: newTestAuditStore(t)}
+	for name, body := range map[string]string{
+		"no steps":        `{"steps":[]}`,
+		"incomplete step": `{"steps":[{"resource_type":"database","action":"read"}]}`,
+		"agent no trace":  `{"agent_name":"db-agent","steps":[{"resource_type":"database","resource_name":"db","action":"read"}]}`,
+		"post-execution":  `{"steps":[{"resource_type":"database","resource_name":"db","action":"write","post_execution":true}]}`,
+		"invalid JSON":    `{`,
+	} {
+		if w := postPolicyCheckBatch(t, gs, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+
+	noEngine := &governanceServer{auditStore: newTestAuditStore(t)}
+	if w := postPolicyCheckBatch(t, noEngine, `{"steps":[]}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no engine: status = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,539 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/audit/auditpb"
+	"helpdesk/internal/authz"
+)
+
+// grpcServer serves auditpb.AuditService. Every RPC except SubscribeEvents
+// is dispatched in-process to the REST route it mirrors, so both APIs share
+// one implementation of validation, authorization, tenant scoping and
+// approval notifications; gRPC replaces only the transport and encoding.
+// gRPC metadata is passed to the REST handlers as request headers, so
+// clients authenticate with the same Authorization / X-User values.
+type grpcServer struct {
+	auditpb.UnimplementedAuditServiceServer
+
+	rest  http.Handler
+	store *audit.Store
+	// authorize resolves the caller and checks it may use a REST route;
+	// it returns the request with the principal attached.
+	authorize func(pattern string, r *http.Request) (*http.Request, int, error)
+}
+
+// newGRPCServer returns a gRPC server with the audit service registered.
+func newGRPCServer(rest http.Handler, store *audit.Store,
+	authorize func(pattern string, r *http.Request) (*http.Request, int, error)) *grpc.Server {
+	gs := grpc.NewServer()
+	auditpb.RegisterAuditServiceServer(gs, &grpcServer{rest: rest, store: store, authorize: authorize})
+	return gs
+}
+
+// restRequest builds the REST request an RPC maps to, carrying the caller's
+// metadata as headers.
+func restRequest(ctx context.Context, method, target string, body []byte) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "build request: %v", err)
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		if strings.HasPrefix(k, ":") || strings.HasPrefix(k, "grpc-") || k == "content-type" {
+			continue
+		}
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+	return r, nil
+}
+
+// restResponse records a REST handler's response in memory.
+type restResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *restResponse) Header() http.Header { return w.header }
+
+func (w *restResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *restResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// do runs a REST route in-process and returns its response.
+func (g *grpcServer) do(ctx context.Context, method, target string, in any) (*restResponse, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, status.Errorf(codes.Internal, "encode request: %v", err)
+		}
+	}
+	r, err := restRequest(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	w := &restResponse{header: make(http.Header)}
+	g.rest.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w, nil
+}
+
+// call runs a REST route in-process, maps an error status to a gRPC
+// status and decodes a successful response into out (when non-nil).
+func (g *grpcServer) call(ctx context.Context, method, target string, in, out any) error {
+	w, err := g.do(ctx, method, target, in)
+	if err != nil {
+		return err
+	}
+	if w.status >= 300 {
+		return restError(w.status, w.body.Bytes())
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+		return status.Errorf(codes.Internal, "decode response: %v", err)
+	}
+	return nil
+}
+
+// restError converts a REST error response into a gRPC status.
+func restError(code int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error != "" {
+		msg = e.Error
+	}
+	return status.Error(grpcCode(code), msg)
+}
+
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func (g *grpcServer) RecordEvent(ctx context.Context, req *auditpb.RecordEventRequest) (*auditpb.RecordEventResponse, error) {
+	if !json.Valid(req.EventJson) {
+		return nil, status.Error(codes.InvalidArgument, "event_json is not valid JSON")
+	}
+	var resp struct {
+		EventID   string `json:"event_id"`
+		EventHash string `json:"event_hash"`
+		PrevHash  string `json:"prev_hash"`
+	}
+	if err := g.call(ctx, http.MethodPost, "/v1/events", json.RawMessage(req.EventJson), &resp); err != nil {
+		return nil, err
+	}
+	return &auditpb.RecordEventResponse{EventId: resp.EventID, EventHash: resp.EventHash, PrevHash: resp.PrevHash}, nil
+}
+
+func (g *grpcServer) RecordEvents(stream grpc.BidiStreamingServer[auditpb.RecordEventRequest, auditpb.RecordEventResponse]) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := g.RecordEvent(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *grpcServer) RecordOutcome(ctx context.Context, req *auditpb.RecordOutcomeRequest) (*auditpb.RecordOutcomeResponse, error) {
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	outcome := audit.Outcome{
+		Status:       req.Status,
+		ErrorMessage: req.ErrorMessage,
+		Duration:     req.Duration.AsDuration(),
+	}
+	if err := g.call(ctx, http.MethodPost, "/v1/events/"+url.PathEscape(req.EventId)+"/outcome", outcome, nil); err != nil {
+		return nil, err
+	}
+	return &auditpb.RecordOutcomeResponse{}, nil
+}
+
+func (g *grpcServer) QueryEvents(req *auditpb.QueryEventsRequest, stream grpc.ServerStreamingServer[auditpb.AuditEvent]) error {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"session_id":      req.SessionId,
+		"trace_id":        req.TraceId,
+		"trace_id_prefix": req.TraceIdPrefix,
+		"types":           strings.Join(req.EventTypes, ","),
+		"agent":           req.Agent,
+		"action_class":    req.ActionClass,
+		"outcome_status":  req.OutcomeStatus,
+		"origin":          req.Origin,
+		"tool_name":       req.ToolName,
+		"tenant_id":       req.TenantId,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if req.Since != nil {
+		q.Set("since", req.Since.AsTime().Format(time.RFC3339Nano))
+	}
+	if req.Limit > 0 {
+		q.Set("limit", strconv.Itoa(int(req.Limit)))
+	}
+
+	var events []json.RawMessage
+	if err := g.call(stream.Context(), http.MethodGet, "/v1/events?"+q.Encode(), nil, &events); err != nil {
+		return err
+	}
+	for _, raw := range events {
+		ev, err := eventToProto(0, raw)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubscribeEvents streams from the store's event broker directly; it is
+// authorized like GET /v1/events and scoped to the caller's tenant.
+func (g *grpcServer) SubscribeEvents(req *auditpb.SubscribeEventsRequest, stream grpc.ServerStreamingServer[auditpb.AuditEvent]) error {
+	ctx := stream.Context()
+	r, err := restRequest(ctx, http.MethodGet, "/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	r, code, err := g.authorize("GET /v1/events", r)
+	if err != nil {
+		return status.Error(grpcCode(code), err.Error())
+	}
+	tenant := authz.PrincipalFromContext(r.Context()).TenantScope(req.TenantId)
+
+	after := audit.SocketLive
+	if req.ReplayAfter != nil {
+		after = *req.ReplayAfter
+	}
+	for se := range g.store.Subscribe(ctx, after) {
+		if tenant != "" && se.Event.Session.TenantID != tenant {
+			continue
+		}
+		if len(req.EventTypes) > 0 && !slices.Contains(req.EventTypes, string(se.Event.EventType)) {
+			continue
+		}
+		if err := stream.Send(eventProto(se.Seq, &se.Event, se.Raw)); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, "event subscription ended; resubscribe with replay_after")
+}
+
+func eventToProto(seq int64, raw json.RawMessage) (*auditpb.AuditEvent, error) {
+	var ev audit.Event
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode event: %v", err)
+	}
+	return eventProto(seq, &ev, raw), nil
+}
+
+func eventProto(seq int64, ev *audit.Event, raw json.RawMessage) *auditpb.AuditEvent {
+	agent := ev.Session.AgentName
+	if agent == "" && ev.Decision != nil {
+		agent = ev.Decision.Agent
+	}
+	return &auditpb.AuditEvent{
+		Seq:       seq,
+		EventId:   ev.EventID,
+		EventType: string(ev.EventType),
+		TraceId:   ev.TraceID,
+		SessionId: ev.Session.ID,
+		Agent:     agent,
+		TenantId:  ev.Session.TenantID,
+		Timestamp: timestamppb.New(ev.Timestamp),
+		EventHash: ev.EventHash,
+		EventJson: raw,
+	}
+}
+
+func (g *grpcServer) CreateApproval(ctx context.Context, req *auditpb.CreateApprovalRequest) (*auditpb.Approval, error) {
+	body := CreateApprovalRequest{
+		EventID:      req.EventId,
+		TraceID:      req.TraceId,
+		TenantID:     req.TenantId,
+		ActionClass:  req.ActionClass,
+		ToolName:     req.ToolName,
+		AgentName:    req.AgentName,
+		ResourceType: req.ResourceType,
+		ResourceName: req.ResourceName,
+		RequestedBy:  req.RequestedBy,
+		PolicyName:   req.PolicyName,
+		ApproverRole: req.ApproverRole,
+		ExpiresInMin: int(req.ExpiresInMinutes),
+		CallbackURL:  req.CallbackUrl,
+	}
+	if len(req.RequestContextJson) > 0 {
+		if err := json.Unmarshal(req.RequestContextJson, &body.Context); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "request_context_json: %v", err)
+		}
+	}
+	var created struct {
+		ApprovalID string `json:"approval_id"`
+	}
+	if err := g.call(ctx, http.MethodPost, "/v1/approvals", body, &created); err != nil {
+		return nil, err
+	}
+	return g.GetApproval(ctx, &auditpb.GetApprovalRequest{ApprovalId: created.ApprovalID})
+}
+
+func (g *grpcServer) GetApproval(ctx context.Context, req *auditpb.GetApprovalRequest) (*auditpb.Approval, error) {
+	if req.ApprovalId == "" {
+		return nil, status.Error(codes.InvalidArgument, "approval_id is required")
+	}
+	return g.approval(ctx, "/v1/approvals/"+url.PathEscape(req.ApprovalId))
+}
+
+func (g *grpcServer) ListApprovals(ctx context.Context, req *auditpb.ListApprovalsRequest) (*auditpb.ListApprovalsResponse, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"status":       req.Status,
+		"agent":        req.Agent,
+		"trace_id":     req.TraceId,
+		"requested_by": req.RequestedBy,
+		"tool_name":    req.ToolName,
+		"tenant_id":    req.TenantId,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if req.Since != nil {
+		q.Set("since", req.Since.AsTime().Format(time.RFC3339Nano))
+	}
+	if req.Limit > 0 {
+		q.Set("limit", strconv.Itoa(int(req.Limit)))
+	}
+
+	var approvals []json.RawMessage
+	if err := g.call(ctx, http.MethodGet, "/v1/approvals?"+q.Encode(), nil, &approvals); err != nil {
+		return nil, err
+	}
+	resp := &auditpb.ListApprovalsResponse{}
+	for _, raw := range approvals {
+		a, err := approvalProto(raw)
+		if err != nil {
+			return nil, err
+		}
+		resp.Approvals = append(resp.Approvals, a)
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) WaitForApproval(ctx context.Context, req *auditpb.WaitForApprovalRequest) (*auditpb.Approval, error) {
+	if req.ApprovalId == "" {
+		return nil, status.Error(codes.InvalidArgument, "approval_id is required")
+	}
+	target := "/v1/approvals/" + url.PathEscape(req.ApprovalId) + "/wait"
+	if req.Timeout != nil {
+		target += "?timeout=" + req.Timeout.AsDuration().String()
+	}
+	return g.approval(ctx, target)
+}
+
+func (g *grpcServer) ResolveApproval(ctx context.Context, req *auditpb.ResolveApprovalRequest) (*auditpb.Approval, error) {
+	if req.ApprovalId == "" {
+		return nil, status.Error(codes.InvalidArgument, "approval_id is required")
+	}
+	base := "/v1/approvals/" + url.PathEscape(req.ApprovalId)
+	var (
+		action string
+		body   any
+	)
+	switch req.Resolution {
+	case auditpb.ResolveApprovalRequest_RESOLUTION_APPROVE:
+		action = "/approve"
+		body = ApproveRequest{ApprovedBy: req.ResolvedBy, Reason: req.Reason, ValidForMin: int(req.ValidForMinutes)}
+	case auditpb.ResolveApprovalRequest_RESOLUTION_DENY:
+		action = "/deny"
+		body = DenyRequest{DeniedBy: req.ResolvedBy, Reason: req.Reason}
+	case auditpb.ResolveApprovalRequest_RESOLUTION_CANCEL:
+		action = "/cancel"
+		body = map[string]string{"cancelled_by": req.ResolvedBy, "reason": req.Reason}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "resolution is required")
+	}
+	if err := g.call(ctx, http.MethodPost, base+action, body, nil); err != nil {
+		return nil, err
+	}
+	return g.approval(ctx, base)
+}
+
+// approval fetches an approval from a REST route that returns one.
+func (g *grpcServer) approval(ctx context.Context, target string) (*auditpb.Approval, error) {
+	var raw json.RawMessage
+	if err := g.call(ctx, http.MethodGet, target, nil, &raw); err != nil {
+		return nil, err
+	}
+	return approvalProto(raw)
+}
+
+func approvalProto(raw json.RawMessage) (*auditpb.Approval, error) {
+	var a audit.StoredApproval
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode approval: %v", err)
+	}
+	return &auditpb.Approval{
+		ApprovalId:         a.ApprovalID,
+		Status:             a.Status,
+		EventId:            a.EventID,
+		TraceId:            a.TraceID,
+		TenantId:           a.TenantID,
+		ActionClass:        a.ActionClass,
+		ToolName:           a.ToolName,
+		AgentName:          a.AgentName,
+		ResourceType:       a.ResourceType,
+		ResourceName:       a.ResourceName,
+		RequestedBy:        a.RequestedBy,
+		RequestedAt:        timestampProto(a.RequestedAt),
+		ResolvedBy:         a.ResolvedBy,
+		ResolvedAt:         timestampProto(a.ResolvedAt),
+		ResolutionReason:   a.ResolutionReason,
+		ExpiresAt:          timestampProto(a.ExpiresAt),
+		ApprovalValidUntil: timestampProto(a.ApprovalValidUntil),
+		PolicyName:         a.PolicyName,
+		ApproverRole:       a.ApproverRole,
+		ApprovalJson:       raw,
+	}, nil
+}
+
+// timestampProto leaves unset times unset rather than encoding year 1.
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// CheckPolicy returns deny decisions as responses: POST /v1/governance/check
+// answers them with 403 and the full decision, which is not an RPC failure.
+func (g *grpcServer) CheckPolicy(ctx context.Context, req *auditpb.CheckPolicyRequest) (*auditpb.CheckPolicyResponse, error) {
+	body := PolicyCheckRequest{
+		ResourceType:  req.ResourceType,
+		ResourceName:  req.ResourceName,
+		Action:        req.Action,
+		Tags:          req.Tags,
+		TraceID:       req.TraceId,
+		SessionID:     req.SessionId,
+		AgentName:     req.AgentName,
+		Note:          req.Note,
+		RowsAffected:  int(req.RowsAffected),
+		PodsAffected:  int(req.PodsAffected),
+		XactAgeSecs:   int(req.XactAgeSecs),
+		PostExecution: req.PostExecution,
+		Purpose:       req.Purpose,
+		PurposeNote:   req.PurposeNote,
+		Sensitivity:   req.Sensitivity,
+		ToolName:      req.ToolName,
+	}
+	if p := req.Principal; p != nil {
+		body.Principal.UserID = p.UserId
+		body.Principal.Roles = p.Roles
+		body.Principal.Service = p.Service
+		body.Principal.AuthMethod = p.AuthMethod
+		body.Principal.Tenant = p.Tenant
+	}
+
+	w, err := g.do(ctx, http.MethodPost, "/v1/governance/check", body)
+	if err != nil {
+		return nil, err
+	}
+	code, respBody := w.status, w.body.Bytes()
+	var resp struct {
+		PolicyCheckResponse
+		Trace json.RawMessage `json:"trace"`
+	}
+	decoded := json.Unmarshal(respBody, &resp) == nil && resp.Effect != ""
+	if code >= 300 && !(code == http.StatusForbidden && decoded) {
+		return nil, restError(code, respBody)
+	}
+	if !decoded {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("decode policy check response: %.200s", respBody))
+	}
+	return &auditpb.CheckPolicyResponse{
+		Effect:           resp.Effect,
+		PolicyName:       resp.PolicyName,
+		Message:          resp.Message,
+		Explanation:      resp.Explanation,
+		RequiresApproval: resp.RequiresApproval,
+		EventId:          resp.EventID,
+		TraceId:          resp.TraceID,
+		TraceJson:        resp.Trace,
+		PolicyVersion:    w.header.Get(policyVersionHeader),
+	}, nil
+}
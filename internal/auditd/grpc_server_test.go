@@ -0,0 +1,254 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/audit/auditpb"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+// allowAll is the authorize function of an auditd running without
+// HELPDESK_USERS_FILE.
+func allowAll(_ string, r *http.Request) (*http.Request, int, error) {
+	return r, http.StatusOK, nil
+}
+
+// newGRPCTestClient serves the gRPC API over an in-memory listener with the
+// event and governance routes registered, and returns a client for it.
+func newGRPCTestClient(t *testing.T, store *audit.Store,
+	authorize func(string, *http.Request) (*http.Request, int, error)) auditpb.AuditServiceClient {
+	t.Helper()
+	auth := func(pattern string, h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r, status, err := authorize(pattern, r)
+			if err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+			h(w, r)
+		}
+	}
+	srv := &server{store: store}
+	gov := &governanceServer{policyEngine: makeEngine(t, minimalPolicyYAML), auditStore: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/events", auth("POST /v1/events", srv.handleRecordEvent))
+	mux.HandleFunc("POST /v1/events/{eventID}/outcome", auth("POST /v1/events/{eventID}/outcome", srv.handleRecordOutcome))
+	mux.HandleFunc("GET /v1/events", auth("GET /v1/events", srv.handleQueryEvents))
+	mux.HandleFunc("POST /v1/governance/check", auth("POST /v1/governance/check", gov.handlePolicyCheck))
+
+	lis := bufconn.Listen(1 << 20)
+	gs := newGRPCServer(mux, store, authorize)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return auditpb.NewAuditServiceClient(conn)
+}
+
+func recordGRPC(t *testing.T, client auditpb.AuditServiceClient, event audit.Event) *auditpb.RecordEventResponse {
+	t.Helper()
+	body, _ := json.Marshal(event)
+	resp, err := client.RecordEvent(context.Background(), &auditpb.RecordEventRequest{EventJson: body})
+	if err != nil {
+		t.Fatalf("RecordEvent: %v", err)
+	}
+	return resp
+}
+
+func TestGRPC_RecordAndQueryEvents(t *testing.T) {
+	store := newTestAuditStore(t)
+	client := newGRPCTestClient(t, store, allowAll)
+
+	first := recordGRPC(t, client, audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "sess-grpc"}})
+	second := recordGRPC(t, client, audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "sess-grpc"}})
+	if first.EventId == "" || first.EventHash == "" {
+		t.Fatalf("RecordEvent response missing chain fields: %+v", first)
+	}
+	if second.PrevHash != first.EventHash {
+		t.Errorf("PrevHash = %q, want previous event hash %q", second.PrevHash, first.EventHash)
+	}
+
+	_, err := client.RecordOutcome(context.Background(), &auditpb.RecordOutcomeRequest{EventId: first.EventId, Status: "success"})
+	if err != nil {
+		t.Fatalf("RecordOutcome: %v", err)
+	}
+
+	stream, err := client.QueryEvents(context.Background(), &auditpb.QueryEventsRequest{SessionId: "sess-grpc"})
+	if err != nil {
+		t.Fatalf("QueryEvents: %v", err)
+	}
+	var got []*auditpb.AuditEvent
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		got = append(got, ev)
+	}
+	if len(got) != 2 {
+		t.Fatalf("QueryEvents returned %d events, want 2", len(got))
+	}
+	for _, ev := range got {
+		if ev.SessionId != "sess-grpc" || ev.EventType != string(audit.EventTypeDelegation) {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		var full audit.Event
+		if err := json.Unmarshal(ev.EventJson, &full); err != nil || full.EventID != ev.EventId {
+			t.Errorf("event_json does not decode to event %s: %v", ev.EventId, err)
+		}
+	}
+}
+
+func TestGRPC_RecordEventsStream(t *testing.T) {
+	store := newTestAuditStore(t)
+	client := newGRPCTestClient(t, store, allowAll)
+
+	stream, err := client.RecordEvents(context.Background())
+	if err != nil {
+		t.Fatalf("RecordEvents: %v", err)
+	}
+	var prev string
+	for i := 0; i < 3; i++ {
+		body, _ := json.Marshal(audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "sess-stream"}})
+		if err := stream.Send(&auditpb.RecordEventRequest{EventJson: body}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if i > 0 && resp.PrevHash != prev {
+			t.Errorf("event %d PrevHash = %q, want %q", i, resp.PrevHash, prev)
+		}
+		prev = resp.EventHash
+	}
+	stream.CloseSend()
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{SessionID: "sess-stream"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("stored %d events, want 3", len(events))
+	}
+}
+
+func TestGRPC_RecordEvent_InvalidJSON(t *testing.T) {
+	client := newGRPCTestClient(t, newTestAuditStore(t), allowAll)
+	_, err := client.RecordEvent(context.Background(), &auditpb.RecordEventRequest{EventJson: []byte("{")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("code = %v, want InvalidArgument", status.Code(err))
+	}
+}
+
+func TestGRPC_SubscribeEvents_ReplayThenLive(t *testing.T) {
+	store := newTestAuditStore(t)
+	client := newGRPCTestClient(t, store, allowAll)
+	recordGRPC(t, client, audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "old"}})
+	recordGRPC(t, client, audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "old"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	replayAfter := int64(1)
+	stream, err := client.SubscribeEvents(ctx, &auditpb.SubscribeEventsRequest{ReplayAfter: &replayAfter})
+	if err != nil {
+		t.Fatalf("SubscribeEvents: %v", err)
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv replay: %v", err)
+	}
+	if ev.Seq != 2 || ev.SessionId != "old" {
+		t.Errorf("replayed event = seq %d session %q, want seq 2 session old", ev.Seq, ev.SessionId)
+	}
+
+	recordGRPC(t, client, audit.Event{EventType: audit.EventTypeDelegation, Session: audit.Session{ID: "new"}})
+	ev, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv live: %v", err)
+	}
+	if ev.Seq != 3 || ev.SessionId != "new" {
+		t.Errorf("live event = seq %d session %q, want seq 3 session new", ev.Seq, ev.SessionId)
+	}
+}
+
+func TestGRPC_CheckPolicy_DenyIsResponse(t *testing.T) {
+	client := newGRPCTestClient(t, newTestAuditStore(t), allowAll)
+	resp, err := client.CheckPolicy(context.Background(), &auditpb.CheckPolicyRequest{
+		ResourceType: "database",
+		ResourceName: "prod-db",
+		Action:       "write",
+	})
+	if err != nil {
+		t.Fatalf("CheckPolicy: %v", err)
+	}
+	if resp.Effect != "deny" || resp.EventId == "" {
+		t.Errorf("CheckPolicy = effect %q event %q, want a recorded deny", resp.Effect, resp.EventId)
+	}
+	if len(resp.TraceJson) == 0 {
+		t.Error("trace_json is empty")
+	}
+}
+
+func TestGRPC_AuthMetadata(t *testing.T) {
+	usersYAML := `
+users:
+  - id: alice@example.com
+    roles: [dba]
+`
+	idProvider := newEnforcingProvider(t, usersYAML)
+	authzr := authz.NewAuthorizer(authz.DefaultAuditdPermissions, true)
+	authorize := func(pattern string, r *http.Request) (*http.Request, int, error) {
+		principal, err := idProvider.Resolve(r)
+		if err != nil {
+			principal = identity.ResolvedPrincipal{AuthMethod: "header"}
+		}
+		if err := authzr.Authorize(pattern, principal); err != nil {
+			return nil, http.StatusUnauthorized, err
+		}
+		return r.WithContext(authz.WithPrincipal(r.Context(), principal)), http.StatusOK, nil
+	}
+	client := newGRPCTestClient(t, newTestAuditStore(t), authorize)
+
+	// Server streams report errors on the first Recv.
+	stream, err := client.QueryEvents(context.Background(), &auditpb.QueryEventsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("anonymous QueryEvents: code = %v, want Unauthenticated", status.Code(err))
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user", "alice@example.com")
+	stream, err = client.QueryEvents(ctx, &auditpb.QueryEventsRequest{})
+	if err != nil {
+		t.Fatalf("QueryEvents: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("authenticated QueryEvents: err = %v, want EOF on empty store", err)
+	}
+}
@@ -0,0 +1,126 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header.
+const maxIdempotencyKeyLen = 255
+
+// idempotencyGuard deduplicates retried creates. A POST carrying an
+// Idempotency-Key that auditd has already answered within the window gets the
+// same response back, marked Idempotent-Replayed, without recording again: a
+// client that retries after a lost response (at-least-once delivery) cannot
+// double-count an event or page approvers twice. Keys are scoped to the route
+// and the caller, so one caller cannot replay another's response. Only
+// successful responses are kept; a failed request releases its key.
+type idempotencyGuard struct {
+	store  *audit.IdempotencyStore
+	window time.Duration
+}
+
+// wrap deduplicates requests to h, the handler of pattern. A nil guard, or
+// a request without the header, passes straight through.
+func (g *idempotencyGuard) wrap(pattern string, h http.HandlerFunc) http.HandlerFunc {
+	if g == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(audit.IdempotencyKeyHeader)
+		if key == "" {
+			h(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		p := authz.PrincipalFromContext(r.Context())
+		scope := pattern + " " + p.EffectiveID() + " " + p.Tenant
+		rec, claimed, err := g.store.Claim(r.Context(), scope, key, hash, g.window)
+		if err != nil {
+			slog.Error("idempotency: failed to claim key", "err", err)
+			http.Error(w, "failed to check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			switch {
+			case rec.RequestHash != hash:
+				http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+			case rec.Pending():
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(rec.Status)
+				w.Write(rec.Body) //nolint:errcheck
+			}
+			return
+		}
+
+		resp := &restResponse{header: make(http.Header)}
+		h(resp, r)
+		if resp.status == 0 {
+			resp.status = http.StatusOK
+		}
+		// Save or release under a fresh context: the key must not stay
+		// pending because the client went away once the work was done.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+		defer cancel()
+		if resp.status >= 200 && resp.status < 300 {
+			err = g.store.Complete(ctx, scope, key, resp.status, resp.body.Bytes())
+		} else {
+			err = g.store.Release(ctx, scope, key)
+		}
+		if err != nil {
+			slog.Warn("idempotency: failed to save key", "err", err)
+		}
+
+		for k, v := range resp.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.status)
+		w.Write(resp.body.Bytes()) //nolint:errcheck
+	}
+}
+
+// startPurgeJob deletes keys older than the window, hourly or once per
+// window if that is shorter, until ctx is cancelled.
+func (g *idempotencyGuard) startPurgeJob(ctx context.Context) {
+	ticker := time.NewTicker(min(g.window, time.Hour))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := g.store.Purge(ctx, time.Now().Add(-g.window))
+			if err != nil {
+				slog.Warn("idempotency: purge failed", "err", err)
+			} else if n > 0 {
+				slog.Debug("idempotency: purged expired keys", "count", n)
+			}
+		}
+	}
+}
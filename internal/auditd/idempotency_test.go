@@ -0,0 +1,117 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newTestIdempotencyGuard(t *testing.T) (*audit.Store, *idempotencyGuard) {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	is, err := audit.NewIdempotencyStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewIdempotencyStore: %v", err)
+	}
+	return store, &idempotencyGuard{store: is, window: time.Hour}
+}
+
+func postWithKey(h http.HandlerFunc, target, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(audit.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestIdempotency_RetriedEventRecordedOnce(t *testing.T) {
+	store, guard := newTestIdempotencyGuard(t)
+	srv := &server{store: store}
+	h := guard.wrap("POST /v1/events", srv.handleRecordEvent)
+	body := `{"event_type":"tool_execution","session":{"id":"s"}}`
+
+	first := postWithKey(h, "/v1/events", "retry-1", body)
+	second := postWithKey(h, "/v1/events", "retry-1", body)
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatalf("status = %d, %d; want 200 twice", first.Code, second.Code)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("replayed body = %s, want %s", second.Body, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("only the retry should be marked Idempotent-Replayed")
+	}
+	events, _ := store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeToolExecution})
+	if len(events) != 1 {
+		t.Errorf("stored events = %d, want 1", len(events))
+	}
+
+	if w := postWithKey(h, "/v1/events", "retry-1", `{"event_type":"tool_execution","session":{"id":"other"}}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused with another body: status = %d, want 422", w.Code)
+	}
+	// Without a key every request records.
+	postWithKey(h, "/v1/events", "", body)
+	postWithKey(h, "/v1/events", "", body)
+	events, _ = store.Query(context.Background(), audit.QueryOptions{EventType: audit.EventTypeToolExecution})
+	if len(events) != 3 {
+		t.Errorf("stored events = %d, want 3", len(events))
+	}
+}
+
+func TestIdempotency_FailedRequestReleasesKey(t *testing.T) {
+	_, guard := newTestIdempotencyGuard(t)
+	fail := true
+	h := guard.wrap("POST /v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "db down", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"approval_id": "apr_1"}) //nolint:errcheck
+	})
+
+	if w := postWithKey(h, "/v1/approvals", "k", "{}"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first status = %d, want 500", w.Code)
+	}
+	fail = false
+	if w := postWithKey(h, "/v1/approvals", "k", "{}"); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry after failure = %d (replayed %q), want a fresh 201", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	w := postWithKey(h, "/v1/approvals", "k", "{}")
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "apr_1") {
+		t.Errorf("replay = %d %s, want the saved 201", w.Code, w.Body)
+	}
+}
+
+func TestIdempotency_KeysScopedToCaller(t *testing.T) {
+	_, guard := newTestIdempotencyGuard(t)
+	calls := 0
+	h := guard.wrap("POST /v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	for _, tenant := range []string{"team-a", "team-b"} {
+		r := httptest.NewRequest(http.MethodPost, "/v1/approvals", strings.NewReader("{}"))
+		r = r.WithContext(authz.WithPrincipal(r.Context(), identity.ResolvedPrincipal{Service: "srebot", Tenant: tenant, AuthMethod: "api_key"}))
+		r.Header.Set(audit.IdempotencyKeyHeader, "same")
+		h(httptest.NewRecorder(), r)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want one per tenant", calls)
+	}
+}
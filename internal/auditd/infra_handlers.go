@@ -0,0 +1,96 @@
+package auditd
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"helpdesk/internal/infra"
+)
+
+// infraServer distributes the infrastructure config (HELPDESK_INFRA_CONFIG)
+// to agents, signed with auditd's Ed25519 key so that agents holding the
+// public key can reject a config that did not come from here. The file is
+// re-read and re-signed whenever its mtime or size changes.
+type infraServer struct {
+	path string
+	key  ed25519.PrivateKey
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	body    []byte // JSON-encoded infra.SignedConfig
+	etag    string
+}
+
+// current returns the signed config and its ETag, reloading the file when it
+// has changed. A file that no longer parses is not signed: the last good
+// config keeps being served and the error is logged.
+func (s *infraServer) current() ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return s.stale(fmt.Errorf("stat infrastructure config: %v", err))
+	}
+	if s.body != nil && fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return s.body, s.etag, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.stale(fmt.Errorf("read infrastructure config: %v", err))
+	}
+	if _, err := infra.Parse(data); err != nil {
+		return s.stale(err)
+	}
+	body, err := json.Marshal(infra.Sign(data, s.key))
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	s.body, s.etag = body, `"`+hex.EncodeToString(sum[:16])+`"`
+	s.modTime, s.size = fi.ModTime(), fi.Size()
+	slog.Info("infrastructure config signed for distribution", "path", s.path, "etag", s.etag)
+	return s.body, s.etag, nil
+}
+
+// stale returns the last good signed config after a reload error, or the
+// error when there is none.
+func (s *infraServer) stale(err error) ([]byte, string, error) {
+	if s.body == nil {
+		return nil, "", err
+	}
+	slog.Warn("infrastructure config reload failed; serving last good version", "path", s.path, "etag", s.etag, "err", err)
+	return s.body, s.etag, nil
+}
+
+// handleGet handles GET /v1/infra. Agents send the ETag they hold in
+// If-None-Match and get 304 until the config changes.
+func (s *infraServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	if s.path == "" || s.key == nil {
+		writeJSONError(w, "infra config distribution is not configured (set HELPDESK_INFRA_CONFIG and HELPDESK_INFRA_SIGNING_KEY)", http.StatusServiceUnavailable)
+		return
+	}
+	body, etag, err := s.current()
+	if err != nil {
+		slog.Error("infrastructure config unavailable", "path", s.path, "err", err)
+		writeJSONError(w, "infrastructure config unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body) //nolint:errcheck
+}
@@ -0,0 +1,77 @@
+package auditd
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/infra"
+)
+
+func getInfra(s *infraServer, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/infra", nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	w := httptest.NewRecorder()
+	s.handleGet(w, req)
+	return w
+}
+
+func TestInfraHandler_SignedAndConditional(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	path := filepath.Join(t.TempDir(), "infra.json")
+	if err := os.WriteFile(path, []byte(`{"db_servers":{"prod-db":{"name":"Prod","connection_string":"host=prod"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := &infraServer{path: path, key: priv}
+
+	w := getInfra(s, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var sc infra.SignedConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &sc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cfg, err := sc.Verify(pub); err != nil || len(cfg.DBServers) != 1 {
+		t.Fatalf("Verify = %v, %v", cfg, err)
+	}
+	etag := w.Header().Get("ETag")
+	if w := getInfra(s, etag); w.Code != http.StatusNotModified {
+		t.Errorf("unchanged config: status = %d, want 304", w.Code)
+	}
+
+	// An edit is picked up and changes the ETag.
+	if err := os.WriteFile(path, []byte(`{"db_servers":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later) //nolint:errcheck
+	w = getInfra(s, etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("edited config: status = %d, etag %q (was %q)", w.Code, w.Header().Get("ETag"), etag)
+	}
+	etag = w.Header().Get("ETag")
+
+	// An invalid edit is not signed; the last good config is still served.
+	if err := os.WriteFile(path, []byte(`{not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later = later.Add(time.Minute)
+	os.Chtimes(path, later, later) //nolint:errcheck
+	if w := getInfra(s, etag); w.Code != http.StatusNotModified {
+		t.Errorf("invalid edit: status = %d, want 304 for the last good config", w.Code)
+	}
+}
+
+func TestInfraHandler_NotConfigured(t *testing.T) {
+	if w := getInfra(&infraServer{path: "infra.json"}, ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no signing key: status = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,1612 @@
+// Package auditd implements the central audit service daemon.
+// All helpdesk components send audit events here via HTTP.
+// This service owns the SQLite database and maintains hash chain integrity.
+// cmd/auditd runs it as its own process; the helpdesk all-in-one mode runs
+// it in-process next to the gateway.
+package auditd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/i18n"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/infra"
+	"helpdesk/internal/notifyplugin"
+	"helpdesk/internal/secrets"
+	"helpdesk/internal/shutdown"
+	"helpdesk/internal/sms"
+	"helpdesk/playbooks"
+)
+
+type config struct {
+	listenAddr      string
+	grpcListenAddr  string // optional; serves the gRPC API (auditpb) when set
+	roListenAddr    string // optional; serves only GET endpoints (dashboards, govbot)
+	roMaxConcurrent int    // requests the read-only listener runs at once
+	dbPath          string
+	socketPath      string
+	usersFile       string // optional; enables role-based auth on approve/deny/cancel
+
+	// Approval notification configuration
+	approvalWebhook  string
+	smtpHost         string
+	smtpPort         string
+	smtpUser         string
+	smtpPassword     string
+	emailFrom        string
+	emailTo          string
+	twilioSID        string
+	twilioToken      string
+	twilioFrom       string
+	smsTo            string
+	smsWebhookURL    string
+	notifyExecuted   bool
+	notifyPlugins    string // comma-separated executable paths
+	approvalSLA      string // per action class, e.g. destructive=15m,write=1h
+
+	// Localization of approval and freeze notifications
+	locale           string
+	localeDir        string
+	recipientLocales string
+
+	// SIEM forwarding configuration
+	siem SIEMForwarderConfig
+
+	// WORM (S3 Object Lock) export configuration
+	worm WORMExportConfig
+
+	// Synthetic canary events
+	canary CanaryConfig
+
+	// Event bus configuration
+	busNATSURL       string
+	busNATSJetStream bool
+	busKafkaRESTURL  string
+	busTopicPrefix   string
+
+	// Policy decision webhook
+	decisionWebhook       string
+	decisionWebhookSecret string
+	decisionWebhookMaxAge time.Duration
+
+	// Hash chain configuration
+	chainSharding string
+	chainKey      string
+	linkKey       string // signs emailed approve/deny links
+	infraKey      string // Ed25519 key that signs the infra config served at GET /v1/infra
+
+	// Field-level encryption of sensitive event fields
+	encryptFields string
+	fieldKey      string // key encryption key: awskms://<key> or a local secret
+	pepper        string // pseudonymizes user IDs; empty stores them as given
+	prevPeppers   string // comma-separated peppers rotated out, still matched
+	decryptRoles  string
+
+	// Shared access tokens: event ingest and approval changes vs. queries
+	tokens accessTokens
+
+	// How long Idempotency-Key responses are kept for retried creates
+	idempotencyWindow time.Duration
+
+	// How long a delegation may go without an outcome before it is orphaned
+	orphanTimeout time.Duration
+
+	// Event sampling: keep 1 in N read tool executions / events of a type
+	sampleReadTools  int
+	sampleEventTypes string
+
+	// Optional prompt-injection classifier, consulted alongside the heuristics
+	injectionClassifierURL string
+
+	// How long GET /v1/governance/info responses are served from cache
+	infoCacheTTL time.Duration
+
+	// Confidence calibration job
+	calibrationInterval  time.Duration
+	calibrationWindow    time.Duration
+	calibrationRetention time.Duration
+
+	// SQLite storage tuning and scheduled compaction
+	sqliteJournalMode       string
+	sqliteWALAutoCheckpoint int
+	sqliteIncrementalVacuum bool
+	compactInterval         time.Duration
+	compactMinFreeRatio     float64
+	compactMaxPages         int
+
+	// Database log correlation (out-of-band use of agent credentials)
+	dbAuditUsers       string
+	dbAuditAgent       string
+	dbAuditApplication string
+	dbAuditTolerance   time.Duration
+}
+
+// Run starts the audit service configured by args (command-line flags
+// without the program name) and the environment, and serves until ctx is
+// cancelled. It then shuts down gracefully and returns nil; it returns an
+// error when the configuration is invalid or a listener fails.
+func Run(ctx context.Context, args []string) error {
+	var cfg config
+	fs := flag.NewFlagSet("auditd", flag.ExitOnError)
+	fs.StringVar(&cfg.listenAddr, "listen", envOrDefault("HELPDESK_AUDIT_ADDR", ":1199"), "HTTP listen address")
+	fs.StringVar(&cfg.roListenAddr, "listen-ro", envOrDefault("HELPDESK_AUDIT_RO_ADDR", ""), "Read-only HTTP listen address serving only GET endpoints, e.g. :1198 (optional; disabled when empty)")
+	fs.IntVar(&cfg.roMaxConcurrent, "ro-max-concurrent", 8, "Requests the read-only listener runs at once; the rest wait up to 5s, then get 503")
+	fs.StringVar(&cfg.grpcListenAddr, "grpc-listen", envOrDefault("HELPDESK_AUDIT_GRPC_ADDR", ""), "gRPC listen address, e.g. :1299 (optional; disabled when empty)")
+	fs.StringVar(&cfg.dbPath, "db", envOrDefault("HELPDESK_AUDIT_DB", "audit.db"), "Path to SQLite database")
+	fs.StringVar(&cfg.socketPath, "socket", envOrDefault("HELPDESK_AUDIT_SOCKET", "/tmp/helpdesk-audit.sock"), "Unix socket for real-time notifications")
+	fs.StringVar(&cfg.usersFile, "users-file", envOrDefault("HELPDESK_USERS_FILE", ""), "Path to users.yaml for role-based auth on approve/deny endpoints (optional)")
+
+	// Approval notification flags
+	fs.StringVar(&cfg.approvalWebhook, "approval-webhook", envOrDefault("HELPDESK_APPROVAL_WEBHOOK", ""), "Webhook URL for approval notifications (Slack, etc.)")
+	approvalBaseURL := fs.String("approval-base-url", envOrDefault("HELPDESK_APPROVAL_BASE_URL", ""), "Base URL for approve/deny links in emails (e.g., http://localhost:1199)")
+	fs.StringVar(&cfg.smtpHost, "smtp-host", envOrDefault("SMTP_HOST", ""), "SMTP server host for approval emails")
+	fs.StringVar(&cfg.smtpPort, "smtp-port", envOrDefault("SMTP_PORT", "587"), "SMTP server port")
+	fs.StringVar(&cfg.smtpUser, "smtp-user", envOrDefault("SMTP_USER", ""), "SMTP username")
+	fs.StringVar(&cfg.smtpPassword, "smtp-password", "", "SMTP password (or use SMTP_PASSWORD env)")
+	fs.StringVar(&cfg.emailFrom, "email-from", envOrDefault("HELPDESK_EMAIL_FROM", ""), "Email sender address for approvals")
+	fs.StringVar(&cfg.emailTo, "email-to", envOrDefault("HELPDESK_EMAIL_TO", ""), "Email recipients for approvals (comma-separated)")
+	fs.StringVar(&cfg.twilioSID, "twilio-account-sid", envOrDefault("TWILIO_ACCOUNT_SID", ""), "Twilio account SID for SMS/WhatsApp approval notifications (auth token from TWILIO_AUTH_TOKEN)")
+	fs.StringVar(&cfg.twilioFrom, "twilio-from", envOrDefault("TWILIO_FROM", ""), "Twilio sending number (E.164), or whatsapp:+1... for WhatsApp")
+	fs.StringVar(&cfg.smsTo, "sms-to", envOrDefault("HELPDESK_SMS_TO", ""), "Phone numbers to text for approvals (comma-separated, E.164)")
+	fs.StringVar(&cfg.smsWebhookURL, "sms-webhook-url", envOrDefault("HELPDESK_SMS_WEBHOOK_URL", ""), "Public URL of /v1/sms/inbound as configured in Twilio (default: <approval-base-url>/v1/sms/inbound)")
+	fs.BoolVar(&cfg.notifyExecuted, "approval-notify-executed", os.Getenv("HELPDESK_APPROVAL_NOTIFY_EXECUTED") == "true", "Also notify approvers when an approved action has run, with its outcome")
+	fs.StringVar(&cfg.locale, "locale", envOrDefault("HELPDESK_LOCALE", i18n.Fallback), "Default language of notifications, e.g. en, de or es")
+	fs.StringVar(&cfg.localeDir, "locale-dir", envOrDefault("HELPDESK_LOCALE_DIR", ""), "Directory of <locale>.json message catalogs adding to or overriding the built-in ones (optional)")
+	fs.StringVar(&cfg.recipientLocales, "recipient-locales", envOrDefault("HELPDESK_RECIPIENT_LOCALES", ""), "Per-recipient notification languages, e.g. *@emea.example.com=de,+34*=es (first match wins)")
+	fs.StringVar(&cfg.notifyPlugins, "approval-notify-plugin", envOrDefault("HELPDESK_APPROVAL_NOTIFY_PLUGINS", ""), "Executables to run for every approval and freeze notification, with it as JSON on stdin (comma-separated paths)")
+	fs.StringVar(&cfg.approvalSLA, "approval-sla", envOrDefault("HELPDESK_APPROVAL_SLA", ""), "Time approvals of each action class must be resolved in, e.g. destructive=15m,write=1h; misses are recorded as approval_sla_breach events (optional)")
+
+	// SIEM forwarding flags
+	fs.StringVar(&cfg.siem.SplunkURL, "siem-splunk-url", envOrDefault("HELPDESK_SIEM_SPLUNK_URL", ""), "Splunk HEC endpoint URL for forwarding audit events (optional)")
+	fs.StringVar(&cfg.siem.SplunkIndex, "siem-splunk-index", envOrDefault("HELPDESK_SIEM_SPLUNK_INDEX", ""), "Splunk index for forwarded events (default: token's index)")
+	fs.StringVar(&cfg.siem.ElasticURL, "siem-elastic-url", envOrDefault("HELPDESK_SIEM_ELASTIC_URL", ""), "Elasticsearch base URL for forwarding audit events via _bulk (optional)")
+	fs.StringVar(&cfg.siem.ElasticIndex, "siem-elastic-index", envOrDefault("HELPDESK_SIEM_ELASTIC_INDEX", "helpdesk-audit"), "Elasticsearch index for forwarded events")
+	fs.StringVar(&cfg.siem.CEFAddr, "siem-cef-addr", envOrDefault("HELPDESK_SIEM_CEF_ADDR", ""), "Syslog receiver host:port for CEF-formatted events (optional)")
+	fs.StringVar(&cfg.siem.CEFNetwork, "siem-cef-network", envOrDefault("HELPDESK_SIEM_CEF_NETWORK", "udp"), "Syslog network for CEF events: udp or tcp")
+	fs.StringVar(&cfg.siem.OTLPTracesURL, "otlp-traces-url", envOrDefault("HELPDESK_OTLP_TRACES_URL", ""), "OTLP/HTTP traces endpoint to export events carrying a W3C traceparent as spans (optional)")
+	fs.IntVar(&cfg.siem.BatchSize, "siem-batch-size", 100, "Events per SIEM delivery batch")
+	fs.DurationVar(&cfg.siem.Interval, "siem-interval", 5*time.Second, "How often the SIEM forwarder polls for new events")
+
+	// WORM export flags
+	fs.StringVar(&cfg.worm.Bucket, "worm-bucket", envOrDefault("HELPDESK_WORM_BUCKET", ""), "S3 bucket with Object Lock enabled to export the audit trail to (optional)")
+	fs.StringVar(&cfg.worm.Prefix, "worm-prefix", envOrDefault("HELPDESK_WORM_PREFIX", "helpdesk-audit"), "Key prefix for exported objects (<prefix>/<YYYY-MM-DD>/<first>-<last>.jsonl)")
+	fs.StringVar(&cfg.worm.Region, "worm-region", envOrDefault("HELPDESK_WORM_REGION", ""), "AWS region of the WORM bucket (default: AWS_REGION)")
+	fs.StringVar(&cfg.worm.Endpoint, "worm-endpoint", envOrDefault("HELPDESK_WORM_ENDPOINT", ""), "S3-compatible endpoint URL (default: https://s3.<region>.amazonaws.com)")
+	fs.StringVar(&cfg.worm.LockMode, "worm-lock-mode", envOrDefault("HELPDESK_WORM_LOCK_MODE", "COMPLIANCE"), "Object Lock mode for exported objects: COMPLIANCE or GOVERNANCE")
+	fs.IntVar(&cfg.worm.RetentionDays, "worm-retention-days", 2557, "Days each exported object is locked against deletion and overwrite")
+	fs.DurationVar(&cfg.worm.Interval, "worm-interval", 5*time.Minute, "How often new audit events are exported to WORM storage")
+	fs.IntVar(&cfg.worm.BatchSize, "worm-batch-size", 10000, "Maximum events per exported object")
+
+	// Event bus flags
+	fs.StringVar(&cfg.busNATSURL, "bus-nats-url", envOrDefault("HELPDESK_BUS_NATS_URL", ""), "NATS URL to publish audit events to (e.g., nats://localhost:4222) (optional)")
+	fs.BoolVar(&cfg.busNATSJetStream, "bus-nats-jetstream", os.Getenv("HELPDESK_BUS_NATS_JETSTREAM") == "true", "Wait for JetStream acks when publishing to NATS")
+	fs.StringVar(&cfg.busKafkaRESTURL, "bus-kafka-rest-url", envOrDefault("HELPDESK_BUS_KAFKA_REST_URL", ""), "Kafka REST Proxy URL to publish audit events to (optional)")
+	fs.StringVar(&cfg.busTopicPrefix, "bus-topic-prefix", envOrDefault("HELPDESK_BUS_TOPIC_PREFIX", audit.DefaultBusTopicPrefix), "Topic/subject prefix for published events (<prefix>.<event_type>)")
+
+	// Policy decision webhook flags
+	fs.StringVar(&cfg.decisionWebhook, "decision-webhook", envOrDefault("HELPDESK_DECISION_WEBHOOK_URL", ""), "URL to POST every enforced deny and require_approval policy decision to, HMAC-signed with HELPDESK_DECISION_WEBHOOK_SECRET (optional)")
+	fs.DurationVar(&cfg.decisionWebhookMaxAge, "decision-webhook-max-age", audit.DefaultDecisionWebhookMaxAge, "Drop decisions older than this instead of delivering them late (e.g. after a webhook outage)")
+
+	// Hash chain flags
+	fs.StringVar(&cfg.chainSharding, "chain-sharding", envOrDefault("HELPDESK_AUDIT_CHAIN_SHARDING", "global"), "Hash chain segmentation: global, session or day")
+
+	// Field encryption flags
+	fs.StringVar(&cfg.encryptFields, "encrypt-fields", envOrDefault("HELPDESK_AUDIT_ENCRYPT_FIELDS", ""), "Comma-separated event fields to encrypt at rest with HELPDESK_AUDIT_FIELD_KEY, e.g. tool.raw_command,input.user_query")
+	fs.StringVar(&cfg.decryptRoles, "decrypt-roles", envOrDefault("HELPDESK_AUDIT_DECRYPT_ROLES", "security"), "Comma-separated roles whose event queries return encrypted fields decrypted (admins always do); other callers see them redacted")
+
+	// Event sampling flags
+	fs.IntVar(&cfg.sampleReadTools, "sample-read-tools", 1, "Keep 1 in N successful read-only tool executions (1 keeps all); the rest are only counted")
+	fs.StringVar(&cfg.sampleEventTypes, "sample-event-types", envOrDefault("HELPDESK_AUDIT_SAMPLE_EVENT_TYPES", ""), "Keep 1 in N events of low-value types, e.g. tool_invoked=10,gateway_request=5")
+
+	fs.StringVar(&cfg.injectionClassifierURL, "injection-classifier-url", envOrDefault("HELPDESK_AUDIT_INJECTION_CLASSIFIER_URL", ""), "Prompt-injection classifier that scores user queries and tool outputs (POST {\"text\"} → {\"score\"}); heuristics only when empty")
+
+	fs.DurationVar(&cfg.infoCacheTTL, "info-cache-ttl", 10*time.Second, "How long governance info responses are cached (0 disables caching)")
+
+	// Confidence calibration flags
+	fs.DurationVar(&cfg.calibrationInterval, "calibration-interval", time.Hour, "How often to snapshot routing confidence calibration (0 disables the job)")
+	fs.DurationVar(&cfg.calibrationWindow, "calibration-window", 7*24*time.Hour, "How far back each calibration snapshot looks")
+	fs.DurationVar(&cfg.calibrationRetention, "calibration-retention", 90*24*time.Hour, "How long calibration snapshots are kept (0 keeps them forever)")
+
+	fs.DurationVar(&cfg.idempotencyWindow, "idempotency-window", 24*time.Hour, "How long POST /v1/events and POST /v1/approvals remember an Idempotency-Key, so retries replay the first response (0 ignores the header)")
+
+	fs.DurationVar(&cfg.orphanTimeout, "orphan-timeout", time.Hour, "Mark delegations that recorded no outcome within this long as timed_out and record an outcome_timeout event (0 disables the job)")
+
+	// Canary flags
+	fs.DurationVar(&cfg.canary.Interval, "canary-interval", 0, "How often to record a synthetic canary event and verify it end to end (0 disables the job)")
+	fs.DurationVar(&cfg.canary.SLA, "canary-sla", 30*time.Second, "How long the canary has to reach subscribers, queries and the test webhook")
+	fs.StringVar(&cfg.canary.WebhookURL, "canary-webhook", envOrDefault("HELPDESK_CANARY_WEBHOOK", ""), "Test notification channel the canary is announced on (optional)")
+	fs.StringVar(&cfg.canary.AlertURL, "canary-alert-url", envOrDefault("HELPDESK_CANARY_ALERT_URL", ""), "URL pinged after each healthy canary run, and <url>/fail when a leg fails (healthchecks.io-style)")
+
+	// SQLite storage and compaction flags
+	fs.StringVar(&cfg.sqliteJournalMode, "sqlite-journal-mode", envOrDefault("HELPDESK_SQLITE_JOURNAL_MODE", "delete"), "SQLite journal mode: delete or wal")
+	fs.IntVar(&cfg.sqliteWALAutoCheckpoint, "sqlite-wal-autocheckpoint", 0, "WAL size in pages that triggers an automatic checkpoint (0 = SQLite default, 1000)")
+	fs.BoolVar(&cfg.sqliteIncrementalVacuum, "sqlite-incremental-vacuum", os.Getenv("HELPDESK_SQLITE_INCREMENTAL_VACUUM") == "true", "Use auto_vacuum=INCREMENTAL so compaction releases free pages without rewriting the file (converts an existing database at startup)")
+	fs.DurationVar(&cfg.compactInterval, "compact-interval", 0, "How often to check the SQLite database for free pages and compact it (0 disables the job)")
+	fs.Float64Var(&cfg.compactMinFreeRatio, "compact-min-free-ratio", 0.2, "Fraction of the database file that must be free pages before a scheduled compaction runs")
+	fs.IntVar(&cfg.compactMaxPages, "compact-max-pages", 0, "Pages one incremental compaction releases at most (0 = all)")
+
+	// Database log correlation flags
+	fs.StringVar(&cfg.dbAuditUsers, "db-audit-users", envOrDefault("HELPDESK_DB_AUDIT_USERS", ""), "Comma-separated database roles the agent connects as; enables POST /v1/db-audit/logs")
+	fs.StringVar(&cfg.dbAuditAgent, "db-audit-agent", envOrDefault("HELPDESK_DB_AUDIT_AGENT", "postgres_database_agent"), "Agent whose tool executions account for changes by those roles")
+	fs.StringVar(&cfg.dbAuditApplication, "db-audit-application-name", envOrDefault("HELPDESK_DB_AUDIT_APPLICATION_NAME", ""), "application_name the agent's connections set; changes under any other name are out of band (optional)")
+	fs.DurationVar(&cfg.dbAuditTolerance, "db-audit-tolerance", 30*time.Second, "How far apart a logged statement and its tool execution may be")
+
+	// The caller has already stripped --log-level (logging.InitLogging).
+	fs.Parse(args) //nolint:errcheck
+
+	// Allow SMTP password from environment. Credentials may be given as
+	// secrets references (vault://, awssm://, gcpsm://, file://) instead of
+	// plain values; they are resolved once here.
+	if cfg.smtpPassword == "" {
+		cfg.smtpPassword = os.Getenv("SMTP_PASSWORD")
+	}
+	// SIEM credentials are read from the environment only.
+	cfg.siem.SplunkToken = os.Getenv("HELPDESK_SIEM_SPLUNK_TOKEN")
+	cfg.siem.ElasticAPIKey = os.Getenv("HELPDESK_SIEM_ELASTIC_API_KEY")
+	// The chain key signs the hash chain segment index.
+	cfg.chainKey = os.Getenv("HELPDESK_AUDIT_CHAIN_KEY")
+	// The field key wraps the data keys that encrypt -encrypt-fields.
+	cfg.fieldKey = os.Getenv("HELPDESK_AUDIT_FIELD_KEY")
+	// The pseudonym pepper keys the HMAC that replaces user IDs.
+	cfg.pepper = os.Getenv("HELPDESK_AUDIT_PSEUDONYM_PEPPER")
+	cfg.prevPeppers = os.Getenv("HELPDESK_AUDIT_PSEUDONYM_PEPPER_PREVIOUS")
+	// The link key signs one-time approve/deny links in approval emails.
+	cfg.linkKey = os.Getenv("HELPDESK_APPROVAL_LINK_KEY")
+	// The decision webhook secret signs policy decisions POSTed to -decision-webhook.
+	cfg.decisionWebhookSecret = os.Getenv("HELPDESK_DECISION_WEBHOOK_SECRET")
+	// The Twilio auth token sends messages and verifies reply webhooks.
+	cfg.twilioToken = os.Getenv("TWILIO_AUTH_TOKEN")
+	// The infra signing key signs the infrastructure config distributed to agents.
+	cfg.infraKey = os.Getenv("HELPDESK_INFRA_SIGNING_KEY")
+	// Access tokens separate event ingest and approval changes from queries.
+	cfg.tokens.write = os.Getenv("HELPDESK_AUDIT_WRITE_TOKEN")
+	cfg.tokens.read = os.Getenv("HELPDESK_AUDIT_READ_TOKEN")
+	for name, v := range map[string]*string{
+		"SMTP_PASSWORD":                            &cfg.smtpPassword,
+		"HELPDESK_SIEM_SPLUNK_TOKEN":               &cfg.siem.SplunkToken,
+		"HELPDESK_SIEM_ELASTIC_API_KEY":            &cfg.siem.ElasticAPIKey,
+		"HELPDESK_AUDIT_CHAIN_KEY":                 &cfg.chainKey,
+		"HELPDESK_AUDIT_FIELD_KEY":                 &cfg.fieldKey,
+		"HELPDESK_AUDIT_PSEUDONYM_PEPPER":          &cfg.pepper,
+		"HELPDESK_AUDIT_PSEUDONYM_PEPPER_PREVIOUS": &cfg.prevPeppers,
+		"HELPDESK_APPROVAL_LINK_KEY":               &cfg.linkKey,
+		"HELPDESK_DECISION_WEBHOOK_SECRET":         &cfg.decisionWebhookSecret,
+		"TWILIO_AUTH_TOKEN":                        &cfg.twilioToken,
+		"HELPDESK_INFRA_SIGNING_KEY":               &cfg.infraKey,
+		"HELPDESK_AUDIT_WRITE_TOKEN":               &cfg.tokens.write,
+		"HELPDESK_AUDIT_READ_TOKEN":                &cfg.tokens.read,
+	} {
+		resolved, err := secrets.Value(ctx, *v)
+		if err != nil {
+			return fmt.Errorf("resolve secret %s: %w", name, err)
+		}
+		*v = resolved
+	}
+
+	var buses []audit.EventBus
+	if cfg.busNATSURL != "" {
+		nb, err := audit.NewNATSBus(cfg.busNATSURL, cfg.busNATSJetStream)
+		if err != nil {
+			return fmt.Errorf("invalid NATS event bus configuration: %w", err)
+		}
+		buses = append(buses, nb)
+	}
+	if cfg.busKafkaRESTURL != "" {
+		buses = append(buses, audit.NewKafkaRESTBus(cfg.busKafkaRESTURL))
+	}
+	if cfg.decisionWebhook != "" {
+		if cfg.decisionWebhookSecret == "" {
+			return errors.New("-decision-webhook requires HELPDESK_DECISION_WEBHOOK_SECRET to sign decisions")
+		}
+		wh := audit.NewDecisionWebhook(cfg.decisionWebhook, []byte(cfg.decisionWebhookSecret))
+		wh.MaxAge = cfg.decisionWebhookMaxAge
+		buses = append(buses, wh)
+		slog.Info("policy decision webhook enabled", "url", cfg.decisionWebhook, "max_age", cfg.decisionWebhookMaxAge)
+	}
+
+	sharding, err := audit.ParseChainSharding(cfg.chainSharding)
+	if err != nil {
+		return fmt.Errorf("invalid -chain-sharding: %w", err)
+	}
+
+	sampleRates, err := audit.ParseSamplingRates(cfg.sampleEventTypes)
+	if err != nil {
+		return fmt.Errorf("invalid -sample-event-types: %w", err)
+	}
+	sampling := audit.SamplingPolicy{ReadToolExecutions: cfg.sampleReadTools, EventTypes: sampleRates}
+	if cfg.sampleReadTools > 1 || len(sampleRates) > 0 {
+		slog.Info("event sampling enabled", "read_tools", cfg.sampleReadTools, "event_types", cfg.sampleEventTypes)
+	}
+
+	approvalSLAs, err := audit.ParseApprovalSLAs(cfg.approvalSLA)
+	if err != nil {
+		return fmt.Errorf("invalid -approval-sla: %w", err)
+	}
+	if len(approvalSLAs) > 0 {
+		slog.Info("approval SLAs enabled", "slas", cfg.approvalSLA)
+	}
+
+	var fieldEncryption audit.FieldEncryption
+	if fieldEncryption.Fields, err = audit.ParseEncryptedFields(cfg.encryptFields); err != nil {
+		return fmt.Errorf("invalid -encrypt-fields: %w", err)
+	}
+	if len(fieldEncryption.Fields) > 0 {
+		if fieldEncryption.KEK, err = audit.ParseKeyEncryptionKey(cfg.fieldKey); err != nil {
+			return fmt.Errorf("-encrypt-fields requires HELPDESK_AUDIT_FIELD_KEY: %w", err)
+		}
+		slog.Info("field encryption enabled", "fields", fieldEncryption.Fields, "kek", fieldEncryption.KEK.ID())
+	}
+
+	var pseudonymizer *audit.Pseudonymizer
+	if cfg.pepper != "" {
+		var previous [][]byte
+		for _, p := range strings.Split(cfg.prevPeppers, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				previous = append(previous, []byte(p))
+			}
+		}
+		pseudonymizer = audit.NewPseudonymizer([]byte(cfg.pepper), previous...)
+		slog.Info("user ID pseudonymization enabled", "previous_peppers", len(previous))
+	}
+
+	// The infrastructure config names the team owning each resource: events
+	// and approvals about it carry the team, and the team's channel gets its
+	// approval notifications.
+	var inventory *infra.Config
+	if path := os.Getenv("HELPDESK_INFRA_CONFIG"); path != "" {
+		if inventory, err = infra.Load(path); err != nil {
+			slog.Warn("failed to load infra config; events and approvals won't name owning teams", "path", path, "err", err)
+		} else if teams := inventory.TeamWebhooks(); len(teams) > 0 {
+			slog.Info("team notification routing enabled", "teams", len(teams))
+		}
+	}
+
+	var classifier audit.InjectionClassifier
+	if cfg.injectionClassifierURL != "" {
+		classifier = &audit.HTTPInjectionClassifier{URL: cfg.injectionClassifierURL}
+		slog.Info("prompt-injection classifier enabled", "url", cfg.injectionClassifierURL)
+	}
+
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath:         cfg.dbPath,
+		SocketPath:     cfg.socketPath,
+		EventBuses:     buses,
+		BusTopicPrefix: cfg.busTopicPrefix,
+		ChainSharding:  sharding,
+		ChainKey:       []byte(cfg.chainKey),
+		Sampling:       sampling,
+
+		InjectionClassifier: classifier,
+		FieldEncryption:     fieldEncryption,
+		Pseudonymizer:       pseudonymizer,
+		ResourceOwner:       resourceOwner(inventory),
+		SQLite: audit.SQLiteOptions{
+			JournalMode:       cfg.sqliteJournalMode,
+			WALAutoCheckpoint: cfg.sqliteWALAutoCheckpoint,
+			IncrementalVacuum: cfg.sqliteIncrementalVacuum,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit store: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+	started := time.Now()
+	checkCleanShutdown(ctx, store)
+
+	// Create approval store (shares the same database connection)
+	approvalStore, err := audit.NewApprovalStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create approval store: %w", err)
+	}
+
+	// Create govbot store (shares the same database connection)
+	govbotStore, err := audit.NewGovbotStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create govbot store: %w", err)
+	}
+
+	// Create fleet store (shares the same database connection)
+	fleetStore, err := audit.NewFleetStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create fleet store: %w", err)
+	}
+
+	// Create playbook store (shares the same database connection)
+	playbookStore, err := audit.NewPlaybookStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create playbook store: %w", err)
+	}
+
+	// Seed system playbooks (idempotent; non-fatal if it fails).
+	if err := playbooks.SeedSystemPlaybooks(ctx, playbookStore); err != nil {
+		slog.Warn("failed to seed system playbooks", "err", err)
+	}
+
+	// Create upload store (shares the same database connection)
+	uploadStore, err := audit.NewUploadStore(store.DB())
+	if err != nil {
+		return fmt.Errorf("failed to create upload store: %w", err)
+	}
+
+	// Create tool result store (shares the same database connection)
+	toolResultStore, err := audit.NewToolResultStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create tool result store: %w", err)
+	}
+
+	// Create trace annotation store (shares the same database connection)
+	traceAnnotationStore, err := audit.NewTraceAnnotationStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create trace annotation store: %w", err)
+	}
+
+	// Create event annotation store (shares the same database connection)
+	eventAnnotationStore, err := audit.NewEventAnnotationStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create event annotation store: %w", err)
+	}
+
+	// Create delegation feedback store (shares the same database connection)
+	delegationFeedbackStore, err := audit.NewDelegationFeedbackStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create delegation feedback store: %w", err)
+	}
+
+	// Create security alert store (shares the same database connection)
+	alertStore, err := audit.NewAlertStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create alert store: %w", err)
+	}
+
+	// Create playbook run store (shares the same database connection)
+	playbookRunStore, err := audit.NewPlaybookRunStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create playbook run store: %w", err)
+	}
+
+	// Create run feedback store (shares the same database connection)
+	runFeedbackStore, err := audit.NewRunFeedbackStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create run feedback store: %w", err)
+	}
+
+	// Create run evaluation store (shares the same database connection)
+	runEvaluationStore, err := audit.NewRunEvaluationStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create run evaluation store: %w", err)
+	}
+
+	// Create fault stability store (shares the same database connection)
+	faultStabilityStore, err := audit.NewFaultStabilityStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create fault stability store: %w", err)
+	}
+
+	// Create playbook run step store (shares the same database connection)
+	playbookRunStepStore, err := audit.NewPlaybookRunStepStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create playbook run step store: %w", err)
+	}
+
+	// Create approval session store (shares the same database connection)
+	approvalSessionStore, err := audit.NewApprovalSessionStore(store.DB())
+	if err != nil {
+		return fmt.Errorf("failed to create approval session store: %w", err)
+	}
+	approvalSessionSrv := &approvalSessionServer{store: approvalSessionStore}
+
+	// Create remediation plan store (shares the same database connection)
+	remediationPlanStore, err := audit.NewRemediationPlanStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create remediation plan store: %w", err)
+	}
+
+	// Create report subscription store (shares the same database connection)
+	reportSubStore, err := audit.NewReportSubscriptionStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create report subscription store: %w", err)
+	}
+
+	// Create rollback store (shares the same database connection)
+	rollbackStore, err := audit.NewRollbackStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create rollback store: %w", err)
+	}
+
+	// Create emergency freeze store (shares the same database connection)
+	freezeStore, err := audit.NewFreezeStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create freeze store: %w", err)
+	}
+
+	// Create maintenance window store (shares the same database connection)
+	maintenanceStore, err := audit.NewMaintenanceWindowStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window store: %w", err)
+	}
+
+	// Create runtime config store (shares the same database connection)
+	runtimeConfigStore, err := audit.NewRuntimeConfigStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create runtime config store: %w", err)
+	}
+
+	// Create idempotency key store (shares the same database connection)
+	var idem *idempotencyGuard
+	if cfg.idempotencyWindow > 0 {
+		idempotencyStore, err := audit.NewIdempotencyStore(store.DB(), store.IsPostgres())
+		if err != nil {
+			return fmt.Errorf("failed to create idempotency store: %w", err)
+		}
+		idem = &idempotencyGuard{store: idempotencyStore, window: cfg.idempotencyWindow}
+	}
+
+	// Create calibration snapshot store (shares the same database connection)
+	calibrationStore, err := audit.NewCalibrationSnapshotStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create calibration snapshot store: %w", err)
+	}
+
+	// Create SIEM forwarder if any sink is configured. Cursors live in the
+	// shared database so restarts resume where delivery left off.
+	var siemFwd *siemForwarder
+	if sinks := buildSIEMSinks(cfg.siem); len(sinks) > 0 {
+		cursorStore, err := audit.NewForwardCursorStore(store.DB(), store.IsPostgres())
+		if err != nil {
+			return fmt.Errorf("failed to create forward cursor store: %w", err)
+		}
+		siemFwd = newSIEMForwarder(store, cursorStore, sinks, cfg.siem)
+		names := make([]string, len(sinks))
+		for i, sk := range sinks {
+			names[i] = sk.Name()
+		}
+		slog.Info("SIEM forwarding enabled", "sinks", strings.Join(names, ","), "batch_size", siemFwd.batchSize)
+	}
+
+	// Create the WORM exporter if a bucket is configured. Uploaded object
+	// versions are recorded in the shared database; the last one recorded is
+	// where the export resumes after a restart.
+	wormExportStore, err := audit.NewWORMExportStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		return fmt.Errorf("failed to create WORM export store: %w", err)
+	}
+	wormExp, err := newWORMExporter(cfg.worm, store, wormExportStore)
+	if err != nil {
+		return fmt.Errorf("invalid WORM export configuration: %w", err)
+	}
+	if wormExp != nil {
+		slog.Info("WORM export enabled", "bucket", cfg.worm.Bucket, "prefix", wormExp.prefix,
+			"lock_mode", wormExp.lockMode, "retention", wormExp.retention)
+	}
+
+	// Create approval notifier if configured
+	// Default baseURL to the listen address if not specified
+	baseURL := *approvalBaseURL
+	if baseURL == "" && cfg.smtpHost != "" {
+		baseURL = "http://localhost" + cfg.listenAddr
+	}
+
+	approvalLinks := newApprovalLinkSigner(cfg.linkKey)
+	twilio := &sms.Client{AccountSID: cfg.twilioSID, AuthToken: cfg.twilioToken, From: cfg.twilioFrom}
+	notifyPlugins, err := notifyplugin.ParseList(cfg.notifyPlugins)
+	if err != nil {
+		return fmt.Errorf("invalid -approval-notify-plugin: %w", err)
+	}
+	locales, err := i18n.Load(cfg.localeDir, cfg.locale)
+	if err != nil {
+		return fmt.Errorf("invalid -locale or -locale-dir: %w", err)
+	}
+	recipientLocales, err := i18n.ParseRecipients(cfg.recipientLocales)
+	if err != nil {
+		return fmt.Errorf("invalid -recipient-locales: %w", err)
+	}
+	approvalNotifier := NewApprovalNotifier(ApprovalNotifierConfig{
+		WebhookURL:   cfg.approvalWebhook,
+		TeamWebhooks: inventory.TeamWebhooks(),
+		BaseURL:      baseURL,
+		Links:        approvalLinks,
+		SMTPHost:     cfg.smtpHost,
+		SMTPPort:     cfg.smtpPort,
+		SMTPUser:     cfg.smtpUser,
+		SMTPPassword: cfg.smtpPassword,
+		EmailFrom:    cfg.emailFrom,
+		EmailTo:      cfg.emailTo,
+		SMS:          twilio,
+		SMSTo:        cfg.smsTo,
+		Plugins:      notifyPlugins,
+
+		NotifyExecuted: cfg.notifyExecuted,
+
+		Locales:          locales,
+		RecipientLocales: recipientLocales,
+	})
+	if approvalNotifier.IsEnabled() {
+		slog.Info("approval notifications enabled",
+			"webhook", cfg.approvalWebhook != "",
+			"email", cfg.smtpHost != "" && cfg.emailTo != "",
+			"sms", len(approvalNotifier.smsTo) > 0,
+			"plugins", len(notifyPlugins),
+			"signed_links", approvalLinks != nil,
+			"locale", locales.Default())
+	}
+
+	// Build identity provider. Defaults to NoAuthProvider (dev mode) when no
+	// users file is configured or HELPDESK_IDENTITY_PROVIDER=none.
+	// StaticProvider enables role-based auth when a users file is provided and
+	// the identity provider is not explicitly disabled.
+	var idProvider identity.Provider = &identity.NoAuthProvider{}
+	idMode := os.Getenv("HELPDESK_IDENTITY_PROVIDER")
+	enforcing := cfg.usersFile != "" && idMode != "none"
+	if enforcing {
+		p, err := identity.NewStaticProvider(cfg.usersFile)
+		if err != nil {
+			return fmt.Errorf("failed to load users file %s: %w", cfg.usersFile, err)
+		}
+		idProvider = p
+		slog.Info("role-based authorization enabled", "users_file", cfg.usersFile)
+	}
+
+	// Build central authorizer.
+	authzr := authz.NewAuthorizer(authz.DefaultAuditdPermissions, enforcing)
+	if enforcing {
+		slog.Info("authorization enforcing: approval and rollback endpoints require authentication")
+	} else {
+		slog.Warn("authorization NOT enforcing: all endpoints are open — set HELPDESK_USERS_FILE to enable role-based access control")
+	}
+	if cfg.tokens.enabled() {
+		slog.Info("audit access tokens enabled",
+			"write_token", cfg.tokens.write != "",
+			"read_token", cfg.tokens.read != "")
+	}
+
+	// authorize resolves the caller of a request and checks it against the
+	// route pattern, returning the request with the principal attached or
+	// the HTTP status to refuse it with.
+	authorize := func(pattern string, r *http.Request) (*http.Request, int, error) {
+		if err := cfg.tokens.check(pattern, r); err != nil {
+			slog.Info("auth: access token rejected", "pattern", pattern, "scope", cfg.tokens.scope(pattern))
+			return nil, http.StatusUnauthorized, err
+		}
+		principal, err := idProvider.Resolve(r)
+		if err != nil {
+			// Bad or unrecognized credential: fall through as anonymous and
+			// let Authorize decide. AllowAnonymous routes pass; protected
+			// routes still get 401 from the Authorize block below.
+			slog.Debug("auth: unrecognized credential, treating as anonymous",
+				"pattern", pattern, "err", err)
+			principal = identity.ResolvedPrincipal{AuthMethod: "header"}
+		}
+		if authErr := authzr.Authorize(pattern, principal); authErr != nil {
+			status := http.StatusForbidden
+			if errors.Is(authErr, authz.ErrUnauthorized) {
+				status = http.StatusUnauthorized
+			}
+			slog.Info("authz: request denied",
+				"pattern", pattern,
+				"principal", principal.EffectiveID(),
+				"anonymous", principal.IsAnonymous(),
+				"err", authErr)
+			return nil, status, authErr
+		}
+		return r.WithContext(authz.WithPrincipal(r.Context(), principal)), http.StatusOK, nil
+	}
+
+	// auth wraps a handler with per-pattern identity resolution and authorization.
+	// The pattern is captured at registration time so r.Pattern need not be set.
+	auth := func(pattern string, h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r, status, err := authorize(pattern, r)
+			if err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	traceAnnotationSrv := &traceAnnotationServer{store: traceAnnotationStore}
+	eventAnnotationSrv := &eventAnnotationServer{store: eventAnnotationStore, events: store}
+	delegationFeedbackSrv := &delegationFeedbackServer{store: delegationFeedbackStore, events: store}
+	alertSrv := &alertServer{store: alertStore}
+	fields := &fieldAccess{store: store, authorizer: authzr}
+	for _, role := range strings.Split(cfg.decryptRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			fields.roles = append(fields.roles, role)
+		}
+	}
+	srv := &server{store: store, approvals: approvalStore, notifier: approvalNotifier, annotations: traceAnnotationSrv, eventAnnotations: eventAnnotationStore, fields: fields,
+		subjectRecords: map[string]audit.SubjectRecords{
+			"approval_requests":   approvalStore,
+			"event_annotations":   eventAnnotationStore,
+			"trace_annotations":   traceAnnotationStore,
+			"delegation_feedback": delegationFeedbackStore,
+			"run_feedback":        runFeedbackStore,
+		}}
+	approvalSrv := &approvalServer{store: approvalStore, notifier: approvalNotifier, authorizer: authzr, links: approvalLinks, infra: inventory,
+		slas: approvalSLAs, events: store}
+	smsSrv := &smsServer{approvals: approvalSrv, authToken: cfg.twilioToken, webhookURL: cfg.smsWebhookURL}
+	if smsSrv.webhookURL == "" && baseURL != "" {
+		smsSrv.webhookURL = strings.TrimSuffix(baseURL, "/") + "/v1/sms/inbound"
+	}
+	// Replies need a verified webhook and users with registered phones.
+	if dir, ok := idProvider.(phoneDirectory); ok && twilio.Enabled() && smsSrv.webhookURL != "" {
+		smsSrv.users = dir
+		approvalNotifier.smsReplies = true
+		slog.Info("SMS approval replies enabled", "webhook_url", smsSrv.webhookURL)
+	}
+	freezeSrv, err := newFreezeServer(freezeStore, store, approvalNotifier)
+	if err != nil {
+		return fmt.Errorf("failed to load freeze state: %w", err)
+	}
+	maintenanceSrv, err := newMaintenanceServer(maintenanceStore, alertStore)
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+	infraSrv := &infraServer{path: os.Getenv("HELPDESK_INFRA_CONFIG")}
+	if cfg.infraKey != "" {
+		if infraSrv.key, err = infra.ParsePrivateKey(cfg.infraKey); err != nil {
+			return fmt.Errorf("invalid HELPDESK_INFRA_SIGNING_KEY: %w", err)
+		}
+		if infraSrv.path != "" {
+			slog.Info("serving signed infrastructure config", "path", infraSrv.path,
+				"public_key", base64.StdEncoding.EncodeToString(infraSrv.key.Public().(ed25519.PublicKey)))
+		}
+	}
+	govSrv := newGovernanceServer(store, approvalStore, approvalNotifier)
+	govSrv.freeze = freezeSrv
+	govSrv.maintenance = maintenanceSrv
+	govSrv.fields = fields
+	govSrv.infoTTL = cfg.infoCacheTTL
+	planSrv := &remediationPlanServer{store: remediationPlanStore, gov: govSrv}
+	govbotSrv := &govbotServer{store: govbotStore}
+	reportSubSrv := &reportSubscriptionServer{subs: reportSubStore, store: store, client: &http.Client{Timeout: 30 * time.Second}}
+	if cfg.smtpHost != "" {
+		reportSubSrv.mail = approvalNotifier.sendMail
+	}
+	fleetSrv := &fleetServer{store: fleetStore, approvalStore: approvalStore}
+	playbookSrv := &playbookServer{store: playbookStore, runStore: playbookRunStore, feedbackStore: runFeedbackStore}
+	uploadSrv := &uploadServer{store: uploadStore}
+	toolResultSrv := &toolResultServer{store: toolResultStore}
+	playbookRunSrv := &playbookRunServer{store: playbookRunStore, playbookStore: playbookStore, feedbackStore: runFeedbackStore, evaluationStore: runEvaluationStore}
+	playbookRunStepSrv := &playbookRunStepServer{store: playbookRunStepStore}
+	rollbackSrv := &rollbackServer{store: rollbackStore, auditStore: store, fleetStore: fleetStore, approvalStore: approvalStore}
+	faultStabilitySrv := &faultStabilityServer{store: faultStabilityStore}
+	calibrationSrv := &calibrationServer{
+		events:    store,
+		snapshots: calibrationStore,
+		window:    cfg.calibrationWindow,
+		retention: cfg.calibrationRetention,
+	}
+	configSrv, err := newConfigServer(runtimeConfigStore, store,
+		map[string]string{
+			"auditd.approval_webhook_url":  cfg.approvalWebhook,
+			"auditd.calibration_retention": cfg.calibrationRetention.String(),
+		},
+		map[string]func(string){
+			"auditd.approval_webhook_url": approvalNotifier.SetWebhookURL,
+			"auditd.calibration_retention": func(v string) {
+				d, _ := time.ParseDuration(v) // validated by audit.RuntimeSetting.Normalize
+				calibrationSrv.setRetention(d)
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to load runtime config: %w", err)
+	}
+	canarySrv := newCanaryServer(store, alertStore, cfg.canary)
+	compactionSrv := &compactionServer{
+		store:        store,
+		minFreeRatio: cfg.compactMinFreeRatio,
+		maxPages:     cfg.compactMaxPages,
+	}
+
+	dbAuditSrv := &dbAuditServer{
+		auditStore: store,
+		agentName:  cfg.dbAuditAgent,
+		opts: audit.DBAuditCorrelation{
+			ApplicationName: cfg.dbAuditApplication,
+			Tolerance:       cfg.dbAuditTolerance,
+		},
+	}
+	for _, u := range strings.Split(cfg.dbAuditUsers, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			dbAuditSrv.opts.AgentUsers = append(dbAuditSrv.opts.AgentUsers, u)
+		}
+	}
+
+	mux := http.NewServeMux()
+
+	// Audit event endpoints
+	mux.HandleFunc("POST /v1/events", auth("POST /v1/events", idem.wrap("POST /v1/events", srv.handleRecordEvent)))
+	mux.HandleFunc("POST /v1/events/{eventID}/outcome", auth("POST /v1/events/{eventID}/outcome", srv.handleRecordOutcome))
+	mux.HandleFunc("GET /v1/events", auth("GET /v1/events", srv.handleQueryEvents))
+	mux.HandleFunc("GET /v1/events/stats", auth("GET /v1/events/stats", srv.handleEventStats))
+	mux.HandleFunc("GET /v1/events/latency", auth("GET /v1/events/latency", srv.handleEventLatency))
+	mux.HandleFunc("GET /v1/events/clock-skew", auth("GET /v1/events/clock-skew", srv.handleClockSkew))
+	mux.HandleFunc("GET /v1/events/scorecards", auth("GET /v1/events/scorecards", srv.handleScorecards))
+	mux.HandleFunc("GET /v1/events/calibration", auth("GET /v1/events/calibration", calibrationSrv.handleCalibration))
+	mux.HandleFunc("GET /v1/events/calibration/history", auth("GET /v1/events/calibration/history", calibrationSrv.handleHistory))
+	mux.HandleFunc("GET /v1/verify", auth("GET /v1/verify", srv.handleVerifyChain))
+
+	// Approval endpoints
+	mux.HandleFunc("POST /v1/approvals", auth("POST /v1/approvals", idem.wrap("POST /v1/approvals", approvalSrv.handleCreateApproval)))
+	mux.HandleFunc("GET /v1/approvals", auth("GET /v1/approvals", approvalSrv.handleListApprovals))
+	mux.HandleFunc("GET /v1/approvals/pending", auth("GET /v1/approvals/pending", approvalSrv.handlePendingApprovals))
+	mux.HandleFunc("GET /v1/approvals/{approvalID}", auth("GET /v1/approvals/{approvalID}", approvalSrv.handleGetApproval))
+	mux.HandleFunc("GET /v1/approvals/{approvalID}/wait", auth("GET /v1/approvals/{approvalID}/wait", approvalSrv.handleWaitForApproval))
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/approve", auth("POST /v1/approvals/{approvalID}/approve", approvalSrv.handleApprove))
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/deny", auth("POST /v1/approvals/{approvalID}/deny", approvalSrv.handleDeny))
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/cancel", auth("POST /v1/approvals/{approvalID}/cancel", approvalSrv.handleCancel))
+	mux.HandleFunc("GET /v1/approvals/{approvalID}/link", auth("GET /v1/approvals/{approvalID}/link", approvalSrv.handleLinkPage))
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/link", auth("POST /v1/approvals/{approvalID}/link", approvalSrv.handleLinkConfirm))
+	mux.HandleFunc("POST /v1/sms/inbound", auth("POST /v1/sms/inbound", smsSrv.handleInbound))
+
+	// Governance endpoints
+	mux.HandleFunc("GET /v1/governance/info", auth("GET /v1/governance/info", govSrv.handleGetInfo))
+	mux.HandleFunc("GET /v1/governance/policies", auth("GET /v1/governance/policies", govSrv.handleGetPolicySummary))
+	mux.HandleFunc("GET /v1/governance/explain", auth("GET /v1/governance/explain", govSrv.handleExplain))
+	mux.HandleFunc("POST /v1/governance/check", auth("POST /v1/governance/check", govSrv.handlePolicyCheck))
+	mux.HandleFunc("POST /v1/governance/check/batch", auth("POST /v1/governance/check/batch", govSrv.handlePolicyCheckBatch))
+	mux.HandleFunc("GET /v1/events/{eventID}", auth("GET /v1/events/{eventID}", govSrv.handleGetEvent))
+	mux.HandleFunc("POST /v1/events/{eventID}/annotations", auth("POST /v1/events/{eventID}/annotations", eventAnnotationSrv.handleCreate))
+	mux.HandleFunc("GET /v1/events/{eventID}/annotations", auth("GET /v1/events/{eventID}/annotations", eventAnnotationSrv.handleList))
+	mux.HandleFunc("POST /v1/events/{eventID}/feedback", auth("POST /v1/events/{eventID}/feedback", delegationFeedbackSrv.handleSubmit))
+	mux.HandleFunc("GET /v1/events/{eventID}/feedback", auth("GET /v1/events/{eventID}/feedback", delegationFeedbackSrv.handleGet))
+	mux.HandleFunc("GET /v1/events/feedback/stats", auth("GET /v1/events/feedback/stats", delegationFeedbackSrv.handleStats))
+
+	// Auditor security alerts and false-positive feedback
+	mux.HandleFunc("POST /v1/alerts", auth("POST /v1/alerts", alertSrv.handleRecord))
+	mux.HandleFunc("GET /v1/alerts", auth("GET /v1/alerts", alertSrv.handleList))
+	mux.HandleFunc("GET /v1/alerts/suppressions", auth("GET /v1/alerts/suppressions", alertSrv.handleSuppressions))
+	mux.HandleFunc("GET /v1/alerts/precision", auth("GET /v1/alerts/precision", alertSrv.handlePrecision))
+	mux.HandleFunc("POST /v1/alerts/{alertID}/false-positive", auth("POST /v1/alerts/{alertID}/false-positive", alertSrv.handleFalsePositive))
+
+	// Emergency freeze (fleet-wide read-only switch)
+	mux.HandleFunc("GET /v1/freeze", auth("GET /v1/freeze", freezeSrv.handleGet))
+	mux.HandleFunc("POST /v1/freeze", auth("POST /v1/freeze", freezeSrv.handleFreeze))
+	mux.HandleFunc("DELETE /v1/freeze", auth("DELETE /v1/freeze", freezeSrv.handleUnfreeze))
+
+	// Maintenance windows (planned work: alerts downgraded, policies may differ)
+	mux.HandleFunc("GET /v1/config", auth("GET /v1/config", configSrv.handleGet))
+	mux.HandleFunc("PUT /v1/config", auth("PUT /v1/config", configSrv.handleUpdate))
+	mux.HandleFunc("POST /v1/maintenance-windows", auth("POST /v1/maintenance-windows", maintenanceSrv.handleCreate))
+	mux.HandleFunc("GET /v1/maintenance-windows", auth("GET /v1/maintenance-windows", maintenanceSrv.handleList))
+	mux.HandleFunc("GET /v1/maintenance-windows/{windowID}", auth("GET /v1/maintenance-windows/{windowID}", maintenanceSrv.handleGet))
+	mux.HandleFunc("PUT /v1/maintenance-windows/{windowID}", auth("PUT /v1/maintenance-windows/{windowID}", maintenanceSrv.handleUpdate))
+	mux.HandleFunc("DELETE /v1/maintenance-windows/{windowID}", auth("DELETE /v1/maintenance-windows/{windowID}", maintenanceSrv.handleDelete))
+
+	// Data subjects (GDPR erasure and pseudonymization of stored events)
+	mux.HandleFunc("POST /v1/subjects/erase", auth("POST /v1/subjects/erase", srv.handleEraseSubject))
+	mux.HandleFunc("POST /v1/subjects/pseudonymize", auth("POST /v1/subjects/pseudonymize", srv.handlePseudonymizeSubjects))
+
+	// Signed infrastructure config for agents
+	mux.HandleFunc("GET /v1/infra", auth("GET /v1/infra", infraSrv.handleGet))
+	mux.HandleFunc("GET /v1/exports/worm", auth("GET /v1/exports/worm", wormExp.handleListExports))
+
+	// Database log ingestion (out-of-band use of agent credentials)
+	mux.HandleFunc("POST /v1/db-audit/logs", auth("POST /v1/db-audit/logs", dbAuditSrv.handleIngest))
+
+	// Journey endpoint
+	mux.HandleFunc("GET /v1/journeys", auth("GET /v1/journeys", srv.handleQueryJourneys))
+	mux.HandleFunc("POST /v1/traces/{traceID}/annotations", auth("POST /v1/traces/{traceID}/annotations", traceAnnotationSrv.handleCreate))
+	mux.HandleFunc("GET /v1/traces/{traceID}/annotations", auth("GET /v1/traces/{traceID}/annotations", traceAnnotationSrv.handleList))
+
+	// Govbot compliance history endpoints
+	mux.HandleFunc("POST /v1/govbot/runs", auth("POST /v1/govbot/runs", govbotSrv.handleSaveRun))
+	mux.HandleFunc("GET /v1/govbot/runs", auth("GET /v1/govbot/runs", govbotSrv.handleGetRuns))
+
+	// Fleet runner job tracking endpoints
+	mux.HandleFunc("POST /v1/fleet/jobs", auth("POST /v1/fleet/jobs", fleetSrv.handleCreateJob))
+	mux.HandleFunc("GET /v1/fleet/jobs", auth("GET /v1/fleet/jobs", fleetSrv.handleListJobs))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}", auth("GET /v1/fleet/jobs/{jobID}", fleetSrv.handleGetJob))
+	mux.HandleFunc("PATCH /v1/fleet/jobs/{jobID}/status", auth("PATCH /v1/fleet/jobs/{jobID}/status", fleetSrv.handleUpdateStatus))
+	mux.HandleFunc("POST /v1/fleet/jobs/{jobID}/servers", auth("POST /v1/fleet/jobs/{jobID}/servers", fleetSrv.handleAddServer))
+	mux.HandleFunc("PATCH /v1/fleet/jobs/{jobID}/servers/{serverName}", auth("PATCH /v1/fleet/jobs/{jobID}/servers/{serverName}", fleetSrv.handleUpdateServer))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}/servers", auth("GET /v1/fleet/jobs/{jobID}/servers", fleetSrv.handleGetServers))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}/servers/{serverName}", auth("GET /v1/fleet/jobs/{jobID}/servers/{serverName}", fleetSrv.handleGetServer))
+	mux.HandleFunc("POST /v1/fleet/jobs/{jobID}/servers/{serverName}/steps", auth("POST /v1/fleet/jobs/{jobID}/servers/{serverName}/steps", fleetSrv.handleAddServerStep))
+	mux.HandleFunc("PATCH /v1/fleet/jobs/{jobID}/servers/{serverName}/steps/{stepIndex}", auth("PATCH /v1/fleet/jobs/{jobID}/servers/{serverName}/steps/{stepIndex}", fleetSrv.handleUpdateServerStep))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}/servers/{serverName}/steps", auth("GET /v1/fleet/jobs/{jobID}/servers/{serverName}/steps", fleetSrv.handleGetServerSteps))
+
+	// Fleet playbook endpoints
+	mux.HandleFunc("POST /v1/fleet/playbooks", auth("POST /v1/fleet/playbooks", playbookSrv.handleCreate))
+	mux.HandleFunc("GET /v1/fleet/playbooks", auth("GET /v1/fleet/playbooks", playbookSrv.handleList))
+	mux.HandleFunc("GET /v1/fleet/playbooks/{playbookID}", auth("GET /v1/fleet/playbooks/{playbookID}", playbookSrv.handleGet))
+	mux.HandleFunc("PUT /v1/fleet/playbooks/{playbookID}", auth("PUT /v1/fleet/playbooks/{playbookID}", playbookSrv.handleUpdate))
+	mux.HandleFunc("DELETE /v1/fleet/playbooks/{playbookID}", auth("DELETE /v1/fleet/playbooks/{playbookID}", playbookSrv.handleDelete))
+	mux.HandleFunc("POST /v1/fleet/playbooks/{playbookID}/activate", auth("POST /v1/fleet/playbooks/{playbookID}/activate", playbookSrv.handleActivate))
+	mux.HandleFunc("POST /v1/fleet/playbooks/{playbookID}/judge-verdict", auth("POST /v1/fleet/playbooks/{playbookID}/judge-verdict", playbookSrv.handleSetJudgeVerdict))
+	mux.HandleFunc("POST /v1/fleet/playbooks/{playbookID}/runs", auth("POST /v1/fleet/playbooks/{playbookID}/runs", playbookRunSrv.handleRecord))
+	mux.HandleFunc("PATCH /v1/fleet/playbook-runs/{runID}", auth("PATCH /v1/fleet/playbook-runs/{runID}", playbookRunSrv.handleUpdate))
+	mux.HandleFunc("GET /v1/fleet/playbooks/{playbookID}/runs", auth("GET /v1/fleet/playbooks/{playbookID}/runs", playbookRunSrv.handleList))
+	mux.HandleFunc("GET /v1/fleet/playbooks/{playbookID}/stats", auth("GET /v1/fleet/playbooks/{playbookID}/stats", playbookRunSrv.handleStats))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/{runID}", auth("GET /v1/fleet/playbook-runs/{runID}", playbookRunSrv.handleGetRun))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs", auth("GET /v1/fleet/playbook-runs", playbookRunSrv.handleListByOutcome))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/feedback-pending", auth("GET /v1/fleet/playbook-runs/feedback-pending", playbookRunSrv.handleListPendingFeedback))
+	mux.HandleFunc("POST /v1/fleet/playbook-runs/{runID}/feedback", auth("POST /v1/fleet/playbook-runs/{runID}/feedback", playbookRunSrv.handleSubmitFeedback))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/{runID}/feedback", auth("GET /v1/fleet/playbook-runs/{runID}/feedback", playbookRunSrv.handleGetFeedback))
+	mux.HandleFunc("POST /v1/fleet/playbook-runs/{runID}/evaluation", auth("POST /v1/fleet/playbook-runs/{runID}/evaluation", playbookRunSrv.handleSubmitEvaluation))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/{runID}/evaluation", auth("GET /v1/fleet/playbook-runs/{runID}/evaluation", playbookRunSrv.handleGetEvaluation))
+	mux.HandleFunc("GET /v1/fleet/series/{seriesID}/version-stats", auth("GET /v1/fleet/series/{seriesID}/version-stats", playbookRunSrv.handleVersionStats))
+	mux.HandleFunc("GET /v1/fleet/calibration", auth("GET /v1/fleet/calibration", playbookRunSrv.handleCalibration))
+	mux.HandleFunc("GET /v1/fleet/fault-run-history", auth("GET /v1/fleet/fault-run-history", playbookRunSrv.handleFaultRunHistory))
+
+	// Fault triage consistency certification (--repeat N results).
+	mux.HandleFunc("POST /v1/fleet/fault-stability", auth("POST /v1/fleet/fault-stability", faultStabilitySrv.handleUpsert))
+	mux.HandleFunc("GET /v1/fleet/fault-stability", auth("GET /v1/fleet/fault-stability", faultStabilitySrv.handleList))
+	mux.HandleFunc("GET /v1/fleet/fault-stability/{faultID}", auth("GET /v1/fleet/fault-stability/{faultID}", faultStabilitySrv.handleGet))
+
+	// Playbook run step endpoints (agent_approve mode)
+	mux.HandleFunc("POST /v1/fleet/playbook-runs/{runID}/steps", auth("POST /v1/fleet/playbook-runs/{runID}/steps", playbookRunStepSrv.handleCreateStep))
+	mux.HandleFunc("PATCH /v1/fleet/playbook-runs/{runID}/steps/{stepIndex}", auth("PATCH /v1/fleet/playbook-runs/{runID}/steps/{stepIndex}", playbookRunStepSrv.handleUpdateStep))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/{runID}/steps", auth("GET /v1/fleet/playbook-runs/{runID}/steps", playbookRunStepSrv.handleListSteps))
+	mux.HandleFunc("GET /v1/fleet/playbook-runs/{runID}/pending-step", auth("GET /v1/fleet/playbook-runs/{runID}/pending-step", playbookRunStepSrv.handleGetPendingStep))
+
+	// Approval session endpoints
+	mux.HandleFunc("POST /v1/approval/sessions", auth("POST /v1/approval/sessions", approvalSessionSrv.handleCreate))
+	mux.HandleFunc("GET /v1/approval/sessions/{sessionID}", auth("GET /v1/approval/sessions/{sessionID}", approvalSessionSrv.handleGet))
+	mux.HandleFunc("DELETE /v1/approval/sessions/{sessionID}", auth("DELETE /v1/approval/sessions/{sessionID}", approvalSessionSrv.handleRevoke))
+
+	// Remediation plans (multi-step changes approved as a whole or per step)
+	mux.HandleFunc("POST /v1/remediation-plans", auth("POST /v1/remediation-plans", planSrv.handleCreate))
+	mux.HandleFunc("GET /v1/remediation-plans", auth("GET /v1/remediation-plans", planSrv.handleList))
+	mux.HandleFunc("GET /v1/remediation-plans/{planID}", auth("GET /v1/remediation-plans/{planID}", planSrv.handleGet))
+	mux.HandleFunc("POST /v1/remediation-plans/{planID}/approve", auth("POST /v1/remediation-plans/{planID}/approve", planSrv.handleApprove))
+	mux.HandleFunc("POST /v1/remediation-plans/{planID}/deny", auth("POST /v1/remediation-plans/{planID}/deny", planSrv.handleDeny))
+	mux.HandleFunc("POST /v1/remediation-plans/{planID}/steps/{stepIndex}/approve", auth("POST /v1/remediation-plans/{planID}/steps/{stepIndex}/approve", planSrv.handleApproveStep))
+	mux.HandleFunc("POST /v1/remediation-plans/{planID}/steps/{stepIndex}/deny", auth("POST /v1/remediation-plans/{planID}/steps/{stepIndex}/deny", planSrv.handleDenyStep))
+	mux.HandleFunc("POST /v1/remediation-plans/{planID}/execute", auth("POST /v1/remediation-plans/{planID}/execute", planSrv.handleExecute))
+
+	// Report subscriptions (per-user scheduled governance reports)
+	mux.HandleFunc("POST /v1/report-subscriptions", auth("POST /v1/report-subscriptions", reportSubSrv.handleCreate))
+	mux.HandleFunc("GET /v1/report-subscriptions", auth("GET /v1/report-subscriptions", reportSubSrv.handleList))
+	mux.HandleFunc("GET /v1/report-subscriptions/{subscriptionID}", auth("GET /v1/report-subscriptions/{subscriptionID}", reportSubSrv.handleGet))
+	mux.HandleFunc("PUT /v1/report-subscriptions/{subscriptionID}", auth("PUT /v1/report-subscriptions/{subscriptionID}", reportSubSrv.handleUpdate))
+	mux.HandleFunc("DELETE /v1/report-subscriptions/{subscriptionID}", auth("DELETE /v1/report-subscriptions/{subscriptionID}", reportSubSrv.handleDelete))
+	mux.HandleFunc("GET /v1/report-subscriptions/{subscriptionID}/preview", auth("GET /v1/report-subscriptions/{subscriptionID}/preview", reportSubSrv.handlePreview))
+
+	// Tool result endpoints
+	// Upload endpoints
+	mux.HandleFunc("POST /v1/uploads", auth("POST /v1/uploads", uploadSrv.handleCreate))
+	mux.HandleFunc("GET /v1/uploads/{uploadID}", auth("GET /v1/uploads/{uploadID}", uploadSrv.handleGet))
+	mux.HandleFunc("GET /v1/uploads/{uploadID}/content", auth("GET /v1/uploads/{uploadID}/content", uploadSrv.handleGetContent))
+
+	mux.HandleFunc("POST /v1/tool-results", auth("POST /v1/tool-results", toolResultSrv.handleRecord))
+	mux.HandleFunc("GET /v1/tool-results", auth("GET /v1/tool-results", toolResultSrv.handleList))
+
+	// Fleet job approval endpoints
+	mux.HandleFunc("POST /v1/fleet/jobs/{jobID}/approval", auth("POST /v1/fleet/jobs/{jobID}/approval", fleetSrv.handleCreateJobApproval))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}/approval/{approvalID}", auth("GET /v1/fleet/jobs/{jobID}/approval/{approvalID}", fleetSrv.handleGetJobApproval))
+
+	// Rollback & Undo endpoints
+	mux.HandleFunc("POST /v1/rollbacks", auth("POST /v1/rollbacks", rollbackSrv.handleInitiateRollback))
+	mux.HandleFunc("GET /v1/rollbacks", auth("GET /v1/rollbacks", rollbackSrv.handleListRollbacks))
+	mux.HandleFunc("GET /v1/rollbacks/{rollbackID}", auth("GET /v1/rollbacks/{rollbackID}", rollbackSrv.handleGetRollback))
+	mux.HandleFunc("POST /v1/rollbacks/{rollbackID}/cancel", auth("POST /v1/rollbacks/{rollbackID}/cancel", rollbackSrv.handleCancelRollback))
+	mux.HandleFunc("POST /v1/events/{eventID}/rollback-plan", auth("POST /v1/events/{eventID}/rollback-plan", rollbackSrv.handleDeriveRollbackPlan))
+	mux.HandleFunc("POST /v1/fleet/jobs/{jobID}/rollback", auth("POST /v1/fleet/jobs/{jobID}/rollback", rollbackSrv.handleInitiateFleetRollback))
+	mux.HandleFunc("GET /v1/fleet/jobs/{jobID}/rollback", auth("GET /v1/fleet/jobs/{jobID}/rollback", rollbackSrv.handleGetFleetRollback))
+
+	// Health endpoint
+	mux.HandleFunc("GET /health", auth("GET /health", srv.handleHealth))
+	mux.HandleFunc("GET /version", auth("GET /version", buildinfo.Handler()))
+
+	// Canary status and on-demand runs
+	mux.HandleFunc("GET /v1/canary", auth("GET /v1/canary", canarySrv.handleCanary))
+	mux.HandleFunc("POST /v1/canary/run", auth("POST /v1/canary/run", canarySrv.handleRunCanary))
+
+	// Storage size, compaction and metrics
+	mux.HandleFunc("GET /v1/storage", auth("GET /v1/storage", compactionSrv.handleStorage))
+	mux.HandleFunc("POST /v1/storage/compact", auth("POST /v1/storage/compact", compactionSrv.handleCompact))
+	mux.HandleFunc("GET /metrics", auth("GET /metrics", compactionSrv.handleMetrics))
+
+	httpServer := &http.Server{
+		Addr:         cfg.listenAddr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	// A failing listener stops the service as if ctx had been cancelled,
+	// and Run returns its error.
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+
+	// The read-only listener serves the GET routes of the same mux, so
+	// dashboards can poll without being able to change anything.
+	var roServer *http.Server
+	if cfg.roListenAddr != "" {
+		roServer = &http.Server{
+			Addr:         cfg.roListenAddr,
+			Handler:      newReadOnlyHandler(mux, cfg.roMaxConcurrent),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+		go func() {
+			if err := roServer.ListenAndServe(); err != http.ErrServerClosed {
+				fail(fmt.Errorf("read-only server error: %w", err))
+			}
+		}()
+	}
+
+	// The gRPC API shares the REST routes, and with them authorization.
+	var grpcSrv *grpc.Server
+	if cfg.grpcListenAddr != "" {
+		lis, err := net.Listen("tcp", cfg.grpcListenAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on %s: %w", cfg.grpcListenAddr, err)
+		}
+		grpcSrv = newGRPCServer(mux, store, authorize)
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				slog.Error("gRPC server error", "err", err)
+			}
+		}()
+	}
+
+	// Background workers outlive ctx until the listeners have drained.
+	jobCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start background workers
+	go approvalSrv.startExpirationWorker(jobCtx)
+	if siemFwd != nil {
+		siemFwd.run(jobCtx)
+	}
+	if wormExp != nil {
+		go wormExp.run(jobCtx)
+	}
+	if cfg.calibrationInterval > 0 {
+		go calibrationSrv.startCalibrationJob(jobCtx, cfg.calibrationInterval)
+	}
+	if cfg.canary.Interval > 0 {
+		go canarySrv.startCanaryJob(jobCtx)
+	}
+	if idem != nil {
+		go idem.startPurgeJob(jobCtx)
+	}
+	if cfg.orphanTimeout > 0 {
+		orphans := &orphanJob{store: store, timeout: cfg.orphanTimeout}
+		go orphans.startOrphanJob(jobCtx)
+	}
+	go reportSubSrv.startSubscriptionJob(jobCtx)
+	if cfg.compactInterval > 0 && !store.IsPostgres() {
+		go compactionSrv.startCompactionJob(jobCtx, cfg.compactInterval)
+	}
+
+	// When ctx is done: stop accepting requests and let in-flight ones
+	// finish, stop the background workers, drain approval notifications,
+	// close the chain with a shutdown marker and flush it to the SIEM sinks,
+	// all within HELPDESK_SHUTDOWN_TIMEOUT. The store closes after that.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		timeout := shutdown.Timeout()
+		slog.Info("shutting down audit service...", "timeout", timeout)
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), timeout)
+		defer drainCancel()
+		if grpcSrv != nil {
+			// Stop rather than GracefulStop: event subscriptions never end
+			// on their own.
+			grpcSrv.Stop()
+		}
+		if roServer != nil {
+			if err := roServer.Shutdown(drainCtx); err != nil {
+				roServer.Close()
+			}
+		}
+		if err := httpServer.Shutdown(drainCtx); err != nil {
+			slog.Warn("in-flight requests did not finish before the shutdown timeout", "err", err)
+			httpServer.Close()
+		}
+		cancel()
+		shutdown.Drain(drainCtx)
+		recordShutdownMarker(drainCtx, store, started)
+		if siemFwd != nil {
+			siemFwd.flush(drainCtx)
+		}
+	}()
+
+	backend := "sqlite"
+	if store.IsPostgres() {
+		backend = "postgres"
+	}
+	slog.Info("audit service starting",
+		"version", buildinfo.Version,
+		"listen", cfg.listenAddr,
+		"grpc_listen", cfg.grpcListenAddr,
+		"listen_ro", cfg.roListenAddr,
+		"db", cfg.dbPath,
+		"backend", backend,
+		"socket", cfg.socketPath,
+		"event_buses", len(buses),
+		"authz_enforcing", enforcing)
+
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		fail(fmt.Errorf("server error: %w", err))
+	}
+
+	<-stopped
+	if err := context.Cause(ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	slog.Info("audit service stopped")
+	return nil
+}
+
+type server struct {
+	store *audit.Store
+
+	// approvals and notifier link tool_execution outcomes back to the
+	// approval that authorised them. Either may be nil.
+	approvals *audit.ApprovalStore
+	notifier  *ApprovalNotifier
+
+	// annotations adds trace annotations to journeys. May be nil.
+	annotations *traceAnnotationServer
+
+	// eventAnnotations resolves the disposition filter of event queries.
+	// May be nil, in which case the filter is rejected.
+	eventAnnotations *audit.EventAnnotationStore
+
+	// fields decrypts encrypted event fields for callers allowed to read
+	// them and redacts them for the rest. May be nil, which redacts.
+	fields *fieldAccess
+
+	// subjectRecords are the stores outside audit_events that a subject
+	// erasure rewrites, by table name.
+	subjectRecords map[string]audit.SubjectRecords
+}
+
+func (s *server) handleRecordEvent(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var event audit.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Our own clock, next to the client's Timestamp, so clock skew can be
+	// told apart from tampering. Never taken from the client.
+	received := time.Now().UTC()
+	event.ReceivedAt = &received
+
+	if err := s.store.Record(r.Context(), &event); err != nil {
+		slog.Error("failed to record event", "err", err)
+		http.Error(w, "failed to record event", http.StatusInternalServerError)
+		return
+	}
+
+	s.linkApprovalExecution(r.Context(), &event)
+
+	// Log policy decisions at an appropriate level so denials are visible in the
+	// auditd log alongside the explain-endpoint decisions.
+	if event.PolicyDecision != nil {
+		pd := event.PolicyDecision
+		attrs := []any{
+			"event_id", event.EventID,
+			"action", pd.Action,
+			"resource_type", pd.ResourceType,
+			"resource_name", pd.ResourceName,
+			"effect", pd.Effect,
+			"policy", pd.PolicyName,
+		}
+		if pd.Message != "" {
+			attrs = append(attrs, "message", pd.Message)
+		}
+		switch pd.Effect {
+		case "deny":
+			slog.Warn("policy decision recorded: DENY", attrs...)
+		case "require_approval":
+			slog.Info("policy decision recorded: REQUIRE_APPROVAL", attrs...)
+		default:
+			slog.Debug("policy decision recorded: ALLOW", attrs...)
+		}
+	}
+
+	// Return the event with computed hashes
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"event_id":   event.EventID,
+		"event_hash": event.EventHash,
+		"prev_hash":  event.PrevHash,
+	})
+}
+
+// linkApprovalExecution records the outcome of a tool_execution event that
+// carries an approval ID on that approval, so GET /v1/approvals/{id} shows
+// whether the approved action ran and how it went, and tells the approver.
+// Linking is best-effort: the event itself is already recorded.
+func (s *server) linkApprovalExecution(ctx context.Context, event *audit.Event) {
+	if s.approvals == nil || event.EventType != audit.EventTypeToolExecution ||
+		event.Approval == nil || event.Approval.ApprovalID == "" || event.Tool == nil {
+		return
+	}
+	exec := audit.ApprovalExecution{
+		EventID:      event.EventID,
+		ToolName:     event.Tool.Name,
+		RowsAffected: event.Tool.RowsAffected,
+		Error:        event.Tool.Error,
+		ExecutedAt:   event.Timestamp,
+	}
+	if event.Outcome != nil {
+		exec.Status = event.Outcome.Status
+	}
+	approval, err := s.approvals.RecordExecution(ctx, event.Approval.ApprovalID, exec)
+	if err != nil {
+		slog.Warn("failed to link tool execution to approval",
+			"approval_id", event.Approval.ApprovalID, "event_id", event.EventID, "err", err)
+		return
+	}
+	slog.Info("approved action executed",
+		"approval_id", approval.ApprovalID, "event_id", event.EventID, "status", exec.Status)
+	if s.notifier != nil {
+		s.notifier.NotifyExecuted(ctx, approval)
+	}
+}
+
+func (s *server) handleRecordOutcome(w http.ResponseWriter, r *http.Request) {
+	eventID := r.PathValue("eventID")
+	if eventID == "" {
+		http.Error(w, "missing event ID", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var outcome audit.Outcome
+	if err := json.Unmarshal(body, &outcome); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.RecordOutcome(r.Context(), eventID, &outcome); err != nil {
+		slog.Error("failed to record outcome", "err", err, "event_id", eventID)
+		http.Error(w, "failed to record outcome", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *server) handleQueryEvents(w http.ResponseWriter, r *http.Request) {
+	opts := audit.QueryOptions{
+		Limit: 100,
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.Limit = n
+		}
+	}
+
+	if v := r.URL.Query().Get("session_id"); v != "" {
+		opts.SessionID = v
+	}
+	if v := r.URL.Query().Get("trace_id"); v != "" {
+		opts.TraceID = v
+	}
+	if v := r.URL.Query().Get("trace_id_prefix"); v != "" {
+		opts.TraceIDPrefix = v
+	}
+	if v := r.URL.Query().Get("correlation_id"); v != "" {
+		opts.CorrelationID = v
+	}
+	if v := r.URL.Query().Get("event_type"); v != "" {
+		opts.EventType = audit.EventType(v)
+	}
+	if v := r.URL.Query().Get("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				opts.EventTypes = append(opts.EventTypes, audit.EventType(t))
+			}
+		}
+	}
+	if v := r.URL.Query().Get("agent"); v != "" {
+		opts.Agent = v
+	}
+	if v := r.URL.Query().Get("action_class"); v != "" {
+		opts.ActionClass = audit.ActionClass(v)
+	}
+	if v := r.URL.Query().Get("since"); v != "" {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			opts.Since = t
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			opts.Since = t
+		}
+	}
+	if v := r.URL.Query().Get("until"); v != "" {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			opts.Until = t
+		}
+	}
+	if v := r.URL.Query().Get("outcome_status"); v != "" {
+		opts.OutcomeStatus = v
+	}
+	if v := r.URL.Query().Get("origin"); v != "" {
+		opts.Origin = v
+	}
+	if v := r.URL.Query().Get("tool_name"); v != "" {
+		opts.ToolName = v
+	}
+	if v := r.URL.Query().Get("disposition"); v != "" {
+		if s.eventAnnotations == nil || !audit.ValidDisposition(v) {
+			http.Error(w, "invalid disposition", http.StatusBadRequest)
+			return
+		}
+		ids, err := s.eventAnnotations.EventIDsByDisposition(r.Context(), v)
+		if err != nil {
+			slog.Error("failed to query event dispositions", "err", err)
+			http.Error(w, "failed to query events", http.StatusInternalServerError)
+			return
+		}
+		opts.EventIDs = append([]string{}, ids...)
+	}
+	opts.TenantID = tenantScope(r)
+
+	events, err := s.store.Query(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to query events", "err", err)
+		http.Error(w, "failed to query events", http.StatusInternalServerError)
+		return
+	}
+
+	if events == nil {
+		events = []audit.Event{}
+	}
+	s.fields.events(r, events)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// handleEventStats returns SQL-side aggregate counts for a time window:
+// events by type, policy decisions by effect and resource, and tool
+// executions by action class. Accepts since/until as RFC3339 timestamps.
+func (s *server) handleEventStats(w http.ResponseWriter, r *http.Request) {
+	var opts audit.StatsOptions
+	for param, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+	opts.TenantID = tenantScope(r)
+
+	stats, err := s.store.Stats(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to aggregate events", "err", err)
+		http.Error(w, "failed to aggregate events", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleEventLatency returns latency percentiles for a time window: per agent
+// for whole requests and each of their phases (queueing, LLM reasoning, tool
+// execution, approval wait), and per tool. Accepts since/until as RFC3339
+// timestamps and an optional agent.
+func (s *server) handleEventLatency(w http.ResponseWriter, r *http.Request) {
+	opts := audit.LatencyOptions{Agent: r.URL.Query().Get("agent")}
+	for param, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+	opts.TenantID = tenantScope(r)
+
+	stats, err := s.store.LatencyStats(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to compute latency stats", "err", err)
+		http.Error(w, "failed to compute latency stats", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleClockSkew returns how far each agent's clock is from auditd's, from
+// the events it posted in a window. Accepts since as an RFC3339 timestamp
+// (default: the last hour), an optional agent, and an optional threshold
+// duration above which an agent is flagged.
+func (s *server) handleClockSkew(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := audit.ClockSkewOptions{Since: time.Now().Add(-time.Hour), Agent: q.Get("agent")}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid since: expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		opts.Since = t
+	}
+	if v := q.Get("threshold"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			http.Error(w, "invalid threshold: expected a duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		opts.Threshold = d
+	}
+	opts.TenantID = tenantScope(r)
+
+	stats, err := s.store.ClockSkewStats(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to compute clock skew", "err", err)
+		http.Error(w, "failed to compute clock skew", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats) //nolint:errcheck
+}
+
+// handleScorecards returns each agent's and user's governance scorecard for a
+// window, with trends against the previous window of the same length.
+// GET /v1/events/scorecards?since=<RFC3339>&until=<RFC3339> (default: the last 7 days)
+func (s *server) handleScorecards(w http.ResponseWriter, r *http.Request) {
+	opts := audit.ScorecardOptions{Until: time.Now(), TenantID: tenantScope(r)}
+	for param, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			http.Error(w, "invalid "+param+": expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*dst = t
+	}
+	if opts.Since.IsZero() {
+		opts.Since = opts.Until.Add(-7 * 24 * time.Hour)
+	}
+	if !opts.Since.Before(opts.Until) {
+		http.Error(w, "since must be before until", http.StatusBadRequest)
+		return
+	}
+
+	cards, err := s.store.Scorecards(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to compute scorecards", "err", err)
+		http.Error(w, "failed to compute scorecards", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cards) //nolint:errcheck
+}
+
+func (s *server) handleQueryJourneys(w http.ResponseWriter, r *http.Request) {
+	opts := audit.JourneyOptions{Limit: 50}
+
+	q := r.URL.Query()
+	if v := q.Get("user"); v != "" {
+		opts.UserID = v
+	}
+	if v := q.Get("from"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			opts.From = t
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			opts.Until = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.Limit = n
+		}
+	}
+	if v := q.Get("since"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			opts.Since = d
+		}
+	}
+	if v := q.Get("purpose"); v != "" {
+		opts.Purpose = v
+	}
+	if v := q.Get("category"); v != "" {
+		opts.Category = v
+	}
+	if v := q.Get("outcome"); v != "" {
+		opts.Outcome = v
+	}
+	if q.Get("has_retries") == "true" {
+		opts.HasRetries = true
+	}
+	if v := q.Get("trace_id"); v != "" {
+		opts.TraceID = v
+	}
+	if v := q.Get("trace_id_prefix"); v != "" {
+		opts.TraceIDPrefix = v
+	}
+	if v := q.Get("origin"); v != "" {
+		opts.Origin = v
+	}
+	if q.Get("incident_only") == "true" {
+		opts.IncidentOnly = true
+	}
+	opts.TenantID = tenantScope(r)
+
+	journeys, err := s.store.QueryJourneys(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to query journeys", "err", err)
+		http.Error(w, "failed to query journeys", http.StatusInternalServerError)
+		return
+	}
+	s.annotations.annotateJourneys(r, journeys)
+	s.fields.journeys(r, journeys)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(journeys)
+}
+
+// handleVerifyChain handles GET /v1/verify. With ?incremental=true only the
+// events appended since each segment's last verified checkpoint are re-hashed.
+func (s *server) handleVerifyChain(w http.ResponseWriter, r *http.Request) {
+	status, err := s.store.VerifySegments(r.Context(), audit.VerifyOptions{
+		Incremental: r.URL.Query().Get("incremental") == "true",
+	})
+	if err != nil {
+		slog.Error("failed to verify chain", "err", err)
+		http.Error(w, "failed to verify chain", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "version": buildinfo.Version})
+}
+
+// envOrDefault returns the value of the environment variable named by key,
+// or def if the variable is not set or empty.
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
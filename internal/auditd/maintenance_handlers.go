@@ -0,0 +1,265 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// maintenanceServer owns the maintenance window registry. Windows that have
+// not ended yet are cached in memory so that every policy check can ask
+// whether a resource is under maintenance without a database round-trip.
+type maintenanceServer struct {
+	store  *audit.MaintenanceWindowStore
+	alerts *audit.AlertStore
+
+	mu      sync.RWMutex
+	windows []audit.MaintenanceWindow // ends_at in the future at last reload
+}
+
+// newMaintenanceServer loads the windows that have not ended yet.
+func newMaintenanceServer(store *audit.MaintenanceWindowStore, alerts *audit.AlertStore) (*maintenanceServer, error) {
+	s := &maintenanceServer{store: store, alerts: alerts}
+	if err := s.reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload refreshes the cache of windows that have not ended yet.
+func (s *maintenanceServer) reload(ctx context.Context) error {
+	windows, err := s.store.List(ctx, time.Now().UTC(), time.Time{})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.windows = windows
+	s.mu.Unlock()
+	return nil
+}
+
+// covering returns the cached window visible to tenant that covers resource
+// at at, or nil. Windows without a tenant apply to every tenant. Safe on a
+// nil receiver.
+func (s *maintenanceServer) covering(tenant, resource string, at time.Time) *audit.MaintenanceWindow {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.windows {
+		mw := &s.windows[i]
+		if (mw.TenantID == "" || mw.TenantID == tenant) && mw.Covers(resource, at) {
+			cp := *mw
+			return &cp
+		}
+	}
+	return nil
+}
+
+// activeVersion identifies the set of windows active at at, so cached policy
+// decisions are dropped when a window opens, closes or changes. It is empty
+// when no window is active. Safe on a nil receiver.
+func (s *maintenanceServer) activeVersion(at time.Time) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h := fnv.New64a()
+	active := false
+	for _, mw := range s.windows {
+		if mw.ActiveAt(at) {
+			active = true
+			h.Write([]byte(mw.WindowID))                                    //nolint:errcheck
+			h.Write([]byte(strconv.FormatInt(mw.UpdatedAt.UnixNano(), 36))) //nolint:errcheck
+		}
+	}
+	if !active {
+		return ""
+	}
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// handleCreate handles POST /v1/maintenance-windows. The owner defaults to
+// the caller.
+func (s *maintenanceServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var mw audit.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	principal := authz.PrincipalFromContext(r.Context())
+	mw.CreatedBy = principal.EffectiveID()
+	if mw.CreatedBy == "" {
+		mw.CreatedBy = "anonymous"
+	}
+	if mw.Owner == "" && !principal.IsAnonymous() {
+		mw.Owner = mw.CreatedBy
+	}
+	mw.TenantID = principal.Tenant
+	mw.WindowID = "mw_" + uuid.New().String()[:8]
+	if err := s.store.Create(r.Context(), &mw); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("maintenance window created",
+		"window_id", mw.WindowID,
+		"owner", mw.Owner,
+		"reason", mw.Reason,
+		"resources", mw.Resources,
+		"starts_at", mw.StartsAt,
+		"ends_at", mw.EndsAt,
+		"by", mw.CreatedBy)
+	s.refresh(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mw) //nolint:errcheck
+}
+
+// handleList handles GET /v1/maintenance-windows. ?active=true returns only
+// the windows active now; ?since= and ?until= (RFC3339) return the windows
+// overlapping that range. Each window carries the number of alerts tagged
+// with it.
+func (s *maintenanceServer) handleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since, until time.Time
+	for name, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeJSONError(w, "invalid "+name+": use RFC3339", http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+	now := time.Now().UTC()
+	active := q.Get("active") == "true"
+	if active {
+		since, until = now, now.Add(time.Nanosecond)
+	}
+	all, err := s.store.List(r.Context(), since, until)
+	if err != nil {
+		slog.Error("failed to list maintenance windows", "err", err)
+		writeJSONError(w, "failed to list maintenance windows", http.StatusInternalServerError)
+		return
+	}
+	scope := tenantScope(r)
+	windows := make([]audit.MaintenanceWindow, 0, len(all))
+	for _, mw := range all {
+		if !inTenant(r, mw.TenantID) || (scope != "" && mw.TenantID != "" && mw.TenantID != scope) {
+			continue
+		}
+		if active && !mw.ActiveAt(now) {
+			continue
+		}
+		windows = append(windows, mw)
+	}
+	if s.alerts != nil && len(windows) > 0 {
+		from, to := since, until
+		if from.IsZero() {
+			from = windows[0].StartsAt
+		}
+		if to.IsZero() {
+			to = now
+		}
+		if counts, err := s.alerts.CountByMaintenanceWindow(r.Context(), from, to); err != nil {
+			slog.Warn("failed to count alerts by maintenance window", "err", err)
+		} else {
+			for i := range windows {
+				windows[i].Alerts = counts[windows[i].WindowID]
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"windows": windows}) //nolint:errcheck
+}
+
+// handleGet handles GET /v1/maintenance-windows/{windowID}.
+func (s *maintenanceServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	mw, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mw) //nolint:errcheck
+}
+
+// handleUpdate handles PUT /v1/maintenance-windows/{windowID}, which replaces
+// the window's owner, reason, resources and times. Extending or ending a
+// window early are both updates.
+func (s *maintenanceServer) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var upd audit.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	mw, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	upd.WindowID = mw.WindowID
+	if err := s.store.Update(r.Context(), &upd); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("maintenance window updated", "window_id", upd.WindowID,
+		"starts_at", upd.StartsAt, "ends_at", upd.EndsAt,
+		"by", authz.PrincipalFromContext(r.Context()).EffectiveID())
+	s.refresh(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(upd) //nolint:errcheck
+}
+
+// handleDelete handles DELETE /v1/maintenance-windows/{windowID}.
+func (s *maintenanceServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	mw, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.Delete(r.Context(), mw.WindowID); err != nil && !errors.Is(err, audit.ErrMaintenanceWindowNotFound) {
+		slog.Error("failed to delete maintenance window", "window_id", mw.WindowID, "err", err)
+		writeJSONError(w, "failed to delete maintenance window", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("maintenance window deleted", "window_id", mw.WindowID, "by", authz.PrincipalFromContext(r.Context()).EffectiveID())
+	s.refresh(r.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookup loads the window named in the path, answering 404 when it does not
+// exist or belongs to another tenant.
+func (s *maintenanceServer) lookup(w http.ResponseWriter, r *http.Request) (*audit.MaintenanceWindow, bool) {
+	mw, err := s.store.Get(r.Context(), r.PathValue("windowID"))
+	if errors.Is(err, audit.ErrMaintenanceWindowNotFound) || (err == nil && !inTenant(r, mw.TenantID)) {
+		writeJSONError(w, "maintenance window not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		slog.Error("failed to get maintenance window", "err", err)
+		writeJSONError(w, "failed to get maintenance window", http.StatusInternalServerError)
+		return nil, false
+	}
+	return mw, true
+}
+
+// refresh reloads the cache after a change, logging rather than failing the
+// request: the change itself is already persisted.
+func (s *maintenanceServer) refresh(ctx context.Context) {
+	if err := s.reload(ctx); err != nil {
+		slog.Warn("failed to reload maintenance windows", "err", err)
+	}
+}
@@ -0,0 +1,150 @@
+package auditd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newTestMaintenanceServer(t *testing.T, store *audit.Store) *maintenanceServer {
+	t.Helper()
+	ms, err := audit.NewMaintenanceWindowStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewMaintenanceWindowStore: %v", err)
+	}
+	as, err := audit.NewAlertStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewAlertStore: %v", err)
+	}
+	srv, err := newMaintenanceServer(ms, as)
+	if err != nil {
+		t.Fatalf("newMaintenanceServer: %v", err)
+	}
+	return srv
+}
+
+func maintenanceRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := authz.WithPrincipal(req.Context(), identity.ResolvedPrincipal{UserID: "alice", AuthMethod: "api_key"})
+	return req.WithContext(ctx)
+}
+
+func TestMaintenanceHandlers_CreateListDelete(t *testing.T) {
+	store := newTestAuditStore(t)
+	srv := newTestMaintenanceServer(t, store)
+	now := time.Now().UTC()
+
+	w := httptest.NewRecorder()
+	srv.handleCreate(w, maintenanceRequest(http.MethodPost, "/v1/maintenance-windows", `{"reason":"x"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("create without times: status = %d, want 400", w.Code)
+	}
+
+	body := `{"reason":"CHG-42 vacuum full","resources":["prod-db-*"],"starts_at":"` +
+		now.Add(-time.Hour).Format(time.RFC3339) + `","ends_at":"` + now.Add(time.Hour).Format(time.RFC3339) + `"}`
+	w = httptest.NewRecorder()
+	srv.handleCreate(w, maintenanceRequest(http.MethodPost, "/v1/maintenance-windows", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var mw audit.MaintenanceWindow
+	json.NewDecoder(w.Body).Decode(&mw) //nolint:errcheck
+	if mw.Owner != "alice" || mw.CreatedBy != "alice" || !strings.HasPrefix(mw.WindowID, "mw_") {
+		t.Errorf("created window = %+v", mw)
+	}
+
+	// The cache sees the new window at once.
+	if got := srv.covering("", "prod-db-1", now); got == nil || got.WindowID != mw.WindowID {
+		t.Errorf("covering(prod-db-1) = %+v", got)
+	}
+	if got := srv.covering("", "staging-db", now); got != nil {
+		t.Errorf("covering(staging-db) = %+v, want nil", got)
+	}
+	if srv.activeVersion(now) == "" {
+		t.Error("activeVersion empty while a window is active")
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleList(w, maintenanceRequest(http.MethodGet, "/v1/maintenance-windows?active=true", ""))
+	var list struct {
+		Windows []audit.MaintenanceWindow `json:"windows"`
+	}
+	json.NewDecoder(w.Body).Decode(&list) //nolint:errcheck
+	if w.Code != http.StatusOK || len(list.Windows) != 1 {
+		t.Fatalf("list active: status = %d, windows = %+v", w.Code, list.Windows)
+	}
+
+	req := maintenanceRequest(http.MethodDelete, "/v1/maintenance-windows/"+mw.WindowID, "")
+	req.SetPathValue("windowID", mw.WindowID)
+	w = httptest.NewRecorder()
+	srv.handleDelete(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d", w.Code)
+	}
+	if got := srv.covering("", "prod-db-1", now); got != nil {
+		t.Errorf("covering after delete = %+v, want nil", got)
+	}
+}
+
+func TestHandlePolicyCheck_MaintenanceWindow(t *testing.T) {
+	store := newTestAuditStore(t)
+	maint := newTestMaintenanceServer(t, store)
+	gs := &governanceServer{
+		policyEngine: makeEngine(t, `
+version: "1"
+policies:
+  - name: changes-in-windows
+    resources:
+      - type: database
+    rules:
+      - action: write
+        effect: deny
+        conditions:
+          maintenance_window: false
+        message: "writes only inside a maintenance window"
+      - action: [read, write]
+        effect: allow
+`),
+		auditStore:  store,
+		maintenance: maint,
+	}
+	check := func() PolicyCheckResponse {
+		t.Helper()
+		body := strings.NewReader(`{"resource_type":"database","resource_name":"prod-db-1","action":"write"}`)
+		w := httptest.NewRecorder()
+		gs.handlePolicyCheck(w, httptest.NewRequest(http.MethodPost, "/v1/governance/check", body))
+		var resp PolicyCheckResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := check(); resp.Effect != "deny" {
+		t.Errorf("write outside window: effect = %q, want deny", resp.Effect)
+	}
+	before := gs.policyVersion()
+
+	now := time.Now().UTC()
+	body := `{"owner":"bob","reason":"CHG-7","starts_at":"` + now.Add(-time.Minute).Format(time.RFC3339) +
+		`","ends_at":"` + now.Add(time.Hour).Format(time.RFC3339) + `"}`
+	w := httptest.NewRecorder()
+	maint.handleCreate(w, maintenanceRequest(http.MethodPost, "/v1/maintenance-windows", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	if resp := check(); resp.Effect != "allow" {
+		t.Errorf("write inside window: effect = %q, want allow", resp.Effect)
+	}
+	if gs.policyVersion() == before {
+		t.Error("policy version unchanged after a window opened")
+	}
+}
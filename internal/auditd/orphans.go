@@ -0,0 +1,83 @@
+package auditd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// orphanLookback is how far back a sweep looks for delegations without an
+// outcome. Older ones predate the job and are left as they are, so enabling
+// it on an existing database does not raise an alert for every old crash.
+const orphanLookback = 7 * 24 * time.Hour
+
+// orphanJob finds delegations whose outcome never arrived, usually because
+// the agent crashed mid-task, and would otherwise look in progress forever.
+// Each is marked timed_out and announced with an outcome_timeout event,
+// which the auditor alerts on and which shows up in the delegation's trace.
+type orphanJob struct {
+	store   *audit.Store
+	timeout time.Duration
+}
+
+// startOrphanJob sweeps for orphaned delegations until ctx is cancelled,
+// every minute or once per timeout if that is shorter.
+func (j *orphanJob) startOrphanJob(ctx context.Context) {
+	ticker := time.NewTicker(min(j.timeout, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.sweep(ctx); err != nil {
+				slog.Warn("orphans: sweep failed", "err", err)
+			}
+		}
+	}
+}
+
+// sweep marks the delegations that have been waiting longer than the timeout
+// and records an outcome_timeout event for each. It returns how many it marked.
+func (j *orphanJob) sweep(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	reason := fmt.Sprintf("no outcome recorded within %s", j.timeout)
+	marked, err := j.store.MarkOrphanedDelegations(ctx, now.Add(-orphanLookback), now.Add(-j.timeout), reason)
+	for _, d := range marked {
+		agent := ""
+		if d.Decision != nil {
+			agent = d.Decision.Agent
+		}
+		event := &audit.Event{
+			EventID:   "orp_" + uuid.New().String()[:8],
+			Timestamp: time.Now().UTC(),
+			EventType: audit.EventTypeOutcomeTimeout,
+			TraceID:   d.TraceID,
+			ParentID:  d.EventID,
+			Session: audit.Session{
+				ID:        d.Session.ID,
+				UserID:    d.Session.UserID,
+				AgentName: agent,
+				TenantID:  d.Session.TenantID,
+			},
+			Outcome: &audit.Outcome{
+				Status:       audit.OutcomeTimedOut,
+				ErrorMessage: reason,
+				Duration:     now.Sub(d.Timestamp),
+			},
+		}
+		if rerr := j.store.Record(ctx, event); rerr != nil {
+			slog.Warn("orphans: failed to record outcome_timeout event", "delegation", d.EventID, "err", rerr)
+			continue
+		}
+		slog.Warn("orphans: delegation never recorded an outcome",
+			"delegation", d.EventID, "agent", agent, "trace_id", d.TraceID, "age", now.Sub(d.Timestamp).Round(time.Second))
+	}
+	return len(marked), err
+}
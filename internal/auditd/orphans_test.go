@@ -0,0 +1,51 @@
+package auditd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestOrphanJob_SweepRecordsTimeoutEvent(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.Record(ctx, &audit.Event{
+		EventID:   "evt_crashed",
+		Timestamp: time.Now().UTC().Add(-2 * time.Hour),
+		EventType: audit.EventTypeDelegation,
+		TraceID:   "tr_1",
+		Session:   audit.Session{ID: "s", TenantID: "payments"},
+		Decision:  &audit.Decision{Agent: "k8s_agent"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	job := &orphanJob{store: store, timeout: time.Hour}
+	n, err := job.sweep(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("sweep = %d, %v; want 1", n, err)
+	}
+	if n, _ := job.sweep(ctx); n != 0 {
+		t.Errorf("second sweep = %d, want 0", n)
+	}
+
+	events, err := store.Query(ctx, audit.QueryOptions{EventType: audit.EventTypeOutcomeTimeout})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("outcome_timeout events = %d (err %v), want 1", len(events), err)
+	}
+	e := events[0]
+	if e.ParentID != "evt_crashed" || e.TraceID != "tr_1" || e.Session.AgentName != "k8s_agent" || e.Session.TenantID != "payments" {
+		t.Errorf("timeout event = %+v", e)
+	}
+	if e.Outcome == nil || e.Outcome.Status != audit.OutcomeTimedOut || e.Outcome.Duration < 2*time.Hour {
+		t.Errorf("timeout outcome = %+v", e.Outcome)
+	}
+}
@@ -0,0 +1,181 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+)
+
+// OTLP span kind and status codes (opentelemetry-proto trace.proto).
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+// otlpTraceSink exports audit events as spans to an OTLP/HTTP traces
+// endpoint, JSON-encoded. Only events that carry a W3C traceparent are
+// exported: each becomes a child span of the caller's span, so helpdesk's
+// work shows up inside the caller's distributed trace. Events without one
+// belong to no external trace and are skipped.
+type otlpTraceSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *otlpTraceSink) Name() string { return "otlp" }
+
+func (s *otlpTraceSink) Send(ctx context.Context, batch []audit.SequencedEvent) error {
+	var spans []otlpSpan
+	for _, se := range batch {
+		if span, ok := eventSpan(&se.Event); ok {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	payload := otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			otlpString("service.name", "helpdesk"),
+			otlpString("service.version", buildinfo.Version),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "helpdesk/audit"},
+			Spans: spans,
+		}},
+	}}}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doSIEMRequest(s.client, req, nil)
+}
+
+// eventSpan converts an audit event into an OTLP span parented on the
+// event's traceparent. The span ID is derived from the event ID, so a batch
+// re-sent after a crash produces the same spans rather than new ones.
+func eventSpan(e *audit.Event) (otlpSpan, bool) {
+	tp, ok := audit.ParseTraceParent(e.TraceParent)
+	if !ok {
+		return otlpSpan{}, false
+	}
+	sum := sha256.Sum256([]byte(e.EventID))
+
+	name := string(e.EventType)
+	if e.Tool != nil && e.Tool.Name != "" {
+		name += " " + e.Tool.Name
+	}
+	start := e.Timestamp.UnixNano()
+	end := start
+	if e.Outcome != nil && e.Outcome.Duration > 0 {
+		end += int64(e.Outcome.Duration)
+	} else if e.Tool != nil && e.Tool.Duration > 0 {
+		end += int64(e.Tool.Duration)
+	}
+
+	attrs := []otlpKeyValue{
+		otlpString("helpdesk.event_id", e.EventID),
+		otlpString("helpdesk.event_type", string(e.EventType)),
+		otlpString("helpdesk.trace_id", e.TraceID),
+	}
+	if e.ActionClass != "" {
+		attrs = append(attrs, otlpString("helpdesk.action_class", string(e.ActionClass)))
+	}
+	if e.Session.AgentName != "" {
+		attrs = append(attrs, otlpString("helpdesk.agent", e.Session.AgentName))
+	} else if e.Tool != nil && e.Tool.Agent != "" {
+		attrs = append(attrs, otlpString("helpdesk.agent", e.Tool.Agent))
+	}
+	if e.CorrelationID != "" {
+		attrs = append(attrs, otlpString("helpdesk.correlation_id", e.CorrelationID))
+	}
+
+	var status *otlpStatus
+	if e.Outcome != nil {
+		switch e.Outcome.Status {
+		case "error", "denied":
+			status = &otlpStatus{Code: otlpStatusError, Message: e.Outcome.ErrorMessage}
+		case "success":
+			status = &otlpStatus{Code: otlpStatusOK}
+		}
+	}
+	return otlpSpan{
+		TraceID:           tp.TraceID,
+		SpanID:            hex.EncodeToString(sum[:8]),
+		ParentSpanID:      tp.ParentID,
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(start, 10),
+		EndTimeUnixNano:   strconv.FormatInt(end, 10),
+		Attributes:        attrs,
+		Status:            status,
+	}, true
+}
+
+// The types below are the subset of the OTLP/HTTP JSON encoding of
+// ExportTraceServiceRequest that eventSpan fills in. Trace and span IDs are
+// hex strings and 64-bit integers decimal strings, as the encoding requires.
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
@@ -0,0 +1,95 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestEventSpan(t *testing.T) {
+	ts := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	e := &audit.Event{
+		EventID:     "tool_abc",
+		Timestamp:   ts,
+		EventType:   audit.EventTypeToolExecution,
+		TraceID:     "tr_1",
+		TraceParent: testTraceParent,
+		Tool:        &audit.ToolExecution{Name: "get_pods", Agent: "k8s_agent", Duration: 250 * time.Millisecond},
+		Outcome:     &audit.Outcome{Status: "error", ErrorMessage: "boom"},
+	}
+	span, ok := eventSpan(e)
+	if !ok {
+		t.Fatal("eventSpan skipped an event with a traceparent")
+	}
+	if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("span IDs = %s/%s, want the traceparent's trace and parent", span.TraceID, span.ParentSpanID)
+	}
+	if len(span.SpanID) != 16 {
+		t.Errorf("SpanID = %q, want 16 hex digits", span.SpanID)
+	}
+	if again, _ := eventSpan(e); again.SpanID != span.SpanID {
+		t.Error("span ID is not stable across exports of the same event")
+	}
+	if span.Name != "tool_execution get_pods" {
+		t.Errorf("Name = %q", span.Name)
+	}
+	wantEnd := ts.Add(250 * time.Millisecond).UnixNano()
+	if span.EndTimeUnixNano != strconv.FormatInt(wantEnd, 10) {
+		t.Errorf("EndTimeUnixNano = %s, want %d", span.EndTimeUnixNano, wantEnd)
+	}
+	if span.Status == nil || span.Status.Code != otlpStatusError || span.Status.Message != "boom" {
+		t.Errorf("Status = %+v, want error boom", span.Status)
+	}
+
+	e.TraceParent = ""
+	if _, ok := eventSpan(e); ok {
+		t.Error("eventSpan exported an event without a traceparent")
+	}
+}
+
+func TestOTLPTraceSink_Send(t *testing.T) {
+	var got otlpTracesRequest
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	sink := &otlpTraceSink{url: srv.URL + "/v1/traces", client: srv.Client()}
+	batch := []audit.SequencedEvent{
+		{Seq: 1, Event: audit.Event{EventID: "gw_1", EventType: audit.EventTypeGatewayRequest, Timestamp: time.Now(), TraceParent: testTraceParent}},
+		{Seq: 2, Event: audit.Event{EventID: "gw_2", EventType: audit.EventTypeGatewayRequest, Timestamp: time.Now()}},
+	}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("collector called %d times, want 1", calls)
+	}
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1 (the event without a traceparent is skipped)", len(spans))
+	}
+
+	// A batch with nothing to export makes no request.
+	if err := sink.Send(context.Background(), batch[1:]); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("collector called for a batch with no spans")
+	}
+}
@@ -0,0 +1,58 @@
+package auditd
+
+import (
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+)
+
+// resourceParams are the tool parameters that name the resource a tool call
+// touches, with the resource type policy checks give it.
+var resourceParams = []struct{ param, resourceType string }{
+	{"connection_string", "database"},
+	{"database", "database"},
+	{"namespace", "kubernetes"},
+	{"host", "host"},
+}
+
+// eventResource returns the resource an event is about: the resource of its
+// policy decision, inventory drift or approval SLA breach, else the one its
+// tool call names.
+func eventResource(event *audit.Event) (resourceType, name string) {
+	if pd := event.PolicyDecision; pd != nil && pd.ResourceName != "" {
+		return pd.ResourceType, pd.ResourceName
+	}
+	if d := event.InventoryDrift; d != nil {
+		return d.ResourceType, d.ResourceName
+	}
+	if b := event.ApprovalSLABreach; b != nil && b.ResourceName != "" {
+		return b.ResourceType, b.ResourceName
+	}
+	if event.Tool == nil {
+		return "", ""
+	}
+	for _, p := range resourceParams {
+		if v, ok := event.Tool.Parameters[p.param].(string); ok && v != "" {
+			return p.resourceType, v
+		}
+	}
+	return "", ""
+}
+
+// ownerTeam returns the team owning a resource in ic, or "".
+func ownerTeam(ic *infra.Config, resourceType, name string) string {
+	o, _ := ic.OwnerOf(resourceType, name)
+	return o.Team
+}
+
+// resourceOwner returns the audit.StoreConfig.ResourceOwner that stamps
+// events with the team owning their resource, or nil without an infra
+// config.
+func resourceOwner(ic *infra.Config) func(*audit.Event) string {
+	if ic == nil {
+		return nil
+	}
+	return func(event *audit.Event) string {
+		resourceType, name := eventResource(event)
+		return ownerTeam(ic, resourceType, name)
+	}
+}
@@ -0,0 +1,122 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/infra"
+	"helpdesk/internal/shutdown"
+)
+
+const ownershipInfra = `{
+  "db_servers": {
+    "orders-db": {"name": "orders", "connection_string": "host=orders.internal port=5432 dbname=orders", "team": "payments"},
+    "scratch-db": {"name": "scratch", "connection_string": "host=scratch dbname=scratch"}
+  },
+  "teams": {"payments": {"webhook_url": "https://hooks.example.com/payments"}}
+}`
+
+func TestResourceOwner(t *testing.T) {
+	ic, err := infra.Parse([]byte(ownershipInfra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resourceOwner(nil) != nil {
+		t.Error("resourceOwner(nil) is not nil")
+	}
+	owner := resourceOwner(ic)
+
+	tests := []struct {
+		name  string
+		event audit.Event
+		want  string
+	}{
+		{"policy decision", audit.Event{PolicyDecision: &audit.PolicyDecision{ResourceType: "database", ResourceName: "orders"}}, "payments"},
+		{"tool connection string", audit.Event{Tool: &audit.ToolExecution{Parameters: map[string]any{
+			"connection_string": "host=orders.internal port=5432 dbname=orders user=app"}}}, "payments"},
+		{"host", audit.Event{PolicyDecision: &audit.PolicyDecision{ResourceType: "host", ResourceName: "orders-db"}}, "payments"},
+		{"inventory drift", audit.Event{InventoryDrift: &audit.InventoryDrift{ResourceType: "database", ResourceName: "orders"}}, "payments"},
+		{"approval SLA breach", audit.Event{ApprovalSLABreach: &audit.ApprovalSLABreach{ResourceType: "database", ResourceName: "orders"}}, "payments"},
+		{"unowned", audit.Event{PolicyDecision: &audit.PolicyDecision{ResourceType: "database", ResourceName: "scratch"}}, ""},
+		{"no resource", audit.Event{Tool: &audit.ToolExecution{Name: "get_status_summary"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := owner(&tt.event); got != tt.want {
+			t.Errorf("%s: team = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApprovalNotifications_RoutedToOwningTeam(t *testing.T) {
+	ic, err := infra.Parse([]byte(ownershipInfra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	received := map[string][]string{} // channel -> approval IDs
+	channel := func(name string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			json.NewDecoder(r.Body).Decode(&payload) //nolint:errcheck
+			mu.Lock()
+			received[name] = append(received[name], payload["approval_id"].(string))
+			mu.Unlock()
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	global, payments := channel("global"), channel("payments")
+
+	s := newApprovalSrv(t, "")
+	s.infra = ic
+	s.notifier = NewApprovalNotifier(ApprovalNotifierConfig{
+		WebhookURL:   global.URL,
+		TeamWebhooks: map[string]string{"payments": payments.URL},
+	})
+
+	create := func(resource string) string {
+		t.Helper()
+		data, _ := json.Marshal(map[string]any{
+			"action_class":  "write",
+			"requested_by":  "database_agent",
+			"resource_type": "database",
+			"resource_name": resource,
+		})
+		w := httptest.NewRecorder()
+		s.handleCreateApproval(w, httptest.NewRequest(http.MethodPost, "/v1/approvals", bytes.NewReader(data)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			ApprovalID string `json:"approval_id"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+		return resp.ApprovalID
+	}
+	owned, unowned := create("orders"), create("scratch")
+	shutdown.Drain(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := received["payments"]; len(got) != 1 || got[0] != owned {
+		t.Errorf("payments channel got %v, want [%s]", got, owned)
+	}
+	if got := received["global"]; len(got) != 1 || got[0] != unowned {
+		t.Errorf("global webhook got %v, want [%s]", got, unowned)
+	}
+
+	a, err := s.store.GetRequest(context.Background(), owned)
+	if err != nil || a.Team != "payments" {
+		t.Errorf("stored approval team = %+v, %v", a, err)
+	}
+	list, err := s.store.ListRequests(context.Background(), audit.ApprovalQueryOptions{Team: "payments"})
+	if err != nil || len(list) != 1 || list[0].ApprovalID != owned {
+		t.Errorf("ListRequests(team=payments) = %v, %v", list, err)
+	}
+}
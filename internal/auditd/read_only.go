@@ -0,0 +1,63 @@
+package auditd
+
+import (
+	"net/http"
+	"time"
+)
+
+// readOnlyQueueTimeout is how long a read-only request waits for a free slot
+// before it is turned away with 503.
+const readOnlyQueueTimeout = 5 * time.Second
+
+// readOnlyExcluded lists GET routes the read-only listener does not serve even
+// though they are reads: they belong to a write flow that only works on the
+// main listener.
+var readOnlyExcluded = map[string]bool{
+	// Long-poll held open by agents waiting on an approval decision.
+	"GET /v1/approvals/{approvalID}/wait": true,
+	// Approval link page: its confirmation form posts back to the same host.
+	"GET /v1/approvals/{approvalID}/link": true,
+}
+
+// readOnlyHandler serves the GET routes of the main mux, with the same
+// authentication and authorization, for dashboards and govbot. Any other
+// method is refused, so nothing reachable through it can record events or
+// resolve approvals. At most cap(sem) requests run at once: they share the
+// store with event ingest (on SQLite, its single connection), and the limit
+// keeps a busy dashboard from starving writes.
+type readOnlyHandler struct {
+	mux *http.ServeMux
+	sem chan struct{}
+}
+
+func newReadOnlyHandler(mux *http.ServeMux, maxConcurrent int) *readOnlyHandler {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &readOnlyHandler{mux: mux, sem: make(chan struct{}, maxConcurrent)}
+}
+
+func (h *readOnlyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "read-only listener: only GET requests are served", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, pattern := h.mux.Handler(r); readOnlyExcluded[pattern] {
+		http.Error(w, "not served on the read-only listener", http.StatusNotFound)
+		return
+	}
+
+	timer := time.NewTimer(readOnlyQueueTimeout)
+	defer timer.Stop()
+	select {
+	case h.sem <- struct{}{}:
+		defer func() { <-h.sem }()
+		h.mux.ServeHTTP(w, r)
+	case <-timer.C:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "read-only listener busy", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+		http.Error(w, "read-only listener busy", http.StatusServiceUnavailable)
+	}
+}
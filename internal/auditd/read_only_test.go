@@ -0,0 +1,66 @@
+package auditd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestReadOnlyHandler(maxConcurrent int) *readOnlyHandler {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /v1/events", ok)
+	mux.HandleFunc("POST /v1/events", ok)
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/approve", ok)
+	mux.HandleFunc("GET /v1/approvals/{approvalID}/wait", ok)
+	return newReadOnlyHandler(mux, maxConcurrent)
+}
+
+func TestReadOnlyHandler(t *testing.T) {
+	h := newTestReadOnlyHandler(2)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/v1/events", http.StatusOK},
+		{http.MethodHead, "/v1/events", http.StatusOK},
+		{http.MethodPost, "/v1/events", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/v1/approvals/apr_1/approve", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/v1/events", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/v1/approvals/apr_1/wait", http.StatusNotFound},
+		{http.MethodGet, "/v1/unknown", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyHandler_ConcurrencyLimit(t *testing.T) {
+	h := newTestReadOnlyHandler(1)
+	h.sem <- struct{}{} // the only slot is taken
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/events", nil).WithContext(ctx))
+	if w.Code == http.StatusOK {
+		t.Errorf("request ran while the only slot was taken")
+	}
+
+	<-h.sem
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/events", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status after slot freed = %d, want 200", w.Code)
+	}
+	if len(h.sem) != 0 {
+		t.Errorf("slot not released after the request")
+	}
+}
@@ -0,0 +1,310 @@
+package auditd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/policy"
+)
+
+// maxRemediationPlanSteps bounds the size of a proposed plan, like
+// maxPolicyCheckBatchSteps bounds a batch check.
+const maxRemediationPlanSteps = maxPolicyCheckBatchSteps
+
+// remediationPlanServer handles the remediation plan endpoints.
+type remediationPlanServer struct {
+	store *audit.RemediationPlanStore
+	gov   *governanceServer // evaluates proposed steps; without an engine every mutating step needs approval
+}
+
+// handleCreate handles POST /v1/remediation-plans. Every step is evaluated
+// against policy (recorded as preflight decisions): a denied step rejects the
+// whole plan with 403, a step that needs approval starts pending, and a step
+// policy allows needs no approval.
+func (s *remediationPlanServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req audit.PlanCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Title == "" || req.RequestedBy == "" {
+		writeJSONError(w, "title and requested_by are required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Steps) == 0 {
+		writeJSONError(w, "steps is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Steps) > maxRemediationPlanSteps {
+		writeJSONError(w, fmt.Sprintf("at most %d steps per plan", maxRemediationPlanSteps), http.StatusBadRequest)
+		return
+	}
+	mutates := false
+	for i, st := range req.Steps {
+		if st.ResourceType == "" || st.ResourceName == "" || st.ActionClass == "" {
+			writeJSONError(w, fmt.Sprintf("steps[%d]: resource_type, resource_name and action_class are required", i), http.StatusBadRequest)
+			return
+		}
+		mutates = mutates || st.Mutates()
+	}
+	if !mutates {
+		writeJSONError(w, "a remediation plan needs at least one write or destructive step", http.StatusBadRequest)
+		return
+	}
+
+	plan := &audit.RemediationPlan{
+		PlanID:      "rpl_" + uuid.New().String()[:8],
+		Title:       req.Title,
+		TraceID:     req.TraceID,
+		TenantID:    req.TenantID,
+		AgentName:   req.AgentName,
+		RequestedBy: req.RequestedBy,
+	}
+	if p := authz.PrincipalFromContext(r.Context()); p.Tenant != "" {
+		plan.TenantID = p.Tenant
+	}
+	if plan.TraceID == "" {
+		plan.TraceID = "tr_" + plan.PlanID // → "tr_rpl_<uuid8>"; mirrors rollback trace IDs
+	}
+
+	for i, st := range req.Steps {
+		st.Status = audit.PlanStepNotRequired
+		st.DecidedBy, st.Reason, st.ExecutedTraceID = "", "", ""
+		if st.Mutates() {
+			st.Status = audit.PlanStepPending
+		}
+		if s.gov != nil && s.gov.policyEngine != nil {
+			dec := s.gov.checkPolicy(r.Context(), PolicyCheckRequest{
+				ResourceType: st.ResourceType,
+				ResourceName: st.ResourceName,
+				Action:       st.ActionClass,
+				TraceID:      plan.TraceID,
+				AgentName:    req.AgentName,
+				Note:         st.Description,
+				Principal:    req.Principal,
+				Purpose:      req.Purpose,
+				PurposeNote:  req.PurposeNote,
+				ToolName:     st.ToolName,
+			}, true)
+			st.PolicyName = dec.PolicyName
+			switch policy.Effect(dec.Effect) {
+			case policy.EffectDeny:
+				writeJSONError(w, fmt.Sprintf("steps[%d] (%s on %s %q) is denied by policy %s: %s",
+					i, st.ActionClass, st.ResourceType, st.ResourceName, dec.PolicyName, dec.Message), http.StatusForbidden)
+				return
+			case policy.EffectAllow:
+				st.Status = audit.PlanStepNotRequired
+			default:
+				st.Status = audit.PlanStepPending
+			}
+		}
+		plan.Steps = append(plan.Steps, st)
+	}
+
+	if err := s.store.Create(r.Context(), plan); err != nil {
+		slog.Error("failed to create remediation plan", "err", err)
+		writeJSONError(w, "failed to create plan", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("remediation plan proposed",
+		"plan_id", plan.PlanID,
+		"agent", plan.AgentName,
+		"requested_by", plan.RequestedBy,
+		"steps", len(plan.Steps),
+		"status", plan.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan) //nolint:errcheck
+}
+
+// handleList handles GET /v1/remediation-plans?status=&agent=&trace_id=&limit=.
+func (s *remediationPlanServer) handleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := audit.RemediationPlanListOptions{
+		Status:    q.Get("status"),
+		AgentName: q.Get("agent"),
+		TraceID:   q.Get("trace_id"),
+		TenantID:  tenantScope(r),
+	}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.Limit = n
+		}
+	}
+	plans, err := s.store.List(r.Context(), opts)
+	if err != nil {
+		slog.Error("failed to list remediation plans", "err", err)
+		writeJSONError(w, "failed to list plans", http.StatusInternalServerError)
+		return
+	}
+	if plans == nil {
+		plans = []*audit.RemediationPlan{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans) //nolint:errcheck
+}
+
+// handleGet handles GET /v1/remediation-plans/{planID}.
+func (s *remediationPlanServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	plan, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan) //nolint:errcheck
+}
+
+// handleApprove handles POST /v1/remediation-plans/{planID}/approve.
+func (s *remediationPlanServer) handleApprove(w http.ResponseWriter, r *http.Request) {
+	s.decide(w, r, -1, true)
+}
+
+// handleDeny handles POST /v1/remediation-plans/{planID}/deny.
+func (s *remediationPlanServer) handleDeny(w http.ResponseWriter, r *http.Request) {
+	s.decide(w, r, -1, false)
+}
+
+// handleApproveStep handles POST /v1/remediation-plans/{planID}/steps/{stepIndex}/approve.
+func (s *remediationPlanServer) handleApproveStep(w http.ResponseWriter, r *http.Request) {
+	if step, ok := stepIndexParam(w, r); ok {
+		s.decide(w, r, step, true)
+	}
+}
+
+// handleDenyStep handles POST /v1/remediation-plans/{planID}/steps/{stepIndex}/deny.
+func (s *remediationPlanServer) handleDenyStep(w http.ResponseWriter, r *http.Request) {
+	if step, ok := stepIndexParam(w, r); ok {
+		s.decide(w, r, step, false)
+	}
+}
+
+func stepIndexParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	step, err := strconv.Atoi(r.PathValue("stepIndex"))
+	if err != nil || step < 0 {
+		writeJSONError(w, "stepIndex must be a non-negative integer", http.StatusBadRequest)
+		return 0, false
+	}
+	return step, true
+}
+
+// decide approves or denies the plan (step < 0) or one of its steps. As with
+// approval requests, an authenticated approver must differ from the requester
+// (four-eyes); in unauthenticated mode decided_by comes from the body.
+func (s *remediationPlanServer) decide(w http.ResponseWriter, r *http.Request, step int, approve bool) {
+	var body struct {
+		DecidedBy string `json:"decided_by"`
+		Reason    string `json:"reason,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	plan, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	principal := authz.PrincipalFromContext(r.Context())
+	if !principal.IsAnonymous() && principal.EffectiveID() != "" {
+		body.DecidedBy = principal.EffectiveID()
+	} else if body.DecidedBy == "" {
+		writeJSONError(w, "decided_by is required", http.StatusBadRequest)
+		return
+	}
+	if audit.IsSelfApproval(plan.RequestedBy, body.DecidedBy) {
+		writeJSONError(w, "four-eyes constraint: approver and requester must be different people", http.StatusForbidden)
+		return
+	}
+
+	plan, err := s.store.Decide(r.Context(), plan.PlanID, step, approve, body.DecidedBy, body.Reason)
+	if err != nil {
+		if errors.Is(err, audit.ErrPlanConflict) {
+			writeJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.Error("failed to decide remediation plan", "plan_id", r.PathValue("planID"), "err", err)
+		writeJSONError(w, "failed to update plan", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("remediation plan decided",
+		"plan_id", plan.PlanID,
+		"step", step,
+		"approved", approve,
+		"decided_by", body.DecidedBy,
+		"status", plan.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan) //nolint:errcheck
+}
+
+// handleExecute handles POST /v1/remediation-plans/{planID}/execute, called
+// by the policy enforcer just before a write or destructive tool call runs.
+// 200 returns the claimed step; 409 means the call is not the plan's next
+// approved step and must not run.
+func (s *remediationPlanServer) handleExecute(w http.ResponseWriter, r *http.Request) {
+	var call audit.PlanStepCall
+	if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if call.ResourceType == "" || call.ResourceName == "" || call.ActionClass == "" {
+		writeJSONError(w, "resource_type, resource_name and action_class are required", http.StatusBadRequest)
+		return
+	}
+	plan, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	step, err := s.store.Execute(r.Context(), plan.PlanID, call)
+	if err != nil {
+		if errors.Is(err, audit.ErrPlanConflict) {
+			slog.Warn("remediation plan step rejected", "plan_id", plan.PlanID, "resource", call.ResourceName, "action", call.ActionClass, "err", err)
+			writeJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.Error("failed to execute remediation plan step", "plan_id", plan.PlanID, "err", err)
+		writeJSONError(w, "failed to update plan", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("remediation plan step executing",
+		"plan_id", plan.PlanID,
+		"step", step.Index,
+		"tool", step.ToolName,
+		"trace_id", call.TraceID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(step) //nolint:errcheck
+}
+
+// lookup fetches the plan named in the path, writing 404 when it does not
+// exist or belongs to another tenant.
+func (s *remediationPlanServer) lookup(w http.ResponseWriter, r *http.Request) (*audit.RemediationPlan, bool) {
+	planID := r.PathValue("planID")
+	plan, err := s.store.Get(r.Context(), planID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, "plan not found", http.StatusNotFound)
+			return nil, false
+		}
+		slog.Error("failed to get remediation plan", "plan_id", planID, "err", err)
+		writeJSONError(w, "failed to get plan", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !inTenant(r, plan.TenantID) {
+		writeJSONError(w, "plan not found", http.StatusNotFound)
+		return nil, false
+	}
+	return plan, true
+}
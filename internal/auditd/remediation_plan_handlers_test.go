@@ -0,0 +1,205 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newPlanServer(t *testing.T, policyYAML string) *remediationPlanServer {
+	t.Helper()
+	store := newTestAuditStore(t)
+	ps, err := audit.NewRemediationPlanStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewRemediationPlanStore: %v", err)
+	}
+	gs := &governanceServer{auditStore: store}
+	if policyYAML != "" {
+		gs.policyEngine = makeEngine(t, policyYAML)
+	}
+	return &remediationPlanServer{store: ps, gov: gs}
+}
+
+func doPlanRequest(srv *remediationPlanServer, h func(*remediationPlanServer) http.HandlerFunc,
+	path, body string, principal *identity.ResolvedPrincipal, pathValues ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for i := 0; i+1 < len(pathValues); i += 2 {
+		req.SetPathValue(pathValues[i], pathValues[i+1])
+	}
+	if principal != nil {
+		req = req.WithContext(authz.WithPrincipal(req.Context(), *principal))
+	}
+	w := httptest.NewRecorder()
+	h(srv)(w, req)
+	return w
+}
+
+const cancelPlanBody = `{"title":"clear blocking session","agent_name":"db-agent","requested_by":"alice","steps":[
+	{"tool_name":"get_session_info","resource_type":"database","resource_name":"prod-db","action_class":"read"},
+	{"tool_name":"cancel_query","resource_type":"database","resource_name":"prod-db","action_class":"write","estimated_rows":1}]}`
+
+func createPlan(t *testing.T, srv *remediationPlanServer, body string) audit.RemediationPlan {
+	t.Helper()
+	w := doPlanRequest(srv, func(s *remediationPlanServer) http.HandlerFunc { return s.handleCreate }, "/v1/remediation-plans", body, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201; body: %s", w.Code, w.Body.String())
+	}
+	var plan audit.RemediationPlan
+	if err := json.NewDecoder(w.Body).Decode(&plan); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return plan
+}
+
+func TestHandlePlanCreate_EvaluatesSteps(t *testing.T) {
+	srv := newPlanServer(t, remediationPolicyYAML)
+	plan := createPlan(t, srv, cancelPlanBody)
+
+	if plan.Status != audit.PlanPending || !strings.HasPrefix(plan.TraceID, "tr_rpl_") {
+		t.Errorf("plan = status %q, trace %q", plan.Status, plan.TraceID)
+	}
+	if plan.Steps[0].Status != audit.PlanStepNotRequired || plan.Steps[1].Status != audit.PlanStepPending {
+		t.Errorf("step statuses = %q, %q; want not_required, pending", plan.Steps[0].Status, plan.Steps[1].Status)
+	}
+	if plan.Steps[1].PolicyName != "remediation-policy" || plan.Steps[1].EstimatedRows != 1 {
+		t.Errorf("step 1 = %+v", plan.Steps[1])
+	}
+
+	// Every step was recorded as a preflight policy decision on the plan's trace.
+	events, err := srv.gov.auditStore.Query(context.Background(), audit.QueryOptions{TraceID: plan.TraceID})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 2 || !events[0].PolicyDecision.Preflight {
+		t.Errorf("recorded %d decisions on the plan trace, want 2 preflight decisions", len(events))
+	}
+}
+
+func TestHandlePlanCreate_DeniedStepRejectsPlan(t *testing.T) {
+	srv := newPlanServer(t, remediationPolicyYAML)
+	w := doPlanRequest(srv, func(s *remediationPlanServer) http.HandlerFunc { return s.handleCreate }, "/v1/remediation-plans",
+		`{"title":"kill it","requested_by":"alice","steps":[
+		{"tool_name":"terminate_connection","resource_type":"database","resource_name":"prod-db","action_class":"destructive"}]}`, nil)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "no terminations") {
+		t.Errorf("status = %d, body %s; want 403 naming the policy message", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlePlanCreate_Validation(t *testing.T) {
+	srv := newPlanServer(t, "")
+	for name, body := range map[string]string{
+		"no title":   `{"requested_by":"alice","steps":[{"resource_type":"database","resource_name":"db","action_class":"write"}]}`,
+		"no steps":   `{"title":"x","requested_by":"alice"}`,
+		"reads only": `{"title":"x","requested_by":"alice","steps":[{"resource_type":"database","resource_name":"db","action_class":"read"}]}`,
+		"incomplete": `{"title":"x","requested_by":"alice","steps":[{"resource_type":"database","action_class":"write"}]}`,
+	} {
+		w := doPlanRequest(srv, func(s *remediationPlanServer) http.HandlerFunc { return s.handleCreate }, "/v1/remediation-plans", body, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+}
+
+func TestHandlePlanCreate_NoEngineRequiresApproval(t *testing.T) {
+	srv := newPlanServer(t, "")
+	plan := createPlan(t, srv, cancelPlanBody)
+	if plan.Steps[1].Status != audit.PlanStepPending {
+		t.Errorf("write step status = %q, want pending without a policy engine", plan.Steps[1].Status)
+	}
+}
+
+func TestHandlePlanDecide_FourEyes(t *testing.T) {
+	srv := newPlanServer(t, remediationPolicyYAML)
+	plan := createPlan(t, srv, cancelPlanBody)
+	approve := func(s *remediationPlanServer) http.HandlerFunc { return s.handleApprove }
+
+	alice := identity.ResolvedPrincipal{UserID: "alice", Roles: []string{"dba"}, AuthMethod: "jwt"}
+	w := doPlanRequest(srv, approve, "/", `{}`, &alice, "planID", plan.PlanID)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("self-approval: status = %d, want 403", w.Code)
+	}
+
+	bob := identity.ResolvedPrincipal{UserID: "bob", Roles: []string{"dba"}, AuthMethod: "jwt"}
+	w = doPlanRequest(srv, approve, "/", `{"decided_by":"mallory","reason":"ok"}`, &bob, "planID", plan.PlanID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var got audit.RemediationPlan
+	json.NewDecoder(w.Body).Decode(&got) //nolint:errcheck
+	if got.Status != audit.PlanApproved || got.ResolvedBy != "bob" {
+		t.Errorf("approved plan = status %q, resolved by %q; want approved by the authenticated bob", got.Status, got.ResolvedBy)
+	}
+
+	// Nothing left to approve.
+	w = doPlanRequest(srv, approve, "/", `{}`, &bob, "planID", plan.PlanID)
+	if w.Code != http.StatusConflict {
+		t.Errorf("second approval: status = %d, want 409", w.Code)
+	}
+}
+
+func TestHandlePlanExecute_StepByStep(t *testing.T) {
+	srv := newPlanServer(t, "")
+	plan := createPlan(t, srv, `{"title":"restart","requested_by":"alice","steps":[
+		{"tool_name":"cancel_query","resource_type":"database","resource_name":"prod-db","action_class":"write"},
+		{"tool_name":"terminate_connection","resource_type":"database","resource_name":"prod-db","action_class":"destructive"}]}`)
+	execute := func(s *remediationPlanServer) http.HandlerFunc { return s.handleExecute }
+	denyStep := func(s *remediationPlanServer) http.HandlerFunc { return s.handleDenyStep }
+	approveStep := func(s *remediationPlanServer) http.HandlerFunc { return s.handleApproveStep }
+	cancel := `{"tool_name":"cancel_query","resource_type":"database","resource_name":"prod-db","action_class":"write"}`
+	terminate := `{"tool_name":"terminate_connection","resource_type":"database","resource_name":"prod-db","action_class":"destructive"}`
+
+	if w := doPlanRequest(srv, execute, "/", cancel, nil, "planID", plan.PlanID); w.Code != http.StatusConflict {
+		t.Errorf("unapproved step: status = %d, want 409", w.Code)
+	}
+	if w := doPlanRequest(srv, approveStep, "/", `{"decided_by":"bob"}`, nil, "planID", plan.PlanID, "stepIndex", "0"); w.Code != http.StatusOK {
+		t.Fatalf("approve step 0: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if w := doPlanRequest(srv, denyStep, "/", `{"decided_by":"bob"}`, nil, "planID", plan.PlanID, "stepIndex", "1"); w.Code != http.StatusOK {
+		t.Fatalf("deny step 1: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if w := doPlanRequest(srv, execute, "/", cancel, nil, "planID", plan.PlanID); w.Code != http.StatusOK {
+		t.Errorf("approved step: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if w := doPlanRequest(srv, execute, "/", terminate, nil, "planID", plan.PlanID); w.Code != http.StatusConflict {
+		t.Errorf("denied step: status = %d, want 409", w.Code)
+	}
+	if w := doPlanRequest(srv, execute, "/", cancel, nil, "planID", "rpl_missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown plan: status = %d, want 404", w.Code)
+	}
+	if w := doPlanRequest(srv, approveStep, "/", `{"decided_by":"bob"}`, nil, "planID", plan.PlanID, "stepIndex", "x"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad step index: status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandlePlanGet_TenantScoped(t *testing.T) {
+	srv := newPlanServer(t, "")
+	acme := identity.ResolvedPrincipal{Service: "db-agent", Tenant: "acme", AuthMethod: "api_key"}
+	w := doPlanRequest(srv, func(s *remediationPlanServer) http.HandlerFunc { return s.handleCreate }, "/v1/remediation-plans", cancelPlanBody, &acme)
+	var plan audit.RemediationPlan
+	json.NewDecoder(w.Body).Decode(&plan) //nolint:errcheck
+	if plan.TenantID != "acme" {
+		t.Fatalf("TenantID = %q, want the caller's tenant", plan.TenantID)
+	}
+
+	get := func(p identity.ResolvedPrincipal) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/remediation-plans/"+plan.PlanID, nil)
+		req.SetPathValue("planID", plan.PlanID)
+		req = req.WithContext(authz.WithPrincipal(req.Context(), p))
+		rec := httptest.NewRecorder()
+		srv.handleGet(rec, req)
+		return rec.Code
+	}
+	if code := get(acme); code != http.StatusOK {
+		t.Errorf("own tenant: status = %d, want 200", code)
+	}
+	if code := get(identity.ResolvedPrincipal{UserID: "eve", Tenant: "globex", AuthMethod: "header"}); code != http.StatusNotFound {
+		t.Errorf("other tenant: status = %d, want 404", code)
+	}
+}
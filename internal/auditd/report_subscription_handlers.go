@@ -0,0 +1,344 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// reportSubscriptionServer handles the report subscription endpoints and
+// delivers the reports when they fall due. Each user subscribes to the
+// agents, resources and event types they own, instead of everyone reading
+// the same govbot digest.
+type reportSubscriptionServer struct {
+	subs  *audit.ReportSubscriptionStore
+	store *audit.Store
+	// mail sends a report email; nil when no SMTP server is configured, in
+	// which case subscriptions can only be delivered by webhook.
+	mail   func(to []string, subject, body string) error
+	client *http.Client
+}
+
+// handleCreate handles POST /v1/report-subscriptions. The caller owns the
+// subscription; in unauthenticated mode the owner comes from the body.
+func (s *reportSubscriptionServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var sub audit.ReportSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	principal := authz.PrincipalFromContext(r.Context())
+	if !principal.IsAnonymous() && principal.EffectiveID() != "" {
+		sub.Owner = principal.EffectiveID()
+	} else if sub.Owner == "" {
+		writeJSONError(w, "owner is required", http.StatusBadRequest)
+		return
+	}
+	if principal.Tenant != "" {
+		sub.TenantID = principal.Tenant
+	}
+	if !s.checkDelivery(w, &sub) {
+		return
+	}
+	sub.SubscriptionID = "rsub_" + uuid.New().String()[:8]
+	if err := s.subs.Create(r.Context(), &sub); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("report subscription created",
+		"subscription_id", sub.SubscriptionID,
+		"owner", sub.Owner,
+		"frequency", sub.Frequency,
+		"next_run_at", sub.NextRunAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub) //nolint:errcheck
+}
+
+// handleList handles GET /v1/report-subscriptions?owner=.
+func (s *reportSubscriptionServer) handleList(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.subs.List(r.Context(), tenantScope(r), r.URL.Query().Get("owner"))
+	if err != nil {
+		slog.Error("failed to list report subscriptions", "err", err)
+		writeJSONError(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs) //nolint:errcheck
+}
+
+// handleGet handles GET /v1/report-subscriptions/{subscriptionID}.
+func (s *reportSubscriptionServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub) //nolint:errcheck
+}
+
+// handleUpdate handles PUT /v1/report-subscriptions/{subscriptionID}, which
+// replaces the subscription's schedule, filters and delivery targets.
+func (s *reportSubscriptionServer) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var upd audit.ReportSubscription
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub, ok := s.lookupOwned(w, r)
+	if !ok {
+		return
+	}
+	upd.SubscriptionID = sub.SubscriptionID
+	if !s.checkDelivery(w, &upd) {
+		return
+	}
+	if err := s.subs.Update(r.Context(), &upd); err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("report subscription updated", "subscription_id", upd.SubscriptionID, "by", authz.PrincipalFromContext(r.Context()).EffectiveID())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(upd) //nolint:errcheck
+}
+
+// handleDelete handles DELETE /v1/report-subscriptions/{subscriptionID}.
+func (s *reportSubscriptionServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.lookupOwned(w, r)
+	if !ok {
+		return
+	}
+	if err := s.subs.Delete(r.Context(), sub.SubscriptionID); err != nil && !errors.Is(err, audit.ErrSubscriptionNotFound) {
+		slog.Error("failed to delete report subscription", "subscription_id", sub.SubscriptionID, "err", err)
+		writeJSONError(w, "failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("report subscription deleted", "subscription_id", sub.SubscriptionID, "by", authz.PrincipalFromContext(r.Context()).EffectiveID())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePreview handles GET /v1/report-subscriptions/{subscriptionID}/preview:
+// the report the subscription would deliver now, without sending it.
+func (s *reportSubscriptionServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	until := time.Now().UTC()
+	rep, err := s.store.SubscriptionReport(r.Context(), sub, until.Add(-sub.Period()), until)
+	if err != nil {
+		slog.Error("failed to build subscription report", "subscription_id", sub.SubscriptionID, "err", err)
+		writeJSONError(w, "failed to build report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep) //nolint:errcheck
+}
+
+// checkDelivery rejects email delivery when no SMTP server is configured,
+// writing 400.
+func (s *reportSubscriptionServer) checkDelivery(w http.ResponseWriter, sub *audit.ReportSubscription) bool {
+	if len(sub.Email) > 0 && s.mail == nil {
+		writeJSONError(w, "email delivery is not configured on this server (-smtp-host); use webhook_url", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// lookup fetches the subscription named in the path, writing 404 when it
+// does not exist or belongs to another tenant.
+func (s *reportSubscriptionServer) lookup(w http.ResponseWriter, r *http.Request) (*audit.ReportSubscription, bool) {
+	id := r.PathValue("subscriptionID")
+	sub, err := s.subs.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, audit.ErrSubscriptionNotFound) {
+			writeJSONError(w, "subscription not found", http.StatusNotFound)
+			return nil, false
+		}
+		slog.Error("failed to get report subscription", "subscription_id", id, "err", err)
+		writeJSONError(w, "failed to get subscription", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !inTenant(r, sub.TenantID) {
+		writeJSONError(w, "subscription not found", http.StatusNotFound)
+		return nil, false
+	}
+	return sub, true
+}
+
+// lookupOwned is lookup for changes: only the owner or an admin may change
+// a subscription. In unauthenticated mode anyone may.
+func (s *reportSubscriptionServer) lookupOwned(w http.ResponseWriter, r *http.Request) (*audit.ReportSubscription, bool) {
+	sub, ok := s.lookup(w, r)
+	if !ok {
+		return nil, false
+	}
+	p := authz.PrincipalFromContext(r.Context())
+	if !p.IsAnonymous() && p.EffectiveID() != "" && p.EffectiveID() != sub.Owner && !p.HasRole("admin") {
+		writeJSONError(w, "only the subscription's owner or an admin may change it", http.StatusForbidden)
+		return nil, false
+	}
+	return sub, true
+}
+
+// startSubscriptionJob delivers the reports that have fallen due, checking
+// every minute until ctx is cancelled.
+func (s *reportSubscriptionServer) startSubscriptionJob(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.runDue(ctx, time.Now().UTC()); err != nil {
+				slog.Warn("report subscriptions: run failed", "err", err)
+			}
+		}
+	}
+}
+
+// runDue builds and delivers every report due at now and schedules each
+// subscription's next run. A subscription that missed runs while auditd was
+// down gets one report, for the latest window. It returns how many reports
+// were delivered.
+func (s *reportSubscriptionServer) runDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.subs.Due(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range due {
+		sub := &due[i]
+		until := sub.NextRunAt
+		for next := sub.NextRun(until); !next.After(now); next = sub.NextRun(next) {
+			until = next
+		}
+		rep, err := s.store.SubscriptionReport(ctx, sub, until.Add(-sub.Period()), until)
+		if err == nil {
+			err = s.deliver(ctx, sub, rep)
+		}
+		if err != nil {
+			slog.Warn("report subscriptions: delivery failed", "subscription_id", sub.SubscriptionID, "owner", sub.Owner, "err", err)
+		} else {
+			sent++
+			slog.Info("report subscriptions: report delivered", "subscription_id", sub.SubscriptionID, "owner", sub.Owner, "events", rep.TotalEvents)
+		}
+		if merr := s.subs.MarkRun(ctx, sub.SubscriptionID, now, err); merr != nil {
+			slog.Warn("report subscriptions: failed to schedule next run", "subscription_id", sub.SubscriptionID, "err", merr)
+		}
+	}
+	return sent, nil
+}
+
+// deliver sends a report to the subscription's webhook and email recipients.
+func (s *reportSubscriptionServer) deliver(ctx context.Context, sub *audit.ReportSubscription, rep *audit.SubscriptionReport) error {
+	var errs []error
+	if sub.WebhookURL != "" {
+		if err := s.postReport(ctx, sub.WebhookURL, rep); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if len(sub.Email) > 0 {
+		if s.mail == nil {
+			errs = append(errs, fmt.Errorf("email: no SMTP server configured"))
+		} else if err := s.mail(sub.Email, reportSubject(rep), reportText(rep)); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postReport POSTs the report as JSON.
+func (s *reportSubscriptionServer) postReport(ctx context.Context, url string, rep *audit.SubscriptionReport) error {
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Helpdesk-Subscription-Id", rep.SubscriptionID)
+	client := s.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// reportSubject is the subject line of a report email.
+func reportSubject(rep *audit.SubscriptionReport) string {
+	title := rep.Name
+	if title == "" {
+		title = rep.SubscriptionID
+	}
+	return fmt.Sprintf("[helpdesk] %s report: %s (%d events, %d notable)",
+		strings.ToUpper(rep.Frequency[:1])+rep.Frequency[1:], title, rep.TotalEvents, rep.NotableTotal)
+}
+
+// reportText renders a report as the plain-text body of an email.
+func reportText(rep *audit.SubscriptionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Helpdesk %s report, %s to %s\n", rep.Frequency, rep.Since, rep.Until)
+	var filters []string
+	if rep.Agent != "" {
+		filters = append(filters, "agent "+rep.Agent)
+	}
+	if rep.Resource != "" {
+		filters = append(filters, "resource "+rep.Resource)
+	}
+	if len(rep.EventTypes) > 0 {
+		filters = append(filters, "event types "+strings.Join(rep.EventTypes, ", "))
+	}
+	if len(filters) > 0 {
+		fmt.Fprintf(&b, "Filtered to %s\n", strings.Join(filters, "; "))
+	}
+	fmt.Fprintf(&b, "\nEvents:            %d\n", rep.TotalEvents)
+	fmt.Fprintf(&b, "Tool executions:   %d (%d failed, %d destructive)\n", rep.ToolExecutions, rep.ToolErrors, rep.Destructive)
+	fmt.Fprintf(&b, "Policy decisions:  %d (%d denied, %d needed approval)\n", rep.PolicyDecisions, rep.Denied, rep.ApprovalsNeeded)
+	writeCounts := func(title string, counts []audit.ReportCount) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, c := range counts {
+			fmt.Fprintf(&b, "  %-30s %d\n", c.Name, c.Count)
+		}
+	}
+	writeCounts("Top resources", rep.TopResources)
+	writeCounts("Top agents", rep.TopAgents)
+	if rep.NotableTotal > 0 {
+		fmt.Fprintf(&b, "\nNotable events (%d):\n", rep.NotableTotal)
+		for _, e := range rep.Notable {
+			fmt.Fprintf(&b, "  %s  %s  %s\n", e.Timestamp.UTC().Format(time.RFC3339), e.EventID, e.Summary)
+		}
+		if more := rep.NotableTotal - len(rep.Notable); more > 0 {
+			fmt.Fprintf(&b, "  ... and %d more\n", more)
+		}
+	}
+	fmt.Fprintf(&b, "\nManage this subscription: helpdeskctl subscriptions get %s\n", rep.SubscriptionID)
+	return b.String()
+}
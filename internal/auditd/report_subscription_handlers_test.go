@@ -0,0 +1,189 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func newReportSubServer(t *testing.T) *reportSubscriptionServer {
+	t.Helper()
+	store := newTestAuditStore(t)
+	subs, err := audit.NewReportSubscriptionStore(store.DB(), false)
+	if err != nil {
+		t.Fatalf("NewReportSubscriptionStore: %v", err)
+	}
+	return &reportSubscriptionServer{subs: subs, store: store}
+}
+
+func doReportSubRequest(h http.HandlerFunc, method, body string, principal identity.ResolvedPrincipal, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/v1/report-subscriptions", strings.NewReader(body))
+	if id != "" {
+		req.SetPathValue("subscriptionID", id)
+	}
+	req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+	h(w, req)
+	return w
+}
+
+func TestReportSubscriptionHandlers_OwnershipAndTenancy(t *testing.T) {
+	srv := newReportSubServer(t)
+	alice := identity.ResolvedPrincipal{UserID: "alice", Tenant: "payments", AuthMethod: "api_key"}
+	bob := identity.ResolvedPrincipal{UserID: "bob", Tenant: "payments", AuthMethod: "api_key"}
+	carol := identity.ResolvedPrincipal{UserID: "carol", Tenant: "search", AuthMethod: "api_key"}
+
+	// Email needs an SMTP server; without one only webhooks are accepted.
+	w := doReportSubRequest(srv.handleCreate, http.MethodPost, `{"frequency":"daily","email":["alice@example.com"]}`, alice, "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("email without SMTP: status = %d, want 400", w.Code)
+	}
+	w = doReportSubRequest(srv.handleCreate, http.MethodPost,
+		`{"name":"payments writes","frequency":"weekly","hour":7,"agent":"k8s_agent","webhook_url":"https://hooks.example.com/r","owner":"mallory","tenant_id":"search"}`, alice, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var sub audit.ReportSubscription
+	json.NewDecoder(w.Body).Decode(&sub) //nolint:errcheck
+	if !strings.HasPrefix(sub.SubscriptionID, "rsub_") || sub.Owner != "alice" || sub.TenantID != "payments" ||
+		sub.NextRunAt.Weekday() != time.Monday {
+		t.Errorf("created = %+v", sub)
+	}
+	if w := doReportSubRequest(srv.handleCreate, http.MethodPost, `{"frequency":"hourly","webhook_url":"https://h"}`, alice, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid frequency: status = %d, want 400", w.Code)
+	}
+
+	// Another tenant cannot see it; a colleague can but cannot change it.
+	if w := doReportSubRequest(srv.handleGet, http.MethodGet, "", carol, sub.SubscriptionID); w.Code != http.StatusNotFound {
+		t.Errorf("other tenant get: status = %d, want 404", w.Code)
+	}
+	w = doReportSubRequest(srv.handleList, http.MethodGet, "", carol, "")
+	var list []audit.ReportSubscription
+	json.NewDecoder(w.Body).Decode(&list) //nolint:errcheck
+	if len(list) != 0 {
+		t.Errorf("other tenant list = %+v, want empty", list)
+	}
+	if w := doReportSubRequest(srv.handleGet, http.MethodGet, "", bob, sub.SubscriptionID); w.Code != http.StatusOK {
+		t.Errorf("colleague get: status = %d, want 200", w.Code)
+	}
+	if w := doReportSubRequest(srv.handleDelete, http.MethodDelete, "", bob, sub.SubscriptionID); w.Code != http.StatusForbidden {
+		t.Errorf("colleague delete: status = %d, want 403", w.Code)
+	}
+
+	w = doReportSubRequest(srv.handleUpdate, http.MethodPut,
+		`{"frequency":"daily","hour":6,"resource":"prod-*","webhook_url":"https://hooks.example.com/r"}`, alice, sub.SubscriptionID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, body %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&sub) //nolint:errcheck
+	if sub.Frequency != audit.ReportDaily || sub.Resource != "prod-*" || sub.Owner != "alice" || sub.NextRunAt.Hour() != 6 {
+		t.Errorf("updated = %+v", sub)
+	}
+
+	if w := doReportSubRequest(srv.handlePreview, http.MethodGet, "", bob, sub.SubscriptionID); w.Code != http.StatusOK {
+		t.Errorf("preview: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if w := doReportSubRequest(srv.handleDelete, http.MethodDelete, "", alice, sub.SubscriptionID); w.Code != http.StatusNoContent {
+		t.Errorf("owner delete: status = %d, want 204", w.Code)
+	}
+	if w := doReportSubRequest(srv.handleGet, http.MethodGet, "", alice, sub.SubscriptionID); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404", w.Code)
+	}
+}
+
+func TestReportSubscriptionJob_DeliversDueReports(t *testing.T) {
+	srv := newReportSubServer(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var posted []audit.SubscriptionReport
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rep audit.SubscriptionReport
+		json.NewDecoder(r.Body).Decode(&rep) //nolint:errcheck
+		mu.Lock()
+		posted = append(posted, rep)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+	var mailed []string
+	srv.mail = func(to []string, subject, body string) error {
+		mailed = append(mailed, strings.Join(to, ",")+" "+subject)
+		return nil
+	}
+
+	sub := &audit.ReportSubscription{SubscriptionID: "rsub_1", Frequency: audit.ReportDaily, Hour: 8, Agent: "k8s_agent",
+		WebhookURL: hook.URL, Email: []string{"alice@example.com"}, Owner: "alice"}
+	if err := srv.subs.Create(ctx, sub); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The subscription's window ends at its scheduled run; a run in it is reported.
+	if err := srv.store.Record(ctx, &audit.Event{
+		EventID:     "tool_1",
+		Timestamp:   sub.NextRunAt.Add(-time.Hour),
+		EventType:   audit.EventTypeToolExecution,
+		ActionClass: audit.ActionDestructive,
+		Session:     audit.Session{ID: "s"},
+		Tool:        &audit.ToolExecution{Name: "delete_pod", Agent: "k8s_agent", Parameters: map[string]any{"namespace": "prod"}},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if n, err := srv.runDue(ctx, sub.NextRunAt.Add(-time.Minute)); err != nil || n != 0 {
+		t.Fatalf("runDue before the run = %d, %v; want 0", n, err)
+	}
+	// Two days late: one report, for the latest window only.
+	now := sub.NextRunAt.Add(48*time.Hour + time.Minute)
+	n, err := srv.runDue(ctx, now)
+	if err != nil || n != 1 {
+		t.Fatalf("runDue = %d, %v; want 1", n, err)
+	}
+	if len(posted) != 1 || posted[0].Until != sub.NextRunAt.Add(48*time.Hour).Format(time.RFC3339) || posted[0].TotalEvents != 0 {
+		t.Errorf("posted = %+v", posted)
+	}
+	if len(mailed) != 1 || !strings.HasPrefix(mailed[0], "alice@example.com [helpdesk] Daily report: rsub_1") {
+		t.Errorf("mailed = %v", mailed)
+	}
+	got, _ := srv.subs.Get(ctx, "rsub_1")
+	if got.LastSentAt == nil || !got.NextRunAt.After(now) {
+		t.Errorf("after run: %+v", got)
+	}
+
+	// A failing webhook is recorded on the subscription.
+	hook.Close()
+	if n, _ := srv.runDue(ctx, got.NextRunAt); n != 0 {
+		t.Errorf("runDue with dead webhook = %d, want 0", n)
+	}
+	got, _ = srv.subs.Get(ctx, "rsub_1")
+	if !strings.HasPrefix(got.LastError, "webhook:") {
+		t.Errorf("LastError = %q, want a webhook error", got.LastError)
+	}
+}
+
+func TestReportText(t *testing.T) {
+	rep := &audit.SubscriptionReport{
+		SubscriptionID: "rsub_1", Frequency: audit.ReportWeekly, Since: "2026-03-02T08:00:00Z", Until: "2026-03-09T08:00:00Z",
+		Agent: "k8s_agent", TotalEvents: 3, ToolExecutions: 3, Destructive: 1,
+		TopResources: []audit.ReportCount{{Name: "prod", Count: 3}},
+		Notable:      []audit.ReportEvent{{EventID: "tool_1", Summary: "destructive call delete_pod"}},
+		NotableTotal: 2,
+	}
+	text := reportText(rep)
+	for _, want := range []string{"Filtered to agent k8s_agent", "3 (0 failed, 1 destructive)", "prod", "destructive call delete_pod", "and 1 more"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report text missing %q:\n%s", want, text)
+		}
+	}
+	if got := reportSubject(rep); got != "[helpdesk] Weekly report: rsub_1 (3 events, 2 notable)" {
+		t.Errorf("subject = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestHandleScorecards(t *testing.T) {
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	srv := &server{store: store}
+
+	if err := store.Record(context.Background(), &audit.Event{
+		EventID:     "tool_1",
+		Timestamp:   time.Now().UTC().Add(-time.Hour),
+		EventType:   audit.EventTypeToolExecution,
+		ActionClass: audit.ActionDestructive,
+		Session:     audit.Session{ID: "s", UserID: "alice"},
+		Tool:        &audit.ToolExecution{Name: "delete_pod", Agent: "k8s_agent"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleScorecards(w, httptest.NewRequest(http.MethodGet, "/v1/events/scorecards", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var cards audit.Scorecards
+	if err := json.Unmarshal(w.Body.Bytes(), &cards); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(cards.Agents) != 1 || cards.Agents[0].Name != "k8s_agent" || cards.Agents[0].Destructive != 1 {
+		t.Errorf("agents = %+v", cards.Agents)
+	}
+	if len(cards.Users) != 1 || cards.Users[0].Name != "alice" {
+		t.Errorf("users = %+v", cards.Users)
+	}
+	since, _ := time.Parse(time.RFC3339, cards.Since)
+	prev, _ := time.Parse(time.RFC3339, cards.PreviousSince)
+	if d := since.Sub(prev); d < 7*24*time.Hour-time.Second || d > 7*24*time.Hour+time.Second {
+		t.Errorf("previous window = %s, want the 7 days before since", d)
+	}
+
+	for _, q := range []string{"?since=yesterday", "?since=2026-03-02T00:00:00Z&until=2026-03-01T00:00:00Z"} {
+		w := httptest.NewRecorder()
+		srv.handleScorecards(w, httptest.NewRequest(http.MethodGet, "/v1/events/scorecards"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package auditd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// recordShutdownMarker closes the chain with a service_shutdown event. It is
+// recorded after in-flight requests have drained, so it is the last event
+// this process writes. Outcome.Duration is the process uptime.
+func recordShutdownMarker(ctx context.Context, store *audit.Store, started time.Time) {
+	now := time.Now().UTC()
+	event := &audit.Event{
+		EventID:   "sdn_" + uuid.New().String()[:8],
+		Timestamp: now,
+		EventType: audit.EventTypeServiceShutdown,
+		TraceID:   audit.NewTraceIDWithPrefix("sdn_"),
+		Session:   audit.Session{ID: "auditd", UserID: "auditd"},
+		Outcome:   &audit.Outcome{Status: "success", Duration: now.Sub(started)},
+	}
+	if err := store.Record(ctx, event); err != nil {
+		slog.Error("failed to record shutdown marker", "err", err)
+		return
+	}
+	slog.Info("audit chain closed", "event_id", event.EventID, "hash", event.EventHash)
+}
+
+// checkCleanShutdown warns when the newest event is not a shutdown marker:
+// the previous auditd was killed or crashed, so writes that were in flight
+// at the time may be missing from the chain.
+func checkCleanShutdown(ctx context.Context, store *audit.Store) {
+	events, err := store.Query(ctx, audit.QueryOptions{Limit: 1})
+	if err != nil || len(events) == 0 {
+		return
+	}
+	if last := events[0]; last.EventType != audit.EventTypeServiceShutdown {
+		slog.Warn("previous audit service run did not shut down cleanly; in-flight events may be missing",
+			"last_event_id", last.EventID, "last_event_type", last.EventType, "last_event_at", last.Timestamp)
+	}
+}
@@ -0,0 +1,50 @@
+package auditd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+func TestShutdown_MarkerClosesChainAndIsFlushedToSIEM(t *testing.T) {
+	sink := &recordingSink{}
+	f, store := newSIEMTestForwarder(t, 3, sink)
+	f.interval = time.Hour // only the drain at start-up runs on its own
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.run(ctx)
+	cancel()
+
+	recordShutdownMarker(context.Background(), store, time.Now().Add(-time.Minute))
+	f.flush(context.Background())
+
+	events, err := store.Query(context.Background(), audit.QueryOptions{Limit: 1})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Query newest = %v, %v", events, err)
+	}
+	marker := events[0]
+	if marker.EventType != audit.EventTypeServiceShutdown || !strings.HasPrefix(marker.EventID, "sdn_") {
+		t.Fatalf("newest event = %s %s, want the service_shutdown marker", marker.EventType, marker.EventID)
+	}
+	if marker.Outcome == nil || marker.Outcome.Duration < time.Minute {
+		t.Errorf("marker outcome = %+v, want the uptime as duration", marker.Outcome)
+	}
+
+	status, err := store.VerifyIntegrity(context.Background())
+	if err != nil || !status.Valid || status.TotalEvents != 4 {
+		t.Errorf("chain after shutdown = %+v, %v; want valid with 4 events", status, err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	var delivered []string
+	for _, b := range sink.batches {
+		delivered = append(delivered, b...)
+	}
+	if len(delivered) != 4 || delivered[3] != marker.EventID {
+		t.Errorf("SIEM received %v, want the 3 events then the marker exactly once", delivered)
+	}
+}
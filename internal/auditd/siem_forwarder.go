@@ -0,0 +1,437 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+)
+
+// SIEMSink delivers batches of audit events to an external SIEM.
+// Send must be all-or-nothing from the forwarder's point of view: a nil
+// error means every event in the batch was accepted and the cursor may
+// advance past the last one.
+type SIEMSink interface {
+	Name() string
+	Send(ctx context.Context, batch []audit.SequencedEvent) error
+}
+
+// SIEMForwarderConfig configures the SIEM forwarder and its sinks.
+// A sink is enabled when its URL/address is non-empty.
+type SIEMForwarderConfig struct {
+	SplunkURL   string // HEC endpoint, e.g. https://splunk:8088/services/collector/event
+	SplunkToken string
+	SplunkIndex string
+
+	ElasticURL    string // cluster base URL; events are POSTed to <url>/_bulk
+	ElasticIndex  string
+	ElasticAPIKey string
+
+	CEFNetwork string // "udp" (default) or "tcp"
+	CEFAddr    string // syslog receiver host:port
+
+	OTLPTracesURL string // OTLP/HTTP traces endpoint, e.g. http://collector:4318/v1/traces
+
+	BatchSize  int           // events per Send call (default 100)
+	Interval   time.Duration // poll interval when caught up (default 5s)
+	MaxRetries int           // attempts per batch before backing off to the next tick (default 5)
+	RetryDelay time.Duration // initial retry delay, doubled per attempt (default 1s)
+}
+
+// buildSIEMSinks returns the sinks enabled by cfg.
+func buildSIEMSinks(cfg SIEMForwarderConfig) []SIEMSink {
+	client := &http.Client{Timeout: 30 * time.Second}
+	var sinks []SIEMSink
+	if cfg.SplunkURL != "" {
+		sinks = append(sinks, &splunkHECSink{
+			url:    cfg.SplunkURL,
+			token:  cfg.SplunkToken,
+			index:  cfg.SplunkIndex,
+			client: client,
+		})
+	}
+	if cfg.ElasticURL != "" {
+		index := cfg.ElasticIndex
+		if index == "" {
+			index = "helpdesk-audit"
+		}
+		sinks = append(sinks, &elasticBulkSink{
+			url:    strings.TrimSuffix(cfg.ElasticURL, "/"),
+			index:  index,
+			apiKey: cfg.ElasticAPIKey,
+			client: client,
+		})
+	}
+	if cfg.CEFAddr != "" {
+		network := cfg.CEFNetwork
+		if network == "" {
+			network = "udp"
+		}
+		sinks = append(sinks, &cefSyslogSink{network: network, addr: cfg.CEFAddr})
+	}
+	if cfg.OTLPTracesURL != "" {
+		sinks = append(sinks, &otlpTraceSink{url: cfg.OTLPTracesURL, client: client})
+	}
+	return sinks
+}
+
+// siemForwarder tails audit_events by insertion sequence and ships each
+// batch to every configured sink. Each sink has its own persisted cursor so
+// a slow or unavailable SIEM never holds back the others, and a restart
+// resumes exactly after the last acknowledged event (at-least-once delivery:
+// a crash between Send and cursor update re-sends that one batch).
+type siemForwarder struct {
+	store      *audit.Store
+	cursors    *audit.ForwardCursorStore
+	sinks      []SIEMSink
+	batchSize  int
+	interval   time.Duration
+	maxRetries int
+	retryDelay time.Duration
+	wg         sync.WaitGroup
+}
+
+func newSIEMForwarder(store *audit.Store, cursors *audit.ForwardCursorStore, sinks []SIEMSink, cfg SIEMForwarderConfig) *siemForwarder {
+	f := &siemForwarder{
+		store:      store,
+		cursors:    cursors,
+		sinks:      sinks,
+		batchSize:  cfg.BatchSize,
+		interval:   cfg.Interval,
+		maxRetries: cfg.MaxRetries,
+		retryDelay: cfg.RetryDelay,
+	}
+	if f.batchSize <= 0 {
+		f.batchSize = 100
+	}
+	if f.interval <= 0 {
+		f.interval = 5 * time.Second
+	}
+	if f.maxRetries <= 0 {
+		f.maxRetries = 5
+	}
+	if f.retryDelay <= 0 {
+		f.retryDelay = time.Second
+	}
+	return f
+}
+
+// run forwards events to all sinks until ctx is cancelled.
+func (f *siemForwarder) run(ctx context.Context) {
+	for _, sink := range f.sinks {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.runSink(ctx, sink)
+		}()
+	}
+}
+
+// flush waits for the sink loops started by run to stop, then forwards
+// whatever each sink has not yet acknowledged, until ctx is done. auditd
+// calls it on shutdown, after the shutdown marker, so the sinks see the
+// chain closed.
+func (f *siemForwarder) flush(ctx context.Context) {
+	f.wg.Wait()
+	for _, sink := range f.sinks {
+		n, err := f.drain(ctx, sink)
+		if err != nil {
+			slog.Warn("siem forwarder: flush incomplete; the rest is sent after restart", "sink", sink.Name(), "err", err)
+			continue
+		}
+		slog.Info("siem forwarder: flushed", "sink", sink.Name(), "events", n)
+	}
+}
+
+func (f *siemForwarder) runSink(ctx context.Context, sink SIEMSink) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := f.drain(ctx, sink); err != nil && ctx.Err() == nil {
+			slog.Warn("siem forwarder: delivery stalled", "sink", sink.Name(), "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain forwards batches to sink until it is caught up. It returns the number
+// of events delivered. On error the cursor is left at the last acknowledged
+// batch so the next call retries from there.
+func (f *siemForwarder) drain(ctx context.Context, sink SIEMSink) (int, error) {
+	cursor, err := f.cursors.Get(ctx, sink.Name())
+	if err != nil {
+		return 0, fmt.Errorf("read cursor: %w", err)
+	}
+	delivered := 0
+	for {
+		batch, err := f.store.EventsAfter(ctx, cursor, f.batchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(batch) == 0 {
+			return delivered, nil
+		}
+		if err := f.sendWithRetry(ctx, sink, batch); err != nil {
+			return delivered, err
+		}
+		cursor = batch[len(batch)-1].Seq
+		if err := f.cursors.Set(ctx, sink.Name(), cursor); err != nil {
+			return delivered, fmt.Errorf("write cursor: %w", err)
+		}
+		delivered += len(batch)
+		if len(batch) < f.batchSize {
+			return delivered, nil
+		}
+	}
+}
+
+func (f *siemForwarder) sendWithRetry(ctx context.Context, sink SIEMSink, batch []audit.SequencedEvent) error {
+	delay := f.retryDelay
+	var err error
+	for attempt := 1; attempt <= f.maxRetries; attempt++ {
+		if err = sink.Send(ctx, batch); err == nil {
+			return nil
+		}
+		slog.Debug("siem forwarder: send failed", "sink", sink.Name(), "attempt", attempt, "err", err)
+		if attempt == f.maxRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("send %d events after %d attempts: %w", len(batch), f.maxRetries, err)
+}
+
+// splunkHECSink posts events to a Splunk HTTP Event Collector endpoint.
+// Multiple events are concatenated in a single request body, as HEC allows.
+type splunkHECSink struct {
+	url    string
+	token  string
+	index  string
+	client *http.Client
+}
+
+func (s *splunkHECSink) Name() string { return "splunk" }
+
+func (s *splunkHECSink) Send(ctx context.Context, batch []audit.SequencedEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, se := range batch {
+		payload := map[string]any{
+			"time":       float64(se.Event.Timestamp.UnixNano()) / 1e9,
+			"source":     "helpdesk-auditd",
+			"sourcetype": "helpdesk:audit",
+			"event":      se.Raw,
+		}
+		if s.index != "" {
+			payload["index"] = s.index
+		}
+		if err := enc.Encode(payload); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Splunk "+s.token)
+	return doSIEMRequest(s.client, req, nil)
+}
+
+// elasticBulkSink indexes events via the Elasticsearch _bulk API. The
+// document _id is the audit event_id, so a batch re-sent after a crash
+// overwrites rather than duplicates.
+type elasticBulkSink struct {
+	url    string
+	index  string
+	apiKey string
+	client *http.Client
+}
+
+func (s *elasticBulkSink) Name() string { return "elastic" }
+
+func (s *elasticBulkSink) Send(ctx context.Context, batch []audit.SequencedEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, se := range batch {
+		action := map[string]any{"index": map[string]string{"_index": s.index, "_id": se.Event.EventID}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		buf.Write(se.Raw)
+		buf.WriteByte('\n')
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/_bulk", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.apiKey)
+	}
+	var resp struct {
+		Errors bool `json:"errors"`
+	}
+	if err := doSIEMRequest(s.client, req, &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		return fmt.Errorf("elastic bulk response reported item errors")
+	}
+	return nil
+}
+
+// doSIEMRequest executes req and treats any non-2xx status as an error.
+// When out is non-nil the response body is decoded into it.
+func doSIEMRequest(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, truncateBody(body, 200))
+	}
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func truncateBody(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return string(b[:n]) + "..."
+}
+
+// cefSyslogSink emits one ArcSight CEF record per event over syslog (UDP or
+// TCP). Each batch uses a fresh connection so a restarted receiver is picked
+// up without reconnect logic.
+type cefSyslogSink struct {
+	network string
+	addr    string
+}
+
+func (s *cefSyslogSink) Name() string { return "cef" }
+
+func (s *cefSyslogSink) Send(ctx context.Context, batch []audit.SequencedEvent) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second)) //nolint:errcheck
+
+	host, _ := os.Hostname()
+	for _, se := range batch {
+		line := fmt.Sprintf("<%d>%s %s helpdesk-auditd: %s\n",
+			8*16+cefSyslogSeverity(&se.Event), // facility local0
+			se.Event.Timestamp.UTC().Format(time.RFC3339), host, formatCEF(&se.Event))
+		if _, err := io.WriteString(conn, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatCEF renders an audit event as a CEF:0 record.
+func formatCEF(e *audit.Event) string {
+	name := string(e.EventType)
+	if e.Tool != nil && e.Tool.Name != "" {
+		name += " " + e.Tool.Name
+	}
+	ext := []string{
+		"rt=" + cefExt(fmt.Sprint(e.Timestamp.UnixMilli())),
+		"externalId=" + cefExt(e.EventID),
+		"cs1Label=trace_id", "cs1=" + cefExt(e.TraceID),
+		"cs2Label=action_class", "cs2=" + cefExt(string(e.ActionClass)),
+		"cs3Label=event_hash", "cs3=" + cefExt(e.EventHash),
+	}
+	if e.Session.UserID != "" {
+		ext = append(ext, "suser="+cefExt(e.Session.UserID))
+	} else if e.Principal != nil && e.Principal.EffectiveID() != "" {
+		ext = append(ext, "suser="+cefExt(e.Principal.EffectiveID()))
+	}
+	if e.Tool != nil && e.Tool.Agent != "" {
+		ext = append(ext, "sproc="+cefExt(e.Tool.Agent))
+	}
+	if e.PolicyDecision != nil {
+		ext = append(ext,
+			"act="+cefExt(e.PolicyDecision.Effect),
+			"cs4Label=resource", "cs4="+cefExt(e.PolicyDecision.ResourceType+"/"+e.PolicyDecision.ResourceName),
+			"cs5Label=policy", "cs5="+cefExt(e.PolicyDecision.PolicyName))
+	}
+	if e.Outcome != nil && e.Outcome.Status != "" {
+		ext = append(ext, "outcome="+cefExt(e.Outcome.Status))
+	}
+	return fmt.Sprintf("CEF:0|aiHelpDesk|helpdesk|%s|%s|%s|%d|%s",
+		cefHeader(buildinfo.Version), cefHeader(string(e.EventType)), cefHeader(name),
+		cefSeverity(e), strings.Join(ext, " "))
+}
+
+// cefSeverity maps an event to the CEF 0–10 severity scale.
+func cefSeverity(e *audit.Event) int {
+	switch {
+	case e.EventType == audit.EventTypeGovernanceViolation, e.EventType == audit.EventTypeOutOfBandChange:
+		return 9
+	case e.PolicyDecision != nil && e.PolicyDecision.Effect == "deny":
+		return 7
+	case e.ActionClass == audit.ActionDestructive:
+		return 6
+	case e.EventType == audit.EventTypeBackupStale:
+		return 5
+	case e.ActionClass == audit.ActionWrite:
+		return 4
+	case e.Outcome != nil && e.Outcome.Status == "error":
+		return 4
+	default:
+		return 2
+	}
+}
+
+// cefSyslogSeverity maps the CEF severity onto a syslog severity (0–7).
+func cefSyslogSeverity(e *audit.Event) int {
+	switch sev := cefSeverity(e); {
+	case sev >= 9:
+		return 2 // critical
+	case sev >= 7:
+		return 3 // error
+	case sev >= 4:
+		return 4 // warning
+	default:
+		return 6 // informational
+	}
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtEscaper    = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+func cefHeader(s string) string { return cefHeaderEscaper.Replace(s) }
+func cefExt(s string) string    { return cefExtEscaper.Replace(s) }
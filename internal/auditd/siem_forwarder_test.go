@@ -0,0 +1,244 @@
+package auditd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// recordingSink captures every batch it receives and can be told to fail.
+type recordingSink struct {
+	mu       sync.Mutex
+	batches  [][]string
+	failures int // number of upcoming Send calls that should fail
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, batch []audit.SequencedEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	ids := make([]string, len(batch))
+	for i, se := range batch {
+		ids[i] = se.Event.EventID
+	}
+	s.batches = append(s.batches, ids)
+	return nil
+}
+
+func newSIEMTestForwarder(t *testing.T, n int, sink SIEMSink) (*siemForwarder, *audit.Store) {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cursors, err := audit.NewForwardCursorStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewForwardCursorStore: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		ev := &audit.Event{EventID: fmt.Sprintf("evt_%d", i), EventType: audit.EventTypeToolExecution, Session: audit.Session{ID: "s"}}
+		if err := store.Record(context.Background(), ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	f := newSIEMForwarder(store, cursors, []SIEMSink{sink}, SIEMForwarderConfig{
+		BatchSize:  2,
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	})
+	return f, store
+}
+
+func TestSIEMForwarder_DrainBatchesAndCursor(t *testing.T) {
+	sink := &recordingSink{}
+	f, store := newSIEMTestForwarder(t, 5, sink)
+	ctx := context.Background()
+
+	n, err := f.drain(ctx, sink)
+	if err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("delivered = %d, want 5", n)
+	}
+	if len(sink.batches) != 3 {
+		t.Errorf("batches = %d, want 3 (2+2+1)", len(sink.batches))
+	}
+
+	// A second drain with no new events delivers nothing (no duplicates).
+	if n, _ := f.drain(ctx, sink); n != 0 {
+		t.Errorf("second drain delivered %d, want 0", n)
+	}
+
+	// New events after a "restart" resume from the persisted cursor.
+	store.Record(ctx, &audit.Event{EventID: "evt_new", EventType: audit.EventTypeToolExecution, Session: audit.Session{ID: "s"}}) //nolint:errcheck
+	f2 := newSIEMForwarder(store, f.cursors, []SIEMSink{sink}, SIEMForwarderConfig{BatchSize: 2})
+	if n, _ := f2.drain(ctx, sink); n != 1 {
+		t.Errorf("drain after restart delivered %d, want 1", n)
+	}
+	last := sink.batches[len(sink.batches)-1]
+	if len(last) != 1 || last[0] != "evt_new" {
+		t.Errorf("last batch = %v, want [evt_new]", last)
+	}
+}
+
+func TestSIEMForwarder_RetryThenStall(t *testing.T) {
+	// One failure is absorbed by the retry loop.
+	sink := &recordingSink{failures: 1}
+	f, _ := newSIEMTestForwarder(t, 2, sink)
+	if n, err := f.drain(context.Background(), sink); err != nil || n != 2 {
+		t.Fatalf("drain = (%d, %v), want (2, nil)", n, err)
+	}
+
+	// Exhausted retries leave the cursor untouched so nothing is dropped.
+	sink2 := &recordingSink{failures: 2}
+	f2, _ := newSIEMTestForwarder(t, 2, sink2)
+	if _, err := f2.drain(context.Background(), sink2); err == nil {
+		t.Fatal("expected error after retries exhausted")
+	}
+	if seq, _ := f2.cursors.Get(context.Background(), sink2.Name()); seq != 0 {
+		t.Errorf("cursor advanced to %d despite failure", seq)
+	}
+	if n, err := f2.drain(context.Background(), sink2); err != nil || n != 2 {
+		t.Errorf("retry drain = (%d, %v), want (2, nil)", n, err)
+	}
+}
+
+func TestSplunkHECSink_Send(t *testing.T) {
+	var gotAuth string
+	var lines []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		dec := json.NewDecoder(r.Body)
+		for {
+			var m map[string]any
+			if err := dec.Decode(&m); err != nil {
+				break
+			}
+			lines = append(lines, m)
+		}
+		w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer srv.Close()
+
+	sink := buildSIEMSinks(SIEMForwarderConfig{SplunkURL: srv.URL, SplunkToken: "tok", SplunkIndex: "sec"})[0]
+	batch := []audit.SequencedEvent{
+		{Seq: 1, Event: audit.Event{EventID: "evt_a", Timestamp: time.Now()}, Raw: json.RawMessage(`{"event_id":"evt_a"}`)},
+		{Seq: 2, Event: audit.Event{EventID: "evt_b", Timestamp: time.Now()}, Raw: json.RawMessage(`{"event_id":"evt_b"}`)},
+	}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotAuth != "Splunk tok" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if len(lines) != 2 || lines[0]["index"] != "sec" || lines[0]["sourcetype"] != "helpdesk:audit" {
+		t.Errorf("unexpected HEC payload: %v", lines)
+	}
+}
+
+func TestElasticBulkSink_ItemErrors(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("path = %s, want /_bulk", r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"errors":true,"items":[]}`))
+	}))
+	defer srv.Close()
+
+	sink := buildSIEMSinks(SIEMForwarderConfig{ElasticURL: srv.URL + "/"})[0]
+	batch := []audit.SequencedEvent{{Seq: 1, Event: audit.Event{EventID: "evt_a"}, Raw: json.RawMessage(`{"event_id":"evt_a"}`)}}
+	if err := sink.Send(context.Background(), batch); err == nil {
+		t.Error("expected error when bulk response has errors=true")
+	}
+	if !strings.Contains(body, `"_id":"evt_a"`) || !strings.Contains(body, `"_index":"helpdesk-audit"`) {
+		t.Errorf("bulk body missing action metadata: %s", body)
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	ev := &audit.Event{
+		EventID:     "evt_1",
+		EventType:   audit.EventTypePolicyDecision,
+		TraceID:     "tr_x",
+		ActionClass: audit.ActionDestructive,
+		Session:     audit.Session{UserID: "alice"},
+		PolicyDecision: &audit.PolicyDecision{
+			Effect: "deny", ResourceType: "database", ResourceName: "prod|db", PolicyName: "a=b",
+		},
+	}
+	got := formatCEF(ev)
+	if !strings.HasPrefix(got, "CEF:0|aiHelpDesk|helpdesk|") {
+		t.Errorf("missing CEF header: %s", got)
+	}
+	if !strings.Contains(got, "|policy_decision|policy_decision|7|") {
+		t.Errorf("expected severity 7 for deny: %s", got)
+	}
+	for _, want := range []string{"suser=alice", "act=deny", `cs5=a\=b`, "cs4=database/prod|db", "externalId=evt_1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("CEF record missing %q: %s", want, got)
+		}
+	}
+}
+
+func TestCEFSyslogSink_TCP(t *testing.T) {
+	ln, err := netListenTCP(t)
+	if err != nil {
+		t.Skipf("tcp listen unavailable: %v", err)
+	}
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		got <- line
+	}()
+
+	sink := buildSIEMSinks(SIEMForwarderConfig{CEFNetwork: "tcp", CEFAddr: ln.Addr().String()})[0]
+	batch := []audit.SequencedEvent{{Seq: 1, Event: audit.Event{EventID: "evt_a", EventType: audit.EventTypeToolExecution, Timestamp: time.Now()}}}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case line := <-got:
+		if !strings.HasPrefix(line, "<134>") || !strings.Contains(line, "CEF:0|") {
+			t.Errorf("unexpected syslog line: %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no syslog line received")
+	}
+}
+
+func netListenTCP(t *testing.T) (net.Listener, error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err == nil {
+		t.Cleanup(func() { ln.Close() })
+	}
+	return ln, err
+}
@@ -0,0 +1,151 @@
+package auditd
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/i18n"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/sms"
+)
+
+// phoneDirectory resolves an SMS sender's number to a registered user.
+// identity.StaticProvider implements it from the phone field in users.yaml.
+type phoneDirectory interface {
+	UserByPhone(phone string) (identity.ResolvedPrincipal, bool)
+}
+
+// smsReplyHelp is sent back for messages that are not an approval reply.
+const smsReplyHelp = "Reply YES <approval-id> to approve or NO <approval-id> [reason] to deny."
+
+// smsServer handles replies to SMS and WhatsApp approval notifications.
+type smsServer struct {
+	approvals  *approvalServer
+	authToken  string // Twilio auth token, which signs incoming webhooks
+	webhookURL string // public URL Twilio posts to, exactly as configured there
+	users      phoneDirectory
+}
+
+// parseSMSReply parses "YES apr_abc123 [reason]" or "NO apr_abc123 [reason]".
+func parseSMSReply(body string) (action, approvalID, reason string, ok bool) {
+	fields := strings.Fields(body)
+	if len(fields) < 2 {
+		return "", "", "", false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "YES", "Y", "APPROVE":
+		action = linkActionApprove
+	case "NO", "N", "DENY":
+		action = linkActionDeny
+	default:
+		return "", "", "", false
+	}
+	return action, fields[1], strings.Join(fields[2:], " "), true
+}
+
+// handleInbound handles POST /v1/sms/inbound, Twilio's incoming message
+// webhook. The request must carry a valid X-Twilio-Signature, and the sender's
+// number must belong to a user in users.yaml, who then needs the same role to
+// approve as on the API. Four-eyes applies.
+func (s *smsServer) handleInbound(w http.ResponseWriter, r *http.Request) {
+	if s.users == nil {
+		http.Error(w, "SMS replies are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if !sms.ValidSignature(s.authToken, s.webhookURL, r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		slog.Warn("sms: rejected webhook with invalid signature", "remote", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	from := r.PostForm.Get("From")
+	principal, ok := s.users.UserByPhone(from)
+	if !ok {
+		slog.Warn("sms: reply from unregistered number", "from", from)
+		sms.Reply(w, "This number is not registered for approvals.")
+		return
+	}
+	action, approvalID, reason, ok := parseSMSReply(r.PostForm.Get("Body"))
+	if !ok {
+		sms.Reply(w, smsReplyHelp)
+		return
+	}
+
+	approval, err := s.approvals.store.GetRequest(r.Context(), approvalID)
+	if err != nil || (principal.Tenant != "" && approval.TenantID != "" && approval.TenantID != principal.Tenant) {
+		sms.Reply(w, "Approval "+approvalID+" not found.")
+		return
+	}
+	if approval.Status != "pending" {
+		sms.Reply(w, "Approval "+approvalID+" is already "+approval.Status+".")
+		return
+	}
+	required := "dba"
+	if isFleetApproval(approval) {
+		required = "fleet-approver"
+	}
+	if err := s.approvals.authorizer.Require(principal, required); err != nil {
+		sms.Reply(w, "Not allowed: "+err.Error())
+		return
+	}
+	userID := principal.EffectiveID()
+	if reason == "" {
+		reason = "via SMS"
+	}
+
+	if action == linkActionApprove {
+		if audit.IsSelfApproval(approval.RequestedBy, userID) {
+			sms.Reply(w, "Not allowed: you cannot approve your own request.")
+			return
+		}
+		err = s.approvals.store.Approve(r.Context(), approvalID, userID, reason, 0)
+	} else {
+		err = s.approvals.store.Deny(r.Context(), approvalID, userID, reason)
+	}
+	if err != nil {
+		sms.Reply(w, "Approval "+approvalID+" could not be resolved: "+err.Error())
+		return
+	}
+	slog.Info("approval resolved via SMS",
+		"approval_id", approvalID,
+		"action", action,
+		"resolved_by", userID)
+
+	resolved, _ := s.approvals.store.GetRequest(r.Context(), approvalID)
+	if s.approvals.notifier != nil && resolved != nil {
+		s.approvals.notifier.NotifyResolved(r.Context(), resolved)
+	}
+	verb := "Approved"
+	if action == linkActionDeny {
+		verb = "Denied"
+	}
+	sms.Reply(w, verb+" "+approvalID+".")
+}
+
+// smsApprovalText is the SMS sent for a new approval request, in locale l.
+// The YES/NO reply keywords stay the same in every language.
+func smsApprovalText(locales *i18n.Bundle, l string, approval *audit.StoredApproval, replies bool) string {
+	var b strings.Builder
+	b.WriteString(locales.T(l, "approval.sms.needed", i18n.Vars{"action": approval.ActionClass}))
+	if approval.ToolName != "" {
+		b.WriteString(" " + approval.ToolName)
+	}
+	if approval.AgentName != "" {
+		b.WriteString(locales.T(l, "approval.sms.agent", i18n.Vars{"agent": approval.AgentName}))
+	}
+	if approval.ResourceName != "" {
+		b.WriteString(locales.T(l, "approval.sms.resource", i18n.Vars{"resource": approval.ResourceType + "/" + approval.ResourceName}))
+	}
+	b.WriteString(locales.T(l, "approval.sms.requested", i18n.Vars{"requested_by": approval.RequestedBy, "approval_id": approval.ApprovalID}))
+	if replies {
+		b.WriteString(locales.T(l, "approval.sms.reply", i18n.Vars{"approval_id": approval.ApprovalID}))
+	}
+	return b.String()
+}
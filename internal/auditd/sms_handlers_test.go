@@ -0,0 +1,120 @@
+package auditd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/sms"
+)
+
+const testSMSWebhookURL = "https://auditd.example.com/v1/sms/inbound"
+
+// fakePhones maps phone numbers to principals.
+type fakePhones map[string]identity.ResolvedPrincipal
+
+func (f fakePhones) UserByPhone(phone string) (identity.ResolvedPrincipal, bool) {
+	p, ok := f[phone]
+	return p, ok
+}
+
+func newSMSSrv(t *testing.T) *smsServer {
+	t.Helper()
+	s := newApprovalSrv(t, testUsersYAML)
+	return &smsServer{
+		approvals:  s.approvalServer,
+		authToken:  "twilio-token",
+		webhookURL: testSMSWebhookURL,
+		users: fakePhones{
+			"+15550100001": {UserID: "alice@example.com", Roles: []string{"dba"}, AuthMethod: "phone"},
+			"+15550100003": {UserID: "charlie@example.com", Roles: []string{"operator"}, AuthMethod: "phone"},
+		},
+	}
+}
+
+// postSMS delivers an incoming message the way Twilio does, signed unless
+// signature is given.
+func postSMS(s *smsServer, from, body, signature string) *httptest.ResponseRecorder {
+	form := url.Values{"From": {from}, "Body": {body}, "MessageSid": {"SM123"}}
+	if signature == "" {
+		signature = sms.Signature(s.authToken, testSMSWebhookURL, form)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/sms/inbound", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", signature)
+	w := httptest.NewRecorder()
+	s.handleInbound(w, req)
+	return w
+}
+
+func TestSMSInbound_Approve(t *testing.T) {
+	s := newSMSSrv(t)
+	a := mutationApproval("bob@example.com")
+	if err := s.approvals.store.CreateRequest(t.Context(), a); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+
+	w := postSMS(s, "+15550100001", "yes "+a.ApprovalID+" checked the plan", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<Message>Approved "+a.ApprovalID) {
+		t.Fatalf("status %d, body %s", w.Code, w.Body.String())
+	}
+	got, _ := s.approvals.store.GetRequest(t.Context(), a.ApprovalID)
+	if got.Status != "approved" || got.ResolvedBy != "alice@example.com" || got.ResolutionReason != "checked the plan" {
+		t.Errorf("approval = %+v", got)
+	}
+
+	// A second reply finds it resolved.
+	w = postSMS(s, "+15550100001", "NO "+a.ApprovalID, "")
+	if !strings.Contains(w.Body.String(), "already approved") {
+		t.Errorf("second reply: %s", w.Body.String())
+	}
+}
+
+func TestSMSInbound_Rejected(t *testing.T) {
+	s := newSMSSrv(t)
+	own := mutationApproval("alice@example.com")
+	if err := s.approvals.store.CreateRequest(t.Context(), own); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name, from, body, signature, want string
+		status                            int
+	}{
+		{"bad signature", "+15550100001", "YES " + own.ApprovalID, "forged", "", http.StatusForbidden},
+		{"unregistered number", "+15550100099", "YES " + own.ApprovalID, "", "not registered", http.StatusOK},
+		{"unparseable", "+15550100001", "ok", "", "Reply YES", http.StatusOK},
+		{"wrong role", "+15550100003", "YES " + own.ApprovalID, "", "Not allowed", http.StatusOK},
+		{"four-eyes", "+15550100001", "YES " + own.ApprovalID, "", "your own request", http.StatusOK},
+		{"unknown approval", "+15550100001", "YES apr_missing", "", "not found", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSMS(s, tc.from, tc.body, tc.signature)
+			if w.Code != tc.status || !strings.Contains(w.Body.String(), tc.want) {
+				t.Errorf("status %d, body %s; want %d containing %q", w.Code, w.Body.String(), tc.status, tc.want)
+			}
+		})
+	}
+	if got, _ := s.approvals.store.GetRequest(t.Context(), own.ApprovalID); got.Status != "pending" {
+		t.Errorf("status = %s, want pending", got.Status)
+	}
+
+	// The requester may still deny their own request by SMS.
+	if w := postSMS(s, "+15550100001", "deny "+own.ApprovalID+" wrong host", ""); !strings.Contains(w.Body.String(), "Denied") {
+		t.Errorf("deny: %s", w.Body.String())
+	}
+}
+
+func TestSMSInbound_NotConfigured(t *testing.T) {
+	s := &smsServer{approvals: &approvalServer{authorizer: authz.NewAuthorizer(authz.DefaultAuditdPermissions, false)}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/sms/inbound", strings.NewReader("From=%2B1&Body=YES"))
+	w := httptest.NewRecorder()
+	s.handleInbound(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
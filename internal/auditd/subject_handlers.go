@@ -0,0 +1,123 @@
+package auditd
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// eraseSubjectRequest is the body of POST /v1/subjects/erase.
+type eraseSubjectRequest struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// eraseSubjectResponse reports an erasure. Subject is the pseudonym the
+// erased events and records now carry, never the raw user ID. ErasedRecords
+// counts the records rewritten outside audit_events, by table.
+type eraseSubjectResponse struct {
+	Subject       string            `json:"subject"`
+	ErasedEvents  int               `json:"erased_events"`
+	ErasedRecords map[string]int64  `json:"erased_records"`
+	Redactions    []audit.Redaction `json:"redactions"`
+}
+
+// subjectRequester returns who is asking for a subject operation, or writes
+// an error. Tenant-scoped callers are refused: a data subject's events may
+// span tenants.
+func subjectRequester(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal := authz.PrincipalFromContext(r.Context())
+	if principal.Tenant != "" {
+		writeJSONError(w, "subject operations span tenants and need an untenanted principal", http.StatusForbidden)
+		return "", false
+	}
+	by := principal.EffectiveID()
+	if by == "" {
+		by = "anonymous"
+	}
+	return by, true
+}
+
+// handleEraseSubject handles POST /v1/subjects/erase: the free text of every
+// event recorded for user_id is erased and the subject's identifiers, in
+// those events and wherever else an event names them (as an approver, say),
+// are replaced by the subject's pseudonym. The erasure is itself recorded as
+// event_redaction events, whose tombstones keep the hash chain verifiable.
+// Approval requests, annotations and feedback are erased in place.
+func (s *server) handleEraseSubject(w http.ResponseWriter, r *http.Request) {
+	var req eraseSubjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeJSONError(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		writeJSONError(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	by, ok := subjectRequester(w, r)
+	if !ok {
+		return
+	}
+	reds, err := s.store.EraseSubject(r.Context(), req.UserID, req.Reason, by)
+	if err != nil {
+		slog.Error("subject erasure failed", "err", err, "by", by)
+		writeJSONError(w, "subject erasure failed", http.StatusInternalServerError)
+		return
+	}
+	resp := eraseSubjectResponse{
+		Subject:       s.store.SubjectPseudonym(req.UserID),
+		ErasedRecords: map[string]int64{},
+		Redactions:    reds,
+	}
+	if resp.Redactions == nil {
+		resp.Redactions = []audit.Redaction{}
+	}
+	for _, red := range reds {
+		resp.ErasedEvents += len(red.Tombstones)
+	}
+	ids := s.store.SubjectIDs(req.UserID)
+	for name, records := range s.subjectRecords {
+		n, err := records.EraseSubjectRecords(r.Context(), ids, resp.Subject)
+		if err != nil {
+			slog.Error("subject erasure failed", "records", name, "err", err, "by", by)
+			writeJSONError(w, "subject erasure failed", http.StatusInternalServerError)
+			return
+		}
+		resp.ErasedRecords[name] = n
+	}
+	slog.Info("data subject erased", "subject", resp.Subject, "events", resp.ErasedEvents,
+		"records", resp.ErasedRecords, "reason", req.Reason, "by", by)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+// handlePseudonymizeSubjects handles POST /v1/subjects/pseudonymize: stored
+// events recorded before pseudonymization was enabled get their user
+// identifiers replaced by pseudonyms.
+func (s *server) handlePseudonymizeSubjects(w http.ResponseWriter, r *http.Request) {
+	by, ok := subjectRequester(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.store.PseudonymizeStored(r.Context(), by)
+	if errors.Is(err, audit.ErrNoPepper) {
+		writeJSONError(w, "pseudonymization is not enabled: set HELPDESK_AUDIT_PSEUDONYM_PEPPER", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		slog.Error("pseudonymization failed", "err", err, "by", by)
+		writeJSONError(w, "pseudonymization failed", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("stored user IDs pseudonymized", "events", n, "by", by)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"pseudonymized_events": n}) //nolint:errcheck
+}
@@ -0,0 +1,80 @@
+package auditd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func TestHandleEraseSubject(t *testing.T) {
+	store := newTestAuditStore(t)
+	if err := store.Record(context.Background(), &audit.Event{
+		EventType: audit.EventTypeToolExecution,
+		Session:   audit.Session{ID: "sess_1", UserID: "alice"},
+		Tool:      &audit.ToolExecution{Name: "run_sql", RawCommand: "SELECT * FROM orders WHERE owner = 'alice'"},
+	}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	approvals, err := audit.NewApprovalStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := approvals.CreateRequest(context.Background(), &audit.StoredApproval{ActionClass: "write", RequestedBy: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	pending, _ := approvals.ListRequests(context.Background(), audit.ApprovalQueryOptions{})
+	if err := approvals.Approve(context.Background(), pending[0].ApprovalID, "alice", "ok", 0); err != nil {
+		t.Fatal(err)
+	}
+	srv := &server{store: store, subjectRecords: map[string]audit.SubjectRecords{"approval_requests": approvals}}
+	erase := func(body string, p identity.ResolvedPrincipal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/subjects/erase", strings.NewReader(body))
+		req = req.WithContext(authz.WithPrincipal(req.Context(), p))
+		w := httptest.NewRecorder()
+		srv.handleEraseSubject(w, req)
+		return w
+	}
+	dpo := identity.ResolvedPrincipal{UserID: "dpo", Roles: []string{"security"}, AuthMethod: "api_key"}
+
+	if w := erase(`{"user_id":"alice"}`, dpo); w.Code != http.StatusBadRequest {
+		t.Errorf("without reason: status = %d, want 400", w.Code)
+	}
+	tenanted := dpo
+	tenanted.Tenant = "acme"
+	if w := erase(`{"user_id":"alice","reason":"art. 17"}`, tenanted); w.Code != http.StatusForbidden {
+		t.Errorf("tenant-scoped caller: status = %d, want 403", w.Code)
+	}
+
+	w := erase(`{"user_id":"alice","reason":"art. 17"}`, dpo)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp eraseSubjectResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.ErasedEvents != 1 || resp.ErasedRecords["approval_requests"] != 1 {
+		t.Fatalf("response = %+v, %v", resp, err)
+	}
+	events, _ := store.Query(context.Background(), audit.QueryOptions{SessionID: "sess_1"})
+	if len(events) != 1 || events[0].Tool.RawCommand != audit.ErasedField {
+		t.Errorf("event not erased: %+v", events)
+	}
+	if status, err := store.VerifyIntegrity(context.Background()); err != nil || !status.Valid {
+		t.Errorf("VerifyIntegrity = %+v, %v", status, err)
+	}
+}
+
+func TestHandlePseudonymizeSubjects_NoPepper(t *testing.T) {
+	srv := &server{store: newTestAuditStore(t)}
+	req := httptest.NewRequest(http.MethodPost, "/v1/subjects/pseudonymize", nil)
+	w := httptest.NewRecorder()
+	srv.handlePseudonymizeSubjects(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409", w.Code)
+	}
+}
@@ -0,0 +1,21 @@
+package auditd
+
+import (
+	"net/http"
+
+	"helpdesk/internal/authz"
+)
+
+// tenantScope returns the tenant a read request is restricted to. A caller
+// bound to a tenant only ever sees its own tenant; an unbound caller (platform
+// operator, the gateway's service account) may narrow with ?tenant_id=.
+func tenantScope(r *http.Request) string {
+	return authz.PrincipalFromContext(r.Context()).TenantScope(r.URL.Query().Get("tenant_id"))
+}
+
+// inTenant reports whether a record owned by tenant is visible to the caller.
+// Records without a tenant predate tenant scoping and stay visible to everyone.
+func inTenant(r *http.Request, tenant string) bool {
+	own := authz.PrincipalFromContext(r.Context()).Tenant
+	return own == "" || tenant == "" || tenant == own
+}
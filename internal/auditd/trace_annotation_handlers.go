@@ -0,0 +1,102 @@
+package auditd
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// traceAnnotationServer handles the external references (PagerDuty incident,
+// Jira ticket, Slack thread) attached to traces.
+type traceAnnotationServer struct {
+	store *audit.TraceAnnotationStore
+}
+
+// handleCreate attaches an annotation to a trace.
+// POST /v1/traces/{traceID}/annotations {"kind": "jira", "ref": "OPS-12", "url": "..."}
+func (s *traceAnnotationServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var a audit.TraceAnnotation
+	if err := json.Unmarshal(body, &a); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	principal := authz.PrincipalFromContext(r.Context())
+	a.AnnotationID = ""
+	a.TraceID = r.PathValue("traceID")
+	a.CreatedBy = principal.EffectiveID()
+	a.TenantID = principal.TenantScope(a.TenantID)
+	a.CreatedAt = time.Time{}
+	if a.Source == "" {
+		a.Source = principal.Service
+	}
+	if a.Kind == "" {
+		http.Error(w, "kind is required", http.StatusBadRequest)
+		return
+	}
+	if a.Ref == "" && a.URL == "" {
+		http.Error(w, "ref or url is required", http.StatusBadRequest)
+		return
+	}
+	if err := s.store.Create(r.Context(), &a); err != nil {
+		slog.Error("failed to create trace annotation", "err", err)
+		http.Error(w, "failed to create trace annotation", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("trace annotated", "trace_id", a.TraceID, "kind", a.Kind, "ref", a.Ref, "by", a.CreatedBy)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a) //nolint:errcheck
+}
+
+// handleList returns the annotations of a trace, oldest first.
+// GET /v1/traces/{traceID}/annotations
+func (s *traceAnnotationServer) handleList(w http.ResponseWriter, r *http.Request) {
+	all, err := s.store.List(r.Context(), r.PathValue("traceID"))
+	if err != nil {
+		slog.Error("failed to list trace annotations", "err", err)
+		http.Error(w, "failed to list trace annotations", http.StatusInternalServerError)
+		return
+	}
+	annotations := []audit.TraceAnnotation{}
+	for _, a := range all {
+		if inTenant(r, a.TenantID) {
+			annotations = append(annotations, a)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"annotations": annotations, "count": len(annotations)}) //nolint:errcheck
+}
+
+// annotateJourneys attaches each journey's trace annotations in place.
+// Failures are logged: the journeys are still worth returning without them.
+func (s *traceAnnotationServer) annotateJourneys(r *http.Request, journeys []audit.JourneySummary) {
+	if s == nil || len(journeys) == 0 {
+		return
+	}
+	traceIDs := make([]string, len(journeys))
+	for i, j := range journeys {
+		traceIDs[i] = j.TraceID
+	}
+	byTrace, err := s.store.ListForTraces(r.Context(), traceIDs)
+	if err != nil {
+		slog.Warn("failed to load trace annotations for journeys", "err", err)
+		return
+	}
+	for i := range journeys {
+		for _, a := range byTrace[journeys[i].TraceID] {
+			if inTenant(r, a.TenantID) {
+				journeys[i].Annotations = append(journeys[i].Annotations, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package auditd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+// newTraceAnnotationServer returns a traceAnnotationServer backed by a fresh
+// temp-dir SQLite store.
+func newTraceAnnotationServer(t *testing.T) *traceAnnotationServer {
+	t.Helper()
+	store, err := audit.NewStore(audit.StoreConfig{
+		DBPath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	as, err := audit.NewTraceAnnotationStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewTraceAnnotationStore: %v", err)
+	}
+	return &traceAnnotationServer{store: as}
+}
+
+func postAnnotation(t *testing.T, srv *traceAnnotationServer, traceID string, principal identity.ResolvedPrincipal, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces/"+traceID+"/annotations", bytes.NewReader(data))
+	req.SetPathValue("traceID", traceID)
+	req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+	srv.handleCreate(w, req)
+	return w
+}
+
+func TestTraceAnnotationHandlers_CreateList(t *testing.T) {
+	srv := newTraceAnnotationServer(t)
+	secbot := identity.ResolvedPrincipal{Service: "secbot", AuthMethod: "api_key"}
+
+	w := postAnnotation(t, srv, "tr_abc", secbot, map[string]any{
+		"kind": "pagerduty",
+		"ref":  "PD-Q1W2E3",
+		"url":  "https://acme.pagerduty.com/incidents/PD-Q1W2E3",
+		// Server-assigned fields are ignored.
+		"trace_id":      "tr_other",
+		"annotation_id": "ann_forged",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body: %s", w.Code, w.Body.String())
+	}
+	var created audit.TraceAnnotation
+	json.NewDecoder(w.Body).Decode(&created) //nolint:errcheck
+	if created.TraceID != "tr_abc" || created.AnnotationID == "ann_forged" {
+		t.Errorf("created = %+v, want trace tr_abc and a generated ID", created)
+	}
+	if created.Source != "secbot" || created.CreatedBy != "secbot" {
+		t.Errorf("source/created_by = %q/%q, want secbot", created.Source, created.CreatedBy)
+	}
+
+	for name, body := range map[string]map[string]any{
+		"missing kind":        {"ref": "OPS-1"},
+		"missing ref and url": {"kind": "jira"},
+	} {
+		if w := postAnnotation(t, srv, "tr_abc", secbot, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/traces/tr_abc/annotations", nil)
+	req.SetPathValue("traceID", "tr_abc")
+	w = httptest.NewRecorder()
+	srv.handleList(w, req)
+	var resp struct {
+		Annotations []audit.TraceAnnotation `json:"annotations"`
+		Count       int                     `json:"count"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+	if resp.Count != 1 || resp.Annotations[0].Ref != "PD-Q1W2E3" {
+		t.Errorf("list = %+v, want the PagerDuty annotation", resp)
+	}
+
+	journeys := []audit.JourneySummary{{TraceID: "tr_abc"}, {TraceID: "tr_plain"}}
+	srv.annotateJourneys(httptest.NewRequest(http.MethodGet, "/v1/journeys", nil), journeys)
+	if len(journeys[0].Annotations) != 1 || len(journeys[1].Annotations) != 0 {
+		t.Errorf("journey annotations = %+v / %+v", journeys[0].Annotations, journeys[1].Annotations)
+	}
+}
+
+func TestTraceAnnotationHandlers_TenantScoped(t *testing.T) {
+	srv := newTraceAnnotationServer(t)
+	alice := identity.ResolvedPrincipal{UserID: "alice", Tenant: "payments", AuthMethod: "api_key"}
+	if w := postAnnotation(t, srv, "tr_t", alice, map[string]any{"kind": "jira", "ref": "PAY-7"}); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/traces/tr_t/annotations", nil)
+	req.SetPathValue("traceID", "tr_t")
+	bob := identity.ResolvedPrincipal{UserID: "bob", Tenant: "search", AuthMethod: "api_key"}
+	req = req.WithContext(authz.WithPrincipal(req.Context(), bob))
+	w := httptest.NewRecorder()
+	srv.handleList(w, req)
+	var resp struct {
+		Count int `json:"count"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp) //nolint:errcheck
+	if resp.Count != 0 {
+		t.Errorf("other tenant sees %d annotations, want 0", resp.Count)
+	}
+}
@@ -0,0 +1,295 @@
+package auditd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/awssig"
+)
+
+// WORMExportConfig configures the continuous export of the audit trail to an
+// S3 bucket with Object Lock, a second copy that nobody (with COMPLIANCE mode,
+// not even the bucket owner) can rewrite or delete before it expires.
+// The export is enabled when Bucket is set.
+type WORMExportConfig struct {
+	Bucket        string
+	Prefix        string        // key prefix (default "helpdesk-audit")
+	Region        string        // default AWS_REGION
+	Endpoint      string        // default https://s3.<region>.amazonaws.com; requests are path-style
+	LockMode      string        // COMPLIANCE (default) or GOVERNANCE
+	RetentionDays int           // Object Lock retention per object (default 2557, seven years)
+	Interval      time.Duration // how often new events are exported (default 5m)
+	BatchSize     int           // maximum events per object (default 10000)
+}
+
+// s3ObjectLockClient writes objects under S3 Object Lock with SigV4-signed
+// PUTs. Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+type s3ObjectLockClient struct {
+	endpoint     string
+	bucket       string
+	region       string
+	accessKeyID  string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+	now          func() time.Time
+}
+
+// put uploads body to key, locked in mode until retainUntil, and returns the
+// object version S3 assigned. A bucket without versioning (and so without
+// Object Lock) returns no version, which is reported as an error.
+func (c *s3ObjectLockClient) put(ctx context.Context, key string, body []byte, mode string, retainUntil time.Time) (string, error) {
+	u := c.endpoint + "/" + c.bucket + "/" + (&url.URL{Path: key}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	req.Header.Set("X-Amz-Object-Lock-Mode", mode)
+	req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", retainUntil.UTC().Format(time.RFC3339))
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+	awssig.Sign(req, body, c.accessKeyID, c.secretKey, c.region, "s3", c.now().UTC())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("PUT %s: status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	version := resp.Header.Get("X-Amz-Version-Id")
+	if version == "" || version == "null" {
+		return "", fmt.Errorf("PUT %s: no object version returned; bucket %s must have versioning and Object Lock enabled", key, c.bucket)
+	}
+	return version, nil
+}
+
+// wormExporter tails audit_events by insertion sequence and writes each run
+// of new events to one object per UTC day. Every uploaded object version is
+// recorded in worm_exports; the highest exported sequence is where the next
+// run resumes, so nothing is skipped across restarts. A crash between upload
+// and record re-uploads the same key, which S3 keeps as a second version.
+type wormExporter struct {
+	events    *audit.Store
+	exports   *audit.WORMExportStore
+	s3        *s3ObjectLockClient
+	prefix    string
+	lockMode  string
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+// newWORMExporter returns the exporter configured by cfg, or nil when the
+// export is disabled.
+func newWORMExporter(cfg WORMExportConfig, events *audit.Store, exports *audit.WORMExportStore) (*wormExporter, error) {
+	if cfg.Bucket == "" {
+		return nil, nil
+	}
+	region := firstNonEmptyString(cfg.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return nil, fmt.Errorf("worm export: AWS region is not set")
+	}
+	keyID, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if keyID == "" || secret == "" {
+		return nil, fmt.Errorf("worm export: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+	mode := strings.ToUpper(cfg.LockMode)
+	if mode == "" {
+		mode = "COMPLIANCE"
+	}
+	if mode != "COMPLIANCE" && mode != "GOVERNANCE" {
+		return nil, fmt.Errorf("worm export: lock mode must be COMPLIANCE or GOVERNANCE, got %q", cfg.LockMode)
+	}
+	x := &wormExporter{
+		events:  events,
+		exports: exports,
+		s3: &s3ObjectLockClient{
+			endpoint:     strings.TrimRight(firstNonEmptyString(cfg.Endpoint, "https://s3."+region+".amazonaws.com"), "/"),
+			bucket:       cfg.Bucket,
+			region:       region,
+			accessKeyID:  keyID,
+			secretKey:    secret,
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			client:       &http.Client{Timeout: 60 * time.Second},
+			now:          time.Now,
+		},
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		lockMode:  mode,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		interval:  cfg.Interval,
+		batchSize: cfg.BatchSize,
+	}
+	if x.prefix == "" {
+		x.prefix = "helpdesk-audit"
+	}
+	if cfg.RetentionDays <= 0 {
+		x.retention = 2557 * 24 * time.Hour
+	}
+	if x.interval <= 0 {
+		x.interval = 5 * time.Minute
+	}
+	if x.batchSize <= 0 {
+		x.batchSize = 10000
+	}
+	return x, nil
+}
+
+func firstNonEmptyString(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// run exports new events every interval until ctx is cancelled.
+func (x *wormExporter) run(ctx context.Context) {
+	ticker := time.NewTicker(x.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := x.exportPending(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("worm export: upload stalled", "bucket", x.s3.bucket, "exported", n, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportPending exports every event not yet exported and returns how many it
+// exported. On error it stops at the last recorded object, so the next call
+// retries from there.
+func (x *wormExporter) exportPending(ctx context.Context) (int, error) {
+	after, err := x.exports.LastSeq(ctx)
+	if err != nil {
+		return 0, err
+	}
+	exported := 0
+	for {
+		batch, err := x.events.EventsAfter(ctx, after, x.batchSize)
+		if err != nil {
+			return exported, err
+		}
+		for _, run := range splitByDay(batch) {
+			if err := x.upload(ctx, run); err != nil {
+				return exported, err
+			}
+			exported += len(run)
+			after = run[len(run)-1].Seq
+		}
+		if len(batch) < x.batchSize {
+			return exported, nil
+		}
+	}
+}
+
+// upload writes one run of same-day events as a JSON Lines object and records
+// the version S3 assigned.
+func (x *wormExporter) upload(ctx context.Context, run []audit.SequencedEvent) error {
+	var body bytes.Buffer
+	for _, se := range run {
+		body.Write(se.Raw)
+		body.WriteByte('\n')
+	}
+	first, last := run[0].Seq, run[len(run)-1].Seq
+	day := eventDay(run[0])
+	key := fmt.Sprintf("%s/%s/%020d-%020d.jsonl", x.prefix, day, first, last)
+	retainUntil := x.s3.now().UTC().Add(x.retention).Truncate(time.Second)
+
+	version, err := x.s3.put(ctx, key, body.Bytes(), x.lockMode, retainUntil)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body.Bytes())
+	if err := x.exports.Record(ctx, audit.WORMExport{
+		Key:         key,
+		VersionID:   version,
+		Day:         day,
+		FirstSeq:    first,
+		LastSeq:     last,
+		Events:      len(run),
+		SHA256:      hex.EncodeToString(sum[:]),
+		LockMode:    x.lockMode,
+		RetainUntil: retainUntil,
+	}); err != nil {
+		return err
+	}
+	slog.Info("audit events exported to WORM storage",
+		"key", key, "version_id", version, "events", len(run), "retain_until", retainUntil)
+	return nil
+}
+
+// splitByDay splits a batch into runs of consecutive events from the same UTC
+// day, so each object belongs to exactly one day.
+func splitByDay(batch []audit.SequencedEvent) [][]audit.SequencedEvent {
+	var runs [][]audit.SequencedEvent
+	start := 0
+	for i := 1; i <= len(batch); i++ {
+		if i == len(batch) || eventDay(batch[i]) != eventDay(batch[start]) {
+			runs = append(runs, batch[start:i])
+			start = i
+		}
+	}
+	return runs
+}
+
+func eventDay(se audit.SequencedEvent) string {
+	return se.Event.Timestamp.UTC().Format(time.DateOnly)
+}
+
+// handleListExports handles GET /v1/exports/worm?day=YYYY-MM-DD: the objects
+// and versions written to WORM storage, for locating a day's copy of the
+// audit trail.
+func (x *wormExporter) handleListExports(w http.ResponseWriter, r *http.Request) {
+	if x == nil {
+		writeJSONError(w, "WORM export is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	day := r.URL.Query().Get("day")
+	if day != "" {
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			writeJSONError(w, "day must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	exports, err := x.exports.List(r.Context(), day, limit)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exports == nil {
+		exports = []audit.WORMExport{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"bucket":  x.s3.bucket,
+		"exports": exports,
+	})
+}
@@ -0,0 +1,203 @@
+package auditd
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// fakeObjectLockS3 accepts PUTs that carry Object Lock headers and a valid
+// Content-MD5, and assigns each a new version like a versioned bucket.
+type fakeObjectLockS3 struct {
+	mu       sync.Mutex
+	objects  map[string]string // key → body of the latest version
+	versions int
+	fail     bool
+}
+
+func (f *fakeObjectLockS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	sum := md5.Sum(body)
+	switch {
+	case f.fail:
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	case r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, "/audit-bucket/"):
+		http.Error(w, "bad request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+		return
+	case r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]):
+		http.Error(w, "bad Content-MD5", http.StatusBadRequest)
+		return
+	case r.Header.Get("X-Amz-Object-Lock-Mode") != "COMPLIANCE" || r.Header.Get("X-Amz-Object-Lock-Retain-Until-Date") == "":
+		http.Error(w, "missing object lock headers", http.StatusBadRequest)
+		return
+	case !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"):
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.versions++
+	f.objects[strings.TrimPrefix(r.URL.Path, "/audit-bucket/")] = string(body)
+	w.Header().Set("X-Amz-Version-Id", fmt.Sprintf("v%d", f.versions))
+}
+
+func newWORMTestExporter(t *testing.T, s3 http.Handler) (*wormExporter, *audit.Store) {
+	t.Helper()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	srv := httptest.NewServer(s3)
+	t.Cleanup(srv.Close)
+
+	store, err := audit.NewStore(audit.StoreConfig{DBPath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	exports, err := audit.NewWORMExportStore(store.DB(), store.IsPostgres())
+	if err != nil {
+		t.Fatalf("NewWORMExportStore: %v", err)
+	}
+	x, err := newWORMExporter(WORMExportConfig{
+		Bucket:    "audit-bucket",
+		Prefix:    "audit",
+		Region:    "us-east-1",
+		Endpoint:  srv.URL,
+		BatchSize: 2,
+	}, store, exports)
+	if err != nil {
+		t.Fatalf("newWORMExporter: %v", err)
+	}
+	return x, store
+}
+
+func recordWORMTestEvents(t *testing.T, store *audit.Store, days ...time.Time) {
+	t.Helper()
+	for i, ts := range days {
+		ev := &audit.Event{EventID: fmt.Sprintf("evt_%d", i), EventType: audit.EventTypeToolExecution, Timestamp: ts, Session: audit.Session{ID: "s"}}
+		if err := store.Record(context.Background(), ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+}
+
+func TestWORMExporter_ExportsByDayAndRecordsVersions(t *testing.T) {
+	s3 := &fakeObjectLockS3{objects: map[string]string{}}
+	x, store := newWORMTestExporter(t, s3)
+	ctx := context.Background()
+
+	d1 := time.Date(2026, 5, 1, 23, 0, 0, 0, time.UTC)
+	d2 := time.Date(2026, 5, 2, 1, 0, 0, 0, time.UTC)
+	recordWORMTestEvents(t, store, d1, d1.Add(time.Minute), d1.Add(2*time.Minute), d2)
+
+	n, err := x.exportPending(ctx)
+	if err != nil || n != 4 {
+		t.Fatalf("exportPending = %d, %v; want 4, nil", n, err)
+	}
+	// Batches of 2 are split by day: [1,2] [3] (May 1) and [4] (May 2).
+	if len(s3.objects) != 3 {
+		t.Fatalf("uploaded %d objects, want 3: %v", len(s3.objects), s3.objects)
+	}
+	body, ok := s3.objects["audit/2026-05-01/00000000000000000001-00000000000000000002.jsonl"]
+	if !ok {
+		t.Fatalf("missing first object; have %v", s3.objects)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	var ev audit.Event
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &ev) != nil || ev.EventID != "evt_0" {
+		t.Errorf("object body = %q", body)
+	}
+
+	day, err := x.exports.List(ctx, "2026-05-01", 0)
+	if err != nil || len(day) != 2 {
+		t.Fatalf("List(2026-05-01) = %d, %v; want 2", len(day), err)
+	}
+	if day[0].VersionID != "v1" || day[0].Events != 2 || day[0].LockMode != "COMPLIANCE" || day[0].SHA256 == "" {
+		t.Errorf("first export = %+v", day[0])
+	}
+
+	// Nothing new: no uploads.
+	if n, err := x.exportPending(ctx); err != nil || n != 0 {
+		t.Errorf("second exportPending = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestWORMExporter_ResumesAfterFailure(t *testing.T) {
+	s3 := &fakeObjectLockS3{objects: map[string]string{}, fail: true}
+	x, store := newWORMTestExporter(t, s3)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	recordWORMTestEvents(t, store, now, now)
+
+	if _, err := x.exportPending(ctx); err == nil {
+		t.Fatal("expected error while S3 is unavailable")
+	}
+	if seq, _ := x.exports.LastSeq(ctx); seq != 0 {
+		t.Fatalf("LastSeq after failure = %d, want 0", seq)
+	}
+
+	s3.mu.Lock()
+	s3.fail = false
+	s3.mu.Unlock()
+	if n, err := x.exportPending(ctx); err != nil || n != 2 {
+		t.Fatalf("exportPending after recovery = %d, %v; want 2, nil", n, err)
+	}
+}
+
+func TestWORMExporter_RequiresObjectVersion(t *testing.T) {
+	unversioned := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	x, store := newWORMTestExporter(t, unversioned)
+	recordWORMTestEvents(t, store, time.Now().UTC())
+
+	_, err := x.exportPending(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Object Lock") {
+		t.Fatalf("err = %v, want missing-version error", err)
+	}
+}
+
+func TestWORMExporter_HandleListExports(t *testing.T) {
+	var disabled *wormExporter
+	rec := httptest.NewRecorder()
+	disabled.handleListExports(rec, httptest.NewRequest(http.MethodGet, "/v1/exports/worm", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("disabled: status = %d, want 503", rec.Code)
+	}
+
+	x, store := newWORMTestExporter(t, &fakeObjectLockS3{objects: map[string]string{}})
+	recordWORMTestEvents(t, store, time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))
+	if _, err := x.exportPending(context.Background()); err != nil {
+		t.Fatalf("exportPending: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	x.handleListExports(rec, httptest.NewRequest(http.MethodGet, "/v1/exports/worm?day=2026-05-01", nil))
+	var resp struct {
+		Bucket  string             `json:"bucket"`
+		Exports []audit.WORMExport `json:"exports"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || resp.Bucket != "audit-bucket" || len(resp.Exports) != 1 || resp.Exports[0].VersionID != "v1" {
+		t.Errorf("status %d, resp %+v", rec.Code, resp)
+	}
+
+	rec = httptest.NewRecorder()
+	x.handleListExports(rec, httptest.NewRequest(http.MethodGet, "/v1/exports/worm?day=May-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad day: status = %d, want 400", rec.Code)
+	}
+}
@@ -2,16 +2,43 @@ package authz
 
 // DefaultAuditdPermissions is the single source of truth for authorization on
 // the auditd service. Keys are Go 1.22 ServeMux patterns exactly as registered
-// in cmd/auditd/main.go.
+// in internal/auditd/main.go.
 var DefaultAuditdPermissions = map[string]Permission{
 	// ── Public ────────────────────────────────────────────────────────────────
 	"GET /health": {AllowAnonymous: true},
+	// Prometheus scrape endpoint: database size and compaction counters only.
+	"GET /metrics": {AllowAnonymous: true},
+	// Build info, so operators and govbot can tell what is deployed.
+	"GET /version": {AllowAnonymous: true},
+
+	// Emailed approve/deny links: the signed token in the link is the
+	// credential, checked by the handler (see internal/auditd/approval_links.go).
+	"GET /v1/approvals/{approvalID}/link":  {AllowAnonymous: true},
+	"POST /v1/approvals/{approvalID}/link": {AllowAnonymous: true},
+
+	// Twilio incoming-message webhook for SMS/WhatsApp approval replies: the
+	// handler verifies X-Twilio-Signature and maps the sender to a user.
+	"POST /v1/sms/inbound": {AllowAnonymous: true},
 
 	// ── Authenticated reads: any verified user ────────────────────────────────
 	"GET /v1/events":                                         {AdminBypass: true},
+	"GET /v1/events/stats":                                  {AdminBypass: true},
+	"GET /v1/events/latency":                                {AdminBypass: true},
+	"GET /v1/events/clock-skew":                             {AdminBypass: true},
+	"GET /v1/events/scorecards":                             {AdminBypass: true},
+	"GET /v1/events/calibration":                            {AdminBypass: true},
+	"GET /v1/events/calibration/history":                    {AdminBypass: true},
 	"GET /v1/events/{eventID}":                              {AdminBypass: true},
 	"GET /v1/verify":                                        {AdminBypass: true},
+	"GET /v1/exports/worm":                                  {AdminBypass: true},
 	"GET /v1/journeys":                                      {AdminBypass: true},
+	"GET /v1/traces/{traceID}/annotations":                  {AdminBypass: true},
+	"GET /v1/events/{eventID}/annotations":                  {AdminBypass: true},
+	"GET /v1/events/{eventID}/feedback":                     {AdminBypass: true},
+	"GET /v1/events/feedback/stats":                         {AdminBypass: true},
+	"GET /v1/alerts":                                        {AdminBypass: true},
+	"GET /v1/alerts/suppressions":                           {AdminBypass: true},
+	"GET /v1/alerts/precision":                              {AdminBypass: true},
 	"GET /v1/approvals":                                     {AdminBypass: true},
 	"GET /v1/approvals/pending":                             {AdminBypass: true},
 	"GET /v1/approvals/{approvalID}":                        {AdminBypass: true},
@@ -43,11 +70,37 @@ var DefaultAuditdPermissions = map[string]Permission{
 	"POST /v1/approvals": {ServiceOnly: true, AdminBypass: true},
 
 	// Policy check (called by agents for pre-flight governance evaluation)
-	"POST /v1/governance/check": {ServiceOnly: true, AdminBypass: true},
+	"POST /v1/governance/check":       {ServiceOnly: true, AdminBypass: true},
+	"POST /v1/governance/check/batch": {ServiceOnly: true, AdminBypass: true},
+
+	// Database log ingestion (called by log shippers on monitored databases)
+	"POST /v1/db-audit/logs": {ServiceOnly: true, AdminBypass: true},
+
+	// Signed infrastructure config (fetched and verified by agents)
+	"GET /v1/infra": {ServiceOnly: true, AdminBypass: true},
+
+	// Security alerts reported by the auditor
+	"POST /v1/alerts": {ServiceOnly: true, AdminBypass: true},
 
 	// Govbot compliance history write
 	"POST /v1/govbot/runs": {ServiceOnly: true, AdminBypass: true},
 
+	// Trace annotations: external references (PagerDuty, Jira, Slack) attached
+	// by secbot, govbot or an investigator. Not part of the hash chain.
+	"POST /v1/traces/{traceID}/annotations": {AdminBypass: true},
+
+	// Event annotations: investigator notes and dispositions (false_positive,
+	// confirmed, ...). Append-only and not part of the hash chain.
+	"POST /v1/events/{eventID}/annotations": {AdminBypass: true},
+
+	// Delegation feedback: the requester, or an automation such as srebot,
+	// records whether a delegation solved the problem. Not part of the hash chain.
+	"POST /v1/events/{eventID}/feedback": {AdminBypass: true},
+
+	// Alert feedback: any authenticated operator may mark an auditor alert as
+	// a false positive; the auditor then down-weights matching alerts.
+	"POST /v1/alerts/{alertID}/false-positive": {AdminBypass: true},
+
 	// Playbook writes
 	"POST /v1/fleet/playbooks":                         {AdminBypass: true},
 	"PUT /v1/fleet/playbooks/{playbookID}":             {AdminBypass: true},
@@ -96,6 +149,90 @@ var DefaultAuditdPermissions = map[string]Permission{
 	// Cancel: any authenticated caller (ownership/requester check is in the handler).
 	"POST /v1/approvals/{approvalID}/cancel": {AdminBypass: true},
 
+	// ── Remediation plans ─────────────────────────────────────────────────────
+
+	// Proposed and executed step by step by agents; readable by anyone.
+	"POST /v1/remediation-plans":                  {ServiceOnly: true, AdminBypass: true},
+	"POST /v1/remediation-plans/{planID}/execute": {ServiceOnly: true, AdminBypass: true},
+	"GET /v1/remediation-plans":                   {AdminBypass: true},
+	"GET /v1/remediation-plans/{planID}":          {AdminBypass: true},
+	// Approved or denied, as a whole or per step, by the same role as
+	// database approvals. The handler enforces four-eyes.
+	"POST /v1/remediation-plans/{planID}/approve": {
+		RequireRoles: []string{"dba"},
+		AdminBypass:  true,
+	},
+	"POST /v1/remediation-plans/{planID}/deny": {
+		RequireRoles: []string{"dba"},
+		AdminBypass:  true,
+	},
+	"POST /v1/remediation-plans/{planID}/steps/{stepIndex}/approve": {
+		RequireRoles: []string{"dba"},
+		AdminBypass:  true,
+	},
+	"POST /v1/remediation-plans/{planID}/steps/{stepIndex}/deny": {
+		RequireRoles: []string{"dba"},
+		AdminBypass:  true,
+	},
+
+	// ── Emergency freeze ──────────────────────────────────────────────────────
+
+	// State is readable by any authenticated caller; agents poll it.
+	"GET /v1/freeze": {AdminBypass: true},
+	// Freezing and unfreezing the whole fleet is limited to incident responders.
+	"POST /v1/freeze": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+	"DELETE /v1/freeze": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+
+	// ── Maintenance windows ───────────────────────────────────────────────────
+
+	// Readable by any authenticated caller; the auditor polls active windows.
+	"GET /v1/maintenance-windows":            {AdminBypass: true},
+	"GET /v1/maintenance-windows/{windowID}": {AdminBypass: true},
+	// Declaring a window downgrades alerts, so it is limited to the people who
+	// schedule planned work.
+	"POST /v1/maintenance-windows": {
+		RequireRoles: []string{"operator", "oncall", "dba"},
+		AdminBypass:  true,
+	},
+	"PUT /v1/maintenance-windows/{windowID}": {
+		RequireRoles: []string{"operator", "oncall", "dba"},
+		AdminBypass:  true,
+	},
+	"DELETE /v1/maintenance-windows/{windowID}": {
+		RequireRoles: []string{"operator", "oncall", "dba"},
+		AdminBypass:  true,
+	},
+
+	// ── Runtime configuration ─────────────────────────────────────────────────
+
+	// Readable by any authenticated caller; the auditor polls it for its
+	// thresholds. Changing alert thresholds or notifier URLs can silence
+	// alerting, so it is limited to the security team.
+	"GET /v1/config": {AdminBypass: true},
+	"PUT /v1/config": {
+		RequireRoles: []string{"security"},
+		AdminBypass:  true,
+	},
+
+	// ── Data subjects ─────────────────────────────────────────────────────────
+
+	// Erasure and pseudonymization rewrite stored events, so they are limited
+	// to the security team.
+	"POST /v1/subjects/erase": {
+		RequireRoles: []string{"security"},
+		AdminBypass:  true,
+	},
+	"POST /v1/subjects/pseudonymize": {
+		RequireRoles: []string{"security"},
+		AdminBypass:  true,
+	},
+
 	// ── Rollback & Undo ───────────────────────────────────────────────────────
 
 	// Read-only: any authenticated caller can query rollbacks and derive plans.
@@ -117,4 +254,36 @@ var DefaultAuditdPermissions = map[string]Permission{
 		RequireRoles: []string{"operator", "fleet-approver", "admin"},
 		AdminBypass:  true,
 	},
+
+	// ── Canary ────────────────────────────────────────────────────────────────
+
+	// The last run is readable by any authenticated caller.
+	"GET /v1/canary": {AdminBypass: true},
+	// An on-demand run records an event, so it is limited like compaction.
+	"POST /v1/canary/run": {
+		RequireRoles: []string{"operator", "admin"},
+		AdminBypass:  true,
+	},
+
+	// ── Report subscriptions ──────────────────────────────────────────────────
+
+	// Any authenticated caller may subscribe to reports and see the
+	// subscriptions in their tenant; the handler limits changes to the
+	// subscription's owner or an admin.
+	"POST /v1/report-subscriptions":                         {AdminBypass: true},
+	"GET /v1/report-subscriptions":                          {AdminBypass: true},
+	"GET /v1/report-subscriptions/{subscriptionID}":         {AdminBypass: true},
+	"PUT /v1/report-subscriptions/{subscriptionID}":         {AdminBypass: true},
+	"DELETE /v1/report-subscriptions/{subscriptionID}":      {AdminBypass: true},
+	"GET /v1/report-subscriptions/{subscriptionID}/preview": {AdminBypass: true},
+
+	// ── Storage ───────────────────────────────────────────────────────────────
+
+	// Size and fragmentation are readable by any authenticated caller.
+	"GET /v1/storage": {AdminBypass: true},
+	// Forcing a compaction takes the write lock for the duration of a VACUUM.
+	"POST /v1/storage/compact": {
+		RequireRoles: []string{"operator", "admin"},
+		AdminBypass:  true,
+	},
 }
@@ -57,6 +57,7 @@ func TestAuthorize_AllowAnonymous(t *testing.T) {
 		"GET /api/v1/agents",
 		"GET /api/v1/tools",
 		"GET /api/v1/tools/{toolName}",
+		"GET /api/v1/trace-prefixes",
 	}
 	for _, pattern := range routes {
 		if err := a.Authorize(pattern, anonPrincipal()); err != nil {
@@ -189,7 +190,13 @@ func TestAuthorize_ServiceOnly_ServicePasses(t *testing.T) {
 		"POST /v1/events",
 		"POST /v1/approvals",
 		"POST /v1/governance/check",
+		"POST /v1/governance/check/batch",
+		"POST /v1/remediation-plans",
+		"POST /v1/remediation-plans/{planID}/execute",
+		"POST /v1/db-audit/logs",
+		"GET /v1/infra",
 		"POST /v1/fleet/jobs",
+		"POST /v1/alerts",
 	}
 	svc := servicePrincipal("srebot")
 	for _, pattern := range serviceRoutes {
@@ -424,31 +431,61 @@ func TestMiddleware_PublicRoute_PassesAnonymous(t *testing.T) {
 // removed without updating the tables.
 
 // gatewayRoutes is the authoritative list of patterns registered in
-// Gateway.RegisterRoutes (cmd/gateway/gateway.go).
+// Gateway.RegisterRoutes (internal/gateway/gateway.go).
 var gatewayRoutes = []string{
 	"GET /health",
+	"GET /version",
 	"GET /api/v1/agents",
 	"GET /api/v1/tools",
 	"GET /api/v1/tools/{toolName}",
+	"GET /api/v1/trace-prefixes",
 	"POST /api/v1/query",
+	"POST /api/v1/route",
 	"POST /api/v1/incidents",
 	"GET /api/v1/incidents",
 	"GET /api/v1/incidents/{runID}",
 	"POST /api/v1/db/{tool}",
 	"POST /api/v1/k8s/{tool}",
+	"POST /api/v1/agents/{agent}/tools/{tool}",
 	"POST /api/v1/research",
 	"GET /api/v1/infrastructure",
 	"GET /api/v1/databases",
+	"POST /api/v1/databases/{name}/statement-snapshots",
 	"POST /api/v1/admin/infra/register-db",
+	"GET /api/v1/admin/killswitch",
+	"POST /api/v1/admin/killswitch/sessions",
+	"DELETE /api/v1/admin/killswitch/sessions/{id}",
+	"POST /api/v1/admin/killswitch/agents/{agent}/revoke",
+	"DELETE /api/v1/admin/killswitch/agents/{agent}",
+	"GET /api/v1/admin/freeze",
+	"POST /api/v1/admin/freeze",
+	"DELETE /api/v1/admin/freeze",
+	"GET /api/v1/admin/config",
+	"PUT /api/v1/admin/config",
 	"GET /api/v1/governance",
 	"GET /api/v1/governance/policies",
 	"GET /api/v1/governance/explain",
 	"GET /api/v1/governance/events",
+	"GET /api/v1/governance/events/stats",
+	"GET /api/v1/governance/events/latency",
+	"GET /api/v1/governance/events/scorecards",
 	"GET /api/v1/governance/events/{eventID}",
+	"POST /api/v1/governance/ask",
 	"GET /api/v1/governance/approvals/pending",
 	"GET /api/v1/governance/approvals",
 	"GET /api/v1/governance/verify",
 	"GET /api/v1/governance/journeys",
+	"POST /api/v1/governance/traces/{traceID}/annotations",
+	"GET /api/v1/governance/traces/{traceID}/annotations",
+	"POST /api/v1/governance/events/{eventID}/annotations",
+	"GET /api/v1/governance/events/{eventID}/annotations",
+	"POST /api/v1/governance/events/{eventID}/feedback",
+	"GET /api/v1/governance/events/{eventID}/feedback",
+	"GET /api/v1/governance/events/feedback/stats",
+	"GET /api/v1/governance/alerts",
+	"GET /api/v1/governance/alerts/precision",
+	"POST /api/v1/governance/alerts/{alertID}/false-positive",
+	"GET /api/v1/governance/maintenance-windows",
 	"GET /api/v1/governance/govbot/runs",
 	"POST /api/v1/fleet/plan",
 	"POST /api/v1/fleet/snapshot",
@@ -507,7 +544,7 @@ var gatewayRoutes = []string{
 }
 
 // auditdRoutes is the authoritative list of patterns registered in
-// cmd/auditd/main.go.
+// internal/auditd/main.go.
 var auditdRoutes = []string{
 	"POST /v1/events",
 	"POST /v1/events/{eventID}/outcome",
@@ -521,12 +558,43 @@ var auditdRoutes = []string{
 	"POST /v1/approvals/{approvalID}/approve",
 	"POST /v1/approvals/{approvalID}/deny",
 	"POST /v1/approvals/{approvalID}/cancel",
+	"GET /v1/approvals/{approvalID}/link",
+	"POST /v1/approvals/{approvalID}/link",
+	"POST /v1/sms/inbound",
 	"GET /v1/governance/info",
 	"GET /v1/governance/policies",
 	"GET /v1/governance/explain",
 	"POST /v1/governance/check",
+	"POST /v1/governance/check/batch",
+	"POST /v1/remediation-plans",
+	"GET /v1/remediation-plans",
+	"GET /v1/remediation-plans/{planID}",
+	"POST /v1/remediation-plans/{planID}/approve",
+	"POST /v1/remediation-plans/{planID}/deny",
+	"POST /v1/remediation-plans/{planID}/steps/{stepIndex}/approve",
+	"POST /v1/remediation-plans/{planID}/steps/{stepIndex}/deny",
+	"POST /v1/remediation-plans/{planID}/execute",
+	"POST /v1/db-audit/logs",
+	"GET /v1/events/stats",
+	"GET /v1/events/latency",
+	"GET /v1/events/clock-skew",
+	"GET /v1/events/scorecards",
+	"GET /v1/events/calibration",
+	"GET /v1/events/calibration/history",
 	"GET /v1/events/{eventID}",
 	"GET /v1/journeys",
+	"POST /v1/traces/{traceID}/annotations",
+	"GET /v1/traces/{traceID}/annotations",
+	"POST /v1/events/{eventID}/annotations",
+	"GET /v1/events/{eventID}/annotations",
+	"POST /v1/events/{eventID}/feedback",
+	"GET /v1/events/{eventID}/feedback",
+	"GET /v1/events/feedback/stats",
+	"POST /v1/alerts",
+	"GET /v1/alerts",
+	"GET /v1/alerts/suppressions",
+	"GET /v1/alerts/precision",
+	"POST /v1/alerts/{alertID}/false-positive",
 	"POST /v1/govbot/runs",
 	"GET /v1/govbot/runs",
 	"POST /v1/fleet/jobs",
@@ -559,7 +627,33 @@ var auditdRoutes = []string{
 	"POST /v1/tool-results",
 	"GET /v1/tool-results",
 	"GET /health",
+	"GET /version",
 	// Rollback & Undo
+	"GET /v1/freeze",
+	"POST /v1/freeze",
+	"DELETE /v1/freeze",
+	"POST /v1/maintenance-windows",
+	"GET /v1/maintenance-windows",
+	"GET /v1/maintenance-windows/{windowID}",
+	"PUT /v1/maintenance-windows/{windowID}",
+	"DELETE /v1/maintenance-windows/{windowID}",
+	"GET /v1/config",
+	"PUT /v1/config",
+	"POST /v1/subjects/erase",
+	"POST /v1/subjects/pseudonymize",
+	"GET /v1/infra",
+	"GET /v1/exports/worm",
+	"GET /v1/canary",
+	"POST /v1/canary/run",
+	"GET /v1/storage",
+	"POST /v1/storage/compact",
+	"POST /v1/report-subscriptions",
+	"GET /v1/report-subscriptions",
+	"GET /v1/report-subscriptions/{subscriptionID}",
+	"PUT /v1/report-subscriptions/{subscriptionID}",
+	"DELETE /v1/report-subscriptions/{subscriptionID}",
+	"GET /v1/report-subscriptions/{subscriptionID}/preview",
+	"GET /metrics",
 	"POST /v1/rollbacks",
 	"GET /v1/rollbacks",
 	"GET /v1/rollbacks/{rollbackID}",
@@ -706,13 +800,16 @@ func TestAuthorizer_RoleGrants(t *testing.T) {
 	a := NewAuthorizer(DefaultGatewayPermissions, true)
 	grants := a.RoleGrants()
 
-	// "dba" should grant DB tools and governance approve/deny.
+	// "dba" should grant DB tools (dedicated and generic routes) and
+	// governance approve/deny.
 	dbaGrants, ok := grants["dba"]
 	if !ok {
 		t.Fatal("RoleGrants missing 'dba' key")
 	}
 	wantDBA := map[string]bool{
 		"POST /api/v1/db/{tool}":                                 true,
+		"POST /api/v1/databases/{name}/statement-snapshots":      true,
+		"POST /api/v1/agents/{agent}/tools/{tool}":               true,
 		"POST /api/v1/governance/approvals/{approvalID}/approve": true,
 		"POST /api/v1/governance/approvals/{approvalID}/deny":    true,
 	}
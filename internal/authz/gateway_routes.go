@@ -7,13 +7,16 @@ var DefaultGatewayPermissions = map[string]Permission{
 	// ── Public: no authentication required ────────────────────────────────────
 	"GET /health":                  {AllowAnonymous: true},
 	"GET /metrics":                 {AllowAnonymous: true}, // Prometheus scrapes
+	"GET /version":                 {AllowAnonymous: true},
 	"GET /api/v1/agents":           {AllowAnonymous: true},
 	"GET /api/v1/tools":            {AllowAnonymous: true},
 	"GET /api/v1/tools/{toolName}": {AllowAnonymous: true},
+	"GET /api/v1/trace-prefixes":   {AllowAnonymous: true},
 	"GET /api/v1/roles":            {AllowAnonymous: true},
 
 	// ── Authenticated: any verified (non-anonymous) user ──────────────────────
 	"POST /api/v1/query":         {AdminBypass: true},
+	"POST /api/v1/route":         {AdminBypass: true}, // routing simulation; no tools run
 	"POST /api/v1/incidents":       {AdminBypass: true},
 	"GET /api/v1/incidents":        {AdminBypass: true},
 	"GET /api/v1/incidents/{runID}": {AdminBypass: true},
@@ -27,13 +30,37 @@ var DefaultGatewayPermissions = map[string]Permission{
 	"GET /api/v1/governance/policies":          {AdminBypass: true},
 	"GET /api/v1/governance/explain":           {AdminBypass: true},
 	"GET /api/v1/governance/events":            {AdminBypass: true},
+	"GET /api/v1/governance/events/stats":      {AdminBypass: true},
+	"GET /api/v1/governance/events/latency":    {AdminBypass: true},
+	"GET /api/v1/governance/events/scorecards": {AdminBypass: true},
 	"GET /api/v1/governance/events/{eventID}":  {AdminBypass: true},
+	"POST /api/v1/governance/ask":              {AdminBypass: true},
 	"GET /api/v1/governance/approvals/pending": {AdminBypass: true},
 	"GET /api/v1/governance/approvals":         {AdminBypass: true},
 	"GET /api/v1/governance/verify":            {AdminBypass: true},
 	"GET /api/v1/governance/journeys":          {AdminBypass: true},
 	"GET /api/v1/governance/govbot/runs":       {AdminBypass: true},
 
+	// Trace annotations: any authenticated caller (secbot, govbot, an
+	// investigator) may attach an external reference to a trace.
+	"GET /api/v1/governance/traces/{traceID}/annotations":  {AdminBypass: true},
+	"POST /api/v1/governance/traces/{traceID}/annotations": {AdminBypass: true},
+
+	// Event annotations: investigator notes and dispositions on single events.
+	"GET /api/v1/governance/events/{eventID}/annotations":  {AdminBypass: true},
+	"POST /api/v1/governance/events/{eventID}/annotations": {AdminBypass: true},
+
+	// Delegation feedback: whether a delegation solved the requester's problem.
+	"GET /api/v1/governance/events/{eventID}/feedback":  {AdminBypass: true},
+	"POST /api/v1/governance/events/{eventID}/feedback": {AdminBypass: true},
+	"GET /api/v1/governance/events/feedback/stats":      {AdminBypass: true},
+
+	// Auditor alerts and false-positive feedback.
+	"GET /api/v1/governance/alerts":                           {AdminBypass: true},
+	"GET /api/v1/governance/alerts/precision":                 {AdminBypass: true},
+	"POST /api/v1/governance/alerts/{alertID}/false-positive": {AdminBypass: true},
+	"GET /api/v1/governance/maintenance-windows":              {AdminBypass: true},
+
 	// Governance approval actions — mirror auditd's POST /v1/approvals/{id}/{approve,deny}
 	// (coarse gateway check; auditd applies the per-approval-type role).
 	"POST /api/v1/governance/approvals/{approvalID}/approve": {
@@ -110,12 +137,70 @@ var DefaultGatewayPermissions = map[string]Permission{
 		AdminBypass:  true,
 	},
 
+	// Statement snapshots run get_statement_snapshot on the DB agent, so they
+	// need the same roles as the DB tool route.
+	"POST /api/v1/databases/{name}/statement-snapshots": {
+		RequireRoles: []string{"dba", "sre", "oncall", "sre-automation"},
+		AdminBypass:  true,
+	},
+
 	// K8s tools: same rationale.
 	"POST /api/v1/k8s/{tool}": {
 		RequireRoles: []string{"sre", "k8s-admin", "oncall", "sre-automation"},
 		AdminBypass:  true,
 	},
 
+	// Generic agent-tool route: the union of the dedicated direct-tool roles.
+	// The handler also applies the agent's dedicated route permission (db, k8s),
+	// so this route never grants more than those do.
+	"POST /api/v1/agents/{agent}/tools/{tool}": {
+		RequireRoles: []string{"dba", "sre", "k8s-admin", "oncall", "sre-automation"},
+		AdminBypass:  true,
+	},
+
+	// Kill-switch: pause delegations for a session/user or revoke an agent.
+	// Security responders and on-call may contain; reads are open to the same set.
+	"GET /api/v1/admin/killswitch": {
+		RequireRoles: []string{"security", "oncall", "sre-automation"},
+		AdminBypass:  true,
+	},
+	"POST /api/v1/admin/killswitch/sessions": {
+		RequireRoles: []string{"security", "oncall", "sre-automation"},
+		AdminBypass:  true,
+	},
+	"DELETE /api/v1/admin/killswitch/sessions/{id}": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+	"POST /api/v1/admin/killswitch/agents/{agent}/revoke": {
+		RequireRoles: []string{"security", "oncall", "sre-automation"},
+		AdminBypass:  true,
+	},
+	"DELETE /api/v1/admin/killswitch/agents/{agent}": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+
+	// Emergency freeze: fleet-wide read-only mode (proxied to auditd).
+	// Anyone may see whether the fleet is frozen; only humans in incident
+	// roles may flip it, so automation cannot freeze or unfreeze on its own.
+	"GET /api/v1/admin/freeze": {AdminBypass: true},
+	"POST /api/v1/admin/freeze": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+	"DELETE /api/v1/admin/freeze": {
+		RequireRoles: []string{"security", "oncall"},
+		AdminBypass:  true,
+	},
+
+	// Runtime configuration (proxied to auditd, which audits every change).
+	"GET /api/v1/admin/config": {AdminBypass: true},
+	"PUT /api/v1/admin/config": {
+		RequireRoles: []string{"security"},
+		AdminBypass:  true,
+	},
+
 	// Fleet job submission: fleet-operator role required to create a live job.
 	"POST /api/v1/fleet/jobs": {
 		RequireRoles: []string{"fleet-operator"},
@@ -2,8 +2,98 @@
 // Every binary in the release sets this at build time:
 //
 //	-X helpdesk/internal/buildinfo.Version=<version>-<git-sha>
+//	-X helpdesk/internal/buildinfo.Commit=<git-sha>
+//	-X helpdesk/internal/buildinfo.BuildDate=<RFC 3339 time>
 package buildinfo
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
 // Version is the release version string, set at build time.
 // Falls back to "dev" when running from source without ldflags.
 var Version = "dev"
+
+// Commit and BuildDate are the git commit and time of the build, set at
+// build time. Without ldflags they are read from the VCS stamp the go
+// command embeds when building inside a git checkout, if any.
+var (
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Component string `json:"component"` // binary name, e.g. "database-agent"
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build info of the running binary.
+func Get() Info {
+	info := Info{
+		Component: filepath.Base(os.Args[0]),
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+	if info.Commit != "" && info.BuildDate != "" {
+		return info
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+				if len(info.Commit) > 7 {
+					info.Commit = info.Commit[:7]
+				}
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// String formats the info as printed by --version, e.g.
+// "auditd v1.2.0-3f9c2ab (commit 3f9c2ab, built 2026-01-15T10:00:00Z, go1.25.1)".
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		details = append(details, "commit "+i.Commit)
+	}
+	if i.BuildDate != "" {
+		details = append(details, "built "+i.BuildDate)
+	}
+	details = append(details, i.GoVersion)
+	return fmt.Sprintf("%s %s (%s)", i.Component, i.Version, strings.Join(details, ", "))
+}
+
+// HandleVersionFlag prints the build info and exits when the binary was run
+// with -version or --version as its first argument. Call it first thing in
+// main, before flag parsing or subcommand dispatch.
+func HandleVersionFlag() {
+	if len(os.Args) > 1 && (os.Args[1] == "-version" || os.Args[1] == "--version") {
+		fmt.Println(Get())
+		os.Exit(0)
+	}
+}
+
+// Handler serves the build info as JSON, for GET /version.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Get()) //nolint:errcheck
+	}
+}
@@ -0,0 +1,37 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	Version, Commit, BuildDate = "v1.2.0-3f9c2ab", "3f9c2ab", "2026-01-15T10:00:00Z"
+	t.Cleanup(func() { Version, Commit, BuildDate = "dev", "", "" })
+
+	info := Get()
+	if info.Version != "v1.2.0-3f9c2ab" || info.Commit != "3f9c2ab" || info.BuildDate != "2026-01-15T10:00:00Z" || info.GoVersion == "" {
+		t.Errorf("Get() = %+v", info)
+	}
+	info.Component, info.GoVersion = "auditd", "go1.25.1"
+	if got, want := info.String(), "auditd v1.2.0-3f9c2ab (commit 3f9c2ab, built 2026-01-15T10:00:00Z, go1.25.1)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler()(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var info Info
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != Version || info.Component == "" {
+		t.Errorf("served %+v", info)
+	}
+}
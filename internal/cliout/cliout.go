@@ -0,0 +1,102 @@
+// Package cliout implements the -o output convention shared by the operator
+// CLIs (approvals, govexplain, govbot, helpdeskctl route): human-readable
+// tables by default, or JSON / YAML documents for scripting.
+//
+// JSON and YAML carry the same field names — the json tags of the API types,
+// which are part of the API contract — so a script can switch between the
+// two without changing its selectors.
+package cliout
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format selected with -o.
+type Format string
+
+const (
+	Table Format = "table" // human-readable (the default)
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+// String implements flag.Value.
+func (f *Format) String() string {
+	if *f == "" {
+		return string(Table)
+	}
+	return string(*f)
+}
+
+// Set implements flag.Value.
+func (f *Format) Set(s string) error {
+	switch Format(s) {
+	case Table, JSON, YAML:
+		*f = Format(s)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want table, json or yaml)", s)
+}
+
+// Structured reports whether f is a machine-readable format.
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+// Register defines -o and its long form -output on fs, both setting f.
+func Register(fs *flag.FlagSet, f *Format) {
+	fs.Var(f, "o", "Output format: table, json or yaml")
+	fs.Var(f, "output", "Same as -o")
+}
+
+// Write encodes v to w as JSON or YAML. Any other format writes JSON.
+func Write(w io.Writer, f Format, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteRaw(w, f, data)
+}
+
+// WriteRaw writes a JSON document, such as an API response body, to w as
+// indented JSON or as YAML with the same keys in the same order.
+func WriteRaw(w io.Writer, f Format, data []byte) error {
+	if f != YAML {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, bytes.TrimSpace(data), "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	// JSON is YAML, so parsing it into a node keeps the key order and the
+	// exact numbers; only the flow style and quoting need resetting.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the JSON styling of n and its children, so the encoder
+// picks block style and quotes scalars only where YAML needs it.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
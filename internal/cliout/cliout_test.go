@@ -0,0 +1,75 @@
+package cliout
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteRaw_YAMLKeepsOrderAndTypes(t *testing.T) {
+	body := `{"status":"pending","approval_id":"apr_1","count":12345678901,"ok":true,"note":"true","tags":["a","b"],"empty":[],"when":"2026-10-15T10:00:00Z"}`
+	var buf bytes.Buffer
+	if err := WriteRaw(&buf, YAML, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	want := `status: pending
+approval_id: apr_1
+count: 12345678901
+ok: true
+note: "true"
+tags:
+  - a
+  - b
+empty: []
+when: "2026-10-15T10:00:00Z"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("YAML =\n%s\nwant\n%s", got, want)
+	}
+
+	var back map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back["note"] != "true" || back["ok"] != true {
+		t.Errorf("round trip changed scalar types: %v", back)
+	}
+}
+
+func TestWrite_JSONUsesJSONTags(t *testing.T) {
+	v := struct {
+		EventID string `json:"event_id"`
+		Skipped string `json:"skipped,omitempty"`
+	}{EventID: "pol_1"}
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, v); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "{\n  \"event_id\": \"pol_1\"\n}\n" {
+		t.Errorf("JSON = %q", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	for _, args := range [][]string{{"-o", "yaml"}, {"-output=yaml"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var f Format
+		Register(fs, &f)
+		if err := fs.Parse(args); err != nil || f != YAML || !f.Structured() {
+			t.Errorf("Parse(%v) = %q, %v", args, f, err)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var f Format
+	Register(fs, &f)
+	if err := fs.Parse([]string{"-o", "xml"}); err == nil {
+		t.Error("Parse(-o xml) succeeded")
+	}
+	if f.Structured() || f.String() != "table" {
+		t.Errorf("default format = %q", f.String())
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"net/smtp"
 	"strings"
 	"time"
+
+	"helpdesk/internal/shutdown"
 )
 
 // NotifierConfig configures the DecisionNotifier.
@@ -55,12 +57,13 @@ func NewDecisionNotifier(cfg NotifierConfig) *DecisionNotifier {
 }
 
 // NotifyPending fires when a decision is created and awaits operator action.
-// The call is non-blocking: notifications are sent in a background goroutine.
+// The call is non-blocking: notifications are sent in a background goroutine
+// that shutdown drains.
 func (n *DecisionNotifier) NotifyPending(ctx context.Context, d Decision) {
 	if n == nil {
 		return
 	}
-	go n.send(context.WithoutCancel(ctx), "decision_pending", d)
+	shutdown.Go(func() { n.send(context.WithoutCancel(ctx), "decision_pending", d) })
 }
 
 // NotifyResolved fires when a decision is approved, denied, or expires.
@@ -69,7 +72,7 @@ func (n *DecisionNotifier) NotifyResolved(ctx context.Context, d Decision) {
 	if n == nil {
 		return
 	}
-	go n.send(context.WithoutCancel(ctx), "decision_resolved", d)
+	shutdown.Go(func() { n.send(context.WithoutCancel(ctx), "decision_resolved", d) })
 }
 
 // webhookPayload is the normalised JSON body sent to any webhook endpoint.
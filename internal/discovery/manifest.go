@@ -0,0 +1,205 @@
+package discovery
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// ManifestPath is where an agent serves its signed capability manifest.
+const ManifestPath = "/.well-known/capability-manifest.json"
+
+// ManifestViolationModule is the GovernanceViolation.Module of the events
+// recorded when an agent is refused for exceeding its manifest.
+const ManifestViolationModule = "capability_manifest"
+
+// ErrBadManifestSignature is returned by SignedManifest.Verify when the
+// signature does not match the payload under the trusted public key.
+var ErrBadManifestSignature = errors.New("capability manifest signature is invalid")
+
+// CapabilityManifest declares what an agent is allowed to advertise: the
+// tools it may expose and the most dangerous action class among them. It is
+// written and signed by an operator, not by the agent, so an agent whose card
+// is tampered with cannot widen its own capabilities.
+type CapabilityManifest struct {
+	Agent          string            `json:"agent"`
+	Tools          []string          `json:"tools"`
+	MaxActionClass audit.ActionClass `json:"max_action_class"`
+	IssuedAt       time.Time         `json:"issued_at,omitempty"`
+}
+
+// SignedManifest is a capability manifest as served by an agent at
+// ManifestPath. Payload is the manifest JSON exactly as signed.
+type SignedManifest struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"` // Ed25519 signature of Payload
+}
+
+// actionClassRank orders action classes by risk. Classes not listed (e.g.
+// unknown) are never compared.
+var actionClassRank = map[audit.ActionClass]int{
+	audit.ActionRead:        1,
+	audit.ActionWrite:       2,
+	audit.ActionDestructive: 3,
+	audit.ActionEscalation:  3,
+}
+
+// ParseManifest parses and validates manifest JSON.
+func ParseManifest(data []byte) (*CapabilityManifest, error) {
+	var m CapabilityManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse capability manifest: %v", err)
+	}
+	if m.Agent == "" {
+		return nil, fmt.Errorf("capability manifest has no agent")
+	}
+	if _, ok := actionClassRank[m.MaxActionClass]; !ok {
+		return nil, fmt.Errorf("capability manifest for %s: invalid max_action_class %q", m.Agent, m.MaxActionClass)
+	}
+	return &m, nil
+}
+
+// SignManifest validates manifest JSON and signs it with an Ed25519 key.
+func SignManifest(payload []byte, key ed25519.PrivateKey) (SignedManifest, error) {
+	if _, err := ParseManifest(payload); err != nil {
+		return SignedManifest{}, err
+	}
+	return SignedManifest{Payload: payload, Signature: ed25519.Sign(key, payload)}, nil
+}
+
+// Verify checks the signature against pub and returns the parsed manifest.
+// Nothing from an unverified payload is parsed.
+func (s SignedManifest) Verify(pub ed25519.PublicKey) (*CapabilityManifest, error) {
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(pub, s.Payload, s.Signature) {
+		return nil, ErrBadManifestSignature
+	}
+	return ParseManifest(s.Payload)
+}
+
+// Check compares what an agent advertises against its manifest and returns
+// every way it exceeds it: a manifest issued for another agent, tools not
+// declared, and tools whose action class is above MaxActionClass.
+func (m *CapabilityManifest) Check(agentName string, tools []string, classification map[string]audit.ActionClass) []string {
+	var problems []string
+	if m.Agent != agentName {
+		problems = append(problems, fmt.Sprintf("manifest is for agent %q", m.Agent))
+	}
+	declared := make(map[string]bool, len(m.Tools))
+	for _, t := range m.Tools {
+		declared[t] = true
+	}
+	maxRank := actionClassRank[m.MaxActionClass]
+	for _, t := range tools {
+		if !declared[t] {
+			problems = append(problems, fmt.Sprintf("tool %s is not in the manifest", t))
+			continue
+		}
+		if rank, ok := actionClassRank[classification[t]]; ok && rank > maxRank {
+			problems = append(problems, fmt.Sprintf("tool %s is %s, above the manifest's %s", t, classification[t], m.MaxActionClass))
+		}
+	}
+	return problems
+}
+
+// AdvertisedTools returns the tools an agent advertises: the tool skills on
+// its card (IDs "<agent>-<tool>") and the tools in its /schemas response.
+func AdvertisedTools(agentName string, card *a2a.AgentCard, schemas map[string]map[string]any) []string {
+	seen := make(map[string]bool)
+	if card != nil {
+		for _, skill := range card.Skills {
+			if tool, ok := strings.CutPrefix(skill.ID, agentName+"-"); ok && tool != "" {
+				seen[tool] = true
+			}
+		}
+	}
+	for tool := range schemas {
+		seen[tool] = true
+	}
+	tools := make([]string, 0, len(seen))
+	for tool := range seen {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// FetchManifest fetches the signed capability manifest from an agent's base URL.
+func FetchManifest(client *http.Client, baseURL string) (SignedManifest, error) {
+	var signed SignedManifest
+	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + ManifestPath)
+	if err != nil {
+		return signed, fmt.Errorf("fetch capability manifest: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return signed, fmt.Errorf("capability manifest returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return signed, fmt.Errorf("read capability manifest: %v", err)
+	}
+	if err := json.Unmarshal(body, &signed); err != nil {
+		return signed, fmt.Errorf("parse capability manifest: %v", err)
+	}
+	return signed, nil
+}
+
+// CheckAgentManifest fetches and verifies an agent's manifest and checks the
+// agent's card and schemas against it. It returns nil when the agent stays
+// within its manifest; a missing or badly signed manifest is a problem too.
+func CheckAgentManifest(baseURL, agentName string, card *a2a.AgentCard, schemas map[string]map[string]any, pub ed25519.PublicKey, classification map[string]audit.ActionClass) []string {
+	signed, err := FetchManifest(&http.Client{Timeout: 5 * time.Second}, baseURL)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	m, err := signed.Verify(pub)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return m.Check(agentName, AdvertisedTools(agentName, card, schemas), classification)
+}
+
+// ManifestViolationEvent builds the governance_violation event recorded when
+// componentName refuses an agent that exceeds its capability manifest.
+func ManifestViolationEvent(componentName, agentName string, problems []string) *audit.Event {
+	return &audit.Event{
+		EventID:   "gov_" + uuid.New().String()[:8],
+		Timestamp: time.Now().UTC(),
+		EventType: audit.EventTypeGovernanceViolation,
+		Session:   audit.Session{ID: componentName},
+		GovernanceViolation: &audit.GovernanceViolation{
+			Module:      ManifestViolationModule,
+			Severity:    "fatal",
+			Description: fmt.Sprintf("agent %s refused: %s", agentName, strings.Join(problems, "; ")),
+			Remediation: "Check the agent's deployment for tampering, or re-sign its capability manifest if the new capabilities are intended",
+		},
+	}
+}
+
+// EnforceManifests checks every discovered agent against its signed
+// capability manifest and removes those that exceed it from agents. It
+// returns the problems found for each refused agent.
+func EnforceManifests(agents map[string]*Agent, pub ed25519.PublicKey, classification map[string]audit.ActionClass) map[string][]string {
+	refused := make(map[string][]string)
+	for name, a := range agents {
+		baseURL := strings.TrimSuffix(a.InvokeURL, "/invoke")
+		if problems := CheckAgentManifest(baseURL, name, a.Card, a.Schemas, pub, classification); len(problems) > 0 {
+			slog.Error("discovery: agent exceeds its capability manifest — refusing it", "agent", name, "problems", strings.Join(problems, "; "))
+			refused[name] = problems
+			delete(agents, name)
+		}
+	}
+	return refused
+}
@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a2aproject/a2a-go/a2a"
+
+	"helpdesk/internal/audit"
+)
+
+var testClassification = map[string]audit.ActionClass{
+	"get_pods":   audit.ActionRead,
+	"delete_pod": audit.ActionDestructive,
+}
+
+func signedTestManifest(t *testing.T, key ed25519.PrivateKey, m CapabilityManifest) SignedManifest {
+	t.Helper()
+	payload, _ := json.Marshal(m)
+	signed, err := SignManifest(payload, key)
+	if err != nil {
+		t.Fatalf("SignManifest: %v", err)
+	}
+	return signed
+}
+
+func TestSignedManifest_Verify(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	signed := signedTestManifest(t, key, CapabilityManifest{Agent: "k8s_agent", Tools: []string{"get_pods"}, MaxActionClass: audit.ActionRead})
+
+	m, err := signed.Verify(pub)
+	if err != nil || m.Agent != "k8s_agent" {
+		t.Fatalf("Verify = %+v, %v", m, err)
+	}
+	if _, err := signed.Verify(otherPub); !errors.Is(err, ErrBadManifestSignature) {
+		t.Errorf("wrong key: err = %v, want ErrBadManifestSignature", err)
+	}
+	tampered := signed
+	tampered.Payload = []byte(strings.Replace(string(signed.Payload), `"read"`, `"destructive"`, 1))
+	if _, err := tampered.Verify(pub); !errors.Is(err, ErrBadManifestSignature) {
+		t.Errorf("tampered payload: err = %v, want ErrBadManifestSignature", err)
+	}
+	if _, err := SignManifest([]byte(`{"agent": "k8s_agent", "max_action_class": "admin"}`), key); err == nil {
+		t.Error("SignManifest accepted an invalid max_action_class")
+	}
+}
+
+func TestCapabilityManifest_Check(t *testing.T) {
+	m := &CapabilityManifest{Agent: "k8s_agent", Tools: []string{"get_pods", "delete_pod"}, MaxActionClass: audit.ActionRead}
+	if p := m.Check("k8s_agent", []string{"get_pods"}, testClassification); len(p) != 0 {
+		t.Errorf("within manifest: problems = %v", p)
+	}
+	p := m.Check("k8s_agent", []string{"delete_pod", "exec_pod"}, testClassification)
+	if len(p) != 2 || !strings.Contains(p[0], "above the manifest's read") || !strings.Contains(p[1], "exec_pod is not in the manifest") {
+		t.Errorf("problems = %v", p)
+	}
+	if p := m.Check("sysadmin_agent", nil, testClassification); len(p) != 1 {
+		t.Errorf("wrong agent: problems = %v", p)
+	}
+}
+
+func TestEnforceManifests(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	manifest := signedTestManifest(t, key, CapabilityManifest{Agent: "k8s_agent", Tools: []string{"get_pods"}, MaxActionClass: audit.ActionRead})
+
+	newAgent := func(name string, serveManifest bool, skills ...string) *Agent {
+		mux := http.NewServeMux()
+		if serveManifest {
+			mux.HandleFunc("GET "+ManifestPath, func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(manifest)
+			})
+		}
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+		card := &a2a.AgentCard{Name: name}
+		for _, s := range skills {
+			card.Skills = append(card.Skills, a2a.AgentSkill{ID: name + "-" + s})
+		}
+		return &Agent{Name: name, InvokeURL: srv.URL + "/invoke", Card: card}
+	}
+
+	agents := map[string]*Agent{
+		"k8s_agent":      newAgent("k8s_agent", true, "get_pods"),
+		"rogue_agent":    newAgent("rogue_agent", true, "get_pods", "delete_pod"), // serves k8s_agent's manifest
+		"sysadmin_agent": newAgent("sysadmin_agent", false),
+	}
+
+	refused := EnforceManifests(agents, pub, testClassification)
+	if len(agents) != 1 || agents["k8s_agent"] == nil {
+		t.Errorf("accepted agents = %v, want only k8s_agent", agents)
+	}
+	if len(refused) != 2 || len(refused["sysadmin_agent"]) != 1 || !strings.Contains(refused["sysadmin_agent"][0], "status 404") {
+		t.Errorf("refused = %v", refused)
+	}
+
+	ev := ManifestViolationEvent("gateway", "rogue_agent", refused["rogue_agent"])
+	if ev.EventType != audit.EventTypeGovernanceViolation || ev.GovernanceViolation.Module != ManifestViolationModule ||
+		!strings.Contains(ev.GovernanceViolation.Description, "rogue_agent") {
+		t.Errorf("violation event = %+v", ev.GovernanceViolation)
+	}
+}
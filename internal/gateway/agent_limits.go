@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/agentlimit"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+)
+
+// SetAgentLimiter sets the per-agent concurrency limiter applied to A2A
+// calls and direct tool calls. nil (the default) limits nothing.
+func (g *Gateway) SetAgentLimiter(l *agentlimit.Limiter) {
+	g.agentLimiter = l
+}
+
+// requestPriority returns the queue priority of an agent call: the X-Priority
+// header when an authenticated service account (secbot, srebot) names a
+// priority, else incident priority for incident bundles, else the priority of
+// the declared purpose. The header is ignored from anyone else, since a higher
+// priority lets a call evict queued calls of other callers.
+func requestPriority(r *http.Request) agentlimit.Priority {
+	if authz.PrincipalFromContext(r.Context()).Service != "" {
+		if p, ok := agentlimit.ParsePriority(r.Header.Get("X-Priority")); ok {
+			return p
+		}
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/incidents") {
+		return agentlimit.PriorityIncident
+	}
+	return agentlimit.PriorityForPurpose(r.Header.Get("X-Purpose"))
+}
+
+// acquireAgentSlot takes a concurrency slot for a call to agentName, queued
+// at the request's priority. When no slot frees up in time it answers 429
+// with a Retry-After header (503 when the request ends while queued), records
+// rec as the failed request and returns false. The caller must call the
+// returned release when done.
+func (g *Gateway) acquireAgentSlot(w http.ResponseWriter, r *http.Request, agentName string, rec *audit.GatewayRequest) (func(), bool) {
+	ctx := agentlimit.WithPriority(r.Context(), requestPriority(r))
+	release, err := g.agentLimiter.Acquire(ctx, agentName)
+	if err == nil {
+		return release, true
+	}
+	status := http.StatusServiceUnavailable
+	var overload *agentlimit.OverloadError
+	if errors.As(err, &overload) {
+		status = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.Itoa(overload.RetryAfterSeconds()))
+	} else {
+		err = fmt.Errorf("gave up waiting for a free %s slot: %w", agentName, err)
+	}
+	slog.Warn("gateway: agent call rejected", "agent", agentName, "status", status, "err", err)
+	rec.Agent = agentName
+	rec.Duration = time.Since(rec.StartTime)
+	rec.Status = "error"
+	rec.Error = err.Error()
+	rec.HTTPCode = status
+	g.recordAudit(r.Context(), rec)
+	writeError(w, status, err.Error())
+	return nil, false
+}
+
+// writeAgentLimitMetrics appends the limiter's per-agent gauges and counters
+// to a /metrics response.
+func writeAgentLimitMetrics(w io.Writer, l *agentlimit.Limiter) {
+	stats := l.Stats()
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP gateway_agent_calls_in_flight Calls in flight to an agent under its concurrency limit\n")
+	fmt.Fprintf(w, "# TYPE gateway_agent_calls_in_flight gauge\n")
+	for _, s := range stats {
+		fmt.Fprintf(w, "gateway_agent_calls_in_flight{agent=%q,limit=\"%d\"} %d\n", s.Agent, s.Limit, s.InFlight)
+	}
+	queues := l.QueueStats()
+	fmt.Fprintf(w, "# HELP gateway_agent_calls_queued Calls waiting for a free slot to an agent, by queue priority\n")
+	fmt.Fprintf(w, "# TYPE gateway_agent_calls_queued gauge\n")
+	for _, q := range queues {
+		fmt.Fprintf(w, "gateway_agent_calls_queued{agent=%q,priority=%q} %d\n", q.Agent, q.Priority, q.Queued)
+	}
+	fmt.Fprintf(w, "# HELP gateway_agent_queue_wait_seconds Time calls waited for a slot to an agent, by queue priority\n")
+	fmt.Fprintf(w, "# TYPE gateway_agent_queue_wait_seconds summary\n")
+	for _, q := range queues {
+		fmt.Fprintf(w, "gateway_agent_queue_wait_seconds_sum{agent=%q,priority=%q} %.6f\n", q.Agent, q.Priority, q.WaitTotal.Seconds())
+		fmt.Fprintf(w, "gateway_agent_queue_wait_seconds_count{agent=%q,priority=%q} %d\n", q.Agent, q.Priority, q.Admitted)
+	}
+	fmt.Fprintf(w, "# HELP gateway_agent_calls_rejected_total Calls rejected because an agent was at its concurrency limit\n")
+	fmt.Fprintf(w, "# TYPE gateway_agent_calls_rejected_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(w, "gateway_agent_calls_rejected_total{agent=%q} %d\n", s.Agent, s.Rejected)
+	}
+}
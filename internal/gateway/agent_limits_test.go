@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/agentlimit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/identity"
+)
+
+func TestAgentLimiter_RejectsOverflowWithRetryAfter(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		json.NewEncoder(w).Encode(map[string]string{"output": "ok"}) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+	gw.SetAgentLimiter(agentlimit.New(agentlimit.Config{Limits: map[string]int{agentNameDB: 1}}))
+	gw.SetMetrics(NewGatewayMetrics())
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection",
+			strings.NewReader(`{"connection_string":"postgres://localhost/test"}`))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() { first <- call() }()
+	<-entered
+
+	rec := call()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("second call: status = %d Retry-After = %q, body %s; want 429 with Retry-After",
+			rec.Code, rec.Header().Get("Retry-After"), rec.Body.String())
+	}
+	close(unblock)
+	if rec := <-first; rec.Code != http.StatusOK {
+		t.Errorf("first call: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// The slot is free again, and the rejection shows in /metrics.
+	go func() { <-entered }()
+	if rec := call(); rec.Code != http.StatusOK {
+		t.Errorf("call after release: status = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`gateway_agent_calls_rejected_total{agent="postgres_database_agent"} 1`,
+		`gateway_agent_calls_queued{agent="postgres_database_agent",priority="incident"} 0`,
+		`gateway_agent_queue_wait_seconds_count{agent="postgres_database_agent",priority="normal"} 2`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %s:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestRequestPriority(t *testing.T) {
+	service := identity.ResolvedPrincipal{Service: "srebot", AuthMethod: "api_key"}
+	user := identity.ResolvedPrincipal{UserID: "alice@example.com", AuthMethod: "api_key"}
+	tests := []struct {
+		path, priority, purpose string
+		principal               identity.ResolvedPrincipal
+		want                    agentlimit.Priority
+	}{
+		{"/api/v1/query", "", "", service, agentlimit.PriorityNormal},
+		{"/api/v1/query", "incident", "compliance", service, agentlimit.PriorityIncident},
+		{"/api/v1/query", "bogus", "compliance", service, agentlimit.PriorityBackground},
+		{"/api/v1/db/get_status_summary", "", "emergency", service, agentlimit.PriorityIncident},
+		{"/api/v1/incidents", "", "", service, agentlimit.PriorityIncident},
+		{"/api/v1/incidents", "background", "", service, agentlimit.PriorityBackground},
+
+		// Only service accounts may pick their priority.
+		{"/api/v1/query", "incident", "", identity.ResolvedPrincipal{}, agentlimit.PriorityNormal},
+		{"/api/v1/query", "incident", "compliance", identity.ResolvedPrincipal{AuthMethod: "header"}, agentlimit.PriorityBackground},
+		{"/api/v1/query", "incident", "", user, agentlimit.PriorityNormal},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		req = req.WithContext(authz.WithPrincipal(req.Context(), tt.principal))
+		if tt.priority != "" {
+			req.Header.Set("X-Priority", tt.priority)
+		}
+		if tt.purpose != "" {
+			req.Header.Set("X-Purpose", tt.purpose)
+		}
+		if got := requestPriority(req); got != tt.want {
+			t.Errorf("%s X-Priority=%q X-Purpose=%q principal=%q: priority %s, want %s",
+				tt.path, tt.priority, tt.purpose, tt.principal.EffectiveID(), got, tt.want)
+		}
+	}
+}
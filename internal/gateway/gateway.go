@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"bytes"
@@ -19,6 +19,7 @@ import (
 	"github.com/a2aproject/a2a-go/a2aclient"
 	"github.com/google/uuid"
 
+	"helpdesk/internal/agentlimit"
 	"helpdesk/internal/audit"
 	"helpdesk/internal/authz"
 	"helpdesk/internal/buildinfo"
@@ -26,6 +27,7 @@ import (
 	"helpdesk/internal/discovery"
 	"helpdesk/internal/identity"
 	"helpdesk/internal/infra"
+	"helpdesk/internal/shutdown"
 	"helpdesk/internal/toolregistry"
 )
 
@@ -44,6 +46,9 @@ const agentNameResearch = "research_agent"
 // agentNameSysadmin is the expected name for the sysadmin agent.
 const agentNameSysadmin = "sysadmin_agent"
 
+// agentNameKB is the expected name for the knowledge base agent.
+const agentNameKB = "kb_agent"
+
 // Gateway translates REST requests into A2A calls to sub-agents.
 type Gateway struct {
 	agents           map[string]*discovery.Agent
@@ -57,6 +62,7 @@ type Gateway struct {
 	authzr           *authz.Authorizer       // central per-route authorizer (nil = no authz)
 	operatingMode    string                  // "readonly" or "fix"
 	agentAPIKey      string                  // Bearer token sent to agent POST /tool/{name} endpoints
+	a2aKeys          map[string][]byte       // per-agent keys A2A requests are signed with
 	toolRegistry     *toolregistry.Registry  // catalog of discovered tools
 	plannerLLM       func(ctx context.Context, prompt string) (string, error) // injectable for tests
 	usersFile        string                  // path to users.yaml; empty = dev/no-auth mode
@@ -64,6 +70,9 @@ type Gateway struct {
 	crystalBall       bool                    // when true, bypass playbook guidance/chaining — for demo/comparison only
 	decisionNotifier *decisions.DecisionNotifier // nil = notifications disabled
 	gitWebhookCfg    GitWebhookConfig
+	killSwitch       *killSwitch // paused sessions and revoked agents
+	limits           RequestLimits // body size, content type and deadline per request
+	agentLimiter     *agentlimit.Limiter // per-agent concurrency caps (nil = unlimited)
 }
 
 // NewGateway creates a Gateway and establishes A2A clients for each agent.
@@ -78,7 +87,7 @@ func NewGateway(agents map[string]*discovery.Agent) *Gateway {
 		clients[name] = client
 		slog.Info("A2A client ready", "agent", name)
 	}
-	return &Gateway{agents: agents, clients: clients}
+	return &Gateway{agents: agents, clients: clients, killSwitch: newKillSwitch()}
 }
 
 // SetPlannerLLM sets the LLM text completion function used by the fleet planner.
@@ -127,6 +136,12 @@ func (g *Gateway) SetAgentAPIKey(key string) {
 	g.agentAPIKey = key
 }
 
+// SetA2ASigningKeys sets the per-agent keys A2A requests are signed with.
+// Agents without a key are called unsigned.
+func (g *Gateway) SetA2ASigningKeys(keys map[string][]byte) {
+	g.a2aKeys = keys
+}
+
 // SetIdentityProvider sets the identity provider used to resolve caller identity.
 func (g *Gateway) SetIdentityProvider(p identity.Provider) {
 	g.identityProvider = p
@@ -165,6 +180,12 @@ func (g *Gateway) SetCrystalBall(enabled bool) {
 	g.crystalBall = enabled
 }
 
+// SetRequestLimits sets the body size limits and per-route deadlines applied
+// to every route. Zero fields keep their defaults. Call before RegisterRoutes.
+func (g *Gateway) SetRequestLimits(l RequestLimits) {
+	g.limits = l
+}
+
 // GatewayMetrics tracks gateway-level operational counters in Prometheus text format.
 // It is thread-safe and uses no external library.
 type GatewayMetrics struct {
@@ -241,6 +262,44 @@ func patternAgent(pattern string) string {
 	}
 }
 
+// requestAgent is patternAgent for a live request: on the generic agent-tool
+// route the agent comes from the {agent} path value rather than the pattern.
+func (g *Gateway) requestAgent(pattern string, r *http.Request) string {
+	if strings.HasSuffix(pattern, "/api/v1/agents/{agent}/tools/{tool}") {
+		if name, _, ok := g.resolveToolAgent(r.PathValue("agent")); ok {
+			return name
+		}
+	}
+	return patternAgent(pattern)
+}
+
+// resolveToolAgent maps the {agent} segment of the generic agent-tool route to
+// a discovered agent. It accepts an alias ("db", "host"), a registry short name
+// ("database", "k8s") or the full agent name, and returns the full name used
+// for dispatch together with the short name used by the tool registry.
+func (g *Gateway) resolveToolAgent(name string) (fullName, shortName string, ok bool) {
+	if alias, found := agentAliases[name]; found {
+		name = alias
+	}
+	if _, found := g.agents[name]; found {
+		return name, toolregistry.NormalizeAgentName(name), true
+	}
+	for full := range g.agents {
+		if toolregistry.NormalizeAgentName(full) == name {
+			return full, name, true
+		}
+	}
+	return "", "", false
+}
+
+// agentToolPatterns maps a registry short name to the dedicated direct-tool
+// route whose permission also applies when that agent's tools are invoked
+// through the generic agent-tool route.
+var agentToolPatterns = map[string]string{
+	"database": "POST /api/v1/db/{tool}",
+	"k8s":      "POST /api/v1/k8s/{tool}",
+}
+
 // agentAliases maps short names (used in the /query endpoint) to internal
 // agent names used for client lookup.
 var agentAliases = map[string]string{
@@ -251,14 +310,21 @@ var agentAliases = map[string]string{
 	"research": agentNameResearch,
 	"sysadmin": agentNameSysadmin,
 	"host":     agentNameSysadmin,
+	"kb":       agentNameKB,
 }
 
 // RegisterRoutes sets up the REST endpoint handlers.
 func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
+	if g.killSwitch == nil {
+		g.killSwitch = newKillSwitch()
+	}
+
 	// auth wraps a handler with per-pattern identity resolution and authorization.
 	// The pattern is captured at registration time so r.Pattern need not be set.
+	// /api/v1 routes also get a trace ID echoed to the client (withTraceEcho).
+	// Request limits apply first, before any work is done for the request.
 	auth := func(pattern string, h http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
+		return g.withRequestLimits(pattern, withTraceEcho(pattern, func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			traceID := r.Header.Get("X-Trace-ID")
 			if traceID == "" {
@@ -293,7 +359,7 @@ func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
 						TraceID:           traceID,
 						Endpoint:          r.URL.Path,
 						Method:            r.Method,
-						Agent:             patternAgent(pattern),
+						Agent:             g.requestAgent(pattern, r),
 						StartTime:         start,
 						Duration:          time.Since(start),
 						Status:            "denied",
@@ -306,8 +372,15 @@ func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
 					return
 				}
 			}
-			h(w, r.WithContext(authz.WithPrincipal(r.Context(), principal)))
-		}
+			r = r.WithContext(authz.WithPrincipal(r.Context(), principal))
+			if r.Method != http.MethodGet || pattern == "GET /health" || !g.auditor.ShouldRecordRead(r.URL.Path) {
+				h(w, r)
+				return
+			}
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			h(rec, r)
+			g.recordRead(r, pattern, principal, traceID, start, rec.status)
+		}))
 	}
 
 	mux.HandleFunc("GET /health", auth("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -315,39 +388,70 @@ func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
 		hostname, _ := os.Hostname()
 		fmt.Fprintf(w, "{\"status\":\"ok\",\"version\":%q,\"hostname\":%q}\n", buildinfo.Version, hostname) //nolint:errcheck
 	}))
+	mux.HandleFunc("GET /version", auth("GET /version", buildinfo.Handler()))
 	// /metrics is unauthenticated (Prometheus scrapes do not carry auth tokens by default).
-	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /metrics", g.withRequestLimits("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
 		if g.metrics != nil {
 			g.metrics.ServeHTTP(w, r)
+			writeAgentLimitMetrics(w, g.agentLimiter)
 		} else {
-			http.NotFound(w, r)
+			writeError(w, http.StatusNotFound, "metrics not enabled")
 		}
-	})
+	}))
 	mux.HandleFunc("GET /api/v1/agents", auth("GET /api/v1/agents", g.handleListAgents))
 	mux.HandleFunc("GET /api/v1/tools", auth("GET /api/v1/tools", g.handleListTools))
 	mux.HandleFunc("GET /api/v1/tools/{toolName}", auth("GET /api/v1/tools/{toolName}", g.handleGetTool))
+	mux.HandleFunc("GET /api/v1/trace-prefixes", auth("GET /api/v1/trace-prefixes", g.handleTracePrefixes))
 	mux.HandleFunc("GET /api/v1/roles", auth("GET /api/v1/roles", g.handleListRoles))
 	mux.HandleFunc("POST /api/v1/query", auth("POST /api/v1/query", g.handleQuery))
+	mux.HandleFunc("POST /api/v1/route", auth("POST /api/v1/route", g.handleRoute))
 	mux.HandleFunc("POST /api/v1/incidents", auth("POST /api/v1/incidents", g.handleCreateIncident))
 	mux.HandleFunc("GET /api/v1/incidents", auth("GET /api/v1/incidents", g.handleListIncidents))
 	mux.HandleFunc("GET /api/v1/incidents/{runID}", auth("GET /api/v1/incidents/{runID}", g.handleGetIncident))
 	mux.HandleFunc("POST /api/v1/db/{tool}", auth("POST /api/v1/db/{tool}", g.handleDBTool))
 	mux.HandleFunc("POST /api/v1/k8s/{tool}", auth("POST /api/v1/k8s/{tool}", g.handleK8sTool))
+	mux.HandleFunc("POST /api/v1/agents/{agent}/tools/{tool}", auth("POST /api/v1/agents/{agent}/tools/{tool}", g.handleAgentTool))
 	mux.HandleFunc("POST /api/v1/research", auth("POST /api/v1/research", g.handleResearch))
 	mux.HandleFunc("GET /api/v1/infrastructure", auth("GET /api/v1/infrastructure", g.handleListInfrastructure))
 	mux.HandleFunc("GET /api/v1/databases", auth("GET /api/v1/databases", g.handleListDatabases))
+	mux.HandleFunc("POST /api/v1/databases/{name}/statement-snapshots", auth("POST /api/v1/databases/{name}/statement-snapshots", g.handleStatementSnapshot))
 	mux.HandleFunc("POST /api/v1/admin/infra/register-db", auth("POST /api/v1/admin/infra/register-db", g.handleRegisterEphemeralDB))
+	mux.HandleFunc("GET /api/v1/admin/killswitch", auth("GET /api/v1/admin/killswitch", g.handleKillSwitchStatus))
+	mux.HandleFunc("POST /api/v1/admin/killswitch/sessions", auth("POST /api/v1/admin/killswitch/sessions", g.handlePauseSession))
+	mux.HandleFunc("DELETE /api/v1/admin/killswitch/sessions/{id}", auth("DELETE /api/v1/admin/killswitch/sessions/{id}", g.handleResumeSession))
+	mux.HandleFunc("POST /api/v1/admin/killswitch/agents/{agent}/revoke", auth("POST /api/v1/admin/killswitch/agents/{agent}/revoke", g.handleRevokeAgent))
+	mux.HandleFunc("DELETE /api/v1/admin/killswitch/agents/{agent}", auth("DELETE /api/v1/admin/killswitch/agents/{agent}", g.handleRestoreAgent))
+	mux.HandleFunc("GET /api/v1/admin/freeze", auth("GET /api/v1/admin/freeze", g.handleFreeze))
+	mux.HandleFunc("POST /api/v1/admin/freeze", auth("POST /api/v1/admin/freeze", g.handleFreeze))
+	mux.HandleFunc("DELETE /api/v1/admin/freeze", auth("DELETE /api/v1/admin/freeze", g.handleFreeze))
+	mux.HandleFunc("GET /api/v1/admin/config", auth("GET /api/v1/admin/config", g.handleRuntimeConfig))
+	mux.HandleFunc("PUT /api/v1/admin/config", auth("PUT /api/v1/admin/config", g.handleRuntimeConfig))
 	mux.HandleFunc("GET /api/v1/governance", auth("GET /api/v1/governance", g.handleGovernance))
 	mux.HandleFunc("GET /api/v1/governance/policies", auth("GET /api/v1/governance/policies", g.handleGovernancePolicies))
 	mux.HandleFunc("GET /api/v1/governance/explain", auth("GET /api/v1/governance/explain", g.handleGovernanceExplain))
 	mux.HandleFunc("GET /api/v1/governance/events", auth("GET /api/v1/governance/events", g.handleGovernanceEvents))
+	mux.HandleFunc("GET /api/v1/governance/events/stats", auth("GET /api/v1/governance/events/stats", g.handleGovernanceEventStats))
+	mux.HandleFunc("GET /api/v1/governance/events/latency", auth("GET /api/v1/governance/events/latency", g.handleGovernanceEventLatency))
+	mux.HandleFunc("GET /api/v1/governance/events/scorecards", auth("GET /api/v1/governance/events/scorecards", g.handleGovernanceEventScorecards))
 	mux.HandleFunc("GET /api/v1/governance/events/{eventID}", auth("GET /api/v1/governance/events/{eventID}", g.handleGovernanceEvent))
+	mux.HandleFunc("POST /api/v1/governance/ask", auth("POST /api/v1/governance/ask", g.handleGovernanceAsk))
 	mux.HandleFunc("GET /api/v1/governance/approvals/pending", auth("GET /api/v1/governance/approvals/pending", g.handleGovernanceApprovalsPending))
 	mux.HandleFunc("GET /api/v1/governance/approvals", auth("GET /api/v1/governance/approvals", g.handleGovernanceApprovals))
 	mux.HandleFunc("POST /api/v1/governance/approvals/{approvalID}/approve", auth("POST /api/v1/governance/approvals/{approvalID}/approve", g.handleGovernanceApprovalApprove))
 	mux.HandleFunc("POST /api/v1/governance/approvals/{approvalID}/deny", auth("POST /api/v1/governance/approvals/{approvalID}/deny", g.handleGovernanceApprovalDeny))
 	mux.HandleFunc("GET /api/v1/governance/verify", auth("GET /api/v1/governance/verify", g.handleGovernanceVerify))
 	mux.HandleFunc("GET /api/v1/governance/journeys", auth("GET /api/v1/governance/journeys", g.handleGovernanceJourneys))
+	mux.HandleFunc("POST /api/v1/governance/traces/{traceID}/annotations", auth("POST /api/v1/governance/traces/{traceID}/annotations", g.handleGovernanceTraceAnnotate))
+	mux.HandleFunc("GET /api/v1/governance/traces/{traceID}/annotations", auth("GET /api/v1/governance/traces/{traceID}/annotations", g.handleGovernanceTraceAnnotations))
+	mux.HandleFunc("POST /api/v1/governance/events/{eventID}/annotations", auth("POST /api/v1/governance/events/{eventID}/annotations", g.handleGovernanceEventAnnotate))
+	mux.HandleFunc("GET /api/v1/governance/events/{eventID}/annotations", auth("GET /api/v1/governance/events/{eventID}/annotations", g.handleGovernanceEventAnnotations))
+	mux.HandleFunc("POST /api/v1/governance/events/{eventID}/feedback", auth("POST /api/v1/governance/events/{eventID}/feedback", g.handleGovernanceEventFeedbackSubmit))
+	mux.HandleFunc("GET /api/v1/governance/events/{eventID}/feedback", auth("GET /api/v1/governance/events/{eventID}/feedback", g.handleGovernanceEventFeedback))
+	mux.HandleFunc("GET /api/v1/governance/events/feedback/stats", auth("GET /api/v1/governance/events/feedback/stats", g.handleGovernanceFeedbackStats))
+	mux.HandleFunc("GET /api/v1/governance/alerts", auth("GET /api/v1/governance/alerts", g.handleGovernanceAlerts))
+	mux.HandleFunc("GET /api/v1/governance/alerts/precision", auth("GET /api/v1/governance/alerts/precision", g.handleGovernanceAlertPrecision))
+	mux.HandleFunc("POST /api/v1/governance/alerts/{alertID}/false-positive", auth("POST /api/v1/governance/alerts/{alertID}/false-positive", g.handleGovernanceAlertFalsePositive))
+	mux.HandleFunc("GET /api/v1/governance/maintenance-windows", auth("GET /api/v1/governance/maintenance-windows", g.handleGovernanceMaintenanceWindows))
 	mux.HandleFunc("GET /api/v1/governance/govbot/runs", auth("GET /api/v1/governance/govbot/runs", g.handleGovernanceGovbotRuns))
 
 	// Fleet job planner and snapshot refresh
@@ -459,7 +563,7 @@ func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/v1/decisions/{id}/resolve", auth("POST /api/v1/decisions/{id}/resolve", g.handleResolveDecision))
 
 	// Git webhook adapter — HMAC-validated, no Bearer auth required.
-	mux.HandleFunc("POST /api/v1/webhooks/git", g.handleGitWebhook)
+	mux.HandleFunc("POST /api/v1/webhooks/git", g.withRequestLimits("POST /api/v1/webhooks/git", g.handleGitWebhook))
 	mux.HandleFunc("GET /api/v1/fleet/playbook-runs/{runID}/steps", auth("GET /api/v1/fleet/playbook-runs/{runID}/steps", func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("runID")
 		g.proxyToAuditd(w, r, "/v1/fleet/playbook-runs/"+id+"/steps")
@@ -522,11 +626,14 @@ func (g *Gateway) handleQuery(w http.ResponseWriter, r *http.Request) {
 		r.Header.Set("X-Purpose-Note", req.PurposeNote)
 	}
 
-	// Generate the trace ID here — before routing — so the delegation_decision
+	// Fix the trace ID here — before routing — so the delegation_decision
 	// event and the subsequent gateway_request event share the same trace ID.
 	// proxyToAgentWithTool will reuse the header value rather than generating a new one.
-	traceID := audit.NewTraceID()
-	r.Header.Set("X-Trace-ID", traceID)
+	traceID := r.Header.Get("X-Trace-ID")
+	if traceID == "" {
+		traceID = audit.NewTraceID()
+		r.Header.Set("X-Trace-ID", traceID)
+	}
 
 	var agentName string
 
@@ -556,7 +663,7 @@ func (g *Gateway) handleQuery(w http.ResponseWriter, r *http.Request) {
 		var ok bool
 		agentName, ok = agentAliases[req.Agent]
 		if !ok {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown agent %q (valid: database, db, k8s, sysadmin, host, incident, research)", req.Agent))
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown agent %q (valid: database, db, k8s, sysadmin, host, incident, research, kb)", req.Agent))
 			return
 		}
 	}
@@ -839,6 +946,65 @@ func (g *Gateway) handleK8sTool(w http.ResponseWriter, r *http.Request) {
 	g.dispatchDirectTool(w, r, agentNameK8s, toolName, args)
 }
 
+// handleAgentTool invokes any tool advertised as a skill on a discovered
+// agent's card. The tool must belong to the named agent in the tool registry,
+// and the body is validated against the tool's published input schema before
+// dispatch, so new agents and tools need no gateway changes.
+func (g *Gateway) handleAgentTool(w http.ResponseWriter, r *http.Request) {
+	agentName, shortName, ok := g.resolveToolAgent(r.PathValue("agent"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown agent: "+r.PathValue("agent"))
+		return
+	}
+	toolName := r.PathValue("tool")
+	var entry toolregistry.ToolEntry
+	if g.toolRegistry != nil {
+		if entry, ok = g.toolRegistry.GetForAgent(shortName, toolName); !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("agent %s has no tool %s", shortName, toolName))
+			return
+		}
+	}
+	// The generic route must not open up tools the agent's dedicated route
+	// restricts to narrower roles.
+	if pattern, found := agentToolPatterns[shortName]; found && g.authzr != nil {
+		principal := authz.PrincipalFromContext(r.Context())
+		if err := g.authzr.Authorize(pattern, principal); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, authz.ErrUnauthorized) {
+				status = http.StatusUnauthorized
+			}
+			g.recordAudit(r.Context(), &audit.GatewayRequest{
+				TraceID:           r.Header.Get("X-Trace-ID"),
+				Endpoint:          r.URL.Path,
+				Method:            r.Method,
+				Agent:             agentName,
+				ToolName:          toolName,
+				StartTime:         time.Now(),
+				Status:            "denied",
+				Error:             err.Error(),
+				HTTPCode:          status,
+				Principal:         principal.EffectiveID(),
+				ResolvedPrincipal: principal,
+			})
+			writeError(w, status, err.Error())
+			return
+		}
+	}
+	if !g.checkOperatingMode(w, r, toolName) {
+		return
+	}
+	var args map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if err := entry.ValidateArgs(args); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	g.dispatchDirectTool(w, r, agentName, toolName, args)
+}
+
 func (g *Gateway) handleResearch(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Query string `json:"query"`
@@ -944,12 +1110,15 @@ func (g *Gateway) handleRegisterEphemeralDB(w http.ResponseWriter, r *http.Reque
 		agentBase := strings.TrimSuffix(dbAgent.InvokeURL, "/invoke")
 		agentURL := agentBase + "/admin/register-db"
 		body, _ := json.Marshal(req)
-		resp, err := http.Post(agentURL, "application/json", bytes.NewReader(body)) //nolint:noctx
-		if err != nil {
-			slog.Warn("failed to forward register-db to DB agent", "url", agentURL, "err", err)
-		} else {
-			resp.Body.Close()
-			slog.Info("forwarded register-db to DB agent", "url", agentURL, "status", resp.StatusCode)
+		dbReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, agentURL, bytes.NewReader(body))
+		if err == nil {
+			dbReq.Header.Set("Content-Type", "application/json")
+			if resp, err := http.DefaultClient.Do(dbReq); err != nil {
+				slog.Warn("failed to forward register-db to DB agent", "url", agentURL, "err", err)
+			} else {
+				resp.Body.Close()
+				slog.Info("forwarded register-db to DB agent", "url", agentURL, "status", resp.StatusCode)
+			}
 		}
 	}
 
@@ -971,6 +1140,7 @@ func (g *Gateway) handleRegisterEphemeralDB(w http.ResponseWriter, r *http.Reque
 				if g.agentAPIKey != "" {
 					sysReq.Header.Set("Authorization", "Bearer "+g.agentAPIKey)
 				}
+				audit.SignA2AToolRequest(sysReq, sysBody, g.a2aKeys[agentNameSysadmin])
 				if resp, err := http.DefaultClient.Do(sysReq); err != nil {
 					slog.Warn("failed to forward register_infra_db to sysadmin agent", "url", agentURL, "err", err)
 				} else {
@@ -1007,8 +1177,13 @@ func (g *Gateway) handleGovernance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	targetURL := g.auditURL + "/v1/governance/info"
+	infoReq, err := http.NewRequestWithContext(r.Context(), http.MethodGet, targetURL, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build governance request: "+err.Error())
+		return
+	}
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(targetURL)
+	resp, err := client.Do(infoReq)
 	if err != nil {
 		slog.Error("failed to query governance service", "err", err)
 		writeError(w, http.StatusBadGateway, "governance service unavailable")
@@ -1047,6 +1222,18 @@ func (g *Gateway) handleGovernanceEvents(w http.ResponseWriter, r *http.Request)
 	g.proxyGovernanceRequest(w, r, "/v1/events")
 }
 
+func (g *Gateway) handleGovernanceEventStats(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/stats")
+}
+
+func (g *Gateway) handleGovernanceEventLatency(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/latency")
+}
+
+func (g *Gateway) handleGovernanceEventScorecards(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/scorecards")
+}
+
 func (g *Gateway) handleGovernanceApprovals(w http.ResponseWriter, r *http.Request) {
 	g.proxyGovernanceRequest(w, r, "/v1/approvals")
 }
@@ -1090,6 +1277,10 @@ func (g *Gateway) handleGovernanceExplain(w http.ResponseWriter, r *http.Request
 	if q.Get("role") == "" && len(principal.Roles) > 0 {
 		q.Set("role", principal.Roles[0])
 	}
+	if principal.Tenant != "" {
+		// A tenant-bound caller always sees its own tenant's policy layer.
+		q.Set("tenant", principal.Tenant)
+	}
 	if q.Get("purpose") == "" && purpose != "" {
 		q.Set("purpose", purpose)
 	}
@@ -1109,6 +1300,55 @@ func (g *Gateway) handleGovernanceJourneys(w http.ResponseWriter, r *http.Reques
 	g.proxyGovernanceRequest(w, r, "/v1/journeys")
 }
 
+// handleGovernanceTraceAnnotate attaches an external reference to a trace.
+// auditd records the caller forwarded in X-User as the annotation's author.
+func (g *Gateway) handleGovernanceTraceAnnotate(w http.ResponseWriter, r *http.Request) {
+	g.proxyToAuditd(w, r, "/v1/traces/"+r.PathValue("traceID")+"/annotations")
+}
+
+func (g *Gateway) handleGovernanceTraceAnnotations(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/traces/"+r.PathValue("traceID")+"/annotations")
+}
+
+func (g *Gateway) handleGovernanceEventAnnotate(w http.ResponseWriter, r *http.Request) {
+	g.proxyToAuditd(w, r, "/v1/events/"+r.PathValue("eventID")+"/annotations")
+}
+
+func (g *Gateway) handleGovernanceEventAnnotations(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/"+r.PathValue("eventID")+"/annotations")
+}
+
+// handleGovernanceEventFeedbackSubmit records whether a delegation solved the
+// requester's problem. auditd records the caller forwarded in X-User as the
+// submitter.
+func (g *Gateway) handleGovernanceEventFeedbackSubmit(w http.ResponseWriter, r *http.Request) {
+	g.proxyToAuditd(w, r, "/v1/events/"+r.PathValue("eventID")+"/feedback")
+}
+
+func (g *Gateway) handleGovernanceEventFeedback(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/"+r.PathValue("eventID")+"/feedback")
+}
+
+func (g *Gateway) handleGovernanceFeedbackStats(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/events/feedback/stats")
+}
+
+func (g *Gateway) handleGovernanceAlerts(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/alerts")
+}
+
+func (g *Gateway) handleGovernanceAlertPrecision(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/alerts/precision")
+}
+
+func (g *Gateway) handleGovernanceAlertFalsePositive(w http.ResponseWriter, r *http.Request) {
+	g.proxyToAuditd(w, r, "/v1/alerts/"+r.PathValue("alertID")+"/false-positive")
+}
+
+func (g *Gateway) handleGovernanceMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	g.proxyGovernanceRequest(w, r, "/v1/maintenance-windows")
+}
+
 func (g *Gateway) handleGovernanceGovbotRuns(w http.ResponseWriter, r *http.Request) {
 	g.proxyGovernanceRequest(w, r, "/v1/govbot/runs")
 }
@@ -1251,9 +1491,15 @@ func (g *Gateway) proxyFleetRequest(w http.ResponseWriter, r *http.Request, path
 		return
 	}
 
+	// Confine tenant-bound callers to their own tenant. auditd sees the
+	// gateway's service account, so the scope has to be applied here.
+	q := r.URL.Query()
+	if principal := authz.PrincipalFromContext(r.Context()); principal.Tenant != "" {
+		q.Set("tenant_id", principal.Tenant)
+	}
 	targetURL := g.auditURL + path
-	if q := r.URL.RawQuery; q != "" {
-		targetURL += "?" + q
+	if enc := q.Encode(); enc != "" {
+		targetURL += "?" + enc
 	}
 
 	var bodyReader io.Reader
@@ -1406,6 +1652,10 @@ func (g *Gateway) proxyToAgentWithTool(w http.ResponseWriter, r *http.Request, a
 		return
 	}
 
+	if g.killSwitchBlocks(w, r, agentName, toolName, contextID, resolvedPrincipal) {
+		return
+	}
+
 	// Enforce approval mode from playbook run context (if set).
 	// This only applies to write/destructive tool calls; reads are always allowed.
 	if toolName != "" {
@@ -1415,12 +1665,34 @@ func (g *Gateway) proxyToAgentWithTool(w http.ResponseWriter, r *http.Request, a
 		}
 	}
 
+	release, ok := g.acquireAgentSlot(w, r, agentName, &audit.GatewayRequest{
+		RequestID:         requestID,
+		TraceID:           traceID,
+		Endpoint:          r.URL.Path,
+		Method:            r.Method,
+		ToolName:          toolName,
+		ToolParameters:    toolParams,
+		Message:           prompt,
+		StartTime:         start,
+		Principal:         principalStr,
+		ResolvedPrincipal: resolvedPrincipal,
+		Purpose:           purpose,
+		PurposeNote:       purposeNote,
+	})
+	if !ok {
+		return
+	}
+	defer release()
+
 	slog.Info("gateway: proxying request", "agent", agentName, "prompt_len", len(prompt),
 		"principal", principalStr, "purpose", purpose)
 
 	// Build A2A metadata: trace_id plus the full principal and purpose so that
 	// downstream agents can enforce policy on behalf of the original caller.
 	meta := map[string]any{"trace_id": traceID}
+	if tp := audit.TraceParentFromContext(r.Context()); tp != "" {
+		meta["traceparent"] = tp
+	}
 	if resolvedPrincipal.UserID != "" {
 		meta["user_id"] = resolvedPrincipal.UserID
 	}
@@ -1433,6 +1705,9 @@ func (g *Gateway) proxyToAgentWithTool(w http.ResponseWriter, r *http.Request, a
 	if resolvedPrincipal.AuthMethod != "" {
 		meta["auth_method"] = resolvedPrincipal.AuthMethod
 	}
+	if resolvedPrincipal.Tenant != "" {
+		meta["tenant"] = resolvedPrincipal.Tenant
+	}
 	if purpose != "" {
 		meta["purpose"] = purpose
 	}
@@ -1452,6 +1727,10 @@ func (g *Gateway) proxyToAgentWithTool(w http.ResponseWriter, r *http.Request, a
 	if contextID != "" {
 		msg.ContextID = contextID
 	}
+	if err := audit.SignA2AMessage(msg, g.a2aKeys[agentName]); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("signing A2A request to %s: %v", agentName, err))
+		return
+	}
 	result, err := client.SendMessage(r.Context(), &a2a.MessageSendParams{Message: msg})
 	if err != nil {
 		slog.Error("gateway: A2A call failed", "agent", agentName, "err", err)
@@ -1654,11 +1933,46 @@ func (g *Gateway) recordAudit(ctx context.Context, req *audit.GatewayRequest) {
 	if g.auditor == nil {
 		return
 	}
-	if err := g.auditor.RecordRequest(ctx, req); err != nil {
+	// A request that ran into its deadline is still recorded.
+	if err := g.auditor.RecordRequest(context.WithoutCancel(ctx), req); err != nil {
 		slog.Warn("failed to record audit", "error", err)
 	}
 }
 
+// recordRead records an audited read request: who read which endpoint, with
+// the query string (e.g. the filters of an event query), and the HTTP status.
+func (g *Gateway) recordRead(r *http.Request, pattern string, principal identity.ResolvedPrincipal, traceID string, start time.Time, httpCode int) {
+	status := "success"
+	if httpCode >= 400 {
+		status = "error"
+	}
+	g.recordAudit(r.Context(), &audit.GatewayRequest{
+		TraceID:           traceID,
+		Endpoint:          r.URL.Path,
+		Method:            r.Method,
+		Agent:             g.requestAgent(pattern, r),
+		ActionClass:       audit.ActionRead,
+		Message:           r.URL.RequestURI(),
+		StartTime:         start,
+		Duration:          time.Since(start),
+		Status:            status,
+		HTTPCode:          httpCode,
+		Principal:         principal.EffectiveID(),
+		ResolvedPrincipal: principal,
+	})
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
 // serverNameFromArgs derives a human-readable server identifier from tool args.
 // Checks connection_string (DB tools), context (K8s tools), then namespace,
 // in priority order. Returns "unknown" when none are present.
@@ -1719,6 +2033,7 @@ func (g *Gateway) recordToolResult(ctx context.Context, toolName string, args ma
 // directToolReq is the JSON body sent to POST /tool/{name} on an agent.
 type directToolReq struct {
 	TraceID         string                     `json:"trace_id,omitempty"`
+	TraceParent     string                     `json:"traceparent,omitempty"`
 	Principal       identity.ResolvedPrincipal `json:"principal,omitempty"`
 	Purpose         string                     `json:"purpose,omitempty"`
 	PurposeNote     string                     `json:"purpose_note,omitempty"`
@@ -1728,8 +2043,20 @@ type directToolReq struct {
 
 // directToolResp is the JSON body returned by POST /tool/{name}.
 type directToolResp struct {
-	Output string `json:"output,omitempty"`
-	Error  string `json:"error,omitempty"`
+	Output string          `json:"output,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// directToolJSONResp is returned by the direct-tool endpoints when the client
+// asks for ?format=json: the tool's structured fields next to its text, so
+// bots don't have to parse prose meant for an LLM.
+type directToolJSONResp struct {
+	Agent   string          `json:"agent"`
+	Tool    string          `json:"tool"`
+	TraceID string          `json:"trace_id"`
+	Text    string          `json:"text"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
 // dispatchDirectTool sends a structured tool call directly to the agent's
@@ -1791,6 +2118,26 @@ func (g *Gateway) dispatchDirectTool(w http.ResponseWriter, r *http.Request, age
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("agent %q not available", agentName))
 		return
 	}
+	if g.killSwitchBlocks(w, r, agentName, toolName, "", resolvedPrincipal) {
+		return
+	}
+	release, ok := g.acquireAgentSlot(w, r, agentName, &audit.GatewayRequest{
+		RequestID:         requestID,
+		TraceID:           traceID,
+		Endpoint:          r.URL.Path,
+		Method:            r.Method,
+		ToolName:          toolName,
+		ToolParameters:    args,
+		StartTime:         start,
+		Principal:         principalStr,
+		ResolvedPrincipal: resolvedPrincipal,
+		Purpose:           purpose,
+		PurposeNote:       purposeNote,
+	})
+	if !ok {
+		return
+	}
+	defer release()
 	baseURL := strings.TrimSuffix(agentInfo.InvokeURL, "/invoke")
 
 	slog.Info("gateway: direct tool dispatch", "agent", agentName, "tool", toolName,
@@ -1799,6 +2146,7 @@ func (g *Gateway) dispatchDirectTool(w http.ResponseWriter, r *http.Request, age
 	// Build request body carrying trace context + args.
 	reqBody := directToolReq{
 		TraceID:         traceID,
+		TraceParent:     audit.TraceParentFromContext(r.Context()),
 		Principal:       resolvedPrincipal,
 		Purpose:         purpose,
 		PurposeNote:     purposeNote,
@@ -1822,6 +2170,7 @@ func (g *Gateway) dispatchDirectTool(w http.ResponseWriter, r *http.Request, age
 	if g.agentAPIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+g.agentAPIKey)
 	}
+	audit.SignA2AToolRequest(req, bodyBytes, g.a2aKeys[agentName])
 
 	client := &http.Client{Timeout: 5 * time.Minute}
 	httpResp, err := client.Do(req)
@@ -1945,7 +2294,18 @@ func (g *Gateway) dispatchDirectTool(w http.ResponseWriter, r *http.Request, age
 
 	// Persist the tool result for historical trend queries (best-effort).
 	if g.auditURL != "" {
-		go g.recordToolResult(context.WithoutCancel(r.Context()), toolName, args, text, traceID, principalStr)
+		shutdown.Go(func() { g.recordToolResult(context.WithoutCancel(r.Context()), toolName, args, text, traceID, principalStr) })
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		writeJSON(w, http.StatusOK, directToolJSONResp{
+			Agent:   agentName,
+			Tool:    toolName,
+			TraceID: traceID,
+			Text:    text,
+			Data:    toolResp.Data,
+		})
+		return
 	}
 
 	// Return the same a2aResponse structure as the NL path for client compatibility.
@@ -1960,13 +2320,14 @@ func (g *Gateway) dispatchDirectTool(w http.ResponseWriter, r *http.Request, age
 
 // a2aResponse is the structured response returned by the gateway.
 type a2aResponse struct {
-	AgentName string   `json:"agent"`
-	TaskID    string   `json:"task_id,omitempty"`
-	State     string   `json:"state,omitempty"`
-	Text      string   `json:"text,omitempty"`
-	Artifacts []any    `json:"artifacts,omitempty"`
-	ContextID string   `json:"context_id,omitempty"` // agent session context — echo back to continue the conversation
-	ToolCalls []string `json:"tool_calls,omitempty"` // tool names called by the agent (from tool_call_summary DataPart)
+	AgentName string           `json:"agent"`
+	TaskID    string           `json:"task_id,omitempty"`
+	State     string           `json:"state,omitempty"`
+	Text      string           `json:"text,omitempty"`
+	Artifacts []any            `json:"artifacts,omitempty"`
+	ContextID string           `json:"context_id,omitempty"` // agent session context — echo back to continue the conversation
+	ToolCalls []string         `json:"tool_calls,omitempty"` // tool names called by the agent (from tool_call_summary DataPart)
+	Citations []audit.Citation `json:"citations,omitempty"`  // sources of the answer (from citations DataPart)
 }
 
 // extractResponse pulls text and artifacts from a SendMessageResult.
@@ -2003,7 +2364,8 @@ func extractResponse(result a2a.SendMessageResult) a2aResponse {
 			})
 			for _, part := range a.Parts {
 				if dp, ok := part.(a2a.DataPart); ok {
-					if meta, _ := dp.Metadata["helpdesk_type"].(string); meta == "tool_call_summary" {
+					switch meta, _ := dp.Metadata["helpdesk_type"].(string); meta {
+					case "tool_call_summary":
 						if names, ok := dp.Data["tool_calls"].([]any); ok {
 							for _, n := range names {
 								if s, ok := n.(string); ok {
@@ -2011,6 +2373,12 @@ func extractResponse(result a2a.SendMessageResult) a2aResponse {
 								}
 							}
 						}
+					case "citations":
+						// The part arrives as decoded JSON; re-decode it into citations.
+						var cs []audit.Citation
+						if b, err := json.Marshal(dp.Data["citations"]); err == nil && json.Unmarshal(b, &cs) == nil {
+							resp.Citations = append(resp.Citations, cs...)
+						}
 					}
 				}
 			}
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"context"
@@ -155,6 +155,32 @@ func TestExtractResponse_TaskWithArtifactFallback(t *testing.T) {
 	}
 }
 
+func TestExtractResponse_Citations(t *testing.T) {
+	task := &a2a.Task{
+		ID:     "task-cite",
+		Status: a2a.TaskStatus{State: a2a.TaskStateCompleted},
+		Artifacts: []*a2a.Artifact{{
+			ID: "art-1",
+			Parts: a2a.ContentParts{
+				a2a.TextPart{Text: "CVE-2026-1234 is fixed in Redis 7.2.5."},
+				a2a.DataPart{
+					// As decoded from the agent's JSON response.
+					Data: map[string]any{"citations": []any{map[string]any{
+						"url": "https://nvd.nist.gov/vuln/detail/CVE-2026-1234", "title": "nvd.nist.gov", "retrieved_at": "2026-05-02T03:00:00Z",
+					}}},
+					Metadata: map[string]any{"helpdesk_type": "citations"},
+				},
+			},
+		}},
+	}
+
+	resp := extractResponse(task)
+	if len(resp.Citations) != 1 || resp.Citations[0].URL != "https://nvd.nist.gov/vuln/detail/CVE-2026-1234" ||
+		!resp.Citations[0].RetrievedAt.Equal(time.Date(2026, 5, 2, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("Citations = %+v, want the NVD source retrieved 2026-05-02 03:00 UTC", resp.Citations)
+	}
+}
+
 func TestExtractResponse_Message(t *testing.T) {
 	msg := &a2a.Message{
 		Role:  a2a.MessageRoleAgent,
@@ -936,6 +962,43 @@ func TestDispatchDirectTool_Success(t *testing.T) {
 	}
 }
 
+func TestDispatchDirectTool_FormatJSON(t *testing.T) {
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"output":"Connection successful!","data":{"result_sets":[[{"current_user":"postgres"}]]}}`) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection?format=json",
+		strings.NewReader(`{"connection_string":"postgres://localhost/test"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Tool    string `json:"tool"`
+		TraceID string `json:"trace_id"`
+		Text    string `json:"text"`
+		Data    struct {
+			ResultSets [][]map[string]string `json:"result_sets"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Tool != "check_connection" || resp.TraceID == "" || resp.Text != "Connection successful!" {
+		t.Errorf("envelope = %+v", resp)
+	}
+	if len(resp.Data.ResultSets) != 1 || resp.Data.ResultSets[0][0]["current_user"] != "postgres" {
+		t.Errorf("data = %+v, want the agent's structured result passed through", resp.Data)
+	}
+}
+
 func TestDispatchDirectTool_AgentReturnsError(t *testing.T) {
 	_, agent := mockDirectToolAgent(t, "terminate_idle_connections", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -962,6 +1025,110 @@ func TestDispatchDirectTool_AgentReturnsError(t *testing.T) {
 	}
 }
 
+// agentToolSchema is the published input schema of check_connection used by
+// the generic agent-tool route tests.
+var agentToolSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"connection_string": map[string]any{"type": "string"},
+		"timeout_seconds":   map[string]any{"type": "integer"},
+	},
+	"required": []any{"connection_string"},
+}
+
+func TestHandleAgentTool_Dispatch(t *testing.T) {
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"output": "connected"}) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+	gw.toolRegistry = makeRegistryWithTools([]toolregistry.ToolEntry{
+		{Name: "check_connection", Agent: "database", ActionClass: "read", InputSchema: agentToolSchema},
+	})
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	// Short name, alias and full agent name all resolve to the same agent.
+	for _, name := range []string{"database", "db", agentNameDB} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/"+name+"/tools/check_connection",
+			strings.NewReader(`{"connection_string":"postgres://localhost/test","timeout_seconds":5}`))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200; body: %s", name, rec.Code, rec.Body.String())
+		}
+		var resp a2aResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.AgentName != agentNameDB || resp.Text != "connected" {
+			t.Errorf("%s: agent=%q text=%q", name, resp.AgentName, resp.Text)
+		}
+	}
+}
+
+func TestHandleAgentTool_Rejected(t *testing.T) {
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("agent should not be called for a rejected request")
+	})
+	gw := makeDirectDispatchGateway(agent)
+	gw.toolRegistry = makeRegistryWithTools([]toolregistry.ToolEntry{
+		{Name: "check_connection", Agent: "database", ActionClass: "read", InputSchema: agentToolSchema},
+		{Name: "get_pods", Agent: "k8s", ActionClass: "read"},
+	})
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	cases := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"unknown agent", "/api/v1/agents/billing/tools/check_connection", `{}`, http.StatusNotFound},
+		{"tool of another agent", "/api/v1/agents/database/tools/get_pods", `{}`, http.StatusNotFound},
+		{"missing required", "/api/v1/agents/database/tools/check_connection", `{}`, http.StatusBadRequest},
+		{"wrong type", "/api/v1/agents/database/tools/check_connection",
+			`{"connection_string":"postgres://x","timeout_seconds":"5"}`, http.StatusBadRequest},
+		{"invalid JSON", "/api/v1/agents/database/tools/check_connection", `{`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d; body: %s", tc.name, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func TestHandleAgentTool_AppliesDedicatedRoutePermission(t *testing.T) {
+	// erin may run k8s tools but not database tools; the generic route must
+	// not let them reach the database agent.
+	usersYAML := `
+users:
+  - id: erin@example.com
+    roles: [k8s-admin]
+`
+	gw, _, handler := makeGatewayWithIdentity(t, usersYAML, http.StatusOK)
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("agent should not be called for a forbidden request")
+	})
+	gw.agents[agent.Name] = agent
+	gw.toolRegistry = makeRegistryWithTools([]toolregistry.ToolEntry{
+		{Name: "check_connection", Agent: "database", ActionClass: "read"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/database/tools/check_connection",
+		strings.NewReader(`{"connection_string":"postgres://x"}`))
+	req.Header.Set("X-User", "erin@example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestDispatchDirectTool_AgentReturnsToolError(t *testing.T) {
 	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -1044,6 +1211,35 @@ func TestDispatchDirectTool_TraceIDPropagated(t *testing.T) {
 	}
 }
 
+func TestDispatchDirectTool_Signed(t *testing.T) {
+	key := []byte("db-agent-key")
+	verifier := audit.NewA2AVerifier(key, 0)
+	var verifyErr error
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = verifier.VerifyToolRequest(r, body, time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"output": "ok"}) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+	gw.SetA2ASigningKeys(map[string][]byte{agentNameDB: key})
+
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	if verifyErr != nil {
+		t.Errorf("agent could not verify the direct tool call: %v", verifyErr)
+	}
+}
+
 func TestHandleFleetCreateJob_AnchorEvent(t *testing.T) {
 	cases := []struct {
 		jobID   string
@@ -1217,6 +1413,36 @@ users:
 	}
 }
 
+func TestAuth_ReadAuditing(t *testing.T) {
+	gw, _, handler := makeGatewayWithIdentity(t, "users:\n  - id: alice@example.com\n    roles: [dba]\n", http.StatusOK)
+	ta := &testAuditor{}
+	gw.auditor = audit.NewGatewayAuditor(ta)
+
+	get := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User", "alice@example.com")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	get("/api/v1/governance/approvals/pending")
+	if len(ta.events) != 0 {
+		t.Fatalf("reads audited without a read audit policy: %d events", len(ta.events))
+	}
+
+	// Governance reads are recorded whatever the sample rate; other reads are sampled.
+	gw.auditor.SetReadAuditPolicy(&audit.ReadAuditPolicy{SampleRate: 0})
+	get("/api/v1/governance/approvals/pending?agent=postgres_database_agent")
+	get("/api/v1/agents")
+	if len(ta.events) != 1 {
+		t.Fatalf("got %d events, want 1 (governance read only)", len(ta.events))
+	}
+	e := ta.events[0]
+	if e.Principal == nil || e.Principal.UserID != "alice@example.com" || e.ActionClass != audit.ActionRead ||
+		e.Input.UserQuery != "/api/v1/governance/approvals/pending?agent=postgres_database_agent" {
+		t.Errorf("event = %+v, input %+v", e, e.Input)
+	}
+}
+
 // ── Fleet job submission role checks ─────────────────────────────────────────
 
 // makeGatewayWithIdentity returns a Gateway with a StaticProvider built from
@@ -0,0 +1,444 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// askMaxSteps bounds the number of LLM turns (tool calls plus the final
+// answer) spent on one question.
+const askMaxSteps = 6
+
+// askMaxResults caps the events or approvals one tool call hands the LLM.
+const askMaxResults = 100
+
+// AskRequest is the body of POST /api/v1/governance/ask.
+type AskRequest struct {
+	Question string `json:"question"`
+}
+
+// AskResponse is the answer to a natural-language audit question. EventIDs
+// holds only IDs returned by the queries the model ran, so every cited event
+// can be fetched from GET /api/v1/governance/events/{eventID}.
+type AskResponse struct {
+	Question string     `json:"question"`
+	Answer   string     `json:"answer"`
+	EventIDs []string   `json:"event_ids"`
+	Queries  []AskQuery `json:"queries"`
+}
+
+// AskQuery records one query the model ran against auditd.
+type AskQuery struct {
+	Tool    string            `json:"tool"`
+	Args    map[string]string `json:"args,omitempty"`
+	Results int               `json:"results"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// askTool is a read-only query the model may run. Only the listed arguments
+// are forwarded to auditd; anything else the model sends is dropped.
+type askTool struct {
+	args []string
+	run  func(g *Gateway, ctx context.Context, args map[string]string) (any, int, []string, error)
+}
+
+var askTools = map[string]askTool{
+	"query_events": {
+		args: []string{"event_type", "types", "action_class", "agent", "tool_name", "outcome_status", "origin", "trace_id", "session_id", "since", "until", "resource", "user", "limit"},
+		run:  (*Gateway).askQueryEvents,
+	},
+	"list_approvals": {
+		args: []string{"status", "agent", "requested_by", "tool_name", "trace_id", "since", "limit"},
+		run:  (*Gateway).askListApprovals,
+	},
+	"event_stats": {
+		args: []string{"since", "until"},
+		run:  (*Gateway).askEventStats,
+	},
+}
+
+const askPromptTemplate = `You answer compliance questions about the helpdesk audit trail. You cannot
+read the database directly; you can only call these read-only tools, one at a time.
+
+Tools (all arguments are strings; timestamps are RFC3339):
+
+query_events — audit events, newest first.
+  event_type (tool_execution, policy_decision, gateway_request, delegation_decision, ...),
+  types (comma-separated event types), action_class (read, write, destructive),
+  agent, tool_name, outcome_status (success, error, denied, ...), origin, trace_id,
+  session_id, since, until, resource (substring of the target resource, e.g. a
+  database or namespace name), user (user ID), limit (max %d).
+
+list_approvals — approval requests and who resolved them.
+  status (pending, approved, denied, expired, cancelled), agent, requested_by,
+  tool_name, trace_id (links an approval to the events of the same request), since, limit.
+
+event_stats — counts by event type, policy effect and action class.
+  since, until.
+
+The current time is %s.
+
+Question: %s
+
+%s
+Respond with JSON only, no markdown fences, in exactly one of these forms:
+{"tool": "<tool name>", "args": {"<name>": "<value>", ...}}
+{"answer": "<answer in plain prose>", "event_ids": ["<event_id>", ...]}
+
+Answer only from tool results; cite in event_ids the events your answer is
+based on. If the results do not answer the question, say so.`
+
+// handleGovernanceAsk handles POST /api/v1/governance/ask. The planner LLM
+// answers the question by calling a fixed set of read-only auditd queries —
+// never SQL — and returns its answer with the event IDs it is based on.
+func (g *Gateway) handleGovernanceAsk(w http.ResponseWriter, r *http.Request) {
+	var req AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		writeError(w, http.StatusBadRequest, "question is required")
+		return
+	}
+	if g.plannerLLM == nil {
+		writeError(w, http.StatusServiceUnavailable, "LLM not configured (HELPDESK_MODEL_VENDOR, HELPDESK_MODEL_NAME, HELPDESK_API_KEY)")
+		return
+	}
+	if g.auditURL == "" {
+		writeError(w, http.StatusServiceUnavailable, "auditd not configured (HELPDESK_AUDIT_URL)")
+		return
+	}
+
+	resp, err := g.askAudit(r.Context(), req.Question)
+	if err != nil {
+		slog.Error("governance ask failed", "err", err)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// askAudit runs the tool loop: each turn the model either calls a tool, whose
+// result is appended to the transcript, or answers.
+func (g *Gateway) askAudit(ctx context.Context, question string) (*AskResponse, error) {
+	resp := &AskResponse{Question: question, EventIDs: []string{}, Queries: []AskQuery{}}
+	seen := map[string]bool{}
+	var transcript strings.Builder
+
+	for step := 0; step < askMaxSteps; step++ {
+		history := ""
+		if transcript.Len() > 0 {
+			history = "Tool calls so far:\n" + transcript.String()
+		}
+		prompt := fmt.Sprintf(askPromptTemplate, askMaxResults, time.Now().UTC().Format(time.RFC3339), question, history)
+		raw, err := g.plannerLLM(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("LLM call failed: %w", err)
+		}
+
+		var turn struct {
+			Tool     string         `json:"tool"`
+			Args     map[string]any `json:"args"`
+			Answer   string         `json:"answer"`
+			EventIDs []string       `json:"event_ids"`
+		}
+		if err := json.Unmarshal([]byte(stripMarkdownFences(raw)), &turn); err != nil {
+			return nil, fmt.Errorf("LLM returned unparseable JSON: %w", err)
+		}
+
+		if turn.Tool == "" {
+			resp.Answer = turn.Answer
+			for _, id := range turn.EventIDs {
+				if seen[id] {
+					resp.EventIDs = append(resp.EventIDs, id)
+				} else {
+					slog.Warn("governance ask: dropping event ID not returned by any query", "event_id", id)
+				}
+			}
+			return resp, nil
+		}
+
+		query := AskQuery{Tool: turn.Tool}
+		var result any
+		tool, ok := askTools[turn.Tool]
+		if !ok {
+			query.Error = "unknown tool"
+		} else {
+			query.Args = askToolArgs(tool, turn.Args)
+			var ids []string
+			result, query.Results, ids, err = tool.run(g, ctx, query.Args)
+			if err != nil {
+				query.Error = err.Error()
+			}
+			for _, id := range ids {
+				seen[id] = true
+			}
+		}
+		resp.Queries = append(resp.Queries, query)
+
+		argsJSON, _ := json.Marshal(query.Args)
+		fmt.Fprintf(&transcript, "\n%s %s →\n", query.Tool, argsJSON)
+		if query.Error != "" {
+			fmt.Fprintf(&transcript, "error: %s\n", query.Error)
+		} else {
+			resultJSON, _ := json.Marshal(result)
+			transcript.Write(resultJSON)
+			transcript.WriteByte('\n')
+		}
+	}
+	return nil, fmt.Errorf("no answer after %d steps", askMaxSteps)
+}
+
+// askToolArgs keeps the arguments the tool accepts, as strings.
+func askToolArgs(tool askTool, in map[string]any) map[string]string {
+	out := map[string]string{}
+	for _, name := range tool.args {
+		v, ok := in[name]
+		if !ok || v == nil {
+			continue
+		}
+		s := strings.TrimSpace(fmt.Sprint(v))
+		if s != "" {
+			out[name] = s
+		}
+	}
+	return out
+}
+
+// askLimit clamps the model's limit argument to (0, askMaxResults].
+func askLimit(args map[string]string) int {
+	n, err := strconv.Atoi(args["limit"])
+	if err != nil || n <= 0 || n > askMaxResults {
+		return askMaxResults
+	}
+	return n
+}
+
+// askGet fetches path from auditd with the given query and decodes the JSON
+// response into dst.
+func (g *Gateway) askGet(ctx context.Context, path string, q url.Values, dst any) error {
+	target := strings.TrimSuffix(g.auditURL, "/") + path
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if g.auditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.auditAPIKey)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auditd request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("reading auditd response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auditd returned %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, dst)
+}
+
+// askEvent is the compact view of an event handed to the model.
+type askEvent struct {
+	EventID     string         `json:"event_id"`
+	Timestamp   string         `json:"timestamp"`
+	EventType   string         `json:"event_type"`
+	ActionClass string         `json:"action_class,omitempty"`
+	TraceID     string         `json:"trace_id,omitempty"`
+	User        string         `json:"user,omitempty"`
+	Agent       string         `json:"agent,omitempty"`
+	Tool        string         `json:"tool,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+	Command     string         `json:"command,omitempty"`
+	Outcome     string         `json:"outcome,omitempty"`
+	Resource    string         `json:"resource,omitempty"`
+	Effect      string         `json:"effect,omitempty"`
+	Policy      string         `json:"policy,omitempty"`
+	Approval    string         `json:"approval,omitempty"`
+	ApprovedBy  string         `json:"approved_by,omitempty"`
+}
+
+func summarizeAskEvent(e audit.Event) askEvent {
+	s := askEvent{
+		EventID:     e.EventID,
+		Timestamp:   e.Timestamp.UTC().Format(time.RFC3339),
+		EventType:   string(e.EventType),
+		ActionClass: string(e.ActionClass),
+		TraceID:     e.TraceID,
+		User:        e.Session.UserID,
+		Agent:       e.Session.AgentName,
+	}
+	if e.Tool != nil {
+		s.Tool, s.Parameters, s.Command = e.Tool.Name, e.Tool.Parameters, e.Tool.RawCommand
+		if e.Tool.Agent != "" {
+			s.Agent = e.Tool.Agent
+		}
+	}
+	if e.Outcome != nil {
+		s.Outcome = e.Outcome.Status
+	}
+	if pd := e.PolicyDecision; pd != nil {
+		s.Resource, s.Effect, s.Policy = pd.ResourceType+"/"+pd.ResourceName, pd.Effect, pd.PolicyName
+	}
+	if e.Approval != nil {
+		s.Approval, s.ApprovedBy = string(e.Approval.Status), e.Approval.ApprovedBy
+	}
+	return s
+}
+
+// matchesResource reports whether the event targets a resource whose name
+// contains want: the policy decision's resource, or a tool parameter or
+// command that mentions it.
+func (s askEvent) matchesResource(want string) bool {
+	want = strings.ToLower(want)
+	if strings.Contains(strings.ToLower(s.Resource), want) || strings.Contains(strings.ToLower(s.Command), want) {
+		return true
+	}
+	for _, v := range s.Parameters {
+		if strings.Contains(strings.ToLower(fmt.Sprint(v)), want) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Gateway) askQueryEvents(ctx context.Context, args map[string]string) (any, int, []string, error) {
+	q := url.Values{}
+	for _, name := range []string{"event_type", "types", "action_class", "agent", "tool_name", "outcome_status", "origin", "trace_id", "session_id", "since"} {
+		if v := args[name]; v != "" {
+			q.Set(name, v)
+		}
+	}
+	limit := askLimit(args)
+	// until, resource and user are applied here, so fetch a wider page.
+	fetch := limit
+	if args["until"] != "" || args["resource"] != "" || args["user"] != "" {
+		fetch = 10 * askMaxResults
+	}
+	q.Set("limit", strconv.Itoa(fetch))
+
+	var events []audit.Event
+	if err := g.askGet(ctx, "/v1/events", q, &events); err != nil {
+		return nil, 0, nil, err
+	}
+	var until time.Time
+	if v := args["until"]; v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("until must be an RFC3339 timestamp")
+		}
+		until = t
+	}
+
+	out := []askEvent{}
+	var ids []string
+	for _, e := range events {
+		if !until.IsZero() && !e.Timestamp.Before(until) {
+			continue
+		}
+		if u := args["user"]; u != "" && e.Session.UserID != u {
+			continue
+		}
+		s := summarizeAskEvent(e)
+		if res := args["resource"]; res != "" && !s.matchesResource(res) {
+			continue
+		}
+		out = append(out, s)
+		ids = append(ids, e.EventID)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, len(out), ids, nil
+}
+
+func (g *Gateway) askListApprovals(ctx context.Context, args map[string]string) (any, int, []string, error) {
+	q := url.Values{}
+	for _, name := range []string{"status", "agent", "requested_by", "tool_name", "trace_id", "since"} {
+		if v := args[name]; v != "" {
+			q.Set(name, v)
+		}
+	}
+	q.Set("limit", strconv.Itoa(askLimit(args)))
+
+	var approvals []audit.StoredApproval
+	if err := g.askGet(ctx, "/v1/approvals", q, &approvals); err != nil {
+		return nil, 0, nil, err
+	}
+	type askApproval struct {
+		ApprovalID  string `json:"approval_id"`
+		EventID     string `json:"event_id,omitempty"`
+		TraceID     string `json:"trace_id,omitempty"`
+		Status      string `json:"status"`
+		ActionClass string `json:"action_class,omitempty"`
+		Tool        string `json:"tool,omitempty"`
+		Resource    string `json:"resource,omitempty"`
+		RequestedBy string `json:"requested_by"`
+		RequestedAt string `json:"requested_at"`
+		ResolvedBy  string `json:"resolved_by,omitempty"`
+		ResolvedAt  string `json:"resolved_at,omitempty"`
+		Reason      string `json:"reason,omitempty"`
+	}
+	out := make([]askApproval, 0, len(approvals))
+	var ids []string
+	for _, a := range approvals {
+		s := askApproval{
+			ApprovalID: a.ApprovalID, EventID: a.EventID, TraceID: a.TraceID, Status: a.Status,
+			ActionClass: a.ActionClass, Tool: a.ToolName, RequestedBy: a.RequestedBy,
+			RequestedAt: a.RequestedAt.UTC().Format(time.RFC3339),
+			ResolvedBy:  a.ResolvedBy, Reason: a.ResolutionReason,
+		}
+		if a.ResourceName != "" {
+			s.Resource = a.ResourceType + "/" + a.ResourceName
+		}
+		if !a.ResolvedAt.IsZero() {
+			s.ResolvedAt = a.ResolvedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, s)
+		if a.EventID != "" {
+			ids = append(ids, a.EventID)
+		}
+	}
+	return out, len(out), ids, nil
+}
+
+func (g *Gateway) askEventStats(ctx context.Context, args map[string]string) (any, int, []string, error) {
+	q := url.Values{}
+	for _, name := range []string{"since", "until"} {
+		if v := args[name]; v != "" {
+			q.Set(name, v)
+		}
+	}
+	var stats audit.EventStats
+	if err := g.askGet(ctx, "/v1/events/stats", q, &stats); err != nil {
+		return nil, 0, nil, err
+	}
+	// Keep the prompt small: the ten busiest resources are enough context.
+	sort.SliceStable(stats.ByResource, func(i, j int) bool {
+		a, b := stats.ByResource[i], stats.ByResource[j]
+		return a.Allow+a.Deny+a.RequireApproval > b.Allow+b.Deny+b.RequireApproval
+	})
+	if len(stats.ByResource) > 10 {
+		stats.ByResource = stats.ByResource[:10]
+	}
+	return stats, stats.TotalEvents, nil, nil
+}
@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// scriptedLLM returns the given replies in order and records every prompt.
+func scriptedLLM(replies ...string) (func(ctx context.Context, prompt string) (string, error), *[]string) {
+	var prompts []string
+	return func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		reply := replies[0]
+		if len(replies) > 1 {
+			replies = replies[1:]
+		}
+		return reply, nil
+	}, &prompts
+}
+
+func doAskRequest(t *testing.T, g *Gateway, question string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(AskRequest{Question: question})
+	w := httptest.NewRecorder()
+	g.handleGovernanceAsk(w, httptest.NewRequest(http.MethodPost, "/api/v1/governance/ask", strings.NewReader(string(body))))
+	return w
+}
+
+func TestHandleGovernanceAsk_Validation(t *testing.T) {
+	llm, _ := scriptedLLM(`{"answer": "x"}`)
+	if w := doAskRequest(t, &Gateway{plannerLLM: llm, auditURL: "http://auditd"}, " "); w.Code != http.StatusBadRequest {
+		t.Errorf("empty question: status = %d, want 400", w.Code)
+	}
+	if w := doAskRequest(t, &Gateway{auditURL: "http://auditd"}, "q"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no LLM: status = %d, want 503", w.Code)
+	}
+	if w := doAskRequest(t, &Gateway{plannerLLM: llm}, "q"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no auditd: status = %d, want 503", w.Code)
+	}
+}
+
+func TestHandleGovernanceAsk_ToolLoop(t *testing.T) {
+	ts := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	var eventQueries []string
+	auditd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer audit-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/events":
+			eventQueries = append(eventQueries, r.URL.RawQuery)
+			json.NewEncoder(w).Encode([]audit.Event{
+				{EventID: "tool_prod", Timestamp: ts, EventType: audit.EventTypeToolExecution, ActionClass: audit.ActionDestructive, TraceID: "tr_1",
+					Session: audit.Session{UserID: "alice"}, Tool: &audit.ToolExecution{Name: "terminate_connection", Parameters: map[string]any{"database": "prod-db"}}},
+				{EventID: "tool_staging", Timestamp: ts, EventType: audit.EventTypeToolExecution, ActionClass: audit.ActionDestructive, TraceID: "tr_2",
+					Tool: &audit.ToolExecution{Name: "terminate_connection", Parameters: map[string]any{"database": "staging-db"}}},
+			})
+		case "/v1/approvals":
+			if r.URL.Query().Get("trace_id") != "tr_1" {
+				t.Errorf("approvals query = %q, want trace_id=tr_1", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode([]audit.StoredApproval{
+				{ApprovalID: "apr_1", TraceID: "tr_1", Status: "approved", RequestedBy: "alice", ResolvedBy: "bob", RequestedAt: ts},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer auditd.Close()
+
+	llm, prompts := scriptedLLM(
+		`{"tool": "query_events", "args": {"action_class": "destructive", "resource": "prod-db", "since": "2026-05-01T00:00:00Z", "sql": "DROP TABLE audit_events"}}`,
+		"```json\n"+`{"tool": "list_approvals", "args": {"trace_id": "tr_1"}}`+"\n```",
+		`{"answer": "alice terminated a connection on prod-db; bob approved it.", "event_ids": ["tool_prod", "evt_made_up"]}`,
+	)
+	g := &Gateway{plannerLLM: llm, auditURL: auditd.URL, auditAPIKey: "audit-key"}
+
+	w := doAskRequest(t, g, "show me every destructive action on prod-db last week and who approved it")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp AskResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.Contains(resp.Answer, "bob approved") {
+		t.Errorf("answer = %q", resp.Answer)
+	}
+	// Event IDs not returned by any query are dropped.
+	if len(resp.EventIDs) != 1 || resp.EventIDs[0] != "tool_prod" {
+		t.Errorf("event_ids = %v, want [tool_prod]", resp.EventIDs)
+	}
+	if len(resp.Queries) != 2 || resp.Queries[0].Results != 1 || resp.Queries[1].Tool != "list_approvals" {
+		t.Errorf("queries = %+v", resp.Queries)
+	}
+	// Arguments the tool does not accept never reach auditd; gateway-side
+	// filters are not forwarded either.
+	if _, ok := resp.Queries[0].Args["sql"]; ok {
+		t.Errorf("sql argument was kept: %v", resp.Queries[0].Args)
+	}
+	if len(eventQueries) != 1 || strings.Contains(eventQueries[0], "resource") || !strings.Contains(eventQueries[0], "action_class=destructive") {
+		t.Errorf("auditd event queries = %v", eventQueries)
+	}
+	// Tool results are fed back to the model.
+	if len(*prompts) != 3 || !strings.Contains((*prompts)[2], `"approval_id":"apr_1"`) || strings.Contains((*prompts)[1], "staging-db") {
+		t.Errorf("prompts did not carry the expected tool results")
+	}
+}
+
+func TestHandleGovernanceAsk_GivesUpAfterMaxSteps(t *testing.T) {
+	auditd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(audit.EventStats{})
+	}))
+	defer auditd.Close()
+	llm, prompts := scriptedLLM(`{"tool": "event_stats", "args": {}}`)
+	g := &Gateway{plannerLLM: llm, auditURL: auditd.URL}
+
+	if w := doAskRequest(t, g, "how many events?"); w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", w.Code)
+	}
+	if len(*prompts) != askMaxSteps {
+		t.Errorf("LLM called %d times, want %d", len(*prompts), askMaxSteps)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"context"
@@ -176,6 +176,12 @@ func (g *Gateway) handleGetIncident(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Annotations let the reviewer jump to the PagerDuty incident, Jira
+	// ticket or Slack thread attached to each trace.
+	for i := range narrative.Journeys {
+		narrative.Journeys[i].Annotations = g.fetchTraceAnnotations(r.Context(), narrative.Journeys[i].TraceID)
+	}
+
 	// 4. Feedback — all operator feedback slots for this incident.
 	narrative.Feedback = g.fetchAllRunFeedback(r.Context(), runID)
 
@@ -269,6 +275,33 @@ func (g *Gateway) fetchAllRunFeedback(ctx context.Context, runID string) []audit
 	return envelope.Feedback
 }
 
+// fetchTraceAnnotations fetches the external references attached to a trace.
+func (g *Gateway) fetchTraceAnnotations(ctx context.Context, traceID string) []audit.TraceAnnotation {
+	if g.auditURL == "" {
+		return nil
+	}
+	url := strings.TrimSuffix(g.auditURL, "/") + "/v1/traces/" + traceID + "/annotations"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil
+	}
+	if g.auditAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.auditAPIKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	defer resp.Body.Close()
+	var envelope struct {
+		Annotations []audit.TraceAnnotation `json:"annotations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil
+	}
+	return envelope.Annotations
+}
+
 // fetchRunEvaluation fetches automated eval scores for a run. Returns nil when none recorded.
 func (g *Gateway) fetchRunEvaluation(ctx context.Context, runID string) *audit.RunEvaluation {
 	if g.auditURL == "" {
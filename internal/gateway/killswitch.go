@@ -0,0 +1,306 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/identity"
+)
+
+// killSwitch holds containment state applied to every delegation the gateway
+// proxies: paused sessions (by A2A context ID or by principal) and revoked
+// agents. State is in-memory; a gateway restart clears it, so containment
+// tooling (e.g. secbot) should treat it as a fast stop, not a permanent ban.
+type killSwitch struct {
+	mu       sync.RWMutex
+	sessions map[string]pausedSession // key: session ID or user ID
+	agents   map[string]revokedAgent  // key: internal agent name
+}
+
+// pausedSession is one kill-switch entry blocking further delegations.
+type pausedSession struct {
+	SessionID string    `json:"session_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Reason    string    `json:"reason"`
+	PausedBy  string    `json:"paused_by"`
+	PausedAt  time.Time `json:"paused_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // zero = until resumed
+}
+
+// revokedAgent is a kill-switch entry removing an agent from service.
+type revokedAgent struct {
+	Agent     string    `json:"agent"`
+	Reason    string    `json:"reason"`
+	RevokedBy string    `json:"revoked_by"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+func newKillSwitch() *killSwitch {
+	return &killSwitch{
+		sessions: make(map[string]pausedSession),
+		agents:   make(map[string]revokedAgent),
+	}
+}
+
+func sessionKey(sessionID string) string { return "session:" + sessionID }
+func userKey(userID string) string       { return "user:" + userID }
+
+// check returns a non-empty reason when a delegation to agentName from the
+// given session/principal must be blocked.
+func (k *killSwitch) check(agentName, contextID, userID string) string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if ra, ok := k.agents[agentName]; ok {
+		return fmt.Sprintf("agent %s revoked by %s: %s", agentName, ra.RevokedBy, ra.Reason)
+	}
+	now := time.Now()
+	for _, key := range []string{sessionKey(contextID), userKey(userID)} {
+		if key == sessionKey("") || key == userKey("") {
+			continue
+		}
+		ps, ok := k.sessions[key]
+		if !ok || (!ps.ExpiresAt.IsZero() && now.After(ps.ExpiresAt)) {
+			continue
+		}
+		return fmt.Sprintf("delegations paused by %s: %s", ps.PausedBy, ps.Reason)
+	}
+	return ""
+}
+
+// killSwitchBlocks reports whether the kill-switch blocks this delegation.
+// When it does, the denial is audited and a 403 written; callers must return.
+func (g *Gateway) killSwitchBlocks(w http.ResponseWriter, r *http.Request, agentName, toolName, contextID string, principal identity.ResolvedPrincipal) bool {
+	if g.killSwitch == nil {
+		return false
+	}
+	reason := g.killSwitch.check(agentName, contextID, principal.EffectiveID())
+	if reason == "" {
+		return false
+	}
+	slog.Warn("gateway: kill-switch blocked delegation",
+		"agent", agentName, "context_id", contextID, "principal", principal.EffectiveID(), "reason", reason)
+	g.recordAudit(r.Context(), &audit.GatewayRequest{
+		TraceID:           r.Header.Get("X-Trace-ID"),
+		ContextID:         contextID,
+		Endpoint:          r.URL.Path,
+		Method:            r.Method,
+		Agent:             agentName,
+		ToolName:          toolName,
+		StartTime:         time.Now(),
+		Status:            "denied",
+		Error:             "kill-switch: " + reason,
+		HTTPCode:          http.StatusForbidden,
+		Principal:         principal.EffectiveID(),
+		ResolvedPrincipal: principal,
+	})
+	writeError(w, http.StatusForbidden, "kill-switch: "+reason)
+	return true
+}
+
+// resolveAgentName maps a short alias or full agent name to the internal name.
+func resolveAgentName(name string) string {
+	if full, ok := agentAliases[name]; ok {
+		return full
+	}
+	return name
+}
+
+// recordKillSwitchAction audits a kill-switch state change as a gateway_request
+// event carrying the acting principal.
+func (g *Gateway) recordKillSwitchAction(r *http.Request, agent, message string) {
+	principal, purpose, purposeNote, _, _ := g.resolveRequest(r, "", "")
+	traceID := r.Header.Get("X-Trace-ID")
+	if traceID == "" {
+		traceID = audit.NewTraceIDWithPrefix("ks_")
+	}
+	g.recordAudit(r.Context(), &audit.GatewayRequest{
+		TraceID:           traceID,
+		Endpoint:          r.URL.Path,
+		Method:            r.Method,
+		Agent:             agent,
+		ActionClass:       audit.ActionWrite,
+		Message:           message,
+		StartTime:         time.Now(),
+		Status:            "success",
+		HTTPCode:          http.StatusOK,
+		Principal:         principal.EffectiveID(),
+		ResolvedPrincipal: principal,
+		Purpose:           purpose,
+		PurposeNote:       purposeNote,
+	})
+}
+
+// handleKillSwitchStatus handles GET /api/v1/admin/killswitch.
+func (g *Gateway) handleKillSwitchStatus(w http.ResponseWriter, r *http.Request) {
+	sessions := []pausedSession{}
+	agents := []revokedAgent{}
+	if g.killSwitch != nil {
+		g.killSwitch.mu.RLock()
+		now := time.Now()
+		for _, ps := range g.killSwitch.sessions {
+			if ps.ExpiresAt.IsZero() || now.Before(ps.ExpiresAt) {
+				sessions = append(sessions, ps)
+			}
+		}
+		for _, ra := range g.killSwitch.agents {
+			agents = append(agents, ra)
+		}
+		g.killSwitch.mu.RUnlock()
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].PausedAt.Before(sessions[j].PausedAt) })
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Agent < agents[j].Agent })
+	writeJSON(w, http.StatusOK, map[string]any{
+		"paused_sessions": sessions,
+		"revoked_agents":  agents,
+	})
+}
+
+// handlePauseSession handles POST /api/v1/admin/killswitch/sessions.
+// At least one of session_id (A2A context ID) or user_id is required.
+func (g *Gateway) handlePauseSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID  string `json:"session_id"`
+		UserID     string `json:"user_id"`
+		Reason     string `json:"reason"`
+		TTLMinutes int    `json:"ttl_minutes"` // 0 = until resumed
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.SessionID == "" && req.UserID == "" {
+		writeError(w, http.StatusBadRequest, "session_id or user_id is required")
+		return
+	}
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	principal, _, _, _, _ := g.resolveRequest(r, "", "")
+	ps := pausedSession{
+		SessionID: req.SessionID,
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		PausedBy:  principal.EffectiveID(),
+		PausedAt:  time.Now().UTC(),
+	}
+	if req.TTLMinutes > 0 {
+		ps.ExpiresAt = ps.PausedAt.Add(time.Duration(req.TTLMinutes) * time.Minute)
+	}
+
+	g.killSwitch.mu.Lock()
+	if req.SessionID != "" {
+		g.killSwitch.sessions[sessionKey(req.SessionID)] = ps
+	}
+	if req.UserID != "" {
+		g.killSwitch.sessions[userKey(req.UserID)] = ps
+	}
+	g.killSwitch.mu.Unlock()
+
+	slog.Warn("gateway: kill-switch session paused",
+		"session_id", req.SessionID, "user_id", req.UserID, "by", ps.PausedBy, "reason", req.Reason)
+	g.recordKillSwitchAction(r, "gateway", fmt.Sprintf("kill-switch pause session=%q user=%q: %s", req.SessionID, req.UserID, req.Reason))
+	writeJSON(w, http.StatusCreated, ps)
+}
+
+// handleResumeSession handles DELETE /api/v1/admin/killswitch/sessions/{id}.
+// id matches either a paused session ID or a paused user ID.
+func (g *Gateway) handleResumeSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	g.killSwitch.mu.Lock()
+	_, hadSession := g.killSwitch.sessions[sessionKey(id)]
+	_, hadUser := g.killSwitch.sessions[userKey(id)]
+	delete(g.killSwitch.sessions, sessionKey(id))
+	delete(g.killSwitch.sessions, userKey(id))
+	g.killSwitch.mu.Unlock()
+
+	if !hadSession && !hadUser {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no paused session or user %q", id))
+		return
+	}
+	slog.Info("gateway: kill-switch session resumed", "id", id)
+	g.recordKillSwitchAction(r, "gateway", fmt.Sprintf("kill-switch resume %q", id))
+	writeJSON(w, http.StatusOK, map[string]string{"status": "resumed", "id": id})
+}
+
+// handleRevokeAgent handles POST /api/v1/admin/killswitch/agents/{agent}/revoke.
+func (g *Gateway) handleRevokeAgent(w http.ResponseWriter, r *http.Request) {
+	agent := resolveAgentName(r.PathValue("agent"))
+	if _, ok := g.agents[agent]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown agent %q", r.PathValue("agent")))
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	principal, _, _, _, _ := g.resolveRequest(r, "", "")
+	ra := revokedAgent{
+		Agent:     agent,
+		Reason:    req.Reason,
+		RevokedBy: principal.EffectiveID(),
+		RevokedAt: time.Now().UTC(),
+	}
+	g.killSwitch.mu.Lock()
+	g.killSwitch.agents[agent] = ra
+	g.killSwitch.mu.Unlock()
+
+	slog.Warn("gateway: kill-switch agent revoked", "agent", agent, "by", ra.RevokedBy, "reason", req.Reason)
+	g.recordKillSwitchAction(r, agent, "kill-switch revoke agent: "+req.Reason)
+	writeJSON(w, http.StatusCreated, ra)
+}
+
+// handleRestoreAgent handles DELETE /api/v1/admin/killswitch/agents/{agent}.
+func (g *Gateway) handleRestoreAgent(w http.ResponseWriter, r *http.Request) {
+	agent := resolveAgentName(r.PathValue("agent"))
+	g.killSwitch.mu.Lock()
+	_, ok := g.killSwitch.agents[agent]
+	delete(g.killSwitch.agents, agent)
+	g.killSwitch.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("agent %q is not revoked", agent))
+		return
+	}
+	slog.Info("gateway: kill-switch agent restored", "agent", agent)
+	g.recordKillSwitchAction(r, agent, "kill-switch restore agent")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "restored", "agent": agent})
+}
+
+// handleFreeze proxies GET/POST/DELETE /api/v1/admin/freeze to auditd, which
+// owns the fleet-wide emergency read-only switch and audits every change.
+// The resolved caller is forwarded as X-User so auditd attributes the change
+// to the human rather than the gateway's service account.
+func (g *Gateway) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	if principal, _, _, _, err := g.resolveRequest(r, "", ""); err == nil && principal.EffectiveID() != "" {
+		r.Header.Set("X-User", principal.EffectiveID())
+	}
+	path := "/v1/freeze"
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+	g.proxyToAuditd(w, r, path)
+}
+
+// handleRuntimeConfig proxies GET/PUT /api/v1/admin/config to auditd, which
+// owns the runtime configuration and audits every change. As with the
+// freeze, the resolved caller is forwarded as X-User.
+func (g *Gateway) handleRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	if principal, _, _, _, err := g.resolveRequest(r, "", ""); err == nil && principal.EffectiveID() != "" {
+		r.Header.Set("X-User", principal.EffectiveID())
+	}
+	g.proxyToAuditd(w, r, "/v1/config")
+}
@@ -0,0 +1,168 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/discovery"
+)
+
+func TestKillSwitch_Check(t *testing.T) {
+	k := newKillSwitch()
+	k.sessions[sessionKey("ctx-1")] = pausedSession{SessionID: "ctx-1", Reason: "r", PausedBy: "secbot"}
+	k.sessions[userKey("mallory")] = pausedSession{UserID: "mallory", Reason: "r", PausedBy: "secbot"}
+	k.sessions[sessionKey("ctx-old")] = pausedSession{SessionID: "ctx-old", ExpiresAt: time.Now().Add(-time.Minute)}
+	k.agents[agentNameK8s] = revokedAgent{Agent: agentNameK8s, Reason: "compromised", RevokedBy: "secbot"}
+
+	tests := []struct {
+		name                   string
+		agent, context, userID string
+		blocked                bool
+	}{
+		{"paused session", agentNameDB, "ctx-1", "alice", true},
+		{"paused user", agentNameDB, "", "mallory", true},
+		{"revoked agent", agentNameK8s, "", "alice", true},
+		{"expired pause", agentNameDB, "ctx-old", "alice", false},
+		{"unrelated", agentNameDB, "ctx-2", "alice", false},
+		{"anonymous no context", agentNameDB, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := k.check(tt.agent, tt.context, tt.userID) != ""
+			if got != tt.blocked {
+				t.Errorf("check(%q, %q, %q) blocked = %v, want %v", tt.agent, tt.context, tt.userID, got, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestKillSwitch_PauseBlocksDirectTool(t *testing.T) {
+	called := false
+	_, agent := mockDirectToolAgent(t, "check_connection", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		json.NewEncoder(w).Encode(map[string]string{"output": "ok"}) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	// Revoke the database agent via its alias.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/killswitch/agents/db/revoke",
+		strings.NewReader(`{"reason":"unauthorized destructive op"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("revoke status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection",
+		strings.NewReader(`{"connection_string":"postgres://localhost/test"}`))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "kill-switch") {
+		t.Fatalf("status = %d body = %s, want 403 kill-switch", rec.Code, rec.Body.String())
+	}
+	if called {
+		t.Error("agent was called despite revocation")
+	}
+
+	// Restore and retry.
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/killswitch/agents/"+agentNameDB, nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection",
+		strings.NewReader(`{"connection_string":"postgres://localhost/test"}`))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !called {
+		t.Errorf("after restore: status = %d called = %v", rec.Code, called)
+	}
+}
+
+func TestKillSwitch_PauseAndResumeSession(t *testing.T) {
+	gw := makeDirectDispatchGateway(&discovery.Agent{Name: agentNameDB, InvokeURL: "http://127.0.0.1:1/invoke"})
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	for _, body := range []string{`{}`, `{"session_id":"ctx-1"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/killswitch/sessions", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/killswitch/sessions",
+		strings.NewReader(`{"session_id":"ctx-1","user_id":"mallory","reason":"chain tampering","ttl_minutes":30}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("pause status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/killswitch", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var status struct {
+		PausedSessions []pausedSession `json:"paused_sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if len(status.PausedSessions) != 2 {
+		t.Errorf("paused entries = %d, want 2 (session + user)", len(status.PausedSessions))
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/killswitch/sessions/mallory", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resume status = %d", rec.Code)
+	}
+	if gw.killSwitch.check(agentNameDB, "", "mallory") != "" {
+		t.Error("user still paused after resume")
+	}
+	if gw.killSwitch.check(agentNameDB, "ctx-1", "") == "" {
+		t.Error("session pause should remain after resuming the user")
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/killswitch/sessions/nobody", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("resume unknown: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestFreeze_ProxiesToAuditd(t *testing.T) {
+	var gotPath, gotMethod, gotUser string
+	auditd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotMethod, gotUser = r.URL.Path, r.Method, r.Header.Get("X-User")
+		w.Write([]byte(`{"frozen":true}`)) //nolint:errcheck
+	}))
+	defer auditd.Close()
+
+	gw := makeDirectDispatchGateway(&discovery.Agent{Name: agentNameDB, InvokeURL: "http://127.0.0.1:1/invoke"})
+	gw.auditURL = auditd.URL
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/freeze", strings.NewReader(`{"reason":"incident"}`))
+	req.Header.Set("X-User", "alice")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if gotPath != "/v1/freeze" || gotMethod != http.MethodPost || gotUser != "alice" {
+		t.Errorf("auditd saw %s %s user=%q", gotMethod, gotPath, gotUser)
+	}
+}
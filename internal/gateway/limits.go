@@ -0,0 +1,248 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// Request limit defaults. Every route gets the short timeout except the ones
+// in agentRoutes, which wait on an agent or the LLM.
+const (
+	defaultMaxBodyBytes    = 1 << 20
+	defaultMaxUploadBytes  = audit.UploadMaxBytes + 1<<20 // room for the multipart framing
+	defaultRequestTimeout  = 30 * time.Second
+	defaultAgentTimeout    = 5 * time.Minute
+	defaultBodyReadTimeout = 10 * time.Second
+)
+
+// uploadRoute is the one route that takes a multipart body rather than JSON.
+const uploadRoute = "POST /api/v1/fleet/uploads"
+
+// agentRoutes are the routes whose handlers call an agent over A2A or the
+// planner LLM, directly or through a playbook run, and so get AgentTimeout.
+var agentRoutes = map[string]bool{
+	"POST /api/v1/query":                                          true,
+	"POST /api/v1/route":                                          true,
+	"POST /api/v1/incidents":                                      true,
+	"GET /api/v1/incidents":                                       true,
+	"POST /api/v1/db/{tool}":                                      true,
+	"POST /api/v1/k8s/{tool}":                                     true,
+	"POST /api/v1/agents/{agent}/tools/{tool}":                    true,
+	"POST /api/v1/research":                                       true,
+	"POST /api/v1/databases/{name}/statement-snapshots":           true,
+	"POST /api/v1/governance/ask":                                 true,
+	"POST /api/v1/fleet/plan":                                     true,
+	"POST /api/v1/fleet/snapshot":                                 true,
+	"POST /api/v1/fleet/review":                                   true,
+	"POST /api/v1/fleet/uploads":                                  true,
+	"POST /api/v1/fleet/playbooks/from-trace":                     true,
+	"POST /api/v1/fleet/playbooks/import":                         true,
+	"POST /api/v1/fleet/playbooks/{playbookID}/run":               true,
+	"POST /api/v1/fleet/playbook-runs/{runID}/proceed":            true,
+	"POST /api/v1/fleet/playbook-runs/{runID}/proceed-escalation": true,
+	"POST /api/v1/decisions/{id}/resolve":                         true,
+}
+
+// RequestLimits bounds what a single request may cost the gateway.
+type RequestLimits struct {
+	MaxBodyBytes    int64         // JSON request bodies
+	MaxUploadBytes  int64         // multipart bodies on POST /api/v1/fleet/uploads
+	RequestTimeout  time.Duration // handler deadline for most routes
+	AgentTimeout    time.Duration // handler deadline for agentRoutes
+	BodyReadTimeout time.Duration // time allowed to receive a JSON body
+}
+
+// DefaultRequestLimits returns the limits used when none are configured.
+func DefaultRequestLimits() RequestLimits {
+	return RequestLimits{
+		MaxBodyBytes:    defaultMaxBodyBytes,
+		MaxUploadBytes:  defaultMaxUploadBytes,
+		RequestTimeout:  defaultRequestTimeout,
+		AgentTimeout:    defaultAgentTimeout,
+		BodyReadTimeout: defaultBodyReadTimeout,
+	}
+}
+
+// withDefaults fills unset (zero or negative) fields from DefaultRequestLimits.
+func (l RequestLimits) withDefaults() RequestLimits {
+	d := DefaultRequestLimits()
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = d.MaxBodyBytes
+	}
+	if l.MaxUploadBytes <= 0 {
+		l.MaxUploadBytes = d.MaxUploadBytes
+	}
+	if l.RequestTimeout <= 0 {
+		l.RequestTimeout = d.RequestTimeout
+	}
+	if l.AgentTimeout <= 0 {
+		l.AgentTimeout = d.AgentTimeout
+	}
+	if l.BodyReadTimeout <= 0 {
+		l.BodyReadTimeout = d.BodyReadTimeout
+	}
+	return l
+}
+
+// requestLimitsFromEnv reads the HELPDESK_GATEWAY_* limit overrides. An
+// invalid value is logged and the default kept.
+func requestLimitsFromEnv() RequestLimits {
+	l := DefaultRequestLimits()
+	envBytes := func(name string, dst *int64) {
+		if v := os.Getenv(name); v != "" {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+				*dst = n
+			} else {
+				slog.Warn("invalid "+name+", using default", "value", v, "default", *dst)
+			}
+		}
+	}
+	envDuration := func(name string, dst *time.Duration) {
+		if v := os.Getenv(name); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				*dst = d
+			} else {
+				slog.Warn("invalid "+name+", using default", "value", v, "default", *dst)
+			}
+		}
+	}
+	envBytes("HELPDESK_GATEWAY_MAX_BODY_BYTES", &l.MaxBodyBytes)
+	envBytes("HELPDESK_GATEWAY_MAX_UPLOAD_BYTES", &l.MaxUploadBytes)
+	envDuration("HELPDESK_GATEWAY_REQUEST_TIMEOUT", &l.RequestTimeout)
+	envDuration("HELPDESK_GATEWAY_AGENT_TIMEOUT", &l.AgentTimeout)
+	envDuration("HELPDESK_GATEWAY_BODY_READ_TIMEOUT", &l.BodyReadTimeout)
+	return l
+}
+
+// withRequestLimits applies the gateway's request limits to one route:
+//
+//   - a body larger than the route's limit is refused with 413, up front when
+//     Content-Length says so and otherwise once the limit is crossed;
+//   - a body must be JSON (multipart/form-data on the upload route); a
+//     declared Content-Type of anything else is refused with 415;
+//   - a JSON body must arrive within BodyReadTimeout or the request fails
+//     with 408, so a slow client cannot hold the handler;
+//   - the request context carries the route's deadline, which cancels the
+//     A2A calls, LLM calls and auditd proxies made on its behalf.
+//
+// The pattern is captured at registration time, as in RegisterRoutes' auth.
+func (g *Gateway) withRequestLimits(pattern string, h http.HandlerFunc) http.HandlerFunc {
+	l := g.limits.withDefaults()
+	maxBytes, timeout := l.MaxBodyBytes, l.RequestTimeout
+	if pattern == uploadRoute {
+		maxBytes = l.MaxUploadBytes
+	}
+	if agentRoutes[pattern] {
+		timeout = l.AgentTimeout
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytes))
+			return
+		}
+		if r.ContentLength != 0 {
+			if msg := checkContentType(pattern, r.Header.Get("Content-Type")); msg != "" {
+				writeError(w, http.StatusUnsupportedMediaType, msg)
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+		// Buffer JSON bodies under a read deadline. Uploads stream through to
+		// auditd and are bounded by the route's deadline instead.
+		if r.ContentLength != 0 && pattern != uploadRoute {
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(time.Now().Add(l.BodyReadTimeout)) //nolint:errcheck // unsupported in tests
+			body, err := io.ReadAll(r.Body)
+			rc.SetReadDeadline(time.Time{}) //nolint:errcheck
+			if err != nil {
+				status, msg := bodyReadError(err)
+				writeError(w, status, msg)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// checkContentType returns why a request body's declared media type is not
+// accepted on the route, or "" when it is. A body without a Content-Type is
+// taken to be JSON.
+func checkContentType(pattern, contentType string) string {
+	if pattern == uploadRoute {
+		if mt, _, err := mime.ParseMediaType(contentType); err != nil || mt != "multipart/form-data" {
+			return "Content-Type must be multipart/form-data"
+		}
+		return ""
+	}
+	if contentType == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) {
+		return fmt.Sprintf("unsupported Content-Type %q: request bodies must be application/json", contentType)
+	}
+	return ""
+}
+
+// bodyReadError maps a failure to read a request body to a status and message.
+func bodyReadError(err error) (int, string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return http.StatusRequestTimeout, "timed out reading request body"
+	}
+	return http.StatusBadRequest, "failed to read request body: " + err.Error()
+}
+
+// withJSONErrors answers requests no route matches with the gateway's error
+// JSON instead of ServeMux's plain-text 404 and 405 pages.
+func withJSONErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rc := newResponseCapture()
+		h.ServeHTTP(rc, r)
+		if rc.code < 400 {
+			// Redirects to the canonical path pass through unchanged.
+			for k, v := range rc.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rc.code)
+			w.Write(rc.body.Bytes()) //nolint:errcheck
+			return
+		}
+		if allow := rc.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		msg := "no such endpoint: " + r.Method + " " + r.URL.Path
+		if rc.code == http.StatusMethodNotAllowed {
+			msg = "method " + r.Method + " not allowed on " + r.URL.Path
+		}
+		writeError(w, rc.code, msg)
+	})
+}
@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRequestLimits_BodySizeAndContentType(t *testing.T) {
+	g := &Gateway{limits: RequestLimits{MaxBodyBytes: 16}}
+	var got string
+	h := g.withRequestLimits("POST /api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		writeJSON(w, http.StatusOK, map[string]string{"ok": "yes"})
+	})
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		chunked     bool
+		want        int
+	}{
+		{"json", `{"a":1}`, "application/json; charset=utf-8", false, http.StatusOK},
+		{"no content type", `{"a":1}`, "", false, http.StatusOK},
+		{"json suffix", `{"a":1}`, "application/merge-patch+json", false, http.StatusOK},
+		{"form", `a=1`, "application/x-www-form-urlencoded", false, http.StatusUnsupportedMediaType},
+		{"too large", `{"a":"0123456789abcdef"}`, "application/json", false, http.StatusRequestEntityTooLarge},
+		{"too large, chunked", `{"a":"0123456789abcdef"}`, "application/json", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		got = ""
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body.String())
+			continue
+		}
+		if tt.want != http.StatusOK {
+			var e map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e["error"] == "" {
+				t.Errorf("%s: body %q is not error JSON", tt.name, rec.Body.String())
+			}
+		} else if got != tt.body {
+			t.Errorf("%s: handler read %q, want %q", tt.name, got, tt.body)
+		}
+	}
+}
+
+func TestWithRequestLimits_UploadNeedsMultipart(t *testing.T) {
+	g := &Gateway{}
+	h := g.withRequestLimits(uploadRoute, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	for ct, want := range map[string]int{
+		"multipart/form-data; boundary=x": http.StatusCreated,
+		"application/json":                http.StatusUnsupportedMediaType,
+		"":                                http.StatusUnsupportedMediaType,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/fleet/uploads", strings.NewReader("--x--"))
+		if ct != "" {
+			req.Header.Set("Content-Type", ct)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != want {
+			t.Errorf("Content-Type %q: status = %d, want %d", ct, rec.Code, want)
+		}
+	}
+}
+
+func TestWithRequestLimits_RouteDeadlines(t *testing.T) {
+	g := &Gateway{limits: RequestLimits{RequestTimeout: time.Second, AgentTimeout: time.Hour}}
+	deadline := func(pattern string) time.Duration {
+		var d time.Duration
+		g.withRequestLimits(pattern, func(w http.ResponseWriter, r *http.Request) {
+			dl, ok := r.Context().Deadline()
+			if !ok {
+				t.Fatalf("%s: request context has no deadline", pattern)
+			}
+			d = time.Until(dl)
+		})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		return d
+	}
+	if d := deadline("GET /api/v1/fleet/jobs"); d > time.Second {
+		t.Errorf("short route deadline in %s, want <= 1s", d)
+	}
+	if d := deadline("POST /api/v1/query"); d < 59*time.Minute {
+		t.Errorf("agent route deadline in %s, want about 1h", d)
+	}
+}
+
+func TestWithRequestLimits_SlowBody(t *testing.T) {
+	g := &Gateway{limits: RequestLimits{BodyReadTimeout: 100 * time.Millisecond}}
+	called := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/query", g.withRequestLimits("POST /api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The client sends part of the body and then stalls.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte(`{"message":`)) //nolint:errcheck
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/query", pr)
+	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout || called {
+		t.Errorf("status = %d, handler called = %v; want 408 without the handler", resp.StatusCode, called)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("slow body held the request for %s", elapsed)
+	}
+}
+
+func TestWithJSONErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []string{})
+	})
+	h := withJSONErrors(mux)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/agents", http.StatusOK},
+		{http.MethodGet, "/api/v1/nope", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/agents", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: Content-Type = %q, want application/json", tt.method, tt.path, ct)
+		}
+		if tt.want == http.StatusMethodNotAllowed && rec.Header().Get("Allow") == "" {
+			t.Errorf("%s %s: 405 without an Allow header", tt.method, tt.path)
+		}
+	}
+}
+
+func TestRequestLimitsFromEnv(t *testing.T) {
+	t.Setenv("HELPDESK_GATEWAY_MAX_BODY_BYTES", "2048")
+	t.Setenv("HELPDESK_GATEWAY_AGENT_TIMEOUT", "90s")
+	t.Setenv("HELPDESK_GATEWAY_REQUEST_TIMEOUT", "soon")
+	l := requestLimitsFromEnv()
+	if l.MaxBodyBytes != 2048 || l.AgentTimeout != 90*time.Second || l.RequestTimeout != defaultRequestTimeout {
+		t.Errorf("limits = %+v", l)
+	}
+}
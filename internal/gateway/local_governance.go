@@ -1,4 +1,4 @@
-package main
+package gateway
 
 // local_governance.go provides an embedded HTTP governance server for the
 // gateway's local audit mode (HELPDESK_AUDIT_DIR).
@@ -99,6 +99,11 @@ func localHandleEvents(store *audit.Store) http.HandlerFunc {
 				opts.Since = t
 			}
 		}
+		if v := q.Get("until"); v != "" {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				opts.Until = t
+			}
+		}
 		if v := q.Get("limit"); v != "" {
 			if n, err := strconv.Atoi(v); err == nil && n > 0 {
 				opts.Limit = n
@@ -0,0 +1,365 @@
+// Package gateway implements the REST gateway — a thin HTTP layer that translates
+// REST requests into A2A JSON-RPC calls to the helpdesk sub-agents.
+// The gateway itself uses an LLM only for the fleet job planner (POST /api/v1/fleet/plan);
+// all other AI reasoning is delegated to the sub-agents.
+// cmd/gateway runs it as its own process; the helpdesk all-in-one mode runs
+// it in-process next to the audit service.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a2aproject/a2a-go/a2a"
+
+	"helpdesk/agentutil"
+	"helpdesk/internal/agentlimit"
+	"helpdesk/internal/audit"
+	"helpdesk/internal/authz"
+	"helpdesk/internal/buildinfo"
+	"helpdesk/internal/decisions"
+	"helpdesk/internal/discovery"
+	"helpdesk/internal/identity"
+	"helpdesk/internal/infra"
+	"helpdesk/internal/secrets"
+	"helpdesk/internal/shutdown"
+	"helpdesk/internal/toolregistry"
+)
+
+// Run starts the gateway configured by the environment and args (command-line
+// arguments without the program name, --log-level already stripped), and
+// serves until ctx is cancelled. It then shuts down gracefully and returns
+// nil; it returns an error when the configuration is invalid, the agents
+// cannot be discovered or the listener fails.
+func Run(ctx context.Context, args []string) error {
+	slog.Info("helpdesk gateway", "version", buildinfo.Version)
+
+	// --crystal-ball: bypass playbook guidance, structured output, and chaining.
+	// For demo and LLM benchmark comparisons only.
+	crystalBall := os.Getenv("HELPDESK_CRYSTAL_BALL") == "true" || os.Getenv("HELPDESK_CRYSTAL_BALL") == "1"
+	for _, arg := range args {
+		if arg == "--crystal-ball" || arg == "-crystal-ball" {
+			crystalBall = true
+			break
+		}
+	}
+	if crystalBall {
+		slog.Warn("⚠️  CRYSTAL-BALL MODE ENABLED — playbook guidance, hypothesis format, and escalation chaining are bypassed. NOT recommended for production use.")
+	}
+
+	listenAddr := os.Getenv("HELPDESK_GATEWAY_ADDR")
+	if listenAddr == "" {
+		listenAddr = "localhost:8080"
+	}
+
+	agentURLs := os.Getenv("HELPDESK_AGENT_URLS")
+	if agentURLs == "" {
+		return errors.New("HELPDESK_AGENT_URLS is required (comma-separated agent base URLs)")
+	}
+
+	urls := strings.Split(agentURLs, ",")
+	for i := range urls {
+		urls[i] = strings.TrimSpace(urls[i])
+	}
+
+	discoveryTimeout := 60 * time.Second
+	if v := os.Getenv("HELPDESK_DISCOVERY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			discoveryTimeout = d
+		} else {
+			slog.Warn("invalid HELPDESK_DISCOVERY_TIMEOUT, using default", "value", v, "default", discoveryTimeout)
+		}
+	}
+	discoverCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	registry, err := discovery.DiscoverWithRetry(discoverCtx, urls, 3*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to discover agents: %w", err)
+	}
+
+	// With a manifest key configured, agents must serve a capability manifest
+	// signed with it and advertise nothing beyond it; the rest are refused.
+	var refusedAgents map[string][]string
+	if pubKey := os.Getenv("HELPDESK_MANIFEST_PUBLIC_KEY"); pubKey != "" {
+		pub, err := infra.ParsePublicKey(pubKey)
+		if err != nil {
+			return fmt.Errorf("invalid HELPDESK_MANIFEST_PUBLIC_KEY: %w", err)
+		}
+		refusedAgents = discovery.EnforceManifests(registry, pub, audit.ToolClassification)
+		slog.Info("capability manifests verified", "accepted", len(registry), "refused", len(refusedAgents))
+	}
+
+	gw := NewGateway(registry)
+
+	// Build tool registry from discovered agent cards and schemas.
+	agentCards := make(map[string]*a2a.AgentCard, len(registry))
+	agentSchemas := make(map[string]map[string]map[string]any, len(registry))
+	for name, agent := range registry {
+		agentCards[name] = agent.Card
+		if agent.Schemas != nil {
+			agentSchemas[name] = agent.Schemas
+		}
+	}
+	toolReg := toolregistry.Build(agentCards, agentSchemas, audit.ToolClassification)
+	gw.SetToolRegistry(toolReg)
+	slog.Info("tool registry built", "tools", len(toolReg.List()))
+
+	// Initialize identity provider.
+	idProvider, err := identity.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize identity provider: %w", err)
+	}
+	gw.SetIdentityProvider(idProvider)
+	gw.SetUsersFile(os.Getenv("HELPDESK_USERS_FILE"))
+	gw.SetOperatingMode(os.Getenv("HELPDESK_OPERATING_MODE"))
+	slog.Info("identity provider initialized", "mode", os.Getenv("HELPDESK_IDENTITY_PROVIDER"))
+
+	// Build central authorizer. Enforcement is active when a real identity
+	// provider is configured (anything other than "none" or empty).
+	idMode := os.Getenv("HELPDESK_IDENTITY_PROVIDER")
+	enforcing := idMode != "" && idMode != "none"
+	authzr := authz.NewAuthorizer(authz.DefaultGatewayPermissions, enforcing)
+	slog.Info("authorization configured", "enforcing", enforcing)
+
+	// Initialize audit store if enabled.
+	auditURL := os.Getenv("HELPDESK_AUDIT_URL")
+	auditAPIKey := os.Getenv("HELPDESK_AUDIT_API_KEY")
+	auditEnabled := os.Getenv("HELPDESK_AUDIT_ENABLED") == "true" || os.Getenv("HELPDESK_AUDIT_ENABLED") == "1"
+	if err := audit.InstallAuditToken(ctx, auditURL); err != nil {
+		return fmt.Errorf("failed to configure the auditd access token: %w", err)
+	}
+
+	// Always set audit URL for governance queries (even if audit logging is disabled)
+	if auditURL != "" {
+		gw.SetAuditURL(auditURL)
+		slog.Info("governance queries enabled", "url", auditURL)
+	}
+	if auditAPIKey != "" {
+		gw.SetAuditAPIKey(auditAPIKey)
+	}
+	if agentAPIKey := os.Getenv("HELPDESK_AGENT_API_KEY"); agentAPIKey != "" {
+		gw.SetAgentAPIKey(agentAPIKey)
+		slog.Info("agent inbound auth configured (HELPDESK_AGENT_API_KEY set)")
+	} else {
+		slog.Warn("HELPDESK_AGENT_API_KEY not set — gateway will not authenticate to agent /tool/{name} endpoints")
+	}
+	signingKeys := make(map[string][]byte)
+	for name := range registry {
+		key, err := audit.ResolveA2ASigningKey(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve A2A signing key for %s: %w", name, err)
+		}
+		if key != nil {
+			signingKeys[name] = key
+		}
+	}
+	if len(signingKeys) > 0 {
+		gw.SetA2ASigningKeys(signingKeys)
+		slog.Info("A2A request signing enabled", "agents", len(signingKeys))
+	}
+
+	if auditEnabled {
+		var auditor audit.Auditor
+		if auditURL != "" {
+			// Use central audit service (preferred)
+			remoteStore := audit.NewRemoteStore(auditURL)
+			if auditAPIKey != "" {
+				remoteStore = remoteStore.WithAPIKey(auditAPIKey)
+			}
+			auditor = remoteStore
+			slog.Info("audit logging enabled (remote)", "url", auditURL)
+		} else {
+			// Fall back to local store with socket (legacy mode)
+			auditDir := os.Getenv("HELPDESK_AUDIT_DIR")
+			if auditDir == "" {
+				auditDir = "."
+			}
+			auditCfg := audit.StoreConfig{
+				DBPath:     filepath.Join(auditDir, "audit.db"),
+				SocketPath: filepath.Join(auditDir, "audit.sock"),
+			}
+			localStore, err := audit.NewStore(auditCfg)
+			if err != nil {
+				return fmt.Errorf("failed to initialize audit store: %w", err)
+			}
+			slog.Info("audit logging enabled (local)", "db", auditCfg.DBPath, "socket", auditCfg.SocketPath)
+			auditor = localStore
+
+			// Start an embedded governance server so that /api/v1/governance/*
+			// proxy endpoints work in local mode, identical to remote auditd mode.
+			govURL, err := startLocalGovernanceServer(localStore)
+			if err != nil {
+				slog.Warn("failed to start local governance server; governance queries unavailable", "err", err)
+			} else {
+				gw.SetAuditURL(govURL)
+				slog.Info("local governance server started", "url", govURL)
+			}
+		}
+		defer func() { _ = auditor.Close() }()
+
+		gwAuditor := audit.NewGatewayAuditor(auditor)
+		// Read (GET) requests are audited only when asked for. Governance reads
+		// are always recorded; the rest are sampled.
+		if v := os.Getenv("HELPDESK_AUDIT_READS"); v == "true" || v == "1" {
+			sampleRate := 1.0
+			if v := os.Getenv("HELPDESK_AUDIT_READS_SAMPLE_RATE"); v != "" {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil || f < 0 || f > 1 {
+					return fmt.Errorf("HELPDESK_AUDIT_READS_SAMPLE_RATE must be a number from 0 to 1, got %q", v)
+				}
+				sampleRate = f
+			}
+			gwAuditor.SetReadAuditPolicy(&audit.ReadAuditPolicy{SampleRate: sampleRate})
+			slog.Info("read request auditing enabled", "sample_rate", sampleRate,
+				"always", strings.Join(audit.DefaultAlwaysAuditedReads, ","))
+		}
+		gw.SetAuditor(gwAuditor)
+
+		for name, problems := range refusedAgents {
+			if err := auditor.Record(ctx, discovery.ManifestViolationEvent("gateway", name, problems)); err != nil {
+				slog.Warn("failed to record capability manifest violation", "agent", name, "err", err)
+			}
+		}
+	}
+
+	// Load infrastructure config if available.
+	if infraPath := os.Getenv("HELPDESK_INFRA_CONFIG"); infraPath != "" {
+		infraConfig, err := infra.Load(infraPath)
+		if err != nil {
+			slog.Warn("failed to load infrastructure config", "path", infraPath, "err", err)
+		} else {
+			gw.SetInfraConfig(infraConfig)
+			slog.Info("infrastructure config loaded",
+				"path", infraPath,
+				"db_servers", len(infraConfig.DBServers),
+				"k8s_clusters", len(infraConfig.K8sClusters),
+				"vms", len(infraConfig.VMs))
+		}
+	} else {
+		slog.Warn("HELPDESK_INFRA_CONFIG not set — fleet planner (POST /api/v1/fleet/plan) will return 503")
+	}
+
+	// Initialize fleet planner LLM (vendor-agnostic via agentutil).
+	plannerAPIKey := os.Getenv("HELPDESK_API_KEY")
+	if plannerAPIKey == "" {
+		plannerAPIKey = os.Getenv("ANTHROPIC_API_KEY") // convenience fallback
+	}
+	plannerCfg := agentutil.Config{
+		ModelVendor: os.Getenv("HELPDESK_MODEL_VENDOR"),
+		ModelName:   os.Getenv("HELPDESK_MODEL_NAME"),
+		APIKey:      plannerAPIKey,
+	}
+	if plannerCfg.ModelName == "" {
+		plannerCfg.ModelName = "claude-haiku-4-5-20251001"
+	}
+	if plannerCfg.ModelVendor == "" || plannerCfg.APIKey == "" {
+		slog.Warn("fleet planner LLM not configured (HELPDESK_MODEL_VENDOR or HELPDESK_API_KEY not set) — POST /api/v1/fleet/plan will return 503")
+	} else {
+		completer, err := agentutil.NewTextCompleter(ctx, plannerCfg)
+		if err != nil {
+			slog.Warn("fleet planner LLM initialization failed — POST /api/v1/fleet/plan will return 503", "err", err)
+		} else {
+			gw.SetPlannerLLM(completer)
+			slog.Info("fleet planner LLM configured", "vendor", plannerCfg.ModelVendor, "model", plannerCfg.ModelName)
+		}
+	}
+
+	gw.SetCrystalBall(crystalBall)
+	gw.SetAuthorizer(authzr)
+
+	// Credentials may be given as secrets references (vault://, awssm://,
+	// gcpsm://, file://). One that cannot be resolved is fatal: starting
+	// without it would silently disable signing or notification.
+	creds := make(map[string]string)
+	for _, name := range []string{"HELPDESK_GIT_WEBHOOK_SECRET", "HELPDESK_DECISION_WEBHOOK_SECRET", "HELPDESK_SMTP_PASSWORD"} {
+		v, err := secrets.Getenv(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret %s: %w", name, err)
+		}
+		creds[name] = v
+	}
+
+	// Git webhook adapter config.
+	gw.gitWebhookCfg = GitWebhookConfig{
+		Secret:        creds["HELPDESK_GIT_WEBHOOK_SECRET"],
+		ResolveBranch: os.Getenv("HELPDESK_GIT_RESOLVE_BRANCH"),
+	}
+
+	// Decision Hub — unified notification + base URL for absolute resolve links.
+	if baseURL := os.Getenv("HELPDESK_BASE_URL"); baseURL != "" {
+		gw.SetBaseURL(baseURL)
+		slog.Info("decision hub base URL configured", "url", baseURL)
+	}
+	gw.SetDecisionNotifier(decisions.NotifierConfig{
+		WebhookURL:    os.Getenv("HELPDESK_DECISION_WEBHOOK"),
+		WebhookSecret: creds["HELPDESK_DECISION_WEBHOOK_SECRET"],
+		BaseURL:       os.Getenv("HELPDESK_BASE_URL"),
+		SMTPHost:      os.Getenv("HELPDESK_SMTP_HOST"),
+		SMTPPort:      os.Getenv("HELPDESK_SMTP_PORT"),
+		SMTPUser:      os.Getenv("HELPDESK_SMTP_USER"),
+		SMTPPassword:  creds["HELPDESK_SMTP_PASSWORD"],
+		EmailFrom:     os.Getenv("HELPDESK_EMAIL_FROM"),
+		EmailTo:       splitEmailTo(os.Getenv("HELPDESK_EMAIL_TO")),
+	})
+
+	// Metrics are always enabled: /metrics on the same port exposes
+	// gateway_fabrication_mismatches_total and is safe to scrape without auth.
+	gw.SetMetrics(NewGatewayMetrics())
+
+	gw.SetRequestLimits(requestLimitsFromEnv())
+
+	// Per-agent concurrency caps: calls over an agent's cap queue briefly and
+	// are then rejected with 429 and Retry-After.
+	agentLimiter, err := agentlimit.FromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid agent concurrency limits: %w", err)
+	}
+	if agentLimiter != nil {
+		gw.SetAgentLimiter(agentLimiter)
+		slog.Info("agent concurrency limits enabled", "limits", os.Getenv("HELPDESK_AGENT_CONCURRENCY"))
+	}
+
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	// When ctx is done stop accepting requests, let in-flight ones finish
+	// and drain the audit writes and notifications they started, within
+	// HELPDESK_SHUTDOWN_TIMEOUT. The deferred auditor close runs after that.
+	slog.Info("starting REST gateway", "addr", listenAddr, "agents", len(registry))
+	// Routes set their own deadlines (withRequestLimits); the server bounds
+	// only the headers and idle keep-alive connections.
+	srv := &http.Server{
+		Addr:              listenAddr,
+		Handler:           withJSONErrors(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	if err := shutdown.ListenAndServe(ctx, srv, shutdown.Timeout()); err != nil {
+		return fmt.Errorf("gateway stopped: %w", err)
+	}
+	slog.Info("gateway stopped")
+	return nil
+}
+
+// splitEmailTo splits a comma-separated email list into a slice.
+func splitEmailTo(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, e := range strings.Split(s, ",") {
+		if t := strings.TrimSpace(e); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"encoding/json"
@@ -99,7 +99,10 @@ func (g *Gateway) handleFleetSnapshot(w http.ResponseWriter, r *http.Request) {
 func (g *Gateway) handleFleetPlan(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	requestID := uuid.New().String()[:8]
-	traceID := audit.NewTraceIDWithPrefix("plan_")
+	traceID := r.Header.Get("X-Trace-ID")
+	if traceID == "" {
+		traceID = audit.NewTraceIDWithPrefix("plan_")
+	}
 	w.Header().Set("X-Trace-ID", traceID)
 
 	resolvedPrincipal, purpose, purposeNote, _, _ := g.resolveRequest(r, "", "")
@@ -215,7 +218,7 @@ func (g *Gateway) handleFleetPlan(w http.ResponseWriter, r *http.Request) {
 	if len(jobDef.Targets.Tags) > 0 {
 		knownTags := make(map[string]bool)
 		for _, server := range g.infra.DBServers {
-			for _, tag := range server.Tags {
+			for _, tag := range g.infra.DatabaseTags(server) {
 				knownTags[tag] = true
 			}
 		}
@@ -334,8 +337,8 @@ func buildPlannerInfraContext(cfg *infra.Config) (summary string, restricted []s
 	var sb strings.Builder
 	for key, server := range cfg.DBServers {
 		tags := "(none)"
-		if len(server.Tags) > 0 {
-			tags = strings.Join(server.Tags, ", ")
+		if serverTags := cfg.DatabaseTags(server); len(serverTags) > 0 {
+			tags = strings.Join(serverTags, ", ")
 		}
 		sensitivity := "(none)"
 		if len(server.Sensitivity) > 0 {
@@ -513,7 +516,7 @@ func resolveTargetsFromInfra(cfg *infra.Config, targets fleet.Targets) []string
 		// Check if server tags match (any tag in the list).
 		if len(targets.Tags) > 0 {
 			for _, wantTag := range targets.Tags {
-				for _, serverTag := range server.Tags {
+				for _, serverTag := range cfg.DatabaseTags(server) {
 					if wantTag == serverTag {
 						result = append(result, key)
 						goto nextServer
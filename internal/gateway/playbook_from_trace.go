@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"bytes"
@@ -116,7 +116,7 @@ func (g *Gateway) handlePlaybookFromTrace(w http.ResponseWriter, r *http.Request
 	// Fetch tool execution events for this trace from auditd.
 	// Refuse to synthesize when auditd is unavailable or the trace has no events —
 	// calling the LLM with an empty trace produces hallucinated generic content.
-	traceJSON, err := g.fetchTraceEvents(req.TraceID)
+	traceJSON, err := g.fetchTraceEvents(r.Context(), req.TraceID)
 	if err != nil {
 		if g.auditURL == "" {
 			writeError(w, http.StatusServiceUnavailable, "auditd not configured: cannot synthesize playbook without an audit trace")
@@ -294,14 +294,14 @@ func (g *Gateway) persistPlaybookDraft(ctx context.Context, pb *audit.Playbook)
 // fetchTraceEvents queries auditd for all events belonging to the given trace_id
 // and returns them as a JSON string. Returns an error when auditd is unavailable
 // or the trace is not found.
-func (g *Gateway) fetchTraceEvents(traceID string) (string, error) {
+func (g *Gateway) fetchTraceEvents(ctx context.Context, traceID string) (string, error) {
 	if g.auditURL == "" {
 		return "", fmt.Errorf("auditd URL not configured")
 	}
 
 	url := strings.TrimSuffix(g.auditURL, "/") + "/v1/events?trace_id=" + traceID + "&event_type=tool_execution"
 
-	hreq, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("build request: %w", err)
 	}
@@ -329,7 +329,7 @@ func (g *Gateway) fetchTraceEvents(traceID string) (string, error) {
 	// IDs (ar_*) rather than the run ID, so audit_events is empty for the plr_*
 	// ID even though full step data exists in playbook_run_steps.
 	if isEmptyJSONArray(string(data)) && strings.HasPrefix(traceID, "plr_") {
-		return g.fetchStepsAsTraceEvents(traceID)
+		return g.fetchStepsAsTraceEvents(ctx, traceID)
 	}
 	return string(data), nil
 }
@@ -337,9 +337,9 @@ func (g *Gateway) fetchTraceEvents(traceID string) (string, error) {
 // fetchStepsAsTraceEvents fetches playbook_run_steps for a run and returns them
 // formatted as a JSON array of tool_execution events so from-trace can synthesize
 // a playbook from runs that don't have audit_events entries.
-func (g *Gateway) fetchStepsAsTraceEvents(runID string) (string, error) {
+func (g *Gateway) fetchStepsAsTraceEvents(ctx context.Context, runID string) (string, error) {
 	stepsURL := strings.TrimSuffix(g.auditURL, "/") + "/v1/fleet/playbook-runs/" + runID + "/steps"
-	hreq, err := http.NewRequestWithContext(context.Background(), http.MethodGet, stepsURL, nil)
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodGet, stepsURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("build steps request: %w", err)
 	}
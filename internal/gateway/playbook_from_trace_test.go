@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"bytes"
@@ -381,7 +381,7 @@ func TestFetchStepsAsTraceEvents_FormatsAsToolExecutionEvents(t *testing.T) {
 	defer srv.Close()
 
 	g := &Gateway{auditURL: srv.URL}
-	result, err := g.fetchStepsAsTraceEvents("plr_test")
+	result, err := g.fetchStepsAsTraceEvents(context.Background(), "plr_test")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -412,7 +412,7 @@ func TestFetchStepsAsTraceEvents_ErrorOnServerFailure(t *testing.T) {
 	defer srv.Close()
 
 	g := &Gateway{auditURL: srv.URL}
-	_, err := g.fetchStepsAsTraceEvents("plr_fail")
+	_, err := g.fetchStepsAsTraceEvents(context.Background(), "plr_fail")
 	if err == nil {
 		t.Error("expected error when steps endpoint returns 500, got nil")
 	}
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"context"
@@ -1015,6 +1015,29 @@ ESCALATE_TO: none`
 	}
 }
 
+// ---- connectionAlias tests ----
+
+func TestConnectionAlias(t *testing.T) {
+	g := &Gateway{infra: makeContextTestInfra()}
+	tests := []struct{ in, want string }{
+		{"host=localhost port=5433 dbname=postgres", "test-pg"},
+		{"host=localhost port=5433 dbname=postgres user=postgres password=secret", "test-pg"},
+		{"test-pg", "test-pg"},
+		{"host=unknown port=1 dbname=x", "host=unknown port=1 dbname=x"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := g.connectionAlias(tt.in); got != tt.want {
+			t.Errorf("connectionAlias(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	noInfra := &Gateway{}
+	if got := noInfra.connectionAlias("host=a password=b"); got != "host=a password=b" {
+		t.Errorf("without infra config the string must pass through, got %q", got)
+	}
+}
+
 // ---- checkContextConsistency tests ----
 
 func makeContextTestInfra() *infra.Config {
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"bytes"
@@ -21,6 +21,7 @@ import (
 	"helpdesk/internal/decisions"
 	"helpdesk/internal/identity"
 	"helpdesk/internal/infra"
+	"helpdesk/internal/shutdown"
 )
 
 // proxyToAuditd forwards the request to the auditd service at the given path
@@ -295,6 +296,19 @@ type PlaybookRunRequest struct {
 	PurposeNote string `json:"purpose_note,omitempty"`
 }
 
+// connectionAlias returns the infra config key of the registered database a
+// raw connection string points at. Aliases, unregistered strings and the
+// no-infra-config case pass through unchanged.
+func (g *Gateway) connectionAlias(connStr string) string {
+	if g.infra == nil || !strings.Contains(connStr, "=") {
+		return connStr
+	}
+	if _, key, ok := g.infra.FindDBByConnStr(connStr); ok {
+		return key
+	}
+	return connStr
+}
+
 // handlePlaybookRun handles POST /api/v1/fleet/playbooks/{id}/run.
 // Routes to the fleet planner (execution_mode="fleet") or the database agent
 // (execution_mode="agent") based on the playbook's execution_mode field.
@@ -321,6 +335,10 @@ func (g *Gateway) handlePlaybookRun(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Registered databases travel by alias only, so no DSN or password reaches
+	// the agent prompt, the run record or the audit trail.
+	req.ConnectionString = g.connectionAlias(req.ConnectionString)
+
 	// Bridge purpose fields into headers so proxyToAgentWithTool has one place
 	// to read them (same pattern as handleQuery).
 	if req.Purpose != "" && r.Header.Get("X-Purpose") == "" {
@@ -429,7 +447,7 @@ func (g *Gateway) handlePlaybookRun(w http.ResponseWriter, r *http.Request) {
 	// Fleet runs complete synchronously; outcome is unknown until operator
 	// reviews and approves the plan. Record completion best-effort.
 	if runID != "" {
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "unknown", "", "", "", "", "", nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "unknown", "", "", "", "", "", nil) })
 	}
 }
 
@@ -603,8 +621,10 @@ func (g *Gateway) handlePlaybookRunAsAgent(w http.ResponseWriter, r *http.Reques
 			"Not recommended for production use."
 		injectFields(w, primary.capture, extra)
 		if runID != "" {
-			go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
-				runID, primary.outcome, "", "", primary.findings, "", primary.traceID, nil)
+			shutdown.Go(func() {
+				g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
+					runID, primary.outcome, "", "", primary.findings, "", primary.traceID, nil)
+			})
 		}
 		return
 	}
@@ -860,8 +880,10 @@ func (g *Gateway) handlePlaybookRunAsAgent(w http.ResponseWriter, r *http.Reques
 	injectFields(w, primary.capture, extra)
 
 	if runID != "" {
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
-			runID, finalOutcome, finalEscalatedTo, finalTransitionedTo, finalFindings, capturedText(primary.capture), primary.traceID, finalReport)
+		shutdown.Go(func() {
+			g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
+				runID, finalOutcome, finalEscalatedTo, finalTransitionedTo, finalFindings, capturedText(primary.capture), primary.traceID, finalReport)
+		})
 	}
 }
 
@@ -922,8 +944,10 @@ func (g *Gateway) chainEscalation(r *http.Request, primaryPB *audit.Playbook, re
 	chainRes := g.runAgentPlaybook(r, nextPB, chainReq, nextPB.AgentName, chainRunID)
 
 	if chainRunID != "" {
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
-			chainRunID, chainRes.outcome, chainRes.escalatedTo, chainRes.transitionTo, chainRes.findings, capturedText(chainRes.capture), chainRes.traceID, chainRes.diagReport)
+		shutdown.Go(func() {
+			g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()),
+				chainRunID, chainRes.outcome, chainRes.escalatedTo, chainRes.transitionTo, chainRes.findings, capturedText(chainRes.capture), chainRes.traceID, chainRes.diagReport)
+		})
 	}
 
 	slog.Info("playbook: auto-chained escalation",
@@ -1082,7 +1106,7 @@ func (g *Gateway) handleProceedEscalation(w http.ResponseWriter, r *http.Request
 	if req.Resolution == "denied" {
 		g.recordGateAcknowledged(r.Context(), run, resolvedBy, req.Resolution, req.ApprovalMode, "", req.Reason)
 		g.recordPlaybookRunComplete(r.Context(), runID, audit.OutcomeAbandoned, "", "", "gate denied by operator", "", "", run.DiagnosticReport)
-		go g.submitDenialFeedback(context.WithoutCancel(r.Context()), runID, run.SeriesID, resolvedBy, req.Reason)
+		shutdown.Go(func() { g.submitDenialFeedback(context.WithoutCancel(r.Context()), runID, run.SeriesID, resolvedBy, req.Reason) })
 		if g.decisionNotifier != nil {
 			g.decisionNotifier.NotifyResolved(r.Context(), decisions.Decision{
 				ID:         "gate:" + runID,
@@ -1121,7 +1145,7 @@ func (g *Gateway) handleProceedEscalation(w http.ResponseWriter, r *http.Request
 	// a cleaner signal than post-incident feedback because the operator hasn't
 	// yet seen whether the fix worked.
 	if req.VerdictCorrect != nil || req.VerdictNotes != "" {
-		go g.postAtGateFeedback(context.WithoutCancel(r.Context()), runID, resolvedBy, req.VerdictCorrect, req.VerdictNotes)
+		shutdown.Go(func() { g.postAtGateFeedback(context.WithoutCancel(r.Context()), runID, resolvedBy, req.VerdictCorrect, req.VerdictNotes) })
 	}
 	if g.decisionNotifier != nil {
 		g.decisionNotifier.NotifyResolved(r.Context(), decisions.Decision{
@@ -1136,7 +1160,7 @@ func (g *Gateway) handleProceedEscalation(w http.ResponseWriter, r *http.Request
 	// Build the remediation request. Prefer connection_string from the resolve
 	// request body; fall back to the one stored on the triage run so operators
 	// don't need to re-supply it when approving a gate.
-	connStr := req.ConnectionString
+	connStr := g.connectionAlias(req.ConnectionString)
 	if connStr == "" {
 		connStr = run.ConnectionString
 	}
@@ -2578,7 +2602,7 @@ func (g *Gateway) handlePlaybookRunApprove(w http.ResponseWriter, r *http.Reques
 
 	if done {
 		// Unusual: playbook declares done on first proposal (no actions needed).
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "resolved", "", "", summary, "", "", nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "resolved", "", "", summary, "", "", nil) })
 		resp := ApproveRunResponse{RunID: runID, Status: "complete", Summary: summary, Warnings: warnings, EffectiveApprovalMode: req.ApprovalMode}
 		writeJSON(w, http.StatusOK, resp)
 		return
@@ -2677,7 +2701,7 @@ func (g *Gateway) handlePlaybookRunProceed(w http.ResponseWriter, r *http.Reques
 
 	if req.Resolution == "denied" {
 		g.updateRunStep(r.Context(), runID, pendingStep.StepIndex, "denied", "", "", "")
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "step denied by operator", "", run.TraceID, nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "step denied by operator", "", run.TraceID, nil) })
 		writeJSON(w, http.StatusOK, ApproveRunResponse{RunID: runID, Status: "denied"})
 		return
 	}
@@ -2721,7 +2745,7 @@ func (g *Gateway) handlePlaybookRunProceed(w http.ResponseWriter, r *http.Reques
 	g.updateRunStep(r.Context(), runID, pendingStep.StepIndex, stepStatus, pendingStep.ApprovalID, result, stepErrStr)
 
 	if toolErr != nil {
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "tool execution failed: "+stepErrStr, "", run.TraceID, nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "tool execution failed: "+stepErrStr, "", run.TraceID, nil) })
 		writeError(w, http.StatusUnprocessableEntity, "tool execution failed: "+stepErrStr)
 		return
 	}
@@ -2763,13 +2787,13 @@ func (g *Gateway) handlePlaybookRunProceed(w http.ResponseWriter, r *http.Reques
 	nextProposal, done, summary, err := g.proposeNextStep(r.Context(), pb, connStr, priorFindings, history)
 	if err != nil {
 		slog.Error("handlePlaybookRunProceed: re-planning failed", "run_id", runID, "err", err)
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "re-planning failed: "+err.Error(), "", run.TraceID, nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "abandoned", "", "", "re-planning failed: "+err.Error(), "", run.TraceID, nil) })
 		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("re-planning failed after step %d: %v", pendingStep.StepIndex, err))
 		return
 	}
 
 	if done {
-		go g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "resolved", "", "", summary, "", run.TraceID, nil)
+		shutdown.Go(func() { g.recordPlaybookRunComplete(context.WithoutCancel(r.Context()), runID, "resolved", "", "", summary, "", run.TraceID, nil) })
 		writeJSON(w, http.StatusOK, ApproveRunResponse{RunID: runID, Status: "complete", Summary: summary})
 		return
 	}
@@ -2977,6 +3001,6 @@ func (g *Gateway) createStepApproval(ctx context.Context, runID string, proposal
 	}
 
 	// Update the step record with the approval_id.
-	go g.updateRunStep(context.WithoutCancel(ctx), runID, proposal.Index, "proposed", stored.ApprovalID, "", "")
+	shutdown.Go(func() { g.updateRunStep(context.WithoutCancel(ctx), runID, proposal.Index, "proposed", stored.ApprovalID, "", "") })
 	return stored.ApprovalID
 }
@@ -1,10 +1,12 @@
-package main
+package gateway
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +23,7 @@ var routingAgentDescriptions = map[string]string{
 	agentNameK8s:      "Live Kubernetes cluster problems that require kubectl: pods, deployments, services, endpoints, events, node resources, CrashLoopBackOff, OOMKilled. Use only when the user needs current state from a live cluster.",
 	agentNameIncident: "Incident creation and investigation: creating incident bundles, listing past incidents, cross-system triage that spans database and infrastructure.",
 	agentNameResearch: "Conceptual questions, how-does-it-work explanations, documentation lookup, and best-practice advice that do not require querying a live system. Examples: explaining VACUUM vs VACUUM FULL, what WAL is, how connection pooling works, what a CrashLoopBackOff means. Prefer this agent whenever the question can be answered from knowledge rather than live data.",
+	agentNameKB:       "How a known issue was handled before: internal runbooks, postmortems and past incident write-ups. Use when the user asks how the team fixed or handles a specific failure, or for the runbook for a known symptom. Prefer research_agent for general documentation and kb_agent for the team's own procedures.",
 	agentNameSysadmin: "Live host/OS-level problems that require shell access: CPU, memory, disk, running processes, system journal, filesystem, non-Kubernetes Linux infrastructure.",
 }
 
@@ -75,14 +78,24 @@ func (g *Gateway) routeWithLLM(ctx context.Context, message string) (*RoutingDec
 	return &decision, nil
 }
 
+// routableAgents returns the routable agents that are actually available,
+// sorted so the routing prompt is the same from one request to the next.
+func (g *Gateway) routableAgents() []string {
+	var names []string
+	for name := range routingAgentDescriptions {
+		if _, ok := g.clients[name]; ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
 // buildRoutingPrompt assembles the LLM prompt for agent routing.
 func (g *Gateway) buildRoutingPrompt(message string) string {
 	var agentList string
-	for name, desc := range routingAgentDescriptions {
-		// Only include agents that are actually available.
-		if _, ok := g.clients[name]; ok {
-			agentList += fmt.Sprintf("  %s — %s\n", name, desc)
-		}
+	for _, name := range g.routableAgents() {
+		agentList += fmt.Sprintf("  %s — %s\n", name, routingAgentDescriptions[name])
 	}
 
 	return fmt.Sprintf(`You are a request router for an AI operations platform.
@@ -128,20 +141,46 @@ func (g *Gateway) recordRoutingDecision(ctx context.Context, traceID string, pri
 		return
 	}
 
-	alts := make([]audit.Alternative, 0, len(decision.AlternativesConsidered))
-	for _, a := range decision.AlternativesConsidered {
-		alts = append(alts, audit.Alternative{
-			Agent:           a.Agent,
-			RejectedBecause: a.RejectedBecause,
-		})
+	event := routingEvent(traceID, principal, decision)
+	if err := g.auditor.RecordEvent(ctx, event); err != nil {
+		slog.Warn("gateway router: failed to record routing decision", "trace_id", traceID, "err", err)
 	}
+}
+
+// recordRoutingSimulation emits a routing_simulation audit event for a
+// decision made by POST /api/v1/route and returns its event ID, or "" when
+// auditing is off or the event could not be recorded. Unlike a
+// delegation_decision it records the query itself, since no gateway_request
+// event follows to carry it.
+func (g *Gateway) recordRoutingSimulation(ctx context.Context, traceID string, principal identity.ResolvedPrincipal, query string, decision *RoutingDecision, quality *audit.ReasoningQuality, elapsed time.Duration) string {
+	if g.auditor == nil {
+		return ""
+	}
+
+	event := routingEvent(traceID, principal, decision)
+	event.EventID = "sim_" + uuid.New().String()[:8]
+	event.EventType = audit.EventTypeRoutingSimulation
+	event.Input.UserQuery = query
+	event.Decision.ReasoningQuality = quality
+	event.Outcome = &audit.Outcome{
+		Status:   "dry_run",
+		Duration: elapsed,
+	}
+	if err := g.auditor.RecordEvent(ctx, event); err != nil {
+		slog.Warn("gateway router: failed to record routing simulation", "trace_id", traceID, "err", err)
+		return ""
+	}
+	return event.EventID
+}
 
+// routingEvent builds the delegation_decision event for an LLM routing choice.
+func routingEvent(traceID string, principal identity.ResolvedPrincipal, decision *RoutingDecision) *audit.Event {
 	var p *identity.ResolvedPrincipal
 	if principal.EffectiveID() != "" {
 		p = &principal
 	}
 
-	event := &audit.Event{
+	return &audit.Event{
 		EventID:   "rt_" + uuid.New().String()[:8],
 		Timestamp: time.Now().UTC(),
 		EventType: audit.EventTypeDelegation,
@@ -153,20 +192,101 @@ func (g *Gateway) recordRoutingDecision(ctx context.Context, traceID string, pri
 		Input: audit.Input{
 			UserQuery: decision.UserIntent,
 		},
-		Decision: &audit.Decision{
-			Agent:                  decision.Agent,
-			RequestCategory:        audit.RequestCategory(decision.RequestCategory),
-			Confidence:             decision.Confidence,
-			UserIntent:             decision.UserIntent,
-			ReasoningChain:         decision.ReasoningChain,
-			AlternativesConsidered: alts,
-		},
+		Decision: decision.auditDecision(),
 		Outcome: &audit.Outcome{
 			Status: "success",
 		},
 	}
+}
 
-	if err := g.auditor.RecordEvent(ctx, event); err != nil {
-		slog.Warn("gateway router: failed to record routing decision", "trace_id", traceID, "err", err)
+// auditDecision converts the LLM's routing decision to its audit form.
+func (d *RoutingDecision) auditDecision() *audit.Decision {
+	alts := make([]audit.Alternative, 0, len(d.AlternativesConsidered))
+	for _, a := range d.AlternativesConsidered {
+		alts = append(alts, audit.Alternative{
+			Agent:           a.Agent,
+			RejectedBecause: a.RejectedBecause,
+		})
+	}
+	return &audit.Decision{
+		Agent:                  d.Agent,
+		RequestCategory:        audit.RequestCategory(d.RequestCategory),
+		Confidence:             d.Confidence,
+		UserIntent:             d.UserIntent,
+		ReasoningChain:         d.ReasoningChain,
+		AlternativesConsidered: alts,
+	}
+}
+
+// RouteSimulation is the response of POST /api/v1/route.
+type RouteSimulation struct {
+	TraceID          string                  `json:"trace_id"`
+	EventID          string                  `json:"event_id,omitempty"` // routing_simulation event; empty when auditing is off
+	Query            string                  `json:"query"`
+	AvailableAgents  []string                `json:"available_agents"` // agents offered to the router
+	Decision         *RoutingDecision        `json:"decision"`
+	ReasoningQuality *audit.ReasoningQuality `json:"reasoning_quality"`
+	DurationMs       int64                   `json:"duration_ms"`
+}
+
+// handleRoute runs only the routing step of POST /api/v1/query: the LLM
+// picks an agent for the message as it would for a real query, but nothing
+// is delegated and no tool runs. It lets operators check a prompt, model or
+// agent-set change against sample queries without side effects.
+func (g *Gateway) handleRoute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+		Query   string `json:"query"` // alias for message; both are accepted
+		User    string `json:"user"`  // caller identity recorded in the audit trail
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
 	}
+	if req.Message == "" {
+		req.Message = req.Query
+	}
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, `"message" (or "query") is required`)
+		return
+	}
+	if req.User != "" && r.Header.Get("X-User") == "" {
+		r.Header.Set("X-User", req.User)
+	}
+
+	start := time.Now()
+	decision, err := g.routeWithLLM(r.Context(), req.Message)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "agent routing failed: "+err.Error())
+		return
+	}
+	elapsed := time.Since(start)
+
+	// No tool outputs exist for a simulated request, so the score rates the
+	// reasoning's length and consistency only.
+	quality := audit.ScoreReasoning(decision.auditDecision(), nil)
+
+	traceID := r.Header.Get("X-Trace-ID")
+	if traceID == "" {
+		traceID = audit.NewTraceIDWithPrefix("sim_")
+	}
+	principal, _, _, _, _ := g.resolveRequest(r, "", "")
+	eventID := g.recordRoutingSimulation(r.Context(), traceID, principal, req.Message, decision, quality, elapsed)
+
+	slog.Info("gateway: routing simulation",
+		"agent", decision.Agent,
+		"confidence", decision.Confidence,
+		"category", decision.RequestCategory,
+		"trace_id", traceID,
+	)
+
+	writeJSON(w, http.StatusOK, RouteSimulation{
+		TraceID:          traceID,
+		EventID:          eventID,
+		Query:            req.Message,
+		AvailableAgents:  g.routableAgents(),
+		Decision:         decision,
+		ReasoningQuality: quality,
+		DurationMs:       elapsed.Milliseconds(),
+	})
 }
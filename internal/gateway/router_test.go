@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"context"
@@ -472,3 +472,81 @@ func TestHandleQuery_TraceIDSetBeforeRouting(t *testing.T) {
 		t.Errorf("X-Trace-ID = %q, want tr_ prefix", traceID)
 	}
 }
+
+// ── handleRoute (routing simulation) ──────────────────────────────────────
+
+func postRoute(t *testing.T, gw *Gateway, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/route", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "test@example.com")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleRoute_RecordsSimulationWithoutDelegating(t *testing.T) {
+	ta := &testAuditor{}
+	// The registered clients are nil: any attempt to reach an agent would panic.
+	gw := makeRouterGateway(func(_ context.Context, _ string) (string, error) {
+		return validRoutingJSON(agentNameDB), nil
+	}, []string{agentNameK8s, agentNameDB})
+	gw.auditor = audit.NewGatewayAuditor(ta)
+
+	rec := postRoute(t, gw, `{"query":"how many connections are open?"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var got RouteSimulation
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.Decision == nil || got.Decision.Agent != agentNameDB {
+		t.Fatalf("decision = %+v, want %s", got.Decision, agentNameDB)
+	}
+	if !strings.HasPrefix(got.TraceID, "sim_") || !strings.HasPrefix(got.EventID, "sim_") {
+		t.Errorf("trace_id = %q, event_id = %q, want sim_ prefixes", got.TraceID, got.EventID)
+	}
+	if strings.Join(got.AvailableAgents, ",") != agentNameK8s+","+agentNameDB {
+		t.Errorf("available_agents = %v, want sorted registered agents", got.AvailableAgents)
+	}
+	if got.ReasoningQuality == nil {
+		t.Error("reasoning_quality missing")
+	}
+
+	ta.mu.Lock()
+	events := ta.events
+	ta.mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("recorded %d events, want only the routing_simulation", len(events))
+	}
+	ev := events[0]
+	if ev.EventType != audit.EventTypeRoutingSimulation || ev.EventID != got.EventID || ev.TraceID != got.TraceID {
+		t.Errorf("event = %s %s %s, want routing_simulation %s %s", ev.EventType, ev.EventID, ev.TraceID, got.EventID, got.TraceID)
+	}
+	if ev.Input.UserQuery != "how many connections are open?" {
+		t.Errorf("UserQuery = %q, want the simulated query", ev.Input.UserQuery)
+	}
+	if ev.Outcome == nil || ev.Outcome.Status != "dry_run" {
+		t.Errorf("Outcome = %+v, want dry_run", ev.Outcome)
+	}
+	if ev.Decision == nil || ev.Decision.ReasoningQuality == nil {
+		t.Error("event decision or its reasoning quality missing")
+	}
+}
+
+func TestHandleRoute_NoLLM_Returns503(t *testing.T) {
+	gw := makeRouterGateway(nil, []string{agentNameDB})
+	if rec := postRoute(t, gw, `{"message":"how many connections?"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
+
+func TestHandleRoute_MissingMessage_Returns400(t *testing.T) {
+	gw := makeRouterGateway(nil, []string{agentNameDB})
+	if rec := postRoute(t, gw, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
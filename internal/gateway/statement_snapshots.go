@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// statementSnapshotReq is the body of POST /api/v1/databases/{name}/statement-snapshots.
+type statementSnapshotReq struct {
+	Label     string `json:"label"`
+	CompareTo string `json:"compare_to,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// handleStatementSnapshot takes a labelled pg_stat_statements snapshot of a
+// database through the database agent's get_statement_snapshot tool. The
+// result is saved like any direct tool call, so a bot can take one snapshot
+// when an incident starts and a second with compare_to once it is over.
+func (g *Gateway) handleStatementSnapshot(w http.ResponseWriter, r *http.Request) {
+	const toolName = "get_statement_snapshot"
+	if !g.checkOperatingMode(w, r, toolName) {
+		return
+	}
+	var req statementSnapshotReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Label == "" {
+		writeError(w, http.StatusBadRequest, "label is required, so a later snapshot can compare against this one")
+		return
+	}
+	args := map[string]any{
+		"connection_string": r.PathValue("name"),
+		"label":             req.Label,
+	}
+	if req.CompareTo != "" {
+		args["compare_to"] = req.CompareTo
+	}
+	if req.Limit > 0 {
+		args["limit"] = req.Limit
+	}
+	g.dispatchDirectTool(w, r, agentNameDB, toolName, args)
+}
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleStatementSnapshot(t *testing.T) {
+	var got directToolReq
+	_, agent := mockDirectToolAgent(t, "get_statement_snapshot", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"output": `=== Statement snapshot "after" ===`}) //nolint:errcheck
+	})
+	gw := makeDirectDispatchGateway(agent)
+
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/databases/prod-db/statement-snapshots",
+		strings.NewReader(`{"label":"after","compare_to":"before"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	want := map[string]any{"connection_string": "prod-db", "label": "after", "compare_to": "before"}
+	if len(got.Args) != len(want) {
+		t.Errorf("args = %v, want %v", got.Args, want)
+	}
+	for k, v := range want {
+		if got.Args[k] != v {
+			t.Errorf("args[%q] = %v, want %v", k, got.Args[k], v)
+		}
+	}
+}
+
+func TestHandleStatementSnapshot_LabelRequired(t *testing.T) {
+	_, agent := mockDirectToolAgent(t, "get_statement_snapshot", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("agent called without a label")
+	})
+	gw := makeDirectDispatchGateway(agent)
+
+	mux := http.NewServeMux()
+	gw.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/databases/prod-db/statement-snapshots", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "label is required") {
+		t.Errorf("status = %d, body = %s; want 400 requiring a label", rec.Code, rec.Body.String())
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"bytes"
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"helpdesk/internal/agentlimit"
 	"helpdesk/internal/audit"
 	"helpdesk/internal/identity"
 	"helpdesk/internal/toolregistry"
@@ -242,9 +243,36 @@ func (g *Gateway) callToolForStep(ctx context.Context, r *http.Request, traceID,
 	if err != nil {
 		return "", fmt.Errorf("auth: %w", err)
 	}
+	ctx = agentlimit.WithPriority(ctx, requestPriority(r))
 	return g.callToolWithPrincipal(ctx, traceID, purpose, agentName, toolName, args, principal)
 }
 
+// dropUndeclaredConnectionString removes connection_string from args when the
+// tool's published input schema does not declare it. Playbook runs inject the
+// run's connection string into every step, but only database tools take one
+// and agents reject parameters their tools do not declare. Args are returned
+// unchanged when the tool has no known schema.
+func (g *Gateway) dropUndeclaredConnectionString(agentName, toolName string, args map[string]any) map[string]any {
+	if _, ok := args["connection_string"]; !ok || g.toolRegistry == nil {
+		return args
+	}
+	entry, ok := g.toolRegistry.GetForAgent(toolregistry.NormalizeAgentName(agentName), toolName)
+	if !ok || entry.InputSchema == nil {
+		return args
+	}
+	props, _ := entry.InputSchema["properties"].(map[string]any)
+	if _, declared := props["connection_string"]; declared {
+		return args
+	}
+	trimmed := make(map[string]any, len(args))
+	for k, v := range args {
+		if k != "connection_string" {
+			trimmed[k] = v
+		}
+	}
+	return trimmed
+}
+
 // callToolWithPrincipal is the low-level agent HTTP call used by callToolForStep.
 func (g *Gateway) callToolWithPrincipal(ctx context.Context, traceID, purpose, agentName, toolName string, args map[string]any, principal identity.ResolvedPrincipal) (string, error) {
 	if resolved, ok := agentAliases[agentName]; ok {
@@ -254,7 +282,16 @@ func (g *Gateway) callToolWithPrincipal(ctx context.Context, traceID, purpose, a
 	if !ok {
 		return "", fmt.Errorf("agent %q not available", agentName)
 	}
+	if _, ok := agentlimit.PriorityFromContext(ctx); !ok {
+		ctx = agentlimit.WithPriority(ctx, agentlimit.PriorityForPurpose(purpose))
+	}
+	release, err := g.agentLimiter.Acquire(ctx, agentName)
+	if err != nil {
+		return "", fmt.Errorf("tool call to %s/%s: %w", agentName, toolName, err)
+	}
+	defer release()
 	baseURL := strings.TrimSuffix(agentInfo.InvokeURL, "/invoke")
+	args = g.dropUndeclaredConnectionString(agentName, toolName, args)
 
 	reqBody := directToolReq{
 		TraceID:         traceID,
@@ -277,6 +314,7 @@ func (g *Gateway) callToolWithPrincipal(ctx context.Context, traceID, purpose, a
 	if g.agentAPIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+g.agentAPIKey)
 	}
+	audit.SignA2AToolRequest(req, bodyBytes, g.a2aKeys[agentName])
 
 	client := &http.Client{Timeout: 5 * time.Minute}
 	httpResp, err := client.Do(req)
@@ -1,4 +1,4 @@
-package main
+package gateway
 
 import (
 	"context"
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"helpdesk/internal/audit"
+	"helpdesk/internal/toolregistry"
 )
 
 // ── buildHistorySection ───────────────────────────────────────────────────────
@@ -258,3 +259,30 @@ func TestProposeNextStep_HistoryAppearsInPrompt(t *testing.T) {
 		t.Error("guidance should appear in the LLM prompt")
 	}
 }
+
+func TestDropUndeclaredConnectionString(t *testing.T) {
+	g := &Gateway{toolRegistry: toolregistry.New([]toolregistry.ToolEntry{
+		{Name: "cancel_query", Agent: "database", InputSchema: map[string]any{
+			"properties": map[string]any{"connection_string": map[string]any{}, "pid": map[string]any{}},
+		}},
+		{Name: "restart_container", Agent: "sysadmin", InputSchema: map[string]any{
+			"properties": map[string]any{"target": map[string]any{}},
+		}},
+	})}
+	args := map[string]any{"connection_string": "prod-db", "target": "prod-db"}
+
+	if got := g.dropUndeclaredConnectionString(agentNameDB, "cancel_query", args); got["connection_string"] != "prod-db" {
+		t.Errorf("database tool lost connection_string: %v", got)
+	}
+	got := g.dropUndeclaredConnectionString("sysadmin", "restart_container", args)
+	if _, ok := got["connection_string"]; ok || got["target"] != "prod-db" {
+		t.Errorf("sysadmin tool args = %v, want connection_string dropped and target kept", got)
+	}
+	if _, ok := args["connection_string"]; !ok {
+		t.Error("caller's args map was modified")
+	}
+	// Tools without a known schema are left alone.
+	if got := g.dropUndeclaredConnectionString("sysadmin", "unknown_tool", args); len(got) != 2 {
+		t.Errorf("unknown tool args = %v, want unchanged", got)
+	}
+}
@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"helpdesk/internal/audit"
+)
+
+// correlationIDPattern bounds what a client may send in X-Correlation-ID. The
+// value is stored on audit events and echoed back, so it stays short and free
+// of characters that need escaping in headers or query strings.
+var correlationIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:/-]{1,128}$`)
+
+// tracePrefixForPattern returns the trace_id prefix minted for a request to
+// the given route when the client did not supply X-Trace-ID. The prefixes are
+// listed in audit.TracePrefixes.
+func tracePrefixForPattern(pattern string) string {
+	_, path, _ := strings.Cut(pattern, " ")
+	switch {
+	case pattern == "POST /api/v1/query":
+		return "tr_"
+	case pattern == "POST /api/v1/route":
+		return "sim_"
+	case pattern == "POST /api/v1/fleet/plan":
+		return "plan_"
+	case pattern == "POST /api/v1/fleet/playbook-runs/{runID}/proceed":
+		return "sa_"
+	case strings.HasSuffix(path, "/{tool}"):
+		return "dt_"
+	case strings.HasPrefix(path, "/api/v1/admin/killswitch"):
+		return "ks_"
+	default:
+		return "api_"
+	}
+}
+
+// withTraceEcho gives every /api/v1 request a trace ID the client can see. It
+// keeps a client-supplied X-Trace-ID or mints one, sets it on the request so
+// handlers record their events under it, and returns it in the X-Trace-ID
+// response header and as "trace_id" in JSON object responses. A client's
+// X-Correlation-ID is validated, stored on the events the request records
+// and echoed the same way. A valid W3C traceparent header is stored on the
+// events too and forwarded to agents; a malformed one is ignored, as the W3C
+// spec requires. Other routes are returned unchanged.
+func withTraceEcho(pattern string, h http.HandlerFunc) http.HandlerFunc {
+	_, path, _ := strings.Cut(pattern, " ")
+	if !strings.HasPrefix(path, "/api/v1/") {
+		return h
+	}
+	prefix := tracePrefixForPattern(pattern)
+	return func(w http.ResponseWriter, r *http.Request) {
+		correlationID := r.Header.Get("X-Correlation-ID")
+		if correlationID != "" && !correlationIDPattern.MatchString(correlationID) {
+			writeError(w, http.StatusBadRequest,
+				"invalid X-Correlation-ID: up to 128 letters, digits and . _ : / - are allowed")
+			return
+		}
+		traceID := r.Header.Get("X-Trace-ID")
+		if traceID == "" {
+			traceID = audit.NewTraceIDWithPrefix(prefix)
+			r.Header.Set("X-Trace-ID", traceID)
+		}
+		w.Header().Set("X-Trace-ID", traceID)
+		if correlationID != "" {
+			w.Header().Set("X-Correlation-ID", correlationID)
+			r = r.WithContext(audit.WithCorrelationID(r.Context(), correlationID))
+		}
+		if tp, ok := audit.ParseTraceParent(r.Header.Get("traceparent")); ok {
+			r = r.WithContext(audit.WithTraceParent(r.Context(), tp.String()))
+		}
+
+		ew := &traceEchoWriter{ResponseWriter: w}
+		h(ew, r)
+		// A handler may replace the trace ID (e.g. a fleet job's tr_<job ID>);
+		// the body reports whichever one the response header ends up with.
+		ew.finish(w.Header().Get("X-Trace-ID"), correlationID)
+	}
+}
+
+// traceEchoWriter holds back JSON responses until the handler returns so
+// withTraceEcho can add the trace fields. Other responses pass straight
+// through.
+type traceEchoWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (e *traceEchoWriter) WriteHeader(code int) {
+	if e.wroteHeader {
+		return
+	}
+	e.wroteHeader = true
+	e.status = code
+	if strings.HasPrefix(e.Header().Get("Content-Type"), "application/json") {
+		e.buffering = true
+		return
+	}
+	e.ResponseWriter.WriteHeader(code)
+}
+
+func (e *traceEchoWriter) Write(p []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.buffering {
+		return e.buf.Write(p)
+	}
+	return e.ResponseWriter.Write(p)
+}
+
+// finish writes a held-back JSON response with the trace fields added.
+func (e *traceEchoWriter) finish(traceID, correlationID string) {
+	if !e.buffering {
+		return
+	}
+	body := addTraceFields(e.buf.Bytes(), traceID, correlationID)
+	e.Header().Del("Content-Length")
+	e.ResponseWriter.WriteHeader(e.status)
+	e.ResponseWriter.Write(body) //nolint:errcheck
+}
+
+// addTraceFields inserts "trace_id" and "correlation_id" at the front of a
+// JSON object body, leaving the rest of the body byte-for-byte intact. Fields
+// the object already has, empty values, and bodies that are not a JSON
+// object are left alone.
+func addTraceFields(body []byte, traceID, correlationID string) []byte {
+	start := bytes.IndexByte(body, '{')
+	if start < 0 || len(bytes.TrimSpace(body[:start])) > 0 {
+		return body
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return body
+	}
+	var fields []byte
+	for _, kv := range [][2]string{{"trace_id", traceID}, {"correlation_id", correlationID}} {
+		if _, exists := obj[kv[0]]; exists || kv[1] == "" {
+			continue
+		}
+		k, _ := json.Marshal(kv[0])
+		v, _ := json.Marshal(kv[1])
+		fields = append(fields, k...)
+		fields = append(fields, ':')
+		fields = append(fields, v...)
+		fields = append(fields, ',')
+	}
+	if len(fields) == 0 {
+		return body
+	}
+	if len(obj) == 0 {
+		fields = fields[:len(fields)-1]
+	}
+	out := make([]byte, 0, len(body)+len(fields))
+	out = append(out, body[:start+1]...)
+	out = append(out, fields...)
+	if len(obj) == 0 {
+		// Drop whatever whitespace sat between the braces.
+		return append(out, bytes.TrimLeft(body[start+1:], " \t\r\n")...)
+	}
+	return append(out, body[start+1:]...)
+}
+
+// handleTracePrefixes is the GET /api/v1/trace-prefixes handler: the trace_id
+// prefix scheme, so clients can tell a request's origin from its trace ID.
+func (g *Gateway) handleTracePrefixes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"prefixes": audit.TracePrefixes})
+}
@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helpdesk/internal/audit"
+)
+
+func TestAddTraceFields(t *testing.T) {
+	tests := []struct {
+		name, body, traceID, corrID, want string
+	}{
+		{"object", `{"a":1}` + "\n", "tr_1", "", `{"trace_id":"tr_1","a":1}` + "\n"},
+		{"with correlation", `{"a":1}`, "tr_1", "req-9", `{"trace_id":"tr_1","correlation_id":"req-9","a":1}`},
+		{"empty object", "{ }\n", "tr_1", "", `{"trace_id":"tr_1"}` + "\n"},
+		{"existing trace_id kept", `{"trace_id":"tr_job"}`, "tr_1", "", `{"trace_id":"tr_job"}`},
+		{"array untouched", `[{"a":1}]`, "tr_1", "", `[{"a":1}]`},
+		{"invalid JSON untouched", `{"a":`, "tr_1", "", `{"a":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(addTraceFields([]byte(tt.body), tt.traceID, tt.corrID))
+			if got != tt.want {
+				t.Errorf("addTraceFields(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracePrefixForPattern(t *testing.T) {
+	tests := map[string]string{
+		"POST /api/v1/query":                               "tr_",
+		"POST /api/v1/route":                               "sim_",
+		"POST /api/v1/fleet/plan":                          "plan_",
+		"POST /api/v1/db/{tool}":                           "dt_",
+		"POST /api/v1/agents/{agent}/tools/{tool}":         "dt_",
+		"POST /api/v1/admin/killswitch/sessions":           "ks_",
+		"POST /api/v1/fleet/playbook-runs/{runID}/proceed": "sa_",
+		"GET /api/v1/governance/events":                    "api_",
+	}
+	for pattern, want := range tests {
+		if got := tracePrefixForPattern(pattern); got != want {
+			t.Errorf("tracePrefixForPattern(%q) = %q, want %q", pattern, got, want)
+		}
+		if _, ok := audit.LookupTracePrefix(want + "x"); !ok {
+			t.Errorf("prefix %q is missing from audit.TracePrefixes", want)
+		}
+	}
+}
+
+func TestWithTraceEcho_MintsAndEchoes(t *testing.T) {
+	var seenTrace, seenCorr string
+	h := withTraceEcho("POST /api/v1/db/{tool}", func(w http.ResponseWriter, r *http.Request) {
+		seenTrace = r.Header.Get("X-Trace-ID")
+		seenCorr = audit.CorrelationIDFromContext(r.Context())
+		writeJSON(w, http.StatusOK, map[string]string{"result": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/db/check_connection", nil)
+	req.Header.Set("X-Correlation-ID", "ticket-42")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if !strings.HasPrefix(seenTrace, "dt_") {
+		t.Fatalf("handler saw X-Trace-ID %q, want a dt_ trace ID", seenTrace)
+	}
+	if seenCorr != "ticket-42" {
+		t.Errorf("correlation ID in context = %q, want ticket-42", seenCorr)
+	}
+	if got := rec.Header().Get("X-Trace-ID"); got != seenTrace {
+		t.Errorf("X-Trace-ID response header = %q, want %q", got, seenTrace)
+	}
+	if got := rec.Header().Get("X-Correlation-ID"); got != "ticket-42" {
+		t.Errorf("X-Correlation-ID response header = %q, want ticket-42", got)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["trace_id"] != seenTrace || body["correlation_id"] != "ticket-42" || body["result"] != "ok" {
+		t.Errorf("body = %v, want trace_id, correlation_id and result", body)
+	}
+}
+
+func TestWithTraceEcho_KeepsClientTraceID(t *testing.T) {
+	h := withTraceEcho("POST /api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"text": "hi"})
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	req.Header.Set("X-Trace-ID", "tr_flj_abc")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if got := rec.Header().Get("X-Trace-ID"); got != "tr_flj_abc" {
+		t.Errorf("X-Trace-ID = %q, want the client's tr_flj_abc", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"trace_id":"tr_flj_abc"`) {
+		t.Errorf("body %q lacks the client's trace_id", rec.Body.String())
+	}
+}
+
+func TestWithTraceEcho_RejectsBadCorrelationID(t *testing.T) {
+	called := false
+	h := withTraceEcho("POST /api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	req.Header.Set("X-Correlation-ID", "has spaces")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if called {
+		t.Error("handler ran despite an invalid correlation ID")
+	}
+}
+
+func TestWithTraceEcho_SkipsNonAPIRoutes(t *testing.T) {
+	h := withTraceEcho("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if got := rec.Header().Get("X-Trace-ID"); got != "" {
+		t.Errorf("X-Trace-ID = %q on /health, want none", got)
+	}
+}
+
+func TestWithTraceEcho_TraceParent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var seen string
+	h := withTraceEcho("POST /api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		seen = audit.TraceParentFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	req.Header.Set("traceparent", tp)
+	h(httptest.NewRecorder(), req)
+	if seen != tp {
+		t.Errorf("traceparent in context = %q, want %q", seen, tp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	req.Header.Set("traceparent", "00-bogus")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if seen != "" {
+		t.Errorf("malformed traceparent reached the context: %q", seen)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d for a malformed traceparent, want 200 (ignored)", rec.Code)
+	}
+}
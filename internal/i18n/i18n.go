@@ -0,0 +1,266 @@
+// Package i18n localizes the text people read in notifications and reports:
+// approval emails and texts, auditor alerts and the govbot summary.
+//
+// Messages live in catalogs, one JSON object per locale that maps message
+// keys to text with {name} placeholders:
+//
+//	{"alert.email.subject": "[AUDIT {level}] {message}"}
+//
+// English, German and Spanish catalogs are built in. A catalog directory
+// (HELPDESK_LOCALE_DIR) adds locales, one <locale>.json file each, or
+// overrides single messages of a built-in locale. A message missing from a
+// locale falls back to its base language ("de-ch" → "de"), then to English.
+// Locales without any catalog get the default locale.
+//
+// Recipients get the locale their pattern names (see Recipients), so one
+// deployment can page two regions in their own languages.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed catalogs/*.json
+var builtinFS embed.FS
+
+// Fallback is the locale every message exists in.
+const Fallback = "en"
+
+// Vars are the values of a message's {name} placeholders.
+type Vars map[string]any
+
+// Bundle holds the catalogs of every known locale. A nil *Bundle uses the
+// built-in catalogs with English as the default locale.
+type Bundle struct {
+	catalogs      map[string]map[string]string
+	defaultLocale string
+}
+
+// builtin is the bundle of built-in catalogs.
+var builtin = sync.OnceValue(func() *Bundle {
+	b := &Bundle{catalogs: map[string]map[string]string{}, defaultLocale: Fallback}
+	entries, err := builtinFS.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		data, err := builtinFS.ReadFile("catalogs/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := b.add(strings.TrimSuffix(e.Name(), ".json"), data); err != nil {
+			panic(fmt.Sprintf("built-in catalog %s: %v", e.Name(), err))
+		}
+	}
+	return b
+})
+
+// Load returns the built-in catalogs plus those in dir (none when dir is
+// empty), with defaultLocale used for recipients without one.
+func Load(dir, defaultLocale string) (*Bundle, error) {
+	b := &Bundle{catalogs: map[string]map[string]string{}, defaultLocale: Normalize(defaultLocale)}
+	if b.defaultLocale == "" {
+		b.defaultLocale = Fallback
+	}
+	for locale, msgs := range builtin().catalogs {
+		cp := make(map[string]string, len(msgs))
+		for k, v := range msgs {
+			cp[k] = v
+		}
+		b.catalogs[locale] = cp
+	}
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no <locale>.json catalogs in %s", dir)
+		}
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := b.add(strings.TrimSuffix(filepath.Base(f), ".json"), data); err != nil {
+				return nil, fmt.Errorf("%s: %w", f, err)
+			}
+		}
+	}
+	if _, ok := b.catalogs[b.defaultLocale]; !ok {
+		if _, ok := b.catalogs[baseLanguage(b.defaultLocale)]; !ok {
+			return nil, fmt.Errorf("no catalog for default locale %q (have %s)", defaultLocale, strings.Join(b.Locales(), ", "))
+		}
+	}
+	return b, nil
+}
+
+// add merges a catalog file into the bundle.
+func (b *Bundle) add(locale string, data []byte) error {
+	var msgs map[string]string
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return err
+	}
+	locale = Normalize(locale)
+	if b.catalogs[locale] == nil {
+		b.catalogs[locale] = map[string]string{}
+	}
+	for k, v := range msgs {
+		b.catalogs[locale][k] = v
+	}
+	return nil
+}
+
+func (b *Bundle) orBuiltin() *Bundle {
+	if b == nil {
+		return builtin()
+	}
+	return b
+}
+
+// Default returns the locale used for recipients without one.
+func (b *Bundle) Default() string { return b.orBuiltin().defaultLocale }
+
+// Locales returns the locales with a catalog, sorted.
+func (b *Bundle) Locales() []string {
+	b = b.orBuiltin()
+	out := make([]string, 0, len(b.catalogs))
+	for l := range b.catalogs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// T returns message key in locale with vars filled in. A key no catalog
+// holds is returned as is.
+func (b *Bundle) T(locale, key string, vars Vars) string {
+	return b.Text(locale, key, key, vars)
+}
+
+// Text is T with fallback used when no catalog holds key. Callers whose
+// English text is built in code (e.g. alert messages) pass it as fallback
+// and let catalogs translate it.
+func (b *Bundle) Text(locale, key, fallback string, vars Vars) string {
+	b = b.orBuiltin()
+	msg := fallback
+	for _, l := range b.chain(locale) {
+		if m, ok := b.catalogs[l][key]; ok {
+			msg = m
+			break
+		}
+	}
+	return fill(msg, vars)
+}
+
+// chain returns the catalogs a message in locale is looked up in. Locales
+// without a catalog, even for their base language, use the default locale.
+func (b *Bundle) chain(locale string) []string {
+	locale = Normalize(locale)
+	lang := baseLanguage(locale)
+	if b.catalogs[locale] == nil && b.catalogs[lang] == nil {
+		locale, lang = b.defaultLocale, baseLanguage(b.defaultLocale)
+	}
+	return []string{locale, lang, Fallback}
+}
+
+// fill replaces {name} placeholders with vars. Unknown placeholders are
+// left as they are.
+func fill(msg string, vars Vars) string {
+	if len(vars) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Normalize returns locale in the form catalogs are keyed by: "de_CH.UTF-8"
+// becomes "de-ch".
+func Normalize(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func baseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
+
+// Recipients assigns locales to notification recipients: email addresses,
+// phone numbers or webhook names. Rules are tried in order; the first whose
+// glob pattern matches (case-insensitively) wins.
+type Recipients []recipientRule
+
+type recipientRule struct {
+	pattern string
+	locale  string
+}
+
+// ParseRecipients parses "pattern=locale,..." such as
+// "*@emea.example.com=de,+34*=es,ana@example.com=es".
+func ParseRecipients(s string) (Recipients, error) {
+	var r Recipients
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pattern, locale, ok := strings.Cut(part, "=")
+		pattern, locale = strings.ToLower(strings.TrimSpace(pattern)), Normalize(locale)
+		if !ok || pattern == "" || locale == "" {
+			return nil, fmt.Errorf("invalid recipient locale %q: expected pattern=locale", part)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid recipient pattern %q: %w", pattern, err)
+		}
+		r = append(r, recipientRule{pattern: pattern, locale: locale})
+	}
+	return r, nil
+}
+
+// Locale returns the locale of recipient, or fallback when no rule matches.
+func (r Recipients) Locale(recipient, fallback string) string {
+	recipient = strings.ToLower(strings.TrimSpace(recipient))
+	for _, rule := range r {
+		if ok, _ := path.Match(rule.pattern, recipient); ok {
+			return rule.locale
+		}
+	}
+	return fallback
+}
+
+// Group is the recipients that share a locale.
+type Group struct {
+	Locale string
+	To     []string
+}
+
+// Group splits recipients by locale, in order of first appearance, so each
+// locale gets one message.
+func (r Recipients) Group(recipients []string, fallback string) []Group {
+	var groups []Group
+	index := map[string]int{}
+	for _, to := range recipients {
+		l := r.Locale(to, fallback)
+		i, ok := index[l]
+		if !ok {
+			i = len(groups)
+			index[l] = i
+			groups = append(groups, Group{Locale: l})
+		}
+		groups[i].To = append(groups[i].To, to)
+	}
+	return groups
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestText_Fallbacks(t *testing.T) {
+	var b *Bundle // built-in catalogs
+	vars := Vars{"approval_id": "apr_1", "action": "destructive", "tool": "delete_pod"}
+
+	if got := b.T("de_CH.UTF-8", "approval.email.created.subject", vars); got != "[GENEHMIGUNG ERFORDERLICH] destructive - delete_pod" {
+		t.Errorf("de-ch via base language = %q", got)
+	}
+	if got := b.T("fr", "approval.email.created.subject", vars); got != "[APPROVAL REQUIRED] destructive - delete_pod" {
+		t.Errorf("unknown locale = %q", got)
+	}
+	if got := b.Text("en", "alert.rule.high_volume", "High volume", nil); got != "High volume" {
+		t.Errorf("English rule message = %q, want the code's fallback", got)
+	}
+	if got := b.T("en", "no.such.key", nil); got != "no.such.key" {
+		t.Errorf("missing key = %q", got)
+	}
+	// Placeholders without a value, and JSON braces, are left alone.
+	if got := b.T("en", "approval.email.links.curl", Vars{"base_url": "http://auditd", "approval_id": "apr_1"}); !strings.Contains(got, `"http://auditd/v1/approvals/apr_1/approve"`) ||
+		!strings.Contains(got, `-d '{"approved_by":"email_user"`) {
+		t.Errorf("curl links = %q", got)
+	}
+}
+
+// TestCatalogs_Complete verifies that every built-in locale translates every
+// English message and keeps its placeholders.
+func TestCatalogs_Complete(t *testing.T) {
+	b := builtin()
+	en := b.catalogs[Fallback]
+	for _, l := range b.Locales() {
+		for key, msg := range en {
+			got, ok := b.catalogs[l][key]
+			if !ok {
+				t.Errorf("%s: missing %s", l, key)
+				continue
+			}
+			for _, p := range placeholders(msg) {
+				if !strings.Contains(got, p) {
+					t.Errorf("%s: %s lacks %s", l, key, p)
+				}
+			}
+		}
+	}
+}
+
+func placeholders(msg string) []string {
+	var out []string
+	for {
+		i := strings.Index(msg, "{")
+		if i < 0 {
+			return out
+		}
+		j := strings.Index(msg[i:], "}")
+		if j < 0 {
+			return out
+		}
+		if p := msg[i : i+j+1]; !strings.ContainsAny(p, `":`) {
+			out = append(out, p)
+		}
+		msg = msg[i+j+1:]
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "pt-BR.json"), []byte(`{"alert.details.none": "(nenhum)"}`), 0o644) //nolint:errcheck
+	os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"label.reason": "Grund"}`), 0o644)             //nolint:errcheck
+
+	b, err := Load(dir, "pt_BR")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if b.Default() != "pt-br" {
+		t.Errorf("Default = %q", b.Default())
+	}
+	if got := b.T("fr", "alert.details.none", nil); got != "(nenhum)" {
+		t.Errorf("locale without a catalog = %q, want the default's", got)
+	}
+	if got := b.Text("en", "alert.rule.high_volume", "High volume", nil); got != "High volume" {
+		t.Errorf("English under another default = %q", got)
+	}
+	if got := b.T("", "alert.details.none", nil); got != "(nenhum)" {
+		t.Errorf("added locale = %q", got)
+	}
+	if got := b.T("pt-br", "label.action", nil); got != "Action" {
+		t.Errorf("message missing from added locale = %q, want English", got)
+	}
+	if got := b.T("de", "label.reason", nil); got != "Grund" {
+		t.Errorf("overridden message = %q", got)
+	}
+	if got := b.T("de", "label.action", nil); got != "Aktion" {
+		t.Errorf("built-in message next to an override = %q", got)
+	}
+	if builtin().catalogs["de"]["label.reason"] != "Begründung" {
+		t.Error("Load changed the built-in catalogs")
+	}
+
+	if _, err := Load("", "fr"); err == nil {
+		t.Error("Load accepted a default locale without a catalog")
+	}
+	if _, err := Load(t.TempDir(), "en"); err == nil {
+		t.Error("Load accepted a directory without catalogs")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	r, err := ParseRecipients(" *@emea.example.com=de , +34*=es_ES, ana@example.com=es")
+	if err != nil {
+		t.Fatalf("ParseRecipients: %v", err)
+	}
+	for to, want := range map[string]string{
+		"Ops@EMEA.example.com": "de",
+		"+34600000000":         "es-es",
+		"ana@example.com":      "es",
+		"bob@example.com":      "en",
+	} {
+		if got := r.Locale(to, "en"); got != want {
+			t.Errorf("Locale(%q) = %q, want %q", to, got, want)
+		}
+	}
+
+	groups := r.Group([]string{"a@emea.example.com", "bob@example.com", "b@emea.example.com"}, "en")
+	if len(groups) != 2 || groups[0].Locale != "de" || len(groups[0].To) != 2 || groups[1].To[0] != "bob@example.com" {
+		t.Errorf("Group = %+v", groups)
+	}
+
+	for _, bad := range []string{"de", "*@x=", "[=de"} {
+		if _, err := ParseRecipients(bad); err == nil {
+			t.Errorf("ParseRecipients(%q) accepted", bad)
+		}
+	}
+}
@@ -10,13 +10,16 @@ type UsersConfig struct {
 
 // UserEntry defines a human user and their roles.
 type UserEntry struct {
-	ID    string   `yaml:"id"`    // e.g., alice@example.com
-	Roles []string `yaml:"roles"` // e.g., [dba, sre]
+	ID     string   `yaml:"id"`               // e.g., alice@example.com
+	Roles  []string `yaml:"roles"`            // e.g., [dba, sre]
+	Tenant string   `yaml:"tenant,omitempty"` // e.g., payments; empty = not tenant-bound
+	Phone  string   `yaml:"phone,omitempty"`  // E.164, e.g., +15550100200; lets the user answer approvals by SMS
 }
 
 // ServiceAccount defines an automated service account.
 type ServiceAccount struct {
-	ID         string   `yaml:"id"`           // e.g., srebot
-	Roles      []string `yaml:"roles"`        // e.g., [sre-automation]
-	APIKeyHash string   `yaml:"api_key_hash"` // Argon2id hash of the API key
+	ID         string   `yaml:"id"`               // e.g., srebot
+	Roles      []string `yaml:"roles"`            // e.g., [sre-automation]
+	APIKeyHash string   `yaml:"api_key_hash"`     // Argon2id hash of the API key
+	Tenant     string   `yaml:"tenant,omitempty"` // empty = shared service; may act for any tenant
 }
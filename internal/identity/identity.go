@@ -31,7 +31,8 @@ type ResolvedPrincipal struct {
 	Service string `json:"service,omitempty"`
 
 	// AuthMethod records how identity was established.
-	// One of: "api_key", "jwt", "header" (legacy no-auth).
+	// One of: "api_key", "jwt", "static", "phone" (SMS sender number matched
+	// against users.yaml), "header" (legacy no-auth).
 	AuthMethod string `json:"auth_method,omitempty"`
 
 	// OperatorID is the verified human operator acting through a service account.
@@ -39,6 +40,11 @@ type ResolvedPrincipal struct {
 	// HasRole and EffectiveID both reflect the operator identity when set.
 	OperatorID    string   `json:"operator_id,omitempty"`
 	OperatorRoles []string `json:"operator_roles,omitempty"`
+
+	// Tenant is the team or business unit the principal belongs to. Audit events,
+	// approvals and tenant policy overrides are scoped by it. Empty means the
+	// principal is not bound to a tenant (platform operators, shared services).
+	Tenant string `json:"tenant,omitempty"`
 }
 
 // IsAnonymous returns true when identity was not verified
@@ -76,3 +82,13 @@ func (p ResolvedPrincipal) EffectiveID() string {
 	}
 	return p.UserID
 }
+
+// TenantScope returns the tenant a query made by p must be restricted to.
+// A principal bound to a tenant is always confined to it, whatever it asked
+// for; an unbound principal may select any tenant (or "" for all).
+func (p ResolvedPrincipal) TenantScope(requested string) string {
+	if p.Tenant != "" {
+		return p.Tenant
+	}
+	return requested
+}
@@ -269,6 +269,106 @@ service_accounts:
 	}
 }
 
+func TestStaticProvider_Tenant(t *testing.T) {
+	apiKey := "tenant-bound-key"
+	hash := makeArgon2idHash(t, apiKey)
+
+	yaml := fmt.Sprintf(`
+users:
+  - id: alice@acme.com
+    roles: [dba]
+    tenant: acme
+  - id: root@example.com
+    roles: [admin]
+service_accounts:
+  - id: acme-bot
+    roles: [sre-automation]
+    tenant: acme
+    api_key_hash: "%s"
+`, hash)
+
+	path := writeTempUsersYAML(t, yaml)
+	p, err := NewStaticProvider(path)
+	if err != nil {
+		t.Fatalf("NewStaticProvider: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name, user, bearer, want string
+	}{
+		{"tenant user", "alice@acme.com", "", "acme"},
+		{"platform user", "root@example.com", "", ""},
+		{"tenant service account", "", apiKey, "acme"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.user != "" {
+				r.Header.Set("X-User", tc.user)
+			}
+			if tc.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.bearer)
+			}
+			principal, err := p.Resolve(r)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if principal.Tenant != tc.want {
+				t.Errorf("Tenant = %q, want %q", principal.Tenant, tc.want)
+			}
+		})
+	}
+}
+
+func TestStaticProvider_UserByPhone(t *testing.T) {
+	path := writeTempUsersYAML(t, `
+users:
+  - id: alice@acme.com
+    roles: [dba]
+    tenant: acme
+    phone: "+1 (555) 010-0200"
+  - id: bob@example.com
+    roles: [operator]
+`)
+	p, err := NewStaticProvider(path)
+	if err != nil {
+		t.Fatalf("NewStaticProvider: %v", err)
+	}
+	principal, ok := p.UserByPhone("whatsapp:+15550100200")
+	if !ok || principal.UserID != "alice@acme.com" || !principal.HasRole("dba") || principal.Tenant != "acme" || principal.IsAnonymous() {
+		t.Errorf("UserByPhone = %+v, %v", principal, ok)
+	}
+	if _, ok := p.UserByPhone("+15550100299"); ok {
+		t.Error("unregistered number resolved to a user")
+	}
+
+	dup := writeTempUsersYAML(t, `
+users:
+  - id: alice@acme.com
+    roles: [dba]
+    phone: "+15550100200"
+  - id: mallory@example.com
+    roles: [dba]
+    phone: "+1 555 010 0200"
+`)
+	if _, err := NewStaticProvider(dup); err == nil {
+		t.Error("expected an error for a phone number registered twice")
+	}
+}
+
+func TestResolvedPrincipal_TenantScope(t *testing.T) {
+	bound := ResolvedPrincipal{UserID: "alice", Tenant: "acme"}
+	if got := bound.TenantScope("globex"); got != "acme" {
+		t.Errorf("bound TenantScope(globex) = %q, want acme", got)
+	}
+	unbound := ResolvedPrincipal{UserID: "root"}
+	if got := unbound.TenantScope("globex"); got != "globex" {
+		t.Errorf("unbound TenantScope(globex) = %q, want globex", got)
+	}
+	if got := unbound.TenantScope(""); got != "" {
+		t.Errorf("unbound TenantScope(\"\") = %q, want empty", got)
+	}
+}
+
 func TestStaticProvider_ServiceAccount_InvalidKey(t *testing.T) {
 	apiKey := "super-secret-key-for-tests"
 	hash := makeArgon2idHash(t, apiKey)
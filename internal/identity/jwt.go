@@ -20,11 +20,12 @@ import (
 
 // JWTConfig configures the JWT identity provider.
 type JWTConfig struct {
-	JWKSUrl    string        // e.g., "https://idp.example.com/.well-known/jwks.json"
-	Issuer     string        // Expected iss claim value
-	Audience   string        // Expected aud claim value (optional)
-	RolesClaim string        // JWT claim containing role list (default: "groups")
-	CacheTTL   time.Duration // How long to cache JWKS keys (default: 5m)
+	JWKSUrl     string        // e.g., "https://idp.example.com/.well-known/jwks.json"
+	Issuer      string        // Expected iss claim value
+	Audience    string        // Expected aud claim value (optional)
+	RolesClaim  string        // JWT claim containing role list (default: "groups")
+	TenantClaim string        // JWT claim containing the tenant ID (default: "tenant")
+	CacheTTL    time.Duration // How long to cache JWKS keys (default: 5m)
 }
 
 // JWTProvider validates JWTs against a JWKS endpoint and extracts principal identity.
@@ -40,6 +41,9 @@ func NewJWTProvider(cfg JWTConfig) *JWTProvider {
 	if cfg.RolesClaim == "" {
 		cfg.RolesClaim = "groups"
 	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant"
+	}
 	if cfg.CacheTTL == 0 {
 		cfg.CacheTTL = 5 * time.Minute
 	}
@@ -77,11 +81,13 @@ func (p *JWTProvider) Resolve(r *http.Request) (ResolvedPrincipal, error) {
 	}
 
 	roles := p.extractRoles(claims)
+	tenant, _ := claims[p.cfg.TenantClaim].(string)
 
 	return ResolvedPrincipal{
 		UserID:     sub,
 		Roles:      roles,
 		AuthMethod: "jwt",
+		Tenant:     tenant,
 	}, nil
 }
 
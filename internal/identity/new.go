@@ -31,11 +31,12 @@ func NewFromEnv() (Provider, error) {
 			}
 		}
 		return NewJWTProvider(JWTConfig{
-			JWKSUrl:    jwksURL,
-			Issuer:     os.Getenv("HELPDESK_JWT_ISSUER"),
-			Audience:   os.Getenv("HELPDESK_JWT_AUDIENCE"),
-			RolesClaim: os.Getenv("HELPDESK_JWT_ROLES_CLAIM"),
-			CacheTTL:   cacheTTL,
+			JWKSUrl:     jwksURL,
+			Issuer:      os.Getenv("HELPDESK_JWT_ISSUER"),
+			Audience:    os.Getenv("HELPDESK_JWT_AUDIENCE"),
+			RolesClaim:  os.Getenv("HELPDESK_JWT_ROLES_CLAIM"),
+			TenantClaim: os.Getenv("HELPDESK_JWT_TENANT_CLAIM"),
+			CacheTTL:    cacheTTL,
 		}), nil
 	default:
 		return nil, fmt.Errorf("identity: unknown provider mode %q (valid: none, static, jwt)", mode)
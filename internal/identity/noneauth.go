@@ -9,12 +9,13 @@ import "net/http"
 // All resolved principals have AuthMethod="header" and no roles.
 type NoAuthProvider struct{}
 
-// Resolve reads the X-User header and returns it as an unverified principal.
-// Never returns an error.
+// Resolve reads the X-User and X-Tenant headers and returns them as an
+// unverified principal. Never returns an error.
 func (p *NoAuthProvider) Resolve(r *http.Request) (ResolvedPrincipal, error) {
 	userID := r.Header.Get("X-User")
 	return ResolvedPrincipal{
 		UserID:     userID,
 		AuthMethod: "header",
+		Tenant:     r.Header.Get("X-Tenant"),
 	}, nil
 }
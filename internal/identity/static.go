@@ -12,6 +12,8 @@ import (
 
 	"golang.org/x/crypto/argon2"
 	"gopkg.in/yaml.v3"
+
+	"helpdesk/internal/sms"
 )
 
 // StaticProvider resolves identity from a users.yaml config file.
@@ -24,6 +26,10 @@ type StaticProvider struct {
 	serviceAccounts map[string]ServiceAccount
 	// aliases maps alias role names to their canonical equivalents
 	aliases map[string]string
+	// tenants maps user ID → tenant (only users bound to a tenant appear)
+	tenants map[string]string
+	// phones maps a normalized phone number → user ID
+	phones map[string]string
 }
 
 // NewStaticProvider loads the users config from the given YAML file path.
@@ -42,9 +48,21 @@ func NewStaticProvider(path string) (*StaticProvider, error) {
 		users:           make(map[string][]string, len(cfg.Users)),
 		serviceAccounts: make(map[string]ServiceAccount, len(cfg.ServiceAccounts)),
 		aliases:         make(map[string]string, len(cfg.RoleAliases)),
+		tenants:         make(map[string]string),
+		phones:          make(map[string]string),
 	}
 	for _, u := range cfg.Users {
 		p.users[u.ID] = u.Roles
+		if u.Tenant != "" {
+			p.tenants[u.ID] = u.Tenant
+		}
+		if u.Phone != "" {
+			phone := sms.NormalizeNumber(u.Phone)
+			if other, dup := p.phones[phone]; dup {
+				return nil, fmt.Errorf("identity: phone %s is registered to both %q and %q", u.Phone, other, u.ID)
+			}
+			p.phones[phone] = u.ID
+		}
 	}
 	for _, sa := range cfg.ServiceAccounts {
 		p.serviceAccounts[sa.ID] = sa
@@ -82,6 +100,21 @@ func (p *StaticProvider) RoleAliases() map[string]string {
 	return result
 }
 
+// UserByPhone returns the user whose registered phone number is phone, for
+// channels (SMS, WhatsApp) where the sender's number is the only identity.
+func (p *StaticProvider) UserByPhone(phone string) (ResolvedPrincipal, bool) {
+	userID, ok := p.phones[sms.NormalizeNumber(phone)]
+	if !ok {
+		return ResolvedPrincipal{}, false
+	}
+	return ResolvedPrincipal{
+		UserID:     userID,
+		Roles:      p.expandRoles(p.users[userID]),
+		AuthMethod: "phone",
+		Tenant:     p.tenants[userID],
+	}, true
+}
+
 // Resolve authenticates the request.
 // Service accounts use Authorization: Bearer <api-key>.
 // Human users use X-User: <email> (must be listed in users.yaml).
@@ -102,6 +135,11 @@ func (p *StaticProvider) Resolve(r *http.Request) (ResolvedPrincipal, error) {
 			if roles, ok := p.users[userID]; ok {
 				principal.OperatorID = userID
 				principal.OperatorRoles = p.expandRoles(roles)
+				// A shared service account acting for a tenant-bound operator
+				// takes on the operator's tenant.
+				if principal.Tenant == "" {
+					principal.Tenant = p.tenants[userID]
+				}
 			}
 		}
 		return principal, nil
@@ -124,6 +162,7 @@ func (p *StaticProvider) Resolve(r *http.Request) (ResolvedPrincipal, error) {
 		UserID:     userID,
 		Roles:      p.expandRoles(roles),
 		AuthMethod: "static",
+		Tenant:     p.tenants[userID],
 	}, nil
 }
 
@@ -140,6 +179,7 @@ func (p *StaticProvider) resolveServiceAccount(apiKey string) (ResolvedPrincipal
 				Service:    id,
 				Roles:      p.expandRoles(sa.Roles),
 				AuthMethod: "api_key",
+				Tenant:     sa.Tenant,
 			}, nil
 		}
 	}
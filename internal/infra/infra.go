@@ -4,7 +4,9 @@ package infra
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -13,6 +15,7 @@ type DBServer struct {
 	Name             string   `json:"name"`
 	ConnectionString string   `json:"connection_string"`
 	PasswordEnv      string   `json:"password_env,omitempty"`   // env var holding the password; appended at runtime
+	Credential       string   `json:"credential,omitempty"`     // alias into Config.Credentials; resolved at execution time
 	K8sCluster       string   `json:"k8s_cluster,omitempty"`
 	K8sNamespace     string   `json:"k8s_namespace,omitempty"`
 	K8sPodSelector   string   `json:"k8s_pod_selector,omitempty"` // label selector for kubectl exec (e.g. "app=postgres,instance=prod")
@@ -22,6 +25,54 @@ type DBServer struct {
 	Tags                 []string `json:"tags,omitempty"`                   // Tags for policy matching (e.g., "production", "staging")
 	Sensitivity          []string `json:"sensitivity,omitempty"`            // Sensitivity classes (e.g., "pii", "critical")
 	ApprovalOverrideRoles []string `json:"approval_override_roles,omitempty"` // Roles allowed to request a less restrictive approval_mode than the playbook declares. Empty = unrestricted.
+	Patroni              *PatroniAPI `json:"patroni,omitempty"`                // Patroni REST API when the server is a Patroni HA cluster
+	Backup               *BackupRepo `json:"backup,omitempty"`                 // backup repository, for backup health checks
+	Ownership                         // owner, team and contact; inherited from the VM or cluster when unset
+}
+
+// Ownership names who answers for a resource. Its fields sit directly on the
+// database, cluster or VM in the config file. Approval requests and critical
+// alerts about the resource go to the channel of its team (Config.Teams)
+// instead of the global webhook.
+type Ownership struct {
+	Owner   string `json:"owner,omitempty"`   // accountable person, e.g. an email address
+	Team    string `json:"team,omitempty"`    // owning team, a key into Config.Teams
+	Contact string `json:"contact,omitempty"` // how to reach the owner, e.g. a pager handle or channel name
+}
+
+// IsZero reports whether no ownership field is set.
+func (o Ownership) IsZero() bool { return o == Ownership{} }
+
+// Team is a team that owns resources.
+type Team struct {
+	WebhookURL string `json:"webhook_url,omitempty"` // Slack or generic webhook for the team's approval requests and critical alerts
+	Contact    string `json:"contact,omitempty"`     // e.g. the team's email list or on-call rotation
+}
+
+// Backup tools the database agent can read a backup catalog with.
+const (
+	BackupToolPgBackRest = "pgbackrest"
+	BackupToolWALG       = "wal-g"
+)
+
+// BackupRepo names the backup tool and repository of a database. The database
+// agent runs the tool's read-only info commands itself, so the agent host needs
+// the tool installed and configured with access to the repository.
+type BackupRepo struct {
+	Tool        string `json:"tool"`                    // BackupToolPgBackRest or BackupToolWALG
+	Stanza      string `json:"stanza,omitempty"`        // pgBackRest stanza; required for pgbackrest
+	ConfigFile  string `json:"config_file,omitempty"`   // tool config file on the agent host; tool default when empty
+	MaxAgeHours int    `json:"max_age_hours,omitempty"` // age above which the last backup is stale (default 26)
+}
+
+// PatroniAPI locates the Patroni REST API of a database's HA cluster. Any
+// member's API serves the whole cluster. Username and Credential are the
+// restapi.authentication that Patroni requires for switchover and
+// configuration changes; reads need none.
+type PatroniAPI struct {
+	URL        string `json:"url"`                  // e.g. "http://pg-1:8008"
+	Username   string `json:"username,omitempty"`
+	Credential string `json:"credential,omitempty"` // alias into Config.Credentials holding the REST API password
 }
 
 // ResolvedConnectionString returns ConnectionString with the password appended when
@@ -42,16 +93,29 @@ func (db DBServer) ResolvedConnectionString() string {
 type K8sCluster struct {
 	Name        string   `json:"name"`
 	Context     string   `json:"context"`
-	Tags        []string `json:"tags,omitempty"`        // Tags for policy matching (e.g., "production", "staging")
+	Kubeconfig  string   `json:"kubeconfig,omitempty"`  // kubeconfig file holding Context; empty = KUBECONFIG or ~/.kube/config
+	Tags        []string `json:"tags,omitempty"`        // Tags for policy matching (e.g., "production", "staging"), inherited by its namespaces and databases
 	Sensitivity []string `json:"sensitivity,omitempty"` // Sensitivity classes (e.g., "critical")
+	// Namespaces allowlists the namespaces the k8s agent may target, keyed by
+	// namespace name. Empty = any namespace. Namespaces of databases hosted on
+	// the cluster are always allowed.
+	Namespaces map[string]K8sNamespace `json:"namespaces,omitempty"`
+	Ownership
+}
+
+// K8sNamespace is an allowlisted namespace of a K8sCluster.
+type K8sNamespace struct {
+	Tags []string `json:"tags,omitempty"` // Tags for policy matching, added to the cluster's tags
 }
 
 // VM represents a physical or virtual machine hosting one or more database services.
 // It is the operational unit for the sysadmin agent.
 type VM struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`          // hostname or IP address
-	Runtime string `json:"runtime,omitempty"` // container runtime: "docker", "podman", or "" (systemd/direct)
+	Name    string   `json:"name"`
+	Address string   `json:"address"`          // hostname or IP address
+	Runtime string   `json:"runtime,omitempty"` // container runtime: "docker", "podman", or "" (systemd/direct)
+	Tags    []string `json:"tags,omitempty"`    // Tags for policy matching, inherited by the databases it hosts
+	Ownership
 }
 
 // Config holds the infrastructure inventory.
@@ -59,6 +123,8 @@ type Config struct {
 	DBServers   map[string]DBServer   `json:"db_servers"`
 	K8sClusters map[string]K8sCluster `json:"k8s_clusters"`
 	VMs         map[string]VM         `json:"vms"`
+	Credentials map[string]Credential `json:"credentials,omitempty"` // password sources referenced by DBServer.Credential
+	Teams       map[string]Team       `json:"teams,omitempty"`       // teams named by Ownership.Team
 }
 
 // Load loads infrastructure configuration from a JSON file.
@@ -67,11 +133,24 @@ func Load(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read infrastructure config file: %v", err)
 	}
+	return Parse(data)
+}
 
+// Parse decodes and validates infrastructure config JSON.
+func Parse(data []byte) (*Config, error) {
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse infrastructure config: %v", err)
 	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid infrastructure config: %v", err)
+	}
+	for id, db := range config.DBServers {
+		if HasInlinePassword(db.ConnectionString) {
+			slog.Warn("connection_string contains a plain-text password; move it behind a credential alias",
+				"db_server", id)
+		}
+	}
 
 	return &config, nil
 }
@@ -192,6 +271,125 @@ func (c *Config) FindDBByConnStr(connStr string) (*DBServer, string, bool) {
 	return nil, "", false
 }
 
+// OwnerOf returns the ownership of a resource as policy checks name it: a
+// database by its name or db_servers ID, a host by its db_servers ID or VM
+// name, and Kubernetes by namespace (or cluster ID). A namespace is owned by
+// the database hosted in it, else by the cluster that allowlists it. A
+// database without ownership of its own inherits that of its VM or cluster.
+// Reports false when the resource has no owner.
+func (c *Config) OwnerOf(resourceType, name string) (Ownership, bool) {
+	if c == nil || name == "" {
+		return Ownership{}, false
+	}
+	var o Ownership
+	switch resourceType {
+	case "database", "host":
+		if db, _, ok := c.FindDBByConnStr(name); ok {
+			o = c.dbOwnership(*db)
+		} else if vm, ok := c.VMs[name]; ok && resourceType == "host" {
+			o = vm.Ownership
+		}
+	case "kubernetes":
+		if k8s, ok := c.K8sClusters[name]; ok {
+			o = k8s.Ownership
+			break
+		}
+		for _, id := range sortedMapKeys(c.DBServers) {
+			db := c.DBServers[id]
+			ns := db.K8sNamespace
+			if ns == "" {
+				ns = "default"
+			}
+			if db.K8sCluster != "" && ns == name {
+				if o = c.dbOwnership(db); !o.IsZero() {
+					return o, true
+				}
+			}
+		}
+		for _, id := range sortedMapKeys(c.K8sClusters) {
+			if _, ok := c.K8sClusters[id].Namespaces[name]; ok && !c.K8sClusters[id].IsZero() {
+				return c.K8sClusters[id].Ownership, true
+			}
+		}
+	}
+	return o, !o.IsZero()
+}
+
+// dbOwnership returns a database's ownership, or that of its VM or cluster
+// when it has none.
+func (c *Config) dbOwnership(db DBServer) Ownership {
+	switch {
+	case !db.Ownership.IsZero():
+		return db.Ownership
+	case db.VMName != "":
+		return c.VMs[db.VMName].Ownership
+	case db.K8sCluster != "":
+		return c.K8sClusters[db.K8sCluster].Ownership
+	}
+	return Ownership{}
+}
+
+// MergeTags returns the union of the tag lists, in order, without duplicates.
+func MergeTags(lists ...[]string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, t := range list {
+			if !seen[t] {
+				seen[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// NamespaceTags returns the policy tags of a namespace on the cluster: its
+// own tags, if it is allowlisted with any, plus the cluster's.
+func (k K8sCluster) NamespaceTags(namespace string) []string {
+	return MergeTags(k.Namespaces[namespace].Tags, k.Tags)
+}
+
+// DatabaseTags returns the policy tags of a database: its own tags plus
+// those it inherits from where it runs — its VM's, or its namespace's and
+// cluster's. Tags set on a VM or cluster therefore need not be repeated on
+// every database it hosts.
+func (c *Config) DatabaseTags(db DBServer) []string {
+	if c == nil {
+		return db.Tags
+	}
+	switch {
+	case db.VMName != "":
+		return MergeTags(db.Tags, c.VMs[db.VMName].Tags)
+	case db.K8sCluster != "":
+		return MergeTags(db.Tags, c.K8sClusters[db.K8sCluster].NamespaceTags(db.K8sNamespace))
+	}
+	return db.Tags
+}
+
+// TeamWebhooks returns the webhook URL of every team that has one, by team.
+func (c *Config) TeamWebhooks() map[string]string {
+	if c == nil {
+		return nil
+	}
+	out := make(map[string]string)
+	for name, t := range c.Teams {
+		if t.WebhookURL != "" {
+			out[name] = t.WebhookURL
+		}
+	}
+	return out
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Summary returns a human-readable summary of the infrastructure.
 func (c *Config) Summary() string {
 	if c == nil {
@@ -219,5 +417,30 @@ func (c *Config) Summary() string {
 		}
 	}
 
+	if len(c.K8sClusters) > 0 {
+		sb.WriteString("\nKubernetes clusters:\n")
+		ids := make([]string, 0, len(c.K8sClusters))
+		for id := range c.K8sClusters {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			k8s := c.K8sClusters[id]
+			line := fmt.Sprintf("  - %s (%s): context %s", id, k8s.Name, k8s.Context)
+			if len(k8s.Tags) > 0 {
+				line += fmt.Sprintf(" [tags: %s]", strings.Join(k8s.Tags, ", "))
+			}
+			if len(k8s.Namespaces) > 0 {
+				namespaces := make([]string, 0, len(k8s.Namespaces))
+				for ns := range k8s.Namespaces {
+					namespaces = append(namespaces, ns)
+				}
+				sort.Strings(namespaces)
+				line += fmt.Sprintf(" [namespaces: %s]", strings.Join(namespaces, ", "))
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
 	return sb.String()
 }
@@ -2,6 +2,7 @@ package infra
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -101,3 +102,92 @@ func TestFindDBByConnStr(t *testing.T) {
 		})
 	}
 }
+
+func TestOwnerOf(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+  "db_servers": {
+    "orders-db": {"name": "orders", "connection_string": "host=orders.internal dbname=orders", "team": "payments", "owner": "ana@example.com"},
+    "vm-db": {"name": "inventory", "connection_string": "host=inv.internal dbname=inv", "vm_name": "inv-vm"},
+    "pod-db": {"name": "catalog", "connection_string": "host=cat dbname=cat", "k8s_cluster": "prod", "k8s_namespace": "catalog"}
+  },
+  "k8s_clusters": {
+    "prod": {"name": "prod", "context": "prod", "team": "platform", "namespaces": {"web": {}}}
+  },
+  "vms": {
+    "inv-vm": {"name": "inv-vm", "address": "10.0.0.5", "team": "warehouse", "contact": "#warehouse-oncall"}
+  },
+  "teams": {
+    "payments": {"webhook_url": "https://hooks.example.com/payments"},
+    "platform": {}
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		resourceType, name string
+		wantTeam           string
+	}{
+		{"database", "orders", "payments"},
+		{"host", "orders-db", "payments"},
+		{"database", "inventory", "warehouse"}, // inherited from the VM
+		{"host", "inv-vm", "warehouse"},
+		{"kubernetes", "catalog", "platform"}, // database namespace, inherited from the cluster
+		{"kubernetes", "web", "platform"},     // allowlisted namespace
+		{"kubernetes", "prod", "platform"},
+		{"kubernetes", "unknown", ""},
+		{"database", "nope", ""},
+	}
+	for _, tt := range tests {
+		o, ok := cfg.OwnerOf(tt.resourceType, tt.name)
+		if o.Team != tt.wantTeam || ok != (tt.wantTeam != "") {
+			t.Errorf("OwnerOf(%s, %s) = %+v, %v; want team %q", tt.resourceType, tt.name, o, ok, tt.wantTeam)
+		}
+	}
+	if o, _ := cfg.OwnerOf("database", "orders"); o.Owner != "ana@example.com" {
+		t.Errorf("owner = %q", o.Owner)
+	}
+	if got := cfg.TeamWebhooks(); len(got) != 1 || got["payments"] != "https://hooks.example.com/payments" {
+		t.Errorf("TeamWebhooks() = %v", got)
+	}
+
+	if _, err := Parse([]byte(`{"teams": {"x": {"webhook_url": "not a url"}}}`)); err == nil {
+		t.Error("Parse accepted a team webhook that is not a URL")
+	}
+}
+
+func TestDatabaseTags(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+  "db_servers": {
+    "vm-db": {"name": "inventory", "connection_string": "host=inv dbname=inv", "vm_name": "inv-vm", "tags": ["pii", "production"]},
+    "pod-db": {"name": "catalog", "connection_string": "host=cat dbname=cat", "k8s_cluster": "prod", "k8s_namespace": "catalog"},
+    "plain-db": {"name": "scratch", "connection_string": "host=s dbname=s", "tags": ["sandbox"]}
+  },
+  "k8s_clusters": {
+    "prod": {"name": "prod", "context": "prod", "tags": ["production"], "namespaces": {"catalog": {"tags": ["tier-1"]}}}
+  },
+  "vms": {
+    "inv-vm": {"name": "inv-vm", "address": "10.0.0.5", "tags": ["production", "on-prem"]}
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		db   string
+		want string
+	}{
+		{"vm-db", "pii,production,on-prem"},
+		{"pod-db", "tier-1,production"},
+		{"plain-db", "sandbox"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(cfg.DatabaseTags(cfg.DBServers[tt.db]), ","); got != tt.want {
+			t.Errorf("DatabaseTags(%s) = %q, want %q", tt.db, got, tt.want)
+		}
+	}
+	if got := strings.Join(cfg.K8sClusters["prod"].NamespaceTags("web"), ","); got != "production" {
+		t.Errorf("NamespaceTags(web) = %q, want the cluster's tags", got)
+	}
+}
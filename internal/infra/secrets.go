@@ -0,0 +1,214 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"helpdesk/internal/secrets"
+)
+
+// Credential is a named secret source referenced from DBServer.Credential.
+// Exactly one of Env, File, Vault or Secret must be set. The secret is read
+// at execution time, so infrastructure.json, prompts and gateway payloads only
+// ever carry the alias.
+type Credential struct {
+	Env    string       `json:"env,omitempty"`    // environment variable holding the password
+	File   string       `json:"file,omitempty"`   // file holding the password (e.g. a mounted K8s Secret); surrounding whitespace is trimmed
+	Vault  *VaultSecret `json:"vault,omitempty"`  // HashiCorp Vault KV secret
+	Secret string       `json:"secret,omitempty"` // any secrets reference: vault://, awssm://, gcpsm://, file://, env://
+}
+
+// VaultSecret locates a password in HashiCorp Vault. The server address and
+// token are taken from VAULT_ADDR and VAULT_TOKEN. Both KV v1 and KV v2 read
+// paths are supported (for v2, include the "data/" segment: "secret/data/prod-db").
+type VaultSecret struct {
+	Path  string `json:"path"`
+	Field string `json:"field,omitempty"` // defaults to "password"
+}
+
+// validate checks that the credential names exactly one source.
+func (c Credential) validate() error {
+	n := 0
+	if c.Env != "" {
+		n++
+	}
+	if c.File != "" {
+		n++
+	}
+	if c.Vault != nil {
+		if c.Vault.Path == "" {
+			return fmt.Errorf("vault.path is required")
+		}
+		n++
+	}
+	if c.Secret != "" {
+		if !secrets.IsRef(c.Secret) {
+			return fmt.Errorf("secret %q is not a secrets reference (vault://, awssm://, gcpsm://, file://, env://)", c.Secret)
+		}
+		n++
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one of env, file, vault or secret must be set")
+	}
+	return nil
+}
+
+// ref returns the credential as a secrets reference.
+func (c Credential) ref() string {
+	switch {
+	case c.Env != "":
+		return "env://" + c.Env
+	case c.File != "":
+		return "file://" + c.File
+	case c.Vault != nil:
+		field := c.Vault.Field
+		if field == "" {
+			field = "password"
+		}
+		return "vault://" + strings.TrimLeft(c.Vault.Path, "/") + "#" + field
+	}
+	return c.Secret
+}
+
+// resolve reads the secret from its source through the shared secrets
+// resolver, which caches values and renews leased (dynamic) credentials.
+func (c Credential) resolve(ctx context.Context) (string, error) {
+	ref := c.ref()
+	if ref == "" {
+		return "", fmt.Errorf("credential has no source")
+	}
+	return secrets.Resolve(ctx, ref)
+}
+
+// ResolveConnectionString returns the connection string for db with its
+// password filled in. When db.Credential names an entry in c.Credentials the
+// secret is read from that source now; otherwise it falls back to PasswordEnv,
+// whose variable may itself hold a secrets reference. The result must only be
+// handed to the database client — never logged, audited or returned to a caller.
+func (c *Config) ResolveConnectionString(ctx context.Context, db DBServer) (string, error) {
+	if db.Credential == "" {
+		if db.PasswordEnv == "" {
+			return db.ConnectionString, nil
+		}
+		pw, err := secrets.Getenv(ctx, db.PasswordEnv)
+		if err != nil {
+			return "", fmt.Errorf("password_env %s: %w", db.PasswordEnv, err)
+		}
+		if pw == "" {
+			return db.ConnectionString, nil
+		}
+		return db.ConnectionString + " password=" + pw, nil
+	}
+	var cred Credential
+	ok := false
+	if c != nil {
+		cred, ok = c.Credentials[db.Credential]
+	}
+	if !ok {
+		return "", fmt.Errorf("credential %q is not defined in infrastructure config", db.Credential)
+	}
+	pw, err := cred.resolve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("credential %q: %w", db.Credential, err)
+	}
+	return db.ConnectionString + " password=" + quoteConnValue(pw), nil
+}
+
+// ResolvePatroniPassword returns the Patroni REST API password of db, read
+// from its patroni credential now. It returns "" when none is configured. Like
+// ResolveConnectionString, the result must never be logged or audited.
+func (c *Config) ResolvePatroniPassword(ctx context.Context, db DBServer) (string, error) {
+	if db.Patroni == nil || db.Patroni.Credential == "" {
+		return "", nil
+	}
+	var cred Credential
+	ok := false
+	if c != nil {
+		cred, ok = c.Credentials[db.Patroni.Credential]
+	}
+	if !ok {
+		return "", fmt.Errorf("credential %q is not defined in infrastructure config", db.Patroni.Credential)
+	}
+	pw, err := cred.resolve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("credential %q: %w", db.Patroni.Credential, err)
+	}
+	return pw, nil
+}
+
+// quoteConnValue quotes a libpq key=value value when it contains characters
+// that would otherwise end or corrupt it.
+func quoteConnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n'\\") {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
+}
+
+// HasInlinePassword reports whether a key=value connection string carries a
+// password field.
+func HasInlinePassword(connStr string) bool {
+	for _, field := range strings.Fields(connStr) {
+		if k, _, ok := strings.Cut(field, "="); ok && k == "password" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks credential references. Every DBServer.Credential must name
+// a defined credential with exactly one source, and a server that uses a
+// credential alias must not also carry a password in its connection string.
+// A Patroni API needs a URL, and its credential must be defined too. A backup
+// repository must name a supported tool, and pgBackRest a stanza. A team's
+// webhook must be an http(s) URL.
+func (c *Config) Validate() error {
+	for alias, cred := range c.Credentials {
+		if err := cred.validate(); err != nil {
+			return fmt.Errorf("credential %q: %v", alias, err)
+		}
+	}
+	for name, t := range c.Teams {
+		if t.WebhookURL == "" {
+			continue
+		}
+		if u, err := url.Parse(t.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("teams.%s: webhook_url must be an http(s) URL", name)
+		}
+	}
+	for id, db := range c.DBServers {
+		if p := db.Patroni; p != nil {
+			if p.URL == "" {
+				return fmt.Errorf("db_servers.%s: patroni.url is required", id)
+			}
+			if p.Credential != "" {
+				if _, ok := c.Credentials[p.Credential]; !ok {
+					return fmt.Errorf("db_servers.%s: patroni credential %q is not defined", id, p.Credential)
+				}
+			}
+		}
+		if b := db.Backup; b != nil {
+			switch {
+			case b.Tool != BackupToolPgBackRest && b.Tool != BackupToolWALG:
+				return fmt.Errorf("db_servers.%s: backup.tool %q is not supported (want %s or %s)", id, b.Tool, BackupToolPgBackRest, BackupToolWALG)
+			case b.Tool == BackupToolPgBackRest && b.Stanza == "":
+				return fmt.Errorf("db_servers.%s: backup.stanza is required for %s", id, BackupToolPgBackRest)
+			case b.MaxAgeHours < 0:
+				return fmt.Errorf("db_servers.%s: backup.max_age_hours must not be negative", id)
+			}
+		}
+		if db.Credential == "" {
+			continue
+		}
+		if _, ok := c.Credentials[db.Credential]; !ok {
+			return fmt.Errorf("db_servers.%s: credential %q is not defined", id, db.Credential)
+		}
+		if HasInlinePassword(db.ConnectionString) {
+			return fmt.Errorf("db_servers.%s: connection_string must not contain a password when credential is set", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package infra
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveConnectionString_EnvCredential(t *testing.T) {
+	t.Setenv("TEST_CRED_PW", "s3cret")
+	cfg := &Config{Credentials: map[string]Credential{"prod-pw": {Env: "TEST_CRED_PW"}}}
+	db := DBServer{ConnectionString: "host=prod dbname=app user=app", Credential: "prod-pw"}
+
+	got, err := cfg.ResolveConnectionString(context.Background(), db)
+	if err != nil {
+		t.Fatalf("ResolveConnectionString: %v", err)
+	}
+	if want := "host=prod dbname=app user=app password=s3cret"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveConnectionString_FileCredentialQuoted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pw")
+	if err := os.WriteFile(path, []byte("it's a pw\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Credentials: map[string]Credential{"pw": {File: path}}}
+	got, err := cfg.ResolveConnectionString(context.Background(), DBServer{ConnectionString: "host=x", Credential: "pw"})
+	if err != nil {
+		t.Fatalf("ResolveConnectionString: %v", err)
+	}
+	if want := `host=x password='it\'s a pw'`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveConnectionString_MissingSecret(t *testing.T) {
+	t.Setenv("TEST_CRED_UNSET", "")
+	cfg := &Config{Credentials: map[string]Credential{"pw": {Env: "TEST_CRED_UNSET"}}}
+	if _, err := cfg.ResolveConnectionString(context.Background(), DBServer{Credential: "pw"}); err == nil {
+		t.Fatal("want error when the env var is unset")
+	}
+	if _, err := cfg.ResolveConnectionString(context.Background(), DBServer{Credential: "nope"}); err == nil {
+		t.Fatal("want error for undefined alias")
+	}
+}
+
+func TestResolveConnectionString_VaultKV2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" || r.URL.Path != "/v1/secret/data/prod-db" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"from-vault"},"metadata":{"version":3}}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "tok")
+
+	cfg := &Config{Credentials: map[string]Credential{"pw": {Vault: &VaultSecret{Path: "secret/data/prod-db"}}}}
+	got, err := cfg.ResolveConnectionString(context.Background(), DBServer{ConnectionString: "host=p", Credential: "pw"})
+	if err != nil {
+		t.Fatalf("ResolveConnectionString: %v", err)
+	}
+	if got != "host=p password=from-vault" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestResolvePatroniPassword(t *testing.T) {
+	t.Setenv("TEST_PATRONI_PW", "rest-pw")
+	cfg := &Config{Credentials: map[string]Credential{"api": {Env: "TEST_PATRONI_PW"}}}
+
+	got, err := cfg.ResolvePatroniPassword(context.Background(), DBServer{Patroni: &PatroniAPI{URL: "http://a:8008", Credential: "api"}})
+	if err != nil || got != "rest-pw" {
+		t.Errorf("ResolvePatroniPassword = %q, %v; want rest-pw", got, err)
+	}
+	got, err = cfg.ResolvePatroniPassword(context.Background(), DBServer{Patroni: &PatroniAPI{URL: "http://a:8008"}})
+	if err != nil || got != "" {
+		t.Errorf("ResolvePatroniPassword without credential = %q, %v; want empty", got, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"ok", Config{
+			Credentials: map[string]Credential{"pw": {Env: "X"}},
+			DBServers:   map[string]DBServer{"db": {ConnectionString: "host=a", Credential: "pw"}},
+		}, ""},
+		{"undefined alias", Config{
+			DBServers: map[string]DBServer{"db": {ConnectionString: "host=a", Credential: "pw"}},
+		}, "not defined"},
+		{"two sources", Config{
+			Credentials: map[string]Credential{"pw": {Env: "X", File: "/y"}},
+		}, "exactly one"},
+		{"inline password with alias", Config{
+			Credentials: map[string]Credential{"pw": {Env: "X"}},
+			DBServers:   map[string]DBServer{"db": {ConnectionString: "host=a password=b", Credential: "pw"}},
+		}, "must not contain a password"},
+		{"patroni without url", Config{
+			DBServers: map[string]DBServer{"db": {ConnectionString: "host=a", Patroni: &PatroniAPI{Username: "patroni"}}},
+		}, "patroni.url is required"},
+		{"undefined patroni alias", Config{
+			DBServers: map[string]DBServer{"db": {ConnectionString: "host=a", Patroni: &PatroniAPI{URL: "http://a:8008", Credential: "api"}}},
+		}, "patroni credential"},
+		{"unknown backup tool", Config{
+			DBServers: map[string]DBServer{"db": {ConnectionString: "host=a", Backup: &BackupRepo{Tool: "barman"}}},
+		}, "backup.tool"},
+		{"pgbackrest without stanza", Config{
+			DBServers: map[string]DBServer{"db": {ConnectionString: "host=a", Backup: &BackupRepo{Tool: BackupToolPgBackRest}}},
+		}, "backup.stanza is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
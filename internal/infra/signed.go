@@ -0,0 +1,89 @@
+package infra
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBadSignature is returned by SignedConfig.Verify when the signature does
+// not match the payload under the trusted public key.
+var ErrBadSignature = errors.New("infrastructure config signature is invalid")
+
+// SignedConfig is an infrastructure config as distributed by auditd at
+// GET /v1/infra. Payload is the config JSON exactly as signed; both fields
+// travel base64-encoded so the bytes agents verify are the bytes auditd signed.
+type SignedConfig struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"` // Ed25519 signature of Payload
+}
+
+// Sign signs config JSON with an Ed25519 private key.
+func Sign(payload []byte, key ed25519.PrivateKey) SignedConfig {
+	return SignedConfig{Payload: payload, Signature: ed25519.Sign(key, payload)}
+}
+
+// Verify checks the signature against pub and returns the parsed, validated
+// config. Nothing from an unverified payload is parsed.
+func (s SignedConfig) Verify(pub ed25519.PublicKey) (*Config, error) {
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(pub, s.Payload, s.Signature) {
+		return nil, ErrBadSignature
+	}
+	return Parse(s.Payload)
+}
+
+// ParsePrivateKey parses an Ed25519 private key given either as PEM (PKCS #8,
+// as written by "openssl genpkey -algorithm ed25519") or as base64 of the
+// 32-byte seed or 64-byte private key.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse infra signing key: %v", err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("infra signing key is %T, want Ed25519", key)
+		}
+		return edKey, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("parse infra signing key: not PEM or base64")
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("infra signing key is %d bytes, want %d or %d", len(raw), ed25519.SeedSize, ed25519.PrivateKeySize)
+}
+
+// ParsePublicKey parses an Ed25519 public key given either as PEM (PKIX, as
+// written by "openssl pkey -pubout") or as base64 of the 32-byte key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse infra public key: %v", err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("infra public key is %T, want Ed25519", key)
+		}
+		return edKey, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("parse infra public key: not PEM or base64")
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("infra public key is %d bytes, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
@@ -0,0 +1,71 @@
+package infra
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+const testSignedPayload = `{"db_servers":{"prod-db":{"name":"Prod","connection_string":"host=prod dbname=app","tags":["production"]}}}`
+
+func TestSignedConfig_Verify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	sc := Sign([]byte(testSignedPayload), priv)
+
+	cfg, err := sc.Verify(pub)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if _, ok := cfg.DBServers["prod-db"]; !ok {
+		t.Errorf("DBServers = %v, want prod-db", cfg.DBServers)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	widened := sc
+	widened.Payload = bytes.Replace(sc.Payload, []byte(`"prod-db"`), []byte(`"prod-db","evil-db"`), 1)
+	for name, tc := range map[string]struct {
+		sc  SignedConfig
+		pub ed25519.PublicKey
+	}{
+		"other key":        {sc, otherPub},
+		"tampered payload": {widened, pub},
+		"no key":           {sc, nil},
+	} {
+		if _, err := tc.sc.Verify(tc.pub); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: err = %v, want ErrBadSignature", name, err)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pkix, _ := x509.MarshalPKIXPublicKey(pub)
+
+	for name, s := range map[string]string{
+		"pem":     string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+		"seed":    base64.StdEncoding.EncodeToString(priv.Seed()),
+		"64-byte": base64.StdEncoding.EncodeToString(priv),
+	} {
+		got, err := ParsePrivateKey(s)
+		if err != nil || !got.Equal(priv) {
+			t.Errorf("ParsePrivateKey(%s) = %v", name, err)
+		}
+	}
+	for name, s := range map[string]string{
+		"pem":    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})),
+		"base64": base64.StdEncoding.EncodeToString(pub),
+	} {
+		got, err := ParsePublicKey(s)
+		if err != nil || !got.Equal(pub) {
+			t.Errorf("ParsePublicKey(%s) = %v", name, err)
+		}
+	}
+	if _, err := ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("ParsePublicKey accepted a 5-byte key")
+	}
+}
@@ -1,11 +1,15 @@
 package logging
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"strings"
 )
 
+// level is the level InitLogging configured, kept for SetOutput.
+var level = new(slog.LevelVar)
+
 // InitLogging configures the default slog logger based on HELPDESK_LOG_LEVEL
 // env var and an optional -log-level / --log-level CLI flag (flag wins).
 // It returns args with the flag stripped so downstream flag parsers (e.g. the
@@ -43,20 +47,24 @@ func InitLogging(args []string) []string {
 		remaining = append(remaining, arg)
 	}
 
-	var level slog.Level
 	switch strings.ToLower(levelStr) {
 	case "debug":
-		level = slog.LevelDebug
+		level.Set(slog.LevelDebug)
 	case "warn", "warning":
-		level = slog.LevelWarn
+		level.Set(slog.LevelWarn)
 	case "error":
-		level = slog.LevelError
+		level.Set(slog.LevelError)
 	default: // "info" or anything unrecognised
-		level = slog.LevelInfo
+		level.Set(slog.LevelInfo)
 	}
 
-	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
-	slog.SetDefault(slog.New(handler))
+	SetOutput(os.Stderr)
 
 	return remaining
 }
+
+// SetOutput points the default slog logger at w, keeping the level
+// InitLogging configured.
+func SetOutput(w io.Writer) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+}
@@ -19,9 +19,11 @@ type AnthropicModel struct {
 	modelName string
 }
 
-// NewAnthropicModel creates a new Anthropic model client.
-func NewAnthropicModel(ctx context.Context, modelName, apiKey string) (*AnthropicModel, error) {
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
+// NewAnthropicModel creates a new Anthropic model client. opts are applied
+// after the API key, e.g. option.WithHTTPClient to route calls through a
+// custom transport.
+func NewAnthropicModel(ctx context.Context, modelName, apiKey string, opts ...option.RequestOption) (*AnthropicModel, error) {
+	client := anthropic.NewClient(append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)...)
 	return &AnthropicModel{
 		client:    client,
 		modelName: modelName,
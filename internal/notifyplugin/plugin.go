@@ -0,0 +1,105 @@
+// Package notifyplugin runs exec-based notification plugins: executables that
+// deliver alerts to systems helpdesk has no native channel for. It backs the
+// auditor's -notify-plugin and auditd's -approval-notify-plugin.
+//
+// The contract is deliberately small so a plugin can be a shell script. Each
+// notification starts the plugin once, with the notification as a single JSON
+// object on stdin and HELPDESK_NOTIFY_EVENT naming what it is. Exit status 0
+// means delivered; anything else is a failure, reported with what the plugin
+// wrote to stderr. Plugins that run past the timeout are killed.
+package notifyplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds one plugin run when Plugin.Timeout is unset.
+const DefaultTimeout = 30 * time.Second
+
+// maxStderr is how much of a failing plugin's stderr goes into its error.
+const maxStderr = 512
+
+// Plugin is one notification plugin executable.
+type Plugin struct {
+	Path    string
+	Timeout time.Duration // default DefaultTimeout
+}
+
+// New returns the plugin at path, which must be an executable file.
+func New(path string) (*Plugin, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("notify plugin: %w", err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return nil, fmt.Errorf("notify plugin %s: not an executable file", path)
+	}
+	return &Plugin{Path: path}, nil
+}
+
+// ParseList returns a plugin for each path in a comma-separated list.
+func ParseList(list string) ([]*Plugin, error) {
+	var plugins []*Plugin
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		p, err := New(path)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Name is the plugin's file name, used in logs.
+func (p *Plugin) Name() string { return filepath.Base(p.Path) }
+
+// Send runs the plugin with payload as JSON on stdin. event is passed in
+// HELPDESK_NOTIFY_EVENT, e.g. "alert" or "approval_created".
+func (p *Plugin) Send(ctx context.Context, event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(append(body, '\n'))
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "HELPDESK_NOTIFY_EVENT="+event)
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("notify plugin %s: timed out after %s", p.Name(), timeout)
+	}
+	msg := strings.TrimSpace(stderr.String())
+	if len(msg) > maxStderr {
+		msg = msg[:maxStderr] + "..."
+	}
+	if msg != "" {
+		return fmt.Errorf("notify plugin %s: %w: %s", p.Name(), err, msg)
+	}
+	return fmt.Errorf("notify plugin %s: %w", p.Name(), err)
+}
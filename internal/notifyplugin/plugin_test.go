@@ -0,0 +1,80 @@
+package notifyplugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin writes a shell script plugin to a temp dir.
+func writePlugin(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins")
+	}
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSend_DeliversJSONOnStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	p, err := New(writePlugin(t, `echo "$HELPDESK_NOTIFY_EVENT" > `+out+"\ncat >> "+out+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send(context.Background(), "alert", map[string]any{"level": "CRITICAL"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, body, _ := strings.Cut(string(got), "\n")
+	if event != "alert" {
+		t.Errorf("HELPDESK_NOTIFY_EVENT = %q, want alert", event)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(body), &payload); err != nil || payload["level"] != "CRITICAL" {
+		t.Errorf("stdin = %q (%v)", body, err)
+	}
+}
+
+func TestSend_Failures(t *testing.T) {
+	p, _ := New(writePlugin(t, "echo 'ticket API down' >&2\nexit 3\n"))
+	err := p.Send(context.Background(), "alert", map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 3: ticket API down") {
+		t.Errorf("failing plugin: %v", err)
+	}
+
+	p, _ = New(writePlugin(t, "sleep 5\n"))
+	p.Timeout = 100 * time.Millisecond
+	err = p.Send(context.Background(), "alert", map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("slow plugin: %v", err)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	a, b := writePlugin(t, "exit 0\n"), writePlugin(t, "exit 0\n")
+	plugins, err := ParseList(a + ", " + b + ",")
+	if err != nil || len(plugins) != 2 || plugins[1].Path != b {
+		t.Fatalf("ParseList = %v, %v", plugins, err)
+	}
+
+	notExec := filepath.Join(t.TempDir(), "plugin")
+	os.WriteFile(notExec, []byte("#!/bin/sh\n"), 0644)
+	if _, err := ParseList(a + "," + notExec); err == nil || !strings.Contains(err.Error(), "not an executable file") {
+		t.Errorf("non-executable plugin: %v", err)
+	}
+	if _, err := ParseList("/no/such/plugin"); err == nil {
+		t.Error("missing plugin accepted")
+	}
+}
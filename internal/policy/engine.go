@@ -79,8 +79,19 @@ func (e *Engine) Explain(req Request) DecisionTrace {
 func (e *Engine) explainEvaluate(req Request) DecisionTrace {
 	var trace DecisionTrace
 
-	for _, pol := range e.config.Policies {
+	// Tenant overrides come first in the layered list; remember how many so
+	// the trace can attribute them.
+	tenant := req.Principal.Tenant
+	nTenant := 0
+	if tenant != "" {
+		nTenant = len(e.config.Tenants[tenant].Policies)
+	}
+
+	for i, pol := range e.config.PoliciesFor(tenant) {
 		pt := PolicyTrace{PolicyName: pol.Name}
+		if i < nTenant {
+			pt.Tenant = tenant
+		}
 
 		if !pol.IsEnabled() {
 			pt.SkipReason = "disabled"
@@ -120,6 +131,17 @@ func (e *Engine) explainEvaluate(req Request) DecisionTrace {
 					continue
 				}
 			}
+			if rule.Conditions != nil && rule.Conditions.MaintenanceWindow != nil {
+				inside := req.Context.MaintenanceWindow != ""
+				if *rule.Conditions.MaintenanceWindow != inside {
+					rt.SkipReason = "maintenance_window_inactive"
+					if inside {
+						rt.SkipReason = "maintenance_window_active"
+					}
+					pt.Rules = append(pt.Rules, rt)
+					continue
+				}
+			}
 
 			// This rule matched.
 			rt.Matched = true
@@ -213,10 +235,8 @@ func (e *Engine) matchesResource(policy Policy, resource RequestResource) bool {
 			continue
 		}
 
-		if len(r.Match.Tags) > 0 {
-			if !hasAllTags(resource.Tags, r.Match.Tags) {
-				continue
-			}
+		if len(r.Match.Tags) > 0 && !r.Match.Tags.Matches(resource.Tags) {
+			continue
 		}
 
 		// Sensitivity matching: all listed classes must be present on the resource.
@@ -273,20 +293,6 @@ func resourceTagSets(pol Policy, resourceType string) [][]string {
 	return sets
 }
 
-// hasAllTags returns true if resourceTags contains all requiredTags.
-func hasAllTags(resourceTags, requiredTags []string) bool {
-	tagSet := make(map[string]bool)
-	for _, t := range resourceTags {
-		tagSet[t] = true
-	}
-	for _, t := range requiredTags {
-		if !tagSet[t] {
-			return false
-		}
-	}
-	return true
-}
-
 // applyConditionsWithTrace applies rule conditions to a decision and returns
 // a ConditionTrace for each condition checked.
 func (e *Engine) applyConditionsWithTrace(decision Decision, cond *Conditions, req Request) (Decision, []ConditionTrace) {
@@ -443,6 +449,9 @@ func logDecision(req Request, decision Decision, dryRun bool) {
 	if req.Principal.Service != "" {
 		attrs = append(attrs, "service", req.Principal.Service)
 	}
+	if req.Principal.Tenant != "" {
+		attrs = append(attrs, "tenant", req.Principal.Tenant)
+	}
 	if len(req.Resource.Sensitivity) > 0 {
 		attrs = append(attrs, "resource_sensitivity", req.Resource.Sensitivity)
 	}
@@ -1,6 +1,9 @@
 package policy
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -171,6 +174,138 @@ policies:
 	}
 }
 
+func TestScheduleTeamCondition(t *testing.T) {
+	dir := t.TempDir()
+	calendar := `
+teams:
+  dba-us:
+    timezone: America/New_York
+    hours: {mon-fri: "09-17"}
+    holidays: ["2026-02-16"]
+  dba-apac:
+    timezone: Asia/Kolkata
+    hours: {mon-fri: "09-17"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "hours.yaml"), []byte(calendar), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policyFile := filepath.Join(dir, "policies.yaml")
+	if err := os.WriteFile(policyFile, []byte(`
+version: "1"
+calendar: hours.yaml
+policies:
+  - name: changes-on-shift
+    resources:
+      - type: database
+    rules:
+      - action: write
+        effect: allow
+        conditions:
+          schedule:
+            team: "*"
+      - action: write
+        effect: deny
+        message: "No changes while no team is on shift"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(policyFile)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	engine := NewEngine(EngineConfig{PolicyConfig: cfg})
+
+	tests := []struct {
+		at   time.Time
+		want Effect
+	}{
+		{time.Date(2026, 2, 17, 15, 0, 0, 0, time.UTC), EffectAllow}, // Tue 10:00 New York
+		{time.Date(2026, 2, 17, 5, 0, 0, 0, time.UTC), EffectAllow},  // Tue 10:30 Kolkata
+		{time.Date(2026, 2, 17, 1, 0, 0, 0, time.UTC), EffectDeny},   // nobody
+		{time.Date(2026, 2, 16, 15, 0, 0, 0, time.UTC), EffectDeny},  // US holiday, after Kolkata hours
+	}
+	for _, tt := range tests {
+		req := Request{
+			Resource: RequestResource{Type: "database", Name: "test-db"},
+			Action:   ActionWrite,
+			Context:  RequestContext{Timestamp: tt.at},
+		}
+		if got := engine.Evaluate(req).Effect; got != tt.want {
+			t.Errorf("write at %s: got %q, want %q", tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := Load([]byte(`
+policies:
+  - name: p
+    resources: [{type: database}]
+    rules:
+      - action: write
+        effect: deny
+        conditions:
+          schedule: {team: dba-us}
+`)); err == nil || !strings.Contains(err.Error(), "needs a calendar") {
+		t.Errorf("schedule team without calendar: err = %v", err)
+	}
+}
+
+func TestMaintenanceWindowCondition(t *testing.T) {
+	yamlConfig := `
+version: "1"
+policies:
+  - name: business-hours-freeze
+    resources:
+      - type: database
+    rules:
+      - action: write
+        effect: deny
+        conditions:
+          maintenance_window: false
+        message: "No changes outside a maintenance window"
+      - action: destructive
+        effect: allow
+        conditions:
+          maintenance_window: true
+      - action: [write, destructive]
+        effect: require_approval
+`
+	cfg, err := Load([]byte(yamlConfig))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine := NewEngine(EngineConfig{PolicyConfig: cfg})
+
+	tests := []struct {
+		action ActionClass
+		window string
+		want   Effect
+	}{
+		{ActionWrite, "", EffectDeny},
+		{ActionWrite, "mw_1", EffectRequireApproval},
+		{ActionDestructive, "", EffectRequireApproval},
+		{ActionDestructive, "mw_1", EffectAllow},
+	}
+	for _, tt := range tests {
+		req := Request{
+			Resource: RequestResource{Type: "database", Name: "test-db"},
+			Action:   tt.action,
+			Context:  RequestContext{MaintenanceWindow: tt.window},
+		}
+		if got := engine.Evaluate(req).Effect; got != tt.want {
+			t.Errorf("%s with window %q: got %q, want %q", tt.action, tt.window, got, tt.want)
+		}
+	}
+
+	trace := engine.Explain(Request{
+		Resource: RequestResource{Type: "database", Name: "test-db"},
+		Action:   ActionWrite,
+		Context:  RequestContext{MaintenanceWindow: "mw_1"},
+	})
+	if rules := trace.PoliciesEvaluated[0].Rules; rules[0].SkipReason != "maintenance_window_active" {
+		t.Errorf("rule 0 skip reason = %q, want maintenance_window_active", rules[0].SkipReason)
+	}
+}
+
 func TestBlastRadiusLimit(t *testing.T) {
 	yamlConfig := `
 version: "1"
@@ -905,3 +1040,67 @@ policies:
 		t.Errorf("policy with tool: should not match request with no ToolName, but got policy %q", d.PolicyName)
 	}
 }
+
+func TestTenantPolicies_OverrideGlobal(t *testing.T) {
+	yaml := `
+version: "1"
+policies:
+  - name: prod-writes
+    resources:
+      - type: database
+        match:
+          tags: [production]
+    rules:
+      - action: write
+        effect: allow
+tenants:
+  acme:
+    policies:
+      - name: prod-writes
+        resources:
+          - type: database
+            match:
+              tags: [production]
+        rules:
+          - action: write
+            effect: deny
+            message: "acme freezes production writes"
+`
+	cfg, err := Load([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine := NewEngine(EngineConfig{PolicyConfig: cfg})
+
+	req := Request{
+		Resource: RequestResource{Type: "database", Name: "db", Tags: []string{"production"}},
+		Action:   ActionWrite,
+	}
+	if d := engine.Evaluate(req); d.Effect != EffectAllow {
+		t.Errorf("untenanted request: effect = %q, want allow", d.Effect)
+	}
+	req.Principal = RequestPrincipal{UserID: "alice", Tenant: "acme"}
+	if d := engine.Evaluate(req); d.Effect != EffectDeny {
+		t.Errorf("acme request: effect = %q, want deny", d.Effect)
+	}
+	req.Principal.Tenant = "globex"
+	if d := engine.Evaluate(req); d.Effect != EffectAllow {
+		t.Errorf("tenant without overrides: effect = %q, want allow", d.Effect)
+	}
+	if n := len(cfg.PoliciesFor("acme")); n != 1 {
+		t.Errorf("PoliciesFor(acme) = %d policies, want 1 (override replaces global)", n)
+	}
+}
+
+func TestTenantPolicies_EmptyTenantRejected(t *testing.T) {
+	yaml := `
+version: "1"
+policies: []
+tenants:
+  "":
+    policies: []
+`
+	if _, err := Load([]byte(yaml)); err == nil {
+		t.Error("expected error for empty tenant ID")
+	}
+}
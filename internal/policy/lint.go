@@ -0,0 +1,238 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue is a problem Lint found in a policy configuration that Load accepts
+// but that keeps part of it from ever taking effect.
+type Issue struct {
+	Tenant  string `json:"tenant,omitempty"` // set for issues in a tenant's override layer
+	Policy  string `json:"policy"`
+	Rule    int    `json:"rule"` // index into the policy's rules
+	Message string `json:"message"`
+}
+
+// String formats the issue as `policy "name" rule N: message`.
+func (i Issue) String() string {
+	s := fmt.Sprintf("policy %q rule %d: %s", i.Policy, i.Rule, i.Message)
+	if i.Tenant != "" {
+		s = fmt.Sprintf("tenant %q %s", i.Tenant, s)
+	}
+	return s
+}
+
+// Lint reports the rules of cfg that can never decide a request: rules with
+// action classes or schedules the engine never matches, and rules that are
+// unreachable because rules evaluated before them match every request they
+// would. Each layer is checked in evaluation order; a global rule overridden
+// for one tenant is not reported, since that is what overrides are for.
+func Lint(cfg *Config) []Issue {
+	issues := lintLayer("", cfg.Policies)
+	tenants := make([]string, 0, len(cfg.Tenants))
+	for tenant := range cfg.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		issues = append(issues, lintLayer(tenant, cfg.Tenants[tenant].Policies)...)
+	}
+	return issues
+}
+
+// lintLayer lints one layer of policies, sorted by priority as Load leaves
+// them.
+func lintLayer(tenant string, policies []Policy) []Issue {
+	var issues []Issue
+	for qi, q := range policies {
+		if !q.IsEnabled() {
+			continue
+		}
+		add := func(rule int, format string, args ...any) {
+			issues = append(issues, Issue{Tenant: tenant, Policy: q.Name, Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+
+		// decidedBy maps each action class to the first rule that decides
+		// every request reaching q with it.
+		decidedBy := make(map[ActionClass]string)
+		for _, p := range policies[:qi] {
+			if !p.IsEnabled() || !principalsCover(p.Principals, q.Principals) || !resourcesCover(p.Resources, q.Resources) {
+				continue
+			}
+			for ri, r := range p.Rules {
+				markDecided(decidedBy, r, fmt.Sprintf("policy %q rule %d", p.Name, ri))
+			}
+		}
+
+		for ri, r := range q.Rules {
+			for _, a := range r.Action {
+				if !validAction(a) {
+					add(ri, "action %q is not read, write or destructive; no request matches it", a)
+				}
+			}
+			if s := ruleSchedule(r); s != nil {
+				for _, msg := range scheduleProblems(s) {
+					add(ri, "%s", msg)
+				}
+			}
+			if by, ok := shadowedBy(decidedBy, r); ok {
+				add(ri, "unreachable: every %s request it matches is decided first by %s", actionList(r.Action), by)
+			}
+			markDecided(decidedBy, r, fmt.Sprintf("rule %d", ri))
+		}
+	}
+	return issues
+}
+
+// markDecided records the action classes r decides for every request it
+// sees. A scheduled rule is skipped outside its schedule, and a maintenance
+// window rule inside or outside a window, so they decide none.
+func markDecided(decidedBy map[ActionClass]string, r Rule, name string) {
+	if ruleSchedule(r) != nil || (r.Conditions != nil && r.Conditions.MaintenanceWindow != nil) {
+		return
+	}
+	for _, a := range r.Action {
+		if _, ok := decidedBy[a]; !ok && validAction(a) {
+			decidedBy[a] = name
+		}
+	}
+}
+
+// shadowedBy reports whether every action class of r is already decided,
+// and by which rules.
+func shadowedBy(decidedBy map[ActionClass]string, r Rule) (string, bool) {
+	if len(r.Action) == 0 {
+		return "", false
+	}
+	var by []string
+	seen := make(map[string]bool)
+	for _, a := range r.Action {
+		name, ok := decidedBy[a]
+		if !ok {
+			return "", false
+		}
+		if !seen[name] {
+			seen[name] = true
+			by = append(by, name)
+		}
+	}
+	return strings.Join(by, " and "), true
+}
+
+func ruleSchedule(r Rule) *Schedule {
+	if r.Conditions == nil {
+		return nil
+	}
+	return r.Conditions.Schedule
+}
+
+func validAction(a ActionClass) bool {
+	return a == ActionRead || a == ActionWrite || a == ActionDestructive
+}
+
+func actionList(am ActionMatcher) string {
+	return strings.Join(actionsToStrings(am), "/")
+}
+
+// scheduleProblems describes the parts of s that IsActive can never match or
+// silently ignores.
+func scheduleProblems(s *Schedule) []string {
+	var out []string
+	for _, d := range s.Days {
+		switch d {
+		case "mon", "tue", "wed", "thu", "fri", "sat", "sun":
+		default:
+			out = append(out, fmt.Sprintf("schedule day %q is not one of mon..sun; it never matches", d))
+		}
+	}
+	for _, h := range s.Hours {
+		if h < 0 || h > 23 {
+			out = append(out, "schedule hour "+strconv.Itoa(h)+" is not 0-23; it never matches")
+		}
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			out = append(out, fmt.Sprintf("schedule timezone %q is unknown; the schedule is evaluated in the server's local time", s.Timezone))
+		}
+	}
+	return out
+}
+
+// principalsCover reports whether every principal matching later also
+// matches earlier.
+func principalsCover(earlier, later []Principal) bool {
+	if len(earlier) == 0 {
+		return true
+	}
+	for _, p := range earlier {
+		if p.Any {
+			return true
+		}
+	}
+	if len(later) == 0 {
+		return false
+	}
+	for _, q := range later {
+		found := false
+		for _, p := range earlier {
+			if !q.Any && p == q {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// resourcesCover reports whether every resource matching one of later's
+// specs also matches one of earlier's.
+func resourcesCover(earlier, later []Resource) bool {
+	for _, q := range later {
+		covered := false
+		for _, p := range earlier {
+			if resourceCovers(p, q) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceCovers reports whether spec p matches every resource spec q
+// matches: each of p's criteria is absent from p or implied by q's.
+func resourceCovers(p, q Resource) bool {
+	pm, qm := p.Match, q.Match
+	return p.Type == q.Type &&
+		(pm.Name == "" || pm.Name == qm.Name) &&
+		patternCovers(pm.NamePattern, qm.NamePattern, qm.Name) &&
+		(pm.Namespace == "" || pm.Namespace == qm.Namespace) &&
+		allPresent(pm.Tags, qm.Tags) &&
+		allPresent(pm.Sensitivity, qm.Sensitivity) &&
+		(pm.Tool == "" || pm.Tool == qm.Tool) &&
+		patternCovers(pm.ToolPattern, qm.ToolPattern, qm.Tool)
+}
+
+// patternCovers reports whether glob pattern matches everything a spec with
+// the given pattern and exact value matches.
+func patternCovers(pattern, otherPattern, otherExact string) bool {
+	if pattern == "" || pattern == "*" || pattern == otherPattern {
+		return true
+	}
+	if otherExact == "" {
+		return false
+	}
+	matched, _ := filepath.Match(pattern, otherExact)
+	return matched
+}
@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	yamlConfig := `
+version: "1"
+policies:
+  - name: prod-guard
+    priority: 100
+    resources:
+      - type: database
+        match:
+          tags: [production]
+    rules:
+      - action: read
+        effect: allow
+      - action: [write, destructive]
+        effect: deny
+        conditions:
+          schedule:
+            days: [mon, fri, funday]
+            hours: [9, 24]
+      - action: write
+        effect: require_approval
+      - action: reed
+        effect: allow
+  - name: dba-prod
+    priority: 50
+    principals:
+      - role: dba
+    resources:
+      - type: database
+        match:
+          name: orders
+          tags: [production, pii]
+    rules:
+      - action: write
+        effect: allow
+      - action: destructive
+        effect: require_approval
+  - name: dba-staging
+    priority: 40
+    principals:
+      - role: dba
+    resources:
+      - type: database
+        match:
+          tags: [staging]
+    rules:
+      - action: write
+        effect: allow
+tenants:
+  acme:
+    policies:
+      - name: acme-all
+        resources:
+          - type: database
+        rules:
+          - action: [read, write, destructive]
+            effect: deny
+      - name: acme-prod
+        resources:
+          - type: database
+            match:
+              name_pattern: "prod-*"
+        rules:
+          - action: read
+            effect: allow
+`
+	cfg, err := Load([]byte(yamlConfig))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var got []string
+	for _, issue := range Lint(cfg) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`policy "prod-guard" rule 1: schedule day "funday" is not one of mon..sun; it never matches`,
+		`policy "prod-guard" rule 1: schedule hour 24 is not 0-23; it never matches`,
+		`policy "prod-guard" rule 3: action "reed" is not read, write or destructive; no request matches it`,
+		`policy "dba-prod" rule 0: unreachable: every write request it matches is decided first by policy "prod-guard" rule 2`,
+		`tenant "acme" policy "acme-prod" rule 0: unreachable: every read request it matches is decided first by policy "acme-all" rule 0`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lint:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	yamlConfig := `
+policies:
+  - name: p
+    resources:
+      - type: database
+    rules:
+      - action: write
+        effect: allow
+        conditons:
+          require_approval: true
+`
+	if _, err := Load([]byte(yamlConfig)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := LoadStrict([]byte(yamlConfig)); err == nil || !strings.Contains(err.Error(), "conditons") {
+		t.Errorf("LoadStrict error = %v, want the unknown field", err)
+	}
+}
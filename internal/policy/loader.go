@@ -1,52 +1,141 @@
 package policy
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"helpdesk/internal/workhours"
 )
 
-// LoadFile loads a policy configuration from a YAML file.
+// LoadFile loads a policy configuration from a YAML file. A relative
+// calendar path in it is resolved against the file's directory.
 func LoadFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read policy file: %w", err)
 	}
-	return Load(data)
+	return load(data, false, filepath.Dir(path))
 }
 
 // Load parses policy configuration from YAML data.
 func Load(data []byte) (*Config, error) {
+	return load(data, false, "")
+}
+
+// LoadStrict is Load that also rejects keys the policy schema does not
+// define, so that a misspelt field fails instead of being silently ignored.
+func LoadStrict(data []byte) (*Config, error) {
+	return load(data, true, "")
+}
+
+func load(data []byte, strict bool, baseDir string) (*Config, error) {
 	// Expand environment variables in the YAML
 	expanded := os.ExpandEnv(string(data))
 
 	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
+	dec := yaml.NewDecoder(strings.NewReader(expanded))
+	dec.KnownFields(strict)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("parse policy YAML: %w", err)
 	}
 
 	if err := validate(&cfg); err != nil {
 		return nil, fmt.Errorf("validate policy: %w", err)
 	}
+	if err := loadCalendar(&cfg, baseDir); err != nil {
+		return nil, fmt.Errorf("validate policy: %w", err)
+	}
 
 	// Sort policies by priority (higher first)
-	sort.Slice(cfg.Policies, func(i, j int) bool {
-		return cfg.Policies[i].Priority > cfg.Policies[j].Priority
-	})
+	sortByPriority(cfg.Policies)
+	for _, layer := range cfg.Tenants {
+		sortByPriority(layer.Policies)
+	}
 
 	return &cfg, nil
 }
 
+func sortByPriority(policies []Policy) {
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Priority > policies[j].Priority
+	})
+}
+
+// loadCalendar loads the hours calendar named by cfg.Calendar and binds every
+// schedule that names a team to it. A schedule naming a team the calendar
+// does not define is an error.
+func loadCalendar(cfg *Config, baseDir string) error {
+	if cfg.Calendar != "" {
+		path := cfg.Calendar
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		cal, err := workhours.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		cfg.Hours = cal
+	}
+	bind := func(where string, policies []Policy) error {
+		for _, p := range policies {
+			for j, r := range p.Rules {
+				s := ruleSchedule(r)
+				if s == nil || s.Team == "" {
+					continue
+				}
+				if cfg.Hours == nil {
+					return fmt.Errorf("%spolicy %q rule %d: schedule team %q needs a calendar", where, p.Name, j, s.Team)
+				}
+				if s.Team != workhours.AnyTeam && cfg.Hours.Team(s.Team) == nil {
+					return fmt.Errorf("%spolicy %q rule %d: schedule team %q is not in calendar %s", where, p.Name, j, s.Team, cfg.Calendar)
+				}
+				s.calendar = cfg.Hours
+			}
+		}
+		return nil
+	}
+	if err := bind("", cfg.Policies); err != nil {
+		return err
+	}
+	for tenant, layer := range cfg.Tenants {
+		if err := bind(fmt.Sprintf("tenant %q: ", tenant), layer.Policies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validate checks the policy configuration for errors.
 func validate(cfg *Config) error {
 	if cfg.Version == "" {
 		cfg.Version = "1"
 	}
 
+	if err := validatePolicies(cfg.Policies); err != nil {
+		return err
+	}
+	for tenant, layer := range cfg.Tenants {
+		if tenant == "" {
+			return fmt.Errorf("tenants: empty tenant ID")
+		}
+		if err := validatePolicies(layer.Policies); err != nil {
+			return fmt.Errorf("tenant %q: %w", tenant, err)
+		}
+	}
+	return nil
+}
+
+// validatePolicies checks one layer of policies (global or a tenant's).
+func validatePolicies(policies []Policy) error {
 	seenNames := make(map[string]bool)
-	for i, p := range cfg.Policies {
+	for i, p := range policies {
 		if p.Name == "" {
 			return fmt.Errorf("policy %d: name is required", i)
 		}
@@ -58,6 +147,11 @@ func validate(cfg *Config) error {
 		if len(p.Resources) == 0 {
 			return fmt.Errorf("policy %q: at least one resource is required", p.Name)
 		}
+		for j, r := range p.Resources {
+			if err := r.Match.Tags.Validate(); err != nil {
+				return fmt.Errorf("policy %q resource %d: %w", p.Name, j, err)
+			}
+		}
 
 		if len(p.Rules) == 0 {
 			return fmt.Errorf("policy %q: at least one rule is required", p.Name)
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagMatcher is the tags criterion of a resource match. Each entry is a tag
+// the resource must have or a tag expression combining tags with AND, OR,
+// NOT and parentheses, e.g. "production AND NOT sandbox"; the resource must
+// satisfy every entry. In YAML it is a list or a single string:
+//
+//	tags: [production, pii]
+//	tags: production AND NOT sandbox
+type TagMatcher []string
+
+// UnmarshalYAML allows TagMatcher to accept either a string or a list.
+func (t *TagMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*t = TagMatcher{single}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Validate reports the first entry that is not a valid tag expression.
+func (t TagMatcher) Validate() error {
+	for _, entry := range t {
+		if _, err := parseTagExpr(entry); err != nil {
+			return fmt.Errorf("tags %q: %w", entry, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a resource with tags satisfies every entry. An
+// entry that does not parse matches nothing; the loader rejects such policies.
+func (t TagMatcher) Matches(tags []string) bool {
+	have := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		have[tag] = true
+	}
+	for _, entry := range t {
+		if !isTagExpr(entry) {
+			if !have[entry] {
+				return false
+			}
+			continue
+		}
+		expr, err := parseTagExpr(entry)
+		if err != nil || !expr.eval(have) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTagExpr reports whether entry is more than a single tag.
+func isTagExpr(entry string) bool {
+	return strings.ContainsAny(entry, " \t()")
+}
+
+// tagExpr is a parsed tag expression.
+type tagExpr interface {
+	eval(have map[string]bool) bool
+}
+
+type (
+	tagLit string
+	tagNot struct{ x tagExpr }
+	tagAnd struct{ x, y tagExpr }
+	tagOr  struct{ x, y tagExpr }
+)
+
+func (e tagLit) eval(have map[string]bool) bool { return have[string(e)] }
+func (e tagNot) eval(have map[string]bool) bool { return !e.x.eval(have) }
+func (e tagAnd) eval(have map[string]bool) bool { return e.x.eval(have) && e.y.eval(have) }
+func (e tagOr) eval(have map[string]bool) bool  { return e.x.eval(have) || e.y.eval(have) }
+
+// parseTagExpr parses a tag expression. NOT binds tighter than AND, which
+// binds tighter than OR. The operators are case-insensitive; anything else
+// between spaces and parentheses is a tag.
+func parseTagExpr(s string) (tagExpr, error) {
+	p := &tagParser{tokens: tokenizeTagExpr(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty tag expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeTagExpr(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n':
+			flush()
+		case '(', ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *tagParser) accept(op string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tagParser) parseOr() (tagExpr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = tagOr{x, y}
+	}
+	return x, nil
+}
+
+func (p *tagParser) parseAnd() (tagExpr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = tagAnd{x, y}
+	}
+	return x, nil
+}
+
+func (p *tagParser) parseNot() (tagExpr, error) {
+	if p.accept("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{x}, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expression ends where a tag was expected")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case tok == "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	case tok == ")", strings.EqualFold(tok, "AND"), strings.EqualFold(tok, "OR"):
+		return nil, fmt.Errorf("unexpected %q where a tag was expected", tok)
+	}
+	return tagLit(tok), nil
+}
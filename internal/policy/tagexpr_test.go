@@ -0,0 +1,87 @@
+package policy
+
+import "testing"
+
+func TestTagMatcher(t *testing.T) {
+	tests := []struct {
+		matcher TagMatcher
+		tags    []string
+		want    bool
+	}{
+		{TagMatcher{"production"}, []string{"production", "pii"}, true},
+		{TagMatcher{"production", "pii"}, []string{"production"}, false},
+		{TagMatcher{"production AND NOT sandbox"}, []string{"production"}, true},
+		{TagMatcher{"production AND NOT sandbox"}, []string{"production", "sandbox"}, false},
+		{TagMatcher{"staging OR production"}, []string{"staging"}, true},
+		{TagMatcher{"a OR b AND c"}, []string{"a"}, true}, // AND binds tighter
+		{TagMatcher{"(a OR b) AND c"}, []string{"a"}, false},
+		{TagMatcher{"not sandbox"}, nil, true},
+		{TagMatcher{"NOT NOT pii"}, []string{"pii"}, true},
+		{TagMatcher{"production", "NOT legacy"}, []string{"production", "legacy"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.Matches(tt.tags); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.matcher, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTagMatcher_Validate(t *testing.T) {
+	for _, bad := range []string{"production AND", "(a OR b", "a b", "OR a", "a )", "NOT"} {
+		if err := (TagMatcher{bad}).Validate(); err == nil {
+			t.Errorf("Validate(%q) = nil, want an error", bad)
+		}
+	}
+	if err := (TagMatcher{"production", "(a OR b) AND NOT c"}).Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestTagExpressionPolicy(t *testing.T) {
+	cfg, err := Load([]byte(`
+version: "1"
+policies:
+  - name: prod-not-sandbox
+    resources:
+      - type: database
+        match:
+          tags: production AND NOT sandbox
+    rules:
+      - action: destructive
+        effect: deny
+  - name: everything
+    resources:
+      - type: database
+    rules:
+      - action: destructive
+        effect: allow
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine := NewEngine(EngineConfig{PolicyConfig: cfg})
+
+	req := Request{Resource: RequestResource{Type: "database", Name: "orders", Tags: []string{"production"}}, Action: ActionDestructive}
+	if d := engine.Evaluate(req); d.Effect != EffectDeny || d.PolicyName != "prod-not-sandbox" {
+		t.Errorf("production database: %s by %q, want deny by prod-not-sandbox", d.Effect, d.PolicyName)
+	}
+	req.Resource.Tags = []string{"production", "sandbox"}
+	if d := engine.Evaluate(req); d.Effect != EffectAllow {
+		t.Errorf("sandbox database: %s by %q, want allow", d.Effect, d.PolicyName)
+	}
+
+	if _, err := Load([]byte(`
+version: "1"
+policies:
+  - name: broken
+    resources:
+      - type: database
+        match:
+          tags: production AND
+    rules:
+      - action: read
+        effect: allow
+`)); err == nil {
+		t.Error("Load accepted an invalid tag expression")
+	}
+}
@@ -2,7 +2,11 @@
 // It evaluates whether actions are allowed based on configurable rules.
 package policy
 
-import "time"
+import (
+	"time"
+
+	"helpdesk/internal/workhours"
+)
 
 // ActionClass represents the classification of an action by its impact.
 type ActionClass string
@@ -26,6 +30,45 @@ const (
 type Config struct {
 	Version  string   `yaml:"version"`
 	Policies []Policy `yaml:"policies"`
+
+	// Tenants layers per-tenant policies over the global ones. A request from
+	// a tenant-bound principal evaluates that tenant's policies first; a tenant
+	// policy with the same name as a global policy replaces it for that tenant.
+	Tenants map[string]TenantPolicies `yaml:"tenants,omitempty"`
+
+	// Calendar is the path of an hours calendar (see package workhours)
+	// whose teams schedules can name. A relative path is resolved against
+	// the policy file's directory.
+	Calendar string `yaml:"calendar,omitempty"`
+	// Hours is the calendar loaded from Calendar.
+	Hours *workhours.Calendar `yaml:"-"`
+}
+
+// TenantPolicies is one tenant's override layer.
+type TenantPolicies struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// PoliciesFor returns the policies that apply to tenant in evaluation order:
+// the tenant's own policies (by priority) followed by the global policies
+// they do not override. An empty or unknown tenant gets the global policies.
+func (c *Config) PoliciesFor(tenant string) []Policy {
+	layer, ok := c.Tenants[tenant]
+	if tenant == "" || !ok || len(layer.Policies) == 0 {
+		return c.Policies
+	}
+	overridden := make(map[string]bool, len(layer.Policies))
+	out := make([]Policy, 0, len(layer.Policies)+len(c.Policies))
+	for _, p := range layer.Policies {
+		overridden[p.Name] = true
+		out = append(out, p)
+	}
+	for _, p := range c.Policies {
+		if !overridden[p.Name] {
+			out = append(out, p)
+		}
+	}
+	return out
 }
 
 // Policy defines access rules for a set of resources.
@@ -63,13 +106,13 @@ type Resource struct {
 
 // ResourceMatch defines criteria for matching resources.
 type ResourceMatch struct {
-	Name        string   `yaml:"name,omitempty"`         // Exact name
-	NamePattern string   `yaml:"name_pattern,omitempty"` // Glob pattern (e.g., "prod-*")
-	Tags        []string `yaml:"tags,omitempty"`         // Must have all tags
-	Namespace   string   `yaml:"namespace,omitempty"`    // K8s namespace
-	Sensitivity []string `yaml:"sensitivity,omitempty"`  // match resources by sensitivity class
-	Tool        string   `yaml:"tool,omitempty"`         // Exact tool name (e.g., "terminate_connection")
-	ToolPattern string   `yaml:"tool_pattern,omitempty"` // Glob pattern (e.g., "terminate_*")
+	Name        string     `yaml:"name,omitempty"`         // Exact name
+	NamePattern string     `yaml:"name_pattern,omitempty"` // Glob pattern (e.g., "prod-*")
+	Tags        TagMatcher `yaml:"tags,omitempty"`         // Must have all tags; entries may be expressions such as "production AND NOT sandbox"
+	Namespace   string     `yaml:"namespace,omitempty"`    // K8s namespace
+	Sensitivity []string   `yaml:"sensitivity,omitempty"`  // match resources by sensitivity class
+	Tool        string     `yaml:"tool,omitempty"`         // Exact tool name (e.g., "terminate_connection")
+	ToolPattern string     `yaml:"tool_pattern,omitempty"` // Glob pattern (e.g., "terminate_*")
 }
 
 // Rule defines an access control rule within a policy.
@@ -130,6 +173,9 @@ type Conditions struct {
 
 	// Time-based conditions
 	Schedule *Schedule `yaml:"schedule,omitempty"`
+	// MaintenanceWindow, when set, limits the rule to requests inside (true)
+	// or outside (false) an active maintenance window covering the resource.
+	MaintenanceWindow *bool `yaml:"maintenance_window,omitempty"`
 
 	// Purpose-based conditions:
 	// AllowedPurposes: if non-empty, the request purpose must be in this list.
@@ -143,6 +189,11 @@ type Schedule struct {
 	Days     []string `yaml:"days,omitempty"`     // mon, tue, wed, thu, fri, sat, sun
 	Hours    []int    `yaml:"hours,omitempty"`    // 0-23
 	Timezone string   `yaml:"timezone,omitempty"` // e.g., America/New_York
+	// Team limits the schedule to the team's shifts in the policy's hours
+	// calendar: its own hours, timezone and holidays. "*" means any team.
+	Team string `yaml:"team,omitempty"`
+
+	calendar *workhours.Calendar // bound to Team at load time
 }
 
 // IsActive returns true if the current time matches the schedule.
@@ -150,6 +201,9 @@ func (s *Schedule) IsActive(now time.Time) bool {
 	if s == nil {
 		return true // No schedule means always active
 	}
+	if s.Team != "" && !s.calendar.OnShift(s.Team, now) {
+		return false
+	}
 
 	// Apply timezone if specified
 	if s.Timezone != "" {
@@ -232,6 +286,7 @@ type RequestPrincipal struct {
 	UserID  string   // User identifier
 	Roles   []string // User's roles
 	Service string   // Service account name (for automated requests)
+	Tenant  string   // Tenant whose policy overrides apply; empty = global policies only
 }
 
 // RequestResource identifies the resource being accessed.
@@ -254,6 +309,9 @@ type RequestContext struct {
 	XactAgeSecs  int       // For database: age of the open transaction in seconds
 	Purpose      string    // declared or derived purpose (diagnostic, remediation, maintenance, compliance, emergency)
 	PurposeNote  string    // optional free-text note
+	// MaintenanceWindow is the ID of the active maintenance window covering
+	// the resource, or empty when there is none.
+	MaintenanceWindow string
 }
 
 // Decision is the result of policy evaluation.
@@ -280,6 +338,8 @@ type DecisionTrace struct {
 // PolicyTrace records what happened for a single policy during evaluation.
 type PolicyTrace struct {
 	PolicyName string      `json:"policy_name"`
+	// Tenant is set when the policy comes from a tenant override layer.
+	Tenant     string      `json:"tenant,omitempty"`
 	Matched    bool        `json:"matched"`
 	// SkipReason is set when Matched == false: "disabled", "principal_mismatch", "resource_mismatch".
 	SkipReason string      `json:"skip_reason,omitempty"`
@@ -298,7 +358,8 @@ type RuleTrace struct {
 	Actions []string `json:"actions"`
 	Effect  string `json:"effect"`
 	Matched bool   `json:"matched"`
-	// SkipReason is set when Matched == false: "action_mismatch", "schedule_inactive".
+	// SkipReason is set when Matched == false: "action_mismatch", "schedule_inactive",
+	// "maintenance_window_inactive" or "maintenance_window_active".
 	SkipReason string           `json:"skip_reason,omitempty"`
 	// Conditions is populated only for the matching rule.
 	Conditions []ConditionTrace `json:"conditions,omitempty"`
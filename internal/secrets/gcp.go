@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+)
+
+// GCPProvider reads secrets from GCP Secret Manager (versions:access). The
+// path is a full version name, "projects/P/secrets/S/versions/V"; a name
+// without "/versions/" reads the latest version.
+//
+// Access tokens come from Application Default Credentials (workload identity,
+// GOOGLE_APPLICATION_CREDENTIALS, or the metadata server) unless Token is set.
+type GCPProvider struct {
+	Endpoint string                                    // defaults to https://secretmanager.googleapis.com
+	Token    func(ctx context.Context) (string, error) // overrides ADC, mainly for tests
+	Client   *http.Client
+
+	once  sync.Once
+	creds *auth.Credentials
+	err   error
+}
+
+func (g *GCPProvider) token(ctx context.Context) (string, error) {
+	if g.Token != nil {
+		return g.Token(ctx)
+	}
+	g.once.Do(func() {
+		g.creds, g.err = credentials.DetectDefault(&credentials.DetectOptions{
+			Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+		})
+	})
+	if g.err != nil {
+		return "", fmt.Errorf("GCP credentials: %v", g.err)
+	}
+	tok, err := g.creds.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("GCP token: %v", err)
+	}
+	return tok.Value, nil
+}
+
+// Fetch returns the payload of the named secret version.
+func (g *GCPProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	name := strings.Trim(path, "/")
+	if !strings.HasPrefix(name, "projects/") {
+		return Secret{}, fmt.Errorf("GCP secret name must start with projects/: %q", path)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	token, err := g.token(ctx)
+	if err != nil {
+		return Secret{}, err
+	}
+	endpoint := strings.TrimRight(g.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return Secret{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Secret{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Secret{}, fmt.Errorf("access %s: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Secret{}, fmt.Errorf("access %s: %v", name, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	if err != nil {
+		return Secret{}, fmt.Errorf("access %s: payload: %v", name, err)
+	}
+	return stringSecret(strings.TrimSpace(string(data))), nil
+}
@@ -0,0 +1,297 @@
+// Package secrets resolves secret references against external secret stores
+// (HashiCorp Vault, AWS Secrets Manager, GCP Secret Manager) so that SMTP
+// passwords, webhook signing keys and database credentials need not be placed
+// in plain environment variables or config files.
+//
+// A reference is a URI whose scheme selects the provider and whose fragment
+// optionally selects one field of a structured secret:
+//
+//	vault://secret/data/smtp#password
+//	awssm://prod/helpdesk/smtp#password
+//	gcpsm://projects/my-proj/secrets/smtp-password/versions/latest
+//	file:///var/run/secrets/smtp/password
+//	env://SMTP_PASSWORD
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a secret without a lease is reused before it is
+// fetched again. Rotated values are picked up within this window.
+const DefaultCacheTTL = 5 * time.Minute
+
+// Secret is one value read from a provider.
+type Secret struct {
+	// Value is the secret as a single string (file contents, an AWS
+	// SecretString, a GCP payload). Empty for purely structured secrets.
+	Value string
+	// Fields holds the key/value pairs of a structured secret (a Vault KV
+	// entry, or a JSON object stored in AWS/GCP).
+	Fields map[string]string
+
+	// LeaseID and LeaseDuration describe a provider lease (Vault dynamic
+	// secrets). A zero LeaseDuration means the secret is not leased.
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+}
+
+// field returns the named field, or the whole value when name is empty.
+func (s Secret) field(name string) (string, error) {
+	if name == "" {
+		if s.Value != "" {
+			return s.Value, nil
+		}
+		if len(s.Fields) == 1 {
+			for _, v := range s.Fields {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("secret has %d fields; select one with #field", len(s.Fields))
+	}
+	v, ok := s.Fields[name]
+	if !ok || v == "" {
+		return "", fmt.Errorf("field %q not found", name)
+	}
+	return v, nil
+}
+
+// Provider reads secrets from one backend. path is the reference with the
+// scheme and fragment removed.
+type Provider interface {
+	Fetch(ctx context.Context, path string) (Secret, error)
+}
+
+// Renewer is implemented by providers that hand out leases. Renew extends the
+// lease on s and returns the updated secret.
+type Renewer interface {
+	Renew(ctx context.Context, s Secret) (Secret, error)
+}
+
+// IsRef reports whether s is a secret reference rather than a literal value.
+func IsRef(s string) bool {
+	scheme, _, ok := strings.Cut(s, "://")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case "vault", "awssm", "gcpsm", "file", "env":
+		return true
+	}
+	return false
+}
+
+// parseRef splits "scheme://path#field".
+func parseRef(ref string) (scheme, path, field string, err error) {
+	scheme, rest, ok := strings.Cut(ref, "://")
+	if !ok || scheme == "" {
+		return "", "", "", fmt.Errorf("invalid secret reference %q: want scheme://path[#field]", ref)
+	}
+	path, field, _ = strings.Cut(rest, "#")
+	if path == "" {
+		return "", "", "", fmt.Errorf("invalid secret reference %q: empty path", ref)
+	}
+	return scheme, path, field, nil
+}
+
+type cacheEntry struct {
+	secret    Secret
+	fetchedAt time.Time
+	expiresAt time.Time
+}
+
+// Resolver resolves references through registered providers, caching each
+// fetched secret and renewing leased secrets before they expire.
+type Resolver struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]Provider
+	cache     map[string]cacheEntry
+}
+
+// NewResolver creates an empty resolver. ttl <= 0 selects DefaultCacheTTL.
+func NewResolver(ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Resolver{
+		ttl:       ttl,
+		now:       time.Now,
+		providers: map[string]Provider{},
+		cache:     map[string]cacheEntry{},
+	}
+}
+
+// NewDefaultResolver returns a resolver with every built-in provider
+// registered. Providers read their connection settings from the environment
+// when first used, so constructing one never fails.
+func NewDefaultResolver() *Resolver {
+	r := NewResolver(0)
+	r.Register("vault", &VaultProvider{})
+	r.Register("awssm", &AWSProvider{})
+	r.Register("gcpsm", &GCPProvider{})
+	r.Register("file", fileProvider{})
+	r.Register("env", envProvider{})
+	return r
+}
+
+// Register installs p for refs with the given scheme, replacing any previous one.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// Resolve returns the secret value for ref.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, path, field, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	s, err := r.fetch(ctx, scheme, path)
+	if err != nil {
+		return "", fmt.Errorf("secret %s://%s: %w", scheme, path, err)
+	}
+	v, err := s.field(field)
+	if err != nil {
+		return "", fmt.Errorf("secret %s://%s: %w", scheme, path, err)
+	}
+	return v, nil
+}
+
+// fetch returns the cached secret for scheme://path, renewing or re-reading it
+// as its cache entry or lease requires.
+func (r *Resolver) fetch(ctx context.Context, scheme, path string) (Secret, error) {
+	key := scheme + "://" + path
+	r.mu.Lock()
+	p, ok := r.providers[scheme]
+	entry, cached := r.cache[key]
+	r.mu.Unlock()
+	if !ok {
+		return Secret{}, fmt.Errorf("no provider registered for scheme %q", scheme)
+	}
+	if _, local := p.(uncached); local {
+		return p.Fetch(ctx, path)
+	}
+
+	now := r.now()
+	if cached && now.Before(entry.renewAt()) {
+		return entry.secret, nil
+	}
+	if cached && entry.secret.Renewable && now.Before(entry.expiresAt) {
+		if rn, ok := p.(Renewer); ok {
+			if s, err := rn.Renew(ctx, entry.secret); err == nil {
+				r.store(key, s, now)
+				return s, nil
+			}
+			// Renewal failed: fall through and read a fresh secret.
+		}
+	}
+
+	s, err := p.Fetch(ctx, path)
+	if err != nil {
+		return Secret{}, err
+	}
+	r.store(key, s, now)
+	return s, nil
+}
+
+func (r *Resolver) store(key string, s Secret, now time.Time) {
+	life := r.ttl
+	if s.LeaseDuration > 0 && s.LeaseDuration < life {
+		life = s.LeaseDuration
+	}
+	r.mu.Lock()
+	r.cache[key] = cacheEntry{secret: s, fetchedAt: now, expiresAt: now.Add(life)}
+	r.mu.Unlock()
+}
+
+// renewAt is when a cached entry should be refreshed: at two thirds of its
+// lifetime, leaving room to renew a lease before it lapses.
+func (e cacheEntry) renewAt() time.Time {
+	return e.fetchedAt.Add(e.expiresAt.Sub(e.fetchedAt) * 2 / 3)
+}
+
+// Default is the process-wide resolver used by Resolve and Getenv.
+var Default = NewDefaultResolver()
+
+// Resolve resolves ref with the Default resolver.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	return Default.Resolve(ctx, ref)
+}
+
+// Getenv returns the value of the environment variable name. When the value
+// is a secret reference (e.g. SMTP_PASSWORD=vault://secret/data/smtp#password)
+// it is resolved with the Default resolver; any other value is returned as-is.
+func Getenv(ctx context.Context, name string) (string, error) {
+	return Value(ctx, os.Getenv(name))
+}
+
+// Value resolves v when it is a secret reference and returns it unchanged
+// otherwise. Use it for values that arrive via flags rather than the environment.
+func Value(ctx context.Context, v string) (string, error) {
+	if !IsRef(v) {
+		return v, nil
+	}
+	return Default.Resolve(ctx, v)
+}
+
+// uncached marks providers whose reads are local and cheap. They are read on
+// every Resolve so a rotated file or variable takes effect immediately.
+type uncached interface{ uncached() }
+
+// fileProvider reads a secret from a file, e.g. a mounted Kubernetes Secret.
+// A JSON object in the file is also exposed as Fields.
+type fileProvider struct{}
+
+func (fileProvider) uncached() {}
+
+func (fileProvider) Fetch(_ context.Context, path string) (Secret, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Secret{}, err
+	}
+	return stringSecret(strings.TrimSpace(string(data))), nil
+}
+
+// envProvider reads a secret from another environment variable.
+type envProvider struct{}
+
+func (envProvider) uncached() {}
+
+func (envProvider) Fetch(_ context.Context, name string) (Secret, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return Secret{}, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return Secret{Value: v}, nil
+}
+
+// stringSecret wraps a raw secret string, additionally exposing its members
+// as Fields when it is a flat JSON object (the usual AWS/GCP convention).
+func stringSecret(v string) Secret {
+	s := Secret{Value: v}
+	if strings.HasPrefix(v, "{") {
+		var obj map[string]any
+		if json.Unmarshal([]byte(v), &obj) == nil {
+			s.Fields = make(map[string]string, len(obj))
+			for k, x := range obj {
+				if str, ok := x.(string); ok {
+					s.Fields[k] = str
+				} else {
+					s.Fields[k] = fmt.Sprint(x)
+				}
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,222 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider returns a fixed secret and counts Fetch/Renew calls.
+type countingProvider struct {
+	secret  Secret
+	fetches atomic.Int32
+	renews  atomic.Int32
+	failRen bool
+}
+
+func (p *countingProvider) Fetch(context.Context, string) (Secret, error) {
+	p.fetches.Add(1)
+	return p.secret, nil
+}
+
+func (p *countingProvider) Renew(_ context.Context, s Secret) (Secret, error) {
+	p.renews.Add(1)
+	if p.failRen {
+		return s, context.DeadlineExceeded
+	}
+	return s, nil
+}
+
+func TestIsRef(t *testing.T) {
+	for in, want := range map[string]bool{
+		"vault://secret/data/x#password": true,
+		"awssm://prod/smtp":              true,
+		"gcpsm://projects/p/secrets/s":   true,
+		"file:///run/secrets/pw":         true,
+		"env://OTHER":                    true,
+		"hunter2":                        false,
+		"https://example.com":            false,
+		"":                               false,
+	} {
+		if got := IsRef(in); got != want {
+			t.Errorf("IsRef(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestResolver_CachesUntilTTL(t *testing.T) {
+	p := &countingProvider{secret: Secret{Value: "v1"}}
+	r := NewResolver(time.Minute)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+	r.Register("test", p)
+
+	for i := 0; i < 3; i++ {
+		if v, err := r.Resolve(context.Background(), "test://a"); err != nil || v != "v1" {
+			t.Fatalf("Resolve = %q, %v", v, err)
+		}
+	}
+	if n := p.fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1 (cached)", n)
+	}
+
+	now = now.Add(time.Minute)
+	r.Resolve(context.Background(), "test://a") //nolint:errcheck
+	if n := p.fetches.Load(); n != 2 {
+		t.Errorf("fetches after TTL = %d, want 2", n)
+	}
+}
+
+func TestResolver_RenewsLeaseBeforeExpiry(t *testing.T) {
+	p := &countingProvider{secret: Secret{Fields: map[string]string{"password": "dyn"}, LeaseID: "l1", LeaseDuration: 30 * time.Second, Renewable: true}}
+	r := NewResolver(time.Hour)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+	r.Register("test", p)
+
+	if v, _ := r.Resolve(context.Background(), "test://db#password"); v != "dyn" {
+		t.Fatalf("Resolve = %q", v)
+	}
+	// Past two thirds of the lease but before expiry: renew, not refetch.
+	now = now.Add(25 * time.Second)
+	r.Resolve(context.Background(), "test://db#password") //nolint:errcheck
+	if p.renews.Load() != 1 || p.fetches.Load() != 1 {
+		t.Errorf("renews=%d fetches=%d, want 1/1", p.renews.Load(), p.fetches.Load())
+	}
+
+	// Failed renewal falls back to a fresh read.
+	p.failRen = true
+	now = now.Add(25 * time.Second)
+	r.Resolve(context.Background(), "test://db#password") //nolint:errcheck
+	if p.fetches.Load() != 2 {
+		t.Errorf("fetches = %d, want 2 after failed renewal", p.fetches.Load())
+	}
+}
+
+func TestResolver_FieldSelection(t *testing.T) {
+	r := NewResolver(0)
+	r.Register("test", &countingProvider{secret: stringSecret(`{"username":"app","password":"pw"}`)})
+	if v, err := r.Resolve(context.Background(), "test://x#password"); err != nil || v != "pw" {
+		t.Errorf("Resolve(#password) = %q, %v", v, err)
+	}
+	if _, err := r.Resolve(context.Background(), "test://x#missing"); err == nil {
+		t.Error("want error for missing field")
+	}
+	if _, err := r.Resolve(context.Background(), "nope://x"); err == nil {
+		t.Error("want error for unregistered scheme")
+	}
+}
+
+func TestValue_LiteralAndFile(t *testing.T) {
+	if v, err := Value(context.Background(), "plain-password"); err != nil || v != "plain-password" {
+		t.Errorf("literal: %q, %v", v, err)
+	}
+	path := filepath.Join(t.TempDir(), "pw")
+	os.WriteFile(path, []byte("from-file\n"), 0o600) //nolint:errcheck
+	t.Setenv("TEST_SECRETS_SMTP", "file://"+path)
+	if v, err := Getenv(context.Background(), "TEST_SECRETS_SMTP"); err != nil || v != "from-file" {
+		t.Errorf("Getenv(file ref) = %q, %v", v, err)
+	}
+	// Local sources are not cached, so rotation is visible immediately.
+	os.WriteFile(path, []byte("rotated"), 0o600) //nolint:errcheck
+	if v, _ := Getenv(context.Background(), "TEST_SECRETS_SMTP"); v != "rotated" {
+		t.Errorf("after rotation = %q, want rotated", v)
+	}
+}
+
+func TestVaultProvider_KV2AndLeaseRenew(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" {
+			http.Error(w, "denied", http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/smtp":
+			w.Write([]byte(`{"data":{"data":{"password":"kv2pw"},"metadata":{"version":1}}}`)) //nolint:errcheck
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/database/creds/ro":
+			w.Write([]byte(`{"lease_id":"database/creds/ro/abc","lease_duration":60,"renewable":true,"data":{"username":"v-ro","password":"dyn"}}`)) //nolint:errcheck
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/sys/leases/renew":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+			if body["lease_id"] != "database/creds/ro/abc" {
+				http.Error(w, "bad lease", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"lease_id":"database/creds/ro/abc","lease_duration":120,"renewable":true}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	v := &VaultProvider{Addr: srv.URL, Token: "tok"}
+
+	s, err := v.Fetch(context.Background(), "secret/data/smtp")
+	if err != nil || s.Fields["password"] != "kv2pw" {
+		t.Fatalf("KV2 fetch = %+v, %v", s, err)
+	}
+
+	s, err = v.Fetch(context.Background(), "database/creds/ro")
+	if err != nil || s.Fields["username"] != "v-ro" || !s.Renewable || s.LeaseDuration != time.Minute {
+		t.Fatalf("dynamic fetch = %+v, %v", s, err)
+	}
+	s, err = v.Renew(context.Background(), s)
+	if err != nil || s.LeaseDuration != 2*time.Minute || s.Fields["password"] != "dyn" {
+		t.Errorf("renew = %+v, %v", s, err)
+	}
+}
+
+func TestAWSProvider_SignedGetSecretValue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20260101/eu-west-1/secretsmanager/aws4_request") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			http.Error(w, "bad signature: "+auth, http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		if body["SecretId"] != "arn:aws:secretsmanager:eu-west-1:123:secret:smtp" {
+			http.Error(w, "unknown secret", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"SecretString":"{\"password\":\"awspw\"}"}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	a := &AWSProvider{AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: srv.URL,
+		now: func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }}
+	s, err := a.Fetch(context.Background(), "arn:aws:secretsmanager:eu-west-1:123:secret:smtp")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if v, _ := s.field("password"); v != "awspw" {
+		t.Errorf("password = %q, want awspw", v)
+	}
+}
+
+func TestGCPProvider_AccessLatest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gtok" || r.URL.Path != "/v1/projects/p/secrets/smtp/versions/latest:access" {
+			http.Error(w, "denied", http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("gcppw"))},
+		})
+	}))
+	defer srv.Close()
+
+	g := &GCPProvider{Endpoint: srv.URL, Token: func(context.Context) (string, error) { return "gtok", nil }}
+	s, err := g.Fetch(context.Background(), "projects/p/secrets/smtp")
+	if err != nil || s.Value != "gcppw" {
+		t.Fatalf("Fetch = %+v, %v", s, err)
+	}
+}
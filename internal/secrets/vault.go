@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads HashiCorp Vault secrets over the HTTP API. Both KV v1
+// and KV v2 are supported (for v2 the path includes "data/", as in
+// "secret/data/smtp"), as are dynamic secrets engines that return a lease,
+// e.g. "database/creds/helpdesk-ro".
+//
+// Zero fields fall back to VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+type VaultProvider struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+func (v *VaultProvider) addr() string {
+	if v.Addr != "" {
+		return strings.TrimRight(v.Addr, "/")
+	}
+	return strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+}
+
+func (v *VaultProvider) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	addr := v.addr()
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	var rd *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, addr+"/v1/"+strings.TrimLeft(path, "/"), rd)
+	if err != nil {
+		return nil, err
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	ns := v.Namespace
+	if ns == "" {
+		ns = os.Getenv("VAULT_NAMESPACE")
+	}
+	if ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("vault %s %s: status %d", method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// vaultResponse is the envelope shared by secret reads and lease renewals.
+type vaultResponse struct {
+	LeaseID       string                     `json:"lease_id"`
+	LeaseDuration int                        `json:"lease_duration"`
+	Renewable     bool                       `json:"renewable"`
+	Data          map[string]json.RawMessage `json:"data"`
+}
+
+// Fetch reads the secret at path.
+func (v *VaultProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	resp, err := v.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return Secret{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Secret{}, fmt.Errorf("vault read %s: %v", path, err)
+	}
+
+	data := body.Data
+	// KV v2 nests the secret under data.data next to data.metadata.
+	if inner, ok := data["data"]; ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(inner, &nested); err == nil {
+				data = nested
+			}
+		}
+	}
+	s := Secret{
+		Fields:        make(map[string]string, len(data)),
+		LeaseID:       body.LeaseID,
+		LeaseDuration: time.Duration(body.LeaseDuration) * time.Second,
+		Renewable:     body.Renewable && body.LeaseID != "",
+	}
+	for k, raw := range data {
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			s.Fields[k] = str
+		} else {
+			s.Fields[k] = string(raw)
+		}
+	}
+	return s, nil
+}
+
+// Renew extends the lease of a dynamic secret. The secret value itself is
+// unchanged; only the lease duration is refreshed.
+func (v *VaultProvider) Renew(ctx context.Context, s Secret) (Secret, error) {
+	if s.LeaseID == "" {
+		return s, fmt.Errorf("secret has no lease")
+	}
+	resp, err := v.do(ctx, http.MethodPut, "sys/leases/renew", map[string]any{"lease_id": s.LeaseID})
+	if err != nil {
+		return s, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return s, fmt.Errorf("vault lease renew: %v", err)
+	}
+	s.LeaseDuration = time.Duration(body.LeaseDuration) * time.Second
+	s.Renewable = body.Renewable
+	return s, nil
+}
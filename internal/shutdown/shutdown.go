@@ -0,0 +1,122 @@
+// Package shutdown coordinates graceful shutdown across helpdesk services:
+// stop accepting requests, let in-flight requests finish, then drain the
+// background work they started (audit writes, notifications) — all within
+// a bounded timeout, so SIGTERM never drops an audit event that was already
+// on its way.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds the whole shutdown: request drain plus background
+// drain. It stays under Kubernetes' default 30s termination grace period.
+const DefaultTimeout = 25 * time.Second
+
+// Timeout returns the shutdown timeout from HELPDESK_SHUTDOWN_TIMEOUT, or
+// DefaultTimeout.
+func Timeout() time.Duration {
+	if v := os.Getenv("HELPDESK_SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid HELPDESK_SHUTDOWN_TIMEOUT, using default", "value", v, "default", DefaultTimeout)
+	}
+	return DefaultTimeout
+}
+
+// Group tracks fire-and-forget goroutines so that shutdown can wait for them.
+type Group struct {
+	wg      sync.WaitGroup
+	pending atomic.Int64
+}
+
+// Go runs fn in a goroutine tracked by g.
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	g.pending.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.pending.Add(-1)
+		fn()
+	}()
+}
+
+// Wait blocks until every goroutine started by Go has returned or ctx is
+// done. It returns the number still running.
+func (g *Group) Wait(ctx context.Context) int {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return 0
+	case <-ctx.Done():
+		return int(g.pending.Load())
+	}
+}
+
+// background is the process-wide group behind Go and Drain.
+var background Group
+
+// Go runs fn in a goroutine that Drain waits for. Use it instead of a bare
+// go statement for work that must not be lost on shutdown: recording audit
+// events and sending notifications after the response has been written.
+func Go(fn func()) {
+	background.Go(fn)
+}
+
+// Drain waits, until ctx is done, for the goroutines started by Go and logs
+// any it had to abandon.
+func Drain(ctx context.Context) {
+	if n := background.Wait(ctx); n > 0 {
+		slog.Warn("shutdown: abandoned background work after drain timeout", "pending", n)
+	}
+}
+
+// Serve serves srv on ln until ctx is done, then shuts down gracefully
+// within timeout: it stops accepting connections, waits for in-flight
+// requests and then drains the background work they started. It returns
+// the error that stopped the server, or nil after a clean shutdown.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down: draining in-flight requests", "addr", ln.Addr().String(), "timeout", timeout)
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Warn("shutdown: in-flight requests did not finish in time", "err", err)
+		srv.Close() //nolint:errcheck
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	Drain(drainCtx)
+	return nil
+}
+
+// ListenAndServe is Serve on a new TCP listener for srv.Addr.
+func ListenAndServe(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, ln, timeout)
+}
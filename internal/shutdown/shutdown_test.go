@@ -0,0 +1,93 @@
+package shutdown
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupWait(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	g.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if n := g.Wait(ctx); n != 1 {
+		t.Fatalf("Wait with blocked goroutine = %d pending, want 1", n)
+	}
+
+	close(release)
+	if n := g.Wait(context.Background()); n != 0 {
+		t.Fatalf("Wait after release = %d pending, want 0", n)
+	}
+}
+
+func TestServe_DrainsRequestsAndBackgroundWork(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	var recorded atomic.Bool
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("ok")) //nolint:errcheck
+		Go(func() {
+			time.Sleep(50 * time.Millisecond)
+			recorded.Store(true)
+		})
+	})}
+
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, srv, ln, 5*time.Second) }()
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respCh <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+
+	<-started
+	stop()
+
+	if err := <-served; err != nil {
+		t.Fatalf("Serve = %v, want nil", err)
+	}
+	if got := <-respCh; got != "ok" {
+		t.Errorf("in-flight request got %q, want it to complete", got)
+	}
+	if !recorded.Load() {
+		t.Error("Serve returned before background work finished")
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Error("server still accepting connections after shutdown")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Setenv("HELPDESK_SHUTDOWN_TIMEOUT", "")
+	if got := Timeout(); got != DefaultTimeout {
+		t.Errorf("Timeout() = %v, want default %v", got, DefaultTimeout)
+	}
+	t.Setenv("HELPDESK_SHUTDOWN_TIMEOUT", "5s")
+	if got := Timeout(); got != 5*time.Second {
+		t.Errorf("Timeout() = %v, want 5s", got)
+	}
+	t.Setenv("HELPDESK_SHUTDOWN_TIMEOUT", "soon")
+	if got := Timeout(); got != DefaultTimeout {
+		t.Errorf("Timeout() with invalid value = %v, want default", got)
+	}
+}
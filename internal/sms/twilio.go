@@ -0,0 +1,153 @@
+// Package sms sends text messages through the Twilio Messaging API and
+// verifies the webhooks Twilio posts for incoming replies. It backs the SMS
+// and WhatsApp channels of auditd's approval notifications and the auditor's
+// CRITICAL alerts.
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Twilio REST API base URL.
+const DefaultAPIURL = "https://api.twilio.com"
+
+// whatsAppPrefix marks a WhatsApp sender or recipient in Twilio addresses.
+const whatsAppPrefix = "whatsapp:"
+
+// maxBodyLen keeps a message within what Twilio accepts (1600 characters).
+const maxBodyLen = 1600
+
+// Client sends messages from one Twilio number.
+type Client struct {
+	AccountSID string
+	AuthToken  string
+	// From is the sending number in E.164 form, or "whatsapp:+1..." to send
+	// over WhatsApp. Recipients get the same prefix.
+	From       string
+	APIURL     string // default DefaultAPIURL
+	HTTPClient *http.Client
+}
+
+// Enabled reports whether the client has the settings it needs to send.
+func (c *Client) Enabled() bool {
+	return c != nil && c.AccountSID != "" && c.AuthToken != "" && c.From != ""
+}
+
+// Send sends body to the number to. Bodies over Twilio's limit are truncated.
+func (c *Client) Send(ctx context.Context, to, body string) error {
+	if strings.HasPrefix(c.From, whatsAppPrefix) && !strings.HasPrefix(to, whatsAppPrefix) {
+		to = whatsAppPrefix + to
+	}
+	if r := []rune(body); len(r) > maxBodyLen {
+		body = string(r[:maxBodyLen-1]) + "…"
+	}
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	endpoint := strings.TrimSuffix(apiURL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(c.AccountSID) + "/Messages.json"
+	form := url.Values{"To": {to}, "From": {c.From}, "Body": {body}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("twilio: %s (code %d, HTTP %d)", apiErr.Message, apiErr.Code, resp.StatusCode)
+		}
+		return fmt.Errorf("twilio: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidSignature reports whether signature (the X-Twilio-Signature header) is
+// Twilio's signature of a webhook POST to fullURL with the given form params.
+// fullURL must be the URL exactly as configured in Twilio, including scheme
+// and query string.
+func ValidSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	return hmac.Equal([]byte(Signature(authToken, fullURL, params)), []byte(signature))
+}
+
+// Signature computes the X-Twilio-Signature of a webhook POST: the base64
+// HMAC-SHA1, keyed with the auth token, of the URL followed by every form
+// param name and value in name order.
+func Signature(authToken, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizeNumber strips the WhatsApp prefix and formatting from a phone
+// number, so "whatsapp:+1 (555) 010-0200" and "+15550100200" compare equal.
+func NormalizeNumber(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), whatsAppPrefix)
+	var b strings.Builder
+	for _, r := range s {
+		if r == '+' && b.Len() == 0 || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Reply is the TwiML response to an incoming message webhook: it answers the
+// sender with text, or sends nothing when text is empty.
+func Reply(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Response>`)
+	if text != "" {
+		b.WriteString("<Message>")
+		xmlEscape(&b, text)
+		b.WriteString("</Message>")
+	}
+	b.WriteString("</Response>")
+	io.WriteString(w, b.String()) //nolint:errcheck
+}
+
+func xmlEscape(b *strings.Builder, s string) {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	b.WriteString(r.Replace(s))
+}
@@ -0,0 +1,89 @@
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientSend(t *testing.T) {
+	var got url.Values
+	var user, pass, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		r.ParseForm() //nolint:errcheck
+		got = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := &Client{AccountSID: "AC123", AuthToken: "secret", From: "whatsapp:+15550100000", APIURL: srv.URL}
+	if err := c.Send(t.Context(), "+15550100200", "approval pending"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if path != "/2010-04-01/Accounts/AC123/Messages.json" || user != "AC123" || pass != "secret" {
+		t.Errorf("request path %q, auth %q:%q", path, user, pass)
+	}
+	if got.Get("To") != "whatsapp:+15550100200" || got.Get("From") != "whatsapp:+15550100000" || got.Get("Body") != "approval pending" {
+		t.Errorf("form = %v", got)
+	}
+}
+
+func TestClientSend_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number."}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	c := &Client{AccountSID: "AC123", AuthToken: "secret", From: "+15550100000", APIURL: srv.URL}
+	err := c.Send(t.Context(), "bogus", "x")
+	if err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Errorf("err = %v, want the Twilio error code", err)
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	// Example from Twilio's webhook security documentation.
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+14158675309"},
+		"Digits":  {"1234"},
+		"From":    {"+14158675309"},
+		"To":      {"+18005551212"},
+	}
+	const fullURL = "https://mycompany.com/myapp.php?foo=1&bar=2"
+	const sig = "RSOYDt4T1cUTdK1PDd93/VVr8B8="
+	if !ValidSignature("12345", fullURL, params, sig) {
+		t.Error("documented signature rejected")
+	}
+	if ValidSignature("12345", fullURL, url.Values{"Digits": {"9999"}}, sig) {
+		t.Error("signature accepted for other params")
+	}
+	if ValidSignature("other", fullURL, params, sig) {
+		t.Error("signature accepted under another token")
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	for in, want := range map[string]string{
+		"whatsapp:+15550100200": "+15550100200",
+		"+1 (555) 010-0200":     "+15550100200",
+		" 15550100200 ":         "15550100200",
+	} {
+		if got := NormalizeNumber(in); got != want {
+			t.Errorf("NormalizeNumber(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReply(t *testing.T) {
+	w := httptest.NewRecorder()
+	Reply(w, "Approved apr_1 <ok> & done")
+	if body := w.Body.String(); !strings.Contains(body, "<Message>Approved apr_1 &lt;ok&gt; &amp; done</Message>") {
+		t.Errorf("body = %s", body)
+	}
+}
@@ -54,6 +54,18 @@ func (r *Registry) Get(name string) (ToolEntry, bool) {
 	return e, ok
 }
 
+// GetForAgent returns the entry for the named tool on the given agent (short
+// registry name). Unlike Get, it does not confuse same-named tools advertised
+// by different agents.
+func (r *Registry) GetForAgent(agent, name string) (ToolEntry, bool) {
+	for _, e := range r.tools {
+		if e.Agent == agent && e.Name == name {
+			return e, true
+		}
+	}
+	return ToolEntry{}, false
+}
+
 // List returns all entries.
 func (r *Registry) List() []ToolEntry {
 	return r.tools
@@ -213,6 +225,7 @@ var agentShortName = map[string]string{
 	"k8s_agent":               "k8s",
 	"incident_agent":          "incident",
 	"research_agent":          "research",
+	"kb_agent":                "kb",
 	"sysadmin_agent":          "sysadmin",
 }
 
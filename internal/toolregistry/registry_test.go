@@ -404,3 +404,58 @@ func TestBuild_AgentVersionAndSchemaFingerprint(t *testing.T) {
 		t.Errorf("SchemaFingerprint = %q, want %q", entry.SchemaFingerprint, "deadbeef1234")
 	}
 }
+
+func TestGetForAgent(t *testing.T) {
+	r := New([]ToolEntry{
+		{Name: "get_status", Agent: "database"},
+		{Name: "get_status", Agent: "sysadmin"},
+	})
+	if e, ok := r.GetForAgent("sysadmin", "get_status"); !ok || e.Agent != "sysadmin" {
+		t.Errorf("GetForAgent(sysadmin) = %+v, %v", e, ok)
+	}
+	if _, ok := r.GetForAgent("k8s", "get_status"); ok {
+		t.Error("GetForAgent(k8s) should not find another agent's tool")
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	e := ToolEntry{Name: "check_connection", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"connection_string": map[string]any{"type": "STRING"}, // genai spelling
+			"limit":             map[string]any{"type": "integer"},
+			"verbose":           map[string]any{"type": "boolean"},
+			"tables":            map[string]any{"type": "array"},
+		},
+		"required":             []any{"connection_string"},
+		"additionalProperties": false,
+	}}
+
+	ok := []map[string]any{
+		{"connection_string": "x"},
+		{"connection_string": "x", "limit": float64(10), "verbose": true, "tables": []any{"a"}},
+	}
+	for _, args := range ok {
+		if err := e.ValidateArgs(args); err != nil {
+			t.Errorf("ValidateArgs(%v) = %v, want nil", args, err)
+		}
+	}
+
+	bad := map[string]map[string]any{
+		"missing required": {"limit": float64(1)},
+		"string for int":   {"connection_string": "x", "limit": "10"},
+		"fractional int":   {"connection_string": "x", "limit": 1.5},
+		"number for bool":  {"connection_string": "x", "verbose": float64(1)},
+		"unknown param":    {"connection_string": "x", "extra": "y"},
+	}
+	for name, args := range bad {
+		if err := e.ValidateArgs(args); err == nil {
+			t.Errorf("%s: ValidateArgs(%v) = nil, want error", name, args)
+		}
+	}
+
+	// Without a schema, anything goes.
+	if err := (ToolEntry{Name: "bare"}).ValidateArgs(map[string]any{"x": 1}); err != nil {
+		t.Errorf("schema-less entry: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package toolregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidateArgs checks args against the entry's published input schema:
+// required parameters must be present, declared parameters must have the
+// declared JSON type, and undeclared parameters are rejected when the schema
+// sets "additionalProperties": false. Args are expected as decoded from a JSON
+// request body. An entry without a schema accepts any args.
+//
+// Types are compared case-insensitively because agents publish either JSON
+// Schema ("string") or genai schema ("STRING") spellings.
+func (e ToolEntry) ValidateArgs(args map[string]any) error {
+	if e.InputSchema == nil {
+		return nil
+	}
+	for _, key := range stringList(e.InputSchema["required"]) {
+		if _, present := args[key]; !present {
+			return fmt.Errorf("tool %s: missing required parameter %q", e.Name, key)
+		}
+	}
+
+	props, _ := e.InputSchema["properties"].(map[string]any)
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // deterministic error for multiple bad params
+
+	for _, key := range keys {
+		prop, declared := props[key].(map[string]any)
+		if !declared {
+			if closed, ok := e.InputSchema["additionalProperties"].(bool); ok && !closed {
+				return fmt.Errorf("tool %s: unknown parameter %q", e.Name, key)
+			}
+			continue
+		}
+		types := schemaTypes(prop["type"])
+		if len(types) == 0 {
+			continue
+		}
+		if !matchesAnyType(args[key], types) {
+			return fmt.Errorf("tool %s: parameter %q must be of type %s", e.Name, key, strings.Join(types, " or "))
+		}
+	}
+	return nil
+}
+
+// stringList reads a []string or []any of strings, as found in a decoded schema.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// schemaTypes returns the lower-cased type names of a schema "type" field,
+// which is either a single name or a list of names.
+func schemaTypes(v any) []string {
+	if s, ok := v.(string); ok {
+		if s == "" || strings.EqualFold(s, "TYPE_UNSPECIFIED") {
+			return nil
+		}
+		return []string{strings.ToLower(s)}
+	}
+	types := stringList(v)
+	for i := range types {
+		types[i] = strings.ToLower(types[i])
+	}
+	return types
+}
+
+func matchesAnyType(v any, types []string) bool {
+	for _, t := range types {
+		if matchesType(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(v any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		switch v.(type) {
+		case float64, json.Number:
+			return true
+		}
+		return false
+	case "integer":
+		switch n := v.(type) {
+		case float64:
+			return n == math.Trunc(n)
+		case json.Number:
+			_, err := n.Int64()
+			return err == nil
+		}
+		return false
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "null":
+		return v == nil
+	}
+	// Unknown type keyword: nothing to check against.
+	return true
+}
@@ -0,0 +1,344 @@
+// Package workhours models when teams are at work: per-weekday shifts in
+// each team's own timezone, minus holidays. One calendar, loaded from YAML,
+// is shared by the auditor's off-hours rule and policy schedule conditions,
+// so follow-the-sun teams are judged by their own hours.
+//
+//	holidays:
+//	  us: ["2026-07-03", "2026-12-25"]
+//	  in: ["2026-08-15", "2026-10-20"]
+//	teams:
+//	  dba-us:
+//	    timezone: America/New_York
+//	    hours:
+//	      mon-fri: "08:00-18:00"
+//	    holidays: [us]
+//	    users: [alice@example.com]
+//	  dba-apac:
+//	    timezone: Asia/Kolkata
+//	    hours:
+//	      mon-fri: "09:00-13:00,14:00-18:00"
+//	      sat: "10-14"
+//	    holidays: [in, "2026-11-09"]
+//	  oncall-night:
+//	    timezone: Europe/Dublin
+//	    hours:
+//	      daily: "22:00-06:00"  # crosses midnight
+package workhours
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AnyTeam names every team of a calendar where a team name is expected.
+const AnyTeam = "*"
+
+const dateLayout = "2006-01-02"
+
+// Calendar is a set of teams' working hours and the holiday lists they use.
+type Calendar struct {
+	// Holidays maps a holiday list name to its dates (YYYY-MM-DD).
+	Holidays map[string][]string `yaml:"holidays,omitempty" json:"holidays,omitempty"`
+	Teams    map[string]*Team    `yaml:"teams" json:"teams"`
+}
+
+// Team is one team's shifts.
+type Team struct {
+	// Timezone the hours are in, e.g. Asia/Kolkata. Empty means UTC.
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Hours maps days to shifts. A day key is a weekday (mon), a range
+	// (mon-fri), a comma list (sat,sun) or "daily"; a shift is one or more
+	// comma-separated HH[:MM]-HH[:MM] spans. A span that ends at or before
+	// its start runs past midnight into the next day.
+	Hours map[string]string `yaml:"hours" json:"hours"`
+	// Holidays are holiday list names from the calendar, or dates
+	// (YYYY-MM-DD). No shift starts on a holiday.
+	Holidays []string `yaml:"holidays,omitempty" json:"holidays,omitempty"`
+	// Users whose activity this team's hours govern, for the auditor's
+	// off-hours rule. A user in no team is judged against every team.
+	Users []string `yaml:"users,omitempty" json:"users,omitempty"`
+
+	loc      *time.Location
+	days     [7][]span // by time.Weekday
+	holidays map[string]bool
+}
+
+// span is a shift from start to end minutes after midnight. end may exceed
+// 24*60 for a shift that runs past midnight.
+type span struct{ start, end int }
+
+// LoadFile reads a calendar from a YAML file.
+func LoadFile(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read hours calendar: %w", err)
+	}
+	return Load(data)
+}
+
+// Load parses and validates a calendar.
+func Load(data []byte) (*Calendar, error) {
+	var c Calendar
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse hours calendar: %w", err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Daily returns a calendar with one team, named "default", working from
+// start to end (hours, 0-23) every day in loc. An end at or before start
+// runs past midnight. It stands in for a single allowed-hours range.
+func Daily(start, end int, loc *time.Location) (*Calendar, error) {
+	if start < 0 || start > 23 || end < 0 || end > 23 {
+		return nil, fmt.Errorf("allowed hours %d-%d: hours must be 0-23", start, end)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	c := &Calendar{Teams: map[string]*Team{
+		"default": {Timezone: loc.String(), Hours: map[string]string{"daily": fmt.Sprintf("%02d-%02d", start, end)}},
+	}}
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
+	c.Teams["default"].loc = loc // Local has no loadable name
+	return c, nil
+}
+
+// Team returns the named team, or nil.
+func (c *Calendar) Team(name string) *Team {
+	if c == nil {
+		return nil
+	}
+	return c.Teams[name]
+}
+
+// TeamNames returns the calendar's team names, sorted.
+func (c *Calendar) TeamNames() []string {
+	names := make([]string, 0, len(c.Teams))
+	for name := range c.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnShift reports whether team is at work at at. AnyTeam asks whether any
+// team is. An unknown team is never on shift.
+func (c *Calendar) OnShift(team string, at time.Time) bool {
+	if c == nil {
+		return false
+	}
+	if team != AnyTeam {
+		return c.Teams[team].OnShift(at)
+	}
+	for _, t := range c.Teams {
+		if t.OnShift(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserOnShift reports whether user's activity at at is within working hours:
+// whether a team that lists user is on shift, or, for a user no team lists,
+// whether any team is. It also returns the teams user was judged against.
+func (c *Calendar) UserOnShift(user string, at time.Time) (bool, []string) {
+	var own []string
+	for _, name := range c.TeamNames() {
+		for _, u := range c.Teams[name].Users {
+			if u == user {
+				own = append(own, name)
+				break
+			}
+		}
+	}
+	teams := own
+	if len(teams) == 0 {
+		teams = c.TeamNames()
+	}
+	for _, name := range teams {
+		if c.Teams[name].OnShift(at) {
+			return true, teams
+		}
+	}
+	return false, teams
+}
+
+// OnShift reports whether the team is at work at at. Safe on a nil receiver,
+// which is never on shift.
+func (t *Team) OnShift(at time.Time) bool {
+	if t == nil || t.loc == nil {
+		return false
+	}
+	at = at.In(t.loc)
+	minute := at.Hour()*60 + at.Minute()
+	if !t.holidays[at.Format(dateLayout)] {
+		for _, s := range t.days[at.Weekday()] {
+			if minute >= s.start && minute < s.end {
+				return true
+			}
+		}
+	}
+	// A shift that started yesterday and runs past midnight.
+	prev := at.AddDate(0, 0, -1)
+	if !t.holidays[prev.Format(dateLayout)] {
+		for _, s := range t.days[prev.Weekday()] {
+			if s.end > 24*60 && minute < s.end-24*60 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compile validates the calendar and resolves timezones, days and holidays.
+func (c *Calendar) compile() error {
+	if len(c.Teams) == 0 {
+		return fmt.Errorf("hours calendar: no teams")
+	}
+	for name, dates := range c.Holidays {
+		for _, d := range dates {
+			if _, err := time.Parse(dateLayout, d); err != nil {
+				return fmt.Errorf("hours calendar: holidays %q: %q is not a YYYY-MM-DD date", name, d)
+			}
+		}
+	}
+	for name, t := range c.Teams {
+		if t == nil {
+			return fmt.Errorf("hours calendar: team %q has no hours", name)
+		}
+		if err := t.compile(c.Holidays); err != nil {
+			return fmt.Errorf("hours calendar: team %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (t *Team) compile(lists map[string][]string) error {
+	t.loc = time.UTC
+	if t.Timezone != "" {
+		loc, err := time.LoadLocation(t.Timezone)
+		if err != nil {
+			return fmt.Errorf("unknown timezone %q", t.Timezone)
+		}
+		t.loc = loc
+	}
+	if len(t.Hours) == 0 {
+		return fmt.Errorf("no hours")
+	}
+	t.days = [7][]span{}
+	for key, spec := range t.Hours {
+		days, err := parseDays(key)
+		if err != nil {
+			return err
+		}
+		spans, err := parseSpans(spec)
+		if err != nil {
+			return fmt.Errorf("hours %q: %w", key, err)
+		}
+		for _, d := range days {
+			t.days[d] = append(t.days[d], spans...)
+		}
+	}
+	t.holidays = make(map[string]bool)
+	for _, h := range t.Holidays {
+		if dates, ok := lists[h]; ok {
+			for _, d := range dates {
+				t.holidays[d] = true
+			}
+			continue
+		}
+		if _, err := time.Parse(dateLayout, h); err != nil {
+			return fmt.Errorf("holidays: %q is neither a holiday list nor a YYYY-MM-DD date", h)
+		}
+		t.holidays[h] = true
+	}
+	return nil
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseDays parses a day key: "daily", a weekday, a range such as mon-fri
+// (which may wrap, e.g. fri-mon) or a comma list of those.
+func parseDays(key string) ([]time.Weekday, error) {
+	var out []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "daily" {
+			part = "sun-sat"
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok1 := dayNames[strings.TrimSpace(from)]
+		last, ok2 := first, true
+		if isRange {
+			last, ok2 = dayNames[strings.TrimSpace(to)]
+		}
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("day %q is not daily, mon..sun or a range like mon-fri", part)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			out = append(out, d)
+			if d == last {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseSpans parses comma-separated HH[:MM]-HH[:MM] spans.
+func parseSpans(spec string) ([]span, error) {
+	var out []span
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("shift %q is not HH:MM-HH:MM", part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, err
+		}
+		if start == 24*60 {
+			return nil, fmt.Errorf("shift %q starts at 24:00", part)
+		}
+		if end <= start {
+			end += 24 * 60
+		}
+		out = append(out, span{start, end})
+	}
+	return out, nil
+}
+
+// parseClock parses HH or HH:MM into minutes after midnight; 24:00 is the
+// end of the day.
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	hh, mm, hasMin := strings.Cut(s, ":")
+	h, err := strconv.Atoi(hh)
+	m := 0
+	if err == nil && hasMin {
+		m, err = strconv.Atoi(mm)
+	}
+	if err != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("time %q is not HH or HH:MM", s)
+	}
+	return h*60 + m, nil
+}
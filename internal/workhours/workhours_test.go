@@ -0,0 +1,103 @@
+package workhours
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCalendar = `
+holidays:
+  us: ["2026-07-03"]
+teams:
+  dba-us:
+    timezone: America/New_York
+    hours:
+      mon-fri: "08:00-18:00"
+    holidays: [us]
+    users: [alice]
+  dba-apac:
+    timezone: Asia/Kolkata
+    hours:
+      mon-fri: "09-13,14-18"
+      sat: "10-14"
+  night:
+    timezone: UTC
+    hours:
+      fri: "22:00-06:00"
+    holidays: ["2026-07-10"]
+`
+
+func TestTeamOnShift(t *testing.T) {
+	c, err := Load([]byte(testCalendar))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	utc := func(s string) time.Time {
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+	for _, tt := range []struct {
+		team string
+		at   string
+		want bool
+	}{
+		{"dba-us", "2026-07-01T13:00:00Z", true},    // Wed 09:00 EDT
+		{"dba-us", "2026-07-01T11:00:00Z", false},   // Wed 07:00 EDT
+		{"dba-us", "2026-07-03T15:00:00Z", false},   // Fri holiday
+		{"dba-us", "2026-07-04T15:00:00Z", false},   // Sat
+		{"dba-apac", "2026-07-01T04:00:00Z", true},  // Wed 09:30 IST
+		{"dba-apac", "2026-07-01T08:00:00Z", false}, // Wed 13:30 IST, lunch
+		{"dba-apac", "2026-07-04T05:00:00Z", true},  // Sat 10:30 IST
+		{"night", "2026-07-03T23:00:00Z", true},     // Fri night
+		{"night", "2026-07-04T05:59:00Z", true},     // Sat morning, Friday's shift
+		{"night", "2026-07-04T06:00:00Z", false},
+		{"night", "2026-07-11T01:00:00Z", false}, // Friday's shift was a holiday
+		{"nobody", "2026-07-01T13:00:00Z", false},
+		{AnyTeam, "2026-07-01T04:00:00Z", true},
+		{AnyTeam, "2026-07-05T12:00:00Z", false}, // Sunday
+	} {
+		if got := c.OnShift(tt.team, utc(tt.at)); got != tt.want {
+			t.Errorf("OnShift(%s, %s) = %v, want %v", tt.team, tt.at, got, tt.want)
+		}
+	}
+
+	// alice is judged by her own team only; bob, in no team, by every team.
+	apacMorning := utc("2026-07-01T04:00:00Z")
+	if ok, teams := c.UserOnShift("alice", apacMorning); ok || len(teams) != 1 {
+		t.Errorf("UserOnShift(alice) = %v, %v; want off shift judged by dba-us", ok, teams)
+	}
+	if ok, teams := c.UserOnShift("bob", apacMorning); !ok || len(teams) != 3 {
+		t.Errorf("UserOnShift(bob) = %v, %v; want on shift judged by all teams", ok, teams)
+	}
+}
+
+func TestLoadRejectsBadCalendars(t *testing.T) {
+	for _, tt := range []struct{ yaml, want string }{
+		{`teams: {}`, "no teams"},
+		{"teams:\n  a:\n    hours: {mon-fry: \"9-17\"}", "mon..sun"},
+		{"teams:\n  a:\n    hours: {mon: \"9-25\"}", "HH or HH:MM"},
+		{"teams:\n  a:\n    timezone: Mars/Olympus\n    hours: {mon: \"9-17\"}", "unknown timezone"},
+		{"teams:\n  a:\n    hours: {mon: \"9-17\"}\n    holidays: [xmas]", "neither a holiday list"},
+	} {
+		if _, err := Load([]byte(tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want containing %q", tt.yaml, err, tt.want)
+		}
+	}
+}
+
+func TestDaily(t *testing.T) {
+	c, err := Daily(22, 6, time.UTC)
+	if err != nil {
+		t.Fatalf("Daily: %v", err)
+	}
+	if !c.OnShift(AnyTeam, time.Date(2026, 7, 1, 23, 0, 0, 0, time.UTC)) || c.OnShift(AnyTeam, time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Error("Daily(22, 6): wrong overnight range")
+	}
+	if _, err := Daily(9, 24, time.UTC); err == nil {
+		t.Error("Daily accepted hour 24")
+	}
+}
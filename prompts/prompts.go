@@ -23,3 +23,6 @@ var Research string
 
 //go:embed sysadmin.txt
 var Sysadmin string
+
+//go:embed kb.txt
+var KB string
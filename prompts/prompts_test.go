@@ -11,6 +11,7 @@ func TestPrompts_NonEmpty(t *testing.T) {
 		"Database":     Database,
 		"K8s":          K8s,
 		"Incident":     Incident,
+		"KB":           KB,
 	}
 
 	for name, content := range prompts {
@@ -46,6 +47,11 @@ func TestPrompts_ExpectedKeywords(t *testing.T) {
 			Incident,
 			[]string{"create_incident_bundle", "incident", "bundle"},
 		},
+		{
+			"KB",
+			KB,
+			[]string{"search_knowledge_base", "get_document", "runbook", "postmortem"},
+		},
 		{
 			"Orchestrator",
 			Orchestrator,
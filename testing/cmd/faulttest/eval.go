@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"helpdesk/testing/evallib"
+	"helpdesk/testing/faultlib"
+)
+
+// ── eval ─────────────────────────────────────────────────────────────────
+
+// cmdEval runs the routing corpus against the orchestrator (or replays
+// recorded fixtures), scores it and optionally gates on a baseline report.
+// Exits 1 when routing accuracy regresses past --tolerance or falls below
+// --min-accuracy.
+func cmdEval(args []string) {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	cfg := &faultlib.HarnessConfig{}
+	fs.StringVar(&cfg.OrchestratorURL, "orchestrator", os.Getenv("FAULTTEST_ORCHESTRATOR_URL"), "Orchestrator agent A2A URL (or FAULTTEST_ORCHESTRATOR_URL)")
+	fs.StringVar(&cfg.AuditURL, "audit-url", "", "Audit service base URL; when set, routing and tool evidence come from the audit trail")
+	fs.StringVar(&cfg.GatewayAPIKey, "api-key", os.Getenv("HELPDESK_CLIENT_API_KEY"), "Bearer token for the audit service (or HELPDESK_CLIENT_API_KEY)")
+	fs.StringVar(&cfg.ConnStr, "conn", os.Getenv("FAULTTEST_CONN_STR"), "Connection string substituted for {{connection_string}}")
+	fs.StringVar(&cfg.AgentConnStr, "agent-conn", os.Getenv("FAULTTEST_AGENT_CONN_STR"), "Connection string or alias substituted for {{connection_string}} (overrides --conn)")
+	fs.StringVar(&cfg.KubeContext, "context", "", "Kubernetes context substituted for {{kube_context}}")
+	fs.StringVar(&cfg.ReportDir, "report-dir", ".", "Directory to write the JSON report")
+
+	corpusPath := fs.String("corpus", "", "Corpus YAML file (default: the built-in corpus)")
+	ids := fs.String("ids", "", "Comma-separated case IDs to run")
+	tags := fs.String("tags", "", "Comma-separated tags; run cases carrying any of them")
+	fixturesPath := fs.String("fixtures", "", "Replay observations from this fixtures file instead of calling the orchestrator")
+	recordPath := fs.String("record", "", "Write the observations of a live run to this fixtures file")
+	baselinePath := fs.String("baseline", "", "Earlier eval report to compare routing accuracy against")
+	tolerance := fs.Float64("tolerance", 0, "Routing accuracy drop (0.0-1.0) accepted against --baseline")
+	minAccuracy := fs.Float64("min-accuracy", 0, "Fail when routing accuracy is below this (0.0-1.0)")
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var (
+		corpus *evallib.Corpus
+		err    error
+	)
+	if *corpusPath != "" {
+		corpus, err = evallib.LoadCorpus(*corpusPath)
+	} else {
+		corpus, err = evallib.LoadBuiltinCorpus()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cases := evallib.FilterCases(corpus, splitList(*ids), splitList(*tags))
+	if len(cases) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no corpus cases match the filters")
+		os.Exit(1)
+	}
+
+	mode := "live"
+	var runner *evallib.Runner
+	if *fixturesPath != "" {
+		fixtures, err := evallib.LoadFixtures(*fixturesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		mode = "fixtures"
+		runner = evallib.NewFixtureRunner(fixtures)
+	} else {
+		if cfg.OrchestratorURL == "" {
+			fmt.Fprintln(os.Stderr, "Error: --orchestrator is required unless --fixtures is set")
+			os.Exit(1)
+		}
+		runner = evallib.NewRunner(cfg)
+	}
+
+	ctx := context.Background()
+	recorded := make(map[string]evallib.Observation, len(cases))
+	results := make([]evallib.CaseResult, 0, len(cases))
+	for _, c := range cases {
+		c.Query = faultlib.ResolvePrompt(c.Query, cfg)
+		slog.Info("eval: running case", "case", c.ID, "expected_agent", c.ExpectedAgent, "mode", mode)
+		obs := runner.Observe(ctx, c)
+		recorded[c.ID] = obs
+		results = append(results, evallib.Score(c, obs))
+	}
+
+	runID := uuid.New().String()[:8]
+	report := evallib.BuildReport(runID, mode, results)
+	printEvalSummary(report)
+
+	reportFile := fmt.Sprintf("%s/eval-%s.json", cfg.ReportDir, runID)
+	if err := report.WriteJSON(reportFile); err != nil {
+		slog.Error("failed to write report", "err", err)
+	} else {
+		fmt.Printf("Report written to %s\n", reportFile)
+	}
+	if *recordPath != "" && mode == "live" {
+		if err := evallib.SaveFixtures(*recordPath, recorded); err != nil {
+			slog.Error("failed to write fixtures", "err", err)
+		} else {
+			fmt.Printf("Fixtures written to %s\n", *recordPath)
+		}
+	}
+
+	failed := false
+	if *baselinePath != "" {
+		baseline, err := evallib.LoadReport(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cmp := evallib.Compare(baseline, report, *tolerance)
+		fmt.Println(cmp.String())
+		failed = cmp.Failed
+	}
+	if report.Summary.RoutingAccuracy < *minAccuracy {
+		fmt.Printf("FAIL: routing accuracy %.1f%% is below --min-accuracy %.1f%%\n",
+			report.Summary.RoutingAccuracy*100, *minAccuracy*100)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printEvalSummary writes a human-readable eval summary to stdout.
+func printEvalSummary(r evallib.Report) {
+	fmt.Printf("\n=== Routing Eval Report: %s (%s) ===\n\n", r.ID, r.Mode)
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		actual := res.ActualAgent
+		if actual == "" {
+			actual = "(none)"
+		}
+		fmt.Printf("[%s] %-24s expected %-24s got %-24s score: %d%%\n",
+			status, res.CaseID, res.ExpectedAgent, actual, int(res.Score*100))
+		if len(res.MissingTools) > 0 {
+			fmt.Printf("       missing tools: %s\n", strings.Join(res.MissingTools, ", "))
+		}
+		if res.Error != "" {
+			fmt.Printf("       error: %s\n", res.Error)
+		}
+	}
+	s := r.Summary
+	fmt.Printf("\nCases: %d  Passed: %d  Failed: %d\n", s.Total, s.Passed, s.Failed)
+	fmt.Printf("Routing accuracy: %.1f%%  Tool recall: %.1f%%  Score: %.1f%%\n",
+		s.RoutingAccuracy*100, s.ToolRecall*100, s.Score*100)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
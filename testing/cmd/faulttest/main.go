@@ -19,6 +19,7 @@ import (
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 
+	"helpdesk/internal/audit"
 	"helpdesk/internal/buildinfo"
 	"helpdesk/testing/faultlib"
 	"helpdesk/testing/testutil"
@@ -40,7 +41,7 @@ func main() {
 
 	switch os.Args[1] {
 	case "version":
-		fmt.Println(buildinfo.Version)
+		fmt.Println(buildinfo.Get())
 	case "list":
 		cmdList(os.Args[2:])
 	case "run":
@@ -57,6 +58,8 @@ func main() {
 		cmdShow(os.Args[2:])
 	case "vault":
 		cmdVault(os.Args[2:])
+	case "eval":
+		cmdEval(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
 		printUsage()
@@ -77,6 +80,7 @@ Commands:
   example    Print an annotated example customer catalog entry to stdout
   show       Print a fault definition as YAML (pipe to a file to customize it)
   vault      Fault↔playbook pairing table, pass rate trends, drift detection
+  eval       Score orchestrator routing against the prompt eval corpus
 `)
 }
 
@@ -782,6 +786,7 @@ func autoDBServerID(connStr string) string {
 // registerAutoDBWithSysadmin registers the ephemeral auto-DB container with the
 // sysadmin agent via its register_infra_db direct-tool endpoint. This is needed
 // so the sysadmin agent can resolve the container for check_host and restart_container.
+// The call is signed when HELPDESK_A2A_SIGNING_KEY(_SYSADMIN_AGENT) is set.
 func registerAutoDBWithSysadmin(sysadminURL, apiKey, serverID, connStr, containerName string) error {
 	body, err := json.Marshal(map[string]any{
 		"server_id":      serverID,
@@ -801,6 +806,11 @@ func registerAutoDBWithSysadmin(sysadminURL, apiKey, serverID, connStr, containe
 	if apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
+	signingKey, err := audit.ResolveA2ASigningKey(context.Background(), "sysadmin_agent")
+	if err != nil {
+		return err
+	}
+	audit.SignA2AToolRequest(req, body, signingKey)
 	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
 	if err != nil {
 		return err
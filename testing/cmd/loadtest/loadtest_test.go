@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+)
+
+// fakeAuditd answers the auditd endpoints loadtest calls and remembers the
+// events it was sent.
+type fakeAuditd struct {
+	mu        sync.Mutex
+	events    []audit.Event
+	outcomes  int
+	approved  []string
+	approveBy []string
+}
+
+func (f *fakeAuditd) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("POST /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		var e audit.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.events = append(f.events, e)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"event_id": e.EventID}) //nolint:errcheck
+	})
+	mux.HandleFunc("POST /v1/events/{eventID}/outcome", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.outcomes++
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("POST /v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"approval_id":"apr_1","status":"pending"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("GET /v1/approvals/{approvalID}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"approval_id":"apr_1","status":"pending"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("POST /v1/approvals/{approvalID}/approve", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.approved = append(f.approved, r.PathValue("approvalID"))
+		f.approveBy = append(f.approveBy, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("GET /v1/verify", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		n := len(f.events)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(audit.ChainStatus{Valid: true, TotalEvents: n}) //nolint:errcheck
+	})
+	return mux
+}
+
+func TestTraceGen_FanOut(t *testing.T) {
+	gen := newTraceGen(1, 4, "acme")
+	totalTools := 0
+	const n = 500
+	for range n {
+		tr := gen.next()
+		evs := tr.events
+		if evs[0].EventType != audit.EventTypeGatewayRequest || evs[1].EventType != audit.EventTypeDelegation ||
+			evs[len(evs)-1].EventType != audit.EventTypeOutcome {
+			t.Fatalf("unexpected trace shape: %v ... %v", evs[0].EventType, evs[len(evs)-1].EventType)
+		}
+		if evs[1].ParentID != evs[0].EventID || evs[len(evs)-1].ParentID != evs[0].EventID {
+			t.Fatal("delegation and outcome must hang off the anchor")
+		}
+		tools := evs[2 : len(evs)-1]
+		if len(tools) < 1 || len(tools) > 7 {
+			t.Fatalf("tool calls = %d, want 1..7", len(tools))
+		}
+		for _, e := range tools {
+			if e.EventType != audit.EventTypeToolExecution || e.ParentID != evs[1].EventID || e.TraceID != tr.id {
+				t.Fatalf("tool event not linked to the delegation: %+v", e)
+			}
+			if e.Session.TenantID != "acme" || e.Tool.Agent != evs[1].Decision.Agent {
+				t.Fatalf("tool event session/agent mismatch: %+v", e)
+			}
+		}
+		totalTools += len(tools)
+	}
+	if mean := float64(totalTools) / n; mean < 3.5 || mean > 4.5 {
+		t.Errorf("mean fan-out = %.2f, want about 4", mean)
+	}
+}
+
+func TestRun_ReportsStagesAndChain(t *testing.T) {
+	fake := &fakeAuditd{}
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	report := run(t.Context(), config{
+		AuditURL:      srv.URL,
+		ApproverKey:   "approver-key",
+		GatewayURL:    srv.URL,
+		Rates:         []float64{200},
+		StageDuration: 500 * time.Millisecond,
+		Fanout:        2,
+		Workers:       16,
+		ApprovalRate:  20,
+		GatewayRate:   20,
+		MaxErrorRate:  0.01,
+		Verify:        true,
+		Seed:          1,
+	})
+
+	if len(report.Stages) != 1 {
+		t.Fatalf("stages = %d, want 1", len(report.Stages))
+	}
+	stage := report.Stages[0]
+	if stage.Events == 0 || stage.Ops["ingest"].Count != int(stage.Events) {
+		t.Errorf("events = %d, ingest count = %d", stage.Events, stage.Ops["ingest"].Count)
+	}
+	if got := stage.Ops["outcome"].Count; int64(got) != stage.Traces {
+		t.Errorf("outcome posts = %d, want one per trace (%d)", got, stage.Traces)
+	}
+	if stage.Ops["approval_create"].Count == 0 || stage.Ops["approval_approve"].Errors != 0 {
+		t.Errorf("approval ops = %+v / %+v", stage.Ops["approval_create"], stage.Ops["approval_approve"])
+	}
+	// The fake has no /api/v1/agents etc., so gateway reads other than
+	// /health fail and are counted as errors rather than ingest failures.
+	if stage.Ops["gateway_health"].Count == 0 || stage.Ops["gateway_health"].Errors != 0 {
+		t.Errorf("gateway_health = %+v", stage.Ops["gateway_health"])
+	}
+	if stage.Ops["ingest"].Errors != 0 {
+		t.Errorf("ingest errors = %d: %v", stage.Ops["ingest"].Errors, stage.ErrorSamples)
+	}
+	if report.Chain == nil || !report.Chain.Valid || report.Chain.TotalEvents != int(stage.Events) {
+		t.Errorf("chain = %+v, want valid over %d events", report.Chain, stage.Events)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, by := range fake.approveBy {
+		if by != "Bearer approver-key" {
+			t.Fatalf("approve sent Authorization %q, want the approver key", by)
+		}
+	}
+}
+
+func TestStageEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  StageReport
+		reason string
+	}{
+		{"keeps up", StageReport{TargetRate: 100, AchievedRate: 98, Ops: map[string]OpStats{"ingest": {Count: 100, P99Ms: 50}}}, ""},
+		{"falls behind", StageReport{TargetRate: 100, AchievedRate: 60, Ops: map[string]OpStats{"ingest": {Count: 100}}}, "achieved 60.0 of 100.0"},
+		{"errors", StageReport{TargetRate: 100, AchievedRate: 100, Ops: map[string]OpStats{"ingest": {Count: 100, Errors: 5}}}, "error rate 5.0%"},
+		{"slow", StageReport{TargetRate: 100, AchievedRate: 100, Ops: map[string]OpStats{"ingest": {Count: 100, P99Ms: 1500}}}, "p99 1500ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stage
+			s.evaluate(0.01, time.Second)
+			if s.Saturated != (tt.reason != "") {
+				t.Fatalf("saturated = %v, reasons %v", s.Saturated, s.Reasons)
+			}
+			if tt.reason != "" && !strings.Contains(strings.Join(s.Reasons, "; "), tt.reason) {
+				t.Errorf("reasons = %v, want one containing %q", s.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRun_StopsAfterSaturatedStage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database is locked", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	report := run(t.Context(), config{
+		AuditURL:      srv.URL,
+		Rates:         []float64{100, 200},
+		StageDuration: 200 * time.Millisecond,
+		Fanout:        1,
+		Workers:       4,
+		MaxErrorRate:  0.01,
+		Seed:          1,
+	})
+	if len(report.Stages) != 1 || !report.Stages[0].Saturated || report.MaxSustainedRate != 0 {
+		t.Fatalf("stages = %+v, max sustained = %v", report.Stages, report.MaxSustainedRate)
+	}
+	if len(report.Stages[0].ErrorSamples) == 0 || !strings.Contains(report.Stages[0].ErrorSamples[0], "database is locked") {
+		t.Errorf("error samples = %v", report.Stages[0].ErrorSamples)
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	got, err := parseRates("50, 100,,200")
+	if err != nil || len(got) != 3 || got[2] != 200 {
+		t.Fatalf("parseRates = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "0", "fast"} {
+		if _, err := parseRates(bad); err == nil {
+			t.Errorf("parseRates(%q) succeeded", bad)
+		}
+	}
+}
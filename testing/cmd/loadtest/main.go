@@ -0,0 +1,366 @@
+// Command loadtest drives synthetic audit event streams, approval requests
+// and gateway reads against a running auditd (and optionally a gateway) at
+// stepped rates, and reports ingest latency, approval API latency and
+// errors per stage as JSON. It finds the event rate at which the audit hash
+// chain stops keeping up.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/internal/buildinfo"
+)
+
+// config holds the loadtest flags.
+type config struct {
+	AuditURL      string
+	APIKey        string
+	ApproverKey   string
+	GatewayURL    string
+	GatewayKey    string
+	Rates         []float64
+	StageDuration time.Duration
+	Fanout        int
+	Workers       int
+	ApprovalRate  float64
+	GatewayRate   float64
+	MaxErrorRate  float64
+	MaxP99        time.Duration
+	Verify        bool
+	Tenant        string
+	Seed          int64
+}
+
+// Report is the JSON document loadtest writes.
+type Report struct {
+	Version       string        `json:"version"`
+	StartedAt     time.Time     `json:"started_at"`
+	Auditd        string        `json:"auditd"`
+	Gateway       string        `json:"gateway,omitempty"`
+	Fanout        int           `json:"fanout"`
+	Workers       int           `json:"workers"`
+	StageDuration string        `json:"stage_duration"`
+	Stages        []StageReport `json:"stages"`
+	// MaxSustainedRate is the highest target event rate auditd kept up with;
+	// 0 when even the first stage saturated.
+	MaxSustainedRate float64     `json:"max_sustained_rate"`
+	Chain            *ChainCheck `json:"chain,omitempty"`
+}
+
+// StageReport is the result of driving one target event rate.
+type StageReport struct {
+	TargetRate   float64 `json:"target_rate"`
+	AchievedRate float64 `json:"achieved_rate"`
+	Traces       int64   `json:"traces"`
+	Events       int64   `json:"events"`
+	ElapsedMs    int64   `json:"elapsed_ms"`
+	// ScheduleLagMs is how far the dispatcher fell behind its schedule
+	// because every worker was busy waiting on auditd.
+	ScheduleLagMs int64              `json:"schedule_lag_ms"`
+	MaxInFlight   int                `json:"max_in_flight"`
+	Ops           map[string]OpStats `json:"ops"`
+	ErrorSamples  []string           `json:"error_samples,omitempty"`
+	Saturated     bool               `json:"saturated"`
+	Reasons       []string           `json:"reasons,omitempty"`
+}
+
+// ChainCheck is the result of verifying the hash chain after the run.
+type ChainCheck struct {
+	Valid       bool   `json:"valid"`
+	TotalEvents int    `json:"total_events"`
+	DurationMs  int64  `json:"duration_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	var cfg config
+	var rates, out string
+	var version bool
+	auditURL := os.Getenv("HELPDESK_AUDIT_URL")
+	if auditURL == "" {
+		auditURL = "http://localhost:1199"
+	}
+	flag.StringVar(&cfg.AuditURL, "auditd", auditURL, "URL of the audit service (or set HELPDESK_AUDIT_URL)")
+	flag.StringVar(&cfg.APIKey, "api-key", os.Getenv("HELPDESK_AUDIT_API_KEY"), "Bearer token for auditd (or set HELPDESK_AUDIT_API_KEY)")
+	flag.StringVar(&cfg.ApproverKey, "approver-api-key", "", "Bearer token that approves the test's approval requests; must be a different identity from -api-key when auditd enforces four-eyes")
+	flag.StringVar(&cfg.GatewayURL, "gateway", "", "Gateway URL; empty skips gateway traffic")
+	flag.StringVar(&cfg.GatewayKey, "gateway-api-key", "", "Bearer token for the gateway")
+	flag.StringVar(&rates, "rates", "50,100,200,400", "Comma-separated target event rates (events/s), one stage each, in order")
+	flag.DurationVar(&cfg.StageDuration, "stage-duration", 30*time.Second, "How long to drive each rate")
+	flag.IntVar(&cfg.Fanout, "fanout", 4, "Mean tool calls per trace")
+	flag.IntVar(&cfg.Workers, "workers", 64, "Maximum concurrent requests")
+	flag.Float64Var(&cfg.ApprovalRate, "approval-rate", 1, "Approval flows (create, get, approve) per second; 0 disables")
+	flag.Float64Var(&cfg.GatewayRate, "gateway-rate", 5, "Gateway reads per second when -gateway is set")
+	flag.Float64Var(&cfg.MaxErrorRate, "max-error-rate", 0.01, "A stage whose ingest error rate exceeds this is saturated")
+	flag.DurationVar(&cfg.MaxP99, "max-p99", time.Second, "A stage whose ingest p99 exceeds this is saturated; 0 disables")
+	flag.BoolVar(&cfg.Verify, "verify", true, "Verify the hash chain after the run")
+	flag.StringVar(&cfg.Tenant, "tenant", "", "Tenant to record the synthetic events under")
+	flag.Int64Var(&cfg.Seed, "seed", time.Now().UnixNano(), "Random seed for trace generation")
+	flag.StringVar(&out, "out", "", "Write the JSON report to this file instead of stdout")
+	flag.BoolVar(&version, "version", false, "Print the build version and exit")
+	flag.Parse()
+
+	if version {
+		fmt.Println(buildinfo.Get())
+		return
+	}
+	var err error
+	if cfg.Rates, err = parseRates(rates); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -rates: %v\n", err)
+		os.Exit(2)
+	}
+	cfg.AuditURL = strings.TrimSuffix(cfg.AuditURL, "/")
+	cfg.GatewayURL = strings.TrimSuffix(cfg.GatewayURL, "/")
+
+	ctx := context.Background()
+	if err := checkHealth(ctx, cfg.AuditURL); err != nil {
+		fmt.Fprintf(os.Stderr, "auditd not reachable at %s: %v\n", cfg.AuditURL, err)
+		os.Exit(1)
+	}
+
+	report := run(ctx, cfg)
+
+	data, _ := json.MarshalIndent(report, "", "  ")
+	if out == "" {
+		fmt.Println(string(data))
+	} else if err := os.WriteFile(out, append(data, '\n'), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "write report: %v\n", err)
+		os.Exit(1)
+	}
+	if report.Chain != nil && !report.Chain.Valid {
+		slog.Error("hash chain failed verification after the run", "error", report.Chain.Error)
+		os.Exit(1)
+	}
+}
+
+// parseRates parses the -rates list.
+func parseRates(s string) ([]float64, error) {
+	var rates []float64
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		r, err := strconv.ParseFloat(f, 64)
+		if err != nil || r <= 0 {
+			return nil, fmt.Errorf("%q is not a positive rate", f)
+		}
+		rates = append(rates, r)
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no rates given")
+	}
+	return rates, nil
+}
+
+// checkHealth fails unless auditd answers /health.
+func checkHealth(ctx context.Context, auditURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, auditURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	return doRequest(&http.Client{Timeout: 5 * time.Second}, req)
+}
+
+// run drives each rate in turn, stopping after the first stage that
+// saturates, then verifies the chain.
+func run(ctx context.Context, cfg config) Report {
+	report := Report{
+		Version:       buildinfo.Version,
+		StartedAt:     time.Now().UTC(),
+		Auditd:        cfg.AuditURL,
+		Gateway:       cfg.GatewayURL,
+		Fanout:        cfg.Fanout,
+		Workers:       cfg.Workers,
+		StageDuration: cfg.StageDuration.String(),
+		Stages:        []StageReport{},
+	}
+	gen := newTraceGen(cfg.Seed, cfg.Fanout, cfg.Tenant)
+	for _, rate := range cfg.Rates {
+		slog.Info("starting stage", "rate", rate, "duration", cfg.StageDuration)
+		stage := runStage(ctx, cfg, gen, rate)
+		report.Stages = append(report.Stages, stage)
+		slog.Info("stage finished", "rate", rate, "achieved", stage.AchievedRate,
+			"ingest_p99_ms", stage.Ops["ingest"].P99Ms, "saturated", stage.Saturated)
+		if stage.Saturated {
+			break
+		}
+		report.MaxSustainedRate = rate
+	}
+	if cfg.Verify {
+		report.Chain = verifyChain(ctx, cfg)
+	}
+	return report
+}
+
+// runStage records traces at rate events per second for cfg.StageDuration,
+// alongside the approval and gateway side loads, and waits for every
+// request it started to finish.
+func runStage(ctx context.Context, cfg config, gen *traceGen, rate float64) StageReport {
+	store := audit.NewRemoteStore(cfg.AuditURL)
+	requester := audit.NewApprovalClient(cfg.AuditURL)
+	if cfg.APIKey != "" {
+		store = store.WithAPIKey(cfg.APIKey)
+		requester = requester.WithAPIKey(cfg.APIKey)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	rec := newRecorder()
+	sem := make(chan struct{}, max(cfg.Workers, 1))
+	var wg sync.WaitGroup
+	var traces, events atomic.Int64
+	var lastTrace atomic.Value
+	lastTrace.Store("")
+
+	stageCtx, cancel := context.WithTimeout(ctx, cfg.StageDuration)
+	defer cancel()
+	start := time.Now()
+
+	// spawn runs fn on a worker, or returns false once the stage is over.
+	spawn := func(fn func()) bool {
+		select {
+		case sem <- struct{}{}:
+		case <-stageCtx.Done():
+			return false
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn()
+		}()
+		return true
+	}
+
+	var side sync.WaitGroup
+	if cfg.ApprovalRate > 0 {
+		approve := approver(client, cfg.AuditURL, cfg.ApproverKey)
+		side.Add(1)
+		go func() {
+			defer side.Done()
+			every(stageCtx, cfg.ApprovalRate, func(int) bool {
+				traceID := lastTrace.Load().(string)
+				return spawn(func() { approvalFlow(ctx, requester, approve, rec, traceID) })
+			})
+		}()
+	}
+	if cfg.GatewayURL != "" && cfg.GatewayRate > 0 {
+		side.Add(1)
+		go func() {
+			defer side.Done()
+			every(stageCtx, cfg.GatewayRate, func(i int) bool {
+				traceID := lastTrace.Load().(string)
+				return spawn(func() { gatewayRead(ctx, client, cfg.GatewayURL, cfg.GatewayKey, rec, i, traceID) })
+			})
+		}()
+	}
+
+	// Dispatch traces on a schedule that averages rate events per second.
+	var lag time.Duration
+	next := start
+	for {
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-stageCtx.Done():
+			case <-time.After(wait):
+			}
+		}
+		if stageCtx.Err() != nil {
+			break
+		}
+		t := gen.next()
+		if !spawn(func() {
+			events.Add(int64(sendTrace(ctx, store, rec, t)))
+			lastTrace.Store(t.id)
+		}) {
+			break
+		}
+		traces.Add(1)
+		lag = max(lag, time.Since(next))
+		next = next.Add(time.Duration(float64(len(t.events)) / rate * float64(time.Second)))
+	}
+	side.Wait()
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	rec.mu.Lock()
+	errorSamples, maxInFlight := rec.errorSamples, rec.maxInFlight
+	rec.mu.Unlock()
+	stage := StageReport{
+		TargetRate:    rate,
+		AchievedRate:  float64(events.Load()) / elapsed.Seconds(),
+		Traces:        traces.Load(),
+		Events:        events.Load(),
+		ElapsedMs:     elapsed.Milliseconds(),
+		ScheduleLagMs: lag.Milliseconds(),
+		MaxInFlight:   maxInFlight,
+		Ops:           rec.summary(),
+		ErrorSamples:  errorSamples,
+	}
+	stage.evaluate(cfg.MaxErrorRate, cfg.MaxP99)
+	return stage
+}
+
+// every calls fn perSecond times a second until ctx is done or fn returns
+// false.
+func every(ctx context.Context, perSecond float64, fn func(i int) bool) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / perSecond))
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !fn(i) {
+				return
+			}
+		}
+	}
+}
+
+// evaluate marks the stage saturated when auditd fell short of the target
+// rate, failed too many writes or answered too slowly.
+func (s *StageReport) evaluate(maxErrorRate float64, maxP99 time.Duration) {
+	ingest := s.Ops["ingest"]
+	if s.AchievedRate < 0.95*s.TargetRate {
+		s.Reasons = append(s.Reasons, fmt.Sprintf("achieved %.1f of %.1f events/s", s.AchievedRate, s.TargetRate))
+	}
+	if ingest.ErrorRate() > maxErrorRate {
+		s.Reasons = append(s.Reasons, fmt.Sprintf("ingest error rate %.1f%% over %.1f%%", 100*ingest.ErrorRate(), 100*maxErrorRate))
+	}
+	if maxP99 > 0 && ingest.P99Ms > ms(maxP99) {
+		s.Reasons = append(s.Reasons, fmt.Sprintf("ingest p99 %.0fms over %s", ingest.P99Ms, maxP99))
+	}
+	s.Saturated = len(s.Reasons) > 0
+}
+
+// verifyChain runs a full chain verification and times it.
+func verifyChain(ctx context.Context, cfg config) *ChainCheck {
+	store := audit.NewRemoteStore(cfg.AuditURL)
+	if cfg.APIKey != "" {
+		store = store.WithAPIKey(cfg.APIKey)
+	}
+	start := time.Now()
+	status, err := store.VerifyIntegrity(ctx)
+	check := &ChainCheck{DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Valid, check.TotalEvents, check.Error = status.Valid, status.TotalEvents, status.Error
+	return check
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxErrorSamples caps the distinct error messages kept per stage.
+const maxErrorSamples = 10
+
+// OpStats summarises the latency and errors of one operation in a stage.
+type OpStats struct {
+	Count  int     `json:"count"`
+	Errors int     `json:"errors"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// ErrorRate is the fraction of attempts that failed.
+func (s OpStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// recorder collects per-operation latencies and errors from concurrent
+// workers.
+type recorder struct {
+	mu           sync.Mutex
+	samples      map[string][]time.Duration
+	errors       map[string]int
+	errorSamples []string
+	inFlight     int
+	maxInFlight  int
+}
+
+func newRecorder() *recorder {
+	return &recorder{samples: map[string][]time.Duration{}, errors: map[string]int{}}
+}
+
+// begin marks a request as in flight and returns its start time.
+func (r *recorder) begin() time.Time {
+	r.mu.Lock()
+	r.inFlight++
+	r.maxInFlight = max(r.maxInFlight, r.inFlight)
+	r.mu.Unlock()
+	return time.Now()
+}
+
+// end records the outcome of a request started by begin.
+func (r *recorder) end(op string, start time.Time, err error) {
+	d := time.Since(start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+	r.samples[op] = append(r.samples[op], d)
+	if err == nil {
+		return
+	}
+	r.errors[op]++
+	msg := op + ": " + err.Error()
+	for _, s := range r.errorSamples {
+		if s == msg {
+			return
+		}
+	}
+	if len(r.errorSamples) < maxErrorSamples {
+		r.errorSamples = append(r.errorSamples, msg)
+	}
+}
+
+// summary returns the stats of every operation seen so far.
+func (r *recorder) summary() map[string]OpStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]OpStats, len(r.samples))
+	for op, samples := range r.samples {
+		out[op] = opStats(samples, r.errors[op])
+	}
+	return out
+}
+
+// opStats returns nearest-rank percentiles of samples, which it sorts.
+func opStats(samples []time.Duration, errors int) OpStats {
+	if len(samples) == 0 {
+		return OpStats{Errors: errors}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p * float64(len(samples))))
+		return ms(samples[max(i, 1)-1])
+	}
+	return OpStats{
+		Count:  len(samples),
+		Errors: errors,
+		P50Ms:  rank(0.50),
+		P90Ms:  rank(0.90),
+		P99Ms:  rank(0.99),
+		MaxMs:  ms(samples[len(samples)-1]),
+	}
+}
+
+// ms converts d to fractional milliseconds, rounded to microseconds.
+func ms(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())) / 1000
+}
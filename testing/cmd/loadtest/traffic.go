@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"helpdesk/internal/audit"
+)
+
+// syntheticAgent is an agent the generator delegates to, with the tools its
+// synthetic traces call.
+type syntheticAgent struct {
+	name     string
+	category audit.RequestCategory
+	tools    []string
+	target   string // tool parameter naming the resource
+}
+
+var syntheticAgents = []syntheticAgent{
+	{"postgres_database_agent", audit.CategoryDatabase,
+		[]string{"check_connection", "get_connection_stats", "get_lock_info", "get_table_stats", "get_replication_status"}, "database"},
+	{"k8s_agent", audit.CategoryKubernetes,
+		[]string{"get_pods", "get_events", "describe_pod", "get_pod_logs", "get_service"}, "namespace"},
+	{"sysadmin_agent", audit.CategorySysadmin,
+		[]string{"check_disk", "check_memory", "list_processes"}, "host"},
+}
+
+// trace is one synthetic request: a gateway_request anchor, the delegation
+// to an agent, the agent's tool calls and its outcome, in recording order.
+type trace struct {
+	id     string
+	events []*audit.Event
+}
+
+// traceGen builds synthetic traces with a realistic fan-out: each request
+// delegates to one agent, which calls between 1 and 2*fanout-1 tools (fanout
+// on average).
+type traceGen struct {
+	mu     sync.Mutex
+	rng    *rand.Rand
+	fanout int
+	tenant string
+}
+
+func newTraceGen(seed int64, fanout int, tenant string) *traceGen {
+	return &traceGen{rng: rand.New(rand.NewSource(seed)), fanout: max(fanout, 1), tenant: tenant}
+}
+
+// meanEvents is the average number of events in a trace.
+func (g *traceGen) meanEvents() float64 {
+	return float64(g.fanout) + 3
+}
+
+func (g *traceGen) next() trace {
+	g.mu.Lock()
+	agent := syntheticAgents[g.rng.Intn(len(syntheticAgents))]
+	calls := 1 + g.rng.Intn(2*g.fanout-1)
+	toolNames := make([]string, calls)
+	toolMs := make([]int, calls)
+	for i := range toolNames {
+		toolNames[i] = agent.tools[g.rng.Intn(len(agent.tools))]
+		toolMs[i] = 20 + g.rng.Intn(2000)
+	}
+	resource := fmt.Sprintf("loadtest-%d", g.rng.Intn(20))
+	confidence := 0.6 + 0.4*g.rng.Float64()
+	g.mu.Unlock()
+
+	traceID := "tr_lt_" + uuid.New().String()[:8]
+	session := audit.Session{ID: "sess_lt_" + uuid.New().String()[:8], UserID: "loadtest", TenantID: g.tenant}
+	now := time.Now().UTC()
+
+	anchor := &audit.Event{
+		EventID:   "req_" + uuid.New().String()[:8],
+		Timestamp: now,
+		EventType: audit.EventTypeGatewayRequest,
+		TraceID:   traceID,
+		Origin:    "gateway",
+		Session:   session,
+		Input:     audit.Input{UserQuery: fmt.Sprintf("why is %s slow?", resource)},
+		Tool:      &audit.ToolExecution{Agent: agent.name},
+	}
+	delegation := &audit.Event{
+		EventID:   "dec_" + uuid.New().String()[:8],
+		Timestamp: now,
+		EventType: audit.EventTypeDelegation,
+		TraceID:   traceID,
+		ParentID:  anchor.EventID,
+		Origin:    "orchestrator",
+		Session:   session,
+		Decision: &audit.Decision{
+			Agent:           agent.name,
+			RequestCategory: agent.category,
+			Confidence:      confidence,
+			UserIntent:      "diagnose " + resource,
+		},
+	}
+	events := []*audit.Event{anchor, delegation}
+
+	agentSession := session
+	agentSession.AgentName = agent.name
+	var total time.Duration
+	for i, name := range toolNames {
+		d := time.Duration(toolMs[i]) * time.Millisecond
+		total += d
+		events = append(events, &audit.Event{
+			EventID:   "tool_" + uuid.New().String()[:8],
+			Timestamp: now,
+			EventType: audit.EventTypeToolExecution,
+			TraceID:   traceID,
+			ParentID:  delegation.EventID,
+			Origin:    "agent",
+			Session:   agentSession,
+			Tool: &audit.ToolExecution{
+				Name:       name,
+				Agent:      agent.name,
+				Parameters: map[string]any{agent.target: resource},
+				Duration:   d,
+			},
+			Outcome: &audit.Outcome{Status: "success", Duration: d},
+		})
+	}
+	events = append(events, &audit.Event{
+		EventID:   "out_" + uuid.New().String()[:8],
+		Timestamp: now,
+		EventType: audit.EventTypeOutcome,
+		TraceID:   traceID,
+		ParentID:  anchor.EventID,
+		Origin:    "agent",
+		Session:   agentSession,
+		Outcome:   &audit.Outcome{Status: "success", Duration: total},
+	})
+	return trace{id: traceID, events: events}
+}
+
+// sendTrace records a trace's events in order, then posts the outcome of
+// the delegation the way the orchestrator does once the agent answers. It
+// returns the number of events auditd accepted.
+func sendTrace(ctx context.Context, store *audit.RemoteStore, rec *recorder, t trace) int {
+	accepted := 0
+	for _, e := range t.events {
+		start := rec.begin()
+		err := store.Record(ctx, e)
+		rec.end("ingest", start, err)
+		if err == nil {
+			accepted++
+		}
+	}
+	delegation := t.events[1]
+	start := rec.begin()
+	err := store.RecordOutcome(ctx, delegation.EventID, &audit.Outcome{Status: "success", Duration: time.Second})
+	rec.end("outcome", start, err)
+	return accepted
+}
+
+// approvalFlow creates an approval, reads it back and approves it, timing
+// each call. requester and approver must be different identities when
+// auditd enforces four-eyes approval.
+func approvalFlow(ctx context.Context, requester *audit.ApprovalClient, approve func(ctx context.Context, id string) error, rec *recorder, traceID string) {
+	start := rec.begin()
+	created, err := requester.CreateApproval(ctx, audit.ApprovalCreateRequest{
+		TraceID:      traceID,
+		ActionClass:  "write",
+		ToolName:     "terminate_connection",
+		AgentName:    "postgres_database_agent",
+		ResourceType: "database",
+		ResourceName: "loadtest",
+		RequestedBy:  "loadtest",
+		ExpiresInMin: 5,
+	})
+	rec.end("approval_create", start, err)
+	if err != nil {
+		return
+	}
+	start = rec.begin()
+	_, err = requester.GetApproval(ctx, created.ApprovalID)
+	rec.end("approval_get", start, err)
+
+	start = rec.begin()
+	err = approve(ctx, created.ApprovalID)
+	rec.end("approval_approve", start, err)
+}
+
+// approver returns a function that approves an approval as approverKey, or,
+// when auditd runs without authentication, as the "loadtest-approver" named
+// in the request body.
+func approver(client *http.Client, auditURL, approverKey string) func(ctx context.Context, id string) error {
+	return func(ctx context.Context, id string) error {
+		body := `{"approved_by":"loadtest-approver","reason":"load test"}`
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, auditURL+"/v1/approvals/"+id+"/approve", strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if approverKey != "" {
+			req.Header.Set("Authorization", "Bearer "+approverKey)
+		}
+		return doRequest(client, req)
+	}
+}
+
+// gatewayReads are the gateway calls the load test makes, round-robin: the
+// reads dashboards and operators issue while traffic flows. Trace-scoped
+// reads look up a trace the run recorded recently.
+var gatewayReads = []struct {
+	op   string
+	path func(traceID string) string
+}{
+	{"gateway_health", func(string) string { return "/health" }},
+	{"gateway_agents", func(string) string { return "/api/v1/agents" }},
+	{"gateway_events", func(id string) string { return "/api/v1/governance/events?trace_id=" + url.QueryEscape(id) }},
+	{"gateway_journeys", func(id string) string { return "/api/v1/governance/journeys?trace_id=" + url.QueryEscape(id) }},
+}
+
+// gatewayRead issues the i'th gateway read.
+func gatewayRead(ctx context.Context, client *http.Client, gatewayURL, apiKey string, rec *recorder, i int, traceID string) {
+	read := gatewayReads[i%len(gatewayReads)]
+	start := rec.begin()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gatewayURL+read.path(traceID), nil)
+	if err == nil {
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		err = doRequest(client, req)
+	}
+	rec.end(read.op, start, err)
+}
+
+// doRequest sends req and fails on any non-2xx status.
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(bytes.ToValidUTF8(body, nil))))
+	}
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	return nil
+}
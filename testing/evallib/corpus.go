@@ -0,0 +1,70 @@
+package evallib
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BuiltinYAML is the raw YAML of the built-in routing corpus, embedded at
+// build time so the runner works without the source tree.
+//
+//go:embed corpus.yaml
+var BuiltinYAML []byte
+
+// LoadCorpus reads and parses a corpus YAML file.
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading corpus: %v", err)
+	}
+	return LoadCorpusFromBytes(data)
+}
+
+// LoadBuiltinCorpus parses the embedded built-in corpus.
+func LoadBuiltinCorpus() (*Corpus, error) {
+	return LoadCorpusFromBytes(BuiltinYAML)
+}
+
+// LoadCorpusFromBytes parses corpus YAML and rejects cases without an ID,
+// query or expected agent, and duplicate IDs.
+func LoadCorpusFromBytes(data []byte) (*Corpus, error) {
+	var c Corpus
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing corpus: %v", err)
+	}
+	seen := make(map[string]bool, len(c.Cases))
+	for i, tc := range c.Cases {
+		switch {
+		case tc.ID == "":
+			return nil, fmt.Errorf("corpus case %d: missing id", i)
+		case tc.Query == "":
+			return nil, fmt.Errorf("corpus case %q: missing query", tc.ID)
+		case tc.ExpectedAgent == "":
+			return nil, fmt.Errorf("corpus case %q: missing expected_agent", tc.ID)
+		case seen[tc.ID]:
+			return nil, fmt.Errorf("corpus case %q: duplicate id", tc.ID)
+		}
+		seen[tc.ID] = true
+	}
+	return &c, nil
+}
+
+// FilterCases returns the cases matching ids (when non-empty) and carrying at
+// least one of tags (when non-empty), in corpus order.
+func FilterCases(c *Corpus, ids, tags []string) []Case {
+	var out []Case
+	for _, tc := range c.Cases {
+		if len(ids) > 0 && !slices.Contains(ids, tc.ID) {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(tc.Tags, func(t string) bool { return slices.Contains(tags, t) }) {
+			continue
+		}
+		out = append(out, tc)
+	}
+	return out
+}
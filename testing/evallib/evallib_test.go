@@ -0,0 +1,214 @@
+package evallib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/prompts"
+	"helpdesk/testing/faultlib"
+)
+
+func TestLoadBuiltinCorpus(t *testing.T) {
+	c, err := LoadBuiltinCorpus()
+	if err != nil {
+		t.Fatalf("LoadBuiltinCorpus: %v", err)
+	}
+	if c.Version == "" || len(c.Cases) == 0 {
+		t.Fatalf("corpus version=%q cases=%d, want both set", c.Version, len(c.Cases))
+	}
+	// Every expected agent must be one the orchestrator prompt routes to, or
+	// the case can never pass.
+	for _, tc := range c.Cases {
+		if !strings.Contains(prompts.Orchestrator, tc.ExpectedAgent) {
+			t.Errorf("case %s: expected_agent %q is not named in prompts/orchestrator.txt", tc.ID, tc.ExpectedAgent)
+		}
+	}
+}
+
+func TestLoadCorpusFromBytes_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"missing agent": "cases:\n  - id: a\n    query: q\n",
+		"missing query": "cases:\n  - id: a\n    expected_agent: k8s_agent\n",
+		"duplicate id": "cases:\n  - id: a\n    query: q\n    expected_agent: k8s_agent\n" +
+			"  - id: a\n    query: q\n    expected_agent: k8s_agent\n",
+	}
+	for name, y := range cases {
+		if _, err := LoadCorpusFromBytes([]byte(y)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestFilterCases(t *testing.T) {
+	c := &Corpus{Cases: []Case{
+		{ID: "a", Tags: []string{"database"}},
+		{ID: "b", Tags: []string{"kubernetes"}},
+		{ID: "c", Tags: []string{"database", "host"}},
+	}}
+	ids := func(cs []Case) []string {
+		var out []string
+		for _, tc := range cs {
+			out = append(out, tc.ID)
+		}
+		return out
+	}
+	if got := ids(FilterCases(c, nil, []string{"database"})); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("tag filter = %v", got)
+	}
+	if got := ids(FilterCases(c, []string{"b", "c"}, []string{"host"})); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("id+tag filter = %v", got)
+	}
+}
+
+func TestScore(t *testing.T) {
+	c := Case{ID: "db", ExpectedAgent: "postgres_database_agent", ExpectedTools: []string{"get_lock_info", "get_blocking_queries"}}
+
+	full := Score(c, Observation{Agents: []string{"postgres_database_agent"}, Tools: []string{"get_blocking_queries", "get_lock_info", "check_connection"}})
+	if !full.Passed || full.Score != 1 || full.ToolRecall != 1 {
+		t.Errorf("full match = %+v, want passed with score 1", full)
+	}
+
+	partial := Score(c, Observation{Agents: []string{"postgres_database_agent"}, Tools: []string{"get_lock_info"}})
+	if partial.Passed || partial.ToolRecall != 0.5 || partial.Score != 0.8 || !slices.Equal(partial.MissingTools, []string{"get_blocking_queries"}) {
+		t.Errorf("partial match = %+v, want recall 0.5, score 0.8", partial)
+	}
+
+	misrouted := Score(c, Observation{Agents: []string{"k8s_agent", "postgres_database_agent"}})
+	if misrouted.RoutingPass || misrouted.ActualAgent != "k8s_agent" || misrouted.Score != 0 {
+		t.Errorf("misrouted = %+v, want first delegation to count", misrouted)
+	}
+
+	routingOnly := Score(Case{ExpectedAgent: "research_agent"}, Observation{Agents: []string{"research_agent"}})
+	if !routingOnly.Passed || routingOnly.Score != 1 {
+		t.Errorf("routing-only = %+v", routingOnly)
+	}
+
+	errored := Score(Case{ExpectedAgent: "research_agent"}, Observation{Agents: []string{"research_agent"}, Error: "timeout"})
+	if errored.Passed {
+		t.Error("a case with an error must not pass")
+	}
+}
+
+func TestTextObservation(t *testing.T) {
+	text := "Delegating to `postgres_database_agent` to check locks...\n" +
+		"The agent ran get_lock_info and found pid 42.\n" +
+		"Delegating to k8s_agent to inspect the pod."
+	obs := TextObservation(text, []string{"get_lock_info", "get_pods"})
+	if !slices.Equal(obs.Agents, []string{"postgres_database_agent", "k8s_agent"}) {
+		t.Errorf("agents = %v", obs.Agents)
+	}
+	if !slices.Equal(obs.Tools, []string{"get_lock_info"}) {
+		t.Errorf("tools = %v", obs.Tools)
+	}
+	if obs.Evidence != "text" {
+		t.Errorf("evidence = %q", obs.Evidence)
+	}
+}
+
+func TestBuildReportAndCompare(t *testing.T) {
+	result := func(id, agent string, routed bool) CaseResult {
+		r := CaseResult{CaseID: id, ExpectedAgent: agent, RoutingPass: routed, Passed: routed, ToolRecall: 1}
+		if routed {
+			r.Score = 1
+		}
+		return r
+	}
+	baseline := BuildReport("base", "live", []CaseResult{
+		result("a", "postgres_database_agent", true),
+		result("b", "k8s_agent", true),
+		result("c", "k8s_agent", false),
+		result("d", "research_agent", true),
+	})
+	if baseline.Summary.RoutingAccuracy != 0.75 || baseline.Summary.Passed != 3 {
+		t.Fatalf("summary = %+v", baseline.Summary)
+	}
+	if st := baseline.Summary.Agents["k8s_agent"]; st.Total != 2 || st.Routed != 1 || st.Rate != 0.5 {
+		t.Errorf("k8s_agent stat = %+v", st)
+	}
+
+	worse := BuildReport("new", "live", []CaseResult{
+		result("a", "postgres_database_agent", false),
+		result("b", "k8s_agent", true),
+		result("c", "k8s_agent", true),
+		result("d", "research_agent", false),
+	})
+	cmp := Compare(baseline, worse, 0)
+	if !cmp.Failed {
+		t.Errorf("accuracy 0.75 → 0.5 should fail: %s", cmp)
+	}
+	if !slices.Equal(cmp.Regressed, []string{"a", "d"}) || !slices.Equal(cmp.Fixed, []string{"c"}) {
+		t.Errorf("regressed=%v fixed=%v", cmp.Regressed, cmp.Fixed)
+	}
+	if Compare(baseline, worse, 0.25).Failed {
+		t.Error("a drop within tolerance should not fail")
+	}
+	if Compare(baseline, baseline, 0).Failed {
+		t.Error("an unchanged run should not fail")
+	}
+}
+
+func TestFixturesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	want := map[string]Observation{
+		"db-locks": {Agents: []string{"postgres_database_agent"}, Tools: []string{"get_lock_info"}, Evidence: "audit"},
+	}
+	if err := SaveFixtures(path, want); err != nil {
+		t.Fatalf("SaveFixtures: %v", err)
+	}
+	got, err := LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadFixtures: %v", err)
+	}
+	r := NewFixtureRunner(got)
+	obs := r.Observe(context.Background(), Case{ID: "db-locks"})
+	if !slices.Equal(obs.Tools, []string{"get_lock_info"}) || obs.Error != "" {
+		t.Errorf("replayed observation = %+v", obs)
+	}
+	if obs := r.Observe(context.Background(), Case{ID: "unknown"}); obs.Error == "" {
+		t.Error("a case without a fixture should report an error")
+	}
+}
+
+func TestAuditObservation(t *testing.T) {
+	now := time.Now().UTC()
+	// Newest first, as auditd returns them.
+	events := []audit.Event{
+		{EventType: audit.EventTypeToolExecution, Timestamp: now.Add(3 * time.Second), Tool: &audit.ToolExecution{Name: "get_lock_info"}},
+		{EventType: audit.EventTypeDelegation, Timestamp: now.Add(2 * time.Second), Decision: &audit.Decision{Agent: "k8s_agent"}},
+		{EventType: audit.EventTypeToolExecution, Timestamp: now.Add(time.Second), Tool: &audit.ToolExecution{Name: "get_lock_info"}},
+		{EventType: audit.EventTypeDelegation, Timestamp: now, Decision: &audit.Decision{Agent: "postgres_database_agent"}},
+	}
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(events) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	r := NewRunner(&faultlib.HarnessConfig{AuditURL: srv.URL, GatewayAPIKey: "key"})
+	obs, err := r.auditObservation(context.Background(), now)
+	if err != nil {
+		t.Fatalf("auditObservation: %v", err)
+	}
+	if !slices.Equal(obs.Agents, []string{"postgres_database_agent", "k8s_agent"}) {
+		t.Errorf("agents = %v, want delegation order", obs.Agents)
+	}
+	if !slices.Equal(obs.Tools, []string{"get_lock_info"}) {
+		t.Errorf("tools = %v", obs.Tools)
+	}
+	if !strings.Contains(gotQuery, "types=delegation_decision%2Ctool_execution") {
+		t.Errorf("query = %s", gotQuery)
+	}
+}
@@ -0,0 +1,208 @@
+package evallib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Score evaluates one observation against its case.
+func Score(c Case, obs Observation) CaseResult {
+	res := CaseResult{
+		CaseID:        c.ID,
+		Query:         c.Query,
+		ExpectedAgent: c.ExpectedAgent,
+		ExpectedTools: c.ExpectedTools,
+		ObservedTools: obs.Tools,
+		Evidence:      obs.Evidence,
+		Duration:      obs.Duration,
+		Error:         obs.Error,
+		ToolRecall:    1,
+	}
+	if len(obs.Agents) > 0 {
+		res.ActualAgent = obs.Agents[0]
+	}
+	res.RoutingPass = res.ActualAgent == c.ExpectedAgent
+
+	for _, t := range c.ExpectedTools {
+		if !slices.Contains(obs.Tools, t) {
+			res.MissingTools = append(res.MissingTools, t)
+		}
+	}
+	if n := len(c.ExpectedTools); n > 0 {
+		res.ToolRecall = float64(n-len(res.MissingTools)) / float64(n)
+	}
+
+	routing := 0.0
+	if res.RoutingPass {
+		routing = 1
+	}
+	if len(c.ExpectedTools) > 0 {
+		res.Score = routing*0.6 + res.ToolRecall*0.4
+	} else {
+		res.Score = routing
+	}
+	res.Passed = res.Error == "" && res.RoutingPass && len(res.MissingTools) == 0
+	return res
+}
+
+// delegationRe matches the announcement the orchestrator prompt requires
+// before every delegation: "Delegating to <agent> to ...".
+var delegationRe = regexp.MustCompile(`(?i)delegating to\s+\x60?([a-z0-9_]+_agent)\x60?`)
+
+// TextObservation fills Agents and Tools from the response text when no
+// structured evidence is available: agents from the orchestrator's delegation
+// announcements, tools from tools of interest mentioned by name.
+func TextObservation(text string, tools []string) Observation {
+	obs := Observation{Text: text, Evidence: "text"}
+	for _, m := range delegationRe.FindAllStringSubmatch(text, -1) {
+		agent := strings.ToLower(m[1])
+		if !slices.Contains(obs.Agents, agent) {
+			obs.Agents = append(obs.Agents, agent)
+		}
+	}
+	lower := strings.ToLower(text)
+	for _, t := range tools {
+		if strings.Contains(lower, strings.ToLower(t)) {
+			obs.Tools = append(obs.Tools, t)
+		}
+	}
+	return obs
+}
+
+// BuildReport aggregates case results into a Report.
+func BuildReport(runID, mode string, results []CaseResult) Report {
+	report := Report{
+		ID:        runID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Mode:      mode,
+		Results:   results,
+	}
+
+	agents := make(map[string]*AgentStat)
+	var routed, toolCases int
+	var recallSum, scoreSum float64
+	for _, r := range results {
+		st := agents[r.ExpectedAgent]
+		if st == nil {
+			st = &AgentStat{}
+			agents[r.ExpectedAgent] = st
+		}
+		st.Total++
+		if r.RoutingPass {
+			st.Routed++
+			routed++
+		}
+		if len(r.ExpectedTools) > 0 {
+			toolCases++
+			recallSum += r.ToolRecall
+		}
+		scoreSum += r.Score
+		if r.Passed {
+			report.Summary.Passed++
+		} else {
+			report.Summary.Failed++
+		}
+	}
+
+	report.Summary.Total = len(results)
+	if report.Summary.Total > 0 {
+		report.Summary.RoutingAccuracy = float64(routed) / float64(report.Summary.Total)
+		report.Summary.Score = scoreSum / float64(report.Summary.Total)
+	}
+	if toolCases > 0 {
+		report.Summary.ToolRecall = recallSum / float64(toolCases)
+	}
+	report.Summary.Agents = make(map[string]AgentStat, len(agents))
+	for name, st := range agents {
+		st.Rate = float64(st.Routed) / float64(st.Total)
+		report.Summary.Agents[name] = *st
+	}
+	return report
+}
+
+// LoadReport reads a report written by an earlier run, e.g. as a baseline
+// for Compare.
+func LoadReport(path string) (Report, error) {
+	var rep Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rep, fmt.Errorf("reading report: %v", err)
+	}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return rep, fmt.Errorf("parsing report: %v", err)
+	}
+	return rep, nil
+}
+
+// WriteJSON writes the report to a JSON file.
+func (r Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling report: %v", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Comparison is the difference between a baseline report and a new one.
+type Comparison struct {
+	BaselineAccuracy float64 `json:"baseline_accuracy"`
+	Accuracy         float64 `json:"accuracy"`
+	// Regressed lists cases the baseline routed correctly and the new run
+	// did not; Fixed lists the reverse. Cases missing from either report are
+	// ignored.
+	Regressed []string `json:"regressed,omitempty"`
+	Fixed     []string `json:"fixed,omitempty"`
+	// Failed is true when routing accuracy fell by more than the tolerance.
+	Failed bool `json:"failed"`
+}
+
+// Compare checks current against baseline. tolerance is the routing accuracy
+// drop (0.0–1.0) still accepted; 0 rejects any drop.
+func Compare(baseline, current Report, tolerance float64) Comparison {
+	cmp := Comparison{
+		BaselineAccuracy: baseline.Summary.RoutingAccuracy,
+		Accuracy:         current.Summary.RoutingAccuracy,
+	}
+	// A small epsilon keeps float noise from failing an unchanged run.
+	cmp.Failed = cmp.BaselineAccuracy-cmp.Accuracy > tolerance+1e-9
+
+	before := make(map[string]bool, len(baseline.Results))
+	for _, r := range baseline.Results {
+		before[r.CaseID] = r.RoutingPass
+	}
+	for _, r := range current.Results {
+		was, ok := before[r.CaseID]
+		switch {
+		case !ok:
+		case was && !r.RoutingPass:
+			cmp.Regressed = append(cmp.Regressed, r.CaseID)
+		case !was && r.RoutingPass:
+			cmp.Fixed = append(cmp.Fixed, r.CaseID)
+		}
+	}
+	sort.Strings(cmp.Regressed)
+	sort.Strings(cmp.Fixed)
+	return cmp
+}
+
+// String renders the comparison as a one-line verdict.
+func (c Comparison) String() string {
+	verdict := "OK"
+	if c.Failed {
+		verdict = "REGRESSION"
+	}
+	s := fmt.Sprintf("%s: routing accuracy %.1f%% → %.1f%%", verdict, c.BaselineAccuracy*100, c.Accuracy*100)
+	if len(c.Regressed) > 0 {
+		s += "; regressed: " + strings.Join(c.Regressed, ", ")
+	}
+	if len(c.Fixed) > 0 {
+		s += "; fixed: " + strings.Join(c.Fixed, ", ")
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package evallib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"helpdesk/internal/audit"
+	"helpdesk/testing/faultlib"
+)
+
+// Runner executes corpus cases against the orchestrator, or replays recorded
+// fixtures when they are set.
+type Runner struct {
+	cfg      faultlib.HarnessConfig
+	inner    *faultlib.Runner
+	fixtures map[string]Observation
+}
+
+// NewRunner creates a Runner that sends queries to cfg.OrchestratorURL through
+// faultlib.Runner. Routing is the orchestrator's job, so ViaGateway is
+// ignored: the gateway query path names the agent explicitly.
+func NewRunner(cfg *faultlib.HarnessConfig) *Runner {
+	c := *cfg
+	c.ViaGateway = false
+	return &Runner{cfg: c, inner: faultlib.NewRunner(&c)}
+}
+
+// NewFixtureRunner creates a Runner that replays recorded observations
+// instead of calling a live stack.
+func NewFixtureRunner(fixtures map[string]Observation) *Runner {
+	return &Runner{fixtures: fixtures}
+}
+
+// Observe runs one case and reports which agents it was routed to and which
+// tools they called. With an audit URL configured the evidence comes from the
+// audit trail, otherwise from the response text.
+func (r *Runner) Observe(ctx context.Context, c Case) Observation {
+	if r.fixtures != nil {
+		obs, ok := r.fixtures[c.ID]
+		if !ok {
+			return Observation{Error: "no fixture recorded for case " + c.ID}
+		}
+		return obs
+	}
+	if r.cfg.OrchestratorURL == "" {
+		return Observation{Error: "no orchestrator URL configured"}
+	}
+
+	start := time.Now()
+	resp := r.inner.Run(ctx, faultlib.Failure{
+		ID:       c.ID,
+		Category: "compound",
+		Prompt:   c.Query,
+		Timeout:  c.Timeout,
+	})
+
+	var obs Observation
+	if r.cfg.AuditURL != "" {
+		var err error
+		obs, err = r.auditObservation(ctx, start)
+		if err != nil {
+			slog.Warn("eval: audit evidence unavailable, falling back to response text", "case", c.ID, "err", err)
+			obs = TextObservation(resp.Text, c.ExpectedTools)
+		}
+		obs.Text = resp.Text
+	} else {
+		obs = TextObservation(resp.Text, c.ExpectedTools)
+	}
+	obs.Duration = resp.Duration.Round(time.Millisecond).String()
+	if resp.Error != nil {
+		obs.Error = resp.Error.Error()
+	}
+	return obs
+}
+
+// auditObservation reads the delegation decisions and tool executions
+// recorded since start.
+func (r *Runner) auditObservation(ctx context.Context, start time.Time) (Observation, error) {
+	q := url.Values{}
+	q.Set("since", start.UTC().Format(time.RFC3339Nano))
+	q.Set("types", string(audit.EventTypeDelegation)+","+string(audit.EventTypeToolExecution))
+	q.Set("limit", "500")
+	reqURL := r.cfg.AuditURL + "/v1/events?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return Observation{}, err
+	}
+	if r.cfg.GatewayAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.GatewayAPIKey)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Observation{}, fmt.Errorf("GET %s: %w", reqURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Observation{}, fmt.Errorf("audit service returned %d: %s", resp.StatusCode, string(body))
+	}
+	var events []audit.Event
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return Observation{}, fmt.Errorf("decoding audit events: %w", err)
+	}
+
+	// Events come newest first; walk them oldest first so Agents keeps the
+	// order delegations were made in.
+	obs := Observation{Evidence: "audit"}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		switch {
+		case e.EventType == audit.EventTypeDelegation && e.Decision != nil && e.Decision.Agent != "":
+			if !slices.Contains(obs.Agents, e.Decision.Agent) {
+				obs.Agents = append(obs.Agents, e.Decision.Agent)
+			}
+		case e.EventType == audit.EventTypeToolExecution && e.Tool != nil && e.Tool.Name != "":
+			if !slices.Contains(obs.Tools, e.Tool.Name) {
+				obs.Tools = append(obs.Tools, e.Tool.Name)
+			}
+		}
+	}
+	return obs, nil
+}
+
+// LoadFixtures reads observations recorded by SaveFixtures, keyed by case ID.
+func LoadFixtures(path string) (map[string]Observation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixtures: %v", err)
+	}
+	var fixtures map[string]Observation
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("parsing fixtures: %v", err)
+	}
+	if fixtures == nil {
+		fixtures = map[string]Observation{}
+	}
+	return fixtures, nil
+}
+
+// SaveFixtures writes observations keyed by case ID so a later run can
+// replay them with NewFixtureRunner.
+func SaveFixtures(path string, fixtures map[string]Observation) error {
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling fixtures: %v", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
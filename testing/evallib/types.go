@@ -0,0 +1,107 @@
+// Package evallib scores orchestrator routing against a corpus of queries with
+// known answers, so prompt edits can be gated on not regressing routing
+// accuracy.
+package evallib
+
+import (
+	"time"
+)
+
+// Corpus is the top-level structure of corpus.yaml.
+type Corpus struct {
+	Version string `yaml:"version"`
+	Cases   []Case `yaml:"cases"`
+}
+
+// Case is a single query with the agent it should be routed to and the tools
+// that agent is expected to call.
+type Case struct {
+	ID    string   `yaml:"id"`
+	Query string   `yaml:"query"`
+	Tags  []string `yaml:"tags,omitempty"`
+	// ExpectedAgent is the agent the orchestrator should delegate to first,
+	// e.g. "postgres_database_agent".
+	ExpectedAgent string `yaml:"expected_agent"`
+	// ExpectedTools lists tools the delegated agent should call. Order does
+	// not matter and other tool calls are allowed. Empty = routing only.
+	ExpectedTools []string `yaml:"expected_tools,omitempty"`
+	Timeout       string   `yaml:"timeout,omitempty"`
+}
+
+// TimeoutDuration parses the timeout string into a time.Duration.
+func (c Case) TimeoutDuration() time.Duration {
+	d, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 120 * time.Second
+	}
+	return d
+}
+
+// Observation is what a case produced: the agents the orchestrator delegated
+// to and the tools they called. Observations recorded from a live run can be
+// saved as fixtures and replayed later.
+type Observation struct {
+	// Agents are the delegation targets in the order they were chosen.
+	Agents []string `json:"agents"`
+	Tools  []string `json:"tools"`
+	Text   string   `json:"text,omitempty"`
+	// Evidence is "audit" when agents and tools came from the audit trail and
+	// "text" when they were matched in the response text.
+	Evidence string `json:"evidence,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CaseResult is the score of one case.
+type CaseResult struct {
+	CaseID        string   `json:"case_id"`
+	Query         string   `json:"query"`
+	ExpectedAgent string   `json:"expected_agent"`
+	ActualAgent   string   `json:"actual_agent"`
+	RoutingPass   bool     `json:"routing_pass"`
+	ExpectedTools []string `json:"expected_tools,omitempty"`
+	ObservedTools []string `json:"observed_tools,omitempty"`
+	MissingTools  []string `json:"missing_tools,omitempty"`
+	// ToolRecall is the fraction of ExpectedTools that were called; 1 when
+	// the case expects none.
+	ToolRecall float64 `json:"tool_recall"`
+	// Score is 1.0 for correct routing with every expected tool called:
+	// routing counts 0.6 and tool recall 0.4 (routing alone when no tools
+	// are expected).
+	Score    float64 `json:"score"`
+	Passed   bool    `json:"passed"`
+	Evidence string  `json:"evidence,omitempty"`
+	Duration string  `json:"duration,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Report is the scored result of an evaluation run.
+type Report struct {
+	ID        string       `json:"id"`
+	Timestamp string       `json:"timestamp"`
+	Mode      string       `json:"mode"` // "live" or "fixtures"
+	Results   []CaseResult `json:"results"`
+	Summary   Summary      `json:"summary"`
+}
+
+// Summary contains aggregate statistics for a Report.
+type Summary struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	// RoutingAccuracy is the fraction of cases routed to the expected agent.
+	RoutingAccuracy float64 `json:"routing_accuracy"`
+	// ToolRecall is the mean ToolRecall over cases that expect tools.
+	ToolRecall float64 `json:"tool_recall"`
+	// Score is the mean case Score.
+	Score float64 `json:"score"`
+	// Agents holds routing accuracy per expected agent.
+	Agents map[string]AgentStat `json:"agents"`
+}
+
+// AgentStat holds routing counts for one expected agent.
+type AgentStat struct {
+	Total  int     `json:"total"`
+	Routed int     `json:"routed"`
+	Rate   float64 `json:"rate"`
+}
@@ -40,6 +40,9 @@ var ToolPatterns = map[string][]string{
 	// New K8s tools (Phase 1a).
 	"get_pod_resources": {"cpu request", "memory limit", "requests", "millicores"},
 	"get_node_status":   {"memorypressure", "diskpressure", "allocatable", "node condition"},
+	"get_pvc_status":    {"persistentvolumeclaim", "storage_class_not_found", "provisioner_missing", "bound"},
+	"get_storageclass":  {"provisioner", "volumebindingmode", "waitforfirstconsumer", "reclaim policy"},
+	"describe_pv":       {"persistentvolume", "volumeattachment", "volume handle", "attach error"},
 	// scale_deployment is used in k8s-scale-to-zero; patterns reference output text.
 	"scale_deployment": {"scaled", "replicas", "scale"},
 	// Sysadmin agent tools.